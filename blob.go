@@ -0,0 +1,72 @@
+package chai
+
+import (
+	"io"
+
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/row"
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// OpenBlob returns a reader streaming the value of a BLOB column of the row
+// identified by its primary key. For tables without a primary key, pk is the rowid.
+// Large values are read one chunk at a time instead of being loaded in memory.
+// The reader is only valid until the end of the transaction.
+func (tx *Tx) OpenBlob(table, column string, pk ...any) (io.Reader, error) {
+	t, key, err := tx.getRow(table, pk)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.OpenBlob(key, column)
+}
+
+// WriteBlob replaces the value of a BLOB column of the row identified by its
+// primary key with the content of r. For tables without a primary key, pk is the rowid.
+// Large values are written one chunk at a time instead of being loaded in memory.
+// The column must not be indexed.
+func (tx *Tx) WriteBlob(table, column string, r io.Reader, pk ...any) error {
+	t, key, err := tx.getRow(table, pk)
+	if err != nil {
+		return err
+	}
+
+	return t.WriteBlob(key, column, r)
+}
+
+// getRow returns the table and the key of the row with the given primary key.
+func (tx *Tx) getRow(table string, pk []any) (*database.Table, *tree.Key, error) {
+	t := tx.conn.Conn.GetTx()
+	if t == nil {
+		return nil, nil, errors.New("transaction has already been committed or rolled back")
+	}
+
+	tb, err := t.Catalog.GetTable(t, table)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tps := []types.Type{types.TypeBigint}
+	if tb.Info.PrimaryKey != nil {
+		tps = tb.Info.PrimaryKey.Types
+	}
+	if len(pk) != len(tps) {
+		return nil, nil, errors.Errorf("expected %d primary key values, got %d", len(tps), len(pk))
+	}
+
+	vs := make([]types.Value, len(pk))
+	for i, x := range pk {
+		v, err := row.NewValue(x)
+		if err != nil {
+			return nil, nil, err
+		}
+		vs[i], err = v.CastAs(tps[i])
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return tb, tree.NewKey(vs...), nil
+}
@@ -0,0 +1,187 @@
+package chai
+
+import (
+	"context"
+	"slices"
+
+	"github.com/chaisql/chai/internal/database"
+	"github.com/cockroachdb/errors"
+)
+
+// ChangeOp is the kind of modification made to a row.
+type ChangeOp = database.ChangeOp
+
+// Kinds of changes.
+const (
+	ChangeInsert = database.ChangeInsert
+	ChangeUpdate = database.ChangeUpdate
+	ChangeDelete = database.ChangeDelete
+)
+
+// A Change is a modification of a row made by a committed transaction.
+type Change struct {
+	// Sequence number of the transaction that made the change.
+	// Transactions committed later have greater sequence numbers.
+	Seq uint64
+	Op  ChangeOp
+	// Table of the row.
+	Table string
+	// Values of the primary key of the row.
+	Key []any
+	// Row before the change. Nil for inserts.
+	Old *Row
+	// Row after the change. Nil for deletes.
+	New *Row
+}
+
+// ChangeStream returns the changes committed to the database, in commit order.
+type ChangeStream struct {
+	stream *database.ChangeStream
+}
+
+// Changes returns a stream of the changes made by the transactions whose
+// sequence number is greater than or equal to from. Use LastChangeSeq()+1
+// to only receive the changes committed from now on.
+// The database must be opened with CaptureChanges enabled.
+// The stream must be closed before the database is closed.
+func (db *DB) Changes(ctx context.Context, from uint64) (*ChangeStream, error) {
+	s, err := db.DB.Changes(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ChangeStream{stream: s}, nil
+}
+
+// LastChangeSeq returns the sequence number of the last transaction
+// recorded by change capture.
+func (db *DB) LastChangeSeq() uint64 {
+	return db.DB.LastChangeSeq()
+}
+
+// PurgeChanges removes the changes made by the transactions
+// whose sequence number is lower than before.
+func (db *DB) PurgeChanges(before uint64) error {
+	return db.DB.PurgeChanges(before)
+}
+
+// WatchOptions configure DB.Watch.
+type WatchOptions struct {
+	// From is the sequence number of the first transaction whose changes
+	// are sent. By default, only the changes committed after the call
+	// to Watch are sent.
+	From uint64
+	// Ops restricts the changes sent to the given kinds. All the changes
+	// are sent if it is empty.
+	Ops []ChangeOp
+	// BufferSize is the capacity of the channel. Defaults to 64.
+	BufferSize int
+	// OnError, if set, is called with the error that stopped the watch,
+	// unless it is the error of the context.
+	OnError func(err error)
+}
+
+// Watch sends the changes made to the rows of the given table to the returned
+// channel, in commit order, until the context is canceled or the database is closed,
+// after which the channel is closed. If table is empty, the changes of every
+// table are sent. It is built on change capture: the database must be opened with
+// CaptureChanges enabled, and the changes must not be purged before being sent.
+// The changes are read as long as the channel is not full: a slow reader
+// delays the following changes but never blocks the writers.
+func (db *DB) Watch(ctx context.Context, table string, opts *WatchOptions) (<-chan *Change, error) {
+	if opts == nil {
+		opts = new(WatchOptions)
+	}
+
+	if table != "" {
+		_, err := db.DB.Catalog().GetTableInfo(table)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	from := opts.From
+	if from == 0 {
+		from = db.LastChangeSeq() + 1
+	}
+
+	s, err := db.Changes(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+
+	size := opts.BufferSize
+	if size <= 0 {
+		size = 64
+	}
+	ch := make(chan *Change, size)
+	ops := slices.Clone(opts.Ops)
+	onError := opts.OnError
+
+	go func() {
+		defer close(ch)
+		defer s.Close()
+
+		for {
+			c, err := s.Next()
+			if err != nil {
+				if onError != nil && ctx.Err() == nil && !errors.Is(err, context.Canceled) {
+					onError(err)
+				}
+				return
+			}
+
+			if table != "" && c.Table != table || len(ops) > 0 && !slices.Contains(ops, c.Op) {
+				continue
+			}
+
+			select {
+			case ch <- c:
+			case <-ctx.Done():
+				return
+			case <-db.DB.Done():
+				return
+			}
+		}
+	}()
+
+	return ch, nil
+}
+
+// Next returns the next change. It blocks until a new transaction is committed
+// if there is none left, and returns an error once the context is canceled
+// or the database is closed.
+func (s *ChangeStream) Next() (*Change, error) {
+	c, err := s.stream.Next()
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := c.Key.Decode()
+	if err != nil {
+		return nil, err
+	}
+
+	change := Change{
+		Seq:   c.Seq,
+		Op:    c.Op,
+		Table: c.Table,
+		Key:   make([]any, len(values)),
+	}
+	for i, v := range values {
+		change.Key[i] = v.V()
+	}
+	if c.Old != nil {
+		change.Old = &Row{Row: c.Old}
+	}
+	if c.New != nil {
+		change.New = &Row{Row: c.New}
+	}
+
+	return &change, nil
+}
+
+// Close the stream.
+func (s *ChangeStream) Close() error {
+	return s.stream.Close()
+}
@@ -6,6 +6,7 @@ import (
 	"os/signal"
 	"syscall"
 
+	"github.com/chaisql/chai"
 	"github.com/chaisql/chai/cmd/chai/dbutil"
 	"github.com/chaisql/chai/cmd/chai/shell"
 	"github.com/urfave/cli/v2"
@@ -17,6 +18,16 @@ func NewApp() *cli.App {
 	app.Name = "chai"
 	app.Usage = "Shell for the ChaiSQL database"
 	app.EnableBashCompletion = true
+	app.Flags = []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "read-only",
+			Usage: "Open an existing database in read-only mode, without locking it. Statements modifying the database are rejected.",
+		},
+		&cli.StringFlag{
+			Name:  "mode",
+			Usage: "Output mode of the results: table, json, ndjson, csv or markdown. Defaults to table in the shell. When reading queries from the standard input, the rows are written as JSON objects unless a mode is set.",
+		},
+	}
 
 	app.Commands = []*cli.Command{
 		NewVersionCommand(),
@@ -24,6 +35,7 @@ func NewApp() *cli.App {
 		NewRestoreCommand(),
 		NewBenchCommand(),
 		NewPebbleCommand(),
+		NewCheckCommand(),
 	}
 
 	// inject cancelable context to all commands (except the shell command)
@@ -48,19 +60,26 @@ func NewApp() *cli.App {
 	// Root command
 	app.Action = func(c *cli.Context) error {
 		dbpath := c.Args().First()
+		readOnly := c.Bool("read-only")
+		mode := c.String("mode")
 
 		if dbutil.CanReadFromStandardInput() {
-			db, err := dbutil.OpenDB(c.Context, dbpath)
+			db, err := dbutil.OpenDBWith(c.Context, dbpath, &chai.Options{ReadOnly: readOnly})
 			if err != nil {
 				return err
 			}
 			defer db.Close()
 
+			if mode != "" {
+				return shell.ExecSQL(c.Context, db, os.Stdin, os.Stdout, mode)
+			}
 			return dbutil.ExecSQL(c.Context, db, os.Stdin, os.Stdout)
 		}
 
 		return shell.Run(c.Context, &shell.Options{
-			DBPath: dbpath,
+			DBPath:   dbpath,
+			ReadOnly: readOnly,
+			Mode:     mode,
 		})
 	}
 
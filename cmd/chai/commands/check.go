@@ -0,0 +1,39 @@
+package commands
+
+import (
+	"os"
+
+	"github.com/chaisql/chai/cmd/chai/dbutil"
+	"github.com/cockroachdb/errors"
+	"github.com/urfave/cli/v2"
+)
+
+// NewCheckCommand returns a cli.Command for "chai check".
+func NewCheckCommand() *cli.Command {
+	cmd := cli.Command{
+		Name:      "check",
+		Usage:     "Verify the integrity of a database",
+		UsageText: `chai check dbPath`,
+		Description: `The check command verifies the checksums of the data files, ensures indexes
+are consistent with their tables and reports data that doesn't belong to any table or index.
+
+	$ chai check mydb`,
+	}
+
+	cmd.Action = func(c *cli.Context) error {
+		dbPath := c.Args().First()
+		if dbPath == "" {
+			return errors.New(cmd.UsageText)
+		}
+
+		db, err := dbutil.OpenDB(c.Context, dbPath)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		return dbutil.Check(c.Context, db, os.Stdout)
+	}
+
+	return &cmd
+}
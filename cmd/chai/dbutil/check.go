@@ -0,0 +1,32 @@
+package dbutil
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/chaisql/chai"
+	"github.com/cockroachdb/errors"
+)
+
+// Check verifies the integrity of the database and writes the problems found to w.
+// It returns an error if the database is not consistent.
+func Check(ctx context.Context, db *chai.DB, w io.Writer) error {
+	report, err := db.Check(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range report.Problems {
+		fmt.Fprintln(w, p)
+	}
+
+	fmt.Fprintf(w, "checked %d tables, %d indexes and %d rows: ", report.Tables, report.Indexes, report.Rows)
+	if report.OK() {
+		fmt.Fprintln(w, "ok")
+		return nil
+	}
+	fmt.Fprintf(w, "%d problems found\n", len(report.Problems))
+
+	return errors.New("the database is corrupted")
+}
@@ -0,0 +1,543 @@
+package dbutil
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/chaisql/chai"
+	"github.com/chaisql/chai/internal/stringutil"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// DefaultCSVSampleSize is the number of records read by ImportCSV
+// to infer the types of the columns of the table it creates.
+const DefaultCSVSampleSize = 100
+
+// CSVOptions configure ImportCSV.
+type CSVOptions struct {
+	// Field delimiter. Defaults to ','.
+	Delimiter rune
+	// Character quoting fields containing delimiters, quotes or newlines.
+	// Quotes are escaped by doubling them. Defaults to '"'.
+	Quote rune
+	// Unquoted fields equal to NullString are NULL. Defaults to "",
+	// which means that unquoted empty fields are NULL, whereas quoted ones are empty strings.
+	NullString string
+	// If true, the first line of the file is a record, not the names of the columns.
+	NoHeader bool
+	// Columns in which the fields of each record are stored, in order,
+	// instead of the ones named in the header. A field whose column is "" or "-" is skipped.
+	// If there is no header and no columns, the fields are stored in the columns of the table,
+	// or in columns named c1, c2, etc. if the table doesn't exist.
+	Columns []string
+	// Types of the columns of the table, by name, used if the table is created.
+	// The types of the other columns are inferred from the first records of the file.
+	Types map[string]string
+	// Number of records read to infer the types of the columns. Defaults to DefaultCSVSampleSize.
+	SampleSize int
+	// Number of rows inserted per transaction. Defaults to DefaultImportBatchSize.
+	BatchSize int
+	// If true, the records that cannot be read or inserted are skipped,
+	// instead of stopping the import, and written to Rejected, if not nil,
+	// as CSV records containing the line number, the error and the text of the record.
+	SkipErrors bool
+	Rejected   io.Writer
+}
+
+// ImportCSV reads a CSV file and inserts every record as a row of the given table.
+// The rows are inserted in batches, each within its own transaction: if an error occurs,
+// the rows of the previous batches are kept. It returns the number of rows inserted,
+// and the number of records rejected if opts.SkipErrors is set.
+// If the table doesn't exist, it is created with the columns of the file,
+// whose types are the ones of opts.Types, or inferred from the values of the first records:
+// BIGINT, DOUBLE, BOOLEAN or TIMESTAMP if all of them are, and TEXT otherwise.
+func ImportCSV(ctx context.Context, db *chai.DB, r io.Reader, table string, opts *CSVOptions) (imported, rejected int64, err error) {
+	var o CSVOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Delimiter == 0 {
+		o.Delimiter = ','
+	}
+	if o.Quote == 0 {
+		o.Quote = '"'
+	}
+	if o.SampleSize <= 0 {
+		o.SampleSize = DefaultCSVSampleSize
+	}
+	if o.BatchSize <= 0 {
+		o.BatchSize = DefaultImportBatchSize
+	}
+	if o.Delimiter == o.Quote || !validCSVRune(o.Delimiter) || !validCSVRune(o.Quote) {
+		return 0, 0, errors.New("invalid delimiter or quote")
+	}
+
+	conn, err := db.Connect()
+	if err != nil {
+		return 0, 0, err
+	}
+	defer conn.Close()
+
+	dec := csvDecoder{
+		r:    bufio.NewReader(r),
+		opts: &o,
+	}
+
+	var rej *csv.Writer
+	if o.SkipErrors && o.Rejected != nil {
+		rej = csv.NewWriter(o.Rejected)
+		defer rej.Flush()
+	}
+
+	// reject skips the record of the error if errors are skipped.
+	reject := func(err error) error {
+		var cerr *csvError
+		if !o.SkipErrors || !errors.As(err, &cerr) {
+			return err
+		}
+
+		rejected++
+		if rej == nil {
+			return nil
+		}
+		rej.Write([]string{strconv.Itoa(cerr.line), cerr.err.Error(), cerr.raw})
+		return rej.Error()
+	}
+
+	exists, err := tableExists(conn, table)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	err = dec.readHeader()
+	if errors.Is(err, io.EOF) {
+		if !exists {
+			return 0, 0, errors.Errorf("cannot create table %s: the file contains no records", table)
+		}
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if dec.columns == nil && exists {
+		info, err := conn.Conn.Catalog().GetTableInfo(table)
+		if err != nil {
+			return 0, 0, err
+		}
+		for _, cc := range info.ColumnConstraints.Ordered {
+			dec.columns = append(dec.columns, cc.Column)
+		}
+	}
+
+	// read a sample of the records to create the table
+	var sample []*record
+	if !exists {
+		for len(sample) < o.SampleSize {
+			rec, err := dec.next()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				if err = reject(err); err != nil {
+					return 0, rejected, err
+				}
+				continue
+			}
+
+			sample = append(sample, rec)
+		}
+
+		q, err := dec.createTableQuery(table, sample)
+		if err != nil {
+			return 0, rejected, err
+		}
+
+		err = conn.Exec(q)
+		if err != nil {
+			return 0, rejected, err
+		}
+	}
+
+	ins := inserter{
+		table: stringutil.NormalizeIdentifier(table, '`'),
+		stmts: make(map[string]*chai.Statement),
+	}
+	defer ins.rollback()
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return imported, rejected, err
+		}
+
+		var rec *record
+		if len(sample) > 0 {
+			rec, sample = sample[0], sample[1:]
+		} else {
+			rec, err = dec.next()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				if err = reject(err); err != nil {
+					return imported, rejected, err
+				}
+				continue
+			}
+		}
+
+		if ins.tx == nil {
+			err = ins.begin(conn)
+			if err != nil {
+				return imported, rejected, err
+			}
+		}
+
+		// a failed insertion doesn't abort the transaction,
+		// which allows skipping the record.
+		err = ins.insert(rec)
+		if err != nil {
+			if err = reject(&csvError{line: rec.line, raw: rec.raw, err: err}); err != nil {
+				return imported, rejected, err
+			}
+			continue
+		}
+
+		if ins.count == o.BatchSize {
+			err = ins.commit()
+			if err != nil {
+				return imported, rejected, err
+			}
+			imported += int64(o.BatchSize)
+		}
+	}
+
+	if ins.tx != nil {
+		count := ins.count
+		err = ins.commit()
+		if err != nil {
+			return imported, rejected, err
+		}
+		imported += int64(count)
+	}
+
+	return imported, rejected, nil
+}
+
+// validCSVRune reports whether r can be used as a delimiter or a quote.
+func validCSVRune(r rune) bool {
+	return r != '\r' && r != '\n' && r != utf8.RuneError && utf8.ValidRune(r)
+}
+
+// A csvError is an error about a record of a CSV file,
+// which can be rejected to continue the import.
+type csvError struct {
+	// line number of the record in the file.
+	line int
+	// text of the record.
+	raw string
+	err error
+}
+
+func (e *csvError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.line, e.err)
+}
+
+func (e *csvError) Unwrap() error {
+	return e.err
+}
+
+// csvDecoder decodes the records of a CSV file.
+type csvDecoder struct {
+	r    *bufio.Reader
+	opts *CSVOptions
+	// columns of the fields of the records, "" for skipped fields.
+	columns []string
+	// number of lines read.
+	line int
+	// text of the current record.
+	raw strings.Builder
+}
+
+// readHeader sets the columns of the fields from the options,
+// reading the header of the file unless there is none.
+// The columns are nil if they are neither in the file nor in the options.
+func (d *csvDecoder) readHeader() error {
+	if !d.opts.NoHeader {
+		line, fields, err := d.readRecord()
+		if err != nil {
+			return err
+		}
+
+		if d.opts.Columns == nil {
+			for i, f := range fields {
+				name, _ := f.(string)
+				if name == "" {
+					return errors.Errorf("line %d: column %d has no name", line, i+1)
+				}
+				d.columns = append(d.columns, name)
+			}
+		}
+	}
+
+	for _, c := range d.opts.Columns {
+		if c == "-" {
+			c = ""
+		}
+		d.columns = append(d.columns, c)
+	}
+
+	return nil
+}
+
+// next returns the next record, or io.EOF at the end of the file.
+// Errors about the contents of the record are of type *csvError.
+func (d *csvDecoder) next() (*record, error) {
+	line, fields, err := d.readRecord()
+	if err != nil {
+		return nil, err
+	}
+
+	// name the columns of files without a header after the position of the fields
+	if d.columns == nil {
+		for i := range fields {
+			d.columns = append(d.columns, "c"+strconv.Itoa(i+1))
+		}
+	}
+
+	rec := record{line: line, raw: d.raw.String()}
+	if len(fields) != len(d.columns) {
+		return nil, &csvError{line: line, raw: rec.raw, err: errors.Errorf("expected %d fields, got %d", len(d.columns), len(fields))}
+	}
+
+	for i, c := range d.columns {
+		if c == "" {
+			continue
+		}
+		rec.columns = append(rec.columns, c)
+		rec.values = append(rec.values, fields[i])
+	}
+
+	return &rec, nil
+}
+
+// readRecord reads the fields of the next record, and returns the number of its first line.
+// The fields are strings, or nil if they are NULL. Empty lines are skipped.
+func (d *csvDecoder) readRecord() (int, []any, error) {
+	for {
+		d.raw.Reset()
+		line := d.line + 1
+
+		fields, err := d.readFields()
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				err = &csvError{line: line, raw: d.raw.String(), err: err}
+			}
+			return line, nil, err
+		}
+
+		if len(fields) == 1 && d.raw.Len() == 0 {
+			continue
+		}
+
+		return line, fields, nil
+	}
+}
+
+// readFields reads the fields of a record, until the end of its last line.
+// The text of the record is stored in d.raw, without the line terminator.
+// If the record is malformed, the rest of its line is skipped.
+func (d *csvDecoder) readFields() ([]any, error) {
+	var fields []any
+	var field strings.Builder
+	// whether the current field is quoted
+	var quoted bool
+
+	endField := func() {
+		if !quoted && field.String() == d.opts.NullString {
+			fields = append(fields, nil)
+		} else {
+			fields = append(fields, field.String())
+		}
+		field.Reset()
+		quoted = false
+	}
+
+	for {
+		c, _, err := d.r.ReadRune()
+		if errors.Is(err, io.EOF) {
+			if fields == nil && d.raw.Len() == 0 {
+				return nil, io.EOF
+			}
+			d.line++
+			endField()
+			return fields, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case c == '\n' || (c == '\r' && d.peek('\n')):
+			if c == '\r' {
+				_, _, _ = d.r.ReadRune()
+			}
+			d.line++
+			endField()
+			return fields, nil
+		case c == d.opts.Delimiter:
+			d.raw.WriteRune(c)
+			endField()
+		case quoted:
+			d.raw.WriteRune(c)
+			return nil, d.skipLine(errors.Errorf("unexpected %q after quoted field", c))
+		case c == d.opts.Quote && field.Len() == 0:
+			d.raw.WriteRune(c)
+			quoted = true
+			err = d.readQuoted(&field)
+			if err != nil {
+				return nil, err
+			}
+		default:
+			d.raw.WriteRune(c)
+			field.WriteRune(c)
+		}
+	}
+}
+
+// readQuoted reads a quoted field, whose opening quote was read,
+// until its closing quote.
+func (d *csvDecoder) readQuoted(field *strings.Builder) error {
+	for {
+		c, _, err := d.r.ReadRune()
+		if errors.Is(err, io.EOF) {
+			d.line++
+			return errors.New("unterminated quoted field")
+		}
+		if err != nil {
+			return err
+		}
+		d.raw.WriteRune(c)
+
+		if c == '\n' {
+			d.line++
+		}
+
+		if c != d.opts.Quote {
+			field.WriteRune(c)
+			continue
+		}
+
+		// doubled quotes are escaped quotes
+		if !d.peek(d.opts.Quote) {
+			return nil
+		}
+		_, _, _ = d.r.ReadRune()
+		d.raw.WriteRune(c)
+		field.WriteRune(c)
+	}
+}
+
+// peek reports whether the next character is c.
+func (d *csvDecoder) peek(c rune) bool {
+	next, _, err := d.r.ReadRune()
+	if err != nil {
+		return false
+	}
+	_ = d.r.UnreadRune()
+	return next == c
+}
+
+// skipLine skips the rest of the current line, and returns err.
+func (d *csvDecoder) skipLine(err error) error {
+	rest, rerr := d.r.ReadString('\n')
+	if rerr != nil && !errors.Is(rerr, io.EOF) {
+		return rerr
+	}
+	d.line++
+	d.raw.WriteString(strings.TrimRight(rest, "\r\n"))
+	return err
+}
+
+// createTableQuery returns the statement creating a table with the columns of the file.
+// The types of the columns that are not set in the options are inferred from the sample.
+func (d *csvDecoder) createTableQuery(table string, sample []*record) (string, error) {
+	var columns []string
+	for _, c := range d.columns {
+		if c != "" {
+			columns = append(columns, c)
+		}
+	}
+	if len(columns) == 0 {
+		return "", errors.Errorf("cannot create table %s: the file contains no columns", table)
+	}
+
+	types := make(map[string]string, len(columns))
+	for c, t := range d.opts.Types {
+		if !slices.Contains(columns, c) {
+			return "", errors.Errorf("cannot set the type of column %s: no such column", c)
+		}
+		types[c] = t
+	}
+
+	for i, c := range columns {
+		if types[c] != "" {
+			continue
+		}
+
+		values := make([]string, 0, len(sample))
+		for _, rec := range sample {
+			if s, ok := rec.values[i].(string); ok {
+				values = append(values, s)
+			}
+		}
+		types[c] = inferCSVType(values)
+	}
+
+	return createTableStmt(table, columns, types), nil
+}
+
+// inferCSVType returns the first of the types BIGINT, DOUBLE, BOOLEAN and TIMESTAMP
+// that all the values can be converted to, or TEXT.
+func inferCSVType(values []string) string {
+	if len(values) == 0 {
+		return "TEXT"
+	}
+
+	all := func(fn func(s string) bool) bool {
+		for _, v := range values {
+			if !fn(v) {
+				return false
+			}
+		}
+		return true
+	}
+
+	switch {
+	case all(func(s string) bool { _, err := strconv.ParseInt(s, 10, 64); return err == nil }):
+		return "BIGINT"
+	case all(func(s string) bool { _, err := strconv.ParseFloat(s, 64); return err == nil }):
+		return "DOUBLE"
+	case all(func(s string) bool { return strings.EqualFold(s, "true") || strings.EqualFold(s, "false") }):
+		return "BOOLEAN"
+	case all(func(s string) bool {
+		// the timestamp parser accepts words like "now", which are not dates
+		if s == "" || s[0] < '0' || s[0] > '9' {
+			return false
+		}
+		_, err := types.ParseTimestamp(s)
+		return err == nil
+	}):
+		return "TIMESTAMP"
+	}
+
+	return "TEXT"
+}
@@ -0,0 +1,129 @@
+package dbutil
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/chaisql/chai"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportCSV(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("New table", func(t *testing.T) {
+		db, err := chai.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		data := "id,name,score,active,created,note\r\n" +
+			"1,foo,1,true,2020-01-01,\"a, \"\"quoted\"\"\nnote\"\r\n" +
+			"\r\n" +
+			"2,,2.5,FALSE,2020-01-02 10:00:00,\"\"\r\n" +
+			"3,bar,,true,,x\r\n"
+
+		n, rejected, err := ImportCSV(ctx, db, strings.NewReader(data), "test", &CSVOptions{BatchSize: 2})
+		require.NoError(t, err)
+		require.EqualValues(t, 3, n)
+		require.Zero(t, rejected)
+
+		var schema strings.Builder
+		require.NoError(t, DumpSchema(db, &schema, "test"))
+		require.Equal(t, "CREATE TABLE test (id BIGINT, name TEXT, score DOUBLE, active BOOLEAN, created TIMESTAMP, note TEXT);\n", schema.String())
+
+		r, err := db.QueryRow(`SELECT note FROM test WHERE id = 1`)
+		require.NoError(t, err)
+		var note string
+		require.NoError(t, r.Scan(&note))
+		require.Equal(t, "a, \"quoted\"\nnote", note)
+
+		// unquoted empty fields are NULL, quoted ones are empty strings
+		r, err = db.QueryRow(`SELECT name IS NULL, note = '', active FROM test WHERE id = 2`)
+		require.NoError(t, err)
+		var isNull, isEmpty, active bool
+		require.NoError(t, r.Scan(&isNull, &isEmpty, &active))
+		require.True(t, isNull)
+		require.True(t, isEmpty)
+		require.False(t, active)
+	})
+
+	t.Run("Dialect", func(t *testing.T) {
+		db, err := chai.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		data := "1;'a;b';x;NULL\n2;c;y;10\n"
+
+		n, _, err := ImportCSV(ctx, db, strings.NewReader(data), "test", &CSVOptions{
+			Delimiter:  ';',
+			Quote:      '\'',
+			NullString: "NULL",
+			NoHeader:   true,
+			Columns:    []string{"id", "name", "-", "size"},
+			Types:      map[string]string{"id": "INT PRIMARY KEY"},
+		})
+		require.NoError(t, err)
+		require.EqualValues(t, 2, n)
+
+		var schema strings.Builder
+		require.NoError(t, DumpSchema(db, &schema, "test"))
+		require.Equal(t, "CREATE TABLE test (id INTEGER NOT NULL, name TEXT, size BIGINT, CONSTRAINT test_pk PRIMARY KEY (id));\n", schema.String())
+
+		r, err := db.QueryRow(`SELECT name, size IS NULL FROM test WHERE id = 1`)
+		require.NoError(t, err)
+		var name string
+		var isNull bool
+		require.NoError(t, r.Scan(&name, &isNull))
+		require.Equal(t, "a;b", name)
+		require.True(t, isNull)
+
+		_, _, err = ImportCSV(ctx, db, strings.NewReader(data), "other", &CSVOptions{Types: map[string]string{"unknown": "INT"}, NoHeader: true})
+		require.ErrorContains(t, err, "unknown")
+	})
+
+	t.Run("Skip errors", func(t *testing.T) {
+		db, err := chai.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec(`CREATE TABLE test (id INT PRIMARY KEY, name TEXT, active BOOLEAN)`)
+		require.NoError(t, err)
+
+		data := `1,a,true
+2,b
+"3"x,c,true
+1,duplicate,false
+4,d,maybe
+5,e,false
+`
+
+		// without a header, the fields are stored in the columns of the table
+		_, _, err = ImportCSV(ctx, db, strings.NewReader(data), "test", &CSVOptions{NoHeader: true})
+		require.ErrorContains(t, err, "line 2")
+
+		var rejectedRecords strings.Builder
+		n, rejected, err := ImportCSV(ctx, db, strings.NewReader(data), "test", &CSVOptions{
+			NoHeader:   true,
+			SkipErrors: true,
+			Rejected:   &rejectedRecords,
+		})
+		require.NoError(t, err)
+		require.EqualValues(t, 2, n)
+		require.EqualValues(t, 4, rejected)
+
+		lines := strings.Split(strings.TrimSpace(rejectedRecords.String()), "\n")
+		require.Len(t, lines, 4)
+		require.Equal(t, "2,\"expected 3 fields, got 2\",\"2,b\"", lines[0])
+		require.True(t, strings.HasPrefix(lines[1], "3,"), lines[1])
+		require.True(t, strings.HasSuffix(lines[1], `"""3""x,c,true"`), lines[1])
+		require.True(t, strings.HasPrefix(lines[2], "4,"), lines[2])
+		require.True(t, strings.HasPrefix(lines[3], "5,"), lines[3])
+
+		r, err := db.QueryRow(`SELECT COUNT(*) FROM test`)
+		require.NoError(t, err)
+		var count int
+		require.NoError(t, r.Scan(&count))
+		require.Equal(t, 2, count)
+	})
+}
@@ -8,11 +8,16 @@ import (
 
 // OpenDB is a helper function that takes raw unvalidated parameters and opens a database.
 func OpenDB(ctx context.Context, dbPath string) (*chai.DB, error) {
+	return OpenDBWith(ctx, dbPath, nil)
+}
+
+// OpenDBWith opens a database like OpenDB, configured with opts.
+func OpenDBWith(ctx context.Context, dbPath string, opts *chai.Options) (*chai.DB, error) {
 	if dbPath == "" {
 		dbPath = ":memory:"
 	}
 
-	db, err := chai.Open(dbPath)
+	db, err := chai.OpenWith(dbPath, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -3,14 +3,22 @@ package dbutil
 import (
 	"fmt"
 	"io"
+	"math"
+	"slices"
 	"strings"
 
 	"github.com/chaisql/chai"
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/stringutil"
+	"github.com/chaisql/chai/internal/types"
 	"go.uber.org/multierr"
 )
 
 // Dump takes a database and dumps its content as SQL queries in the given writer.
 // If tables is provided, only selected tables will be outputted.
+// Otherwise, the sequences of the database are outputted first.
+// The schema is reconstructed from the catalog, as with DumpSchema.
+// The output can be imported in another database with the shell or with ExecSQL.
 func Dump(db *chai.DB, w io.Writer, tables ...string) error {
 	conn, err := db.Connect()
 	if err != nil {
@@ -28,8 +36,34 @@ func Dump(db *chai.DB, w io.Writer, tables ...string) error {
 		return err
 	}
 
+	err = dumpTables(tx, conn.Conn.Catalog(), w, tables)
+	if err != nil {
+		_, er := fmt.Fprintln(w, "ROLLBACK;")
+		return multierr.Append(err, er)
+	}
+
+	_, err = fmt.Fprintln(w, "COMMIT;")
+	return err
+}
+
+// dumpTables dumps the given tables, or all the tables after
+// the sequences if none is provided, separating them with blank lines.
+// The tables that don't exist are ignored.
+func dumpTables(tx *chai.Tx, catalog *database.Catalog, w io.Writer, tables []string) error {
 	i := 0
-	err = QueryTables(tx, tables, func(name, query string) error {
+	if len(tables) == 0 {
+		n, err := writeSequences(w, catalog, tx)
+		if err != nil {
+			return err
+		}
+		i += n
+	}
+
+	for _, name := range userTables(catalog) {
+		if len(tables) > 0 && !slices.Contains(tables, name) {
+			continue
+		}
+
 		// Blank separation between tables.
 		if i > 0 {
 			if _, err := fmt.Fprintln(w, ""); err != nil {
@@ -38,61 +72,48 @@ func Dump(db *chai.DB, w io.Writer, tables ...string) error {
 		}
 		i++
 
-		return dumpTable(tx, w, query, name)
-	})
-	if err != nil {
-		_, er := fmt.Fprintln(w, "ROLLBACK;")
-		return multierr.Append(err, er)
+		if err := dumpTable(tx, catalog, w, name); err != nil {
+			return err
+		}
 	}
 
-	_, err = fmt.Fprintln(w, "COMMIT;")
-	return err
+	return nil
 }
 
 // dumpTable displays the content of the given table as SQL statements.
-func dumpTable(tx *chai.Tx, w io.Writer, query, tableName string) error {
+func dumpTable(tx *chai.Tx, catalog *database.Catalog, w io.Writer, tableName string) error {
 	// Dump schema first.
-	if err := dumpSchema(tx, w, query, tableName); err != nil {
+	if err := writeTableSchema(w, catalog, tableName); err != nil {
 		return err
 	}
 
-	q := fmt.Sprintf("SELECT * FROM %s", tableName)
-	res, err := tx.Query(q)
+	table := stringutil.NormalizeIdentifier(tableName, '`')
+	res, err := tx.Query("SELECT * FROM " + table)
 	if err != nil {
 		return err
 	}
 	defer res.Close()
 
 	// Inserts statements.
+	var sb strings.Builder
 	return res.Iterate(func(r *chai.Row) error {
-		cols, err := r.Columns()
-		if err != nil {
-			return err
-		}
-
-		m := make(map[string]interface{}, len(cols))
-		err = r.MapScan(m)
-		if err != nil {
-			return err
-		}
-
-		var sb strings.Builder
+		sb.Reset()
 
-		for i, c := range cols {
+		i := 0
+		err := r.Row.Iterate(func(_ string, v types.Value) error {
 			if i > 0 {
 				sb.WriteString(", ")
 			}
+			i++
 
-			v := m[c]
-			if v == nil {
-				sb.WriteString("NULL")
-				continue
-			}
-
-			fmt.Fprintf(&sb, "%v", v)
+			writeValue(&sb, v)
+			return nil
+		})
+		if err != nil {
+			return err
 		}
 
-		if _, err := fmt.Fprintf(w, "INSERT INTO %s VALUES (%s);\n", tableName, sb.String()); err != nil {
+		if _, err := fmt.Fprintf(w, "INSERT INTO %s VALUES (%s);\n", table, sb.String()); err != nil {
 			return err
 		}
 
@@ -100,8 +121,64 @@ func dumpTable(tx *chai.Tx, w io.Writer, query, tableName string) error {
 	})
 }
 
+// writeValue writes v as an SQL literal.
+func writeValue(sb *strings.Builder, v types.Value) {
+	switch t := v.(type) {
+	case types.TextValue:
+		writeString(sb, string(t))
+	case types.CitextValue:
+		writeString(sb, string(t))
+	case types.JSONValue:
+		writeString(sb, types.FormatJSON(t))
+	case types.DoubleValue:
+		writeFloat(sb, v, float64(t), "DOUBLE")
+	case types.RealValue:
+		writeFloat(sb, v, float64(t), "REAL")
+	default:
+		sb.WriteString(v.String())
+	}
+}
+
+// writeFloat writes the floating-point value v, whose value is x, as a literal
+// of the given type. NaN and the infinities have no literal and are written as a cast.
+func writeFloat(sb *strings.Builder, v types.Value, x float64, typ string) {
+	switch {
+	case math.IsNaN(x):
+		fmt.Fprintf(sb, "CAST('NaN' AS %s)", typ)
+	case math.IsInf(x, 1):
+		fmt.Fprintf(sb, "CAST('+Inf' AS %s)", typ)
+	case math.IsInf(x, -1):
+		fmt.Fprintf(sb, "CAST('-Inf' AS %s)", typ)
+	default:
+		sb.WriteString(v.String())
+	}
+}
+
+// writeString writes s as a quoted string literal, escaping the characters
+// that cannot appear as is in a string literal.
+func writeString(sb *strings.Builder, s string) {
+	sb.WriteByte('\'')
+	for _, r := range s {
+		switch r {
+		case '\'', '\\':
+			sb.WriteByte('\\')
+			sb.WriteRune(r)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+			sb.WriteString(`\r`)
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	sb.WriteByte('\'')
+}
+
 // DumpSchema takes a database and dumps its schema as SQL queries in the given writer.
-// If tables are provided, only selected tables will be outputted.
+// The statements are reconstructed from the catalog and include the constraints
+// and the indexes of the tables. If tables are provided, only selected tables
+// will be outputted and they must exist. Otherwise, the sequences of the database
+// are outputted first.
 func DumpSchema(db *chai.DB, w io.Writer, tables ...string) error {
 	conn, err := db.Connect()
 	if err != nil {
@@ -109,14 +186,19 @@ func DumpSchema(db *chai.DB, w io.Writer, tables ...string) error {
 	}
 	defer conn.Close()
 
-	tx, err := conn.Begin(false)
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
+	catalog := conn.Conn.Catalog()
 
 	i := 0
-	return QueryTables(tx, tables, func(name, query string) error {
+	if len(tables) == 0 {
+		i, err = writeSequences(w, catalog, nil)
+		if err != nil {
+			return err
+		}
+
+		tables = userTables(catalog)
+	}
+
+	for _, name := range tables {
 		// Blank separation between tables.
 		if i > 0 {
 			if _, err := fmt.Fprintln(w, ""); err != nil {
@@ -125,37 +207,109 @@ func DumpSchema(db *chai.DB, w io.Writer, tables ...string) error {
 		}
 		i++
 
-		return dumpSchema(tx, w, query, name)
-	})
+		if err := writeTableSchema(w, catalog, name); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
-// dumpSchema displays the schema of the given table as SQL statements.
-func dumpSchema(tx *chai.Tx, w io.Writer, query string, tableName string) error {
-	_, err := fmt.Fprintf(w, "%s;\n", query)
-	if err != nil {
-		return err
+// userTables returns the names of the tables created by users, sorted lexicographically.
+func userTables(catalog *database.Catalog) []string {
+	var tables []string
+	for _, name := range catalog.ListTables() {
+		if !strings.HasPrefix(name, database.InternalPrefix) {
+			tables = append(tables, name)
+		}
+	}
+
+	return tables
+}
+
+// writeSequences writes the statements creating the sequences created by users
+// and returns how many were written. If tx is not nil, the sequences start
+// after the last value they returned.
+func writeSequences(w io.Writer, catalog *database.Catalog, tx *chai.Tx) (int, error) {
+	n := 0
+	for _, name := range catalog.ListSequences() {
+		seq, err := catalog.GetSequence(name)
+		if err != nil {
+			return n, err
+		}
+		// skip the sequences of the tables and of the database
+		if seq.Info.Owner.TableName != "" || strings.HasPrefix(name, database.InternalPrefix) {
+			continue
+		}
+
+		info := seq.Info
+		if tx != nil {
+			info, err = continuedSequence(tx, seq.Info)
+			if err != nil {
+				return n, err
+			}
+		}
+
+		if _, err := fmt.Fprintf(w, "%s;\n", info.String()); err != nil {
+			return n, err
+		}
+		n++
 	}
 
-	// Indexes statements.
-	res, err := tx.Query(`
-		SELECT sql FROM __chai_catalog WHERE 
-			type = 'index' AND owner_table_name = ? OR
-			type = 'sequence' AND owner_table_name IS NULL
-	`, tableName)
+	return n, nil
+}
+
+// writeTableSchema writes the statements creating the given table and its indexes.
+// The indexes created by the constraints of the table are skipped.
+func writeTableSchema(w io.Writer, catalog *database.Catalog, tableName string) error {
+	info, err := catalog.GetTableInfo(tableName)
 	if err != nil {
 		return err
 	}
-	defer res.Close()
 
-	return res.Iterate(func(r *chai.Row) error {
-		var q string
+	if _, err := fmt.Fprintf(w, "%s;\n", info.String()); err != nil {
+		return err
+	}
 
-		err = r.Scan(&q)
+	for _, name := range catalog.ListIndexes(tableName) {
+		idx, err := catalog.GetIndexInfo(name)
 		if err != nil {
 			return err
 		}
+		if len(idx.Owner.Columns) > 0 {
+			continue
+		}
 
-		_, err = fmt.Fprintf(w, "%s;\n", q)
-		return err
-	})
+		if _, err := fmt.Fprintf(w, "%s;\n", idx.String()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// continuedSequence returns the sequence modified to start
+// after the last value it returned, if any.
+func continuedSequence(tx *chai.Tx, info *database.SequenceInfo) (*database.SequenceInfo, error) {
+	r, err := tx.QueryRow(`SELECT seq FROM __chai_sequence WHERE name = ?`, info.Name)
+	if chai.IsNotFoundError(err) {
+		return info, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var cur *int64
+	if err := r.Scan(&cur); err != nil || cur == nil {
+		return info, err
+	}
+
+	next := *cur + info.IncrementBy
+	if next < info.Min || next > info.Max {
+		return info, nil
+	}
+
+	info = info.Clone()
+	info.Start = next
+	return info, nil
 }
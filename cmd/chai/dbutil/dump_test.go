@@ -2,8 +2,12 @@ package dbutil
 
 import (
 	"bytes"
+	"context"
 	"fmt"
+	"io"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/chaisql/chai"
 	"github.com/stretchr/testify/require"
@@ -92,3 +96,131 @@ func TestDump(t *testing.T) {
 		})
 	}
 }
+
+func TestDumpRestore(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE SEQUENCE seq;
+		CREATE TABLE ` + "`my table`" + ` (
+			id INT PRIMARY KEY, name TEXT UNIQUE, data BLOB, ts TIMESTAMP, d DOUBLE, j JSON, u UUID
+		);
+		CREATE INDEX my_table_ts ON ` + "`my table`" + ` (ts);
+		CREATE TABLE other (a TEXT);
+	`)
+	require.NoError(t, err)
+
+	err = db.Exec(`INSERT INTO other (a) VALUES (NULL), (?)`, "multi\nline\r\t")
+	require.NoError(t, err)
+
+	err = db.Exec(`INSERT INTO `+"`my table`"+` VALUES (nextval('seq'), ?, ?, ?, ?, ?, ?)`,
+		`it's a "quote" \ with a backslash`, []byte{0, 1, 255}, time.Date(2024, 1, 2, 3, 4, 5, 6000, time.UTC), 1.5, `{"a": [1, "b"]}`, "6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	require.NoError(t, err)
+	err = db.Exec(`INSERT INTO ` + "`my table`" + ` (id, name) VALUES (nextval('seq'), 'b')`)
+	require.NoError(t, err)
+
+	var dump bytes.Buffer
+	err = Dump(db, &dump)
+	require.NoError(t, err)
+
+	restored, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer restored.Close()
+
+	err = ExecSQL(context.Background(), restored, bytes.NewReader(dump.Bytes()), io.Discard)
+	require.NoError(t, err)
+
+	var got bytes.Buffer
+	err = Dump(restored, &got)
+	require.NoError(t, err)
+	require.Equal(t, dump.String(), got.String())
+
+	// the sequence continues after its last value
+	r, err := restored.QueryRow(`SELECT nextval('seq')`)
+	require.NoError(t, err)
+	var next int
+	require.NoError(t, r.Scan(&next))
+	require.Equal(t, 3, next)
+}
+
+func TestDumpRestoreNonFinite(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE test (id INT PRIMARY KEY, d DOUBLE, r REAL);
+		INSERT INTO test VALUES
+			(1, CAST('NaN' AS DOUBLE), CAST('NaN' AS REAL)),
+			(2, CAST('+Inf' AS DOUBLE), CAST('+Inf' AS REAL)),
+			(3, CAST('-Inf' AS DOUBLE), CAST('-Inf' AS REAL)),
+			(4, 1.5, 1.1);
+	`)
+	require.NoError(t, err)
+
+	var dump bytes.Buffer
+	err = Dump(db, &dump)
+	require.NoError(t, err)
+
+	restored, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer restored.Close()
+
+	err = ExecSQL(context.Background(), restored, bytes.NewReader(dump.Bytes()), io.Discard)
+	require.NoError(t, err)
+
+	res, err := restored.Query(`SELECT id, CAST(d AS TEXT), CAST(r AS TEXT) FROM test ORDER BY id`)
+	require.NoError(t, err)
+	defer res.Close()
+
+	var got []string
+	for res.Next() {
+		var id int
+		var d, r string
+		require.NoError(t, res.Scan(&id, &d, &r))
+		got = append(got, fmt.Sprintf("%d %s %s", id, d, r))
+	}
+	require.NoError(t, res.Err())
+	require.Equal(t, []string{"1 NaN NaN", "2 +Inf +Inf", "3 -Inf -Inf", "4 1.5 1.1"}, got)
+
+	// the schema of the dump is the one of DumpSchema
+	var schema bytes.Buffer
+	err = DumpSchema(db, &schema)
+	require.NoError(t, err)
+	require.Contains(t, dump.String(), schema.String())
+}
+
+func TestDumpSchema(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE SEQUENCE seq INCREMENT BY 2;
+		CREATE TABLE foo (a INTEGER PRIMARY KEY, b TEXT NOT NULL UNIQUE, c DOUBLE DEFAULT 1.5 CHECK (c > 0));
+		CREATE INDEX foo_c ON foo (c);
+		CREATE TABLE bar (a INTEGER);
+	`)
+	require.NoError(t, err)
+
+	var got bytes.Buffer
+	err = DumpSchema(db, &got)
+	require.NoError(t, err)
+	require.Equal(t, `CREATE SEQUENCE seq INCREMENT BY 2;
+
+CREATE TABLE bar (a INTEGER);
+
+CREATE TABLE foo (a INTEGER NOT NULL, b TEXT NOT NULL, c DOUBLE DEFAULT 1.5, CONSTRAINT foo_pk PRIMARY KEY (a), CONSTRAINT foo_b_unique UNIQUE (b), CONSTRAINT foo_check CHECK (c > 0));
+CREATE INDEX foo_c ON foo (c);
+`, got.String())
+
+	got.Reset()
+	err = DumpSchema(db, &got, "foo")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(got.String(), "CREATE TABLE foo "))
+
+	err = DumpSchema(db, &got, "unknown")
+	require.Error(t, err)
+}
@@ -4,11 +4,14 @@ import (
 	"context"
 	"encoding/json"
 	"io"
+	"time"
 
 	"github.com/chaisql/chai"
 	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/environment"
 	"github.com/chaisql/chai/internal/query"
 	"github.com/chaisql/chai/internal/query/statement"
+	"github.com/chaisql/chai/internal/row"
 	"github.com/chaisql/chai/internal/sql/parser"
 )
 
@@ -19,6 +22,42 @@ func ExecSQL(ctx context.Context, db *chai.DB, r io.Reader, w io.Writer) error {
 	enc.SetEscapeHTML(false)
 	enc.SetIndent("", "  ")
 
+	return ExecSQLFunc(ctx, db, r, func(_ []string, iterate func(fn func(r row.Row) error) error) error {
+		return iterate(func(r row.Row) error {
+			return enc.Encode(r)
+		})
+	})
+}
+
+// ExecSQLFunc reads SQL queries from reader and executes them until the reader is exhausted.
+// For every query returning rows, fn is called with the columns of the query, which
+// may be nil if they are not known in advance, and a function iterating over its rows.
+func ExecSQLFunc(ctx context.Context, db *chai.DB, r io.Reader, fn func(columns []string, iterate func(fn func(r row.Row) error) error) error) error {
+	return ExecSQLStats(ctx, db, r, fn, nil)
+}
+
+// StatementStats describes the execution of a statement.
+type StatementStats struct {
+	// Time spent preparing and running the statement, including
+	// the time spent by the caller processing its rows.
+	Duration time.Duration
+	// ReturnsRows is true if the statement returns rows, as SELECT does.
+	ReturnsRows bool
+	// Number of rows returned by the statement.
+	RowsReturned int64
+	// ModifiesRows is true if the statement inserts, updates or deletes rows.
+	ModifiesRows bool
+	// Number of rows inserted, updated or deleted by the statement,
+	// or -1 if it is unknown, which is the case of some deletions.
+	RowsAffected int64
+	// Summary of the execution plan of the statement, as displayed by EXPLAIN,
+	// or "" if the statement has none.
+	Plan string
+}
+
+// ExecSQLStats is like ExecSQLFunc, and calls stats, if not nil, after the successful
+// execution of every statement, with the statistics of its execution.
+func ExecSQLStats(ctx context.Context, db *chai.DB, r io.Reader, fn func(columns []string, iterate func(fn func(r row.Row) error) error) error, stats func(*StatementStats)) error {
 	conn, err := db.Connect()
 	if err != nil {
 		return err
@@ -26,6 +65,8 @@ func ExecSQL(ctx context.Context, db *chai.DB, r io.Reader, w io.Writer) error {
 	defer conn.Close()
 
 	return parser.NewParser(r).Parse(func(s statement.Statement) error {
+		start := time.Now()
+
 		qq := query.New(s)
 		qctx := query.Context{
 			Ctx:  ctx,
@@ -42,20 +83,83 @@ func ExecSQL(ctx context.Context, db *chai.DB, r io.Reader, w io.Writer) error {
 			return err
 		}
 
-		err = res.Iterate(func(r database.Row) error {
+		var st StatementStats
+		err = outputResult(ctx, res, fn, &st)
+		if err != nil {
+			res.Close()
+			return err
+		}
+
+		err = res.Close()
+		if err != nil {
+			return err
+		}
+
+		if stats != nil {
+			st.Duration = time.Since(start)
+			stats(&st)
+		}
+
+		return nil
+	})
+}
+
+// outputResult passes the rows of the result to fn, if it returns rows,
+// and records the statistics of the statement in st.
+func outputResult(ctx context.Context, res *statement.Result, fn func(columns []string, iterate func(fn func(r row.Row) error) error) error, st *StatementStats) error {
+	if res.Iterator == nil {
+		return nil
+	}
+
+	var columns []string
+	if it, ok := res.Iterator.(*statement.StreamStmtIterator); ok {
+		st.Plan = it.Stream.String()
+
+		if !it.ReturnsRows() {
+			// run the statement
+			err := res.Iterate(func(database.Row) error { return nil })
+			if err != nil {
+				return err
+			}
+
+			st.ModifiesRows = it.CountRows
+			st.RowsAffected = it.RowsAffected()
+			return nil
+		}
+
+		var env environment.Environment
+		env.DB = it.Context.DB
+		env.Tx = it.Context.Tx
+
+		var err error
+		columns, err = it.Stream.Columns(&env)
+		if err != nil {
+			return err
+		}
+	}
+
+	st.ReturnsRows = true
+	err := fn(columns, func(fn func(r row.Row) error) error {
+		return res.Iterate(func(r database.Row) error {
 			select {
 			case <-ctx.Done():
 				return ctx.Err()
 			default:
 			}
 
-			return enc.Encode(r)
+			st.RowsReturned++
+			return fn(r)
 		})
-		if err != nil {
-			res.Close()
-			return err
-		}
-
-		return res.Close()
 	})
+	if err != nil {
+		return err
+	}
+
+	// statements with a RETURNING clause modify the rows they return
+	if it, ok := res.Iterator.(*statement.StreamStmtIterator); ok && it.CountRows {
+		st.ModifiesRows = true
+		st.RowsAffected = it.RowsAffected()
+	}
+
+	return nil
 }
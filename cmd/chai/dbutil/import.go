@@ -0,0 +1,442 @@
+package dbutil
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/chaisql/chai"
+	"github.com/chaisql/chai/internal/stringutil"
+	"github.com/cockroachdb/errors"
+)
+
+// DefaultImportBatchSize is the number of rows inserted per transaction
+// by the importers, unless specified otherwise.
+const DefaultImportBatchSize = 10000
+
+// number of records read to infer the columns of the table
+// created by ImportNDJSON, if it doesn't exist.
+const ndjsonSampleSize = 100
+
+// NDJSONOptions configure ImportNDJSON.
+type NDJSONOptions struct {
+	// Number of rows inserted per transaction. Defaults to DefaultImportBatchSize.
+	BatchSize int
+	// If true, the fields of nested objects are stored in their own columns,
+	// named after their path, joined with Separator. Otherwise, nested objects
+	// are stored in JSON columns, like arrays.
+	Flatten bool
+	// Separator of the names of flattened fields. Defaults to "_".
+	Separator string
+}
+
+// ImportNDJSON reads a file of newline-delimited JSON objects, also known
+// as JSON Lines, and inserts every object as a row of the given table.
+// The file is decoded as a stream, and the rows are inserted in batches,
+// each within its own transaction: if an error occurs, the rows of the previous
+// batches are kept. It returns the number of rows inserted.
+// If the table doesn't exist, it is created with the columns found
+// in the first objects of the file, whose types are inferred from their values.
+func ImportNDJSON(ctx context.Context, db *chai.DB, r io.Reader, table string, opts *NDJSONOptions) (int64, error) {
+	var o NDJSONOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.BatchSize <= 0 {
+		o.BatchSize = DefaultImportBatchSize
+	}
+	if o.Separator == "" {
+		o.Separator = "_"
+	}
+
+	conn, err := db.Connect()
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	dec := ndjsonDecoder{
+		r:    bufio.NewReader(r),
+		opts: &o,
+	}
+
+	exists, err := tableExists(conn, table)
+	if err != nil {
+		return 0, err
+	}
+
+	// read a sample of the records to create the table
+	var sample []*record
+	if !exists {
+		for len(sample) < ndjsonSampleSize {
+			rec, err := dec.next()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return 0, err
+			}
+
+			sample = append(sample, rec)
+		}
+
+		if len(sample) == 0 {
+			return 0, errors.Errorf("cannot create table %s: the file contains no objects", table)
+		}
+
+		err = conn.Exec(createTableQuery(table, sample))
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	ins := inserter{
+		table: stringutil.NormalizeIdentifier(table, '`'),
+		stmts: make(map[string]*chai.Statement),
+	}
+	defer ins.rollback()
+
+	var n int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return n, err
+		}
+
+		var rec *record
+		if len(sample) > 0 {
+			rec, sample = sample[0], sample[1:]
+		} else {
+			rec, err = dec.next()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return n, err
+			}
+		}
+
+		if ins.tx == nil {
+			err = ins.begin(conn)
+			if err != nil {
+				return n, err
+			}
+		}
+
+		err = ins.insert(rec)
+		if err != nil {
+			return n, errors.Wrapf(err, "line %d", rec.line)
+		}
+
+		if ins.count == o.BatchSize {
+			err = ins.commit()
+			if err != nil {
+				return n, err
+			}
+			n += int64(o.BatchSize)
+		}
+	}
+
+	if ins.tx != nil {
+		count := ins.count
+		err = ins.commit()
+		if err != nil {
+			return n, err
+		}
+		n += int64(count)
+	}
+
+	return n, nil
+}
+
+// tableExists reports whether the table exists.
+func tableExists(conn *chai.Connection, table string) (bool, error) {
+	_, err := conn.QueryRow("SELECT 1 FROM __chai_catalog WHERE name = ? AND type = 'table'", table)
+	if chai.IsNotFoundError(err) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+// A record is a row read from a file, with its columns in order.
+type record struct {
+	// line number of the record in the file.
+	line    int
+	columns []string
+	values  []any
+	// text of the record in the file, if kept to report it.
+	raw string
+}
+
+// ndjsonDecoder decodes the records of a newline-delimited JSON file.
+type ndjsonDecoder struct {
+	r    *bufio.Reader
+	opts *NDJSONOptions
+	line int
+}
+
+// next returns the next record, or io.EOF at the end of the file.
+// Blank lines are skipped.
+func (d *ndjsonDecoder) next() (*record, error) {
+	for {
+		data, err := d.r.ReadBytes('\n')
+		if len(data) == 0 && err != nil {
+			return nil, err
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		d.line++
+
+		data = bytes.TrimSpace(data)
+		if len(data) == 0 {
+			continue
+		}
+
+		rec := record{line: d.line}
+		err = d.decodeObject(data, "", &rec)
+		if err == nil && len(rec.columns) == 0 {
+			err = errors.New("empty object")
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "line %d", d.line)
+		}
+
+		return &rec, nil
+	}
+}
+
+// decodeObject adds the fields of the JSON object to the record,
+// prefixing their names with prefix.
+func (d *ndjsonDecoder) decodeObject(data []byte, prefix string, rec *record) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != json.Delim('{') {
+		return errors.New("expected a JSON object")
+	}
+
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		name := prefix + tok.(string)
+
+		var raw json.RawMessage
+		err = dec.Decode(&raw)
+		if err != nil {
+			return err
+		}
+
+		if d.opts.Flatten && raw[0] == '{' {
+			err = d.decodeObject(raw, name+d.opts.Separator, rec)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		v, err := jsonValue(raw)
+		if err != nil {
+			return err
+		}
+
+		rec.columns = append(rec.columns, name)
+		rec.values = append(rec.values, v)
+	}
+
+	_, err = dec.Token()
+	return err
+}
+
+// rawJSON is a nested object or array, stored as JSON.
+type rawJSON string
+
+// jsonValue converts a JSON value to the value inserted in the table.
+func jsonValue(raw json.RawMessage) (any, error) {
+	switch raw[0] {
+	case '{', '[':
+		var buf bytes.Buffer
+		err := json.Compact(&buf, raw)
+		return rawJSON(buf.String()), err
+	case '"':
+		var s string
+		err := json.Unmarshal(raw, &s)
+		return s, err
+	case 't', 'f':
+		return raw[0] == 't', nil
+	case 'n':
+		return nil, nil
+	}
+
+	if i, err := strconv.ParseInt(string(raw), 10, 64); err == nil {
+		return i, nil
+	}
+
+	return strconv.ParseFloat(string(raw), 64)
+}
+
+// columnType returns the type of the column storing the value, or "" for NULL.
+func columnType(v any) string {
+	switch v.(type) {
+	case int64:
+		return "BIGINT"
+	case float64:
+		return "DOUBLE"
+	case bool:
+		return "BOOLEAN"
+	case string:
+		return "TEXT"
+	case rawJSON:
+		return "JSON"
+	}
+
+	return ""
+}
+
+// createTableQuery returns the statement creating a table with the columns of the records.
+// The type of each column is the one of its values: integer columns with
+// floating point values are DOUBLE, and the other columns with values of different types,
+// or with only NULL values, are TEXT.
+func createTableQuery(table string, records []*record) string {
+	var columns []string
+	types := make(map[string]string)
+	for _, rec := range records {
+		for i, c := range rec.columns {
+			t, seen := types[c]
+			if !seen {
+				columns = append(columns, c)
+			}
+
+			vt := columnType(rec.values[i])
+			switch {
+			case vt == "" || vt == t:
+			case t == "":
+				t = vt
+			case (t == "BIGINT" || t == "DOUBLE") && (vt == "BIGINT" || vt == "DOUBLE"):
+				t = "DOUBLE"
+			default:
+				t = "TEXT"
+			}
+			types[c] = t
+		}
+	}
+
+	return createTableStmt(table, columns, types)
+}
+
+// createTableStmt returns the statement creating a table with the given columns,
+// whose types are looked up in types. Columns without a type are TEXT.
+func createTableStmt(table string, columns []string, types map[string]string) string {
+	var sb strings.Builder
+	sb.WriteString("CREATE TABLE ")
+	sb.WriteString(stringutil.NormalizeIdentifier(table, '`'))
+	sb.WriteString(" (")
+	for i, c := range columns {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(stringutil.NormalizeIdentifier(c, '`'))
+		sb.WriteByte(' ')
+		if t := types[c]; t != "" {
+			sb.WriteString(t)
+		} else {
+			sb.WriteString("TEXT")
+		}
+	}
+	sb.WriteString(")")
+
+	return sb.String()
+}
+
+// inserter inserts records in a table, within a transaction.
+type inserter struct {
+	table string
+	tx    *chai.Tx
+	// number of records inserted in the transaction.
+	count int
+	// statements inserting a set of columns, within the transaction.
+	stmts map[string]*chai.Statement
+}
+
+func (ins *inserter) begin(conn *chai.Connection) error {
+	tx, err := conn.Begin(true)
+	if err != nil {
+		return err
+	}
+
+	ins.tx = tx
+	ins.count = 0
+	clear(ins.stmts)
+	return nil
+}
+
+func (ins *inserter) insert(rec *record) error {
+	key := strings.Join(rec.columns, "\x00")
+	stmt, ok := ins.stmts[key]
+	if !ok {
+		var sb strings.Builder
+		sb.WriteString("INSERT INTO ")
+		sb.WriteString(ins.table)
+		sb.WriteString(" (")
+		for i, c := range rec.columns {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			sb.WriteString(stringutil.NormalizeIdentifier(c, '`'))
+		}
+		sb.WriteString(") VALUES (")
+		for i := range rec.columns {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			sb.WriteString("?")
+		}
+		sb.WriteString(")")
+
+		var err error
+		stmt, err = ins.tx.Prepare(sb.String())
+		if err != nil {
+			return err
+		}
+		ins.stmts[key] = stmt
+	}
+
+	args := make([]any, len(rec.values))
+	for i, v := range rec.values {
+		if j, ok := v.(rawJSON); ok {
+			v = string(j)
+		}
+		args[i] = v
+	}
+
+	err := stmt.Exec(args...)
+	if err != nil {
+		return err
+	}
+
+	ins.count++
+	return nil
+}
+
+func (ins *inserter) commit() error {
+	tx := ins.tx
+	ins.tx = nil
+	return tx.Commit()
+}
+
+func (ins *inserter) rollback() {
+	if ins.tx != nil {
+		_ = ins.tx.Rollback()
+		ins.tx = nil
+	}
+}
@@ -0,0 +1,93 @@
+package dbutil
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/chaisql/chai"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportNDJSON(t *testing.T) {
+	ctx := context.Background()
+
+	data := `{"id": 1, "name": "foo", "score": 1, "meta": {"tags": ["a"], "size": 10}}
+
+{"id": 2, "name": "bar", "score": 2.5, "meta": {"size": 20}, "active": true}
+{"id": 3, "score": null}
+`
+
+	t.Run("New table", func(t *testing.T) {
+		db, err := chai.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		n, err := ImportNDJSON(ctx, db, strings.NewReader(data), "test", &NDJSONOptions{BatchSize: 2})
+		require.NoError(t, err)
+		require.EqualValues(t, 3, n)
+
+		var schema strings.Builder
+		require.NoError(t, DumpSchema(db, &schema, "test"))
+		require.Equal(t, "CREATE TABLE test (id BIGINT, name TEXT, score DOUBLE, meta JSONB, active BOOLEAN);\n", schema.String())
+
+		r, err := db.QueryRow(`SELECT * FROM test WHERE id = 2`)
+		require.NoError(t, err)
+		got, err := r.MarshalJSON()
+		require.NoError(t, err)
+		require.JSONEq(t, `{"id": 2, "name": "bar", "score": 2.5, "meta": {"size": 20}, "active": true}`, string(got))
+	})
+
+	t.Run("Flatten", func(t *testing.T) {
+		db, err := chai.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		n, err := ImportNDJSON(ctx, db, strings.NewReader(data), "test", &NDJSONOptions{Flatten: true})
+		require.NoError(t, err)
+		require.EqualValues(t, 3, n)
+
+		r, err := db.QueryRow(`SELECT meta_tags, meta_size FROM test WHERE id = 1`)
+		require.NoError(t, err)
+		var tags string
+		var size int
+		require.NoError(t, r.Scan(&tags, &size))
+		require.Equal(t, `["a"]`, tags)
+		require.Equal(t, 10, size)
+	})
+
+	t.Run("Existing table", func(t *testing.T) {
+		db, err := chai.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec(`CREATE TABLE test (id INT PRIMARY KEY, name TEXT)`)
+		require.NoError(t, err)
+
+		n, err := ImportNDJSON(ctx, db, strings.NewReader(`{"id": 1, "name": "a"}
+{"id": 2}
+{"id": 2, "name": "duplicate"}
+`), "test", &NDJSONOptions{BatchSize: 2})
+		require.ErrorContains(t, err, "line 3")
+		require.EqualValues(t, 2, n)
+
+		// the first batch was committed
+		r, err := db.QueryRow(`SELECT COUNT(*) FROM test`)
+		require.NoError(t, err)
+		var count int
+		require.NoError(t, r.Scan(&count))
+		require.Equal(t, 2, count)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		db, err := chai.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		_, err = ImportNDJSON(ctx, db, strings.NewReader("{\"a\": 1}\n[1, 2]\n"), "test", nil)
+		require.ErrorContains(t, err, "line 2")
+
+		_, err = ImportNDJSON(ctx, db, strings.NewReader(""), "empty", nil)
+		require.Error(t, err)
+	})
+}
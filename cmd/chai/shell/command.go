@@ -3,7 +3,6 @@ package shell
 import (
 	"bytes"
 	"context"
-	"encoding/csv"
 	"fmt"
 	"io"
 	"os"
@@ -15,6 +14,7 @@ import (
 	"github.com/chaisql/chai/cmd/chai/dbutil"
 	errs "github.com/chaisql/chai/internal/errors"
 	"github.com/chaisql/chai/internal/row"
+	"github.com/chaisql/chai/internal/sql/parser"
 )
 
 type command struct {
@@ -53,6 +53,18 @@ var commands = []command{
 		DisplayName: ".indexes",
 		Description: "Display all indexes or the indexes of the given table name.",
 	},
+	{
+		Name:        ".mode",
+		Options:     "[mode]",
+		DisplayName: ".mode",
+		Description: "Show or set the output mode of the results: table, json, ndjson, csv or markdown.",
+	},
+	{
+		Name:        ".export",
+		Options:     "FORMAT 'file' AS query",
+		DisplayName: ".export",
+		Description: "Write the results of a query to a file, in parquet or in one of the formats of .mode, like csv, json or ndjson.",
+	},
 	{
 		Name:        ".dump",
 		Options:     "[table_name]",
@@ -69,19 +81,19 @@ var commands = []command{
 		Name:        ".schema",
 		Options:     "[table_name]",
 		DisplayName: ".schema",
-		Description: "Show the CREATE statements of all tables or of the selected ones.",
+		Description: "Show the CREATE statements of all tables, with their indexes and constraints, or of the selected ones.",
 	},
 	{
 		Name:        ".import",
-		Options:     "TYPE FILE table",
+		Options:     "TYPE FILE table [options]",
 		DisplayName: ".import",
-		Description: "Import data from a file. Only supported type is 'csv'",
+		Description: "Import data from a file, creating the table if it doesn't exist. Supported types are 'csv' and 'ndjson' (or 'jsonl'). With ndjson, --flatten stores the fields of nested objects in their own columns. With csv, the options are --delimiter=C (a character or 'tab'), --quote=C, --null=S (the text of NULL values, empty by default), --no-header, --columns=a,b (the columns of the fields, '-' to skip one), --types=a:INT,b:TEXT (the types of the created columns, inferred otherwise) and --skip-errors[=FILE] (skip invalid records and write them to FILE).",
 	},
 	{
 		Name:        ".timer",
 		Options:     "[on|off]",
 		DisplayName: ".timer",
-		Description: "Display the execution time after each query or hide it.",
+		Description: "Display the execution time, the number of rows returned or affected and the plan summary after each statement, or hide them.",
 	},
 	{
 		Name:        ".restore",
@@ -188,108 +200,210 @@ func runSaveCmd(ctx context.Context, db *chai.DB, dbPath string) error {
 	return otherDB.Exec(dbDump.String())
 }
 
-const csvBatchSize = 1000
+// parseCSVOptions parses the options of the .import command for CSV files,
+// and returns the path of the file of rejected records, if any.
+func parseCSVOptions(args []string) (*dbutil.CSVOptions, string, error) {
+	var opts dbutil.CSVOptions
+	var rejectedPath string
+
+	for _, arg := range args {
+		name, value, hasValue := strings.Cut(arg, "=")
+		switch name {
+		case "--delimiter", "--quote":
+			r, err := parseCSVRune(value)
+			if err != nil {
+				return nil, "", errors.Wrapf(err, "invalid %s", name)
+			}
+			if name == "--delimiter" {
+				opts.Delimiter = r
+			} else {
+				opts.Quote = r
+			}
+		case "--null":
+			opts.NullString = value
+		case "--no-header":
+			if hasValue {
+				return nil, "", errors.Errorf("unexpected value for %s", name)
+			}
+			opts.NoHeader = true
+		case "--columns":
+			opts.Columns = strings.Split(value, ",")
+		case "--types":
+			opts.Types = make(map[string]string)
+			for _, ct := range strings.Split(value, ",") {
+				c, t, ok := strings.Cut(ct, ":")
+				if !ok || c == "" || t == "" {
+					return nil, "", errors.Errorf("invalid type %q, expected column:TYPE", ct)
+				}
+				opts.Types[c] = t
+			}
+		case "--skip-errors":
+			opts.SkipErrors = true
+			rejectedPath = value
+		default:
+			return nil, "", errors.Errorf("unknown option %s", arg)
+		}
+	}
 
-func runImportCmd(db *chai.DB, fileType, path, table string) error {
-	if strings.ToLower(fileType) != "csv" {
-		return errors.New("TYPE should be csv")
+	return &opts, rejectedPath, nil
+}
+
+// parseCSVRune parses a delimiter or a quote, which must be a single character,
+// "tab" or "\t".
+func parseCSVRune(s string) (rune, error) {
+	switch s {
+	case "tab", `\t`:
+		return '\t', nil
 	}
 
-	f, err := os.Open(path)
-	if err != nil {
-		return err
+	r := []rune(s)
+	if len(r) != 1 {
+		return 0, errors.Errorf("expected a single character, got %q", s)
 	}
-	defer f.Close()
+	return r[0], nil
+}
 
-	conn, err := db.Connect()
+// runImportCSVCmd imports a CSV file in the table and displays the number of imported rows,
+// and of rejected records if errors are skipped.
+func runImportCSVCmd(ctx context.Context, db *chai.DB, path, table string, args []string, w io.Writer) error {
+	opts, rejectedPath, err := parseCSVOptions(args)
 	if err != nil {
 		return err
 	}
-	defer conn.Close()
 
-	tx, err := conn.Begin(true)
+	f, err := os.Open(path)
 	if err != nil {
 		return err
 	}
-	defer tx.Rollback()
-
-	r := csv.NewReader(f)
+	defer f.Close()
 
-	err = tx.Exec(fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s", table))
-	if err != nil {
-		return err
+	if rejectedPath != "" {
+		rf, err := os.Create(rejectedPath)
+		if err != nil {
+			return err
+		}
+		defer rf.Close()
+		opts.Rejected = rf
 	}
 
-	headers, err := r.Read()
-	if err != nil {
-		return err
+	n, rejected, err := dbutil.ImportCSV(ctx, db, f, table, opts)
+	if n > 0 || err == nil {
+		fmt.Fprintf(w, "%d rows imported\n", n)
+	}
+	if rejected > 0 {
+		if rejectedPath != "" {
+			fmt.Fprintf(w, "%d records rejected, written to %s\n", rejected, rejectedPath)
+		} else {
+			fmt.Fprintf(w, "%d records rejected\n", rejected)
+		}
 	}
+	return err
+}
+
+// parseExportCmd parses the arguments of the .export command:
+// the format, the path of the file, which can be quoted, and the query following AS.
+func parseExportCmd(in string) (format, path, query string, err error) {
+	usage := errors.New(getUsage(".export"))
 
-	baseQ := fmt.Sprintf("INSERT INTO %s VALUES ", table)
+	in = strings.TrimSpace(strings.TrimPrefix(in, ".export"))
+	format, in, ok := strings.Cut(in, " ")
+	if !ok {
+		return "", "", "", usage
+	}
+	in = strings.TrimSpace(in)
 
-	buf := make([][]string, csvBatchSize)
-	fbs := make([]*row.ColumnBuffer, csvBatchSize)
-	for i := range fbs {
-		fbs[i] = row.NewColumnBuffer()
+	if in == "" {
+		return "", "", "", usage
 	}
-	args := make([]any, csvBatchSize)
-	for i := range args {
-		args[i] = fbs[i]
+	if q := in[0]; q == '\'' || q == '"' {
+		end := strings.IndexByte(in[1:], q)
+		if end < 0 {
+			return "", "", "", errors.Errorf("missing closing quote in file name: %s", in)
+		}
+		path, in = in[1:end+1], in[end+2:]
+	} else {
+		path, in, _ = strings.Cut(in, " ")
 	}
 
-	var sb strings.Builder
-	var stop bool
-	var stmt *chai.Statement
+	as, query, _ := strings.Cut(strings.TrimSpace(in), " ")
+	if !strings.EqualFold(as, "AS") || path == "" || strings.TrimSpace(query) == "" {
+		return "", "", "", usage
+	}
 
-	for !stop {
-		sb.Reset()
-		n, err := csvReadN(r, csvBatchSize, buf)
-		if errors.Is(err, io.EOF) {
-			stop = true
-		} else if err != nil {
-			return err
-		}
+	return strings.ToLower(format), path, strings.TrimSpace(query), nil
+}
 
-		if n == 0 {
-			break
+// runExportCmd runs a query and writes its rows in the given file,
+// in parquet or in the format of one of the output modes, and displays the number of exported rows.
+// The query must be a single read-only statement.
+func runExportCmd(ctx context.Context, db *chai.DB, format, path, query string, w io.Writer) error {
+	newWriter := newParquetWriter
+	if format != "parquet" {
+		if err := checkMode(format); err != nil {
+			return err
 		}
+		newWriter = outputModes[format]
+	}
 
-		for i := 0; i < n; i++ {
-			fbs[i].Reset()
-			fbs[i].ScanCSV(headers, buf[i])
-		}
+	q, err := parser.ParseQuery(query)
+	if err != nil {
+		return err
+	}
+	if len(q.Statements) != 1 || !q.Statements[0].IsReadOnly() {
+		return errors.New("the query of .export must be a single read-only statement, like SELECT")
+	}
 
-		if stmt == nil || n < csvBatchSize {
-			sb.WriteString(baseQ)
-			for i := 0; i < n; i++ {
-				if i > 0 {
-					sb.WriteString(",")
-				}
-				sb.WriteString("?")
-			}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
 
-			stmt, err = tx.Prepare(sb.String())
-			if err != nil {
-				return err
-			}
-		}
+	var n int
+	var returnsRows bool
+	err = dbutil.ExecSQLFunc(ctx, db, strings.NewReader(query), func(columns []string, iterate func(fn func(r row.Row) error) error) error {
+		returnsRows = true
 
-		err = stmt.Exec(args[:n]...)
+		rw := newWriter(f, columns)
+		err := iterate(func(r row.Row) error {
+			n++
+			return rw.WriteRow(r)
+		})
 		if err != nil {
 			return err
 		}
+
+		return rw.Close()
+	})
+	if err == nil && !returnsRows {
+		err = errors.New("the query of .export returns no rows")
+	}
+	if err != nil {
+		_ = f.Close()
+		_ = os.Remove(path)
+		return err
+	}
+
+	err = f.Close()
+	if err != nil {
+		return err
 	}
 
-	return tx.Commit()
+	_, err = fmt.Fprintf(w, "%d rows exported\n", n)
+	return err
 }
 
-func csvReadN(r *csv.Reader, n int, dst [][]string) (int, error) {
-	for i := 0; i < n; i++ {
-		record, err := r.Read()
-		if err != nil {
-			return i, err
-		}
-		dst[i] = record
+// runImportNDJSONCmd imports a newline-delimited JSON file in the table
+// and displays the number of imported rows.
+func runImportNDJSONCmd(ctx context.Context, db *chai.DB, path, table string, flatten bool, w io.Writer) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	n, err := dbutil.ImportNDJSON(ctx, db, f, table, &dbutil.NDJSONOptions{Flatten: flatten})
+	if n > 0 || err == nil {
+		fmt.Fprintf(w, "%d rows imported\n", n)
 	}
-	return n, nil
+	return err
 }
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/csv"
+	"io"
 	"os"
 	"path/filepath"
 	"testing"
@@ -160,7 +161,7 @@ func BenchmarkImportCSV(b *testing.B) {
 	b.ResetTimer()
 
 	for i := 0; i < b.N; i++ {
-		err = runImportCmd(db, "csv", fp, "foo")
+		err = runImportCSVCmd(context.Background(), db, fp, "foo", nil, io.Discard)
 		require.NoError(b, err)
 
 		b.StopTimer()
@@ -169,3 +170,55 @@ func BenchmarkImportCSV(b *testing.B) {
 		b.StartTimer()
 	}
 }
+
+func TestExportCmd(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE test (a INT, b TEXT);
+		INSERT INTO test (a, b) VALUES (1, 'foo'), (2, 'bar baz'), (3, 'qux');
+	`)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	sh := Shell{db: db, mode: defaultMode}
+
+	var out bytes.Buffer
+	fp := filepath.Join(dir, "out file.csv")
+	err = sh.runCommand(context.Background(), `.export CSV '`+fp+`' AS SELECT a, b FROM test WHERE b != 'qux';`, &out)
+	require.NoError(t, err)
+	require.Equal(t, "2 rows exported\n", out.String())
+
+	data, err := os.ReadFile(fp)
+	require.NoError(t, err)
+	require.Equal(t, "a,b\n1,foo\n2,bar baz\n", string(data))
+
+	fp = filepath.Join(dir, "out.ndjson")
+	err = sh.runCommand(context.Background(), `.export ndjson `+fp+` as SELECT a FROM test`, &out)
+	require.NoError(t, err)
+	data, err = os.ReadFile(fp)
+	require.NoError(t, err)
+	require.Equal(t, "{\"a\": 1}\n{\"a\": 2}\n{\"a\": 3}\n", string(data))
+
+	tests := []string{
+		`.export csv`,
+		`.export csv out.csv SELECT 1`,
+		`.export csv 'out.csv AS SELECT 1`,
+		`.export xml out.xml AS SELECT 1`,
+		`.export csv out.csv AS DELETE FROM test`,
+		`.export csv out.csv AS SELECT 1; SELECT 2`,
+	}
+	for _, in := range tests {
+		err = sh.runCommand(context.Background(), in, &out)
+		require.Error(t, err, in)
+	}
+
+	// the table was not modified
+	r, err := db.QueryRow(`SELECT COUNT(*) FROM test`)
+	require.NoError(t, err)
+	var n int
+	require.NoError(t, r.Scan(&n))
+	require.Equal(t, 3, n)
+}
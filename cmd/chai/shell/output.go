@@ -0,0 +1,425 @@
+package shell
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/chaisql/chai/internal/row"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// defaultMode is the output mode of the shell when none is selected.
+const defaultMode = "table"
+
+// A resultWriter writes the rows of a query in a given output mode.
+type resultWriter interface {
+	// WriteRow writes a row of the query.
+	WriteRow(r row.Row) error
+	// Close is called after the last row of the query.
+	Close() error
+}
+
+// outputModes lists the result writers, by name of output mode.
+// Each function returns a writer for the rows of a query, whose columns
+// are nil if they are not known before reading the rows.
+var outputModes = map[string]func(w io.Writer, columns []string) resultWriter{
+	"table":    newTableWriter,
+	"json":     newJSONWriter,
+	"ndjson":   newNDJSONWriter,
+	"csv":      newCSVWriter,
+	"markdown": newMarkdownWriter,
+}
+
+// listModes returns the names of the output modes, sorted.
+func listModes() []string {
+	modes := make([]string, 0, len(outputModes))
+	for m := range outputModes {
+		modes = append(modes, m)
+	}
+	sort.Strings(modes)
+	return modes
+}
+
+// checkMode returns an error if the output mode doesn't exist.
+func checkMode(mode string) error {
+	if _, ok := outputModes[mode]; !ok {
+		return errors.Errorf("unknown output mode %q, expected one of %s", mode, strings.Join(listModes(), ", "))
+	}
+
+	return nil
+}
+
+// newResultWriter returns the writer of the given output mode.
+func newResultWriter(mode string, w io.Writer, columns []string) (resultWriter, error) {
+	if err := checkMode(mode); err != nil {
+		return nil, err
+	}
+
+	return outputModes[mode](w, columns), nil
+}
+
+// rowColumns returns the names of the columns of the row.
+func rowColumns(r row.Row) ([]string, error) {
+	var columns []string
+	err := r.Iterate(func(column string, _ types.Value) error {
+		columns = append(columns, column)
+		return nil
+	})
+	return columns, err
+}
+
+// rowValues returns the values of the row, formatted for display.
+func rowValues(r row.Row) ([]string, error) {
+	var values []string
+	err := r.Iterate(func(_ string, v types.Value) error {
+		values = append(values, formatValue(v))
+		return nil
+	})
+	return values, err
+}
+
+// formatValue returns v as displayed in a cell, without the quotes
+// of its literal representation. NULL values are returned as "NULL".
+func formatValue(v types.Value) string {
+	switch t := v.(type) {
+	case types.TextValue:
+		return string(t)
+	case types.CitextValue:
+		return string(t)
+	}
+
+	s := v.String()
+	if strings.HasPrefix(s, `"`) {
+		if u, err := strconv.Unquote(s); err == nil {
+			return u
+		}
+	}
+
+	return s
+}
+
+// isNumeric reports whether v is a number, which is aligned to the right.
+func isNumeric(v types.Value) bool {
+	switch v.Type() {
+	case types.TypeInteger, types.TypeBigint, types.TypeUnsignedBigint, types.TypeDouble, types.TypeReal:
+		return true
+	}
+
+	return false
+}
+
+// tableWriter writes the rows as an aligned ASCII table, followed by the number of rows.
+// The rows are buffered to compute the width of the columns.
+type tableWriter struct {
+	w       io.Writer
+	columns []string
+	rows    [][]string
+	// numeric columns, aligned to the right
+	numeric []bool
+}
+
+func newTableWriter(w io.Writer, columns []string) resultWriter {
+	return &tableWriter{w: w, columns: columns, numeric: make([]bool, len(columns))}
+}
+
+func (t *tableWriter) WriteRow(r row.Row) error {
+	if t.columns == nil {
+		columns, err := rowColumns(r)
+		if err != nil {
+			return err
+		}
+		t.columns = columns
+		t.numeric = make([]bool, len(columns))
+	}
+
+	values := make([]string, 0, len(t.columns))
+	err := r.Iterate(func(_ string, v types.Value) error {
+		i := len(values)
+		if i < len(t.numeric) && isNumeric(v) {
+			t.numeric[i] = true
+		}
+		values = append(values, formatValue(v))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	t.rows = append(t.rows, values)
+	return nil
+}
+
+func (t *tableWriter) Close() error {
+	widths := make([]int, len(t.columns))
+	for i, c := range t.columns {
+		widths[i] = utf8.RuneCountInString(c)
+	}
+	for _, r := range t.rows {
+		for i, v := range r {
+			if i < len(widths) {
+				widths[i] = max(widths[i], utf8.RuneCountInString(v))
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+
+	separator := func() {
+		for _, w := range widths {
+			buf.WriteByte('+')
+			buf.WriteString(strings.Repeat("-", w+2))
+		}
+		buf.WriteString("+\n")
+	}
+
+	line := func(cells []string, numeric []bool) {
+		for i, w := range widths {
+			var c string
+			if i < len(cells) {
+				c = cells[i]
+			}
+			pad := strings.Repeat(" ", w-utf8.RuneCountInString(c))
+
+			buf.WriteString("| ")
+			if numeric != nil && numeric[i] {
+				buf.WriteString(pad)
+				buf.WriteString(c)
+			} else {
+				buf.WriteString(c)
+				buf.WriteString(pad)
+			}
+			buf.WriteByte(' ')
+		}
+		buf.WriteString("|\n")
+	}
+
+	if len(t.columns) > 0 {
+		separator()
+		line(t.columns, nil)
+		separator()
+		for _, r := range t.rows {
+			line(r, t.numeric)
+		}
+		separator()
+	}
+
+	if len(t.rows) == 1 {
+		buf.WriteString("(1 row)\n")
+	} else {
+		fmt.Fprintf(&buf, "(%d rows)\n", len(t.rows))
+	}
+
+	_, err := t.w.Write(buf.Bytes())
+	return err
+}
+
+// jsonWriter writes the rows as an indented JSON array of objects.
+type jsonWriter struct {
+	w     io.Writer
+	count int
+	buf   bytes.Buffer
+}
+
+func newJSONWriter(w io.Writer, _ []string) resultWriter {
+	return &jsonWriter{w: w}
+}
+
+func (j *jsonWriter) WriteRow(r row.Row) error {
+	data, err := r.MarshalJSON()
+	if err != nil {
+		return err
+	}
+
+	j.buf.Reset()
+	if j.count == 0 {
+		j.buf.WriteString("[\n  ")
+	} else {
+		j.buf.WriteString(",\n  ")
+	}
+	j.count++
+
+	err = json.Indent(&j.buf, data, "  ", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = j.w.Write(j.buf.Bytes())
+	return err
+}
+
+func (j *jsonWriter) Close() error {
+	var err error
+	if j.count == 0 {
+		_, err = io.WriteString(j.w, "[]\n")
+	} else {
+		_, err = io.WriteString(j.w, "\n]\n")
+	}
+	return err
+}
+
+// ndjsonWriter writes every row as a JSON object on its own line.
+type ndjsonWriter struct {
+	w io.Writer
+}
+
+func newNDJSONWriter(w io.Writer, _ []string) resultWriter {
+	return &ndjsonWriter{w: w}
+}
+
+func (n *ndjsonWriter) WriteRow(r row.Row) error {
+	data, err := r.MarshalJSON()
+	if err != nil {
+		return err
+	}
+
+	_, err = n.w.Write(append(data, '\n'))
+	return err
+}
+
+func (n *ndjsonWriter) Close() error {
+	return nil
+}
+
+// csvWriter writes the rows as CSV, with a header containing the names of the columns.
+// NULL values are written as empty fields.
+type csvWriter struct {
+	w       *csv.Writer
+	columns []string
+	header  bool
+}
+
+func newCSVWriter(w io.Writer, columns []string) resultWriter {
+	return &csvWriter{w: csv.NewWriter(w), columns: columns}
+}
+
+func (c *csvWriter) writeHeader() error {
+	c.header = true
+	if c.columns == nil {
+		return nil
+	}
+
+	return c.w.Write(c.columns)
+}
+
+func (c *csvWriter) WriteRow(r row.Row) error {
+	if !c.header {
+		if c.columns == nil {
+			columns, err := rowColumns(r)
+			if err != nil {
+				return err
+			}
+			c.columns = columns
+		}
+		if err := c.writeHeader(); err != nil {
+			return err
+		}
+	}
+
+	var record []string
+	err := r.Iterate(func(_ string, v types.Value) error {
+		if v.Type() == types.TypeNull {
+			record = append(record, "")
+		} else {
+			record = append(record, formatValue(v))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.w.Write(record)
+}
+
+func (c *csvWriter) Close() error {
+	if !c.header {
+		if err := c.writeHeader(); err != nil {
+			return err
+		}
+	}
+
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// markdownWriter writes the rows as a Markdown table.
+type markdownWriter struct {
+	w       io.Writer
+	columns []string
+	header  bool
+}
+
+func newMarkdownWriter(w io.Writer, columns []string) resultWriter {
+	return &markdownWriter{w: w, columns: columns}
+}
+
+func (m *markdownWriter) writeLine(cells []string) error {
+	var sb strings.Builder
+	sb.WriteByte('|')
+	for _, c := range cells {
+		sb.WriteByte(' ')
+		c = strings.ReplaceAll(c, "|", `\|`)
+		c = strings.ReplaceAll(c, "\n", "<br>")
+		sb.WriteString(c)
+		sb.WriteString(" |")
+	}
+	sb.WriteByte('\n')
+
+	_, err := io.WriteString(m.w, sb.String())
+	return err
+}
+
+func (m *markdownWriter) writeHeader() error {
+	m.header = true
+	if m.columns == nil {
+		return nil
+	}
+
+	if err := m.writeLine(m.columns); err != nil {
+		return err
+	}
+
+	sep := make([]string, len(m.columns))
+	for i := range sep {
+		sep[i] = "---"
+	}
+	return m.writeLine(sep)
+}
+
+func (m *markdownWriter) WriteRow(r row.Row) error {
+	if !m.header {
+		if m.columns == nil {
+			columns, err := rowColumns(r)
+			if err != nil {
+				return err
+			}
+			m.columns = columns
+		}
+		if err := m.writeHeader(); err != nil {
+			return err
+		}
+	}
+
+	values, err := rowValues(r)
+	if err != nil {
+		return err
+	}
+
+	return m.writeLine(values)
+}
+
+func (m *markdownWriter) Close() error {
+	if !m.header {
+		return m.writeHeader()
+	}
+
+	return nil
+}
@@ -0,0 +1,138 @@
+package shell
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/chaisql/chai"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecSQLModes(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE test (a INT PRIMARY KEY, b TEXT, c DOUBLE);
+		INSERT INTO test (a, b, c) VALUES (1, 'foo', 1.5), (10, 'a|b', NULL);
+	`)
+	require.NoError(t, err)
+
+	tests := []struct {
+		mode string
+		want string
+	}{
+		{"table", `+----+-----+------+
+| a  | b   | c    |
++----+-----+------+
+|  1 | foo |  1.5 |
+| 10 | a|b | NULL |
++----+-----+------+
+(2 rows)
+`},
+		{"json", `[
+  {
+    "a": 1,
+    "b": "foo",
+    "c": 1.5
+  },
+  {
+    "a": 10,
+    "b": "a|b",
+    "c": null
+  }
+]
+`},
+		{"ndjson", `{"a": 1, "b": "foo", "c": 1.5}
+{"a": 10, "b": "a|b", "c": null}
+`},
+		{"csv", `a,b,c
+1,foo,1.5
+10,a|b,
+`},
+		{"markdown", `| a | b | c |
+| --- | --- | --- |
+| 1 | foo | 1.5 |
+| 10 | a\|b | NULL |
+`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.mode, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := ExecSQL(context.Background(), db, strings.NewReader(`UPDATE test SET b = b; SELECT * FROM test;`), &buf, test.mode)
+			require.NoError(t, err)
+			require.Equal(t, test.want, buf.String())
+		})
+	}
+
+	t.Run("No rows", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := ExecSQL(context.Background(), db, strings.NewReader(`SELECT a, b FROM test WHERE a > 100;`), &buf, "table")
+		require.NoError(t, err)
+		require.Equal(t, "+---+---+\n| a | b |\n+---+---+\n+---+---+\n(0 rows)\n", buf.String())
+
+		buf.Reset()
+		err = ExecSQL(context.Background(), db, strings.NewReader(`SELECT a, b FROM test WHERE a > 100;`), &buf, "json")
+		require.NoError(t, err)
+		require.Equal(t, "[]\n", buf.String())
+	})
+
+	t.Run("Unknown mode", func(t *testing.T) {
+		err := ExecSQL(context.Background(), db, strings.NewReader(`SELECT 1;`), &bytes.Buffer{}, "xml")
+		require.Error(t, err)
+	})
+}
+
+func TestModeCmd(t *testing.T) {
+	sh := Shell{mode: defaultMode}
+
+	var buf bytes.Buffer
+	err := sh.runCommand(context.Background(), ".mode", &buf)
+	require.NoError(t, err)
+	require.Equal(t, "table (available: csv, json, markdown, ndjson, table)\n", buf.String())
+
+	err = sh.runCommand(context.Background(), ".mode csv", &buf)
+	require.NoError(t, err)
+	require.Equal(t, "csv", sh.mode)
+
+	err = sh.runCommand(context.Background(), ".mode xml", &buf)
+	require.Error(t, err)
+	require.Equal(t, "csv", sh.mode)
+}
+
+func TestTimerCmd(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	sh := Shell{db: db, mode: "csv"}
+
+	var buf bytes.Buffer
+	err = sh.runCommand(ctx, ".timer on", &buf)
+	require.NoError(t, err)
+	require.True(t, sh.displayTime)
+
+	err = sh.runQuery(ctx, `CREATE TABLE test (a INT PRIMARY KEY, b TEXT); INSERT INTO test VALUES (1, 'a'), (2, 'b'); SELECT a FROM test WHERE a > 1;`, &buf)
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 7)
+	require.Regexp(t, `^Time: \S+$`, lines[0])
+	require.Regexp(t, `^Time: \S+, 2 rows affected$`, lines[1])
+	require.Equal(t, `Plan: rows.Emit((1, "a"), (2, "b")) | table.Validate("test") | table.Insert("test") | discard()`, lines[2])
+	require.Equal(t, []string{"a", "2"}, lines[3:5])
+	require.Regexp(t, `^Time: \S+, 1 row returned$`, lines[5])
+	require.Equal(t, `Plan: table.Scan("test", [{"min": (1), "exclusive": true}]) | rows.Project(a)`, lines[6])
+
+	buf.Reset()
+	err = sh.runCommand(ctx, ".timer off", &buf)
+	require.NoError(t, err)
+	err = sh.runQuery(ctx, `SELECT a FROM test;`, &buf)
+	require.NoError(t, err)
+	require.Equal(t, "a\n1\n2\n", buf.String())
+}
@@ -0,0 +1,463 @@
+package shell
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+
+	"github.com/chaisql/chai/internal/row"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// parquetMagic starts and ends every Parquet file.
+var parquetMagic = []byte("PAR1")
+
+// Physical types, converted types and encodings of the Parquet format,
+// see parquet.thrift.
+const (
+	parquetBoolean   = 0
+	parquetInt32     = 1
+	parquetInt64     = 2
+	parquetFloat     = 4
+	parquetDouble    = 5
+	parquetByteArray = 6
+
+	parquetConvertedUTF8            = 0
+	parquetConvertedTimestampMicros = 10
+	parquetConvertedUint64          = 14
+
+	parquetOptional = 1
+
+	parquetEncodingPlain = 0
+	parquetEncodingRLE   = 3
+
+	parquetUncompressed = 0
+	parquetDataPage     = 0
+)
+
+// parquetWriter writes the rows as a Parquet file with a single row group.
+// Parquet stores the values column by column, so the rows are kept in memory
+// until the writer is closed.
+// Every column is optional, NULL values are not stored. The type of a column
+// is the one of its values: integers mixed with floating-point numbers are
+// stored as DOUBLE, and the columns whose values have other different types,
+// or types without an equivalent in Parquet, are stored as text.
+type parquetWriter struct {
+	w       io.Writer
+	columns []string
+	values  [][]types.Value
+	rows    int
+}
+
+func newParquetWriter(w io.Writer, columns []string) resultWriter {
+	return &parquetWriter{w: w, columns: columns}
+}
+
+func (p *parquetWriter) WriteRow(r row.Row) error {
+	if p.columns == nil {
+		columns, err := rowColumns(r)
+		if err != nil {
+			return err
+		}
+		p.columns = columns
+	}
+	if p.values == nil {
+		p.values = make([][]types.Value, len(p.columns))
+	}
+
+	var i int
+	err := r.Iterate(func(column string, v types.Value) error {
+		if i >= len(p.columns) {
+			return errors.Errorf("unexpected column %q", column)
+		}
+
+		switch v.Type() {
+		case types.TypeNull, types.TypeBoolean, types.TypeInteger, types.TypeBigint,
+			types.TypeUnsignedBigint, types.TypeReal, types.TypeDouble,
+			types.TypeTimestamp, types.TypeTimestampTZ, types.TypeText:
+		case types.TypeBlob:
+			// the row may reuse the buffer of the value
+			v = types.NewBlobValue(bytes.Clone(types.AsByteSlice(v)))
+		default:
+			v = types.NewTextValue(formatValue(v))
+		}
+
+		p.values[i] = append(p.values[i], v)
+		i++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if i != len(p.columns) {
+		return errors.Errorf("expected %d columns, got %d", len(p.columns), i)
+	}
+
+	p.rows++
+	return nil
+}
+
+// parquetColumn is a column chunk written to the file.
+type parquetColumn struct {
+	name       string
+	typ        types.Type
+	offset     int64
+	size       int64
+	numValues  int
+	encodings  []int32
+	parquetTyp int32
+}
+
+func (p *parquetWriter) Close() error {
+	w := bufio.NewWriter(p.w)
+	_, err := w.Write(parquetMagic)
+	if err != nil {
+		return err
+	}
+	offset := int64(len(parquetMagic))
+
+	chunks := make([]parquetColumn, len(p.columns))
+	for i, name := range p.columns {
+		var values []types.Value
+		if p.values != nil {
+			values = p.values[i]
+		}
+
+		c := parquetColumn{
+			name:      name,
+			typ:       parquetColumnType(values),
+			offset:    offset,
+			numValues: len(values),
+			encodings: []int32{parquetEncodingPlain, parquetEncodingRLE},
+		}
+		c.parquetTyp = parquetPhysicalType(c.typ)
+
+		page, err := encodeParquetPage(c.typ, values)
+		if err != nil {
+			return errors.Wrapf(err, "column %s", name)
+		}
+
+		var t thriftWriter
+		t.begin()
+		t.i32(1, parquetDataPage)
+		t.i32(2, int32(len(page)))
+		t.i32(3, int32(len(page)))
+		t.beginStruct(5)
+		t.i32(1, int32(len(values)))
+		t.i32(2, parquetEncodingPlain)
+		t.i32(3, parquetEncodingRLE)
+		t.i32(4, parquetEncodingRLE)
+		t.end()
+		t.end()
+
+		_, err = w.Write(t.buf)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(page)
+		if err != nil {
+			return err
+		}
+
+		c.size = int64(len(t.buf) + len(page))
+		offset += c.size
+		chunks[i] = c
+	}
+
+	footer := p.encodeFooter(chunks)
+	_, err = w.Write(footer)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(binary.LittleEndian.AppendUint32(nil, uint32(len(footer))))
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(parquetMagic)
+	if err != nil {
+		return err
+	}
+
+	return w.Flush()
+}
+
+// encodeFooter returns the FileMetaData structure of the file.
+func (p *parquetWriter) encodeFooter(chunks []parquetColumn) []byte {
+	var t thriftWriter
+	t.begin()
+	t.i32(1, 1)
+
+	// the root of the schema, followed by the columns
+	t.list(2, thriftStruct, len(chunks)+1)
+	t.begin()
+	t.binary(4, "schema")
+	t.i32(5, int32(len(chunks)))
+	t.end()
+	for _, c := range chunks {
+		t.begin()
+		t.i32(1, c.parquetTyp)
+		t.i32(3, parquetOptional)
+		t.binary(4, c.name)
+		if ct, ok := parquetConvertedType(c.typ); ok {
+			t.i32(6, ct)
+		}
+		t.end()
+	}
+
+	t.i64(3, int64(p.rows))
+
+	var total int64
+	for _, c := range chunks {
+		total += c.size
+	}
+
+	t.list(4, thriftStruct, 1)
+	t.begin()
+	t.list(1, thriftStruct, len(chunks))
+	for _, c := range chunks {
+		t.begin()
+		t.i64(2, c.offset)
+		t.beginStruct(3)
+		t.i32(1, c.parquetTyp)
+		t.list(2, thriftI32, len(c.encodings))
+		for _, e := range c.encodings {
+			t.elemI32(e)
+		}
+		t.list(3, thriftBinary, 1)
+		t.elemBinary(c.name)
+		t.i32(4, parquetUncompressed)
+		t.i64(5, int64(c.numValues))
+		t.i64(6, c.size)
+		t.i64(7, c.size)
+		t.i64(9, c.offset)
+		t.end()
+		t.end()
+	}
+	t.i64(2, total)
+	t.i64(3, int64(p.rows))
+	t.end()
+
+	t.binary(6, "chai")
+	t.end()
+
+	return t.buf
+}
+
+// parquetColumnType returns the type in which the values of a column are stored.
+func parquetColumnType(values []types.Value) types.Type {
+	typ := types.TypeNull
+	for _, v := range values {
+		vt := v.Type()
+		switch {
+		case vt == types.TypeNull || vt == typ:
+		case typ == types.TypeNull:
+			typ = vt
+		case parquetIsNumber(typ) && parquetIsNumber(vt):
+			if typ.IsInteger() && vt.IsInteger() {
+				typ = types.TypeBigint
+			} else {
+				typ = types.TypeDouble
+			}
+		case typ.IsTimestamp() && vt.IsTimestamp():
+			typ = types.TypeTimestampTZ
+		default:
+			return types.TypeText
+		}
+	}
+
+	if typ == types.TypeNull {
+		return types.TypeText
+	}
+	return typ
+}
+
+// parquetIsNumber returns whether t is a number type that can be stored
+// as a DOUBLE. Unsigned bigints may not fit in a BIGINT.
+func parquetIsNumber(t types.Type) bool {
+	switch t {
+	case types.TypeInteger, types.TypeBigint, types.TypeReal, types.TypeDouble:
+		return true
+	}
+	return false
+}
+
+func parquetPhysicalType(t types.Type) int32 {
+	switch t {
+	case types.TypeBoolean:
+		return parquetBoolean
+	case types.TypeInteger:
+		return parquetInt32
+	case types.TypeBigint, types.TypeUnsignedBigint, types.TypeTimestamp, types.TypeTimestampTZ:
+		return parquetInt64
+	case types.TypeReal:
+		return parquetFloat
+	case types.TypeDouble:
+		return parquetDouble
+	}
+	return parquetByteArray
+}
+
+func parquetConvertedType(t types.Type) (int32, bool) {
+	switch t {
+	case types.TypeText:
+		return parquetConvertedUTF8, true
+	case types.TypeUnsignedBigint:
+		return parquetConvertedUint64, true
+	case types.TypeTimestamp, types.TypeTimestampTZ:
+		return parquetConvertedTimestampMicros, true
+	}
+	return 0, false
+}
+
+// encodeParquetPage returns a data page containing the values, converted to typ.
+// The page starts with the definition levels, 0 for NULL values and 1 for
+// the others, followed by the non-NULL values with the PLAIN encoding.
+func encodeParquetPage(typ types.Type, values []types.Value) ([]byte, error) {
+	// the definition levels are written as a single bit-packed run
+	groups := (len(values) + 7) / 8
+	levels := binary.AppendUvarint(nil, uint64(groups)<<1|1)
+	levels = append(levels, make([]byte, groups)...)
+	bits := levels[len(levels)-groups:]
+
+	var data []byte
+	var n int
+	for i, v := range values {
+		if v.Type() == types.TypeNull {
+			continue
+		}
+		bits[i/8] |= 1 << (i % 8)
+
+		switch typ {
+		case types.TypeBoolean:
+			if n%8 == 0 {
+				data = append(data, 0)
+			}
+			if types.AsBool(v) {
+				data[len(data)-1] |= 1 << (n % 8)
+			}
+		case types.TypeInteger:
+			data = binary.LittleEndian.AppendUint32(data, uint32(types.AsInt32(v)))
+		case types.TypeBigint:
+			data = binary.LittleEndian.AppendUint64(data, uint64(types.AsInt64(v)))
+		case types.TypeUnsignedBigint:
+			data = binary.LittleEndian.AppendUint64(data, types.AsUint64(v))
+		case types.TypeReal:
+			data = binary.LittleEndian.AppendUint32(data, math.Float32bits(types.AsFloat32(v)))
+		case types.TypeDouble:
+			f, err := parquetFloat64(v)
+			if err != nil {
+				return nil, err
+			}
+			data = binary.LittleEndian.AppendUint64(data, math.Float64bits(f))
+		case types.TypeTimestamp, types.TypeTimestampTZ:
+			data = binary.LittleEndian.AppendUint64(data, uint64(types.AsTime(v).UnixMicro()))
+		case types.TypeBlob:
+			b := types.AsByteSlice(v)
+			data = binary.LittleEndian.AppendUint32(data, uint32(len(b)))
+			data = append(data, b...)
+		default:
+			s := formatValue(v)
+			data = binary.LittleEndian.AppendUint32(data, uint32(len(s)))
+			data = append(data, s...)
+		}
+		n++
+	}
+
+	page := binary.LittleEndian.AppendUint32(nil, uint32(len(levels)))
+	page = append(page, levels...)
+	return append(page, data...), nil
+}
+
+// parquetFloat64 returns the value of a number as a float64.
+func parquetFloat64(v types.Value) (float64, error) {
+	switch v.Type() {
+	case types.TypeInteger, types.TypeBigint:
+		return float64(types.AsInt64(v)), nil
+	case types.TypeReal:
+		return float64(types.AsFloat32(v)), nil
+	case types.TypeDouble:
+		return types.AsFloat64(v), nil
+	}
+	return 0, errors.Errorf("unexpected value %s", v)
+}
+
+// Types of the Thrift compact protocol, used by the metadata of Parquet files.
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter encodes structures with the Thrift compact protocol.
+type thriftWriter struct {
+	buf []byte
+	// id of the last field written in each of the structures being written.
+	last []int16
+}
+
+// begin starts a top-level structure or an element of a list of structures.
+func (t *thriftWriter) begin() {
+	t.last = append(t.last, 0)
+}
+
+func (t *thriftWriter) beginStruct(id int16) {
+	t.field(id, thriftStruct)
+	t.begin()
+}
+
+// end ends the current structure.
+func (t *thriftWriter) end() {
+	t.buf = append(t.buf, 0)
+	t.last = t.last[:len(t.last)-1]
+}
+
+func (t *thriftWriter) field(id int16, typ byte) {
+	last := &t.last[len(t.last)-1]
+	if d := id - *last; d > 0 && d <= 15 {
+		t.buf = append(t.buf, byte(d)<<4|typ)
+	} else {
+		t.buf = append(t.buf, typ)
+		t.buf = binary.AppendVarint(t.buf, int64(id))
+	}
+	*last = id
+}
+
+func (t *thriftWriter) i32(id int16, v int32) {
+	t.field(id, thriftI32)
+	t.elemI32(v)
+}
+
+func (t *thriftWriter) i64(id int16, v int64) {
+	t.field(id, thriftI64)
+	t.buf = binary.AppendVarint(t.buf, v)
+}
+
+func (t *thriftWriter) binary(id int16, s string) {
+	t.field(id, thriftBinary)
+	t.elemBinary(s)
+}
+
+// list writes the header of a list of n elements, which must be written next.
+func (t *thriftWriter) list(id int16, elemType byte, n int) {
+	t.field(id, thriftList)
+	if n < 15 {
+		t.buf = append(t.buf, byte(n)<<4|elemType)
+	} else {
+		t.buf = append(t.buf, 0xF0|elemType)
+		t.buf = binary.AppendUvarint(t.buf, uint64(n))
+	}
+}
+
+func (t *thriftWriter) elemI32(v int32) {
+	t.buf = binary.AppendVarint(t.buf, int64(v))
+}
+
+func (t *thriftWriter) elemBinary(s string) {
+	t.buf = binary.AppendUvarint(t.buf, uint64(len(s)))
+	t.buf = append(t.buf, s...)
+}
@@ -0,0 +1,243 @@
+package shell
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/chaisql/chai"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/stretchr/testify/require"
+)
+
+// thriftReader decodes structures of the Thrift compact protocol
+// as maps of field ids to values.
+type thriftReader struct {
+	b []byte
+}
+
+func (r *thriftReader) byte() byte {
+	b := r.b[0]
+	r.b = r.b[1:]
+	return b
+}
+
+func (r *thriftReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.b)
+	r.b = r.b[n:]
+	return v
+}
+
+func (r *thriftReader) varint() int64 {
+	v, n := binary.Varint(r.b)
+	r.b = r.b[n:]
+	return v
+}
+
+func (r *thriftReader) value(typ byte) any {
+	switch typ {
+	case 1:
+		return true
+	case 2:
+		return false
+	case thriftI32, thriftI64:
+		return r.varint()
+	case thriftBinary:
+		n := r.uvarint()
+		v := r.b[:n]
+		r.b = r.b[n:]
+		return string(v)
+	case thriftList:
+		h := r.byte()
+		n := uint64(h >> 4)
+		if n == 15 {
+			n = r.uvarint()
+		}
+		l := make([]any, n)
+		for i := range l {
+			l[i] = r.value(h & 0x0f)
+		}
+		return l
+	case thriftStruct:
+		m := make(map[int16]any)
+		var last int16
+		for {
+			h := r.byte()
+			if h == 0 {
+				return m
+			}
+			if d := h >> 4; d != 0 {
+				last += int16(d)
+			} else {
+				last = int16(r.varint())
+			}
+			m[last] = r.value(h & 0x0f)
+		}
+	}
+
+	panic("unexpected thrift type")
+}
+
+func TestExportParquet(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE test (a INT, b DOUBLE, c TEXT, d BOOL, e TIMESTAMP, f BLOB, g BIGINT);
+		INSERT INTO test VALUES
+			(1, 1.5, 'foo', true, '2024-01-02 03:04:05', '\xAF01', 10),
+			(2, NULL, NULL, NULL, NULL, NULL, NULL),
+			(3, -2.5, 'bar', false, '2024-01-03', '\x02', 30);
+	`)
+	require.NoError(t, err)
+
+	r, err := db.QueryRow(`SELECT e FROM test WHERE a = 1`)
+	require.NoError(t, err)
+	var ts time.Time
+	require.NoError(t, r.Scan(&ts))
+
+	fp := filepath.Join(t.TempDir(), "out.parquet")
+	sh := Shell{db: db, mode: defaultMode}
+	var out bytes.Buffer
+	err = sh.runCommand(context.Background(), `.export parquet '`+fp+`' AS SELECT a, b, c, d, e, f, g, a + b AS h FROM test`, &out)
+	require.NoError(t, err)
+	require.Equal(t, "3 rows exported\n", out.String())
+
+	data, err := os.ReadFile(fp)
+	require.NoError(t, err)
+	require.Equal(t, "PAR1", string(data[:4]))
+	require.Equal(t, "PAR1", string(data[len(data)-4:]))
+	size := binary.LittleEndian.Uint32(data[len(data)-8:])
+	footer := data[len(data)-8-int(size) : len(data)-8]
+
+	tr := thriftReader{b: footer}
+	meta := tr.value(thriftStruct).(map[int16]any)
+	require.Empty(t, tr.b)
+	require.EqualValues(t, 3, meta[3])
+
+	// the root of the schema, followed by the columns
+	schema := meta[2].([]any)
+	require.Len(t, schema, 9)
+	require.EqualValues(t, 8, schema[0].(map[int16]any)[5])
+
+	type column struct {
+		name      string
+		typ       int64
+		converted any
+	}
+	var columns []column
+	for _, s := range schema[1:] {
+		m := s.(map[int16]any)
+		require.EqualValues(t, parquetOptional, m[3])
+		columns = append(columns, column{name: m[4].(string), typ: m[1].(int64), converted: m[6]})
+	}
+	require.Equal(t, []column{
+		{"a", parquetInt32, nil},
+		{"b", parquetDouble, nil},
+		{"c", parquetByteArray, int64(parquetConvertedUTF8)},
+		{"d", parquetBoolean, nil},
+		{"e", parquetInt64, int64(parquetConvertedTimestampMicros)},
+		{"f", parquetByteArray, nil},
+		{"g", parquetInt64, nil},
+		{"h", parquetDouble, nil},
+	}, columns)
+
+	rowGroups := meta[4].([]any)
+	require.Len(t, rowGroups, 1)
+	rg := rowGroups[0].(map[int16]any)
+	require.EqualValues(t, 3, rg[3])
+	chunks := rg[1].([]any)
+	require.Len(t, chunks, 8)
+
+	// decode the values of each column, nil for NULL values
+	var got [][]any
+	for i, c := range chunks {
+		md := c.(map[int16]any)[3].(map[int16]any)
+		require.Equal(t, []any{columns[i].name}, md[3])
+		require.EqualValues(t, 3, md[5])
+
+		off := md[9].(int64)
+		tr := thriftReader{b: data[off : off+md[7].(int64)]}
+		header := tr.value(thriftStruct).(map[int16]any)
+		require.EqualValues(t, parquetDataPage, header[1])
+		require.EqualValues(t, len(tr.b), header[3])
+		require.EqualValues(t, 3, header[5].(map[int16]any)[1])
+
+		// definition levels, as a single bit-packed run
+		page := tr.b
+		n := binary.LittleEndian.Uint32(page)
+		levels := page[4 : 4+n]
+		page = page[4+n:]
+		h, k := binary.Uvarint(levels)
+		require.EqualValues(t, 1<<1|1, h)
+		levels = levels[k:]
+
+		var values []any
+		var bit int
+		for j := 0; j < 3; j++ {
+			if levels[j/8]&(1<<(j%8)) == 0 {
+				values = append(values, nil)
+				continue
+			}
+
+			switch columns[i].typ {
+			case parquetBoolean:
+				values = append(values, page[0]&(1<<bit) != 0)
+				bit++
+			case parquetInt32:
+				values = append(values, int32(binary.LittleEndian.Uint32(page)))
+				page = page[4:]
+			case parquetInt64:
+				values = append(values, int64(binary.LittleEndian.Uint64(page)))
+				page = page[8:]
+			case parquetDouble:
+				values = append(values, math.Float64frombits(binary.LittleEndian.Uint64(page)))
+				page = page[8:]
+			case parquetByteArray:
+				l := binary.LittleEndian.Uint32(page)
+				values = append(values, string(page[4:4+l]))
+				page = page[4+l:]
+			}
+		}
+		got = append(got, values)
+	}
+
+	require.Equal(t, [][]any{
+		{int32(1), int32(2), int32(3)},
+		{1.5, nil, -2.5},
+		{"foo", nil, "bar"},
+		{true, nil, false},
+		{ts.UnixMicro(), nil, time.Date(2024, 1, 3, 0, 0, 0, 0, ts.Location()).UnixMicro()},
+		{"\xaf\x01", nil, "\x02"},
+		{int64(10), nil, int64(30)},
+		{2.5, nil, 0.5},
+	}, got)
+}
+
+func TestParquetColumnType(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []types.Value
+		want   types.Type
+	}{
+		{"null", []types.Value{types.NewNullValue()}, types.TypeText},
+		{"integer", []types.Value{types.NewNullValue(), types.NewIntegerValue(1)}, types.TypeInteger},
+		{"integers", []types.Value{types.NewIntegerValue(1), types.NewBigintValue(2)}, types.TypeBigint},
+		{"numbers", []types.Value{types.NewIntegerValue(1), types.NewRealValue(2)}, types.TypeDouble},
+		{"unsigned", []types.Value{types.NewUnsignedBigintValue(1), types.NewDoubleValue(2)}, types.TypeText},
+		{"timestamps", []types.Value{types.NewTimestampValue(time.Now()), types.NewTimestampTZValue(time.Now())}, types.TypeTimestampTZ},
+		{"mixed", []types.Value{types.NewIntegerValue(1), types.NewTextValue("a")}, types.TypeText},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.want, parquetColumnType(test.values))
+		})
+	}
+}
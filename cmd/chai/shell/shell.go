@@ -20,6 +20,7 @@ import (
 
 	"github.com/chaisql/chai"
 	"github.com/chaisql/chai/cmd/chai/dbutil"
+	"github.com/chaisql/chai/internal/row"
 )
 
 const (
@@ -40,6 +41,9 @@ type Shell struct {
 
 	displayTime bool
 
+	// output mode of the results of the queries, see outputModes.
+	mode string
+
 	history []string
 
 	// context used for execution cancellation,
@@ -55,6 +59,12 @@ type Options struct {
 	// Path of the database directory that will be created.
 	// If empty, the database will be in-memory.
 	DBPath string
+	// If true, the database is opened in read-only mode.
+	// It must already exist.
+	ReadOnly bool
+	// Output mode of the results of the queries, "table" by default.
+	// It can be changed with the .mode command.
+	Mode string
 }
 
 type queryTask struct {
@@ -72,8 +82,15 @@ func Run(ctx context.Context, opts *Options) error {
 	var sh Shell
 
 	sh.opts = opts
+	sh.mode = opts.Mode
+	if sh.mode == "" {
+		sh.mode = defaultMode
+	}
+	if err := checkMode(sh.mode); err != nil {
+		return err
+	}
 
-	db, err := dbutil.OpenDB(ctx, sh.opts.DBPath)
+	db, err := dbutil.OpenDBWith(ctx, sh.opts.DBPath, &chai.Options{ReadOnly: opts.ReadOnly})
 	if err != nil {
 		return err
 	}
@@ -87,6 +104,8 @@ func Run(ctx context.Context, opts *Options) error {
 
 	if opts.DBPath == "" {
 		fmt.Println("Opened an in-memory database.")
+	} else if opts.ReadOnly {
+		fmt.Printf("Opened an on-disk database in read-only mode at path %s.\n", opts.DBPath)
 	} else {
 		// check if the directory exists
 		if _, err := os.Stat(opts.DBPath); os.IsNotExist(err) {
@@ -167,8 +186,9 @@ func (sh *Shell) runExecutor(ctx context.Context, promptExecCh chan queryTask) e
 				continue
 			}
 
-			// if showtime is true, ensure it's a query, and it was executed.
-			if displayTime {
+			// the statistics of queries are displayed after each statement,
+			// commands only display their execution time.
+			if displayTime && strings.HasPrefix(input.q, ".") {
 				fmt.Fprintf(input.w, "Time: %s\n", time.Since(start))
 			}
 
@@ -316,6 +336,21 @@ func (sh *Shell) runCommand(ctx context.Context, in string, out io.Writer) error
 		return nil
 	case ".help":
 		return runHelpCmd(out)
+	case ".mode":
+		if len(cmd) > 2 {
+			return fmt.Errorf(getUsage(".mode"))
+		}
+
+		if len(cmd) == 1 {
+			_, err := fmt.Fprintf(out, "%s (available: %s)\n", sh.mode, strings.Join(listModes(), ", "))
+			return err
+		}
+
+		if err := checkMode(cmd[1]); err != nil {
+			return err
+		}
+		sh.mode = cmd[1]
+		return nil
 	case ".tables":
 		if len(cmd) > 1 {
 			return fmt.Errorf(getUsage(".tables"))
@@ -333,6 +368,13 @@ func (sh *Shell) runCommand(ctx context.Context, in string, out io.Writer) error
 		}
 
 		return runIndexesCmd(sh.db, tableName, out)
+	case ".export":
+		format, path, query, err := parseExportCmd(in)
+		if err != nil {
+			return err
+		}
+
+		return runExportCmd(ctx, sh.db, format, path, query, out)
 	case ".dump":
 		return dbutil.Dump(sh.db, out, cmd[1:]...)
 	case ".save":
@@ -343,11 +385,23 @@ func (sh *Shell) runCommand(ctx context.Context, in string, out io.Writer) error
 	case ".schema":
 		return dbutil.DumpSchema(sh.db, out, cmd[1:]...)
 	case ".import":
-		if len(cmd) != 4 {
+		if len(cmd) < 4 {
 			return fmt.Errorf(getUsage(".import"))
 		}
 
-		return runImportCmd(sh.db, cmd[1], cmd[2], cmd[3])
+		switch strings.ToLower(cmd[1]) {
+		case "ndjson", "jsonl":
+			flatten := len(cmd) == 5 && cmd[4] == "--flatten"
+			if len(cmd) > 5 || (len(cmd) == 5 && !flatten) {
+				return fmt.Errorf(getUsage(".import"))
+			}
+
+			return runImportNDJSONCmd(ctx, sh.db, cmd[2], cmd[3], flatten, out)
+		case "csv":
+			return runImportCSVCmd(ctx, sh.db, cmd[2], cmd[3], cmd[4:], out)
+		}
+
+		return errors.New("TYPE should be csv, ndjson or jsonl")
 	case ".restore":
 		if len(cmd) != 2 {
 			return fmt.Errorf(getUsage(".restore"))
@@ -359,7 +413,14 @@ func (sh *Shell) runCommand(ctx context.Context, in string, out io.Writer) error
 }
 
 func (sh *Shell) runQuery(ctx context.Context, q string, out io.Writer) error {
-	err := dbutil.ExecSQL(ctx, sh.db, strings.NewReader(q), out)
+	var stats func(*dbutil.StatementStats)
+	if sh.displayTime {
+		stats = func(st *dbutil.StatementStats) {
+			writeStats(out, st)
+		}
+	}
+
+	err := execSQL(ctx, sh.db, strings.NewReader(q), out, sh.mode, stats)
 	if errors.Is(err, context.Canceled) {
 		return errors.New("interrupted")
 	}
@@ -367,6 +428,60 @@ func (sh *Shell) runQuery(ctx context.Context, q string, out io.Writer) error {
 	return err
 }
 
+// ExecSQL reads SQL queries from r and executes them until r is exhausted.
+// The rows returned by the queries are written to w in the given output mode,
+// one of "table", "json", "ndjson", "csv" or "markdown".
+func ExecSQL(ctx context.Context, db *chai.DB, r io.Reader, w io.Writer, mode string) error {
+	return execSQL(ctx, db, r, w, mode, nil)
+}
+
+// execSQL is like ExecSQL, and calls stats, if not nil, after every statement.
+func execSQL(ctx context.Context, db *chai.DB, r io.Reader, w io.Writer, mode string, stats func(*dbutil.StatementStats)) error {
+	if err := checkMode(mode); err != nil {
+		return err
+	}
+
+	return dbutil.ExecSQLStats(ctx, db, r, func(columns []string, iterate func(fn func(r row.Row) error) error) error {
+		rw, err := newResultWriter(mode, w, columns)
+		if err != nil {
+			return err
+		}
+
+		err = iterate(rw.WriteRow)
+		if err != nil {
+			return err
+		}
+
+		return rw.Close()
+	}, stats)
+}
+
+// writeStats displays the execution time of a statement, the number of rows
+// it returned or modified, and a summary of its plan.
+func writeStats(w io.Writer, st *dbutil.StatementStats) {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Time: %s", st.Duration)
+	if st.ReturnsRows {
+		fmt.Fprintf(&sb, ", %s returned", pluralRows(st.RowsReturned))
+	}
+	if st.ModifiesRows && st.RowsAffected >= 0 {
+		fmt.Fprintf(&sb, ", %s affected", pluralRows(st.RowsAffected))
+	}
+	sb.WriteByte('\n')
+	if st.Plan != "" {
+		fmt.Fprintf(&sb, "Plan: %s\n", st.Plan)
+	}
+
+	_, _ = io.WriteString(w, sb.String())
+}
+
+func pluralRows(n int64) string {
+	if n == 1 {
+		return "1 row"
+	}
+	return fmt.Sprintf("%d rows", n)
+}
+
 func shouldDisplaySuggestion(name, in string) bool {
 	// input should be at least half the command size to get a suggestion.
 	d := levenshtein.ComputeDistance(name, in)
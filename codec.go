@@ -0,0 +1,57 @@
+package chai
+
+import (
+	"reflect"
+
+	"github.com/chaisql/chai/internal/row"
+)
+
+// TypeCodec converts the values of the Go type T to and from the values of the database.
+// See RegisterType.
+type TypeCodec[T any] struct {
+	// Encode converts a value of type T to one of the types accepted as
+	// query parameters, such as string, []byte, int64, float64, bool or time.Time.
+	// The type of the returned value determines the type stored in the database.
+	Encode func(v T) (any, error)
+
+	// Decode converts a value read from the database to a value of type T.
+	// Like with sql.Scanner, src is a bool, int64, float64, time.Time, string or []byte,
+	// depending on the type of the column. Decode is not called for NULL values,
+	// which are scanned as the zero value of T, or nil for pointers.
+	// Byte slices must be copied to be retained.
+	Decode func(src any) (T, error)
+}
+
+// RegisterType registers the codec used to pass the values of type T
+// as query parameters, to store them in the fields of structs, and to scan them
+// from the results of queries. It applies to every database, including the ones
+// opened with the database/sql driver, and replaces the previous codec of T.
+// Registered codecs take precedence over the standard interfaces, such as
+// driver.Valuer or sql.Scanner, and over the built-in conversions.
+// It is meant to be called during initialization, for example to store
+// the values of a decimal type as text:
+//
+//	chai.RegisterType(chai.TypeCodec[decimal.Decimal]{
+//		Encode: func(d decimal.Decimal) (any, error) { return d.String(), nil },
+//		Decode: func(src any) (decimal.Decimal, error) {
+//			s, ok := src.(string)
+//			if !ok {
+//				return decimal.Decimal{}, fmt.Errorf("cannot decode %T as a decimal", src)
+//			}
+//			return decimal.NewFromString(s)
+//		},
+//	})
+func RegisterType[T any](c TypeCodec[T]) {
+	if c.Encode == nil || c.Decode == nil {
+		panic("chai: RegisterType requires an encoder and a decoder")
+	}
+
+	row.RegisterCodec(reflect.TypeFor[T](), row.Codec{
+		Encode: func(x any) (any, error) {
+			return c.Encode(x.(T))
+		},
+		Decode: func(src any) (any, error) {
+			return c.Decode(src)
+		},
+	})
+}
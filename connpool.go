@@ -0,0 +1,111 @@
+package chai
+
+import (
+	"context"
+	"sync"
+
+	"github.com/chaisql/chai/internal/database"
+	"github.com/cockroachdb/errors"
+)
+
+// defaultMaxIdleConns is the default value of Options.MaxIdleConns.
+const defaultMaxIdleConns = 8
+
+// connPool keeps the connections used by the query methods of DB once they
+// are done, to reuse them, and limits the number of them in use at the same time.
+type connPool struct {
+	mu      sync.Mutex
+	idle    []*database.Connection
+	maxIdle int
+	closed  bool
+	// one token per connection in use, nil if there is no limit
+	tokens chan struct{}
+}
+
+func newConnPool(maxIdle, maxOpen int) *connPool {
+	if maxIdle == 0 {
+		maxIdle = defaultMaxIdleConns
+	}
+
+	p := connPool{maxIdle: max(maxIdle, 0)}
+	if maxOpen > 0 {
+		p.tokens = make(chan struct{}, maxOpen)
+	}
+
+	return &p
+}
+
+// get returns an idle connection or a new one. If the number of connections
+// in use is limited, it waits until one is released or the context is done.
+func (p *connPool) get(ctx context.Context, db *database.Database) (*database.Connection, error) {
+	if p.tokens != nil {
+		if ctx == nil {
+			ctx = context.Background()
+		}
+
+		select {
+		case p.tokens <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-db.Done():
+			return nil, errors.New("database is closed")
+		}
+	}
+
+	p.mu.Lock()
+	if n := len(p.idle); n > 0 {
+		c := p.idle[n-1]
+		p.idle = p.idle[:n-1]
+		p.mu.Unlock()
+		return c, nil
+	}
+	p.mu.Unlock()
+
+	c, err := db.Connect()
+	if err != nil {
+		p.release()
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// put gives back a connection returned by get. It is closed instead of being kept
+// if the pool is full or closed, or if a transaction is still attached to it,
+// which rolls the transaction back.
+func (p *connPool) put(c *database.Connection) error {
+	defer p.release()
+
+	if c.GetTx() != nil {
+		return c.Close()
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed || len(p.idle) >= p.maxIdle || c.ResetSession() != nil {
+		return c.Close()
+	}
+
+	p.idle = append(p.idle, c)
+	return nil
+}
+
+func (p *connPool) release() {
+	if p.tokens != nil {
+		<-p.tokens
+	}
+}
+
+// close closes the idle connections, which must be done
+// before closing the database, and the connections put afterwards.
+func (p *connPool) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.closed = true
+	for _, c := range p.idle {
+		_ = c.Close()
+	}
+	p.idle = nil
+}
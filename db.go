@@ -10,11 +10,18 @@ import (
 	"database/sql"
 	"database/sql/driver"
 	"io"
+	"math/rand/v2"
+	"sync/atomic"
+	"time"
 
 	"github.com/chaisql/chai/internal/database"
 	"github.com/chaisql/chai/internal/database/catalogstore"
+	"github.com/chaisql/chai/internal/engine/memory"
 	"github.com/chaisql/chai/internal/environment"
 	errs "github.com/chaisql/chai/internal/errors"
+	"github.com/chaisql/chai/internal/kv"
+	"github.com/chaisql/chai/internal/kv/encryption"
+	"github.com/chaisql/chai/internal/pkg/backoff"
 	"github.com/chaisql/chai/internal/query"
 	"github.com/chaisql/chai/internal/query/statement"
 	"github.com/chaisql/chai/internal/row"
@@ -22,30 +29,757 @@ import (
 	"github.com/chaisql/chai/internal/stream"
 	"github.com/chaisql/chai/internal/types"
 	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/pebble"
+	"github.com/cockroachdb/pebble/objstorage/remote"
 )
 
 // DB represents a collection of tables.
+// It is safe for concurrent use by multiple goroutines: each query run by its
+// methods uses a connection of its own, taken from a pool, and write transactions
+// are run one at a time, unless Options.ConcurrentWrites is set.
+// Connections and transactions, on the other hand, must not be shared.
 type DB struct {
 	DB  *database.Database
 	ctx context.Context
+	// cache of the prepared queries, nil if disabled.
+	stmtCache *statementCache
+	// logger set by SetQueryLogger, shared by the copies of the DB.
+	queryLogger *atomic.Pointer[QueryLogger]
+	// counters of the queries reported by Metrics.
+	metrics *queryMetrics
+	// connections used by the query methods.
+	pool *connPool
 }
 
-// Open creates a Chai database at the given path.
+// Open creates a Chai database at the given path, with the default options.
 // If path is equal to ":memory:" it will open an in-memory database,
-// otherwise it will create an on-disk database.
+// otherwise it will create an on-disk database. See OpenWith to configure it.
 func Open(path string) (*DB, error) {
-	db, err := database.Open(path, &database.Options{
+	return OpenWith(path, nil)
+}
+
+// OpenReadOnly opens an existing on-disk database in read-only mode.
+// The database is not locked, which allows inspecting a database used by another
+// process, for example from a monitoring tool. The database sees the data committed
+// before it was opened: it must be reopened to see the latest changes.
+// Queries that modify the database or its schema fail with ErrReadOnly
+// when they are prepared.
+func OpenReadOnly(path string) (*DB, error) {
+	return OpenWith(path, &Options{ReadOnly: true})
+}
+
+// Options configure how a database is opened.
+type Options struct {
+	// Engine selects where the data is stored. By default, databases
+	// are stored in memory if the path is ":memory:", and on disk otherwise.
+	Engine Engine
+
+	// QueryLogger, if set, is the logger of the queries. See DB.SetQueryLogger.
+	QueryLogger *QueryLogger
+
+	// Encryption enables encryption at rest: every file of the database,
+	// including the write-ahead log, is encrypted with AES-GCM.
+	// It is ignored by in-memory databases.
+	Encryption *EncryptionOptions
+
+	// Compression algorithm used for the data blocks stored on disk.
+	// Defaults to Snappy. It is ignored by in-memory databases.
+	Compression Compression
+	// Compression algorithm used for each level of the storage, starting
+	// from the level holding the most recent data. Levels beyond the end of
+	// the slice use the last element. Overrides Compression for the given levels.
+	// A common setting is to use a fast compression for the first levels
+	// and a strong one for the last, larger, levels:
+	//
+	//	LevelCompression: []Compression{SnappyCompression, SnappyCompression, ZstdCompression}
+	LevelCompression []Compression
+
+	// TrackChanges records the keys modified by every transaction,
+	// which is required to create incremental backups.
+	// It adds a small overhead to every write transaction.
+	// Opening the database with TrackChanges disabled discards
+	// the changes tracked so far: a new full backup is then required
+	// before creating incremental backups again.
+	TrackChanges bool
+
+	// WALArchive enables WAL archiving: instead of being deleted, the files of
+	// the write-ahead log are uploaded to this storage once they are no longer needed.
+	// Combined with a checkpoint created with DB.Checkpoint, they allow
+	// restoring the database at any point in time with RestorePointInTime.
+	// It cannot be used with encryption.
+	WALArchive ObjectStorage
+	// Prefix of the names of the archived WAL files.
+	WALArchivePrefix string
+
+	// ConcurrentWrites allows write transactions to run concurrently instead of
+	// one at a time. Conflicts are detected when committing: a transaction that
+	// modified a row, or the schema, also modified by a transaction committed after
+	// it began fails with ErrWriteConflict and can be retried.
+	// Transactions using sequences, including inserts into tables without
+	// a primary key, are still run one at a time.
+	// Each write transaction is kept in memory until it is committed.
+	ConcurrentWrites bool
+
+	// Sync controls when committed transactions are written durably to disk.
+	// Defaults to SyncAlways. It is ignored by in-memory databases.
+	Sync SyncMode
+	// Interval between two syncs with SyncPeriodic. Defaults to 100ms.
+	SyncInterval time.Duration
+	// WALDir stores the write-ahead log in a different directory than the data,
+	// for example on a faster disk. It must be used every time the database is opened.
+	WALDir string
+	// WALBytesPerSync, if non-zero, syncs the write-ahead log in the background
+	// every time this number of bytes is written to it, which avoids long
+	// syncs with SyncPeriodic and SyncNever.
+	WALBytesPerSync int
+
+	// CaptureChanges records the rows modified by every committed transaction,
+	// with their values before and after the change. They can be read in commit
+	// order with DB.Changes, for example to invalidate caches or to synchronize
+	// another system. The change log grows until it is purged with DB.PurgeChanges.
+	CaptureChanges bool
+
+	// ReadOnly opens an existing on-disk database in read-only mode. See OpenReadOnly.
+	ReadOnly bool
+
+	// OverflowThreshold is the size in bytes above which BLOB values are stored
+	// outside of their row, in chunks that can be streamed with Tx.OpenBlob and
+	// Tx.WriteBlob. Defaults to 64KiB.
+	OverflowThreshold int
+
+	// TempDir is the directory in which intermediate results too large to be
+	// kept in memory, such as the rows of a large ORDER BY or UNION, are stored
+	// until the end of the query. Defaults to the temporary directory of the OS.
+	TempDir string
+	// SpillThreshold is the size in bytes above which an intermediate result
+	// is moved from memory to TempDir. Defaults to 4MiB.
+	SpillThreshold int
+
+	// The following options tune the storage engine. They are ignored by
+	// in-memory databases. Their zero value means the default of the engine.
+
+	// CacheSize is the size in bytes of the cache of the data read from disk.
+	// Defaults to 8MiB.
+	CacheSize int64
+	// MemTableSize is the size in bytes of the buffers holding the latest writes
+	// in memory before they are flushed to disk. Defaults to 4MiB.
+	MemTableSize uint64
+	// MaxOpenFiles is the maximum number of files kept open. Defaults to 1000.
+	MaxOpenFiles int
+	// L0CompactionThreshold is the number of files of the first level of the storage,
+	// which contains the data flushed from memory, above which they are compacted.
+	// Defaults to 4.
+	L0CompactionThreshold int
+	// L0StopWritesThreshold is the number of files of the first level of the storage
+	// above which writes are stopped until it is compacted. Defaults to 12,
+	// or to L0CompactionThreshold if it is greater.
+	L0StopWritesThreshold int
+	// TargetFileSize is the target size in bytes of the files of the first level
+	// of the storage. The files of each following level are twice as large.
+	// Defaults to 2MiB.
+	TargetFileSize int64
+	// MaxConcurrentCompactions is the maximum number of compactions
+	// running in the background at the same time. Defaults to 1.
+	MaxConcurrentCompactions int
+
+	// Locations maps the names of storage locations to directories, possibly
+	// on other disks, in which tables can be stored instead of the directory
+	// of the database, with CREATE TABLE ... WITH (location = 'name').
+	// The indexes of a table are stored in the same location.
+	// Every location used by a table must be provided each time the database
+	// is opened. Locations cannot be used with concurrent writes or WAL
+	// archiving, and databases using them cannot be backed up or exported.
+	Locations map[string]string
+
+	// LockWait controls how long to wait when the database is locked by another
+	// process, and when a write transaction is begun while another one is running.
+	// The lock is retried with an exponential backoff until the timeout expires,
+	// in which case ErrLockTimeout is returned.
+	// By default, opening a locked database fails immediately and write
+	// transactions wait for as long as needed.
+	LockWait LockWaitPolicy
+
+	// CrashHook, if set, is called every time the engine writes to disk
+	// at a point where a crash can be simulated, and returns whether it must
+	// crash before or after the write. After a simulated crash, every write
+	// fails with ErrCrashed: the database must be closed and reopened, which
+	// recovers it as after a real crash. Crashing after CrashPointFlush leaves
+	// a partially written transaction on disk.
+	// This is meant to test durability, see VerifyRecovery.
+	// It is ignored by in-memory databases.
+	CrashHook func(p CrashPoint) CrashMode
+
+	// TimeZone is the default time zone of the connections. TIMESTAMPTZ values
+	// are stored as UTC instants: the time zone of a connection is used to parse
+	// texts without time zone and to display the values it reads.
+	// It can be changed for a connection with SET TIME ZONE or
+	// Connection.SetTimeZone. Defaults to UTC.
+	TimeZone *time.Location
+
+	// Coercion is the default coercion mode of the connections, which controls
+	// how values of different types are compared, such as an integer and a text.
+	// It can be changed for a connection with SET COERCION or
+	// Connection.SetCoercion. Defaults to CoercionLenient.
+	Coercion Coercion
+
+	// RowMigrationInterval, if set, upgrades the rows written by older versions
+	// of the database to the current row format in the background, with a job
+	// run at this interval until every row is upgraded. Rows are always upgraded
+	// when they are written, and can be upgraded at once with DB.MigrateRows.
+	RowMigrationInterval time.Duration
+
+	// StatementCacheSize is the number of prepared queries kept in memory, by text,
+	// so that running the same query again doesn't parse and prepare it again.
+	// The cache is cleared every time a change of the schema is committed.
+	// Defaults to 1000. A negative value disables the cache.
+	StatementCacheSize int
+
+	// MaxIdleConns is the number of connections kept by the query methods of DB,
+	// such as Exec and QueryRow, to be reused by the following queries.
+	// Their settings, such as their time zone, are reset when they are reused.
+	// Defaults to 8. A negative value disables the reuse of connections.
+	MaxIdleConns int
+	// MaxOpenConns, if positive, limits the number of queries run at the same time
+	// by the query methods of DB, which wait for one of them to end otherwise.
+	// It doesn't limit the connections created by DB.Connect.
+	MaxOpenConns int
+}
+
+// Coercion controls how values of different types are compared.
+// See the modes below for the rules applied in each mode.
+type Coercion = types.Coercion
+
+// Coercion modes.
+const (
+	// CoercionLenient converts the values compared with one another:
+	// a literal compared with a column is converted to the type of the column,
+	// and a text compared with a boolean, a number, a timestamp, a UUID,
+	// a network address or a point is converted to the type of the other value.
+	// Values that can't be converted are neither equal nor ordered.
+	CoercionLenient = types.CoercionLenient
+	// CoercionStrict rejects the comparisons of values of different types,
+	// when the query is planned if the types are known, and otherwise when it
+	// runs. Only string literals are converted to the type they are compared with.
+	CoercionStrict = types.CoercionStrict
+)
+
+// CrashPoint identifies a write of the engine at which a crash can be simulated.
+type CrashPoint = kv.CrashPoint
+
+// Points at which a crash can be simulated.
+const (
+	// CrashPointFlush is the write of the changes of a transaction too large
+	// to be kept in memory, before the transaction is committed.
+	CrashPointFlush = kv.CrashPointFlush
+	// CrashPointPrepare is the write of the changes of a transaction
+	// to a storage location, before the transaction is committed.
+	CrashPointPrepare = kv.CrashPointPrepare
+	// CrashPointCommit is the commit of a transaction.
+	CrashPointCommit = kv.CrashPointCommit
+	// CrashPointRollback is the write undoing the changes of a transaction
+	// that was rolled back.
+	CrashPointRollback = kv.CrashPointRollback
+)
+
+// CrashMode tells how a simulated crash affects the write at which it happens.
+type CrashMode = kv.CrashMode
+
+// Crash modes returned by Options.CrashHook.
+const (
+	// NoCrash performs the write normally.
+	NoCrash = kv.NoCrash
+	// CrashBefore crashes before the write: none of it is stored.
+	CrashBefore = kv.CrashBefore
+	// CrashAfter crashes once the write is durably stored.
+	CrashAfter = kv.CrashAfter
+)
+
+// LockWaitPolicy configures how long to wait for a lock.
+// The lock is tried again after MinDelay, then after a delay doubling
+// each time, up to MaxDelay, until Timeout expires.
+// MinDelay defaults to 1ms and MaxDelay to 100ms.
+type LockWaitPolicy = backoff.Policy
+
+// SyncMode controls when committed transactions are synced to disk.
+type SyncMode uint8
+
+// Supported sync modes.
+const (
+	// SyncAlways syncs every transaction before Commit returns:
+	// committed transactions survive crashes of the machine.
+	SyncAlways SyncMode = iota
+	// SyncPeriodic syncs the committed transactions in the background
+	// every SyncInterval. Transactions committed since the last sync
+	// are lost if the machine crashes, but not if the process crashes.
+	SyncPeriodic
+	// SyncNever lets the operating system decide when to write
+	// the committed transactions to disk. It offers the best throughput,
+	// but any number of transactions can be lost if the machine crashes.
+	SyncNever
+)
+
+func (m SyncMode) toEngine() kv.SyncMode {
+	switch m {
+	case SyncPeriodic:
+		return kv.SyncPeriodic
+	case SyncNever:
+		return kv.SyncNever
+	}
+
+	return kv.SyncAlways
+}
+
+// Compression algorithm used to compress data blocks.
+type Compression uint8
+
+// Supported compression algorithms.
+const (
+	DefaultCompression Compression = iota
+	NoCompression
+	SnappyCompression
+	ZstdCompression
+)
+
+func (c Compression) toEngine() pebble.Compression {
+	switch c {
+	case NoCompression:
+		return pebble.NoCompression
+	case SnappyCompression:
+		return pebble.SnappyCompression
+	case ZstdCompression:
+		return pebble.ZstdCompression
+	}
+
+	return pebble.DefaultCompression
+}
+
+// EncryptionOptions configure the encryption of the database files.
+// Each file records the ID of the key used to encrypt it. To rotate keys,
+// open the database with a new KeyID and a Key function that still knows
+// the previous keys: new files are encrypted with the new key and
+// Reencrypt can be used to rewrite the old ones.
+type EncryptionOptions struct {
+	// ID of the key used to encrypt new files.
+	KeyID uint32
+	// Key returns the AES key (16, 24 or 32 bytes) associated with the given ID.
+	// It is called once per key ID and can be used to fetch keys from a KMS.
+	Key func(id uint32) ([]byte, error)
+}
+
+func (o *EncryptionOptions) toEngine() *encryption.Options {
+	if o == nil {
+		return nil
+	}
+
+	return &encryption.Options{
+		KeyID: o.KeyID,
+		Key:   o.Key,
+	}
+}
+
+// OpenWith creates a Chai database at the given path, configured with opts.
+// If opts is nil, it behaves like Open.
+func OpenWith(path string, opts *Options) (*DB, error) {
+	if opts == nil {
+		opts = new(Options)
+	}
+
+	switch opts.Engine {
+	case EngineMemory:
+		path = ":memory:"
+	case EngineDisk:
+		if path == ":memory:" {
+			return nil, errors.New("an on-disk database requires a path")
+		}
+	}
+
+	db, err := database.Open(path, opts.toDatabase())
+	if err != nil {
+		return nil, err
+	}
+
+	logger := new(atomic.Pointer[QueryLogger])
+	logger.Store(opts.QueryLogger)
+
+	return &DB{
+		DB:          db,
+		stmtCache:   newStatementCache(opts.StatementCacheSize),
+		queryLogger: logger,
+		metrics:     new(queryMetrics),
+		pool:        newConnPool(opts.MaxIdleConns, opts.MaxOpenConns),
+	}, nil
+}
+
+// Engine selects where the data of a database is stored.
+type Engine int
+
+// Engines.
+const (
+	// EngineDefault stores the data in memory if the path is ":memory:",
+	// and on disk otherwise.
+	EngineDefault Engine = iota
+	// EngineDisk stores the data on disk, in the directory of the path.
+	EngineDisk
+	// EngineMemory stores the data in memory, it is lost when the database
+	// is closed. The path is ignored and the options of the on-disk storage,
+	// such as encryption, have no effect.
+	EngineMemory
+)
+
+func (opts *Options) toDatabase() *database.Options {
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	var levels []pebble.Compression
+	for _, c := range opts.LevelCompression {
+		levels = append(levels, c.toEngine())
+	}
+
+	return &database.Options{
+		CatalogLoader: catalogstore.LoadCatalog,
+		EngineOptions: kv.Options{
+			Encryption:               opts.Encryption.toEngine(),
+			Compression:              opts.Compression.toEngine(),
+			LevelCompression:         levels,
+			WALArchive:               opts.WALArchive,
+			WALArchivePrefix:         opts.WALArchivePrefix,
+			SyncMode:                 opts.Sync.toEngine(),
+			SyncInterval:             opts.SyncInterval,
+			WALDir:                   opts.WALDir,
+			WALBytesPerSync:          opts.WALBytesPerSync,
+			TempDir:                  opts.TempDir,
+			TransientSpillThreshold:  opts.SpillThreshold,
+			CacheSize:                opts.CacheSize,
+			MemTableSize:             opts.MemTableSize,
+			MaxOpenFiles:             opts.MaxOpenFiles,
+			L0CompactionThreshold:    opts.L0CompactionThreshold,
+			L0StopWritesThreshold:    opts.L0StopWritesThreshold,
+			TargetFileSize:           opts.TargetFileSize,
+			MaxConcurrentCompactions: opts.MaxConcurrentCompactions,
+			Locations:                opts.Locations,
+			CrashHook:                opts.CrashHook,
+		},
+		TrackChanges:         opts.TrackChanges,
+		ConcurrentWrites:     opts.ConcurrentWrites,
+		CaptureChanges:       opts.CaptureChanges,
+		ReadOnly:             opts.ReadOnly,
+		OverflowThreshold:    opts.OverflowThreshold,
+		LockWait:             opts.LockWait,
+		TimeZone:             opts.TimeZone,
+		Coercion:             opts.Coercion,
+		RowMigrationInterval: opts.RowMigrationInterval,
+	}
+}
+
+// VerifyRecovery opens the database found at path, which recovers it from
+// a crash by rolling back the transactions that were not committed, then
+// verifies that no change remains to be rolled back and that the catalog,
+// tables and indexes are consistent. See DB.Check.
+// It is meant to validate the durability of a database after simulating
+// crashes with Options.CrashHook, which is ignored. The database is closed
+// before returning.
+func VerifyRecovery(ctx context.Context, path string, opts *Options) (*CheckReport, error) {
+	return database.VerifyRecovery(ctx, path, opts.toDatabase())
+}
+
+// Reencrypt rewrites the files of the database found at path that are not
+// encrypted with the current key, so that previous keys can be retired.
+// The database must be closed. It returns the number of rewritten files.
+func Reencrypt(path string, opts EncryptionOptions) (int, error) {
+	return kv.Reencrypt(path, *opts.toEngine())
+}
+
+// OpenSnapshot opens an in-memory database and loads the content
+// of a snapshot file created with SaveSnapshot.
+// Changes made to the database are not written back to the file.
+func OpenSnapshot(path string) (*DB, error) {
+	ng, err := memory.LoadSnapshot(path)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := database.Open(":memory:", &database.Options{
 		CatalogLoader: catalogstore.LoadCatalog,
+		Engine:        ng,
 	})
 	if err != nil {
 		return nil, err
 	}
 
 	return &DB{
-		DB: db,
+		DB:          db,
+		stmtCache:   newStatementCache(0),
+		queryLogger: new(atomic.Pointer[QueryLogger]),
+		metrics:     new(queryMetrics),
+		pool:        newConnPool(0, 0),
 	}, nil
 }
 
+// SaveSnapshot writes the committed content of an in-memory database to the given file.
+// The snapshot can be loaded later with OpenSnapshot.
+// It returns an error if the database was not opened in memory.
+func (db *DB) SaveSnapshot(path string) error {
+	ng, ok := db.DB.Engine.(*memory.Engine)
+	if !ok {
+		return errors.New("snapshots are only supported by in-memory databases")
+	}
+
+	return ng.SaveSnapshot(path)
+}
+
+// ObjectStorage is implemented by object storages (S3, GCS, etc.)
+// from which a replica can read its data.
+type ObjectStorage = remote.Storage
+
+// NewLocalObjectStorage returns an ObjectStorage that stores
+// its objects as files in the given directory.
+func NewLocalObjectStorage(dir string) ObjectStorage {
+	return kv.NewDirStorage(dir)
+}
+
+// ReplicaOptions configure how a replica reads its data.
+type ReplicaOptions struct {
+	// Storage containing the sstables created with ExportSST.
+	Storage ObjectStorage
+	// Only objects whose name starts with Prefix and ends with ".sst" are loaded,
+	// in lexicographic order.
+	Prefix string
+	// Size in bytes of the block cache. Defaults to 64MB.
+	CacheSize int64
+}
+
+// OpenReplica opens a database whose data is read from the sstables stored in an object storage.
+// The sstables are never downloaded entirely, blocks are fetched on demand and cached in memory.
+// The replica accepts writes, but they are kept in memory and discarded when it is closed.
+func OpenReplica(opts ReplicaOptions) (*DB, error) {
+	db, err := database.Open(":memory:", &database.Options{
+		CatalogLoader: catalogstore.LoadCatalog,
+		Remote: &kv.RemoteOptions{
+			Storage:   opts.Storage,
+			Prefix:    opts.Prefix,
+			CacheSize: opts.CacheSize,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &DB{
+		DB:          db,
+		stmtCache:   newStatementCache(0),
+		queryLogger: new(atomic.Pointer[QueryLogger]),
+		metrics:     new(queryMetrics),
+		pool:        newConnPool(0, 0),
+	}, nil
+}
+
+// ExportSST writes a consistent snapshot of the database to w, in the sstable format.
+// Once uploaded to an object storage, the output can be read by OpenReplica.
+func (db *DB) ExportSST(w io.Writer) error {
+	return db.DB.ExportSST(w)
+}
+
+// Backup writes a consistent snapshot of the database to w.
+// Other connections can keep reading and writing while the backup is running:
+// the backup contains the data committed before it started.
+func (db *DB) Backup(ctx context.Context, w io.Writer) error {
+	return db.DB.Backup(ctx, w)
+}
+
+// Restore creates a database at path, which must not exist, from a backup created with
+// DB.Backup, followed by the incremental backups created since, in order, with DB.BackupIncremental.
+// The restored database can then be opened with Open. If the restoration fails,
+// nothing is left at path.
+func Restore(ctx context.Context, r io.Reader, path string, incrementals ...io.Reader) error {
+	return database.RestoreTo(ctx, path, append([]io.Reader{r}, incrementals...)...)
+}
+
+// Checkpoint identifies the state of a database at the time of a backup.
+type Checkpoint = database.Checkpoint
+
+// BackupIncremental writes to w the changes made since the given checkpoint,
+// which is usually the checkpoint of the previous backup, read with ReadBackupCheckpoint.
+// It requires the database to be opened with TrackChanges enabled.
+func (db *DB) BackupIncremental(ctx context.Context, w io.Writer, since Checkpoint) error {
+	return db.DB.BackupIncremental(ctx, w, since)
+}
+
+// ReadBackupCheckpoint returns the checkpoint of a backup created with Backup or BackupIncremental.
+// The checkpoint is empty if the backup was created with TrackChanges disabled.
+func ReadBackupCheckpoint(r io.Reader) (Checkpoint, error) {
+	return database.ReadBackupCheckpoint(r)
+}
+
+// Checkpoint creates a copy of the database in dir, which must not already contain a database.
+// Data files are hard-linked when possible, which makes checkpoints cheap.
+// The copy can be opened as a regular database, or used as the starting point of
+// RestorePointInTime if the database was opened with WAL archiving.
+func (db *DB) Checkpoint(dir string) error {
+	ng, ok := db.DB.Engine.(*kv.PebbleEngine)
+	if !ok {
+		return errors.New("checkpoints are only supported by on-disk databases")
+	}
+
+	return ng.Checkpoint(dir)
+}
+
+// PointInTimeOptions describe how to restore a database with RestorePointInTime.
+type PointInTimeOptions struct {
+	// Directory of a checkpoint created with DB.Checkpoint.
+	Checkpoint string
+	// Storage containing the archived WAL files.
+	Archive ObjectStorage
+	// Prefix of the names of the archived WAL files.
+	Prefix string
+	// Optional path of the original database. If set, its WAL files
+	// that were not archived yet are replayed after the archived ones.
+	// The original database must be closed.
+	Path string
+	// Transactions committed after Target are not restored.
+	// It must be after the creation of the checkpoint.
+	Target time.Time
+}
+
+// RestorePointInTime creates a database at path, which must not exist, from a checkpoint
+// and replays the archived WAL files until the last transaction committed before the target time.
+// It can be used to undo an operational mistake, by restoring the database as it was right before it.
+func RestorePointInTime(path string, opts PointInTimeOptions) error {
+	return database.RestorePointInTime(path, kv.Options{}, kv.PointInTimeOptions{
+		Checkpoint: opts.Checkpoint,
+		Archive:    opts.Archive,
+		Prefix:     opts.Prefix,
+		Path:       opts.Path,
+		Target:     opts.Target,
+	})
+}
+
+// CheckReport contains the result of DB.Check.
+type CheckReport = database.CheckReport
+
+// Check verifies the integrity of the database: the checksums of the data files,
+// the consistency between indexes and tables, and the absence of orphaned data.
+// The problems found are listed in the report, the error is only returned
+// if the check couldn't run.
+func (db *DB) Check(ctx context.Context) (*CheckReport, error) {
+	return db.DB.Check(ctx)
+}
+
+// RowMigrationReport contains the result of DB.MigrateRows.
+type RowMigrationReport = database.RowMigrationReport
+
+// MigrateRows upgrades the rows written by older versions of the database
+// to the current row format, in batches of rows committed separately,
+// until every row is upgraded or the context is cancelled.
+// Rows using an older format can still be read and are upgraded when they
+// are written: migrating them only avoids converting them on every read.
+func (db *DB) MigrateRows(ctx context.Context) (*RowMigrationReport, error) {
+	return db.DB.MigrateRows(ctx)
+}
+
+// NamespaceStats describes the disk usage of a table, an index
+// or an internal structure of the database.
+type NamespaceStats = database.NamespaceStats
+
+// LevelStats describes the part of a namespace stored in a level of the storage.
+type LevelStats = kv.LevelStats
+
+// Compact compacts the storage between the keys start (inclusive) and end (exclusive),
+// reclaiming the space used by deleted and overwritten rows.
+// The bounds of each table and index are returned by StorageStats.
+// If both are nil, the whole database is compacted.
+// It is only supported by on-disk databases.
+func (db *DB) Compact(start, end []byte) error {
+	return db.DB.Compact(start, end)
+}
+
+// StorageStats returns the approximate disk usage of every table, index and
+// internal structure of the database, and how it is spread across the levels of the storage.
+// It is only supported by on-disk databases.
+func (db *DB) StorageStats() ([]NamespaceStats, error) {
+	return db.DB.StorageStats()
+}
+
+// Stats contains counters describing the activity of the storage engine,
+// which can be used to monitor the database.
+type Stats = kv.EngineStats
+
+// Stats returns the counters of the storage engine: block cache usage,
+// bytes flushed and compacted, size of the write-ahead log, open iterators, etc.
+// It is only supported by on-disk databases.
+func (db *DB) Stats() (*Stats, error) {
+	return db.DB.EngineStats()
+}
+
+// Job is a task run periodically in the background by the database.
+type Job = database.Job
+
+// JobInfo describes the state of a background job.
+type JobInfo = database.JobInfo
+
+// Scheduler runs the background jobs of the database.
+type Scheduler = database.Scheduler
+
+// Scheduler returns the scheduler of the background jobs, used to register,
+// pause and resume them. The state of the jobs can also be queried
+// from the __chai_jobs table.
+func (db *DB) Scheduler() *Scheduler {
+	return db.DB.Scheduler()
+}
+
+// Snapshot is a read-only view of the database at the time it was created.
+// Queries run against a snapshot always see the same data, no matter how long they run
+// or how many transactions are committed meanwhile, and they never block writers.
+type Snapshot struct {
+	db       *DB
+	snapshot *database.Snapshot
+}
+
+// Snapshot creates a snapshot of the database. Snapshots prevent the storage from
+// reclaiming the space of the data they can see: they must be closed as soon as possible,
+// and always before the database is closed.
+func (db *DB) Snapshot() (*Snapshot, error) {
+	s, err := db.DB.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Snapshot{
+		db:       db,
+		snapshot: s,
+	}, nil
+}
+
+// Connect returns a connection that reads from the snapshot.
+// Statements that modify the database return an error.
+func (s *Snapshot) Connect() (*Connection, error) {
+	conn, err := s.snapshot.Connect()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Connection{
+		db:   s.db,
+		Conn: conn,
+	}, nil
+}
+
+// QueryRow runs the query against the snapshot and returns the first row.
+func (s *Snapshot) QueryRow(q string, args ...any) (r *Row, err error) {
+	conn, err := s.Connect()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	return conn.QueryRow(q, args...)
+}
+
+// Close the snapshot.
+func (s *Snapshot) Close() error {
+	return s.snapshot.Close()
+}
+
 func (db *DB) Connect() (*Connection, error) {
 	conn, err := db.DB.Connect()
 	if err != nil {
@@ -64,20 +798,51 @@ func (db DB) WithContext(ctx context.Context) *DB {
 	return &db
 }
 
-func (db *DB) withConn(fn func(*Connection) error) error {
-	conn, err := db.Connect()
+// withConn runs fn with a connection of the pool.
+func (db *DB) withConn(ctx context.Context, fn func(*Connection) error) error {
+	conn, release, err := db.acquireConn(ctx)
 	if err != nil {
 		return err
 	}
-	defer conn.Close()
+	defer release()
 
 	return fn(conn)
 }
 
+// acquireConn returns a connection of the pool and the function
+// returning it to the pool, which must be called once it is no longer used.
+func (db *DB) acquireConn(ctx context.Context) (*Connection, func(), error) {
+	// databases which were not opened by this package have no pool
+	if db.pool == nil {
+		conn, err := db.Connect()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return conn, func() { _ = conn.Close() }, nil
+	}
+
+	conn, err := db.pool.get(ctx, db.DB)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &Connection{
+		db:   db,
+		Conn: conn,
+	}, func() { _ = db.pool.put(conn) }, nil
+}
+
 // QueryRow runs the query and returns the first row.
 func (db *DB) QueryRow(q string, args ...any) (r *Row, err error) {
-	err = db.withConn(func(c *Connection) error {
-		r, err = c.QueryRow(q, args...)
+	return db.QueryRowContext(db.ctx, q, args...)
+}
+
+// QueryRowContext runs the query with the given context and returns the first row.
+// See Statement.QueryContext for how the context is used.
+func (db *DB) QueryRowContext(ctx context.Context, q string, args ...any) (r *Row, err error) {
+	err = db.withConn(ctx, func(c *Connection) error {
+		r, err = c.QueryRowContext(ctx, q, args...)
 		return err
 	})
 	return
@@ -85,13 +850,87 @@ func (db *DB) QueryRow(q string, args ...any) (r *Row, err error) {
 
 // Exec a query against the database without returning the result.
 func (db *DB) Exec(q string, args ...any) error {
-	return db.withConn(func(c *Connection) error {
-		return c.Exec(q, args...)
+	return db.ExecContext(db.ctx, q, args...)
+}
+
+// ExecContext runs a query with the given context without returning the result.
+// See Statement.QueryContext for how the context is used.
+func (db *DB) ExecContext(ctx context.Context, q string, args ...any) error {
+	return db.withConn(ctx, func(c *Connection) error {
+		return c.ExecContext(ctx, q, args...)
 	})
 }
 
+// PlanNode describes an operator of the execution plan of a statement. See DB.Explain.
+type PlanNode = statement.PlanNode
+
+// Explain returns the execution plan of the statement, without running it,
+// as a tree of operators whose root is the last operator of the plan.
+// It contains the same information as the EXPLAIN statement, in a form
+// that can be inspected by programs, for example to check in tests
+// that a query uses an index. See ExplainContext.
+func (db *DB) Explain(q string, args ...any) (*PlanNode, error) {
+	return db.ExplainContext(db.ctx, q, args...)
+}
+
+// ExplainContext is like Explain, with the given context.
+// Like EXPLAIN, it only works on SELECT, INSERT, UPDATE and DELETE statements.
+// The arguments are used to choose the plan, for example the number
+// of rows of a LIMIT clause.
+func (db *DB) ExplainContext(ctx context.Context, q string, args ...any) (*PlanNode, error) {
+	pq, err := parser.ParseQuery(q)
+	if err != nil {
+		return nil, err
+	}
+	if len(pq.Statements) != 1 {
+		return nil, errors.New("Explain requires a single statement")
+	}
+
+	explain, ok := pq.Statements[0].(*statement.ExplainStmt)
+	if !ok {
+		p, ok := pq.Statements[0].(statement.Preparer)
+		if !ok {
+			return nil, errors.New("Explain only works on INSERT, SELECT, UPDATE AND DELETE statements")
+		}
+		explain = &statement.ExplainStmt{Statement: p}
+	}
+
+	var plan *PlanNode
+	err = db.withConn(ctx, func(c *Connection) error {
+		tx, err := c.Conn.BeginTx(&database.TxOptions{ReadOnly: true})
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		sctx := statement.Context{
+			Ctx:    ctx,
+			DB:     db.DB,
+			Conn:   c.Conn,
+			Tx:     tx,
+			Params: argsToParams(args),
+		}
+		err = explain.Bind(&sctx)
+		if err != nil {
+			return err
+		}
+
+		plan, err = explain.Plan(&sctx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return plan, nil
+}
+
 // Close the database.
 func (db *DB) Close() error {
+	if db.pool != nil {
+		db.pool.close()
+	}
+
 	return db.DB.Close()
 }
 
@@ -103,8 +942,33 @@ type Connection struct {
 // Begin starts a new transaction.
 // The returned transaction must be closed either by calling Rollback or Commit.
 func (c *Connection) Begin(writable bool) (*Tx, error) {
+	return c.BeginTx(&TxOptions{ReadOnly: !writable})
+}
+
+// TxOptions configure a transaction.
+type TxOptions struct {
+	// ReadOnly starts a read-only transaction.
+	ReadOnly bool
+	// Serializable makes a write transaction fail to commit with ErrWriteConflict
+	// if any of the rows it read, or any row matching the conditions it used to
+	// read rows, was modified by a transaction committed after it began.
+	// Read-modify-write patterns are then safe without locking, but
+	// transactions fail more often and must be retried.
+	// It only has an effect with Options.ConcurrentWrites: otherwise,
+	// write transactions run one at a time and are always serializable.
+	Serializable bool
+}
+
+// BeginTx starts a new transaction with the given options.
+// The returned transaction must be closed either by calling Rollback or Commit.
+func (c *Connection) BeginTx(opts *TxOptions) (*Tx, error) {
+	if opts == nil {
+		opts = new(TxOptions)
+	}
+
 	_, err := c.Conn.BeginTx(&database.TxOptions{
-		ReadOnly: !writable,
+		ReadOnly:     opts.ReadOnly,
+		Serializable: opts.Serializable,
 	})
 	if err != nil {
 		return nil, err
@@ -145,12 +1009,19 @@ func (c *Connection) Update(fn func(tx *Tx) error) error {
 // Query the database and return the result.
 // The returned result must always be closed after usage.
 func (c *Connection) Query(q string, args ...any) (*Result, error) {
-	stmt, err := c.Prepare(q)
+	return c.QueryContext(c.db.ctx, q, args...)
+}
+
+// QueryContext queries the database with the given context and returns the result.
+// The returned result must always be closed after usage.
+// See Statement.QueryContext for how the context is used.
+func (c *Connection) QueryContext(ctx context.Context, q string, args ...any) (*Result, error) {
+	stmt, err := c.prepare(ctx, q)
 	if err != nil {
 		return nil, err
 	}
 
-	res, err := stmt.Query(args...)
+	res, err := stmt.QueryContext(ctx, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -162,42 +1033,116 @@ func (c *Connection) Query(q string, args ...any) (*Result, error) {
 
 // QueryRow runs the query and returns the first row.
 func (c *Connection) QueryRow(q string, args ...any) (*Row, error) {
-	stmt, err := c.Prepare(q)
+	return c.QueryRowContext(c.db.ctx, q, args...)
+}
+
+// QueryRowContext runs the query with the given context and returns the first row.
+// See Statement.QueryContext for how the context is used.
+func (c *Connection) QueryRowContext(ctx context.Context, q string, args ...any) (*Row, error) {
+	stmt, err := c.prepare(ctx, q)
 	if err != nil {
 		return nil, err
 	}
 
-	return stmt.QueryRow(args...)
+	return stmt.QueryRowContext(ctx, args...)
 }
 
 // Exec a query against the database without returning the result.
 func (c *Connection) Exec(q string, args ...any) error {
-	stmt, err := c.Prepare(q)
+	return c.ExecContext(c.db.ctx, q, args...)
+}
+
+// ExecContext runs a query with the given context without returning the result.
+// See Statement.QueryContext for how the context is used.
+func (c *Connection) ExecContext(ctx context.Context, q string, args ...any) error {
+	stmt, err := c.prepare(ctx, q)
 	if err != nil {
 		return err
 	}
 
-	return stmt.Exec(args...)
+	return stmt.ExecContext(ctx, args...)
 }
 
 // Prepare parses the query and returns a prepared statement.
 func (c *Connection) Prepare(q string) (*Statement, error) {
-	pq, err := parser.ParseQuery(q)
-	if err != nil {
-		return nil, err
-	}
+	return c.prepare(c.db.ctx, q)
+}
 
-	err = pq.Prepare(newQueryContext(c, nil))
+func (c *Connection) prepare(ctx context.Context, q string) (*Statement, error) {
+	pq, err := c.prepareQuery(ctx, q)
 	if err != nil {
 		return nil, err
 	}
 
 	return &Statement{
 		pq:   pq,
+		q:    q,
 		conn: c,
 	}, nil
 }
 
+// prepareQuery parses and prepares the query, or returns it from the cache of the database.
+// The cache is only used if the connection sees the latest schema, which is not the case
+// of transactions that modified it or that began before it was modified.
+func (c *Connection) prepareQuery(ctx context.Context, q string) (query.Query, error) {
+	cache := c.db.stmtCache
+	catalog := c.Conn.Catalog()
+	cached := cache != nil && catalog == c.db.DB.Catalog()
+
+	key := statementCacheKey{q: q, timeZone: c.Conn.TimeZone(), coercion: c.Conn.Coercion()}
+	if cached {
+		if pq, ok := cache.get(key, catalog); ok {
+			if tx := c.Conn.GetTx(); tx != nil && !tx.Writable && !pq.IsReadOnly() {
+				return pq, errors.WithStack(database.ErrReadOnlyTx)
+			}
+			return pq, nil
+		}
+	}
+
+	pq, err := parser.ParseQuery(q)
+	if err != nil {
+		return pq, err
+	}
+
+	err = pq.Prepare(newQueryContext(ctx, c, nil))
+	if err != nil {
+		return pq, err
+	}
+
+	// queries whose statements are prepared when they are run,
+	// such as CREATE TABLE, are not cached
+	if cached && pq.IsPrepared() {
+		cache.put(key, pq, catalog)
+	}
+
+	return pq, nil
+}
+
+// SetTimeZone sets the time zone used by the connection to parse and
+// display TIMESTAMPTZ values, like SET TIME ZONE. If loc is nil,
+// the default time zone of the database is used.
+func (c *Connection) SetTimeZone(loc *time.Location) {
+	c.Conn.SetTimeZone(loc)
+}
+
+// SetCoercion sets the coercion mode of the connection, which controls
+// how values of different types are compared, like SET COERCION.
+func (c *Connection) SetCoercion(mode Coercion) {
+	c.Conn.SetCoercion(mode)
+}
+
+// Ping returns an error if the connection or its database is closed,
+// or if the context is done.
+func (c *Connection) Ping(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return c.Conn.Err()
+}
+
+// Close rolls back the ongoing transaction, if any, and releases the connection.
+// The connection cannot be used after it is closed.
 func (c *Connection) Close() error {
 	return c.Conn.Close()
 }
@@ -208,6 +1153,123 @@ func (c *Connection) Close() error {
 // and read/write can be used to read, create, delete and modify tables.
 type Tx struct {
 	conn *Connection
+	// functions registered with OnCommit and OnRollback.
+	onCommit   []func()
+	onRollback []func()
+	// whether the connection was opened for the transaction
+	// and must be closed with it.
+	ownsConn bool
+}
+
+// BeginRead starts a read-only transaction on a connection of its own,
+// closed with the transaction. The transaction reads a snapshot of the database
+// taken when it begins: it sees the same data until it is closed, never takes
+// the write lock and never blocks the write transactions, nor is blocked by them.
+// Queries modifying the database are rejected with ErrReadOnlyTx when they are
+// prepared. The context is used by the queries run by the transaction.
+// The returned transaction must be closed by calling Rollback.
+func (db *DB) BeginRead(ctx context.Context) (*Tx, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	conn, err := db.WithContext(ctx).Connect()
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := conn.BeginTx(&TxOptions{ReadOnly: true})
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	tx.ownsConn = true
+	return tx, nil
+}
+
+// RunTxOptions configures DB.RunInTx.
+type RunTxOptions struct {
+	TxOptions
+
+	// MaxRetries is the maximum number of times the transaction is run again
+	// after failing with a write conflict. Defaults to 10. If negative,
+	// the transaction is never retried.
+	MaxRetries int
+	// Delay before the first retry, doubling after each retry up to MaxDelay.
+	// Each delay is randomized to avoid conflicting again with the same transactions.
+	// MinDelay defaults to 1ms and MaxDelay to 100ms.
+	MinDelay time.Duration
+	MaxDelay time.Duration
+}
+
+// RunInTx runs fn within a transaction, committed if fn returns nil and rolled back otherwise.
+// If the transaction fails with a write conflict, see IsWriteConflictError, it is rolled back
+// and fn is run again in a new transaction, after a delay, until it succeeds or until
+// the maximum number of retries of opts is reached. fn must then be safe to run more than once,
+// and must not keep the results of a failed attempt.
+// The context is used by the queries run by the transaction and interrupts the retries.
+// A nil opts starts read-write transactions with the default retry policy.
+func (db *DB) RunInTx(ctx context.Context, opts *RunTxOptions, fn func(tx *Tx) error) error {
+	if opts == nil {
+		opts = new(RunTxOptions)
+	}
+
+	maxRetries := opts.MaxRetries
+	if maxRetries == 0 {
+		maxRetries = 10
+	}
+	delay := opts.MinDelay
+	if delay <= 0 {
+		delay = time.Millisecond
+	}
+	maxDelay := opts.MaxDelay
+	if maxDelay <= 0 {
+		maxDelay = 100 * time.Millisecond
+	}
+	maxDelay = max(maxDelay, delay)
+
+	cdb := db.WithContext(ctx)
+	for retries := 0; ; retries++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		err := cdb.withConn(ctx, func(c *Connection) error {
+			return c.runTx(&opts.TxOptions, fn)
+		})
+		if err == nil || !IsWriteConflictError(err) || retries >= maxRetries {
+			return err
+		}
+
+		// wait between half the delay and the full delay
+		t := time.NewTimer(delay/2 + rand.N(delay/2+1))
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return err
+		case <-t.C:
+		}
+
+		delay = min(delay*2, maxDelay)
+	}
+}
+
+// runTx runs fn within a transaction, committed if fn returns nil,
+// unless the transaction is read-only.
+func (c *Connection) runTx(opts *TxOptions, fn func(tx *Tx) error) error {
+	tx, err := c.BeginTx(opts)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = fn(tx)
+	if err != nil || opts.ReadOnly {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 // Rollback the transaction. Can be used safely after commit.
@@ -217,7 +1279,18 @@ func (tx *Tx) Rollback() error {
 		return errors.New("transaction has already been committed or rolled back")
 	}
 
-	return t.Rollback()
+	err := t.Rollback()
+	if err != nil {
+		return err
+	}
+
+	if tx.ownsConn {
+		err = tx.conn.Close()
+	}
+
+	runTxHooks(tx.onRollback)
+	tx.onCommit, tx.onRollback = nil, nil
+	return err
 }
 
 // Commit the transaction. Calling this method on read-only transactions
@@ -228,54 +1301,103 @@ func (tx *Tx) Commit() error {
 		return errors.New("transaction has already been committed or rolled back")
 	}
 
-	return t.Commit()
+	err := t.Commit()
+	if err != nil {
+		return err
+	}
+
+	runTxHooks(tx.onCommit)
+	tx.onCommit, tx.onRollback = nil, nil
+	return nil
+}
+
+// OnCommit registers fn to be called after the transaction is successfully committed
+// by the Commit method. The functions are called in the order they were registered,
+// once the transaction is over, and can use the database.
+// They are never called if the transaction is rolled back, including after
+// a failed commit.
+func (tx *Tx) OnCommit(fn func()) {
+	tx.onCommit = append(tx.onCommit, fn)
+}
+
+// OnRollback registers fn to be called after the transaction is rolled back
+// by the Rollback method. The functions are called in the order they were registered,
+// once the transaction is over, and can use the database.
+// They are never called if the transaction is committed.
+func (tx *Tx) OnRollback(fn func()) {
+	tx.onRollback = append(tx.onRollback, fn)
+}
+
+func runTxHooks(hooks []func()) {
+	for _, fn := range hooks {
+		fn()
+	}
 }
 
 // Query the database withing the transaction and returns the result.
 // Closing the returned result after usage is not mandatory.
 func (tx *Tx) Query(q string, args ...any) (*Result, error) {
-	stmt, err := tx.Prepare(q)
+	return tx.QueryContext(tx.conn.db.ctx, q, args...)
+}
+
+// QueryContext queries the database within the transaction with the given context
+// and returns the result. Closing the returned result after usage is not mandatory.
+// See Statement.QueryContext for how the context is used.
+func (tx *Tx) QueryContext(ctx context.Context, q string, args ...any) (*Result, error) {
+	stmt, err := tx.prepare(ctx, q)
 	if err != nil {
 		return nil, err
 	}
 
-	return stmt.Query(args...)
+	return stmt.QueryContext(ctx, args...)
 }
 
 // QueryRow runs the query and returns the first row.
 func (tx *Tx) QueryRow(q string, args ...any) (*Row, error) {
-	stmt, err := tx.Prepare(q)
+	return tx.QueryRowContext(tx.conn.db.ctx, q, args...)
+}
+
+// QueryRowContext runs the query with the given context and returns the first row.
+// See Statement.QueryContext for how the context is used.
+func (tx *Tx) QueryRowContext(ctx context.Context, q string, args ...any) (*Row, error) {
+	stmt, err := tx.prepare(ctx, q)
 	if err != nil {
 		return nil, err
 	}
 
-	return stmt.QueryRow(args...)
+	return stmt.QueryRowContext(ctx, args...)
 }
 
 // Exec a query against the database within tx and without returning the result.
 func (tx *Tx) Exec(q string, args ...any) (err error) {
-	stmt, err := tx.Prepare(q)
+	return tx.ExecContext(tx.conn.db.ctx, q, args...)
+}
+
+// ExecContext runs a query within tx with the given context and without returning the result.
+// See Statement.QueryContext for how the context is used.
+func (tx *Tx) ExecContext(ctx context.Context, q string, args ...any) (err error) {
+	stmt, err := tx.prepare(ctx, q)
 	if err != nil {
 		return err
 	}
 
-	return stmt.Exec(args...)
+	return stmt.ExecContext(ctx, args...)
 }
 
 // Prepare parses the query and returns a prepared statement.
 func (tx *Tx) Prepare(q string) (*Statement, error) {
-	pq, err := parser.ParseQuery(q)
-	if err != nil {
-		return nil, err
-	}
+	return tx.prepare(tx.conn.db.ctx, q)
+}
 
-	err = pq.Prepare(newQueryContext(tx.conn, nil))
+func (tx *Tx) prepare(ctx context.Context, q string) (*Statement, error) {
+	pq, err := tx.conn.prepareQuery(ctx, q)
 	if err != nil {
 		return nil, err
 	}
 
 	return &Statement{
 		pq:   pq,
+		q:    q,
 		conn: tx.conn,
 		tx:   tx,
 	}, nil
@@ -287,6 +1409,7 @@ func (tx *Tx) Prepare(q string) (*Statement, error) {
 // It's safe for concurrent use by multiple goroutines.
 type Statement struct {
 	pq   query.Query
+	q    string
 	conn *Connection
 	tx   *Tx
 }
@@ -294,15 +1417,47 @@ type Statement struct {
 // Query the database and return the result.
 // The returned result must always be closed after usage.
 func (s *Statement) Query(args ...any) (*Result, error) {
+	return s.QueryContext(s.conn.db.ctx, args...)
+}
+
+// QueryContext queries the database with the given context and returns the result.
+// The returned result must always be closed after usage.
+// If the context is canceled or its deadline is exceeded, the query stops,
+// even in the middle of a scan, and returns the error of the context.
+// The changes of a query run outside of a transaction are then rolled back.
+func (s *Statement) QueryContext(ctx context.Context, args ...any) (*Result, error) {
 	var r *statement.Result
 	var err error
 
-	r, err = s.pq.Run(newQueryContext(s.conn, argsToParams(args)))
+	log := startQueryLog(s.conn.db, ctx, s.q, args)
+
+	r, err = s.pq.Run(newQueryContext(ctx, s.conn, argsToParams(args)))
 	if err != nil {
+		if log != nil {
+			log.done(nil, err)
+		}
 		return nil, err
 	}
 
-	return &Result{result: r, ctx: s.conn.db.ctx}, nil
+	return &Result{result: r, ctx: ctx, log: log}, nil
+}
+
+// Split returns one statement per statement of the query, in order.
+// Running them one after the other has the same effect as running s,
+// but returns the result of each statement instead of only the last one.
+// They are bound to the same connection and transaction as s.
+func (s *Statement) Split() []*Statement {
+	stmts := make([]*Statement, len(s.pq.Statements))
+	for i, st := range s.pq.Statements {
+		stmts[i] = &Statement{
+			pq:   query.New(st),
+			q:    s.q,
+			conn: s.conn,
+			tx:   s.tx,
+		}
+	}
+
+	return stmts
 }
 
 func argsToParams(args []interface{}) []environment.Param {
@@ -335,7 +1490,13 @@ func argsToParams(args []interface{}) []environment.Param {
 
 // QueryRow runs the query and returns the first row.
 func (s *Statement) QueryRow(args ...any) (r *Row, err error) {
-	res, err := s.Query(args...)
+	return s.QueryRowContext(s.conn.db.ctx, args...)
+}
+
+// QueryRowContext runs the query with the given context and returns the first row.
+// See QueryContext for how the context is used.
+func (s *Statement) QueryRowContext(ctx context.Context, args ...any) (r *Row, err error) {
+	res, err := s.QueryContext(ctx, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -351,7 +1512,13 @@ func (s *Statement) QueryRow(args ...any) (r *Row, err error) {
 
 // Exec a query against the database without returning the result.
 func (s *Statement) Exec(args ...any) (err error) {
-	res, err := s.Query(args...)
+	return s.ExecContext(s.conn.db.ctx, args...)
+}
+
+// ExecContext runs the query with the given context without returning the result.
+// See QueryContext for how the context is used.
+func (s *Statement) ExecContext(ctx context.Context, args ...any) (err error) {
+	res, err := s.QueryContext(ctx, args...)
 	if err != nil {
 		return err
 	}
@@ -372,18 +1539,23 @@ type Result struct {
 	result *statement.Result
 	ctx    context.Context
 	conn   *Connection
+	// log of the query, nil if it is not logged.
+	log *queryLog
+	// error returned by Iterate, reported to the logger.
+	err error
 }
 
 func (r *Result) Iterate(fn func(r *Row) error) error {
 	var row Row
 	if r.ctx == nil {
-		return r.result.Iterate(func(dr database.Row) error {
+		r.err = r.result.Iterate(func(dr database.Row) error {
 			row.Row = dr
 			return fn(&row)
 		})
+		return r.err
 	}
 
-	return r.result.Iterate(func(dr database.Row) error {
+	r.err = r.result.Iterate(func(dr database.Row) error {
 		if err := r.ctx.Err(); err != nil {
 			return err
 		}
@@ -391,6 +1563,7 @@ func (r *Result) Iterate(fn func(r *Row) error) error {
 		row.Row = dr
 		return fn(&row)
 	})
+	return r.err
 }
 
 func (r *Result) GetFirst() (*Row, error) {
@@ -428,6 +1601,14 @@ func (r *Result) Columns() ([]string, error) {
 	return stmt.Stream.Columns(&env)
 }
 
+// ReturnsRows reports whether the statement of the result returns rows,
+// like SELECT or INSERT ... RETURNING. Statements like UPDATE, CREATE TABLE
+// or BEGIN return no rows.
+func (r *Result) ReturnsRows() bool {
+	stmt, ok := r.result.Iterator.(*statement.StreamStmtIterator)
+	return ok && stmt.ReturnsRows()
+}
+
 // Close the result stream.
 func (r *Result) Close() (err error) {
 	if r == nil {
@@ -436,6 +1617,15 @@ func (r *Result) Close() (err error) {
 
 	err = r.result.Close()
 
+	if r.log != nil {
+		lerr := r.err
+		if lerr == nil {
+			lerr = err
+		}
+		r.log.done(r.result, lerr)
+		r.log = nil
+	}
+
 	return err
 }
 
@@ -478,9 +1668,9 @@ func (r *Result) MarshalJSONTo(w io.Writer) error {
 	return buf.Flush()
 }
 
-func newQueryContext(conn *Connection, params []environment.Param) *query.Context {
+func newQueryContext(ctx context.Context, conn *Connection, params []environment.Param) *query.Context {
 	return &query.Context{
-		Ctx:    conn.db.ctx,
+		Ctx:    ctx,
 		DB:     conn.db.DB,
 		Conn:   conn.Conn,
 		Params: params,
@@ -498,6 +1688,17 @@ func (r *Row) Clone() *Row {
 	if err != nil {
 		panic(err)
 	}
+	// blobs stored outside of their row can only be read during the transaction,
+	// load them so that the clone remains usable afterwards.
+	err = cb.Apply(func(_ string, v types.Value) (types.Value, error) {
+		if bs, ok := v.(types.BlobStream); ok {
+			return bs.Load()
+		}
+		return v, nil
+	})
+	if err != nil {
+		panic(err)
+	}
 	var br database.BasicRow
 	br.ResetWith(r.Row.TableName(), r.Row.Key(), cb)
 	rr.Row = &br
@@ -538,10 +1739,24 @@ func (r *Row) StructScan(dest any) error {
 	return row.StructScan(r.Row, dest)
 }
 
+// StructScanStrict is like StructScan, but returns an error if a column
+// of the row doesn't match any field of dest.
+func (r *Row) StructScanStrict(dest any) error {
+	return row.StructScanStrict(r.Row, dest)
+}
+
+// MapScan copies the columns of the row into dest, which must not be nil.
+// The values are converted like when scanning into an empty interface.
 func (r *Row) MapScan(dest map[string]any) error {
 	return row.MapScan(r.Row, dest)
 }
 
+// SliceScan returns the values of the row, in the order of its columns,
+// for callers which don't know the columns in advance. NULL values are returned as nil.
+func (r *Row) SliceScan() ([]any, error) {
+	return row.SliceScan(r.Row)
+}
+
 func (r *Row) MarshalJSON() ([]byte, error) {
 	return r.Row.MarshalJSON()
 }
@@ -1,13 +1,24 @@
 package chai_test
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"expvar"
 	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/chaisql/chai"
 	"github.com/chaisql/chai/internal/testutil"
+	"github.com/cockroachdb/errors"
 	"github.com/stretchr/testify/require"
 )
 
@@ -254,3 +265,2142 @@ func TestIterateDeepCopy(t *testing.T) {
 	require.Equal(t, &item{A: 2, B: "sample text 2"}, items[0])
 	require.Equal(t, &item{A: 1, B: "sample text 1"}, items[1])
 }
+
+func TestQueryAs(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE foo (a INTEGER PRIMARY KEY, b TEXT, c TIMESTAMP);
+		INSERT INTO foo (a, b, c) VALUES (1, 'one', '2024-01-01'), (2, 'two', NULL);
+	`)
+	require.NoError(t, err)
+
+	type Base struct {
+		A int
+	}
+	type item struct {
+		Base
+		Name string `chai:"b"`
+		C    *time.Time
+	}
+
+	items, err := chai.QueryAs[item](db, "SELECT * FROM foo ORDER BY a")
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	require.Equal(t, 1, items[0].A)
+	require.Equal(t, "one", items[0].Name)
+	require.NotNil(t, items[0].C)
+	require.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), *items[0].C)
+	require.Equal(t, item{Base: Base{A: 2}, Name: "two"}, items[1])
+
+	// other types receive the first column
+	names, err := chai.QueryAs[string](db, "SELECT b, a FROM foo WHERE a > ? ORDER BY a", 0)
+	require.NoError(t, err)
+	require.Equal(t, []string{"one", "two"}, names)
+
+	empty, err := chai.QueryAs[item](db, "SELECT * FROM foo WHERE a > 10")
+	require.NoError(t, err)
+	require.Empty(t, empty)
+
+	it, err := chai.QueryOneAs[item](db, "SELECT * FROM foo WHERE a = ?", 2)
+	require.NoError(t, err)
+	require.Equal(t, item{Base: Base{A: 2}, Name: "two"}, it)
+
+	c, err := chai.QueryOneAs[time.Time](db, "SELECT c FROM foo WHERE a = 1")
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), c)
+
+	_, err = chai.QueryOneAs[item](db, "SELECT * FROM foo WHERE a > 10")
+	require.True(t, chai.IsNotFoundError(err))
+
+	_, err = chai.QueryAs[int](db, "SELECT b FROM foo")
+	require.Error(t, err)
+}
+
+func TestSnapshot(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE test (a INTEGER PRIMARY KEY, b TEXT);
+		CREATE INDEX test_b_idx ON test(b);
+		INSERT INTO test (a, b) VALUES (1, 'foo'), (2, 'bar');
+	`)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "snapshot")
+	err = db.SaveSnapshot(path)
+	require.NoError(t, err)
+
+	db2, err := chai.OpenSnapshot(path)
+	require.NoError(t, err)
+	defer db2.Close()
+
+	r, err := db2.QueryRow("SELECT * FROM test WHERE b = 'bar'")
+	require.NoError(t, err)
+	testutil.RequireJSONEq(t, r, `{"a": 2, "b": "bar"}`)
+
+	// the loaded database is independent from the file and the original database
+	err = db2.Exec("INSERT INTO test (a, b) VALUES (3, 'baz')")
+	require.NoError(t, err)
+
+	r, err = db.QueryRow("SELECT COUNT(*) FROM test")
+	require.NoError(t, err)
+	testutil.RequireJSONEq(t, r, `{"COUNT(*)": 2}`)
+
+	t.Run("on-disk database", func(t *testing.T) {
+		db, err := chai.Open(filepath.Join(t.TempDir(), "db"))
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.SaveSnapshot(path)
+		require.Error(t, err)
+	})
+}
+
+func TestReplica(t *testing.T) {
+	dir := t.TempDir()
+	storage := chai.NewLocalObjectStorage(dir)
+
+	export := func(db *chai.DB, name string) {
+		t.Helper()
+
+		w, err := storage.CreateObject(name)
+		require.NoError(t, err)
+		err = db.ExportSST(w)
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+	}
+
+	db, err := chai.Open(filepath.Join(t.TempDir(), "source"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE test (a INTEGER PRIMARY KEY, b TEXT);
+		CREATE INDEX test_b_idx ON test(b);
+		INSERT INTO test (a, b) VALUES (1, 'foo'), (2, 'bar');
+	`)
+	require.NoError(t, err)
+	export(db, "data/1.sst")
+
+	err = db.Exec(`UPDATE test SET b = 'baz' WHERE a = 2`)
+	require.NoError(t, err)
+	export(db, "data/2.sst")
+
+	replica, err := chai.OpenReplica(chai.ReplicaOptions{
+		Storage: storage,
+		Prefix:  "data/",
+	})
+	require.NoError(t, err)
+	defer replica.Close()
+
+	// the last sstable wins
+	r, err := replica.QueryRow("SELECT * FROM test WHERE b = 'baz'")
+	require.NoError(t, err)
+	testutil.RequireJSONEq(t, r, `{"a": 2, "b": "baz"}`)
+
+	// the replica accepts writes
+	err = replica.Exec(`INSERT INTO test (a, b) VALUES (3, 'qux')`)
+	require.NoError(t, err)
+
+	r, err = replica.QueryRow("SELECT COUNT(*) FROM test")
+	require.NoError(t, err)
+	testutil.RequireJSONEq(t, r, `{"COUNT(*)": 3}`)
+
+	// without affecting the source
+	r, err = db.QueryRow("SELECT COUNT(*) FROM test")
+	require.NoError(t, err)
+	testutil.RequireJSONEq(t, r, `{"COUNT(*)": 2}`)
+}
+
+func TestEncryption(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "db")
+
+	keys := map[uint32][]byte{
+		1: []byte("0123456789abcdef0123456789abcdef"),
+		2: []byte("fedcba9876543210"),
+	}
+	keyFn := func(id uint32) ([]byte, error) {
+		k, ok := keys[id]
+		if !ok {
+			return nil, fmt.Errorf("unknown key %d", id)
+		}
+		return k, nil
+	}
+
+	db, err := chai.OpenWith(dir, &chai.Options{
+		Encryption: &chai.EncryptionOptions{KeyID: 1, Key: keyFn},
+	})
+	require.NoError(t, err)
+
+	err = db.Exec(`
+		CREATE TABLE test (a INTEGER PRIMARY KEY, b TEXT);
+		INSERT INTO test (a, b) VALUES (1, 'very secret value');
+	`)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	// the plaintext is never written to disk
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		b, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.NotContains(t, string(b), "very secret value", path)
+		return nil
+	})
+	require.NoError(t, err)
+
+	// opening without the key fails
+	_, err = chai.Open(dir)
+	require.Error(t, err)
+
+	// rotate the key
+	n, err := chai.Reencrypt(dir, chai.EncryptionOptions{KeyID: 2, Key: keyFn})
+	require.NoError(t, err)
+	require.NotZero(t, n)
+	delete(keys, 1)
+
+	db, err = chai.OpenWith(dir, &chai.Options{
+		Encryption: &chai.EncryptionOptions{KeyID: 2, Key: keyFn},
+	})
+	require.NoError(t, err)
+	defer db.Close()
+
+	r, err := db.QueryRow("SELECT * FROM test")
+	require.NoError(t, err)
+	testutil.RequireJSONEq(t, r, `{"a": 1, "b": "very secret value"}`)
+}
+
+func TestCompression(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "db")
+
+	db, err := chai.OpenWith(dir, &chai.Options{
+		Compression:      chai.NoCompression,
+		LevelCompression: []chai.Compression{chai.SnappyCompression, chai.ZstdCompression},
+	})
+	require.NoError(t, err)
+
+	err = db.Exec(`
+		CREATE TABLE test (a INTEGER PRIMARY KEY, b TEXT);
+		INSERT INTO test (a, b) VALUES (1, 'a'), (2, 'b');
+	`)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	// the compression doesn't need to be specified to read the data
+	db, err = chai.Open(dir)
+	require.NoError(t, err)
+	defer db.Close()
+
+	r, err := db.QueryRow(`SELECT COUNT(*) FROM test`)
+	require.NoError(t, err)
+	var count int
+	err = r.Scan(&count)
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+}
+
+func TestUpdateWideRows(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "db")
+
+	db, err := chai.Open(dir)
+	require.NoError(t, err)
+
+	// the updates of the wide rows only write the delta between the rows
+	err = db.Exec(`
+		CREATE TABLE test (a INTEGER PRIMARY KEY, b TEXT, c INTEGER);
+		CREATE INDEX test_c ON test(c);
+	`)
+	require.NoError(t, err)
+	err = db.Exec(`INSERT INTO test (a, b, c) VALUES (1, ?, 0), (2, ?, 0)`, strings.Repeat("b", 500), strings.Repeat("b", 500))
+	require.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		err = db.Exec(`UPDATE test SET c = c + 1 WHERE a = 1`)
+		require.NoError(t, err)
+	}
+	err = db.Exec(`UPDATE test SET b = ? WHERE a = 2`, strings.Repeat("b", 500)+"c")
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	db, err = chai.Open(dir)
+	require.NoError(t, err)
+	defer db.Close()
+
+	r, err := db.QueryRow(`SELECT LEN(b), c FROM test WHERE a = 1`)
+	require.NoError(t, err)
+	var l, c int
+	err = r.Scan(&l, &c)
+	require.NoError(t, err)
+	require.Equal(t, 500, l)
+	require.Equal(t, 10, c)
+
+	r, err = db.QueryRow(`SELECT a FROM test WHERE c = 10`)
+	require.NoError(t, err)
+	var a int
+	err = r.Scan(&a)
+	require.NoError(t, err)
+	require.Equal(t, 1, a)
+
+	r, err = db.QueryRow(`SELECT b FROM test WHERE a = 2`)
+	require.NoError(t, err)
+	var b string
+	err = r.Scan(&b)
+	require.NoError(t, err)
+	require.Equal(t, strings.Repeat("b", 500)+"c", b)
+}
+
+func TestRestorePointInTime(t *testing.T) {
+	dir := t.TempDir()
+	archive := chai.NewLocalObjectStorage(filepath.Join(dir, "archive"))
+
+	db, err := chai.OpenWith(filepath.Join(dir, "db"), &chai.Options{
+		WALArchive: archive,
+	})
+	require.NoError(t, err)
+
+	err = db.Exec(`
+		CREATE TABLE test (a INTEGER PRIMARY KEY, b TEXT);
+		INSERT INTO test (a, b) VALUES (1, 'a'), (2, 'b');
+	`)
+	require.NoError(t, err)
+
+	err = db.Checkpoint(filepath.Join(dir, "checkpoint"))
+	require.NoError(t, err)
+
+	err = db.Exec(`INSERT INTO test (a, b) VALUES (3, 'c')`)
+	require.NoError(t, err)
+
+	target := time.Now()
+	time.Sleep(time.Millisecond)
+
+	// oops
+	err = db.Exec(`DELETE FROM test`)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	path := filepath.Join(dir, "restored")
+	err = chai.RestorePointInTime(path, chai.PointInTimeOptions{
+		Checkpoint: filepath.Join(dir, "checkpoint"),
+		Archive:    archive,
+		Path:       filepath.Join(dir, "db"),
+		Target:     target,
+	})
+	require.NoError(t, err)
+
+	db, err = chai.Open(path)
+	require.NoError(t, err)
+	defer db.Close()
+
+	r, err := db.QueryRow(`SELECT COUNT(*) FROM test`)
+	require.NoError(t, err)
+	var count int
+	require.NoError(t, r.Scan(&count))
+	require.Equal(t, 3, count)
+}
+
+func TestRestore(t *testing.T) {
+	dir := t.TempDir()
+
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE test (a INTEGER PRIMARY KEY, b TEXT);
+		CREATE INDEX test_b ON test (b);
+		INSERT INTO test (a, b) VALUES (1, 'a'), (2, 'b'), (3, 'c');
+	`)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	err = db.Backup(context.Background(), &buf)
+	require.NoError(t, err)
+
+	path := filepath.Join(dir, "restored")
+	err = chai.Restore(context.Background(), bytes.NewReader(buf.Bytes()), path)
+	require.NoError(t, err)
+
+	t.Run("Existing path", func(t *testing.T) {
+		err := chai.Restore(context.Background(), bytes.NewReader(buf.Bytes()), path)
+		require.Error(t, err)
+	})
+
+	t.Run("Invalid backup", func(t *testing.T) {
+		p := filepath.Join(dir, "invalid")
+		err := chai.Restore(context.Background(), strings.NewReader("not a backup"), p)
+		require.Error(t, err)
+
+		_, err = os.Stat(p)
+		require.True(t, os.IsNotExist(err))
+	})
+
+	rdb, err := chai.Open(path)
+	require.NoError(t, err)
+	defer rdb.Close()
+
+	r, err := rdb.QueryRow(`SELECT COUNT(*) FROM test WHERE b >= 'b'`)
+	require.NoError(t, err)
+	var count int
+	require.NoError(t, r.Scan(&count))
+	require.Equal(t, 2, count)
+}
+
+func TestOpenReadOnly(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := chai.OpenReadOnly(filepath.Join(dir, "missing"))
+	require.Error(t, err)
+
+	// the database stays open in read-write mode while it is inspected
+	db, err := chai.Open(dir)
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE test (a INTEGER PRIMARY KEY, b TEXT);
+		CREATE INDEX test_b_idx ON test(b);
+		INSERT INTO test (a, b) VALUES (1, 'foo'), (2, 'bar');
+	`)
+	require.NoError(t, err)
+
+	rdb, err := chai.OpenReadOnly(dir)
+	require.NoError(t, err)
+	defer rdb.Close()
+
+	r, err := rdb.QueryRow("SELECT * FROM test WHERE b = 'bar'")
+	require.NoError(t, err)
+	testutil.RequireJSONEq(t, r, `{"a": 2, "b": "bar"}`)
+
+	// sorting uses transient trees
+	r, err = rdb.QueryRow("SELECT a FROM test ORDER BY b DESC")
+	require.NoError(t, err)
+	testutil.RequireJSONEq(t, r, `{"a": 1}`)
+
+	conn, err := rdb.Connect()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// writes are rejected when the query is prepared
+	for _, q := range []string{
+		"INSERT INTO test (a, b) VALUES (3, 'baz')",
+		"UPDATE test SET b = 'baz'",
+		"DELETE FROM test",
+		"CREATE TABLE other (a INT)",
+		"DROP INDEX test_b_idx",
+		"SELECT 1; DELETE FROM test",
+		"BEGIN",
+	} {
+		_, err = conn.Prepare(q)
+		require.ErrorIs(t, err, chai.ErrReadOnly, q)
+	}
+
+	_, err = conn.Begin(true)
+	require.ErrorIs(t, err, chai.ErrReadOnly)
+
+	tx, err := conn.Begin(false)
+	require.NoError(t, err)
+	r, err = tx.QueryRow("SELECT COUNT(*) FROM test")
+	require.NoError(t, err)
+	testutil.RequireJSONEq(t, r, `{"COUNT(*)": 2}`)
+	require.NoError(t, tx.Rollback())
+
+	require.NoError(t, conn.Exec("BEGIN READ ONLY; SELECT * FROM test; ROLLBACK"))
+
+	// the original database is still writable
+	require.NoError(t, db.Exec("INSERT INTO test (a, b) VALUES (3, 'baz')"))
+}
+
+func TestOpenWith(t *testing.T) {
+	t.Run("Engine", func(t *testing.T) {
+		dir := t.TempDir()
+
+		db, err := chai.OpenWith(filepath.Join(dir, "db"), &chai.Options{Engine: chai.EngineMemory})
+		require.NoError(t, err)
+		require.NoError(t, db.Exec("CREATE TABLE foo (a INTEGER PRIMARY KEY)"))
+		_, err = db.Stats()
+		require.Error(t, err)
+		require.NoError(t, db.Close())
+
+		// nothing is written on disk
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		require.Empty(t, entries)
+
+		_, err = chai.OpenWith(":memory:", &chai.Options{Engine: chai.EngineDisk})
+		require.Error(t, err)
+
+		db, err = chai.OpenWith(filepath.Join(dir, "db"), &chai.Options{Engine: chai.EngineDisk})
+		require.NoError(t, err)
+		defer db.Close()
+		_, err = db.Stats()
+		require.NoError(t, err)
+	})
+
+	t.Run("QueryLogger", func(t *testing.T) {
+		var queries []string
+		db, err := chai.OpenWith(":memory:", &chai.Options{
+			QueryLogger: &chai.QueryLogger{
+				OnQuery: func(ctx context.Context, e chai.QueryEvent) {
+					queries = append(queries, e.SQL)
+				},
+			},
+		})
+		require.NoError(t, err)
+		defer db.Close()
+
+		require.NoError(t, db.Exec("SELECT 1"))
+		require.Equal(t, []string{"SELECT 1"}, queries)
+	})
+}
+
+func TestTimeZone(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	db, err := chai.OpenWith(dir, &chai.Options{TimeZone: paris})
+	require.NoError(t, err)
+
+	conn, err := db.Connect()
+	require.NoError(t, err)
+
+	// texts without time zone are parsed in the time zone of the connection
+	err = conn.Exec(`
+		CREATE TABLE test(a TIMESTAMP WITH TIME ZONE PRIMARY KEY, b TIMESTAMP);
+		CREATE INDEX ON test(b);
+		INSERT INTO test (a, b) VALUES ('2024-01-15 10:00:00', '2024-01-15 10:00:00');
+		INSERT INTO test (a, b) VALUES ('2024-07-15T10:00:00Z', '2024-07-15T10:00:00Z');
+	`)
+	require.NoError(t, err)
+
+	// the same instant, written in another time zone
+	err = conn.Exec(`INSERT INTO test (a) VALUES ('2024-01-15T09:00:00Z')`)
+	require.Error(t, err)
+
+	query := func(q string) []string {
+		t.Helper()
+
+		res, err := conn.Query(q)
+		require.NoError(t, err)
+		defer res.Close()
+
+		var got []string
+		err = res.Iterate(func(r *chai.Row) error {
+			var ts time.Time
+			err := r.Scan(&ts)
+			if err != nil {
+				return err
+			}
+			got = append(got, ts.Format(time.RFC3339))
+			return nil
+		})
+		require.NoError(t, err)
+		return got
+	}
+
+	// values are displayed in the time zone of the connection
+	require.Equal(t, []string{"2024-01-15T10:00:00+01:00", "2024-07-15T12:00:00+02:00"}, query(`SELECT a FROM test ORDER BY a`))
+	require.Equal(t, []string{"2024-01-15T10:00:00Z", "2024-07-15T10:00:00Z"}, query(`SELECT b FROM test ORDER BY a`))
+	require.Equal(t, []string{"2024-07-15T12:00:00+02:00"}, query(`SELECT a FROM test WHERE a > '2024-01-15 10:00:00'`))
+	require.Equal(t, []string{"2024-01-15T10:00:00+01:00"}, query(`SELECT a FROM test WHERE a = CAST('2024-01-15 10:00:00' AS TIMESTAMPTZ)`))
+
+	r, err := conn.QueryRow(`SELECT * FROM test WHERE a < '2024-02-01'`)
+	require.NoError(t, err)
+	js, err := r.MarshalJSON()
+	require.NoError(t, err)
+	require.JSONEq(t, `{"a": "2024-01-15T10:00:00+01:00", "b": "2024-01-15T10:00:00Z"}`, string(js))
+
+	// SET TIME ZONE changes the time zone of the connection
+	err = conn.Exec(`SET TIME ZONE '-05:00'`)
+	require.NoError(t, err)
+	require.Equal(t, []string{"2024-01-15T04:00:00-05:00", "2024-07-15T05:00:00-05:00"}, query(`SELECT a FROM test ORDER BY a`))
+	require.Equal(t, []string{"2024-01-15T04:00:00-05:00"}, query(`SELECT a FROM test WHERE a = '2024-01-15 04:00:00'`))
+
+	err = conn.Exec(`SET TIME ZONE 'UTC'`)
+	require.NoError(t, err)
+	require.Equal(t, []string{"2024-01-15T09:00:00Z", "2024-07-15T10:00:00Z"}, query(`SELECT a FROM test ORDER BY a`))
+
+	// it also applies to the current transaction
+	err = conn.Exec(`BEGIN; SET TIME ZONE 'Europe/Paris'`)
+	require.NoError(t, err)
+	require.Equal(t, []string{"2024-01-15T10:00:00+01:00", "2024-07-15T12:00:00+02:00"}, query(`SELECT a FROM test ORDER BY a`))
+	require.NoError(t, conn.Exec(`ROLLBACK`))
+
+	err = conn.Exec(`SET TIME ZONE 'Nowhere/Nowhere'`)
+	require.Error(t, err)
+
+	conn.SetTimeZone(time.UTC)
+	require.NoError(t, conn.Exec(`SET TIME ZONE DEFAULT`))
+	require.Equal(t, []string{"2024-01-15T10:00:00+01:00", "2024-07-15T12:00:00+02:00"}, query(`SELECT a FROM test ORDER BY a`))
+
+	// the type is preserved when the database is reopened
+	require.NoError(t, conn.Close())
+	require.NoError(t, db.Close())
+
+	db, err = chai.Open(dir)
+	require.NoError(t, err)
+	defer db.Close()
+
+	r, err = db.QueryRow(`SELECT a FROM test ORDER BY a DESC`)
+	require.NoError(t, err)
+	tp, err := r.GetColumnType("a")
+	require.NoError(t, err)
+	require.Equal(t, "timestamptz", tp)
+	var ts time.Time
+	require.NoError(t, r.Scan(&ts))
+	require.Equal(t, "2024-07-15T10:00:00Z", ts.Format(time.RFC3339))
+}
+
+func TestDateTimeFunctions(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	conn, err := db.Connect()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	err = conn.Exec(`
+		CREATE TABLE test(a TIMESTAMPTZ, b TIMESTAMP);
+		INSERT INTO test (a, b) VALUES ('2024-03-31T23:30:00Z', '2024-03-31T23:30:00Z');
+	`)
+	require.NoError(t, err)
+
+	query := func(q string) string {
+		t.Helper()
+
+		r, err := conn.QueryRow(q)
+		require.NoError(t, err)
+		var s string
+		require.NoError(t, r.Scan(&s))
+		return s
+	}
+
+	// the fields of TIMESTAMPTZ values are read in the time zone of the connection,
+	// the fields of TIMESTAMP values in UTC
+	require.NoError(t, conn.Exec(`SET TIME ZONE 'Europe/Paris'`))
+	require.Equal(t, "2024-04-01 01:30 +0200", query(`SELECT strftime('%Y-%m-%d %H:%M %z', a) FROM test`))
+	require.Equal(t, "2024-03-31 23:30 +0000", query(`SELECT strftime('%Y-%m-%d %H:%M %z', b) FROM test`))
+	require.Equal(t, "4", query(`SELECT CAST(EXTRACT(month FROM a) AS TEXT) FROM test`))
+	require.Equal(t, "3", query(`SELECT CAST(EXTRACT(month FROM b) AS TEXT) FROM test`))
+	require.Equal(t, "7200", query(`SELECT CAST(EXTRACT(timezone FROM a) AS TEXT) FROM test`))
+	require.Equal(t, "2024-04-01 00:00 +0200", query(`SELECT strftime('%Y-%m-%d %H:%M %z', date_trunc('day', a)) FROM test`))
+	require.Equal(t, "1 day 01:00:00", query(`SELECT age(date_trunc('hour', a), date_trunc('day', b)) FROM test`))
+
+	require.NoError(t, conn.Exec(`SET TIME ZONE 'America/New_York'`))
+	require.Equal(t, "2024-03-31 19:30 -0400", query(`SELECT strftime('%Y-%m-%d %H:%M %z', a) FROM test`))
+	require.Equal(t, "31", query(`SELECT CAST(EXTRACT(day FROM a) AS TEXT) FROM test`))
+
+	// CURRENT_DATE is the date of the start of the transaction in the time zone of the connection
+	for _, tz := range []string{"Pacific/Kiritimati", "Pacific/Pago_Pago"} {
+		loc, err := time.LoadLocation(tz)
+		require.NoError(t, err)
+		require.NoError(t, conn.Exec(`SET TIME ZONE '`+tz+`'`))
+
+		before := time.Now().In(loc).Format("2006-01-02")
+		got := query(`SELECT strftime('%F', CURRENT_DATE)`)
+		after := time.Now().In(loc).Format("2006-01-02")
+		require.Contains(t, []string{before, after}, got)
+	}
+}
+
+func TestCoercion(t *testing.T) {
+	db, err := chai.OpenWith(":memory:", &chai.Options{Coercion: chai.CoercionStrict})
+	require.NoError(t, err)
+	defer db.Close()
+
+	conn, err := db.Connect()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	err = conn.Exec(`
+		CREATE TABLE test(a INT PRIMARY KEY, b TEXT);
+		INSERT INTO test (a, b) VALUES (1, '1'), (2, 'foo');
+	`)
+	require.NoError(t, err)
+
+	count := func(q string) (int, error) {
+		t.Helper()
+
+		r, err := conn.QueryRow(q)
+		if err != nil {
+			return 0, err
+		}
+		var n int
+		err = r.Scan(&n)
+		return n, err
+	}
+
+	// string literals are converted in both modes
+	n, err := count(`SELECT COUNT(*) FROM test WHERE a = '1'`)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	_, err = count(`SELECT COUNT(*) FROM test WHERE a = 'foo'`)
+	require.EqualError(t, err, `invalid input syntax for type integer: "foo"`)
+
+	// strict mode rejects the other comparisons when the query is planned
+	_, err = count(`SELECT COUNT(*) FROM test WHERE b = 1`)
+	require.EqualError(t, err, "cannot compare text with integer")
+	_, err = count(`SELECT COUNT(*) FROM test WHERE a = b`)
+	require.EqualError(t, err, "cannot compare integer with text")
+	_, err = count(`SELECT COUNT(*) FROM test WHERE a IN (1, true)`)
+	require.EqualError(t, err, "cannot compare integer with boolean")
+	// or when it runs if the types are only known then
+	_, err = count(`SELECT COUNT(*) FROM test WHERE a + 1 = b`)
+	require.EqualError(t, err, "cannot compare integer with text")
+
+	// SET COERCION changes the mode of the connection
+	require.NoError(t, conn.Exec(`SET COERCION LENIENT`))
+	n, err = count(`SELECT COUNT(*) FROM test WHERE a = b`)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	n, err = count(`SELECT COUNT(*) FROM test WHERE b = 1`)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+
+	// it also applies to the current transaction
+	require.NoError(t, conn.Exec(`BEGIN; SET COERCION STRICT`))
+	_, err = count(`SELECT COUNT(*) FROM test WHERE a = b`)
+	require.Error(t, err)
+	require.NoError(t, conn.Exec(`ROLLBACK`))
+
+	conn.SetCoercion(chai.CoercionLenient)
+	require.NoError(t, conn.Exec(`SET COERCION DEFAULT`))
+	_, err = count(`SELECT COUNT(*) FROM test WHERE a = b`)
+	require.Error(t, err)
+
+	// other connections use the default mode of the database
+	n, err = count(`SELECT COUNT(*) FROM test WHERE a = 1`)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	_, err = db.QueryRow(`SELECT COUNT(*) FROM test WHERE b = 1`)
+	require.EqualError(t, err, "cannot compare text with integer")
+}
+func TestUUID(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`CREATE TABLE test(id UUID PRIMARY KEY, n INT)`)
+	require.NoError(t, err)
+
+	// arrays of 16 bytes are stored as UUIDs
+	id := [16]byte{0xf8, 0x1d, 0x4f, 0xae, 0x7d, 0xec, 0x11, 0xd0, 0xa7, 0x65, 0x00, 0xa0, 0xc9, 0x1e, 0x6b, 0xf6}
+	err = db.Exec(`INSERT INTO test (id, n) VALUES (?, 0)`, id)
+	require.NoError(t, err)
+
+	for i := 1; i <= 10; i++ {
+		err = db.Exec(`INSERT INTO test (id, n) VALUES (gen_uuid_v7(), ?)`, i)
+		require.NoError(t, err)
+	}
+
+	var got [16]byte
+	var s string
+	r, err := db.QueryRow(`SELECT id, id FROM test WHERE id = 'F81D4FAE-7DEC-11D0-A765-00A0C91E6BF6'`)
+	require.NoError(t, err)
+	require.NoError(t, r.Scan(&got, &s))
+	require.Equal(t, id, got)
+	require.Equal(t, "f81d4fae-7dec-11d0-a765-00a0c91e6bf6", s)
+
+	// generated v7 UUIDs sort in insertion order
+	conn, err := db.Connect()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	res, err := conn.Query(`SELECT n FROM test WHERE n > 0`)
+	require.NoError(t, err)
+	defer res.Close()
+
+	var ns []int
+	err = res.Iterate(func(r *chai.Row) error {
+		var n int
+		err := r.Scan(&n)
+		ns = append(ns, n)
+		return err
+	})
+	require.NoError(t, err)
+	require.Equal(t, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, ns)
+}
+
+func TestRandomSeed(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	conn, err := db.Connect()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	err = conn.Exec(`CREATE TABLE test(a INT PRIMARY KEY)`)
+	require.NoError(t, err)
+	for i := 1; i <= 100; i++ {
+		err = conn.Exec(`INSERT INTO test (a) VALUES (?)`, i)
+		require.NoError(t, err)
+	}
+
+	sample := func() []int {
+		t.Helper()
+
+		res, err := conn.Query(`SELECT a FROM test WHERE random_between(1, 10) = 1`)
+		require.NoError(t, err)
+		defer res.Close()
+
+		var as []int
+		err = res.Iterate(func(r *chai.Row) error {
+			var a int
+			err := r.Scan(&a)
+			as = append(as, a)
+			return err
+		})
+		require.NoError(t, err)
+		return as
+	}
+
+	// the seed of the connection is kept across statements and transactions
+	_, err = conn.QueryRow(`SELECT setseed(0.42)`)
+	require.NoError(t, err)
+	first := sample()
+	require.NotEmpty(t, first)
+
+	_, err = conn.QueryRow(`SELECT setseed(0.42)`)
+	require.NoError(t, err)
+	require.Equal(t, first, sample())
+
+	// another seed returns another sample
+	_, err = conn.QueryRow(`SELECT setseed(-0.42)`)
+	require.NoError(t, err)
+	require.NotEqual(t, first, sample())
+}
+
+func TestSequenceFunctions(t *testing.T) {
+	dir := t.TempDir()
+	db, err := chai.Open(filepath.Join(dir, "db"))
+	require.NoError(t, err)
+
+	err = db.Exec(`CREATE SEQUENCE seq CACHE 10`)
+	require.NoError(t, err)
+
+	connA, err := db.Connect()
+	require.NoError(t, err)
+	connB, err := db.Connect()
+	require.NoError(t, err)
+
+	queryRow := func(q interface {
+		QueryRow(string, ...any) (*chai.Row, error)
+	}, query string, want string) {
+		t.Helper()
+
+		r, err := q.QueryRow(query)
+		require.NoError(t, err)
+		testutil.RequireJSONEq(t, r, want)
+	}
+
+	queryRow(connA, `SELECT nextval('seq') AS v`, `{"v": 1}`)
+	queryRow(connB, `SELECT nextval('seq') AS v`, `{"v": 2}`)
+
+	// currval and lastval return the values of each connection
+	queryRow(connA, `SELECT currval('seq') AS c, lastval() AS l`, `{"c": 1, "l": 1}`)
+	queryRow(connB, `SELECT currval('seq') AS c, lastval() AS l`, `{"c": 2, "l": 2}`)
+
+	// the values of a rolled back transaction are not reused
+	tx, err := connA.Begin(true)
+	require.NoError(t, err)
+	queryRow(tx, `SELECT nextval('seq') AS v`, `{"v": 3}`)
+	require.NoError(t, tx.Rollback())
+
+	queryRow(connA, `SELECT currval('seq') AS v`, `{"v": 3}`)
+	queryRow(connB, `SELECT nextval('seq') AS v`, `{"v": 4}`)
+
+	require.NoError(t, connA.Close())
+	require.NoError(t, connB.Close())
+	require.NoError(t, db.Close())
+
+	// nor after reopening the database
+	db, err = chai.Open(filepath.Join(dir, "db"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	r, err := db.QueryRow(`SELECT nextval('seq') > 4 AS ok`)
+	require.NoError(t, err)
+	testutil.RequireJSONEq(t, r, `{"ok": true}`)
+}
+
+// countdownContext is canceled after its Done method is called n times,
+// which cancels a query in the middle of a scan.
+type countdownContext struct {
+	context.Context
+
+	n    int
+	done chan struct{}
+}
+
+func newCountdownContext(n int) *countdownContext {
+	return &countdownContext{Context: context.Background(), n: n, done: make(chan struct{})}
+}
+
+func (c *countdownContext) Done() <-chan struct{} {
+	if c.n == 0 {
+		close(c.done)
+	}
+	c.n--
+	return c.done
+}
+
+func (c *countdownContext) Err() error {
+	if c.n < 0 {
+		return context.Canceled
+	}
+	return nil
+}
+
+func TestQueryContext(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE test(a INT PRIMARY KEY, b INT);
+		CREATE INDEX ON test(b);
+		INSERT INTO test (a, b) SELECT generate_series, 0 FROM generate_series(1, 1000);
+	`)
+	require.NoError(t, err)
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := db.ExecContext(ctx, `INSERT INTO test (a, b) VALUES (0, 0)`)
+		require.ErrorIs(t, err, context.Canceled)
+
+		_, err = db.QueryRowContext(ctx, `SELECT COUNT(*) FROM test`)
+		require.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("during a scan", func(t *testing.T) {
+		// the aggregation doesn't return any row before the end of the scan
+		for _, q := range []string{
+			`SELECT COUNT(*) FROM test`,
+			`SELECT COUNT(*) FROM test WHERE b = 0`,
+			`SELECT COUNT(*) FROM generate_series(1, 1000)`,
+		} {
+			ctx := newCountdownContext(100)
+			_, err = db.QueryRowContext(ctx, q)
+			require.ErrorIs(t, err, context.Canceled, q)
+			require.Negative(t, ctx.n, q)
+		}
+	})
+
+	t.Run("rollback", func(t *testing.T) {
+		err := db.ExecContext(newCountdownContext(500), `UPDATE test SET b = 1`)
+		require.ErrorIs(t, err, context.Canceled)
+
+		r, err := db.QueryRow(`SELECT COUNT(*) AS n FROM test WHERE b = 1`)
+		require.NoError(t, err)
+		testutil.RequireJSONEq(t, r, `{"n": 0}`)
+	})
+
+	t.Run("transaction", func(t *testing.T) {
+		conn, err := db.Connect()
+		require.NoError(t, err)
+		defer conn.Close()
+
+		tx, err := conn.Begin(false)
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		_, err = tx.QueryRowContext(newCountdownContext(100), `SELECT COUNT(*) FROM test`)
+		require.ErrorIs(t, err, context.Canceled)
+
+		// the transaction can still be used
+		r, err := tx.QueryRowContext(context.Background(), `SELECT COUNT(*) AS n FROM test`)
+		require.NoError(t, err)
+		testutil.RequireJSONEq(t, r, `{"n": 1000}`)
+	})
+
+	t.Run("deadline", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		_, err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM generate_series(1, 1000000000000)`)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
+func TestLocations(t *testing.T) {
+	dir := t.TempDir()
+	opts := chai.Options{
+		Locations: map[string]string{"cold": filepath.Join(dir, "cold")},
+	}
+
+	db, err := chai.OpenWith(filepath.Join(dir, "db"), &opts)
+	require.NoError(t, err)
+
+	err = db.Exec(`
+		CREATE TABLE hot (a INTEGER PRIMARY KEY, b TEXT);
+		CREATE TABLE archive (a INTEGER PRIMARY KEY, b TEXT UNIQUE) WITH (location = 'cold');
+		CREATE INDEX archive_a_b_idx ON archive(a, b);
+		INSERT INTO hot (a, b) VALUES (1, 'a'), (2, 'b');
+		INSERT INTO archive (a, b) VALUES (1, 'old a'), (2, 'old b'), (3, 'old c');
+	`)
+	require.NoError(t, err)
+
+	err = db.Exec(`CREATE TABLE other (a INT) WITH (location = 'unknown')`)
+	require.Error(t, err)
+
+	// rolled back writes are removed from every location
+	conn, err := db.Connect()
+	require.NoError(t, err)
+	tx, err := conn.Begin(true)
+	require.NoError(t, err)
+	require.NoError(t, tx.Exec("INSERT INTO hot (a, b) VALUES (3, 'c')"))
+	require.NoError(t, tx.Exec("INSERT INTO archive (a, b) VALUES (4, 'old d')"))
+	require.NoError(t, tx.Rollback())
+	require.NoError(t, conn.Close())
+
+	r, err := db.QueryRow("SELECT COUNT(*) FROM archive")
+	require.NoError(t, err)
+	testutil.RequireJSONEq(t, r, `{"COUNT(*)": 3}`)
+
+	_, err = db.Check(context.Background())
+	require.NoError(t, err)
+	require.Error(t, db.Backup(context.Background(), io.Discard))
+	require.NoError(t, db.Close())
+
+	// the table is stored in the location
+	entries, err := os.ReadDir(filepath.Join(dir, "cold", "pebble"))
+	require.NoError(t, err)
+	require.NotEmpty(t, entries)
+
+	// the location is required to open the database
+	_, err = chai.Open(filepath.Join(dir, "db"))
+	require.Error(t, err)
+
+	db, err = chai.OpenWith(filepath.Join(dir, "db"), &opts)
+	require.NoError(t, err)
+	defer db.Close()
+
+	r, err = db.QueryRow("SELECT a FROM archive WHERE b = 'old b'")
+	require.NoError(t, err)
+	testutil.RequireJSONEq(t, r, `{"a": 2}`)
+
+	r, err = db.QueryRow("SELECT COUNT(*) FROM hot")
+	require.NoError(t, err)
+	testutil.RequireJSONEq(t, r, `{"COUNT(*)": 2}`)
+
+	r, err = db.QueryRow("SELECT sql FROM __chai_catalog WHERE name = 'archive'")
+	require.NoError(t, err)
+	testutil.RequireJSONEq(t, r, `{"sql": "CREATE TABLE archive (a INTEGER NOT NULL, b TEXT, CONSTRAINT archive_pk PRIMARY KEY (a), CONSTRAINT archive_b_unique UNIQUE (b)) WITH (location = \"cold\")"}`)
+}
+
+func TestCrashHook(t *testing.T) {
+	// runs the workload until it fails, and returns the number of rows
+	// inserted by the committed transactions
+	workload := func(db *chai.DB) (int, error) {
+		err := db.Exec(`CREATE TABLE test (a INT PRIMARY KEY, b TEXT)`)
+		if err != nil {
+			return 0, err
+		}
+		err = db.Exec(`CREATE INDEX test_b_idx ON test (b)`)
+		if err != nil {
+			return 0, err
+		}
+
+		var n int
+		for i := 0; i < 5; i++ {
+			err = db.Exec(`INSERT INTO test (a, b) VALUES (?, ?)`, i, fmt.Sprint(i))
+			if err != nil {
+				return n, err
+			}
+			n++
+
+			// a transaction that is rolled back
+			conn, err := db.Connect()
+			if err != nil {
+				return n, err
+			}
+			tx, err := conn.Begin(true)
+			if err == nil {
+				err = tx.Exec(`UPDATE test SET b = 'rolled back'`)
+				err = errors.CombineErrors(err, tx.Rollback())
+			}
+			err = errors.CombineErrors(err, conn.Close())
+			if err != nil {
+				return n, err
+			}
+		}
+
+		return n, nil
+	}
+
+	// count the writes at which a crash can be simulated
+	var total int
+	db, err := chai.OpenWith(filepath.Join(t.TempDir(), "db"), &chai.Options{
+		CrashHook: func(p chai.CrashPoint) chai.CrashMode {
+			total++
+			return chai.NoCrash
+		},
+	})
+	require.NoError(t, err)
+	_, err = workload(db)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	for i := 1; i <= total; i++ {
+		for _, mode := range []chai.CrashMode{chai.CrashBefore, chai.CrashAfter} {
+			path := filepath.Join(t.TempDir(), "db")
+
+			var calls int
+			var point chai.CrashPoint
+			opts := chai.Options{
+				CrashHook: func(p chai.CrashPoint) chai.CrashMode {
+					calls++
+					if calls == i {
+						point = p
+						return mode
+					}
+					return chai.NoCrash
+				},
+			}
+
+			n := -1
+			db, err := chai.OpenWith(path, &opts)
+			if err == nil {
+				n, err = workload(db)
+				err = errors.CombineErrors(err, db.Close())
+			}
+			require.ErrorIs(t, err, chai.ErrCrashed)
+
+			report, err := chai.VerifyRecovery(context.Background(), path, &opts)
+			require.NoError(t, err)
+			require.True(t, report.OK(), "crash %d (%s): %v", i, point, report.Problems)
+
+			db, err = chai.OpenWith(path, nil)
+			require.NoError(t, err)
+
+			// only the committed rows are found, along with the row
+			// of the transaction that crashed after being committed
+			r, err := db.QueryRow(`SELECT COUNT(*) FROM test`)
+			if err == nil {
+				var count int
+				require.NoError(t, r.Scan(&count))
+				if mode == chai.CrashAfter && point == chai.CrashPointCommit && count == n+1 {
+					count--
+				}
+				require.Equal(t, n, count, "crash %d (%s)", i, point)
+			}
+			require.NoError(t, db.Close())
+		}
+	}
+}
+
+func TestStatementCache(t *testing.T) {
+	columns := func(t *testing.T, db *chai.DB, q string) []string {
+		t.Helper()
+
+		r, err := db.QueryRow(q)
+		require.NoError(t, err)
+		cols, err := r.Columns()
+		require.NoError(t, err)
+		return cols
+	}
+
+	for _, size := range []int{0, 1, -1} {
+		t.Run(fmt.Sprintf("size %d", size), func(t *testing.T) {
+			db, err := chai.OpenWith(":memory:", &chai.Options{StatementCacheSize: size})
+			require.NoError(t, err)
+			defer db.Close()
+
+			err = db.Exec("CREATE TABLE foo (a INTEGER PRIMARY KEY, b TEXT); INSERT INTO foo VALUES (1, 'one')")
+			require.NoError(t, err)
+
+			for i := 0; i < 3; i++ {
+				require.Equal(t, []string{"a", "b"}, columns(t, db, "SELECT * FROM foo"))
+			}
+
+			// the parameters of a query are not kept by the cache
+			for i := 2; i <= 3; i++ {
+				err = db.Exec("INSERT INTO foo (a, b) VALUES (?, ?)", i, fmt.Sprint(i))
+				require.NoError(t, err)
+			}
+			var sum int
+			r, err := db.QueryRow("SELECT SUM(a) FROM foo WHERE b != ?", "one")
+			require.NoError(t, err)
+			require.NoError(t, r.Scan(&sum))
+			require.Equal(t, 5, sum)
+
+			// the columns of the new schema are returned
+			err = db.Exec("ALTER TABLE foo ADD COLUMN c INTEGER")
+			require.NoError(t, err)
+			require.Equal(t, []string{"a", "b", "c"}, columns(t, db, "SELECT * FROM foo"))
+
+			err = db.Exec("DROP TABLE foo; CREATE TABLE foo (d TEXT PRIMARY KEY); INSERT INTO foo VALUES ('four')")
+			require.NoError(t, err)
+			require.Equal(t, []string{"d"}, columns(t, db, "SELECT * FROM foo"))
+
+			// the changes made by a transaction are only visible to it
+			conn, err := db.Connect()
+			require.NoError(t, err)
+			defer conn.Close()
+			tx, err := conn.Begin(true)
+			require.NoError(t, err)
+			err = tx.Exec("ALTER TABLE foo ADD COLUMN e INTEGER")
+			require.NoError(t, err)
+			r, err = tx.QueryRow("SELECT * FROM foo")
+			require.NoError(t, err)
+			cols, err := r.Columns()
+			require.NoError(t, err)
+			require.Equal(t, []string{"d", "e"}, cols)
+			require.Equal(t, []string{"d"}, columns(t, db, "SELECT * FROM foo"))
+			require.NoError(t, tx.Rollback())
+			require.Equal(t, []string{"d"}, columns(t, db, "SELECT * FROM foo"))
+		})
+	}
+}
+
+func TestTxHooks(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec("CREATE TABLE foo (a INTEGER PRIMARY KEY)")
+	require.NoError(t, err)
+
+	conn, err := db.Connect()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	t.Run("Commit", func(t *testing.T) {
+		var calls []string
+		tx, err := conn.Begin(true)
+		require.NoError(t, err)
+		tx.OnCommit(func() { calls = append(calls, "commit 1") })
+		tx.OnCommit(func() {
+			// the transaction is over and the database can be used
+			var n int
+			r, err := db.QueryRow("SELECT COUNT(*) FROM foo")
+			require.NoError(t, err)
+			require.NoError(t, r.Scan(&n))
+			require.NoError(t, db.Exec("INSERT INTO foo (a) VALUES (?)", n+1))
+			calls = append(calls, "commit 2")
+		})
+		tx.OnRollback(func() { calls = append(calls, "rollback") })
+
+		require.NoError(t, tx.Exec("INSERT INTO foo (a) VALUES (1)"))
+		require.Empty(t, calls)
+		require.NoError(t, tx.Commit())
+		require.Equal(t, []string{"commit 1", "commit 2"}, calls)
+
+		// rolling back a committed transaction doesn't call the hooks
+		require.Error(t, tx.Rollback())
+		require.Equal(t, []string{"commit 1", "commit 2"}, calls)
+
+		var n int
+		r, err := db.QueryRow("SELECT COUNT(*) FROM foo")
+		require.NoError(t, err)
+		require.NoError(t, r.Scan(&n))
+		require.Equal(t, 2, n)
+	})
+
+	t.Run("Rollback", func(t *testing.T) {
+		var calls []string
+		err := conn.Update(func(tx *chai.Tx) error {
+			tx.OnCommit(func() { calls = append(calls, "commit") })
+			tx.OnRollback(func() { calls = append(calls, "rollback 1") })
+			tx.OnRollback(func() { calls = append(calls, "rollback 2") })
+
+			return tx.Exec("INSERT INTO foo (a) VALUES (1)")
+		})
+		require.Error(t, err)
+		require.Equal(t, []string{"rollback 1", "rollback 2"}, calls)
+	})
+
+	t.Run("Failed commit", func(t *testing.T) {
+		var calls []string
+		tx, err := conn.Begin(false)
+		require.NoError(t, err)
+		defer tx.Rollback()
+		tx.OnCommit(func() { calls = append(calls, "commit") })
+		tx.OnRollback(func() { calls = append(calls, "rollback") })
+
+		require.Error(t, tx.Commit())
+		require.Empty(t, calls)
+		require.NoError(t, tx.Rollback())
+		require.Equal(t, []string{"rollback"}, calls)
+	})
+}
+
+func TestQueryLogger(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	var events []chai.QueryEvent
+	db.SetQueryLogger(&chai.QueryLogger{
+		OnQuery: func(ctx context.Context, e chai.QueryEvent) {
+			events = append(events, e)
+		},
+	})
+
+	err = db.Exec("CREATE TABLE foo (a INTEGER PRIMARY KEY, b TEXT)")
+	require.NoError(t, err)
+	err = db.Exec("INSERT INTO foo (a, b) VALUES (1, 'a'), (2, 'b'), (?, ?)", 3, "c")
+	require.NoError(t, err)
+	err = db.Exec("UPDATE foo SET b = 'z' WHERE a > 1")
+	require.NoError(t, err)
+	var n int
+	r, err := db.QueryRow("SELECT COUNT(*) FROM foo")
+	require.NoError(t, err)
+	require.NoError(t, r.Scan(&n))
+	err = db.Exec("INSERT INTO foo (a, b) VALUES (1, 'a')")
+	require.Error(t, err)
+	err = db.Exec("DELETE FROM foo")
+	require.NoError(t, err)
+
+	require.Len(t, events, 6)
+	require.Equal(t, "CREATE TABLE foo (a INTEGER PRIMARY KEY, b TEXT)", events[0].SQL)
+	require.Equal(t, int64(0), events[0].RowsAffected)
+	require.Equal(t, []any{3, "c"}, events[1].Args)
+	require.Equal(t, int64(3), events[1].RowsAffected)
+	require.Equal(t, int64(2), events[2].RowsAffected)
+	require.Equal(t, int64(0), events[3].RowsAffected)
+	require.Error(t, events[4].Err)
+	require.Equal(t, int64(-1), events[5].RowsAffected)
+	for i, e := range events {
+		require.Positive(t, e.Duration)
+		if i != 4 {
+			require.NoError(t, e.Err)
+		}
+	}
+
+	// with RETURNING, the returned rows are the affected rows
+	events = nil
+	res, err := db.WithContext(context.Background()).QueryRowContext(context.Background(), "INSERT INTO foo (a, b) VALUES (4, 'd') RETURNING a")
+	require.NoError(t, err)
+	require.NoError(t, res.Scan(&n))
+	require.Len(t, events, 1)
+	require.Equal(t, int64(1), events[0].RowsAffected)
+
+	// the arguments can be redacted and fast queries ignored
+	events = nil
+	db.SetQueryLogger(&chai.QueryLogger{
+		OnQuery: func(ctx context.Context, e chai.QueryEvent) {
+			events = append(events, e)
+		},
+		RedactArgs: true,
+	})
+	err = db.Exec("DELETE FROM foo WHERE b = ?", "d")
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Nil(t, events[0].Args)
+	require.Equal(t, int64(1), events[0].RowsAffected)
+
+	events = nil
+	db.SetQueryLogger(&chai.QueryLogger{
+		OnQuery: func(ctx context.Context, e chai.QueryEvent) {
+			events = append(events, e)
+		},
+		SlowQueryThreshold: time.Hour,
+	})
+	err = db.Exec("SELECT 1")
+	require.NoError(t, err)
+	require.Empty(t, events)
+
+	db.SetQueryLogger(nil)
+	err = db.Exec("SELECT 1")
+	require.NoError(t, err)
+	require.Empty(t, events)
+}
+
+func TestMetrics(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	// opening the database runs transactions
+	committed := db.Metrics().Transactions.Committed
+
+	err = db.Exec("CREATE TABLE foo (a INTEGER PRIMARY KEY)")
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		err = db.Exec("INSERT INTO foo (a) VALUES (?)", i)
+		require.NoError(t, err)
+	}
+	err = db.Exec("INSERT INTO foo (a) VALUES (1)")
+	require.Error(t, err)
+
+	conn, err := db.Connect()
+	require.NoError(t, err)
+	defer conn.Close()
+	tx, err := conn.Begin(false)
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	m := db.Metrics()
+	require.Equal(t, int64(5), m.Queries)
+	require.Equal(t, int64(1), m.QueryErrors)
+	require.Equal(t, int64(5), m.QueryLatency.Count)
+	require.Len(t, m.QueryLatency.Counts, len(m.QueryLatency.Bounds))
+	require.LessOrEqual(t, m.QueryLatency.Counts[len(m.QueryLatency.Counts)-1], m.QueryLatency.Count)
+	require.Positive(t, m.QueryLatency.Sum)
+	require.Equal(t, int64(2), m.StatementCacheHits)
+	require.Equal(t, int64(3), m.StatementCacheMisses)
+	require.Equal(t, int64(1), m.Transactions.Active)
+	require.Equal(t, committed+4, m.Transactions.Committed)
+	require.Nil(t, m.Storage)
+
+	require.NoError(t, tx.Rollback())
+	m = db.Metrics()
+	require.Equal(t, int64(0), m.Transactions.Active)
+
+	rec := httptest.NewRecorder()
+	db.MetricsHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	body := rec.Body.String()
+	require.Contains(t, body, "# TYPE chai_queries_total counter\nchai_queries_total 5\n")
+	require.Contains(t, body, `chai_query_duration_seconds_bucket{le="+Inf"} 5`)
+	require.Contains(t, body, "chai_query_duration_seconds_count 5\n")
+	require.Contains(t, body, "chai_transactions_active 0\n")
+
+	db.PublishExpvar("chai_test_metrics")
+	var vars map[string]any
+	require.NoError(t, json.Unmarshal([]byte(expvar.Get("chai_test_metrics").String()), &vars))
+	require.Equal(t, float64(5), vars["Queries"])
+}
+
+func TestBeginRead(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec("CREATE TABLE foo (a INTEGER PRIMARY KEY); INSERT INTO foo (a) VALUES (1)")
+	require.NoError(t, err)
+
+	count := func(t *testing.T, tx *chai.Tx) int {
+		t.Helper()
+
+		var n int
+		r, err := tx.QueryRow("SELECT COUNT(*) FROM foo")
+		require.NoError(t, err)
+		require.NoError(t, r.Scan(&n))
+		return n
+	}
+
+	tx, err := db.BeginRead(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, count(t, tx))
+
+	// writers are not blocked and the transaction keeps its snapshot
+	err = db.Exec("INSERT INTO foo (a) VALUES (2)")
+	require.NoError(t, err)
+	require.Equal(t, 1, count(t, tx))
+
+	// writes are rejected when they are prepared, even if they are cached
+	_, err = tx.Prepare("INSERT INTO foo (a) VALUES (2)")
+	require.ErrorIs(t, err, chai.ErrReadOnlyTx)
+	_, err = tx.Prepare("INSERT INTO foo (a) VALUES (3)")
+	require.ErrorIs(t, err, chai.ErrReadOnlyTx)
+	err = tx.Exec("SELECT 1; DELETE FROM foo")
+	require.ErrorIs(t, err, chai.ErrReadOnlyTx)
+	err = tx.Exec("CREATE TABLE bar (a INTEGER)")
+	require.ErrorIs(t, err, chai.ErrReadOnlyTx)
+	require.Equal(t, 1, count(t, tx))
+
+	require.NoError(t, tx.Rollback())
+	require.Error(t, tx.Rollback())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = db.BeginRead(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+
+	// statements prepared outside of the transaction are rejected when they are run
+	conn, err := db.Connect()
+	require.NoError(t, err)
+	defer conn.Close()
+	stmt, err := conn.Prepare("INSERT INTO foo (a) VALUES (3)")
+	require.NoError(t, err)
+	err = conn.Exec("BEGIN READ ONLY")
+	require.NoError(t, err)
+	err = stmt.Exec()
+	require.ErrorIs(t, err, chai.ErrReadOnlyTx)
+	err = conn.Exec("ROLLBACK")
+	require.NoError(t, err)
+	err = stmt.Exec()
+	require.NoError(t, err)
+}
+
+func TestConnPool(t *testing.T) {
+	t.Run("Concurrent queries", func(t *testing.T) {
+		db, err := chai.OpenWith(":memory:", &chai.Options{MaxOpenConns: 2})
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec("CREATE TABLE foo (a INTEGER PRIMARY KEY)")
+		require.NoError(t, err)
+
+		var wg sync.WaitGroup
+		errs := make(chan error, 50)
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+
+				err := db.Exec("INSERT INTO foo (a) VALUES (?)", i)
+				if err == nil {
+					_, err = db.QueryRow("SELECT COUNT(*) FROM foo")
+				}
+				errs <- err
+			}(i)
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			require.NoError(t, err)
+		}
+
+		var n int
+		r, err := db.QueryRow("SELECT COUNT(*) FROM foo")
+		require.NoError(t, err)
+		require.NoError(t, r.Scan(&n))
+		require.Equal(t, 50, n)
+	})
+
+	t.Run("Reset", func(t *testing.T) {
+		for _, maxIdle := range []int{0, -1} {
+			db, err := chai.OpenWith(":memory:", &chai.Options{MaxIdleConns: maxIdle})
+			require.NoError(t, err)
+			defer db.Close()
+
+			err = db.Exec("CREATE TABLE foo (a INTEGER PRIMARY KEY); CREATE SEQUENCE seq")
+			require.NoError(t, err)
+
+			// the values of the sequences are not kept
+			_, err = db.QueryRow("SELECT nextval('seq')")
+			require.NoError(t, err)
+			_, err = db.QueryRow("SELECT currval('seq')")
+			require.Error(t, err)
+
+			// transactions left open are rolled back
+			err = db.Exec("BEGIN; INSERT INTO foo (a) VALUES (1)")
+			require.NoError(t, err)
+			err = db.Exec("INSERT INTO foo (a) VALUES (1)")
+			require.NoError(t, err)
+		}
+	})
+
+	t.Run("Wait", func(t *testing.T) {
+		db, err := chai.OpenWith(":memory:", &chai.Options{MaxOpenConns: 1})
+		require.NoError(t, err)
+		defer db.Close()
+
+		// block the only connection until the query is logged
+		started, unblock := make(chan struct{}), make(chan struct{})
+		db.SetQueryLogger(&chai.QueryLogger{
+			OnQuery: func(ctx context.Context, e chai.QueryEvent) {
+				if e.SQL == "SELECT 1" {
+					close(started)
+					<-unblock
+				}
+			},
+		})
+		done := make(chan error)
+		go func() {
+			done <- db.Exec("SELECT 1")
+		}()
+		<-started
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		err = db.ExecContext(ctx, "SELECT 2")
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+
+		close(unblock)
+		require.NoError(t, <-done)
+		require.NoError(t, db.Exec("SELECT 2"))
+	})
+}
+
+// gridPoint is stored as text by the codec registered in TestRegisterType.
+type gridPoint struct {
+	X, Y int
+}
+
+func TestRegisterType(t *testing.T) {
+	chai.RegisterType(chai.TypeCodec[gridPoint]{
+		Encode: func(p gridPoint) (any, error) {
+			return fmt.Sprintf("%d,%d", p.X, p.Y), nil
+		},
+		Decode: func(src any) (gridPoint, error) {
+			var p gridPoint
+			s, ok := src.(string)
+			if !ok {
+				return p, errors.Errorf("cannot decode %T as a point", src)
+			}
+			_, err := fmt.Sscanf(s, "%d,%d", &p.X, &p.Y)
+			return p, err
+		},
+	})
+
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`CREATE TABLE test (id INTEGER PRIMARY KEY, p TEXT)`)
+	require.NoError(t, err)
+	err = db.Exec(`INSERT INTO test (id, p) VALUES (1, ?), (2, ?), (3, ?)`, gridPoint{1, 2}, &gridPoint{3, 4}, (*gridPoint)(nil))
+	require.NoError(t, err)
+
+	t.Run("Stored value", func(t *testing.T) {
+		r, err := db.QueryRow(`SELECT p FROM test WHERE id = 1`)
+		require.NoError(t, err)
+		var s string
+		require.NoError(t, r.Scan(&s))
+		require.Equal(t, "1,2", s)
+	})
+
+	t.Run("Scan", func(t *testing.T) {
+		r, err := db.QueryRow(`SELECT p, p FROM test WHERE id = 2`)
+		require.NoError(t, err)
+		var p gridPoint
+		var pp *gridPoint
+		require.NoError(t, r.Scan(&p, &pp))
+		require.Equal(t, gridPoint{3, 4}, p)
+		require.Equal(t, &gridPoint{3, 4}, pp)
+
+		r, err = db.QueryRow(`SELECT p, p FROM test WHERE id = 3`)
+		require.NoError(t, err)
+		require.NoError(t, r.Scan(&p, &pp))
+		require.Zero(t, p)
+		require.Nil(t, pp)
+	})
+
+	t.Run("Struct fields", func(t *testing.T) {
+		type item struct {
+			ID int
+			P  gridPoint
+		}
+
+		err := db.Exec(`INSERT INTO test (id, p) VALUES (4, ?)`, gridPoint{5, 6})
+		require.NoError(t, err)
+
+		items, err := chai.QueryAs[item](db, `SELECT * FROM test WHERE id IN (1, 4) ORDER BY id`)
+		require.NoError(t, err)
+		require.Equal(t, []item{{1, gridPoint{1, 2}}, {4, gridPoint{5, 6}}}, items)
+
+		p, err := chai.QueryOneAs[gridPoint](db, `SELECT p FROM test WHERE id = 4`)
+		require.NoError(t, err)
+		require.Equal(t, gridPoint{5, 6}, p)
+	})
+
+	t.Run("Decoding error", func(t *testing.T) {
+		r, err := db.QueryRow(`SELECT 10`)
+		require.NoError(t, err)
+		var p gridPoint
+		require.Error(t, r.Scan(&p))
+	})
+}
+
+func TestRows(t *testing.T) {
+	db, err := chai.OpenWith(":memory:", &chai.Options{MaxOpenConns: 1})
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE test (a INTEGER PRIMARY KEY, b TEXT);
+		INSERT INTO test (a, b) VALUES (1, 'a'), (2, 'b'), (3, 'c');
+	`)
+	require.NoError(t, err)
+
+	t.Run("Next", func(t *testing.T) {
+		rows, err := db.Query(`SELECT a, b FROM test WHERE a > ? ORDER BY a`, 1)
+		require.NoError(t, err)
+		defer rows.Close()
+
+		require.Equal(t, []string{"a", "b"}, rows.Columns())
+		require.Error(t, rows.Scan())
+
+		var got []string
+		for rows.Next() {
+			var a int
+			var b string
+			require.NoError(t, rows.Scan(&a, &b))
+			got = append(got, fmt.Sprintf("%d%s", a, b))
+
+			typ, err := rows.Row().GetColumnType("a")
+			require.NoError(t, err)
+			require.Equal(t, "integer", typ)
+		}
+		require.NoError(t, rows.Err())
+		require.Equal(t, []string{"2b", "3c"}, got)
+		require.False(t, rows.Next())
+		require.Nil(t, rows.Row())
+
+		// the connection was released when Next returned false
+		require.NoError(t, db.Exec(`SELECT 1`))
+	})
+
+	t.Run("Close early", func(t *testing.T) {
+		rows, err := db.Query(`SELECT * FROM test`)
+		require.NoError(t, err)
+		require.True(t, rows.Next())
+		require.NoError(t, rows.Close())
+		require.NoError(t, rows.Close())
+		require.False(t, rows.Next())
+
+		require.NoError(t, db.Exec(`SELECT 1`))
+	})
+
+	t.Run("No rows", func(t *testing.T) {
+		rows, err := db.Query(`INSERT INTO test (a, b) VALUES (4, 'd')`)
+		require.NoError(t, err)
+		require.False(t, rows.Next())
+		require.NoError(t, rows.Err())
+
+		r, err := db.QueryRow(`SELECT COUNT(*) FROM test`)
+		require.NoError(t, err)
+		var n int
+		require.NoError(t, r.Scan(&n))
+		require.Equal(t, 4, n)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		_, err := db.Query(`SELECT * FROM unknown`)
+		require.Error(t, err)
+
+		rows, err := db.Query(`SELECT a / (a - 2) FROM test ORDER BY a`)
+		require.NoError(t, err)
+		defer rows.Close()
+		for rows.Next() {
+		}
+		require.Error(t, rows.Err())
+	})
+
+	t.Run("Context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		rows, err := db.QueryContext(ctx, `SELECT * FROM test`)
+		require.NoError(t, err)
+		defer rows.Close()
+
+		require.True(t, rows.Next())
+		cancel()
+		require.False(t, rows.Next())
+		require.ErrorIs(t, rows.Err(), context.Canceled)
+	})
+}
+
+func TestRunInTx(t *testing.T) {
+	db, err := chai.OpenWith(":memory:", &chai.Options{ConcurrentWrites: true})
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE test (a INTEGER PRIMARY KEY, b INTEGER);
+		INSERT INTO test (a, b) VALUES (1, 0);
+	`)
+	require.NoError(t, err)
+
+	// increment updates the row after writing it from another transaction
+	// during the first attempt, which makes the commit fail with a conflict.
+	increment := func(attempts *int) func(tx *chai.Tx) error {
+		return func(tx *chai.Tx) error {
+			*attempts++
+
+			err := tx.Exec(`UPDATE test SET b = b + 1 WHERE a = 1`)
+			if err != nil {
+				return err
+			}
+
+			if *attempts == 1 {
+				return db.Exec(`UPDATE test SET b = b + 10 WHERE a = 1`)
+			}
+			return nil
+		}
+	}
+
+	value := func(t *testing.T) int {
+		t.Helper()
+
+		r, err := db.QueryRow(`SELECT b FROM test WHERE a = 1`)
+		require.NoError(t, err)
+		var b int
+		require.NoError(t, r.Scan(&b))
+		return b
+	}
+
+	t.Run("Retry", func(t *testing.T) {
+		before := value(t)
+
+		var attempts int
+		err := db.RunInTx(context.Background(), nil, increment(&attempts))
+		require.NoError(t, err)
+		require.Equal(t, 2, attempts)
+		require.Equal(t, before+11, value(t))
+	})
+
+	t.Run("No retries", func(t *testing.T) {
+		before := value(t)
+
+		var attempts int
+		err := db.RunInTx(context.Background(), &chai.RunTxOptions{MaxRetries: -1}, increment(&attempts))
+		require.True(t, chai.IsWriteConflictError(err))
+		require.Equal(t, 1, attempts)
+		require.Equal(t, before+10, value(t))
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		var attempts int
+		err := db.RunInTx(context.Background(), nil, func(tx *chai.Tx) error {
+			attempts++
+			return errors.New("boom")
+		})
+		require.EqualError(t, err, "boom")
+		require.Equal(t, 1, attempts)
+	})
+
+	t.Run("Concurrent", func(t *testing.T) {
+		before := value(t)
+
+		var wg sync.WaitGroup
+		for range 4 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range 10 {
+					err := db.RunInTx(context.Background(), &chai.RunTxOptions{MaxRetries: 1000}, func(tx *chai.Tx) error {
+						return tx.Exec(`UPDATE test SET b = b + 1 WHERE a = 1`)
+					})
+					require.NoError(t, err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		require.Equal(t, before+40, value(t))
+	})
+}
+
+func TestVirtualTable(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	config := map[string]int{"a": 1, "b": 2, "c": 3}
+	var filters []*chai.VirtualFilter
+	err = db.RegisterVirtualTable(chai.VirtualTable{
+		Schema: `CREATE TABLE config (name TEXT PRIMARY KEY, val INTEGER NOT NULL)`,
+		Rows: func(ctx context.Context, f *chai.VirtualFilter, fn func(values ...any) error) error {
+			filters = append(filters, f)
+			if len(f.Key) > 0 {
+				k := f.Key[0].(string)
+				if v, ok := config[k]; ok {
+					return fn(k, v)
+				}
+				return nil
+			}
+
+			for k, v := range config {
+				if err := fn(k, v); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+	require.NoError(t, err)
+
+	err = db.Exec(`
+		CREATE TABLE test (id INTEGER PRIMARY KEY, name TEXT);
+		INSERT INTO test (id, name) VALUES (1, 'a'), (2, 'c'), (3, 'z');
+	`)
+	require.NoError(t, err)
+
+	t.Run("Scan", func(t *testing.T) {
+		got, err := chai.QueryAs[int](db, `SELECT val FROM config ORDER BY name`)
+		require.NoError(t, err)
+		require.Equal(t, []int{1, 2, 3}, got)
+	})
+
+	t.Run("Filter pushdown", func(t *testing.T) {
+		filters = nil
+		got, err := chai.QueryAs[int](db, `SELECT val FROM config WHERE name = ?`, "b")
+		require.NoError(t, err)
+		require.Equal(t, []int{2}, got)
+		require.Len(t, filters, 1)
+		require.Equal(t, []any{"b"}, filters[0].Key)
+	})
+
+	t.Run("Insert select", func(t *testing.T) {
+		err := db.Exec(`INSERT INTO test (id, name) SELECT val + 10, name FROM config WHERE val > 1`)
+		require.NoError(t, err)
+
+		got, err := chai.QueryAs[string](db, `SELECT name FROM test WHERE id > 10 ORDER BY id`)
+		require.NoError(t, err)
+		require.Equal(t, []string{"b", "c"}, got)
+	})
+
+	t.Run("Read-only", func(t *testing.T) {
+		require.Error(t, db.Exec(`INSERT INTO config (name, val) VALUES ('d', 4)`))
+		require.Error(t, db.Exec(`DROP TABLE config`))
+		require.Error(t, db.Exec(`CREATE TABLE config (a INT)`))
+	})
+
+	t.Run("Indexes", func(t *testing.T) {
+		// an index would not follow the rows generated by the table
+		err := db.Exec(`CREATE INDEX ON config (val)`)
+		require.ErrorContains(t, err, "virtual table config")
+		indexes, err := chai.QueryAs[string](db, `SELECT name FROM __chai_catalog WHERE type = 'index' AND owner_table_name = 'config'`)
+		require.NoError(t, err)
+		require.Empty(t, indexes)
+
+		config["b"] = 99
+		defer func() { config["b"] = 2 }()
+		got, err := chai.QueryAs[string](db, `SELECT name FROM config WHERE val = 99`)
+		require.NoError(t, err)
+		require.Equal(t, []string{"b"}, got)
+
+		err = db.Exec(`REINDEX config`)
+		require.ErrorContains(t, err, "cannot reindex virtual table config")
+		require.NoError(t, db.Exec(`REINDEX`))
+	})
+
+	t.Run("Register", func(t *testing.T) {
+		rows := func(ctx context.Context, f *chai.VirtualFilter, fn func(values ...any) error) error {
+			return fn(nil)
+		}
+
+		err := db.RegisterVirtualTable(chai.VirtualTable{Schema: `CREATE TABLE test (a INT)`, Rows: rows})
+		require.True(t, chai.IsAlreadyExistsError(err))
+		err = db.RegisterVirtualTable(chai.VirtualTable{Schema: `SELECT 1`, Rows: rows})
+		require.Error(t, err)
+
+		// the NOT NULL constraint is validated
+		err = db.RegisterVirtualTable(chai.VirtualTable{Schema: `CREATE TABLE bad (a INT NOT NULL)`, Rows: rows})
+		require.NoError(t, err)
+		_, err = db.QueryRow(`SELECT * FROM bad`)
+		require.Error(t, err)
+
+		require.NoError(t, db.UnregisterVirtualTable("bad"))
+		_, err = db.QueryRow(`SELECT * FROM bad`)
+		require.True(t, chai.IsNotFoundError(err))
+	})
+}
+
+func TestExplain(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE test (a INTEGER PRIMARY KEY, b TEXT, c INTEGER);
+		CREATE UNIQUE INDEX test_b ON test (b);
+		CREATE INDEX test_c ON test (c);
+	`)
+	require.NoError(t, err)
+
+	// source returns the first operator of the plan
+	source := func(n *chai.PlanNode) *chai.PlanNode {
+		for len(n.Children) > 0 {
+			n = n.Children[0]
+		}
+		return n
+	}
+
+	t.Run("Primary key", func(t *testing.T) {
+		plan, err := db.Explain(`SELECT * FROM test WHERE a IN (1, 2)`)
+		require.NoError(t, err)
+
+		scan := source(plan)
+		require.Equal(t, "table.Scan", scan.Operator)
+		require.Equal(t, "test", scan.Table)
+		require.Empty(t, scan.Index)
+		require.EqualValues(t, 2, scan.EstimatedRows)
+		require.EqualValues(t, 2, plan.EstimatedRows)
+	})
+
+	t.Run("Index", func(t *testing.T) {
+		plan, err := db.Explain(`SELECT a FROM test WHERE b = ?`, "x")
+		require.NoError(t, err)
+
+		scan := source(plan)
+		require.Equal(t, "index.Scan", scan.Operator)
+		require.Equal(t, "test_b", scan.Index)
+		require.Equal(t, "test", scan.Table)
+		require.EqualValues(t, 1, scan.EstimatedRows)
+
+		plan, err = db.Explain(`SELECT a FROM test WHERE c = 1`)
+		require.NoError(t, err)
+		require.Equal(t, "test_c", source(plan).Index)
+		require.EqualValues(t, -1, plan.EstimatedRows)
+	})
+
+	t.Run("Limit", func(t *testing.T) {
+		plan, err := db.Explain(`EXPLAIN SELECT * FROM test LIMIT ?`, 10)
+		require.NoError(t, err)
+		require.Equal(t, "rows.Take", plan.Operator)
+		require.EqualValues(t, 10, plan.EstimatedRows)
+		require.Equal(t, "table.Scan", source(plan).Operator)
+		require.EqualValues(t, -1, source(plan).EstimatedRows)
+
+		r, err := db.QueryRow(`EXPLAIN SELECT * FROM test LIMIT ?`, 10)
+		require.NoError(t, err)
+		var s string
+		require.NoError(t, r.Scan(&s))
+
+		var ops []string
+		for n := plan; n != nil; {
+			ops = append([]string{n.Description}, ops...)
+			if len(n.Children) == 0 {
+				break
+			}
+			n = n.Children[0]
+		}
+		require.Equal(t, s, strings.Join(ops, " | "))
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		_, err := db.Explain(`CREATE TABLE foo (a INT)`)
+		require.Error(t, err)
+		_, err = db.Explain(`SELECT 1; SELECT 2`)
+		require.Error(t, err)
+		_, err = db.Explain(`SELECT * FROM unknown`)
+		require.Error(t, err)
+	})
+}
+
+type tenantKey struct{}
+
+func TestRowPolicy(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE orders (id INT PRIMARY KEY, tenant TEXT, amount INT);
+		CREATE TABLE events (id INT PRIMARY KEY, tenant TEXT);
+		INSERT INTO orders (id, tenant, amount) VALUES (1, 'a', 10), (2, 'b', 20), (3, 'a', 30), (4, 'b', 40);
+		INSERT INTO events (id, tenant) VALUES (1, 'a'), (2, 'b'), (3, 'a'), (4, 'b');
+	`)
+	require.NoError(t, err)
+
+	conn, err := db.Connect()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// prepared before the policy is set
+	stmt, err := conn.Prepare(`SELECT SUM(amount) FROM orders`)
+	require.NoError(t, err)
+
+	policy := func(ctx context.Context) (string, []any, error) {
+		tenant, ok := ctx.Value(tenantKey{}).(string)
+		if !ok {
+			return "", nil, nil
+		}
+		if tenant == "" {
+			return "", nil, errors.New("invalid tenant")
+		}
+		return "tenant = ?", []any{tenant}, nil
+	}
+	db.SetRowPolicy("orders", policy)
+	db.SetRowPolicy("events", policy)
+
+	ctxA := context.WithValue(context.Background(), tenantKey{}, "a")
+	ctxB := context.WithValue(context.Background(), tenantKey{}, "b")
+
+	sum := func(ctx context.Context, q string, args ...any) int {
+		t.Helper()
+
+		r, err := db.QueryRowContext(ctx, q, args...)
+		require.NoError(t, err)
+		var n int
+		require.NoError(t, r.Scan(&n))
+		return n
+	}
+
+	require.Equal(t, 40, sum(ctxA, `SELECT SUM(amount) FROM orders`))
+	require.Equal(t, 60, sum(ctxB, `SELECT SUM(amount) FROM orders`))
+	require.Equal(t, 100, sum(context.Background(), `SELECT SUM(amount) FROM orders`))
+
+	r, err := stmt.QueryRowContext(ctxB)
+	require.NoError(t, err)
+	var n int
+	require.NoError(t, r.Scan(&n))
+	require.Equal(t, 60, n)
+
+	// indexes are built from every row and their scans are restricted as well
+	require.NoError(t, db.ExecContext(ctxA, `CREATE INDEX orders_amount ON orders (amount)`))
+	require.Equal(t, 100, sum(context.Background(), `SELECT SUM(amount) FROM orders WHERE amount > 0`))
+	require.Equal(t, 30, sum(ctxA, `SELECT SUM(amount) FROM orders WHERE amount > 15`))
+	require.Equal(t, 0, sum(ctxA, `SELECT COUNT(*) FROM orders WHERE id = 2`))
+
+	// updates and deletes only see the rows of the tenant
+	require.NoError(t, db.ExecContext(ctxA, `UPDATE orders SET amount = 0`))
+	require.Equal(t, 60, sum(context.Background(), `SELECT SUM(amount) FROM orders`))
+	require.NoError(t, db.ExecContext(ctxA, `DELETE FROM events WHERE id > 1`))
+	require.Equal(t, 3, sum(context.Background(), `SELECT COUNT(*) FROM events`))
+	require.Equal(t, 2, sum(ctxB, `SELECT COUNT(*) FROM events`))
+
+	// the policy can fail the query
+	_, err = db.QueryRowContext(context.WithValue(context.Background(), tenantKey{}, ""), `SELECT * FROM orders`)
+	require.ErrorContains(t, err, "invalid tenant")
+
+	db.SetRowPolicy("orders", func(ctx context.Context) (string, []any, error) {
+		return "unknown = 1", nil, nil
+	})
+	_, err = db.QueryRow(`SELECT * FROM orders`)
+	require.Error(t, err)
+
+	db.SetRowPolicy("orders", nil)
+	require.Equal(t, 60, sum(ctxA, `SELECT SUM(amount) FROM orders`))
+}
+
+func TestRowPolicyUpsert(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE t (id INT PRIMARY KEY, tenant TEXT, secret TEXT UNIQUE);
+		INSERT INTO t (id, tenant, secret) VALUES (1, 'a', 'secret-a'), (2, 'b', 'secret-b');
+	`)
+	require.NoError(t, err)
+
+	db.SetRowPolicy("t", func(ctx context.Context) (string, []any, error) {
+		return "tenant = ?", []any{ctx.Value(tenantKey{})}, nil
+	})
+	ctxB := context.WithValue(context.Background(), tenantKey{}, "b")
+
+	secret := func(id int) string {
+		t.Helper()
+
+		r, err := db.QueryRow(`SELECT secret FROM t WHERE id = ?`, id)
+		require.NoError(t, err)
+		var s string
+		require.NoError(t, r.Scan(&s))
+		return s
+	}
+
+	// the rows of other tenants cannot be replaced, whether the conflict
+	// is on the primary key or on a unique column
+	err = db.ExecContext(ctxB, `INSERT INTO t VALUES (1, 'b', 'hijacked') ON CONFLICT DO REPLACE`)
+	require.ErrorContains(t, err, "row policy")
+	err = db.ExecContext(ctxB, `INSERT INTO t VALUES (3, 'b', 'secret-a') ON CONFLICT DO REPLACE`)
+	require.ErrorContains(t, err, "row policy")
+
+	db.SetRowPolicy("t", nil)
+	require.Equal(t, "secret-a", secret(1))
+	db.SetRowPolicy("t", func(ctx context.Context) (string, []any, error) {
+		return "tenant = ?", []any{ctx.Value(tenantKey{})}, nil
+	})
+
+	// the rows of the tenant can
+	err = db.ExecContext(ctxB, `INSERT INTO t VALUES (2, 'b', 'replaced') ON CONFLICT DO REPLACE`)
+	require.NoError(t, err)
+	db.SetRowPolicy("t", nil)
+	require.Equal(t, "replaced", secret(2))
+}
@@ -4,7 +4,12 @@ import (
 	"context"
 	"database/sql"
 	"database/sql/driver"
+	"encoding"
+	"encoding/json"
 	"io"
+	"net/netip"
+	"reflect"
+	"strings"
 	"sync"
 	"time"
 
@@ -79,6 +84,15 @@ func (c *connector) Close() error {
 	return err
 }
 
+var (
+	_ driver.NamedValueChecker  = (*conn)(nil)
+	_ driver.Pinger             = (*conn)(nil)
+	_ driver.SessionResetter    = (*conn)(nil)
+	_ driver.Validator          = (*conn)(nil)
+	_ driver.ConnPrepareContext = (*conn)(nil)
+	_ driver.ConnBeginTx        = (*conn)(nil)
+)
+
 // conn represents a connection to the Chai database.
 // It implements the database/sql/driver.Conn interface.
 type conn struct {
@@ -103,17 +117,65 @@ func (c *conn) PrepareContext(ctx context.Context, q string) (driver.Stmt, error
 	}, nil
 }
 
-// Close closes any ongoing transaction.
+// Close rolls back any ongoing transaction and releases the connection.
+// The connection cannot be used after it is closed.
 func (c *conn) Close() error {
 	return c.conn.Close()
 }
 
+// Ping returns driver.ErrBadConn if the connection or the database is closed.
+func (c *conn) Ping(ctx context.Context) error {
+	err := c.conn.Ping(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			return err
+		}
+		return driver.ErrBadConn
+	}
+
+	return nil
+}
+
+// IsValid reports whether the connection can be reused by the pool.
+func (c *conn) IsValid() bool {
+	return c.conn.Conn.Err() == nil
+}
+
+// CheckNamedValue accepts unsigned integers larger than math.MaxInt64,
+// network addresses, JSON documents and types implementing encoding.TextMarshaler
+// or encoding.BinaryMarshaler, which are rejected or converted to other types
+// by the default converter of database/sql.
+// Values of the types registered with chai.RegisterType are converted by their codec.
+// Other values, including the ones implementing driver.Valuer, are left to the default converter.
+func (c *conn) CheckNamedValue(nv *driver.NamedValue) error {
+	if row.HasCodec(reflect.TypeOf(nv.Value)) {
+		return nil
+	}
+
+	switch nv.Value.(type) {
+	case uint64, netip.Prefix, netip.Addr, json.RawMessage:
+		return nil
+	case driver.Valuer:
+		return driver.ErrSkip
+	case encoding.TextMarshaler, encoding.BinaryMarshaler:
+		return nil
+	}
+
+	return driver.ErrSkip
+}
+
 // Begin starts and returns a new transaction.
 func (c *conn) Begin() (driver.Tx, error) {
 	return c.BeginTx(context.Background(), driver.TxOptions{})
 }
 
+// ResetSession returns driver.ErrBadConn if the connection is closed
+// or if a transaction is still attached to it.
 func (c *conn) ResetSession(ctx context.Context) error {
+	if c.conn.Conn.Err() != nil {
+		return driver.ErrBadConn
+	}
+
 	err := c.conn.Conn.Reset()
 	if err != nil {
 		return driver.ErrBadConn
@@ -124,15 +186,24 @@ func (c *conn) ResetSession(ctx context.Context) error {
 
 // BeginTx starts and returns a new transaction.
 // It uses the ReadOnly option to determine whether to start a read-only or read/write transaction.
-// If the Isolation option is non zero, an error is returned.
+// Transactions use snapshot isolation by default. The only other supported
+// isolation level is sql.LevelSerializable.
 func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
-	if opts.Isolation != 0 {
-		return nil, errors.New("isolation levels are not supported")
+	var serializable bool
+	switch sql.IsolationLevel(opts.Isolation) {
+	case sql.LevelDefault, sql.LevelSnapshot:
+	case sql.LevelSerializable:
+		serializable = true
+	default:
+		return nil, errors.Errorf("isolation level %s is not supported", sql.IsolationLevel(opts.Isolation))
 	}
 
 	// if the ReadOnly flag is explicitly specified, create a read-only transaction,
 	// otherwise create a read/write transaction.
-	return c.conn.Begin(!opts.ReadOnly)
+	return c.conn.BeginTx(&chai.TxOptions{
+		ReadOnly:     opts.ReadOnly,
+		Serializable: serializable,
+	})
 }
 
 // Stmt is a prepared statement. It is bound to a Conn and not
@@ -147,7 +218,7 @@ func (s stmt) NumInput() int { return -1 }
 // Exec executes a query that doesn't return rows, such
 // as an INSERT or UPDATE.
 func (s stmt) Exec(args []driver.Value) (driver.Result, error) {
-	return nil, errors.New("not implemented")
+	return s.ExecContext(context.Background(), valuesToNamedValues(args))
 }
 
 // ExecContext executes a query that doesn't return rows, such
@@ -159,7 +230,7 @@ func (s stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver
 	default:
 	}
 
-	return execResult{}, s.stmt.Exec(namedValueToParams(args)...)
+	return execResult{}, s.stmt.ExecContext(ctx, namedValueToParams(args)...)
 }
 
 type execResult struct{}
@@ -174,12 +245,18 @@ func (r execResult) RowsAffected() (int64, error) {
 	return 0, errors.New("not supported")
 }
 
+// Query executes a query that may return rows, such as a
+// SELECT.
 func (s stmt) Query(args []driver.Value) (driver.Rows, error) {
-	return nil, errors.New("not implemented")
+	return s.QueryContext(context.Background(), valuesToNamedValues(args))
 }
 
 // QueryContext executes a query that may return rows, such as a
 // SELECT.
+// If the query contains multiple statements, each statement returning rows
+// is a result set, which can be read using the NextResultSet method of sql.Rows.
+// The statements are run when their result set is reached. The ones
+// that were not reached are run when the rows are closed.
 func (s stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
 	select {
 	case <-ctx.Done():
@@ -187,12 +264,34 @@ func (s stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (drive
 	default:
 	}
 
-	res, err := s.stmt.Query(namedValueToParams(args)...)
+	rs := Rows{
+		ctx:    ctx,
+		params: namedValueToParams(args),
+		stmts:  s.stmt.Split(),
+	}
+
+	err := rs.nextResultSet()
+	// if none of the statements return rows, return an empty result set
+	if errors.Is(err, io.EOF) {
+		err = nil
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	return newRows(res)
+	return &rs, nil
+}
+
+func valuesToNamedValues(args []driver.Value) []driver.NamedValue {
+	named := make([]driver.NamedValue, len(args))
+	for i, arg := range args {
+		named[i] = driver.NamedValue{
+			Ordinal: i + 1,
+			Value:   arg,
+		}
+	}
+
+	return named
 }
 
 func namedValueToParams(args []driver.NamedValue) []any {
@@ -214,12 +313,32 @@ func (s stmt) Close() error {
 
 var errStop = errors.New("stop")
 
+var (
+	_ driver.RowsNextResultSet              = (*Rows)(nil)
+	_ driver.RowsColumnTypeScanType         = (*Rows)(nil)
+	_ driver.RowsColumnTypeDatabaseTypeName = (*Rows)(nil)
+)
+
+// Rows iterates over the result sets of a query.
+// The rows of the current result set are read by a goroutine,
+// which sends them one by one to Next.
 type Rows struct {
+	ctx    context.Context
+	params []any
+	// statements which were not run yet
+	stmts []*chai.Statement
+
 	res      *chai.Result
 	cancelFn func()
 	c        chan Row
 	wg       sync.WaitGroup
 	columns  []string
+	// row read by ColumnTypeScanType or ColumnTypeDatabaseTypeName
+	// and not yet returned by Next
+	peeked *Row
+	done   bool
+	// types of the columns, as found in the first row
+	types []types.Type
 }
 
 type Row struct {
@@ -227,26 +346,56 @@ type Row struct {
 	err error
 }
 
-func newRows(res *chai.Result) (*Rows, error) {
-	ctx, cancel := context.WithCancel(context.Background())
+// nextResultSet runs the remaining statements until one of them returns rows,
+// and starts iterating over its result. It returns io.EOF if none of them
+// return rows.
+func (rs *Rows) nextResultSet() error {
+	for len(rs.stmts) > 0 {
+		st := rs.stmts[0]
+		rs.stmts = rs.stmts[1:]
+
+		res, err := st.QueryContext(rs.ctx, rs.params...)
+		if err != nil {
+			return err
+		}
 
-	rs := Rows{
-		res:      res,
-		cancelFn: cancel,
-		c:        make(chan Row),
+		if res.ReturnsRows() {
+			return rs.start(res)
+		}
+
+		err = res.Iterate(func(*chai.Row) error { return nil })
+		if er := res.Close(); err == nil {
+			err = er
+		}
+		if err != nil {
+			return err
+		}
 	}
-	rs.wg.Add(1)
 
-	cols, err := rs.res.Columns()
+	return io.EOF
+}
+
+func (rs *Rows) start(res *chai.Result) error {
+	cols, err := res.Columns()
 	if err != nil {
-		return nil, err
+		_ = res.Close()
+		return err
 	}
 
+	ctx, cancel := context.WithCancel(context.Background())
+
+	rs.res = res
+	rs.cancelFn = cancel
+	rs.c = make(chan Row)
 	rs.columns = cols
+	rs.peeked = nil
+	rs.done = false
+	rs.types = nil
+	rs.wg.Add(1)
 
 	go rs.iterate(ctx)
 
-	return &rs, nil
+	return nil
 }
 
 func (rs *Rows) iterate(ctx context.Context) {
@@ -287,32 +436,156 @@ func (rs *Rows) iterate(ctx context.Context) {
 	}
 }
 
+// closeResultSet stops the iteration over the current result set and closes it.
+func (rs *Rows) closeResultSet() error {
+	if rs.res == nil {
+		return nil
+	}
+
+	rs.cancelFn()
+	rs.wg.Wait()
+	err := rs.res.Close()
+	rs.res = nil
+	return err
+}
+
 // Columns returns the fields selected by the SELECT statement.
 func (rs *Rows) Columns() []string {
 	return rs.columns
 }
 
-// Close closes the rows iterator.
+// Close closes the rows iterator and runs the statements
+// whose result sets were not reached.
 func (rs *Rows) Close() error {
-	rs.cancelFn()
-	rs.wg.Wait()
-	return rs.res.Close()
+	err := rs.closeResultSet()
+
+	for len(rs.stmts) > 0 && err == nil {
+		err = rs.stmts[0].ExecContext(rs.ctx, rs.params...)
+		rs.stmts = rs.stmts[1:]
+	}
+	rs.stmts = nil
+
+	return err
 }
 
-func (rs *Rows) Next(dest []driver.Value) error {
+// HasNextResultSet reports whether some statements of the query were not run yet.
+// They may return no rows, in which case NextResultSet returns io.EOF.
+func (rs *Rows) HasNextResultSet() bool {
+	return len(rs.stmts) > 0
+}
+
+// NextResultSet closes the current result set and runs the next statements
+// until one of them returns rows.
+func (rs *Rows) NextResultSet() error {
+	err := rs.closeResultSet()
+	if err != nil {
+		return err
+	}
+
+	rs.columns = nil
+	return rs.nextResultSet()
+}
+
+// fetch returns the next row of the current result set, or io.EOF.
+func (rs *Rows) fetch() (*chai.Row, error) {
+	if rs.peeked != nil {
+		r := rs.peeked
+		rs.peeked = nil
+		return r.r, r.err
+	}
+
+	if rs.res == nil || rs.done {
+		return nil, io.EOF
+	}
+
 	rs.c <- Row{}
 
 	r, ok := <-rs.c
 	if !ok {
-		return io.EOF
+		rs.done = true
+		return nil, io.EOF
 	}
 
-	if r.err != nil {
-		return r.err
+	return r.r, r.err
+}
+
+// columnType returns the type of the column in the first row of the result set.
+// It returns TypeAny if there are no rows, or if the value is NULL.
+func (rs *Rows) columnType(index int) types.Type {
+	if rs.types == nil {
+		rs.types = make([]types.Type, len(rs.columns))
+
+		r, err := rs.fetch()
+		if r != nil || err != io.EOF {
+			rs.peeked = &Row{r: r, err: err}
+		}
+		if r != nil {
+			var i int
+			_ = r.Row.Iterate(func(column string, v types.Value) error {
+				if i < len(rs.types) && v.Type() != types.TypeNull {
+					rs.types[i] = v.Type()
+				}
+				i++
+				return nil
+			})
+		}
+	}
+
+	if index < 0 || index >= len(rs.types) {
+		return types.TypeAny
+	}
+
+	return rs.types[index]
+}
+
+// ColumnTypeDatabaseTypeName returns the name of the type of the column, such as "INTEGER" or "TEXT".
+// Since the type of an expression depends on the values, it is the type of the value
+// in the first row. It returns an empty string if there are no rows or if the value is NULL.
+func (rs *Rows) ColumnTypeDatabaseTypeName(index int) string {
+	t := rs.columnType(index)
+	if t == types.TypeAny {
+		return ""
+	}
+
+	return strings.ToUpper(t.String())
+}
+
+// ColumnTypeScanType returns the type of the values returned by Next for the column.
+// Like for ColumnTypeDatabaseTypeName, it depends on the value in the first row.
+// It returns the type of an empty interface if there are no rows or if the value is NULL.
+func (rs *Rows) ColumnTypeScanType(index int) reflect.Type {
+	switch rs.columnType(index) {
+	case types.TypeBoolean:
+		return reflect.TypeOf(false)
+	case types.TypeInteger:
+		return reflect.TypeOf(int32(0))
+	case types.TypeBigint:
+		return reflect.TypeOf(int64(0))
+	case types.TypeUnsignedBigint:
+		return reflect.TypeOf(uint64(0))
+	case types.TypeDouble:
+		return reflect.TypeOf(float64(0))
+	case types.TypeReal:
+		return reflect.TypeOf(float32(0))
+	case types.TypeTimestamp, types.TypeTimestampTZ:
+		return reflect.TypeOf(time.Time{})
+	case types.TypeText, types.TypeCitext, types.TypeUUID, types.TypeJSON, types.TypeObject, types.TypeArray, types.TypeInet, types.TypeCidr, types.TypePoint, types.TypeInterval:
+		return reflect.TypeOf("")
+	case types.TypeBlob:
+		return reflect.TypeOf([]byte(nil))
+	}
+
+	return reflect.TypeOf((*any)(nil)).Elem()
+}
+
+func (rs *Rows) Next(dest []driver.Value) error {
+	r, err := rs.fetch()
+	if err != nil {
+		return err
 	}
 
 	var i int
-	err := r.r.Row.Iterate(func(column string, v types.Value) error {
+	err = r.Row.Iterate(func(column string, v types.Value) error {
 		var err error
 
 		switch v.Type() {
@@ -339,6 +612,13 @@ func (rs *Rows) Next(dest []driver.Value) error {
 				return err
 			}
 			dest[i] = bi
+		case types.TypeUnsignedBigint:
+			var ui uint64
+			err = row.ScanValue(v, &ui)
+			if err != nil {
+				return err
+			}
+			dest[i] = ui
 		case types.TypeDouble:
 			var d float64
 			err = row.ScanValue(v, &d)
@@ -346,14 +626,21 @@ func (rs *Rows) Next(dest []driver.Value) error {
 				return err
 			}
 			dest[i] = d
-		case types.TypeTimestamp:
+		case types.TypeReal:
+			var r float32
+			err = row.ScanValue(v, &r)
+			if err != nil {
+				return err
+			}
+			dest[i] = r
+		case types.TypeTimestamp, types.TypeTimestampTZ:
 			var t time.Time
 			err = row.ScanValue(v, &t)
 			if err != nil {
 				return err
 			}
 			dest[i] = t
-		case types.TypeText:
+		case types.TypeText, types.TypeCitext, types.TypeUUID, types.TypeJSON, types.TypeObject, types.TypeArray, types.TypeInet, types.TypeCidr, types.TypePoint, types.TypeInterval:
 			var s string
 			err = row.ScanValue(v, &s)
 			if err != nil {
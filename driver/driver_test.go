@@ -3,10 +3,15 @@ package driver
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
+	"math"
+	"net/netip"
+	"reflect"
 	"testing"
 	"time"
 
+	"github.com/chaisql/chai"
 	"github.com/stretchr/testify/require"
 )
 
@@ -212,16 +217,22 @@ func TestDriver(t *testing.T) {
 		require.NoError(t, err)
 		defer rows.Close()
 
-		var count int
-		var rt rowtest
-		for rows.Next() {
-			err = rows.Scan(&rt.A, &rt.B, &rt.C)
-			require.NoError(t, err)
-			require.Equal(t, rowtest{count, fmt.Sprintf("foo%d", count), count%2 == 0}, rt)
-			count++
+		// each SELECT returns a result set
+		for i, want := range []int{10, 11} {
+			var count int
+			var rt rowtest
+			for rows.Next() {
+				err = rows.Scan(&rt.A, &rt.B, &rt.C)
+				require.NoError(t, err)
+				require.Equal(t, rowtest{count, fmt.Sprintf("foo%d", count), count%2 == 0}, rt)
+				count++
+			}
+			require.NoError(t, rows.Err())
+			require.Equal(t, want, count)
+
+			require.Equal(t, i == 0, rows.NextResultSet())
 		}
 		require.NoError(t, rows.Err())
-		require.Equal(t, 11, count)
 	})
 
 	t.Run("Multiple queries in transaction", func(t *testing.T) {
@@ -237,16 +248,22 @@ func TestDriver(t *testing.T) {
 		require.NoError(t, err)
 		defer rows.Close()
 
-		var count int
-		var rt rowtest
-		for rows.Next() {
-			err = rows.Scan(&rt.A, &rt.B, &rt.C)
-			require.NoError(t, err)
-			require.Equal(t, rowtest{count, fmt.Sprintf("foo%d", count), count%2 == 0}, rt)
-			count++
+		// each SELECT returns a result set
+		for i, want := range []int{11, 12} {
+			var count int
+			var rt rowtest
+			for rows.Next() {
+				err = rows.Scan(&rt.A, &rt.B, &rt.C)
+				require.NoError(t, err)
+				require.Equal(t, rowtest{count, fmt.Sprintf("foo%d", count), count%2 == 0}, rt)
+				count++
+			}
+			require.NoError(t, rows.Err())
+			require.Equal(t, want, count)
+
+			require.Equal(t, i == 0, rows.NextResultSet())
 		}
 		require.NoError(t, rows.Err())
-		require.Equal(t, 12, count)
 	})
 
 	t.Run("Multiple queries in read only transaction", func(t *testing.T) {
@@ -254,15 +271,44 @@ func TestDriver(t *testing.T) {
 		require.NoError(t, err)
 		defer tx.Rollback()
 
+		// the whole query is rejected when it is prepared
 		_, err = tx.Query(`
 			SELECT * FROM test;;;
 			INSERT INTO test (a, b, c) VALUES (12, 13, 14);
 			SELECT * FROM test;
 		`)
-		require.EqualError(t, err, "cannot increment sequence on read-only transaction")
+		require.ErrorIs(t, err, chai.ErrReadOnlyTx)
 	})
 }
 
+func TestDriverWithContext(t *testing.T) {
+	db, err := sql.Open("chai", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec("CREATE TABLE test(a INT)")
+	require.NoError(t, err)
+
+	// the queries are stopped when their deadline is exceeded
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	var n int
+	err = db.QueryRowContext(ctx, "SELECT COUNT(*) FROM generate_series(1, 1000000000000)").Scan(&n)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err = db.ExecContext(ctx, "INSERT INTO test (a) SELECT generate_series FROM generate_series(1, 1000000000000)")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// and their changes are rolled back
+	err = db.QueryRow("SELECT COUNT(*) FROM test").Scan(&n)
+	require.NoError(t, err)
+	require.Zero(t, n)
+}
+
 func TestDriverWithTimeValues(t *testing.T) {
 	db, err := sql.Open("chai", ":memory:")
 	require.NoError(t, err)
@@ -281,3 +327,227 @@ func TestDriverWithTimeValues(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, now, tt)
 }
+
+func TestDriverWithUnsignedValues(t *testing.T) {
+	db, err := sql.Open("chai", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec("CREATE TABLE test(a BIGINT UNSIGNED PRIMARY KEY)")
+	require.NoError(t, err)
+
+	for _, x := range []uint64{math.MaxUint64, 10, math.MaxInt64 + 1} {
+		_, err = db.Exec("INSERT INTO test (a) VALUES (?)", x)
+		require.NoError(t, err)
+	}
+
+	rows, err := db.Query("SELECT a FROM test WHERE a > ?", uint64(10))
+	require.NoError(t, err)
+	defer rows.Close()
+
+	var got []uint64
+	for rows.Next() {
+		var x uint64
+		require.NoError(t, rows.Scan(&x))
+		got = append(got, x)
+	}
+	require.NoError(t, rows.Err())
+	require.Equal(t, []uint64{math.MaxInt64 + 1, math.MaxUint64}, got)
+}
+
+func TestDriverWithNetworkValues(t *testing.T) {
+	db, err := sql.Open("chai", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec("CREATE TABLE test(a INET PRIMARY KEY)")
+	require.NoError(t, err)
+
+	_, err = db.Exec("INSERT INTO test (a) VALUES (?), (?), (?)",
+		netip.MustParseAddr("10.0.0.1"), netip.MustParsePrefix("10.1.2.3/16"), netip.MustParseAddr("11.0.0.1"))
+	require.NoError(t, err)
+
+	rows, err := db.Query("SELECT a FROM test WHERE a <<= ?", netip.MustParsePrefix("10.0.0.0/8"))
+	require.NoError(t, err)
+	defer rows.Close()
+
+	var got []string
+	for rows.Next() {
+		var s string
+		require.NoError(t, rows.Scan(&s))
+		got = append(got, s)
+	}
+	require.NoError(t, rows.Err())
+	require.Equal(t, []string{"10.0.0.1", "10.1.2.3/16"}, got)
+}
+
+func TestDriverResultSets(t *testing.T) {
+	db, err := sql.Open("chai", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec("CREATE TABLE test(a INT PRIMARY KEY, b TEXT)")
+	require.NoError(t, err)
+
+	t.Run("Statements without rows are skipped", func(t *testing.T) {
+		rows, err := db.Query(`
+			INSERT INTO test (a, b) VALUES (1, 'foo');
+			SELECT a FROM test;
+			UPDATE test SET b = 'bar';
+			SELECT b FROM test;
+			INSERT INTO test (a, b) VALUES (2, 'baz');
+		`)
+		require.NoError(t, err)
+		defer rows.Close()
+
+		var a int
+		require.True(t, rows.Next())
+		require.NoError(t, rows.Scan(&a))
+		require.Equal(t, 1, a)
+		require.False(t, rows.Next())
+
+		require.True(t, rows.NextResultSet())
+		cols, err := rows.Columns()
+		require.NoError(t, err)
+		require.Equal(t, []string{"b"}, cols)
+
+		var b string
+		require.True(t, rows.Next())
+		require.NoError(t, rows.Scan(&b))
+		require.Equal(t, "bar", b)
+
+		// the last INSERT returns no rows
+		require.False(t, rows.NextResultSet())
+		require.NoError(t, rows.Err())
+
+		var n int
+		require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM test").Scan(&n))
+		require.Equal(t, 2, n)
+	})
+
+	t.Run("Close runs the remaining statements", func(t *testing.T) {
+		rows, err := db.Query("SELECT a FROM test; DELETE FROM test WHERE a = 2")
+		require.NoError(t, err)
+		require.NoError(t, rows.Close())
+
+		var n int
+		require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM test").Scan(&n))
+		require.Equal(t, 1, n)
+	})
+
+	t.Run("Column types", func(t *testing.T) {
+		rows, err := db.Query("SELECT a, b, 1.5 AS c, NULL AS d FROM test")
+		require.NoError(t, err)
+		defer rows.Close()
+
+		cts, err := rows.ColumnTypes()
+		require.NoError(t, err)
+
+		var names []string
+		var scanTypes []reflect.Type
+		for _, ct := range cts {
+			names = append(names, ct.DatabaseTypeName())
+			scanTypes = append(scanTypes, ct.ScanType())
+		}
+		require.Equal(t, []string{"INTEGER", "TEXT", "DOUBLE", ""}, names)
+		require.Equal(t, []reflect.Type{reflect.TypeOf(int32(0)), reflect.TypeOf(""), reflect.TypeOf(float64(0)), reflect.TypeOf((*any)(nil)).Elem()}, scanTypes)
+
+		// the row read to find the types is still returned
+		var a int
+		var b string
+		var c float64
+		var d any
+		require.True(t, rows.Next())
+		require.NoError(t, rows.Scan(&a, &b, &c, &d))
+		require.Equal(t, 1, a)
+		require.False(t, rows.Next())
+		require.NoError(t, rows.Err())
+	})
+}
+
+func TestDriverConnLifetime(t *testing.T) {
+	db, err := sql.Open("chai", ":memory:")
+	require.NoError(t, err)
+
+	require.NoError(t, db.Ping())
+
+	sc, err := db.Conn(context.Background())
+	require.NoError(t, err)
+
+	err = sc.Raw(func(dc any) error {
+		c := dc.(*conn)
+		require.True(t, c.IsValid())
+		require.NoError(t, c.Ping(context.Background()))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		require.ErrorIs(t, c.Ping(ctx), context.Canceled)
+		return nil
+	})
+	require.NoError(t, err)
+	require.NoError(t, sc.Close())
+
+	// closing a connection twice doesn't release it twice
+	c, err := db.Driver().(sqlDriver).OpenConnector(":memory:")
+	require.NoError(t, err)
+	dc, err := c.Connect(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, dc.Close())
+	require.NoError(t, dc.Close())
+	require.False(t, dc.(*conn).IsValid())
+	require.ErrorIs(t, dc.(*conn).Ping(context.Background()), driver.ErrBadConn)
+	require.ErrorIs(t, dc.(*conn).ResetSession(context.Background()), driver.ErrBadConn)
+	_, err = dc.(*conn).BeginTx(context.Background(), driver.TxOptions{})
+	require.EqualError(t, err, "connection is closed")
+	require.NoError(t, c.(*connector).Close())
+
+	require.NoError(t, db.Close())
+	require.Error(t, db.Ping())
+}
+
+// money is stored as a number of cents and implements the sql.Scanner
+// and driver.Valuer interfaces.
+type money struct {
+	cents int64
+}
+
+func (m money) Value() (driver.Value, error) {
+	return m.cents, nil
+}
+
+func (m *money) Scan(src any) error {
+	n, ok := src.(int64)
+	if !ok {
+		return fmt.Errorf("cannot scan %T into money", src)
+	}
+	m.cents = n
+	return nil
+}
+
+// orderID is stored as text and implements the encoding.TextMarshaler interface.
+type orderID struct {
+	n int
+}
+
+func (o orderID) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("order-%d", o.n)), nil
+}
+
+func TestDriverWithCustomTypes(t *testing.T) {
+	db, err := sql.Open("chai", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec("CREATE TABLE test(id TEXT PRIMARY KEY, price BIGINT)")
+	require.NoError(t, err)
+
+	_, err = db.Exec("INSERT INTO test (id, price) VALUES (?, ?)", orderID{n: 1}, money{cents: 1050})
+	require.NoError(t, err)
+
+	var id string
+	var m money
+	err = db.QueryRow("SELECT id, price FROM test WHERE id = ?", orderID{n: 1}).Scan(&id, &m)
+	require.NoError(t, err)
+	require.Equal(t, "order-1", id)
+	require.Equal(t, money{cents: 1050}, m)
+}
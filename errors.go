@@ -3,6 +3,7 @@ package chai
 import (
 	"github.com/chaisql/chai/internal/database"
 	errs "github.com/chaisql/chai/internal/errors"
+	"github.com/chaisql/chai/internal/kv"
 	"github.com/cockroachdb/errors"
 )
 
@@ -11,6 +12,38 @@ import (
 // doesn't exist.
 var IsNotFoundError = errs.IsNotFoundError
 
+// ErrWriteConflict is returned when committing a transaction that conflicts
+// with another transaction. See Options.ConcurrentWrites.
+var ErrWriteConflict = database.ErrWriteConflict
+
+// ErrReadOnly is returned when trying to modify a database opened
+// in read-only mode. See OpenReadOnly.
+var ErrReadOnly = database.ErrReadOnly
+
+// ErrReadOnlyTx is returned when preparing or running a query that modifies
+// the database within a read-only transaction. See DB.BeginRead.
+var ErrReadOnlyTx = database.ErrReadOnlyTx
+
+// ErrLockTimeout is returned when the database is still locked by another
+// process, or by another write transaction, after the timeout of Options.LockWait.
+var ErrLockTimeout = database.ErrLockTimeout
+
+// ErrCrashed is returned by the writes of a database after a crash
+// simulated with Options.CrashHook.
+var ErrCrashed = kv.ErrCrashed
+
+// IsLockTimeoutError determines if the database or the write lock
+// couldn't be acquired before the timeout of Options.LockWait.
+func IsLockTimeoutError(err error) bool {
+	return errors.Is(err, ErrLockTimeout)
+}
+
+// IsWriteConflictError determines if the transaction failed to commit because
+// of a conflict with another transaction. The transaction can be retried.
+func IsWriteConflictError(err error) bool {
+	return errors.Is(err, ErrWriteConflict)
+}
+
 // IsAlreadyExistsError determines if the error is returned as a result of
 // a conflict when attempting to create a table, an index, an row or a sequence
 // with a name that is already used by another resource.
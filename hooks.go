@@ -0,0 +1,48 @@
+package chai
+
+import (
+	"github.com/chaisql/chai/internal/database"
+)
+
+// A WriteEvent describes a modification of a row that is about to be applied.
+type WriteEvent struct {
+	Op    ChangeOp
+	Table string
+	// Row before the modification. Nil for inserts.
+	Old *Row
+	// Row after the modification. Nil for deletes.
+	New *Row
+}
+
+// OnWrite registers a function called before every insertion, replacement or deletion
+// of a row of the given table. It runs within the transaction modifying the row, which
+// can be used to run other statements, for example to maintain denormalized counters.
+// Returning an error vetoes the modification: the row is left untouched and the statement
+// fails with that error. The rows of the event are only valid until fn returns.
+// The returned function unregisters fn.
+func (db *DB) OnWrite(table string, fn func(tx *Tx, e *WriteEvent) error) (remove func()) {
+	return db.DB.AddWriteHook(table, func(tx *database.Transaction, e *database.WriteEvent) error {
+		var t *Tx
+		if conn := tx.Connection(); conn != nil {
+			t = &Tx{
+				conn: &Connection{
+					db:   db,
+					Conn: conn,
+				},
+			}
+		}
+
+		ev := WriteEvent{
+			Op:    e.Op,
+			Table: e.Table,
+		}
+		if e.Old != nil {
+			ev.Old = &Row{Row: e.Old}
+		}
+		if e.New != nil {
+			ev.New = &Row{Row: e.New}
+		}
+
+		return fn(t, &ev)
+	})
+}
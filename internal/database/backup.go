@@ -0,0 +1,560 @@
+package database
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"hash"
+	"hash/crc32"
+	"io"
+	"os"
+
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/chaisql/chai/internal/engine"
+	"github.com/chaisql/chai/internal/kv"
+	"github.com/cockroachdb/errors"
+)
+
+// backupMagic is written at the beginning of every backup.
+// The last byte is the version of the format.
+const backupMagic = "CHAIBKP\x01"
+
+// size of the magic, the kind and the two checkpoints.
+const backupHeaderSize = len(backupMagic) + 1 + 32
+
+// Kinds of backups.
+const (
+	backupFull byte = iota
+	backupIncremental
+)
+
+// Types of the records of a backup.
+const (
+	backupRecordEnd byte = iota
+	backupRecordSet
+	backupRecordDelete
+)
+
+const (
+	// number of records written or restored between two checks of the context.
+	backupCheckInterval = 1000
+	// number of bytes restored per transaction.
+	restoreBatchSize = 4 << 20
+)
+
+// backupHeader is written after the magic.
+type backupHeader struct {
+	kind byte
+	// checkpoint the incremental backup starts from.
+	// Zero for full backups.
+	since Checkpoint
+	// checkpoint of the database at the time of the backup.
+	// Zero if change tracking was disabled.
+	checkpoint Checkpoint
+}
+
+// Backup writes a consistent snapshot of the database to w.
+// The snapshot is read from a read-only transaction, which
+// doesn't prevent other transactions from writing while the backup is running.
+func (db *Database) Backup(ctx context.Context, w io.Writer) error {
+	tx, err := db.Begin(false)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	return tx.Backup(ctx, w)
+}
+
+// BackupIncremental writes the changes made since the given checkpoint to w.
+// It requires change tracking to be enabled.
+func (db *Database) BackupIncremental(ctx context.Context, w io.Writer, since Checkpoint) error {
+	tx, err := db.Begin(false)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	return tx.BackupIncremental(ctx, w, since)
+}
+
+// Backup writes the content of the database, as seen by the transaction, to w.
+// The rollback segment, the tracked changes and transient data are not included.
+// If change tracking is enabled, the backup records the checkpoint of the database,
+// from which incremental backups can be created.
+//
+// The format is a magic header, the kind of backup and its checkpoints, followed
+// by a list of records, each starting with its type, an end record
+// and a CRC-32 checksum of everything that precedes it.
+// Set records contain a uvarint-prefixed key and a uvarint-prefixed value,
+// delete records only contain a uvarint-prefixed key.
+func (tx *Transaction) Backup(ctx context.Context, w io.Writer) error {
+	if len(tx.db.Locations()) > 0 {
+		return errors.Wrap(errLocations, "backup")
+	}
+
+	c, err := readCheckpoint(tx.Session)
+	if err != nil {
+		return err
+	}
+
+	it, err := tx.Session.Iterator(&engine.IterOptions{
+		UpperBound: encoding.EncodeUint(nil, uint64(MinTransientNamespace)),
+	})
+	if err != nil {
+		return err
+	}
+	defer it.Close()
+
+	bw := newBackupWriter(w)
+
+	err = bw.writeHeader(backupHeader{kind: backupFull, checkpoint: c})
+	if err != nil {
+		return err
+	}
+
+	var n int
+	for it.First(); it.Valid(); it.Next() {
+		n++
+		if n%backupCheckInterval == 0 && ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		k := it.Key()
+		ns, _ := encoding.DecodeInt(k)
+		if ns == int64(RollbackSegmentNamespace) || ns == int64(ChangesNamespace) {
+			continue
+		}
+
+		v, err := it.Value()
+		if err != nil {
+			return err
+		}
+
+		err = bw.writeRecord(backupRecordSet, k, v)
+		if err != nil {
+			return err
+		}
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+
+	return bw.close()
+}
+
+// BackupIncremental writes to w the keys modified, as seen by the transaction,
+// since the given checkpoint. The checkpoint must have been created
+// during the current change tracking epoch.
+func (tx *Transaction) BackupIncremental(ctx context.Context, w io.Writer, since Checkpoint) error {
+	if len(tx.db.Locations()) > 0 {
+		return errors.Wrap(errLocations, "incremental backup")
+	}
+
+	c, err := readCheckpoint(tx.Session)
+	if err != nil {
+		return err
+	}
+	if c.IsZero() {
+		return errors.New("incremental backups require change tracking to be enabled")
+	}
+	if since.Epoch != c.Epoch || since.Seq > c.Seq {
+		return errors.New("the checkpoint doesn't belong to this database or change tracking was restarted: a full backup is required")
+	}
+
+	it, err := tx.Session.Iterator(&engine.IterOptions{
+		LowerBound: changesPrefix,
+		UpperBound: encoding.EncodeUint(nil, uint64(ChangesNamespace)+1),
+	})
+	if err != nil {
+		return err
+	}
+	defer it.Close()
+
+	bw := newBackupWriter(w)
+
+	err = bw.writeHeader(backupHeader{kind: backupIncremental, since: since, checkpoint: c})
+	if err != nil {
+		return err
+	}
+
+	var n int
+	for it.First(); it.Valid(); it.Next() {
+		n++
+		if n%backupCheckInterval == 0 && ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		k := it.Key()
+		if len(k) == len(changesPrefix) {
+			// tracking state
+			continue
+		}
+
+		v, err := it.Value()
+		if err != nil {
+			return err
+		}
+		if len(v) != 8 {
+			return errors.New("invalid tracked change")
+		}
+		if binary.BigEndian.Uint64(v) <= since.Seq {
+			continue
+		}
+
+		k = k[len(changesPrefix):]
+		v, err = tx.Session.Get(k)
+		if errors.Is(err, engine.ErrKeyNotFound) {
+			err = bw.writeRecord(backupRecordDelete, k, nil)
+		} else if err == nil {
+			err = bw.writeRecord(backupRecordSet, k, v)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+
+	return bw.close()
+}
+
+type backupWriter struct {
+	w   io.Writer
+	bw  *bufio.Writer
+	h   hash.Hash32
+	buf [binary.MaxVarintLen64]byte
+}
+
+func newBackupWriter(w io.Writer) *backupWriter {
+	h := crc32.NewIEEE()
+
+	return &backupWriter{
+		w:  w,
+		bw: bufio.NewWriter(io.MultiWriter(w, h)),
+		h:  h,
+	}
+}
+
+func (w *backupWriter) writeHeader(hdr backupHeader) error {
+	var buf [backupHeaderSize]byte
+	copy(buf[:], backupMagic)
+	b := buf[len(backupMagic):]
+	b[0] = hdr.kind
+	binary.BigEndian.PutUint64(b[1:], hdr.since.Epoch)
+	binary.BigEndian.PutUint64(b[9:], hdr.since.Seq)
+	binary.BigEndian.PutUint64(b[17:], hdr.checkpoint.Epoch)
+	binary.BigEndian.PutUint64(b[25:], hdr.checkpoint.Seq)
+
+	_, err := w.bw.Write(buf[:])
+	return err
+}
+
+func (w *backupWriter) writeBytes(b []byte) error {
+	n := binary.PutUvarint(w.buf[:], uint64(len(b)))
+	_, err := w.bw.Write(w.buf[:n])
+	if err != nil {
+		return err
+	}
+	_, err = w.bw.Write(b)
+	return err
+}
+
+func (w *backupWriter) writeRecord(typ byte, k, v []byte) error {
+	err := w.bw.WriteByte(typ)
+	if err != nil {
+		return err
+	}
+
+	err = w.writeBytes(k)
+	if err != nil || typ == backupRecordDelete {
+		return err
+	}
+
+	return w.writeBytes(v)
+}
+
+// close writes the end record and the checksum.
+func (w *backupWriter) close() error {
+	err := w.bw.WriteByte(backupRecordEnd)
+	if err != nil {
+		return err
+	}
+
+	err = w.bw.Flush()
+	if err != nil {
+		return err
+	}
+
+	return binary.Write(w.w, binary.BigEndian, w.h.Sum32())
+}
+
+// ReadBackupCheckpoint returns the checkpoint recorded in a backup.
+// It can be used to create the next incremental backup.
+// Only the beginning of the backup is read.
+func ReadBackupCheckpoint(r io.Reader) (Checkpoint, error) {
+	hdr, err := newBackupReader(r).readHeader()
+	if err != nil {
+		return Checkpoint{}, err
+	}
+
+	return hdr.checkpoint, nil
+}
+
+// Restore writes the content of a full backup created with Backup to the given engine,
+// which must be empty, followed by the changes of a chain of incremental backups.
+// Each incremental backup must start from the checkpoint of the previous backup.
+// The engine must not be used by a database while it is restored.
+// If a backup is invalid, the data restored so far is left in the engine.
+func Restore(ctx context.Context, ng engine.Engine, backups ...io.Reader) error {
+	if len(backups) == 0 {
+		return errors.New("no backup to restore")
+	}
+
+	empty, err := isEmpty(ng)
+	if err != nil {
+		return err
+	}
+	if !empty {
+		return errors.New("cannot restore a backup to a non-empty database")
+	}
+
+	var last Checkpoint
+	for i, r := range backups {
+		br := newBackupReader(r)
+
+		hdr, err := br.readHeader()
+		if err != nil {
+			return err
+		}
+
+		if i == 0 && hdr.kind != backupFull {
+			return errors.New("the first backup must be a full backup")
+		}
+		if i > 0 {
+			if hdr.kind != backupIncremental {
+				return errors.Newf("backup %d is not an incremental backup", i)
+			}
+			if last.IsZero() || hdr.since != last {
+				return errors.Newf("backup %d doesn't start from the checkpoint of the previous backup", i)
+			}
+		}
+
+		err = restoreRecords(ctx, ng, br)
+		if err != nil {
+			return err
+		}
+
+		last = hdr.checkpoint
+	}
+
+	return nil
+}
+
+// RestoreTo creates a database at path, which must not exist, and restores
+// the given backups into it. See Restore.
+// If the restoration fails, the directory of the database is removed.
+func RestoreTo(ctx context.Context, path string, backups ...io.Reader) (err error) {
+	if path == ":memory:" {
+		return errors.New("cannot restore a backup to an in-memory database")
+	}
+
+	_, err = os.Stat(path)
+	if err == nil {
+		return errors.Newf("cannot restore a backup to %q: the path already exists", path)
+	}
+	if !os.IsNotExist(err) {
+		return err
+	}
+
+	ng, err := kv.NewEngine(path, engineOptions(kv.Options{}))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		cerr := ng.Close()
+		if err == nil {
+			err = cerr
+		}
+		if err != nil {
+			_ = os.RemoveAll(path)
+		}
+	}()
+
+	return Restore(ctx, ng, backups...)
+}
+
+// restoreRecords writes the records of a backup to the engine.
+// The content is committed in multiple transactions
+// to avoid keeping the whole backup in memory.
+func restoreRecords(ctx context.Context, ng engine.Engine, br *backupReader) error {
+	s := ng.NewBatchSession()
+	defer func() {
+		_ = s.Close()
+	}()
+
+	var n, size int
+	for {
+		n++
+		if n%backupCheckInterval == 0 && ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		typ, k, v, err := br.readRecord()
+		if err != nil {
+			return err
+		}
+		if typ == backupRecordEnd {
+			break
+		}
+
+		if typ == backupRecordDelete {
+			// the key might have been created after the previous backup
+			err = s.Delete(k)
+			if errors.Is(err, engine.ErrKeyNotFound) {
+				err = nil
+			}
+		} else {
+			err = s.Put(k, v)
+		}
+		if err != nil {
+			return err
+		}
+
+		size += len(k) + len(v)
+		if size >= restoreBatchSize {
+			err = s.Commit()
+			if err != nil {
+				return err
+			}
+			s = ng.NewBatchSession()
+			size = 0
+		}
+	}
+
+	err := br.checkSum()
+	if err != nil {
+		return err
+	}
+
+	return s.Commit()
+}
+
+type backupReader struct {
+	br *bufio.Reader
+	h  hash.Hash32
+}
+
+func newBackupReader(r io.Reader) *backupReader {
+	return &backupReader{
+		br: bufio.NewReader(r),
+		h:  crc32.NewIEEE(),
+	}
+}
+
+func (r *backupReader) Read(p []byte) (int, error) {
+	n, err := r.br.Read(p)
+	_, _ = r.h.Write(p[:n])
+	return n, err
+}
+
+func (r *backupReader) ReadByte() (byte, error) {
+	c, err := r.br.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	_, _ = r.h.Write([]byte{c})
+	return c, nil
+}
+
+func (r *backupReader) readHeader() (backupHeader, error) {
+	var hdr backupHeader
+	var buf [backupHeaderSize]byte
+
+	_, err := io.ReadFull(r, buf[:])
+	if err != nil || string(buf[:len(backupMagic)]) != backupMagic {
+		return hdr, errors.New("invalid backup: bad header")
+	}
+
+	b := buf[len(backupMagic):]
+	hdr.kind = b[0]
+	if hdr.kind != backupFull && hdr.kind != backupIncremental {
+		return hdr, errors.Newf("invalid backup: unknown kind %d", hdr.kind)
+	}
+	hdr.since.Epoch = binary.BigEndian.Uint64(b[1:])
+	hdr.since.Seq = binary.BigEndian.Uint64(b[9:])
+	hdr.checkpoint.Epoch = binary.BigEndian.Uint64(b[17:])
+	hdr.checkpoint.Seq = binary.BigEndian.Uint64(b[25:])
+
+	return hdr, nil
+}
+
+func (r *backupReader) readBytes() ([]byte, error) {
+	l, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+
+	b := make([]byte, l)
+	_, err = io.ReadFull(r, b)
+	return b, err
+}
+
+// readRecord returns the next record of the backup.
+// The key and value are nil for the end record.
+func (r *backupReader) readRecord() (typ byte, k, v []byte, err error) {
+	typ, err = r.ReadByte()
+	if err != nil {
+		return 0, nil, nil, errors.Wrap(err, "invalid backup")
+	}
+
+	switch typ {
+	case backupRecordEnd:
+		return typ, nil, nil, nil
+	case backupRecordSet, backupRecordDelete:
+	default:
+		return 0, nil, nil, errors.Newf("invalid backup: unknown record type %d", typ)
+	}
+
+	k, err = r.readBytes()
+	if err == nil && typ == backupRecordSet {
+		v, err = r.readBytes()
+	}
+	if err != nil {
+		return 0, nil, nil, errors.Wrap(err, "invalid backup")
+	}
+
+	return typ, k, v, nil
+}
+
+// checkSum reads the checksum that follows the end record
+// and compares it with the checksum of the data read so far.
+func (r *backupReader) checkSum() error {
+	sum := r.h.Sum32()
+
+	var expected uint32
+	err := binary.Read(r.br, binary.BigEndian, &expected)
+	if err != nil {
+		return errors.Wrap(err, "invalid backup: missing checksum")
+	}
+	if sum != expected {
+		return errors.New("invalid backup: checksum mismatch")
+	}
+
+	return nil
+}
+
+func isEmpty(ng engine.Engine) (bool, error) {
+	s := ng.NewSnapshotSession()
+	defer s.Close()
+
+	it, err := s.Iterator(nil)
+	if err != nil {
+		return false, err
+	}
+	defer it.Close()
+
+	it.First()
+	return !it.Valid(), it.Error()
+}
@@ -0,0 +1,286 @@
+package database_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/chaisql/chai"
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/database/catalogstore"
+	"github.com/chaisql/chai/internal/engine"
+	"github.com/chaisql/chai/internal/engine/memory"
+	"github.com/chaisql/chai/internal/kv"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackup(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE test (a INTEGER PRIMARY KEY, b TEXT);
+		CREATE INDEX test_b ON test (b);
+		INSERT INTO test (a, b) VALUES (1, 'a'), (2, 'b'), (3, 'c');
+	`)
+	require.NoError(t, err)
+
+	restoreTo := func(t *testing.T, b []byte, ng engine.Engine) *chai.DB {
+		t.Helper()
+
+		err := database.Restore(context.Background(), ng, bytes.NewReader(b))
+		require.NoError(t, err)
+
+		rdb, err := database.Open(":memory:", &database.Options{
+			CatalogLoader: catalogstore.LoadCatalog,
+			Engine:        ng,
+		})
+		require.NoError(t, err)
+		t.Cleanup(func() { rdb.Close() })
+
+		return &chai.DB{DB: rdb}
+	}
+
+	restore := func(t *testing.T, b []byte) *chai.DB {
+		return restoreTo(t, b, memory.NewEngine())
+	}
+
+	count := func(t *testing.T, db *chai.DB) int {
+		t.Helper()
+
+		r, err := db.QueryRow(`SELECT COUNT(*) FROM test WHERE b >= 'a'`)
+		require.NoError(t, err)
+		var n int
+		require.NoError(t, r.Scan(&n))
+		return n
+	}
+
+	t.Run("Backup", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := db.Backup(context.Background(), &buf)
+		require.NoError(t, err)
+
+		require.Equal(t, 3, count(t, restore(t, buf.Bytes())))
+
+		// the database closes the engine
+		ng, err := kv.NewEngine(t.TempDir(), kv.Options{
+			RollbackSegmentNamespace: int64(database.RollbackSegmentNamespace),
+			MinTransientNamespace:    uint64(database.MinTransientNamespace),
+			MaxTransientNamespace:    uint64(database.MaxTransientNamespace),
+		})
+		require.NoError(t, err)
+		require.Equal(t, 3, count(t, restoreTo(t, buf.Bytes(), ng)))
+	})
+
+	t.Run("Concurrent writes", func(t *testing.T) {
+		tx, err := db.DB.Begin(false)
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		// writes committed after the backup started are not included
+		err = db.Exec(`INSERT INTO test (a, b) VALUES (4, 'd')`)
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		err = tx.Backup(context.Background(), &buf)
+		require.NoError(t, err)
+
+		require.Equal(t, 3, count(t, restore(t, buf.Bytes())))
+
+		err = db.Exec(`DELETE FROM test WHERE a = 4`)
+		require.NoError(t, err)
+	})
+
+	t.Run("Statement", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "db.bak")
+		err := db.Exec(`BACKUP TO ?`, path)
+		require.Error(t, err)
+
+		err = db.Exec(`BACKUP TO '` + path + `'`)
+		require.NoError(t, err)
+
+		// the file must not exist
+		err = db.Exec(`BACKUP TO '` + path + `'`)
+		require.Error(t, err)
+	})
+
+	t.Run("Statement canceled", func(t *testing.T) {
+		db, err := chai.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec(`CREATE TABLE test (a INTEGER PRIMARY KEY)`)
+		require.NoError(t, err)
+		for i := 0; i < 2000; i++ {
+			require.NoError(t, db.Exec(`INSERT INTO test (a) VALUES (?)`, i))
+		}
+
+		// the statement is prepared, then the backup stops when it checks the context
+		path := filepath.Join(t.TempDir(), "db.bak")
+		err = db.ExecContext(canceledContext{context.Background()}, `BACKUP TO '`+path+`'`)
+		require.ErrorIs(t, err, context.Canceled)
+		require.NoFileExists(t, path)
+	})
+
+	t.Run("Corrupted", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := db.Backup(context.Background(), &buf)
+		require.NoError(t, err)
+
+		b := buf.Bytes()
+		b[len(b)/2] ^= 0xff
+		err = database.Restore(context.Background(), memory.NewEngine(), bytes.NewReader(b))
+		require.Error(t, err)
+
+		err = database.Restore(context.Background(), memory.NewEngine(), bytes.NewReader(b[:len(b)-2]))
+		require.Error(t, err)
+	})
+
+	t.Run("Non-empty", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := db.Backup(context.Background(), &buf)
+		require.NoError(t, err)
+
+		ng := memory.NewEngine()
+		require.NoError(t, database.Restore(context.Background(), ng, bytes.NewReader(buf.Bytes())))
+		err = database.Restore(context.Background(), ng, bytes.NewReader(buf.Bytes()))
+		require.Error(t, err)
+	})
+}
+
+func TestBackupIncremental(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "db")
+	db, err := chai.OpenWith(dir, &chai.Options{TrackChanges: true})
+	require.NoError(t, err)
+
+	err = db.Exec(`
+		CREATE TABLE test (a INTEGER PRIMARY KEY, b TEXT);
+		CREATE TABLE other (a INTEGER PRIMARY KEY);
+		INSERT INTO test (a, b) VALUES (1, 'a'), (2, 'b'), (3, 'c');
+		INSERT INTO other (a) VALUES (1);
+	`)
+	require.NoError(t, err)
+
+	backup := func(t *testing.T, since []byte) []byte {
+		t.Helper()
+
+		var buf bytes.Buffer
+		if since == nil {
+			require.NoError(t, db.Backup(context.Background(), &buf))
+			return buf.Bytes()
+		}
+
+		c, err := chai.ReadBackupCheckpoint(bytes.NewReader(since))
+		require.NoError(t, err)
+		require.NoError(t, db.BackupIncremental(context.Background(), &buf, c))
+		return buf.Bytes()
+	}
+
+	restore := func(t *testing.T, backups ...[]byte) (*chai.DB, error) {
+		t.Helper()
+
+		var rs []io.Reader
+		for _, b := range backups {
+			rs = append(rs, bytes.NewReader(b))
+		}
+
+		ng := memory.NewEngine()
+		err := database.Restore(context.Background(), ng, rs...)
+		if err != nil {
+			return nil, err
+		}
+
+		rdb, err := database.Open(":memory:", &database.Options{
+			CatalogLoader: catalogstore.LoadCatalog,
+			Engine:        ng,
+		})
+		require.NoError(t, err)
+		t.Cleanup(func() { rdb.Close() })
+
+		return &chai.DB{DB: rdb}, nil
+	}
+
+	rows := func(t *testing.T, db *chai.DB) string {
+		t.Helper()
+
+		conn, err := db.Connect()
+		require.NoError(t, err)
+		defer conn.Close()
+
+		res, err := conn.Query(`SELECT a, b FROM test`)
+		require.NoError(t, err)
+		defer res.Close()
+
+		var buf bytes.Buffer
+		err = res.MarshalJSONTo(&buf)
+		require.NoError(t, err)
+		return buf.String()
+	}
+
+	full := backup(t, nil)
+
+	err = db.Exec(`
+		INSERT INTO test (a, b) VALUES (4, 'd');
+		DELETE FROM test WHERE a = 1;
+		UPDATE test SET b = 'B' WHERE a = 2;
+	`)
+	require.NoError(t, err)
+	inc1 := backup(t, full)
+
+	err = db.Exec(`
+		INSERT INTO test (a, b) VALUES (5, 'e');
+		DROP TABLE other;
+	`)
+	require.NoError(t, err)
+	inc2 := backup(t, inc1)
+
+	// nothing changed since the last backup
+	inc3 := backup(t, inc2)
+
+	expected := rows(t, db)
+
+	rdb, err := restore(t, full, inc1, inc2, inc3)
+	require.NoError(t, err)
+	require.JSONEq(t, expected, rows(t, rdb))
+	err = rdb.Exec(`SELECT * FROM other`)
+	require.Error(t, err)
+
+	rdb, err = restore(t, full, inc1)
+	require.NoError(t, err)
+	require.JSONEq(t, `[{"a": 2, "b": "B"}, {"a": 3, "b": "c"}, {"a": 4, "b": "d"}]`, rows(t, rdb))
+
+	// broken chains
+	_, err = restore(t, full, inc2)
+	require.Error(t, err)
+	_, err = restore(t, inc1)
+	require.Error(t, err)
+	_, err = restore(t, full, full)
+	require.Error(t, err)
+
+	// disabling change tracking invalidates the checkpoints
+	require.NoError(t, db.Close())
+	db, err = chai.OpenWith(dir, nil)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+	db, err = chai.OpenWith(dir, &chai.Options{TrackChanges: true})
+	require.NoError(t, err)
+	defer db.Close()
+
+	c, err := chai.ReadBackupCheckpoint(bytes.NewReader(inc3))
+	require.NoError(t, err)
+	err = db.BackupIncremental(context.Background(), io.Discard, c)
+	require.Error(t, err)
+}
+
+// canceledContext is a context that is never done, but whose Err
+// reports a cancellation to the operations that poll it.
+type canceledContext struct {
+	context.Context
+}
+
+func (canceledContext) Err() error {
+	return context.Canceled
+}
@@ -0,0 +1,564 @@
+package database
+
+import (
+	"bytes"
+	"io"
+	"slices"
+
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/chaisql/chai/internal/engine"
+	errs "github.com/chaisql/chai/internal/errors"
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// Large BLOB values are not stored in their row: their content is split
+// in chunks stored in the overflow namespace and the row only contains
+// a reference to them, made of the identifier of the blob and its size.
+// Chunks are stored under the key (id, n), where n is the position
+// of the chunk in the blob, and are encoded as BLOB values.
+//
+// A blob belongs to exactly one row: rows never share chunks, which are
+// deleted when the transaction that deleted the row or replaced the value
+// is committed. Blob identifiers are allocated in memory and are never reused.
+
+const (
+	// DefaultOverflowThreshold is the size above which BLOB values
+	// are stored outside of their row.
+	DefaultOverflowThreshold = 64 << 10
+
+	// size of the chunks of the blobs stored outside of their row.
+	overflowChunkSize = 64 << 10
+)
+
+// A blobRef references a blob stored in the overflow namespace.
+type blobRef struct {
+	id   uint64
+	size uint64
+}
+
+func overflowTree(tx *Transaction) *tree.Tree {
+	return tree.New(tx.Session, OverflowNamespace, nil)
+}
+
+func blobChunkKey(id uint64, n int) *tree.Key {
+	return tree.NewKey(types.NewBigintValue(int64(id)), types.NewBigintValue(int64(n)))
+}
+
+// loadLastBlobID returns the identifier of the last blob stored in the overflow namespace.
+func loadLastBlobID(tx *Transaction) (uint64, error) {
+	var id uint64
+	err := overflowTree(tx).IterateOnRange(nil, true, func(k *tree.Key, _ []byte) error {
+		vs, err := k.Decode()
+		if err != nil {
+			return err
+		}
+		id = uint64(types.AsInt64(vs[0]))
+		return errStop
+	})
+	if err != nil && !errors.Is(err, errStop) {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// writeBlob stores the content of r in the overflow namespace.
+func (tx *Transaction) writeBlob(r io.Reader) (blobRef, error) {
+	ref := blobRef{id: tx.db.blobIDs.Add(1)}
+	t := overflowTree(tx)
+
+	buf := make([]byte, overflowChunkSize)
+	var enc []byte
+	for n := 0; ; n++ {
+		l, err := io.ReadFull(r, buf)
+		if l > 0 {
+			enc = encoding.EncodeBlob(enc[:0], buf[:l])
+			err := t.Put(blobChunkKey(ref.id, n), enc)
+			if err != nil {
+				return blobRef{}, err
+			}
+			ref.size += uint64(l)
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return ref, nil
+		}
+		if err != nil {
+			return blobRef{}, err
+		}
+	}
+}
+
+// deleteBlob deletes the chunks of the blob.
+func (tx *Transaction) deleteBlob(ref blobRef) error {
+	k := tree.NewKey(types.NewBigintValue(int64(ref.id)))
+	return overflowTree(tx).DeleteRange(&tree.Range{Min: k, Max: k})
+}
+
+// readBlob loads the whole content of the blob in memory.
+func (tx *Transaction) readBlob(ref blobRef) ([]byte, error) {
+	buf := bytes.NewBuffer(make([]byte, 0, ref.size))
+	_, err := io.Copy(buf, tx.newBlobReader(ref))
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// blobReader streams the content of a blob stored in the overflow namespace,
+// loading one chunk at a time.
+type blobReader struct {
+	tx   *Transaction
+	ref  blobRef
+	next int
+	// remaining data of the current chunk.
+	buf  []byte
+	read uint64
+}
+
+func (tx *Transaction) newBlobReader(ref blobRef) *blobReader {
+	return &blobReader{tx: tx, ref: ref}
+}
+
+func (r *blobReader) Read(p []byte) (int, error) {
+	if len(r.buf) == 0 {
+		if r.read >= r.ref.size {
+			return 0, io.EOF
+		}
+
+		chunk, err := overflowTree(r.tx).Get(blobChunkKey(r.ref.id, r.next))
+		if errors.Is(err, engine.ErrKeyNotFound) {
+			return 0, errors.Errorf("chunk %d of blob %d is missing", r.next, r.ref.id)
+		}
+		if err != nil {
+			return 0, err
+		}
+		r.next++
+		r.buf, _ = encoding.DecodeBlob(chunk)
+	}
+
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	r.read += uint64(n)
+	return n, nil
+}
+
+var _ types.BlobStream = (*blobValue)(nil)
+
+// blobValue is the value of a BLOB column stored outside of its row,
+// as returned by EncodedRow. Its content is loaded the first time it is used,
+// unless it is read with Open.
+// It must not be used after the end of the transaction.
+type blobValue struct {
+	tx  *Transaction
+	ref blobRef
+
+	loaded bool
+	data   types.BlobValue
+	err    error
+}
+
+func (v *blobValue) Open() (io.Reader, error) {
+	if v.loaded {
+		if v.err != nil {
+			return nil, v.err
+		}
+		return bytes.NewReader(v.data), nil
+	}
+
+	return v.tx.newBlobReader(v.ref), nil
+}
+
+func (v *blobValue) Load() (types.BlobValue, error) {
+	if !v.loaded {
+		var data []byte
+		data, v.err = v.tx.readBlob(v.ref)
+		v.data = types.NewBlobValue(data)
+		v.loaded = true
+	}
+
+	return v.data, v.err
+}
+
+// mustLoad is used by the methods that cannot return an error.
+func (v *blobValue) mustLoad() types.BlobValue {
+	data, err := v.Load()
+	if err != nil {
+		panic(err)
+	}
+
+	return data
+}
+
+func (v *blobValue) V() any {
+	return v.mustLoad().V()
+}
+
+func (v *blobValue) Type() types.Type {
+	return types.TypeBlob
+}
+
+func (v *blobValue) TypeDef() types.TypeDefinition {
+	return types.BlobTypeDef{}
+}
+
+func (v *blobValue) IsZero() (bool, error) {
+	return false, nil
+}
+
+func (v *blobValue) String() string {
+	return v.mustLoad().String()
+}
+
+func (v *blobValue) MarshalText() ([]byte, error) {
+	data, err := v.Load()
+	if err != nil {
+		return nil, err
+	}
+	return data.MarshalText()
+}
+
+func (v *blobValue) MarshalJSON() ([]byte, error) {
+	data, err := v.Load()
+	if err != nil {
+		return nil, err
+	}
+	return data.MarshalJSON()
+}
+
+func (v *blobValue) Encode(dst []byte) ([]byte, error) {
+	data, err := v.Load()
+	if err != nil {
+		return nil, err
+	}
+	return data.Encode(dst)
+}
+
+func (v *blobValue) EncodeAsKey(dst []byte) ([]byte, error) {
+	data, err := v.Load()
+	if err != nil {
+		return nil, err
+	}
+	return data.EncodeAsKey(dst)
+}
+
+func (v *blobValue) CastAs(target types.Type) (types.Value, error) {
+	data, err := v.Load()
+	if err != nil {
+		return nil, err
+	}
+	return data.CastAs(target)
+}
+
+func (v *blobValue) EQ(other types.Value) (bool, error) {
+	data, err := v.Load()
+	if err != nil {
+		return false, err
+	}
+	return data.EQ(other)
+}
+
+func (v *blobValue) GT(other types.Value) (bool, error) {
+	data, err := v.Load()
+	if err != nil {
+		return false, err
+	}
+	return data.GT(other)
+}
+
+func (v *blobValue) GTE(other types.Value) (bool, error) {
+	data, err := v.Load()
+	if err != nil {
+		return false, err
+	}
+	return data.GTE(other)
+}
+
+func (v *blobValue) LT(other types.Value) (bool, error) {
+	data, err := v.Load()
+	if err != nil {
+		return false, err
+	}
+	return data.LT(other)
+}
+
+func (v *blobValue) LTE(other types.Value) (bool, error) {
+	data, err := v.Load()
+	if err != nil {
+		return false, err
+	}
+	return data.LTE(other)
+}
+
+func (v *blobValue) Between(a, b types.Value) (bool, error) {
+	data, err := v.Load()
+	if err != nil {
+		return false, err
+	}
+	return data.Between(a, b)
+}
+
+// iterateBlobRefs calls fn for every blob referenced by the encoded row.
+func iterateBlobRefs(ccs *ColumnConstraints, enc []byte, fn func(cc *ColumnConstraint, ref blobRef) error) error {
+	b, err := rowValues(ccs, enc)
+	if err != nil {
+		return err
+	}
+
+	for _, cc := range ccs.Ordered {
+		if len(b) == 0 {
+			return nil
+		}
+
+		if b[0] == encoding.BlobRefValue {
+			id, size, _ := encoding.DecodeBlobRef(b)
+			err := fn(cc, blobRef{id: id, size: size})
+			if err != nil {
+				return err
+			}
+		}
+
+		b = b[encoding.Skip(b):]
+	}
+
+	return nil
+}
+
+// storesBlobs returns whether the table has BLOB columns
+// whose values may be stored outside of the rows.
+func (t *Table) storesBlobs() bool {
+	if t.Tx == nil || t.Tx.db == nil {
+		return false
+	}
+
+	for _, cc := range t.Info.ColumnConstraints.Ordered {
+		if cc.Type == types.TypeBlob {
+			return true
+		}
+	}
+
+	return false
+}
+
+// moveBlobs returns the encoded row with its large BLOB values stored
+// outside of the row. The blobs referenced by the encoded row are copied,
+// since they belong to another row: every blob referenced by the returned row
+// is new, except the one of the given column, if any.
+func (t *Table) moveBlobs(enc []byte, except *ColumnConstraint) ([]byte, error) {
+	threshold := t.Tx.db.overflowThreshold
+
+	values, err := rowValues(&t.Info.ColumnConstraints, enc)
+	if err != nil {
+		return nil, err
+	}
+
+	// most rows don't contain large blobs
+	var found bool
+	b := values
+	for _, cc := range t.Info.ColumnConstraints.Ordered {
+		n := encoding.Skip(b)
+		if cc == except {
+			b = b[n:]
+			continue
+		}
+		if b[0] == encoding.BlobRefValue || (cc.Type == types.TypeBlob && b[0] == encoding.BlobValue && n > threshold) {
+			found = true
+			break
+		}
+		b = b[n:]
+	}
+	if !found {
+		return enc, nil
+	}
+
+	dst := encodeRowHeader(make([]byte, 0, len(enc)))
+	b = values
+	for _, cc := range t.Info.ColumnConstraints.Ordered {
+		n := encoding.Skip(b)
+
+		var r io.Reader
+		switch {
+		case cc == except:
+		case b[0] == encoding.BlobRefValue:
+			id, size, _ := encoding.DecodeBlobRef(b)
+			r = t.Tx.newBlobReader(blobRef{id: id, size: size})
+		case cc.Type == types.TypeBlob && b[0] == encoding.BlobValue && n > threshold:
+			data, _ := encoding.DecodeBlob(b)
+			r = bytes.NewReader(data)
+		}
+
+		if r == nil {
+			dst = append(dst, b[:n]...)
+		} else {
+			ref, err := t.Tx.writeBlob(r)
+			if err != nil {
+				return nil, err
+			}
+			dst = encoding.EncodeBlobRef(dst, ref.id, ref.size)
+		}
+
+		b = b[n:]
+	}
+
+	return dst, nil
+}
+
+// releaseBlobs marks the blobs referenced by the encoded row as unused.
+// They are deleted when the transaction is committed, which lets the rows
+// deleted or replaced by a statement be read until the end of the statement.
+func (t *Table) releaseBlobs(enc []byte) {
+	_ = iterateBlobRefs(&t.Info.ColumnConstraints, enc, func(_ *ColumnConstraint, ref blobRef) error {
+		t.Tx.unusedBlobs = append(t.Tx.unusedBlobs, ref)
+		return nil
+	})
+}
+
+// releaseAllBlobs marks the blobs referenced by the rows of the table
+// in the given range as unused. If the range is nil, all the rows are considered.
+func (t *Table) releaseAllBlobs(rng *tree.Range) error {
+	if !t.storesBlobs() {
+		return nil
+	}
+
+	return t.Tree.IterateOnRange(rng, false, func(_ *tree.Key, enc []byte) error {
+		t.releaseBlobs(enc)
+		return nil
+	})
+}
+
+// deleteUnusedBlobs deletes the blobs released by the transaction.
+func (tx *Transaction) deleteUnusedBlobs() error {
+	for _, ref := range tx.unusedBlobs {
+		err := tx.deleteBlob(ref)
+		if err != nil {
+			return err
+		}
+	}
+
+	tx.unusedBlobs = nil
+	return nil
+}
+
+// OpenBlob returns a reader streaming the value of a BLOB column of the row
+// with the given key. Blobs stored outside of the row are read one chunk
+// at a time. The reader must not be used after the end of the transaction.
+func (t *Table) OpenBlob(key *tree.Key, column string) (io.Reader, error) {
+	cc, b, err := t.blobColumn(key, column)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < cc.Position; i++ {
+		b = b[encoding.Skip(b):]
+	}
+
+	switch b[0] {
+	case encoding.NullValue:
+		return nil, errors.Errorf("column %q is NULL", column)
+	case encoding.BlobRefValue:
+		id, size, _ := encoding.DecodeBlobRef(b)
+		return t.Tx.newBlobReader(blobRef{id: id, size: size}), nil
+	}
+
+	data, _ := encoding.DecodeBlob(b)
+	return bytes.NewReader(data), nil
+}
+
+// WriteBlob replaces the value of a BLOB column of the row with the given key
+// by the content of r, without loading it entirely in memory if it is larger
+// than the overflow threshold. The column must not be indexed.
+func (t *Table) WriteBlob(key *tree.Key, column string, r io.Reader) error {
+	if t.Info.ReadOnly {
+		return errors.New("cannot write to read-only table")
+	}
+
+	cc, old, err := t.blobColumn(key, column)
+	if err != nil {
+		return err
+	}
+
+	// the indexes are not updated
+	for _, name := range t.Tx.Catalog.ListIndexes(t.Info.TableName) {
+		info, err := t.Tx.Catalog.GetIndexInfo(name)
+		if err != nil {
+			return err
+		}
+		if slices.Contains(info.Columns, column) {
+			return errors.Errorf("cannot stream to column %q used by index %q", column, name)
+		}
+	}
+
+	// small values are stored in the row
+	threshold := t.Tx.db.overflowThreshold
+	buf := make([]byte, threshold+1)
+	l, err := io.ReadFull(r, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return err
+	}
+
+	var value []byte
+	if l <= threshold {
+		value = encoding.EncodeBlob(nil, buf[:l])
+	} else {
+		ref, err := t.Tx.writeBlob(io.MultiReader(bytes.NewReader(buf[:l]), r))
+		if err != nil {
+			return err
+		}
+		value = encoding.EncodeBlobRef(nil, ref.id, ref.size)
+	}
+
+	// the other blobs of the row are copied
+	enc := encodeRowHeader(make([]byte, 0, len(old)+len(value)+2))
+	b := old
+	for _, c := range t.Info.ColumnConstraints.Ordered {
+		n := encoding.Skip(b)
+		if c == cc {
+			enc = append(enc, value...)
+		} else {
+			enc = append(enc, b[:n]...)
+		}
+		b = b[n:]
+	}
+	enc, err = t.moveBlobs(enc, cc)
+	if err != nil {
+		return err
+	}
+
+	nr := newEncodedRowWithTx(t.Tx, t.Info, enc)
+	err = t.Info.TableConstraints.ValidateRow(t.Tx, nr)
+	if err != nil {
+		return err
+	}
+
+	_, err = t.put(key, nr, enc)
+	return err
+}
+
+// blobColumn returns the constraint of a BLOB column
+// and the values of the row with the given key.
+func (t *Table) blobColumn(key *tree.Key, column string) (*ColumnConstraint, []byte, error) {
+	cc, ok := t.Info.ColumnConstraints.ByColumn[column]
+	if !ok {
+		return nil, nil, errors.Wrapf(types.ErrColumnNotFound, "%s not found", column)
+	}
+	if cc.Type != types.TypeBlob {
+		return nil, nil, errors.Errorf("column %q is not a BLOB", column)
+	}
+
+	enc, err := t.Tree.Get(key)
+	if errors.Is(err, engine.ErrKeyNotFound) {
+		return nil, nil, errs.NewNotFoundError(key.String())
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	values, err := rowValues(&t.Info.ColumnConstraints, enc)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return cc, values, nil
+}
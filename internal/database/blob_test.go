@@ -0,0 +1,219 @@
+package database_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"io"
+	"testing"
+
+	"github.com/chaisql/chai"
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOverflowBlobs(t *testing.T) {
+	db, err := chai.OpenWith(t.TempDir(), &chai.Options{OverflowThreshold: 1024})
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE test (a INT PRIMARY KEY, b BLOB, c INT);
+		CREATE TABLE copy (a INT PRIMARY KEY, b BLOB, c INT);
+	`)
+	require.NoError(t, err)
+
+	chunks := func(t *testing.T) int {
+		t.Helper()
+
+		tx, err := db.DB.Begin(false)
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		var n int
+		err = tree.New(tx.Session, database.OverflowNamespace, nil).IterateOnRange(nil, false, func(*tree.Key, []byte) error {
+			n++
+			return nil
+		})
+		require.NoError(t, err)
+		return n
+	}
+
+	get := func(t *testing.T, a int) []byte {
+		t.Helper()
+
+		r, err := db.QueryRow(`SELECT b FROM test WHERE a = ?`, a)
+		require.NoError(t, err)
+		var b []byte
+		require.NoError(t, r.Scan(&b))
+		return b
+	}
+
+	// 3 chunks of 64KiB
+	large := make([]byte, 150_000)
+	_, err = rand.Read(large)
+	require.NoError(t, err)
+
+	require.NoError(t, db.Exec(`INSERT INTO test (a, b, c) VALUES (1, ?, 1), (2, ?, 2)`, large, []byte("small")))
+	require.Equal(t, 3, chunks(t))
+	require.Equal(t, large, get(t, 1))
+	require.Equal(t, []byte("small"), get(t, 2))
+
+	// updating the row copies the blob and deletes the old one
+	require.NoError(t, db.Exec(`UPDATE test SET c = 10`))
+	require.Equal(t, 3, chunks(t))
+	require.Equal(t, large, get(t, 1))
+
+	// rows read from the table keep their values
+	require.NoError(t, db.Exec(`INSERT INTO copy (a, b, c) SELECT a, b, c FROM test`))
+	require.Equal(t, 6, chunks(t))
+	r, err := db.QueryRow(`SELECT b FROM copy WHERE a = 1`)
+	require.NoError(t, err)
+	var b []byte
+	require.NoError(t, r.Scan(&b))
+	require.Equal(t, large, b)
+
+	conn, err := db.Connect()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	t.Run("Streaming", func(t *testing.T) {
+		data := make([]byte, 1_000_000)
+		_, err := rand.Read(data)
+		require.NoError(t, err)
+
+		tx, err := conn.Begin(true)
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		require.NoError(t, tx.WriteBlob("test", "b", bytes.NewReader(data), 2))
+		r, err := tx.OpenBlob("test", "b", 2)
+		require.NoError(t, err)
+		got, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.Equal(t, data, got)
+		require.NoError(t, tx.Commit())
+
+		require.Equal(t, data, get(t, 2))
+		require.Equal(t, 6+16, chunks(t))
+
+		// small values are stored in the row
+		err = conn.Update(func(tx *chai.Tx) error {
+			return tx.WriteBlob("test", "b", bytes.NewReader([]byte("small")), 2)
+		})
+		require.NoError(t, err)
+		require.Equal(t, []byte("small"), get(t, 2))
+		require.Equal(t, 6, chunks(t))
+
+		err = conn.View(func(tx *chai.Tx) error {
+			r, err := tx.OpenBlob("test", "b", 2)
+			require.NoError(t, err)
+			got, err := io.ReadAll(r)
+			require.NoError(t, err)
+			require.Equal(t, []byte("small"), got)
+			return nil
+		})
+		require.NoError(t, err)
+
+		// writing to a missing row or to a column that is not a blob
+		err = conn.Update(func(tx *chai.Tx) error {
+			return tx.WriteBlob("test", "b", bytes.NewReader(data), 100)
+		})
+		require.True(t, chai.IsNotFoundError(err))
+		err = conn.Update(func(tx *chai.Tx) error {
+			return tx.WriteBlob("test", "c", bytes.NewReader(data), 1)
+		})
+		require.Error(t, err)
+	})
+
+	t.Run("ScanReader", func(t *testing.T) {
+		res, err := conn.Query(`SELECT a, b FROM test ORDER BY a`)
+		require.NoError(t, err)
+		defer res.Close()
+
+		var got [][]byte
+		err = res.Iterate(func(row *chai.Row) error {
+			var a int
+			var r io.Reader
+			err := row.Scan(&a, &r)
+			if err != nil {
+				return err
+			}
+
+			// large values are streamed from the overflow namespace
+			_, inMemory := r.(*bytes.Reader)
+			require.Equal(t, a == 2, inMemory)
+
+			b, err := io.ReadAll(r)
+			if err != nil {
+				return err
+			}
+			got = append(got, b)
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, [][]byte{large, []byte("small")}, got)
+
+		// rows returned by QueryRow can be read after the end of the transaction
+		row, err := db.QueryRow(`SELECT b FROM test WHERE a = 1`)
+		require.NoError(t, err)
+		var r io.Reader
+		require.NoError(t, row.ScanColumn("b", &r))
+		b, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.Equal(t, large, b)
+
+		// expressions returning blobs can be read as well
+		row, err = db.QueryRow(`SELECT x'DEADBEEF'`)
+		require.NoError(t, err)
+		require.NoError(t, row.Scan(&r))
+		b, err = io.ReadAll(r)
+		require.NoError(t, err)
+		require.Equal(t, []byte{0xde, 0xad, 0xbe, 0xef}, b)
+	})
+
+	t.Run("Rollback", func(t *testing.T) {
+		tx, err := conn.Begin(true)
+		require.NoError(t, err)
+		require.NoError(t, tx.Exec(`DELETE FROM test WHERE a = 1`))
+		require.NoError(t, tx.Rollback())
+
+		require.Equal(t, 6, chunks(t))
+		require.Equal(t, large, get(t, 1))
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		// rebuilding the table keeps the values
+		require.NoError(t, db.Exec(`ALTER TABLE test ADD COLUMN d INT DEFAULT 0`))
+		require.Equal(t, 6, chunks(t))
+		require.Equal(t, large, get(t, 1))
+
+		require.NoError(t, db.Exec(`DELETE FROM test WHERE a = 1`))
+		require.Equal(t, 3, chunks(t))
+
+		require.NoError(t, db.Exec(`DROP TABLE copy`))
+		require.Equal(t, 0, chunks(t))
+	})
+
+	t.Run("Rebuild", func(t *testing.T) {
+		// the rows are deleted before being inserted again
+		zeros := make([]byte, 100_000)
+		require.NoError(t, db.Exec(`CREATE TABLE nopk (b BLOB); INSERT INTO nopk (b) VALUES (?)`, zeros))
+		require.NoError(t, db.Exec(`ALTER TABLE nopk ADD COLUMN id INT PRIMARY KEY DEFAULT 1`))
+		require.Equal(t, 2, chunks(t))
+
+		r, err := db.QueryRow(`SELECT b FROM nopk WHERE id = 1`)
+		require.NoError(t, err)
+		var b []byte
+		require.NoError(t, r.Scan(&b))
+		require.Equal(t, zeros, b)
+
+		require.NoError(t, db.Exec(`DELETE FROM nopk`))
+		require.Equal(t, 0, chunks(t))
+	})
+
+	report, err := db.Check(context.Background())
+	require.NoError(t, err)
+	require.True(t, report.OK(), report.Problems)
+}
@@ -3,11 +3,12 @@ package database
 import (
 	"fmt"
 	"math"
+	"slices"
 	"sort"
 	"strings"
 
+	"github.com/chaisql/chai/internal/engine"
 	errs "github.com/chaisql/chai/internal/errors"
-	"github.com/chaisql/chai/internal/pkg/atomic"
 	"github.com/chaisql/chai/internal/row"
 	"github.com/chaisql/chai/internal/tree"
 	"github.com/chaisql/chai/internal/types"
@@ -34,11 +35,26 @@ const (
 
 // System namespaces
 const (
+	// Namespace of the metadata of the database, such as the version
+	// of the key format, see keyformat.go
+	MetadataNamespace        tree.Namespace = 0
 	CatalogTableNamespace    tree.Namespace = 1
 	SequenceTableNamespace   tree.Namespace = 2
 	RollbackSegmentNamespace tree.Namespace = 3
-	MinTransientNamespace    tree.Namespace = math.MaxInt64 - 1<<24
-	MaxTransientNamespace    tree.Namespace = math.MaxInt64
+	ChangesNamespace         tree.Namespace = 4
+	// Namespace of the namespace registry, see namespace.go
+	NamespaceRegistryNamespace tree.Namespace = 5
+	// Namespace of the change log, see changelog.go
+	ChangeLogNamespace tree.Namespace = 6
+	// Namespace of the large values stored outside of their row, see blob.go
+	OverflowNamespace tree.Namespace = 7
+	// Namespace of the state of the transactions committed to several
+	// storage locations, see kv.Options.Locations
+	PlacementNamespace tree.Namespace = 8
+	// Namespace of the dictionaries of the TEXT columns, see dictionary.go
+	DictionaryNamespace   tree.Namespace = 9
+	MinTransientNamespace tree.Namespace = math.MaxInt64 - 1<<24
+	MaxTransientNamespace tree.Namespace = math.MaxInt64
 )
 
 // Catalog manages all database objects such as tables, indexes and sequences.
@@ -48,14 +64,18 @@ type Catalog struct {
 	Cache        *catalogCache
 	CatalogTable *CatalogStore
 
-	TransientNamespaces *atomic.Counter
+	TransientNamespaces *TransientNamespaces
+
+	// virtual tables registered by the application
+	virtual *virtualTableSet
 }
 
 func NewCatalog() *Catalog {
 	return &Catalog{
 		Cache:               newCatalogCache(),
 		CatalogTable:        newCatalogStore(),
-		TransientNamespaces: atomic.NewCounter(int64(MinTransientNamespace), int64(MaxTransientNamespace), true),
+		TransientNamespaces: newTransientNamespaces(),
+		virtual:             &virtualTableSet{tables: make(map[string]*virtualTable)},
 	}
 }
 
@@ -64,11 +84,17 @@ func (c *Catalog) Clone() *Catalog {
 		Cache:               c.Cache.Clone(),
 		CatalogTable:        c.CatalogTable,
 		TransientNamespaces: c.TransientNamespaces,
+		virtual:             c.virtual,
 	}
 }
 
 func (c *Catalog) GetTable(tx *Transaction, tableName string) (*Table, error) {
 	o, err := c.Cache.Get(RelationTableType, tableName)
+	if errs.IsNotFoundError(err) {
+		if v, ok := c.virtualTable(tableName); ok {
+			return v.open(tx), nil
+		}
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -85,6 +111,11 @@ func (c *Catalog) GetTable(tx *Transaction, tableName string) (*Table, error) {
 // GetTableInfo returns the table info for the given table name.
 func (c *Catalog) GetTableInfo(tableName string) (*TableInfo, error) {
 	r, err := c.Cache.Get(RelationTableType, tableName)
+	if errs.IsNotFoundError(err) {
+		if v, ok := c.virtualTable(tableName); ok {
+			return v.info, nil
+		}
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -111,6 +142,12 @@ func (c *Catalog) GetIndexInfo(indexName string) (*IndexInfo, error) {
 	return r.(*IndexInfoRelation).Info, nil
 }
 
+// ListTables returns all table names sorted lexicographically,
+// including the internal ones but not the virtual tables.
+func (c *Catalog) ListTables() []string {
+	return c.Cache.ListObjects(RelationTableType)
+}
+
 // ListIndexes returns all indexes for a given table name. If tableName is empty
 // if returns a list of all indexes.
 // The returned list of indexes is sorted lexicographically.
@@ -144,15 +181,21 @@ func (c *Catalog) ListSequences() []string {
 	return c.Cache.ListObjects(RelationSequenceType)
 }
 
-// GetFreeTransientNamespace returns the next available transient namespace.
-// Transient namespaces start from math.MaxInt64 - (2 << 24) to math.MaxInt64 (around 16 M).
-// The transient namespaces counter is not persisted and resets when the database is restarted.
-// Once the counter reaches its maximum value, it will wrap around to the minimum value.
-// Technically, if a transient namespace is still in use by the time the counter wraps around
-// its data may be overwritten. However, transient trees are supposed to verify that the
-// namespace is not in use before writing to it.
-func (c *Catalog) GetFreeTransientNamespace() tree.Namespace {
-	return tree.Namespace(c.TransientNamespaces.Incr())
+// NewTransientTree creates a tree in a transient namespace that is not in use.
+// Transient namespaces start from math.MaxInt64 - (1 << 24) to math.MaxInt64 (around 16 M).
+// The returned function deletes the content of the tree and releases its namespace.
+func (c *Catalog) NewTransientTree(session engine.Session, order tree.SortOrder) (*tree.Tree, func() error, error) {
+	ns, err := c.TransientNamespaces.Acquire()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	t := tree.New(session, ns, order)
+	return t, func() error {
+		defer c.TransientNamespaces.Release(ns)
+
+		return t.Truncate()
+	}, nil
 }
 
 // A CatalogWriter is used to apply modifications to the catalog
@@ -215,19 +258,6 @@ func (c *CatalogWriter) ensureSequenceExists(tx *Transaction, seq *SequenceInfo)
 	return nil
 }
 
-func (c *CatalogWriter) generateStoreNamespace(tx *Transaction) (tree.Namespace, error) {
-	seq, err := c.Catalog.GetSequence(StoreSequence)
-	if err != nil {
-		return 0, err
-	}
-	v, err := seq.Next(tx)
-	if err != nil {
-		return 0, err
-	}
-
-	return tree.Namespace(v), nil
-}
-
 // CreateTable creates a table with the given name.
 // If it already exists, returns ErrTableAlreadyExists.
 func (c *CatalogWriter) CreateTable(tx *Transaction, tableName string, info *TableInfo) error {
@@ -249,7 +279,7 @@ func (c *CatalogWriter) CreateTable(tx *Transaction, tableName string, info *Tab
 	}
 
 	if info.StoreNamespace == 0 {
-		info.StoreNamespace, err = c.generateStoreNamespace(tx)
+		info.StoreNamespace, err = c.allocateNamespace(tx, info.Location)
 		if err != nil {
 			return err
 		}
@@ -275,6 +305,21 @@ func (c *CatalogWriter) DropTable(tx *Transaction, tableName string) error {
 		return errors.New("cannot write to read-only table")
 	}
 
+	// the namespace of the table is freed below, but its large values
+	// and its dictionaries are stored in system namespaces
+	tb, err := c.GetTable(tx, tableName)
+	if err != nil {
+		return err
+	}
+	err = tb.releaseAllBlobs(nil)
+	if err != nil {
+		return err
+	}
+	err = tx.deleteDictionaries(ti.StoreNamespace)
+	if err != nil {
+		return err
+	}
+
 	for _, idx := range c.Cache.GetTableIndexes(tableName) {
 		_, err = c.Cache.Delete(tx, RelationIndexType, idx.IndexName)
 		if err != nil {
@@ -297,7 +342,7 @@ func (c *CatalogWriter) DropTable(tx *Transaction, tableName string) error {
 		return err
 	}
 
-	return tree.New(tx.Session, ti.StoreNamespace, ti.PrimaryKeySortOrder()).Truncate()
+	return c.freeNamespace(tx, ti.StoreNamespace)
 }
 
 // CreateIndex creates an index with the given name.
@@ -309,15 +354,30 @@ func (c *CatalogWriter) CreateIndex(tx *Transaction, info *IndexInfo) (*IndexInf
 		return nil, err
 	}
 
+	// the rows of virtual tables are generated when they are read,
+	// an index would not follow them
+	if c.Catalog.IsVirtualTable(info.Owner.TableName) {
+		return nil, errors.Errorf("cannot create an index on virtual table %s", info.Owner.TableName)
+	}
+
 	// check if the indexed columns exist
-	for _, p := range info.Columns {
+	for i, p := range info.Columns {
+		if info.IsExpr(i) {
+			err = info.Exprs[i].Validate(ti)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
 		fc := ti.GetColumnConstraint(p)
 		if fc == nil {
 			return nil, errors.Errorf("field %q does not exist for table %q", p, ti.TableName)
 		}
 	}
 
-	info.StoreNamespace, err = c.generateStoreNamespace(tx)
+	// indexes are stored in the location of their table
+	info.StoreNamespace, err = c.allocateNamespace(tx, ti.Location)
 	if err != nil {
 		return nil, err
 	}
@@ -358,7 +418,7 @@ func (c *CatalogWriter) DropIndex(tx *Transaction, name string) error {
 }
 
 func (c *CatalogWriter) dropIndex(tx *Transaction, info *IndexInfo) error {
-	err := tree.New(tx.Session, info.StoreNamespace, info.KeySortOrder).Truncate()
+	err := c.freeNamespace(tx, info.StoreNamespace)
 	if err != nil {
 		return err
 	}
@@ -580,7 +640,18 @@ func (r *IndexInfoRelation) SetName(name string) {
 }
 
 func (r *IndexInfoRelation) GenerateBaseName() string {
-	return fmt.Sprintf("%s_%s_idx", r.Info.Owner.TableName, columnsToIndexName(r.Info.Columns))
+	columns := r.Info.Columns
+	if r.Info.Exprs != nil {
+		// expressions are not valid identifiers
+		columns = slices.Clone(columns)
+		for i := range columns {
+			if r.Info.IsExpr(i) {
+				columns[i] = "expr"
+			}
+		}
+	}
+
+	return fmt.Sprintf("%s_%s_idx", r.Info.Owner.TableName, columnsToIndexName(columns))
 }
 
 func (r *IndexInfoRelation) Clone() Relation {
@@ -471,13 +471,14 @@ func TestReadOnlyTables(t *testing.T) {
 		case 6:
 			testutil.RequireJSONEq(t, r, `{"name":"idx_foo_a", "namespace":12, "owner_table_name":"foo", "owner_table_columns":null, "rowid_sequence_name": null, "sql":"CREATE INDEX idx_foo_a ON foo (a, c)", "type":"index", "owner_table_name":"foo"}`)
 		default:
-			t.Fatalf("count should be 6, got %d", i)
+			t.Fatalf("count should be 7, got %d", i+1)
 		}
 
 		i++
 		return nil
 	})
 	require.NoError(t, err)
+	require.Equal(t, 7, i)
 }
 
 func TestCatalogCreateSequence(t *testing.T) {
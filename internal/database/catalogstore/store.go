@@ -12,11 +12,13 @@ import (
 )
 
 func LoadCatalog(tx *database.Transaction) error {
-	cw := tx.CatalogWriter()
-
-	err := cw.Init(tx)
-	if err != nil {
-		return err
+	// read-only transactions can only load a catalog
+	// that was already initialized.
+	if tx.Writable {
+		err := tx.CatalogWriter().Init(tx)
+		if err != nil {
+			return err
+		}
 	}
 
 	tables, indexes, sequences, err := loadCatalogStore(tx, tx.Catalog.CatalogTable)
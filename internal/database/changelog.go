@@ -0,0 +1,391 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"strings"
+	"sync"
+
+	"github.com/chaisql/chai/internal/row"
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// Change data capture records the rows modified by every committed
+// write transaction in the change log namespace, in commit order.
+//
+// Each transaction is assigned a sequence number when it is committed,
+// and each of its changes is stored under the key (seq, n), where n is the
+// position of the change within the transaction. The value of an entry
+// contains the table name, the kind of change, the primary key of the row
+// and its values before and after the change. Row values are stored with
+// their column names, so that they can be decoded even if the schema
+// of the table changed since then.
+//
+// Changes made to the internal tables of the database are not recorded.
+
+// A ChangeOp is the kind of modification made to a row.
+type ChangeOp int
+
+const (
+	ChangeInsert ChangeOp = iota + 1
+	ChangeUpdate
+	ChangeDelete
+)
+
+func (o ChangeOp) String() string {
+	switch o {
+	case ChangeInsert:
+		return "INSERT"
+	case ChangeUpdate:
+		return "UPDATE"
+	case ChangeDelete:
+		return "DELETE"
+	}
+
+	return "UNKNOWN"
+}
+
+// A Change is a committed modification of a row.
+type Change struct {
+	// Sequence number of the transaction that made the change.
+	// Every committed transaction gets a sequence number greater
+	// than the previous one.
+	Seq uint64
+	Op  ChangeOp
+	// Table of the row.
+	Table string
+	// Primary key of the row.
+	Key *tree.Key
+	// Values of the row before the change. Nil for inserts.
+	Old Row
+	// Values of the row after the change. Nil for deletes.
+	New Row
+}
+
+// changeLog keeps the sequence number of the last transaction
+// recorded in the change log and notifies the readers of new commits.
+type changeLog struct {
+	mu  sync.Mutex
+	seq uint64
+	// closed every time a transaction is recorded.
+	notify chan struct{}
+}
+
+// loadChangeLog returns the state of the change log stored in the session.
+func loadChangeLog(tx *Transaction) (*changeLog, error) {
+	l := changeLog{
+		notify: make(chan struct{}),
+	}
+
+	err := changeLogTree(tx).IterateOnRange(nil, true, func(k *tree.Key, _ []byte) error {
+		vs, err := k.Decode()
+		if err != nil {
+			return err
+		}
+		l.seq = uint64(types.AsInt64(vs[0]))
+		return errStop
+	})
+	if err != nil && !errors.Is(err, errStop) {
+		return nil, err
+	}
+
+	return &l, nil
+}
+
+// next returns the sequence number of the next transaction.
+// It must be called while holding the commit lock.
+func (l *changeLog) next() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.seq + 1
+}
+
+// commit records that the transaction with the given sequence
+// number was committed and wakes up the readers.
+func (l *changeLog) commit(seq uint64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.seq = seq
+	close(l.notify)
+	l.notify = make(chan struct{})
+}
+
+// wait returns a channel closed when the next transaction is recorded.
+func (l *changeLog) wait() <-chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.notify
+}
+
+func changeLogTree(tx *Transaction) *tree.Tree {
+	return tree.New(tx.Session, ChangeLogNamespace, nil)
+}
+
+func changeLogKey(seq uint64, n int) *tree.Key {
+	return tree.NewKey(types.NewBigintValue(int64(seq)), types.NewBigintValue(int64(n)))
+}
+
+// writeChanges stores the changes captured by the transaction
+// with the given sequence number.
+func (tx *Transaction) writeChanges(seq uint64) error {
+	t := changeLogTree(tx)
+	for i, c := range tx.changes {
+		err := t.Put(changeLogKey(seq, i), c)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// capturesChanges returns whether the changes made to the table are recorded.
+func (t *Table) capturesChanges() bool {
+	return t.Tx != nil && t.Tx.db != nil && t.Tx.db.changeLog != nil &&
+		!strings.HasPrefix(t.Info.TableName, InternalPrefix)
+}
+
+// captureChange records a change made to a row of the table by the transaction.
+// old and new are the encoded rows before and after the change, or nil.
+func (t *Table) captureChange(op ChangeOp, key *tree.Key, old, new []byte) error {
+	kv, err := key.Decode()
+	if err != nil {
+		return err
+	}
+	encKey, err := types.EncodeValuesAsKey(nil, kv...)
+	if err != nil {
+		return err
+	}
+
+	values := []types.Value{
+		types.NewTextValue(t.Info.TableName),
+		types.NewIntegerValue(int32(op)),
+		types.NewBlobValue(encKey),
+	}
+
+	for _, enc := range [][]byte{old, new} {
+		if enc == nil {
+			values = append(values, types.NewNullValue())
+			continue
+		}
+
+		rv := row.Flatten(newEncodedRowWithTx(t.Tx, t.Info, enc))
+		// CITEXT values are folded by the key encoding,
+		// record them as TEXT to keep their case
+		for i, v := range rv {
+			if v.Type() == types.TypeCitext {
+				rv[i] = types.NewTextValue(types.AsString(v))
+			}
+		}
+
+		b, err := types.EncodeValuesAsKey(nil, rv...)
+		if err != nil {
+			return err
+		}
+		values = append(values, types.NewBlobValue(b))
+	}
+
+	v, err := types.EncodeValuesAsKey(nil, values...)
+	if err != nil {
+		return err
+	}
+
+	t.Tx.changes = append(t.Tx.changes, v)
+	return nil
+}
+
+func decodeChange(seq uint64, v []byte) (*Change, error) {
+	values := types.DecodeValues(v)
+	if len(values) != 5 {
+		return nil, errors.New("invalid change log entry")
+	}
+
+	c := Change{
+		Seq:   seq,
+		Table: types.AsString(values[0]),
+		Op:    ChangeOp(types.AsInt64(values[1])),
+		Key:   tree.NewKey(types.DecodeValues(types.AsByteSlice(values[2]))...),
+	}
+
+	for i, r := range []*Row{&c.Old, &c.New} {
+		enc := values[3+i]
+		if enc.Type() == types.TypeNull {
+			continue
+		}
+
+		var br BasicRow
+		br.ResetWith(c.Table, c.Key, row.Unflatten(types.DecodeValues(types.AsByteSlice(enc))))
+		*r = &br
+	}
+
+	return &c, nil
+}
+
+// changesBatchSize is the maximum number of changes read at once.
+const changesBatchSize = 1000
+
+// A ChangeStream returns the changes recorded in the change log, in commit order.
+// It is not thread safe.
+type ChangeStream struct {
+	db  *Database
+	ctx context.Context
+
+	// key of the next change to read.
+	seq uint64
+	n   int
+
+	buf    []*Change
+	closed bool
+}
+
+// Changes returns a stream of the changes committed by the transactions
+// whose sequence number is greater than or equal to from.
+// The database must have been opened with change capture enabled.
+// The stream must be closed before the database is closed.
+func (db *Database) Changes(ctx context.Context, from uint64) (*ChangeStream, error) {
+	if db.changeLog == nil {
+		return nil, errors.New("change capture is disabled")
+	}
+
+	if db.closeContext.Err() != nil {
+		return nil, errors.New("database is closed")
+	}
+
+	db.connectionWg.Add(1)
+	return &ChangeStream{
+		db:  db,
+		ctx: ctx,
+		seq: from,
+	}, nil
+}
+
+// LastChangeSeq returns the sequence number of the last
+// transaction recorded in the change log.
+func (db *Database) LastChangeSeq() uint64 {
+	if db.changeLog == nil {
+		return 0
+	}
+
+	db.changeLog.mu.Lock()
+	defer db.changeLog.mu.Unlock()
+
+	return db.changeLog.seq
+}
+
+// Next returns the next change. If there is no change left, it waits
+// until a new transaction is committed, the context is canceled
+// or the database is closed.
+func (s *ChangeStream) Next() (*Change, error) {
+	if s.closed {
+		return nil, errors.New("change stream is closed")
+	}
+
+	for len(s.buf) == 0 {
+		// get the notification channel before reading,
+		// to avoid missing a commit happening meanwhile.
+		wait := s.db.changeLog.wait()
+
+		err := s.read()
+		if err != nil {
+			return nil, err
+		}
+		if len(s.buf) > 0 {
+			break
+		}
+
+		select {
+		case <-wait:
+		case <-s.ctx.Done():
+			return nil, s.ctx.Err()
+		case <-s.db.closeContext.Done():
+			return nil, errors.New("database is closed")
+		}
+	}
+
+	c := s.buf[0]
+	s.buf = s.buf[1:]
+	return c, nil
+}
+
+// read loads the next batch of changes.
+func (s *ChangeStream) read() error {
+	tx, err := s.db.beginTx(&TxOptions{ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	rng := tree.Range{
+		Min: changeLogKey(s.seq, s.n),
+		Max: tree.NewKey(types.NewBigintValue(math.MaxInt64)),
+	}
+	err = changeLogTree(tx).IterateOnRange(&rng, false, func(k *tree.Key, v []byte) error {
+		vs, err := k.Decode()
+		if err != nil {
+			return err
+		}
+
+		c, err := decodeChange(uint64(types.AsInt64(vs[0])), bytes.Clone(v))
+		if err != nil {
+			return err
+		}
+		s.buf = append(s.buf, c)
+		s.seq, s.n = c.Seq, int(types.AsInt64(vs[1]))+1
+
+		if len(s.buf) >= changesBatchSize {
+			return errStop
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errStop) {
+		return err
+	}
+
+	return nil
+}
+
+// Close the stream.
+func (s *ChangeStream) Close() error {
+	if s.closed {
+		return nil
+	}
+
+	s.closed = true
+	s.db.connectionWg.Done()
+	return nil
+}
+
+// PurgeChanges removes from the change log the changes made by
+// the transactions whose sequence number is lower than before.
+// The changes of the last transaction are always kept, since the
+// sequence numbers are resumed from them when the database is opened.
+func (db *Database) PurgeChanges(before uint64) error {
+	if db.changeLog == nil {
+		return errors.New("change capture is disabled")
+	}
+
+	before = min(before, db.LastChangeSeq())
+
+	tx, err := db.Begin(true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	rng := tree.Range{
+		Max:       tree.NewKey(types.NewBigintValue(int64(before))),
+		Exclusive: true,
+	}
+	err = changeLogTree(tx).DeleteRange(&rng)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
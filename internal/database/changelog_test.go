@@ -0,0 +1,193 @@
+package database_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/chaisql/chai"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChanges(t *testing.T) {
+	type change struct {
+		Seq      uint64
+		Op       chai.ChangeOp
+		Table    string
+		Key      []any
+		Old, New string
+	}
+
+	toJSON := func(t *testing.T, r *chai.Row) string {
+		if r == nil {
+			return ""
+		}
+		b, err := r.MarshalJSON()
+		require.NoError(t, err)
+		return string(b)
+	}
+
+	next := func(t *testing.T, s *chai.ChangeStream) change {
+		t.Helper()
+
+		c, err := s.Next()
+		require.NoError(t, err)
+		return change{c.Seq, c.Op, c.Table, c.Key, toJSON(t, c.Old), toJSON(t, c.New)}
+	}
+
+	dir := t.TempDir()
+
+	db, err := chai.OpenWith(dir, &chai.Options{CaptureChanges: true})
+	require.NoError(t, err)
+
+	err = db.Exec(`
+		CREATE TABLE test (a INT PRIMARY KEY, b TEXT);
+		INSERT INTO test (a, b) VALUES (1, 'a'), (2, 'b');
+		UPDATE test SET b = 'c' WHERE a = 2;
+		DELETE FROM test WHERE a = 1;
+		CREATE TABLE other (a INT PRIMARY KEY, b INT);
+		INSERT INTO other (a, b) VALUES (1, 1), (2, 2);
+		DELETE FROM other WHERE a >= 1;
+	`)
+	require.NoError(t, err)
+
+	s, err := db.Changes(context.Background(), 0)
+	require.NoError(t, err)
+
+	require.Equal(t, change{1, chai.ChangeInsert, "test", []any{int32(1)}, "", `{"a": 1, "b": "a"}`}, next(t, s))
+	require.Equal(t, change{1, chai.ChangeInsert, "test", []any{int32(2)}, "", `{"a": 2, "b": "b"}`}, next(t, s))
+	require.Equal(t, change{2, chai.ChangeUpdate, "test", []any{int32(2)}, `{"a": 2, "b": "b"}`, `{"a": 2, "b": "c"}`}, next(t, s))
+	require.Equal(t, change{3, chai.ChangeDelete, "test", []any{int32(1)}, `{"a": 1, "b": "a"}`, ""}, next(t, s))
+	require.Equal(t, change{4, chai.ChangeInsert, "other", []any{int32(1)}, "", `{"a": 1, "b": 1}`}, next(t, s))
+	require.Equal(t, change{4, chai.ChangeInsert, "other", []any{int32(2)}, "", `{"a": 2, "b": 2}`}, next(t, s))
+	// range deletion
+	require.Equal(t, change{5, chai.ChangeDelete, "other", []any{int32(1)}, `{"a": 1, "b": 1}`, ""}, next(t, s))
+	require.Equal(t, change{5, chai.ChangeDelete, "other", []any{int32(2)}, `{"a": 2, "b": 2}`, ""}, next(t, s))
+	require.EqualValues(t, 5, db.LastChangeSeq())
+
+	// Next waits for the next commit
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		_ = db.Exec(`INSERT INTO test (a, b) VALUES (3, 'd')`)
+	}()
+	require.Equal(t, change{6, chai.ChangeInsert, "test", []any{int32(3)}, "", `{"a": 3, "b": "d"}`}, next(t, s))
+	require.NoError(t, s.Close())
+
+	// transactions that are rolled back are not recorded
+	conn, err := db.Connect()
+	require.NoError(t, err)
+	tx, err := conn.Begin(true)
+	require.NoError(t, err)
+	require.NoError(t, tx.Exec(`INSERT INTO test (a, b) VALUES (4, 'e')`))
+	require.NoError(t, tx.Rollback())
+	require.NoError(t, conn.Close())
+	require.EqualValues(t, 6, db.LastChangeSeq())
+
+	// canceled context
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	s, err = db.Changes(ctx, 7)
+	require.NoError(t, err)
+	_, err = s.Next()
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.NoError(t, s.Close())
+
+	// purge, the last transaction is always kept
+	require.NoError(t, db.PurgeChanges(100))
+	s, err = db.Changes(context.Background(), 0)
+	require.NoError(t, err)
+	require.Equal(t, uint64(6), next(t, s).Seq)
+	require.NoError(t, s.Close())
+	require.NoError(t, db.Close())
+
+	// sequence numbers are resumed when the database is reopened
+	db, err = chai.OpenWith(dir, &chai.Options{CaptureChanges: true})
+	require.NoError(t, err)
+	defer db.Close()
+	require.EqualValues(t, 6, db.LastChangeSeq())
+
+	require.NoError(t, db.Exec(`DELETE FROM test WHERE a = 3`))
+	s, err = db.Changes(context.Background(), 7)
+	require.NoError(t, err)
+	defer s.Close()
+	require.Equal(t, change{7, chai.ChangeDelete, "test", []any{int32(3)}, `{"a": 3, "b": "d"}`, ""}, next(t, s))
+}
+
+func TestChangesDisabled(t *testing.T) {
+	db, err := chai.Open(filepath.Join(t.TempDir(), "db"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Changes(context.Background(), 0)
+	require.Error(t, err)
+}
+
+func TestWatch(t *testing.T) {
+	db, err := chai.OpenWith(t.TempDir(), &chai.Options{CaptureChanges: true})
+	require.NoError(t, err)
+
+	err = db.Exec(`
+		CREATE TABLE test (a INT PRIMARY KEY, b TEXT);
+		CREATE TABLE other (a INT PRIMARY KEY);
+		INSERT INTO test (a, b) VALUES (1, 'a');
+	`)
+	require.NoError(t, err)
+
+	_, err = db.Watch(context.Background(), "unknown", nil)
+	require.Error(t, err)
+
+	receive := func(t *testing.T, ch <-chan *chai.Change) *chai.Change {
+		t.Helper()
+
+		select {
+		case c := <-ch:
+			return c
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout")
+			return nil
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	all, err := db.Watch(ctx, "test", nil)
+	require.NoError(t, err)
+	deletes, err := db.Watch(context.Background(), "", &chai.WatchOptions{Ops: []chai.ChangeOp{chai.ChangeDelete}})
+	require.NoError(t, err)
+	// the changes committed before the call are sent from the given transaction
+	since, err := db.Watch(context.Background(), "test", &chai.WatchOptions{From: 1})
+	require.NoError(t, err)
+
+	err = db.Exec(`
+		INSERT INTO other (a) VALUES (1);
+		UPDATE test SET b = 'b' WHERE a = 1;
+		DELETE FROM other WHERE a = 1;
+	`)
+	require.NoError(t, err)
+
+	c := receive(t, all)
+	require.Equal(t, chai.ChangeUpdate, c.Op)
+	require.Equal(t, "test", c.Table)
+	require.Equal(t, []any{int32(1)}, c.Key)
+
+	c = receive(t, deletes)
+	require.Equal(t, chai.ChangeDelete, c.Op)
+	require.Equal(t, "other", c.Table)
+
+	c = receive(t, since)
+	require.Equal(t, chai.ChangeInsert, c.Op)
+	c = receive(t, since)
+	require.Equal(t, chai.ChangeUpdate, c.Op)
+
+	// the channel is closed when the context is canceled
+	cancel()
+	for range all {
+	}
+
+	// and when the database is closed
+	require.NoError(t, db.Close())
+	for range deletes {
+	}
+	for range since {
+	}
+}
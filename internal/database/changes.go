@@ -0,0 +1,240 @@
+package database
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/chaisql/chai/internal/engine"
+	"github.com/cockroachdb/errors"
+)
+
+// Change tracking records, for every key modified by a write transaction,
+// the sequence number of the last transaction that modified it.
+// Every committed transaction increments the sequence number by one.
+//
+// The changes namespace contains:
+//   - the state of the tracking, stored under the namespace prefix: a random epoch
+//     generated when the tracking starts and the sequence number of the last commit.
+//   - one entry per modified key, whose value is the sequence number
+//     of the last transaction that modified it.
+//
+// The epoch changes every time the tracking is restarted, to ensure changes
+// made while the tracking was disabled are never missed.
+
+// Checkpoint identifies the state of the database at the time of a backup.
+// Incremental backups contain the changes made since a checkpoint.
+type Checkpoint struct {
+	Epoch uint64
+	Seq   uint64
+}
+
+// IsZero returns whether the checkpoint was created while
+// change tracking was disabled.
+func (c Checkpoint) IsZero() bool {
+	return c.Epoch == 0
+}
+
+var changesPrefix = encoding.EncodeUint(nil, uint64(ChangesNamespace))
+
+func changesKey(k []byte) []byte {
+	return append(bytes.Clone(changesPrefix), k...)
+}
+
+// readCheckpoint returns the checkpoint of the last transaction
+// committed before the session was created.
+func readCheckpoint(s engine.Session) (Checkpoint, error) {
+	v, err := s.Get(changesPrefix)
+	if errors.Is(err, engine.ErrKeyNotFound) {
+		return Checkpoint{}, nil
+	}
+	if err != nil {
+		return Checkpoint{}, err
+	}
+	if len(v) != 16 {
+		return Checkpoint{}, errors.New("invalid change tracking state")
+	}
+
+	return Checkpoint{
+		Epoch: binary.BigEndian.Uint64(v),
+		Seq:   binary.BigEndian.Uint64(v[8:]),
+	}, nil
+}
+
+func writeCheckpoint(s engine.Session, c Checkpoint) error {
+	var v [16]byte
+	binary.BigEndian.PutUint64(v[:], c.Epoch)
+	binary.BigEndian.PutUint64(v[8:], c.Seq)
+
+	return s.Put(changesPrefix, v[:])
+}
+
+// newCheckpoint returns the first checkpoint of a new epoch.
+func newCheckpoint() (Checkpoint, error) {
+	var c Checkpoint
+	var b [8]byte
+
+	for c.Epoch == 0 {
+		_, err := rand.Read(b[:])
+		if err != nil {
+			return c, err
+		}
+		c.Epoch = binary.BigEndian.Uint64(b[:])
+	}
+
+	return c, nil
+}
+
+// initChanges starts tracking changes if it was not already started.
+// If track is false, every change tracked so far is removed.
+func initChanges(s engine.Session, track bool) error {
+	c, err := readCheckpoint(s)
+	if err != nil {
+		return err
+	}
+
+	if !track {
+		if c.IsZero() {
+			return nil
+		}
+		return resetChanges(s)
+	}
+
+	if !c.IsZero() {
+		return nil
+	}
+
+	c, err = newCheckpoint()
+	if err != nil {
+		return err
+	}
+	return writeCheckpoint(s, c)
+}
+
+// resetChanges removes every change tracked so far.
+func resetChanges(s engine.Session) error {
+	return s.DeleteRange(changesPrefix, encoding.EncodeUint(nil, uint64(ChangesNamespace)+1))
+}
+
+// trackingSession records the keys modified by a write transaction
+// and stores them in the changes namespace when the transaction is committed.
+type trackingSession struct {
+	engine.Session
+
+	keys map[string]struct{}
+}
+
+func newTrackingSession(s engine.Session) *trackingSession {
+	return &trackingSession{
+		Session: s,
+		keys:    make(map[string]struct{}),
+	}
+}
+
+func (s *trackingSession) track(k []byte) {
+	ns, _ := encoding.DecodeInt(k)
+	if ns == int64(ChangesNamespace) {
+		return
+	}
+
+	s.keys[string(k)] = struct{}{}
+}
+
+func (s *trackingSession) Insert(k, v []byte) error {
+	err := s.Session.Insert(k, v)
+	if err != nil {
+		return err
+	}
+
+	s.track(k)
+	return nil
+}
+
+func (s *trackingSession) Put(k, v []byte) error {
+	err := s.Session.Put(k, v)
+	if err != nil {
+		return err
+	}
+
+	s.track(k)
+	return nil
+}
+
+func (s *trackingSession) Merge(k []byte, d engine.Delta) error {
+	err := engine.Merge(s.Session, k, d)
+	if err != nil {
+		return err
+	}
+
+	s.track(k)
+	return nil
+}
+
+func (s *trackingSession) Delete(k []byte) error {
+	err := s.Session.Delete(k)
+	if err != nil {
+		return err
+	}
+
+	s.track(k)
+	return nil
+}
+
+func (s *trackingSession) DeleteRange(start, end []byte) error {
+	it, err := s.Session.Iterator(&engine.IterOptions{
+		LowerBound: start,
+		UpperBound: end,
+	})
+	if err != nil {
+		return err
+	}
+
+	for it.First(); it.Valid(); it.Next() {
+		s.track(bytes.Clone(it.Key()))
+	}
+	err = it.Error()
+	if cerr := it.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.Session.DeleteRange(start, end)
+}
+
+// Commit stores the modified keys with a new sequence number
+// and commits the underlying session.
+func (s *trackingSession) Commit() error {
+	if len(s.keys) == 0 {
+		return s.Session.Commit()
+	}
+
+	c, err := readCheckpoint(s.Session)
+	if err != nil {
+		return err
+	}
+	if c.IsZero() {
+		c, err = newCheckpoint()
+		if err != nil {
+			return err
+		}
+	}
+	c.Seq++
+
+	v := binary.BigEndian.AppendUint64(nil, c.Seq)
+	for k := range s.keys {
+		err = s.Session.Put(changesKey([]byte(k)), v)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = writeCheckpoint(s.Session, c)
+	if err != nil {
+		return err
+	}
+
+	return s.Session.Commit()
+}
@@ -0,0 +1,391 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/chaisql/chai/internal/engine"
+	errs "github.com/chaisql/chai/internal/errors"
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// number of rows or index entries checked between two checks of the context.
+const checkInterval = 1000
+
+// CheckReport contains the result of an integrity check.
+type CheckReport struct {
+	Tables  int
+	Indexes int
+	Rows    int
+	// Problems found during the check. Empty if the database is consistent.
+	Problems []CheckProblem
+}
+
+// OK returns true if no problem was found.
+func (r *CheckReport) OK() bool {
+	return len(r.Problems) == 0
+}
+
+func (r *CheckReport) addProblem(object, format string, args ...any) {
+	r.Problems = append(r.Problems, CheckProblem{
+		Object:  object,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// CheckProblem describes an inconsistency found by Check.
+type CheckProblem struct {
+	// Name of the table or index the problem was found in.
+	// Empty for problems related to the storage.
+	Object  string
+	Message string
+}
+
+func (p CheckProblem) String() string {
+	if p.Object == "" {
+		return p.Message
+	}
+
+	return p.Object + ": " + p.Message
+}
+
+// An integrityChecker is an engine able to verify the integrity of its storage.
+type integrityChecker interface {
+	CheckIntegrity() error
+}
+
+// Check verifies the integrity of the database:
+//   - the checksums of the storage, if supported by the engine
+//   - the objects of the catalog must reference existing objects
+//   - every index entry must point to an existing row, with the right values
+//   - every row must be referenced by each index of its table
+//   - every namespace must belong to a table, an index, the database itself or be reserved
+//
+// Problems are listed in the report, the returned error is only used
+// if the check could not be performed.
+func (db *Database) Check(ctx context.Context) (*CheckReport, error) {
+	var report CheckReport
+
+	if c, ok := db.Engine.(integrityChecker); ok {
+		err := c.CheckIntegrity()
+		if err != nil {
+			report.addProblem("", "storage is corrupted: %v", err)
+		}
+	}
+
+	tx, err := db.Begin(false)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	err = tx.check(ctx, &report)
+	if err != nil {
+		return nil, err
+	}
+
+	return &report, nil
+}
+
+func (tx *Transaction) check(ctx context.Context, report *CheckReport) error {
+	list, err := tx.Catalog.ListNamespaces(tx)
+	if err != nil {
+		return err
+	}
+	namespaces := make(map[tree.Namespace]struct{}, len(list))
+	for _, info := range list {
+		namespaces[info.Namespace] = struct{}{}
+	}
+
+	err = tx.checkCatalog(report)
+	if err != nil {
+		return err
+	}
+
+	for _, tableName := range tx.Catalog.Cache.ListObjects(RelationTableType) {
+		ti, err := tx.Catalog.GetTableInfo(tableName)
+		if err != nil {
+			return err
+		}
+
+		// the catalog tables are stored in the system namespaces
+		if ti.StoreNamespace == CatalogTableNamespace || ti.StoreNamespace == SequenceTableNamespace {
+			continue
+		}
+		report.Tables++
+
+		err = tx.checkTable(ctx, tableName, report)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, indexName := range tx.Catalog.Cache.ListObjects(RelationIndexType) {
+		report.Indexes++
+
+		err := tx.checkIndex(ctx, indexName, report)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.checkNamespaces(namespaces, report)
+}
+
+// checkCatalog ensures the sequences and indexes of the catalog belong to
+// existing tables, and the tables use existing sequences.
+func (tx *Transaction) checkCatalog(report *CheckReport) error {
+	for _, tableName := range tx.Catalog.Cache.ListObjects(RelationTableType) {
+		ti, err := tx.Catalog.GetTableInfo(tableName)
+		if err != nil {
+			return err
+		}
+
+		if ti.RowidSequenceName != "" {
+			_, err = tx.Catalog.GetSequence(ti.RowidSequenceName)
+			if errs.IsNotFoundError(err) {
+				report.addProblem(tableName, "rowid sequence %q not found", ti.RowidSequenceName)
+			} else if err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, indexName := range tx.Catalog.Cache.ListObjects(RelationIndexType) {
+		info, err := tx.Catalog.GetIndexInfo(indexName)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Catalog.GetTableInfo(info.Owner.TableName)
+		if errs.IsNotFoundError(err) {
+			report.addProblem(indexName, "table %q not found", info.Owner.TableName)
+		} else if err != nil {
+			return err
+		}
+	}
+
+	for _, seqName := range tx.Catalog.ListSequences() {
+		seq, err := tx.Catalog.GetSequence(seqName)
+		if err != nil {
+			return err
+		}
+		if seq.Info.Owner.TableName == "" {
+			continue
+		}
+
+		_, err = tx.Catalog.GetTableInfo(seq.Info.Owner.TableName)
+		if errs.IsNotFoundError(err) {
+			report.addProblem(seqName, "table %q not found", seq.Info.Owner.TableName)
+		} else if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// A recoverableEngine is an engine able to tell whether
+// changes remain to be rolled back.
+type recoverableEngine interface {
+	RollbackPending() (bool, error)
+}
+
+// VerifyRecovery opens the database found at path, which recovers it from
+// a crash by rolling back the transactions that were not committed,
+// and verifies the recovery succeeded: no change remains to be rolled back
+// and the catalog, tables and indexes are consistent. See Check.
+// It is meant to be used after simulating crashes with kv.Options.CrashHook,
+// which is ignored.
+func VerifyRecovery(ctx context.Context, path string, opts *Options) (*CheckReport, error) {
+	o := *opts
+	o.EngineOptions.CrashHook = nil
+
+	db, err := Open(path, &o)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot recover the database")
+	}
+	defer db.Close()
+
+	report, err := db.Check(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if e, ok := db.Engine.(recoverableEngine); ok {
+		pending, err := e.RollbackPending()
+		if err != nil {
+			return nil, err
+		}
+		if pending {
+			report.addProblem("", "changes remain to be rolled back after the recovery")
+		}
+	}
+
+	return report, nil
+}
+
+// checkTable ensures every row of the table is referenced by its indexes.
+func (tx *Transaction) checkTable(ctx context.Context, tableName string, report *CheckReport) error {
+	tb, err := tx.Catalog.GetTable(tx, tableName)
+	if err != nil {
+		return err
+	}
+
+	type tableIndex struct {
+		name string
+		info *IndexInfo
+		idx  *Index
+	}
+
+	var indexes []tableIndex
+	for _, name := range tx.Catalog.ListIndexes(tableName) {
+		info, err := tx.Catalog.GetIndexInfo(name)
+		if err != nil {
+			return err
+		}
+		idx, err := tx.Catalog.GetIndex(tx, name)
+		if err != nil {
+			return err
+		}
+
+		indexes = append(indexes, tableIndex{name: name, info: info, idx: idx})
+	}
+
+	return tb.IterateOnRange(nil, false, func(key *tree.Key, r Row) error {
+		report.Rows++
+		if report.Rows%checkInterval == 0 && ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		encKey, err := tb.Info.EncodeKey(key)
+		if err != nil {
+			return err
+		}
+
+		for _, ti := range indexes {
+			vs, err := ti.info.Values(tx, r)
+			if err != nil {
+				return err
+			}
+
+			ok, err := ti.idx.Tree.Exists(tree.NewKey(append(vs, types.NewBlobValue(encKey))...))
+			if err != nil {
+				return err
+			}
+			if !ok {
+				report.addProblem(ti.name, "row %s of table %s is not indexed", key, tableName)
+			}
+		}
+
+		return nil
+	})
+}
+
+// checkIndex ensures every entry of the index points to an existing row
+// whose values match the entry.
+func (tx *Transaction) checkIndex(ctx context.Context, indexName string, report *CheckReport) error {
+	info, err := tx.Catalog.GetIndexInfo(indexName)
+	if err != nil {
+		return err
+	}
+
+	idx, err := tx.Catalog.GetIndex(tx, indexName)
+	if err != nil {
+		return err
+	}
+
+	tb, err := tx.Catalog.GetTable(tx, info.Owner.TableName)
+	if err != nil {
+		return err
+	}
+
+	var n int
+	return idx.iterateOnRange(nil, false, func(itmKey *tree.Key, pk *tree.Key) error {
+		n++
+		if n%checkInterval == 0 && ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		r, err := tb.GetRow(pk)
+		if errs.IsNotFoundError(err) {
+			report.addProblem(indexName, "entry points to missing row %s of table %s", pk, tb.Info.TableName)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		vs, err := info.Values(tx, r)
+		if err != nil {
+			return err
+		}
+		expected, err := tree.NewKey(append(vs, types.NewBlobValue(pk.Encoded))...).Encode(idx.Tree.Namespace, idx.Tree.Order)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(expected, itmKey.Encoded) {
+			report.addProblem(indexName, "entry of row %s of table %s doesn't match the values of the row", pk, tb.Info.TableName)
+		}
+
+		return nil
+	})
+}
+
+// checkNamespaces reports the namespaces that contain data but don't belong
+// to any table or index. Transient namespaces are ignored.
+func (tx *Transaction) checkNamespaces(known map[tree.Namespace]struct{}, report *CheckReport) error {
+	return tx.iterateStoredNamespaces(func(ns tree.Namespace) error {
+		if _, ok := known[ns]; !ok {
+			report.addProblem("", "namespace %d doesn't belong to any table or index", ns)
+		}
+
+		return nil
+	})
+}
+
+// iterateStoredNamespaces calls fn for every namespace that contains
+// at least one key, in order. Transient namespaces are ignored.
+func (tx *Transaction) iterateStoredNamespaces(fn func(ns tree.Namespace) error) error {
+	upper := encoding.EncodeUint(nil, uint64(MinTransientNamespace))
+	var ns tree.Namespace
+
+	for {
+		// find the first key of the next namespace
+		it, err := tx.Session.Iterator(&engine.IterOptions{
+			LowerBound: encoding.EncodeUint(nil, uint64(ns)),
+			UpperBound: upper,
+		})
+		if err != nil {
+			return err
+		}
+
+		var found bool
+		if it.First(); it.Valid() {
+			n, _ := encoding.DecodeInt(it.Key())
+			ns = tree.Namespace(n)
+			found = true
+		}
+		err = it.Error()
+		if cerr := it.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return err
+		}
+		if !found {
+			return nil
+		}
+
+		err = fn(ns)
+		if err != nil {
+			return err
+		}
+
+		ns++
+	}
+}
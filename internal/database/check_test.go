@@ -0,0 +1,98 @@
+package database_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/chaisql/chai"
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheck(t *testing.T) {
+	db, err := chai.Open(filepath.Join(t.TempDir(), "db"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE test (a INTEGER PRIMARY KEY, b TEXT);
+		CREATE INDEX test_b ON test (b);
+		INSERT INTO test (a, b) VALUES (1, 'a'), (2, 'b'), (3, 'c'), (4, 'd');
+	`)
+	require.NoError(t, err)
+
+	report, err := db.Check(context.Background())
+	require.NoError(t, err)
+	require.True(t, report.OK(), report.Problems)
+	require.Equal(t, 1, report.Tables)
+	require.Equal(t, 1, report.Indexes)
+	require.Equal(t, 4, report.Rows)
+
+	// corrupt the database
+	tx, err := db.DB.Begin(true)
+	require.NoError(t, err)
+
+	tb, err := tx.Catalog.GetTable(tx, "test")
+	require.NoError(t, err)
+	idx, err := tx.Catalog.GetIndex(tx, "test_b")
+	require.NoError(t, err)
+
+	encKey := func(pk int32) []byte {
+		k, err := tb.Info.EncodeKey(tree.NewKey(types.NewIntegerValue(pk)))
+		require.NoError(t, err)
+		return k
+	}
+
+	// row 1 is not indexed
+	err = idx.Delete([]types.Value{types.NewTextValue("a")}, encKey(1))
+	require.NoError(t, err)
+	// the entry of row 2 points to a missing row
+	err = tb.Tree.Delete(tree.NewKey(types.NewIntegerValue(2)))
+	require.NoError(t, err)
+	// row 3 is indexed with the wrong value
+	err = idx.Set([]types.Value{types.NewTextValue("z")}, encKey(3))
+	require.NoError(t, err)
+	// orphaned namespace
+	err = tx.Session.Put(encoding.EncodeUint(nil, 500), []byte("orphan"))
+	require.NoError(t, err)
+
+	require.NoError(t, tx.Commit())
+
+	report, err = db.Check(context.Background())
+	require.NoError(t, err)
+	require.False(t, report.OK())
+	require.Equal(t, 3, report.Rows)
+
+	var problems []string
+	for _, p := range report.Problems {
+		problems = append(problems, p.String())
+	}
+	require.Equal(t, []string{
+		"test_b: row (1) of table test is not indexed",
+		"test_b: entry points to missing row (2) of table test",
+		"test_b: entry of row (3) of table test doesn't match the values of the row",
+		"namespace 500 doesn't belong to any table or index",
+	}, problems)
+}
+
+func TestCheckMemory(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE test (a INTEGER PRIMARY KEY, b TEXT, c INT);
+		CREATE UNIQUE INDEX test_b_c ON test (b, c DESC);
+		CREATE SEQUENCE seq;
+		INSERT INTO test (a, b) VALUES (1, 'a'), (2, 'b');
+	`)
+	require.NoError(t, err)
+
+	report, err := db.Check(context.Background())
+	require.NoError(t, err)
+	require.True(t, report.OK(), report.Problems)
+	require.Equal(t, 2, report.Rows)
+}
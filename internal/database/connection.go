@@ -2,7 +2,10 @@ package database
 
 import (
 	"context"
+	"math/rand"
+	"time"
 
+	"github.com/chaisql/chai/internal/types"
 	"github.com/cockroachdb/errors"
 )
 
@@ -10,20 +13,45 @@ type Connection struct {
 	db  *Database
 	ctx context.Context
 	tx  *Transaction
+	// if set, transactions read from the snapshot.
+	snapshot *Snapshot
+	// time zone used to parse and display TIMESTAMPTZ values.
+	timeZone *time.Location
+	// controls how values of different types are compared.
+	coercion types.Coercion
+	// random number generator seeded by setseed().
+	// if nil, the global generator is used.
+	rand *rand.Rand
+	// values returned by nextval() or set by setval(), by sequence name,
+	// and the last sequence used by nextval().
+	// they are kept when transactions are rolled back.
+	seqValues    map[string]int64
+	lastSequence string
+	closed       bool
 }
 
 // BeginTx starts a new transaction with the given options.
 // If opts is empty, it will use the default options.
 // The returned transaction must be closed either by calling Rollback or Commit.
 func (c *Connection) BeginTx(opts *TxOptions) (*Transaction, error) {
-	if c.ctx.Err() != nil {
-		return nil, errors.New("connection is closed")
+	if err := c.Err(); err != nil {
+		return nil, err
 	}
 
 	if c.tx != nil {
 		return nil, errors.New("cannot open a transaction within a transaction")
 	}
 
+	if c.snapshot != nil {
+		if opts == nil || !opts.ReadOnly {
+			return nil, errors.New("cannot write to a snapshot")
+		}
+		opts = &TxOptions{
+			ReadOnly: true,
+			Snapshot: c.snapshot,
+		}
+	}
+
 	tx, err := c.db.beginTx(opts)
 	if err != nil {
 		return nil, err
@@ -31,12 +59,23 @@ func (c *Connection) BeginTx(opts *TxOptions) (*Transaction, error) {
 
 	c.tx = tx
 	tx.conn = c
+	tx.TimeZone = c.timeZone
+	tx.Coercion = c.coercion
 	tx.OnRollbackHooks = append(tx.OnRollbackHooks, c.releaseAttachedTx)
 	tx.OnCommitHooks = append(tx.OnCommitHooks, c.releaseAttachedTx)
 
 	return tx, nil
 }
 
+// Err returns an error if the connection or its database is closed.
+func (c *Connection) Err() error {
+	if c.closed || c.ctx.Err() != nil {
+		return errors.New("connection is closed")
+	}
+
+	return nil
+}
+
 func (c *Connection) Reset() error {
 	if c.tx != nil {
 		return errors.New("cannot reset a connection with an attached transaction")
@@ -51,13 +90,128 @@ func (c *Connection) releaseAttachedTx() {
 	}
 }
 
+// Catalog returns the catalog seen by the queries of the connection: the one
+// of its attached transaction or of its snapshot, if any, or the latest
+// catalog of the database.
+func (c *Connection) Catalog() *Catalog {
+	if c.tx != nil {
+		return c.tx.Catalog
+	}
+	if c.snapshot != nil {
+		return c.snapshot.catalog
+	}
+
+	return c.db.Catalog()
+}
+
 // GetAttachedTx returns the transaction attached to the connection, if any.
 // The returned transaction is not thread safe.
 func (c *Connection) GetTx() *Transaction {
 	return c.tx
 }
 
+// TimeZone returns the time zone of the connection.
+func (c *Connection) TimeZone() *time.Location {
+	return c.timeZone
+}
+
+// SetTimeZone sets the time zone used by the connection to parse and display
+// TIMESTAMPTZ values. If loc is nil, the default time zone of the database is used.
+// The transaction attached to the connection, if any, uses the new time zone.
+func (c *Connection) SetTimeZone(loc *time.Location) {
+	if loc == nil {
+		loc = c.db.timeZone
+	}
+
+	c.timeZone = loc
+	if c.tx != nil {
+		c.tx.TimeZone = loc
+	}
+}
+
+// Coercion returns the coercion mode of the connection.
+func (c *Connection) Coercion() types.Coercion {
+	return c.coercion
+}
+
+// SetCoercion sets the coercion mode of the connection, which controls how
+// values of different types are compared. The transaction attached to the
+// connection, if any, uses the new mode.
+func (c *Connection) SetCoercion(mode types.Coercion) {
+	c.coercion = mode
+	if c.tx != nil {
+		c.tx.Coercion = mode
+	}
+}
+
+// ResetCoercion sets the coercion mode of the connection
+// back to the default mode of the database.
+func (c *Connection) ResetCoercion() {
+	c.SetCoercion(c.db.coercion)
+}
+
+// Rand returns the random number generator of the connection,
+// or nil if it was never seeded, in which case the global generator must be used.
+func (c *Connection) Rand() *rand.Rand {
+	return c.rand
+}
+
+// SetSeed seeds the random number generator of the connection,
+// which makes the values returned by the random functions reproducible.
+func (c *Connection) SetSeed(seed int64) {
+	c.rand = rand.New(rand.NewSource(seed))
+}
+
+// SequenceValue returns the value most recently returned by nextval()
+// for a sequence in this connection, or set by setval().
+// It returns false if there is none.
+func (c *Connection) SequenceValue(name string) (int64, bool) {
+	v, ok := c.seqValues[name]
+	return v, ok
+}
+
+// SetSequenceValue sets the value returned by currval() for a sequence.
+// If last is true, the sequence becomes the one used by lastval().
+func (c *Connection) SetSequenceValue(name string, v int64, last bool) {
+	if c.seqValues == nil {
+		c.seqValues = make(map[string]int64)
+	}
+
+	c.seqValues[name] = v
+	if last {
+		c.lastSequence = name
+	}
+}
+
+// LastSequence returns the name of the last sequence used by nextval()
+// in this connection, or an empty string if there is none.
+func (c *Connection) LastSequence() string {
+	return c.lastSequence
+}
+
+// ResetSession restores the settings of the connection to the defaults
+// of the database and forgets the values of the sequences, as if
+// it was a new connection. It must not have an attached transaction.
+func (c *Connection) ResetSession() error {
+	if err := c.Reset(); err != nil {
+		return err
+	}
+
+	c.timeZone = c.db.timeZone
+	c.coercion = c.db.coercion
+	c.rand = nil
+	c.seqValues = nil
+	c.lastSequence = ""
+	return nil
+}
+
+// Close rolls back the attached transaction, if any, and releases the connection.
+// Closing a connection more than once does nothing.
 func (c *Connection) Close() error {
+	if c.closed {
+		return nil
+	}
+	c.closed = true
 	defer c.db.connectionWg.Done()
 
 	if c.tx != nil {
@@ -2,7 +2,9 @@ package database
 
 import (
 	"fmt"
+	"math"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/chaisql/chai/internal/row"
 	"github.com/chaisql/chai/internal/stringutil"
@@ -18,6 +20,18 @@ type ColumnConstraint struct {
 	Type         types.Type
 	IsNotNull    bool
 	DefaultValue TableExpression
+	// If true, the texts of the column are stored
+	// in a dictionary shared by the rows of the table.
+	Dictionary bool
+	// If not zero, the texts of the column can't have
+	// more than Length characters, see FitLength.
+	Length int
+	// If true, the texts of the column are padded with spaces
+	// to Length characters, as for CHAR(n) columns.
+	Padded bool
+	// If true, the DOUBLE and REAL values of the column
+	// must be finite numbers, see CheckFinite.
+	Finite bool
 }
 
 func (f *ColumnConstraint) IsEmpty() bool {
@@ -29,7 +43,15 @@ func (f *ColumnConstraint) String() string {
 
 	s.WriteString(f.Column)
 	s.WriteString(" ")
-	s.WriteString(strings.ToUpper(f.Type.String()))
+	s.WriteString(f.TypeName())
+
+	if f.Dictionary {
+		s.WriteString(" DICTIONARY")
+	}
+
+	if f.Finite {
+		s.WriteString(" FINITE")
+	}
 
 	if f.IsNotNull {
 		s.WriteString(" NOT NULL")
@@ -43,6 +65,68 @@ func (f *ColumnConstraint) String() string {
 	return s.String()
 }
 
+// TypeName returns the name of the type of the column, including
+// the length of VARCHAR(n) and CHAR(n) columns.
+func (f *ColumnConstraint) TypeName() string {
+	switch {
+	case f.Length == 0:
+		return strings.ToUpper(f.Type.String())
+	case f.Padded:
+		return fmt.Sprintf("CHAR(%d)", f.Length)
+	}
+
+	return fmt.Sprintf("VARCHAR(%d)", f.Length)
+}
+
+// FitLength returns the text v stored in a column whose length is limited.
+// Texts longer than the length of the column are rejected, unless the extra
+// characters are spaces, which are removed. The texts of CHAR(n) columns
+// are padded with spaces to n characters.
+func (f *ColumnConstraint) FitLength(v types.Value) (types.Value, error) {
+	if f.Length == 0 || v.Type() != types.TypeText {
+		return v, nil
+	}
+
+	s := types.AsString(v)
+	n := utf8.RuneCountInString(s)
+	switch {
+	case n > f.Length:
+		// find the end of the first Length characters
+		end, count := len(s), 0
+		for i := range s {
+			if count == f.Length {
+				end = i
+				break
+			}
+			count++
+		}
+		if strings.TrimRight(s[end:], " ") != "" {
+			return nil, errors.Errorf("value too long for type %s", f.TypeName())
+		}
+		return types.NewTextValue(s[:end]), nil
+	case n < f.Length && f.Padded:
+		return types.NewTextValue(s + strings.Repeat(" ", f.Length-n)), nil
+	}
+
+	return v, nil
+}
+
+// CheckFinite returns a constraint violation error if the column only
+// accepts finite numbers and v is NaN or an infinity. Otherwise, these values are
+// stored and sorted like the other numbers: NaN is lower than -Inf.
+func (f *ColumnConstraint) CheckFinite(v types.Value) error {
+	if !f.Finite || (v.Type() != types.TypeDouble && v.Type() != types.TypeReal) {
+		return nil
+	}
+
+	x := types.AsFloat64(v)
+	if math.IsNaN(x) || math.IsInf(x, 0) {
+		return &ConstraintViolationError{Constraint: "FINITE", Columns: []string{f.Column}}
+	}
+
+	return nil
+}
+
 // ColumnConstraints is a list of column constraints.
 type ColumnConstraints struct {
 	Ordered  []*ColumnConstraint
@@ -94,7 +178,7 @@ func (f *ColumnConstraints) Add(newCc *ColumnConstraint) error {
 			// Integers can be converted to other integers, doubles, texts and bools.
 			// TODO: rework
 			switch newCc.Type {
-			case types.TypeInteger, types.TypeBigint, types.TypeDouble, types.TypeText:
+			case types.TypeInteger, types.TypeBigint, types.TypeUnsignedBigint, types.TypeDouble, types.TypeReal, types.TypeText:
 			default:
 				return fmt.Errorf("default value %q cannot be converted to type %q", newCc.DefaultValue, newCc.Type)
 			}
@@ -147,9 +231,7 @@ func (t *TableConstraint) String() string {
 			}
 			sb.WriteString(c)
 
-			if t.SortOrder.IsDesc(i) {
-				sb.WriteString(" DESC")
-			}
+			writeSortOrder(&sb, t.SortOrder, i)
 		}
 		sb.WriteString(")")
 	case t.Unique:
@@ -160,9 +242,7 @@ func (t *TableConstraint) String() string {
 			}
 			sb.WriteString(c)
 
-			if t.SortOrder.IsDesc(i) {
-				sb.WriteString(" DESC")
-			}
+			writeSortOrder(&sb, t.SortOrder, i)
 		}
 		sb.WriteString(")")
 	}
@@ -190,7 +270,9 @@ func (t *TableConstraints) ValidateRow(tx *Transaction, r row.Row) error {
 			ok = types.AsBool(v)
 		case types.TypeInteger, types.TypeBigint:
 			ok = types.AsInt64(v) != 0
-		case types.TypeDouble:
+		case types.TypeUnsignedBigint:
+			ok = types.AsUint64(v) != 0
+		case types.TypeDouble, types.TypeReal:
 			ok = types.AsFloat64(v) != 0
 		case types.TypeNull:
 			ok = true
@@ -84,3 +84,38 @@ func TestColumnConstraintsAdd(t *testing.T) {
 		})
 	}
 }
+
+func TestColumnConstraintFitLength(t *testing.T) {
+	varchar := database.ColumnConstraint{Column: "a", Type: types.TypeText, Length: 3}
+	char := database.ColumnConstraint{Column: "a", Type: types.TypeText, Length: 3, Padded: true}
+
+	tests := []struct {
+		name  string
+		cc    *database.ColumnConstraint
+		v     types.Value
+		want  types.Value
+		fails bool
+	}{
+		{"VARCHAR, shorter", &varchar, types.NewTextValue("ab"), types.NewTextValue("ab"), false},
+		{"VARCHAR, same length", &varchar, types.NewTextValue("abc"), types.NewTextValue("abc"), false},
+		{"VARCHAR, multibyte", &varchar, types.NewTextValue("héé"), types.NewTextValue("héé"), false},
+		{"VARCHAR, longer", &varchar, types.NewTextValue("abcd"), nil, true},
+		{"VARCHAR, trailing spaces", &varchar, types.NewTextValue("ab    "), types.NewTextValue("ab "), false},
+		{"VARCHAR, NULL", &varchar, types.NewNullValue(), types.NewNullValue(), false},
+		{"CHAR, shorter", &char, types.NewTextValue("a"), types.NewTextValue("a  "), false},
+		{"CHAR, multibyte", &char, types.NewTextValue("é"), types.NewTextValue("é  "), false},
+		{"CHAR, longer", &char, types.NewTextValue("abcd"), nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			v, err := test.cc.FitLength(test.v)
+			if test.fails {
+				require.EqualError(t, err, "value too long for type "+test.cc.TypeName())
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.want, v)
+		})
+	}
+}
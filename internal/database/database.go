@@ -8,7 +8,10 @@ import (
 	"time"
 
 	"github.com/chaisql/chai/internal/engine"
+	"github.com/chaisql/chai/internal/engine/memory"
 	"github.com/chaisql/chai/internal/kv"
+	"github.com/chaisql/chai/internal/pkg/backoff"
+	"github.com/chaisql/chai/internal/types"
 	"github.com/cockroachdb/errors"
 )
 
@@ -16,6 +19,12 @@ const (
 	InternalPrefix = "__chai_"
 )
 
+// ErrReadOnly is returned when trying to write to a database opened in read-only mode.
+var ErrReadOnly = errors.New("database is opened in read-only mode")
+
+// ErrReadOnlyTx is returned when trying to write within a read-only transaction.
+var ErrReadOnlyTx = errors.New("cannot write within a read-only transaction")
+
 type Database struct {
 	catalogMu sync.RWMutex
 	catalog   *Catalog
@@ -31,9 +40,20 @@ type Database struct {
 	// during certain operations (commit, close, etc.)
 	txmu sync.RWMutex
 
-	// This limits the number of write transactions to 1.
+	// This limits the number of write transactions to 1,
+	// unless concurrent writes are enabled.
 	writetxmu sync.Mutex
 
+	// If concurrent writes are enabled, detects the conflicts
+	// between write transactions. Nil otherwise.
+	conflicts *writeConflicts
+
+	// Serializes the concurrent write transactions using sequences.
+	seqmu sync.Mutex
+
+	// policy used to wait for the write lock.
+	lockWait backoff.Policy
+
 	// transactionIDs is used to assign transaction an ID at runtime.
 	// Since transaction IDs are not persisted and not used for concurrent
 	// access, we can use 8 bytes ids that will be reset every time
@@ -42,6 +62,39 @@ type Database struct {
 
 	closeOnce sync.Once
 
+	// whether the keys modified by write transactions are tracked.
+	trackChanges bool
+
+	// If change capture is enabled, state of the change log. Nil otherwise.
+	changeLog *changeLog
+
+	// Go functions called before modifying the rows of a table.
+	writeHooks writeHooks
+
+	// Go functions restricting the rows of a table visible to queries.
+	rowPolicies rowPolicies
+
+	// whether the database was opened in read-only mode.
+	readOnly bool
+
+	// size above which BLOB values are stored outside of their row.
+	overflowThreshold int
+
+	// last identifier assigned to a blob stored outside of its row.
+	blobIDs atomic.Uint64
+
+	// background jobs, stopped when the database is closed.
+	scheduler *Scheduler
+
+	// default time zone of the connections.
+	timeZone *time.Location
+
+	// default coercion mode of the connections.
+	coercion types.Coercion
+
+	// counters of the transactions, see TransactionStats.
+	txStats txCounters
+
 	// Underlying kv store.
 	Engine engine.Engine
 }
@@ -50,6 +103,65 @@ type Database struct {
 // how the database is loaded.
 type Options struct {
 	CatalogLoader func(tx *Transaction) error
+
+	// Engine used to store the data. If nil, Open creates one based on the path:
+	// an in-memory engine for ":memory:", a Pebble engine otherwise.
+	Engine engine.Engine
+
+	// Options of the Pebble engine. The namespaces are set by Open.
+	EngineOptions kv.Options
+
+	// If set, the database reads its data from the sstables
+	// stored in a remote object storage. The path is ignored.
+	Remote *kv.RemoteOptions
+
+	// If true, the keys modified by every write transaction are tracked,
+	// which is required to create incremental backups.
+	// Disabling it removes the changes tracked so far.
+	TrackChanges bool
+
+	// If true, write transactions run concurrently instead of one at a time.
+	// A transaction fails to commit with ErrWriteConflict if it modified
+	// a key or the schema also modified by a transaction committed after it began.
+	// Write transactions are then kept in memory until they are committed.
+	// The engine must implement engine.ConcurrentEngine.
+	ConcurrentWrites bool
+
+	// If true, the rows modified by every committed write transaction
+	// are recorded in the change log and can be read with Changes.
+	// The changes of a transaction are kept in memory until it is committed.
+	CaptureChanges bool
+
+	// If true, the database is opened in read-only mode: write transactions
+	// are rejected with ErrReadOnly. On disk, the database is opened without
+	// being locked, which allows inspecting a database used by another process.
+	// The database must already exist.
+	ReadOnly bool
+
+	// Size in bytes above which BLOB values are stored outside of their row,
+	// in chunks that can be streamed. Defaults to DefaultOverflowThreshold.
+	OverflowThreshold int
+
+	// Controls how long to wait for the lock of the database directory when
+	// it is held by another process, and for the write lock when beginning
+	// a write transaction while another one is running.
+	// By default, opening a locked database fails immediately with ErrLockTimeout
+	// and write transactions wait until the write lock is released.
+	LockWait backoff.Policy
+
+	// Default time zone of the connections, used to parse and display
+	// TIMESTAMPTZ values. Defaults to UTC.
+	TimeZone *time.Location
+
+	// Default coercion mode of the connections, which controls how values
+	// of different types are compared. Defaults to lenient.
+	Coercion types.Coercion
+
+	// If set, the rows encoded with an older version of the row format
+	// are upgraded in the background by a job run at this interval,
+	// until every row is upgraded. Otherwise, rows are upgraded
+	// when they are written.
+	RowMigrationInterval time.Duration
 }
 
 // CatalogLoader loads the catalog from the disk.
@@ -63,24 +175,61 @@ type CatalogLoader interface {
 type TxOptions struct {
 	// Open a read-only transaction.
 	ReadOnly bool
+	// If set, the transaction reads from the snapshot instead
+	// of the latest version of the database. It must be read-only.
+	Snapshot *Snapshot
+	// If set, a write transaction run concurrently with other write
+	// transactions fails to commit with ErrWriteConflict if any of the rows
+	// it read, or any row matching the ranges it scanned, was modified by a
+	// transaction committed after it began. This makes the serializable
+	// transactions behave as if they had run one at a time.
+	// Without concurrent writes, write transactions are always serializable.
+	Serializable bool
 }
 
-func Open(path string, opts *Options) (*Database, error) {
-	store, err := kv.NewEngine(path, kv.Options{
-		RollbackSegmentNamespace: int64(RollbackSegmentNamespace),
-		MinTransientNamespace:    uint64(MinTransientNamespace),
-		MaxTransientNamespace:    uint64(MaxTransientNamespace),
-	})
-	if err != nil {
-		return nil, err
+func Open(path string, opts *Options) (_ *Database, err error) {
+	store := opts.Engine
+	if store == nil {
+		store, err = newEngine(path, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		// release the directory if the database cannot be opened
+		defer func() {
+			if err != nil {
+				_ = store.Close()
+			}
+		}()
 	}
 
 	db := Database{
-		Engine: store,
+		Engine:            store,
+		trackChanges:      opts.TrackChanges,
+		readOnly:          opts.ReadOnly,
+		overflowThreshold: opts.OverflowThreshold,
+		lockWait:          opts.LockWait,
+		timeZone:          opts.TimeZone,
+		coercion:          opts.Coercion,
+	}
+	if db.overflowThreshold <= 0 {
+		db.overflowThreshold = DefaultOverflowThreshold
+	}
+	if db.timeZone == nil {
+		db.timeZone = time.UTC
 	}
 
 	// create a context that will be cancelled when the database is closed.
 	db.closeContext, db.closeCancel = context.WithCancel(context.Background())
+	db.scheduler = newScheduler(db.closeContext)
+
+	if opts.ConcurrentWrites && len(db.Locations()) > 0 {
+		return nil, errors.New("concurrent writes cannot be used with storage locations")
+	}
+
+	if db.readOnly {
+		return openReadOnly(&db, opts)
+	}
 
 	// ensure the rollback segment doesn't contain any data that needs to be rolled back
 	// due to a previous crash.
@@ -104,6 +253,11 @@ func Open(path string, opts *Options) (*Database, error) {
 	db.catalog = NewCatalog()
 	tx.Catalog = db.catalog
 
+	err = initChanges(tx.Session, opts.TrackChanges)
+	if err != nil {
+		return nil, err
+	}
+
 	if opts.CatalogLoader != nil {
 		err = opts.CatalogLoader(tx)
 		if err != nil {
@@ -116,14 +270,146 @@ func Open(path string, opts *Options) (*Database, error) {
 		}
 	}
 
+	err = placeCatalog(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = upgradeKeys(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.CaptureChanges {
+		db.changeLog, err = loadChangeLog(tx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	lastBlobID, err := loadLastBlobID(tx)
+	if err != nil {
+		return nil, err
+	}
+	db.blobIDs.Store(lastBlobID)
+
 	err = tx.Commit()
 	if err != nil {
 		return nil, err
 	}
 
+	if opts.ConcurrentWrites {
+		if _, ok := db.Engine.(engine.ConcurrentEngine); !ok {
+			return nil, errors.New("the engine doesn't support concurrent writes")
+		}
+
+		db.conflicts = newWriteConflicts()
+	}
+
+	if opts.RowMigrationInterval > 0 {
+		err = db.registerRowMigration(opts.RowMigrationInterval)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return &db, nil
 }
 
+// openReadOnly loads the catalog of a database opened in read-only mode,
+// without modifying anything. The catalog must have been initialized.
+func openReadOnly(db *Database, opts *Options) (*Database, error) {
+	if opts.TrackChanges || opts.ConcurrentWrites {
+		return nil, errors.New("cannot track changes or enable concurrent writes in read-only mode")
+	}
+
+	tx, err := db.Begin(false)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	db.catalog = NewCatalog()
+	tx.Catalog = db.catalog
+
+	if opts.CatalogLoader != nil {
+		err = opts.CatalogLoader(tx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to load catalog")
+		}
+	}
+
+	err = placeCatalog(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkKeyFormat(tx.Session)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.CaptureChanges {
+		db.changeLog, err = loadChangeLog(tx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return db, nil
+}
+
+// Scheduler returns the scheduler running the background jobs of the database.
+func (db *Database) Scheduler() *Scheduler {
+	return db.scheduler
+}
+
+// Done returns a channel closed when the database is closed.
+func (db *Database) Done() <-chan struct{} {
+	return db.closeContext.Done()
+}
+
+// ReadOnly returns whether the database was opened in read-only mode.
+func (db *Database) ReadOnly() bool {
+	return db.readOnly
+}
+
+// engineOptions returns the options of the Pebble engine,
+// with the namespaces used by the database.
+func engineOptions(opts kv.Options) kv.Options {
+	opts.RollbackSegmentNamespace = int64(RollbackSegmentNamespace)
+	opts.PlacementNamespace = int64(PlacementNamespace)
+	opts.MinTransientNamespace = uint64(MinTransientNamespace)
+	opts.MaxTransientNamespace = uint64(MaxTransientNamespace)
+	return opts
+}
+
+func newEngine(path string, opts *Options) (engine.Engine, error) {
+	kvOpts := engineOptions(opts.EngineOptions)
+	kvOpts.ReadOnly = opts.ReadOnly
+	kvOpts.LockWait = opts.LockWait
+
+	if opts.Remote != nil {
+		return kv.NewRemoteEngine(kvOpts, *opts.Remote)
+	}
+
+	if path == ":memory:" {
+		if opts.ReadOnly {
+			return nil, errors.New("in-memory databases cannot be opened in read-only mode")
+		}
+
+		return memory.NewEngine(), nil
+	}
+
+	return kv.NewEngine(path, kvOpts)
+}
+
+// RestorePointInTime creates a database at path from a checkpoint and replays
+// the archived WAL files up to the target time. See kv.RestorePointInTime.
+func RestorePointInTime(path string, opts kv.Options, popts kv.PointInTimeOptions) error {
+	return kv.RestorePointInTime(path, engineOptions(opts), popts)
+}
+
 // Close the database.
 func (db *Database) Close() error {
 	var err error
@@ -131,6 +417,7 @@ func (db *Database) Close() error {
 	db.closeOnce.Do(func() {
 		db.closeCancel()
 
+		db.scheduler.wait()
 		db.connectionWg.Wait()
 		err = db.closeDatabase()
 	})
@@ -139,7 +426,16 @@ func (db *Database) Close() error {
 }
 
 func (db *Database) closeDatabase() error {
-	// release all sequences
+	err := db.releaseSequences()
+
+	// the engine is closed even if the sequences cannot be released,
+	// for example after a simulated crash: the values they cached
+	// are then skipped, as after a real crash.
+	return errors.CombineErrors(err, db.Engine.Close())
+}
+
+// releaseSequences stores the current value of every sequence.
+func (db *Database) releaseSequences() error {
 	tx, err := db.beginTxUnlocked(nil)
 	if err != nil {
 		return err
@@ -158,12 +454,7 @@ func (db *Database) closeDatabase() error {
 		}
 	}
 
-	err = tx.Session.Commit()
-	if err != nil {
-		return err
-	}
-
-	return db.Engine.Close()
+	return tx.Session.Commit()
 }
 
 // Connect returns a new connection to the database.
@@ -176,8 +467,10 @@ func (db *Database) Connect() (*Connection, error) {
 
 	db.connectionWg.Add(1)
 	return &Connection{
-		db:  db,
-		ctx: db.closeContext,
+		db:       db,
+		ctx:      db.closeContext,
+		timeZone: db.timeZone,
+		coercion: db.coercion,
 	}, nil
 }
 
@@ -201,18 +494,54 @@ func (db *Database) beginTx(opts *TxOptions) (*Transaction, error) {
 		return nil, errors.New("database is closed")
 	}
 
+	if opts == nil {
+		opts = new(TxOptions)
+	}
+
+	if opts.Snapshot != nil && !opts.ReadOnly {
+		return nil, errors.New("cannot write to a snapshot")
+	}
+
+	if db.readOnly && !opts.ReadOnly {
+		return nil, errors.WithStack(ErrReadOnly)
+	}
+
+	// the write lock must be acquired before txmu: the running
+	// write transaction needs txmu to commit and release it.
+	locked := !opts.ReadOnly && db.conflicts == nil
+	if locked {
+		err := db.lockWriteTx()
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	db.txmu.RLock()
 	defer db.txmu.RUnlock()
 
-	if opts == nil {
-		opts = new(TxOptions)
+	tx, err := db.beginTxUnlocked(opts)
+	if err != nil && locked {
+		db.writetxmu.Unlock()
 	}
+	return tx, err
+}
+
+// ErrLockTimeout is returned when the database, or the write lock,
+// is still locked after the timeout of Options.LockWait.
+var ErrLockTimeout = kv.ErrLockTimeout
 
-	if !opts.ReadOnly {
+// lockWriteTx acquires the write lock, following the LockWait policy.
+func (db *Database) lockWriteTx() error {
+	if db.lockWait.Timeout <= 0 {
 		db.writetxmu.Lock()
+		return nil
+	}
+
+	if !db.lockWait.Retry(db.writetxmu.TryLock) {
+		return errors.Wrap(ErrLockTimeout, "another write transaction is running")
 	}
 
-	return db.beginTxUnlocked(opts)
+	return nil
 }
 
 // beginTxUnlocked creates a transaction without locks.
@@ -221,11 +550,29 @@ func (db *Database) beginTxUnlocked(opts *TxOptions) (*Transaction, error) {
 		opts = &TxOptions{}
 	}
 
+	catalog := db.Catalog()
+
 	var sess engine.Session
-	if opts.ReadOnly {
+	if opts.Snapshot != nil {
+		var err error
+		sess, err = opts.Snapshot.newSession()
+		if err != nil {
+			return nil, err
+		}
+		catalog = opts.Snapshot.catalog
+	} else if opts.ReadOnly {
 		sess = db.Engine.NewSnapshotSession()
+	} else if db.conflicts != nil {
+		sess = db.Engine.(engine.ConcurrentEngine).NewConcurrentSession()
+		if db.trackChanges {
+			sess = newTrackingSession(sess)
+		}
+		sess = newConflictSession(sess, db.conflicts, opts.Serializable)
 	} else {
 		sess = db.Engine.NewBatchSession()
+		if db.trackChanges {
+			sess = newTrackingSession(sess)
+		}
 	}
 
 	tx := Transaction{
@@ -234,17 +581,44 @@ func (db *Database) beginTxUnlocked(opts *TxOptions) (*Transaction, error) {
 		Session:  sess,
 		Writable: !opts.ReadOnly,
 		ID:       db.transactionIDs.Add(1),
-		Catalog:  db.Catalog(),
+		Catalog:  catalog,
 		TxStart:  time.Now(),
+
+		baseCatalog: catalog,
 	}
 
-	if !opts.ReadOnly {
+	if !opts.ReadOnly && db.conflicts == nil {
 		tx.WriteTxMu = &db.writetxmu
 	}
 
+	db.txStats.active.Add(1)
 	return &tx, nil
 }
 
+type txCounters struct {
+	active     atomic.Int64
+	committed  atomic.Int64
+	rolledBack atomic.Int64
+}
+
+// TransactionStats counts the transactions of the database since it was opened.
+type TransactionStats struct {
+	// Number of transactions neither committed nor rolled back yet.
+	Active int64
+	// Number of transactions committed or rolled back.
+	Committed  int64
+	RolledBack int64
+}
+
+// TransactionStats returns the counters of the transactions.
+func (db *Database) TransactionStats() TransactionStats {
+	return TransactionStats{
+		Active:     db.txStats.active.Load(),
+		Committed:  db.txStats.committed.Load(),
+		RolledBack: db.txStats.rolledBack.Load(),
+	}
+}
+
 func (db *Database) Catalog() *Catalog {
 	db.catalogMu.RLock()
 	c := db.catalog
@@ -75,3 +75,62 @@ func TestConcurrentTransactionManagement(t *testing.T) {
 		t.Fatal("deadlock")
 	}
 }
+
+func TestLockWait(t *testing.T) {
+	dir := t.TempDir()
+	opts := chai.Options{LockWait: chai.LockWaitPolicy{Timeout: 50 * time.Millisecond}}
+
+	db, err := chai.OpenWith(dir, &opts)
+	require.NoError(t, err)
+
+	t.Run("Open", func(t *testing.T) {
+		// fails without waiting by default
+		_, err := chai.Open(dir)
+		require.Error(t, err)
+		require.False(t, chai.IsLockTimeoutError(err))
+
+		start := time.Now()
+		_, err = chai.OpenWith(dir, &opts)
+		require.True(t, chai.IsLockTimeoutError(err), err)
+		require.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+	})
+
+	t.Run("Write transaction", func(t *testing.T) {
+		conn1, err := db.Connect()
+		require.NoError(t, err)
+		defer conn1.Close()
+		conn2, err := db.Connect()
+		require.NoError(t, err)
+		defer conn2.Close()
+
+		tx, err := conn1.Begin(true)
+		require.NoError(t, err)
+
+		_, err = conn2.Begin(true)
+		require.True(t, chai.IsLockTimeoutError(err), err)
+
+		// read-only transactions don't wait
+		ro, err := conn2.Begin(false)
+		require.NoError(t, err)
+		require.NoError(t, ro.Rollback())
+
+		// the lock is acquired once released
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			_ = tx.Rollback()
+		}()
+		tx, err = conn2.Begin(true)
+		require.NoError(t, err)
+		require.NoError(t, tx.Rollback())
+	})
+
+	// the database is opened once closed by the other process
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		_ = db.Close()
+	}()
+	opts.LockWait.Timeout = 5 * time.Second
+	db, err = chai.OpenWith(dir, &opts)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+}
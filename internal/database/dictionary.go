@@ -0,0 +1,168 @@
+package database
+
+import (
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/chaisql/chai/internal/engine"
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// The values of the TEXT columns declared with DICTIONARY are stored in
+// a dictionary shared by all the rows of the table: each distinct text
+// is assigned an identifier and the rows only contain a reference to it.
+// This saves space for columns with few distinct values, such as statuses
+// or country codes. The texts are decoded when the rows are read, which
+// makes the dictionaries invisible outside of the rows: indexes and
+// queries only deal with texts.
+//
+// The dictionaries are stored in the dictionary namespace. For the
+// column c of the table stored in the namespace ns:
+//   - (ns, c, 0) holds the last identifier assigned
+//   - (ns, c, 1, id) holds the text with the given identifier
+//   - (ns, c, 2, text) holds the identifier of the text
+//
+// Texts are never removed from a dictionary, which is deleted with its table.
+// Once a dictionary contains maxDictionarySize texts, the new texts are
+// stored in the rows.
+
+// maxDictionarySize is the maximum number of texts of a dictionary.
+const maxDictionarySize = 1 << 16
+
+const (
+	dictionaryLastID byte = iota
+	dictionaryTexts
+	dictionaryIDs
+)
+
+// A dictionaryID identifies the dictionary of a column.
+type dictionaryID struct {
+	namespace tree.Namespace
+	column    string
+}
+
+// dictionary caches the part of a dictionary
+// read or written by a transaction.
+type dictionary struct {
+	texts map[uint64]string
+	ids   map[string]uint64
+}
+
+func dictionaryTree(tx *Transaction) *tree.Tree {
+	return tree.New(tx.Session, DictionaryNamespace, nil)
+}
+
+func dictionaryKey(id dictionaryID, kind byte, v ...types.Value) *tree.Key {
+	return tree.NewKey(append([]types.Value{
+		types.NewBigintValue(int64(id.namespace)),
+		types.NewTextValue(id.column),
+		types.NewIntegerValue(int32(kind)),
+	}, v...)...)
+}
+
+func (tx *Transaction) dictionary(id dictionaryID) *dictionary {
+	d, ok := tx.dictionaries[id]
+	if !ok {
+		if tx.dictionaries == nil {
+			tx.dictionaries = make(map[dictionaryID]*dictionary)
+		}
+		d = &dictionary{
+			texts: make(map[uint64]string),
+			ids:   make(map[string]uint64),
+		}
+		tx.dictionaries[id] = d
+	}
+
+	return d
+}
+
+// dictionaryText returns the text referenced by a row.
+func (tx *Transaction) dictionaryText(id dictionaryID, ref uint64) (string, error) {
+	d := tx.dictionary(id)
+	if s, ok := d.texts[ref]; ok {
+		return s, nil
+	}
+
+	enc, err := dictionaryTree(tx).Get(dictionaryKey(id, dictionaryTexts, types.NewBigintValue(int64(ref))))
+	if errors.Is(err, engine.ErrKeyNotFound) {
+		return "", errors.Errorf("text %d is missing from the dictionary of column %q", ref, id.column)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	s, _ := encoding.DecodeText(enc)
+	d.texts[ref] = s
+	d.ids[s] = ref
+	return s, nil
+}
+
+// dictionaryRef returns the identifier of a text in a dictionary, adding
+// the text to the dictionary if necessary. It returns false if the text
+// must be stored in the row instead, because the dictionary is full or
+// the transaction is read-only.
+func (tx *Transaction) dictionaryRef(id dictionaryID, s string) (uint64, bool, error) {
+	d := tx.dictionary(id)
+	if ref, ok := d.ids[s]; ok {
+		return ref, true, nil
+	}
+
+	t := dictionaryTree(tx)
+	enc, err := t.Get(dictionaryKey(id, dictionaryIDs, types.NewTextValue(s)))
+	if err == nil {
+		ref, _ := encoding.DecodeUint(enc)
+		d.texts[ref] = s
+		d.ids[s] = ref
+		return ref, true, nil
+	}
+	if !errors.Is(err, engine.ErrKeyNotFound) {
+		return 0, false, err
+	}
+
+	if !tx.Writable {
+		return 0, false, nil
+	}
+
+	var last uint64
+	lastKey := dictionaryKey(id, dictionaryLastID)
+	enc, err = t.Get(lastKey)
+	if err == nil {
+		last, _ = encoding.DecodeUint(enc)
+	} else if !errors.Is(err, engine.ErrKeyNotFound) {
+		return 0, false, err
+	}
+	if last >= maxDictionarySize {
+		return 0, false, nil
+	}
+
+	ref := last + 1
+	err = t.Put(lastKey, encoding.EncodeUint(nil, ref))
+	if err != nil {
+		return 0, false, err
+	}
+	err = t.Put(dictionaryKey(id, dictionaryTexts, types.NewBigintValue(int64(ref))), encoding.EncodeText(nil, s))
+	if err != nil {
+		return 0, false, err
+	}
+	err = t.Put(dictionaryKey(id, dictionaryIDs, types.NewTextValue(s)), encoding.EncodeUint(nil, ref))
+	if err != nil {
+		return 0, false, err
+	}
+
+	d.texts[ref] = s
+	d.ids[s] = ref
+	return ref, true, nil
+}
+
+// deleteDictionaries deletes the dictionaries of the table
+// stored in the given namespace.
+func (tx *Transaction) deleteDictionaries(ns tree.Namespace) error {
+	for id := range tx.dictionaries {
+		if id.namespace == ns {
+			delete(tx.dictionaries, id)
+		}
+	}
+
+	k := tree.NewKey(types.NewBigintValue(int64(ns)))
+	return dictionaryTree(tx).DeleteRange(&tree.Range{Min: k, Max: k})
+}
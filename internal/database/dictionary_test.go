@@ -0,0 +1,114 @@
+package database_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/chaisql/chai"
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDictionary(t *testing.T) {
+	rowSize := func(t *testing.T, db *chai.DB, a int) int {
+		t.Helper()
+
+		tx, err := db.DB.Begin(false)
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		tb, err := tx.Catalog.GetTable(tx, "test")
+		require.NoError(t, err)
+
+		enc, err := tb.Tree.Get(tree.NewKey(types.NewIntegerValue(int32(a))))
+		require.NoError(t, err)
+		return len(enc)
+	}
+
+	dictionarySize := func(t *testing.T, db *chai.DB) int {
+		t.Helper()
+
+		tx, err := db.DB.Begin(false)
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		var n int
+		err = tree.New(tx.Session, database.DictionaryNamespace, nil).IterateOnRange(nil, false, func(*tree.Key, []byte) error {
+			n++
+			return nil
+		})
+		require.NoError(t, err)
+		return n
+	}
+
+	long := strings.Repeat("a", 100)
+
+	t.Run("Rows store references", func(t *testing.T) {
+		db, err := chai.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		require.NoError(t, db.Exec(`CREATE TABLE test (a INT PRIMARY KEY, b TEXT DICTIONARY, c TEXT)`))
+		for i := 0; i < 10; i++ {
+			require.NoError(t, db.Exec(`INSERT INTO test (a, b, c) VALUES (?, ?, ?)`, i, long, long))
+		}
+
+		// the text is stored once, the rows only contain a reference
+		require.Less(t, rowSize(t, db, 1), 2*len(long))
+		// last identifier, and the text in both directions
+		require.Equal(t, 3, dictionarySize(t, db))
+
+		r, err := db.QueryRow(`SELECT b, c FROM test WHERE a = 1`)
+		require.NoError(t, err)
+		var b, c string
+		require.NoError(t, r.Scan(&b, &c))
+		require.Equal(t, long, b)
+		require.Equal(t, long, c)
+
+		// the dictionary is deleted with the table
+		require.NoError(t, db.Exec(`DROP TABLE test`))
+		require.Zero(t, dictionarySize(t, db))
+
+		report, err := db.Check(context.Background())
+		require.NoError(t, err)
+		require.True(t, report.OK())
+	})
+
+	t.Run("Rollback", func(t *testing.T) {
+		db, err := chai.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		require.NoError(t, db.Exec(`CREATE TABLE test (a INT PRIMARY KEY, b TEXT DICTIONARY)`))
+
+		conn, err := db.Connect()
+		require.NoError(t, err)
+		defer conn.Close()
+		tx, err := conn.Begin(true)
+		require.NoError(t, err)
+		require.NoError(t, tx.Exec(`INSERT INTO test (a, b) VALUES (1, 'foo')`))
+		require.NoError(t, tx.Rollback())
+		require.Zero(t, dictionarySize(t, db))
+
+		// identifiers are assigned again
+		require.NoError(t, db.Exec(`INSERT INTO test (a, b) VALUES (1, 'bar'), (2, 'foo'), (3, 'bar')`))
+		res, err := conn.Query(`SELECT b FROM test ORDER BY a`)
+		require.NoError(t, err)
+		defer res.Close()
+
+		var got []string
+		err = res.Iterate(func(r *chai.Row) error {
+			var b string
+			if err := r.Scan(&b); err != nil {
+				return err
+			}
+			got = append(got, b)
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{"bar", "foo", "bar"}, got)
+	})
+}
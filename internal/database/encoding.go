@@ -1,8 +1,11 @@
 package database
 
 import (
+	"time"
+
 	"github.com/chaisql/chai/internal/encoding"
 	"github.com/chaisql/chai/internal/row"
+	"github.com/chaisql/chai/internal/tree"
 	"github.com/chaisql/chai/internal/types"
 	"github.com/cockroachdb/errors"
 )
@@ -10,16 +13,19 @@ import (
 // EncodeRow validates a row against all the constraints of the table
 // and encodes it.
 func (t *TableInfo) EncodeRow(tx *Transaction, dst []byte, r row.Row) ([]byte, error) {
+	// rows encoded with an older row format are upgraded
 	if ed, ok := RowIsEncoded(r, &t.ColumnConstraints); ok {
-		return ed.encoded, nil
+		return upgradeRow(&t.ColumnConstraints, ed.encoded)
 	}
 
-	return encodeRow(tx, dst, &t.ColumnConstraints, r)
+	return encodeRow(tx, dst, t, r)
 }
 
-func encodeRow(tx *Transaction, dst []byte, ccs *ColumnConstraints, r row.Row) ([]byte, error) {
+func encodeRow(tx *Transaction, dst []byte, t *TableInfo, r row.Row) ([]byte, error) {
+	dst = encodeRowHeader(dst)
+
 	// loop over all the defined column contraints in order.
-	for _, cc := range ccs.Ordered {
+	for _, cc := range t.ColumnConstraints.Ordered {
 
 		// get the column from the row
 		v, err := r.Get(cc.Column)
@@ -47,12 +53,42 @@ func encodeRow(tx *Transaction, dst []byte, ccs *ColumnConstraints, r row.Row) (
 			return nil, &ConstraintViolationError{Constraint: "NOT NULL", Columns: []string{cc.Column}}
 		}
 
-		// ensure the value is of the correct type
-		v, err = v.CastAs(cc.Type)
+		// ensure the value is of the correct type.
+		// texts converted to TIMESTAMPTZ are parsed in the time zone of the transaction.
+		var loc *time.Location
+		if tx != nil {
+			loc = tx.TimeZone
+		}
+		v, err = types.CastIn(v, cc.Type, loc)
+		if err != nil {
+			return nil, err
+		}
+
+		// the texts of VARCHAR(n) and CHAR(n) columns must fit their length
+		v, err = cc.FitLength(v)
+		if err != nil {
+			return nil, err
+		}
+
+		// FINITE columns reject NaN and infinities
+		err = cc.CheckFinite(v)
 		if err != nil {
 			return nil, err
 		}
 
+		// the texts of dictionary columns are replaced by a reference
+		if cc.Dictionary && tx != nil && v.Type() == types.TypeText {
+			id := dictionaryID{namespace: t.StoreNamespace, column: cc.Column}
+			ref, ok, err := tx.dictionaryRef(id, types.AsString(v))
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				dst = encoding.EncodeDictionaryRef(dst, ref)
+				continue
+			}
+		}
+
 		dst, err = v.Encode(dst)
 		if err != nil {
 			return nil, err
@@ -63,8 +99,15 @@ func encodeRow(tx *Transaction, dst []byte, ccs *ColumnConstraints, r row.Row) (
 }
 
 type EncodedRow struct {
-	encoded           []byte
+	encoded []byte
+	// values of the row, upgraded to the current row format.
+	// They are decoded the first time the row is read.
+	values            []byte
 	columnConstraints *ColumnConstraints
+	// transaction used to read the values stored outside of the row.
+	tx *Transaction
+	// namespace of the table, which identifies its dictionaries.
+	namespace tree.Namespace
 }
 
 func NewEncodedRow(ccs *ColumnConstraints, data []byte) *EncodedRow {
@@ -76,14 +119,63 @@ func NewEncodedRow(ccs *ColumnConstraints, data []byte) *EncodedRow {
 	return &e
 }
 
-func (e *EncodedRow) ResetWith(ccs *ColumnConstraints, data []byte) {
-	e.columnConstraints = ccs
+func newEncodedRowWithTx(tx *Transaction, info *TableInfo, data []byte) *EncodedRow {
+	e := NewEncodedRow(&info.ColumnConstraints, data)
+	e.tx = tx
+	e.namespace = info.StoreNamespace
+	return e
+}
+
+// ResetWith resets the row of the given table with the given data.
+// The transaction is used to read the values stored outside of the row.
+func (e *EncodedRow) ResetWith(tx *Transaction, info *TableInfo, data []byte) {
+	e.tx = tx
+	e.columnConstraints = &info.ColumnConstraints
+	e.namespace = info.StoreNamespace
+	e.reset(data)
+}
+
+func (e *EncodedRow) reset(data []byte) {
 	e.encoded = data
+	e.values = nil
+}
+
+// decodeValues returns the values of the row, upgrading them
+// if the row was encoded with an older row format.
+func (e *EncodedRow) decodeValues() ([]byte, error) {
+	if e.values == nil {
+		values, err := rowValues(e.columnConstraints, e.encoded)
+		if err != nil {
+			return nil, err
+		}
+		e.values = values
+	}
+
+	return e.values, nil
 }
 
 func (e *EncodedRow) decodeValue(fc *ColumnConstraint, b []byte) (types.Value, int, error) {
-	if b[0] == encoding.NullValue {
+	switch b[0] {
+	case encoding.NullValue:
 		return types.NewNullValue(), 1, nil
+	case encoding.BlobRefValue:
+		if e.tx == nil {
+			return nil, 0, errors.Errorf("cannot read the value of column %q stored outside of the row", fc.Column)
+		}
+
+		id, size, n := encoding.DecodeBlobRef(b)
+		return &blobValue{tx: e.tx, ref: blobRef{id: id, size: size}}, n, nil
+	case encoding.DictionaryRefValue:
+		if e.tx == nil {
+			return nil, 0, errors.Errorf("cannot read the dictionary of column %q", fc.Column)
+		}
+
+		ref, n := encoding.DecodeDictionaryRef(b)
+		s, err := e.tx.dictionaryText(dictionaryID{namespace: e.namespace, column: fc.Column}, ref)
+		if err != nil {
+			return nil, 0, err
+		}
+		return types.NewTextValue(s), n, nil
 	}
 
 	v, n := fc.Type.Def().Decode(b)
@@ -93,14 +185,17 @@ func (e *EncodedRow) decodeValue(fc *ColumnConstraint, b []byte) (types.Value, i
 
 // Get decodes the selected column from the buffer.
 func (e *EncodedRow) Get(column string) (v types.Value, err error) {
-	b := e.encoded
-
 	// get the column from the list of column constraints
 	cc, ok := e.columnConstraints.ByColumn[column]
 	if !ok {
 		return nil, errors.Wrapf(types.ErrColumnNotFound, "%s not found", column)
 	}
 
+	b, err := e.decodeValues()
+	if err != nil {
+		return nil, err
+	}
+
 	// skip all columns before the selected column
 	for i := 0; i < cc.Position; i++ {
 		n := encoding.Skip(b)
@@ -114,7 +209,10 @@ func (e *EncodedRow) Get(column string) (v types.Value, err error) {
 // Iterate decodes each columns one by one and passes them to fn
 // until the end of the row or until fn returns an error.
 func (e *EncodedRow) Iterate(fn func(column string, value types.Value) error) error {
-	b := e.encoded
+	b, err := e.decodeValues()
+	if err != nil {
+		return err
+	}
 
 	for _, fc := range e.columnConstraints.Ordered {
 		v, n, err := e.decodeValue(fc, b)
@@ -0,0 +1,38 @@
+package database
+
+import (
+	"io"
+
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/chaisql/chai/internal/engine"
+	"github.com/chaisql/chai/internal/kv"
+	"github.com/cockroachdb/errors"
+)
+
+// ExportSST writes a consistent snapshot of the database to w, in the sstable format.
+// The rollback segment and transient data are not exported.
+// The output can be loaded by a database opened with the Remote option.
+func (db *Database) ExportSST(w io.Writer) error {
+	if len(db.Locations()) > 0 {
+		return errors.Wrap(errLocations, "export")
+	}
+
+	tx, err := db.Begin(false)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	it, err := tx.Session.Iterator(&engine.IterOptions{
+		UpperBound: encoding.EncodeUint(nil, uint64(MinTransientNamespace)),
+	})
+	if err != nil {
+		return err
+	}
+	defer it.Close()
+
+	return kv.WriteSST(w, it, func(k []byte) bool {
+		ns, _ := encoding.DecodeInt(k)
+		return ns == int64(RollbackSegmentNamespace)
+	})
+}
@@ -0,0 +1,138 @@
+package database
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/chaisql/chai/internal/row"
+	"github.com/chaisql/chai/internal/tree"
+)
+
+// A WriteEvent describes a modification of a row that is about to be applied.
+type WriteEvent struct {
+	Op    ChangeOp
+	Table string
+	// Primary key of the row.
+	Key *tree.Key
+	// Row before the modification. Nil for inserts.
+	Old Row
+	// Row after the modification. Nil for deletes.
+	New Row
+}
+
+// A WriteHook is called before a row of a table is inserted, replaced or deleted,
+// within the transaction that modifies it. Returning an error vetoes the modification:
+// the row is left untouched and the statement fails with that error.
+// The rows of the event are only valid until the hook returns.
+type WriteHook func(tx *Transaction, e *WriteEvent) error
+
+// writeHooks stores the write hooks registered per table.
+type writeHooks struct {
+	mu sync.RWMutex
+	// identifier of the next hook, used to remove it.
+	next  int
+	hooks map[string][]registeredWriteHook
+}
+
+type registeredWriteHook struct {
+	id   int
+	hook WriteHook
+}
+
+// get returns the hooks registered for the table.
+// The returned slice must not be modified.
+func (w *writeHooks) get(tableName string) []registeredWriteHook {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	return w.hooks[tableName]
+}
+
+func (w *writeHooks) add(tableName string, h WriteHook) func() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.hooks == nil {
+		w.hooks = make(map[string][]registeredWriteHook)
+	}
+
+	w.next++
+	id := w.next
+
+	// copy the list, readers may be using the previous one
+	list := w.hooks[tableName]
+	newList := make([]registeredWriteHook, 0, len(list)+1)
+	newList = append(newList, list...)
+	w.hooks[tableName] = append(newList, registeredWriteHook{id: id, hook: h})
+
+	return func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+
+		list := w.hooks[tableName]
+		for i, rh := range list {
+			if rh.id != id {
+				continue
+			}
+
+			newList := make([]registeredWriteHook, 0, len(list)-1)
+			newList = append(newList, list[:i]...)
+			newList = append(newList, list[i+1:]...)
+			if len(newList) == 0 {
+				delete(w.hooks, tableName)
+			} else {
+				w.hooks[tableName] = newList
+			}
+			return
+		}
+	}
+}
+
+// AddWriteHook registers a hook called before every modification
+// of the rows of the given table, in the order of registration.
+// The table doesn't need to exist. The returned function unregisters the hook.
+func (db *Database) AddWriteHook(tableName string, h WriteHook) (remove func()) {
+	return db.writeHooks.add(tableName, h)
+}
+
+// writeHooks returns the hooks to run when modifying the table.
+func (t *Table) writeHooks() []registeredWriteHook {
+	if t.Tx == nil || t.Tx.db == nil || strings.HasPrefix(t.Info.TableName, InternalPrefix) {
+		return nil
+	}
+
+	return t.Tx.db.writeHooks.get(t.Info.TableName)
+}
+
+// runWriteHooks runs the hooks for a modification of the row with the given key.
+// old and new are the encoded rows before and after the modification, or nil.
+func (t *Table) runWriteHooks(hooks []registeredWriteHook, op ChangeOp, key *tree.Key, old []byte, new row.Row) error {
+	e := WriteEvent{
+		Op:    op,
+		Table: t.Info.TableName,
+		Key:   key,
+	}
+	if old != nil {
+		e.Old = &BasicRow{
+			tableName: t.Info.TableName,
+			Row:       newEncodedRowWithTx(t.Tx, t.Info, old),
+			key:       key,
+		}
+	}
+	if new != nil {
+		e.New = &BasicRow{
+			tableName: t.Info.TableName,
+			Row:       new,
+			key:       key,
+		}
+	}
+
+	for _, rh := range hooks {
+		err := rh.hook(t.Tx, &e)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
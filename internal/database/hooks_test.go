@@ -0,0 +1,88 @@
+package database_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/chaisql/chai"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteHooks(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE test (a INT PRIMARY KEY, b INT);
+		CREATE TABLE counts (name TEXT PRIMARY KEY, n INT);
+		INSERT INTO counts (name, n) VALUES ('test', 0);
+	`)
+	require.NoError(t, err)
+
+	count := func(t *testing.T) int {
+		t.Helper()
+
+		r, err := db.QueryRow(`SELECT n FROM counts WHERE name = 'test'`)
+		require.NoError(t, err)
+		var n int
+		require.NoError(t, r.Scan(&n))
+		return n
+	}
+
+	errNegative := errors.New("b must be positive")
+
+	var events []string
+	remove := db.OnWrite("test", func(tx *chai.Tx, e *chai.WriteEvent) error {
+		var old, new int
+		if e.Old != nil {
+			require.NoError(t, e.Old.ScanColumn("b", &old))
+		}
+		if e.New != nil {
+			require.NoError(t, e.New.ScanColumn("b", &new))
+			if new < 0 {
+				return errNegative
+			}
+		}
+		events = append(events, e.Op.String())
+
+		switch e.Op {
+		case chai.ChangeInsert:
+			return tx.Exec(`UPDATE counts SET n = n + 1 WHERE name = 'test'`)
+		case chai.ChangeDelete:
+			return tx.Exec(`UPDATE counts SET n = n - 1 WHERE name = 'test'`)
+		}
+		return nil
+	})
+
+	require.NoError(t, db.Exec(`INSERT INTO test (a, b) VALUES (1, 1), (2, 2), (3, 3)`))
+	require.Equal(t, 3, count(t))
+
+	// hooks are not called for rows that can't be inserted
+	err = db.Exec(`INSERT INTO test (a, b) VALUES (1, 1)`)
+	require.Error(t, err)
+	require.Equal(t, 3, count(t))
+
+	// veto
+	err = db.Exec(`INSERT INTO test (a, b) VALUES (4, -1)`)
+	require.ErrorIs(t, err, errNegative)
+	err = db.Exec(`UPDATE test SET b = -1 WHERE a = 1`)
+	require.ErrorIs(t, err, errNegative)
+	r, err := db.QueryRow(`SELECT COUNT(*) FROM test WHERE b < 0`)
+	require.NoError(t, err)
+	var n int
+	require.NoError(t, r.Scan(&n))
+	require.Zero(t, n)
+
+	require.NoError(t, db.Exec(`UPDATE test SET b = 10 WHERE a = 1`))
+	require.NoError(t, db.Exec(`DELETE FROM test WHERE a = 2`))
+	// range deletion
+	require.NoError(t, db.Exec(`DELETE FROM test WHERE a >= 3`))
+	require.Equal(t, 1, count(t))
+
+	require.Equal(t, []string{"INSERT", "INSERT", "INSERT", "UPDATE", "DELETE", "DELETE"}, events)
+
+	remove()
+	require.NoError(t, db.Exec(`INSERT INTO test (a, b) VALUES (5, -5)`))
+	require.Equal(t, 1, count(t))
+}
@@ -75,11 +75,19 @@ func (idx *Index) Exists(vs []types.Value) (bool, *tree.Key, error) {
 	}
 
 	seek := tree.NewKey(vs...)
+	rng := tree.Range{Min: seek, Max: seek}
+
+	// the keys of the index contain the primary key of the rows: concurrent
+	// transactions inserting the same values would not modify the same keys
+	err := idx.Tree.ReadForConstraint(&rng)
+	if err != nil {
+		return false, nil, err
+	}
 
 	var found bool
 	var dKey *tree.Key
 
-	err := idx.Tree.IterateOnRange(&tree.Range{Min: seek, Max: seek}, false, func(k *tree.Key, _ []byte) error {
+	err = idx.Tree.IterateOnRange(&rng, false, func(k *tree.Key, _ []byte) error {
 		values, err := k.Decode()
 		if err != nil {
 			return err
@@ -29,7 +29,7 @@ func getIndex(t testing.TB, arity int) *database.Index {
 
 	session := st.NewBatchSession()
 
-	tr := tree.New(session, 10, 0)
+	tr := tree.New(session, 10, nil)
 
 	var columns []string
 	for i := 0; i < arity; i++ {
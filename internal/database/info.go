@@ -7,6 +7,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/chaisql/chai/internal/row"
 	"github.com/chaisql/chai/internal/stringutil"
 	"github.com/chaisql/chai/internal/tree"
 	"github.com/chaisql/chai/internal/types"
@@ -24,6 +25,10 @@ type TableInfo struct {
 	// Name of the rowid sequence if any.
 	RowidSequenceName string
 
+	// Name of the storage location of the table and its indexes,
+	// empty for the main location.
+	Location string
+
 	ColumnConstraints ColumnConstraints
 	TableConstraints  TableConstraints
 
@@ -167,7 +172,7 @@ func (ti *TableInfo) BuildPrimaryKey() {
 
 func (ti *TableInfo) PrimaryKeySortOrder() tree.SortOrder {
 	if ti.PrimaryKey == nil {
-		return 0
+		return nil
 	}
 
 	return ti.PrimaryKey.SortOrder
@@ -213,6 +218,10 @@ func (ti *TableInfo) String() string {
 
 	s.WriteString(")")
 
+	if ti.Location != "" {
+		fmt.Fprintf(&s, " WITH (location = %s)", types.NewTextValue(ti.Location))
+	}
+
 	return s.String()
 }
 
@@ -243,6 +252,12 @@ type IndexInfo struct {
 	IndexName      string
 	Columns        []string
 
+	// Expressions indexed instead of columns, i.e. CREATE INDEX ON tbl(JSON_EXTRACT(a, '$.b')).
+	// If set, it has the same length as Columns: a nil expression indexes the column
+	// at the same position, otherwise the column is the SQL representation
+	// of the expression.
+	Exprs []TableExpression
+
 	// Sort order of each indexed field.
 	KeySortOrder tree.SortOrder
 
@@ -274,9 +289,7 @@ func (idx *IndexInfo) String() string {
 		// Column
 		s.WriteString(p)
 
-		if idx.KeySortOrder.IsDesc(i) {
-			s.WriteString(" DESC")
-		}
+		writeSortOrder(&s, idx.KeySortOrder, i)
 	}
 
 	s.WriteString(")")
@@ -291,9 +304,43 @@ func (i IndexInfo) Clone() *IndexInfo {
 	c.Columns = make([]string, len(i.Columns))
 	copy(c.Columns, i.Columns)
 
+	if i.Exprs != nil {
+		c.Exprs = make([]TableExpression, len(i.Exprs))
+		copy(c.Exprs, i.Exprs)
+	}
+
 	return &c
 }
 
+// IsExpr returns whether the indexed value at position i is an expression.
+func (idx *IndexInfo) IsExpr(i int) bool {
+	return i < len(idx.Exprs) && idx.Exprs[i] != nil
+}
+
+// Values returns the values of the row indexed by the index.
+// Missing columns are indexed as NULL.
+func (idx *IndexInfo) Values(tx *Transaction, r row.Row) ([]types.Value, error) {
+	vs := make([]types.Value, 0, len(idx.Columns)+1)
+	for i, column := range idx.Columns {
+		if idx.IsExpr(i) {
+			v, err := idx.Exprs[i].Eval(tx, r)
+			if err != nil {
+				return nil, err
+			}
+			vs = append(vs, v)
+			continue
+		}
+
+		v, err := r.Get(column)
+		if err != nil {
+			v = types.NewNullValue()
+		}
+		vs = append(vs, v)
+	}
+
+	return vs, nil
+}
+
 // SequenceInfo holds the configuration of a sequence.
 type SequenceInfo struct {
 	Name        string
@@ -355,3 +402,19 @@ type Owner struct {
 	TableName string
 	Columns   []string
 }
+
+// writeSortOrder writes the sort order of the i-th column of a key,
+// omitting the default ones.
+func writeSortOrder(sb *strings.Builder, order tree.SortOrder, i int) {
+	if order.IsDesc(i) {
+		sb.WriteString(" DESC")
+	}
+
+	if !order.HasDefaultNulls(i) {
+		if order.IsNullsFirst(i) {
+			sb.WriteString(" NULLS FIRST")
+		} else {
+			sb.WriteString(" NULLS LAST")
+		}
+	}
+}
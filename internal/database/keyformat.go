@@ -0,0 +1,233 @@
+package database
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/chaisql/chai/internal/engine"
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// The keys of the tables and the indexes are encoded with
+// types.EncodeValueAsKey and their encoding is versioned separately
+// from the rows: the version of the key format is stored under
+// keyFormatKey, in the metadata namespace. Databases created before
+// the key format was versioned use the version 0.
+//
+// Unlike the rows, keys cannot be upgraded lazily: a lookup must find
+// the key with its current encoding. Changing the encoding of the keys
+// requires incrementing the version and upgrading the keys of the
+// previous versions in upgradeKeys, which runs when the database is
+// opened in read-write mode. Databases using an older version cannot
+// be opened in read-only mode.
+//
+// Version 1 gave a single encoding to the NaN floating-point numbers,
+// see encoding.EncodeFloat64. The NaN values with the sign bit set,
+// such as the ones produced by arithmetic operations, were encoded
+// after their bits and are upgraded. The other ones, and -0, were
+// already decoded as other numbers and keep their encoding.
+const currentKeyFormat = 1
+
+var keyFormatKey = encoding.EncodeText(encoding.EncodeUint(nil, uint64(MetadataNamespace)), "key_format")
+
+// readKeyFormat returns the version of the key format used by the database.
+func readKeyFormat(s engine.Session) (uint64, error) {
+	v, err := s.Get(keyFormatKey)
+	if errors.Is(err, engine.ErrKeyNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	version, n := binary.Uvarint(v)
+	if n <= 0 {
+		return 0, errors.New("invalid key format version")
+	}
+
+	return version, nil
+}
+
+// checkKeyFormat returns an error if the keys of the database
+// don't use the current key format.
+func checkKeyFormat(s engine.Session) error {
+	version, err := readKeyFormat(s)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case version > currentKeyFormat:
+		return errors.Errorf("unsupported key format version %d", version)
+	case version < currentKeyFormat:
+		return errors.Errorf("the keys use the format version %d and must be upgraded by opening the database in read-write mode", version)
+	}
+
+	return nil
+}
+
+// upgradeKeys upgrades the keys of the tables and of the indexes encoded with
+// an older version of the key format, and stores the current version.
+// The indexes of a table whose keys are upgraded are rebuilt, since their
+// entries contain the keys of the table.
+func upgradeKeys(tx *Transaction) error {
+	version, err := readKeyFormat(tx.Session)
+	if err != nil {
+		return err
+	}
+	if version == currentKeyFormat {
+		return nil
+	}
+	if version > currentKeyFormat {
+		return errors.Errorf("unsupported key format version %d", version)
+	}
+
+	for _, tableName := range tx.Catalog.Cache.ListObjects(RelationTableType) {
+		tb, err := tx.Catalog.GetTable(tx, tableName)
+		if err != nil {
+			return err
+		}
+
+		n, err := upgradeTreeKeys(tb.Tree)
+		if err != nil {
+			return errors.Wrapf(err, "failed to upgrade the keys of table %s", tableName)
+		}
+		if n > 0 {
+			err = rebuildIndexes(tx, tb)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		for _, indexName := range tx.Catalog.ListIndexes(tableName) {
+			idx, err := tx.Catalog.GetIndex(tx, indexName)
+			if err != nil {
+				return err
+			}
+
+			_, err = upgradeTreeKeys(idx.Tree)
+			if err != nil {
+				return errors.Wrapf(err, "failed to upgrade the keys of index %s", indexName)
+			}
+		}
+	}
+
+	return tx.Session.Put(keyFormatKey, binary.AppendUvarint(nil, currentKeyFormat))
+}
+
+// upgradeTreeKeys reencodes the keys of the tree that don't use the current
+// key format and returns their number. Two keys that only differed by
+// their encoding cannot both be upgraded, which returns an error.
+func upgradeTreeKeys(t *tree.Tree) (int, error) {
+	type upgrade struct {
+		old   *tree.Key
+		key   *tree.Key
+		value []byte
+	}
+
+	// the keys are rewritten once the iteration is over
+	var upgrades []upgrade
+	err := t.IterateOnRange(nil, false, func(k *tree.Key, v []byte) error {
+		old := tree.NewEncodedKey(bytes.Clone(k.Encoded))
+		vs, err := old.Decode()
+		if err != nil {
+			return err
+		}
+		if !hasNaN(vs) {
+			return nil
+		}
+
+		key := tree.NewKey(vs...)
+		enc, err := key.Encode(t.Namespace, t.Order)
+		if err != nil {
+			return err
+		}
+		if bytes.Equal(enc, old.Encoded) {
+			return nil
+		}
+		upgrades = append(upgrades, upgrade{old: old, key: key, value: bytes.Clone(v)})
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, u := range upgrades {
+		err = t.Delete(u.old)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	for _, u := range upgrades {
+		err = t.Insert(u.key, u.value)
+		if err != nil {
+			return 0, errors.Wrapf(err, "key %s", u.key)
+		}
+	}
+
+	return len(upgrades), nil
+}
+
+// hasNaN returns whether one of the values is a NaN floating-point number.
+func hasNaN(vs []types.Value) bool {
+	for _, v := range vs {
+		switch v.Type() {
+		case types.TypeDouble:
+			if math.IsNaN(types.AsFloat64(v)) {
+				return true
+			}
+		case types.TypeReal:
+			if math.IsNaN(float64(types.AsFloat32(v))) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// rebuildIndexes replaces the entries of the indexes of the table
+// by the ones of its rows.
+func rebuildIndexes(tx *Transaction, tb *Table) error {
+	for _, indexName := range tx.Catalog.ListIndexes(tb.Info.TableName) {
+		info, err := tx.Catalog.GetIndexInfo(indexName)
+		if err != nil {
+			return err
+		}
+
+		idx, err := tx.Catalog.GetIndex(tx, indexName)
+		if err != nil {
+			return err
+		}
+
+		err = idx.Truncate()
+		if err != nil {
+			return err
+		}
+
+		err = tb.IterateOnRange(nil, false, func(key *tree.Key, r Row) error {
+			vs, err := info.Values(tx, r)
+			if err != nil {
+				return err
+			}
+
+			encKey, err := tb.Info.EncodeKey(key)
+			if err != nil {
+				return err
+			}
+
+			return idx.Set(vs, encKey)
+		})
+		if err != nil {
+			return errors.Wrapf(err, "failed to rebuild index %s", indexName)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,124 @@
+package database_test
+
+import (
+	"context"
+	"encoding/binary"
+	"math"
+	"path/filepath"
+	"testing"
+
+	"github.com/chaisql/chai"
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyFormatUpgrade(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "db")
+
+	// before the key format was versioned, the NaN values with
+	// the sign bit set were encoded after their bits
+	legacyDoubleNaN := func(dst []byte) []byte {
+		dst = append(dst, byte(encoding.Float64Value))
+		return binary.BigEndian.AppendUint64(dst, 0xFFF8000000000000^(1<<64-1))
+	}
+	legacyRealNaN := func(dst []byte) []byte {
+		dst = append(dst, byte(encoding.Float32Value))
+		return binary.BigEndian.AppendUint32(dst, 0xFFC00000^(1<<32-1))
+	}
+
+	count := func(t *testing.T, db *chai.DB, q string, args ...any) int {
+		t.Helper()
+
+		r, err := db.QueryRow(q, args...)
+		require.NoError(t, err)
+		var n int
+		require.NoError(t, r.Scan(&n))
+		return n
+	}
+
+	db, err := chai.Open(path)
+	require.NoError(t, err)
+
+	err = db.Exec(`
+		CREATE TABLE test (a DOUBLE PRIMARY KEY, b INT);
+		CREATE INDEX test_b_idx ON test (b);
+		CREATE TABLE other (a INT PRIMARY KEY, b REAL);
+		CREATE INDEX other_b_idx ON other (b);
+		INSERT INTO test VALUES (CAST('NaN' AS DOUBLE), 10), (1.5, 20);
+		INSERT INTO other VALUES (1, CAST('NaN' AS REAL)), (2, 1.5);
+	`)
+	require.NoError(t, err)
+
+	// rewrite the keys as they were stored by a database of version 0
+	tx, err := db.DB.Begin(true)
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	tb, err := tx.Catalog.GetTable(tx, "test")
+	require.NoError(t, err)
+	key := tree.NewKey(types.NewDoubleValue(math.NaN()))
+	enc, err := tb.Tree.Get(key)
+	require.NoError(t, err)
+	enc = append([]byte(nil), enc...)
+	require.NoError(t, tb.Tree.Delete(key))
+	legacyKey := legacyDoubleNaN(encoding.EncodeUint(nil, uint64(tb.Info.StoreNamespace)))
+	require.NoError(t, tb.Tree.Put(tree.NewEncodedKey(legacyKey), enc))
+
+	idx, err := tx.Catalog.GetIndex(tx, "test_b_idx")
+	require.NoError(t, err)
+	require.NoError(t, idx.Truncate())
+	require.NoError(t, idx.Set([]types.Value{types.NewIntegerValue(10)}, legacyKey))
+	pk, err := tb.Info.EncodeKey(tree.NewKey(types.NewDoubleValue(1.5)))
+	require.NoError(t, err)
+	require.NoError(t, idx.Set([]types.Value{types.NewIntegerValue(20)}, pk))
+
+	tb, err = tx.Catalog.GetTable(tx, "other")
+	require.NoError(t, err)
+	idx, err = tx.Catalog.GetIndex(tx, "other_b_idx")
+	require.NoError(t, err)
+	require.NoError(t, idx.Truncate())
+	pk, err = tb.Info.EncodeKey(tree.NewKey(types.NewIntegerValue(1)))
+	require.NoError(t, err)
+	legacyKey = legacyRealNaN(encoding.EncodeUint(nil, uint64(idx.Tree.Namespace)))
+	legacyKey, err = types.EncodeValueAsKey(legacyKey, types.NewBlobValue(pk), false)
+	require.NoError(t, err)
+	require.NoError(t, idx.Tree.Put(tree.NewEncodedKey(legacyKey), nil))
+	pk, err = tb.Info.EncodeKey(tree.NewKey(types.NewIntegerValue(2)))
+	require.NoError(t, err)
+	require.NoError(t, idx.Set([]types.Value{types.NewRealValue(1.5)}, pk))
+
+	// databases of version 0 don't have a key format
+	require.NoError(t, tx.Session.DeleteRange(database.MetadataNamespace.Bounds()))
+	require.NoError(t, tx.Commit())
+
+	// the legacy keys cannot be found
+	require.Zero(t, count(t, db, `SELECT COUNT(*) FROM test WHERE a = ?`, math.NaN()))
+	require.Zero(t, count(t, db, `SELECT COUNT(*) FROM other WHERE b = ?`, math.NaN()))
+	require.NoError(t, db.Close())
+
+	// the keys can only be upgraded in read-write mode
+	_, err = chai.OpenReadOnly(path)
+	require.ErrorContains(t, err, "read-write mode")
+
+	db, err = chai.Open(path)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	// once upgraded, the database can be opened in read-only mode
+	db, err = chai.OpenReadOnly(path)
+	require.NoError(t, err)
+	defer db.Close()
+
+	require.Equal(t, 1, count(t, db, `SELECT COUNT(*) FROM test WHERE a = ?`, math.NaN()))
+	require.Equal(t, 1, count(t, db, `SELECT COUNT(*) FROM test WHERE b = 10`))
+	require.Equal(t, 1, count(t, db, `SELECT COUNT(*) FROM test WHERE b = 20`))
+	require.Equal(t, 1, count(t, db, `SELECT COUNT(*) FROM other WHERE b = ?`, math.NaN()))
+	require.Equal(t, 1, count(t, db, `SELECT COUNT(*) FROM other WHERE b = 1.5`))
+
+	report, err := db.DB.Check(context.Background())
+	require.NoError(t, err)
+	require.True(t, report.OK(), report.Problems)
+}
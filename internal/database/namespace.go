@@ -0,0 +1,269 @@
+package database
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/chaisql/chai/internal/engine"
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// The namespace registry records the store namespaces that are not owned
+// by a table or an index: the namespaces reserved by the user, and the
+// namespaces freed when a table or an index is dropped.
+// Each entry is keyed by the namespace and its value is the state
+// of the namespace, followed by the name of its owner for reserved ones.
+// Freed namespaces are reused by the next allocation, before
+// generating new ones from the store sequence.
+const (
+	namespaceFree     byte = 'f'
+	namespaceReserved byte = 'r'
+)
+
+// Owner types of a namespace.
+const (
+	NamespaceSystemType   = "system"
+	NamespaceTableType    = RelationTableType
+	NamespaceIndexType    = RelationIndexType
+	NamespaceReservedType = "reserved"
+)
+
+// NamespaceInfo describes an allocated namespace.
+type NamespaceInfo struct {
+	Namespace tree.Namespace
+	// Type of the owner of the namespace.
+	Type string
+	// Name of the owner of the namespace.
+	Owner string
+}
+
+func namespaceRegistry(tx *Transaction) *tree.Tree {
+	return tree.New(tx.Session, NamespaceRegistryNamespace, nil)
+}
+
+func namespaceKey(ns tree.Namespace) *tree.Key {
+	return tree.NewKey(types.NewBigintValue(int64(ns)))
+}
+
+// iterateNamespaceRegistry calls fn for every entry of the registry,
+// in ascending order.
+func iterateNamespaceRegistry(tx *Transaction, fn func(ns tree.Namespace, state byte, owner string) error) error {
+	return namespaceRegistry(tx).IterateOnRange(nil, false, func(k *tree.Key, v []byte) error {
+		vs, err := k.Decode()
+		if err != nil {
+			return err
+		}
+		if len(vs) != 1 || !vs[0].Type().IsInteger() || len(v) == 0 {
+			return errors.New("invalid namespace registry entry")
+		}
+
+		return fn(tree.Namespace(types.AsInt64(vs[0])), v[0], string(v[1:]))
+	})
+}
+
+// allocateNamespace returns a namespace that is not used by any object,
+// stored in the given storage location, or in the main one if empty.
+// It reuses the namespaces freed by previous drops if any, provided
+// they are stored in the same location: concurrent read transactions
+// may still be reading them.
+func (c *CatalogWriter) allocateNamespace(tx *Transaction, location string) (tree.Namespace, error) {
+	var free tree.Namespace
+	err := iterateNamespaceRegistry(tx, func(ns tree.Namespace, state byte, owner string) error {
+		if state == namespaceFree && tx.db.location(ns) == location {
+			free = ns
+			return errStop
+		}
+
+		return nil
+	})
+	if err != nil && !errors.Is(err, errStop) {
+		return 0, err
+	}
+
+	if free != 0 {
+		err = namespaceRegistry(tx).Delete(namespaceKey(free))
+		if err != nil {
+			return 0, err
+		}
+
+		return free, nil
+	}
+
+	seq, err := c.Catalog.GetSequence(StoreSequence)
+	if err != nil {
+		return 0, err
+	}
+	v, err := seq.Next(tx)
+	if err != nil {
+		return 0, err
+	}
+
+	// the namespace may have been placed by a transaction that was rolled back
+	err = tx.db.place(tree.Namespace(v), location)
+	if err != nil {
+		return 0, err
+	}
+
+	return tree.Namespace(v), nil
+}
+
+// freeNamespace deletes the content of the namespace and
+// makes it available for future allocations.
+func (c *CatalogWriter) freeNamespace(tx *Transaction, ns tree.Namespace) error {
+	err := tree.New(tx.Session, ns, nil).Truncate()
+	if err != nil {
+		return err
+	}
+
+	return namespaceRegistry(tx).Put(namespaceKey(ns), []byte{namespaceFree})
+}
+
+// ReserveNamespace allocates a namespace for the given owner.
+// The namespace is not used by any table or index until it is released.
+func (c *CatalogWriter) ReserveNamespace(tx *Transaction, owner string) (tree.Namespace, error) {
+	if owner == "" {
+		return 0, errors.New("namespace owner required")
+	}
+
+	ns, err := c.allocateNamespace(tx, "")
+	if err != nil {
+		return 0, err
+	}
+
+	err = namespaceRegistry(tx).Put(namespaceKey(ns), append([]byte{namespaceReserved}, owner...))
+	if err != nil {
+		return 0, err
+	}
+
+	return ns, nil
+}
+
+// ReleaseNamespace deletes the content of a namespace reserved with ReserveNamespace
+// and makes it available for future allocations.
+func (c *CatalogWriter) ReleaseNamespace(tx *Transaction, ns tree.Namespace) error {
+	v, err := namespaceRegistry(tx).Get(namespaceKey(ns))
+	if errors.Is(err, engine.ErrKeyNotFound) || (err == nil && v[0] != namespaceReserved) {
+		return errors.Errorf("namespace %d is not reserved", ns)
+	}
+	if err != nil {
+		return err
+	}
+
+	return c.freeNamespace(tx, ns)
+}
+
+// ListNamespaces returns the namespaces used by the system, the tables,
+// the indexes and the reserved namespaces, sorted by namespace.
+// Freed namespaces are not returned.
+func (c *Catalog) ListNamespaces(tx *Transaction) ([]NamespaceInfo, error) {
+	list := []NamespaceInfo{
+		{Namespace: MetadataNamespace, Type: NamespaceSystemType, Owner: "metadata"},
+		{Namespace: CatalogTableNamespace, Type: NamespaceSystemType, Owner: CatalogTableName},
+		{Namespace: SequenceTableNamespace, Type: NamespaceSystemType, Owner: SequenceTableName},
+		{Namespace: RollbackSegmentNamespace, Type: NamespaceSystemType, Owner: "rollback segment"},
+		{Namespace: ChangesNamespace, Type: NamespaceSystemType, Owner: "changes"},
+		{Namespace: NamespaceRegistryNamespace, Type: NamespaceSystemType, Owner: "namespace registry"},
+		{Namespace: ChangeLogNamespace, Type: NamespaceSystemType, Owner: "change log"},
+		{Namespace: OverflowNamespace, Type: NamespaceSystemType, Owner: "overflow values"},
+		{Namespace: PlacementNamespace, Type: NamespaceSystemType, Owner: "storage locations"},
+		{Namespace: DictionaryNamespace, Type: NamespaceSystemType, Owner: "dictionaries"},
+	}
+
+	for _, name := range c.Cache.ListObjects(RelationTableType) {
+		ti, err := c.GetTableInfo(name)
+		if err != nil {
+			return nil, err
+		}
+
+		// the catalog tables are stored in the system namespaces
+		if ti.StoreNamespace == CatalogTableNamespace || ti.StoreNamespace == SequenceTableNamespace {
+			continue
+		}
+
+		list = append(list, NamespaceInfo{Namespace: ti.StoreNamespace, Type: NamespaceTableType, Owner: name})
+	}
+
+	for _, name := range c.Cache.ListObjects(RelationIndexType) {
+		info, err := c.GetIndexInfo(name)
+		if err != nil {
+			return nil, err
+		}
+
+		list = append(list, NamespaceInfo{Namespace: info.StoreNamespace, Type: NamespaceIndexType, Owner: name})
+	}
+
+	err := iterateNamespaceRegistry(tx, func(ns tree.Namespace, state byte, owner string) error {
+		if state == namespaceReserved {
+			list = append(list, NamespaceInfo{Namespace: ns, Type: NamespaceReservedType, Owner: owner})
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Namespace < list[j].Namespace
+	})
+
+	return list, nil
+}
+
+// TransientNamespaces allocates the namespaces of the transient trees.
+// Allocated namespaces are tracked in memory until they are released,
+// which guarantees a namespace is never used by two trees at the same time.
+// The allocator is not persisted: transient namespaces are cleaned up
+// when the database is opened.
+type TransientNamespaces struct {
+	mu   sync.Mutex
+	next tree.Namespace
+	used map[tree.Namespace]struct{}
+}
+
+func newTransientNamespaces() *TransientNamespaces {
+	return &TransientNamespaces{
+		next: MinTransientNamespace,
+		used: make(map[tree.Namespace]struct{}),
+	}
+}
+
+// Acquire returns a transient namespace that is not in use.
+func (t *TransientNamespaces) Acquire() (tree.Namespace, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.used) >= int(MaxTransientNamespace-MinTransientNamespace) {
+		return 0, errors.New("no transient namespace available")
+	}
+
+	for {
+		ns := t.next
+		t.next++
+		if t.next >= MaxTransientNamespace {
+			t.next = MinTransientNamespace
+		}
+
+		if _, ok := t.used[ns]; !ok {
+			t.used[ns] = struct{}{}
+			return ns, nil
+		}
+	}
+}
+
+// Release makes a namespace returned by Acquire available again.
+func (t *TransientNamespaces) Release(ns tree.Namespace) {
+	t.mu.Lock()
+	delete(t.used, ns)
+	t.mu.Unlock()
+}
+
+// Len returns the number of transient namespaces in use.
+func (t *TransientNamespaces) Len() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return len(t.used)
+}
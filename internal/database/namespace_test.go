@@ -0,0 +1,125 @@
+package database_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/testutil"
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNamespaces(t *testing.T) {
+	t.Run("Reuse", func(t *testing.T) {
+		db := testutil.NewTestDB(t)
+
+		var dropped tree.Namespace
+		updateCatalog(t, db, func(tx *database.Transaction, catalog *database.CatalogWriter) error {
+			require.NoError(t, catalog.CreateTable(tx, "a", nil))
+			require.NoError(t, catalog.CreateTable(tx, "b", nil))
+
+			ti, err := catalog.GetTableInfo("a")
+			require.NoError(t, err)
+			dropped = ti.StoreNamespace
+
+			return tree.New(tx.Session, dropped, nil).Put(tree.NewKey(types.NewBigintValue(1)), nil)
+		})
+
+		updateCatalog(t, db, func(tx *database.Transaction, catalog *database.CatalogWriter) error {
+			return catalog.DropTable(tx, "a")
+		})
+
+		updateCatalog(t, db, func(tx *database.Transaction, catalog *database.CatalogWriter) error {
+			require.NoError(t, catalog.CreateTable(tx, "c", nil))
+
+			ti, err := catalog.GetTableInfo("c")
+			require.NoError(t, err)
+			require.Equal(t, dropped, ti.StoreNamespace)
+
+			// the content of the dropped table must be gone
+			ok, err := tree.New(tx.Session, dropped, nil).Exists(tree.NewKey(types.NewBigintValue(1)))
+			require.NoError(t, err)
+			require.False(t, ok)
+			return nil
+		})
+
+		report, err := db.Check(context.Background())
+		require.NoError(t, err)
+		require.True(t, report.OK(), report.Problems)
+	})
+
+	t.Run("Reserve", func(t *testing.T) {
+		db := testutil.NewTestDB(t)
+
+		var ns tree.Namespace
+		updateCatalog(t, db, func(tx *database.Transaction, catalog *database.CatalogWriter) error {
+			require.NoError(t, catalog.CreateTable(tx, "a", nil))
+
+			var err error
+			ns, err = catalog.ReserveNamespace(tx, "ext")
+			require.NoError(t, err)
+
+			_, err = catalog.ReserveNamespace(tx, "")
+			require.Error(t, err)
+
+			return tree.New(tx.Session, ns, nil).Put(tree.NewKey(types.NewBigintValue(1)), nil)
+		})
+
+		tx, err := db.Begin(false)
+		require.NoError(t, err)
+		list, err := tx.Catalog.ListNamespaces(tx)
+		require.NoError(t, err)
+		require.NoError(t, tx.Rollback())
+
+		ti, err := db.Catalog().GetTableInfo("a")
+		require.NoError(t, err)
+		require.Contains(t, list, database.NamespaceInfo{Namespace: ti.StoreNamespace, Type: database.NamespaceTableType, Owner: "a"})
+		require.Contains(t, list, database.NamespaceInfo{Namespace: ns, Type: database.NamespaceReservedType, Owner: "ext"})
+		for i := 1; i < len(list); i++ {
+			require.Less(t, list[i-1].Namespace, list[i].Namespace)
+		}
+
+		// reserved namespaces are known by the integrity check
+		report, err := db.Check(context.Background())
+		require.NoError(t, err)
+		require.True(t, report.OK(), report.Problems)
+
+		updateCatalog(t, db, func(tx *database.Transaction, catalog *database.CatalogWriter) error {
+			// only reserved namespaces can be released
+			require.Error(t, catalog.ReleaseNamespace(tx, ti.StoreNamespace))
+			require.NoError(t, catalog.ReleaseNamespace(tx, ns))
+			require.Error(t, catalog.ReleaseNamespace(tx, ns))
+
+			list, err := catalog.ListNamespaces(tx)
+			require.NoError(t, err)
+			for _, info := range list {
+				require.NotEqual(t, ns, info.Namespace)
+			}
+
+			other, err := catalog.ReserveNamespace(tx, "other")
+			require.NoError(t, err)
+			require.Equal(t, ns, other)
+			return nil
+		})
+	})
+
+	t.Run("Transient", func(t *testing.T) {
+		db := testutil.NewTestDB(t)
+		catalog := db.Catalog()
+
+		tr1, cleanup1, err := catalog.NewTransientTree(db.Engine.NewTransientSession(), nil)
+		require.NoError(t, err)
+		tr2, cleanup2, err := catalog.NewTransientTree(db.Engine.NewTransientSession(), nil)
+		require.NoError(t, err)
+
+		require.NotEqual(t, tr1.Namespace, tr2.Namespace)
+		require.GreaterOrEqual(t, tr1.Namespace, database.MinTransientNamespace)
+		require.Equal(t, 2, catalog.TransientNamespaces.Len())
+
+		require.NoError(t, cleanup1())
+		require.NoError(t, cleanup2())
+		require.Zero(t, catalog.TransientNamespaces.Len())
+	})
+}
@@ -0,0 +1,84 @@
+package database
+
+import (
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/cockroachdb/errors"
+)
+
+// A placementEngine is an engine able to store namespaces in other
+// locations than its main directory, such as other disks.
+// See kv.Options.Locations.
+type placementEngine interface {
+	Locations() []string
+	Place(ns int64, location string) error
+	Location(ns int64) string
+}
+
+// Locations returns the names of the storage locations
+// in which tables can be created, sorted by name.
+func (db *Database) Locations() []string {
+	p, ok := db.Engine.(placementEngine)
+	if !ok {
+		return nil
+	}
+
+	return p.Locations()
+}
+
+// place stores the namespace in the given location,
+// or in the main location if it is empty.
+func (db *Database) place(ns tree.Namespace, location string) error {
+	p, ok := db.Engine.(placementEngine)
+	if !ok {
+		if location != "" {
+			return errors.New("storage locations are not supported by the engine")
+		}
+
+		return nil
+	}
+
+	return p.Place(int64(ns), location)
+}
+
+// location returns the location of the namespace.
+func (db *Database) location(ns tree.Namespace) string {
+	p, ok := db.Engine.(placementEngine)
+	if !ok {
+		return ""
+	}
+
+	return p.Location(int64(ns))
+}
+
+// placeCatalog stores the namespaces of the tables, and of their indexes,
+// in the location of their table. The engine doesn't persist the placement
+// of the namespaces: it must be done every time the database is opened.
+func placeCatalog(tx *Transaction) error {
+	for _, name := range tx.Catalog.Cache.ListObjects(RelationTableType) {
+		ti, err := tx.Catalog.GetTableInfo(name)
+		if err != nil {
+			return err
+		}
+		if ti.Location == "" {
+			continue
+		}
+
+		err = tx.db.place(ti.StoreNamespace, ti.Location)
+		if err != nil {
+			return errors.Wrapf(err, "cannot open table %q", name)
+		}
+
+		for _, idx := range tx.Catalog.Cache.GetTableIndexes(name) {
+			err = tx.db.place(idx.StoreNamespace, ti.Location)
+			if err != nil {
+				return errors.Wrapf(err, "cannot open index %q", idx.IndexName)
+			}
+		}
+	}
+
+	return nil
+}
+
+// errLocations is returned by the operations copying the whole
+// database, which only support databases stored in a single location.
+var errLocations = errors.New("not supported for databases with storage locations")
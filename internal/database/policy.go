@@ -0,0 +1,71 @@
+package database
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/chaisql/chai/internal/row"
+)
+
+// A RowPolicy restricts the rows of a table that queries can read, update or delete.
+// It is called every time the table is scanned, with the context of the query,
+// and returns the predicate that the rows must satisfy, or nil to allow every row.
+type RowPolicy func(ctx context.Context, tx *Transaction) (RowPredicate, error)
+
+// A RowPredicate reports whether a row satisfies a row policy.
+type RowPredicate func(r row.Row) (bool, error)
+
+// rowPolicies stores the row policies registered per table.
+type rowPolicies struct {
+	mu       sync.RWMutex
+	policies map[string]RowPolicy
+}
+
+func (p *rowPolicies) get(tableName string) RowPolicy {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.policies[tableName]
+}
+
+func (p *rowPolicies) set(tableName string, policy RowPolicy) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if policy == nil {
+		delete(p.policies, tableName)
+		return
+	}
+
+	if p.policies == nil {
+		p.policies = make(map[string]RowPolicy)
+	}
+	p.policies[tableName] = policy
+}
+
+// SetRowPolicy sets the row policy of the given table, replacing the previous one.
+// A nil policy removes it. The table doesn't need to exist.
+// Internal operations, such as building indexes, ignore the policies.
+func (db *Database) SetRowPolicy(tableName string, policy RowPolicy) {
+	db.rowPolicies.set(tableName, policy)
+}
+
+// RowPredicate returns the predicate of the row policy of the table,
+// evaluated with the given context, or nil if the rows are not restricted.
+func (t *Table) RowPredicate(ctx context.Context) (RowPredicate, error) {
+	if t.Tx == nil || t.Tx.db == nil || strings.HasPrefix(t.Info.TableName, InternalPrefix) {
+		return nil, nil
+	}
+
+	policy := t.Tx.db.rowPolicies.get(t.Info.TableName)
+	if policy == nil {
+		return nil, nil
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	return policy(ctx, t.Tx)
+}
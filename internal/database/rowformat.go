@@ -0,0 +1,237 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/cockroachdb/errors"
+)
+
+// Rows are encoded as a header followed by the values of their columns,
+// in the order of the column constraints of their table.
+// The header is made of the RowFormatValue byte, which never starts
+// an encoded value, followed by the version of the row format.
+// Rows without a header were written before the format was versioned,
+// they use the version 0.
+//
+// Changing the encoding of the values requires incrementing the version
+// and adding a migration that upgrades the values of the previous version.
+// Rows are upgraded lazily when they are read, and stored with the
+// current version the next time they are written. They can also be
+// upgraded eagerly with MigrateRows, or in the background with
+// the RowMigrationInterval option.
+const currentRowFormat = 1
+
+// A rowMigration upgrades the values of a row
+// from one version of the row format to the next.
+type rowMigration func(ccs *ColumnConstraints, values []byte) ([]byte, error)
+
+// rowMigrations upgrade the values of the rows encoded with an older
+// version of the row format. The migration at index i upgrades the rows
+// of version i. A nil migration leaves the values unchanged.
+var rowMigrations = [currentRowFormat]rowMigration{
+	// version 1 only added the header.
+	nil,
+}
+
+// rowMigrationBatchSize is the number of rows upgraded by each
+// write transaction of the background migration.
+const rowMigrationBatchSize = 1000
+
+// rowMigrationJob is the name of the job upgrading the rows in the background.
+const rowMigrationJob = InternalPrefix + "row_migration"
+
+// encodeRowHeader appends the header of the current row format to dst.
+func encodeRowHeader(dst []byte) []byte {
+	return append(dst, encoding.RowFormatValue, currentRowFormat)
+}
+
+// decodeRowHeader returns the version of the row format of the encoded row,
+// and its values.
+func decodeRowHeader(enc []byte) (int, []byte) {
+	if len(enc) < 2 || enc[0] != encoding.RowFormatValue {
+		return 0, enc
+	}
+
+	return int(enc[1]), enc[2:]
+}
+
+// rowValues returns the values of the encoded row,
+// upgraded to the current row format.
+func rowValues(ccs *ColumnConstraints, enc []byte) ([]byte, error) {
+	version, values := decodeRowHeader(enc)
+	if version > currentRowFormat {
+		return nil, errors.Errorf("unsupported row format version %d", version)
+	}
+
+	for ; version < currentRowFormat; version++ {
+		m := rowMigrations[version]
+		if m == nil {
+			continue
+		}
+
+		var err error
+		values, err = m(ccs, values)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to upgrade row from format version %d", version)
+		}
+	}
+
+	return values, nil
+}
+
+// upgradeRow returns the encoded row using the current row format.
+// It returns enc if the row already uses it.
+func upgradeRow(ccs *ColumnConstraints, enc []byte) ([]byte, error) {
+	if version, _ := decodeRowHeader(enc); version == currentRowFormat {
+		return enc, nil
+	}
+
+	values, err := rowValues(ccs, enc)
+	if err != nil {
+		return nil, err
+	}
+
+	dst := encodeRowHeader(make([]byte, 0, len(values)+2))
+	return append(dst, values...), nil
+}
+
+// RowMigrationReport contains the result of a row migration.
+type RowMigrationReport struct {
+	// Number of rows upgraded to the current row format.
+	Rows int
+	// Whether some rows still use an older row format,
+	// because the limit was reached.
+	Remaining bool
+}
+
+// MigrateRows upgrades the rows encoded with an older version of the
+// row format, up to limit rows, or all of them if limit is zero.
+// Only the encoding of the rows changes: their values are the same,
+// which leaves the indexes untouched, and the write hooks and the change
+// log are not notified.
+func (tx *Transaction) MigrateRows(limit int) (*RowMigrationReport, error) {
+	if !tx.Writable {
+		return nil, errors.New("cannot migrate rows in a read-only transaction")
+	}
+
+	var report RowMigrationReport
+	for _, tableName := range tx.Catalog.Cache.ListObjects(RelationTableType) {
+		tb, err := tx.Catalog.GetTable(tx, tableName)
+		if err != nil {
+			return nil, err
+		}
+
+		n := -1
+		if limit > 0 {
+			n = limit - report.Rows
+		}
+		err = tb.migrateRows(n, &report)
+		if err != nil {
+			return nil, err
+		}
+		if report.Remaining {
+			break
+		}
+	}
+
+	return &report, nil
+}
+
+// migrateRows upgrades up to limit rows of the table, or all of them if limit is negative.
+func (t *Table) migrateRows(limit int, report *RowMigrationReport) error {
+	type upgrade struct {
+		key *tree.Key
+		enc []byte
+	}
+
+	// the rows are rewritten once the iteration is over
+	var upgrades []upgrade
+	err := t.Tree.IterateOnRange(nil, false, func(k *tree.Key, enc []byte) error {
+		if version, _ := decodeRowHeader(enc); version == currentRowFormat {
+			return nil
+		}
+		if limit >= 0 && len(upgrades) == limit {
+			report.Remaining = true
+			return errStop
+		}
+
+		enc, err := upgradeRow(&t.Info.ColumnConstraints, enc)
+		if err != nil {
+			return errors.Wrapf(err, "table %s, row %s", t.Info.TableName, k)
+		}
+
+		upgrades = append(upgrades, upgrade{key: tree.NewEncodedKey(bytes.Clone(k.Encoded)), enc: enc})
+		return nil
+	})
+	if err != nil && !errors.Is(err, errStop) {
+		return err
+	}
+
+	for _, u := range upgrades {
+		err = t.Tree.Put(u.key, u.enc)
+		if err != nil {
+			return err
+		}
+	}
+
+	report.Rows += len(upgrades)
+	return nil
+}
+
+// MigrateRows upgrades every row encoded with an older version of the
+// row format, in write transactions of a limited number of rows,
+// until all the rows are upgraded or the context is cancelled.
+func (db *Database) MigrateRows(ctx context.Context) (*RowMigrationReport, error) {
+	var report RowMigrationReport
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		r, err := db.migrateRowBatch()
+		if err != nil {
+			return nil, err
+		}
+
+		report.Rows += r.Rows
+		if !r.Remaining {
+			return &report, nil
+		}
+	}
+}
+
+func (db *Database) migrateRowBatch() (*RowMigrationReport, error) {
+	tx, err := db.Begin(true)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	report, err := tx.MigrateRows(rowMigrationBatchSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return report, tx.Commit()
+}
+
+// registerRowMigration registers the job upgrading the rows in the background.
+// The job unregisters itself once every row uses the current row format.
+func (db *Database) registerRowMigration(interval time.Duration) error {
+	return db.scheduler.Register(Job{
+		Name:     rowMigrationJob,
+		Interval: interval,
+		Run: func(ctx context.Context) error {
+			_, err := db.MigrateRows(ctx)
+			if err != nil {
+				return err
+			}
+
+			return db.scheduler.Unregister(rowMigrationJob)
+		},
+	})
+}
@@ -0,0 +1,174 @@
+package database_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/chaisql/chai"
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRowFormat(t *testing.T) {
+	key := func(a int) *tree.Key {
+		return tree.NewKey(types.NewIntegerValue(int32(a)))
+	}
+
+	// writes a row without header, as before the row format was versioned
+	writeLegacyRow := func(t *testing.T, db *chai.DB, a int, b string) {
+		t.Helper()
+
+		tx, err := db.DB.Begin(true)
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		tb, err := tx.Catalog.GetTable(tx, "test")
+		require.NoError(t, err)
+
+		enc, err := types.NewIntegerValue(int32(a)).Encode(nil)
+		require.NoError(t, err)
+		enc, err = types.NewTextValue(b).Encode(enc)
+		require.NoError(t, err)
+
+		require.NoError(t, tb.Tree.Put(key(a), enc))
+		require.NoError(t, tx.Commit())
+	}
+
+	readRawRow := func(t *testing.T, db *chai.DB, a int) []byte {
+		t.Helper()
+
+		tx, err := db.DB.Begin(false)
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		tb, err := tx.Catalog.GetTable(tx, "test")
+		require.NoError(t, err)
+
+		enc, err := tb.Tree.Get(key(a))
+		require.NoError(t, err)
+		return bytes.Clone(enc)
+	}
+
+	isUpgraded := func(t *testing.T, db *chai.DB, a int) bool {
+		t.Helper()
+
+		return readRawRow(t, db, a)[0] == encoding.RowFormatValue
+	}
+
+	requireValue := func(t *testing.T, db *chai.DB, a int, want string) {
+		t.Helper()
+
+		r, err := db.QueryRow(`SELECT b FROM test WHERE a = ?`, a)
+		require.NoError(t, err)
+		var b string
+		require.NoError(t, r.Scan(&b))
+		require.Equal(t, want, b)
+	}
+
+	setup := func(t *testing.T) *chai.DB {
+		t.Helper()
+
+		db, err := chai.Open(":memory:")
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+
+		require.NoError(t, db.Exec(`CREATE TABLE test (a INT PRIMARY KEY, b TEXT)`))
+		return db
+	}
+
+	t.Run("New rows", func(t *testing.T) {
+		db := setup(t)
+
+		require.NoError(t, db.Exec(`INSERT INTO test (a, b) VALUES (1, 'a')`))
+		require.True(t, isUpgraded(t, db, 1))
+		requireValue(t, db, 1, "a")
+	})
+
+	t.Run("Lazy", func(t *testing.T) {
+		db := setup(t)
+
+		writeLegacyRow(t, db, 1, "a")
+		writeLegacyRow(t, db, 2, "b")
+		requireValue(t, db, 1, "a")
+		requireValue(t, db, 2, "b")
+		require.False(t, isUpgraded(t, db, 1))
+
+		// written rows are upgraded
+		require.NoError(t, db.Exec(`UPDATE test SET b = 'c' WHERE a = 1`))
+		require.True(t, isUpgraded(t, db, 1))
+		require.False(t, isUpgraded(t, db, 2))
+		requireValue(t, db, 1, "c")
+		requireValue(t, db, 2, "b")
+	})
+
+	t.Run("MigrateRows", func(t *testing.T) {
+		db := setup(t)
+
+		writeLegacyRow(t, db, 1, "a")
+		writeLegacyRow(t, db, 2, "b")
+		writeLegacyRow(t, db, 3, "c")
+
+		tx, err := db.DB.Begin(true)
+		require.NoError(t, err)
+		defer tx.Rollback()
+		report, err := tx.MigrateRows(2)
+		require.NoError(t, err)
+		require.Equal(t, 2, report.Rows)
+		require.True(t, report.Remaining)
+		require.NoError(t, tx.Commit())
+
+		res, err := db.MigrateRows(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, 1, res.Rows)
+		require.False(t, res.Remaining)
+
+		for i, want := range []string{"a", "b", "c"} {
+			require.True(t, isUpgraded(t, db, i+1))
+			requireValue(t, db, i+1, want)
+		}
+
+		res, err = db.MigrateRows(context.Background())
+		require.NoError(t, err)
+		require.Zero(t, res.Rows)
+	})
+
+	t.Run("Background", func(t *testing.T) {
+		dir := t.TempDir()
+
+		db, err := chai.Open(dir)
+		require.NoError(t, err)
+		require.NoError(t, db.Exec(`CREATE TABLE test (a INT PRIMARY KEY, b TEXT)`))
+		writeLegacyRow(t, db, 1, "a")
+		require.NoError(t, db.Close())
+
+		db, err = chai.OpenWith(dir, &chai.Options{RowMigrationInterval: 10 * time.Millisecond})
+		require.NoError(t, err)
+		defer db.Close()
+
+		// the job unregisters itself once every row is upgraded
+		require.Eventually(t, func() bool {
+			return len(db.Scheduler().Jobs()) == 0
+		}, 5*time.Second, 10*time.Millisecond)
+		require.True(t, isUpgraded(t, db, 1))
+		requireValue(t, db, 1, "a")
+	})
+
+	t.Run("Unsupported version", func(t *testing.T) {
+		db := setup(t)
+
+		tx, err := db.DB.Begin(true)
+		require.NoError(t, err)
+		defer tx.Rollback()
+		tb, err := tx.Catalog.GetTable(tx, "test")
+		require.NoError(t, err)
+		require.NoError(t, tb.Tree.Put(key(1), []byte{encoding.RowFormatValue, 200}))
+		require.NoError(t, tx.Commit())
+
+		_, err = db.QueryRow(`SELECT b FROM test WHERE a = 1`)
+		require.ErrorContains(t, err, "unsupported row format version 200")
+	})
+}
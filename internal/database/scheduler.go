@@ -0,0 +1,330 @@
+package database
+
+import (
+	"context"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cockroachdb/errors"
+)
+
+// A Job is a task run periodically in the background by the scheduler
+// of the database, such as expiring rows or refreshing statistics.
+type Job struct {
+	// Name of the job, unique per database.
+	Name string
+	// Interval between the end of a run and the start of the next one.
+	Interval time.Duration
+	// Maximum random delay added to the interval before each run,
+	// to avoid running the jobs registered at the same time together.
+	Jitter time.Duration
+	// Run is called by the scheduler every time the job is due.
+	// The context is cancelled when the database is closed.
+	Run func(ctx context.Context) error
+}
+
+// JobInfo describes the state of a registered job.
+type JobInfo struct {
+	Name     string
+	Interval time.Duration
+	Jitter   time.Duration
+	Paused   bool
+	Running  bool
+	// Number of runs, and number of runs that returned an error.
+	Runs     int64
+	Failures int64
+	// Start, duration and error of the last run.
+	// LastRun is zero if the job never ran.
+	LastRun      time.Time
+	LastDuration time.Duration
+	LastError    string
+	// Time of the next run, zero if the job is paused.
+	NextRun time.Time
+}
+
+// Scheduler runs the jobs of the database in the background,
+// each in its own goroutine, until they are unregistered or
+// the database is closed.
+type Scheduler struct {
+	mu   sync.Mutex
+	jobs map[string]*scheduledJob
+	ctx  context.Context
+	wg   sync.WaitGroup
+}
+
+type scheduledJob struct {
+	Job
+
+	// state of the job, protected by the scheduler mutex.
+	info JobInfo
+
+	// wake asks the job to run immediately, or to reschedule itself.
+	wake chan struct{}
+	// stop is closed when the job is unregistered.
+	stop chan struct{}
+	// now is set when the job must run without waiting for its next run.
+	now bool
+}
+
+func newScheduler(ctx context.Context) *Scheduler {
+	return &Scheduler{
+		jobs: make(map[string]*scheduledJob),
+		ctx:  ctx,
+	}
+}
+
+// Register adds a job to the scheduler. Its first run happens
+// after its interval and jitter.
+func (s *Scheduler) Register(job Job) error {
+	if job.Name == "" {
+		return errors.New("job name required")
+	}
+	if job.Interval <= 0 {
+		return errors.Errorf("job %q: interval must be positive", job.Name)
+	}
+	if job.Jitter < 0 {
+		return errors.Errorf("job %q: jitter cannot be negative", job.Name)
+	}
+	if job.Run == nil {
+		return errors.Errorf("job %q: run function required", job.Name)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ctx.Err() != nil {
+		return errors.New("database is closed")
+	}
+	if _, ok := s.jobs[job.Name]; ok {
+		return errors.Errorf("job %q already registered", job.Name)
+	}
+
+	j := scheduledJob{
+		Job: job,
+		info: JobInfo{
+			Name:     job.Name,
+			Interval: job.Interval,
+			Jitter:   job.Jitter,
+		},
+		wake: make(chan struct{}, 1),
+		stop: make(chan struct{}),
+	}
+	j.info.NextRun = j.next(time.Now())
+	s.jobs[job.Name] = &j
+
+	s.wg.Add(1)
+	go s.loop(&j)
+
+	return nil
+}
+
+// Unregister removes a job from the scheduler.
+// If the job is running, the current run is not interrupted.
+func (s *Scheduler) Unregister(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	j, err := s.get(name)
+	if err != nil {
+		return err
+	}
+
+	delete(s.jobs, name)
+	close(j.stop)
+	return nil
+}
+
+// Pause stops running a job until it is resumed.
+// If the job is running, the current run is not interrupted.
+func (s *Scheduler) Pause(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	j, err := s.get(name)
+	if err != nil {
+		return err
+	}
+
+	j.info.Paused = true
+	j.info.NextRun = time.Time{}
+	j.notify()
+	return nil
+}
+
+// Resume schedules a paused job again, starting from its interval
+// and jitter.
+func (s *Scheduler) Resume(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	j, err := s.get(name)
+	if err != nil {
+		return err
+	}
+	if !j.info.Paused {
+		return nil
+	}
+
+	j.info.Paused = false
+	j.info.NextRun = j.next(time.Now())
+	j.notify()
+	return nil
+}
+
+// RunNow runs a job as soon as possible, even if it is paused.
+// If the job is running, it runs again once the current run is done.
+func (s *Scheduler) RunNow(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	j, err := s.get(name)
+	if err != nil {
+		return err
+	}
+
+	j.now = true
+	j.notify()
+	return nil
+}
+
+// Jobs returns the state of the registered jobs, sorted by name.
+func (s *Scheduler) Jobs() []JobInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list := make([]JobInfo, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		list = append(list, j.info)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+
+	return list
+}
+
+// wait blocks until every job returns, once the context
+// of the scheduler is cancelled.
+func (s *Scheduler) wait() {
+	s.wg.Wait()
+}
+
+func (s *Scheduler) get(name string) (*scheduledJob, error) {
+	j, ok := s.jobs[name]
+	if !ok {
+		return nil, errors.Errorf("job %q not found", name)
+	}
+
+	return j, nil
+}
+
+// loop runs the job every time it is due, until it is
+// unregistered or the scheduler is stopped.
+func (s *Scheduler) loop(j *scheduledJob) {
+	defer s.wg.Done()
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		s.mu.Lock()
+		due, at := j.now, j.info.NextRun
+		s.mu.Unlock()
+
+		// wait for the next run, or for the state of the job to change
+		if !due {
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+
+			var c <-chan time.Time
+			if !at.IsZero() {
+				timer.Reset(time.Until(at))
+				c = timer.C
+			}
+
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-j.stop:
+				return
+			case <-j.wake:
+				continue
+			case <-c:
+			}
+		}
+
+		if !s.run(j) {
+			return
+		}
+	}
+}
+
+// run runs the job once and schedules its next run.
+// It returns false if the job must not run anymore.
+func (s *Scheduler) run(j *scheduledJob) bool {
+	s.mu.Lock()
+	select {
+	case <-s.ctx.Done():
+		s.mu.Unlock()
+		return false
+	case <-j.stop:
+		s.mu.Unlock()
+		return false
+	default:
+	}
+	// the job may have been paused while the timer fired
+	if !j.now && j.info.Paused {
+		s.mu.Unlock()
+		return true
+	}
+	j.now = false
+	j.info.Running = true
+	s.mu.Unlock()
+
+	start := time.Now()
+	err := j.Run(s.ctx)
+	end := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	j.info.Running = false
+	j.info.Runs++
+	j.info.LastRun = start
+	j.info.LastDuration = end.Sub(start)
+	j.info.LastError = ""
+	if err != nil {
+		j.info.Failures++
+		j.info.LastError = err.Error()
+	}
+	if !j.info.Paused {
+		j.info.NextRun = j.next(end)
+	}
+
+	return true
+}
+
+// next returns the time of the next run, for a run that ended at t.
+func (j *scheduledJob) next(t time.Time) time.Time {
+	t = t.Add(j.Interval)
+	if j.Jitter > 0 {
+		t = t.Add(time.Duration(rand.Int63n(int64(j.Jitter) + 1)))
+	}
+
+	return t
+}
+
+// notify wakes the goroutine of the job up, without blocking.
+func (j *scheduledJob) notify() {
+	select {
+	case j.wake <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,126 @@
+package database_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/chaisql/chai"
+	"github.com/chaisql/chai/internal/database"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduler(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	s := db.Scheduler()
+
+	runs := make(chan struct{}, 10)
+	job := database.Job{
+		Name:     "test",
+		Interval: time.Hour,
+		Jitter:   time.Minute,
+		Run: func(ctx context.Context) error {
+			runs <- struct{}{}
+			return errors.New("boom")
+		},
+	}
+
+	before := time.Now()
+	require.NoError(t, s.Register(job))
+	require.Error(t, s.Register(job))
+	require.Error(t, s.Register(database.Job{Name: "invalid", Run: job.Run}))
+
+	jobs := s.Jobs()
+	require.Len(t, jobs, 1)
+	require.Equal(t, "test", jobs[0].Name)
+	require.True(t, jobs[0].LastRun.IsZero())
+	require.False(t, jobs[0].NextRun.Before(before.Add(time.Hour)))
+	require.False(t, jobs[0].NextRun.After(time.Now().Add(time.Hour+time.Minute)))
+
+	// wait for the run to be recorded
+	waitRuns := func(n int64) database.JobInfo {
+		t.Helper()
+
+		require.Eventually(t, func() bool {
+			return s.Jobs()[0].Runs == n && !s.Jobs()[0].Running
+		}, 5*time.Second, time.Millisecond)
+		return s.Jobs()[0]
+	}
+
+	require.NoError(t, s.RunNow("test"))
+	<-runs
+	info := waitRuns(1)
+	require.Equal(t, int64(1), info.Failures)
+	require.Equal(t, "boom", info.LastError)
+	require.False(t, info.LastRun.IsZero())
+
+	// paused jobs only run when asked to
+	require.NoError(t, s.Pause("test"))
+	info = s.Jobs()[0]
+	require.True(t, info.Paused)
+	require.True(t, info.NextRun.IsZero())
+
+	require.NoError(t, s.RunNow("test"))
+	<-runs
+	info = waitRuns(2)
+	require.True(t, info.NextRun.IsZero())
+
+	require.NoError(t, s.Resume("test"))
+	info = s.Jobs()[0]
+	require.False(t, info.Paused)
+	require.False(t, info.NextRun.IsZero())
+
+	// the jobs are listed by the virtual table
+	r, err := db.QueryRow(`SELECT name, paused, runs, failures, last_error, next_run IS NULL FROM __chai_jobs`)
+	require.NoError(t, err)
+	var name, lastError string
+	var paused, noNextRun bool
+	var n, failures int
+	require.NoError(t, r.Scan(&name, &paused, &n, &failures, &lastError, &noNextRun))
+	require.Equal(t, "test", name)
+	require.False(t, paused)
+	require.Equal(t, 2, n)
+	require.Equal(t, 2, failures)
+	require.Equal(t, "boom", lastError)
+	require.False(t, noNextRun)
+
+	err = db.Exec(`INSERT INTO __chai_jobs (name, interval, jitter, paused, running, runs, failures) VALUES ('a', '1s', '0s', false, false, 0, 0)`)
+	require.Error(t, err)
+	err = db.Exec(`CREATE TABLE __chai_jobs (a INT PRIMARY KEY)`)
+	require.Error(t, err)
+
+	require.NoError(t, s.Unregister("test"))
+	require.Error(t, s.Unregister("test"))
+	require.Empty(t, s.Jobs())
+}
+
+func TestSchedulerInterval(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+
+	runs := make(chan struct{}, 10)
+	err = db.Scheduler().Register(database.Job{
+		Name:     "test",
+		Interval: time.Millisecond,
+		Run: func(ctx context.Context) error {
+			select {
+			case runs <- struct{}{}:
+			default:
+			}
+			return nil
+		},
+	})
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		<-runs
+	}
+
+	// closing the database stops the jobs
+	require.NoError(t, db.Close())
+	require.Error(t, db.Scheduler().Register(database.Job{Name: "other", Interval: time.Second, Run: func(ctx context.Context) error { return nil }}))
+}
@@ -51,6 +51,9 @@ type Sequence struct {
 	CurrentValue *int64
 	Cached       uint64
 	Key          *tree.Key
+
+	// transaction which stored the last lease, until it is committed or rolled back.
+	leaseTx *Transaction
 }
 
 // NewSequence creates a new or existing sequence. If currentValue is not nil
@@ -109,6 +112,8 @@ func (s *Sequence) Next(tx *Transaction) (int64, error) {
 		return 0, errors.New("cannot increment sequence on read-only transaction")
 	}
 
+	tx.lockSequences()
+
 	var newValue int64
 	if s.CurrentValue == nil {
 		newValue = s.Info.Start
@@ -171,7 +176,40 @@ func (s *Sequence) Next(tx *Transaction) (int64, error) {
 	return newValue, nil
 }
 
+// SetValue sets the current value of the sequence and stores it as its lease.
+// If isCalled is true, the next call to Next returns the value following v,
+// otherwise it returns v.
+func (s *Sequence) SetValue(tx *Transaction, v int64, isCalled bool) error {
+	if !tx.Writable {
+		return errors.New("cannot set sequence on read-only transaction")
+	}
+
+	if v < s.Info.Min || v > s.Info.Max {
+		return fmt.Errorf("value %d is out of bounds for sequence %s (%d..%d)", v, s.Info.Name, s.Info.Min, s.Info.Max)
+	}
+
+	newValue := v
+	if !isCalled {
+		newValue = v - s.Info.IncrementBy
+		if (s.Info.IncrementBy > 0) != (newValue < v) {
+			return fmt.Errorf("value %d is out of bounds for sequence %s (%d..%d)", v, s.Info.Name, s.Info.Min, s.Info.Max)
+		}
+	}
+
+	err := s.SetLease(tx, s.Info.Name, newValue)
+	if err != nil {
+		return err
+	}
+
+	s.CurrentValue = &newValue
+	// the next call to Next must store a new lease
+	s.Cached = s.Info.Cache
+	return nil
+}
+
 func (s *Sequence) SetLease(tx *Transaction, name string, v int64) error {
+	tx.lockSequences()
+
 	tb, err := s.GetOrCreateTable(tx)
 	if err != nil {
 		return err
@@ -184,7 +222,25 @@ func (s *Sequence) SetLease(tx *Transaction, name string, v int64) error {
 			Add("name", types.NewTextValue(name)).
 			Add("seq", types.NewBigintValue(v)),
 	)
-	return err
+	if err != nil {
+		return err
+	}
+
+	// the values of a sequence are never reused, even if the transaction is rolled back.
+	// if it is, its lease is lost but the current value is kept in memory,
+	// and the next call to Next must store a new lease.
+	if s.leaseTx != tx {
+		s.leaseTx = tx
+		tx.OnCommitHooks = append(tx.OnCommitHooks, func() {
+			s.leaseTx = nil
+		})
+		tx.OnRollbackHooks = append(tx.OnRollbackHooks, func() {
+			s.leaseTx = nil
+			s.Cached = s.Info.Cache
+		})
+	}
+
+	return nil
 }
 
 func (s *Sequence) GetOrCreateTable(tx *Transaction) (*Table, error) {
@@ -392,4 +392,81 @@ func TestSequence(t *testing.T) {
 
 		next(seq, tx, tx.Catalog, 5, 9)
 	})
+
+	t.Run("rollback", func(t *testing.T) {
+		db := testutil.NewTestDB(t)
+
+		tx, err := db.Begin(true)
+		require.NoError(t, err)
+
+		err = tx.CatalogWriter().CreateSequence(tx, &database.SequenceInfo{
+			Name:        "a",
+			IncrementBy: 1,
+			Min:         1, Max: 20,
+			Start: 1,
+			Cache: 5,
+		})
+		require.NoError(t, err)
+		require.NoError(t, tx.Commit())
+
+		tx, err = db.Begin(true)
+		require.NoError(t, err)
+
+		seq, err := tx.Catalog.GetSequence("a")
+		require.NoError(t, err)
+
+		next(seq, tx, tx.Catalog, 1, 5)
+		next(seq, tx, tx.Catalog, 2, 5)
+		require.NoError(t, tx.Rollback())
+
+		// the values are not reused and the lease lost
+		// by the rollback must be stored again
+		tx, err = db.Begin(true)
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		next(seq, tx, tx.Catalog, 3, 7)
+		next(seq, tx, tx.Catalog, 4, 7)
+	})
+
+	t.Run("set value", func(t *testing.T) {
+		db := testutil.NewTestDB(t)
+
+		tx, err := db.Begin(true)
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		err = tx.CatalogWriter().CreateSequence(tx, &database.SequenceInfo{
+			Name:        "a",
+			IncrementBy: 2,
+			Min:         1, Max: 20,
+			Start: 1,
+			Cache: 5,
+		})
+		require.NoError(t, err)
+
+		seq, err := tx.Catalog.GetSequence("a")
+		require.NoError(t, err)
+
+		next(seq, tx, tx.Catalog, 1, 5)
+
+		err = seq.SetValue(tx, 10, true)
+		require.NoError(t, err)
+		got, err := getLease(t, tx, tx.Catalog, "a")
+		require.NoError(t, err)
+		require.Equal(t, int64(10), *got)
+		next(seq, tx, tx.Catalog, 12, 16)
+
+		err = seq.SetValue(tx, 5, false)
+		require.NoError(t, err)
+		got, err = getLease(t, tx, tx.Catalog, "a")
+		require.NoError(t, err)
+		require.Equal(t, int64(3), *got)
+		next(seq, tx, tx.Catalog, 5, 9)
+
+		err = seq.SetValue(tx, 21, true)
+		require.Error(t, err)
+		err = seq.SetValue(tx, 0, false)
+		require.Error(t, err)
+	})
 }
@@ -0,0 +1,119 @@
+package database
+
+import (
+	"sync"
+
+	"github.com/chaisql/chai/internal/engine"
+	"github.com/cockroachdb/errors"
+)
+
+// Snapshot is a stable, read-only view of the database at the time it was created.
+// Every read-only transaction begun from a snapshot sees the same data and the same
+// schema, no matter how long it runs or how many transactions are committed meanwhile.
+// Snapshots never block writers, but they prevent the engine from reclaiming the
+// space used by the data they can see: they must be closed as soon as possible.
+type Snapshot struct {
+	db      *Database
+	session engine.Session
+	catalog *Catalog
+
+	mu sync.Mutex
+	// number of users of the session: the snapshot itself
+	// and the transactions that are still running.
+	refs   int
+	closed bool
+}
+
+// Snapshot creates a snapshot of the database.
+// It must be closed before the database is closed.
+func (db *Database) Snapshot() (*Snapshot, error) {
+	if db.closeContext.Err() != nil {
+		return nil, errors.New("database is closed")
+	}
+
+	// prevent transactions from being committed while the session
+	// and the catalog are captured.
+	db.txmu.RLock()
+	defer db.txmu.RUnlock()
+
+	return &Snapshot{
+		db:      db,
+		session: db.Engine.NewSnapshotSession(),
+		catalog: db.Catalog(),
+		refs:    1,
+	}, nil
+}
+
+// Connect returns a connection whose transactions read from the snapshot.
+// Write transactions cannot be created from this connection.
+func (s *Snapshot) Connect() (*Connection, error) {
+	conn, err := s.db.Connect()
+	if err != nil {
+		return nil, err
+	}
+
+	conn.snapshot = s
+	return conn, nil
+}
+
+// Close the snapshot. Transactions that are still running
+// can keep reading from it until they are closed.
+func (s *Snapshot) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return errors.New("snapshot already closed")
+	}
+	s.closed = true
+	s.mu.Unlock()
+
+	return s.release()
+}
+
+// newSession returns a session reading from the snapshot,
+// which must be closed once the transaction is done.
+func (s *Snapshot) newSession() (engine.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil, errors.New("snapshot is closed")
+	}
+	s.refs++
+
+	return &snapshotSession{
+		Session:  s.session,
+		snapshot: s,
+	}, nil
+}
+
+func (s *Snapshot) release() error {
+	s.mu.Lock()
+	s.refs--
+	refs := s.refs
+	s.mu.Unlock()
+
+	if refs > 0 {
+		return nil
+	}
+
+	return s.session.Close()
+}
+
+// snapshotSession is the session of a transaction begun from a snapshot.
+// Closing it releases the snapshot instead of closing the shared session.
+type snapshotSession struct {
+	engine.Session
+
+	snapshot *Snapshot
+	closed   bool
+}
+
+func (s *snapshotSession) Close() error {
+	if s.closed {
+		return errors.New("already closed")
+	}
+	s.closed = true
+
+	return s.snapshot.release()
+}
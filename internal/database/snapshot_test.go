@@ -0,0 +1,80 @@
+package database_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/chaisql/chai"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshot(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+	}{
+		{"Memory", ":memory:"},
+		{"Pebble", filepath.Join(t.TempDir(), "db")},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, err := chai.Open(test.path)
+			require.NoError(t, err)
+			defer db.Close()
+
+			err = db.Exec(`
+				CREATE TABLE test (a INTEGER PRIMARY KEY, b TEXT);
+				INSERT INTO test (a, b) VALUES (1, 'a'), (2, 'b');
+			`)
+			require.NoError(t, err)
+
+			count := func(t *testing.T, q interface {
+				QueryRow(string, ...any) (*chai.Row, error)
+			}) int {
+				t.Helper()
+
+				r, err := q.QueryRow(`SELECT COUNT(*) FROM test`)
+				require.NoError(t, err)
+				var n int
+				require.NoError(t, r.Scan(&n))
+				return n
+			}
+
+			s, err := db.Snapshot()
+			require.NoError(t, err)
+
+			err = db.Exec(`
+				INSERT INTO test (a, b) VALUES (3, 'c');
+				DELETE FROM test WHERE a = 1;
+				ALTER TABLE test RENAME TO test2;
+			`)
+			require.NoError(t, err)
+
+			require.Equal(t, 2, count(t, s))
+
+			conn, err := s.Connect()
+			require.NoError(t, err)
+			defer conn.Close()
+
+			// writes are not allowed
+			err = conn.Exec(`CREATE TABLE foo (a INT)`)
+			require.Error(t, err)
+			_, err = conn.Begin(true)
+			require.Error(t, err)
+
+			tx, err := conn.Begin(false)
+			require.NoError(t, err)
+			require.Equal(t, 2, count(t, tx))
+
+			// running transactions can read after the snapshot is closed
+			require.NoError(t, s.Close())
+			require.Equal(t, 2, count(t, tx))
+			require.NoError(t, tx.Rollback())
+
+			_, err = s.QueryRow(`SELECT COUNT(*) FROM test`)
+			require.Error(t, err)
+			require.Error(t, s.Close())
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package database
+
+import (
+	"math"
+
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/chaisql/chai/internal/kv"
+	"github.com/cockroachdb/errors"
+)
+
+// A storageManager is an engine able to compact its storage
+// and report its disk usage and activity.
+type storageManager interface {
+	Compact(start, end []byte) error
+	RangeStats(start, end []byte) (*kv.RangeStats, error)
+	Stats() *kv.EngineStats
+}
+
+// NamespaceStats describes the disk usage of a namespace.
+type NamespaceStats struct {
+	NamespaceInfo
+	// Bounds of the keys of the namespace, that can be passed to Compact.
+	Start, End []byte
+	kv.RangeStats
+}
+
+func (db *Database) storageManager() (storageManager, error) {
+	m, ok := db.Engine.(storageManager)
+	if !ok {
+		return nil, errors.New("storage management is not supported by the engine")
+	}
+
+	return m, nil
+}
+
+// Compact compacts the keys of the storage between start (inclusive) and end (exclusive),
+// which reclaims the space used by deleted and overwritten data.
+// A nil start or end means the beginning or the end of the storage.
+// Compacting a large range can take a long time and a lot of I/O,
+// but the database remains usable meanwhile.
+func (db *Database) Compact(start, end []byte) error {
+	m, err := db.storageManager()
+	if err != nil {
+		return err
+	}
+
+	if start == nil {
+		start = encoding.EncodeInt(nil, 0)
+	}
+	if end == nil {
+		end = encoding.EncodeInt(nil, math.MaxInt64)
+	}
+
+	return m.Compact(start, end)
+}
+
+// StorageStats returns the disk usage of every namespace listed by
+// Catalog.ListNamespaces, sorted by namespace.
+// Sizes are approximate and don't include the data that was not flushed
+// to disk yet.
+func (db *Database) StorageStats() ([]NamespaceStats, error) {
+	m, err := db.storageManager()
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.Begin(false)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	list, err := tx.Catalog.ListNamespaces(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make([]NamespaceStats, 0, len(list))
+	for _, info := range list {
+		start, end := info.Namespace.Bounds()
+		rs, err := m.RangeStats(start, end)
+		if err != nil {
+			return nil, err
+		}
+
+		stats = append(stats, NamespaceStats{
+			NamespaceInfo: info,
+			Start:         start,
+			End:           end,
+			RangeStats:    *rs,
+		})
+	}
+
+	return stats, nil
+}
+
+// EngineStats returns the counters of the engine, such as the
+// block cache hit rate or the number of bytes written by compactions.
+func (db *Database) EngineStats() (*kv.EngineStats, error) {
+	m, err := db.storageManager()
+	if err != nil {
+		return nil, err
+	}
+
+	return m.Stats(), nil
+}
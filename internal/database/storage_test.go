@@ -0,0 +1,128 @@
+package database_test
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/chaisql/chai"
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/database/catalogstore"
+	"github.com/chaisql/chai/internal/engine/memory"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorageStats(t *testing.T) {
+	db, err := chai.Open(t.TempDir())
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE test (a INT PRIMARY KEY, b TEXT);
+		CREATE INDEX test_b ON test (b);
+	`)
+	require.NoError(t, err)
+
+	// random payloads, to prevent compression
+	b := make([]byte, 500)
+	for i := 0; i < 1000; i++ {
+		_, err = rand.Read(b)
+		require.NoError(t, err)
+		require.NoError(t, db.Exec(`INSERT INTO test (a, b) VALUES (?, ?)`, i, hex.EncodeToString(b)))
+	}
+
+	require.NoError(t, db.Compact(nil, nil))
+
+	get := func(t *testing.T, owner string) chai.NamespaceStats {
+		t.Helper()
+
+		stats, err := db.StorageStats()
+		require.NoError(t, err)
+		for i := 1; i < len(stats); i++ {
+			require.Less(t, stats[i-1].Namespace, stats[i].Namespace)
+		}
+		for _, s := range stats {
+			if s.Owner == owner {
+				return s
+			}
+		}
+		t.Fatalf("namespace of %q not found", owner)
+		return chai.NamespaceStats{}
+	}
+
+	table := get(t, "test")
+	require.Equal(t, database.NamespaceTableType, table.Type)
+	require.Greater(t, table.Size, uint64(500_000))
+	require.NotEmpty(t, table.Levels)
+	var files int
+	for _, l := range table.Levels {
+		files += l.Files
+	}
+	require.NotZero(t, files)
+
+	index := get(t, "test_b")
+	require.Equal(t, database.NamespaceIndexType, index.Type)
+	require.NotZero(t, index.Size)
+
+	// compacting the range of the table reclaims the space of deleted rows
+	require.NoError(t, db.Exec(`DELETE FROM test`))
+	require.NoError(t, db.Compact(table.Start, table.End))
+	require.Less(t, get(t, "test").Size, table.Size/10)
+}
+
+func TestStorageStatsUnsupported(t *testing.T) {
+	db, err := database.Open(":memory:", &database.Options{
+		CatalogLoader: catalogstore.LoadCatalog,
+		Engine:        memory.NewEngine(),
+	})
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.StorageStats()
+	require.Error(t, err)
+	require.Error(t, db.Compact(nil, nil))
+	_, err = db.EngineStats()
+	require.Error(t, err)
+}
+
+func TestEngineStats(t *testing.T) {
+	db, err := chai.Open(t.TempDir())
+	require.NoError(t, err)
+	defer db.Close()
+
+	require.NoError(t, db.Exec(`CREATE TABLE test (a INT PRIMARY KEY, b TEXT)`))
+	for i := 0; i < 100; i++ {
+		require.NoError(t, db.Exec(`INSERT INTO test (a, b) VALUES (?, ?)`, i, strings.Repeat("a", 1000)))
+	}
+	require.NoError(t, db.Compact(nil, nil))
+
+	stats, err := db.Stats()
+	require.NoError(t, err)
+	require.Greater(t, stats.Flushes, int64(0))
+	require.Greater(t, stats.BytesFlushed, uint64(0))
+	require.Greater(t, stats.DiskUsage, uint64(0))
+	require.Greater(t, stats.WALSize, uint64(0))
+	require.Zero(t, stats.OpenIterators)
+
+	// reading the table goes through the block cache
+	conn, err := db.Connect()
+	require.NoError(t, err)
+	defer conn.Close()
+	res, err := conn.Query(`SELECT * FROM test`)
+	require.NoError(t, err)
+	err = res.Iterate(func(*chai.Row) error {
+		stats, err := db.Stats()
+		require.NoError(t, err)
+		require.Positive(t, stats.OpenIterators)
+		return nil
+	})
+	require.NoError(t, err)
+	require.NoError(t, res.Close())
+
+	stats, err = db.Stats()
+	require.NoError(t, err)
+	require.Greater(t, stats.BlockCacheHits+stats.BlockCacheMisses, int64(0))
+	require.GreaterOrEqual(t, stats.BlockCacheHitRate(), 0.0)
+	require.Zero(t, stats.OpenIterators)
+}
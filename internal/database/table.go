@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/chaisql/chai/internal/engine"
@@ -19,10 +20,18 @@ type Table struct {
 	// May not represent the most up to date data.
 	// Always get a fresh Table instance before relying on this field.
 	Info *TableInfo
+
+	// virtual table generating the rows, if any
+	virtual *virtualTable
 }
 
 // Truncate deletes all the objects from the table.
 func (t *Table) Truncate() error {
+	err := t.releaseAllBlobs(nil)
+	if err != nil {
+		return err
+	}
+
 	return t.Tree.Truncate()
 }
 
@@ -46,6 +55,24 @@ func (t *Table) Insert(r row.Row) (*tree.Key, Row, error) {
 		return nil, nil, err
 	}
 
+	if hooks := t.writeHooks(); len(hooks) > 0 {
+		// hooks must not be called for rows that can't be inserted
+		if !isRowid {
+			ok, err := t.Tree.Exists(key)
+			if err != nil {
+				return nil, nil, err
+			}
+			if ok {
+				return nil, nil, t.primaryKeyViolation(key)
+			}
+		}
+
+		err = t.runWriteHooks(hooks, ChangeInsert, key, nil, r)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
 	// insert into the table
 	if !isRowid {
 		// if the key is not a rowid, make sure it doesn't exist
@@ -56,16 +83,19 @@ func (t *Table) Insert(r row.Row) (*tree.Key, Row, error) {
 	}
 	if err != nil {
 		if errors.Is(err, engine.ErrKeyAlreadyExists) {
-			return nil, nil, &ConstraintViolationError{
-				Constraint: "PRIMARY KEY",
-				Columns:    t.Info.PrimaryKey.Columns,
-				Key:        key,
-			}
+			return nil, nil, t.primaryKeyViolation(key)
 		}
 
 		return nil, nil, errors.Wrapf(err, "failed to insert row %q", key)
 	}
 
+	if t.capturesChanges() {
+		err = t.captureChange(ChangeInsert, key, nil, enc)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
 	return key, &BasicRow{
 		tableName: t.Info.TableName,
 		Row:       r,
@@ -73,19 +103,48 @@ func (t *Table) Insert(r row.Row) (*tree.Key, Row, error) {
 	}, nil
 }
 
+func (t *Table) primaryKeyViolation(key *tree.Key) error {
+	return &ConstraintViolationError{
+		Constraint: "PRIMARY KEY",
+		Columns:    t.Info.PrimaryKey.Columns,
+		Key:        key,
+	}
+}
+
 func (t *Table) encodeRow(r row.Row) (row.Row, []byte, error) {
+	blobs := t.storesBlobs()
+
+	var dst []byte
 	ed, ok := r.(*EncodedRow)
 	// pointer comparison is enough here
 	if ok && ed.columnConstraints == &t.Info.ColumnConstraints {
-		return r, ed.encoded, nil
+		// rows encoded with an older row format are upgraded
+		var err error
+		dst, err = upgradeRow(&t.Info.ColumnConstraints, ed.encoded)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !blobs {
+			return r, dst, nil
+		}
+	} else {
+		var err error
+		dst, err = t.Info.EncodeRow(t.Tx, nil, r)
+		if err != nil {
+			return nil, nil, err
+		}
 	}
 
-	dst, err := t.Info.EncodeRow(t.Tx, nil, r)
-	if err != nil {
-		return nil, nil, err
+	// the row must not share its blobs with the row it was read from
+	if blobs {
+		var err error
+		dst, err = t.moveBlobs(dst, nil)
+		if err != nil {
+			return nil, nil, err
+		}
 	}
 
-	return NewEncodedRow(&t.Info.ColumnConstraints, dst), dst, nil
+	return newEncodedRowWithTx(t.Tx, t.Info, dst), dst, nil
 }
 
 // Delete a object by key.
@@ -94,12 +153,101 @@ func (t *Table) Delete(key *tree.Key) error {
 		return errors.New("cannot write to read-only table")
 	}
 
+	hooks := t.writeHooks()
+	capture := t.capturesChanges()
+	blobs := t.storesBlobs()
+
+	var old []byte
+	if capture || blobs || len(hooks) > 0 {
+		var err error
+		old, err = t.Tree.Get(key)
+		if errors.Is(err, engine.ErrKeyNotFound) {
+			return errs.NewNotFoundError(key.String())
+		}
+		if err != nil {
+			return err
+		}
+
+		if len(hooks) > 0 {
+			err = t.runWriteHooks(hooks, ChangeDelete, key, old, nil)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
 	err := t.Tree.Delete(key)
 	if errors.Is(err, engine.ErrKeyNotFound) {
 		return errs.NewNotFoundError(key.String())
 	}
+	if err != nil {
+		return err
+	}
+
+	if blobs {
+		t.releaseBlobs(old)
+	}
+
+	if capture {
+		return t.captureChange(ChangeDelete, key, old, nil)
+	}
 
-	return err
+	return nil
+}
+
+// DeleteRange deletes all the rows whose primary key is in the given range,
+// without reading them, unless their changes are captured, the table has write hooks
+// or values stored outside of the rows.
+// It doesn't update the indexes of the table.
+// If the range is nil, it deletes all the rows.
+func (t *Table) DeleteRange(rng *Range) error {
+	if t.Info.ReadOnly {
+		return errors.New("cannot write to read-only table")
+	}
+
+	var r *tree.Range
+	if rng != nil {
+		var columns []string
+		if pk := t.Info.PrimaryKey; pk != nil {
+			columns = pk.Columns
+		}
+
+		var err error
+		r, err = rng.ToTreeRange(&t.Info.ColumnConstraints, columns)
+		if err != nil {
+			return err
+		}
+	}
+
+	hooks := t.writeHooks()
+	capture := t.capturesChanges()
+	blobs := t.storesBlobs()
+
+	if capture || blobs || len(hooks) > 0 {
+		err := t.Tree.IterateOnRange(r, false, func(k *tree.Key, enc []byte) error {
+			if blobs {
+				t.releaseBlobs(enc)
+			}
+
+			if len(hooks) > 0 {
+				err := t.runWriteHooks(hooks, ChangeDelete, k, enc, nil)
+				if err != nil {
+					return err
+				}
+			}
+
+			if capture {
+				return t.captureChange(ChangeDelete, k, enc, nil)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return t.Tree.DeleteRange(r)
 }
 
 // Replace a row by key.
@@ -132,16 +280,85 @@ func (t *Table) Put(key *tree.Key, r row.Row) (Row, error) {
 		return nil, err
 	}
 
+	return t.put(key, r, enc)
+}
+
+// put stores the encoded row r under the given key.
+func (t *Table) put(key *tree.Key, r row.Row, enc []byte) (Row, error) {
+	hooks := t.writeHooks()
+	capture := t.capturesChanges()
+	blobs := t.storesBlobs()
+
+	// the previous row of sessions supporting deltas is read
+	// to only write the delta between both rows, see tree.Update
+	_, merges := t.Tree.Session.(engine.MergeSession)
+
+	var old []byte
+	var err error
+	if capture || blobs || merges || len(hooks) > 0 {
+		old, err = t.Tree.Get(key)
+		if err != nil && !errors.Is(err, engine.ErrKeyNotFound) {
+			return nil, err
+		}
+
+		if len(hooks) > 0 {
+			op := ChangeUpdate
+			if old == nil {
+				op = ChangeInsert
+			}
+			err = t.runWriteHooks(hooks, op, key, old, r)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
 	// replace old row with new row
-	err = t.Tree.Put(key, enc)
+	if old != nil {
+		err = t.Tree.Update(key, old, enc)
+	} else {
+		err = t.Tree.Put(key, enc)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if blobs && old != nil {
+		t.releaseBlobs(old)
+	}
+
+	if capture {
+		op := ChangeUpdate
+		if old == nil {
+			op = ChangeInsert
+		}
+		err = t.captureChange(op, key, old, enc)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return &BasicRow{
 		tableName: t.Info.TableName,
 		Row:       r,
 		key:       key,
-	}, err
+	}, nil
 }
 
 func (t *Table) IterateOnRange(rng *Range, reverse bool, fn func(key *tree.Key, r Row) error) error {
+	return t.IterateOnRangeContext(context.Background(), rng, reverse, fn)
+}
+
+// IterateOnRangeContext is like IterateOnRange. The context is passed
+// to the function generating the rows of virtual tables.
+func (t *Table) IterateOnRangeContext(ctx context.Context, rng *Range, reverse bool, fn func(key *tree.Key, r Row) error) error {
+	if t.virtual != nil {
+		err := t.generate(ctx, rng)
+		if err != nil {
+			return err
+		}
+	}
+
 	var columns []string
 
 	pk := t.Info.PrimaryKey
@@ -161,6 +378,8 @@ func (t *Table) IterateOnRange(rng *Range, reverse bool, fn func(key *tree.Key,
 
 	e := EncodedRow{
 		columnConstraints: &t.Info.ColumnConstraints,
+		tx:                t.Tx,
+		namespace:         t.Info.StoreNamespace,
 	}
 	row := BasicRow{
 		tableName: t.Info.TableName,
@@ -169,7 +388,7 @@ func (t *Table) IterateOnRange(rng *Range, reverse bool, fn func(key *tree.Key,
 
 	return t.Tree.IterateOnRange(r, reverse, func(k *tree.Key, enc []byte) error {
 		row.key = k
-		e.encoded = enc
+		e.reset(enc)
 		return fn(k, &row)
 	})
 }
@@ -186,7 +405,7 @@ func (t *Table) GetRow(key *tree.Key) (Row, error) {
 
 	return &BasicRow{
 		tableName: t.Info.TableName,
-		Row:       NewEncodedRow(&t.Info.ColumnConstraints, enc),
+		Row:       newEncodedRowWithTx(t.Tx, t.Info, enc),
 		key:       key,
 	}, nil
 }
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/chaisql/chai/internal/engine"
+	"github.com/chaisql/chai/internal/types"
 	"github.com/cockroachdb/errors"
 )
 
@@ -20,6 +21,13 @@ type Transaction struct {
 	// The timestamp must use the local timezone.
 	TxStart time.Time
 
+	// Time zone used to parse and display TIMESTAMPTZ values.
+	// Nil means UTC.
+	TimeZone *time.Location
+
+	// Controls how values of different types are compared.
+	Coercion types.Coercion
+
 	Session   engine.Session
 	Engine    engine.Engine
 	ID        uint64
@@ -32,6 +40,20 @@ type Transaction struct {
 
 	Catalog       *Catalog
 	catalogWriter *CatalogWriter
+	// catalog of the database when the transaction began.
+	baseCatalog *Catalog
+	// whether the transaction holds the sequences lock.
+	sequencesLocked bool
+	// encoded row changes captured by the transaction,
+	// written to the change log on commit.
+	changes [][]byte
+	// blobs of the rows deleted or replaced by the transaction,
+	// deleted on commit.
+	unusedBlobs []blobRef
+	// texts of the dictionaries read or written by the transaction.
+	dictionaries map[dictionaryID]*dictionary
+	// whether the transaction was committed or rolled back.
+	finished bool
 }
 
 func (tx *Transaction) Connection() *Connection {
@@ -45,7 +67,9 @@ func (tx *Transaction) Rollback() error {
 		return err
 	}
 
-	if tx.Writable {
+	// concurrent write transactions don't hold the write lock
+	// and never use the rollback segment.
+	if tx.Writable && tx.WriteTxMu != nil {
 		err = tx.Engine.Rollback()
 		if err != nil {
 			return err
@@ -60,9 +84,26 @@ func (tx *Transaction) Rollback() error {
 		tx.OnRollbackHooks[i]()
 	}
 
+	tx.finish(false)
 	return nil
 }
 
+// finish updates the transaction counters of the database
+// the first time the transaction is committed or rolled back.
+func (tx *Transaction) finish(committed bool) {
+	if tx.finished || tx.db == nil {
+		return
+	}
+	tx.finished = true
+
+	tx.db.txStats.active.Add(-1)
+	if committed {
+		tx.db.txStats.committed.Add(1)
+	} else {
+		tx.db.txStats.rolledBack.Add(1)
+	}
+}
+
 // Commit the transaction. Calling this method on read-only transactions
 // will return an error.
 func (tx *Transaction) Commit() error {
@@ -75,21 +116,48 @@ func (tx *Transaction) Commit() error {
 	tx.db.txmu.Lock()
 	defer tx.db.txmu.Unlock()
 
-	err := tx.Session.Commit()
+	// the transaction may have been built on a previous version of the schema
+	if tx.db.conflicts != nil && tx.db.Catalog() != tx.baseCatalog {
+		return errors.WithStack(ErrWriteConflict)
+	}
+
+	err := tx.deleteUnusedBlobs()
+	if err != nil {
+		return err
+	}
+
+	var changeSeq uint64
+	if len(tx.changes) > 0 {
+		changeSeq = tx.db.changeLog.next()
+		err := tx.writeChanges(changeSeq)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = tx.Session.Commit()
 	if err != nil {
 		return err
 	}
 
 	_ = tx.Session.Close()
 
-	defer func() {
-		tx.WriteTxMu.Unlock()
-	}()
+	if changeSeq != 0 {
+		tx.db.changeLog.commit(changeSeq)
+	}
+
+	if tx.WriteTxMu != nil {
+		defer func() {
+			tx.WriteTxMu.Unlock()
+		}()
+	}
 
 	for i := len(tx.OnCommitHooks) - 1; i >= 0; i-- {
 		tx.OnCommitHooks[i]()
 	}
 
+	tx.finish(true)
+
 	// if the catalog has been modified, update the database catalog
 	if tx.catalogWriter != nil {
 		tx.db.SetCatalog(tx.Catalog)
@@ -98,6 +166,24 @@ func (tx *Transaction) Commit() error {
 	return nil
 }
 
+// lockSequences serializes the concurrent write transactions that use sequences
+// until they are committed or rolled back: the current values of sequences are
+// cached in memory and their changes can't be merged.
+func (tx *Transaction) lockSequences() {
+	if tx.db == nil || tx.db.conflicts == nil || tx.sequencesLocked {
+		return
+	}
+
+	tx.db.seqmu.Lock()
+	tx.sequencesLocked = true
+
+	unlock := func() {
+		tx.db.seqmu.Unlock()
+	}
+	tx.OnCommitHooks = append(tx.OnCommitHooks, unlock)
+	tx.OnRollbackHooks = append(tx.OnRollbackHooks, unlock)
+}
+
 func (tx *Transaction) CatalogWriter() *CatalogWriter {
 	if !tx.Writable {
 		panic("cannot get catalog writer from read-only transaction")
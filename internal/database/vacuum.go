@@ -0,0 +1,131 @@
+package database
+
+import (
+	"slices"
+
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/cockroachdb/errors"
+)
+
+// VacuumReport contains the result of a vacuum.
+type VacuumReport struct {
+	// Number of dead namespaces that were cleaned up.
+	Namespaces int
+	// Approximate number of bytes used on disk by the dead namespaces,
+	// reclaimed once they are compacted.
+	ReclaimedBytes uint64
+}
+
+// Vacuum removes the data of the dead namespaces: the namespaces freed
+// by dropped tables and indexes, and the namespaces that contain data
+// but don't belong to anything, as reported by Check.
+// Once the transaction is committed, the ranges of these namespaces are
+// compacted in the background, which reclaims their space on disk.
+func (tx *Transaction) Vacuum() (*VacuumReport, error) {
+	if !tx.Writable {
+		return nil, errors.New("cannot vacuum in a read-only transaction")
+	}
+
+	list, err := tx.Catalog.ListNamespaces(tx)
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[tree.Namespace]struct{}, len(list))
+	for _, info := range list {
+		known[info.Namespace] = struct{}{}
+	}
+
+	// freed namespaces are already empty, but their deleted
+	// data may still be stored on disk.
+	var dead []tree.Namespace
+	err = iterateNamespaceRegistry(tx, func(ns tree.Namespace, state byte, owner string) error {
+		if state == namespaceFree {
+			dead = append(dead, ns)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var orphans []tree.Namespace
+	err = tx.iterateStoredNamespaces(func(ns tree.Namespace) error {
+		if _, ok := known[ns]; !ok {
+			orphans = append(orphans, ns)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// the engine may not be able to compact its storage,
+	// in which case deleting the data is enough.
+	m, _ := tx.Engine.(storageManager)
+
+	var report VacuumReport
+	var compact []tree.Namespace
+	for _, ns := range dead {
+		// freed namespaces that still contain data are handled as orphans
+		if m == nil || slices.Contains(orphans, ns) {
+			continue
+		}
+
+		start, end := ns.Bounds()
+		rs, err := m.RangeStats(start, end)
+		if err != nil {
+			return nil, err
+		}
+		if rs.Size == 0 {
+			continue
+		}
+
+		report.Namespaces++
+		report.ReclaimedBytes += rs.Size
+		compact = append(compact, ns)
+	}
+
+	for _, ns := range orphans {
+		if m != nil {
+			start, end := ns.Bounds()
+			rs, err := m.RangeStats(start, end)
+			if err != nil {
+				return nil, err
+			}
+			report.ReclaimedBytes += rs.Size
+		}
+
+		err = tree.New(tx.Session, ns, nil).Truncate()
+		if err != nil {
+			return nil, err
+		}
+
+		report.Namespaces++
+		compact = append(compact, ns)
+	}
+
+	if m != nil && len(compact) > 0 && tx.db != nil {
+		db := tx.db
+		tx.OnCommitHooks = append(tx.OnCommitHooks, func() {
+			db.compactInBackground(m, compact)
+		})
+	}
+
+	return &report, nil
+}
+
+// compactInBackground compacts the ranges of the given namespaces
+// without blocking the caller. Closing the database waits for the
+// compaction to complete.
+func (db *Database) compactInBackground(m storageManager, namespaces []tree.Namespace) {
+	db.connectionWg.Add(1)
+	go func() {
+		defer db.connectionWg.Done()
+
+		for _, ns := range namespaces {
+			// the data was already deleted, the compaction only
+			// reclaims space and can be done again by the next vacuum.
+			_ = m.Compact(ns.Bounds())
+		}
+	}()
+}
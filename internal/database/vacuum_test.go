@@ -0,0 +1,97 @@
+package database_test
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+
+	"github.com/chaisql/chai"
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVacuum(t *testing.T) {
+	type result struct {
+		Namespaces     int   `chai:"namespaces"`
+		ReclaimedBytes int64 `chai:"reclaimed_bytes"`
+	}
+
+	vacuum := func(t *testing.T, db *chai.DB) result {
+		t.Helper()
+
+		r, err := db.QueryRow("VACUUM")
+		require.NoError(t, err)
+		var res result
+		require.NoError(t, r.StructScan(&res))
+		return res
+	}
+
+	// writes data to a namespace that doesn't belong to anything
+	writeOrphan := func(t *testing.T, db *chai.DB) {
+		t.Helper()
+
+		tx, err := db.DB.Begin(true)
+		require.NoError(t, err)
+		defer tx.Rollback()
+		err = tree.New(tx.Session, 100_000, nil).Put(tree.NewKey(types.NewBigintValue(1)), []byte("orphan"))
+		require.NoError(t, err)
+		require.NoError(t, tx.Commit())
+
+		report, err := db.Check(context.Background())
+		require.NoError(t, err)
+		require.False(t, report.OK())
+	}
+
+	t.Run("On disk", func(t *testing.T) {
+		dir := t.TempDir()
+
+		db, err := chai.Open(dir)
+		require.NoError(t, err)
+
+		require.NoError(t, db.Exec(`CREATE TABLE test (a INT PRIMARY KEY, b TEXT)`))
+		// random payloads, to prevent compression
+		b := make([]byte, 500)
+		for i := 0; i < 500; i++ {
+			_, err = rand.Read(b)
+			require.NoError(t, err)
+			require.NoError(t, db.Exec(`INSERT INTO test (a, b) VALUES (?, ?)`, i, hex.EncodeToString(b)))
+		}
+		// flush the table to disk
+		require.NoError(t, db.Compact(nil, nil))
+
+		require.NoError(t, db.Exec(`DROP TABLE test`))
+		writeOrphan(t, db)
+
+		res := vacuum(t, db)
+		require.Equal(t, 2, res.Namespaces)
+		require.Greater(t, res.ReclaimedBytes, int64(250_000))
+
+		report, err := db.Check(context.Background())
+		require.NoError(t, err)
+		require.True(t, report.OK(), report.Problems)
+
+		// closing the database waits for the compaction
+		require.NoError(t, db.Close())
+
+		db, err = chai.Open(dir)
+		require.NoError(t, err)
+		defer db.Close()
+
+		require.Equal(t, result{}, vacuum(t, db))
+	})
+
+	t.Run("In memory", func(t *testing.T) {
+		db, err := chai.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		writeOrphan(t, db)
+		require.Equal(t, result{Namespaces: 1}, vacuum(t, db))
+
+		report, err := db.Check(context.Background())
+		require.NoError(t, err)
+		require.True(t, report.OK(), report.Problems)
+	})
+}
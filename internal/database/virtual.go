@@ -0,0 +1,246 @@
+package database
+
+import (
+	"context"
+	"sync"
+
+	"github.com/chaisql/chai/internal/engine/memory"
+	errs "github.com/chaisql/chai/internal/errors"
+	"github.com/chaisql/chai/internal/row"
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// Virtual tables
+const (
+	// JobsTableName is the name of the virtual table
+	// listing the jobs of the scheduler.
+	JobsTableName = InternalPrefix + "jobs"
+)
+
+// A virtualTable is a read-only table whose rows are generated every time
+// the table is scanned, from the state of the database or by the application.
+// Virtual tables are not stored in the catalog.
+type virtualTable struct {
+	info *TableInfo
+	rows VirtualRowsFunc
+}
+
+// VirtualRowsFunc calls fn for every row of a virtual table.
+// rng is the range of primary keys requested by the query, or nil.
+// It can be used to skip the rows outside of the range, which are
+// filtered anyway.
+type VirtualRowsFunc func(ctx context.Context, tx *Transaction, rng *Range, fn func(r row.Row) error) error
+
+var virtualTables = map[string]*virtualTable{
+	JobsTableName: {
+		info: newVirtualTableInfo(JobsTableName, []string{"name"},
+			&ColumnConstraint{Column: "name", Type: types.TypeText, IsNotNull: true},
+			&ColumnConstraint{Column: "interval", Type: types.TypeText, IsNotNull: true},
+			&ColumnConstraint{Column: "jitter", Type: types.TypeText, IsNotNull: true},
+			&ColumnConstraint{Column: "paused", Type: types.TypeBoolean, IsNotNull: true},
+			&ColumnConstraint{Column: "running", Type: types.TypeBoolean, IsNotNull: true},
+			&ColumnConstraint{Column: "runs", Type: types.TypeBigint, IsNotNull: true},
+			&ColumnConstraint{Column: "failures", Type: types.TypeBigint, IsNotNull: true},
+			&ColumnConstraint{Column: "last_run", Type: types.TypeTimestamp},
+			&ColumnConstraint{Column: "last_duration", Type: types.TypeText},
+			&ColumnConstraint{Column: "last_error", Type: types.TypeText},
+			&ColumnConstraint{Column: "next_run", Type: types.TypeTimestamp},
+		),
+		rows: jobsRows,
+	},
+}
+
+func newVirtualTableInfo(name string, pk []string, columns ...*ColumnConstraint) *TableInfo {
+	for i, cc := range columns {
+		cc.Position = i
+	}
+
+	info := &TableInfo{
+		TableName: name,
+		ReadOnly:  true,
+		TableConstraints: []*TableConstraint{
+			{
+				Name:       name + "_pk",
+				PrimaryKey: true,
+				Columns:    pk,
+			},
+		},
+		ColumnConstraints: MustNewColumnConstraints(columns...),
+	}
+	info.BuildPrimaryKey()
+
+	return info
+}
+
+// open returns the table, whose rows are generated when it is scanned.
+func (v *virtualTable) open(tx *Transaction) *Table {
+	return &Table{
+		Tx:      tx,
+		Tree:    tree.New(memory.NewEngine().NewTransientSession(), MinTransientNamespace, v.info.PrimaryKeySortOrder()),
+		Info:    v.info,
+		virtual: v,
+	}
+}
+
+// generate replaces the rows of the table with the rows of the given range
+// generated by the virtual table, stored in a temporary in-memory tree.
+// Tables without primary key use the position of the rows as key.
+func (t *Table) generate(ctx context.Context, rng *Range) error {
+	info := t.Info
+	t.Tree = tree.New(memory.NewEngine().NewTransientSession(), MinTransientNamespace, info.PrimaryKeySortOrder())
+
+	var eo EncodedRow
+	var n int64
+	return t.virtual.rows(ctx, t.Tx, rng, func(r row.Row) error {
+		enc, err := info.EncodeRow(t.Tx, nil, r)
+		if err != nil {
+			return err
+		}
+
+		var key *tree.Key
+		if pk := info.PrimaryKey; pk != nil {
+			eo.ResetWith(t.Tx, info, enc)
+
+			vs := make([]types.Value, len(pk.Columns))
+			for i, c := range pk.Columns {
+				vs[i], err = eo.Get(c)
+				if err != nil {
+					return err
+				}
+			}
+			key = tree.NewKey(vs...)
+		} else {
+			n++
+			key = tree.NewKey(types.NewBigintValue(n))
+		}
+
+		return t.Tree.Put(key, enc)
+	})
+}
+
+// virtualTableSet holds the virtual tables registered by the application.
+// It is shared by the clones of a catalog.
+type virtualTableSet struct {
+	mu     sync.RWMutex
+	tables map[string]*virtualTable
+}
+
+// virtualTable returns the built-in or registered virtual table with the given name.
+func (c *Catalog) virtualTable(name string) (*virtualTable, bool) {
+	if v, ok := virtualTables[name]; ok {
+		return v, true
+	}
+
+	if c.virtual == nil {
+		return nil, false
+	}
+
+	c.virtual.mu.RLock()
+	defer c.virtual.mu.RUnlock()
+
+	v, ok := c.virtual.tables[name]
+	return v, ok
+}
+
+// IsVirtualTable reports whether the table is a built-in or registered virtual table.
+func (c *Catalog) IsVirtualTable(name string) bool {
+	if _, err := c.Cache.Get(RelationTableType, name); err == nil {
+		return false
+	}
+
+	_, ok := c.virtualTable(name)
+	return ok
+}
+
+// RegisterVirtualTable registers a read-only table described by info,
+// whose rows are generated by the given function every time it is scanned.
+// The name of the table must not be used by another table.
+func (db *Database) RegisterVirtualTable(info *TableInfo, rows VirtualRowsFunc) error {
+	c := db.Catalog()
+
+	_, err := c.GetTableInfo(info.TableName)
+	if err == nil {
+		return errors.WithStack(errs.AlreadyExistsError{Name: info.TableName})
+	}
+	if !errs.IsNotFoundError(err) {
+		return err
+	}
+
+	info.ReadOnly = true
+	if info.PrimaryKey == nil {
+		info.BuildPrimaryKey()
+	}
+
+	c.virtual.mu.Lock()
+	defer c.virtual.mu.Unlock()
+
+	if _, ok := c.virtual.tables[info.TableName]; ok {
+		return errors.WithStack(errs.AlreadyExistsError{Name: info.TableName})
+	}
+	c.virtual.tables[info.TableName] = &virtualTable{info: info, rows: rows}
+
+	return nil
+}
+
+// UnregisterVirtualTable removes a virtual table registered with RegisterVirtualTable.
+func (db *Database) UnregisterVirtualTable(name string) error {
+	c := db.Catalog()
+
+	c.virtual.mu.Lock()
+	defer c.virtual.mu.Unlock()
+
+	if _, ok := c.virtual.tables[name]; !ok {
+		return errors.WithStack(errs.NewNotFoundError(name))
+	}
+	delete(c.virtual.tables, name)
+
+	return nil
+}
+
+func jobsRows(ctx context.Context, tx *Transaction, rng *Range, fn func(r row.Row) error) error {
+	if tx.db == nil {
+		return nil
+	}
+
+	for _, j := range tx.db.scheduler.Jobs() {
+		name := types.NewTextValue(j.Name)
+
+		r := row.NewColumnBuffer().
+			Add("name", name).
+			Add("interval", types.NewTextValue(j.Interval.String())).
+			Add("jitter", types.NewTextValue(j.Jitter.String())).
+			Add("paused", types.NewBooleanValue(j.Paused)).
+			Add("running", types.NewBooleanValue(j.Running)).
+			Add("runs", types.NewBigintValue(j.Runs)).
+			Add("failures", types.NewBigintValue(j.Failures))
+
+		if j.LastRun.IsZero() {
+			r.Add("last_run", types.NewNullValue()).
+				Add("last_duration", types.NewNullValue())
+		} else {
+			r.Add("last_run", types.NewTimestampValue(j.LastRun)).
+				Add("last_duration", types.NewTextValue(j.LastDuration.String()))
+		}
+
+		if j.LastError == "" {
+			r.Add("last_error", types.NewNullValue())
+		} else {
+			r.Add("last_error", types.NewTextValue(j.LastError))
+		}
+
+		if j.NextRun.IsZero() {
+			r.Add("next_run", types.NewNullValue())
+		} else {
+			r.Add("next_run", types.NewTimestampValue(j.NextRun))
+		}
+
+		err := fn(r)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
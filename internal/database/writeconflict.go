@@ -0,0 +1,360 @@
+package database
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/chaisql/chai/internal/engine"
+	"github.com/cockroachdb/errors"
+)
+
+// ErrWriteConflict is returned when committing a transaction that modified a key
+// or the schema also modified by a transaction committed after it began.
+// The transaction is not committed and can be retried.
+var ErrWriteConflict = errors.New("write conflict")
+
+// number of tracked commits above which they are pruned.
+const writeConflictsPruneThreshold = 1024
+
+// writeConflicts detects conflicts between concurrent write transactions.
+// Every committed transaction increments the version of the database, and
+// the version of the last commit that modified each key is kept in memory
+// for as long as a transaction begun before that commit is still running.
+// A transaction conflicts if one of the keys it modified was committed
+// at a version greater than the one it began with.
+type writeConflicts struct {
+	mu sync.Mutex
+
+	// version of the last committed transaction.
+	version uint64
+	// version of the last commit of each key.
+	keys map[string]uint64
+	// number of running transactions per version they began with.
+	running map[uint64]int
+	// total number of running transactions.
+	active int
+}
+
+func newWriteConflicts() *writeConflicts {
+	return &writeConflicts{
+		keys:    make(map[string]uint64),
+		running: make(map[uint64]int),
+	}
+}
+
+// begin registers a new transaction and returns its version.
+func (w *writeConflicts) begin() uint64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.running[w.version]++
+	w.active++
+	return w.version
+}
+
+// done unregisters a transaction begun at the given version
+// and forgets the commits no running transaction can conflict with.
+func (w *writeConflicts) done(version uint64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.running[version]--
+	if w.running[version] == 0 {
+		delete(w.running, version)
+	}
+	w.active--
+
+	if w.active == 0 {
+		clear(w.keys)
+		return
+	}
+
+	if len(w.keys) < writeConflictsPruneThreshold {
+		return
+	}
+
+	oldest := w.version
+	for v := range w.running {
+		oldest = min(oldest, v)
+	}
+	for k, v := range w.keys {
+		if v <= oldest {
+			delete(w.keys, k)
+		}
+	}
+}
+
+// commit calls fn if none of the keys were committed after the given version,
+// and records the keys as committed by a new version if fn succeeds.
+// If reads is not nil, fn is also not called if one of the keys committed
+// after the given version was read.
+func (w *writeConflicts) commit(version uint64, keys map[string]struct{}, reads *readSet, fn func() error) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for k := range keys {
+		if w.keys[k] > version {
+			return errors.WithStack(ErrWriteConflict)
+		}
+	}
+
+	if reads != nil {
+		for k, v := range w.keys {
+			if v > version && reads.contains([]byte(k)) {
+				return errors.WithStack(ErrWriteConflict)
+			}
+		}
+	}
+
+	err := fn()
+	if err != nil {
+		return err
+	}
+
+	w.version++
+	// the committing transaction is still registered: if it is the only one,
+	// no other transaction can conflict with this commit.
+	if w.active > 1 {
+		for k := range keys {
+			w.keys[k] = w.version
+		}
+	}
+
+	return nil
+}
+
+// readSet contains the keys and the key ranges read by a serializable transaction.
+type readSet struct {
+	keys   map[string]struct{}
+	ranges []keyRange
+}
+
+// keyRange is a range of keys read by an iterator.
+// A nil bound means the range is unbounded on that side.
+type keyRange struct {
+	start []byte
+	// exclusive
+	end []byte
+}
+
+func newReadSet() *readSet {
+	return &readSet{
+		keys: make(map[string]struct{}),
+	}
+}
+
+func (r *readSet) addKey(k []byte) {
+	r.keys[string(k)] = struct{}{}
+}
+
+func (r *readSet) addRange(start, end []byte) {
+	r.ranges = append(r.ranges, keyRange{
+		start: bytes.Clone(start),
+		end:   bytes.Clone(end),
+	})
+}
+
+// contains returns whether the key was read, or would have been
+// returned by one of the ranges read if it had existed at the time.
+func (r *readSet) contains(k []byte) bool {
+	if _, ok := r.keys[string(k)]; ok {
+		return true
+	}
+
+	for _, rng := range r.ranges {
+		if rng.start != nil && encoding.Compare(k, rng.start) < 0 {
+			continue
+		}
+		if rng.end != nil && encoding.Compare(k, rng.end) >= 0 {
+			continue
+		}
+		return true
+	}
+
+	return false
+}
+
+// conflictSession records the keys modified by a concurrent write transaction
+// and checks for conflicts when it is committed.
+// Serializable sessions also record the keys and the ranges they read:
+// they conflict with the transactions committed since they began
+// that modified any of them, which could have changed what they read.
+// Other sessions only record the ranges read to check constraints.
+type conflictSession struct {
+	engine.Session
+
+	conflicts *writeConflicts
+	version   uint64
+	keys      map[string]struct{}
+	// nil if the session is not serializable.
+	reads *readSet
+	// ranges read to check constraints, if the session is not serializable.
+	constraintReads *readSet
+	done            bool
+}
+
+func newConflictSession(s engine.Session, conflicts *writeConflicts, serializable bool) *conflictSession {
+	cs := conflictSession{
+		Session:   s,
+		conflicts: conflicts,
+		version:   conflicts.begin(),
+		keys:      make(map[string]struct{}),
+	}
+	if serializable {
+		cs.reads = newReadSet()
+	}
+
+	return &cs
+}
+
+// track records a modified key. Sequences are not tracked: transactions
+// using them are serialized, see Transaction.lockSequences.
+func (s *conflictSession) track(k []byte) {
+	ns, _ := encoding.DecodeInt(k)
+	if ns == int64(SequenceTableNamespace) {
+		return
+	}
+
+	s.keys[string(k)] = struct{}{}
+}
+
+// ReadForConstraint records a range read to check a constraint. Serializable
+// sessions already record every range they read.
+func (s *conflictSession) ReadForConstraint(start, end []byte) {
+	if s.reads != nil {
+		return
+	}
+
+	if s.constraintReads == nil {
+		s.constraintReads = newReadSet()
+	}
+	s.constraintReads.addRange(start, end)
+}
+
+func (s *conflictSession) Get(k []byte) ([]byte, error) {
+	if s.reads != nil {
+		s.reads.addKey(k)
+	}
+
+	return s.Session.Get(k)
+}
+
+func (s *conflictSession) Exists(k []byte) (bool, error) {
+	if s.reads != nil {
+		s.reads.addKey(k)
+	}
+
+	return s.Session.Exists(k)
+}
+
+// Iterator records the bounds of the iterator, rather than the keys it returns,
+// to also detect the keys inserted in the range by other transactions.
+func (s *conflictSession) Iterator(opts *engine.IterOptions) (engine.Iterator, error) {
+	if s.reads != nil {
+		if opts != nil {
+			s.reads.addRange(opts.LowerBound, opts.UpperBound)
+		} else {
+			s.reads.addRange(nil, nil)
+		}
+	}
+
+	return s.Session.Iterator(opts)
+}
+
+func (s *conflictSession) Insert(k, v []byte) error {
+	err := s.Session.Insert(k, v)
+	if err != nil {
+		return err
+	}
+
+	s.track(k)
+	return nil
+}
+
+func (s *conflictSession) Put(k, v []byte) error {
+	err := s.Session.Put(k, v)
+	if err != nil {
+		return err
+	}
+
+	s.track(k)
+	return nil
+}
+
+func (s *conflictSession) Merge(k []byte, d engine.Delta) error {
+	err := engine.Merge(s.Session, k, d)
+	if err != nil {
+		return err
+	}
+
+	s.track(k)
+	return nil
+}
+
+func (s *conflictSession) Delete(k []byte) error {
+	err := s.Session.Delete(k)
+	if err != nil {
+		return err
+	}
+
+	s.track(k)
+	return nil
+}
+
+func (s *conflictSession) DeleteRange(start, end []byte) error {
+	it, err := s.Session.Iterator(&engine.IterOptions{
+		LowerBound: start,
+		UpperBound: end,
+	})
+	if err != nil {
+		return err
+	}
+
+	for it.First(); it.Valid(); it.Next() {
+		s.track(bytes.Clone(it.Key()))
+	}
+	err = it.Error()
+	if cerr := it.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.Session.DeleteRange(start, end)
+}
+
+// Commit returns ErrWriteConflict if one of the modified keys, one of
+// the keys read by a serializable session, or one of the keys read to check
+// a constraint, was committed by another transaction since the session was created.
+// The session is not closed in that case.
+func (s *conflictSession) Commit() error {
+	reads := s.reads
+	if reads == nil {
+		reads = s.constraintReads
+	}
+
+	err := s.conflicts.commit(s.version, s.keys, reads, s.Session.Commit)
+	if err != nil {
+		return err
+	}
+
+	s.release()
+	return nil
+}
+
+func (s *conflictSession) Close() error {
+	err := s.Session.Close()
+	s.release()
+	return err
+}
+
+func (s *conflictSession) release() {
+	if s.done {
+		return
+	}
+	s.done = true
+
+	s.conflicts.done(s.version)
+}
@@ -0,0 +1,240 @@
+package database_test
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/chaisql/chai"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConcurrentWrites(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+	}{
+		{"Memory", ":memory:"},
+		{"Pebble", filepath.Join(t.TempDir(), "db")},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, err := chai.OpenWith(test.path, &chai.Options{ConcurrentWrites: true})
+			require.NoError(t, err)
+			defer db.Close()
+
+			err = db.Exec(`
+				CREATE TABLE test (a INTEGER PRIMARY KEY, b INT);
+				INSERT INTO test (a, b) VALUES (1, 1), (2, 2);
+			`)
+			require.NoError(t, err)
+
+			begin := func(t *testing.T) *chai.Tx {
+				t.Helper()
+
+				conn, err := db.Connect()
+				require.NoError(t, err)
+				t.Cleanup(func() { conn.Close() })
+
+				tx, err := conn.Begin(true)
+				require.NoError(t, err)
+				return tx
+			}
+
+			sum := func(t *testing.T) int {
+				t.Helper()
+
+				r, err := db.QueryRow(`SELECT SUM(b) FROM test`)
+				require.NoError(t, err)
+				var n int
+				require.NoError(t, r.Scan(&n))
+				return n
+			}
+
+			t.Run("No conflict", func(t *testing.T) {
+				tx1 := begin(t)
+				tx2 := begin(t)
+
+				require.NoError(t, tx1.Exec(`UPDATE test SET b = b + 10 WHERE a = 1`))
+				require.NoError(t, tx2.Exec(`UPDATE test SET b = b + 10 WHERE a = 2`))
+
+				require.NoError(t, tx2.Commit())
+				require.NoError(t, tx1.Commit())
+				require.Equal(t, 23, sum(t))
+			})
+
+			t.Run("Conflict", func(t *testing.T) {
+				tx1 := begin(t)
+				tx2 := begin(t)
+
+				require.NoError(t, tx1.Exec(`UPDATE test SET b = 100 WHERE a = 1`))
+				require.NoError(t, tx2.Exec(`UPDATE test SET b = 200 WHERE a = 1`))
+
+				require.NoError(t, tx2.Commit())
+				err := tx1.Commit()
+				require.True(t, chai.IsWriteConflictError(err), err)
+				require.NoError(t, tx1.Rollback())
+				require.Equal(t, 212, sum(t))
+			})
+
+			beginSerializable := func(t *testing.T) *chai.Tx {
+				t.Helper()
+
+				conn, err := db.Connect()
+				require.NoError(t, err)
+				t.Cleanup(func() { conn.Close() })
+
+				tx, err := conn.BeginTx(&chai.TxOptions{Serializable: true})
+				require.NoError(t, err)
+				return tx
+			}
+
+			t.Run("Serializable", func(t *testing.T) {
+				require.NoError(t, db.Exec(`CREATE TABLE oncall (name TEXT PRIMARY KEY, available BOOL); INSERT INTO oncall VALUES ('alice', true), ('bob', true)`))
+
+				available := func(t *testing.T, tx *chai.Tx) int {
+					t.Helper()
+
+					r, err := tx.QueryRow(`SELECT COUNT(*) FROM oncall WHERE available`)
+					require.NoError(t, err)
+					var n int
+					require.NoError(t, r.Scan(&n))
+					return n
+				}
+
+				// write skew: both transactions read the rows modified by the other one
+				tx1 := beginSerializable(t)
+				tx2 := beginSerializable(t)
+				require.Equal(t, 2, available(t, tx1))
+				require.Equal(t, 2, available(t, tx2))
+				require.NoError(t, tx1.Exec(`UPDATE oncall SET available = false WHERE name = 'alice'`))
+				require.NoError(t, tx2.Exec(`UPDATE oncall SET available = false WHERE name = 'bob'`))
+
+				require.NoError(t, tx1.Commit())
+				err := tx2.Commit()
+				require.True(t, chai.IsWriteConflictError(err), err)
+				require.NoError(t, tx2.Rollback())
+
+				// rows inserted in a range read by a serializable transaction
+				tx1 = beginSerializable(t)
+				r, err := tx1.QueryRow(`SELECT COUNT(*) FROM test WHERE a > 100`)
+				require.NoError(t, err)
+				var n int
+				require.NoError(t, r.Scan(&n))
+				require.Equal(t, 0, n)
+				require.NoError(t, tx1.Exec(`INSERT INTO oncall VALUES ('carol', true)`))
+
+				require.NoError(t, db.Exec(`INSERT INTO test (a, b) VALUES (101, 0)`))
+				err = tx1.Commit()
+				require.True(t, chai.IsWriteConflictError(err), err)
+				require.NoError(t, tx1.Rollback())
+
+				// rows modified by others that were not read don't conflict
+				tx1 = beginSerializable(t)
+				tx2 = begin(t)
+				require.NoError(t, tx2.Exec(`UPDATE test SET b = b + 1 WHERE a = 1`))
+				require.NoError(t, tx2.Commit())
+				_, err = tx1.QueryRow(`SELECT b FROM test WHERE a = 2`)
+				require.NoError(t, err)
+				require.NoError(t, tx1.Exec(`UPDATE oncall SET available = true WHERE name = 'alice'`))
+				require.NoError(t, tx1.Commit())
+
+				// snapshot transactions allow write skew
+				tx1 = begin(t)
+				tx2 = begin(t)
+				require.Equal(t, 2, available(t, tx1))
+				require.Equal(t, 2, available(t, tx2))
+				require.NoError(t, tx1.Exec(`UPDATE oncall SET available = false WHERE name = 'alice'`))
+				require.NoError(t, tx2.Exec(`UPDATE oncall SET available = false WHERE name = 'bob'`))
+				require.NoError(t, tx1.Commit())
+				require.NoError(t, tx2.Commit())
+				require.NoError(t, db.Exec(`DROP TABLE oncall`))
+			})
+
+			t.Run("Unique", func(t *testing.T) {
+				require.NoError(t, db.Exec(`CREATE TABLE users (id INT PRIMARY KEY, email TEXT UNIQUE)`))
+				defer func() { require.NoError(t, db.Exec(`DROP TABLE users`)) }()
+
+				count := func(t *testing.T) int {
+					t.Helper()
+
+					r, err := db.QueryRow(`SELECT COUNT(*) FROM users WHERE email = 'a@x'`)
+					require.NoError(t, err)
+					var n int
+					require.NoError(t, r.Scan(&n))
+					return n
+				}
+
+				// the index keys contain the primary key, the transactions
+				// conflict on the unique value they both checked
+				tx1 := begin(t)
+				tx2 := begin(t)
+				require.NoError(t, tx1.Exec(`INSERT INTO users (id, email) VALUES (1, 'a@x')`))
+				require.NoError(t, tx2.Exec(`INSERT INTO users (id, email) VALUES (2, 'a@x')`))
+
+				require.NoError(t, tx1.Commit())
+				err := tx2.Commit()
+				require.True(t, chai.IsWriteConflictError(err), err)
+				require.NoError(t, tx2.Rollback())
+				require.Equal(t, 1, count(t))
+
+				// updates setting the same value
+				require.NoError(t, db.Exec(`INSERT INTO users (id, email) VALUES (3, 'b@x'), (4, 'c@x')`))
+				tx1 = begin(t)
+				tx2 = begin(t)
+				require.NoError(t, tx1.Exec(`UPDATE users SET email = 'd@x' WHERE id = 3`))
+				require.NoError(t, tx2.Exec(`UPDATE users SET email = 'd@x' WHERE id = 4`))
+				require.NoError(t, tx2.Commit())
+				err = tx1.Commit()
+				require.True(t, chai.IsWriteConflictError(err), err)
+				require.NoError(t, tx1.Rollback())
+
+				// different values don't conflict
+				tx1 = begin(t)
+				tx2 = begin(t)
+				require.NoError(t, tx1.Exec(`INSERT INTO users (id, email) VALUES (5, 'e@x')`))
+				require.NoError(t, tx2.Exec(`INSERT INTO users (id, email) VALUES (6, 'f@x')`))
+				require.NoError(t, tx2.Commit())
+				require.NoError(t, tx1.Commit())
+			})
+
+			t.Run("Schema change", func(t *testing.T) {
+				tx := begin(t)
+				require.NoError(t, tx.Exec(`INSERT INTO test (a, b) VALUES (3, 3)`))
+
+				require.NoError(t, db.Exec(`CREATE INDEX test_b ON test (b)`))
+
+				err := tx.Commit()
+				require.True(t, chai.IsWriteConflictError(err), err)
+				require.NoError(t, tx.Rollback())
+
+				report, err := db.Check(context.Background())
+				require.NoError(t, err)
+				require.True(t, report.OK(), report.Problems)
+			})
+
+			t.Run("Sequences", func(t *testing.T) {
+				require.NoError(t, db.Exec(`CREATE TABLE norowid (a INT)`))
+
+				var wg sync.WaitGroup
+				for i := 0; i < 10; i++ {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						err := db.Exec(`INSERT INTO norowid (a) VALUES (1), (2)`)
+						require.NoError(t, err)
+					}()
+				}
+				wg.Wait()
+
+				r, err := db.QueryRow(`SELECT COUNT(*) FROM norowid`)
+				require.NoError(t, err)
+				var n int
+				require.NoError(t, r.Scan(&n))
+				require.Equal(t, 20, n)
+			})
+		})
+	}
+}
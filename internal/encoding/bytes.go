@@ -22,6 +22,36 @@ func DecodeBlob(b []byte) ([]byte, int) {
 	return b[n : n+int(l)], 1 + n + int(l)
 }
 
+// EncodeBlobRef encodes a reference to a blob stored outside of its row,
+// made of the identifier of the blob and its size.
+func EncodeBlobRef(dst []byte, id, size uint64) []byte {
+	dst = append(dst, BlobRefValue)
+	dst = binary.AppendUvarint(dst, id)
+	return binary.AppendUvarint(dst, size)
+}
+
+func DecodeBlobRef(b []byte) (id, size uint64, n int) {
+	// skip type
+	n = 1
+	id, m := binary.Uvarint(b[n:])
+	n += m
+	size, m = binary.Uvarint(b[n:])
+	return id, size, n + m
+}
+
+// EncodeDictionaryRef encodes a reference to a text stored
+// in the dictionary of its column, made of its identifier.
+func EncodeDictionaryRef(dst []byte, id uint64) []byte {
+	dst = append(dst, DictionaryRefValue)
+	return binary.AppendUvarint(dst, id)
+}
+
+func DecodeDictionaryRef(b []byte) (id uint64, n int) {
+	// skip type
+	id, n = binary.Uvarint(b[1:])
+	return id, n + 1
+}
+
 func EncodeText(dst []byte, x string) []byte {
 	// encode the length as a varint
 	buf := make([]byte, binary.MaxVarintLen64+1)
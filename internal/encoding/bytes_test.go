@@ -57,3 +57,26 @@ func TestEncodeDecodeBlob(t *testing.T) {
 		})
 	}
 }
+
+func TestEncodeDecodeBlobRef(t *testing.T) {
+	tests := []struct {
+		id, size uint64
+	}{
+		{1, 0},
+		{1, 1 << 20},
+		{1 << 40, 1 << 33},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%d/%d", test.id, test.size), func(t *testing.T) {
+			got := encoding.EncodeBlobRef(nil, test.id, test.size)
+			require.Equal(t, encoding.BlobRefValue, got[0])
+			require.Equal(t, len(got), encoding.Skip(got))
+
+			id, size, n := encoding.DecodeBlobRef(got)
+			require.Equal(t, test.id, id)
+			require.Equal(t, test.size, size)
+			require.Equal(t, len(got), n)
+		})
+	}
+}
@@ -16,6 +16,11 @@ func EncodeNull(dst []byte) []byte {
 	return append(dst, byte(NullValue))
 }
 
+// EncodeNullLast encodes a NULL that sorts after the values of every other type.
+func EncodeNullLast(dst []byte) []byte {
+	return append(dst, byte(NullLastValue))
+}
+
 // Desc changes the type of the encoded value to its descending counterpart.
 // It is meant to be used in combination with one of the Encode* functions.
 //
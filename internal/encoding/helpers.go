@@ -47,19 +47,33 @@ func Skip(b []byte) int {
 	}
 
 	switch b[0] {
-	case NullValue, FalseValue, TrueValue, DESC_NullValue, DESC_FalseValue, DESC_TrueValue:
+	case NullValue, FalseValue, TrueValue, DESC_NullValue, DESC_FalseValue, DESC_TrueValue,
+		NullLastValue, DESC_NullLastValue:
 		return 1
 	case Int8Value, Uint8Value, DESC_Int8Value, DESC_Uint8Value:
 		return 2
 	case Int16Value, Uint16Value, DESC_Int16Value, DESC_Uint16Value:
 		return 3
-	case Int32Value, Uint32Value, DESC_Int32Value, DESC_Uint32Value:
+	case Int32Value, Uint32Value, Float32Value, DESC_Int32Value, DESC_Uint32Value, DESC_Float32Value:
 		return 5
 	case Int64Value, Uint64Value, Float64Value, DESC_Int64Value, DESC_Uint64Value, DESC_Float64Value:
 		return 9
-	case TextValue, BlobValue, DESC_TextValue, DESC_BlobValue:
+	case TextValue, BlobValue, JSONValue, DESC_TextValue, DESC_BlobValue, DESC_JSONValue:
 		l, n := binary.Uvarint(b[1:])
 		return n + int(l) + 1
+	case UUIDValue, DESC_UUIDValue:
+		return 17
+	case InetValue, CidrValue, DESC_InetValue, DESC_CidrValue:
+		_, _, n := DecodeNetwork(b)
+		return n
+	case PointValue, DESC_PointValue, IntervalValue, DESC_IntervalValue:
+		return 25
+	case BlobRefValue:
+		_, _, n := DecodeBlobRef(b)
+		return n
+	case DictionaryRefValue:
+		_, n := DecodeDictionaryRef(b)
+		return n
 	case ArrayValue, DESC_ArrayValue:
 		return 1 + SkipArray(b[1:])
 	case ObjectValue, DESC_ObjectValue:
@@ -148,8 +162,8 @@ func compareNextValue(a, b []byte) (cmp int, n int) {
 
 	// then compare values
 	switch a[0] {
-	case TombstoneValue, NullValue, FalseValue, TrueValue,
-		DESC_NullValue, DESC_FalseValue, DESC_TrueValue:
+	case TombstoneValue, NullValue, FalseValue, TrueValue, NullLastValue,
+		DESC_NullValue, DESC_FalseValue, DESC_TrueValue, DESC_NullLastValue:
 		return 0, 1
 	}
 
@@ -187,13 +201,13 @@ func compareNonEmptyValues(t byte, a, b []byte) (cmp int, n int) {
 	switch t {
 	case Int64Value, Uint64Value, Float64Value:
 		return bytes.Compare(a[1:9], b[1:9]), 9
-	case Int32Value, Uint32Value:
+	case Int32Value, Uint32Value, Float32Value:
 		return bytes.Compare(a[1:5], b[1:5]), 5
 	case Int16Value, Uint16Value:
 		return bytes.Compare(a[1:3], b[1:3]), 3
 	case Int8Value, Uint8Value:
 		return bytes.Compare(a[1:2], b[1:2]), 2
-	case TextValue, BlobValue:
+	case TextValue, BlobValue, JSONValue:
 		l, n := binary.Uvarint(a[1:])
 		n++
 		enda := n + int(l)
@@ -201,6 +215,14 @@ func compareNonEmptyValues(t byte, a, b []byte) (cmp int, n int) {
 		n++
 		endb := n + int(l)
 		return bytes.Compare(a[n:enda], b[n:endb]), enda
+	case UUIDValue:
+		return bytes.Compare(a[1:17], b[1:17]), 17
+	case InetValue, CidrValue:
+		_, _, n := DecodeNetwork(a)
+		_, _, nb := DecodeNetwork(b)
+		return bytes.Compare(a[1:n], b[1:nb]), n
+	case PointValue, IntervalValue:
+		return bytes.Compare(a[1:25], b[1:25]), 25
 	case ArrayValue:
 		la, na := binary.Uvarint(a[1:])
 		lb, nb := binary.Uvarint(b[1:])
@@ -344,7 +366,7 @@ func abbreviatedValue(key []byte) uint64 {
 		}
 		x := DecodeUint16(key[1:])
 		return uint64(x)
-	case Uint32Value, Int32Value:
+	case Uint32Value, Int32Value, Float32Value:
 		if len(key) < 5 {
 			return 0
 		}
@@ -356,7 +378,7 @@ func abbreviatedValue(key []byte) uint64 {
 		}
 		x := DecodeUint64(key[1:])
 		return uint64(x) >> 24
-	case TextValue, BlobValue:
+	case TextValue, BlobValue, JSONValue:
 		var abbv uint64
 		l, n := binary.Uvarint(key[1:])
 		n++
@@ -367,6 +389,13 @@ func abbreviatedValue(key []byte) uint64 {
 			abbv |= uint64(key[i]) << (32 - uint64(i)*8)
 		}
 		return abbv
+	case UUIDValue, InetValue, CidrValue, PointValue, IntervalValue:
+		var abbv uint64
+		// put the first 5 bytes of the value
+		for i := 0; i < 5 && i+1 < len(key); i++ {
+			abbv |= uint64(key[i+1]) << (32 - uint64(i)*8)
+		}
+		return abbv
 	case ArrayValue, ObjectValue:
 		key = key[1:]
 		l, n := binary.Uvarint(key)
@@ -107,19 +107,19 @@ func TestCompare(t *testing.T) {
 		t.Run(fmt.Sprintf("Compare(%s, %s)", test.k1, test.k2), func(t *testing.T) {
 			a1, err := testutil.ParseExprList(t, test.k1).EvalAll(&environment.Environment{})
 			require.NoError(t, err)
-			k1 := mustNewKey(t, 0, 0, a1...)
+			k1 := mustNewKey(t, 0, nil, a1...)
 
 			a2, err := testutil.ParseExprList(t, test.k2).EvalAll(&environment.Environment{})
 			require.NoError(t, err)
-			k2 := mustNewKey(t, 0, 0, a2...)
+			k2 := mustNewKey(t, 0, nil, a2...)
 
 			require.Equal(t, test.cmp, encoding.Compare(k1, k2))
 
 			// compare abbreviated keys
 
 			// prepend namespace
-			kk1 := mustNewKey(t, 1, 0, a1...)
-			kk2 := mustNewKey(t, 1, 0, a2...)
+			kk1 := mustNewKey(t, 1, nil, a1...)
+			kk2 := mustNewKey(t, 1, nil, a2...)
 
 			cmp := int64(encoding.AbbreviatedKey(kk1) - encoding.AbbreviatedKey(kk2))
 			if test.cmp < 0 {
@@ -154,7 +154,7 @@ func TestCompareOrder(t *testing.T) {
 		t.Run(fmt.Sprintf("CompareOrder(%s, %s)", test.k1, test.k2), func(t *testing.T) {
 			a1, err := testutil.ParseExprList(t, test.k1).EvalAll(&environment.Environment{})
 			require.NoError(t, err)
-			order := tree.SortOrder(0)
+			order := tree.SortOrder(nil)
 			for i := range a1 {
 				if test.order[i] {
 					order = order.SetDesc(i)
@@ -249,7 +249,7 @@ func TestAbbreviatedKey(t *testing.T) {
 		t.Run(fmt.Sprintf("AbbreviatedKey(%s)", test.k), func(t *testing.T) {
 			a, err := testutil.ParseExprList(t, test.k).EvalAll(&environment.Environment{})
 			require.NoError(t, err)
-			k := mustNewKey(t, 0, 0, a...)
+			k := mustNewKey(t, 0, nil, a...)
 
 			require.Equal(t, test.want, encoding.AbbreviatedKey(k))
 		})
@@ -270,8 +270,8 @@ func TestSeparator(t *testing.T) {
 			require.NoError(t, err)
 			v2, err := testutil.ParseExprList(t, test.k2).EvalAll(&environment.Environment{})
 			require.NoError(t, err)
-			k1 := mustNewKey(t, 0, 0, v1...)
-			k2 := mustNewKey(t, 0, 0, v2...)
+			k1 := mustNewKey(t, 0, nil, v1...)
+			k2 := mustNewKey(t, 0, nil, v2...)
 			sep := encoding.Separator(nil, k1, k2)
 			require.LessOrEqual(t, encoding.Compare(k1, sep), 0)
 			require.Less(t, encoding.Compare(sep, k2), 0)
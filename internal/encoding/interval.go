@@ -0,0 +1,38 @@
+package encoding
+
+import "encoding/binary"
+
+// MicrosPerDay is the number of microseconds in the days of intervals.
+const MicrosPerDay = 24 * 60 * 60 * 1_000_000
+
+// EncodeInterval encodes an interval made of months, days and microseconds.
+// Intervals are sorted by their length, counting 30 days per month
+// and 24 hours per day, followed by their months and days so that
+// intervals of the same length with different fields have different keys.
+// The length is encoded as a number of days and the remaining microseconds,
+// which can't overflow.
+func EncodeInterval(dst []byte, months, days int32, micros int64) []byte {
+	d := int64(months)*30 + int64(days) + micros/MicrosPerDay
+	r := micros % MicrosPerDay
+	if r < 0 {
+		r += MicrosPerDay
+		d--
+	}
+
+	dst = append(dst, IntervalValue)
+	dst = binary.BigEndian.AppendUint64(dst, uint64(d)^1<<63)
+	dst = binary.BigEndian.AppendUint64(dst, uint64(r))
+	dst = binary.BigEndian.AppendUint32(dst, uint32(months)^1<<31)
+	return binary.BigEndian.AppendUint32(dst, uint32(days)^1<<31)
+}
+
+// DecodeInterval decodes an interval encoded by EncodeInterval.
+func DecodeInterval(b []byte) (months, days int32, micros int64, n int) {
+	d := int64(binary.BigEndian.Uint64(b[1:9]) ^ 1<<63)
+	r := int64(binary.BigEndian.Uint64(b[9:17]))
+	months = int32(binary.BigEndian.Uint32(b[17:21]) ^ 1<<31)
+	days = int32(binary.BigEndian.Uint32(b[21:25]) ^ 1<<31)
+
+	micros = (d-int64(months)*30-int64(days))*MicrosPerDay + r
+	return months, days, micros, 25
+}
@@ -0,0 +1,53 @@
+package encoding_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeDecodeInterval(t *testing.T) {
+	tests := []struct {
+		months, days int32
+		micros       int64
+	}{
+		{0, 0, 0},
+		{1, 2, 3},
+		{-14, 3, -5 * encoding.MicrosPerDay / 2},
+		{0, 0, -1},
+		{math.MaxInt32, math.MinInt32, math.MaxInt64},
+		{math.MinInt32, math.MaxInt32, math.MinInt64},
+	}
+
+	for _, test := range tests {
+		enc := encoding.EncodeInterval(nil, test.months, test.days, test.micros)
+		require.Equal(t, len(enc), encoding.Skip(enc))
+
+		months, days, micros, n := encoding.DecodeInterval(enc)
+		require.Equal(t, test.months, months)
+		require.Equal(t, test.days, days)
+		require.Equal(t, test.micros, micros)
+		require.Equal(t, len(enc), n)
+	}
+}
+
+func TestIntervalOrder(t *testing.T) {
+	// sorted by length, then by months and days
+	sorted := [][]byte{
+		encoding.EncodeInterval(nil, -1, 0, 0),
+		encoding.EncodeInterval(nil, 0, 0, -1),
+		encoding.EncodeInterval(nil, 0, 0, 0),
+		encoding.EncodeInterval(nil, 0, 0, encoding.MicrosPerDay-1),
+		encoding.EncodeInterval(nil, 0, 0, encoding.MicrosPerDay),
+		encoding.EncodeInterval(nil, 0, 1, 0),
+		encoding.EncodeInterval(nil, 0, 30, 0),
+		encoding.EncodeInterval(nil, 1, 0, 0),
+		encoding.EncodeInterval(nil, 0, 31, 0),
+	}
+
+	for i := 1; i < len(sorted); i++ {
+		require.Negative(t, encoding.Compare(sorted[i-1], sorted[i]), "%d", i)
+	}
+}
@@ -0,0 +1,44 @@
+package encoding
+
+import (
+	"encoding/binary"
+)
+
+// EncodeJSON encodes a JSON document already converted to its binary form.
+// The document is prefixed by its size, so that it can be skipped
+// without being traversed.
+func EncodeJSON(dst []byte, doc []byte) []byte {
+	dst = append(dst, JSONValue)
+	dst = binary.AppendUvarint(dst, uint64(len(doc)))
+	return append(dst, doc...)
+}
+
+func DecodeJSON(b []byte) ([]byte, int) {
+	// skip type
+	b = b[1:]
+	// decode the length as a varint
+	l, n := binary.Uvarint(b)
+	return b[n : n+int(l)], 1 + n + int(l)
+}
+
+// EncodeArrayHeader encodes the header of an array of n values.
+// It must be followed by the n encoded values.
+func EncodeArrayHeader(dst []byte, n int) []byte {
+	dst = append(dst, ArrayValue)
+	return binary.AppendUvarint(dst, uint64(n))
+}
+
+// EncodeObjectHeader encodes the header of an object of n fields.
+// It must be followed by the n fields, each encoded as a text
+// followed by the encoded value.
+func EncodeObjectHeader(dst []byte, n int) []byte {
+	dst = append(dst, ObjectValue)
+	return binary.AppendUvarint(dst, uint64(n))
+}
+
+// DecodeHeader returns the number of elements of an encoded array or object,
+// and the size of its header.
+func DecodeHeader(b []byte) (int, int) {
+	l, n := binary.Uvarint(b[1:])
+	return int(l), 1 + n
+}
@@ -0,0 +1,35 @@
+package encoding
+
+import "net/netip"
+
+// EncodeNetwork encodes a network address with the given type, InetValue or CidrValue,
+// followed by its family (4 or 6), its address in big-endian order and the length
+// of its prefix. IPv4 addresses are sorted before IPv6 addresses, then addresses
+// are sorted by their bytes and their prefix length, which makes all the addresses
+// of a network contiguous.
+func EncodeNetwork(dst []byte, t byte, p netip.Prefix) []byte {
+	addr := p.Addr()
+	if addr.Is4() {
+		dst = append(dst, t, 4)
+	} else {
+		dst = append(dst, t, 6)
+	}
+	dst = append(dst, addr.AsSlice()...)
+	return append(dst, byte(p.Bits()))
+}
+
+// DecodeNetwork decodes a network address encoded by EncodeNetwork and returns its type.
+func DecodeNetwork(b []byte) (netip.Prefix, byte, int) {
+	t := b[0]
+	if t > 128 {
+		t = 255 - t
+	}
+
+	if b[1] == 4 {
+		addr := netip.AddrFrom4([4]byte(b[2:6]))
+		return netip.PrefixFrom(addr, int(b[6])), t, 7
+	}
+
+	addr := netip.AddrFrom16([16]byte(b[2:18]))
+	return netip.PrefixFrom(addr, int(b[18])), t, 19
+}
@@ -0,0 +1,38 @@
+package encoding_test
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeDecodeNetwork(t *testing.T) {
+	tests := []string{"10.0.0.0/8", "10.1.2.3/32", "::1/128", "2001:db8::/32"}
+
+	for _, test := range tests {
+		p := netip.MustParsePrefix(test)
+		enc := encoding.EncodeNetwork(nil, encoding.InetValue, p)
+		require.Equal(t, len(enc), encoding.Skip(enc))
+
+		x, tp, n := encoding.DecodeNetwork(enc)
+		require.Equal(t, p, x)
+		require.Equal(t, encoding.InetValue, tp)
+		require.Equal(t, len(enc), n)
+	}
+
+	// the addresses of a network are sorted between its first and last address
+	key := func(s string) []byte {
+		k := encoding.EncodeInt(nil, 10)
+		k = encoding.EncodeNetwork(k, encoding.CidrValue, netip.MustParsePrefix(s))
+		return encoding.EncodeInt(k, 1)
+	}
+	sorted := []string{"10.0.0.0/0", "10.0.0.0/8", "10.0.0.0/16", "10.1.0.0/16", "10.255.255.255/32", "11.0.0.0/8", "::/0", "::1/128"}
+	for i := 1; i < len(sorted); i++ {
+		a, b := key(sorted[i-1]), key(sorted[i])
+		require.Negative(t, encoding.Compare(a, b), "%s < %s", sorted[i-1], sorted[i])
+		require.Positive(t, encoding.Compare(b, a))
+		require.LessOrEqual(t, encoding.AbbreviatedKey(a), encoding.AbbreviatedKey(b))
+	}
+}
@@ -107,6 +107,31 @@ func DecodeInt(b []byte) (int64, int) {
 	panic(fmt.Sprintf("invalid type %d", b[0]))
 }
 
+// DecodeUint decodes a non-negative integer encoded by EncodeUint or EncodeInt.
+func DecodeUint(b []byte) (uint64, int) {
+	t := b[0]
+	if t > 128 {
+		t = 255 - t
+	}
+
+	if t >= IntSmallValue+32 && t < Uint8Value {
+		return uint64(t - IntSmallValue - 32), 1
+	}
+
+	switch t {
+	case Uint8Value:
+		return uint64(DecodeUint8(b[1:])), 2
+	case Uint16Value:
+		return uint64(DecodeUint16(b[1:])), 3
+	case Uint32Value:
+		return uint64(DecodeUint32(b[1:])), 5
+	case Uint64Value:
+		return DecodeUint64(b[1:]), 9
+	}
+
+	panic(fmt.Sprintf("invalid type %d", b[0]))
+}
+
 func DecodeUint8(b []byte) uint8 {
 	return b[0]
 }
@@ -165,7 +190,21 @@ func EncodeFloat(dst []byte, x float64) []byte {
 	return EncodeFloat64(dst, x)
 }
 
+// EncodeFloat64 appends x to dst in an order-preserving form.
+// Infinities sort before and after all the other numbers, and -0 is
+// encoded as 0. All the NaN values are encoded the same way and
+// sort before -Inf, as they do with cmp.Compare.
+// The keys of the databases encoded before that are upgraded when they
+// are opened: changing this encoding requires a new version of the key
+// format, see currentKeyFormat in the database package.
 func EncodeFloat64(dst []byte, x float64) []byte {
+	if math.IsNaN(x) {
+		return write8(dst, byte(Float64Value), 0)
+	}
+	if x == 0 {
+		x = 0
+	}
+
 	fb := math.Float64bits(x)
 	if x >= 0 {
 		fb ^= 1 << 63
@@ -175,6 +214,36 @@ func EncodeFloat64(dst []byte, x float64) []byte {
 	return write8(dst, byte(Float64Value), fb)
 }
 
+// EncodeFloat32 appends x to dst in an order-preserving form,
+// following the same rules as EncodeFloat64.
+func EncodeFloat32(dst []byte, x float32) []byte {
+	if math.IsNaN(float64(x)) {
+		return write4(dst, byte(Float32Value), 0)
+	}
+	if x == 0 {
+		x = 0
+	}
+
+	fb := math.Float32bits(x)
+	if x >= 0 {
+		fb ^= 1 << 31
+	} else {
+		fb ^= 1<<32 - 1
+	}
+	return write4(dst, byte(Float32Value), fb)
+}
+
+func DecodeFloat32(b []byte) float32 {
+	x := DecodeUint32(b)
+
+	if (x & (1 << 31)) != 0 {
+		x ^= 1 << 31
+	} else {
+		x ^= 1<<32 - 1
+	}
+	return math.Float32frombits(x)
+}
+
 func DecodeFloat(b []byte) (float64, int) {
 	switch b[0] {
 	case Float64Value, DESC_Float64Value:
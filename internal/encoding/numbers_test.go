@@ -63,6 +63,32 @@ func TestEncodeDecodeInt(t *testing.T) {
 	}
 }
 
+func TestEncodeDecodeUint(t *testing.T) {
+	tests := []uint64{0, 31, 32, math.MaxUint8, math.MaxUint16, math.MaxUint32, math.MaxInt64, math.MaxInt64 + 1, math.MaxUint64}
+
+	var prev []byte
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%d", test), func(t *testing.T) {
+			got := encoding.EncodeUint(nil, test)
+			if test <= math.MaxInt64 {
+				// same encoding as signed integers
+				require.Equal(t, encoding.EncodeInt(nil, int64(test)), got)
+			}
+
+			// unsigned integers are sorted after all signed integers
+			require.Positive(t, encoding.Compare(got, encoding.EncodeInt(nil, -1)))
+			if prev != nil {
+				require.Positive(t, encoding.Compare(got, prev))
+			}
+			prev = got
+
+			x, n := encoding.DecodeUint(got)
+			require.Equal(t, test, x)
+			require.Equal(t, len(got), n)
+		})
+	}
+}
+
 func TestEncodeDecodeFloat(t *testing.T) {
 	tests := []struct {
 		input float64
@@ -82,3 +108,52 @@ func TestEncodeDecodeFloat(t *testing.T) {
 		})
 	}
 }
+
+func TestEncodeFloat64Order(t *testing.T) {
+	tests := []float64{math.NaN(), math.Inf(-1), -math.MaxFloat64, -3.14, -math.SmallestNonzeroFloat64, 0, math.SmallestNonzeroFloat64, 3.14, math.MaxFloat64, math.Inf(1)}
+
+	var prev []byte
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%f", test), func(t *testing.T) {
+			got := encoding.EncodeFloat64(nil, test)
+			if prev != nil {
+				require.Positive(t, encoding.Compare(got, prev))
+			}
+			prev = got
+
+			x, _ := encoding.DecodeFloat(got)
+			if math.IsNaN(test) {
+				require.True(t, math.IsNaN(x))
+			} else {
+				require.Equal(t, test, x)
+			}
+		})
+	}
+
+	t.Run("NaN", func(t *testing.T) {
+		nan := math.Float64frombits(math.Float64bits(math.NaN()) | 1<<63)
+		require.Equal(t, encoding.EncodeFloat64(nil, math.NaN()), encoding.EncodeFloat64(nil, nan))
+	})
+
+	t.Run("-0", func(t *testing.T) {
+		require.Equal(t, encoding.EncodeFloat64(nil, 0), encoding.EncodeFloat64(nil, math.Copysign(0, -1)))
+	})
+}
+
+func TestEncodeDecodeFloat32(t *testing.T) {
+	tests := []float32{-math.MaxFloat32, -3.14, -1, 0, math.SmallestNonzeroFloat32, 1, 3.14, math.MaxFloat32}
+
+	var prev []byte
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%f", test), func(t *testing.T) {
+			got := encoding.EncodeFloat32(nil, test)
+			require.Len(t, got, 5)
+			if prev != nil {
+				require.Positive(t, encoding.Compare(got, prev))
+			}
+			prev = got
+
+			require.Equal(t, test, encoding.DecodeFloat32(got[1:]))
+		})
+	}
+}
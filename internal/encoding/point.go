@@ -0,0 +1,64 @@
+package encoding
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// EncodePoint encodes a geographic point, followed by its position on
+// a Z-order curve and its latitude and longitude, as float64 values.
+// Points are sorted by their position on the curve, which keeps
+// points close to each other together: all the points of a bounding box
+// are sorted between the positions of its south-west and north-east corners.
+func EncodePoint(dst []byte, lat, lon float64) []byte {
+	// -0 and 0 must have the same encoding
+	if lat == 0 {
+		lat = 0
+	}
+	if lon == 0 {
+		lon = 0
+	}
+
+	dst = append(dst, PointValue)
+	dst = binary.BigEndian.AppendUint64(dst, ZOrder(lat, lon))
+	dst = binary.BigEndian.AppendUint64(dst, sortableFloat64(lat))
+	return binary.BigEndian.AppendUint64(dst, sortableFloat64(lon))
+}
+
+// DecodePoint decodes a point encoded by EncodePoint.
+func DecodePoint(b []byte) (lat, lon float64, n int) {
+	return DecodeFloat64(b[9:17]), DecodeFloat64(b[17:25]), 25
+}
+
+// ZOrder returns the position of a point on a Z-order curve, obtained
+// by interleaving the bits of its latitude and longitude, scaled on 32 bits.
+// The position increases with the latitude and the longitude.
+func ZOrder(lat, lon float64) uint64 {
+	return interleave(scaleCoord(lat, 90))<<1 | interleave(scaleCoord(lon, 180))
+}
+
+// scaleCoord maps a coordinate from [-max, max] to [0, math.MaxUint32].
+func scaleCoord(x, max float64) uint32 {
+	x = math.Max(-max, math.Min(max, x))
+	return uint32((x + max) / (2 * max) * math.MaxUint32)
+}
+
+// interleave spreads the bits of x over the even bits of a uint64.
+func interleave(x uint32) uint64 {
+	v := uint64(x)
+	v = (v | v<<16) & 0x0000FFFF0000FFFF
+	v = (v | v<<8) & 0x00FF00FF00FF00FF
+	v = (v | v<<4) & 0x0F0F0F0F0F0F0F0F
+	v = (v | v<<2) & 0x3333333333333333
+	v = (v | v<<1) & 0x5555555555555555
+	return v
+}
+
+// sortableFloat64 returns the bits of x as encoded by EncodeFloat64.
+func sortableFloat64(x float64) uint64 {
+	fb := math.Float64bits(x)
+	if x >= 0 {
+		return fb ^ 1<<63
+	}
+	return fb ^ (1<<64 - 1)
+}
@@ -0,0 +1,43 @@
+package encoding_test
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeDecodePoint(t *testing.T) {
+	tests := [][2]float64{{0, 0}, {48.8566, 2.3522}, {-33.8688, 151.2093}, {90, 180}, {-90, -180}}
+
+	for _, test := range tests {
+		enc := encoding.EncodePoint(nil, test[0], test[1])
+		require.Equal(t, len(enc), encoding.Skip(enc))
+
+		lat, lon, n := encoding.DecodePoint(enc)
+		require.Equal(t, test[0], lat)
+		require.Equal(t, test[1], lon)
+		require.Equal(t, len(enc), n)
+	}
+
+	require.Equal(t, encoding.EncodePoint(nil, 0, 0), encoding.EncodePoint(nil, -0.0, -0.0))
+}
+
+func TestPointBoundingBox(t *testing.T) {
+	// all the points of a box are sorted between its corners
+	sw := encoding.EncodePoint(nil, 45, -1)
+	ne := encoding.EncodePoint(nil, 52, 5)
+
+	r := rand.New(rand.NewSource(0))
+	for i := 0; i < 1000; i++ {
+		lat := 45 + r.Float64()*7
+		lon := -1 + r.Float64()*6
+		p := encoding.EncodePoint(nil, lat, lon)
+		require.GreaterOrEqual(t, encoding.Compare(p, sw), 0, "(%f, %f)", lat, lon)
+		require.LessOrEqual(t, encoding.Compare(p, ne), 0, "(%f, %f)", lat, lon)
+	}
+
+	require.LessOrEqual(t, encoding.ZOrder(45, -1), encoding.ZOrder(45, 5))
+	require.LessOrEqual(t, encoding.ZOrder(45, -1), encoding.ZOrder(52, -1))
+}
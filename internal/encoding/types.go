@@ -11,7 +11,9 @@ package encoding
 const (
 	TombstoneValue byte = 0
 
-	// 1: 1 type is free
+	// Header of the encoded rows, followed by the version
+	// of the row format. It is never used to encode a value.
+	RowFormatValue byte = 1
 
 	// Null
 	NullValue byte = 2
@@ -45,18 +47,44 @@ const (
 
 	// Floating point numbers
 	Float64Value byte = 90
+	Float32Value byte = 91
 
 	// 92 to 97: 6 types are free
 
 	// Text
 	TextValue byte = 98
 
-	// 101 to 105: 5 types are free
+	// Reference to a text stored in the dictionary of its column.
+	// Only used in row values, never in keys.
+	DictionaryRefValue byte = 99
+
+	// Network addresses, followed by their family (4 or 6),
+	// their address and the length of their prefix
+	InetValue byte = 100
+	CidrValue byte = 101
+
+	// Geographic points, followed by their position on a Z-order curve,
+	// their latitude and their longitude
+	PointValue byte = 102
 
 	// Binary
 	BlobValue byte = 103
 
-	// 104 to 109: 6 types are free
+	// Reference to a blob stored outside of its row.
+	// Only used in row values, never in keys.
+	BlobRefValue byte = 104
+
+	// UUIDs, encoded on 16 bytes
+	UUIDValue byte = 105
+
+	// JSON documents, prefixed by their size
+	JSONValue byte = 106
+
+	// Intervals, followed by their length in days and microseconds,
+	// their months and their days
+	IntervalValue byte = 107
+
+	// 108, 109: 2 types are free
 
 	// Arrays
 	ArrayValue byte = 110
@@ -66,16 +94,30 @@ const (
 	// Objects
 	ObjectValue byte = 120
 
-	// 121 to 127: 7 types are free
+	// 121 to 125: 5 types are free
+
+	// Null sorted after all the other types,
+	// used by keys placing NULLs at the opposite of their default position.
+	NullLastValue byte = 126
+
+	// 127: 1 type is free
 
 	// The second half of the byte is organized in reverse order, and it
 	// symmetrical to the first 128 values.
 
 	// DESC_ prefix means that the value is encoded in reverse order.
+	DESC_NullLastValue byte = 255 - NullLastValue
 	DESC_ObjectValue   byte = 255 - ObjectValue
 	DESC_ArrayValue    byte = 255 - ArrayValue
+	DESC_IntervalValue byte = 255 - IntervalValue
+	DESC_JSONValue     byte = 255 - JSONValue
+	DESC_UUIDValue     byte = 255 - UUIDValue
+	DESC_PointValue    byte = 255 - PointValue
+	DESC_CidrValue     byte = 255 - CidrValue
+	DESC_InetValue     byte = 255 - InetValue
 	DESC_BlobValue     byte = 255 - BlobValue
 	DESC_TextValue     byte = 255 - TextValue
+	DESC_Float32Value  byte = 255 - Float32Value
 	DESC_Float64Value  byte = 255 - Float64Value
 	DESC_Uint64Value   byte = 255 - Uint64Value
 	DESC_Uint32Value   byte = 255 - Uint32Value
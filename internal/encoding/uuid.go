@@ -0,0 +1,14 @@
+package encoding
+
+// EncodeUUID encodes a UUID on 16 bytes, in big-endian order:
+// UUIDs are sorted by their bytes, which sorts version 7 UUIDs by time.
+func EncodeUUID(dst []byte, x [16]byte) []byte {
+	dst = append(dst, UUIDValue)
+	return append(dst, x[:]...)
+}
+
+func DecodeUUID(b []byte) ([16]byte, int) {
+	var x [16]byte
+	copy(x[:], b[1:17])
+	return x, 17
+}
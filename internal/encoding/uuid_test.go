@@ -0,0 +1,30 @@
+package encoding_test
+
+import (
+	"testing"
+
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeDecodeUUID(t *testing.T) {
+	a := [16]byte{0x01, 0x90, 0xb6, 0xa4, 0x00, 0x00, 0x70, 0x00, 0x80}
+	b := a
+	b[15] = 1
+
+	enc := encoding.EncodeUUID(nil, a)
+	require.Len(t, enc, 17)
+	require.Equal(t, 17, encoding.Skip(enc))
+
+	x, n := encoding.DecodeUUID(enc)
+	require.Equal(t, a, x)
+	require.Equal(t, 17, n)
+
+	// keys are compared byte by byte, whatever the following values
+	ka := encoding.EncodeInt(encoding.EncodeUUID(encoding.EncodeInt(nil, 10), a), 2)
+	kb := encoding.EncodeInt(encoding.EncodeUUID(encoding.EncodeInt(nil, 10), b), 1)
+	require.Negative(t, encoding.Compare(ka, kb))
+	require.Positive(t, encoding.Compare(kb, ka))
+	require.Zero(t, encoding.Compare(ka, ka))
+	require.LessOrEqual(t, encoding.AbbreviatedKey(ka), encoding.AbbreviatedKey(kb))
+}
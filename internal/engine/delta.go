@@ -0,0 +1,82 @@
+package engine
+
+import (
+	"bytes"
+
+	"github.com/cockroachdb/errors"
+)
+
+// A MergeSession can store the new value of an existing key
+// as a delta against its previous value, which avoids rewriting
+// the whole value when only a small part of it changes.
+type MergeSession interface {
+	Session
+	// Merge applies a delta to the value of an existing key.
+	Merge(k []byte, d Delta) error
+}
+
+// Delta replaces the Removed bytes of a value starting at Offset
+// by the Inserted bytes.
+type Delta struct {
+	Offset   int
+	Removed  int
+	Inserted []byte
+}
+
+// Diff returns the delta turning old into new. It replaces
+// the bytes between the common prefix and the common suffix
+// of both values.
+func Diff(old, new []byte) Delta {
+	n := min(len(old), len(new))
+
+	prefix := 0
+	for prefix < n && old[prefix] == new[prefix] {
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < n-prefix && old[len(old)-1-suffix] == new[len(new)-1-suffix] {
+		suffix++
+	}
+
+	return Delta{
+		Offset:   prefix,
+		Removed:  len(old) - prefix - suffix,
+		Inserted: new[prefix : len(new)-suffix],
+	}
+}
+
+// Apply returns the value v with the delta applied.
+// v is not modified.
+func (d Delta) Apply(v []byte) ([]byte, error) {
+	if d.Offset < 0 || d.Removed < 0 || d.Offset+d.Removed > len(v) {
+		return nil, errors.Errorf("delta out of range: offset %d, removed %d, value of %d bytes", d.Offset, d.Removed, len(v))
+	}
+
+	var buf bytes.Buffer
+	buf.Grow(len(v) - d.Removed + len(d.Inserted))
+	buf.Write(v[:d.Offset])
+	buf.Write(d.Inserted)
+	buf.Write(v[d.Offset+d.Removed:])
+	return buf.Bytes(), nil
+}
+
+// Merge applies the delta d to the value of k, using s.Merge if s
+// is a MergeSession, or by storing the whole new value otherwise.
+func Merge(s Session, k []byte, d Delta) error {
+	if ms, ok := s.(MergeSession); ok {
+		return ms.Merge(k, d)
+	}
+
+	v, err := s.Get(k)
+	if err != nil {
+		return err
+	}
+
+	v, err = d.Apply(v)
+	if err != nil {
+		return err
+	}
+
+	return s.Put(k, v)
+}
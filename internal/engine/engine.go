@@ -23,6 +23,24 @@ type Engine interface {
 	NewTransientSession() Session
 }
 
+// A ConcurrentEngine supports write sessions that can run concurrently.
+// A concurrent session keeps its changes private until it is committed
+// and never uses the rollback segment. Detecting conflicts between
+// concurrent sessions is the responsibility of the caller.
+type ConcurrentEngine interface {
+	Engine
+	NewConcurrentSession() Session
+}
+
+// A ConstraintReader is a session that detects conflicts between concurrent
+// transactions, and must be told about the ranges of keys read to check
+// a constraint, such as the uniqueness of a value. The session conflicts
+// with the transactions committed since it began that modified a key
+// of these ranges, even if its other reads are not tracked.
+type ConstraintReader interface {
+	ReadForConstraint(start, end []byte)
+}
+
 type Session interface {
 	Commit() error
 	Close() error
@@ -44,6 +62,9 @@ type Iterator interface {
 	Close() error
 	First() bool
 	Last() bool
+	// SeekGE moves the iterator to the first key greater than
+	// or equal to the given key.
+	SeekGE(key []byte) bool
 	Valid() bool
 	Next() bool
 	Prev() bool
@@ -0,0 +1,138 @@
+// Package memory implements an engine that keeps every key in memory.
+// It doesn't write anything to disk unless explicitly asked to with SaveSnapshot,
+// which makes it a good fit for tests, caches and other short-lived databases.
+package memory
+
+import (
+	"sync"
+
+	"github.com/chaisql/chai/internal/engine"
+	"github.com/cockroachdb/errors"
+)
+
+var _ engine.ConcurrentEngine = (*Engine)(nil)
+
+// Engine is an in-memory engine.
+// The data is stored in an immutable sorted tree: read sessions capture
+// the current root and are never affected by concurrent writes, and write
+// sessions only publish their changes when they are committed.
+// Because of that, the engine doesn't need a rollback segment.
+type Engine struct {
+	mu     sync.RWMutex
+	root   *node
+	closed bool
+}
+
+// NewEngine returns an empty in-memory engine.
+func NewEngine() *Engine {
+	return &Engine{}
+}
+
+func (e *Engine) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.closed {
+		return errors.New("engine already closed")
+	}
+	e.closed = true
+	e.root = nil
+
+	return nil
+}
+
+// Rollback is a no-op: uncommitted changes are discarded
+// when the write session is closed.
+func (e *Engine) Rollback() error {
+	return nil
+}
+
+// Recover is a no-op: an in-memory engine can't be left
+// in an inconsistent state by a crash.
+func (e *Engine) Recover() error {
+	return nil
+}
+
+// LockSharedSnapshot is a no-op, every session reads from
+// its own immutable version of the tree.
+func (e *Engine) LockSharedSnapshot() {}
+
+// UnlockSharedSnapshot is a no-op.
+func (e *Engine) UnlockSharedSnapshot() {}
+
+// CleanupTransientNamespaces is a no-op, transient sessions
+// never write to the shared tree.
+func (e *Engine) CleanupTransientNamespaces() error {
+	return nil
+}
+
+func (e *Engine) load() *node {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	return e.root
+}
+
+func (e *Engine) publish(root *node) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.closed {
+		return errors.New("engine closed")
+	}
+	e.root = root
+
+	return nil
+}
+
+// merge publishes the tree of a concurrent session. If other sessions were committed
+// since base was loaded, the changes of the session are replayed on the latest tree.
+func (e *Engine) merge(base, root *node, ops []func(root *node) *node) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.closed {
+		return errors.New("engine closed")
+	}
+
+	if e.root != base {
+		root = e.root
+		for _, op := range ops {
+			root = op(root)
+		}
+	}
+	e.root = root
+
+	return nil
+}
+
+func (e *Engine) NewSnapshotSession() engine.Session {
+	return &SnapshotSession{
+		root: e.load(),
+	}
+}
+
+func (e *Engine) NewBatchSession() engine.Session {
+	return &BatchSession{
+		engine: e,
+		root:   e.load(),
+	}
+}
+
+// NewConcurrentSession returns a write session that can run concurrently
+// with other write sessions. It reads the version of the tree that was committed
+// when it was created, and its changes are merged with the latest version on commit.
+func (e *Engine) NewConcurrentSession() engine.Session {
+	root := e.load()
+
+	return &BatchSession{
+		engine:     e,
+		root:       root,
+		concurrent: true,
+		base:       root,
+	}
+}
+
+func (e *Engine) NewTransientSession() engine.Session {
+	return &TransientSession{}
+}
@@ -0,0 +1,208 @@
+package memory_test
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/chaisql/chai/internal/engine"
+	"github.com/chaisql/chai/internal/engine/memory"
+	"github.com/stretchr/testify/require"
+)
+
+func key(i int64) []byte {
+	return encoding.EncodeInt(encoding.EncodeInt(nil, 10), i)
+}
+
+func TestBatchCommit(t *testing.T) {
+	ng := memory.NewEngine()
+	defer ng.Close()
+
+	batch := ng.NewBatchSession()
+	for i := int64(0); i < 100; i++ {
+		err := batch.Put(key(i), encoding.EncodeInt(nil, i))
+		require.NoError(t, err)
+	}
+
+	// snapshots created during the write transaction should not see the changes
+	ss := ng.NewSnapshotSession()
+	_, err := ss.Get(key(9))
+	require.ErrorIs(t, err, engine.ErrKeyNotFound)
+
+	err = batch.Commit()
+	require.NoError(t, err)
+
+	// the snapshot still reads the state it was created with
+	ok, err := ss.Exists(key(9))
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, ss.Close())
+
+	ss = ng.NewSnapshotSession()
+	defer ss.Close()
+	v, err := ss.Get(key(9))
+	require.NoError(t, err)
+	require.Equal(t, encoding.EncodeInt(nil, 9), v)
+}
+
+func TestBatchRollback(t *testing.T) {
+	ng := memory.NewEngine()
+	defer ng.Close()
+
+	batch := ng.NewBatchSession()
+	require.NoError(t, batch.Put(key(1), []byte("a")))
+	require.NoError(t, batch.Commit())
+
+	batch = ng.NewBatchSession()
+	require.NoError(t, batch.Put(key(2), []byte("b")))
+	require.NoError(t, batch.Delete(key(1)))
+	require.NoError(t, batch.Close())
+	require.NoError(t, ng.Rollback())
+
+	ss := ng.NewSnapshotSession()
+	defer ss.Close()
+
+	v, err := ss.Get(key(1))
+	require.NoError(t, err)
+	require.Equal(t, []byte("a"), v)
+
+	ok, err := ss.Exists(key(2))
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestInsert(t *testing.T) {
+	ng := memory.NewEngine()
+	defer ng.Close()
+
+	batch := ng.NewBatchSession()
+	defer batch.Close()
+
+	require.NoError(t, batch.Insert(key(1), []byte("a")))
+	require.ErrorIs(t, batch.Insert(key(1), []byte("b")), engine.ErrKeyAlreadyExists)
+	require.Error(t, batch.Insert(nil, []byte("b")))
+	require.Error(t, batch.Insert(key(2), nil))
+}
+
+func TestIterator(t *testing.T) {
+	ng := memory.NewEngine()
+	defer ng.Close()
+
+	batch := ng.NewBatchSession()
+	defer batch.Close()
+
+	// insert in a random order
+	for _, i := range []int64{5, 3, 9, 1, 7, 0, 8, 2, 6, 4} {
+		require.NoError(t, batch.Put(key(i), encoding.EncodeInt(nil, i)))
+	}
+
+	collect := func(opts *engine.IterOptions, reverse bool) []int64 {
+		it, err := batch.Iterator(opts)
+		require.NoError(t, err)
+		defer it.Close()
+
+		var res []int64
+		if !reverse {
+			for it.First(); it.Valid(); it.Next() {
+				v, err := it.Value()
+				require.NoError(t, err)
+				n, _ := encoding.DecodeInt(v)
+				res = append(res, n)
+			}
+		} else {
+			for it.Last(); it.Valid(); it.Prev() {
+				v, err := it.Value()
+				require.NoError(t, err)
+				n, _ := encoding.DecodeInt(v)
+				res = append(res, n)
+			}
+		}
+		return res
+	}
+
+	require.Equal(t, []int64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, collect(nil, false))
+	require.Equal(t, []int64{9, 8, 7, 6, 5, 4, 3, 2, 1, 0}, collect(nil, true))
+
+	opts := engine.IterOptions{LowerBound: key(3), UpperBound: key(7)}
+	require.Equal(t, []int64{3, 4, 5, 6}, collect(&opts, false))
+	require.Equal(t, []int64{6, 5, 4, 3}, collect(&opts, true))
+
+	require.NoError(t, batch.DeleteRange(key(2), key(8)))
+	require.Equal(t, []int64{0, 1, 8, 9}, collect(nil, false))
+}
+
+func TestTransient(t *testing.T) {
+	ng := memory.NewEngine()
+	defer ng.Close()
+
+	s := ng.NewTransientSession()
+	defer s.Close()
+
+	require.NoError(t, s.Put(key(1), []byte("a")))
+	v, err := s.Get(key(1))
+	require.NoError(t, err)
+	require.Equal(t, []byte("a"), v)
+
+	require.NoError(t, s.Delete(key(1)))
+	require.ErrorIs(t, s.Delete(key(1)), engine.ErrKeyNotFound)
+
+	// transient data is never visible to other sessions
+	require.NoError(t, s.Put(key(2), []byte("b")))
+	ss := ng.NewSnapshotSession()
+	defer ss.Close()
+	ok, err := ss.Exists(key(2))
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestSnapshot(t *testing.T) {
+	ng := memory.NewEngine()
+	defer ng.Close()
+
+	batch := ng.NewBatchSession()
+	for i := int64(0); i < 1000; i++ {
+		require.NoError(t, batch.Put(key(i), encoding.EncodeInt(nil, i)))
+	}
+	require.NoError(t, batch.Commit())
+
+	// uncommitted changes must not be part of the snapshot
+	batch = ng.NewBatchSession()
+	require.NoError(t, batch.Put(key(5000), []byte("a")))
+
+	path := filepath.Join(t.TempDir(), "snapshot")
+	require.NoError(t, ng.SaveSnapshot(path))
+	require.NoError(t, batch.Close())
+
+	loaded, err := memory.LoadSnapshot(path)
+	require.NoError(t, err)
+	defer loaded.Close()
+
+	ss := loaded.NewSnapshotSession()
+	defer ss.Close()
+
+	it, err := ss.Iterator(nil)
+	require.NoError(t, err)
+	defer it.Close()
+
+	var i int64
+	for it.First(); it.Valid(); it.Next() {
+		require.Equal(t, key(i), it.Key())
+		i++
+	}
+	require.EqualValues(t, 1000, i)
+
+	t.Run("corrupted", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, ng.WriteSnapshot(&buf))
+
+		b := buf.Bytes()
+		b[len(b)/2] ^= 0xFF
+
+		_, err := memory.ReadSnapshot(bytes.NewReader(b))
+		require.Error(t, err)
+
+		_, err = memory.ReadSnapshot(bytes.NewReader([]byte("not a snapshot")))
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,293 @@
+package memory
+
+import (
+	"bytes"
+
+	"github.com/chaisql/chai/internal/engine"
+	"github.com/cockroachdb/errors"
+)
+
+var (
+	_ engine.Session = (*SnapshotSession)(nil)
+	_ engine.Session = (*BatchSession)(nil)
+	_ engine.Session = (*TransientSession)(nil)
+)
+
+func clone(b []byte) []byte {
+	return bytes.Clone(b)
+}
+
+func validate(k, v []byte) error {
+	if len(k) == 0 {
+		return errors.New("cannot store empty key")
+	}
+
+	if len(v) == 0 {
+		return errors.New("cannot store empty value")
+	}
+
+	return nil
+}
+
+func getValue(root *node, k []byte) ([]byte, error) {
+	n := get(root, k)
+	if n == nil {
+		return nil, errors.WithStack(engine.ErrKeyNotFound)
+	}
+
+	return clone(n.value), nil
+}
+
+func newIterator(root *node, opts *engine.IterOptions) *iterator {
+	it := iterator{
+		root: root,
+	}
+	if opts != nil {
+		it.lowerBound = opts.LowerBound
+		it.upperBound = opts.UpperBound
+	}
+
+	return &it
+}
+
+// deleteRange removes every key between start (inclusive) and end (exclusive).
+func deleteRange(root *node, start, end []byte) *node {
+	it := newIterator(root, &engine.IterOptions{LowerBound: start, UpperBound: end})
+	for it.First(); it.Valid(); it.Next() {
+		root, _ = remove(root, it.Key())
+	}
+
+	return root
+}
+
+// SnapshotSession is a read-only session that reads
+// the version of the tree that was committed when it was created.
+type SnapshotSession struct {
+	root   *node
+	closed bool
+}
+
+func (s *SnapshotSession) Commit() error {
+	return errors.New("cannot commit in read-only mode")
+}
+
+func (s *SnapshotSession) Close() error {
+	if s.closed {
+		return errors.New("already closed")
+	}
+	s.closed = true
+	s.root = nil
+
+	return nil
+}
+
+func (s *SnapshotSession) Insert(k, v []byte) error {
+	return errors.New("cannot insert in read-only mode")
+}
+
+func (s *SnapshotSession) Put(k, v []byte) error {
+	return errors.New("cannot put in read-only mode")
+}
+
+// Get returns a value associated with the given key. If not found, returns ErrKeyNotFound.
+func (s *SnapshotSession) Get(k []byte) ([]byte, error) {
+	return getValue(s.root, k)
+}
+
+// Exists returns whether a key exists and is visible by the current session.
+func (s *SnapshotSession) Exists(k []byte) (bool, error) {
+	return get(s.root, k) != nil, nil
+}
+
+func (s *SnapshotSession) Delete(k []byte) error {
+	return errors.New("cannot delete in read-only mode")
+}
+
+func (s *SnapshotSession) DeleteRange(start []byte, end []byte) error {
+	return errors.New("cannot delete range in read-only mode")
+}
+
+func (s *SnapshotSession) Iterator(opts *engine.IterOptions) (engine.Iterator, error) {
+	return newIterator(s.root, opts), nil
+}
+
+// BatchSession is a read-write session. It works on its own
+// copy of the tree, which replaces the engine's tree on commit.
+type BatchSession struct {
+	engine *Engine
+	root   *node
+	closed bool
+
+	// concurrent sessions record their changes, to replay them
+	// on top of the changes committed since base was loaded.
+	concurrent bool
+	base       *node
+	ops        []func(root *node) *node
+}
+
+func (s *BatchSession) Commit() error {
+	if s.closed {
+		return errors.New("already closed")
+	}
+
+	var err error
+	if s.concurrent {
+		err = s.engine.merge(s.base, s.root, s.ops)
+	} else {
+		err = s.engine.publish(s.root)
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.Close()
+}
+
+func (s *BatchSession) Close() error {
+	if s.closed {
+		return errors.New("already closed")
+	}
+	s.closed = true
+	s.root = nil
+	s.base = nil
+	s.ops = nil
+
+	return nil
+}
+
+// write applies op to the tree of the session.
+func (s *BatchSession) write(op func(root *node) *node) {
+	s.root = op(s.root)
+	if s.concurrent {
+		s.ops = append(s.ops, op)
+	}
+}
+
+// Insert inserts a key-value pair. If it already exists, it returns ErrKeyAlreadyExists.
+func (s *BatchSession) Insert(k, v []byte) error {
+	err := validate(k, v)
+	if err != nil {
+		return err
+	}
+
+	if get(s.root, k) != nil {
+		return engine.ErrKeyAlreadyExists
+	}
+
+	return s.Put(k, v)
+}
+
+// Put stores a key value pair. If it already exists, it overrides it.
+func (s *BatchSession) Put(k, v []byte) error {
+	err := validate(k, v)
+	if err != nil {
+		return err
+	}
+
+	k, v = clone(k), clone(v)
+	s.write(func(root *node) *node {
+		return put(root, k, v)
+	})
+	return nil
+}
+
+// Get returns a value associated with the given key. If not found, returns ErrKeyNotFound.
+func (s *BatchSession) Get(k []byte) ([]byte, error) {
+	return getValue(s.root, k)
+}
+
+// Exists returns whether a key exists and is visible by the current session.
+func (s *BatchSession) Exists(k []byte) (bool, error) {
+	return get(s.root, k) != nil, nil
+}
+
+// Delete a record by key. If the key doesn't exist, it doesn't do anything.
+func (s *BatchSession) Delete(k []byte) error {
+	k = clone(k)
+	s.write(func(root *node) *node {
+		root, _ = remove(root, k)
+		return root
+	})
+	return nil
+}
+
+// DeleteRange deletes all keys in the given range.
+func (s *BatchSession) DeleteRange(start []byte, end []byte) error {
+	start, end = clone(start), clone(end)
+	s.write(func(root *node) *node {
+		return deleteRange(root, start, end)
+	})
+	return nil
+}
+
+// Iterator returns an iterator over the state of the session
+// at the time of the call. Subsequent writes are not visible to it.
+func (s *BatchSession) Iterator(opts *engine.IterOptions) (engine.Iterator, error) {
+	return newIterator(s.root, opts), nil
+}
+
+// TransientSession stores temporary data in a private tree
+// that is dropped when the session is closed.
+type TransientSession struct {
+	root   *node
+	closed bool
+}
+
+func (s *TransientSession) Commit() error {
+	return errors.New("cannot commit in transient mode")
+}
+
+func (s *TransientSession) Close() error {
+	if s.closed {
+		return errors.New("already closed")
+	}
+	s.closed = true
+	s.root = nil
+
+	return nil
+}
+
+func (s *TransientSession) Insert(k, v []byte) error {
+	return errors.New("cannot insert in transient mode")
+}
+
+// Put stores a key value pair. If it already exists, it overrides it.
+func (s *TransientSession) Put(k, v []byte) error {
+	err := validate(k, v)
+	if err != nil {
+		return err
+	}
+
+	s.root = put(s.root, clone(k), clone(v))
+	return nil
+}
+
+// Get returns a value associated with the given key. If not found, returns ErrKeyNotFound.
+func (s *TransientSession) Get(k []byte) ([]byte, error) {
+	return getValue(s.root, k)
+}
+
+// Exists returns whether a key exists and is visible by the current session.
+func (s *TransientSession) Exists(k []byte) (bool, error) {
+	return get(s.root, k) != nil, nil
+}
+
+// Delete a record by key. If not found, returns ErrKeyNotFound.
+func (s *TransientSession) Delete(k []byte) error {
+	var found bool
+	s.root, found = remove(s.root, k)
+	if !found {
+		return errors.WithStack(engine.ErrKeyNotFound)
+	}
+
+	return nil
+}
+
+func (s *TransientSession) DeleteRange(start []byte, end []byte) error {
+	s.root = deleteRange(s.root, start, end)
+	return nil
+}
+
+func (s *TransientSession) Iterator(opts *engine.IterOptions) (engine.Iterator, error) {
+	return newIterator(s.root, opts), nil
+}
@@ -0,0 +1,184 @@
+package memory
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/cockroachdb/errors"
+)
+
+// snapshotMagic is written at the beginning of every snapshot file.
+// The last byte is the version of the format.
+var snapshotMagic = []byte("CHAIMEM\x01")
+
+// WriteSnapshot writes the last committed state of the engine to w.
+// Uncommitted changes of an ongoing write session are not included,
+// and the write session is not blocked while the snapshot is written.
+//
+// The format is the magic header followed by a list of
+// uvarint-prefixed key-value pairs, a zero-length key marking the end of
+// the list and a CRC-32 checksum of everything that precedes it.
+func (e *Engine) WriteSnapshot(w io.Writer) error {
+	root := e.load()
+
+	h := crc32.NewIEEE()
+	bw := bufio.NewWriter(io.MultiWriter(w, h))
+
+	_, err := bw.Write(snapshotMagic)
+	if err != nil {
+		return err
+	}
+
+	var buf [binary.MaxVarintLen64]byte
+	writeBytes := func(b []byte) error {
+		n := binary.PutUvarint(buf[:], uint64(len(b)))
+		_, err := bw.Write(buf[:n])
+		if err != nil {
+			return err
+		}
+		_, err = bw.Write(b)
+		return err
+	}
+
+	err = walk(root, func(n *node) error {
+		err := writeBytes(n.key)
+		if err != nil {
+			return err
+		}
+		return writeBytes(n.value)
+	})
+	if err != nil {
+		return err
+	}
+
+	err = writeBytes(nil)
+	if err != nil {
+		return err
+	}
+
+	err = bw.Flush()
+	if err != nil {
+		return err
+	}
+
+	return binary.Write(w, binary.BigEndian, h.Sum32())
+}
+
+// SaveSnapshot writes the last committed state of the engine to the given file.
+// The file is first written to a temporary location and then renamed,
+// so that an existing snapshot is never left half-written.
+func (e *Engine) SaveSnapshot(path string) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	err = e.WriteSnapshot(f)
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	err = f.Sync()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	err = f.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
+}
+
+// ReadSnapshot reads a snapshot written by WriteSnapshot
+// and returns a new engine containing its data.
+func ReadSnapshot(r io.Reader) (*Engine, error) {
+	h := crc32.NewIEEE()
+	br := bufio.NewReader(r)
+	tr := io.TeeReader(br, h)
+
+	magic := make([]byte, len(snapshotMagic))
+	_, err := io.ReadFull(tr, magic)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read snapshot header")
+	}
+	if !bytes.Equal(magic, snapshotMagic) {
+		return nil, errors.New("invalid snapshot header")
+	}
+
+	readBytes := func() ([]byte, error) {
+		l, err := binary.ReadUvarint(byteReader{tr})
+		if err != nil {
+			return nil, err
+		}
+		if l == 0 {
+			return nil, nil
+		}
+		b := make([]byte, l)
+		_, err = io.ReadFull(tr, b)
+		return b, err
+	}
+
+	var root *node
+	for {
+		k, err := readBytes()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read snapshot")
+		}
+		if k == nil {
+			break
+		}
+
+		v, err := readBytes()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read snapshot")
+		}
+
+		root = put(root, k, v)
+	}
+
+	sum := h.Sum32()
+	var expected uint32
+	err = binary.Read(br, binary.BigEndian, &expected)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read snapshot checksum")
+	}
+	if sum != expected {
+		return nil, errors.New("snapshot checksum mismatch")
+	}
+
+	return &Engine{root: root}, nil
+}
+
+// LoadSnapshot reads a snapshot file written by SaveSnapshot
+// and returns a new engine containing its data.
+func LoadSnapshot(path string) (*Engine, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ReadSnapshot(f)
+}
+
+// byteReader turns a reader into an io.ByteReader, without
+// reading more than necessary from the underlying reader.
+type byteReader struct {
+	io.Reader
+}
+
+func (r byteReader) ReadByte() (byte, error) {
+	var b [1]byte
+	_, err := io.ReadFull(r.Reader, b[:])
+	return b[0], err
+}
@@ -0,0 +1,289 @@
+package memory
+
+import (
+	"hash/fnv"
+
+	"github.com/chaisql/chai/internal/encoding"
+)
+
+// node is an element of an immutable treap.
+// Nodes are never modified once they are reachable from a published root:
+// every write copies the path from the root to the modified node.
+// This makes taking a snapshot of the tree as cheap as copying a pointer.
+type node struct {
+	key      []byte
+	value    []byte
+	priority uint32
+	left     *node
+	right    *node
+}
+
+func (n *node) clone() *node {
+	c := *n
+	return &c
+}
+
+func priority(k []byte) uint32 {
+	h := fnv.New32a()
+	_, _ = h.Write(k)
+	return h.Sum32()
+}
+
+// get returns the node associated with the given key, or nil.
+func get(n *node, k []byte) *node {
+	for n != nil {
+		c := encoding.Compare(k, n.key)
+		switch {
+		case c < 0:
+			n = n.left
+		case c > 0:
+			n = n.right
+		default:
+			return n
+		}
+	}
+
+	return nil
+}
+
+// put returns a new tree where k is associated with v.
+func put(n *node, k, v []byte) *node {
+	if n == nil {
+		return &node{key: k, value: v, priority: priority(k)}
+	}
+
+	c := encoding.Compare(k, n.key)
+	if c == 0 {
+		n = n.clone()
+		n.value = v
+		return n
+	}
+
+	n = n.clone()
+	if c < 0 {
+		n.left = put(n.left, k, v)
+		if n.left.priority > n.priority {
+			return rotateRight(n)
+		}
+	} else {
+		n.right = put(n.right, k, v)
+		if n.right.priority > n.priority {
+			return rotateLeft(n)
+		}
+	}
+
+	return n
+}
+
+// rotateRight expects n and n.left to be copies that are safe to modify.
+func rotateRight(n *node) *node {
+	l := n.left
+	n.left = l.right
+	l.right = n
+	return l
+}
+
+// rotateLeft expects n and n.right to be copies that are safe to modify.
+func rotateLeft(n *node) *node {
+	r := n.right
+	n.right = r.left
+	r.left = n
+	return r
+}
+
+// remove returns a new tree without k. The boolean reports
+// whether the key was found.
+func remove(n *node, k []byte) (*node, bool) {
+	if n == nil {
+		return nil, false
+	}
+
+	c := encoding.Compare(k, n.key)
+	if c == 0 {
+		return merge(n.left, n.right), true
+	}
+
+	var found bool
+	if c < 0 {
+		var l *node
+		l, found = remove(n.left, k)
+		if !found {
+			return n, false
+		}
+		n = n.clone()
+		n.left = l
+	} else {
+		var r *node
+		r, found = remove(n.right, k)
+		if !found {
+			return n, false
+		}
+		n = n.clone()
+		n.right = r
+	}
+
+	return n, true
+}
+
+// merge joins two trees where every key of a is lower than every key of b.
+func merge(a, b *node) *node {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+
+	if a.priority > b.priority {
+		a = a.clone()
+		a.right = merge(a.right, b)
+		return a
+	}
+
+	b = b.clone()
+	b.left = merge(a, b.left)
+	return b
+}
+
+// seekGE returns the node with the smallest key greater than or equal to k.
+// If k is nil, it returns the smallest node of the tree.
+func seekGE(n *node, k []byte) *node {
+	var res *node
+	for n != nil {
+		if k == nil || encoding.Compare(n.key, k) >= 0 {
+			res = n
+			n = n.left
+		} else {
+			n = n.right
+		}
+	}
+	return res
+}
+
+// seekGT returns the node with the smallest key strictly greater than k.
+func seekGT(n *node, k []byte) *node {
+	var res *node
+	for n != nil {
+		if encoding.Compare(n.key, k) > 0 {
+			res = n
+			n = n.left
+		} else {
+			n = n.right
+		}
+	}
+	return res
+}
+
+// seekLT returns the node with the largest key strictly lower than k.
+// If k is nil, it returns the largest node of the tree.
+func seekLT(n *node, k []byte) *node {
+	var res *node
+	for n != nil {
+		if k == nil || encoding.Compare(n.key, k) < 0 {
+			res = n
+			n = n.right
+		} else {
+			n = n.left
+		}
+	}
+	return res
+}
+
+// walk calls fn for every node of the tree in key order.
+func walk(n *node, fn func(n *node) error) error {
+	if n == nil {
+		return nil
+	}
+
+	err := walk(n.left, fn)
+	if err != nil {
+		return err
+	}
+
+	err = fn(n)
+	if err != nil {
+		return err
+	}
+
+	return walk(n.right, fn)
+}
+
+// iterator iterates over an immutable tree. Since the tree
+// never changes, the iterator doesn't need to hold any lock.
+type iterator struct {
+	root       *node
+	lowerBound []byte
+	upperBound []byte
+	cur        *node
+}
+
+func (it *iterator) inBounds(n *node) bool {
+	if n == nil {
+		return false
+	}
+	if it.lowerBound != nil && encoding.Compare(n.key, it.lowerBound) < 0 {
+		return false
+	}
+	if it.upperBound != nil && encoding.Compare(n.key, it.upperBound) >= 0 {
+		return false
+	}
+	return true
+}
+
+func (it *iterator) set(n *node) bool {
+	if !it.inBounds(n) {
+		n = nil
+	}
+	it.cur = n
+	return n != nil
+}
+
+func (it *iterator) First() bool {
+	return it.set(seekGE(it.root, it.lowerBound))
+}
+
+func (it *iterator) Last() bool {
+	return it.set(seekLT(it.root, it.upperBound))
+}
+
+func (it *iterator) SeekGE(k []byte) bool {
+	if it.lowerBound != nil && encoding.Compare(k, it.lowerBound) < 0 {
+		k = it.lowerBound
+	}
+	return it.set(seekGE(it.root, k))
+}
+
+func (it *iterator) Valid() bool {
+	return it.cur != nil
+}
+
+func (it *iterator) Next() bool {
+	if it.cur == nil {
+		return false
+	}
+	return it.set(seekGT(it.root, it.cur.key))
+}
+
+func (it *iterator) Prev() bool {
+	if it.cur == nil {
+		return false
+	}
+	return it.set(seekLT(it.root, it.cur.key))
+}
+
+func (it *iterator) Error() error {
+	return nil
+}
+
+func (it *iterator) Key() []byte {
+	return it.cur.key
+}
+
+func (it *iterator) Value() ([]byte, error) {
+	return it.cur.value, nil
+}
+
+func (it *iterator) Close() error {
+	it.cur = nil
+	return nil
+}
@@ -1,7 +1,9 @@
 package environment
 
 import (
+	"context"
 	"fmt"
+	"time"
 
 	"github.com/chaisql/chai/internal/database"
 	"github.com/chaisql/chai/internal/row"
@@ -24,6 +26,8 @@ type Environment struct {
 	Row    row.Row
 	DB     *database.Database
 	Tx     *database.Transaction
+	// context of the query, which stops its execution when it is canceled.
+	Ctx context.Context
 
 	Outer *Environment
 }
@@ -121,6 +125,65 @@ func (e *Environment) GetTx() *database.Transaction {
 	return nil
 }
 
+// GetContext returns the context of the query, or nil if it has none.
+func (e *Environment) GetContext() context.Context {
+	if e.Ctx != nil {
+		return e.Ctx
+	}
+
+	if outer := e.GetOuter(); outer != nil {
+		return outer.GetContext()
+	}
+
+	return nil
+}
+
+// Err returns the error of the context of the query if it is canceled
+// or its deadline is exceeded. The operators reading rows call it for every row,
+// so that canceling a query stops it without waiting for the end of a scan.
+func (e *Environment) Err() error {
+	ctx := e.GetContext()
+	if ctx == nil {
+		return nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+		return nil
+	}
+}
+
+// GetTimeZone returns the time zone of the transaction,
+// used to parse and display TIMESTAMPTZ values. Nil means UTC.
+func (e *Environment) GetTimeZone() *time.Location {
+	if tx := e.GetTx(); tx != nil {
+		return tx.TimeZone
+	}
+
+	return nil
+}
+
+// GetCoercion returns the coercion mode of the transaction,
+// which controls how values of different types are compared.
+func (e *Environment) GetCoercion() types.Coercion {
+	if tx := e.GetTx(); tx != nil {
+		return tx.Coercion
+	}
+
+	return types.CoercionLenient
+}
+
+// GetConnection returns the connection of the transaction, if any.
+func (e *Environment) GetConnection() *database.Connection {
+	if tx := e.GetTx(); tx != nil {
+		return tx.Connection()
+	}
+
+	return nil
+}
+
 func (e *Environment) GetDB() *database.Database {
 	if e.DB != nil {
 		return e.DB
@@ -1,6 +1,8 @@
 package expr
 
 import (
+	"time"
+
 	"github.com/chaisql/chai/internal/environment"
 	"github.com/chaisql/chai/internal/sql/scanner"
 	"github.com/chaisql/chai/internal/types"
@@ -25,6 +27,10 @@ func (op *arithmeticOperator) Clone() Expr {
 
 func (op *arithmeticOperator) Eval(env *environment.Environment) (types.Value, error) {
 	return op.simpleOperator.eval(env, func(va, vb types.Value) (types.Value, error) {
+		if isTemporal(va.Type()) || isTemporal(vb.Type()) {
+			return evalTemporal(env, op.simpleOperator.Tok, va, vb)
+		}
+
 		a, ok := va.(types.Numeric)
 		if !ok {
 			return NullLiteral, nil
@@ -71,6 +77,78 @@ func (op *arithmeticOperator) Eval(env *environment.Environment) (types.Value, e
 	})
 }
 
+func isTemporal(t types.Type) bool {
+	return t.IsTimestamp() || t == types.TypeInterval
+}
+
+// evalTemporal adds or subtracts timestamps and intervals:
+//
+//	timestamp + interval --> timestamp
+//	interval + timestamp --> timestamp
+//	timestamp - interval --> timestamp
+//	timestamp - timestamp --> interval
+//	interval + interval --> interval
+//	interval - interval --> interval
+//
+// Timestamps keep their type. The days and months of intervals are added
+// in the time zone of the transaction to TIMESTAMPTZ values. The other
+// combinations return NULL.
+func evalTemporal(env *environment.Environment, tok scanner.Token, a, b types.Value) (types.Value, error) {
+	at, bt := a.Type(), b.Type()
+
+	switch {
+	case tok == scanner.ADD && bt.IsTimestamp() && at == types.TypeInterval:
+		return addInterval(env, b, a, 1)
+	case (tok == scanner.ADD || tok == scanner.SUB) && at.IsTimestamp() && bt == types.TypeInterval:
+		n := 1
+		if tok == scanner.SUB {
+			n = -1
+		}
+		return addInterval(env, a, b, n)
+	case tok == scanner.SUB && at.IsTimestamp() && bt.IsTimestamp():
+		return types.NewIntervalValue(types.IntervalBetween(types.AsTime(a), types.AsTime(b))), nil
+	case (tok == scanner.ADD || tok == scanner.SUB) && at == types.TypeInterval && bt == types.TypeInterval:
+		x := b.V().(types.Interval)
+		if tok == scanner.SUB {
+			var err error
+			x, err = x.Neg()
+			if err != nil {
+				return nil, err
+			}
+		}
+		iv, err := a.V().(types.Interval).Add(x)
+		if err != nil {
+			return nil, err
+		}
+		return types.NewIntervalValue(iv), nil
+	}
+
+	return NullLiteral, nil
+}
+
+// addInterval returns the timestamp ts plus n times the interval iv.
+func addInterval(env *environment.Environment, ts, iv types.Value, n int) (types.Value, error) {
+	t := types.AsTime(ts)
+	if ts.Type() == types.TypeTimestampTZ {
+		loc := time.UTC
+		if env != nil && env.GetTimeZone() != nil {
+			loc = env.GetTimeZone()
+		}
+		t = t.In(loc)
+	}
+
+	t = iv.V().(types.Interval).AddTo(t, n)
+	err := types.ValidateTimestamp(t)
+	if err != nil {
+		return nil, err
+	}
+
+	if ts.Type() == types.TypeTimestampTZ {
+		return types.NewTimestampTZValue(t), nil
+	}
+	return types.NewTimestampValue(t), nil
+}
+
 // Add creates an expression thats evaluates to the result of a + b.
 func Add(a, b Expr) Expr {
 	return &arithmeticOperator{&simpleOperator{a, b, scanner.ADD}}
@@ -3,6 +3,7 @@ package expr_test
 import (
 	"math"
 	"testing"
+	"time"
 
 	"github.com/chaisql/chai/internal/expr"
 	"github.com/chaisql/chai/internal/types"
@@ -28,6 +29,14 @@ func TestValueAdd(t *testing.T) {
 		{"int64(min)+integer(-10)", types.NewBigintValue(math.MinInt64), types.NewIntegerValue(-10), nil, true},
 		{"integer(120)+text('120')", types.NewIntegerValue(120), types.NewTextValue("120"), types.NewNullValue(), false},
 		{"text('120')+text('120')", types.NewTextValue("120"), types.NewTextValue("120"), types.NewNullValue(), false},
+		{"timestamp+interval", timestamp("2024-01-31T10:00:00Z"), interval(1, 1, 0), timestamp("2024-03-01T10:00:00Z"), false},
+		{"interval+timestamp", interval(0, 0, 3600e6), timestamp("2024-01-31T10:00:00Z"), timestamp("2024-01-31T11:00:00Z"), false},
+		{"timestamptz+interval", types.NewTimestampTZValue(time.Date(2024, 1, 31, 10, 0, 0, 0, time.UTC)), interval(0, 1, 0), types.NewTimestampTZValue(time.Date(2024, 2, 1, 10, 0, 0, 0, time.UTC)), false},
+		{"interval+interval", interval(1, 2, 3), interval(4, 5, 6), interval(5, 7, 9), false},
+		{"timestamp+timestamp", timestamp("2024-01-31T10:00:00Z"), timestamp("2024-01-31T10:00:00Z"), types.NewNullValue(), false},
+		{"timestamp+integer(1)", timestamp("2024-01-31T10:00:00Z"), types.NewIntegerValue(1), types.NewNullValue(), false},
+		{"timestamp(max)+interval", timestamp("9999-12-31T00:00:00Z"), interval(12*1000000, 0, 0), nil, true},
+		{"interval(max)+interval", interval(math.MaxInt32, 0, 0), interval(1, 0, 0), nil, true},
 	}
 
 	for _, test := range tests {
@@ -45,6 +54,18 @@ func TestValueAdd(t *testing.T) {
 	}
 }
 
+func timestamp(s string) types.Value {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		panic(err)
+	}
+	return types.NewTimestampValue(t)
+}
+
+func interval(months, days int32, micros int64) types.Value {
+	return types.NewIntervalValue(types.Interval{Months: months, Days: days, Micros: micros})
+}
+
 func TestValueSub(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -64,6 +85,11 @@ func TestValueSub(t *testing.T) {
 		{"int64(max)-integer(-10)", types.NewBigintValue(math.MaxInt64), types.NewIntegerValue(-10), nil, true},
 		{"integer(120)-text('120')", types.NewIntegerValue(120), types.NewTextValue("120"), types.NewNullValue(), false},
 		{"text('120')-text('120')", types.NewTextValue("120"), types.NewTextValue("120"), types.NewNullValue(), false},
+		{"timestamp-interval", timestamp("2024-03-31T10:00:00Z"), interval(1, 0, 1), timestamp("2024-02-29T09:59:59.999999Z"), false},
+		{"timestamp-timestamp", timestamp("2024-03-02T01:00:00Z"), timestamp("2024-01-01T00:00:00Z"), interval(0, 61, 3600e6), false},
+		{"timestamp-timestamptz", timestamp("2024-01-01T00:00:00Z"), types.NewTimestampTZValue(time.Date(2024, 1, 1, 1, 0, 0, 0, time.UTC)), interval(0, 0, -3600e6), false},
+		{"interval-interval", interval(1, 2, 3), interval(4, 5, 6), interval(-3, -3, -3), false},
+		{"interval-timestamp", interval(1, 2, 3), timestamp("2024-01-01T00:00:00Z"), types.NewNullValue(), false},
 	}
 
 	for _, test := range tests {
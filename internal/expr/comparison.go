@@ -27,7 +27,19 @@ func (op *cmpOp) Eval(env *environment.Environment) (types.Value, error) {
 			return NullLiteral, nil
 		}
 
-		ok, err := op.compare(a, b)
+		a, b, ok, err := coerce(env, a, b)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			// values that can't be compared are neither equal nor ordered
+			if op.Tok == scanner.NEQ {
+				return TrueLiteral, nil
+			}
+			return FalseLiteral, nil
+		}
+
+		ok, err = op.compare(a, b)
 		if ok {
 			return TrueLiteral, err
 		}
@@ -36,6 +48,14 @@ func (op *cmpOp) Eval(env *environment.Environment) (types.Value, error) {
 	})
 }
 
+// coerce converts the compared values following the coercion mode of
+// the transaction, see types.Coercion. Texts compared to TIMESTAMPTZ values
+// are parsed in the time zone of the transaction, rather than in UTC.
+// It returns false if the values can't be compared.
+func coerce(env *environment.Environment, a, b types.Value) (types.Value, types.Value, bool, error) {
+	return env.GetCoercion().Coerce(a, b, env.GetTimeZone())
+}
+
 func (op *cmpOp) compare(l, r types.Value) (bool, error) {
 	switch op.Tok {
 	case scanner.EQ:
@@ -124,7 +144,16 @@ func (op *BetweenOperator) Eval(env *environment.Environment) (types.Value, erro
 			return NullLiteral, nil
 		}
 
-		ok, err := x.Between(a, b)
+		x, a, ok, err := coerce(env, x, a)
+		if err != nil || !ok {
+			return FalseLiteral, err
+		}
+		x, b, ok, err = coerce(env, x, b)
+		if err != nil || !ok {
+			return FalseLiteral, err
+		}
+
+		ok, err = x.Between(a, b)
 		if err != nil {
 			return NullLiteral, err
 		}
@@ -141,11 +170,88 @@ func (op *BetweenOperator) String() string {
 	return fmt.Sprintf("%v BETWEEN %v AND %v", op.X, op.a, op.b)
 }
 
+// A ContainsOperator tests whether a network contains an address
+// or another network. It implements the <<, <<=, >> and >>= operators.
+// Between integers, << and >> shift the bits of the left operand instead.
+type ContainsOperator struct {
+	*simpleOperator
+}
+
+// ContainedBy creates an expression that returns true if a is a network or an address
+// strictly contained in the network b.
+func ContainedBy(a, b Expr) Expr {
+	return &ContainsOperator{&simpleOperator{a, b, scanner.CONTAINEDBY}}
+}
+
+// ContainedByOrEq creates an expression that returns true if a is a network or an address
+// contained in or equal to the network b.
+func ContainedByOrEq(a, b Expr) Expr {
+	return &ContainsOperator{&simpleOperator{a, b, scanner.CONTAINEDBYEQ}}
+}
+
+// Contains creates an expression that returns true if the network a
+// strictly contains the network or the address b.
+func Contains(a, b Expr) Expr {
+	return &ContainsOperator{&simpleOperator{a, b, scanner.CONTAINS}}
+}
+
+// ContainsOrEq creates an expression that returns true if the network a
+// contains or is equal to the network or the address b.
+func ContainsOrEq(a, b Expr) Expr {
+	return &ContainsOperator{&simpleOperator{a, b, scanner.CONTAINSEQ}}
+}
+
+func (op *ContainsOperator) Clone() Expr {
+	return &ContainsOperator{op.simpleOperator.Clone()}
+}
+
+// Eval returns whether the network contains the other operand.
+// It returns NULL if one of the operands is NULL.
+func (op *ContainsOperator) Eval(env *environment.Environment) (types.Value, error) {
+	return op.simpleOperator.eval(env, func(a, b types.Value) (types.Value, error) {
+		if a.Type() == types.TypeNull || b.Type() == types.TypeNull {
+			return NullLiteral, nil
+		}
+
+		if a.Type().IsInteger() && b.Type().IsInteger() {
+			switch op.Tok {
+			case scanner.CONTAINEDBY:
+				return a.(types.Integral).ShiftLeft(b.(types.Numeric))
+			case scanner.CONTAINS:
+				return a.(types.Integral).ShiftRight(b.(types.Numeric))
+			}
+		}
+
+		var ok bool
+		var err error
+		switch op.Tok {
+		case scanner.CONTAINEDBY:
+			ok, err = types.NetworkContains(b, a, true)
+		case scanner.CONTAINEDBYEQ:
+			ok, err = types.NetworkContains(b, a, false)
+		case scanner.CONTAINS:
+			ok, err = types.NetworkContains(a, b, true)
+		case scanner.CONTAINSEQ:
+			ok, err = types.NetworkContains(a, b, false)
+		}
+		if err != nil {
+			return NullLiteral, err
+		}
+
+		if ok {
+			return TrueLiteral, nil
+		}
+
+		return FalseLiteral, nil
+	})
+}
+
 // IsComparisonOperator returns true if e is one of
-// =, !=, >, >=, <, <=, IS, IS NOT, IN, or NOT IN operators.
+// =, !=, >, >=, <, <=, IS, IS NOT, IN, NOT IN, LIKE, NOT LIKE, BETWEEN
+// or one of the network containment operators.
 func IsComparisonOperator(op Operator) bool {
 	switch op.(type) {
-	case *cmpOp, *IsOperator, *IsNotOperator, *InOperator, *NotInOperator, *LikeOperator, *NotLikeOperator, *BetweenOperator:
+	case *cmpOp, *IsOperator, *IsNotOperator, *InOperator, *NotInOperator, *LikeOperator, *NotLikeOperator, *BetweenOperator, *ContainsOperator:
 		return true
 	}
 
@@ -217,13 +323,28 @@ func (op *InOperator) Eval(env *environment.Environment) (types.Value, error) {
 		return NullLiteral, nil
 	}
 
+	// following three-valued logic, the result is NULL
+	// rather than false if the list contains NULL
+	var hasNull bool
 	for _, bb := range b {
 		v, err := bb.Eval(env)
 		if err != nil {
 			return NullLiteral, err
 		}
+		if v.Type() == types.TypeNull {
+			hasNull = true
+			continue
+		}
+
+		x, v, ok, err := coerce(env, va, v)
+		if err != nil {
+			return NullLiteral, err
+		}
+		if !ok {
+			continue
+		}
 
-		ok, err := va.EQ(v)
+		ok, err = x.EQ(v)
 		if err != nil {
 			return NullLiteral, err
 		}
@@ -233,6 +354,10 @@ func (op *InOperator) Eval(env *environment.Environment) (types.Value, error) {
 		}
 	}
 
+	if hasNull {
+		return NullLiteral, nil
+	}
+
 	return FalseLiteral, nil
 }
 
@@ -60,6 +60,8 @@ func TestComparisonINExpr(t *testing.T) {
 		{"(1) IN (1, 2, 3)", types.NewBooleanValue(true), false},
 		{"(1) IN (1), (2), (3)", types.NewBooleanValue(true), false},
 		{"NULL IN (1, 2, NULL)", nullLiteral, false},
+		{"1 IN (1, NULL)", types.NewBooleanValue(true), false},
+		{"1 IN (2, NULL)", nullLiteral, false},
 	}
 
 	for _, test := range tests {
@@ -79,6 +81,8 @@ func TestComparisonNOTINExpr(t *testing.T) {
 		{"1 NOT IN (2, 3)", types.NewBooleanValue(true), false},
 		{"(1) NOT IN (1, 2, 3)", types.NewBooleanValue(false), false},
 		{"NULL NOT IN (1, 2, NULL)", nullLiteral, false},
+		{"1 NOT IN (1, NULL)", types.NewBooleanValue(false), false},
+		{"1 NOT IN (2, NULL)", nullLiteral, false},
 	}
 
 	for _, test := range tests {
@@ -170,7 +174,7 @@ func TestComparisonBetweenExpr(t *testing.T) {
 		{"1 BETWEEN NULL AND 2", types.NewNullValue(), false},
 		{"1 BETWEEN 0 AND 'foo'", types.NewBooleanValue(false), false},
 		{"1 BETWEEN 'foo' AND 2", types.NewBooleanValue(false), false},
-		{"1 BETWEEN '1' AND 2", types.NewBooleanValue(false), false},
+		{"1 BETWEEN '1' AND 2", types.NewBooleanValue(true), false},
 		{"1 BETWEEN CAST('1' AS int) AND 2", types.NewBooleanValue(true), false},
 		{"1 BETWEEN CAST('1' AS double) AND 2", types.NewBooleanValue(true), false},
 	}
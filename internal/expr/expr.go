@@ -124,6 +124,17 @@ type AggregatorBuilder interface {
 	Aggregator() Aggregator
 }
 
+// A TableFunction is a function returning rows instead of a value,
+// which is used in place of a table in the FROM clause.
+type TableFunction interface {
+	Expr
+
+	// Columns returns the names of the columns of the rows.
+	Columns() []string
+	// Iterate calls fn with the values of the columns of every row.
+	Iterate(env *environment.Environment, fn func(values []types.Value) error) error
+}
+
 func Walk(e Expr, fn func(Expr) bool) bool {
 	if e == nil {
 		return true
@@ -148,6 +159,20 @@ func Walk(e Expr, fn func(Expr) bool) bool {
 				return false
 			}
 		}
+	case *Path:
+		return Walk(t.Expr, fn)
+	case *ObjectLiteral:
+		for _, f := range t.Fields {
+			if !Walk(f.Expr, fn) {
+				return false
+			}
+		}
+	case ArrayLiteral:
+		for _, e := range t {
+			if !Walk(e, fn) {
+				return false
+			}
+		}
 	}
 
 	return true
@@ -175,6 +200,11 @@ func (n NextValueFor) Eval(env *environment.Environment) (types.Value, error) {
 		return NullLiteral, err
 	}
 
+	// currval() and lastval() return the values of the connection
+	if conn := tx.Connection(); conn != nil {
+		conn.SetSequenceValue(n.SeqName, i, true)
+	}
+
 	return types.NewBigintValue(i), nil
 }
 
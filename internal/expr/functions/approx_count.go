@@ -0,0 +1,105 @@
+package functions
+
+import (
+	"fmt"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/chaisql/chai/internal/environment"
+	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/pkg/hyperloglog"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+var _ expr.AggregatorBuilder = (*ApproxCountDistinct)(nil)
+
+// ApproxCountDistinct is the APPROX_COUNT_DISTINCT aggregator function.
+// It estimates the number of distinct non-null values of a group with
+// a HyperLogLog sketch, which uses a bounded amount of memory instead
+// of keeping every distinct value.
+type ApproxCountDistinct struct {
+	Expr expr.Expr
+}
+
+func (a *ApproxCountDistinct) Clone() expr.Expr {
+	return &ApproxCountDistinct{
+		Expr: expr.Clone(a.Expr),
+	}
+}
+
+// Eval extracts the result of the aggregation from the given row and returns it.
+func (a *ApproxCountDistinct) Eval(env *environment.Environment) (types.Value, error) {
+	r, ok := env.GetRow()
+	if !ok {
+		return nil, errors.New("misuse of aggregation function APPROX_COUNT_DISTINCT()")
+	}
+
+	return r.Get(a.String())
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (a *ApproxCountDistinct) IsEqual(other expr.Expr) bool {
+	if other == nil {
+		return false
+	}
+
+	o, ok := other.(*ApproxCountDistinct)
+	if !ok {
+		return false
+	}
+
+	return expr.Equal(a.Expr, o.Expr)
+}
+
+func (a *ApproxCountDistinct) Params() []expr.Expr { return []expr.Expr{a.Expr} }
+
+func (a *ApproxCountDistinct) String() string {
+	return fmt.Sprintf("APPROX_COUNT_DISTINCT(%v)", a.Expr)
+}
+
+// Aggregator returns an ApproxCountDistinctAggregator. It implements the AggregatorBuilder interface.
+func (a *ApproxCountDistinct) Aggregator() expr.Aggregator {
+	return &ApproxCountDistinctAggregator{
+		Fn:     a,
+		Sketch: hyperloglog.New(hyperloglog.DefaultPrecision),
+	}
+}
+
+// ApproxCountDistinctAggregator adds the hashes of the values of a group to a sketch.
+// Values are hashed using their key encoding, so that values considered equal
+// by DISTINCT have the same hash.
+type ApproxCountDistinctAggregator struct {
+	Fn     *ApproxCountDistinct
+	Sketch *hyperloglog.Sketch
+
+	buf []byte
+}
+
+// Aggregate adds the value of the expression to the sketch if it is not NULL.
+func (a *ApproxCountDistinctAggregator) Aggregate(env *environment.Environment) error {
+	v, err := a.Fn.Expr.Eval(env)
+	if err != nil && !errors.Is(err, types.ErrColumnNotFound) {
+		return err
+	}
+	if v == nil || v.Type() == types.TypeNull {
+		return nil
+	}
+
+	a.buf, err = types.EncodeValueAsKey(a.buf[:0], v, false)
+	if err != nil {
+		return err
+	}
+
+	a.Sketch.Add(xxhash.Sum64(a.buf))
+	return nil
+}
+
+// Eval returns the estimated number of distinct values as a bigint.
+func (a *ApproxCountDistinctAggregator) Eval(_ *environment.Environment) (types.Value, error) {
+	return types.NewBigintValue(int64(a.Sketch.Count())), nil
+}
+
+func (a *ApproxCountDistinctAggregator) String() string {
+	return a.Fn.String()
+}
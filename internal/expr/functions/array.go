@@ -0,0 +1,191 @@
+package functions
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/chaisql/chai/internal/environment"
+	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// ArrayAgg is the ARRAY_AGG aggregator function.
+// It collects the values of a group, including NULL, into an array.
+// The values are collected in the order of the ORDER BY clause
+// of the call if any, and in the order of the rows otherwise.
+type ArrayAgg struct {
+	Expr expr.Expr
+	// expression given to the ORDER BY clause of the call, if any.
+	OrderBy expr.Expr
+	Desc    bool
+}
+
+func (a *ArrayAgg) Clone() expr.Expr {
+	return &ArrayAgg{
+		Expr:    expr.Clone(a.Expr),
+		OrderBy: expr.Clone(a.OrderBy),
+		Desc:    a.Desc,
+	}
+}
+
+// Eval extracts the result of the aggregation from the given row and returns it.
+func (a *ArrayAgg) Eval(env *environment.Environment) (types.Value, error) {
+	r, ok := env.GetRow()
+	if !ok {
+		return nil, errors.New("misuse of aggregation function ARRAY_AGG()")
+	}
+
+	return r.Get(a.String())
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (a *ArrayAgg) IsEqual(other expr.Expr) bool {
+	if other == nil {
+		return false
+	}
+
+	o, ok := other.(*ArrayAgg)
+	if !ok {
+		return false
+	}
+
+	return a.Desc == o.Desc && expr.Equal(a.Expr, o.Expr) && expr.Equal(a.OrderBy, o.OrderBy)
+}
+
+func (a *ArrayAgg) Params() []expr.Expr {
+	if a.OrderBy == nil {
+		return []expr.Expr{a.Expr}
+	}
+
+	return []expr.Expr{a.Expr, a.OrderBy}
+}
+
+func (a *ArrayAgg) String() string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "ARRAY_AGG(%v", a.Expr)
+	if a.OrderBy != nil {
+		fmt.Fprintf(&sb, " ORDER BY %v", a.OrderBy)
+		if a.Desc {
+			sb.WriteString(" DESC")
+		}
+	}
+	sb.WriteString(")")
+
+	return sb.String()
+}
+
+// Aggregator returns an ArrayAggAggregator. It implements the AggregatorBuilder interface.
+func (a *ArrayAgg) Aggregator() expr.Aggregator {
+	return &ArrayAggAggregator{
+		Fn: a,
+	}
+}
+
+// ArrayAggAggregator collects the values of a group.
+type ArrayAggAggregator struct {
+	Fn     *ArrayAgg
+	Values []types.Value
+	// encoded values of the ORDER BY expression
+	Keys [][]byte
+}
+
+// Aggregate stores the value of the expression.
+func (s *ArrayAggAggregator) Aggregate(env *environment.Environment) error {
+	v, err := s.Fn.Expr.Eval(env)
+	if err != nil && !errors.Is(err, types.ErrColumnNotFound) {
+		return err
+	}
+	if v == nil {
+		v = types.NewNullValue()
+	}
+
+	k, err := orderKey(env, s.Fn.OrderBy)
+	if err != nil {
+		return err
+	}
+
+	s.Values = append(s.Values, v)
+	s.Keys = append(s.Keys, k)
+	return nil
+}
+
+// Eval returns the array of the values, or NULL if there are none.
+func (s *ArrayAggAggregator) Eval(_ *environment.Environment) (types.Value, error) {
+	if len(s.Values) == 0 {
+		return types.NewNullValue(), nil
+	}
+
+	if s.Fn.OrderBy != nil {
+		sort.Stable(s)
+	}
+
+	return types.MakeArray(s.Values...)
+}
+
+func (s *ArrayAggAggregator) Len() int { return len(s.Values) }
+
+func (s *ArrayAggAggregator) Less(i, j int) bool {
+	return compareKeys(s.Keys[i], s.Keys[j], s.Fn.Desc) < 0
+}
+
+func (s *ArrayAggAggregator) Swap(i, j int) {
+	s.Values[i], s.Values[j] = s.Values[j], s.Values[i]
+	s.Keys[i], s.Keys[j] = s.Keys[j], s.Keys[i]
+}
+
+func (s *ArrayAggAggregator) String() string {
+	return s.Fn.String()
+}
+
+// Unnest is the UNNEST function. It returns one row per element of an array:
+// in the projection of a query, each row is repeated for every element of the array,
+// and each element is stored in a column named after the function call,
+// from which Eval reads it. Rows with a NULL or an empty array are skipped.
+type Unnest struct {
+	Expr expr.Expr
+}
+
+func (u *Unnest) Clone() expr.Expr {
+	return &Unnest{
+		Expr: expr.Clone(u.Expr),
+	}
+}
+
+// Eval returns the element of the array stored in the row.
+func (u *Unnest) Eval(env *environment.Environment) (types.Value, error) {
+	r, ok := env.GetRow()
+	if !ok {
+		return nil, errors.New("misuse of function UNNEST()")
+	}
+
+	v, err := r.Get(u.String())
+	if errors.Is(err, types.ErrColumnNotFound) {
+		return nil, errors.New("UNNEST() is only allowed in the projection and in the FROM clause")
+	}
+	return v, err
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (u *Unnest) IsEqual(other expr.Expr) bool {
+	if other == nil {
+		return false
+	}
+
+	o, ok := other.(*Unnest)
+	if !ok {
+		return false
+	}
+
+	return expr.Equal(u.Expr, o.Expr)
+}
+
+func (u *Unnest) Params() []expr.Expr { return []expr.Expr{u.Expr} }
+
+func (u *Unnest) String() string {
+	return fmt.Sprintf("UNNEST(%v)", u.Expr)
+}
@@ -0,0 +1,146 @@
+package functions
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// encode returns the textual representation of a blob,
+// using one of the 'hex' or 'base64' formats.
+var encode = &ScalarDefinition{
+	name:  "encode",
+	arity: 2,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		if args[0].Type() != types.TypeBlob || args[1].Type() != types.TypeText {
+			return types.NewNullValue(), nil
+		}
+
+		data := types.AsByteSlice(args[0])
+		switch strings.ToLower(types.AsString(args[1])) {
+		case "hex":
+			return types.NewTextValue(hex.EncodeToString(data)), nil
+		case "base64":
+			return types.NewTextValue(base64.StdEncoding.EncodeToString(data)), nil
+		}
+
+		return nil, errors.Errorf("unrecognized encoding: %q", types.AsString(args[1]))
+	},
+}
+
+// decode returns the blob represented by a text,
+// using one of the 'hex' or 'base64' formats.
+var decode = &ScalarDefinition{
+	name:  "decode",
+	arity: 2,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		if args[0].Type() != types.TypeText || args[1].Type() != types.TypeText {
+			return types.NewNullValue(), nil
+		}
+
+		s := types.AsString(args[0])
+		var data []byte
+		var err error
+		switch strings.ToLower(types.AsString(args[1])) {
+		case "hex":
+			data, err = hex.DecodeString(s)
+		case "base64":
+			data, err = base64.StdEncoding.DecodeString(s)
+		default:
+			return nil, errors.Errorf("unrecognized encoding: %q", types.AsString(args[1]))
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid %s data", strings.ToLower(types.AsString(args[1])))
+		}
+
+		return types.NewBlobValue(data), nil
+	},
+}
+
+// The character encodings supported by convert_to and convert_from.
+const (
+	encodingUTF8   = "UTF8"
+	encodingLatin1 = "LATIN1"
+)
+
+// characterEncoding returns the canonical name of a character encoding.
+func characterEncoding(v types.Value) (string, error) {
+	name := types.AsString(v)
+	switch strings.ToUpper(strings.ReplaceAll(name, "-", "")) {
+	case "UTF8":
+		return encodingUTF8, nil
+	case "LATIN1", "ISO88591":
+		return encodingLatin1, nil
+	}
+
+	return "", errors.Errorf("unrecognized character encoding: %q", name)
+}
+
+// convertTo returns the bytes of a text in one of the 'UTF8' or 'LATIN1'
+// character encodings, as a blob.
+var convertTo = &ScalarDefinition{
+	name:  "convert_to",
+	arity: 2,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		if args[0].Type() != types.TypeText || args[1].Type() != types.TypeText {
+			return types.NewNullValue(), nil
+		}
+
+		enc, err := characterEncoding(args[1])
+		if err != nil {
+			return nil, err
+		}
+
+		s := types.AsString(args[0])
+		if enc == encodingUTF8 {
+			return types.NewBlobValue([]byte(s)), nil
+		}
+
+		data := make([]byte, 0, len(s))
+		for _, r := range s {
+			if r > 0xFF {
+				return nil, errors.Errorf("character %q has no equivalent in encoding %s", r, enc)
+			}
+			data = append(data, byte(r))
+		}
+
+		return types.NewBlobValue(data), nil
+	},
+}
+
+// convertFrom returns the text represented by the bytes of a blob
+// in one of the 'UTF8' or 'LATIN1' character encodings.
+var convertFrom = &ScalarDefinition{
+	name:  "convert_from",
+	arity: 2,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		if args[0].Type() != types.TypeBlob || args[1].Type() != types.TypeText {
+			return types.NewNullValue(), nil
+		}
+
+		enc, err := characterEncoding(args[1])
+		if err != nil {
+			return nil, err
+		}
+
+		data := types.AsByteSlice(args[0])
+		if enc == encodingUTF8 {
+			if !utf8.Valid(data) {
+				return nil, errors.Errorf("invalid byte sequence for encoding %s", enc)
+			}
+			return types.NewTextValue(string(data)), nil
+		}
+
+		var sb strings.Builder
+		sb.Grow(len(data))
+		for _, b := range data {
+			sb.WriteRune(rune(b))
+		}
+
+		return types.NewTextValue(sb.String()), nil
+	},
+}
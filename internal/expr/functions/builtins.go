@@ -2,6 +2,7 @@ package functions
 
 import (
 	"fmt"
+	"math"
 	"strings"
 
 	"github.com/chaisql/chai/internal/environment"
@@ -46,6 +47,20 @@ var builtinFunctions = Definitions{
 			return &Sum{Expr: args[0]}, nil
 		},
 	},
+	"count_if": &definition{
+		name:  "count_if",
+		arity: 1,
+		constructorFn: func(args ...expr.Expr) (expr.Function, error) {
+			return &CountIf{Cond: args[0]}, nil
+		},
+	},
+	"sum_if": &definition{
+		name:  "sum_if",
+		arity: 2,
+		constructorFn: func(args ...expr.Expr) (expr.Function, error) {
+			return &SumIf{Cond: args[0], Expr: args[1]}, nil
+		},
+	},
 	"avg": &definition{
 		name:  "avg",
 		arity: 1,
@@ -53,6 +68,173 @@ var builtinFunctions = Definitions{
 			return &Avg{Expr: args[0]}, nil
 		},
 	},
+	"bool_and": &definition{
+		name:  "bool_and",
+		arity: 1,
+		constructorFn: func(args ...expr.Expr) (expr.Function, error) {
+			return &BoolAnd{Expr: args[0]}, nil
+		},
+	},
+	"every": &definition{
+		name:  "every",
+		arity: 1,
+		constructorFn: func(args ...expr.Expr) (expr.Function, error) {
+			return &BoolAnd{Expr: args[0], Every: true}, nil
+		},
+	},
+	"bool_or": &definition{
+		name:  "bool_or",
+		arity: 1,
+		constructorFn: func(args ...expr.Expr) (expr.Function, error) {
+			return &BoolOr{Expr: args[0]}, nil
+		},
+	},
+	"var_pop": &definition{
+		name:  "var_pop",
+		arity: 1,
+		constructorFn: func(args ...expr.Expr) (expr.Function, error) {
+			return &Variance{Expr: args[0]}, nil
+		},
+	},
+	"var_samp": &definition{
+		name:  "var_samp",
+		arity: 1,
+		constructorFn: func(args ...expr.Expr) (expr.Function, error) {
+			return &Variance{Expr: args[0], Sample: true}, nil
+		},
+	},
+	"stddev_pop": &definition{
+		name:  "stddev_pop",
+		arity: 1,
+		constructorFn: func(args ...expr.Expr) (expr.Function, error) {
+			return &Variance{Expr: args[0], Stddev: true}, nil
+		},
+	},
+	"stddev_samp": &definition{
+		name:  "stddev_samp",
+		arity: 1,
+		constructorFn: func(args ...expr.Expr) (expr.Function, error) {
+			return &Variance{Expr: args[0], Sample: true, Stddev: true}, nil
+		},
+	},
+	"covar_pop": &definition{
+		name:  "covar_pop",
+		arity: 2,
+		constructorFn: func(args ...expr.Expr) (expr.Function, error) {
+			return &Covariance{Y: args[0], X: args[1]}, nil
+		},
+	},
+	"covar_samp": &definition{
+		name:  "covar_samp",
+		arity: 2,
+		constructorFn: func(args ...expr.Expr) (expr.Function, error) {
+			return &Covariance{Y: args[0], X: args[1], Sample: true}, nil
+		},
+	},
+	"corr": &definition{
+		name:  "corr",
+		arity: 2,
+		constructorFn: func(args ...expr.Expr) (expr.Function, error) {
+			return &Covariance{Y: args[0], X: args[1], Corr: true}, nil
+		},
+	},
+	"percentile_cont": &definition{
+		name:  "percentile_cont",
+		arity: 2,
+		constructorFn: func(args ...expr.Expr) (expr.Function, error) {
+			return &Percentile{Expr: args[0], Fraction: args[1]}, nil
+		},
+	},
+	"median": &definition{
+		name:  "median",
+		arity: 1,
+		constructorFn: func(args ...expr.Expr) (expr.Function, error) {
+			return &Percentile{Expr: args[0]}, nil
+		},
+	},
+	"approx_percentile": &definition{
+		name:  "approx_percentile",
+		arity: 2,
+		constructorFn: func(args ...expr.Expr) (expr.Function, error) {
+			return &Percentile{Expr: args[0], Fraction: args[1], Approx: true}, nil
+		},
+	},
+	"approx_count_distinct": &definition{
+		name:  "approx_count_distinct",
+		arity: 1,
+		constructorFn: func(args ...expr.Expr) (expr.Function, error) {
+			return &ApproxCountDistinct{Expr: args[0]}, nil
+		},
+	},
+	"histogram": &definition{
+		name:  "histogram",
+		arity: 4,
+		constructorFn: func(args ...expr.Expr) (expr.Function, error) {
+			return &Histogram{Expr: args[0], Low: args[1], High: args[2], Count: args[3]}, nil
+		},
+	},
+	"string_agg": &definition{
+		name:  "string_agg",
+		arity: 2,
+		constructorFn: func(args ...expr.Expr) (expr.Function, error) {
+			return &StringAgg{Expr: args[0], Delimiter: args[1]}, nil
+		},
+	},
+	"group_concat": &definition{
+		name:  "group_concat",
+		arity: variadicArity,
+		constructorFn: func(args ...expr.Expr) (expr.Function, error) {
+			switch len(args) {
+			case 1:
+				return &StringAgg{Expr: args[0], GroupConcat: true}, nil
+			case 2:
+				return &StringAgg{Expr: args[0], Delimiter: args[1], GroupConcat: true}, nil
+			}
+			return nil, fmt.Errorf("group_concat() takes 1 or 2 argument(s), not %d", len(args))
+		},
+	},
+	"array_agg": &definition{
+		name:  "array_agg",
+		arity: 1,
+		constructorFn: func(args ...expr.Expr) (expr.Function, error) {
+			return &ArrayAgg{Expr: args[0]}, nil
+		},
+	},
+	"first_value": &definition{
+		name:  "first_value",
+		arity: 1,
+		constructorFn: func(args ...expr.Expr) (expr.Function, error) {
+			return &FirstValue{Expr: args[0]}, nil
+		},
+	},
+	"last_value": &definition{
+		name:  "last_value",
+		arity: 1,
+		constructorFn: func(args ...expr.Expr) (expr.Function, error) {
+			return &FirstValue{Expr: args[0], Last: true}, nil
+		},
+	},
+	"arg_min": &definition{
+		name:  "arg_min",
+		arity: 2,
+		constructorFn: func(args ...expr.Expr) (expr.Function, error) {
+			return &ArgMin{Expr: args[0], By: args[1]}, nil
+		},
+	},
+	"arg_max": &definition{
+		name:  "arg_max",
+		arity: 2,
+		constructorFn: func(args ...expr.Expr) (expr.Function, error) {
+			return &ArgMin{Expr: args[0], By: args[1], Max: true}, nil
+		},
+	},
+	"unnest": &definition{
+		name:  "unnest",
+		arity: 1,
+		constructorFn: func(args ...expr.Expr) (expr.Function, error) {
+			return &Unnest{Expr: args[0]}, nil
+		},
+	},
 	"len": &definition{
 		name:  "len",
 		arity: 1,
@@ -74,6 +256,23 @@ var builtinFunctions = Definitions{
 			return &Now{}, nil
 		},
 	},
+	"current_date": &definition{
+		name:  "current_date",
+		arity: 0,
+		constructorFn: func(args ...expr.Expr) (expr.Function, error) {
+			return &CurrentDate{}, nil
+		},
+	},
+	"age": &definition{
+		name:  "age",
+		arity: variadicArity,
+		constructorFn: func(args ...expr.Expr) (expr.Function, error) {
+			if len(args) > 2 {
+				return nil, fmt.Errorf("age() takes 1 or 2 argument(s), not %d", len(args))
+			}
+			return &Age{Exprs: args}, nil
+		},
+	},
 
 	"lower": &definition{
 		name:  "lower",
@@ -110,17 +309,148 @@ var builtinFunctions = Definitions{
 			return &Trim{Expr: args, TrimFunc: strings.TrimRight, Name: "RTRIM"}, nil
 		},
 	},
+	"json_extract": &definition{
+		name:  "json_extract",
+		arity: 2,
+		constructorFn: func(args ...expr.Expr) (expr.Function, error) {
+			return NewJSONExtract(args[0], args[1])
+		},
+	},
+	"json_each": &definition{
+		name:  "json_each",
+		arity: variadicArity,
+		constructorFn: func(args ...expr.Expr) (expr.Function, error) {
+			switch len(args) {
+			case 1:
+				return &JSONEach{Expr: args[0]}, nil
+			case 2:
+				return &JSONEach{Expr: args[0], Path: args[1]}, nil
+			}
+			return nil, fmt.Errorf("json_each() takes 1 or 2 argument(s), not %d", len(args))
+		},
+	},
 
-	"floor":  floor,
-	"abs":    abs,
-	"acos":   acos,
-	"acosh":  acosh,
-	"asin":   asin,
-	"asinh":  asinh,
-	"atan":   atan,
-	"atan2":  atan2,
-	"random": random,
-	"sqrt":   sqrt,
+	"generate_series": &definition{
+		name:  "generate_series",
+		arity: variadicArity,
+		constructorFn: func(args ...expr.Expr) (expr.Function, error) {
+			switch len(args) {
+			case 2:
+				return &GenerateSeries{Start: args[0], Stop: args[1]}, nil
+			case 3:
+				return &GenerateSeries{Start: args[0], Stop: args[1], Step: args[2]}, nil
+			}
+			return nil, fmt.Errorf("generate_series() takes 2 or 3 argument(s), not %d", len(args))
+		},
+	},
+
+	"regexp_split_to_table": &definition{
+		name:  "regexp_split_to_table",
+		arity: variadicArity,
+		constructorFn: func(args ...expr.Expr) (expr.Function, error) {
+			if len(args) < 2 || len(args) > 3 {
+				return nil, fmt.Errorf("regexp_split_to_table() takes 2 or 3 argument(s), not %d", len(args))
+			}
+			return &RegexpSplitToTable{Exprs: args}, nil
+		},
+	},
+
+	"make_point": &definition{
+		name:  "make_point",
+		arity: 2,
+		constructorFn: func(args ...expr.Expr) (expr.Function, error) {
+			return &MakePoint{Lat: args[0], Lon: args[1]}, nil
+		},
+	},
+	"within_box": &definition{
+		name:  "within_box",
+		arity: 3,
+		constructorFn: func(args ...expr.Expr) (expr.Function, error) {
+			return &WithinBox{Point: args[0], SW: args[1], NE: args[2]}, nil
+		},
+	},
+
+	"floor":          floor,
+	"abs":            abs,
+	"acos":           acos,
+	"acosh":          acosh,
+	"asin":           asin,
+	"asinh":          asinh,
+	"atan":           atan,
+	"atan2":          atan2,
+	"random":         random,
+	"random_between": randomBetween,
+	"setseed":        setseed,
+	"sqrt":           sqrt,
+	"round":          round,
+	"trunc":          trunc,
+	"sign":           sign,
+	"log":            logarithm,
+	"degrees":        degrees,
+	"radians":        radians,
+	"bit_count":      bitCount,
+	"get_bit":        getBit,
+	"width_bucket":   widthBucketFn,
+
+	"gen_uuid_v4": genUUIDv4,
+	"gen_uuid_v7": genUUIDv7,
+
+	"nextval": nextval,
+	"currval": currval,
+	"setval":  setval,
+	"lastval": lastval,
+
+	"json_set":          jsonSet,
+	"json_remove":       jsonRemove,
+	"json_array_length": jsonArrayLength,
+
+	"encode": encode,
+	"decode": decode,
+
+	"convert_to":   convertTo,
+	"convert_from": convertFrom,
+
+	"md5":    md5Hash,
+	"sha1":   sha1Hash,
+	"sha256": sha256Hash,
+	"xxhash": xxHash,
+	"crc32":  crc32Hash,
+
+	"encrypt": encrypt,
+	"decrypt": decrypt,
+	"hmac":    hmacHash,
+
+	"split_part":            splitPart,
+	"regexp_replace":        regexpReplace,
+	"regexp_match":          regexpMatch,
+	"regexp_split_to_array": regexpSplitToArray,
+	"lpad":                  lpad,
+	"rpad":                  rpad,
+	"translate":             translate,
+	"repeat":                repeat,
+	"reverse":               reverse,
+	"initcap":               initcap,
+	"position":              position,
+	"format":                format,
+
+	"levenshtein": levenshtein,
+	"similarity":  similarity,
+	"soundex":     soundex,
+	"difference":  difference,
+
+	"greatest": greatest,
+	"least":    least,
+	"nullif":   nullif,
+
+	"date_trunc":     dateTrunc,
+	"extract":        extract,
+	"make_date":      makeDate,
+	"make_timestamp": makeTimestamp,
+	"strftime":       strftime,
+
+	"latitude":  latitude,
+	"longitude": longitude,
+	"distance":  distance,
 }
 
 type TypeOf struct {
@@ -548,6 +878,8 @@ func (s *SumAggregator) Aggregate(env *environment.Environment) error {
 		switch v.Type() {
 		case types.TypeInteger, types.TypeBigint:
 			*s.SumF += float64(types.AsInt64(v))
+		case types.TypeUnsignedBigint:
+			*s.SumF += float64(types.AsUint64(v))
 		default:
 			*s.SumF += float64(types.AsFloat64(v))
 		}
@@ -555,7 +887,18 @@ func (s *SumAggregator) Aggregate(env *environment.Environment) error {
 		return nil
 	}
 
-	if v.Type() == types.TypeDouble {
+	// unsigned integers that don't fit in a BIGINT
+	// are summed as doubles
+	if v.Type() == types.TypeUnsignedBigint {
+		x := types.AsUint64(v)
+		if x > math.MaxInt64 {
+			v = types.NewDoubleValue(float64(x))
+		} else {
+			v = types.NewBigintValue(int64(x))
+		}
+	}
+
+	if v.Type() == types.TypeDouble || v.Type() == types.TypeReal {
 		var sumF float64
 		if s.SumI != nil {
 			sumF = float64(*s.SumI)
@@ -659,7 +1002,9 @@ func (s *AvgAggregator) Aggregate(env *environment.Environment) error {
 	switch v.Type() {
 	case types.TypeInteger, types.TypeBigint:
 		s.Avg += float64(types.AsInt64(v))
-	case types.TypeDouble:
+	case types.TypeUnsignedBigint:
+		s.Avg += float64(types.AsUint64(v))
+	case types.TypeDouble, types.TypeReal:
 		s.Avg += types.AsFloat64(v)
 	default:
 		return nil
@@ -682,6 +1027,163 @@ func (s *AvgAggregator) String() string {
 	return s.Fn.String()
 }
 
+// BoolAnd is the BOOL_AND aggregator function, or the EVERY function
+// if Every is set.
+type BoolAnd struct {
+	Expr  expr.Expr
+	Every bool
+}
+
+func (b *BoolAnd) Clone() expr.Expr {
+	return &BoolAnd{
+		Expr:  expr.Clone(b.Expr),
+		Every: b.Every,
+	}
+}
+
+// Eval extracts the result of the aggregation from the given row and returns it.
+func (b *BoolAnd) Eval(env *environment.Environment) (types.Value, error) {
+	r, ok := env.GetRow()
+	if !ok {
+		return nil, errors.Errorf("misuse of aggregation function %s()", b.name())
+	}
+
+	return r.Get(b.String())
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (b *BoolAnd) IsEqual(other expr.Expr) bool {
+	if other == nil {
+		return false
+	}
+
+	o, ok := other.(*BoolAnd)
+	if !ok {
+		return false
+	}
+
+	return b.Every == o.Every && expr.Equal(b.Expr, o.Expr)
+}
+
+func (b *BoolAnd) Params() []expr.Expr { return []expr.Expr{b.Expr} }
+
+func (b *BoolAnd) name() string {
+	if b.Every {
+		return "EVERY"
+	}
+
+	return "BOOL_AND"
+}
+
+func (b *BoolAnd) String() string {
+	return fmt.Sprintf("%s(%v)", b.name(), b.Expr)
+}
+
+// Aggregator returns a BoolAggregator. It implements the AggregatorBuilder interface.
+func (b *BoolAnd) Aggregator() expr.Aggregator {
+	return &BoolAggregator{
+		Fn:  b,
+		Arg: b.Expr,
+		And: true,
+	}
+}
+
+// BoolOr is the BOOL_OR aggregator function.
+type BoolOr struct {
+	Expr expr.Expr
+}
+
+func (b *BoolOr) Clone() expr.Expr {
+	return &BoolOr{
+		Expr: expr.Clone(b.Expr),
+	}
+}
+
+// Eval extracts the result of the aggregation from the given row and returns it.
+func (b *BoolOr) Eval(env *environment.Environment) (types.Value, error) {
+	r, ok := env.GetRow()
+	if !ok {
+		return nil, errors.New("misuse of aggregation function BOOL_OR()")
+	}
+
+	return r.Get(b.String())
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (b *BoolOr) IsEqual(other expr.Expr) bool {
+	if other == nil {
+		return false
+	}
+
+	o, ok := other.(*BoolOr)
+	if !ok {
+		return false
+	}
+
+	return expr.Equal(b.Expr, o.Expr)
+}
+
+func (b *BoolOr) Params() []expr.Expr { return []expr.Expr{b.Expr} }
+
+func (b *BoolOr) String() string {
+	return fmt.Sprintf("BOOL_OR(%v)", b.Expr)
+}
+
+// Aggregator returns a BoolAggregator. It implements the AggregatorBuilder interface.
+func (b *BoolOr) Aggregator() expr.Aggregator {
+	return &BoolAggregator{
+		Fn:  b,
+		Arg: b.Expr,
+	}
+}
+
+// BoolAggregator is an aggregator that combines the non-NULL booleans of a group
+// with AND, or with OR if And is not set. It returns NULL if the group has none.
+type BoolAggregator struct {
+	Fn     expr.Expr
+	Arg    expr.Expr
+	And    bool
+	Result *bool
+}
+
+// Aggregate combines the result with the value of the argument if it is a boolean.
+func (b *BoolAggregator) Aggregate(env *environment.Environment) error {
+	v, err := b.Arg.Eval(env)
+	if err != nil && !errors.Is(err, types.ErrColumnNotFound) {
+		return err
+	}
+	if v == nil || v.Type() != types.TypeBoolean {
+		return nil
+	}
+
+	x := types.AsBool(v)
+	switch {
+	case b.Result == nil:
+		b.Result = &x
+	case b.And:
+		*b.Result = *b.Result && x
+	default:
+		*b.Result = *b.Result || x
+	}
+
+	return nil
+}
+
+// Eval returns the aggregated boolean.
+func (b *BoolAggregator) Eval(_ *environment.Environment) (types.Value, error) {
+	if b.Result == nil {
+		return types.NewNullValue(), nil
+	}
+
+	return types.NewBooleanValue(*b.Result), nil
+}
+
+func (b *BoolAggregator) String() string {
+	return b.Fn.String()
+}
+
 // Len represents the len() function.
 // It returns the length of string, array or row.
 // For other types len() returns NULL.
@@ -703,7 +1205,7 @@ func (s *Len) Eval(env *environment.Environment) (types.Value, error) {
 	}
 	var length int
 	switch val.Type() {
-	case types.TypeText:
+	case types.TypeText, types.TypeCitext:
 		length = len(types.AsString(val))
 	default:
 		return types.NewNullValue(), nil
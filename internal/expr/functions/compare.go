@@ -0,0 +1,88 @@
+package functions
+
+import (
+	"github.com/chaisql/chai/internal/types"
+)
+
+// lessThan reports whether a is smaller than b. Values of the same type,
+// or numbers, are compared by value, and other values by type, like MIN and MAX do.
+func lessThan(a, b types.Value) (bool, error) {
+	if a.Type() == b.Type() || a.Type().IsNumber() && b.Type().IsNumber() {
+		return a.LT(b)
+	}
+
+	return a.Type() < b.Type(), nil
+}
+
+// extremum returns a function returning the largest of its arguments,
+// or the smallest if min is set. NULL arguments are ignored, and NULL is
+// returned if all of them are NULL.
+func extremum(min bool) func(args ...types.Value) (types.Value, error) {
+	return func(args ...types.Value) (types.Value, error) {
+		var res types.Value
+		for _, v := range args {
+			if v.Type() == types.TypeNull {
+				continue
+			}
+			if res == nil {
+				res = v
+				continue
+			}
+
+			a, b := res, v
+			if min {
+				a, b = b, a
+			}
+			ok, err := lessThan(a, b)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				res = v
+			}
+		}
+
+		if res == nil {
+			return types.NewNullValue(), nil
+		}
+
+		return res, nil
+	}
+}
+
+// greatest returns the largest of its arguments, ignoring NULL.
+var greatest = &ScalarDefinition{
+	name:     "greatest",
+	arity:    1,
+	maxArity: variadicArity,
+	callFn:   extremum(false),
+}
+
+// least returns the smallest of its arguments, ignoring NULL.
+var least = &ScalarDefinition{
+	name:     "least",
+	arity:    1,
+	maxArity: variadicArity,
+	callFn:   extremum(true),
+}
+
+// nullif returns NULL if its arguments are equal, and its first argument otherwise.
+var nullif = &ScalarDefinition{
+	name:  "nullif",
+	arity: 2,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		if args[0].Type() == types.TypeNull || args[1].Type() == types.TypeNull {
+			return args[0], nil
+		}
+
+		ok, err := args[0].EQ(args[1])
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return types.NewNullValue(), nil
+		}
+
+		return args[0], nil
+	},
+}
@@ -0,0 +1,165 @@
+package functions
+
+import (
+	"fmt"
+
+	"github.com/chaisql/chai/internal/environment"
+	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// The conditional aggregates are shorthands for aggregates with a FILTER clause:
+// count_if(cond) is COUNT(*) FILTER (WHERE cond) and sum_if(cond, e)
+// is SUM(e) FILTER (WHERE cond). They are computed by an aggregator
+// which only passes the rows matching the condition to the one of
+// COUNT or SUM.
+
+var (
+	_ expr.AggregatorBuilder = (*CountIf)(nil)
+	_ expr.AggregatorBuilder = (*SumIf)(nil)
+)
+
+// CountIf is the COUNT_IF aggregator function. It counts the rows
+// for which the condition is true.
+type CountIf struct {
+	Cond expr.Expr
+}
+
+func (c *CountIf) Clone() expr.Expr {
+	return &CountIf{
+		Cond: expr.Clone(c.Cond),
+	}
+}
+
+// Eval extracts the result of the aggregation from the given row and returns it.
+func (c *CountIf) Eval(env *environment.Environment) (types.Value, error) {
+	r, ok := env.GetRow()
+	if !ok {
+		return nil, errors.New("misuse of aggregation function COUNT_IF()")
+	}
+
+	return r.Get(c.String())
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (c *CountIf) IsEqual(other expr.Expr) bool {
+	if other == nil {
+		return false
+	}
+
+	o, ok := other.(*CountIf)
+	if !ok {
+		return false
+	}
+
+	return expr.Equal(c.Cond, o.Cond)
+}
+
+func (c *CountIf) Params() []expr.Expr { return []expr.Expr{c.Cond} }
+
+func (c *CountIf) String() string {
+	return fmt.Sprintf("COUNT_IF(%v)", c.Cond)
+}
+
+// Aggregator returns a FilterAggregator counting the rows matching the condition.
+// It implements the AggregatorBuilder interface.
+func (c *CountIf) Aggregator() expr.Aggregator {
+	return &FilterAggregator{
+		Fn:         c,
+		Cond:       c.Cond,
+		Aggregator: NewCount(expr.Wildcard{}).Aggregator(),
+	}
+}
+
+// SumIf is the SUM_IF aggregator function. It sums the non-null numeric values
+// of the expression for the rows where the condition is true.
+type SumIf struct {
+	Cond expr.Expr
+	Expr expr.Expr
+}
+
+func (s *SumIf) Clone() expr.Expr {
+	return &SumIf{
+		Cond: expr.Clone(s.Cond),
+		Expr: expr.Clone(s.Expr),
+	}
+}
+
+// Eval extracts the result of the aggregation from the given row and returns it.
+func (s *SumIf) Eval(env *environment.Environment) (types.Value, error) {
+	r, ok := env.GetRow()
+	if !ok {
+		return nil, errors.New("misuse of aggregation function SUM_IF()")
+	}
+
+	return r.Get(s.String())
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (s *SumIf) IsEqual(other expr.Expr) bool {
+	if other == nil {
+		return false
+	}
+
+	o, ok := other.(*SumIf)
+	if !ok {
+		return false
+	}
+
+	return expr.Equal(s.Cond, o.Cond) && expr.Equal(s.Expr, o.Expr)
+}
+
+func (s *SumIf) Params() []expr.Expr { return []expr.Expr{s.Cond, s.Expr} }
+
+func (s *SumIf) String() string {
+	return fmt.Sprintf("SUM_IF(%v, %v)", s.Cond, s.Expr)
+}
+
+// Aggregator returns a FilterAggregator summing the rows matching the condition.
+// It implements the AggregatorBuilder interface.
+func (s *SumIf) Aggregator() expr.Aggregator {
+	return &FilterAggregator{
+		Fn:         s,
+		Cond:       s.Cond,
+		Aggregator: (&Sum{Expr: s.Expr}).Aggregator(),
+	}
+}
+
+// FilterAggregator only aggregates the rows for which the condition is true,
+// like the FILTER clause of an aggregate. Rows where the condition
+// is false or NULL are skipped.
+type FilterAggregator struct {
+	Fn         expr.Expr
+	Cond       expr.Expr
+	Aggregator expr.Aggregator
+}
+
+// Aggregate passes the row to the wrapped aggregator if the condition is true.
+func (f *FilterAggregator) Aggregate(env *environment.Environment) error {
+	v, err := f.Cond.Eval(env)
+	if err != nil && !errors.Is(err, types.ErrColumnNotFound) {
+		return err
+	}
+	if v == nil {
+		return nil
+	}
+
+	ok, err := types.IsTruthy(v)
+	if err != nil || !ok {
+		return err
+	}
+
+	return f.Aggregator.Aggregate(env)
+}
+
+// Eval returns the result of the wrapped aggregator.
+func (f *FilterAggregator) Eval(env *environment.Environment) (types.Value, error) {
+	return f.Aggregator.Eval(env)
+}
+
+func (f *FilterAggregator) String() string {
+	return f.Fn.String()
+}
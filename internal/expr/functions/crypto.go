@@ -0,0 +1,150 @@
+package functions
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"hash"
+	"strings"
+
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// The encryption functions work like the ones of the pgcrypto extension of PostgreSQL.
+// Their data and keys are texts or blobs, texts being used as their UTF-8 bytes,
+// and they return NULL for other types.
+
+// aesGCM returns the AES-GCM cipher of a key, which must be 16, 24 or 32 bytes long
+// to select AES-128, AES-192 or AES-256. The type of cipher, if given, must be 'aes'.
+func aesGCM(fn string, args []types.Value) (cipher.AEAD, error) {
+	if len(args) > 2 && !strings.EqualFold(types.AsString(args[2]), "aes") {
+		return nil, errors.Errorf("%s: unsupported cipher type %q", fn, types.AsString(args[2]))
+	}
+
+	key, _ := hashInput(args[1])
+	switch len(key) {
+	case 16, 24, 32:
+	default:
+		return nil, errors.Errorf("%s: key must be 16, 24 or 32 bytes long, got %d", fn, len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// cryptoArgs returns the data of an encryption function,
+// or false if one of its arguments has the wrong type.
+func cryptoArgs(args []types.Value) ([]byte, bool) {
+	data, ok := hashInput(args[0])
+	if !ok {
+		return nil, false
+	}
+	if _, ok := hashInput(args[1]); !ok {
+		return nil, false
+	}
+	if len(args) > 2 && args[2].Type() != types.TypeText {
+		return nil, false
+	}
+
+	return data, true
+}
+
+// encrypt encrypts data with AES-GCM and returns a blob made of a random nonce
+// followed by the encrypted data and its authentication tag.
+// Encrypting the same data twice returns different blobs.
+var encrypt = &ScalarDefinition{
+	name:     "encrypt",
+	arity:    2,
+	maxArity: 3,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		data, ok := cryptoArgs(args)
+		if !ok {
+			return types.NewNullValue(), nil
+		}
+
+		gcm, err := aesGCM("encrypt(data, key[, type])", args)
+		if err != nil {
+			return nil, err
+		}
+
+		nonce := make([]byte, gcm.NonceSize(), gcm.NonceSize()+len(data)+gcm.Overhead())
+		_, err = rand.Read(nonce)
+		if err != nil {
+			return nil, err
+		}
+
+		return types.NewBlobValue(gcm.Seal(nonce, nonce, data, nil)), nil
+	},
+}
+
+// decrypt decrypts a blob returned by encrypt with the same key.
+// It returns an error if the key is wrong or the blob was modified.
+var decrypt = &ScalarDefinition{
+	name:     "decrypt",
+	arity:    2,
+	maxArity: 3,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		const fn = "decrypt(data, key[, type])"
+		data, ok := cryptoArgs(args)
+		if !ok {
+			return types.NewNullValue(), nil
+		}
+
+		gcm, err := aesGCM(fn, args)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(data) < gcm.NonceSize()+gcm.Overhead() {
+			return nil, errors.Errorf("%s: wrong key or corrupt data", fn)
+		}
+		nonce, data := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+		plain, err := gcm.Open(nil, nonce, data, nil)
+		if err != nil {
+			return nil, errors.Errorf("%s: wrong key or corrupt data", fn)
+		}
+
+		return types.NewBlobValue(plain), nil
+	},
+}
+
+// hmacAlgorithms are the hashes supported by hmac, by name.
+var hmacAlgorithms = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// hmacHash returns the HMAC of data with a key, using one of the
+// 'md5', 'sha1', 'sha256' or 'sha512' hashes, as a blob.
+var hmacHash = &ScalarDefinition{
+	name:  "hmac",
+	arity: 3,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		data, ok := cryptoArgs(args)
+		if !ok {
+			return types.NewNullValue(), nil
+		}
+
+		newHash, ok := hmacAlgorithms[strings.ToLower(types.AsString(args[2]))]
+		if !ok {
+			return nil, errors.Errorf("hmac(data, key, type): unsupported hash type %q", types.AsString(args[2]))
+		}
+
+		key, _ := hashInput(args[1])
+		h := hmac.New(newHash, key)
+		h.Write(data)
+		return types.NewBlobValue(h.Sum(nil)), nil
+	},
+}
@@ -0,0 +1,212 @@
+package functions
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/chaisql/chai/internal/types"
+)
+
+// The fuzzy matching functions compare texts by edit distance,
+// by trigrams like the pg_trgm extension of PostgreSQL,
+// or by how they sound in English.
+
+// levenshtein returns the minimum cost of the insertions, deletions and
+// substitutions of characters turning a text into another one.
+// By default each operation costs 1. Other costs can be given as
+// levenshtein(source, target, insertion cost, deletion cost, substitution cost).
+var levenshtein = &ScalarDefinition{
+	name:     "levenshtein",
+	arity:    2,
+	maxArity: 5,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		if len(args) != 2 && len(args) != 5 {
+			return nil, fmt.Errorf("levenshtein(source, target[, ins_cost, del_cost, sub_cost]) takes 2 or 5 arguments, not %d", len(args))
+		}
+		if anyNull(args) {
+			return types.NewNullValue(), nil
+		}
+
+		texts, ok := textArgs(args[:2]...)
+		if !ok {
+			return types.NewNullValue(), nil
+		}
+
+		ins, del, sub := 1, 1, 1
+		if len(args) == 5 {
+			costs := make([]int, 3)
+			for i, a := range args[2:] {
+				c, err := intArg("levenshtein(source, target, ins_cost, del_cost, sub_cost)", a)
+				if err != nil {
+					return nil, err
+				}
+				if c < 0 {
+					return nil, fmt.Errorf("levenshtein(source, target, ins_cost, del_cost, sub_cost): costs must not be negative")
+				}
+				costs[i] = c
+			}
+			ins, del, sub = costs[0], costs[1], costs[2]
+		}
+
+		d := editDistance([]rune(texts[0]), []rune(texts[1]), ins, del, sub)
+		return types.NewIntegerValue(int32(min(d, 1<<31-1))), nil
+	},
+}
+
+// editDistance computes the Levenshtein distance between s and t,
+// keeping a single row of the matrix.
+func editDistance(s, t []rune, ins, del, sub int) int {
+	row := make([]int, len(t)+1)
+	for j := range row {
+		row[j] = j * ins
+	}
+
+	for i := 1; i <= len(s); i++ {
+		prev := row[0]
+		row[0] = i * del
+		for j := 1; j <= len(t); j++ {
+			cost := prev
+			if s[i-1] != t[j-1] {
+				cost += sub
+			}
+			prev = row[j]
+			row[j] = min(cost, row[j]+del, row[j-1]+ins)
+		}
+	}
+
+	return row[len(t)]
+}
+
+// trigrams returns the set of trigrams of a text: the text is converted to lower case
+// and split into words of letters and digits, each word being padded with two spaces
+// at the start and one at the end.
+func trigrams(s string) map[string]struct{} {
+	set := make(map[string]struct{})
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	for _, w := range words {
+		r := []rune("  " + w + " ")
+		for i := 0; i+3 <= len(r); i++ {
+			set[string(r[i:i+3])] = struct{}{}
+		}
+	}
+
+	return set
+}
+
+// similarity returns how similar two texts are, from 0 to 1, as the number of their
+// common trigrams divided by the number of their distinct trigrams.
+var similarity = &ScalarDefinition{
+	name:  "similarity",
+	arity: 2,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		texts, ok := textArgs(args...)
+		if !ok {
+			return types.NewNullValue(), nil
+		}
+
+		a, b := trigrams(texts[0]), trigrams(texts[1])
+		if len(a) == 0 || len(b) == 0 {
+			return types.NewDoubleValue(0), nil
+		}
+
+		common := 0
+		for t := range a {
+			if _, ok := b[t]; ok {
+				common++
+			}
+		}
+
+		return types.NewDoubleValue(float64(common) / float64(len(a)+len(b)-common)), nil
+	},
+}
+
+// soundexCodes are the digits of the consonants of the Soundex algorithm,
+// indexed by letter. Vowels, H, W and Y have no digit.
+const soundexCodes = "01230120022455012623010202"
+
+// soundexCode returns the Soundex code of a text: its first letter in upper case
+// followed by three digits representing the sound of the following consonants.
+// Characters other than ASCII letters are ignored. It returns an empty text
+// if the text has no letters.
+func soundexCode(s string) string {
+	var code []byte
+	var last byte
+
+	for i := 0; i < len(s) && len(code) < 4; i++ {
+		c := s[i]
+		if c >= 'a' && c <= 'z' {
+			c -= 'a' - 'A'
+		}
+		if c < 'A' || c > 'Z' {
+			continue
+		}
+
+		digit := soundexCodes[c-'A']
+		if len(code) == 0 {
+			code = append(code, c)
+			last = digit
+			continue
+		}
+
+		switch {
+		case c == 'H' || c == 'W':
+			// don't separate consonants with the same digit
+		case digit == '0':
+			// vowels separate consonants with the same digit
+			last = 0
+		case digit != last:
+			code = append(code, digit)
+			last = digit
+		}
+	}
+
+	if len(code) == 0 {
+		return ""
+	}
+	for len(code) < 4 {
+		code = append(code, '0')
+	}
+
+	return string(code)
+}
+
+// soundex returns the Soundex code of a text, such as R163 for 'Robert'.
+var soundex = &ScalarDefinition{
+	name:  "soundex",
+	arity: 1,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		texts, ok := textArgs(args...)
+		if !ok {
+			return types.NewNullValue(), nil
+		}
+
+		return types.NewTextValue(soundexCode(texts[0])), nil
+	},
+}
+
+// difference returns the number of characters, from 0 to 4, which are the same
+// at the same position in the Soundex codes of two texts.
+var difference = &ScalarDefinition{
+	name:  "difference",
+	arity: 2,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		texts, ok := textArgs(args...)
+		if !ok {
+			return types.NewNullValue(), nil
+		}
+
+		a, b := soundexCode(texts[0]), soundexCode(texts[1])
+		n := 0
+		for i := 0; i < len(a) && i < len(b); i++ {
+			if a[i] == b[i] {
+				n++
+			}
+		}
+
+		return types.NewIntegerValue(int32(n)), nil
+	},
+}
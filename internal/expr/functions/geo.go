@@ -0,0 +1,245 @@
+package functions
+
+import (
+	"fmt"
+
+	"github.com/chaisql/chai/internal/environment"
+	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// MakePoint is the MAKE_POINT function.
+// It returns the point of the given latitude and longitude, in degrees.
+type MakePoint struct {
+	Lat expr.Expr
+	Lon expr.Expr
+}
+
+func (m *MakePoint) Clone() expr.Expr {
+	return &MakePoint{
+		Lat: expr.Clone(m.Lat),
+		Lon: expr.Clone(m.Lon),
+	}
+}
+
+func (m *MakePoint) Eval(env *environment.Environment) (types.Value, error) {
+	lat, err := m.Lat.Eval(env)
+	if err != nil {
+		return nil, err
+	}
+	lon, err := m.Lon.Eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	return makePoint(lat, lon)
+}
+
+func makePoint(lat, lon types.Value) (types.Value, error) {
+	if lat.Type() == types.TypeNull || lon.Type() == types.TypeNull {
+		return types.NewNullValue(), nil
+	}
+	if !lat.Type().IsNumber() || !lon.Type().IsNumber() {
+		return nil, errors.New("make_point(lat, lon) expects lat and lon to be numbers")
+	}
+
+	x, err := lat.CastAs(types.TypeDouble)
+	if err != nil {
+		return nil, err
+	}
+	y, err := lon.CastAs(types.TypeDouble)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := types.NewPoint(types.AsFloat64(x), types.AsFloat64(y))
+	if err != nil {
+		return nil, errors.Wrap(err, "make_point(lat, lon)")
+	}
+
+	return types.NewPointValue(p), nil
+}
+
+func (m *MakePoint) IsEqual(other expr.Expr) bool {
+	if other == nil {
+		return false
+	}
+
+	o, ok := other.(*MakePoint)
+	if !ok {
+		return false
+	}
+
+	return expr.Equal(m.Lat, o.Lat) && expr.Equal(m.Lon, o.Lon)
+}
+
+func (m *MakePoint) Params() []expr.Expr { return []expr.Expr{m.Lat, m.Lon} }
+
+func (m *MakePoint) String() string {
+	return fmt.Sprintf("make_point(%v, %v)", m.Lat, m.Lon)
+}
+
+// WithinBox is the WITHIN_BOX function.
+// It returns whether a point is within the bounding box whose south-west
+// and north-east corners are sw and ne. If the longitude of sw is greater
+// than the one of ne, the box crosses the antimeridian.
+// When the corners are constant, the planner reads the points of an
+// indexed column from an index range, see Box.
+type WithinBox struct {
+	Point expr.Expr
+	SW    expr.Expr
+	NE    expr.Expr
+}
+
+func (w *WithinBox) Clone() expr.Expr {
+	return &WithinBox{
+		Point: expr.Clone(w.Point),
+		SW:    expr.Clone(w.SW),
+		NE:    expr.Clone(w.NE),
+	}
+}
+
+func (w *WithinBox) Eval(env *environment.Environment) (types.Value, error) {
+	var points [3]types.Point
+	for i, e := range []expr.Expr{w.Point, w.SW, w.NE} {
+		v, err := e.Eval(env)
+		if err != nil {
+			return nil, err
+		}
+		if v.Type() == types.TypeNull {
+			return types.NewNullValue(), nil
+		}
+
+		p, ok, err := types.AsPoint(v)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, errors.New("within_box(p, sw, ne) expects p, sw and ne to be points")
+		}
+		points[i] = p
+	}
+
+	return types.NewBooleanValue(points[0].InBox(points[1], points[2])), nil
+}
+
+// Box returns the corners of the bounding box if they are constant,
+// that is if they are literals or MAKE_POINT calls with literal arguments.
+func (w *WithinBox) Box() (sw, ne types.Point, ok bool, err error) {
+	sw, ok, err = constantPoint(w.SW)
+	if !ok || err != nil {
+		return
+	}
+
+	ne, ok, err = constantPoint(w.NE)
+	return
+}
+
+func constantPoint(e expr.Expr) (types.Point, bool, error) {
+	switch t := e.(type) {
+	case expr.LiteralValue:
+		return types.AsPoint(t.Value)
+	case *MakePoint:
+		lat, ok := t.Lat.(expr.LiteralValue)
+		if !ok {
+			return types.Point{}, false, nil
+		}
+		lon, ok := t.Lon.(expr.LiteralValue)
+		if !ok {
+			return types.Point{}, false, nil
+		}
+
+		v, err := makePoint(lat.Value, lon.Value)
+		if err != nil || v.Type() == types.TypeNull {
+			return types.Point{}, false, err
+		}
+		return types.AsPoint(v)
+	}
+
+	return types.Point{}, false, nil
+}
+
+func (w *WithinBox) IsEqual(other expr.Expr) bool {
+	if other == nil {
+		return false
+	}
+
+	o, ok := other.(*WithinBox)
+	if !ok {
+		return false
+	}
+
+	return expr.Equal(w.Point, o.Point) && expr.Equal(w.SW, o.SW) && expr.Equal(w.NE, o.NE)
+}
+
+func (w *WithinBox) Params() []expr.Expr { return []expr.Expr{w.Point, w.SW, w.NE} }
+
+func (w *WithinBox) String() string {
+	return fmt.Sprintf("within_box(%v, %v, %v)", w.Point, w.SW, w.NE)
+}
+
+// asPointArg returns the point of an argument of a scalar function.
+func asPointArg(fn string, v types.Value) (types.Point, error) {
+	p, ok, err := types.AsPoint(v)
+	if err != nil {
+		return types.Point{}, err
+	}
+	if !ok {
+		return types.Point{}, fmt.Errorf("%s expects a point, got %s", fn, v.Type())
+	}
+
+	return p, nil
+}
+
+// latitude returns the latitude of a point, in degrees.
+var latitude = &ScalarDefinition{
+	name:  "latitude",
+	arity: 1,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		if args[0].Type() == types.TypeNull {
+			return args[0], nil
+		}
+		p, err := asPointArg("latitude(arg1)", args[0])
+		if err != nil {
+			return nil, err
+		}
+		return types.NewDoubleValue(p.Lat), nil
+	},
+}
+
+// longitude returns the longitude of a point, in degrees.
+var longitude = &ScalarDefinition{
+	name:  "longitude",
+	arity: 1,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		if args[0].Type() == types.TypeNull {
+			return args[0], nil
+		}
+		p, err := asPointArg("longitude(arg1)", args[0])
+		if err != nil {
+			return nil, err
+		}
+		return types.NewDoubleValue(p.Lon), nil
+	},
+}
+
+// distance returns the great-circle distance between two points, in meters.
+var distance = &ScalarDefinition{
+	name:  "distance",
+	arity: 2,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		if args[0].Type() == types.TypeNull || args[1].Type() == types.TypeNull {
+			return types.NewNullValue(), nil
+		}
+		a, err := asPointArg("distance(arg1, arg2)", args[0])
+		if err != nil {
+			return nil, err
+		}
+		b, err := asPointArg("distance(arg1, arg2)", args[1])
+		if err != nil {
+			return nil, err
+		}
+		return types.NewDoubleValue(a.Distance(b)), nil
+	},
+}
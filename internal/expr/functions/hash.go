@@ -0,0 +1,115 @@
+package functions
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"hash/crc32"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/types"
+)
+
+// The hash functions accept a text or a blob and return NULL for other types.
+// Texts are hashed as their UTF-8 bytes, so hashing a text and the blob
+// of the same bytes returns the same value.
+
+// hashInput returns the bytes of a text or a blob.
+func hashInput(v types.Value) ([]byte, bool) {
+	switch v.Type() {
+	case types.TypeText:
+		return []byte(types.AsString(v)), true
+	case types.TypeBlob:
+		return types.AsByteSlice(v), true
+	}
+
+	return nil, false
+}
+
+// hexDigest returns a function returning the digest of its argument
+// computed by the given hash, as lowercase hexadecimal text.
+func hexDigest(newHash func() hash.Hash) func(args ...types.Value) (types.Value, error) {
+	return func(args ...types.Value) (types.Value, error) {
+		data, ok := hashInput(args[0])
+		if !ok {
+			return types.NewNullValue(), nil
+		}
+
+		h := newHash()
+		h.Write(data)
+		return types.NewTextValue(hex.EncodeToString(h.Sum(nil))), nil
+	}
+}
+
+// md5Hash returns the MD5 digest of a text or a blob, in hexadecimal.
+var md5Hash = &ScalarDefinition{
+	name:   "md5",
+	arity:  1,
+	callFn: hexDigest(md5.New),
+}
+
+// sha1Hash returns the SHA-1 digest of a text or a blob, in hexadecimal.
+var sha1Hash = &ScalarDefinition{
+	name:   "sha1",
+	arity:  1,
+	callFn: hexDigest(sha1.New),
+}
+
+// sha256Hash returns the SHA-256 digest of a text or a blob, in hexadecimal.
+var sha256Hash = &ScalarDefinition{
+	name:   "sha256",
+	arity:  1,
+	callFn: hexDigest(sha256.New),
+}
+
+// xxHash returns the 64-bit xxHash of a text or a blob, as a bigint.
+// Hashes larger than the maximum bigint are returned as negative numbers.
+var xxHash = &ScalarDefinition{
+	name:  "xxhash",
+	arity: 1,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		data, ok := hashInput(args[0])
+		if !ok {
+			return types.NewNullValue(), nil
+		}
+
+		return types.NewBigintValue(int64(xxhash.Sum64(data))), nil
+	},
+}
+
+// crc32Hash returns the CRC-32 checksum of a text or a blob, as a bigint,
+// using the IEEE polynomial.
+var crc32Hash = &ScalarDefinition{
+	name:  "crc32",
+	arity: 1,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		data, ok := hashInput(args[0])
+		if !ok {
+			return types.NewNullValue(), nil
+		}
+
+		return types.NewBigintValue(int64(crc32.ChecksumIEEE(data))), nil
+	},
+}
+
+// HashType returns the type of the values returned by the expression
+// if it is a call to one of the hash functions.
+// Their results only depend on their argument, which allows to index them.
+func HashType(e expr.Expr) (types.Type, bool) {
+	sf, ok := e.(*ScalarFunction)
+	if !ok {
+		return 0, false
+	}
+
+	switch sf.def {
+	case md5Hash, sha1Hash, sha256Hash:
+		return types.TypeText, true
+	case xxHash, crc32Hash:
+		return types.TypeBigint, true
+	}
+
+	return 0, false
+}
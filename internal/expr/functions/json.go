@@ -0,0 +1,308 @@
+package functions
+
+import (
+	"fmt"
+
+	"github.com/chaisql/chai/internal/environment"
+	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// JSONExtract is the JSON_EXTRACT function.
+// It returns the value of a JSON document selected by a path, such as $.a.b[0],
+// or NULL if it doesn't exist. Documents are traversed in their binary form:
+// only the selected value is decoded.
+type JSONExtract struct {
+	Expr expr.Expr
+	Path expr.Expr
+
+	// path parsed once, if it is a literal
+	path types.JSONPath
+}
+
+// NewJSONExtract returns a JSON_EXTRACT function. If the path is a literal,
+// it is parsed once.
+func NewJSONExtract(e, path expr.Expr) (*JSONExtract, error) {
+	j := JSONExtract{
+		Expr: e,
+		Path: path,
+	}
+
+	if l, ok := path.(expr.LiteralValue); ok && l.Value.Type() == types.TypeText {
+		var err error
+		j.path, err = types.ParseJSONPath(types.AsString(l.Value))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &j, nil
+}
+
+func (j *JSONExtract) Clone() expr.Expr {
+	return &JSONExtract{
+		Expr: expr.Clone(j.Expr),
+		Path: expr.Clone(j.Path),
+		path: j.path,
+	}
+}
+
+func (j *JSONExtract) Eval(env *environment.Environment) (types.Value, error) {
+	v, err := j.Expr.Eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, ok, err := types.JSONDocument(v)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return types.NewNullValue(), nil
+	}
+
+	path := j.path
+	if path == nil {
+		p, err := j.Path.Eval(env)
+		if err != nil {
+			return nil, err
+		}
+		path, err = parseJSONPath("JSON_EXTRACT", p)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	x, ok := path.Extract(doc)
+	if !ok {
+		return types.NewNullValue(), nil
+	}
+
+	return types.JSONToValue(x), nil
+}
+
+func (j *JSONExtract) IsEqual(other expr.Expr) bool {
+	if other == nil {
+		return false
+	}
+
+	o, ok := other.(*JSONExtract)
+	if !ok {
+		return false
+	}
+
+	return expr.Equal(j.Expr, o.Expr) && expr.Equal(j.Path, o.Path)
+}
+
+func (j *JSONExtract) Params() []expr.Expr { return []expr.Expr{j.Expr, j.Path} }
+
+func (j *JSONExtract) String() string {
+	return fmt.Sprintf("JSON_EXTRACT(%v, %v)", j.Expr, j.Path)
+}
+
+// parseJSONPath parses the path argument of a JSON function.
+func parseJSONPath(name string, p types.Value) (types.JSONPath, error) {
+	if p.Type() != types.TypeText {
+		return nil, errors.Errorf("%s path must be a text, got %s", name, p.Type())
+	}
+
+	return types.ParseJSONPath(types.AsString(p))
+}
+
+// jsonSet returns a copy of a JSON document where the value selected by a path
+// is replaced by the JSON equivalent of a value. A missing field is added to its object,
+// and an index following the last element of an array appends to it.
+// Other missing paths return the document unchanged.
+var jsonSet = &ScalarDefinition{
+	name:  "json_set",
+	arity: 3,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		doc, ok, err := types.JSONDocument(args[0])
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return types.NewNullValue(), nil
+		}
+
+		path, err := parseJSONPath("JSON_SET", args[1])
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := types.ValueToJSON(args[2])
+		if err != nil {
+			return nil, err
+		}
+
+		return types.NewJSONValue(path.Set(doc, value)), nil
+	},
+}
+
+// jsonRemove returns a copy of a JSON document without the value selected by a path,
+// or NULL if the path is the root of the document.
+var jsonRemove = &ScalarDefinition{
+	name:  "json_remove",
+	arity: 2,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		doc, ok, err := types.JSONDocument(args[0])
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return types.NewNullValue(), nil
+		}
+
+		path, err := parseJSONPath("JSON_REMOVE", args[1])
+		if err != nil {
+			return nil, err
+		}
+
+		doc = path.Remove(doc)
+		if doc == nil {
+			return types.NewNullValue(), nil
+		}
+
+		return types.NewJSONValue(doc), nil
+	},
+}
+
+// jsonArrayLength returns the number of elements of a JSON array,
+// or of the array selected by an optional path.
+// It returns NULL if the value is not an array or if the path doesn't exist.
+var jsonArrayLength = &ScalarDefinition{
+	name:     "json_array_length",
+	arity:    1,
+	maxArity: 2,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		doc, ok, err := types.JSONDocument(args[0])
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return types.NewNullValue(), nil
+		}
+
+		if len(args) > 1 {
+			path, err := parseJSONPath("JSON_ARRAY_LENGTH", args[1])
+			if err != nil {
+				return nil, err
+			}
+
+			doc, ok = path.Extract(doc)
+			if !ok {
+				return types.NewNullValue(), nil
+			}
+		}
+
+		n, ok := types.JSONArrayLength(doc)
+		if !ok {
+			return types.NewNullValue(), nil
+		}
+
+		return types.NewBigintValue(int64(n)), nil
+	},
+}
+
+// JSONEach is the JSON_EACH table function, which is only allowed in the FROM clause.
+// It returns one row per element of a JSON array, or per field of a JSON object,
+// of the document or of the value selected by an optional path.
+// The rows are made of the key, value and type columns: the index of the element
+// or the name of the field, its value converted like JSON_EXTRACT does, and its JSON type.
+type JSONEach struct {
+	Expr expr.Expr
+	Path expr.Expr
+}
+
+func (j *JSONEach) Clone() expr.Expr {
+	return &JSONEach{
+		Expr: expr.Clone(j.Expr),
+		Path: expr.Clone(j.Path),
+	}
+}
+
+func (j *JSONEach) Eval(env *environment.Environment) (types.Value, error) {
+	return nil, errors.New("JSON_EACH() is only allowed in the FROM clause")
+}
+
+func (j *JSONEach) IsEqual(other expr.Expr) bool {
+	if other == nil {
+		return false
+	}
+
+	o, ok := other.(*JSONEach)
+	if !ok {
+		return false
+	}
+
+	return expr.Equal(j.Expr, o.Expr) && expr.Equal(j.Path, o.Path)
+}
+
+func (j *JSONEach) Params() []expr.Expr {
+	if j.Path == nil {
+		return []expr.Expr{j.Expr}
+	}
+
+	return []expr.Expr{j.Expr, j.Path}
+}
+
+func (j *JSONEach) String() string {
+	if j.Path == nil {
+		return fmt.Sprintf("JSON_EACH(%v)", j.Expr)
+	}
+
+	return fmt.Sprintf("JSON_EACH(%v, %v)", j.Expr, j.Path)
+}
+
+// Columns returns the columns of the rows. It implements the TableFunction interface.
+func (j *JSONEach) Columns() []string {
+	return []string{"key", "value", "type"}
+}
+
+// Iterate calls fn with the key, value and type of every element of the array,
+// or field of the object. A scalar is returned as a single row with a NULL key,
+// and a NULL or a missing path returns no rows.
+func (j *JSONEach) Iterate(env *environment.Environment, fn func(values []types.Value) error) error {
+	v, err := j.Expr.Eval(env)
+	if err != nil {
+		return err
+	}
+
+	if v.Type() == types.TypeNull {
+		return nil
+	}
+
+	doc, ok, err := types.JSONDocument(v)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.Errorf("cannot call JSON_EACH() on a value of type %s", v.Type())
+	}
+
+	if j.Path != nil {
+		p, err := j.Path.Eval(env)
+		if err != nil {
+			return err
+		}
+
+		path, err := parseJSONPath("JSON_EACH", p)
+		if err != nil {
+			return err
+		}
+
+		doc, ok = path.Extract(doc)
+		if !ok {
+			return nil
+		}
+	}
+
+	values := make([]types.Value, 3)
+	return types.IterateJSON(doc, func(key types.Value, value []byte) error {
+		values[0] = key
+		values[1] = types.JSONToValue(value)
+		values[2] = types.NewTextValue(types.JSONTypeOf(value))
+		return fn(values)
+	})
+}
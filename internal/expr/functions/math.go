@@ -3,8 +3,10 @@ package functions
 import (
 	"fmt"
 	"math"
+	"math/bits"
 	"math/rand"
 
+	"github.com/chaisql/chai/internal/environment"
 	"github.com/chaisql/chai/internal/types"
 )
 
@@ -15,7 +17,9 @@ var floor = &ScalarDefinition{
 		switch args[0].Type() {
 		case types.TypeDouble:
 			return types.NewDoubleValue(math.Floor(types.AsFloat64(args[0]))), nil
-		case types.TypeInteger, types.TypeBigint:
+		case types.TypeReal:
+			return types.NewRealValue(float32(math.Floor(types.AsFloat64(args[0])))), nil
+		case types.TypeInteger, types.TypeBigint, types.TypeUnsignedBigint:
 			return args[0], nil
 		default:
 			return nil, fmt.Errorf("floor(arg1) expects arg1 to be a number")
@@ -27,8 +31,8 @@ var abs = &ScalarDefinition{
 	name:  "abs",
 	arity: 1,
 	callFn: func(args ...types.Value) (types.Value, error) {
-		if args[0].Type() == types.TypeNull {
-			return types.NewNullValue(), nil
+		if args[0].Type() == types.TypeNull || args[0].Type() == types.TypeUnsignedBigint {
+			return args[0], nil
 		}
 		v, err := args[0].CastAs(types.TypeDouble)
 		if err != nil {
@@ -41,6 +45,9 @@ var abs = &ScalarDefinition{
 		if args[0].Type() == types.TypeBigint {
 			return types.NewDoubleValue(res).CastAs(types.TypeBigint)
 		}
+		if args[0].Type() == types.TypeReal {
+			return types.NewDoubleValue(res).CastAs(types.TypeReal)
+		}
 		return types.NewDoubleValue(res), nil
 	},
 }
@@ -152,12 +159,106 @@ var atan2 = &ScalarDefinition{
 	},
 }
 
+// randomGenerator returns the random number generator of the connection
+// if it was seeded by setseed(), or nil if the global generator must be used.
+func randomGenerator(env *environment.Environment) *rand.Rand {
+	if conn := env.GetConnection(); conn != nil {
+		return conn.Rand()
+	}
+
+	return nil
+}
+
+// random returns a random non-negative bigint.
 var random = &ScalarDefinition{
-	name:  "random",
-	arity: 0,
-	callFn: func(args ...types.Value) (types.Value, error) {
-		randomNum := rand.Int63()
-		return types.NewBigintValue(randomNum), nil
+	name:     "random",
+	arity:    0,
+	volatile: true,
+	envCallFn: func(env *environment.Environment, args ...types.Value) (types.Value, error) {
+		if r := randomGenerator(env); r != nil {
+			return types.NewBigintValue(r.Int63()), nil
+		}
+
+		return types.NewBigintValue(rand.Int63()), nil
+	},
+}
+
+// randomBetween returns a random bigint between its two arguments, inclusive.
+var randomBetween = &ScalarDefinition{
+	name:     "random_between",
+	arity:    2,
+	volatile: true,
+	envCallFn: func(env *environment.Environment, args ...types.Value) (types.Value, error) {
+		vA, err := args[0].CastAs(types.TypeBigint)
+		if err != nil || vA.Type() == types.TypeNull {
+			return vA, err
+		}
+		vB, err := args[1].CastAs(types.TypeBigint)
+		if err != nil || vB.Type() == types.TypeNull {
+			return vB, err
+		}
+
+		lo, hi := types.AsInt64(vA), types.AsInt64(vB)
+		if lo > hi {
+			return nil, fmt.Errorf("random_between(arg1, arg2) expects arg1 to be lower than or equal to arg2")
+		}
+
+		uint64n := rand.Uint64
+		int63n := rand.Int63n
+		if r := randomGenerator(env); r != nil {
+			uint64n, int63n = r.Uint64, r.Int63n
+		}
+
+		// number of possible values, which is 0 if it overflows
+		n := uint64(hi-lo) + 1
+		if n != 0 && n <= math.MaxInt64 {
+			return types.NewBigintValue(lo + int63n(int64(n))), nil
+		}
+
+		x := uint64n()
+		for n != 0 && x >= n {
+			x = uint64n()
+		}
+
+		return types.NewBigintValue(lo + int64(x)), nil
+	},
+}
+
+// setseed seeds the random number generator of the connection,
+// which makes the values returned by the random functions reproducible.
+// The seed is an integer, or a double between -1 and 1.
+var setseed = &ScalarDefinition{
+	name:     "setseed",
+	arity:    1,
+	volatile: true,
+	envCallFn: func(env *environment.Environment, args ...types.Value) (types.Value, error) {
+		conn := env.GetConnection()
+		if conn == nil {
+			return nil, fmt.Errorf("setseed(arg1) must be called within a connection")
+		}
+
+		var seed int64
+		switch tp := args[0].Type(); {
+		case tp == types.TypeNull:
+			return args[0], nil
+		case tp.IsInteger():
+			v, err := args[0].CastAs(types.TypeBigint)
+			if err != nil {
+				return nil, err
+			}
+			seed = types.AsInt64(v)
+		case tp == types.TypeDouble, tp == types.TypeReal:
+			x := types.AsFloat64(args[0])
+			if x < -1 || x > 1 {
+				return nil, fmt.Errorf("setseed(arg1) expects arg1 to be between -1 and 1, got %v", x)
+			}
+			seed = int64(math.Float64bits(x))
+		default:
+			return nil, fmt.Errorf("setseed(arg1) expects arg1 to be a number")
+		}
+
+		conn.SetSeed(seed)
+		return types.NewNullValue(), nil
 	},
 }
 
@@ -165,7 +266,7 @@ var sqrt = &ScalarDefinition{
 	name:  "sqrt",
 	arity: 1,
 	callFn: func(args ...types.Value) (types.Value, error) {
-		if args[0].Type() != types.TypeDouble && args[0].Type() != types.TypeInteger {
+		if !args[0].Type().IsNumber() {
 			return types.NewNullValue(), nil
 		}
 		v, err := args[0].CastAs(types.TypeDouble)
@@ -176,3 +277,371 @@ var sqrt = &ScalarDefinition{
 		return types.NewDoubleValue(res), nil
 	},
 }
+
+// round rounds a number to the nearest integer, or to arg2 decimal places,
+// rounding halfway values away from zero. arg2 can be negative,
+// in which case integers are rounded as well: round(1250, -2) = 1300.
+var round = &ScalarDefinition{
+	name:     "round",
+	arity:    1,
+	maxArity: 2,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		return roundNumber("round", args, false)
+	},
+}
+
+// trunc truncates a number towards zero, to an integer or to arg2 decimal places.
+var trunc = &ScalarDefinition{
+	name:     "trunc",
+	arity:    1,
+	maxArity: 2,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		return roundNumber("trunc", args, true)
+	},
+}
+
+// roundNumber implements round and trunc. The result has the type of the number.
+func roundNumber(name string, args []types.Value, trunc bool) (types.Value, error) {
+	var places int64
+	if len(args) > 1 {
+		v, err := args[1].CastAs(types.TypeBigint)
+		if err != nil || v.Type() == types.TypeNull {
+			return v, err
+		}
+		places = types.AsInt64(v)
+	}
+
+	switch tp := args[0].Type(); {
+	case tp == types.TypeNull:
+		return args[0], nil
+	case tp.IsInteger():
+		return roundInteger(args[0], places, trunc)
+	case tp == types.TypeDouble, tp == types.TypeReal:
+		x := roundFloat(types.AsFloat64(args[0]), places, trunc)
+		if tp == types.TypeReal {
+			return types.NewRealValue(float32(x)), nil
+		}
+		return types.NewDoubleValue(x), nil
+	}
+
+	return nil, fmt.Errorf("%s(arg1[, arg2]) expects arg1 to be a number", name)
+}
+
+func roundFloat(x float64, places int64, trunc bool) float64 {
+	fn := math.Round
+	if trunc {
+		fn = math.Trunc
+	}
+
+	// beyond these, the result is either x or 0
+	switch {
+	case places > 340:
+		return x
+	case places < -340:
+		return fn(0)
+	case places >= 0:
+		p := math.Pow10(int(places))
+		// x is returned if it has less decimal places,
+		// or if x * p overflows
+		if r := fn(x*p) / p; !math.IsInf(r, 0) && !math.IsNaN(r) {
+			return r
+		}
+		return x
+	}
+
+	p := math.Pow10(int(-places))
+	return fn(x/p) * p
+}
+
+// roundInteger rounds an integer to a negative number of decimal places.
+func roundInteger(v types.Value, places int64, trunc bool) (types.Value, error) {
+	if places >= 0 {
+		return v, nil
+	}
+
+	// work on the absolute value of v
+	var m uint64
+	var neg bool
+	if v.Type() == types.TypeUnsignedBigint {
+		m = types.AsUint64(v)
+	} else {
+		x := types.AsInt64(v)
+		neg = x < 0
+		m = uint64(x)
+		if neg {
+			m = -m
+		}
+	}
+
+	// 10^20 doesn't fit in an uint64, the result is 0
+	var r uint64
+	if places >= -19 {
+		p := uint64(1)
+		for i := int64(0); i < -places; i++ {
+			p *= 10
+		}
+
+		q, rem := m/p, m%p
+		if !trunc && rem >= p-rem {
+			q++
+		}
+		if q > math.MaxUint64/p {
+			return nil, fmt.Errorf("integer out of range")
+		}
+		r = q * p
+	}
+
+	if v.Type() == types.TypeUnsignedBigint {
+		return types.NewUnsignedBigintValue(r), nil
+	}
+
+	if neg && r <= 1<<63 {
+		return types.NewBigintValue(int64(-r)).CastAs(v.Type())
+	}
+	if !neg && r <= math.MaxInt64 {
+		return types.NewBigintValue(int64(r)).CastAs(v.Type())
+	}
+
+	return nil, fmt.Errorf("integer out of range")
+}
+
+// sign returns -1, 0 or 1 depending on the sign of a number,
+// with the type of the number.
+var sign = &ScalarDefinition{
+	name:  "sign",
+	arity: 1,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		switch tp := args[0].Type(); {
+		case tp == types.TypeNull:
+			return args[0], nil
+		case tp == types.TypeUnsignedBigint:
+			if types.AsUint64(args[0]) == 0 {
+				return types.NewUnsignedBigintValue(0), nil
+			}
+			return types.NewUnsignedBigintValue(1), nil
+		case tp.IsInteger():
+			x := types.AsInt64(args[0])
+			var s int64
+			if x > 0 {
+				s = 1
+			} else if x < 0 {
+				s = -1
+			}
+			return types.NewBigintValue(s).CastAs(tp)
+		case tp == types.TypeDouble, tp == types.TypeReal:
+			x := types.AsFloat64(args[0])
+			var s float64
+			if x > 0 {
+				s = 1
+			} else if x < 0 {
+				s = -1
+			} else if math.IsNaN(x) {
+				s = x
+			}
+			return types.NewDoubleValue(s).CastAs(tp)
+		}
+
+		return nil, fmt.Errorf("sign(arg1) expects arg1 to be a number")
+	},
+}
+
+// log returns the base 10 logarithm of a number,
+// or its logarithm in base arg1 if it is called with two arguments: log(b, x).
+var logarithm = &ScalarDefinition{
+	name:     "log",
+	arity:    1,
+	maxArity: 2,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		base := 10.0
+		if len(args) > 1 {
+			b, err := args[0].CastAs(types.TypeDouble)
+			if err != nil || b.Type() == types.TypeNull {
+				return b, err
+			}
+			base = types.AsFloat64(b)
+			if base <= 0 || base == 1 {
+				return nil, fmt.Errorf("out of range, log(arg1, arg2) expects arg1 > 0 and arg1 != 1")
+			}
+			args = args[1:]
+		}
+
+		v, err := args[0].CastAs(types.TypeDouble)
+		if err != nil || v.Type() == types.TypeNull {
+			return v, err
+		}
+		x := types.AsFloat64(v)
+		if x <= 0 {
+			return nil, fmt.Errorf("out of range, log expects its argument to be > 0")
+		}
+
+		if base == 10 {
+			return types.NewDoubleValue(math.Log10(x)), nil
+		}
+		return types.NewDoubleValue(math.Log(x) / math.Log(base)), nil
+	},
+}
+
+// degrees converts an angle from radians to degrees.
+var degrees = &ScalarDefinition{
+	name:  "degrees",
+	arity: 1,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		v, err := args[0].CastAs(types.TypeDouble)
+		if err != nil || v.Type() == types.TypeNull {
+			return v, err
+		}
+		return types.NewDoubleValue(types.AsFloat64(v) * 180 / math.Pi), nil
+	},
+}
+
+// radians converts an angle from degrees to radians.
+var radians = &ScalarDefinition{
+	name:  "radians",
+	arity: 1,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		v, err := args[0].CastAs(types.TypeDouble)
+		if err != nil || v.Type() == types.TypeNull {
+			return v, err
+		}
+		return types.NewDoubleValue(types.AsFloat64(v) * math.Pi / 180), nil
+	},
+}
+
+// bitsOf returns the bits of an integer, in two's complement for negative numbers,
+// and the number of bits of its type.
+func bitsOf(v types.Value) (uint64, int, bool) {
+	switch v.Type() {
+	case types.TypeInteger:
+		return uint64(uint32(types.AsInt32(v))), 32, true
+	case types.TypeBigint:
+		return uint64(types.AsInt64(v)), 64, true
+	case types.TypeUnsignedBigint:
+		return types.AsUint64(v), 64, true
+	}
+
+	return 0, 0, false
+}
+
+// bitCount returns the number of bits set in an integer, in two's complement
+// for negative numbers, or in a blob.
+var bitCount = &ScalarDefinition{
+	name:  "bit_count",
+	arity: 1,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		if args[0].Type() == types.TypeNull {
+			return args[0], nil
+		}
+
+		if args[0].Type() == types.TypeBlob {
+			var n int
+			for _, b := range types.AsByteSlice(args[0]) {
+				n += bits.OnesCount8(b)
+			}
+			return types.NewBigintValue(int64(n)), nil
+		}
+
+		x, _, ok := bitsOf(args[0])
+		if !ok {
+			return nil, fmt.Errorf("bit_count(arg1) expects arg1 to be an integer or a blob")
+		}
+		return types.NewBigintValue(int64(bits.OnesCount64(x))), nil
+	},
+}
+
+// getBit returns the n-th bit of an integer, starting from the least significant bit,
+// or of a blob, starting from the least significant bit of its first byte.
+var getBit = &ScalarDefinition{
+	name:  "get_bit",
+	arity: 2,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		if anyNull(args) {
+			return types.NewNullValue(), nil
+		}
+		if !args[1].Type().IsInteger() {
+			return nil, fmt.Errorf("get_bit(arg1, n) expects n to be an integer")
+		}
+		n := types.AsInt64(args[1])
+
+		if args[0].Type() == types.TypeBlob {
+			data := types.AsByteSlice(args[0])
+			if n < 0 || n >= int64(len(data))*8 {
+				return nil, fmt.Errorf("get_bit(arg1, n): index %d out of valid range, 0..%d", n, int64(len(data))*8-1)
+			}
+			return types.NewIntegerValue(int32(data[n/8]>>(n%8)) & 1), nil
+		}
+
+		x, size, ok := bitsOf(args[0])
+		if !ok {
+			return nil, fmt.Errorf("get_bit(arg1, n) expects arg1 to be an integer or a blob")
+		}
+		if n < 0 || n >= int64(size) {
+			return nil, fmt.Errorf("get_bit(arg1, n): index %d out of valid range, 0..%d", n, size-1)
+		}
+		return types.NewIntegerValue(int32(x>>n) & 1), nil
+	},
+}
+
+// widthBucket returns the bucket of x among n buckets of the same width
+// between lo and hi, numbered from 1 to n. Values below the lower bound are
+// in bucket 0 and values above the upper bound in bucket n + 1.
+// The lower bound can be greater than the upper bound, to number the
+// buckets in decreasing order.
+func widthBucket(fn string, x, lo, hi float64, n int) (int, error) {
+	if n <= 0 {
+		return 0, fmt.Errorf("%s: count must be greater than zero", fn)
+	}
+	if math.IsNaN(x) || math.IsNaN(lo) || math.IsNaN(hi) {
+		return 0, fmt.Errorf("%s: operand, lower bound, and upper bound cannot be NaN", fn)
+	}
+	if math.IsInf(lo, 0) || math.IsInf(hi, 0) {
+		return 0, fmt.Errorf("%s: lower and upper bounds must be finite", fn)
+	}
+	if lo == hi {
+		return 0, fmt.Errorf("%s: lower bound cannot equal upper bound", fn)
+	}
+
+	if lo > hi {
+		x, lo, hi = -x, -lo, -hi
+	}
+	switch {
+	case x < lo:
+		return 0, nil
+	case x >= hi:
+		return n + 1, nil
+	}
+
+	// rounding errors must not move the largest values out of the last bucket
+	return min(int(float64(n)*((x-lo)/(hi-lo)))+1, n), nil
+}
+
+// widthBucketFn returns the bucket of a number among buckets of the same width
+// between two bounds: width_bucket(operand, low, high, count).
+var widthBucketFn = &ScalarDefinition{
+	name:  "width_bucket",
+	arity: 4,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		const fn = "width_bucket(operand, low, high, count)"
+		if anyNull(args) {
+			return types.NewNullValue(), nil
+		}
+
+		var f [3]float64
+		for i, a := range args[:3] {
+			x, ok := numberOf(a)
+			if !ok {
+				return nil, fmt.Errorf("%s expects numbers, got %s", fn, a.Type())
+			}
+			f[i] = x
+		}
+		n, err := intArg(fn, args[3])
+		if err != nil {
+			return nil, err
+		}
+
+		b, err := widthBucket(fn, f[0], f[1], f[2], n)
+		if err != nil {
+			return nil, err
+		}
+		return types.NewIntegerValue(int32(b)), nil
+	},
+}
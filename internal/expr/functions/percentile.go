@@ -0,0 +1,320 @@
+package functions
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/chaisql/chai/internal/environment"
+	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/pkg/tdigest"
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// percentileBufferSize is the number of values the exact percentile aggregators
+// keep in memory. Past this number, the values are moved to a transient tree,
+// which keeps them sorted and spills to disk when they are too large.
+var percentileBufferSize = 1 << 16
+
+// Percentile is the PERCENTILE_CONT and MEDIAN aggregator functions,
+// or the APPROX_PERCENTILE function if Approx is set.
+// It returns the value at the given fraction of the sorted values of the group,
+// interpolating between the two closest values if necessary.
+type Percentile struct {
+	Expr expr.Expr
+	// fraction between 0 and 1, nil for MEDIAN.
+	Fraction expr.Expr
+	// if set, the percentile is estimated with a t-digest, which uses a bounded
+	// amount of memory instead of keeping every value of the group.
+	Approx bool
+}
+
+func (p *Percentile) Clone() expr.Expr {
+	return &Percentile{
+		Expr:     expr.Clone(p.Expr),
+		Fraction: expr.Clone(p.Fraction),
+		Approx:   p.Approx,
+	}
+}
+
+func (p *Percentile) name() string {
+	switch {
+	case p.Approx:
+		return "APPROX_PERCENTILE"
+	case p.Fraction == nil:
+		return "MEDIAN"
+	}
+	return "PERCENTILE_CONT"
+}
+
+// Eval extracts the result of the aggregation from the given row and returns it.
+func (p *Percentile) Eval(env *environment.Environment) (types.Value, error) {
+	r, ok := env.GetRow()
+	if !ok {
+		return nil, errors.Errorf("misuse of aggregation function %s()", p.name())
+	}
+
+	return r.Get(p.String())
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (p *Percentile) IsEqual(other expr.Expr) bool {
+	if other == nil {
+		return false
+	}
+
+	o, ok := other.(*Percentile)
+	if !ok {
+		return false
+	}
+
+	return p.Approx == o.Approx && expr.Equal(p.Expr, o.Expr) && expr.Equal(p.Fraction, o.Fraction)
+}
+
+func (p *Percentile) Params() []expr.Expr {
+	if p.Fraction == nil {
+		return []expr.Expr{p.Expr}
+	}
+
+	return []expr.Expr{p.Expr, p.Fraction}
+}
+
+func (p *Percentile) String() string {
+	if p.Fraction == nil {
+		return fmt.Sprintf("%s(%v)", p.name(), p.Expr)
+	}
+
+	return fmt.Sprintf("%s(%v, %v)", p.name(), p.Expr, p.Fraction)
+}
+
+// Aggregator returns a PercentileAggregator, or an ApproxPercentileAggregator
+// if Approx is set. It implements the AggregatorBuilder interface.
+func (p *Percentile) Aggregator() expr.Aggregator {
+	if p.Approx {
+		return &ApproxPercentileAggregator{
+			Fn:     p,
+			Digest: tdigest.New(tdigest.DefaultCompression),
+		}
+	}
+
+	return &PercentileAggregator{
+		Fn: p,
+	}
+}
+
+// fraction evaluates the fraction of the percentile.
+// It returns false if it is NULL.
+func (p *Percentile) fraction(env *environment.Environment) (float64, bool, error) {
+	if p.Fraction == nil {
+		return 0.5, true, nil
+	}
+
+	v, err := p.Fraction.Eval(env)
+	if err != nil {
+		return 0, false, err
+	}
+	if v.Type() == types.TypeNull {
+		return 0, false, nil
+	}
+
+	f, ok := numberOf(v)
+	if !ok || !(f >= 0 && f <= 1) {
+		return 0, false, errors.Errorf("%s(): percentile %v is not between 0 and 1", p.name(), v)
+	}
+
+	return f, true, nil
+}
+
+// numberArg evaluates the aggregated expression.
+// It returns false if the value is not a number.
+func (p *Percentile) numberArg(env *environment.Environment) (float64, bool, error) {
+	v, err := p.Expr.Eval(env)
+	if err != nil && !errors.Is(err, types.ErrColumnNotFound) {
+		return 0, false, err
+	}
+	if v == nil {
+		return 0, false, nil
+	}
+
+	x, ok := numberOf(v)
+	return x, ok, nil
+}
+
+// PercentileAggregator computes the exact percentile of the numbers of a group.
+// It keeps the values in memory until there are more than percentileBufferSize,
+// then moves them to a transient tree.
+type PercentileAggregator struct {
+	Fn     *Percentile
+	Values []float64
+	Count  int64
+
+	tree    *tree.Tree
+	cleanup func() error
+}
+
+// Aggregate stores the value of the expression if it is a number.
+func (s *PercentileAggregator) Aggregate(env *environment.Environment) error {
+	x, ok, err := s.Fn.numberArg(env)
+	if err != nil || !ok {
+		return err
+	}
+
+	if s.tree == nil {
+		s.Values = append(s.Values, x)
+		s.Count++
+		if len(s.Values) > percentileBufferSize {
+			return s.spill(env)
+		}
+		return nil
+	}
+
+	err = s.put(x)
+	if err != nil {
+		return err
+	}
+	s.Count++
+	return nil
+}
+
+// spill moves the buffered values to a transient tree.
+// The values are kept in memory if there is no database.
+func (s *PercentileAggregator) spill(env *environment.Environment) error {
+	db, tx := env.GetDB(), env.GetTx()
+	if db == nil || tx == nil {
+		return nil
+	}
+
+	tr, cleanup, err := tx.Catalog.NewTransientTree(db.Engine.NewTransientSession(), nil)
+	if err != nil {
+		return err
+	}
+	s.tree, s.cleanup = tr, cleanup
+
+	for i, x := range s.Values {
+		// the position of the value makes the key unique
+		err = s.tree.Put(tree.NewKey(types.NewDoubleValue(x), types.NewBigintValue(int64(i))), nil)
+		if err != nil {
+			return err
+		}
+	}
+	s.Values = nil
+
+	return nil
+}
+
+func (s *PercentileAggregator) put(x float64) error {
+	return s.tree.Put(tree.NewKey(types.NewDoubleValue(x), types.NewBigintValue(s.Count)), nil)
+}
+
+// Eval returns the percentile as a double, or NULL if there are no values.
+func (s *PercentileAggregator) Eval(env *environment.Environment) (types.Value, error) {
+	f, ok, err := s.Fn.fraction(env)
+	if err != nil || !ok || s.Count == 0 {
+		return types.NewNullValue(), err
+	}
+
+	// the percentile is between the values at the positions lo and hi
+	pos := f * float64(s.Count-1)
+	lo, hi := int64(math.Floor(pos)), int64(math.Ceil(pos))
+
+	var x, y float64
+	if s.tree == nil {
+		sort.Float64s(s.Values)
+		x, y = s.Values[lo], s.Values[hi]
+	} else {
+		x, y, err = s.valuesAt(lo, hi)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if lo == hi {
+		return types.NewDoubleValue(x), nil
+	}
+	return types.NewDoubleValue(x + (pos-float64(lo))*(y-x)), nil
+}
+
+// valuesAt returns the values at the positions lo and hi of the tree,
+// which keeps them in order.
+func (s *PercentileAggregator) valuesAt(lo, hi int64) (x, y float64, err error) {
+	var i int64
+	err = s.tree.IterateOnRange(nil, false, func(k *tree.Key, _ []byte) error {
+		if i < lo {
+			i++
+			return nil
+		}
+
+		values, err := k.Decode()
+		if err != nil {
+			return err
+		}
+		v := types.AsFloat64(values[0])
+
+		if i == lo {
+			x = v
+		}
+		if i == hi {
+			y = v
+			return errStop
+		}
+		i++
+		return nil
+	})
+	if errors.Is(err, errStop) {
+		err = nil
+	}
+
+	return x, y, err
+}
+
+// Close releases the transient tree, if any.
+func (s *PercentileAggregator) Close() error {
+	if s.cleanup == nil {
+		return nil
+	}
+
+	cleanup := s.cleanup
+	s.tree, s.cleanup = nil, nil
+	return cleanup()
+}
+
+func (s *PercentileAggregator) String() string {
+	return s.Fn.String()
+}
+
+var errStop = errors.New("stop")
+
+// ApproxPercentileAggregator estimates the percentile of the numbers of a group
+// with a t-digest.
+type ApproxPercentileAggregator struct {
+	Fn     *Percentile
+	Digest *tdigest.Digest
+}
+
+// Aggregate adds the value of the expression to the digest if it is a number.
+func (s *ApproxPercentileAggregator) Aggregate(env *environment.Environment) error {
+	x, ok, err := s.Fn.numberArg(env)
+	if err != nil || !ok {
+		return err
+	}
+
+	s.Digest.Add(x)
+	return nil
+}
+
+// Eval returns the estimated percentile as a double, or NULL if there are no values.
+func (s *ApproxPercentileAggregator) Eval(env *environment.Environment) (types.Value, error) {
+	f, ok, err := s.Fn.fraction(env)
+	if err != nil || !ok || s.Digest.Count() == 0 {
+		return types.NewNullValue(), err
+	}
+
+	return types.NewDoubleValue(s.Digest.Quantile(f)), nil
+}
+
+func (s *ApproxPercentileAggregator) String() string {
+	return s.Fn.String()
+}
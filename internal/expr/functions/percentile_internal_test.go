@@ -0,0 +1,53 @@
+package functions
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/engine/memory"
+	"github.com/chaisql/chai/internal/environment"
+	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/row"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPercentileSpill(t *testing.T) {
+	defer func(size int) { percentileBufferSize = size }(percentileBufferSize)
+	percentileBufferSize = 10
+
+	db, err := database.Open(":memory:", &database.Options{
+		Engine: memory.NewEngine(),
+	})
+	require.NoError(t, err)
+	defer db.Close()
+
+	tx, err := db.Begin(false)
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	p := &Percentile{Expr: &expr.Column{Name: "a"}, Fraction: expr.LiteralValue{Value: types.NewDoubleValue(0.25)}}
+	agg := p.Aggregator().(*PercentileAggregator)
+
+	env := environment.Environment{DB: db, Tx: tx}
+	for _, i := range rand.Perm(100) {
+		cb := row.NewColumnBuffer().Add("a", types.NewIntegerValue(int32(i)))
+		env.SetRow(cb)
+		require.NoError(t, agg.Aggregate(&env))
+	}
+	require.NotNil(t, agg.tree)
+	require.Nil(t, agg.Values)
+
+	v, err := agg.Eval(&env)
+	require.NoError(t, err)
+	require.Equal(t, types.NewDoubleValue(24.75), v)
+
+	p.Fraction = nil
+	v, err = agg.Eval(&env)
+	require.NoError(t, err)
+	require.Equal(t, types.NewDoubleValue(49.5), v)
+
+	require.NoError(t, agg.Close())
+	require.Nil(t, agg.tree)
+}
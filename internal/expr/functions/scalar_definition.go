@@ -3,6 +3,7 @@ package functions
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/chaisql/chai/internal/environment"
 	"github.com/chaisql/chai/internal/expr"
@@ -15,9 +16,25 @@ import (
 // This difference allows to simply define them with a CallFn function that takes multiple row.Value and
 // return another types.Value, rather than having to manually evaluate expressions (see Definition).
 type ScalarDefinition struct {
-	name   string
-	arity  int
-	callFn func(...types.Value) (types.Value, error)
+	name  string
+	arity int
+	// if not zero, the function also accepts up to maxArity arguments,
+	// or any number of arguments if it is variadicArity.
+	// callFn receives the arguments that were given.
+	maxArity int
+	callFn   func(...types.Value) (types.Value, error)
+	// if set, it is called instead of callFn with the time zone of the transaction,
+	// for the functions which depend on it. A nil location is UTC.
+	zonedCallFn func(loc *time.Location, args ...types.Value) (types.Value, error)
+	// if set, it is called instead of callFn with the environment of the query,
+	// for the functions which depend on the state of the connection.
+	envCallFn func(env *environment.Environment, args ...types.Value) (types.Value, error)
+	// if set, the function can return a different value every time it is called
+	// with the same arguments, and its calls are never precalculated by the planner.
+	volatile bool
+	// if set, the function writes to the database, like nextval(),
+	// and the statements calling it require a read-write transaction.
+	writes bool
 }
 
 func NewScalarDefinition(name string, arity int, callFn func(...types.Value) (types.Value, error)) *ScalarDefinition {
@@ -35,12 +52,28 @@ func (fd *ScalarDefinition) String() string {
 	for i := 0; i < fd.arity; i++ {
 		args = append(args, fmt.Sprintf("arg%d", i+1))
 	}
-	return fmt.Sprintf("%s(%s)", fd.name, strings.Join(args, ", "))
+	if fd.maxArity == variadicArity {
+		return fmt.Sprintf("%s(%s[, ...])", fd.name, strings.Join(args, ", "))
+	}
+	optional := ""
+	for i := fd.arity; i < fd.maxArity; i++ {
+		optional += fmt.Sprintf("[, arg%d", i+1)
+	}
+	if optional != "" {
+		optional += strings.Repeat("]", fd.maxArity-fd.arity)
+	}
+	return fmt.Sprintf("%s(%s%s)", fd.name, strings.Join(args, ", "), optional)
 }
 
 // Function returns a Function expr node.
 func (fd *ScalarDefinition) Function(args ...expr.Expr) (expr.Function, error) {
-	if len(args) != fd.arity {
+	if fd.maxArity == variadicArity && len(args) < fd.arity {
+		return nil, fmt.Errorf("%s takes at least %d argument(s), not %d", fd.String(), fd.arity, len(args))
+	}
+	if fd.maxArity > 0 && (len(args) < fd.arity || len(args) > fd.maxArity) {
+		return nil, fmt.Errorf("%s takes %d to %d argument(s), not %d", fd.String(), fd.arity, fd.maxArity, len(args))
+	}
+	if fd.maxArity == 0 && len(args) != fd.arity {
 		return nil, fmt.Errorf("%s takes %d argument(s), not %d", fd.String(), fd.arity, len(args))
 	}
 	return &ScalarFunction{
@@ -75,6 +108,12 @@ func (sf *ScalarFunction) Eval(env *environment.Environment) (types.Value, error
 	if err != nil {
 		return nil, err
 	}
+	if sf.def.zonedCallFn != nil {
+		return sf.def.zonedCallFn(env.GetTimeZone(), args...)
+	}
+	if sf.def.envCallFn != nil {
+		return sf.def.envCallFn(env, args...)
+	}
 	return sf.def.callFn(args...)
 }
 
@@ -93,7 +132,31 @@ func (sf *ScalarFunction) evalParams(env *environment.Environment) ([]types.Valu
 
 // String returns a string represention of the function expression and its arguments.
 func (sf *ScalarFunction) String() string {
-	return fmt.Sprintf("%s(%v)", sf.def.name, sf.params)
+	var sb strings.Builder
+
+	sb.WriteString(sf.def.name)
+	sb.WriteString("(")
+	for i, p := range sf.params {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(p.String())
+	}
+	sb.WriteString(")")
+
+	return sb.String()
+}
+
+// IsVolatile returns whether the function can return a different value
+// every time it is called with the same arguments.
+func (sf *ScalarFunction) IsVolatile() bool {
+	return sf.def.volatile
+}
+
+// IsReadOnly returns whether the function can be called
+// in a read-only transaction.
+func (sf *ScalarFunction) IsReadOnly() bool {
+	return !sf.def.writes
 }
 
 // Params return the function arguments.
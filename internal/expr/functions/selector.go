@@ -0,0 +1,264 @@
+package functions
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/chaisql/chai/internal/environment"
+	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// The selector aggregators return the value of an expression
+// at one of the rows of the group, chosen by another expression.
+
+// FirstValue is the FIRST_VALUE and LAST_VALUE aggregator functions.
+// It returns the value of the expression at the first or the last row
+// of the group, in the order of the ORDER BY clause of the call if any,
+// and in the order of the rows otherwise.
+type FirstValue struct {
+	Expr expr.Expr
+	// expression given to the ORDER BY clause of the call, if any.
+	OrderBy expr.Expr
+	Desc    bool
+	// if set, the function is LAST_VALUE.
+	Last bool
+}
+
+func (f *FirstValue) Clone() expr.Expr {
+	return &FirstValue{
+		Expr:    expr.Clone(f.Expr),
+		OrderBy: expr.Clone(f.OrderBy),
+		Desc:    f.Desc,
+		Last:    f.Last,
+	}
+}
+
+func (f *FirstValue) name() string {
+	if f.Last {
+		return "LAST_VALUE"
+	}
+	return "FIRST_VALUE"
+}
+
+// Eval extracts the result of the aggregation from the given row and returns it.
+func (f *FirstValue) Eval(env *environment.Environment) (types.Value, error) {
+	r, ok := env.GetRow()
+	if !ok {
+		return nil, errors.Errorf("misuse of aggregation function %s()", f.name())
+	}
+
+	return r.Get(f.String())
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (f *FirstValue) IsEqual(other expr.Expr) bool {
+	if other == nil {
+		return false
+	}
+
+	o, ok := other.(*FirstValue)
+	if !ok {
+		return false
+	}
+
+	return f.Last == o.Last && f.Desc == o.Desc && expr.Equal(f.Expr, o.Expr) && expr.Equal(f.OrderBy, o.OrderBy)
+}
+
+func (f *FirstValue) Params() []expr.Expr {
+	if f.OrderBy == nil {
+		return []expr.Expr{f.Expr}
+	}
+
+	return []expr.Expr{f.Expr, f.OrderBy}
+}
+
+func (f *FirstValue) String() string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "%s(%v", f.name(), f.Expr)
+	if f.OrderBy != nil {
+		fmt.Fprintf(&sb, " ORDER BY %v", f.OrderBy)
+		if f.Desc {
+			sb.WriteString(" DESC")
+		}
+	}
+	sb.WriteString(")")
+
+	return sb.String()
+}
+
+// Aggregator returns a FirstValueAggregator. It implements the AggregatorBuilder interface.
+func (f *FirstValue) Aggregator() expr.Aggregator {
+	return &FirstValueAggregator{
+		Fn: f,
+	}
+}
+
+// FirstValueAggregator keeps the value of the first or the last row of a group.
+type FirstValueAggregator struct {
+	Fn    *FirstValue
+	Value types.Value
+	// encoded value of the ORDER BY expression at the kept row
+	Key []byte
+}
+
+// Aggregate keeps the value of the expression if the row comes before
+// the kept row for FIRST_VALUE, or after it for LAST_VALUE.
+func (s *FirstValueAggregator) Aggregate(env *environment.Environment) error {
+	k, err := orderKey(env, s.Fn.OrderBy)
+	if err != nil {
+		return err
+	}
+
+	if s.Value != nil {
+		cmp := compareKeys(k, s.Key, s.Fn.Desc)
+		// the first of the equal rows is kept by FIRST_VALUE,
+		// and the last one by LAST_VALUE
+		if s.Fn.Last && cmp < 0 || !s.Fn.Last && cmp >= 0 {
+			return nil
+		}
+	}
+
+	v, err := s.Fn.Expr.Eval(env)
+	if err != nil && !errors.Is(err, types.ErrColumnNotFound) {
+		return err
+	}
+	if v == nil {
+		v = types.NewNullValue()
+	}
+
+	s.Value, s.Key = v, k
+	return nil
+}
+
+// Eval returns the kept value, or NULL if the group is empty.
+func (s *FirstValueAggregator) Eval(_ *environment.Environment) (types.Value, error) {
+	if s.Value == nil {
+		return types.NewNullValue(), nil
+	}
+
+	return s.Value, nil
+}
+
+func (s *FirstValueAggregator) String() string {
+	return s.Fn.String()
+}
+
+// ArgMin is the ARG_MIN and ARG_MAX aggregator functions.
+// It returns the value of the expression at the row where By is the smallest,
+// or the largest if Max is set. Rows where By is NULL are ignored, and the first
+// of the rows with the same value of By is chosen.
+type ArgMin struct {
+	Expr expr.Expr
+	By   expr.Expr
+	Max  bool
+}
+
+func (a *ArgMin) Clone() expr.Expr {
+	return &ArgMin{
+		Expr: expr.Clone(a.Expr),
+		By:   expr.Clone(a.By),
+		Max:  a.Max,
+	}
+}
+
+func (a *ArgMin) name() string {
+	if a.Max {
+		return "ARG_MAX"
+	}
+	return "ARG_MIN"
+}
+
+// Eval extracts the result of the aggregation from the given row and returns it.
+func (a *ArgMin) Eval(env *environment.Environment) (types.Value, error) {
+	r, ok := env.GetRow()
+	if !ok {
+		return nil, errors.Errorf("misuse of aggregation function %s()", a.name())
+	}
+
+	return r.Get(a.String())
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (a *ArgMin) IsEqual(other expr.Expr) bool {
+	if other == nil {
+		return false
+	}
+
+	o, ok := other.(*ArgMin)
+	if !ok {
+		return false
+	}
+
+	return a.Max == o.Max && expr.Equal(a.Expr, o.Expr) && expr.Equal(a.By, o.By)
+}
+
+func (a *ArgMin) Params() []expr.Expr { return []expr.Expr{a.Expr, a.By} }
+
+func (a *ArgMin) String() string {
+	return fmt.Sprintf("%s(%v, %v)", a.name(), a.Expr, a.By)
+}
+
+// Aggregator returns an ArgMinAggregator. It implements the AggregatorBuilder interface.
+func (a *ArgMin) Aggregator() expr.Aggregator {
+	return &ArgMinAggregator{
+		Fn: a,
+	}
+}
+
+// ArgMinAggregator keeps the value of the row of a group where By is the smallest or the largest.
+type ArgMinAggregator struct {
+	Fn    *ArgMin
+	Value types.Value
+	// encoded value of By at the kept row
+	Key []byte
+}
+
+// Aggregate keeps the value of the expression if By is smaller,
+// or larger for ARG_MAX, than at the kept row.
+func (s *ArgMinAggregator) Aggregate(env *environment.Environment) error {
+	by, err := s.Fn.By.Eval(env)
+	if err != nil && !errors.Is(err, types.ErrColumnNotFound) {
+		return err
+	}
+	if by == nil || by.Type() == types.TypeNull {
+		return nil
+	}
+
+	// keys are compared like the values of ORDER BY
+	k, err := types.EncodeValuesAsKey(nil, by)
+	if err != nil {
+		return err
+	}
+	if s.Value != nil && compareKeys(k, s.Key, s.Fn.Max) >= 0 {
+		return nil
+	}
+
+	v, err := s.Fn.Expr.Eval(env)
+	if err != nil && !errors.Is(err, types.ErrColumnNotFound) {
+		return err
+	}
+	if v == nil {
+		v = types.NewNullValue()
+	}
+
+	s.Value, s.Key = v, k
+	return nil
+}
+
+// Eval returns the kept value, or NULL if By is NULL for every row of the group.
+func (s *ArgMinAggregator) Eval(_ *environment.Environment) (types.Value, error) {
+	if s.Value == nil {
+		return types.NewNullValue(), nil
+	}
+
+	return s.Value, nil
+}
+
+func (s *ArgMinAggregator) String() string {
+	return s.Fn.String()
+}
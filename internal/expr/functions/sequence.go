@@ -0,0 +1,159 @@
+package functions
+
+import (
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/environment"
+	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// The sequence functions work like the ones of PostgreSQL. Sequences are not
+// transactional: the values returned by nextval() are never returned again,
+// even if the transaction is rolled back, so the values used by the committed
+// transactions may have gaps.
+// currval() and lastval() return the values of the connection, and they
+// are not changed by the other connections.
+
+// sequenceArg returns the sequence named by arg and the transaction of the query.
+func sequenceArg(fn string, env *environment.Environment, arg types.Value) (*database.Sequence, *database.Transaction, error) {
+	if arg.Type() != types.TypeText {
+		return nil, nil, errors.Errorf("%s expects a sequence name, got %s", fn, arg.Type())
+	}
+
+	tx := env.GetTx()
+	if tx == nil {
+		return nil, nil, errors.Errorf("%s must be called within a transaction", fn)
+	}
+
+	seq, err := tx.Catalog.GetSequence(types.AsString(arg))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return seq, tx, nil
+}
+
+// nextval advances a sequence and returns its new value, like NEXT VALUE FOR.
+var nextval = &ScalarDefinition{
+	name:     "nextval",
+	arity:    1,
+	volatile: true,
+	writes:   true,
+	envCallFn: func(env *environment.Environment, args ...types.Value) (types.Value, error) {
+		if args[0].Type() == types.TypeNull {
+			return args[0], nil
+		}
+
+		_, _, err := sequenceArg("nextval(name)", env, args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		return expr.NextValueFor{SeqName: types.AsString(args[0])}.Eval(env)
+	},
+}
+
+// currval returns the value most recently returned by nextval() for a sequence
+// in the current connection. It returns an error if nextval() was never called
+// for this sequence.
+var currval = &ScalarDefinition{
+	name:     "currval",
+	arity:    1,
+	volatile: true,
+	envCallFn: func(env *environment.Environment, args ...types.Value) (types.Value, error) {
+		const fn = "currval(name)"
+		if args[0].Type() == types.TypeNull {
+			return args[0], nil
+		}
+
+		seq, tx, err := sequenceArg(fn, env, args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		if conn := tx.Connection(); conn != nil {
+			if v, ok := conn.SequenceValue(seq.Info.Name); ok {
+				return types.NewBigintValue(v), nil
+			}
+		}
+
+		return nil, errors.Errorf("%s: currval of sequence %q is not yet defined in this session", fn, seq.Info.Name)
+	},
+}
+
+// lastval returns the value most recently returned by nextval()
+// in the current connection, for any sequence.
+var lastval = &ScalarDefinition{
+	name:     "lastval",
+	arity:    0,
+	volatile: true,
+	envCallFn: func(env *environment.Environment, args ...types.Value) (types.Value, error) {
+		const fn = "lastval()"
+		conn := env.GetConnection()
+		if conn == nil || conn.LastSequence() == "" {
+			return nil, errors.Errorf("%s: lastval is not yet defined in this session", fn)
+		}
+
+		// the sequence may have been dropped
+		seq, _, err := sequenceArg(fn, env, types.NewTextValue(conn.LastSequence()))
+		if err != nil {
+			return nil, err
+		}
+
+		v, _ := conn.SequenceValue(seq.Info.Name)
+		return types.NewBigintValue(v), nil
+	},
+}
+
+// setval sets the current value of a sequence and returns it.
+// By default the next call to nextval() returns the value following it.
+// If is_called is false, the next call to nextval() returns the value itself.
+var setval = &ScalarDefinition{
+	name:     "setval",
+	arity:    2,
+	maxArity: 3,
+	volatile: true,
+	writes:   true,
+	envCallFn: func(env *environment.Environment, args ...types.Value) (types.Value, error) {
+		const fn = "setval(name, value[, is_called])"
+		if anyNull(args) {
+			return types.NewNullValue(), nil
+		}
+
+		seq, tx, err := sequenceArg(fn, env, args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		if !args[1].Type().IsInteger() {
+			return nil, errors.Errorf("%s expects the value to be an integer, got %s", fn, args[1].Type())
+		}
+		v, err := args[1].CastAs(types.TypeBigint)
+		if err != nil {
+			return nil, err
+		}
+		value := types.AsInt64(v)
+
+		isCalled := true
+		if len(args) == 3 {
+			if args[2].Type() != types.TypeBoolean {
+				return nil, errors.Errorf("%s expects is_called to be a boolean, got %s", fn, args[2].Type())
+			}
+			isCalled = types.AsBool(args[2])
+		}
+
+		err = seq.SetValue(tx, value, isCalled)
+		if err != nil {
+			return nil, errors.Wrap(err, fn)
+		}
+
+		// like in PostgreSQL, currval() only returns the value
+		// if it was returned by nextval()
+		if conn := tx.Connection(); conn != nil && isCalled {
+			conn.SetSequenceValue(seq.Info.Name, value, false)
+		}
+
+		return types.NewBigintValue(value), nil
+	},
+}
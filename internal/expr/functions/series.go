@@ -0,0 +1,225 @@
+package functions
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/chaisql/chai/internal/environment"
+	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// GenerateSeries is the GENERATE_SERIES table function, which is only allowed
+// in the FROM clause. It returns one row per value from Start to Stop included,
+// incremented by Step, in a column named generate_series.
+// The values are integers, with a default step of 1, doubles, or timestamps,
+// whose step is an interval such as INTERVAL '1 day' or '1 day'. The n-th value is Start plus n times Step,
+// so that adding months to the last day of a month keeps returning last days.
+// NULL arguments return no rows.
+type GenerateSeries struct {
+	Start expr.Expr
+	Stop  expr.Expr
+	Step  expr.Expr
+}
+
+func (g *GenerateSeries) Clone() expr.Expr {
+	return &GenerateSeries{
+		Start: expr.Clone(g.Start),
+		Stop:  expr.Clone(g.Stop),
+		Step:  expr.Clone(g.Step),
+	}
+}
+
+func (g *GenerateSeries) Eval(env *environment.Environment) (types.Value, error) {
+	return nil, errors.New("GENERATE_SERIES() is only allowed in the FROM clause")
+}
+
+func (g *GenerateSeries) IsEqual(other expr.Expr) bool {
+	if other == nil {
+		return false
+	}
+
+	o, ok := other.(*GenerateSeries)
+	if !ok {
+		return false
+	}
+
+	return expr.Equal(g.Start, o.Start) && expr.Equal(g.Stop, o.Stop) && expr.Equal(g.Step, o.Step)
+}
+
+func (g *GenerateSeries) Params() []expr.Expr {
+	if g.Step == nil {
+		return []expr.Expr{g.Start, g.Stop}
+	}
+
+	return []expr.Expr{g.Start, g.Stop, g.Step}
+}
+
+func (g *GenerateSeries) String() string {
+	if g.Step == nil {
+		return fmt.Sprintf("GENERATE_SERIES(%v, %v)", g.Start, g.Stop)
+	}
+
+	return fmt.Sprintf("GENERATE_SERIES(%v, %v, %v)", g.Start, g.Stop, g.Step)
+}
+
+// Columns returns the column of the rows. It implements the TableFunction interface.
+func (g *GenerateSeries) Columns() []string {
+	return []string{"generate_series"}
+}
+
+// Iterate calls fn with every value of the series.
+func (g *GenerateSeries) Iterate(env *environment.Environment, fn func(values []types.Value) error) error {
+	args := make([]types.Value, 0, 3)
+	for _, e := range g.Params() {
+		v, err := e.Eval(env)
+		if err != nil {
+			return err
+		}
+		args = append(args, v)
+	}
+	if anyNull(args) {
+		return nil
+	}
+
+	values := make([]types.Value, 1)
+	emit := func(v types.Value) error {
+		values[0] = v
+		return fn(values)
+	}
+
+	start, stop := args[0], args[1]
+	switch {
+	case start.Type().IsNumber() && stop.Type().IsNumber():
+		if isSigned(start) && isSigned(stop) && (len(args) == 2 || isSigned(args[2])) {
+			return g.iterateIntegers(args, emit)
+		}
+		return g.iterateDoubles(args, emit)
+	case start.Type().IsTimestamp() || start.Type().IsText():
+		return g.iterateTimestamps(env, args, emit)
+	}
+
+	return errors.Errorf("GENERATE_SERIES() expects numbers or timestamps, got %s and %s", start.Type(), stop.Type())
+}
+
+// isSigned returns true if v is an INTEGER or a BIGINT.
+func isSigned(v types.Value) bool {
+	return v.Type() == types.TypeInteger || v.Type() == types.TypeBigint
+}
+
+func (g *GenerateSeries) iterateIntegers(args []types.Value, emit func(types.Value) error) error {
+	start, stop, step := types.AsInt64(args[0]), types.AsInt64(args[1]), int64(1)
+	if len(args) == 3 {
+		step = types.AsInt64(args[2])
+	}
+	if step == 0 {
+		return errors.New("GENERATE_SERIES() step size cannot equal zero")
+	}
+
+	// the values are integers if all the arguments are integers, and bigints otherwise
+	bigint := false
+	for _, a := range args {
+		bigint = bigint || a.Type() != types.TypeInteger
+	}
+
+	for v := start; step > 0 && v <= stop || step < 0 && v >= stop; v += step {
+		var err error
+		if bigint {
+			err = emit(types.NewBigintValue(v))
+		} else {
+			err = emit(types.NewIntegerValue(int32(v)))
+		}
+		if err != nil {
+			return err
+		}
+
+		// stop before overflowing
+		if step > 0 && v > math.MaxInt64-step || step < 0 && v < math.MinInt64-step {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func (g *GenerateSeries) iterateDoubles(args []types.Value, emit func(types.Value) error) error {
+	var f [3]float64
+	f[2] = 1
+	for i, a := range args {
+		v, err := a.CastAs(types.TypeDouble)
+		if err != nil {
+			return err
+		}
+		f[i] = types.AsFloat64(v)
+	}
+
+	for _, x := range f {
+		if math.IsNaN(x) || math.IsInf(x, 0) {
+			return errors.New("GENERATE_SERIES() arguments must be finite numbers")
+		}
+	}
+	start, stop, step := f[0], f[1], f[2]
+	if step == 0 {
+		return errors.New("GENERATE_SERIES() step size cannot equal zero")
+	}
+
+	for n := 0.0; ; n++ {
+		v := start + n*step
+		if step > 0 && v > stop || step < 0 && v < stop {
+			return nil
+		}
+
+		err := emit(types.NewDoubleValue(v))
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (g *GenerateSeries) iterateTimestamps(env *environment.Environment, args []types.Value, emit func(types.Value) error) error {
+	const fn = "GENERATE_SERIES(start, stop, step)"
+	if len(args) != 3 {
+		return errors.New("GENERATE_SERIES() requires an interval step for timestamps")
+	}
+
+	loc := env.GetTimeZone()
+	start, err := timeArg(fn, args[0], loc)
+	if err != nil {
+		return err
+	}
+	stop, err := timeArg(fn, args[1], loc)
+	if err != nil {
+		return err
+	}
+	var step types.Interval
+	switch args[2].Type() {
+	case types.TypeInterval:
+		step = args[2].V().(types.Interval)
+	case types.TypeText:
+		step, err = parseInterval(fn, types.AsString(args[2]))
+		if err != nil {
+			return err
+		}
+	default:
+		return errors.Errorf("%s expects the step to be an interval, got %s", fn, args[2].Type())
+	}
+
+	next := step.AddTo(start, 1)
+	if next.Equal(start) {
+		return errors.New("GENERATE_SERIES() step size cannot equal zero")
+	}
+	forward := next.After(start)
+
+	for n := 0; ; n++ {
+		t := step.AddTo(start, n)
+		if forward && t.After(stop) || !forward && t.Before(stop) {
+			return nil
+		}
+
+		err := emit(timeValue(args[0], t))
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,422 @@
+package functions
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/chaisql/chai/internal/environment"
+	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// The statistical aggregators compute their result in a single pass,
+// using the algorithm of Welford, which doesn't lose precision when the values
+// are large compared to their variance. They ignore the values which are
+// not numbers, and return NULL if there are not enough values.
+
+// numberOf returns the value of a number as a float64,
+// and false if it is not a number.
+func numberOf(v types.Value) (float64, bool) {
+	switch v.Type() {
+	case types.TypeInteger, types.TypeBigint:
+		return float64(types.AsInt64(v)), true
+	case types.TypeUnsignedBigint:
+		return float64(types.AsUint64(v)), true
+	case types.TypeDouble, types.TypeReal:
+		return types.AsFloat64(v), true
+	}
+
+	return 0, false
+}
+
+// Variance is the VAR_POP and VAR_SAMP aggregator functions, or the
+// STDDEV_POP and STDDEV_SAMP functions if Stddev is set.
+type Variance struct {
+	Expr expr.Expr
+	// if set, the variance of the sample is returned, which divides
+	// the sum of the squared deviations by the number of values minus one.
+	Sample bool
+	// if set, the square root of the variance is returned.
+	Stddev bool
+}
+
+func (v *Variance) Clone() expr.Expr {
+	return &Variance{
+		Expr:   expr.Clone(v.Expr),
+		Sample: v.Sample,
+		Stddev: v.Stddev,
+	}
+}
+
+func (v *Variance) name() string {
+	name := "VAR"
+	if v.Stddev {
+		name = "STDDEV"
+	}
+	if v.Sample {
+		return name + "_SAMP"
+	}
+	return name + "_POP"
+}
+
+// Eval extracts the result of the aggregation from the given row and returns it.
+func (v *Variance) Eval(env *environment.Environment) (types.Value, error) {
+	r, ok := env.GetRow()
+	if !ok {
+		return nil, errors.Errorf("misuse of aggregation function %s()", v.name())
+	}
+
+	return r.Get(v.String())
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (v *Variance) IsEqual(other expr.Expr) bool {
+	if other == nil {
+		return false
+	}
+
+	o, ok := other.(*Variance)
+	if !ok {
+		return false
+	}
+
+	return v.Sample == o.Sample && v.Stddev == o.Stddev && expr.Equal(v.Expr, o.Expr)
+}
+
+func (v *Variance) Params() []expr.Expr { return []expr.Expr{v.Expr} }
+
+func (v *Variance) String() string {
+	return fmt.Sprintf("%s(%v)", v.name(), v.Expr)
+}
+
+// Aggregator returns a VarianceAggregator. It implements the AggregatorBuilder interface.
+func (v *Variance) Aggregator() expr.Aggregator {
+	return &VarianceAggregator{
+		Fn: v,
+	}
+}
+
+// VarianceAggregator computes the variance of the numbers of a group.
+type VarianceAggregator struct {
+	Fn    *Variance
+	Count int64
+	Mean  float64
+	// sum of the squared deviations from the mean
+	M2 float64
+}
+
+// Aggregate adds a value to the running mean and sum of squared deviations.
+func (s *VarianceAggregator) Aggregate(env *environment.Environment) error {
+	v, err := s.Fn.Expr.Eval(env)
+	if err != nil && !errors.Is(err, types.ErrColumnNotFound) {
+		return err
+	}
+	if v == nil {
+		return nil
+	}
+
+	x, ok := numberOf(v)
+	if !ok {
+		return nil
+	}
+
+	s.Count++
+	d := x - s.Mean
+	s.Mean += d / float64(s.Count)
+	s.M2 += d * (x - s.Mean)
+	return nil
+}
+
+// Eval returns the variance or the standard deviation as a double,
+// or NULL if there are no values, or only one for the sample functions.
+func (s *VarianceAggregator) Eval(_ *environment.Environment) (types.Value, error) {
+	n := s.Count
+	if s.Fn.Sample {
+		n--
+	}
+	if n <= 0 {
+		return types.NewNullValue(), nil
+	}
+
+	res := s.M2 / float64(n)
+	if s.Fn.Stddev {
+		res = math.Sqrt(res)
+	}
+	return types.NewDoubleValue(res), nil
+}
+
+func (s *VarianceAggregator) String() string {
+	return s.Fn.String()
+}
+
+// Covariance is the COVAR_POP and COVAR_SAMP aggregator functions,
+// or the CORR function if Corr is set. Like in the SQL standard,
+// the dependent variable Y is the first argument.
+type Covariance struct {
+	Y, X expr.Expr
+	// if set, the covariance of the sample is returned, which divides
+	// the sum of the products of the deviations by the number of pairs minus one.
+	Sample bool
+	// if set, the correlation coefficient is returned.
+	Corr bool
+}
+
+func (c *Covariance) Clone() expr.Expr {
+	return &Covariance{
+		Y:      expr.Clone(c.Y),
+		X:      expr.Clone(c.X),
+		Sample: c.Sample,
+		Corr:   c.Corr,
+	}
+}
+
+func (c *Covariance) name() string {
+	switch {
+	case c.Corr:
+		return "CORR"
+	case c.Sample:
+		return "COVAR_SAMP"
+	}
+	return "COVAR_POP"
+}
+
+// Eval extracts the result of the aggregation from the given row and returns it.
+func (c *Covariance) Eval(env *environment.Environment) (types.Value, error) {
+	r, ok := env.GetRow()
+	if !ok {
+		return nil, errors.Errorf("misuse of aggregation function %s()", c.name())
+	}
+
+	return r.Get(c.String())
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (c *Covariance) IsEqual(other expr.Expr) bool {
+	if other == nil {
+		return false
+	}
+
+	o, ok := other.(*Covariance)
+	if !ok {
+		return false
+	}
+
+	return c.Sample == o.Sample && c.Corr == o.Corr && expr.Equal(c.Y, o.Y) && expr.Equal(c.X, o.X)
+}
+
+func (c *Covariance) Params() []expr.Expr { return []expr.Expr{c.Y, c.X} }
+
+func (c *Covariance) String() string {
+	return fmt.Sprintf("%s(%v, %v)", c.name(), c.Y, c.X)
+}
+
+// Aggregator returns a CovarianceAggregator. It implements the AggregatorBuilder interface.
+func (c *Covariance) Aggregator() expr.Aggregator {
+	return &CovarianceAggregator{
+		Fn: c,
+	}
+}
+
+// CovarianceAggregator computes the covariance or the correlation
+// of the pairs of numbers of a group.
+type CovarianceAggregator struct {
+	Fn           *Covariance
+	Count        int64
+	MeanX, MeanY float64
+	// sums of the squared deviations of X and Y from their mean
+	M2X, M2Y float64
+	// sum of the products of the deviations of X and Y
+	C float64
+}
+
+// Aggregate adds a pair of values to the running means and co-moments.
+// Pairs where one of the values is not a number are ignored.
+func (s *CovarianceAggregator) Aggregate(env *environment.Environment) error {
+	var xy [2]float64
+	for i, e := range []expr.Expr{s.Fn.X, s.Fn.Y} {
+		v, err := e.Eval(env)
+		if err != nil && !errors.Is(err, types.ErrColumnNotFound) {
+			return err
+		}
+		if v == nil {
+			return nil
+		}
+
+		f, ok := numberOf(v)
+		if !ok {
+			return nil
+		}
+		xy[i] = f
+	}
+	x, y := xy[0], xy[1]
+
+	s.Count++
+	n := float64(s.Count)
+	dx := x - s.MeanX
+	dy := y - s.MeanY
+	s.MeanX += dx / n
+	s.MeanY += dy / n
+	s.M2X += dx * (x - s.MeanX)
+	s.M2Y += dy * (y - s.MeanY)
+	s.C += dx * (y - s.MeanY)
+	return nil
+}
+
+// Eval returns the covariance or the correlation as a double, or NULL
+// if there are not enough pairs, or if X or Y is constant for CORR.
+func (s *CovarianceAggregator) Eval(_ *environment.Environment) (types.Value, error) {
+	if s.Fn.Corr {
+		if s.Count == 0 || s.M2X == 0 || s.M2Y == 0 {
+			return types.NewNullValue(), nil
+		}
+		return types.NewDoubleValue(s.C / math.Sqrt(s.M2X*s.M2Y)), nil
+	}
+
+	n := s.Count
+	if s.Fn.Sample {
+		n--
+	}
+	if n <= 0 {
+		return types.NewNullValue(), nil
+	}
+
+	return types.NewDoubleValue(s.C / float64(n)), nil
+}
+
+func (s *CovarianceAggregator) String() string {
+	return s.Fn.String()
+}
+
+// Histogram is the HISTOGRAM aggregator function: histogram(value, low, high, count).
+// It counts the numbers of a group in each of the buckets of width_bucket.
+type Histogram struct {
+	Expr      expr.Expr
+	Low, High expr.Expr
+	Count     expr.Expr
+}
+
+func (h *Histogram) Clone() expr.Expr {
+	return &Histogram{
+		Expr:  expr.Clone(h.Expr),
+		Low:   expr.Clone(h.Low),
+		High:  expr.Clone(h.High),
+		Count: expr.Clone(h.Count),
+	}
+}
+
+// Eval extracts the result of the aggregation from the given row and returns it.
+func (h *Histogram) Eval(env *environment.Environment) (types.Value, error) {
+	r, ok := env.GetRow()
+	if !ok {
+		return nil, errors.New("misuse of aggregation function HISTOGRAM()")
+	}
+
+	return r.Get(h.String())
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (h *Histogram) IsEqual(other expr.Expr) bool {
+	if other == nil {
+		return false
+	}
+
+	o, ok := other.(*Histogram)
+	if !ok {
+		return false
+	}
+
+	return expr.Equal(h.Expr, o.Expr) && expr.Equal(h.Low, o.Low) &&
+		expr.Equal(h.High, o.High) && expr.Equal(h.Count, o.Count)
+}
+
+func (h *Histogram) Params() []expr.Expr { return []expr.Expr{h.Expr, h.Low, h.High, h.Count} }
+
+func (h *Histogram) String() string {
+	return fmt.Sprintf("HISTOGRAM(%v, %v, %v, %v)", h.Expr, h.Low, h.High, h.Count)
+}
+
+// Aggregator returns a HistogramAggregator. It implements the AggregatorBuilder interface.
+func (h *Histogram) Aggregator() expr.Aggregator {
+	return &HistogramAggregator{
+		Fn: h,
+	}
+}
+
+// HistogramAggregator counts the numbers of a group per bucket.
+type HistogramAggregator struct {
+	Fn *Histogram
+	// number of values per bucket, including the buckets 0 and count + 1
+	// of the values out of the bounds
+	Counts []int64
+}
+
+// Aggregate increments the count of the bucket of the value if it is a number.
+// The bounds and the number of buckets are evaluated for every value,
+// and the number of buckets must stay the same.
+func (s *HistogramAggregator) Aggregate(env *environment.Environment) error {
+	const fn = "HISTOGRAM(value, low, high, count)"
+
+	var args [4]types.Value
+	for i, e := range s.Fn.Params() {
+		v, err := e.Eval(env)
+		if err != nil && !errors.Is(err, types.ErrColumnNotFound) {
+			return err
+		}
+		if v == nil || v.Type() == types.TypeNull {
+			return nil
+		}
+		args[i] = v
+	}
+
+	x, ok := numberOf(args[0])
+	if !ok {
+		return nil
+	}
+	var bounds [2]float64
+	for i, a := range args[1:3] {
+		f, ok := numberOf(a)
+		if !ok {
+			return errors.Errorf("%s expects the bounds to be numbers, got %s", fn, a.Type())
+		}
+		bounds[i] = f
+	}
+	n, err := intArg(fn, args[3])
+	if err != nil {
+		return err
+	}
+
+	b, err := widthBucket(fn, x, bounds[0], bounds[1], n)
+	if err != nil {
+		return err
+	}
+
+	if s.Counts == nil {
+		s.Counts = make([]int64, n+2)
+	} else if len(s.Counts) != n+2 {
+		return errors.Errorf("%s: the number of buckets must be the same for all the values", fn)
+	}
+	s.Counts[b]++
+	return nil
+}
+
+// Eval returns the counts of the buckets as an array of count + 2 bigints,
+// indexed by the bucket numbers of width_bucket, or NULL if there are no values.
+func (s *HistogramAggregator) Eval(_ *environment.Environment) (types.Value, error) {
+	if s.Counts == nil {
+		return types.NewNullValue(), nil
+	}
+
+	values := make([]types.Value, len(s.Counts))
+	for i, c := range s.Counts {
+		values[i] = types.NewBigintValue(c)
+	}
+	return types.MakeArray(values...)
+}
+
+func (s *HistogramAggregator) String() string {
+	return s.Fn.String()
+}
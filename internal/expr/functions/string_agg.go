@@ -0,0 +1,241 @@
+package functions
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/chaisql/chai/internal/environment"
+	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// StringAgg is the STRING_AGG and GROUP_CONCAT aggregator functions.
+// It concatenates the values of a group, separated by the delimiter.
+// The values are concatenated in the order of the ORDER BY clause
+// of the call if any, and in the order of the rows otherwise.
+type StringAgg struct {
+	Expr      expr.Expr
+	Delimiter expr.Expr
+	// expression given to the ORDER BY clause of the call, if any.
+	OrderBy expr.Expr
+	Desc    bool
+	// if set, the function was called as GROUP_CONCAT.
+	GroupConcat bool
+}
+
+func (s *StringAgg) Clone() expr.Expr {
+	return &StringAgg{
+		Expr:        expr.Clone(s.Expr),
+		Delimiter:   expr.Clone(s.Delimiter),
+		OrderBy:     expr.Clone(s.OrderBy),
+		Desc:        s.Desc,
+		GroupConcat: s.GroupConcat,
+	}
+}
+
+func (s *StringAgg) name() string {
+	if s.GroupConcat {
+		return "GROUP_CONCAT"
+	}
+	return "STRING_AGG"
+}
+
+// Eval extracts the result of the aggregation from the given row and returns it.
+func (s *StringAgg) Eval(env *environment.Environment) (types.Value, error) {
+	r, ok := env.GetRow()
+	if !ok {
+		return nil, errors.Errorf("misuse of aggregation function %s()", s.name())
+	}
+
+	return r.Get(s.String())
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (s *StringAgg) IsEqual(other expr.Expr) bool {
+	if other == nil {
+		return false
+	}
+
+	o, ok := other.(*StringAgg)
+	if !ok {
+		return false
+	}
+
+	return s.GroupConcat == o.GroupConcat &&
+		s.Desc == o.Desc &&
+		expr.Equal(s.Expr, o.Expr) &&
+		expr.Equal(s.Delimiter, o.Delimiter) &&
+		expr.Equal(s.OrderBy, o.OrderBy)
+}
+
+func (s *StringAgg) Params() []expr.Expr {
+	params := []expr.Expr{s.Expr}
+	if s.Delimiter != nil {
+		params = append(params, s.Delimiter)
+	}
+	if s.OrderBy != nil {
+		params = append(params, s.OrderBy)
+	}
+
+	return params
+}
+
+func (s *StringAgg) String() string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "%s(%v", s.name(), s.Expr)
+	if s.Delimiter != nil {
+		fmt.Fprintf(&sb, ", %v", s.Delimiter)
+	}
+	if s.OrderBy != nil {
+		fmt.Fprintf(&sb, " ORDER BY %v", s.OrderBy)
+		if s.Desc {
+			sb.WriteString(" DESC")
+		}
+	}
+	sb.WriteString(")")
+
+	return sb.String()
+}
+
+// Aggregator returns a StringAggAggregator. It implements the AggregatorBuilder interface.
+func (s *StringAgg) Aggregator() expr.Aggregator {
+	return &StringAggAggregator{
+		Fn: s,
+	}
+}
+
+// stringAggValue is a value concatenated by STRING_AGG,
+// with the delimiter that precedes it.
+type stringAggValue struct {
+	value     string
+	delimiter string
+	// encoded value of the ORDER BY expression
+	key []byte
+}
+
+// StringAggAggregator concatenates the values of a group.
+type StringAggAggregator struct {
+	Fn     *StringAgg
+	Values []stringAggValue
+	// length of the result, in bytes
+	Len int
+}
+
+// Aggregate stores the value of the expression, unless it is NULL.
+// Values that are not texts are converted to text.
+func (s *StringAggAggregator) Aggregate(env *environment.Environment) error {
+	v, err := s.evalText(env, s.Fn.Expr)
+	if err != nil || v == nil {
+		return err
+	}
+
+	// the delimiter of GROUP_CONCAT defaults to a comma
+	sv := stringAggValue{value: *v, delimiter: ","}
+	if s.Fn.Delimiter != nil {
+		d, err := s.evalText(env, s.Fn.Delimiter)
+		if err != nil {
+			return err
+		}
+		sv.delimiter = ""
+		if d != nil {
+			sv.delimiter = *d
+		}
+	}
+
+	sv.key, err = orderKey(env, s.Fn.OrderBy)
+	if err != nil {
+		return err
+	}
+
+	s.Len += len(sv.delimiter) + len(sv.value)
+	if s.Len > maxTextLen {
+		return errors.Errorf("%s(): result too large", s.Fn.name())
+	}
+
+	s.Values = append(s.Values, sv)
+	return nil
+}
+
+// orderKey evaluates the expression of the ORDER BY clause of an aggregate
+// function call and encodes its value, so that the values of the group
+// are sorted like the rows of a query. It returns nil if there is no ORDER BY.
+func orderKey(env *environment.Environment, orderBy expr.Expr) ([]byte, error) {
+	if orderBy == nil {
+		return nil, nil
+	}
+
+	k, err := orderBy.Eval(env)
+	if errors.Is(err, types.ErrColumnNotFound) {
+		k, err = types.NewNullValue(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return types.EncodeValuesAsKey(nil, k)
+}
+
+// compareKeys compares two keys returned by orderKey,
+// in descending order if desc is set.
+func compareKeys(a, b []byte, desc bool) int {
+	if desc {
+		return bytes.Compare(b, a)
+	}
+	return bytes.Compare(a, b)
+}
+
+// evalText evaluates e and converts its value to text.
+// It returns nil if the value is NULL.
+func (s *StringAggAggregator) evalText(env *environment.Environment, e expr.Expr) (*string, error) {
+	v, err := e.Eval(env)
+	if err != nil && !errors.Is(err, types.ErrColumnNotFound) {
+		return nil, err
+	}
+	if v == nil || v.Type() == types.TypeNull {
+		return nil, nil
+	}
+
+	if !v.Type().IsText() {
+		v, err = types.CastIn(v, types.TypeText, env.GetTimeZone())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	str := types.AsString(v)
+	return &str, nil
+}
+
+// Eval returns the concatenated values as a text, or NULL if there are none.
+// The delimiter of the first value is ignored.
+func (s *StringAggAggregator) Eval(_ *environment.Environment) (types.Value, error) {
+	if len(s.Values) == 0 {
+		return types.NewNullValue(), nil
+	}
+
+	if s.Fn.OrderBy != nil {
+		sort.SliceStable(s.Values, func(i, j int) bool {
+			return compareKeys(s.Values[i].key, s.Values[j].key, s.Fn.Desc) < 0
+		})
+	}
+
+	var sb strings.Builder
+	sb.Grow(s.Len)
+	for i, v := range s.Values {
+		if i > 0 {
+			sb.WriteString(v.delimiter)
+		}
+		sb.WriteString(v.value)
+	}
+
+	return types.NewTextValue(sb.String()), nil
+}
+
+func (s *StringAggAggregator) String() string {
+	return s.Fn.String()
+}
@@ -2,7 +2,12 @@ package functions
 
 import (
 	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/chaisql/chai/internal/environment"
 	"github.com/chaisql/chai/internal/expr"
@@ -27,7 +32,7 @@ func (s *Lower) Eval(env *environment.Environment) (types.Value, error) {
 		return nil, err
 	}
 
-	if val.Type() != types.TypeText {
+	if !val.Type().IsText() {
 		return types.NewNullValue(), nil
 	}
 
@@ -73,7 +78,7 @@ func (s *Upper) Eval(env *environment.Environment) (types.Value, error) {
 		return nil, err
 	}
 
-	if val.Type() != types.TypeText {
+	if !val.Type().IsText() {
 		return types.NewNullValue(), nil
 	}
 
@@ -135,7 +140,7 @@ func (s *Trim) Eval(env *environment.Environment) (types.Value, error) {
 		return nil, err
 	}
 
-	if input.Type() != types.TypeText {
+	if !input.Type().IsText() {
 		return types.NewNullValue(), nil
 	}
 
@@ -146,7 +151,7 @@ func (s *Trim) Eval(env *environment.Environment) (types.Value, error) {
 		if err != nil {
 			return nil, err
 		}
-		if remove.Type() != types.TypeText {
+		if !remove.Type().IsText() {
 			return types.NewNullValue(), nil
 		}
 		cutset = types.AsString(remove)
@@ -188,3 +193,573 @@ func (s *Trim) String() string {
 	}
 	return fmt.Sprintf("%v(%v, %v)", s.Name, s.Expr[0], s.Expr[1])
 }
+
+// The following functions return NULL if one of their arguments is NULL,
+// or is not of the expected type: a text, or an integer for the lengths and positions.
+
+// textArgs returns the texts of the arguments of a function,
+// and false if one of them is not a text.
+func textArgs(args ...types.Value) ([]string, bool) {
+	texts := make([]string, len(args))
+	for i, a := range args {
+		if !a.Type().IsText() {
+			return nil, false
+		}
+		texts[i] = types.AsString(a)
+	}
+
+	return texts, true
+}
+
+// intArgOrNull returns the value of an integer argument of a function,
+// and false if it is not an integer.
+func intArgOrNull(v types.Value) (int64, bool) {
+	switch v.Type() {
+	case types.TypeInteger, types.TypeBigint:
+		return types.AsInt64(v), true
+	}
+
+	return 0, false
+}
+
+// maxTextLen is the maximum length, in bytes, of the texts
+// returned by the functions which make texts longer.
+const maxTextLen = 1 << 30
+
+// splitPart returns the n-th field of a text split on a delimiter, starting at 1.
+// If n is negative, the fields are counted from the end. It returns an empty text
+// if there are fewer fields.
+var splitPart = &ScalarDefinition{
+	name:  "split_part",
+	arity: 3,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		texts, ok := textArgs(args[0], args[1])
+		n, isInt := intArgOrNull(args[2])
+		if !ok || !isInt {
+			return types.NewNullValue(), nil
+		}
+		if n == 0 {
+			return nil, fmt.Errorf("split_part(text, delimiter, n): field position must not be zero")
+		}
+
+		var fields []string
+		if texts[1] == "" {
+			fields = []string{texts[0]}
+		} else {
+			fields = strings.Split(texts[0], texts[1])
+		}
+		if n < 0 {
+			n += int64(len(fields)) + 1
+		}
+		if n < 1 || n > int64(len(fields)) {
+			return types.NewTextValue(""), nil
+		}
+		return types.NewTextValue(fields[n-1]), nil
+	},
+}
+
+// compileRegexp compiles a pattern of the regular expression functions,
+// with the given flags: i for case-insensitive matching, and for regexp_replace,
+// g to replace all the matches.
+func compileRegexp(fn, pattern, flags string, allowed string) (*regexp.Regexp, bool, error) {
+	var global bool
+	for _, f := range flags {
+		if !strings.ContainsRune(allowed, f) {
+			return nil, false, fmt.Errorf("%s: invalid regular expression flag %q", fn, f)
+		}
+		switch f {
+		case 'i':
+			pattern = "(?i)" + pattern
+		case 'g':
+			global = true
+		}
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, false, fmt.Errorf("%s: invalid regular expression: %w", fn, err)
+	}
+	return re, global, nil
+}
+
+// regexpReplace replaces the first match of a regular expression in a text,
+// or all of them with the g flag. In the replacement, \1 to \9 are replaced
+// by the text matched by the corresponding group, and \& by the whole match.
+var regexpReplace = &ScalarDefinition{
+	name:     "regexp_replace",
+	arity:    3,
+	maxArity: 4,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		const fn = "regexp_replace(source, pattern, replacement[, flags])"
+		texts, ok := textArgs(args...)
+		if !ok {
+			return types.NewNullValue(), nil
+		}
+		var flags string
+		if len(texts) > 3 {
+			flags = texts[3]
+		}
+		re, global, err := compileRegexp(fn, texts[1], flags, "gi")
+		if err != nil {
+			return nil, err
+		}
+
+		repl := regexpTemplate(texts[2])
+		src := texts[0]
+		if global {
+			return types.NewTextValue(re.ReplaceAllString(src, repl)), nil
+		}
+
+		loc := re.FindStringSubmatchIndex(src)
+		if loc == nil {
+			return args[0], nil
+		}
+		dst := re.ExpandString([]byte(src[:loc[0]]), repl, src, loc)
+		return types.NewTextValue(string(dst) + src[loc[1]:]), nil
+	},
+}
+
+// regexpTemplate converts a replacement text using \1 and \& to refer to the groups
+// to the template syntax of the regexp package.
+func regexpTemplate(repl string) string {
+	var sb strings.Builder
+
+	for i := 0; i < len(repl); i++ {
+		c := repl[i]
+		switch {
+		case c == '$':
+			sb.WriteString("$$")
+		case c == '\\' && i+1 < len(repl) && repl[i+1] >= '1' && repl[i+1] <= '9':
+			fmt.Fprintf(&sb, "${%c}", repl[i+1])
+			i++
+		case c == '\\' && i+1 < len(repl) && repl[i+1] == '&':
+			sb.WriteString("${0}")
+			i++
+		case c == '\\' && i+1 < len(repl) && repl[i+1] == '\\':
+			sb.WriteByte('\\')
+			i++
+		default:
+			sb.WriteByte(c)
+		}
+	}
+
+	return sb.String()
+}
+
+// regexpMatch returns the texts matched by the groups of a regular expression
+// in the first match, or the whole match if it has no groups, as an array.
+// It returns NULL if the text doesn't match.
+var regexpMatch = &ScalarDefinition{
+	name:     "regexp_match",
+	arity:    2,
+	maxArity: 3,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		const fn = "regexp_match(source, pattern[, flags])"
+		texts, ok := textArgs(args...)
+		if !ok {
+			return types.NewNullValue(), nil
+		}
+		var flags string
+		if len(texts) > 2 {
+			flags = texts[2]
+		}
+		re, _, err := compileRegexp(fn, texts[1], flags, "i")
+		if err != nil {
+			return nil, err
+		}
+
+		loc := re.FindStringSubmatchIndex(texts[0])
+		if loc == nil {
+			return types.NewNullValue(), nil
+		}
+		if len(loc) > 2 {
+			loc = loc[2:]
+		}
+
+		values := make([]types.Value, 0, len(loc)/2)
+		for i := 0; i < len(loc); i += 2 {
+			// groups that didn't participate in the match are NULL
+			if loc[i] < 0 {
+				values = append(values, types.NewNullValue())
+				continue
+			}
+			values = append(values, types.NewTextValue(texts[0][loc[i]:loc[i+1]]))
+		}
+		return types.MakeArray(values...)
+	},
+}
+
+// regexpSplit splits a text on the matches of a regular expression.
+// It returns false if one of the arguments is not a text.
+func regexpSplit(fn string, args []types.Value) ([]string, bool, error) {
+	texts, ok := textArgs(args...)
+	if !ok {
+		return nil, false, nil
+	}
+	var flags string
+	if len(texts) > 2 {
+		flags = texts[2]
+	}
+	re, _, err := compileRegexp(fn, texts[1], flags, "i")
+	if err != nil {
+		return nil, false, err
+	}
+
+	return re.Split(texts[0], -1), true, nil
+}
+
+// regexpSplitToArray splits a text on the matches of a regular expression
+// and returns the parts as an array.
+var regexpSplitToArray = &ScalarDefinition{
+	name:     "regexp_split_to_array",
+	arity:    2,
+	maxArity: 3,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		parts, ok, err := regexpSplit("regexp_split_to_array(source, pattern[, flags])", args)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return types.NewNullValue(), nil
+		}
+
+		values := make([]types.Value, len(parts))
+		for i, p := range parts {
+			values[i] = types.NewTextValue(p)
+		}
+		return types.MakeArray(values...)
+	},
+}
+
+// RegexpSplitToTable is the REGEXP_SPLIT_TO_TABLE table function, which is only
+// allowed in the FROM clause. It splits a text on the matches of a regular expression
+// and returns one row per part, in a column named regexp_split_to_table.
+// A NULL text returns no rows.
+type RegexpSplitToTable struct {
+	Exprs []expr.Expr
+}
+
+func (r *RegexpSplitToTable) Clone() expr.Expr {
+	exprs := make([]expr.Expr, 0, len(r.Exprs))
+	for _, e := range r.Exprs {
+		exprs = append(exprs, expr.Clone(e))
+	}
+
+	return &RegexpSplitToTable{Exprs: exprs}
+}
+
+func (r *RegexpSplitToTable) Eval(env *environment.Environment) (types.Value, error) {
+	return nil, fmt.Errorf("REGEXP_SPLIT_TO_TABLE() is only allowed in the FROM clause")
+}
+
+func (r *RegexpSplitToTable) IsEqual(other expr.Expr) bool {
+	if other == nil {
+		return false
+	}
+
+	o, ok := other.(*RegexpSplitToTable)
+	if !ok || len(r.Exprs) != len(o.Exprs) {
+		return false
+	}
+
+	for i := range r.Exprs {
+		if !expr.Equal(r.Exprs[i], o.Exprs[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (r *RegexpSplitToTable) Params() []expr.Expr { return r.Exprs }
+
+func (r *RegexpSplitToTable) String() string {
+	return "REGEXP_SPLIT_TO_TABLE" + expr.LiteralExprList(r.Exprs).String()
+}
+
+// Columns returns the column of the rows. It implements the TableFunction interface.
+func (r *RegexpSplitToTable) Columns() []string {
+	return []string{"regexp_split_to_table"}
+}
+
+// Iterate calls fn with every part of the text.
+func (r *RegexpSplitToTable) Iterate(env *environment.Environment, fn func(values []types.Value) error) error {
+	args := make([]types.Value, 0, len(r.Exprs))
+	for _, e := range r.Exprs {
+		v, err := e.Eval(env)
+		if err != nil {
+			return err
+		}
+		args = append(args, v)
+	}
+
+	parts, ok, err := regexpSplit("regexp_split_to_table(source, pattern[, flags])", args)
+	if err != nil || !ok {
+		return err
+	}
+
+	values := make([]types.Value, 1)
+	for _, p := range parts {
+		values[0] = types.NewTextValue(p)
+		if err := fn(values); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// pad returns a text filled up to length characters with the characters of fill,
+// on the left or on the right. Longer texts are truncated on the right.
+func pad(name string, left bool) *ScalarDefinition {
+	return &ScalarDefinition{
+		name:     name,
+		arity:    2,
+		maxArity: 3,
+		callFn: func(args ...types.Value) (types.Value, error) {
+			texts, ok := textArgs(args[0])
+			n, isInt := intArgOrNull(args[1])
+			fill := " "
+			if len(args) > 2 {
+				f, isText := textArgs(args[2])
+				ok = ok && isText
+				if ok {
+					fill = f[0]
+				}
+			}
+			if !ok || !isInt {
+				return types.NewNullValue(), nil
+			}
+			if n*int64(utf8.UTFMax) > maxTextLen {
+				return nil, fmt.Errorf("%s(text, length[, fill]): requested length too large", name)
+			}
+
+			s := []rune(texts[0])
+			if n <= 0 {
+				return types.NewTextValue(""), nil
+			}
+			if int64(len(s)) >= n {
+				return types.NewTextValue(string(s[:n])), nil
+			}
+			f := []rune(fill)
+			if len(f) == 0 {
+				return types.NewTextValue(texts[0]), nil
+			}
+
+			padding := make([]rune, 0, int(n)-len(s))
+			for i := 0; len(padding) < cap(padding); i++ {
+				padding = append(padding, f[i%len(f)])
+			}
+			if left {
+				return types.NewTextValue(string(padding) + texts[0]), nil
+			}
+			return types.NewTextValue(texts[0] + string(padding)), nil
+		},
+	}
+}
+
+// lpad fills a text on the left, see pad.
+var lpad = pad("lpad", true)
+
+// rpad fills a text on the right, see pad.
+var rpad = pad("rpad", false)
+
+// translate replaces each character of a text found in from by the character
+// at the same position in to, or removes it if to is shorter than from.
+var translate = &ScalarDefinition{
+	name:  "translate",
+	arity: 3,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		texts, ok := textArgs(args...)
+		if !ok {
+			return types.NewNullValue(), nil
+		}
+
+		from, to := []rune(texts[1]), []rune(texts[2])
+		return types.NewTextValue(strings.Map(func(r rune) rune {
+			for i, f := range from {
+				if f != r {
+					continue
+				}
+				if i < len(to) {
+					return to[i]
+				}
+				return -1
+			}
+			return r
+		}, texts[0])), nil
+	},
+}
+
+// repeat returns a text repeated n times.
+var repeat = &ScalarDefinition{
+	name:  "repeat",
+	arity: 2,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		texts, ok := textArgs(args[0])
+		n, isInt := intArgOrNull(args[1])
+		if !ok || !isInt {
+			return types.NewNullValue(), nil
+		}
+		if n <= 0 {
+			return types.NewTextValue(""), nil
+		}
+		if int64(len(texts[0]))*n > maxTextLen {
+			return nil, fmt.Errorf("repeat(text, n): requested length too large")
+		}
+
+		return types.NewTextValue(strings.Repeat(texts[0], int(n))), nil
+	},
+}
+
+// reverse returns the characters of a text in reverse order.
+var reverse = &ScalarDefinition{
+	name:  "reverse",
+	arity: 1,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		texts, ok := textArgs(args...)
+		if !ok {
+			return types.NewNullValue(), nil
+		}
+
+		r := []rune(texts[0])
+		slices.Reverse(r)
+		return types.NewTextValue(string(r)), nil
+	},
+}
+
+// initcap converts the first letter of each word to upper case
+// and the other letters to lower case. Words are sequences of letters and digits.
+var initcap = &ScalarDefinition{
+	name:  "initcap",
+	arity: 1,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		texts, ok := textArgs(args...)
+		if !ok {
+			return types.NewNullValue(), nil
+		}
+
+		inWord := false
+		return types.NewTextValue(strings.Map(func(r rune) rune {
+			start := !inWord
+			inWord = unicode.IsLetter(r) || unicode.IsDigit(r)
+			if start {
+				return unicode.ToUpper(r)
+			}
+			return unicode.ToLower(r)
+		}, texts[0])), nil
+	},
+}
+
+// position returns the position of the first occurrence of a substring
+// in a text, starting at 1, or 0 if the text doesn't contain it.
+// The parser also accepts the standard form POSITION(substring IN text).
+var position = &ScalarDefinition{
+	name:  "position",
+	arity: 2,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		texts, ok := textArgs(args...)
+		if !ok {
+			return types.NewNullValue(), nil
+		}
+
+		i := strings.Index(texts[1], texts[0])
+		if i < 0 {
+			return types.NewIntegerValue(0), nil
+		}
+		return types.NewIntegerValue(int32(utf8.RuneCountInString(texts[1][:i]) + 1)), nil
+	},
+}
+
+// format returns a text formatted according to a format string, in which
+// %s is replaced by the text representation of the next argument and %d by
+// its value as an integer. A position can be given with %n$s or %n$d to use
+// the n-th argument, starting at 1, and the following specifiers continue
+// from there. NULL arguments are formatted as empty texts, and %% outputs a
+// single %. A NULL format returns NULL.
+var format = &ScalarDefinition{
+	name:     "format",
+	arity:    1,
+	maxArity: variadicArity,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		if !args[0].Type().IsText() {
+			return types.NewNullValue(), nil
+		}
+
+		f := types.AsString(args[0])
+		args = args[1:]
+
+		var sb strings.Builder
+		next := 0
+		for i := 0; i < len(f); i++ {
+			if f[i] != '%' {
+				sb.WriteByte(f[i])
+				continue
+			}
+
+			i++
+			if i == len(f) {
+				return nil, fmt.Errorf("format(): unterminated format specifier")
+			}
+			if f[i] == '%' {
+				sb.WriteByte('%')
+				continue
+			}
+
+			// optional position, followed by $
+			j := i
+			for j < len(f) && f[j] >= '0' && f[j] <= '9' {
+				j++
+			}
+			if j > i && j < len(f) && f[j] == '$' {
+				n, err := strconv.Atoi(f[i:j])
+				if err != nil || n == 0 {
+					return nil, fmt.Errorf("format(): invalid argument position %q", f[i:j])
+				}
+				next = n - 1
+				i = j + 1
+				if i == len(f) {
+					return nil, fmt.Errorf("format(): unterminated format specifier")
+				}
+			}
+
+			if next >= len(args) {
+				return nil, fmt.Errorf("format(): too few arguments")
+			}
+			v := args[next]
+			next++
+
+			switch f[i] {
+			case 's':
+				if v.Type() == types.TypeNull {
+					continue
+				}
+				if !v.Type().IsText() {
+					var err error
+					v, err = v.CastAs(types.TypeText)
+					if err != nil {
+						return nil, err
+					}
+				}
+				sb.WriteString(types.AsString(v))
+			case 'd':
+				if v.Type() == types.TypeNull {
+					continue
+				}
+				v, err := v.CastAs(types.TypeBigint)
+				if err != nil {
+					return nil, err
+				}
+				sb.WriteString(strconv.FormatInt(types.AsInt64(v), 10))
+			default:
+				return nil, fmt.Errorf("format(): unrecognized format specifier %q", f[i])
+			}
+
+			if sb.Len() > maxTextLen {
+				return nil, fmt.Errorf("format(): requested length too large")
+			}
+		}
+
+		return types.NewTextValue(sb.String()), nil
+	},
+}
@@ -31,3 +31,521 @@
 -- test: now
 > typeof(now())
 'timestamp'
+
+-- test: current_date
+> CURRENT_DATE
+CAST('2020-01-01' AS TIMESTAMP)
+
+> current_date()
+CAST('2020-01-01' AS TIMESTAMP)
+
+-- test: date_trunc
+> date_trunc('day', CAST('2021-03-17 15:04:05.123456' AS TIMESTAMP))
+CAST('2021-03-17' AS TIMESTAMP)
+
+> date_trunc('HOUR', CAST('2021-03-17 15:04:05.123456' AS TIMESTAMP))
+CAST('2021-03-17 15:00:00' AS TIMESTAMP)
+
+> date_trunc('milliseconds', CAST('2021-03-17 15:04:05.123456' AS TIMESTAMP))
+CAST('2021-03-17 15:04:05.123' AS TIMESTAMP)
+
+> date_trunc('week', CAST('2021-03-17 15:04:05' AS TIMESTAMP))
+CAST('2021-03-15' AS TIMESTAMP)
+
+> date_trunc('quarter', CAST('2021-08-17 15:04:05' AS TIMESTAMP))
+CAST('2021-07-01' AS TIMESTAMP)
+
+> date_trunc('century', CAST('2021-08-17 15:04:05' AS TIMESTAMP))
+CAST('2001-01-01' AS TIMESTAMP)
+
+> date_trunc('month', '2021-08-17 15:04:05')
+CAST('2021-08-01' AS TIMESTAMP)
+
+> typeof(date_trunc('month', CAST('2021-08-17 15:04:05' AS TIMESTAMPTZ)))
+'timestamptz'
+
+> date_trunc('day', NULL)
+NULL
+
+! date_trunc('fortnight', CAST('2021-08-17' AS TIMESTAMP))
+'unsupported field "fortnight"'
+
+! date_trunc('day', 10)
+'expects a timestamp'
+
+-- test: extract
+> extract('year', CAST('2021-03-17 15:04:05.5' AS TIMESTAMP))
+2021
+
+> EXTRACT(month FROM CAST('2021-03-17 15:04:05.5' AS TIMESTAMP))
+3
+
+> EXTRACT(second FROM CAST('2021-03-17 15:04:05.5' AS TIMESTAMP))
+5.5
+
+> EXTRACT(dow FROM CAST('2021-03-21' AS TIMESTAMP))
+0
+
+> EXTRACT(isodow FROM CAST('2021-03-21' AS TIMESTAMP))
+7
+
+> EXTRACT(doy FROM CAST('2021-03-17' AS TIMESTAMP))
+76
+
+> EXTRACT(week FROM CAST('2021-01-01' AS TIMESTAMP))
+53
+
+> EXTRACT(epoch FROM CAST('1970-01-02 00:00:01' AS TIMESTAMP))
+86401.0
+
+> EXTRACT(quarter FROM '2021-11-01')
+4
+
+> EXTRACT(year FROM NULL)
+NULL
+
+! EXTRACT(fortnight FROM CAST('2021-08-17' AS TIMESTAMP))
+'unsupported field "fortnight"'
+
+-- test: age
+> age(CAST('2001-04-10' AS TIMESTAMP), CAST('1957-06-13' AS TIMESTAMP))
+'43 years 9 mons 27 days'
+
+> age(CAST('1957-06-13' AS TIMESTAMP), CAST('2001-04-10' AS TIMESTAMP))
+'-43 years -9 mons -27 days'
+
+> age(CAST('2021-03-01 12:00:00' AS TIMESTAMP), CAST('2021-02-28 13:30:00.25' AS TIMESTAMP))
+'22:29:59.75'
+
+> age(CAST('2021-03-01' AS TIMESTAMP), CAST('2021-03-01' AS TIMESTAMP))
+'00:00:00'
+
+> age(CAST('2019-01-01' AS TIMESTAMP))
+'1 year'
+
+> age(NULL, CAST('2021-03-01' AS TIMESTAMP))
+NULL
+
+! age(CAST('2019-01-01' AS TIMESTAMP), CAST('2019-01-01' AS TIMESTAMP), CAST('2019-01-01' AS TIMESTAMP))
+'age() takes 1 or 2 argument(s), not 3'
+
+-- test: make_date
+> make_date(2021, 3, 17)
+CAST('2021-03-17' AS TIMESTAMP)
+
+> make_timestamp(2021, 3, 17, 15, 4, 5.25)
+CAST('2021-03-17 15:04:05.25' AS TIMESTAMP)
+
+> make_date(2021, NULL, 17)
+NULL
+
+! make_date(2021, 2, 30)
+'date field value out of range'
+
+! make_date(2021, 2, 'a')
+'expects integers'
+
+! make_timestamp(2021, 3, 17, 15, 4, 60)
+'seconds field value out of range'
+
+-- test: strftime
+> strftime('%Y-%m-%d %H:%M:%S', CAST('2021-03-07 05:04:03' AS TIMESTAMP))
+'2021-03-07 05:04:03'
+
+> strftime('%a %A %b %B %e %I%p %j', CAST('2021-03-07 15:04:03' AS TIMESTAMP))
+'Sun Sunday Mar March 7 03PM 066'
+
+> strftime('%F %T.%f %s %u %w %V %G %y %z %Z %%', CAST('2021-01-03 05:04:03.5' AS TIMESTAMP))
+'2021-01-03 05:04:03.03.500 1609650243 7 0 53 2020 21 +0000 UTC %'
+
+> strftime('%Y', NULL)
+NULL
+
+! strftime('%Q', CAST('2021-03-07' AS TIMESTAMP))
+'unsupported directive %Q'
+
+! strftime('%', CAST('2021-03-07' AS TIMESTAMP))
+'incomplete directive'
+
+-- test: uuid
+> typeof(gen_uuid_v4())
+'uuid'
+
+> typeof(gen_uuid_v7())
+'uuid'
+
+> gen_uuid_v4() = gen_uuid_v4()
+false
+
+> gen_uuid_v7() < gen_uuid_v7()
+true
+
+> len(CAST(gen_uuid_v7() AS TEXT))
+36
+
+> CAST('F81D4FAE-7DEC-11D0-A765-00A0C91E6BF6' AS UUID) = 'f81d4fae7dec11d0a76500a0c91e6bf6'
+true
+
+> CAST(CAST('f81d4fae-7dec-11d0-a765-00a0c91e6bf6' AS UUID) AS TEXT)
+'f81d4fae-7dec-11d0-a765-00a0c91e6bf6'
+
+! CAST('f81d4fae' AS UUID)
+
+-- test: json_extract
+> json_extract(CAST('{"a": {"b": [1, 2.5, "c"]}}' AS JSONB), '$.a.b[1]')
+2.5
+
+> json_extract('{"a": {"b": [1, 2.5, "c"]}}', '$.a.b[2]')
+'c'
+
+> typeof(json_extract('{"a": {"b": [1, 2.5, "c"]}}', '$.a'))
+'jsonb'
+
+> CAST(json_extract('{"a": {"b": [1, 2.5, "c"]}}', '$.a') AS TEXT)
+'{"b": [1, 2.5, "c"]}'
+
+> json_extract('{"a b": true}', '$."a b"')
+true
+
+> json_extract('{"a": null}', '$.a')
+NULL
+
+> json_extract('{"a": 1}', '$.b')
+NULL
+
+> json_extract('[1, 2]', '$[2]')
+NULL
+
+> json_extract(1, '$')
+NULL
+
+! json_extract('{"a": 1}', 'a')
+
+! json_extract('{"a": 1', '$.a')
+
+-- test: json_set
+> CAST(json_set('{"a": 1, "c": 3}', '$.a', 'x') AS TEXT)
+'{"a": "x", "c": 3}'
+
+> CAST(json_set('{"a": 1, "c": 3}', '$.b', [1, true]) AS TEXT)
+'{"a": 1, "b": [1, true], "c": 3}'
+
+> CAST(json_set('{"a": [1, 2]}', '$.a[1]', NULL) AS TEXT)
+'{"a": [1, null]}'
+
+> CAST(json_set('{"a": [1, 2]}', '$.a[2]', 3) AS TEXT)
+'{"a": [1, 2, 3]}'
+
+> CAST(json_set('{"a": [1, 2]}', '$.a[5]', 3) AS TEXT)
+'{"a": [1, 2]}'
+
+> CAST(json_set('{"a": 1}', '$.b.c', 3) AS TEXT)
+'{"a": 1}'
+
+> CAST(json_set('{"a": 1}', '$', CAST('{"b": 2}' AS JSONB)) AS TEXT)
+'{"b": 2}'
+
+> json_set(1, '$.a', 1)
+NULL
+
+! json_set('{"a": 1}', 'a', 1)
+
+-- test: json_remove
+> CAST(json_remove('{"a": 1, "b": {"c": 2, "d": 3}}', '$.b.c') AS TEXT)
+'{"a": 1, "b": {"d": 3}}'
+
+> CAST(json_remove('[1, 2, 3]', '$[1]') AS TEXT)
+'[1, 3]'
+
+> CAST(json_remove('{"a": 1}', '$.b') AS TEXT)
+'{"a": 1}'
+
+> json_remove('{"a": 1}', '$')
+NULL
+
+> json_remove(NULL, '$.a')
+NULL
+
+-- test: json_array_length
+> json_array_length('[1, [2, 3], {"a": 4}]')
+3
+
+> json_array_length('[]')
+0
+
+> json_array_length('{"a": [1, 2]}', '$.a')
+2
+
+> json_array_length('{"a": [1, 2]}')
+NULL
+
+> json_array_length('{"a": [1, 2]}', '$.b')
+NULL
+
+> json_array_length('{"a": 1}', '$.a')
+NULL
+
+! json_array_length('[1', '$')
+
+-- test: greatest
+> greatest(1, 5, 3)
+5
+
+> greatest(1, 2.5)
+2.5
+
+> greatest('apple', 'banana')
+'banana'
+
+> greatest(NULL, 2, NULL)
+2
+
+> greatest(NULL, NULL)
+NULL
+
+> greatest(4)
+4
+
+! greatest()
+
+-- test: least
+> least(3, 1, 2)
+1
+
+> least(1.5, 2)
+1.5
+
+> least('b', 'a', NULL)
+'a'
+
+> least(NULL)
+NULL
+
+-- test: nullif
+> nullif(1, 1)
+NULL
+
+> nullif(1, 2)
+1
+
+> nullif('a', 'b')
+'a'
+
+> nullif(1, 1.0)
+NULL
+
+> nullif(NULL, 1)
+NULL
+
+> nullif(1, NULL)
+1
+
+! nullif(1)
+
+-- test: encode
+> encode(x'DEADBEEF', 'hex')
+'deadbeef'
+
+> encode(X'deadbeef', 'BASE64')
+'3q2+7w=='
+
+> encode(x'', 'hex')
+''
+
+> encode(NULL, 'hex')
+NULL
+
+> encode('hello', 'hex')
+NULL
+
+! encode(x'DEADBEEF', 'escape')
+
+-- test: decode
+> decode('deadbeef', 'hex') = x'DEADBEEF'
+true
+
+> decode('3q2+7w==', 'base64') = x'DEADBEEF'
+true
+
+> typeof(decode('', 'hex'))
+'blob'
+
+> decode(NULL, 'hex')
+NULL
+
+> encode(decode('c0ffee', 'hex'), 'base64')
+'wP/u'
+
+! decode('xyz', 'hex')
+
+! decode('3q2+7w', 'base64')
+
+! decode('deadbeef', 'escape')
+
+-- test: convert_to
+> convert_to('héllo', 'UTF8') = x'68C3A96C6C6F'
+true
+
+> convert_to('héllo', 'latin1') = x'68E96C6C6F'
+true
+
+> convert_to('héllo', 'ISO-8859-1') = x'68E96C6C6F'
+true
+
+> convert_to('', 'UTF8') = x''
+true
+
+> convert_to(NULL, 'UTF8')
+NULL
+
+> convert_to(x'68', 'UTF8')
+NULL
+
+! convert_to('€', 'LATIN1')
+
+! convert_to('hello', 'EBCDIC')
+
+-- test: convert_from
+> convert_from(x'68C3A96C6C6F', 'UTF8')
+'héllo'
+
+> convert_from(x'68E96C6C6F', 'LATIN1')
+'héllo'
+
+> convert_from(convert_to('héllo', 'utf-8'), 'Latin1')
+'hÃ©llo'
+
+> encode(convert_to('hello', 'UTF8'), 'base64')
+'aGVsbG8='
+
+> convert_from(decode('aGVsbG8=', 'base64'), 'UTF8')
+'hello'
+
+> convert_from(NULL, 'UTF8')
+NULL
+
+> convert_from('hello', 'UTF8')
+NULL
+
+! convert_from(x'68E96C6C6F', 'UTF8')
+
+! convert_from(x'68', 'EBCDIC')
+
+-- test: md5
+> md5('hello')
+'5d41402abc4b2a76b9719d911017c592'
+
+> md5(x'68656c6c6f')
+'5d41402abc4b2a76b9719d911017c592'
+
+> md5(NULL)
+NULL
+
+> md5(1)
+NULL
+
+-- test: sha1
+> sha1('hello')
+'aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d'
+
+> sha1(NULL)
+NULL
+
+-- test: sha256
+> sha256('hello')
+'2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824'
+
+> sha256(x'68656c6c6f')
+'2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824'
+
+> sha256(true)
+NULL
+
+-- test: xxhash
+> xxhash('hello')
+2794345569481354659
+
+> xxhash('')
+-1205034819632174695
+
+> xxhash(NULL)
+NULL
+
+-- test: crc32
+> crc32('hello')
+907060870
+
+> crc32(x'68656c6c6f')
+907060870
+
+> crc32('')
+0
+
+> crc32(NULL)
+NULL
+
+-- test: encrypt
+> convert_from(decrypt(encrypt('secret', '0123456789abcdef'), '0123456789abcdef'), 'UTF8')
+'secret'
+
+> decrypt(encrypt(x'DEADBEEF', x'000102030405060708090A0B0C0D0E0F101112131415161718191A1B1C1D1E1F'), x'000102030405060708090A0B0C0D0E0F101112131415161718191A1B1C1D1E1F', 'AES') = x'DEADBEEF'
+true
+
+> len(encode(encrypt('secret', '0123456789abcdef'), 'hex'))
+68
+
+> encrypt('secret', '0123456789abcdef') = encrypt('secret', '0123456789abcdef')
+false
+
+> encrypt(NULL, '0123456789abcdef')
+NULL
+
+> encrypt('secret', 1)
+NULL
+
+! encrypt('secret', 'short')
+'key must be 16, 24 or 32 bytes long'
+
+! encrypt('secret', '0123456789abcdef', 'bf')
+'unsupported cipher type'
+
+-- test: decrypt
+> decrypt(NULL, '0123456789abcdef')
+NULL
+
+! decrypt(encrypt('secret', '0123456789abcdef'), 'fedcba9876543210')
+'wrong key or corrupt data'
+
+! decrypt(x'DEADBEEF', '0123456789abcdef')
+'wrong key or corrupt data'
+
+-- test: hmac
+> encode(hmac('The quick brown fox jumps over the lazy dog', 'key', 'sha256'), 'hex')
+'f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8'
+
+> encode(hmac(x'54686520717569636b2062726f776e20666f78206a756d7073206f76657220746865206c617a7920646f67', 'key', 'MD5'), 'hex')
+'80070713463e7749b90c2dc24911e275'
+
+> typeof(hmac('data', 'key', 'sha512'))
+'blob'
+
+> hmac(NULL, 'key', 'sha1')
+NULL
+
+! hmac('data', 'key', 'crc32')
+'unsupported hash type'
+
+-- test: random_between
+> random_between(5, 5)
+5
+
+> random_between(-1, 1) BETWEEN -1 AND 1
+true
+
+> random_between(-9223372036854775808, 9223372036854775807) IS NOT NULL
+true
+
+> random_between(1, NULL)
+NULL
+
+! random_between(2, 1)
@@ -134,4 +134,181 @@ NULL
 > sqrt(1.1)
 1.0488088481701516
 > sqrt('foo')
-NULL
\ No newline at end of file
+NULL
+-- test: round
+> round(NULL)
+NULL
+> round(2.5)
+3.0
+> round(-2.5)
+-3.0
+> round(2.4)
+2.0
+> round(2)
+2
+> round(3.14159, 2)
+3.14
+> round(-3.14159, 3)
+-3.142
+> round(1234.5678, -2)
+1200.0
+> round(1250, -2)
+1300
+> round(-1250, -2)
+-1300
+> round(1249, -2)
+1200
+> round(12, -5)
+0
+> round(4611686018427387904, -19)
+0
+> round(2.5, NULL)
+NULL
+> round(1e300, 400)
+1e300
+! round(9223372036854775807, -1)
+'integer out of range'
+! round('a')
+'round(arg1[, arg2]) expects arg1 to be a number'
+
+-- test: trunc
+> trunc(NULL)
+NULL
+> trunc(2.7)
+2.0
+> trunc(-2.7)
+-2.0
+> trunc(3.14159, 3)
+3.141
+> trunc(1299, -2)
+1200
+> trunc(-1299, -2)
+-1200
+> trunc(5)
+5
+! trunc('a', 1)
+'trunc(arg1[, arg2]) expects arg1 to be a number'
+
+-- test: sign
+> sign(NULL)
+NULL
+> sign(-5)
+-1
+> sign(0)
+0
+> sign(7)
+1
+> sign(-2.5)
+-1.0
+> sign(0.0)
+0.0
+> typeof(sign(-9223372036854775807))
+'bigint'
+! sign('a')
+'sign(arg1) expects arg1 to be a number'
+
+-- test: log
+> log(NULL)
+NULL
+> log(100)
+2.0
+> log(2, 8)
+3.0
+> log(2, NULL)
+NULL
+> log(10, 1000)
+3.0
+! log(0)
+'out of range'
+! log(-1)
+'out of range'
+! log(1, 10)
+'out of range'
+! log(0, 10)
+'out of range'
+
+-- test: degrees
+> degrees(NULL)
+NULL
+> degrees(0)
+0.0
+> degrees(3.141592653589793)
+180.0
+! degrees('foo')
+'cannot cast "foo" as double'
+
+-- test: radians
+> radians(NULL)
+NULL
+> radians(180)
+3.141592653589793
+> radians(90.0)
+1.5707963267948966
+
+-- test: bit_count
+> bit_count(NULL)
+NULL
+> bit_count(0)
+0
+> bit_count(7)
+3
+> bit_count(-1)
+32
+> bit_count(CAST(-1 AS BIGINT))
+64
+> bit_count(x'FF01')
+9
+! bit_count(1.5)
+'expects arg1 to be an integer or a blob'
+
+-- test: get_bit
+> get_bit(5, 0)
+1
+> get_bit(5, 1)
+0
+> get_bit(-1, 31)
+1
+> get_bit(CAST(1 AS BIGINT), 63)
+0
+> get_bit(x'0180', 0)
+1
+> get_bit(x'0180', 15)
+1
+> get_bit(NULL, 1)
+NULL
+> get_bit(5, NULL)
+NULL
+! get_bit(5, 32)
+'out of valid range, 0..31'
+! get_bit(x'01', 8)
+'out of valid range, 0..7'
+! get_bit(5, -1)
+'out of valid range'
+! get_bit('a', 1)
+'expects arg1 to be an integer or a blob'
+
+-- test: width_bucket
+> width_bucket(5.35, 0.024, 10.06, 5)
+3
+> width_bucket(0, 0, 10, 5)
+1
+> width_bucket(9.99, 0, 10, 5)
+5
+> width_bucket(10, 0, 10, 5)
+6
+> width_bucket(-1, 0, 10, 5)
+0
+> width_bucket(7, 10, 0, 5)
+2
+> width_bucket(0, 10, 0, 5)
+6
+> width_bucket(NULL, 0, 10, 5)
+NULL
+! width_bucket(1, 0, 10, 0)
+'count must be greater than zero'
+! width_bucket(1, 5, 5, 2)
+'lower bound cannot equal upper bound'
+! width_bucket(1, 0, 10, 2.5)
+'expects integers'
+! width_bucket('a', 0, 10, 2)
+'expects numbers'
@@ -0,0 +1,240 @@
+-- test: split_part
+> split_part('a,b,c', ',', 2)
+'b'
+> split_part('a,b,c', ',', -1)
+'c'
+> split_part('a,b,c', ',', 4)
+''
+> split_part('a::b', '::', 2)
+'b'
+> split_part('abc', '', 1)
+'abc'
+> split_part(NULL, ',', 1)
+NULL
+> split_part('a,b', ',', 'x')
+NULL
+! split_part('a,b,c', ',', 0)
+'field position must not be zero'
+
+-- test: regexp_replace
+> regexp_replace('foo bar foo', 'fo+', 'baz')
+'baz bar foo'
+> regexp_replace('foo bar foo', 'FO+', 'baz', 'gi')
+'baz bar baz'
+> regexp_replace('John Smith', '(\\w+) (\\w+)', '\\2, \\1')
+'Smith, John'
+> regexp_replace('a1b2', '\\d', '<\\&>', 'g')
+'a<1>b<2>'
+> regexp_replace('price', 'price', '$1 \\\\')
+'$1 \\'
+> regexp_replace('abc', 'x', 'y')
+'abc'
+> regexp_replace(NULL, 'x', 'y')
+NULL
+! regexp_replace('abc', '(', 'y')
+'invalid regular expression'
+! regexp_replace('abc', 'a', 'y', 'z')
+'invalid regular expression flag'
+! regexp_replace('abc', 'a')
+'takes 3 to 4 argument(s), not 2'
+
+-- test: regexp_match
+> regexp_match('foobarbequebaz', 'bar.*que')
+['barbeque']
+> regexp_match('foobarbequebaz', '(bar)(beque)')
+['bar', 'beque']
+> regexp_match('FOO', 'o+', 'i')
+['OO']
+> regexp_match('abc', '(x)?c')
+[NULL]
+> regexp_match('abc', 'x')
+NULL
+> typeof(regexp_match('abc', 'b'))
+'array'
+! regexp_match('abc', 'b', 'g')
+'invalid regular expression flag'
+
+-- test: regexp_split_to_array
+> regexp_split_to_array('hello   world', ' +')
+['hello', 'world']
+> regexp_split_to_array('a1b22c', '[0-9]+')
+['a', 'b', 'c']
+> regexp_split_to_array('aXbxc', 'x', 'i')
+['a', 'b', 'c']
+> regexp_split_to_array(',a,', ',')
+['', 'a', '']
+> regexp_split_to_array('abc', '')
+['a', 'b', 'c']
+> regexp_split_to_array('abc', 'x')
+['abc']
+> regexp_split_to_array(NULL, ',')
+NULL
+! regexp_split_to_array('abc', '(')
+'invalid regular expression'
+! regexp_split_to_array('abc', 'b', 'g')
+'invalid regular expression flag'
+
+-- test: lpad
+> lpad('hi', 5)
+'   hi'
+> lpad('hi', 5, 'xy')
+'xyxhi'
+> lpad('hello', 2)
+'he'
+> lpad('hé', 4, 'é')
+'ééhé'
+> lpad('hi', -1)
+''
+> lpad('hi', 5, '')
+'hi'
+> lpad('hi', NULL)
+NULL
+! lpad('hi', 2000000000)
+'requested length too large'
+
+-- test: rpad
+> rpad('hi', 5)
+'hi   '
+> rpad('hi', 5, 'xy')
+'hixyx'
+> rpad('hello', 2)
+'he'
+
+-- test: translate
+> translate('12345', '143', 'ax')
+'a2x5'
+> translate('hello', 'l', '')
+'heo'
+> translate(NULL, 'a', 'b')
+NULL
+
+-- test: repeat
+> repeat('ab', 3)
+'ababab'
+> repeat('ab', 0)
+''
+> repeat('ab', -1)
+''
+> repeat(1, 2)
+NULL
+! repeat('ab', 2000000000)
+'requested length too large'
+
+-- test: reverse
+> reverse('abc')
+'cba'
+> reverse('héllo')
+'olléh'
+> reverse('')
+''
+
+-- test: initcap
+> initcap('hi THOMAS')
+'Hi Thomas'
+> initcap('jean-luc o\'neil 2nd')
+'Jean-Luc O\'Neil 2nd'
+
+-- test: position
+> position('om' IN 'Thomas')
+3
+> position('x' IN 'Thomas')
+0
+> position('é' IN 'héllo')
+2
+> position('b', 'abc')
+2
+> position('' IN 'abc')
+1
+> position(NULL IN 'abc')
+NULL
+! position('a' 'abc')
+
+-- test: format
+> format('Hello %s, you are %d', 'Ann', 42)
+'Hello Ann, you are 42'
+> format('%s and %s', 1.5, true)
+'1.5 and true'
+> format('%d', 7.0)
+'7'
+> format('%2$s %1$s %s', 'a', 'b', 'c')
+'b a b'
+> format('[%s]', NULL)
+'[]'
+> format('100%%')
+'100%'
+> format(NULL, 'a')
+NULL
+! format('%s %s', 'a')
+'too few arguments'
+! format('%x', 1)
+'unrecognized format specifier'
+! format('%0$s', 1)
+'invalid argument position'
+! format('abc%')
+'unterminated format specifier'
+! format('%d', 'abc')
+! format()
+
+-- test: levenshtein
+> levenshtein('kitten', 'sitting')
+3
+> levenshtein('GUMBO', 'GAMBOL')
+2
+> levenshtein('GUMBO', 'GAMBOL', 2, 1, 1)
+3
+> levenshtein('', 'abc')
+3
+> levenshtein('héllo', 'hello')
+1
+> levenshtein('abc', 'abc')
+0
+> levenshtein(NULL, 'abc')
+NULL
+! levenshtein('a', 'b', 1)
+'takes 2 or 5 arguments'
+! levenshtein('a', 'b', 1, -1, 1)
+'must not be negative'
+
+-- test: similarity
+> similarity('word', 'two words')
+0.36363636363636365
+> similarity('hello', 'hello')
+1.0
+> similarity('Hello', 'hello!')
+1.0
+> similarity('abc', 'xyz')
+0.0
+> similarity('', 'abc')
+0.0
+> similarity(NULL, 'abc')
+NULL
+
+-- test: soundex
+> soundex('Robert')
+'R163'
+> soundex('Rupert')
+'R163'
+> soundex('Tymczak')
+'T522'
+> soundex('Pfister')
+'P236'
+> soundex('Ashcraft')
+'A261'
+> soundex('Anne')
+'A500'
+> soundex('  ann')
+'A500'
+> soundex('')
+''
+> soundex(NULL)
+NULL
+
+-- test: difference
+> difference('Anne', 'Ann')
+4
+> difference('Anne', 'Andrew')
+2
+> difference('Anne', 'Margaret')
+0
+> difference(NULL, 'Ann')
+NULL
@@ -0,0 +1,608 @@
+package functions
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chaisql/chai/internal/environment"
+	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// The date and time functions work on the fields of TIMESTAMP and TIMESTAMPTZ values.
+// The fields of TIMESTAMP values are read in UTC, and the fields of TIMESTAMPTZ
+// values in the time zone of the transaction, see SET TIME ZONE.
+// Texts are converted to TIMESTAMP.
+
+// timeArg returns the time of a TIMESTAMP or TIMESTAMPTZ argument of a function,
+// in the location its fields are read from.
+func timeArg(fn string, v types.Value, loc *time.Location) (time.Time, error) {
+	switch v.Type() {
+	case types.TypeTimestamp:
+		return types.AsTime(v).UTC(), nil
+	case types.TypeTimestampTZ:
+		if loc == nil {
+			loc = time.UTC
+		}
+		return types.AsTime(v).In(loc), nil
+	case types.TypeText:
+		ts, err := v.CastAs(types.TypeTimestamp)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return types.AsTime(ts), nil
+	}
+
+	return time.Time{}, fmt.Errorf("%s expects a timestamp, got %s", fn, v.Type())
+}
+
+// timeValue returns t as a value of the type of the argument it was computed from.
+func timeValue(arg types.Value, t time.Time) types.Value {
+	if arg.Type() == types.TypeTimestampTZ {
+		return types.NewTimestampTZValue(t)
+	}
+
+	return types.NewTimestampValue(t)
+}
+
+// fieldArg returns the name of the field of a timestamp, in lower case.
+func fieldArg(fn string, v types.Value) (string, error) {
+	if !v.Type().IsText() {
+		return "", fmt.Errorf("%s expects the field to be a text, got %s", fn, v.Type())
+	}
+
+	return strings.ToLower(types.AsString(v)), nil
+}
+
+// intArg returns the value of an integer argument of a function.
+func intArg(fn string, v types.Value) (int, error) {
+	switch v.Type() {
+	case types.TypeInteger, types.TypeBigint:
+		x := types.AsInt64(v)
+		if x < math.MinInt32 || x > math.MaxInt32 {
+			return 0, fmt.Errorf("%s: %d is out of range", fn, x)
+		}
+		return int(x), nil
+	}
+
+	return 0, fmt.Errorf("%s expects integers, got %s", fn, v.Type())
+}
+
+func anyNull(args []types.Value) bool {
+	for _, a := range args {
+		if a.Type() == types.TypeNull {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CurrentDate is the CURRENT_DATE function.
+// It returns the date at which the transaction started, in the time zone
+// of the transaction, as a TIMESTAMP at midnight.
+type CurrentDate struct{}
+
+func (c *CurrentDate) Clone() expr.Expr {
+	return &CurrentDate{}
+}
+
+func (c *CurrentDate) Eval(env *environment.Environment) (types.Value, error) {
+	tx := env.GetTx()
+	if tx == nil {
+		return nil, errors.New("misuse of CURRENT_DATE")
+	}
+
+	return types.NewTimestampValue(startOfDay(txStart(tx.TxStart, tx.TimeZone))), nil
+}
+
+func (c *CurrentDate) IsEqual(other expr.Expr) bool {
+	if other == nil {
+		return false
+	}
+
+	_, ok := other.(*CurrentDate)
+	return ok
+}
+
+func (c *CurrentDate) Params() []expr.Expr { return nil }
+
+func (c *CurrentDate) String() string {
+	return "CURRENT_DATE"
+}
+
+// txStart returns the start of the transaction, in the time zone of the
+// transaction, with its fields in UTC.
+func txStart(start time.Time, loc *time.Location) time.Time {
+	if loc == nil {
+		loc = time.UTC
+	}
+	t := start.In(loc)
+
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// Age is the AGE function.
+// AGE(a, b) returns the difference between two timestamps, in years, months,
+// days and time, using the format of the intervals of PostgreSQL.
+// AGE(b) returns the difference between CURRENT_DATE and b.
+type Age struct {
+	Exprs []expr.Expr
+}
+
+func (a *Age) Clone() expr.Expr {
+	exprs := make([]expr.Expr, 0, len(a.Exprs))
+	for _, e := range a.Exprs {
+		exprs = append(exprs, expr.Clone(e))
+	}
+
+	return &Age{Exprs: exprs}
+}
+
+func (a *Age) Eval(env *environment.Environment) (types.Value, error) {
+	args := make([]types.Value, 0, 2)
+	if len(a.Exprs) == 1 {
+		tx := env.GetTx()
+		if tx == nil {
+			return nil, errors.New("misuse of AGE()")
+		}
+		args = append(args, types.NewTimestampValue(startOfDay(txStart(tx.TxStart, tx.TimeZone))))
+	}
+	for _, e := range a.Exprs {
+		v, err := e.Eval(env)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, v)
+	}
+	if anyNull(args) {
+		return types.NewNullValue(), nil
+	}
+
+	loc := env.GetTimeZone()
+	t1, err := timeArg("age(arg1, arg2)", args[0], loc)
+	if err != nil {
+		return nil, err
+	}
+	t2, err := timeArg("age(arg1, arg2)", args[1], loc)
+	if err != nil {
+		return nil, err
+	}
+
+	return types.NewTextValue(age(t1, t2)), nil
+}
+
+// age returns the difference between the fields of t1 and t2,
+// borrowing from the days of the month of the earliest one.
+func age(t1, t2 time.Time) string {
+	// compare the fields, not the instants
+	w1 := time.Date(t1.Year(), t1.Month(), t1.Day(), t1.Hour(), t1.Minute(), t1.Second(), t1.Nanosecond(), time.UTC)
+	w2 := time.Date(t2.Year(), t2.Month(), t2.Day(), t2.Hour(), t2.Minute(), t2.Second(), t2.Nanosecond(), time.UTC)
+	sign := 1
+	if w1.Before(w2) {
+		w1, w2 = w2, w1
+		sign = -1
+	}
+
+	us := (w1.Nanosecond() - w2.Nanosecond()) / 1000
+	sec := w1.Second() - w2.Second()
+	mi := w1.Minute() - w2.Minute()
+	hour := w1.Hour() - w2.Hour()
+	day := w1.Day() - w2.Day()
+	mon := int(w1.Month()) - int(w2.Month())
+	year := w1.Year() - w2.Year()
+
+	if us < 0 {
+		us += 1e6
+		sec--
+	}
+	if sec < 0 {
+		sec += 60
+		mi--
+	}
+	if mi < 0 {
+		mi += 60
+		hour--
+	}
+	if hour < 0 {
+		hour += 24
+		day--
+	}
+	if day < 0 {
+		day += types.DaysIn(w2.Year(), w2.Month())
+		mon--
+	}
+	if mon < 0 {
+		mon += 12
+		year--
+	}
+
+	var parts []string
+	for _, p := range []struct {
+		n    int
+		unit string
+	}{{year, "year"}, {mon, "mon"}, {day, "day"}} {
+		if p.n == 0 {
+			continue
+		}
+		unit := p.unit
+		if p.n != 1 {
+			unit += "s"
+		}
+		parts = append(parts, fmt.Sprintf("%d %s", sign*p.n, unit))
+	}
+
+	if hour != 0 || mi != 0 || sec != 0 || us != 0 || len(parts) == 0 {
+		s := fmt.Sprintf("%02d:%02d:%02d", hour, mi, sec)
+		if us != 0 {
+			s += strings.TrimRight(fmt.Sprintf(".%06d", us), "0")
+		}
+		if sign < 0 && s != "00:00:00" {
+			s = "-" + s
+		}
+		parts = append(parts, s)
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// parseInterval parses an interval in the format of PostgreSQL,
+// see types.ParseInterval.
+func parseInterval(fn string, s string) (types.Interval, error) {
+	iv, err := types.ParseInterval(s)
+	if err != nil {
+		return iv, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return iv, nil
+}
+
+func (a *Age) IsEqual(other expr.Expr) bool {
+	if other == nil {
+		return false
+	}
+
+	o, ok := other.(*Age)
+	if !ok || len(a.Exprs) != len(o.Exprs) {
+		return false
+	}
+
+	for i := range a.Exprs {
+		if !expr.Equal(a.Exprs[i], o.Exprs[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (a *Age) Params() []expr.Expr { return a.Exprs }
+
+func (a *Age) String() string {
+	args := make([]string, 0, len(a.Exprs))
+	for _, e := range a.Exprs {
+		args = append(args, e.String())
+	}
+
+	return fmt.Sprintf("AGE(%s)", strings.Join(args, ", "))
+}
+
+// dateTrunc returns a timestamp truncated to the given precision:
+// microseconds, milliseconds, second, minute, hour, day, week (starting on Monday),
+// month, quarter, year, decade, century or millennium.
+var dateTrunc = &ScalarDefinition{
+	name:  "date_trunc",
+	arity: 2,
+	zonedCallFn: func(loc *time.Location, args ...types.Value) (types.Value, error) {
+		const fn = "date_trunc(field, timestamp)"
+		if anyNull(args) {
+			return types.NewNullValue(), nil
+		}
+		field, err := fieldArg(fn, args[0])
+		if err != nil {
+			return nil, err
+		}
+		t, err := timeArg(fn, args[1], loc)
+		if err != nil {
+			return nil, err
+		}
+
+		y, m, d := t.Date()
+		l := t.Location()
+		switch field {
+		case "microseconds":
+			t = t.Truncate(time.Microsecond)
+		case "milliseconds":
+			t = time.Date(y, m, d, t.Hour(), t.Minute(), t.Second(), t.Nanosecond()/1e6*1e6, l)
+		case "second":
+			t = time.Date(y, m, d, t.Hour(), t.Minute(), t.Second(), 0, l)
+		case "minute":
+			t = time.Date(y, m, d, t.Hour(), t.Minute(), 0, 0, l)
+		case "hour":
+			t = time.Date(y, m, d, t.Hour(), 0, 0, 0, l)
+		case "day":
+			t = time.Date(y, m, d, 0, 0, 0, 0, l)
+		case "week":
+			// weeks start on Monday
+			t = time.Date(y, m, d-(int(t.Weekday())+6)%7, 0, 0, 0, 0, l)
+		case "month":
+			t = time.Date(y, m, 1, 0, 0, 0, 0, l)
+		case "quarter":
+			t = time.Date(y, m-(m-1)%3, 1, 0, 0, 0, 0, l)
+		case "year":
+			t = time.Date(y, 1, 1, 0, 0, 0, 0, l)
+		case "decade":
+			t = time.Date(types.FloorDiv(y, 10)*10, 1, 1, 0, 0, 0, 0, l)
+		case "century":
+			t = time.Date(types.FloorDiv(y-1, 100)*100+1, 1, 1, 0, 0, 0, 0, l)
+		case "millennium":
+			t = time.Date(types.FloorDiv(y-1, 1000)*1000+1, 1, 1, 0, 0, 0, 0, l)
+		default:
+			return nil, fmt.Errorf("%s: unsupported field %q", fn, field)
+		}
+
+		return timeValue(args[1], t), nil
+	},
+}
+
+// extract returns a field of a timestamp, as an INTEGER, or as a DOUBLE
+// for the fields with a fractional part: second, milliseconds and epoch.
+// The parser also accepts the standard form EXTRACT(field FROM timestamp).
+var extract = &ScalarDefinition{
+	name:  "extract",
+	arity: 2,
+	zonedCallFn: func(loc *time.Location, args ...types.Value) (types.Value, error) {
+		const fn = "extract(field, timestamp)"
+		if anyNull(args) {
+			return types.NewNullValue(), nil
+		}
+		field, err := fieldArg(fn, args[0])
+		if err != nil {
+			return nil, err
+		}
+		t, err := timeArg(fn, args[1], loc)
+		if err != nil {
+			return nil, err
+		}
+
+		sec := float64(t.Second()) + float64(t.Nanosecond()/1000)/1e6
+		var n int
+		switch field {
+		case "microseconds":
+			n = t.Second()*1e6 + t.Nanosecond()/1000
+		case "milliseconds":
+			return types.NewDoubleValue(sec * 1000), nil
+		case "second":
+			return types.NewDoubleValue(sec), nil
+		case "epoch":
+			return types.NewDoubleValue(float64(t.UnixMicro()) / 1e6), nil
+		case "minute":
+			n = t.Minute()
+		case "hour":
+			n = t.Hour()
+		case "day":
+			n = t.Day()
+		case "dow":
+			// Sunday is 0
+			n = int(t.Weekday())
+		case "isodow":
+			// Sunday is 7
+			n = (int(t.Weekday())+6)%7 + 1
+		case "doy":
+			n = t.YearDay()
+		case "week":
+			_, n = t.ISOWeek()
+		case "month":
+			n = int(t.Month())
+		case "quarter":
+			n = (int(t.Month())-1)/3 + 1
+		case "year":
+			n = t.Year()
+		case "isoyear":
+			n, _ = t.ISOWeek()
+		case "decade":
+			n = types.FloorDiv(t.Year(), 10)
+		case "century":
+			n = types.FloorDiv(t.Year()-1, 100) + 1
+		case "millennium":
+			n = types.FloorDiv(t.Year()-1, 1000) + 1
+		case "timezone":
+			// offset from UTC, in seconds
+			_, n = t.Zone()
+		default:
+			return nil, fmt.Errorf("%s: unsupported field %q", fn, field)
+		}
+
+		return types.NewIntegerValue(int32(n)), nil
+	},
+}
+
+// makeDate returns the TIMESTAMP at midnight of the given year, month and day.
+var makeDate = &ScalarDefinition{
+	name:  "make_date",
+	arity: 3,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		return makeTimestampOf("make_date(year, month, day)", args)
+	},
+}
+
+// makeTimestamp returns the TIMESTAMP of the given year, month, day,
+// hour, minute and second. The seconds may have a fractional part.
+var makeTimestamp = &ScalarDefinition{
+	name:  "make_timestamp",
+	arity: 6,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		return makeTimestampOf("make_timestamp(year, month, day, hour, min, sec)", args)
+	},
+}
+
+// makeTimestampOf returns the TIMESTAMP made of the fields of the arguments,
+// in that order: year, month, day, hour, minute and second.
+// The missing fields are zero.
+func makeTimestampOf(fn string, args []types.Value) (types.Value, error) {
+	if anyNull(args) {
+		return types.NewNullValue(), nil
+	}
+
+	var fields [5]int
+	for i, a := range args[:min(len(args), 5)] {
+		x, err := intArg(fn, a)
+		if err != nil {
+			return nil, err
+		}
+		fields[i] = x
+	}
+
+	var us int
+	if len(args) > 5 {
+		if !args[5].Type().IsNumber() {
+			return nil, fmt.Errorf("%s expects sec to be a number, got %s", fn, args[5].Type())
+		}
+		v, err := args[5].CastAs(types.TypeDouble)
+		if err != nil {
+			return nil, err
+		}
+		sec := types.AsFloat64(v)
+		if sec < 0 || sec >= 60 || math.IsNaN(sec) {
+			return nil, fmt.Errorf("%s: seconds field value out of range: %v", fn, sec)
+		}
+		us = int(math.Round(sec * 1e6))
+	}
+
+	year, month, day, hour, minute := fields[0], fields[1], fields[2], fields[3], fields[4]
+	t := time.Date(year, time.Month(month), day, hour, minute, 0, us*1000, time.UTC)
+	if t.Year() != year || int(t.Month()) != month || t.Day() != day || t.Hour() != hour || t.Minute() != minute {
+		return nil, fmt.Errorf("%s: date field value out of range", fn)
+	}
+
+	return types.NewTimestampValue(t), nil
+}
+
+// strftime formats a timestamp using the directives of the C function strftime:
+//
+//	%a, %A   abbreviated and full weekday name
+//	%b, %B   abbreviated and full month name
+//	%d, %e   day of the month, 01-31 and 1-31
+//	%f       seconds with milliseconds, SS.SSS
+//	%F       %Y-%m-%d
+//	%H, %I   hour, 00-23 and 01-12
+//	%j       day of the year, 001-366
+//	%m       month, 01-12
+//	%M       minute, 00-59
+//	%p       AM or PM
+//	%s       seconds since 1970-01-01
+//	%S       seconds, 00-59
+//	%T       %H:%M:%S
+//	%u, %w   day of the week, 1-7 starting on Monday and 0-6 starting on Sunday
+//	%V, %G   ISO 8601 week number and year
+//	%y, %Y   year, 00-99 and with the century
+//	%z, %Z   time zone offset, +hhmm, and name
+//	%%       a literal %
+var strftime = &ScalarDefinition{
+	name:  "strftime",
+	arity: 2,
+	zonedCallFn: func(loc *time.Location, args ...types.Value) (types.Value, error) {
+		const fn = "strftime(format, timestamp)"
+		if anyNull(args) {
+			return types.NewNullValue(), nil
+		}
+		if !args[0].Type().IsText() {
+			return nil, fmt.Errorf("%s expects the format to be a text, got %s", fn, args[0].Type())
+		}
+		t, err := timeArg(fn, args[1], loc)
+		if err != nil {
+			return nil, err
+		}
+
+		s, err := formatTime(types.AsString(args[0]), t)
+		if err != nil {
+			return nil, errors.Wrap(err, fn)
+		}
+		return types.NewTextValue(s), nil
+	},
+}
+
+func formatTime(format string, t time.Time) (string, error) {
+	var sb strings.Builder
+
+	for i := 0; i < len(format); i++ {
+		c := format[i]
+		if c != '%' {
+			sb.WriteByte(c)
+			continue
+		}
+		i++
+		if i == len(format) {
+			return "", errors.New("incomplete directive at the end of the format")
+		}
+
+		switch format[i] {
+		case 'a':
+			sb.WriteString(t.Format("Mon"))
+		case 'A':
+			sb.WriteString(t.Format("Monday"))
+		case 'b':
+			sb.WriteString(t.Format("Jan"))
+		case 'B':
+			sb.WriteString(t.Format("January"))
+		case 'd':
+			fmt.Fprintf(&sb, "%02d", t.Day())
+		case 'e':
+			sb.WriteString(strconv.Itoa(t.Day()))
+		case 'f':
+			fmt.Fprintf(&sb, "%02d.%03d", t.Second(), t.Nanosecond()/1e6)
+		case 'F':
+			sb.WriteString(t.Format("2006-01-02"))
+		case 'G':
+			y, _ := t.ISOWeek()
+			fmt.Fprintf(&sb, "%04d", y)
+		case 'H':
+			fmt.Fprintf(&sb, "%02d", t.Hour())
+		case 'I':
+			sb.WriteString(t.Format("03"))
+		case 'j':
+			fmt.Fprintf(&sb, "%03d", t.YearDay())
+		case 'm':
+			fmt.Fprintf(&sb, "%02d", int(t.Month()))
+		case 'M':
+			fmt.Fprintf(&sb, "%02d", t.Minute())
+		case 'p':
+			sb.WriteString(t.Format("PM"))
+		case 's':
+			sb.WriteString(strconv.FormatInt(t.Unix(), 10))
+		case 'S':
+			fmt.Fprintf(&sb, "%02d", t.Second())
+		case 'T':
+			sb.WriteString(t.Format("15:04:05"))
+		case 'u':
+			sb.WriteString(strconv.Itoa((int(t.Weekday())+6)%7 + 1))
+		case 'V':
+			_, w := t.ISOWeek()
+			fmt.Fprintf(&sb, "%02d", w)
+		case 'w':
+			sb.WriteString(strconv.Itoa(int(t.Weekday())))
+		case 'y':
+			fmt.Fprintf(&sb, "%02d", t.Year()%100)
+		case 'Y':
+			fmt.Fprintf(&sb, "%04d", t.Year())
+		case 'z':
+			sb.WriteString(t.Format("-0700"))
+		case 'Z':
+			sb.WriteString(t.Format("MST"))
+		case '%':
+			sb.WriteByte('%')
+		default:
+			return "", errors.Errorf("unsupported directive %%%c", format[i])
+		}
+	}
+
+	return sb.String(), nil
+}
@@ -0,0 +1,37 @@
+package functions
+
+import (
+	"time"
+
+	"github.com/chaisql/chai/internal/types"
+)
+
+// genUUIDv4 returns a random UUID.
+var genUUIDv4 = &ScalarDefinition{
+	name:     "gen_uuid_v4",
+	arity:    0,
+	volatile: true,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		x, err := types.NewUUIDv4()
+		if err != nil {
+			return nil, err
+		}
+		return types.NewUUIDValue(x), nil
+	},
+}
+
+// genUUIDv7 returns a UUID starting with the current time.
+// The UUIDs it returns are increasing, which makes them
+// suitable for primary keys: new rows are appended at the end of the table.
+var genUUIDv7 = &ScalarDefinition{
+	name:     "gen_uuid_v7",
+	arity:    0,
+	volatile: true,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		x, err := types.NewUUIDv7(time.Now())
+		if err != nil {
+			return nil, err
+		}
+		return types.NewUUIDValue(x), nil
+	},
+}
@@ -30,11 +30,17 @@ func (op *LikeOperator) Clone() Expr {
 
 func (op *LikeOperator) Eval(env *environment.Environment) (types.Value, error) {
 	return op.simpleOperator.eval(env, func(a, b types.Value) (types.Value, error) {
-		if a.Type() != types.TypeText || b.Type() != types.TypeText {
+		if !a.Type().IsText() || !b.Type().IsText() {
 			return NullLiteral, nil
 		}
 
-		if like(types.AsString(b), types.AsString(a)) {
+		pattern, text := types.AsString(b), types.AsString(a)
+		// CITEXT values are matched without regard to case
+		if a.Type() == types.TypeCitext || b.Type() == types.TypeCitext {
+			pattern, text = types.FoldCase(pattern), types.FoldCase(text)
+		}
+
+		if like(pattern, text) {
 			return TrueLiteral, nil
 		}
 
@@ -24,25 +24,23 @@ func (op *AndOp) Clone() Expr {
 	}
 }
 
-// Eval implements the Expr interface. It evaluates a and b and returns true if both evaluate
-// to true.
+// Eval implements the Expr interface. It follows three-valued logic:
+// it returns false if a or b evaluates to false, NULL if one of them is NULL
+// and the other is not false, and true otherwise.
+// b is not evaluated if a is false.
 func (op *AndOp) Eval(env *environment.Environment) (types.Value, error) {
-	s, err := op.a.Eval(env)
-	if err != nil {
-		return FalseLiteral, err
-	}
-	isTruthy, err := types.IsTruthy(s)
-	if !isTruthy || err != nil {
+	a, err := evalTruth(env, op.a)
+	if err != nil || a == FalseLiteral {
 		return FalseLiteral, err
 	}
 
-	s, err = op.b.Eval(env)
-	if err != nil {
+	b, err := evalTruth(env, op.b)
+	if err != nil || b == FalseLiteral {
 		return FalseLiteral, err
 	}
-	isTruthy, err = types.IsTruthy(s)
-	if !isTruthy || err != nil {
-		return FalseLiteral, err
+
+	if a == NullLiteral || b == NullLiteral {
+		return NullLiteral, nil
 	}
 
 	return TrueLiteral, nil
@@ -64,33 +62,31 @@ func (op *OrOp) Clone() Expr {
 	}
 }
 
-// Eval implements the Expr interface. It evaluates a and b and returns true if a or b evalutate
-// to true.
+// Eval implements the Expr interface. It follows three-valued logic:
+// it returns true if a or b evaluates to true, NULL if one of them is NULL
+// and the other is not true, and false otherwise.
+// b is not evaluated if a is true.
 func (op *OrOp) Eval(env *environment.Environment) (types.Value, error) {
-	s, err := op.a.Eval(env)
+	a, err := evalTruth(env, op.a)
 	if err != nil {
 		return FalseLiteral, err
 	}
-	isTruthy, err := types.IsTruthy(s)
-	if err != nil {
-		return FalseLiteral, err
-	}
-	if isTruthy {
+	if a == TrueLiteral {
 		return TrueLiteral, nil
 	}
 
-	s, err = op.b.Eval(env)
-	if err != nil {
-		return FalseLiteral, err
-	}
-	isTruthy, err = types.IsTruthy(s)
+	b, err := evalTruth(env, op.b)
 	if err != nil {
 		return FalseLiteral, err
 	}
-	if isTruthy {
+	if b == TrueLiteral {
 		return TrueLiteral, nil
 	}
 
+	if a == NullLiteral || b == NullLiteral {
+		return NullLiteral, nil
+	}
+
 	return FalseLiteral, nil
 }
 
@@ -110,22 +106,44 @@ func (op *NotOp) Clone() Expr {
 	}
 }
 
-// Eval implements the Expr interface. It evaluates e and returns true if b is falsy
+// Eval implements the Expr interface. It evaluates e and returns true if it is falsy,
+// false if it is truthy and NULL if it is NULL.
 func (op *NotOp) Eval(env *environment.Environment) (types.Value, error) {
-	s, err := op.a.Eval(env)
+	v, err := evalTruth(env, op.a)
 	if err != nil {
 		return FalseLiteral, err
 	}
 
-	isTruthy, err := types.IsTruthy(s)
+	switch v {
+	case TrueLiteral:
+		return FalseLiteral, nil
+	case FalseLiteral:
+		return TrueLiteral, nil
+	}
+
+	return NullLiteral, nil
+}
+
+// evalTruth evaluates e and returns its truth value in three-valued logic:
+// NULL if it is NULL, and whether it is truthy otherwise.
+func evalTruth(env *environment.Environment, e Expr) (types.Value, error) {
+	v, err := e.Eval(env)
 	if err != nil {
-		return FalseLiteral, err
+		return nil, err
 	}
-	if isTruthy {
-		return FalseLiteral, nil
+	if v.Type() == types.TypeNull {
+		return NullLiteral, nil
 	}
 
-	return TrueLiteral, nil
+	ok, err := types.IsTruthy(v)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return TrueLiteral, nil
+	}
+
+	return FalseLiteral, nil
 }
 
 // String implements the fmt.Stringer interface.
@@ -0,0 +1,45 @@
+package expr_test
+
+import (
+	"testing"
+
+	"github.com/chaisql/chai/internal/testutil"
+	"github.com/chaisql/chai/internal/types"
+)
+
+func TestLogicalExpr(t *testing.T) {
+	tests := []struct {
+		expr  string
+		res   types.Value
+		fails bool
+	}{
+		{"true AND true", types.NewBooleanValue(true), false},
+		{"true AND false", types.NewBooleanValue(false), false},
+		{"true AND NULL", nullLiteral, false},
+		{"NULL AND true", nullLiteral, false},
+		{"false AND NULL", types.NewBooleanValue(false), false},
+		{"NULL AND false", types.NewBooleanValue(false), false},
+		{"NULL AND NULL", nullLiteral, false},
+		{"false AND notFound", types.NewBooleanValue(false), false},
+		{"true AND notFound", nullLiteral, true},
+		{"true OR false", types.NewBooleanValue(true), false},
+		{"false OR false", types.NewBooleanValue(false), false},
+		{"true OR NULL", types.NewBooleanValue(true), false},
+		{"NULL OR true", types.NewBooleanValue(true), false},
+		{"false OR NULL", nullLiteral, false},
+		{"NULL OR false", nullLiteral, false},
+		{"NULL OR NULL", nullLiteral, false},
+		{"true OR notFound", types.NewBooleanValue(true), false},
+		{"NOT true", types.NewBooleanValue(false), false},
+		{"NOT 0", types.NewBooleanValue(true), false},
+		{"NOT NULL", nullLiteral, false},
+		{"NOT (a = NULL)", nullLiteral, false},
+		{"NOT (a > 0 AND NULL)", nullLiteral, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			testutil.TestExpr(t, test.expr, envWithRow, test.res, test.fails)
+		})
+	}
+}
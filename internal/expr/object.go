@@ -0,0 +1,218 @@
+package expr
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/chaisql/chai/internal/environment"
+	"github.com/chaisql/chai/internal/stringutil"
+	"github.com/chaisql/chai/internal/types"
+)
+
+// ObjectLiteralField is a field of an object literal.
+type ObjectLiteralField struct {
+	Name string
+	Expr Expr
+}
+
+// ObjectLiteral is an expression that evaluates to an object,
+// such as {a: 1, "b": c + 1}.
+type ObjectLiteral struct {
+	Fields []ObjectLiteralField
+}
+
+// Eval evaluates the value of each field and returns an object.
+func (o *ObjectLiteral) Eval(env *environment.Environment) (types.Value, error) {
+	fields := make([]types.ObjectField, len(o.Fields))
+	for i, f := range o.Fields {
+		v, err := f.Expr.Eval(env)
+		if err != nil {
+			return nil, err
+		}
+
+		fields[i] = types.ObjectField{Name: f.Name, Value: v}
+	}
+
+	return types.MakeObject(fields...)
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (o *ObjectLiteral) IsEqual(other Expr) bool {
+	oo, ok := other.(*ObjectLiteral)
+	if !ok || len(o.Fields) != len(oo.Fields) {
+		return false
+	}
+
+	for i := range o.Fields {
+		if o.Fields[i].Name != oo.Fields[i].Name || !Equal(o.Fields[i].Expr, oo.Fields[i].Expr) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (o *ObjectLiteral) Clone() Expr {
+	fields := make([]ObjectLiteralField, len(o.Fields))
+	for i, f := range o.Fields {
+		fields[i] = ObjectLiteralField{Name: f.Name, Expr: Clone(f.Expr)}
+	}
+
+	return &ObjectLiteral{Fields: fields}
+}
+
+func (o *ObjectLiteral) String() string {
+	var b strings.Builder
+
+	b.WriteRune('{')
+	for i, f := range o.Fields {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(strconv.Quote(f.Name))
+		b.WriteString(": ")
+		b.WriteString(f.Expr.String())
+	}
+	b.WriteRune('}')
+
+	return b.String()
+}
+
+// ArrayLiteral is an expression that evaluates to an array, such as [1, a, 'b'].
+type ArrayLiteral []Expr
+
+// Eval evaluates each element and returns an array.
+func (a ArrayLiteral) Eval(env *environment.Environment) (types.Value, error) {
+	values := make([]types.Value, len(a))
+	for i, e := range a {
+		v, err := e.Eval(env)
+		if err != nil {
+			return nil, err
+		}
+
+		values[i] = v
+	}
+
+	return types.MakeArray(values...)
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (a ArrayLiteral) IsEqual(other Expr) bool {
+	o, ok := other.(ArrayLiteral)
+	if !ok {
+		return false
+	}
+
+	return LiteralExprList(a).IsEqual(LiteralExprList(o))
+}
+
+func (a ArrayLiteral) Clone() Expr {
+	exprs := make(ArrayLiteral, len(a))
+	for i, e := range a {
+		exprs[i] = Clone(e)
+	}
+	return exprs
+}
+
+func (a ArrayLiteral) String() string {
+	var b strings.Builder
+
+	b.WriteRune('[')
+	for i, e := range a {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(e.String())
+	}
+	b.WriteRune(']')
+
+	return b.String()
+}
+
+// A Path selects a value nested in an object, an array or a JSONB document,
+// such as a.b[0].c. It evaluates to NULL if the value doesn't exist.
+type Path struct {
+	Expr      Expr
+	Fragments types.JSONPath
+}
+
+// Eval evaluates the expression and returns the value selected by the path.
+func (p *Path) Eval(env *environment.Environment) (types.Value, error) {
+	v, err := p.Expr.Eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, f := range p.Fragments {
+		var ok bool
+
+		switch x := v.(type) {
+		case types.ObjectValue:
+			if !f.IsIndex {
+				v, ok = x.Get(f.Field)
+			}
+		case types.ArrayValue:
+			if f.IsIndex {
+				v, ok = x.Get(f.Index)
+			}
+		case types.JSONValue:
+			// the rest of the path is extracted from the document
+			doc, ok := p.Fragments[i:].Extract(x)
+			if !ok {
+				return NullLiteral, nil
+			}
+			return types.JSONToValue(doc), nil
+		}
+
+		if !ok {
+			return NullLiteral, nil
+		}
+	}
+
+	return v, nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (p *Path) IsEqual(other Expr) bool {
+	o, ok := other.(*Path)
+	if !ok || len(p.Fragments) != len(o.Fragments) {
+		return false
+	}
+
+	for i := range p.Fragments {
+		if p.Fragments[i] != o.Fragments[i] {
+			return false
+		}
+	}
+
+	return Equal(p.Expr, o.Expr)
+}
+
+func (p *Path) Clone() Expr {
+	return &Path{
+		Expr:      Clone(p.Expr),
+		Fragments: p.Fragments,
+	}
+}
+
+func (p *Path) String() string {
+	var b strings.Builder
+
+	b.WriteString(p.Expr.String())
+	for _, f := range p.Fragments {
+		if f.IsIndex {
+			b.WriteRune('[')
+			b.WriteString(strconv.Itoa(f.Index))
+			b.WriteRune(']')
+			continue
+		}
+
+		b.WriteRune('.')
+		b.WriteString(stringutil.NormalizeIdentifier(f.Field, '`'))
+	}
+
+	return b.String()
+}
@@ -102,7 +102,8 @@ type ConcatOperator struct {
 	*simpleOperator
 }
 
-// Concat creates an expression that concatenates two text values together.
+// Concat creates an expression that concatenates two text values together,
+// case-sensitive or not, and returns a TEXT value.
 // It returns null if one of the values is not a text.
 func Concat(a, b Expr) Expr {
 	return &ConcatOperator{&simpleOperator{a, b, scanner.CONCAT}}
@@ -110,7 +111,7 @@ func Concat(a, b Expr) Expr {
 
 func (op *ConcatOperator) Eval(env *environment.Environment) (types.Value, error) {
 	return op.simpleOperator.eval(env, func(a, b types.Value) (types.Value, error) {
-		if a.Type() != types.TypeText || b.Type() != types.TypeText {
+		if !a.Type().IsText() || !b.Type().IsText() {
 			return NullLiteral, nil
 		}
 
@@ -131,7 +132,7 @@ func (c *Cast) Eval(env *environment.Environment) (types.Value, error) {
 		return v, err
 	}
 
-	return v.CastAs(c.CastAs)
+	return types.CastIn(v, c.CastAs, env.GetTimeZone())
 }
 
 // IsEqual compares this expression with the other expression and returns
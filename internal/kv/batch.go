@@ -6,20 +6,37 @@ import (
 	"github.com/cockroachdb/pebble"
 )
 
-var _ engine.Session = (*BatchSession)(nil)
+var _ engine.MergeSession = (*BatchSession)(nil)
 
 var (
-	tombStone = []byte{0}
+	// tombStone marks the keys that didn't exist in the rollback segment.
+	// It is empty to never be mistaken with a value, which can't be empty.
+	tombStone = []byte{}
 )
 
 type BatchSession struct {
-	Store           *PebbleEngine
-	DB              *pebble.DB
-	Batch           *pebble.Batch
-	closed          bool
+	Store        *PebbleEngine
+	closed       bool
+	maxBatchSize int
+
+	// writes to the main database.
+	main *batchTarget
+	// writes to the other locations, created on the first write to each of them.
+	locations map[*location]*batchTarget
+}
+
+// batchTarget buffers the writes of a batch session
+// to one of the databases of the engine.
+type batchTarget struct {
+	db              *pebble.DB
+	batch           *pebble.Batch
 	rollbackSegment *RollbackSegment
-	maxBatchSize    int
 	keys            map[string]struct{}
+
+	// location written to, nil for the main database.
+	loc *location
+	// token identifying the transaction in the location.
+	token uint64
 }
 
 func (s *PebbleEngine) NewBatchSession() engine.Session {
@@ -27,35 +44,98 @@ func (s *PebbleEngine) NewBatchSession() engine.Session {
 	// at this point-in-time.
 	s.LockSharedSnapshot()
 
-	b := s.db.NewBatch()
-
 	return &BatchSession{
-		Store:           s,
-		DB:              s.db,
-		Batch:           b,
-		rollbackSegment: s.rollbackSegment,
-		maxBatchSize:    s.opts.MaxBatchSize,
-		keys:            make(map[string]struct{}),
+		Store: s,
+		main: &batchTarget{
+			db:              s.db,
+			batch:           s.db.NewBatch(),
+			rollbackSegment: s.rollbackSegment,
+			keys:            make(map[string]struct{}),
+		},
+		maxBatchSize: s.opts.MaxBatchSize,
 	}
 }
 
+// target returns the batch of the database storing the key,
+// creating it if it doesn't exist yet.
+func (s *BatchSession) target(k []byte) *batchTarget {
+	loc := s.Store.locationOf(k)
+	if loc == nil {
+		return s.main
+	}
+
+	t, ok := s.locations[loc]
+	if !ok {
+		if s.locations == nil {
+			s.locations = make(map[*location]*batchTarget)
+		}
+
+		t = &batchTarget{
+			db:              loc.db,
+			batch:           loc.newBatch(),
+			rollbackSegment: loc.rollbackSegment,
+			keys:            make(map[string]struct{}),
+			loc:             loc,
+			token:           loc.nextToken(),
+		}
+		s.locations[loc] = t
+	}
+
+	return t
+}
+
+// reader returns the database storing the key, and its batch
+// if the session wrote to that database.
+func (s *BatchSession) reader(k []byte) (*pebble.DB, *batchTarget) {
+	loc := s.Store.locationOf(k)
+	if loc == nil {
+		return s.main.db, s.main
+	}
+
+	return loc.db, s.locations[loc]
+}
+
 func (s *BatchSession) Commit() error {
 	if s.closed {
 		return errors.New("already closed")
 	}
 
+	// the changes of the other locations are committed first,
+	// and become permanent once the main batch is committed.
+	for _, t := range s.locations {
+		err := t.prepare()
+		if err != nil {
+			return err
+		}
+
+		err = t.loc.markCommitted(s.main.batch, t.token)
+		if err != nil {
+			return err
+		}
+	}
+
 	// We are about to commit the batch, we can empty
 	// the rollback segment.
-	err := s.rollbackSegment.Clear(s.Batch)
+	err := s.main.rollbackSegment.Clear(s.main.batch)
 	if err != nil {
 		return err
 	}
 
-	err = s.Batch.Commit(nil)
+	err = s.Store.logCommit(s.main.batch)
 	if err != nil {
 		return err
 	}
 
+	err = s.Store.crash.commit(CrashPointCommit, s.main.batch, s.Store.writeOptions())
+	if err != nil {
+		return err
+	}
+	s.main.rollbackSegment.Committed()
+
+	for _, t := range s.locations {
+		t.loc.clearRollbackSegment()
+	}
+
 	return s.Close()
 }
 
@@ -67,65 +147,118 @@ func (s *BatchSession) Close() error {
 
 	s.Store.UnlockSharedSnapshot()
 
-	return s.Batch.Close()
+	err := s.main.batch.Close()
+	for _, t := range s.locations {
+		err = errors.CombineErrors(err, t.batch.Close())
+	}
+
+	return err
 }
 
 // Get returns a value associated with the given key. If not found, returns ErrKeyNotFound.
 func (s *BatchSession) Get(k []byte) ([]byte, error) {
-	if _, ok := s.keys[string(k)]; ok {
-		err := s.applyBatch()
-		if err != nil {
-			return nil, err
+	db, t := s.reader(k)
+	if t != nil {
+		if _, ok := t.keys[string(k)]; ok {
+			err := t.apply()
+			if err != nil {
+				return nil, err
+			}
 		}
 	}
 
-	return get(s.DB, k)
+	return get(db, k)
 }
 
 // Exists returns whether a key exists and is visible by the current session.
 func (s *BatchSession) Exists(k []byte) (bool, error) {
-	if _, ok := s.keys[string(k)]; ok {
-		return true, nil
-	}
+	db, t := s.reader(k)
+	if t != nil {
+		if _, ok := t.keys[string(k)]; ok {
+			return true, nil
+		}
 
-	s.applyBatch()
+		err := t.apply()
+		if err != nil {
+			return false, err
+		}
+	}
 
-	return exists(s.DB, k)
+	return exists(db, k)
 }
 
+// applyBatch commits the pending writes of every database.
 func (s *BatchSession) applyBatch() error {
-	if s.Batch.Empty() {
+	err := s.main.apply()
+	if err != nil {
+		return err
+	}
+
+	for _, t := range s.locations {
+		err = t.apply()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (t *batchTarget) apply() error {
+	if t.batch.Empty() {
 		return nil
 	}
 
-	err := s.rollbackSegment.Apply(s.Batch)
+	err := t.rollbackSegment.Apply(t.batch)
 	if err != nil {
 		return err
 	}
 
+	if t.loc != nil {
+		err = t.loc.markPrepared(t.batch, t.token)
+		if err != nil {
+			return err
+		}
+	}
+
 	// this is an intermediary commit that might be rolled back by the user
 	// so we don't need durability here.
-	err = s.Batch.Commit(pebble.NoSync)
+	err = t.rollbackSegment.crash.commit(CrashPointFlush, t.batch, pebble.NoSync)
 	if err != nil {
 		return err
 	}
 
 	// reset batch
-	s.Batch.Reset()
-	clear(s.keys)
+	t.batch.Reset()
+	clear(t.keys)
 
 	return nil
 }
 
-func (s *BatchSession) ensureBatchSize() error {
-	if s.Batch.Len() < s.maxBatchSize {
+// prepare durably commits the writes to a location, along with their
+// rollback segment. They are rolled back during recovery unless the
+// main batch is committed.
+func (t *batchTarget) prepare() error {
+	err := t.rollbackSegment.Apply(t.batch)
+	if err != nil {
+		return err
+	}
+
+	err = t.loc.markPrepared(t.batch, t.token)
+	if err != nil {
+		return err
+	}
+
+	return t.rollbackSegment.crash.commit(CrashPointPrepare, t.batch, pebble.Sync)
+}
+
+func (t *batchTarget) ensureBatchSize(maxBatchSize int) error {
+	if t.batch.Len() < maxBatchSize {
 		return nil
 	}
 
 	// The batch is too large. Insert the rollback segments and commit the batch.
-	s.applyBatch()
-
-	return nil
+	return t.apply()
 }
 
 // Insert inserts a key-value pair. If it already exists, it returns ErrKeyAlreadyExists.
@@ -146,14 +279,15 @@ func (s *BatchSession) Insert(k, v []byte) error {
 		return engine.ErrKeyAlreadyExists
 	}
 
-	s.keys[string(k)] = struct{}{}
+	t := s.target(k)
+	t.keys[string(k)] = struct{}{}
 
-	err = s.Batch.Set(k, v, nil)
+	err = t.batch.Set(k, v, nil)
 	if err != nil {
 		return err
 	}
 
-	return s.ensureBatchSize()
+	return t.ensureBatchSize(s.maxBatchSize)
 }
 
 // Put stores a key value pair. If it already exists, it overrides it.
@@ -166,26 +300,46 @@ func (s *BatchSession) Put(k, v []byte) error {
 		return errors.New("cannot store empty value")
 	}
 
-	s.keys[string(k)] = struct{}{}
+	t := s.target(k)
+	t.keys[string(k)] = struct{}{}
 
-	err := s.Batch.Set(k, v, nil)
+	err := t.batch.Set(k, v, nil)
 	if err != nil {
 		return err
 	}
 
-	return s.ensureBatchSize()
+	return t.ensureBatchSize(s.maxBatchSize)
+}
+
+// Merge applies a delta to the value of an existing key.
+// Only the delta is written, see DeltaMerger.
+func (s *BatchSession) Merge(k []byte, d engine.Delta) error {
+	if len(k) == 0 {
+		return errors.New("cannot store empty key")
+	}
+
+	t := s.target(k)
+	t.keys[string(k)] = struct{}{}
+
+	err := t.batch.Merge(k, appendDelta(nil, d), nil)
+	if err != nil {
+		return err
+	}
+
+	return t.ensureBatchSize(s.maxBatchSize)
 }
 
 // Delete a record by key. If the key doesn't exist, it doesn't do anything.
 func (s *BatchSession) Delete(k []byte) error {
-	err := s.Batch.Delete(k, nil)
+	t := s.target(k)
+	err := t.batch.Delete(k, nil)
 	if err != nil {
 		return err
 	}
 
-	delete(s.keys, string(k))
+	delete(t.keys, string(k))
 
-	return s.ensureBatchSize()
+	return t.ensureBatchSize(s.maxBatchSize)
 }
 
 // DeleteRange deletes all keys in the given range.
@@ -224,12 +378,5 @@ func (s *BatchSession) Iterator(opts *engine.IterOptions) (engine.Iterator, erro
 		}
 	}
 
-	it, err := s.DB.NewIter(popts)
-	if err != nil {
-		return nil, err
-	}
-
-	return &iterator{
-		Iterator: it,
-	}, nil
+	return s.Store.newLocationsIterator(popts, s.Store.reader)
 }
@@ -0,0 +1,46 @@
+package kv_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/chaisql/chai/internal/kv"
+	"github.com/cockroachdb/pebble"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompression(t *testing.T) {
+	// returns the size of the sstables after writing compressible data
+	write := func(opts kv.Options) int64 {
+		opts.RollbackSegmentNamespace = int64(database.RollbackSegmentNamespace)
+		opts.MinTransientNamespace = uint64(database.MinTransientNamespace)
+		opts.MaxTransientNamespace = uint64(database.MaxTransientNamespace)
+
+		ng, err := kv.NewEngine(t.TempDir(), opts)
+		require.NoError(t, err)
+		defer ng.Close()
+
+		s := ng.NewBatchSession()
+		v := bytes.Repeat([]byte("chai "), 200)
+		for i := int64(0); i < 1000; i++ {
+			err := s.Put(encoding.EncodeInt(encoding.EncodeInt(nil, 10), i), v)
+			require.NoError(t, err)
+		}
+		require.NoError(t, s.Commit())
+		require.NoError(t, ng.DB().Flush())
+
+		return ng.DB().Metrics().Total().Size
+	}
+
+	none := write(kv.Options{Compression: pebble.NoCompression})
+	snappy := write(kv.Options{})
+	zstd := write(kv.Options{Compression: pebble.ZstdCompression})
+	perLevel := write(kv.Options{LevelCompression: []pebble.Compression{pebble.NoCompression, pebble.ZstdCompression}})
+
+	require.Less(t, snappy, none)
+	require.Less(t, zstd, none)
+	// flushed data goes to L0 which isn't compressed
+	require.Equal(t, none, perLevel)
+}
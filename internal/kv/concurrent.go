@@ -0,0 +1,131 @@
+package kv
+
+import (
+	"github.com/chaisql/chai/internal/engine"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/pebble"
+)
+
+var (
+	_ engine.ConcurrentEngine = (*PebbleEngine)(nil)
+	_ engine.MergeSession     = (*ConcurrentSession)(nil)
+)
+
+// ConcurrentSession is a write session that keeps all of its changes
+// in an indexed batch until it is committed.
+// Unlike BatchSession, it never applies its changes to the database before
+// the commit, which allows several sessions to write concurrently,
+// at the cost of keeping the whole transaction in memory.
+// Reads see the changes of the session on top of the latest committed data.
+type ConcurrentSession struct {
+	Store  *PebbleEngine
+	Batch  *pebble.Batch
+	closed bool
+}
+
+func (s *PebbleEngine) NewConcurrentSession() engine.Session {
+	return &ConcurrentSession{
+		Store: s,
+		Batch: s.db.NewIndexedBatch(),
+	}
+}
+
+func (s *ConcurrentSession) Commit() error {
+	if s.closed {
+		return errors.New("already closed")
+	}
+
+	err := s.Store.logCommit(s.Batch)
+	if err != nil {
+		return err
+	}
+
+	err = s.Store.crash.commit(CrashPointCommit, s.Batch, s.Store.writeOptions())
+	if err != nil {
+		return err
+	}
+
+	return s.Close()
+}
+
+func (s *ConcurrentSession) Close() error {
+	if s.closed {
+		return errors.New("already closed")
+	}
+	s.closed = true
+
+	return s.Batch.Close()
+}
+
+// Insert inserts a key-value pair. If it already exists, it returns ErrKeyAlreadyExists.
+func (s *ConcurrentSession) Insert(k, v []byte) error {
+	ok, err := s.Exists(k)
+	if err != nil {
+		return err
+	}
+	if ok {
+		return engine.ErrKeyAlreadyExists
+	}
+
+	return s.Put(k, v)
+}
+
+// Put stores a key value pair. If it already exists, it overrides it.
+func (s *ConcurrentSession) Put(k, v []byte) error {
+	if len(k) == 0 {
+		return errors.New("cannot store empty key")
+	}
+
+	if len(v) == 0 {
+		return errors.New("cannot store empty value")
+	}
+
+	return s.Batch.Set(k, v, nil)
+}
+
+// Merge applies a delta to the value of an existing key.
+// Only the delta is written, see DeltaMerger.
+func (s *ConcurrentSession) Merge(k []byte, d engine.Delta) error {
+	if len(k) == 0 {
+		return errors.New("cannot store empty key")
+	}
+
+	return s.Batch.Merge(k, appendDelta(nil, d), nil)
+}
+
+// Get returns a value associated with the given key. If not found, returns ErrKeyNotFound.
+func (s *ConcurrentSession) Get(k []byte) ([]byte, error) {
+	return get(s.Batch, k)
+}
+
+// Exists returns whether a key exists and is visible by the current session.
+func (s *ConcurrentSession) Exists(k []byte) (bool, error) {
+	return exists(s.Batch, k)
+}
+
+// Delete a record by key. If the key doesn't exist, it doesn't do anything.
+func (s *ConcurrentSession) Delete(k []byte) error {
+	return s.Batch.Delete(k, nil)
+}
+
+// DeleteRange deletes all keys in the given range.
+func (s *ConcurrentSession) DeleteRange(start []byte, end []byte) error {
+	return s.Batch.DeleteRange(start, end, nil)
+}
+
+func (s *ConcurrentSession) Iterator(opts *engine.IterOptions) (engine.Iterator, error) {
+	var popts *pebble.IterOptions
+	if opts != nil {
+		popts = &pebble.IterOptions{
+			LowerBound: opts.LowerBound,
+			UpperBound: opts.UpperBound,
+		}
+	}
+
+	it, err := s.Batch.NewIter(popts)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Store.newIterator(it), nil
+}
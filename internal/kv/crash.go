@@ -0,0 +1,111 @@
+package kv
+
+import (
+	gatomic "sync/atomic"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/pebble"
+)
+
+// CrashPoint identifies a write of the engine at which a crash
+// can be simulated. See Options.CrashHook.
+type CrashPoint uint8
+
+const (
+	// CrashPointFlush is the write of the changes of a transaction too large
+	// to be kept in memory, along with their rollback segment, before the
+	// transaction is committed. Crashing after it leaves a partially written
+	// transaction that must be rolled back by the recovery.
+	CrashPointFlush CrashPoint = iota + 1
+	// CrashPointPrepare is the write of the changes of a transaction to
+	// a storage location, before the main database is committed.
+	CrashPointPrepare
+	// CrashPointCommit is the commit of a transaction.
+	CrashPointCommit
+	// CrashPointRollback is the write undoing the changes of a transaction
+	// that was rolled back.
+	CrashPointRollback
+)
+
+func (p CrashPoint) String() string {
+	switch p {
+	case CrashPointFlush:
+		return "flush"
+	case CrashPointPrepare:
+		return "prepare"
+	case CrashPointCommit:
+		return "commit"
+	case CrashPointRollback:
+		return "rollback"
+	}
+
+	return "unknown"
+}
+
+// CrashMode tells how a simulated crash affects the write at which it happens.
+type CrashMode uint8
+
+const (
+	// NoCrash performs the write normally.
+	NoCrash CrashMode = iota
+	// CrashBefore stops the engine before the write: none of it is stored.
+	CrashBefore
+	// CrashAfter stops the engine once the write is durably stored.
+	CrashAfter
+)
+
+// ErrCrashed is returned by every write of an engine that simulated a crash.
+var ErrCrashed = errors.New("simulated crash")
+
+// crashInjector simulates crashes at the writes chosen by Options.CrashHook.
+// Once a crash is simulated, every write fails until the engine is closed,
+// as if the process stopped: the engine must be reopened, which runs the recovery.
+type crashInjector struct {
+	hook    func(p CrashPoint) CrashMode
+	crashed gatomic.Bool
+}
+
+func newCrashInjector(hook func(p CrashPoint) CrashMode) *crashInjector {
+	if hook == nil {
+		return nil
+	}
+
+	return &crashInjector{hook: hook}
+}
+
+// check returns ErrCrashed if a crash was simulated.
+func (c *crashInjector) check() error {
+	if c != nil && c.crashed.Load() {
+		return ErrCrashed
+	}
+
+	return nil
+}
+
+// commit commits the batch written at the given point,
+// simulating a crash if the hook requires it.
+func (c *crashInjector) commit(p CrashPoint, b *pebble.Batch, opts *pebble.WriteOptions) error {
+	if c == nil {
+		return b.Commit(opts)
+	}
+
+	err := c.check()
+	if err != nil {
+		return err
+	}
+
+	switch c.hook(p) {
+	case CrashBefore:
+		c.crashed.Store(true)
+		return ErrCrashed
+	case CrashAfter:
+		err = b.Commit(pebble.Sync)
+		c.crashed.Store(true)
+		if err != nil {
+			return err
+		}
+		return ErrCrashed
+	}
+
+	return b.Commit(opts)
+}
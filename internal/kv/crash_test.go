@@ -0,0 +1,116 @@
+package kv_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/chaisql/chai/internal/kv"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCrashHook(t *testing.T) {
+	key := func(i int64) []byte {
+		return encoding.EncodeInt(encoding.EncodeInt(nil, 10), i)
+	}
+
+	// writes n keys starting at start in a single transaction
+	write := func(ng *kv.PebbleEngine, start, n int64) error {
+		s := ng.NewBatchSession()
+		defer s.Close()
+
+		for i := start; i < start+n; i++ {
+			err := s.Put(key(i), []byte{1})
+			if err != nil {
+				return err
+			}
+		}
+
+		return s.Commit()
+	}
+
+	// returns the number of keys found between 0 and n
+	count := func(ng *kv.PebbleEngine, n int64) int64 {
+		s := ng.NewSnapshotSession()
+		defer s.Close()
+
+		var found int64
+		for i := int64(0); i < n; i++ {
+			ok, err := s.Exists(key(i))
+			require.NoError(t, err)
+			if ok {
+				found++
+			}
+		}
+
+		return found
+	}
+
+	tests := []struct {
+		name  string
+		point kv.CrashPoint
+		mode  kv.CrashMode
+		// number of keys found after the recovery
+		want int64
+	}{
+		// the first transaction is committed, the second one is not
+		{"before commit", kv.CrashPointCommit, kv.CrashBefore, 10},
+		{"after commit", kv.CrashPointCommit, kv.CrashAfter, 10},
+		// partially written transactions are rolled back
+		{"before flush", kv.CrashPointFlush, kv.CrashBefore, 10},
+		{"after flush", kv.CrashPointFlush, kv.CrashAfter, 10},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "db")
+			var armed bool
+			var points []kv.CrashPoint
+
+			open := func(hook func(p kv.CrashPoint) kv.CrashMode) *kv.PebbleEngine {
+				ng, err := kv.NewEngine(dir, kv.Options{
+					RollbackSegmentNamespace: int64(database.RollbackSegmentNamespace),
+					MinTransientNamespace:    uint64(database.MinTransientNamespace),
+					MaxTransientNamespace:    uint64(database.MaxTransientNamespace),
+					MaxBatchSize:             100,
+					CrashHook:                hook,
+				})
+				require.NoError(t, err)
+				return ng
+			}
+
+			ng := open(func(p kv.CrashPoint) kv.CrashMode {
+				points = append(points, p)
+				if armed && p == test.point {
+					return test.mode
+				}
+				return kv.NoCrash
+			})
+
+			require.NoError(t, write(ng, 0, 10))
+			armed = true
+			err := write(ng, 10, 100)
+			if test.point == kv.CrashPointCommit && test.mode == kv.CrashAfter {
+				// the transaction is durable, even if the commit failed
+				test.want += 100
+			}
+			require.ErrorIs(t, err, kv.ErrCrashed)
+			require.Contains(t, points, test.point)
+
+			// every write fails after the crash
+			require.ErrorIs(t, write(ng, 200, 1), kv.ErrCrashed)
+			require.ErrorIs(t, ng.Rollback(), kv.ErrCrashed)
+			require.NoError(t, ng.Close())
+
+			ng = open(nil)
+			defer ng.Close()
+			require.NoError(t, ng.Recover())
+
+			pending, err := ng.RollbackPending()
+			require.NoError(t, err)
+			require.False(t, pending)
+			require.Equal(t, test.want, count(ng, 300))
+		})
+	}
+}
@@ -0,0 +1,264 @@
+package encryption
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/pebble/vfs"
+)
+
+// file is an encrypted file. Data can only be appended to it.
+type file struct {
+	f     vfs.File
+	aead  cipher.AEAD
+	keyID uint32
+	// random identifier of the file, authenticated with every block
+	// to prevent blocks from being moved from one file to another.
+	id [8]byte
+
+	mu sync.Mutex
+	// size of the plaintext
+	size int64
+	// plaintext of the last block, when it is incomplete.
+	// It is rewritten every time the file is synced.
+	tail      []byte
+	tailDirty bool
+	// offset used by Read
+	offset int64
+	// last block read, to avoid decrypting the same block for sequential reads.
+	cache struct {
+		idx  int64
+		data []byte
+	}
+}
+
+var _ vfs.File = (*file)(nil)
+
+func (f *file) additionalData(idx int64) []byte {
+	var ad [16]byte
+	copy(ad[:], f.id[:])
+	binary.BigEndian.PutUint64(ad[8:], uint64(idx))
+	return ad[:]
+}
+
+func (f *file) writeBlock(idx int64, plaintext []byte) error {
+	buf := make([]byte, nonceSize, nonceSize+len(plaintext)+tagSize)
+	_, err := rand.Read(buf)
+	if err != nil {
+		return err
+	}
+
+	buf = f.aead.Seal(buf, buf[:nonceSize], plaintext, f.additionalData(idx))
+	_, err = f.f.WriteAt(buf, headerSize+idx*encBlockSize)
+	return err
+}
+
+// readBlock returns the plaintext of the block at the given index.
+func (f *file) readBlock(idx int64) ([]byte, error) {
+	if f.cache.data != nil && f.cache.idx == idx {
+		return f.cache.data, nil
+	}
+
+	// the last block might be incomplete.
+	n := f.size - idx*blockSize
+	if n > blockSize {
+		n = blockSize
+	}
+
+	buf := make([]byte, nonceSize+n+tagSize)
+	_, err := f.f.ReadAt(buf, headerSize+idx*encBlockSize)
+	if err != nil && !(errors.Is(err, io.EOF) && len(buf) > 0) {
+		return nil, err
+	}
+
+	plaintext, err := f.aead.Open(buf[nonceSize:nonceSize], buf[:nonceSize], buf[nonceSize:], f.additionalData(idx))
+	if err != nil {
+		return nil, errors.WithStack(ErrCorrupted)
+	}
+
+	f.cache.idx = idx
+	f.cache.data = plaintext
+	return plaintext, nil
+}
+
+func (f *file) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.aead == nil {
+		return 0, errors.New("file not opened for writing")
+	}
+
+	// writing to a block invalidates the cache
+	f.cache.data = nil
+
+	var written int
+	for len(p) > 0 {
+		if f.tail == nil {
+			f.tail = make([]byte, 0, blockSize)
+		}
+
+		n := copy(f.tail[len(f.tail):blockSize], p)
+		f.tail = f.tail[:len(f.tail)+n]
+		f.size += int64(n)
+		written += n
+		p = p[n:]
+
+		if len(f.tail) < blockSize {
+			f.tailDirty = true
+			break
+		}
+
+		err := f.writeBlock((f.size-1)/blockSize, f.tail)
+		if err != nil {
+			return written, err
+		}
+		f.tail = f.tail[:0]
+		f.tailDirty = false
+	}
+
+	return written, nil
+}
+
+// WriteAt is not supported, encrypted files can only be appended to.
+func (f *file) WriteAt(p []byte, off int64) (int, error) {
+	return 0, errors.New("encrypted files don't support WriteAt")
+}
+
+func (f *file) flush() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !f.tailDirty {
+		return nil
+	}
+
+	err := f.writeBlock(f.size/blockSize, f.tail)
+	if err != nil {
+		return err
+	}
+	f.tailDirty = false
+
+	return nil
+}
+
+func (f *file) Read(p []byte) (int, error) {
+	f.mu.Lock()
+	off := f.offset
+	f.mu.Unlock()
+
+	n, err := f.ReadAt(p, off)
+
+	f.mu.Lock()
+	f.offset += int64(n)
+	f.mu.Unlock()
+
+	if n > 0 && errors.Is(err, io.EOF) {
+		err = nil
+	}
+	return n, err
+}
+
+func (f *file) ReadAt(p []byte, off int64) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var read int
+	for len(p) > 0 {
+		if off >= f.size {
+			return read, io.EOF
+		}
+
+		idx := off / blockSize
+
+		var block []byte
+		if len(f.tail) > 0 && idx == f.size/blockSize {
+			block = f.tail
+		} else {
+			var err error
+			block, err = f.readBlock(idx)
+			if err != nil {
+				return read, err
+			}
+		}
+
+		n := copy(p, block[off-idx*blockSize:])
+		read += n
+		off += int64(n)
+		p = p[n:]
+	}
+
+	return read, nil
+}
+
+func (f *file) Close() error {
+	err := f.flush()
+	if err != nil {
+		_ = f.f.Close()
+		return err
+	}
+
+	return f.f.Close()
+}
+
+// Preallocate is a no-op, the space taken on disk
+// doesn't match the size of the plaintext.
+func (f *file) Preallocate(offset, length int64) error {
+	return nil
+}
+
+func (f *file) Stat() (os.FileInfo, error) {
+	fi, err := f.f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return fileInfo{FileInfo: fi, size: f.size}, nil
+}
+
+func (f *file) Sync() error {
+	err := f.flush()
+	if err != nil {
+		return err
+	}
+
+	return f.f.Sync()
+}
+
+func (f *file) SyncData() error {
+	err := f.flush()
+	if err != nil {
+		return err
+	}
+
+	return f.f.SyncData()
+}
+
+// SyncTo syncs the whole file, since offsets of the plaintext
+// don't match the offsets of the file on disk.
+func (f *file) SyncTo(length int64) (fullSync bool, err error) {
+	err = f.SyncData()
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (f *file) Prefetch(offset int64, length int64) error {
+	return nil
+}
+
+// Fd returns vfs.InvalidFd to prevent callers from
+// using the file descriptor to access the encrypted content.
+func (f *file) Fd() uintptr {
+	return vfs.InvalidFd
+}
@@ -0,0 +1,319 @@
+// Package encryption implements a filesystem that encrypts
+// every file written by the engine with AES-GCM.
+//
+// Files are split in fixed-size blocks that are encrypted independently,
+// which keeps random reads cheap: reading a few bytes only requires
+// decrypting the blocks that contain them.
+// Each file starts with a header identifying the key that was used
+// to encrypt it, which allows rotating keys without rewriting
+// the whole database at once.
+package encryption
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/pebble/vfs"
+)
+
+const (
+	// size of the plaintext of a block
+	blockSize = 4096
+	nonceSize = 12
+	tagSize   = 16
+	// size of an encrypted block on disk
+	encBlockSize = blockSize + nonceSize + tagSize
+	// magic (8 bytes) + key ID (4 bytes) + file ID (8 bytes)
+	headerSize = 20
+)
+
+var magic = []byte("CHAIENC1")
+
+// ErrCorrupted is returned when a block fails to be authenticated,
+// either because the file was modified or because the wrong key was used.
+var ErrCorrupted = errors.New("encrypted file corrupted or wrong key")
+
+// KeyFunc returns the AES key associated with the given ID.
+// The key must be 16, 24 or 32 bytes long.
+type KeyFunc func(id uint32) ([]byte, error)
+
+// Options configure the encryption.
+type Options struct {
+	// ID of the key used to encrypt new files.
+	KeyID uint32
+	// Key is called once per key ID to get the key used to encrypt
+	// or decrypt files. It can be used to fetch keys from a KMS.
+	Key KeyFunc
+}
+
+// FS wraps a vfs.FS and encrypts the content of every file.
+type FS struct {
+	vfs.FS
+
+	opts Options
+
+	mu    sync.Mutex
+	aeads map[uint32]cipher.AEAD
+}
+
+var _ vfs.FS = (*FS)(nil)
+
+// NewFS returns a filesystem that encrypts files stored in fs.
+func NewFS(fs vfs.FS, opts Options) (*FS, error) {
+	if opts.Key == nil {
+		return nil, errors.New("encryption key function cannot be nil")
+	}
+
+	efs := FS{
+		FS:    fs,
+		opts:  opts,
+		aeads: make(map[uint32]cipher.AEAD),
+	}
+
+	// ensure the current key is valid
+	_, err := efs.aead(opts.KeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &efs, nil
+}
+
+func (fs *FS) aead(id uint32) (cipher.AEAD, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if a, ok := fs.aeads[id]; ok {
+		return a, nil
+	}
+
+	key, err := fs.opts.Key(id)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get encryption key %d", id)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid encryption key %d", id)
+	}
+
+	a, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	fs.aeads[id] = a
+	return a, nil
+}
+
+// Create creates a new file encrypted with the current key.
+func (fs *FS) Create(name string) (vfs.File, error) {
+	f, err := fs.FS.Create(name)
+	if err != nil {
+		return nil, err
+	}
+
+	ef, err := fs.newFile(f)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+
+	return ef, nil
+}
+
+func (fs *FS) newFile(f vfs.File) (*file, error) {
+	aead, err := fs.aead(fs.opts.KeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	ef := file{
+		f:     f,
+		aead:  aead,
+		keyID: fs.opts.KeyID,
+	}
+
+	_, err = rand.Read(ef.id[:])
+	if err != nil {
+		return nil, err
+	}
+
+	var header [headerSize]byte
+	copy(header[:], magic)
+	binary.BigEndian.PutUint32(header[8:], ef.keyID)
+	copy(header[12:], ef.id[:])
+
+	_, err = f.WriteAt(header[:], 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ef, nil
+}
+
+// Open opens an encrypted file for reading.
+func (fs *FS) Open(name string, opts ...vfs.OpenOption) (vfs.File, error) {
+	f, err := fs.FS.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	ef, err := fs.openFile(f, false)
+	if err != nil {
+		_ = f.Close()
+		return nil, errors.Wrapf(err, "failed to open %q", name)
+	}
+
+	for _, opt := range opts {
+		opt.Apply(ef)
+	}
+
+	return ef, nil
+}
+
+// OpenReadWrite opens an encrypted file for reading and writing.
+// New data is appended at the end of the file.
+func (fs *FS) OpenReadWrite(name string, opts ...vfs.OpenOption) (vfs.File, error) {
+	f, err := fs.FS.OpenReadWrite(name)
+	if err != nil {
+		return nil, err
+	}
+
+	ef, err := fs.openFile(f, true)
+	if err != nil {
+		_ = f.Close()
+		return nil, errors.Wrapf(err, "failed to open %q", name)
+	}
+
+	for _, opt := range opts {
+		opt.Apply(ef)
+	}
+
+	return ef, nil
+}
+
+func (fs *FS) openFile(f vfs.File, writable bool) (*file, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	// the file was created but the header was never written
+	if fi.Size() < headerSize {
+		if !writable {
+			return &file{f: f}, nil
+		}
+
+		return fs.newFile(f)
+	}
+
+	var header [headerSize]byte
+	_, err = f.ReadAt(header[:], 0)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(header[:8], magic) {
+		return nil, errors.New("file is not encrypted")
+	}
+
+	ef := file{
+		f:     f,
+		keyID: binary.BigEndian.Uint32(header[8:]),
+		size:  plaintextSize(fi.Size()),
+	}
+	copy(ef.id[:], header[12:])
+
+	ef.aead, err = fs.aead(ef.keyID)
+	if err != nil {
+		return nil, err
+	}
+
+	// load the last incomplete block in memory to be able to append to it.
+	if writable && ef.size%blockSize != 0 {
+		tail, err := ef.readBlock(ef.size / blockSize)
+		if err != nil {
+			return nil, err
+		}
+		ef.tail = append(make([]byte, 0, blockSize), tail...)
+	}
+
+	return &ef, nil
+}
+
+// ReuseForWrite doesn't reuse the old file, whose content
+// can't be overwritten in place: it creates a new one instead.
+func (fs *FS) ReuseForWrite(oldname, newname string) (vfs.File, error) {
+	err := fs.FS.Remove(oldname)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	return fs.Create(newname)
+}
+
+// Stat returns the file info of the named file, reporting the size of its plaintext.
+func (fs *FS) Stat(name string) (os.FileInfo, error) {
+	fi, err := fs.FS.Stat(name)
+	if err != nil || !fi.Mode().IsRegular() {
+		return fi, err
+	}
+
+	return fileInfo{FileInfo: fi, size: plaintextSize(fi.Size())}, nil
+}
+
+// KeyID returns the ID of the key used to encrypt the named file.
+func (fs *FS) KeyID(name string) (uint32, error) {
+	f, err := fs.FS.Open(name)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	var header [headerSize]byte
+	_, err = f.ReadAt(header[:], 0)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return fs.opts.KeyID, nil
+		}
+		return 0, err
+	}
+	if !bytes.Equal(header[:8], magic) {
+		return 0, errors.Newf("file %q is not encrypted", name)
+	}
+
+	return binary.BigEndian.Uint32(header[8:]), nil
+}
+
+// plaintextSize returns the size of the plaintext
+// of a file that takes n bytes on disk.
+func plaintextSize(n int64) int64 {
+	if n <= headerSize {
+		return 0
+	}
+	n -= headerSize
+
+	size := (n / encBlockSize) * blockSize
+	if rem := n % encBlockSize; rem > nonceSize+tagSize {
+		size += rem - nonceSize - tagSize
+	}
+
+	return size
+}
+
+type fileInfo struct {
+	os.FileInfo
+
+	size int64
+}
+
+func (fi fileInfo) Size() int64 {
+	return fi.size
+}
@@ -0,0 +1,161 @@
+package encryption_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/chaisql/chai/internal/kv/encryption"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/pebble/vfs"
+	"github.com/stretchr/testify/require"
+)
+
+func keys(m map[uint32][]byte) encryption.KeyFunc {
+	return func(id uint32) ([]byte, error) {
+		k, ok := m[id]
+		if !ok {
+			return nil, errors.Newf("unknown key %d", id)
+		}
+		return k, nil
+	}
+}
+
+var testKeys = map[uint32][]byte{
+	1: bytes.Repeat([]byte{1}, 32),
+	2: bytes.Repeat([]byte{2}, 16),
+}
+
+func newFS(t *testing.T, mem vfs.FS, keyID uint32) *encryption.FS {
+	t.Helper()
+
+	fs, err := encryption.NewFS(mem, encryption.Options{KeyID: keyID, Key: keys(testKeys)})
+	require.NoError(t, err)
+	return fs
+}
+
+func TestFile(t *testing.T) {
+	mem := vfs.NewMem()
+	fs := newFS(t, mem, 1)
+
+	// write more than a block, with syncs in the middle of blocks
+	data := make([]byte, 10_000)
+	for i := range data {
+		data[i] = byte(i)
+	}
+
+	f, err := fs.Create("a")
+	require.NoError(t, err)
+	_, err = f.Write(data[:100])
+	require.NoError(t, err)
+	require.NoError(t, f.Sync())
+	_, err = f.Write(data[100:5000])
+	require.NoError(t, err)
+	require.NoError(t, f.SyncData())
+	_, err = f.Write(data[5000:])
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	fi, err := fs.Stat("a")
+	require.NoError(t, err)
+	require.EqualValues(t, len(data), fi.Size())
+
+	// the plaintext is not stored on disk
+	raw, err := mem.Open("a")
+	require.NoError(t, err)
+	b, err := io.ReadAll(raw)
+	require.NoError(t, err)
+	require.NoError(t, raw.Close())
+	require.False(t, bytes.Contains(b, data[200:300]))
+
+	f, err = fs.Open("a")
+	require.NoError(t, err)
+	got, err := io.ReadAll(f)
+	require.NoError(t, err)
+	require.Equal(t, data, got)
+
+	p := make([]byte, 200)
+	_, err = f.ReadAt(p, 4000)
+	require.NoError(t, err)
+	require.Equal(t, data[4000:4200], p)
+
+	_, err = f.ReadAt(p, int64(len(data))-100)
+	require.ErrorIs(t, err, io.EOF)
+	require.NoError(t, f.Close())
+
+	// append to an existing file
+	f, err = fs.OpenReadWrite("a")
+	require.NoError(t, err)
+	_, err = f.Write([]byte("hello"))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	f, err = fs.Open("a")
+	require.NoError(t, err)
+	got, err = io.ReadAll(f)
+	require.NoError(t, err)
+	require.Equal(t, append(data, "hello"...), got)
+	require.NoError(t, f.Close())
+}
+
+func TestCorruption(t *testing.T) {
+	mem := vfs.NewMem()
+	fs := newFS(t, mem, 1)
+
+	f, err := fs.Create("a")
+	require.NoError(t, err)
+	_, err = f.Write(bytes.Repeat([]byte("a"), 100))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	raw, err := mem.OpenReadWrite("a")
+	require.NoError(t, err)
+	_, err = raw.WriteAt([]byte{0xFF}, 50)
+	require.NoError(t, err)
+	require.NoError(t, raw.Close())
+
+	f, err = fs.Open("a")
+	require.NoError(t, err)
+	defer f.Close()
+
+	_, err = io.ReadAll(f)
+	require.ErrorIs(t, err, encryption.ErrCorrupted)
+}
+
+func TestReencrypt(t *testing.T) {
+	mem := vfs.NewMem()
+	require.NoError(t, mem.MkdirAll("db", 0755))
+	fs := newFS(t, mem, 1)
+
+	for _, name := range []string{"db/a", "db/b"} {
+		f, err := fs.Create(name)
+		require.NoError(t, err)
+		_, err = f.Write([]byte(name))
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+	}
+
+	n, err := encryption.Reencrypt(mem, "db", encryption.Options{KeyID: 2, Key: keys(testKeys)})
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+
+	// running it again doesn't rewrite anything
+	n, err = encryption.Reencrypt(mem, "db", encryption.Options{KeyID: 2, Key: keys(testKeys)})
+	require.NoError(t, err)
+	require.Equal(t, 0, n)
+
+	// key 1 is not needed anymore
+	fs, err = encryption.NewFS(mem, encryption.Options{KeyID: 2, Key: keys(map[uint32][]byte{2: testKeys[2]})})
+	require.NoError(t, err)
+
+	id, err := fs.KeyID("db/a")
+	require.NoError(t, err)
+	require.EqualValues(t, 2, id)
+
+	f, err := fs.Open("db/b")
+	require.NoError(t, err)
+	got, err := io.ReadAll(f)
+	require.NoError(t, err)
+	require.Equal(t, "db/b", string(got))
+	require.NoError(t, f.Close())
+}
@@ -0,0 +1,93 @@
+package encryption
+
+import (
+	"io"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/pebble/vfs"
+)
+
+// Reencrypt rewrites every file of dir that is not encrypted with the current key.
+// The database stored in dir must not be open: the job takes the lock file
+// of the database for its whole duration.
+// The key function must know both the current key and the previous ones.
+// It returns the number of files that were rewritten.
+func Reencrypt(fs vfs.FS, dir string, opts Options) (int, error) {
+	efs, err := NewFS(fs, opts)
+	if err != nil {
+		return 0, err
+	}
+
+	lock, err := fs.Lock(fs.PathJoin(dir, "LOCK"))
+	if err != nil {
+		return 0, errors.Wrap(err, "database is in use")
+	}
+	defer lock.Close()
+
+	names, err := fs.List(dir)
+	if err != nil {
+		return 0, err
+	}
+
+	var count int
+	for _, name := range names {
+		if name == "LOCK" {
+			continue
+		}
+
+		path := fs.PathJoin(dir, name)
+		fi, err := fs.Stat(path)
+		if err != nil {
+			return count, err
+		}
+		if !fi.Mode().IsRegular() {
+			continue
+		}
+
+		id, err := efs.KeyID(path)
+		if err != nil {
+			return count, err
+		}
+		if id == opts.KeyID {
+			continue
+		}
+
+		err = reencryptFile(efs, path)
+		if err != nil {
+			return count, errors.Wrapf(err, "failed to re-encrypt %q", name)
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+// reencryptFile copies the plaintext of a file to a new file
+// encrypted with the current key, and replaces the original file with it.
+func reencryptFile(efs *FS, path string) error {
+	src, err := efs.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	tmp := path + ".reencrypt"
+	dst, err := efs.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(dst, src)
+	if err == nil {
+		err = dst.Sync()
+	}
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		_ = efs.Remove(tmp)
+		return err
+	}
+
+	return efs.Rename(tmp, path)
+}
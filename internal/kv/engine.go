@@ -1,17 +1,21 @@
 package kv
 
 import (
-	"math"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync"
+	gatomic "sync/atomic"
+	"time"
 
 	"github.com/chaisql/chai/internal/encoding"
-	"github.com/chaisql/chai/internal/pkg/atomic"
+	"github.com/chaisql/chai/internal/kv/encryption"
+	"github.com/chaisql/chai/internal/pkg/backoff"
 	"github.com/chaisql/chai/internal/pkg/pebbleutil"
 	"github.com/cockroachdb/errors"
 	"github.com/cockroachdb/pebble"
+	"github.com/cockroachdb/pebble/objstorage/remote"
 	"github.com/cockroachdb/pebble/vfs"
 )
 
@@ -24,6 +28,8 @@ type PebbleEngine struct {
 	db              *pebble.DB
 	opts            Options
 	rollbackSegment *RollbackSegment
+	// simulates crashes, nil unless Options.CrashHook is set.
+	crash *crashInjector
 
 	// holds the shared snapshot read by all the read sessions
 	// when a write session is open.
@@ -35,8 +41,26 @@ type PebbleEngine struct {
 		snapshot *snapshot
 	}
 
+	// locations other than the main database, by name.
+	// Set when the engine is opened and never modified afterwards.
+	locations map[string]*location
+	// location of each namespace stored outside of the main database.
+	placement struct {
+		sync.RWMutex
+
+		namespaces map[int64]*location
+	}
+
 	minTransientNamespace uint64
 	maxTransientNamespace uint64
+
+	// set when a transaction is committed without being synced.
+	unsynced gatomic.Bool
+	// number of iterators created by the sessions and not closed yet.
+	openIterators gatomic.Int64
+	// stops the background sync, with SyncPeriodic.
+	stopSync chan struct{}
+	syncDone chan struct{}
 }
 
 type Options struct {
@@ -45,64 +69,368 @@ type Options struct {
 	MaxTransientBatchSize    int
 	MinTransientNamespace    uint64
 	MaxTransientNamespace    uint64
+
+	// Size in bytes above which the data of a transient session
+	// is moved from memory to a temporary database. Defaults to 4MB.
+	TransientSpillThreshold int
+	// Directory in which the temporary databases of the transient
+	// sessions are created. Defaults to the temporary directory of the OS.
+	TempDir string
+
+	// If set, every file written by the engine is encrypted.
+	Encryption *encryption.Options
+
+	// Compression algorithm used for the blocks of every level.
+	// Defaults to Snappy.
+	Compression pebble.Compression
+	// Compression algorithm used per level, starting from L0.
+	// Levels beyond the end of the slice use the last element.
+	// Overrides Compression for the given levels.
+	LevelCompression []pebble.Compression
+
+	// If set, WAL files are uploaded to this storage once they are
+	// no longer needed by the engine, instead of being deleted.
+	// Combined with a checkpoint, they allow restoring the database
+	// at any point in time with RestorePointInTime.
+	// It cannot be used with encryption.
+	WALArchive remote.Storage
+	// Prefix of the names of the archived WAL files.
+	WALArchivePrefix string
+
+	// SyncMode controls when committed transactions are synced to disk.
+	// Defaults to SyncAlways.
+	SyncMode SyncMode
+	// Interval between two syncs with SyncPeriodic. Defaults to 100ms.
+	SyncInterval time.Duration
+	// Directory of the WAL files. Defaults to the directory of the database.
+	WALDir string
+	// If non-zero, the WAL is synced in the background every time
+	// this number of bytes is written, to smooth out the cost of syncs.
+	WALBytesPerSync int
+
+	// Size in bytes of the cache of the blocks read from disk. Defaults to 8MB.
+	CacheSize int64
+	// Size in bytes of a memtable, which buffers the writes in memory
+	// before they are flushed to disk. Defaults to 4MB.
+	MemTableSize uint64
+	// Maximum number of files kept open by the engine. Defaults to 1000.
+	MaxOpenFiles int
+	// Number of files in L0 above which L0 is compacted. Defaults to 4.
+	L0CompactionThreshold int
+	// Number of files in L0 above which writes are stopped until L0 is compacted.
+	// Defaults to 12, or to L0CompactionThreshold if it is greater.
+	L0StopWritesThreshold int
+	// Target size of the files of L0. The target size of each
+	// following level is twice the one of the previous level.
+	// Defaults to 2MB.
+	TargetFileSize int64
+	// Maximum number of compactions running concurrently. Defaults to 1.
+	MaxConcurrentCompactions int
+
+	// Controls how long to wait for the lock of the directory when it is held
+	// by another process. By default, opening a locked database fails immediately.
+	LockWait backoff.Policy
+
+	// Directories of the locations in which namespaces can be stored
+	// instead of the directory of the database, by location name.
+	// Each location is a separate Pebble database, which allows storing
+	// some of the data on other disks. Namespaces are assigned to a location
+	// with Place. The same locations must be provided every time the
+	// database is opened. Locations cannot be used with WAL archiving,
+	// checkpoints or concurrent sessions.
+	Locations map[string]string
+	// Namespace in which the state of the transactions committed
+	// to several locations is stored.
+	PlacementNamespace int64
+
+	// If true, the database is opened in read-only mode, without
+	// acquiring the lock of its directory: it can be opened while another
+	// process is writing to it. The engine sees the data committed before
+	// it was opened.
+	// The directory must contain an existing database.
+	ReadOnly bool
+
+	// CrashHook, if set, is called before every write at which a crash can
+	// be simulated, and returns whether the engine must crash before or after
+	// the write. Once it crashed, every write of the engine fails with ErrCrashed:
+	// it must be closed and reopened, which runs the recovery.
+	// This is meant to test the durability of the database.
+	CrashHook func(p CrashPoint) CrashMode
+}
+
+// numLevels is the number of levels of the LSM.
+const numLevels = 7
+
+// defaultTargetFileSize is the target size of the files of L0.
+const defaultTargetFileSize = 2 << 20 // 2MB
+
+// levelOptions returns the options of each level of the LSM.
+func (o *Options) levelOptions() []pebble.LevelOptions {
+	if o.Compression == pebble.DefaultCompression && len(o.LevelCompression) == 0 && o.TargetFileSize <= 0 {
+		return nil
+	}
+
+	target := o.TargetFileSize
+	if target <= 0 {
+		target = defaultTargetFileSize
+	}
+
+	levels := make([]pebble.LevelOptions, numLevels)
+	for i := range levels {
+		c := o.Compression
+		if n := len(o.LevelCompression); n > 0 {
+			c = o.LevelCompression[min(i, n-1)]
+		}
+		levels[i].Compression = c
+		levels[i].TargetFileSize = target << i
+	}
+
+	return levels
+}
+
+// tune applies the tuning options to popts, unless they are already set.
+func (o *Options) tune(popts *pebble.Options) {
+	if popts.MemTableSize == 0 {
+		popts.MemTableSize = o.MemTableSize
+	}
+	if popts.MaxOpenFiles == 0 {
+		popts.MaxOpenFiles = o.MaxOpenFiles
+	}
+	if popts.L0CompactionThreshold == 0 {
+		popts.L0CompactionThreshold = o.L0CompactionThreshold
+	}
+	if popts.L0StopWritesThreshold == 0 {
+		popts.L0StopWritesThreshold = o.L0StopWritesThreshold
+		// pebble's default may be lower than the compaction threshold
+		if popts.L0StopWritesThreshold <= 0 && popts.L0CompactionThreshold > 12 {
+			popts.L0StopWritesThreshold = popts.L0CompactionThreshold
+		}
+	}
+	if popts.MaxConcurrentCompactions == nil && o.MaxConcurrentCompactions > 0 {
+		n := o.MaxConcurrentCompactions
+		popts.MaxConcurrentCompactions = func() int { return n }
+	}
 }
 
 func NewEngineWith(path string, opts Options, popts *pebble.Options) (*PebbleEngine, error) {
+	db, err := openDB(path, opts, popts)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewStore(db, opts), nil
+}
+
+// openDB opens the Pebble database at path, configured with opts.
+func openDB(path string, opts Options, popts *pebble.Options) (*pebble.DB, error) {
 	if popts == nil {
 		popts = &pebble.Options{}
 	}
 
-	popts.FormatMajorVersion = pebble.FormatPrePebblev1MarkedCompacted
+	if popts.FormatMajorVersion < pebble.FormatPrePebblev1MarkedCompacted {
+		popts.FormatMajorVersion = pebble.FormatPrePebblev1MarkedCompacted
+	}
 	popts.Comparer = DefaultComparer
+	if popts.Merger == nil {
+		popts.Merger = DeltaMerger
+
+		name, err := storedMergerName(popts.FS, path)
+		if err != nil {
+			return nil, err
+		}
+		if name == legacyMergerName {
+			popts.Merger = legacyDeltaMerger
+		}
+	}
+	if popts.Levels == nil {
+		popts.Levels = opts.levelOptions()
+	}
 	if popts.Logger == nil {
 		popts.Logger = pebbleutil.NoopLoggerAndTracer{}
 	}
+	if popts.WALDir == "" && path != "" {
+		popts.WALDir = opts.WALDir
+	}
+	if popts.WALBytesPerSync == 0 {
+		popts.WALBytesPerSync = opts.WALBytesPerSync
+	}
+	if opts.ReadOnly {
+		popts.ReadOnly = true
+		popts.ErrorIfNotExists = true
+	}
+	opts.tune(popts)
+	if popts.Cache == nil && opts.CacheSize > 0 {
+		// the engine holds its own reference to the cache
+		cache := pebble.NewCache(opts.CacheSize)
+		defer cache.Unref()
+		popts.Cache = cache
+	}
 
-	popts = popts.EnsureDefaults()
+	return pebble.Open(path, popts.EnsureDefaults())
+}
 
-	db, err := pebble.Open(path, popts)
+func NewEngine(path string, opts Options) (*PebbleEngine, error) {
+	var popts pebble.Options
+
+	pbpath, err := preparePath(path, &popts, opts.ReadOnly)
 	if err != nil {
 		return nil, err
 	}
 
-	return NewStore(db, opts), nil
+	if opts.WALArchive != nil {
+		if opts.Encryption != nil {
+			return nil, errors.New("WAL archiving cannot be used with encryption")
+		}
+		if len(opts.Locations) > 0 {
+			return nil, errors.New("WAL archiving cannot be used with locations")
+		}
+
+		fs := popts.FS
+		if fs == nil {
+			fs = vfs.Default
+		}
+
+		popts.FS = &walArchiveFS{
+			FS:      fs,
+			storage: opts.WALArchive,
+			prefix:  opts.WALArchivePrefix,
+		}
+		popts.Cleaner = pebble.ArchiveCleaner{}
+	}
+
+	release, err := opts.prepareFS(pbpath, &popts)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	ng, err := NewEngineWith(pbpath, opts, &popts)
+	if err != nil {
+		return nil, err
+	}
+
+	err = ng.openLocations()
+	if err != nil {
+		_ = ng.Close()
+		return nil, err
+	}
+
+	return ng, nil
 }
 
-func NewEngine(path string, opts Options) (*PebbleEngine, error) {
-	var popts pebble.Options
-	var pbpath string
+// prepareFS configures the filesystem of popts for the Pebble directory at path:
+// it encrypts the files if needed, and locks the directory, waiting for the lock
+// with the LockWait policy. The returned function releases the reference
+// to the lock held by the caller once the database is opened.
+func (o *Options) prepareFS(path string, popts *pebble.Options) (func(), error) {
+	if o.Encryption != nil {
+		fs := popts.FS
+		if fs == nil {
+			fs = vfs.Default
+		}
 
-	if path == ":memory:" {
-		popts.FS = vfs.NewMem()
-	} else {
-		path = strings.TrimSpace(path)
-		path = filepath.Clean(path)
-		if path == "" {
-			return nil, errors.New("path cannot be empty")
+		var err error
+		popts.FS, err = encryption.NewFS(fs, *o.Encryption)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if o.ReadOnly {
+		fs := popts.FS
+		if fs == nil {
+			fs = vfs.Default
 		}
 
-		fi, err := os.Stat(path)
+		popts.FS = noLockFS{fs}
+	}
+
+	if !o.ReadOnly && path != "" && o.LockWait.Timeout > 0 {
+		lock, err := lockDirectory(path, popts.FS, o.LockWait)
 		if err != nil {
-			if !os.IsNotExist(err) {
-				return nil, err
-			}
+			return nil, err
+		}
+		popts.Lock = lock
+		return func() { _ = lock.Close() }, nil
+	}
+
+	return func() {}, nil
+}
+
+// ErrLockTimeout is returned when a lock couldn't be acquired before the timeout.
+var ErrLockTimeout = errors.New("timed out waiting for the lock")
+
+// lockDirectory acquires the lock of the Pebble directory,
+// retrying while it is held by another process.
+func lockDirectory(path string, fs vfs.FS, policy backoff.Policy) (*pebble.Lock, error) {
+	if fs == nil {
+		fs = vfs.Default
+	}
+
+	// the directory is created by Pebble when the database doesn't exist yet
+	err := fs.MkdirAll(path, 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	var lock *pebble.Lock
+	ok := policy.Retry(func() bool {
+		lock, err = pebble.LockDirectory(path, fs)
+		return err == nil
+	})
+	if !ok {
+		return nil, errors.Mark(errors.Wrapf(err, "cannot lock %q", path), ErrLockTimeout)
+	}
+
+	return lock, nil
+}
 
-			err = os.MkdirAll(path, 0700)
-			if err != nil {
-				return nil, err
-			}
-		} else {
-			if !fi.IsDir() {
-				return nil, errors.New("path must be a directory")
-			}
+// preparePath returns the path of the Pebble directory of the database.
+// If path is ":memory:", it configures popts to use an in-memory filesystem.
+// If readOnly is true, the directory must already exist.
+func preparePath(path string, popts *pebble.Options, readOnly bool) (string, error) {
+	if path == ":memory:" {
+		if readOnly {
+			return "", errors.New("in-memory databases cannot be opened in read-only mode")
 		}
 
-		pbpath = filepath.Join(path, "pebble")
+		popts.FS = vfs.NewMem()
+		return "", nil
+	}
+
+	path = strings.TrimSpace(path)
+	path = filepath.Clean(path)
+	if path == "" {
+		return "", errors.New("path cannot be empty")
 	}
 
-	popts.FormatMajorVersion = pebble.FormatVirtualSSTables
+	fi, err := os.Stat(path)
+	if err != nil {
+		if !os.IsNotExist(err) || readOnly {
+			return "", err
+		}
 
-	return NewEngineWith(pbpath, opts, &popts)
+		err = os.MkdirAll(path, 0700)
+		if err != nil {
+			return "", err
+		}
+	} else {
+		if !fi.IsDir() {
+			return "", errors.New("path must be a directory")
+		}
+	}
+
+	return filepath.Join(path, "pebble"), nil
+}
+
+// noLockFS is a filesystem that doesn't lock files,
+// used to open a database that may be locked by another process.
+type noLockFS struct {
+	vfs.FS
+}
+
+func (noLockFS) Lock(string) (io.Closer, error) {
+	return io.NopCloser(nil), nil
 }
 
 // DefaultComparer is the default implementation of the Comparer interface for chai.
@@ -132,31 +460,96 @@ func NewStore(db *pebble.DB, opts Options) *PebbleEngine {
 		panic("max transient namespace cannot be 0")
 	}
 
-	return &PebbleEngine{
+	s := PebbleEngine{
 		db:              db,
 		opts:            opts,
 		rollbackSegment: NewRollbackSegment(db, opts.RollbackSegmentNamespace),
+		crash:           newCrashInjector(opts.CrashHook),
 	}
+	s.rollbackSegment.crash = s.crash
+
+	if opts.SyncMode == SyncPeriodic && !opts.ReadOnly {
+		interval := opts.SyncInterval
+		if interval <= 0 {
+			interval = defaultSyncInterval
+		}
+
+		s.stopSync = make(chan struct{})
+		s.syncDone = make(chan struct{})
+		go s.syncPeriodically(interval, s.stopSync, s.syncDone)
+	}
+
+	return &s
 }
 
 func (s *PebbleEngine) Close() error {
-	return s.db.Close()
+	if s.stopSync != nil {
+		close(s.stopSync)
+		<-s.syncDone
+	}
+
+	err := s.db.Close()
+	for _, loc := range s.locations {
+		err = errors.CombineErrors(err, loc.db.Close())
+	}
+
+	return err
 }
 
 func (s *PebbleEngine) Rollback() error {
-	return s.rollbackSegment.Rollback()
+	err := s.rollbackSegment.Rollback()
+	if err != nil {
+		return err
+	}
+
+	for _, loc := range s.locations {
+		err = loc.rollbackSegment.Rollback()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 func (s *PebbleEngine) Recover() error {
-	return s.rollbackSegment.Reset()
+	err := s.rollbackSegment.Reset()
+	if err != nil {
+		return err
+	}
+
+	for _, loc := range s.locations {
+		err = s.recoverLocation(loc)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RollbackPending returns whether the rollback segment of any location
+// contains changes that were not rolled back yet. It is always
+// false after a successful recovery, when no transaction is running.
+func (s *PebbleEngine) RollbackPending() (bool, error) {
+	empty, err := s.rollbackSegment.Empty()
+	if err != nil || !empty {
+		return !empty, err
+	}
+
+	for _, loc := range s.locations {
+		empty, err = loc.rollbackSegment.Empty()
+		if err != nil || !empty {
+			return !empty, err
+		}
+	}
+
+	return false, nil
 }
 
 func (s *PebbleEngine) LockSharedSnapshot() {
 	s.sharedSnapshot.Lock()
-	s.sharedSnapshot.snapshot = &snapshot{
-		snapshot: s.db.NewSnapshot(),
-		refCount: atomic.NewCounter(0, math.MaxInt64, false),
-	}
+	s.sharedSnapshot.snapshot = s.newSnapshot()
 	s.sharedSnapshot.snapshot.Incr()
 	s.sharedSnapshot.Unlock()
 }
@@ -179,3 +572,36 @@ func (s *PebbleEngine) CleanupTransientNamespaces() error {
 		pebble.NoSync,
 	)
 }
+
+// CheckIntegrity reads every block of the database, which verifies their checksums,
+// and ensures the levels of the LSM are consistent.
+func (s *PebbleEngine) CheckIntegrity() error {
+	err := s.db.CheckLevels(nil)
+	if err != nil {
+		return err
+	}
+
+	for _, loc := range s.locations {
+		err = loc.db.CheckLevels(nil)
+		if err != nil {
+			return errors.Wrapf(err, "location %q", loc.name)
+		}
+	}
+
+	return nil
+}
+
+// Reencrypt rewrites the files of the database found at path
+// that are not encrypted with the current key of opts.
+// The database must be closed.
+func Reencrypt(path string, opts encryption.Options) (int, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	if !fi.IsDir() {
+		return 0, errors.New("path must be a directory")
+	}
+
+	return encryption.Reencrypt(vfs.Default, filepath.Join(path, "pebble"), opts)
+}
@@ -0,0 +1,173 @@
+package kv
+
+import (
+	"encoding/binary"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/chaisql/chai/internal/engine"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/errors/oserror"
+	"github.com/cockroachdb/pebble"
+	"github.com/cockroachdb/pebble/vfs"
+)
+
+// DeltaMerger stores the values written with Merge as deltas applied
+// to the previous value of the key, see engine.Delta.
+// The merge operands are lists of deltas, from the oldest to the newest:
+// merging operands without the previous value concatenates their lists,
+// and merging them with the previous value applies each delta in order.
+// Readers always see the resulting values.
+var DeltaMerger = &pebble.Merger{
+	Merge: func(key, value []byte) (pebble.ValueMerger, error) {
+		return &deltaValueMerger{operands: [][]byte{append([]byte(nil), value...)}}, nil
+	},
+	Name: "chai.delta",
+}
+
+// legacyMergerName is the name of the merger of the databases created
+// before the deltas were introduced. They contain no merge operand,
+// and are opened with DeltaMerger under that name.
+var legacyMergerName = pebble.DefaultMerger.Name
+
+// legacyDeltaMerger is DeltaMerger under the name of the legacy merger.
+var legacyDeltaMerger = &pebble.Merger{
+	Merge: DeltaMerger.Merge,
+	Name:  legacyMergerName,
+}
+
+// appendDelta appends the encoded delta to dst.
+func appendDelta(dst []byte, d engine.Delta) []byte {
+	dst = binary.AppendUvarint(dst, uint64(d.Offset))
+	dst = binary.AppendUvarint(dst, uint64(d.Removed))
+	dst = binary.AppendUvarint(dst, uint64(len(d.Inserted)))
+	return append(dst, d.Inserted...)
+}
+
+// applyDeltas applies the list of encoded deltas to v.
+func applyDeltas(v, deltas []byte) ([]byte, error) {
+	for len(deltas) > 0 {
+		var d engine.Delta
+		var fields [3]uint64
+		for i := range fields {
+			x, n := binary.Uvarint(deltas)
+			if n <= 0 {
+				return nil, errors.New("malformed delta")
+			}
+			fields[i] = x
+			deltas = deltas[n:]
+		}
+		if fields[2] > uint64(len(deltas)) {
+			return nil, errors.New("malformed delta")
+		}
+
+		d.Offset, d.Removed = int(fields[0]), int(fields[1])
+		d.Inserted = deltas[:fields[2]]
+		deltas = deltas[fields[2]:]
+
+		var err error
+		v, err = d.Apply(v)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return v, nil
+}
+
+// deltaValueMerger merges the operands of a key, see DeltaMerger.
+type deltaValueMerger struct {
+	// operands from the oldest to the newest.
+	operands [][]byte
+}
+
+func (m *deltaValueMerger) MergeNewer(value []byte) error {
+	m.operands = append(m.operands, append([]byte(nil), value...))
+	return nil
+}
+
+func (m *deltaValueMerger) MergeOlder(value []byte) error {
+	m.operands = append([][]byte{append([]byte(nil), value...)}, m.operands...)
+	return nil
+}
+
+// Finish applies the deltas to the oldest operand if it is the previous
+// value of the key. Otherwise, it returns the concatenation of the deltas,
+// which are applied once the previous value is merged.
+func (m *deltaValueMerger) Finish(includesBase bool) ([]byte, io.Closer, error) {
+	if !includesBase {
+		var buf []byte
+		for _, op := range m.operands {
+			buf = append(buf, op...)
+		}
+		return buf, nil, nil
+	}
+
+	v := m.operands[0]
+	for _, op := range m.operands[1:] {
+		var err error
+		v, err = applyDeltas(v, op)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return v, nil, nil
+}
+
+// storedMergerName returns the name of the merger recorded in the latest
+// OPTIONS file of the Pebble database at path, or an empty string
+// if the database doesn't exist yet.
+func storedMergerName(fs vfs.FS, path string) (string, error) {
+	if path == "" {
+		return "", nil
+	}
+	if fs == nil {
+		fs = vfs.Default
+	}
+
+	names, err := fs.List(path)
+	if err != nil {
+		if oserror.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	var latest string
+	var latestNum uint64
+	for _, name := range names {
+		num, ok := strings.CutPrefix(name, "OPTIONS-")
+		if !ok {
+			continue
+		}
+		n, err := strconv.ParseUint(num, 10, 64)
+		if err != nil || (latest != "" && n < latestNum) {
+			continue
+		}
+		latest, latestNum = name, n
+	}
+	if latest == "" {
+		return "", nil
+	}
+
+	f, err := fs.Open(fs.PathJoin(path, latest))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return "", err
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		if name, ok := strings.CutPrefix(strings.TrimSpace(line), "merger="); ok {
+			return strings.TrimSpace(name), nil
+		}
+	}
+
+	return "", nil
+}
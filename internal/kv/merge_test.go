@@ -0,0 +1,183 @@
+package kv_test
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/chaisql/chai/internal/engine"
+	"github.com/chaisql/chai/internal/kv"
+	"github.com/chaisql/chai/internal/testutil"
+	"github.com/cockroachdb/pebble"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		want     engine.Delta
+	}{
+		{"same", "abcdef", "abcdef", engine.Delta{Offset: 6, Inserted: []byte{}}},
+		{"middle", "abcdef", "abXYef", engine.Delta{Offset: 2, Removed: 2, Inserted: []byte("XY")}},
+		{"longer", "abcdef", "abcXYZdef", engine.Delta{Offset: 3, Inserted: []byte("XYZ")}},
+		{"shorter", "abcdef", "af", engine.Delta{Offset: 1, Removed: 4, Inserted: []byte{}}},
+		{"prefix", "abc", "abcabc", engine.Delta{Offset: 3, Inserted: []byte("abc")}},
+		{"empty", "", "abc", engine.Delta{Inserted: []byte("abc")}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := engine.Diff([]byte(test.old), []byte(test.new))
+			require.Equal(t, test.want, d)
+
+			v, err := d.Apply([]byte(test.old))
+			require.NoError(t, err)
+			require.Equal(t, test.new, string(v))
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	key := encoding.EncodeInt(encoding.EncodeInt(nil, 10), 1)
+	base := bytes.Repeat([]byte("a"), 200)
+
+	// set returns the value with the bytes at offset replaced by s.
+	set := func(v []byte, offset int, s string) []byte {
+		v = bytes.Clone(v)
+		copy(v[offset:], s)
+		return v
+	}
+
+	merge := func(t *testing.T, ng *kv.PebbleEngine, old, new []byte) {
+		t.Helper()
+
+		s := ng.NewBatchSession()
+		err := s.(engine.MergeSession).Merge(key, engine.Diff(old, new))
+		require.NoError(t, err)
+		require.Equal(t, new, getValue(t, s, key))
+		require.NoError(t, s.Commit())
+	}
+
+	t.Run("Read", func(t *testing.T) {
+		ng := testutil.NewEngine(t)
+
+		s := ng.NewBatchSession()
+		require.NoError(t, s.Put(key, base))
+		require.NoError(t, s.Commit())
+
+		v1 := set(base, 10, "xyz")
+		merge(t, ng, base, v1)
+		v2 := set(v1, 150, "12345")
+		merge(t, ng, v1, v2)
+
+		ss := ng.NewSnapshotSession()
+		defer ss.Close()
+		require.Equal(t, v2, getValue(t, ss, key))
+
+		it, err := ss.Iterator(nil)
+		require.NoError(t, err)
+		defer it.Close()
+		require.True(t, it.First())
+		v, err := it.Value()
+		require.NoError(t, err)
+		require.Equal(t, v2, v)
+	})
+
+	t.Run("Compaction", func(t *testing.T) {
+		ng := testutil.NewEngine(t)
+
+		s := ng.NewBatchSession()
+		require.NoError(t, s.Put(key, base))
+		require.NoError(t, s.Commit())
+		require.NoError(t, ng.DB().Flush())
+
+		// the operands are flushed to separate files,
+		// and merged without and with the base value
+		v := base
+		for i := 0; i < 5; i++ {
+			next := set(v, i*20, "xyz")
+			merge(t, ng, v, next)
+			require.NoError(t, ng.DB().Flush())
+			v = next
+		}
+		require.NoError(t, ng.DB().Compact(key, append(bytes.Clone(key), 0xFF), true))
+
+		ss := ng.NewSnapshotSession()
+		defer ss.Close()
+		require.Equal(t, v, getValue(t, ss, key))
+	})
+
+	t.Run("Rollback", func(t *testing.T) {
+		ng := testutil.NewEngine(t)
+
+		s := ng.NewBatchSession()
+		require.NoError(t, s.Put(key, base))
+		require.NoError(t, s.Commit())
+
+		s = ng.NewBatchSession()
+		err := s.(engine.MergeSession).Merge(key, engine.Diff(base, set(base, 0, "xyz")))
+		require.NoError(t, err)
+		// applies the batch, which fills the rollback segment
+		_, err = s.Iterator(nil)
+		require.NoError(t, err)
+		require.NoError(t, s.Close())
+		require.NoError(t, ng.Rollback())
+
+		ss := ng.NewSnapshotSession()
+		defer ss.Close()
+		require.Equal(t, base, getValue(t, ss, key))
+	})
+
+	t.Run("Concurrent", func(t *testing.T) {
+		ng := testutil.NewEngine(t)
+
+		s := ng.NewBatchSession()
+		require.NoError(t, s.Put(key, base))
+		require.NoError(t, s.Commit())
+
+		v := set(base, 100, "xyz")
+		cs := ng.NewConcurrentSession()
+		require.NoError(t, cs.(engine.MergeSession).Merge(key, engine.Diff(base, v)))
+		require.Equal(t, v, getValue(t, cs, key))
+		require.NoError(t, cs.Commit())
+
+		ss := ng.NewSnapshotSession()
+		defer ss.Close()
+		require.Equal(t, v, getValue(t, ss, key))
+	})
+}
+
+func TestMergeLegacyDatabase(t *testing.T) {
+	dir := t.TempDir()
+	key := encoding.EncodeInt(encoding.EncodeInt(nil, 10), 1)
+	base := bytes.Repeat([]byte("a"), 200)
+
+	// databases created before the deltas use the default merger of Pebble
+	db, err := pebble.Open(filepath.Join(dir, "pebble"), &pebble.Options{Comparer: kv.DefaultComparer})
+	require.NoError(t, err)
+	require.NoError(t, db.Set(key, base, pebble.Sync))
+	require.NoError(t, db.Close())
+
+	for i := 0; i < 2; i++ {
+		ng, err := kv.NewEngine(dir, kv.Options{
+			RollbackSegmentNamespace: 100,
+			MinTransientNamespace:    10_000,
+			MaxTransientNamespace:    11_000,
+		})
+		require.NoError(t, err)
+
+		v := bytes.Clone(base)
+		v[i] = 'x'
+		s := ng.NewBatchSession()
+		require.NoError(t, s.(engine.MergeSession).Merge(key, engine.Diff(base, v)))
+		require.NoError(t, s.Commit())
+		ss := ng.NewSnapshotSession()
+		require.Equal(t, v, getValue(t, ss, key))
+		require.NoError(t, ss.Close())
+
+		base = v
+		require.NoError(t, ng.Close())
+	}
+}
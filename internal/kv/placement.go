@@ -0,0 +1,522 @@
+package kv
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"sort"
+	gatomic "sync/atomic"
+
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/chaisql/chai/internal/engine"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/pebble"
+)
+
+// A location is a directory, possibly on another disk, storing the namespaces
+// placed in it in its own Pebble database.
+//
+// Transactions writing to several locations are committed in two steps:
+// the writes of every location are committed along with their rollback segment
+// and the token of the transaction, marked as prepared, then the main batch
+// is committed and records the token as committed for each location.
+// If the engine crashes in between, the recovery rolls back the locations
+// whose prepared token was not committed.
+type location struct {
+	name            string
+	db              *pebble.DB
+	rollbackSegment *RollbackSegment
+	// key of the token of the last transaction prepared in the location.
+	preparedKey []byte
+	// key of the token of the last transaction committed in the location,
+	// stored in the main database.
+	committedKey []byte
+
+	// last token assigned to a transaction.
+	// only modified by write sessions, which are not concurrent.
+	token uint64
+	// set if the rollback segment of the last committed transaction
+	// couldn't be cleared.
+	stale bool
+}
+
+// openLocations opens the database of every location of the options.
+func (s *PebbleEngine) openLocations() error {
+	if len(s.opts.Locations) == 0 {
+		return nil
+	}
+	if s.opts.PlacementNamespace == 0 {
+		return errors.New("placement namespace cannot be 0")
+	}
+
+	s.locations = make(map[string]*location, len(s.opts.Locations))
+	s.placement.namespaces = make(map[int64]*location)
+
+	for name, path := range s.opts.Locations {
+		if name == "" {
+			return errors.New("location name cannot be empty")
+		}
+
+		db, err := openLocation(path, s.opts)
+		if err != nil {
+			return errors.Wrapf(err, "cannot open location %q", name)
+		}
+
+		ns := encoding.EncodeInt(nil, s.opts.PlacementNamespace)
+		loc := location{
+			name:            name,
+			db:              db,
+			rollbackSegment: NewRollbackSegment(db, s.opts.RollbackSegmentNamespace),
+			preparedKey:     ns,
+			committedKey:    encoding.EncodeText(bytes.Clone(ns), name),
+		}
+		loc.rollbackSegment.crash = s.crash
+		s.locations[name] = &loc
+
+		prepared, err := readToken(db, loc.preparedKey)
+		if err != nil {
+			return err
+		}
+		committed, err := readToken(s.db, loc.committedKey)
+		if err != nil {
+			return err
+		}
+		loc.token = max(prepared, committed)
+	}
+
+	return nil
+}
+
+// openLocation opens the Pebble database of a location,
+// with the same options as the main database.
+func openLocation(path string, opts Options) (*pebble.DB, error) {
+	var popts pebble.Options
+
+	pbpath, err := preparePath(path, &popts, opts.ReadOnly)
+	if err != nil {
+		return nil, err
+	}
+
+	release, err := opts.prepareFS(pbpath, &popts)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	// the WAL of a location is stored in its own directory
+	opts.WALDir = ""
+
+	return openDB(pbpath, opts, &popts)
+}
+
+// recoverLocation rolls back the writes of the last transaction
+// prepared in the location if it wasn't committed.
+func (s *PebbleEngine) recoverLocation(loc *location) error {
+	rs := loc.rollbackSegment
+
+	empty, err := rs.Empty()
+	if err != nil || empty {
+		return err
+	}
+
+	prepared, err := readToken(loc.db, loc.preparedKey)
+	if err != nil {
+		return err
+	}
+	committed, err := readToken(s.db, loc.committedKey)
+	if err != nil {
+		return err
+	}
+
+	if prepared == committed {
+		// the engine stopped before clearing the rollback segment
+		return loc.db.DeleteRange(rs.nsStart, rs.nsEnd, pebble.Sync)
+	}
+
+	return rs.Reset()
+}
+
+// nextToken returns the token of a new transaction writing to the location.
+func (l *location) nextToken() uint64 {
+	l.token++
+	return l.token
+}
+
+// newBatch returns a batch writing to the location.
+// If the rollback segment of the previous transaction wasn't cleared,
+// it is cleared by the batch.
+func (l *location) newBatch() *pebble.Batch {
+	b := l.db.NewBatch()
+	if l.stale {
+		// the rollback segment ignores range deletions
+		if b.DeleteRange(l.rollbackSegment.nsStart, l.rollbackSegment.nsEnd, nil) == nil {
+			l.stale = false
+		}
+	}
+
+	return b
+}
+
+// markPrepared records the token as prepared in the batch of the location.
+func (l *location) markPrepared(b *pebble.Batch, token uint64) error {
+	return b.Set(l.preparedKey, binary.BigEndian.AppendUint64(nil, token), nil)
+}
+
+// markCommitted records the token as committed for the location in the main batch.
+func (l *location) markCommitted(b *pebble.Batch, token uint64) error {
+	return b.Set(l.committedKey, binary.BigEndian.AppendUint64(nil, token), nil)
+}
+
+// clearRollbackSegment clears the rollback segment of a committed transaction.
+// If it fails, the transaction is still committed: the segment is cleared by
+// the next transaction writing to the location or by the recovery.
+func (l *location) clearRollbackSegment() {
+	b := l.db.NewBatch()
+	defer b.Close()
+
+	err := l.rollbackSegment.crash.check()
+	if err == nil {
+		err = l.rollbackSegment.Clear(b)
+	}
+	if err == nil {
+		err = b.Commit(pebble.NoSync)
+	}
+	if err != nil {
+		l.stale = true
+	}
+
+	l.rollbackSegment.Committed()
+}
+
+func readToken(r pebble.Reader, k []byte) (uint64, error) {
+	v, err := get(r, k)
+	if errors.Is(err, engine.ErrKeyNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if len(v) != 8 {
+		return 0, errors.New("invalid transaction token")
+	}
+
+	return binary.BigEndian.Uint64(v), nil
+}
+
+// Locations returns the names of the locations of the engine, sorted by name.
+func (s *PebbleEngine) Locations() []string {
+	names := make([]string, 0, len(s.locations))
+	for name := range s.locations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Place stores the namespace in the given location,
+// or in the main database if the location is empty.
+// The namespace must not contain any key, and the placement must be
+// done again every time the engine is opened.
+func (s *PebbleEngine) Place(ns int64, name string) error {
+	var loc *location
+	if name != "" {
+		var ok bool
+		loc, ok = s.locations[name]
+		if !ok {
+			return errors.Errorf("unknown location %q", name)
+		}
+	}
+
+	if len(s.locations) == 0 {
+		return nil
+	}
+
+	s.placement.Lock()
+	if loc == nil {
+		delete(s.placement.namespaces, ns)
+	} else {
+		s.placement.namespaces[ns] = loc
+	}
+	s.placement.Unlock()
+
+	return nil
+}
+
+// Location returns the location of the namespace,
+// or an empty string if it is stored in the main database.
+func (s *PebbleEngine) Location(ns int64) string {
+	if len(s.locations) == 0 {
+		return ""
+	}
+
+	s.placement.RLock()
+	defer s.placement.RUnlock()
+
+	if loc := s.placement.namespaces[ns]; loc != nil {
+		return loc.name
+	}
+
+	return ""
+}
+
+// namespaceOf decodes the namespace prefixing the key.
+// It returns the length of the prefix, and false if the key
+// doesn't start with a namespace.
+func namespaceOf(k []byte) (int64, int, bool) {
+	if len(k) == 0 || k[0] < encoding.Int64Value || k[0] > encoding.Uint64Value {
+		return 0, 0, false
+	}
+
+	if encoding.Skip(k) > len(k) {
+		return 0, 0, false
+	}
+
+	ns, n := encoding.DecodeInt(k)
+	return ns, n, true
+}
+
+// locationOf returns the location storing the key,
+// or nil if it is stored in the main database.
+func (s *PebbleEngine) locationOf(k []byte) *location {
+	if len(s.locations) == 0 {
+		return nil
+	}
+
+	ns, _, ok := namespaceOf(k)
+	if !ok {
+		return nil
+	}
+
+	s.placement.RLock()
+	defer s.placement.RUnlock()
+
+	return s.placement.namespaces[ns]
+}
+
+// locationsOf returns the locations that may store keys within the bounds
+// of the iterator options, nil standing for the main database.
+func (s *PebbleEngine) locationsOf(popts *pebble.IterOptions) []*location {
+	if len(s.locations) == 0 {
+		return []*location{nil}
+	}
+
+	first, last := int64(math.MinInt64), int64(math.MaxInt64)
+	if popts != nil && popts.LowerBound != nil {
+		if ns, _, ok := namespaceOf(popts.LowerBound); ok {
+			first = ns
+		}
+	}
+	if popts != nil && popts.UpperBound != nil {
+		if ns, n, ok := namespaceOf(popts.UpperBound); ok {
+			last = ns
+			// the upper bound is exclusive: the keys of the namespace
+			// are greater than the namespace itself
+			if n == len(popts.UpperBound) {
+				last--
+			}
+		}
+	}
+
+	s.placement.RLock()
+	defer s.placement.RUnlock()
+
+	if first == last {
+		return []*location{s.placement.namespaces[first]}
+	}
+
+	locs := []*location{nil}
+	for ns, loc := range s.placement.namespaces {
+		if ns < first || ns > last {
+			continue
+		}
+
+		var found bool
+		for _, l := range locs {
+			found = found || l == loc
+		}
+		if !found {
+			locs = append(locs, loc)
+		}
+	}
+
+	return locs
+}
+
+// dbOf returns the database of the location,
+// or the main database if loc is nil.
+func (s *PebbleEngine) dbOf(loc *location) *pebble.DB {
+	if loc == nil {
+		return s.db
+	}
+
+	return loc.db
+}
+
+func (s *PebbleEngine) reader(loc *location) pebble.Reader {
+	return s.dbOf(loc)
+}
+
+// newLocationsIterator returns an iterator over the keys of the locations
+// within the bounds of popts, read from the readers returned by reader.
+func (s *PebbleEngine) newLocationsIterator(popts *pebble.IterOptions, reader func(*location) pebble.Reader) (engine.Iterator, error) {
+	locs := s.locationsOf(popts)
+
+	its := make([]*pebble.Iterator, 0, len(locs))
+	for _, loc := range locs {
+		it, err := reader(loc).NewIter(popts)
+		if err != nil {
+			for _, it := range its {
+				_ = it.Close()
+			}
+			return nil, err
+		}
+		its = append(its, it)
+	}
+
+	if len(its) == 1 {
+		return s.newIterator(its[0]), nil
+	}
+
+	s.openIterators.Add(1)
+	return &mergingIterator{
+		its:  its,
+		cur:  -1,
+		open: &s.openIterators,
+	}, nil
+}
+
+// mergingIterator iterates over the keys of several databases
+// as if they were stored in a single one. The databases must not
+// contain the same keys.
+type mergingIterator struct {
+	its []*pebble.Iterator
+	// index of the iterator positioned at the current key, -1 if none.
+	cur int
+	// whether the iterators are positioned after the current key,
+	// or before it.
+	reverse bool
+	open    *gatomic.Int64
+}
+
+// pick selects the iterator positioned at the smallest key,
+// or the largest one in reverse.
+func (m *mergingIterator) pick() bool {
+	m.cur = -1
+	for i, it := range m.its {
+		if !it.Valid() {
+			continue
+		}
+		if m.cur < 0 {
+			m.cur = i
+			continue
+		}
+
+		c := encoding.Compare(it.Key(), m.its[m.cur].Key())
+		if (!m.reverse && c < 0) || (m.reverse && c > 0) {
+			m.cur = i
+		}
+	}
+
+	return m.cur >= 0
+}
+
+func (m *mergingIterator) First() bool {
+	for _, it := range m.its {
+		it.First()
+	}
+	m.reverse = false
+	return m.pick()
+}
+
+func (m *mergingIterator) Last() bool {
+	for _, it := range m.its {
+		it.Last()
+	}
+	m.reverse = true
+	return m.pick()
+}
+
+func (m *mergingIterator) SeekGE(key []byte) bool {
+	for _, it := range m.its {
+		it.SeekGE(key)
+	}
+	m.reverse = false
+	return m.pick()
+}
+
+func (m *mergingIterator) Next() bool {
+	if m.cur < 0 {
+		return false
+	}
+
+	if m.reverse {
+		// position the other iterators after the current key
+		key := bytes.Clone(m.Key())
+		for i, it := range m.its {
+			if i != m.cur {
+				it.SeekGE(key)
+			}
+		}
+		m.reverse = false
+	}
+
+	m.its[m.cur].Next()
+	return m.pick()
+}
+
+func (m *mergingIterator) Prev() bool {
+	if m.cur < 0 {
+		return false
+	}
+
+	if !m.reverse {
+		// position the other iterators before the current key
+		key := bytes.Clone(m.Key())
+		for i, it := range m.its {
+			if i != m.cur {
+				it.SeekLT(key)
+			}
+		}
+		m.reverse = true
+	}
+
+	m.its[m.cur].Prev()
+	return m.pick()
+}
+
+func (m *mergingIterator) Valid() bool {
+	return m.cur >= 0
+}
+
+func (m *mergingIterator) Key() []byte {
+	return m.its[m.cur].Key()
+}
+
+func (m *mergingIterator) Value() ([]byte, error) {
+	return m.its[m.cur].ValueAndErr()
+}
+
+func (m *mergingIterator) Error() error {
+	for _, it := range m.its {
+		if err := it.Error(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *mergingIterator) Close() error {
+	if m.open != nil {
+		m.open.Add(-1)
+		m.open = nil
+	}
+
+	var err error
+	for _, it := range m.its {
+		err = errors.CombineErrors(err, it.Close())
+	}
+
+	return err
+}
@@ -0,0 +1,149 @@
+package kv_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/chaisql/chai/internal/engine"
+	"github.com/chaisql/chai/internal/kv"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocations(t *testing.T) {
+	dir := t.TempDir()
+
+	open := func() *kv.PebbleEngine {
+		ng, err := kv.NewEngine(filepath.Join(dir, "main"), kv.Options{
+			RollbackSegmentNamespace: int64(database.RollbackSegmentNamespace),
+			PlacementNamespace:       int64(database.PlacementNamespace),
+			MinTransientNamespace:    uint64(database.MinTransientNamespace),
+			MaxTransientNamespace:    uint64(database.MaxTransientNamespace),
+			MaxBatchSize:             1000,
+			Locations:                map[string]string{"cold": filepath.Join(dir, "cold")},
+		})
+		require.NoError(t, err)
+
+		// namespace 20 is stored in the location
+		require.NoError(t, ng.Place(20, "cold"))
+		return ng
+	}
+
+	key := func(ns, i int64) []byte {
+		return encoding.EncodeInt(encoding.EncodeInt(nil, ns), i)
+	}
+
+	// ignores the system namespaces
+	appendKey := func(got []string, k []byte) []string {
+		ns, n := encoding.DecodeInt(k)
+		if ns < 10 {
+			return got
+		}
+		i, _ := encoding.DecodeInt(k[n:])
+		return append(got, fmt.Sprintf("%d/%d", ns, i))
+	}
+
+	// returns the keys of the range, in order
+	keys := func(s engine.Session, opts *engine.IterOptions, reverse bool) []string {
+		it, err := s.Iterator(opts)
+		require.NoError(t, err)
+		defer it.Close()
+
+		var got []string
+		if reverse {
+			for it.Last(); it.Valid(); it.Prev() {
+				got = appendKey(got, it.Key())
+			}
+		} else {
+			for it.First(); it.Valid(); it.Next() {
+				got = appendKey(got, it.Key())
+			}
+		}
+		require.NoError(t, it.Error())
+		return got
+	}
+
+	ng := open()
+	require.Error(t, ng.Place(21, "unknown"))
+	require.Equal(t, "cold", ng.Location(20))
+	require.Equal(t, "", ng.Location(10))
+
+	s := ng.NewBatchSession()
+	for _, ns := range []int64{10, 20, 30} {
+		for i := int64(0); i < 2; i++ {
+			require.NoError(t, s.Put(key(ns, i), []byte{1}))
+		}
+	}
+	require.NoError(t, s.Commit())
+
+	s = ng.NewSnapshotSession()
+	v, err := s.Get(key(20, 1))
+	require.NoError(t, err)
+	require.Equal(t, []byte{1}, v)
+
+	all := []string{"10/0", "10/1", "20/0", "20/1", "30/0", "30/1"}
+	bounds := &engine.IterOptions{
+		LowerBound: encoding.EncodeInt(nil, 10),
+		UpperBound: encoding.EncodeInt(nil, 40),
+	}
+	require.Equal(t, all, keys(s, bounds, false))
+	require.Equal(t, []string{"30/1", "30/0", "20/1", "20/0", "10/1", "10/0"}, keys(s, bounds, true))
+	require.Equal(t, []string{"20/0", "20/1"}, keys(s, &engine.IterOptions{
+		LowerBound: encoding.EncodeInt(nil, 20),
+		UpperBound: encoding.EncodeInt(nil, 21),
+	}, false))
+
+	// changing direction across locations
+	it, err := s.Iterator(bounds)
+	require.NoError(t, err)
+	require.True(t, it.SeekGE(key(20, 1)))
+	require.True(t, it.Prev())
+	require.Equal(t, key(20, 0), it.Key())
+	require.True(t, it.Prev())
+	require.Equal(t, key(10, 1), it.Key())
+	require.True(t, it.Next())
+	require.Equal(t, key(20, 0), it.Key())
+	require.NoError(t, it.Close())
+	require.NoError(t, s.Close())
+
+	// rolled back writes, including the ones committed
+	// to make room in the batch, are removed from every location
+	s = ng.NewBatchSession()
+	for i := int64(2); i < 200; i++ {
+		require.NoError(t, s.Put(key(10, i), []byte{1}))
+		require.NoError(t, s.Put(key(20, i), []byte{1}))
+	}
+	require.NoError(t, s.Delete(key(20, 0)))
+	require.NoError(t, s.Close())
+	require.NoError(t, ng.Rollback())
+
+	s = ng.NewSnapshotSession()
+	require.Equal(t, all, keys(s, nil, false))
+	require.NoError(t, s.Close())
+
+	// the namespaces of the location are not stored in the main database
+	require.NoError(t, ng.Place(20, ""))
+	s = ng.NewSnapshotSession()
+	_, err = s.Get(key(20, 0))
+	require.ErrorIs(t, err, engine.ErrKeyNotFound)
+	require.NoError(t, s.Close())
+	require.NoError(t, ng.Place(20, "cold"))
+
+	// writes not committed before a crash are rolled back by the recovery
+	s = ng.NewBatchSession()
+	for i := int64(2); i < 200; i++ {
+		require.NoError(t, s.Put(key(20, i), []byte{1}))
+	}
+	require.NoError(t, s.Close())
+	require.NoError(t, ng.Close())
+
+	ng = open()
+	defer ng.Close()
+	require.NoError(t, ng.Recover())
+
+	s = ng.NewSnapshotSession()
+	defer s.Close()
+	require.Equal(t, all, keys(s, nil, false))
+}
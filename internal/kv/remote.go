@@ -0,0 +1,296 @@
+package kv
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/pebble"
+	"github.com/cockroachdb/pebble/objstorage"
+	"github.com/cockroachdb/pebble/objstorage/remote"
+	"github.com/cockroachdb/pebble/sstable"
+	"github.com/cockroachdb/pebble/vfs"
+)
+
+const (
+	remoteLocator = remote.Locator("chai-remote")
+
+	defaultRemoteCacheSize = 64 << 20 // 64MB
+)
+
+// RemoteOptions configure an engine that reads its data
+// from immutable sstables stored in an object storage.
+type RemoteOptions struct {
+	// Storage from which the sstables are read.
+	Storage remote.Storage
+	// Only objects whose name starts with Prefix and ends with ".sst" are loaded.
+	// They are loaded in lexicographic order: if two sstables contain the
+	// same key, the value of the last one wins.
+	Prefix string
+	// Size in bytes of the block cache.
+	// Defaults to 64MB.
+	CacheSize int64
+}
+
+// NewRemoteEngine creates an engine whose data is read from sstables
+// stored in an object storage. The sstables are never copied locally:
+// blocks are fetched from the storage on demand and kept in a cache.
+//
+// The engine stays writable but new data is only kept in memory
+// and is lost when the engine is closed. Remote sstables are never modified.
+// Every time the engine is opened, it lists the objects of the storage
+// and loads the latest version of the dataset.
+func NewRemoteEngine(opts Options, ropts RemoteOptions) (*PebbleEngine, error) {
+	if ropts.Storage == nil {
+		return nil, errors.New("remote storage cannot be nil")
+	}
+	if len(opts.Locations) > 0 {
+		return nil, errors.New("locations cannot be used with a remote storage")
+	}
+	if ropts.CacheSize <= 0 {
+		ropts.CacheSize = defaultRemoteCacheSize
+	}
+
+	cache := pebble.NewCache(ropts.CacheSize)
+	defer cache.Unref()
+
+	// Pebble doesn't persist the names of external sstables,
+	// which prevents reopening a local database referencing them.
+	// The local database is thus always kept in memory.
+	popts := pebble.Options{
+		FS:                 vfs.NewMem(),
+		Cache:              cache,
+		FormatMajorVersion: pebble.FormatVirtualSSTables,
+		// the sstables exported before the deltas were introduced
+		// are marked with the name of the legacy merger.
+		Merger: legacyDeltaMerger,
+	}
+	popts.Experimental.RemoteStorage = remote.MakeSimpleFactory(map[remote.Locator]remote.Storage{
+		remoteLocator: ropts.Storage,
+	})
+	popts.Experimental.CreateOnShared = remote.CreateOnSharedNone
+
+	ng, err := NewEngineWith("", opts, &popts)
+	if err != nil {
+		return nil, err
+	}
+
+	err = ng.ingestRemote(ropts)
+	if err != nil {
+		_ = ng.Close()
+		return nil, err
+	}
+
+	return ng, nil
+}
+
+// ingestRemote references every remote sstable in the local database.
+func (s *PebbleEngine) ingestRemote(ropts RemoteOptions) error {
+	// the creator ID is only used by Pebble to identify sstables created
+	// by this database on shared storage, which never happens here.
+	err := s.db.SetCreatorID(1)
+	if err != nil {
+		return err
+	}
+
+	names, err := ropts.Storage.List(ropts.Prefix, "")
+	if err != nil {
+		return err
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if !strings.HasSuffix(name, ".sst") {
+			continue
+		}
+		name = ropts.Prefix + name
+
+		f, err := remoteFile(ropts.Storage, name)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read remote sstable %q", name)
+		}
+		if f == nil {
+			// empty sstable
+			continue
+		}
+
+		// sstables are ingested one by one, to allow them to overlap.
+		_, err = s.db.IngestExternalFiles([]pebble.ExternalFile{*f})
+		if err != nil {
+			return errors.Wrapf(err, "failed to load remote sstable %q", name)
+		}
+	}
+
+	return nil
+}
+
+// remoteFile reads the bounds of a remote sstable.
+// It returns nil if the sstable doesn't contain any key.
+func remoteFile(st remote.Storage, name string) (*pebble.ExternalFile, error) {
+	obj, size, err := st.ReadObject(context.Background(), name)
+	if err != nil {
+		return nil, err
+	}
+
+	rd := readable{r: obj, size: size}
+	rd.rh = objstorage.MakeNoopReadHandle(&rd)
+
+	r, err := sstable.NewReader(&rd, sstable.ReaderOptions{
+		Comparer: DefaultComparer,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	it, err := r.NewIter(nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer it.Close()
+
+	first, _ := it.First()
+	if first == nil {
+		return nil, it.Error()
+	}
+	smallest := bytes.Clone(first.UserKey)
+
+	last, _ := it.Last()
+	if last == nil {
+		return nil, it.Error()
+	}
+	// the largest key of an external file is exclusive:
+	// any key prefixed by the last key is greater than it.
+	largest := encoding.EncodeNull(bytes.Clone(last.UserKey))
+
+	return &pebble.ExternalFile{
+		Locator:         remoteLocator,
+		ObjName:         name,
+		Size:            uint64(size),
+		SmallestUserKey: smallest,
+		LargestUserKey:  largest,
+		HasPointKey:     true,
+	}, nil
+}
+
+// NewDirStorage returns a remote.Storage that stores its objects
+// as files in the given directory. Slashes in object names
+// are mapped to subdirectories.
+func NewDirStorage(dir string) remote.Storage {
+	return &dirStorage{dir: dir}
+}
+
+type dirStorage struct {
+	dir string
+}
+
+func (s *dirStorage) path(name string) string {
+	return filepath.Join(s.dir, filepath.FromSlash(name))
+}
+
+func (s *dirStorage) Close() error {
+	return nil
+}
+
+func (s *dirStorage) ReadObject(ctx context.Context, name string) (remote.ObjectReader, int64, error) {
+	f, err := os.Open(s.path(name))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	fi, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, 0, err
+	}
+
+	return &fileReader{f}, fi.Size(), nil
+}
+
+func (s *dirStorage) CreateObject(name string) (io.WriteCloser, error) {
+	p := s.path(name)
+	err := os.MkdirAll(filepath.Dir(p), 0700)
+	if err != nil {
+		return nil, err
+	}
+
+	return os.Create(p)
+}
+
+func (s *dirStorage) List(prefix, delimiter string) ([]string, error) {
+	var names []string
+
+	err := filepath.WalkDir(s.dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(s.dir, p)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+		if !strings.HasPrefix(name, prefix) {
+			return nil
+		}
+		name = name[len(prefix):]
+
+		if delimiter != "" {
+			if i := strings.Index(name, delimiter); i >= 0 {
+				name = name[:i]
+			}
+		}
+		if len(names) == 0 || names[len(names)-1] != name {
+			names = append(names, name)
+		}
+		return nil
+	})
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	return names, err
+}
+
+func (s *dirStorage) Delete(name string) error {
+	return os.Remove(s.path(name))
+}
+
+func (s *dirStorage) Size(name string) (int64, error) {
+	fi, err := os.Stat(s.path(name))
+	if err != nil {
+		return 0, err
+	}
+
+	return fi.Size(), nil
+}
+
+func (s *dirStorage) IsNotExistError(err error) bool {
+	return errors.Is(err, os.ErrNotExist)
+}
+
+type fileReader struct {
+	f *os.File
+}
+
+func (r *fileReader) ReadAt(_ context.Context, p []byte, off int64) error {
+	n, err := r.f.ReadAt(p, off)
+	if err == io.EOF && n == len(p) {
+		return nil
+	}
+	return err
+}
+
+func (r *fileReader) Close() error {
+	return r.f.Close()
+}
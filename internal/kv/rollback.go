@@ -15,6 +15,8 @@ type RollbackSegment struct {
 	buf              []byte
 	seen             map[string]struct{}
 	segmentCommitted bool
+	// simulates crashes, see Options.CrashHook.
+	crash *crashInjector
 }
 
 func NewRollbackSegment(db *pebble.DB, namespace int64) *RollbackSegment {
@@ -51,7 +53,7 @@ func (s *RollbackSegment) Apply(b *pebble.Batch) error {
 		var closer io.Closer
 
 		switch kind {
-		case pebble.InternalKeyKindDelete, pebble.InternalKeyKindSet:
+		case pebble.InternalKeyKindDelete, pebble.InternalKeyKindSet, pebble.InternalKeyKindMerge:
 			v, closer, err = s.db.Get(key)
 			if err != nil {
 				if err != pebble.ErrNotFound {
@@ -136,7 +138,13 @@ func (s *RollbackSegment) Rollback() error {
 	// we don't need to sync here.
 	// in case of a crash, the rollback segment will be rolled back
 	// during the next recovery phase.
-	return b.Commit(pebble.NoSync)
+	err = s.crash.commit(CrashPointRollback, b, pebble.NoSync)
+	if err != nil {
+		return err
+	}
+
+	s.reset()
+	return nil
 }
 
 func (s *RollbackSegment) Reset() error {
@@ -144,20 +152,41 @@ func (s *RollbackSegment) Reset() error {
 	return s.Rollback()
 }
 
+// Empty returns whether the rollback segment stored in the database is empty.
+func (s *RollbackSegment) Empty() (bool, error) {
+	it, err := s.db.NewIter(&pebble.IterOptions{
+		LowerBound: s.nsStart,
+		UpperBound: s.nsEnd,
+	})
+	if err != nil {
+		return false, err
+	}
+	empty := !it.First()
+
+	return empty, it.Close()
+}
+
+// Clear empties the rollback segment in the batch committing the transaction.
+// Committed must be called once the batch is committed: until then,
+// the changes can still be rolled back if the commit fails.
 func (s *RollbackSegment) Clear(b *pebble.Batch) error {
 	if s.segmentCommitted {
-		err := b.DeleteRange(s.nsStart, s.nsEnd, nil)
-		if err != nil {
-			return err
-		}
+		return b.DeleteRange(s.nsStart, s.nsEnd, nil)
 	}
 
-	s.reset()
-
 	return nil
 }
 
+// Committed resets the state of the rollback segment
+// once the transaction is committed.
+func (s *RollbackSegment) Committed() {
+	s.reset()
+}
+
 func (s *RollbackSegment) reset() {
 	s.buf = s.buf[:len(s.nsStart)]
 	s.segmentCommitted = false
+	// the keys of the next transaction must be saved again,
+	// even if they were modified by this one
+	clear(s.seen)
 }
@@ -1,6 +1,8 @@
 package kv
 
 import (
+	gatomic "sync/atomic"
+
 	"github.com/chaisql/chai/internal/engine"
 	"github.com/cockroachdb/errors"
 	"github.com/cockroachdb/pebble"
@@ -8,6 +10,28 @@ import (
 
 type iterator struct {
 	*pebble.Iterator
+
+	// if set, counts the open iterators of the engine.
+	open *gatomic.Int64
+}
+
+// newIterator returns an iterator counted as open by the engine until it is closed.
+func (s *PebbleEngine) newIterator(it *pebble.Iterator) *iterator {
+	s.openIterators.Add(1)
+
+	return &iterator{
+		Iterator: it,
+		open:     &s.openIterators,
+	}
+}
+
+func (i *iterator) Close() error {
+	if i.open != nil {
+		i.open.Add(-1)
+		i.open = nil
+	}
+
+	return i.Iterator.Close()
 }
 
 func (i *iterator) Value() ([]byte, error) {
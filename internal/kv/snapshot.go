@@ -12,6 +12,36 @@ import (
 type snapshot struct {
 	refCount *atomic.Counter
 	snapshot *pebble.Snapshot
+	// snapshots of the other locations.
+	locations map[*location]*pebble.Snapshot
+}
+
+// newSnapshot returns a snapshot of the main database and of every location.
+// Writes are not committed while no write session is open, which makes
+// the snapshots of the locations consistent with each other.
+func (s *PebbleEngine) newSnapshot() *snapshot {
+	sn := snapshot{
+		snapshot: s.db.NewSnapshot(),
+		refCount: atomic.NewCounter(0, math.MaxInt64, false),
+	}
+
+	if len(s.locations) > 0 {
+		sn.locations = make(map[*location]*pebble.Snapshot, len(s.locations))
+		for _, loc := range s.locations {
+			sn.locations[loc] = loc.db.NewSnapshot()
+		}
+	}
+
+	return &sn
+}
+
+// reader returns the snapshot of the location, or of the main database if loc is nil.
+func (s *snapshot) reader(loc *location) pebble.Reader {
+	if loc == nil {
+		return s.snapshot
+	}
+
+	return s.locations[loc]
 }
 
 func (s *snapshot) Incr() {
@@ -20,7 +50,11 @@ func (s *snapshot) Incr() {
 
 func (s *snapshot) Done() error {
 	if s.refCount.Decr() <= 0 {
-		return s.snapshot.Close()
+		err := s.snapshot.Close()
+		for _, sn := range s.locations {
+			err = errors.CombineErrors(err, sn.Close())
+		}
+		return err
 	}
 	return nil
 }
@@ -42,10 +76,7 @@ func (s *PebbleEngine) NewSnapshotSession() engine.Session {
 
 	// if there is no shared snapshot, create one.
 	if sn == nil {
-		sn = &snapshot{
-			snapshot: s.db.NewSnapshot(),
-			refCount: atomic.NewCounter(0, math.MaxInt64, false),
-		}
+		sn = s.newSnapshot()
 	}
 	sn.Incr()
 
@@ -80,12 +111,12 @@ func (s *SnapshotSession) Put(k, v []byte) error {
 
 // Get returns a value associated with the given key. If not found, returns ErrKeyNotFound.
 func (s *SnapshotSession) Get(k []byte) ([]byte, error) {
-	return get(s.Snapshot.snapshot, k)
+	return get(s.Snapshot.reader(s.Store.locationOf(k)), k)
 }
 
 // Exists returns whether a key exists and is visible by the current session.
 func (s *SnapshotSession) Exists(k []byte) (bool, error) {
-	return exists(s.Snapshot.snapshot, k)
+	return exists(s.Snapshot.reader(s.Store.locationOf(k)), k)
 }
 
 // Delete a record by key. If not found, returns ErrKeyNotFound.
@@ -106,12 +137,5 @@ func (s *SnapshotSession) Iterator(opts *engine.IterOptions) (engine.Iterator, e
 		}
 	}
 
-	it, err := s.Snapshot.snapshot.NewIter(popts)
-	if err != nil {
-		return nil, err
-	}
-
-	return &iterator{
-		Iterator: it,
-	}, nil
+	return s.Store.newLocationsIterator(popts, s.Snapshot.reader)
 }
@@ -0,0 +1,222 @@
+package kv
+
+import (
+	"os"
+
+	"github.com/chaisql/chai/internal/engine"
+	"github.com/chaisql/chai/internal/engine/memory"
+	"github.com/chaisql/chai/internal/kv/encryption"
+	"github.com/chaisql/chai/internal/pkg/pebbleutil"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/pebble"
+	"github.com/cockroachdb/pebble/vfs"
+)
+
+const defaultTransientSpillThreshold = 4 << 20 // 4MB
+
+var _ engine.Session = (*SpillSession)(nil)
+
+// SpillSession is a transient session that keeps its data in memory
+// until its size exceeds a threshold. Its data is then moved to a temporary
+// Pebble database, stored in its own directory and removed when the session
+// is closed. Small intermediate results never touch the disk, while large
+// ones don't have to fit in memory.
+type SpillSession struct {
+	opts Options
+
+	// in-memory session, nil once spilled.
+	mem  engine.Session
+	size int
+
+	// set once spilled.
+	db      *pebble.DB
+	dir     string
+	spilled *TransientSession
+
+	closed bool
+}
+
+func (s *PebbleEngine) NewTransientSession() engine.Session {
+	return NewSpillSession(s.opts)
+}
+
+// NewSpillSession returns a transient session that spills to disk
+// past opts.TransientSpillThreshold bytes.
+func NewSpillSession(opts Options) *SpillSession {
+	if opts.TransientSpillThreshold <= 0 {
+		opts.TransientSpillThreshold = defaultTransientSpillThreshold
+	}
+	if opts.MaxTransientBatchSize <= 0 {
+		opts.MaxTransientBatchSize = defaultMaxTransientBatchSize
+	}
+
+	return &SpillSession{
+		opts: opts,
+		mem:  memory.NewEngine().NewTransientSession(),
+	}
+}
+
+// Spilled returns whether the data of the session was moved to disk.
+func (s *SpillSession) Spilled() bool {
+	return s.spilled != nil
+}
+
+func (s *SpillSession) session() engine.Session {
+	if s.spilled != nil {
+		return s.spilled
+	}
+
+	return s.mem
+}
+
+func (s *SpillSession) Commit() error {
+	return errors.New("cannot commit in transient mode")
+}
+
+func (s *SpillSession) Close() error {
+	if s.closed {
+		return errors.New("already closed")
+	}
+	s.closed = true
+
+	if s.spilled == nil {
+		return s.mem.Close()
+	}
+
+	if s.spilled.batch != nil {
+		err := s.spilled.batch.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	err := s.db.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.RemoveAll(s.dir)
+}
+
+func (s *SpillSession) Insert(k, v []byte) error {
+	return errors.New("cannot insert in transient mode")
+}
+
+// Put stores a key value pair. If it already exists, it overrides it.
+// If the size of the in-memory data exceeds the threshold, the data
+// is moved to disk.
+func (s *SpillSession) Put(k, v []byte) error {
+	if s.spilled != nil {
+		return s.spilled.Put(k, v)
+	}
+
+	err := s.mem.Put(k, v)
+	if err != nil {
+		return err
+	}
+
+	s.size += len(k) + len(v)
+	if s.size > s.opts.TransientSpillThreshold {
+		return s.spill()
+	}
+
+	return nil
+}
+
+// spill moves the data of the in-memory session to a temporary database.
+func (s *SpillSession) spill() error {
+	dir, err := os.MkdirTemp(s.opts.TempDir, "chai-transient-")
+	if err != nil {
+		return err
+	}
+
+	popts := pebble.Options{
+		Comparer: DefaultComparer,
+		Logger:   pebbleutil.NoopLoggerAndTracer{},
+		// the data doesn't need to survive a crash
+		DisableWAL: true,
+	}
+	if s.opts.Encryption != nil {
+		// temporary data is as sensitive as the database
+		popts.FS, err = encryption.NewFS(vfs.Default, *s.opts.Encryption)
+		if err != nil {
+			_ = os.RemoveAll(dir)
+			return err
+		}
+	}
+
+	db, err := pebble.Open(dir, popts.EnsureDefaults())
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		return err
+	}
+
+	spilled := &TransientSession{
+		db:           db,
+		maxBatchSize: s.opts.MaxTransientBatchSize,
+	}
+
+	err = copySession(s.mem, spilled)
+	if err != nil {
+		// the session keeps its in-memory data: remove the temporary database
+		if spilled.batch != nil {
+			_ = spilled.batch.Close()
+		}
+		_ = db.Close()
+		_ = os.RemoveAll(dir)
+		return err
+	}
+
+	s.db = db
+	s.dir = dir
+	s.spilled = spilled
+	err = s.mem.Close()
+	s.mem = nil
+	return err
+}
+
+// copySession copies the key value pairs of src to dst.
+func copySession(src engine.Session, dst *TransientSession) error {
+	it, err := src.Iterator(nil)
+	if err != nil {
+		return err
+	}
+	defer it.Close()
+
+	for it.First(); it.Valid(); it.Next() {
+		v, err := it.Value()
+		if err != nil {
+			return err
+		}
+
+		err = dst.Put(it.Key(), v)
+		if err != nil {
+			return err
+		}
+	}
+
+	return it.Error()
+}
+
+// Get returns a value associated with the given key. If not found, returns ErrKeyNotFound.
+func (s *SpillSession) Get(k []byte) ([]byte, error) {
+	return s.session().Get(k)
+}
+
+// Exists returns whether a key exists and is visible by the current session.
+func (s *SpillSession) Exists(k []byte) (bool, error) {
+	return s.session().Exists(k)
+}
+
+// Delete a record by key. If not found, returns ErrKeyNotFound.
+func (s *SpillSession) Delete(k []byte) error {
+	return s.session().Delete(k)
+}
+
+func (s *SpillSession) DeleteRange(start []byte, end []byte) error {
+	return s.session().DeleteRange(start, end)
+}
+
+func (s *SpillSession) Iterator(opts *engine.IterOptions) (engine.Iterator, error) {
+	return s.session().Iterator(opts)
+}
@@ -0,0 +1,38 @@
+package kv
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/chaisql/chai/internal/engine"
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// failingSession is a session whose data cannot be read.
+type failingSession struct {
+	engine.Session
+}
+
+func (f failingSession) Iterator(*engine.IterOptions) (engine.Iterator, error) {
+	return nil, errors.New("boom")
+}
+
+func TestSpillSessionFailure(t *testing.T) {
+	dir := t.TempDir()
+	s := NewSpillSession(Options{TempDir: dir, TransientSpillThreshold: 1000})
+	s.mem = failingSession{Session: s.mem}
+
+	err := s.Put(encoding.EncodeInt(nil, 1), bytes.Repeat([]byte("a"), 2000))
+	require.ErrorContains(t, err, "boom")
+	require.False(t, s.Spilled())
+
+	// the temporary database is removed
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+
+	require.NoError(t, s.Close())
+}
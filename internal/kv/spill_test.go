@@ -0,0 +1,74 @@
+package kv_test
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/chaisql/chai/internal/engine"
+	"github.com/chaisql/chai/internal/kv"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpillSession(t *testing.T) {
+	dir := t.TempDir()
+	s := kv.NewSpillSession(kv.Options{TempDir: dir, TransientSpillThreshold: 10_000})
+
+	key := func(i int64) []byte {
+		return encoding.EncodeInt(encoding.EncodeInt(nil, 1000), i)
+	}
+	v := bytes.Repeat([]byte("a"), 100)
+
+	count := func() int {
+		it, err := s.Iterator(nil)
+		require.NoError(t, err)
+		defer it.Close()
+
+		var n int
+		var prev []byte
+		for it.First(); it.Valid(); it.Next() {
+			if prev != nil {
+				require.Negative(t, encoding.Compare(prev, it.Key()))
+			}
+			prev = append(prev[:0], it.Key()...)
+			n++
+		}
+		return n
+	}
+
+	// small results stay in memory
+	for i := int64(0); i < 50; i++ {
+		require.NoError(t, s.Put(key(i), v))
+	}
+	require.False(t, s.Spilled())
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+
+	// past the threshold, the data is moved to disk
+	for i := int64(50); i < 1000; i++ {
+		require.NoError(t, s.Put(key(i), v))
+	}
+	require.True(t, s.Spilled())
+	entries, err = os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	got, err := s.Get(key(10))
+	require.NoError(t, err)
+	require.Equal(t, v, got)
+	require.Equal(t, 1000, count())
+
+	require.NoError(t, s.Delete(key(10)))
+	_, err = s.Get(key(10))
+	require.ErrorIs(t, err, engine.ErrKeyNotFound)
+	require.NoError(t, s.DeleteRange(key(500), key(1000)))
+	require.Equal(t, 499, count())
+
+	// the temporary database is removed on close
+	require.NoError(t, s.Close())
+	entries, err = os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
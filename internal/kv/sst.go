@@ -0,0 +1,88 @@
+package kv
+
+import (
+	"context"
+	"io"
+
+	"github.com/chaisql/chai/internal/engine"
+	"github.com/cockroachdb/pebble/objstorage"
+	"github.com/cockroachdb/pebble/objstorage/objstorageprovider"
+	"github.com/cockroachdb/pebble/objstorage/remote"
+	"github.com/cockroachdb/pebble/sstable"
+)
+
+// sstTableFormat is the format of the sstables written by WriteSST.
+// It is the most recent format readable by every format major version
+// used by the engine.
+const sstTableFormat = sstable.TableFormatPebblev1
+
+// noMergerName is the name of the merger of sstables without
+// merge operands, accepted by every database.
+const noMergerName = "nullptr"
+
+// WriteSST writes every key-value pair returned by the iterator to w,
+// in the sstable format. The iterator must return keys in ascending order.
+// If skip is not nil, keys for which it returns true are not written.
+func WriteSST(w io.Writer, it engine.Iterator, skip func(k []byte) bool) error {
+	sw := sstable.NewWriter(objstorageprovider.NewRemoteWritable(nopWriteCloser{w}), sstable.WriterOptions{
+		Comparer:    DefaultComparer,
+		TableFormat: sstTableFormat,
+		// the iterator returns merged values: the sstables contain
+		// no merge operand and can be read with any merger.
+		MergerName: noMergerName,
+	})
+
+	for it.First(); it.Valid(); it.Next() {
+		k := it.Key()
+		if skip != nil && skip(k) {
+			continue
+		}
+
+		v, err := it.Value()
+		if err != nil {
+			_ = sw.Close()
+			return err
+		}
+
+		err = sw.Set(k, v)
+		if err != nil {
+			_ = sw.Close()
+			return err
+		}
+	}
+	if err := it.Error(); err != nil {
+		_ = sw.Close()
+		return err
+	}
+
+	return sw.Close()
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+// readable adapts an object of a remote storage to the objstorage.Readable interface.
+type readable struct {
+	r    remote.ObjectReader
+	size int64
+	rh   objstorage.NoopReadHandle
+}
+
+func (r *readable) ReadAt(ctx context.Context, p []byte, off int64) error {
+	return r.r.ReadAt(ctx, p, off)
+}
+
+func (r *readable) Close() error {
+	return r.r.Close()
+}
+
+func (r *readable) Size() int64 {
+	return r.size
+}
+
+func (r *readable) NewReadHandle(_ context.Context) objstorage.ReadHandle {
+	return &r.rh
+}
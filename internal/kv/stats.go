@@ -0,0 +1,171 @@
+package kv
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/cockroachdb/pebble"
+)
+
+// LevelStats describes the files of a level of the LSM
+// that contain keys of a given range.
+type LevelStats struct {
+	Level int
+	// Number of files overlapping the range.
+	Files int
+	// Approximate number of bytes of these files used by the range.
+	Size uint64
+}
+
+// RangeStats describes the disk usage of a range of keys.
+// Data that was not flushed from memory yet is not taken into account.
+type RangeStats struct {
+	// Approximate number of bytes used on disk by the range.
+	Size uint64
+	// Levels of the LSM containing keys of the range, from L0.
+	// Empty levels are omitted.
+	Levels []LevelStats
+}
+
+// Compact compacts the keys between start (inclusive) and end (exclusive),
+// flushing them from memory first if needed. Compaction rewrites the files
+// containing the range, which reclaims the space used by deleted or
+// overwritten keys.
+func (s *PebbleEngine) Compact(start, end []byte) error {
+	for _, loc := range s.locationsOf(&pebble.IterOptions{LowerBound: start, UpperBound: end}) {
+		err := s.dbOf(loc).Compact(start, end, true)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RangeStats returns the disk usage of the keys between start (inclusive) and end (exclusive).
+// If the range is stored in several locations, their usage is added up.
+func (s *PebbleEngine) RangeStats(start, end []byte) (*RangeStats, error) {
+	var stats RangeStats
+
+	for _, loc := range s.locationsOf(&pebble.IterOptions{LowerBound: start, UpperBound: end}) {
+		db := s.dbOf(loc)
+
+		size, err := db.EstimateDiskUsage(start, end)
+		if err != nil {
+			return nil, err
+		}
+		stats.Size += size
+
+		levels, err := db.SSTables(
+			pebble.WithKeyRangeFilter(start, end),
+			pebble.WithProperties(),
+			pebble.WithApproximateSpanBytes(),
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		for i, files := range levels {
+			if len(files) == 0 {
+				continue
+			}
+
+			var ls *LevelStats
+			for j := range stats.Levels {
+				if stats.Levels[j].Level == i {
+					ls = &stats.Levels[j]
+				}
+			}
+			if ls == nil {
+				stats.Levels = append(stats.Levels, LevelStats{Level: i})
+				ls = &stats.Levels[len(stats.Levels)-1]
+			}
+
+			ls.Files += len(files)
+			for _, f := range files {
+				n, err := strconv.ParseUint(f.Properties.UserProperties["approximate-span-bytes"], 10, 64)
+				if err != nil {
+					return nil, err
+				}
+				ls.Size += n
+			}
+		}
+	}
+
+	sort.Slice(stats.Levels, func(i, j int) bool {
+		return stats.Levels[i].Level < stats.Levels[j].Level
+	})
+
+	return &stats, nil
+}
+
+// EngineStats contains counters describing the activity of the engine.
+// Counters are cumulative since the engine was opened, other values
+// describe the state of the engine when the stats were collected.
+type EngineStats struct {
+	// Number of bytes used by the block cache.
+	BlockCacheSize int64
+	// Number of blocks found, or not, in the block cache.
+	BlockCacheHits   int64
+	BlockCacheMisses int64
+
+	// Number of flushes of the memtables to disk and number of bytes written by them.
+	Flushes      int64
+	BytesFlushed uint64
+	// Number of compactions and number of bytes written by them.
+	Compactions    int64
+	BytesCompacted uint64
+	// Number of bytes that must be compacted for the storage to reach a stable shape.
+	CompactionDebt uint64
+
+	// Number of bytes of the memtables.
+	MemTableSize uint64
+	// Number of WAL files and number of bytes they use on disk.
+	WALFiles int64
+	WALSize  uint64
+	// Number of bytes used on disk by the engine, including the WAL.
+	DiskUsage uint64
+	// Number of sstables that must be read, in the worst case, to find a key.
+	ReadAmplification int
+
+	// Number of iterators opened by sessions and not closed yet.
+	OpenIterators int64
+	// Number of open snapshots.
+	Snapshots int
+}
+
+// BlockCacheHitRate returns the proportion of blocks read from the cache,
+// between 0 and 1. It returns 0 if no block was read.
+func (s *EngineStats) BlockCacheHitRate() float64 {
+	total := s.BlockCacheHits + s.BlockCacheMisses
+	if total == 0 {
+		return 0
+	}
+
+	return float64(s.BlockCacheHits) / float64(total)
+}
+
+// Stats returns the counters of the engine.
+// The other locations are not taken into account.
+func (s *PebbleEngine) Stats() *EngineStats {
+	m := s.db.Metrics()
+	total := m.Total()
+
+	return &EngineStats{
+		BlockCacheSize:    m.BlockCache.Size,
+		BlockCacheHits:    m.BlockCache.Hits,
+		BlockCacheMisses:  m.BlockCache.Misses,
+		Flushes:           m.Flush.Count,
+		BytesFlushed:      total.BytesFlushed,
+		Compactions:       m.Compact.Count,
+		BytesCompacted:    total.BytesCompacted,
+		CompactionDebt:    m.Compact.EstimatedDebt,
+		MemTableSize:      m.MemTable.Size,
+		WALFiles:          m.WAL.Files,
+		WALSize:           m.WAL.PhysicalSize,
+		DiskUsage:         m.DiskSpaceUsage(),
+		ReadAmplification: m.ReadAmp(),
+		OpenIterators:     s.openIterators.Load(),
+		Snapshots:         m.Snapshots.Count,
+	}
+}
@@ -0,0 +1,62 @@
+package kv
+
+import (
+	"time"
+
+	"github.com/cockroachdb/pebble"
+)
+
+// SyncMode controls when committed transactions are synced to disk.
+type SyncMode uint8
+
+const (
+	// SyncAlways syncs the WAL before every commit returns.
+	// A committed transaction survives a crash of the machine.
+	SyncAlways SyncMode = iota
+	// SyncPeriodic syncs the WAL in the background at a fixed interval.
+	// A crash of the machine loses the transactions committed since the last sync.
+	SyncPeriodic
+	// SyncNever never syncs the WAL explicitly and lets the operating system
+	// write it to disk. A crash of the process doesn't lose any transaction,
+	// but a crash of the machine can lose an unbounded number of them.
+	SyncNever
+)
+
+const defaultSyncInterval = 100 * time.Millisecond
+
+// writeOptions returns the options used to commit transactions.
+func (s *PebbleEngine) writeOptions() *pebble.WriteOptions {
+	if s.opts.SyncMode == SyncAlways {
+		return pebble.Sync
+	}
+
+	s.unsynced.Store(true)
+	return pebble.NoSync
+}
+
+// syncPeriodically syncs the WAL at every interval, if transactions
+// were committed since the last sync, until stop is closed.
+func (s *PebbleEngine) syncPeriodically(interval time.Duration, stop, done chan struct{}) {
+	defer close(done)
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-t.C:
+		}
+
+		if !s.unsynced.Swap(false) {
+			continue
+		}
+
+		// an empty record is enough to sync everything written before it
+		err := s.db.LogData(nil, pebble.Sync)
+		if err != nil {
+			s.unsynced.Store(true)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package kv_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/chaisql/chai/internal/kv"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyncMode(t *testing.T) {
+	tests := []struct {
+		name string
+		mode kv.SyncMode
+	}{
+		{"Always", kv.SyncAlways},
+		{"Periodic", kv.SyncPeriodic},
+		{"Never", kv.SyncNever},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			walDir := t.TempDir()
+
+			opts := kv.Options{
+				RollbackSegmentNamespace: int64(database.RollbackSegmentNamespace),
+				MinTransientNamespace:    uint64(database.MinTransientNamespace),
+				MaxTransientNamespace:    uint64(database.MaxTransientNamespace),
+				SyncMode:                 test.mode,
+				SyncInterval:             time.Millisecond,
+				WALDir:                   walDir,
+				WALBytesPerSync:          512 << 10,
+			}
+
+			ng, err := kv.NewEngine(dir, opts)
+			require.NoError(t, err)
+
+			k := encoding.EncodeInt(encoding.EncodeInt(nil, 10), 1)
+			s := ng.NewBatchSession()
+			require.NoError(t, s.Put(k, []byte("a")))
+			require.NoError(t, s.Commit())
+
+			// let the background sync run
+			time.Sleep(10 * time.Millisecond)
+			require.NoError(t, ng.Close())
+
+			entries, err := os.ReadDir(walDir)
+			require.NoError(t, err)
+			var wals int
+			for _, e := range entries {
+				if strings.HasSuffix(e.Name(), ".log") {
+					wals++
+				}
+			}
+			require.NotZero(t, wals)
+
+			matches, err := filepath.Glob(filepath.Join(dir, "pebble", "*.log"))
+			require.NoError(t, err)
+			require.Empty(t, matches)
+
+			ng, err = kv.NewEngine(dir, opts)
+			require.NoError(t, err)
+			defer ng.Close()
+
+			s = ng.NewSnapshotSession()
+			defer s.Close()
+			v, err := s.Get(k)
+			require.NoError(t, err)
+			require.Equal(t, []byte("a"), v)
+		})
+	}
+}
@@ -8,22 +8,16 @@ import (
 
 var _ engine.Session = (*TransientSession)(nil)
 
+// TransientSession stores the data of a spilled SpillSession
+// in a temporary database, using batches committed once they
+// exceed a maximum size.
 type TransientSession struct {
 	db           *pebble.DB
 	batch        *pebble.Batch
-	store        *PebbleEngine
 	maxBatchSize int
 	closed       bool
 }
 
-func (s *PebbleEngine) NewTransientSession() engine.Session {
-	return &TransientSession{
-		db:           s.db,
-		maxBatchSize: s.opts.MaxTransientBatchSize,
-		store:        s,
-	}
-}
-
 func (s *TransientSession) Commit() error {
 	return errors.New("cannot commit in transient mode")
 }
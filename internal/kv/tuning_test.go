@@ -0,0 +1,57 @@
+package kv_test
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/chaisql/chai/internal/kv"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTuning(t *testing.T) {
+	// returns the number of files in the storage after writing 4MB of random data
+	// and compacting it, and the number of flushes before the compaction
+	write := func(opts kv.Options) (files int64, flushes int64) {
+		opts.RollbackSegmentNamespace = int64(database.RollbackSegmentNamespace)
+		opts.MinTransientNamespace = uint64(database.MinTransientNamespace)
+		opts.MaxTransientNamespace = uint64(database.MaxTransientNamespace)
+
+		ng, err := kv.NewEngine(t.TempDir(), opts)
+		require.NoError(t, err)
+		defer ng.Close()
+
+		v := make([]byte, 1000)
+		for i := int64(0); i < 4000; i += 100 {
+			s := ng.NewBatchSession()
+			for j := i; j < i+100; j++ {
+				_, err := rand.Read(v)
+				require.NoError(t, err)
+				err = s.Put(encoding.EncodeInt(encoding.EncodeInt(nil, 10), j), v)
+				require.NoError(t, err)
+			}
+			require.NoError(t, s.Commit())
+		}
+		m := ng.DB().Metrics()
+		flushes = m.Flush.Count
+
+		require.NoError(t, ng.Compact(encoding.EncodeInt(nil, 10), encoding.EncodeInt(nil, 11)))
+		return ng.DB().Metrics().Total().NumFiles, flushes
+	}
+
+	defaultFiles, defaultFlushes := write(kv.Options{})
+
+	// small memtables are flushed more often
+	// and small target sizes split the data in more files
+	files, flushes := write(kv.Options{
+		MemTableSize:             1 << 20,
+		TargetFileSize:           256 << 10,
+		CacheSize:                1 << 20,
+		MaxOpenFiles:             100,
+		L0CompactionThreshold:    20,
+		MaxConcurrentCompactions: 2,
+	})
+	require.Greater(t, files, 2*defaultFiles)
+	require.Greater(t, flushes, defaultFlushes)
+}
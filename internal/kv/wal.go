@@ -0,0 +1,529 @@
+package kv
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/pebble"
+	"github.com/cockroachdb/pebble/objstorage/remote"
+	"github.com/cockroachdb/pebble/record"
+	"github.com/cockroachdb/pebble/vfs"
+)
+
+// Markers are written to the WAL with Batch.LogData.
+// They are ignored by Pebble, which doesn't store them in the memtables,
+// but they are read when the WAL is replayed by RestorePointInTime.
+var (
+	// written with every committed transaction, followed by the commit time.
+	commitMarker = []byte("chai-commit:")
+	// written before creating a checkpoint, followed by the checkpoint ID.
+	checkpointMarker = []byte("chai-checkpoint:")
+)
+
+const (
+	walExt = ".log"
+
+	// name of the file storing the ID and time of a checkpoint.
+	checkpointFile   = "CHECKPOINT"
+	checkpointIDSize = 16
+)
+
+// walArchiveFS intercepts the files moved to the archive directory by
+// pebble.ArchiveCleaner: WAL files are uploaded to the storage,
+// other files are deleted.
+// Using ArchiveCleaner also prevents Pebble from recycling WAL files.
+type walArchiveFS struct {
+	vfs.FS
+
+	storage remote.Storage
+	prefix  string
+}
+
+func (fs *walArchiveFS) isArchive(oldname, newname string) bool {
+	dir := fs.PathDir(newname)
+	return fs.PathBase(dir) == "archive" && dir == fs.PathJoin(fs.PathDir(oldname), "archive")
+}
+
+func (fs *walArchiveFS) MkdirAll(dir string, perm os.FileMode) error {
+	if fs.PathBase(dir) == "archive" {
+		return nil
+	}
+
+	return fs.FS.MkdirAll(dir, perm)
+}
+
+func (fs *walArchiveFS) Rename(oldname, newname string) error {
+	if !fs.isArchive(oldname, newname) {
+		return fs.FS.Rename(oldname, newname)
+	}
+
+	if strings.HasSuffix(oldname, walExt) {
+		err := fs.upload(oldname)
+		if err != nil {
+			return errors.Wrapf(err, "failed to archive %q", oldname)
+		}
+	}
+
+	return fs.FS.Remove(oldname)
+}
+
+func (fs *walArchiveFS) upload(name string) error {
+	f, err := fs.FS.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w, err := fs.storage.CreateObject(fs.prefix + fs.PathBase(name))
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(w, f)
+	if cerr := w.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// logCommit records the commit time in the WAL if WAL archiving is enabled,
+// to allow replaying archived WAL files up to a given time.
+func (s *PebbleEngine) logCommit(b *pebble.Batch) error {
+	if s.opts.WALArchive == nil {
+		return nil
+	}
+
+	marker := binary.BigEndian.AppendUint64(bytes.Clone(commitMarker), uint64(time.Now().UnixNano()))
+	return b.LogData(marker, nil)
+}
+
+// Checkpoint creates a copy of the database in dir, which must not already contain a database.
+// The copy can be opened as a regular database, or used as the starting point
+// of RestorePointInTime if WAL archiving is enabled.
+func (s *PebbleEngine) Checkpoint(dir string) error {
+	if len(s.locations) > 0 {
+		return errors.New("checkpoints are not supported with locations")
+	}
+
+	id, err := s.markCheckpoint()
+	if err != nil {
+		return err
+	}
+
+	return s.createCheckpoint(dir, id)
+}
+
+// markCheckpoint writes the marker of a new checkpoint to the WAL
+// and returns the ID of the checkpoint.
+func (s *PebbleEngine) markCheckpoint() ([]byte, error) {
+	id := make([]byte, checkpointIDSize)
+	_, err := rand.Read(id)
+	if err != nil {
+		return nil, err
+	}
+
+	b := s.db.NewBatch()
+	err = b.LogData(append(bytes.Clone(checkpointMarker), id...), nil)
+	if err == nil {
+		err = b.Commit(pebble.Sync)
+	}
+	_ = b.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return id, nil
+}
+
+// createCheckpoint copies the database to dir once its marker is written.
+// Transactions committed in the meantime are part of the copy:
+// replay skips them using their sequence number.
+func (s *PebbleEngine) createCheckpoint(dir string, id []byte) error {
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.Checkpoint(filepath.Join(dir, "pebble"), pebble.WithFlushedWAL())
+	if err != nil {
+		return err
+	}
+
+	buf := make([]byte, checkpointIDSize+8)
+	copy(buf, id)
+	binary.BigEndian.PutUint64(buf[checkpointIDSize:], uint64(time.Now().UnixNano()))
+
+	return os.WriteFile(filepath.Join(dir, checkpointFile), buf, 0600)
+}
+
+// PointInTimeOptions describe how to restore a database with RestorePointInTime.
+type PointInTimeOptions struct {
+	// Directory of a checkpoint created with Checkpoint.
+	Checkpoint string
+	// Storage containing the archived WAL files.
+	Archive remote.Storage
+	// Prefix of the WAL files in the storage.
+	Prefix string
+	// Optional directory of the original database. Its WAL files that weren't
+	// archived yet are replayed after the archived ones.
+	// The database must be closed.
+	Path string
+	// Transactions committed after Target are not restored.
+	// It must be after the creation of the checkpoint.
+	Target time.Time
+}
+
+// RestorePointInTime creates a database at path, which must not exist,
+// from a checkpoint and replays the archived WAL files
+// until the last transaction committed before the target time.
+// Transactions that were still running at the target time are rolled back
+// by Recover when the database is opened.
+func RestorePointInTime(path string, opts Options, popts PointInTimeOptions) error {
+	if popts.Archive == nil {
+		return errors.New("WAL archive storage cannot be nil")
+	}
+	if opts.Encryption != nil {
+		return errors.New("WAL archiving cannot be used with encryption")
+	}
+
+	id, created, err := readCheckpointFile(popts.Checkpoint)
+	if err != nil {
+		return err
+	}
+	if popts.Target.Before(created) {
+		return errors.Newf("target time %s is before the creation of the checkpoint (%s)", popts.Target, created)
+	}
+
+	_, err = os.Stat(path)
+	if err == nil {
+		return errors.Newf("%q already exists", path)
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	err = copyDir(filepath.Join(popts.Checkpoint, "pebble"), filepath.Join(path, "pebble"))
+	if err != nil {
+		return err
+	}
+
+	wals, err := listWALs(popts)
+	if err != nil {
+		return err
+	}
+
+	opts.WALArchive = nil
+	ng, err := NewEngine(path, opts)
+	if err != nil {
+		return err
+	}
+
+	err = ng.replay(wals, id, popts.Target)
+	if err != nil {
+		_ = ng.Close()
+		return err
+	}
+
+	return ng.Close()
+}
+
+// replay applies the batches written after the checkpoint marker,
+// and stops at the first transaction committed after the target.
+// The batches committed between the marker and the copy of the database
+// are already part of the checkpoint and are skipped: applying them again
+// would apply their Merge operands twice.
+func (s *PebbleEngine) replay(wals []walFile, id []byte, target time.Time) error {
+	marker := append(bytes.Clone(checkpointMarker), id...)
+	var found bool
+
+	next, err := s.nextSeqNum()
+	if err != nil {
+		return err
+	}
+
+	for _, wal := range wals {
+		stop, err := wal.read(func(repr []byte) (bool, error) {
+			if !found {
+				found = hasLogData(repr, func(data []byte) bool {
+					return bytes.Equal(data, marker)
+				})
+				return false, nil
+			}
+
+			late := hasLogData(repr, func(data []byte) bool {
+				if !bytes.HasPrefix(data, commitMarker) || len(data) != len(commitMarker)+8 {
+					return false
+				}
+				ts := int64(binary.BigEndian.Uint64(data[len(commitMarker):]))
+				return ts > target.UnixNano()
+			})
+			if late {
+				return true, nil
+			}
+			if batchSeqNum(repr) < next {
+				return false, nil
+			}
+
+			b := s.db.NewBatch()
+			err := b.SetRepr(bytes.Clone(repr))
+			if err == nil {
+				err = s.db.Apply(b, pebble.NoSync)
+			}
+			_ = b.Close()
+			return false, err
+		})
+		if err != nil {
+			return errors.Wrapf(err, "failed to replay %q", wal.name)
+		}
+		if stop {
+			break
+		}
+	}
+
+	if !found {
+		return errors.New("checkpoint not found in the WAL archive")
+	}
+
+	return s.db.Flush()
+}
+
+// nextSeqNum returns the sequence number of the next batch committed
+// to the database. Batches are written to the WAL in the order of their
+// sequence numbers, so the batches of the WAL included in a checkpoint
+// are the ones with a lower sequence number, once the checkpoint is opened.
+func (s *PebbleEngine) nextSeqNum() (uint64, error) {
+	// a batch with no operations doesn't consume a sequence number
+	b := s.db.NewBatch()
+	defer b.Close()
+
+	err := b.LogData(nil, nil)
+	if err != nil {
+		return 0, err
+	}
+	err = b.Commit(pebble.NoSync)
+	if err != nil {
+		return 0, err
+	}
+
+	return b.SeqNum(), nil
+}
+
+// batchSeqNum returns the sequence number stored in the header of a batch of the WAL.
+func batchSeqNum(repr []byte) uint64 {
+	if len(repr) < 8 {
+		return 0
+	}
+
+	return binary.LittleEndian.Uint64(repr)
+}
+
+// hasLogData returns true if fn returns true for one of the LogData records of the batch.
+func hasLogData(repr []byte, fn func(data []byte) bool) bool {
+	r, _ := pebble.ReadBatch(repr)
+	for {
+		// the data of LogData records is returned as the key
+		kind, data, _, ok, err := r.Next()
+		if !ok || err != nil {
+			return false
+		}
+		if kind == pebble.InternalKeyKindLogData && fn(data) {
+			return true
+		}
+	}
+}
+
+func readCheckpointFile(dir string) ([]byte, time.Time, error) {
+	b, err := os.ReadFile(filepath.Join(dir, checkpointFile))
+	if err != nil {
+		return nil, time.Time{}, errors.Wrap(err, "invalid checkpoint")
+	}
+	if len(b) != checkpointIDSize+8 {
+		return nil, time.Time{}, errors.New("invalid checkpoint")
+	}
+
+	return b[:checkpointIDSize], time.Unix(0, int64(binary.BigEndian.Uint64(b[checkpointIDSize:]))), nil
+}
+
+// walFile is a WAL file stored either in the archive or in the database directory.
+type walFile struct {
+	name   string
+	logNum pebble.FileNum
+	open   func() (io.ReadCloser, error)
+}
+
+// read calls fn with every batch of the WAL, until fn returns true.
+// It returns whether fn returned true.
+func (w *walFile) read(fn func(repr []byte) (bool, error)) (bool, error) {
+	f, err := w.open()
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	r := record.NewReader(f, w.logNum)
+	for {
+		rr, err := r.Next()
+		if err == io.EOF || record.IsInvalidRecord(err) {
+			// a torn write at the end of the WAL
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+
+		repr, err := io.ReadAll(rr)
+		if err != nil {
+			if record.IsInvalidRecord(err) {
+				return false, nil
+			}
+			return false, err
+		}
+
+		stop, err := fn(repr)
+		if err != nil || stop {
+			return stop, err
+		}
+	}
+}
+
+func parseLogNum(name string) (pebble.FileNum, bool) {
+	if !strings.HasSuffix(name, walExt) {
+		return 0, false
+	}
+
+	n, err := strconv.ParseUint(strings.TrimSuffix(name, walExt), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return pebble.FileNum(n), true
+}
+
+// listWALs returns the WAL files of the archive and of the original database,
+// sorted by log number.
+func listWALs(popts PointInTimeOptions) ([]walFile, error) {
+	wals := make(map[pebble.FileNum]walFile)
+
+	names, err := popts.Archive.List(popts.Prefix, "")
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		n, ok := parseLogNum(name)
+		if !ok {
+			continue
+		}
+
+		obj := popts.Prefix + name
+		wals[n] = walFile{
+			name:   obj,
+			logNum: n,
+			open: func() (io.ReadCloser, error) {
+				r, size, err := popts.Archive.ReadObject(context.Background(), obj)
+				if err != nil {
+					return nil, err
+				}
+				return &objectReader{r: r, size: size}, nil
+			},
+		}
+	}
+
+	if popts.Path != "" {
+		dir := filepath.Join(popts.Path, "pebble")
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			n, ok := parseLogNum(e.Name())
+			if !ok {
+				continue
+			}
+			if _, ok := wals[n]; ok {
+				continue
+			}
+
+			p := filepath.Join(dir, e.Name())
+			wals[n] = walFile{
+				name:   p,
+				logNum: n,
+				open: func() (io.ReadCloser, error) {
+					return os.Open(p)
+				},
+			}
+		}
+	}
+
+	list := make([]walFile, 0, len(wals))
+	for _, w := range wals {
+		list = append(list, w)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].logNum < list[j].logNum
+	})
+
+	return list, nil
+}
+
+// objectReader reads an object of a remote storage sequentially.
+type objectReader struct {
+	r    remote.ObjectReader
+	size int64
+	off  int64
+}
+
+func (r *objectReader) Read(p []byte) (int, error) {
+	if r.off >= r.size {
+		return 0, io.EOF
+	}
+	if rem := r.size - r.off; int64(len(p)) > rem {
+		p = p[:rem]
+	}
+
+	err := r.r.ReadAt(context.Background(), p, r.off)
+	if err != nil {
+		return 0, err
+	}
+	r.off += int64(len(p))
+	return len(p), nil
+}
+
+func (r *objectReader) Close() error {
+	return r.r.Close()
+}
+
+// copyDir copies the files of src to dst.
+func copyDir(src, dst string) error {
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(dst, 0700)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		if !e.Type().IsRegular() {
+			continue
+		}
+
+		err = vfs.Copy(vfs.Default, filepath.Join(src, e.Name()), filepath.Join(dst, e.Name()))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
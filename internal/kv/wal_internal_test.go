@@ -0,0 +1,79 @@
+package kv
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/chaisql/chai/internal/engine"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRestorePointInTimeMergeDuringCheckpoint(t *testing.T) {
+	dir := t.TempDir()
+	archive := NewDirStorage(filepath.Join(dir, "archive"))
+
+	opts := Options{
+		RollbackSegmentNamespace: 2,
+		MinTransientNamespace:    1 << 32,
+		MaxTransientNamespace:    1<<32 + 1000,
+	}
+
+	aopts := opts
+	aopts.WALArchive = archive
+	aopts.WALArchivePrefix = "wal/"
+
+	ng, err := NewEngine(filepath.Join(dir, "db"), aopts)
+	require.NoError(t, err)
+
+	key := encoding.EncodeInt(encoding.EncodeInt(nil, 10), 1)
+
+	write := func(fn func(s engine.Session) error) {
+		s := ng.NewBatchSession()
+		require.NoError(t, fn(s))
+		require.NoError(t, s.Commit())
+	}
+	appendValue := func(s engine.Session, suffix string) error {
+		v, err := s.Get(key)
+		if err != nil {
+			return err
+		}
+		return s.(engine.MergeSession).Merge(key, engine.Delta{Offset: len(v), Inserted: []byte(suffix)})
+	}
+
+	write(func(s engine.Session) error { return s.Put(key, []byte("a")) })
+
+	// a transaction is committed after the marker of the checkpoint
+	// is written and before the database is copied
+	id, err := ng.markCheckpoint()
+	require.NoError(t, err)
+	write(func(s engine.Session) error { return appendValue(s, "b") })
+	require.NoError(t, ng.createCheckpoint(filepath.Join(dir, "checkpoint"), id))
+
+	time.Sleep(time.Millisecond)
+	write(func(s engine.Session) error { return appendValue(s, "c") })
+	require.NoError(t, ng.Close())
+
+	path := filepath.Join(t.TempDir(), "restored")
+	err = RestorePointInTime(path, opts, PointInTimeOptions{
+		Checkpoint: filepath.Join(dir, "checkpoint"),
+		Archive:    archive,
+		Prefix:     "wal/",
+		Path:       filepath.Join(dir, "db"),
+		Target:     time.Now(),
+	})
+	require.NoError(t, err)
+
+	ng, err = NewEngine(path, opts)
+	require.NoError(t, err)
+	defer ng.Close()
+	require.NoError(t, ng.Recover())
+
+	s := ng.NewSnapshotSession()
+	defer s.Close()
+
+	v, err := s.Get(key)
+	require.NoError(t, err)
+	require.Equal(t, "abc", string(v))
+}
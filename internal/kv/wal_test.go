@@ -0,0 +1,114 @@
+package kv_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/chaisql/chai/internal/engine"
+	"github.com/chaisql/chai/internal/kv"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRestorePointInTime(t *testing.T) {
+	dir := t.TempDir()
+	archive := kv.NewDirStorage(filepath.Join(dir, "archive"))
+
+	opts := kv.Options{
+		RollbackSegmentNamespace: int64(database.RollbackSegmentNamespace),
+		MinTransientNamespace:    uint64(database.MinTransientNamespace),
+		MaxTransientNamespace:    uint64(database.MaxTransientNamespace),
+	}
+
+	aopts := opts
+	aopts.WALArchive = archive
+	aopts.WALArchivePrefix = "wal/"
+
+	ng, err := kv.NewEngine(filepath.Join(dir, "db"), aopts)
+	require.NoError(t, err)
+
+	key := func(i int64) []byte {
+		return encoding.EncodeInt(encoding.EncodeInt(nil, 10), i)
+	}
+
+	write := func(fn func(s engine.Session) error) time.Time {
+		s := ng.NewBatchSession()
+		require.NoError(t, fn(s))
+		require.NoError(t, s.Commit())
+		ts := time.Now()
+		// ensure the next commit happens strictly after ts
+		time.Sleep(time.Millisecond)
+		return ts
+	}
+
+	write(func(s engine.Session) error { return s.Put(key(1), []byte("a")) })
+
+	err = ng.Checkpoint(filepath.Join(dir, "checkpoint"))
+	require.NoError(t, err)
+
+	t1 := write(func(s engine.Session) error { return s.Put(key(2), []byte("b")) })
+	// flush to force the archiving of the current WAL file
+	require.NoError(t, ng.DB().Flush())
+	t2 := write(func(s engine.Session) error { return s.Delete(key(1)) })
+
+	// this transaction is rolled back
+	s := ng.NewBatchSession()
+	require.NoError(t, s.Put(key(3), []byte("c")))
+	require.NoError(t, s.Close())
+	require.NoError(t, ng.Rollback())
+
+	require.NoError(t, ng.Close())
+
+	names, err := archive.List("wal/", "")
+	require.NoError(t, err)
+	require.NotEmpty(t, names)
+	for _, name := range names {
+		require.True(t, strings.HasSuffix(name, ".log"), name)
+	}
+
+	restore := func(t *testing.T, target time.Time) map[string]string {
+		t.Helper()
+
+		path := filepath.Join(t.TempDir(), "restored")
+		err := kv.RestorePointInTime(path, opts, kv.PointInTimeOptions{
+			Checkpoint: filepath.Join(dir, "checkpoint"),
+			Archive:    archive,
+			Prefix:     "wal/",
+			Path:       filepath.Join(dir, "db"),
+			Target:     target,
+		})
+		require.NoError(t, err)
+
+		ng, err := kv.NewEngine(path, opts)
+		require.NoError(t, err)
+		defer ng.Close()
+		require.NoError(t, ng.Recover())
+
+		s := ng.NewSnapshotSession()
+		defer s.Close()
+
+		m := make(map[string]string)
+		for i := int64(1); i <= 3; i++ {
+			v, err := s.Get(key(i))
+			if err == nil {
+				m[string(key(i))] = string(v)
+			}
+		}
+		return m
+	}
+
+	require.Equal(t, map[string]string{string(key(1)): "a", string(key(2)): "b"}, restore(t, t1))
+	require.Equal(t, map[string]string{string(key(2)): "b"}, restore(t, t2))
+	require.Equal(t, map[string]string{string(key(2)): "b"}, restore(t, time.Now()))
+
+	// the target must be after the checkpoint
+	err = kv.RestorePointInTime(filepath.Join(t.TempDir(), "restored"), opts, kv.PointInTimeOptions{
+		Checkpoint: filepath.Join(dir, "checkpoint"),
+		Archive:    archive,
+		Target:     t1.Add(-time.Hour),
+	})
+	require.Error(t, err)
+}
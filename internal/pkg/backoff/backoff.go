@@ -0,0 +1,60 @@
+// Package backoff retries operations with an exponential backoff.
+package backoff
+
+import (
+	"time"
+)
+
+const (
+	defaultMinDelay = time.Millisecond
+	defaultMaxDelay = 100 * time.Millisecond
+)
+
+// Policy configures how an operation is retried.
+// The zero value doesn't retry.
+type Policy struct {
+	// Maximum time spent retrying the operation.
+	// If zero, the operation is only tried once.
+	Timeout time.Duration
+	// Delay before the first retry. It doubles after each retry.
+	// Defaults to 1ms.
+	MinDelay time.Duration
+	// Maximum delay between two retries. Defaults to 100ms.
+	MaxDelay time.Duration
+}
+
+// Retry calls fn until it returns true or until the timeout expires,
+// in which case it returns false.
+func (p Policy) Retry(fn func() bool) bool {
+	if fn() {
+		return true
+	}
+	if p.Timeout <= 0 {
+		return false
+	}
+
+	delay := p.MinDelay
+	if delay <= 0 {
+		delay = defaultMinDelay
+	}
+	maxDelay := p.MaxDelay
+	if maxDelay <= 0 {
+		maxDelay = defaultMaxDelay
+	}
+	maxDelay = max(maxDelay, delay)
+
+	deadline := time.Now().Add(p.Timeout)
+	for {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return false
+		}
+
+		time.Sleep(min(delay, remaining))
+		if fn() {
+			return true
+		}
+
+		delay = min(delay*2, maxDelay)
+	}
+}
@@ -0,0 +1,40 @@
+package backoff_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/chaisql/chai/internal/pkg/backoff"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetry(t *testing.T) {
+	// returns a function succeeding after n calls
+	after := func(n int) (func() bool, *int) {
+		var calls int
+		return func() bool {
+			calls++
+			return calls > n
+		}, &calls
+	}
+
+	fn, calls := after(0)
+	require.True(t, backoff.Policy{}.Retry(fn))
+	require.Equal(t, 1, *calls)
+
+	// no timeout, no retry
+	fn, calls = after(1)
+	require.False(t, backoff.Policy{}.Retry(fn))
+	require.Equal(t, 1, *calls)
+
+	fn, calls = after(3)
+	require.True(t, backoff.Policy{Timeout: time.Second, MinDelay: time.Millisecond}.Retry(fn))
+	require.Equal(t, 4, *calls)
+
+	// the delays double up to MaxDelay
+	fn, calls = after(1000)
+	start := time.Now()
+	require.False(t, backoff.Policy{Timeout: 50 * time.Millisecond, MinDelay: time.Millisecond, MaxDelay: 8 * time.Millisecond}.Retry(fn))
+	require.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+	require.Less(t, *calls, 15)
+}
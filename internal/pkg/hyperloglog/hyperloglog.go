@@ -0,0 +1,106 @@
+// Package hyperloglog estimates the number of distinct values of a stream
+// in a bounded amount of memory, using the HyperLogLog algorithm of Flajolet et al.
+package hyperloglog
+
+import (
+	"errors"
+	"math"
+	"math/bits"
+)
+
+// DefaultPrecision uses 2^14 registers of one byte,
+// for a standard error of about 0.8%.
+const DefaultPrecision = 14
+
+// The bounds of the precision.
+const (
+	MinPrecision = 4
+	MaxPrecision = 18
+)
+
+// A Sketch summarizes the hashes of the values of a stream with registers,
+// each one keeping the longest run of leading zeros among the hashes
+// whose first bits select it. Sketches of the same precision can be merged,
+// so that the distinct values of several streams can be estimated together.
+type Sketch struct {
+	precision uint8
+	registers []uint8
+}
+
+// New returns an empty sketch with 2^precision registers:
+// the higher the precision, the more precise the estimates.
+// The precision is clamped between MinPrecision and MaxPrecision.
+func New(precision uint8) *Sketch {
+	precision = min(max(precision, MinPrecision), MaxPrecision)
+
+	return &Sketch{
+		precision: precision,
+		registers: make([]uint8, 1<<precision),
+	}
+}
+
+// Add adds the 64-bit hash of a value to the sketch.
+// The hashes must be uniformly distributed.
+func (s *Sketch) Add(hash uint64) {
+	i := hash >> (64 - s.precision)
+	// the remaining bits, with a sentinel bit so that the rank is bounded
+	w := hash<<s.precision | 1<<(s.precision-1)
+	rank := uint8(bits.LeadingZeros64(w)) + 1
+
+	if rank > s.registers[i] {
+		s.registers[i] = rank
+	}
+}
+
+// Merge adds the values of another sketch to the sketch.
+// Both sketches must have the same precision.
+func (s *Sketch) Merge(other *Sketch) error {
+	if s.precision != other.precision {
+		return errors.New("cannot merge sketches of different precisions")
+	}
+
+	for i, r := range other.registers {
+		if r > s.registers[i] {
+			s.registers[i] = r
+		}
+	}
+
+	return nil
+}
+
+// Count returns the estimated number of distinct values added to the sketch.
+func (s *Sketch) Count() uint64 {
+	m := float64(len(s.registers))
+
+	var sum float64
+	var zeros int
+	for _, r := range s.registers {
+		sum += 1 / float64(uint64(1)<<r)
+		if r == 0 {
+			zeros++
+		}
+	}
+
+	estimate := alpha(m) * m * m / sum
+	// small cardinalities are estimated more precisely
+	// by the proportion of empty registers
+	if estimate <= 2.5*m && zeros > 0 {
+		estimate = m * math.Log(m/float64(zeros))
+	}
+
+	return uint64(estimate + 0.5)
+}
+
+// alpha corrects the bias of the harmonic mean of m registers.
+func alpha(m float64) float64 {
+	switch m {
+	case 16:
+		return 0.673
+	case 32:
+		return 0.697
+	case 64:
+		return 0.709
+	}
+
+	return 0.7213 / (1 + 1.079/m)
+}
@@ -0,0 +1,55 @@
+package hyperloglog_test
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/chaisql/chai/internal/pkg/hyperloglog"
+	"github.com/stretchr/testify/require"
+)
+
+func hash(i int) uint64 {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], uint64(i))
+	return xxhash.Sum64(buf[:])
+}
+
+func TestSketchSmall(t *testing.T) {
+	s := hyperloglog.New(hyperloglog.DefaultPrecision)
+	require.EqualValues(t, 0, s.Count())
+
+	for i := 0; i < 100; i++ {
+		// every value is added twice
+		s.Add(hash(i % 50))
+	}
+
+	// small cardinalities are almost exact
+	require.EqualValues(t, 50, s.Count())
+}
+
+func TestSketchLarge(t *testing.T) {
+	s := hyperloglog.New(hyperloglog.DefaultPrecision)
+
+	for i := 0; i < 1_000_000; i++ {
+		s.Add(hash(i))
+	}
+
+	require.InEpsilon(t, 1_000_000, float64(s.Count()), 0.02)
+}
+
+func TestSketchMerge(t *testing.T) {
+	a := hyperloglog.New(hyperloglog.DefaultPrecision)
+	b := hyperloglog.New(hyperloglog.DefaultPrecision)
+
+	// the sketches have 50,000 values in common
+	for i := 0; i < 100_000; i++ {
+		a.Add(hash(i))
+		b.Add(hash(i + 50_000))
+	}
+
+	require.NoError(t, a.Merge(b))
+	require.InEpsilon(t, 150_000, float64(a.Count()), 0.02)
+
+	require.Error(t, a.Merge(hyperloglog.New(10)))
+}
@@ -0,0 +1,146 @@
+// Package tdigest estimates the quantiles of a stream of values in
+// a bounded amount of memory, using the merging t-digest of Ted Dunning.
+package tdigest
+
+import (
+	"math"
+	"sort"
+)
+
+// DefaultCompression keeps the error on the median under 1%,
+// and much less on the extreme quantiles, with a few hundred centroids.
+const DefaultCompression = 100
+
+type centroid struct {
+	mean  float64
+	count float64
+}
+
+// A Digest summarizes a stream of values with centroids, clusters of values
+// represented by their mean and their count. The clusters are small near
+// the extreme quantiles, so that they are estimated precisely.
+type Digest struct {
+	compression float64
+	// merged centroids, sorted by mean
+	centroids []centroid
+	// values added since the last merge
+	buffer []centroid
+	count  float64
+	min    float64
+	max    float64
+}
+
+// New returns an empty digest. The compression bounds the number of
+// centroids: the higher it is, the more precise the quantiles.
+func New(compression float64) *Digest {
+	if compression <= 0 {
+		compression = DefaultCompression
+	}
+
+	return &Digest{
+		compression: compression,
+		min:         math.Inf(1),
+		max:         math.Inf(-1),
+	}
+}
+
+// Add adds a value to the digest. NaN values are ignored.
+func (d *Digest) Add(x float64) {
+	if math.IsNaN(x) {
+		return
+	}
+
+	d.buffer = append(d.buffer, centroid{mean: x, count: 1})
+	d.count++
+	d.min = math.Min(d.min, x)
+	d.max = math.Max(d.max, x)
+
+	if len(d.buffer) >= int(5*d.compression) {
+		d.merge()
+	}
+}
+
+// Count returns the number of values added to the digest.
+func (d *Digest) Count() int64 {
+	return int64(d.count)
+}
+
+// k maps a quantile to the scale used to size the centroids:
+// a centroid can't span more than one unit of the scale.
+func (d *Digest) k(q float64) float64 {
+	return d.compression / (2 * math.Pi) * math.Asin(2*q-1)
+}
+
+// merge merges the buffered values with the centroids.
+func (d *Digest) merge() {
+	if len(d.buffer) == 0 {
+		return
+	}
+
+	all := append(d.centroids, d.buffer...)
+	sort.SliceStable(all, func(i, j int) bool {
+		return all[i].mean < all[j].mean
+	})
+
+	merged := make([]centroid, 0, len(d.centroids)+1)
+	cur := all[0]
+	var before float64
+	for _, c := range all[1:] {
+		proposed := cur.count + c.count
+		if d.k((before+proposed)/d.count)-d.k(before/d.count) <= 1 {
+			cur.mean += (c.mean - cur.mean) * c.count / proposed
+			cur.count = proposed
+			continue
+		}
+
+		merged = append(merged, cur)
+		before += cur.count
+		cur = c
+	}
+
+	d.centroids = append(merged, cur)
+	d.buffer = d.buffer[:0]
+}
+
+// Quantile returns an estimate of the quantile q of the values, between 0 and 1.
+// Like the continuous percentile, it interpolates between the closest values:
+// the result is exact as long as every value has its own centroid.
+// It returns NaN if the digest is empty.
+func (d *Digest) Quantile(q float64) float64 {
+	d.merge()
+
+	switch {
+	case len(d.centroids) == 0:
+		return math.NaN()
+	case q <= 0:
+		return d.min
+	case q >= 1:
+		return d.max
+	}
+
+	// position of the quantile in the cumulated counts,
+	// where the n-th value is centered on n - 0.5
+	target := q*(d.count-1) + 0.5
+
+	var cum float64
+	prevMean, prevMid := d.min, 0.5
+	for _, c := range d.centroids {
+		mid := cum + c.count/2
+		if target < mid {
+			return interpolate(prevMean, prevMid, c.mean, mid, target)
+		}
+
+		prevMean, prevMid = c.mean, mid
+		cum += c.count
+	}
+
+	return interpolate(prevMean, prevMid, d.max, d.count-0.5, target)
+}
+
+func interpolate(y0, x0, y1, x1, x float64) float64 {
+	if x1 <= x0 {
+		return y1
+	}
+
+	return y0 + (y1-y0)*(x-x0)/(x1-x0)
+}
@@ -0,0 +1,46 @@
+package tdigest_test
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/chaisql/chai/internal/pkg/tdigest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDigestSmall(t *testing.T) {
+	d := tdigest.New(tdigest.DefaultCompression)
+	require.True(t, math.IsNaN(d.Quantile(0.5)))
+
+	for _, x := range []float64{5, 1, 4, 2, 3, math.NaN()} {
+		d.Add(x)
+	}
+
+	// every value has its own centroid: the quantiles are exact
+	require.EqualValues(t, 5, d.Count())
+	require.Equal(t, 1.0, d.Quantile(0))
+	require.Equal(t, 2.0, d.Quantile(0.25))
+	require.Equal(t, 3.0, d.Quantile(0.5))
+	require.Equal(t, 4.6, math.Round(d.Quantile(0.9)*1e9)/1e9)
+	require.Equal(t, 5.0, d.Quantile(1))
+}
+
+func TestDigestLarge(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	d := tdigest.New(tdigest.DefaultCompression)
+
+	values := make([]float64, 100_000)
+	for i := range values {
+		values[i] = r.NormFloat64()
+		d.Add(values[i])
+	}
+	sort.Float64s(values)
+
+	for _, q := range []float64{0.001, 0.01, 0.1, 0.5, 0.9, 0.99, 0.999} {
+		// compare the rank of the estimate with the expected one
+		rank := float64(sort.SearchFloat64s(values, d.Quantile(q))) / float64(len(values))
+		require.InDelta(t, q, rank, 0.005, "quantile %v", q)
+	}
+}
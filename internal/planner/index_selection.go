@@ -3,6 +3,7 @@ package planner
 import (
 	"github.com/chaisql/chai/internal/database"
 	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/expr/functions"
 	"github.com/chaisql/chai/internal/sql/scanner"
 	"github.com/chaisql/chai/internal/stream"
 	"github.com/chaisql/chai/internal/stream/index"
@@ -27,9 +28,13 @@ import (
 //	<expression> <compatible operator> <path>
 //
 // path: path of an object
-// compatible operator: one of =, >, >=, <, <=, IN
+// compatible operator: one of =, >, >=, <, <=, IN, BETWEEN, or <<, <<=, >>, >>=
+// if the path is a network contained in the expression
 // expression: any expression
 //
+// Filter nodes calling within_box(<path>, <sw>, <ne>), where sw and ne are
+// constant points, are selected as well.
+//
 // Index compatibility.
 //
 // Once we have a list of all compatible filter nodes, we try to associate
@@ -119,9 +124,30 @@ type indexSelector struct {
 	tableScan *table.ScanOperator
 	sctx      *StreamContext
 	info      *database.TableInfo
+
+	// SQL representation of the expressions indexed
+	// by the indexes of the table, and the type of their values
+	exprs map[string]types.Type
 }
 
 func (i *indexSelector) selectIndex() error {
+	for _, idxName := range i.sctx.Catalog.ListIndexes(i.tableScan.TableName) {
+		idxInfo, err := i.sctx.Catalog.GetIndexInfo(idxName)
+		if err != nil {
+			return err
+		}
+
+		for j, c := range idxInfo.Columns {
+			if !idxInfo.IsExpr(j) {
+				continue
+			}
+			if i.exprs == nil {
+				i.exprs = make(map[string]types.Type)
+			}
+			i.exprs[c] = indexedExprType(idxInfo.Exprs[j])
+		}
+	}
+
 	// generate a list of candidates from all the filter nodes that
 	// can benefit from reading from an index or the table pk,
 	// plus potentially ORDER BY nodes (1 max)
@@ -207,7 +233,9 @@ func (i *indexSelector) selectIndex() error {
 	for _, f := range selected.nodes {
 		switch tp := f.node.(type) {
 		case *rows.FilterOperator:
-			i.sctx.removeFilterNode(tp)
+			if !f.keepFilter {
+				i.sctx.removeFilterNode(tp)
+			}
 			if f.orderBy != nil {
 				i.sctx.removeTempTreeNodeNode(f.orderBy.node.(*rows.TempTreeSortOperator))
 			}
@@ -232,6 +260,10 @@ func (i *indexSelector) selectIndex() error {
 }
 
 func (i *indexSelector) isFilterIndexable(f *rows.FilterOperator) (*indexableNode, error) {
+	if wb, ok := f.Expr.(*functions.WithinBox); ok {
+		return i.isWithinBoxIndexable(f, wb)
+	}
+
 	// only operators can associate this node to an index
 	op, ok := f.Expr.(expr.Operator)
 	if !ok {
@@ -254,11 +286,44 @@ func (i *indexSelector) isFilterIndexable(f *rows.FilterOperator) (*indexableNod
 		col:      path,
 		operator: op.Token(),
 		operand:  e,
+		// the range of a network also contains the networks
+		// with a shorter prefix, which are removed by the filter
+		keepFilter: isContainmentOperator(op.Token()),
 	}
 
 	return &node, nil
 }
 
+// isWithinBoxIndexable selects calls to within_box on a POINT column whose
+// corners are constant. The index is read from the south-west corner to the
+// north-east corner of the box, as a BETWEEN operator: because points are sorted
+// on a Z-order curve, this range contains all the points of the box, but also
+// points outside of it, which are removed by the filter.
+func (i *indexSelector) isWithinBoxIndexable(f *rows.FilterOperator, wb *functions.WithinBox) (*indexableNode, error) {
+	name, tp, ok := i.indexedOperand(wb.Point)
+	if !ok || tp != types.TypePoint {
+		return nil, nil
+	}
+
+	sw, ne, ok, err := wb.Box()
+	if !ok || err != nil {
+		return nil, err
+	}
+
+	// empty boxes and boxes crossing the antimeridian don't map to a single range
+	if sw.Lat > ne.Lat || sw.Lon > ne.Lon {
+		return nil, nil
+	}
+
+	return &indexableNode{
+		node:       f,
+		col:        name,
+		operator:   scanner.BETWEEN,
+		operand:    expr.LiteralExprList{expr.LiteralValue{Value: types.NewPointValue(sw)}, expr.LiteralValue{Value: types.NewPointValue(ne)}},
+		keepFilter: true,
+	}, nil
+}
+
 func (i *indexSelector) isTempTreeSortIndexable(n *rows.TempTreeSortOperator) *indexableNode {
 	// only columns can be associated with an index
 	col, ok := n.Expr.(*expr.Column)
@@ -295,7 +360,7 @@ func (i *indexSelector) associateIndexWithNodes(treeName string, isIndex bool, i
 
 	var hasIn bool
 	var sorter *indexableNode
-	for _, p := range columns {
+	for j, p := range columns {
 		ns := nodes.getByColumn(p)
 		if len(ns) == 0 {
 			break
@@ -305,6 +370,11 @@ func (i *indexSelector) associateIndexWithNodes(treeName string, isIndex bool, i
 		var filter *indexableNode
 		for i, n := range ns {
 			if n.operator == scanner.ORDER && sorter == nil {
+				// ORDER BY sorts NULLs at their default position,
+				// other indexes cannot replace the TempSort node
+				if !sortOrder.HasDefaultNulls(j) {
+					continue
+				}
 				sorter = ns[i]
 				desc = sorter.desc
 				continue
@@ -538,7 +608,7 @@ func (i *indexSelector) buildRangeFromOperator(lastOp scanner.Token, columns []s
 		rng.Max = el
 	case scanner.LTE:
 		rng.Max = el
-	case scanner.BETWEEN:
+	case scanner.BETWEEN, scanner.CONTAINEDBY, scanner.CONTAINEDBYEQ, scanner.CONTAINS, scanner.CONTAINSEQ:
 		/* example:
 		CREATE TABLE test(a int, b int, c int, d int, e int);
 		CREATE INDEX on test(a, b, c, d);
@@ -595,6 +665,10 @@ type indexableNode struct {
 	operand  expr.Expr
 	desc     bool
 
+	// the range read from the index may contain rows
+	// that don't match the filter node, which must be kept
+	keepFilter bool
+
 	// merged TempTreeSort node to remove
 	// from the stream
 	orderBy *indexableNode
@@ -658,6 +732,17 @@ func operatorIsIndexCompatible(op expr.Operator) bool {
 		return true
 	}
 
+	return isContainmentOperator(op.Token())
+}
+
+// isContainmentOperator returns whether the operator tests if a network
+// contains an address or another network.
+func isContainmentOperator(tok scanner.Token) bool {
+	switch tok {
+	case scanner.CONTAINEDBY, scanner.CONTAINEDBYEQ, scanner.CONTAINS, scanner.CONTAINSEQ:
+		return true
+	}
+
 	return false
 }
 
@@ -667,46 +752,75 @@ func (i *indexSelector) operatorCanUseIndex(op expr.Operator) (bool, string, exp
 		return i.inOperatorCanUseIndex(op)
 	case scanner.BETWEEN:
 		return i.betweenOperatorCanUseIndex(op)
+	case scanner.CONTAINEDBY, scanner.CONTAINEDBYEQ, scanner.CONTAINS, scanner.CONTAINSEQ:
+		return i.containmentOperatorCanUseIndex(op)
 	}
 
 	lh := op.LeftHand()
 	rh := op.RightHand()
-	lc, leftIsCol := lh.(*expr.Column)
-	rc, rightIsCol := rh.(*expr.Column)
-
-	var cc *database.ColumnConstraint
-	if leftIsCol {
-		cc = i.info.ColumnConstraints.GetColumnConstraint(lc.Name)
-	} else if rightIsCol {
-		cc = i.info.ColumnConstraints.GetColumnConstraint(rc.Name)
-	}
-	if cc == nil {
-		return false, "", nil, nil
-	}
 
 	// column OP literal
-	if leftIsCol {
-		ok, v, err := exprIsCompatibleLiteral(rh, cc.Type)
+	if name, tp, ok := i.indexedOperand(lh); ok {
+		ok, v, err := exprIsCompatibleLiteral(rh, tp)
 		if !ok || err != nil {
 			return false, "", nil, err
 		}
 
-		return true, lc.Name, v, nil
+		return true, name, v, nil
 	}
 
 	// literal OP column
-	if rightIsCol {
-		ok, v, err := exprIsCompatibleLiteral(lh, cc.Type)
+	if name, tp, ok := i.indexedOperand(rh); ok {
+		ok, v, err := exprIsCompatibleLiteral(lh, tp)
 		if !ok || err != nil {
 			return false, "", nil, err
 		}
 
-		return true, rc.Name, v, nil
+		return true, name, v, nil
 	}
 
 	return false, "", nil, nil
 }
 
+// indexedOperand returns the name under which e is indexed if it is either
+// a column of the table or an expression indexed by one of its indexes,
+// along with the type of its values.
+// Indexed expressions extract values from JSON documents, whose type is not
+// known in advance: TypeAny is returned for them.
+func (i *indexSelector) indexedOperand(e expr.Expr) (string, types.Type, bool) {
+	if c, ok := e.(*expr.Column); ok {
+		cc := i.info.ColumnConstraints.GetColumnConstraint(c.Name)
+		if cc == nil {
+			return "", 0, false
+		}
+
+		return c.Name, cc.Type, true
+	}
+
+	if len(i.exprs) == 0 {
+		return "", 0, false
+	}
+
+	s := e.String()
+	if tp, ok := i.exprs[s]; ok {
+		return s, tp, true
+	}
+
+	return "", 0, false
+}
+
+// indexedExprType returns the type of the values of an indexed expression.
+// Values extracted from JSON documents can be of any type.
+func indexedExprType(te database.TableExpression) types.Type {
+	if ce, ok := te.(*expr.ConstraintExpr); ok {
+		if tp, ok := functions.HashType(ce.Expr); ok {
+			return tp
+		}
+	}
+
+	return types.TypeAny
+}
+
 // Special case for IN operator: only left operand is valid for index usage
 // valid:   a IN (1, 2, 3)
 // invalid: 1 IN a
@@ -718,10 +832,8 @@ func (i *indexSelector) inOperatorCanUseIndex(op expr.Operator) (bool, string, e
 		return false, "", nil, nil
 	}
 
-	lh := op.LeftHand()
-	lc, leftIsCol := lh.(*expr.Column)
-
-	if !leftIsCol {
+	name, tp, ok := i.indexedOperand(op.LeftHand())
+	if !ok {
 		return false, "", nil, nil
 	}
 
@@ -734,23 +846,28 @@ func (i *indexSelector) inOperatorCanUseIndex(op expr.Operator) (bool, string, e
 		return false, "", nil, nil
 	}
 
-	cc := i.info.ColumnConstraints.GetColumnConstraint(lc.Name)
-	if cc == nil {
-		return false, "", nil, nil
-	}
-
 	// Ensure that each element of the list is a literal value
-	// and that each value has the same type as the column
+	// and that each value has the same type as the column.
+	// NULL is never equal to a value: it is not looked up.
+	values := make(expr.LiteralExprList, 0, len(rlist))
 	for i, e := range rlist {
-		ok, v, err := exprIsCompatibleLiteral(e, cc.Type)
+		if l, ok := e.(expr.LiteralValue); ok && l.Value.Type() == types.TypeNull {
+			continue
+		}
+
+		ok, v, err := exprIsCompatibleLiteral(e, tp)
 		if !ok || err != nil {
 			return false, "", nil, err
 		}
 
 		rlist[i] = v
+		values = append(values, v)
+	}
+	if len(values) == 0 {
+		return false, "", nil, nil
 	}
 
-	return true, lc.Name, rlist, nil
+	return true, name, values, nil
 }
 
 // Special case for BETWEEN operator: Given this expression (x BETWEEN a AND b),
@@ -760,29 +877,52 @@ func (i *indexSelector) betweenOperatorCanUseIndex(op expr.Operator) (bool, stri
 	rh := op.RightHand()
 
 	bt := op.(*expr.BetweenOperator)
-	x, xIsCol := bt.X.(*expr.Column)
-	if !xIsCol {
-		return false, "", nil, nil
-	}
-
-	cc := i.info.ColumnConstraints.GetColumnConstraint(x.Name)
-	if cc == nil {
+	name, tp, ok := i.indexedOperand(bt.X)
+	if !ok {
 		return false, "", nil, nil
 	}
 
-	lok, lv, err := exprIsCompatibleLiteral(lh, cc.Type)
+	lok, lv, err := exprIsCompatibleLiteral(lh, tp)
 	if err != nil {
 		return false, "", nil, err
 	}
-	rok, rv, err := exprIsCompatibleLiteral(rh, cc.Type)
+	rok, rv, err := exprIsCompatibleLiteral(rh, tp)
 	if err != nil {
 		return false, "", nil, err
 	}
-	if !xIsCol || !lok || !rok {
+	if !lok || !rok {
+		return false, "", nil, nil
+	}
+
+	return true, name, expr.LiteralExprList{lv, rv}, nil
+}
+
+// Special case for network containment operators: the column must be contained
+// in a literal network, as in (a <<= '10.0.0.0/8') or ('10.0.0.0/8' >> a).
+// The index is read from the first to the last address of the network,
+// as a BETWEEN operator.
+func (i *indexSelector) containmentOperatorCanUseIndex(op expr.Operator) (bool, string, expr.Expr, error) {
+	col, network := op.LeftHand(), op.RightHand()
+	if op.Token() == scanner.CONTAINS || op.Token() == scanner.CONTAINSEQ {
+		col, network = network, col
+	}
+
+	name, tp, ok := i.indexedOperand(col)
+	if !ok || (tp != types.TypeInet && tp != types.TypeCidr) {
+		return false, "", nil, nil
+	}
+
+	l, ok := network.(expr.LiteralValue)
+	if !ok || !tp.Def().IsComparableWith(l.Value.Type()) {
 		return false, "", nil, nil
 	}
 
-	return true, x.Name, expr.LiteralExprList{lv, rv}, nil
+	first, last, err := types.NetworkBounds(l.Value, tp)
+	if err != nil {
+		return false, "", nil, err
+	}
+
+	return true, name, expr.LiteralExprList{expr.LiteralValue{Value: first}, expr.LiteralValue{Value: last}}, nil
 }
 
 func exprIsCompatibleLiteral(e expr.Expr, tp types.Type) (bool, expr.LiteralValue, error) {
@@ -791,6 +931,10 @@ func exprIsCompatibleLiteral(e expr.Expr, tp types.Type) (bool, expr.LiteralValu
 		return false, expr.LiteralValue{}, nil
 	}
 
+	if tp.IsAny() {
+		return exprIsCompatibleJSONLiteral(l)
+	}
+
 	if !l.Value.Type().Def().IsIndexComparableWith(tp) {
 		return false, expr.LiteralValue{}, nil
 	}
@@ -802,3 +946,22 @@ func exprIsCompatibleLiteral(e expr.Expr, tp types.Type) (bool, expr.LiteralValu
 
 	return true, expr.LiteralValue{Value: v}, nil
 }
+
+// exprIsCompatibleJSONLiteral returns the literal as it must be looked up
+// in an index of values extracted from JSON documents: booleans, texts,
+// and numbers, which are always doubles.
+func exprIsCompatibleJSONLiteral(l expr.LiteralValue) (bool, expr.LiteralValue, error) {
+	switch tp := l.Value.Type(); {
+	case tp == types.TypeBoolean, tp == types.TypeText, tp == types.TypeDouble:
+		return true, l, nil
+	case tp.IsInteger(), tp == types.TypeReal:
+		v, err := l.Value.CastAs(types.TypeDouble)
+		if err != nil {
+			return false, expr.LiteralValue{}, err
+		}
+
+		return true, expr.LiteralValue{Value: v}, nil
+	}
+
+	return false, expr.LiteralValue{}, nil
+}
@@ -1,9 +1,12 @@
 package planner
 
 import (
+	"time"
+
 	"github.com/chaisql/chai/internal/database"
 	"github.com/chaisql/chai/internal/environment"
 	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/expr/functions"
 	"github.com/chaisql/chai/internal/sql/scanner"
 	"github.com/chaisql/chai/internal/stream"
 	"github.com/chaisql/chai/internal/stream/path"
@@ -20,17 +23,20 @@ var optimizerRules = []func(sctx *StreamContext) error{
 	RemoveUnnecessaryFilterNodesRule,
 	RemoveUnnecessaryTempSortNodesRule,
 	SelectIndex,
+	DeleteRangeRule,
 }
 
 // Optimize takes a tree, applies a list of optimization rules
 // and returns an optimized tree.
 // Depending on the rule, the tree may be modified in place or
 // replaced by a new one.
-func Optimize(s *stream.Stream, catalog *database.Catalog, params []environment.Param) (*stream.Stream, error) {
+// Comparisons are checked and their literals converted following
+// the coercion mode of the transaction, see types.Coercion.
+func Optimize(s *stream.Stream, tx *database.Transaction, params []environment.Param) (*stream.Stream, error) {
 	if firstNode, ok := s.First().(*stream.ConcatOperator); ok {
 		// If the first operation is a concat, optimize all streams individually.
 		for i, st := range firstNode.Streams {
-			ss, err := Optimize(st, catalog, params)
+			ss, err := Optimize(st, tx, params)
 			if err != nil {
 				return nil, err
 			}
@@ -43,7 +49,7 @@ func Optimize(s *stream.Stream, catalog *database.Catalog, params []environment.
 	if firstNode, ok := s.First().(*stream.UnionOperator); ok {
 		// If the first operation is a union, optimize all streams individually.
 		for i, st := range firstNode.Streams {
-			ss, err := Optimize(st, catalog, params)
+			ss, err := Optimize(st, tx, params)
 			if err != nil {
 				return nil, err
 			}
@@ -53,11 +59,12 @@ func Optimize(s *stream.Stream, catalog *database.Catalog, params []environment.
 		return s, nil
 	}
 
-	return optimize(s, catalog, params)
+	return optimize(s, tx, params)
 }
 
 type StreamContext struct {
 	Catalog       *database.Catalog
+	Tx            *database.Transaction
 	TableInfo     *database.TableInfo
 	Params        []environment.Param
 	Stream        *stream.Stream
@@ -142,8 +149,9 @@ func (sctx *StreamContext) removeProjectionNode(index int) {
 	sctx.Projections = append(sctx.Projections[:index], sctx.Projections[index+1:]...)
 }
 
-func optimize(s *stream.Stream, catalog *database.Catalog, params []environment.Param) (*stream.Stream, error) {
-	sctx := NewStreamContext(s, catalog)
+func optimize(s *stream.Stream, tx *database.Transaction, params []environment.Param) (*stream.Stream, error) {
+	sctx := NewStreamContext(s, tx.Catalog)
+	sctx.Tx = tx
 	sctx.Params = params
 
 	for _, rule := range optimizerRules {
@@ -222,10 +230,13 @@ func splitANDExpr(cond expr.Expr) (exprs []expr.Expr) {
 // before running the query and replaces it by the result of the evaluation.
 // The result of constant sub-expressions, like "3 + 4", is always the same and thus
 // can be precalculated.
+// Calls of functions with constant arguments are precalculated as well,
+// unless the function is volatile.
 // Examples:
 //
 //	3 + 4 --> 7
 //	3 + 1 > 10 - a --> 4 > 10 - a
+//	md5('a') = a --> '0cc175b9c0f1b6a831c399e269772661' = a
 func PrecalculateExprRule(sctx *StreamContext) error {
 	n := sctx.Stream.Op
 	var err error
@@ -287,6 +298,32 @@ func precalculateExpr(sctx *StreamContext, e expr.Expr) (expr.Expr, error) {
 			return nil, err
 		}
 		return expr.LiteralValue{Value: v}, nil
+	case *functions.ScalarFunction:
+		params := t.Params()
+		allLit := true
+		for i := range params {
+			e, err := precalculateExpr(sctx, params[i])
+			if err != nil {
+				return nil, err
+			}
+			params[i] = e
+
+			if _, ok := e.(expr.LiteralValue); !ok {
+				allLit = false
+			}
+		}
+
+		// volatile functions, like gen_uuid_v4(), must be called
+		// for every row, even if their arguments are literals
+		if !allLit || t.IsVolatile() {
+			break
+		}
+
+		v, err := t.Eval(&environment.Environment{Tx: sctx.Tx})
+		if err != nil {
+			return nil, err
+		}
+		return expr.LiteralValue{Value: v}, nil
 	case expr.Operator:
 		// since expr.Operator is an interface,
 		// this optimization must only be applied to
@@ -315,7 +352,21 @@ func precalculateExpr(sctx *StreamContext, e expr.Expr) (expr.Expr, error) {
 			if err != nil {
 				return nil, err
 			}
+		}
 
+		if isCoercedComparison(t) {
+			err = coerceOperands(sctx, t)
+			if err != nil {
+				return nil, err
+			}
+			lh, rh = t.LeftHand(), t.RightHand()
+		}
+
+		if v, ok := precalculateLogic(t); ok {
+			return v, nil
+		}
+
+		if b, ok := t.(*expr.BetweenOperator); ok {
 			if _, isLit := b.X.(expr.LiteralValue); !isLit {
 				break
 			}
@@ -325,7 +376,8 @@ func precalculateExpr(sctx *StreamContext, e expr.Expr) (expr.Expr, error) {
 		rv, rightIsLit := rh.(expr.LiteralValue)
 		// if both operands are literals, we can precalculate them now
 		if leftIsLit && rightIsLit {
-			v, err := t.Eval(&environment.Environment{})
+			// the transaction provides the coercion mode and the time zone
+			v, err := t.Eval(&environment.Environment{Tx: sctx.Tx})
 			if err != nil {
 				return nil, err
 			}
@@ -333,6 +385,29 @@ func precalculateExpr(sctx *StreamContext, e expr.Expr) (expr.Expr, error) {
 			return expr.LiteralValue{Value: v}, nil
 		}
 
+		// the literals of comparisons have already been converted
+		if isCoercedComparison(t) {
+			return t, nil
+		}
+
+		// NULL can be compared with any column using IS and IS NOT
+		if (leftIsLit && lv.Value.Type() == types.TypeNull) || (rightIsLit && rv.Value.Type() == types.TypeNull) {
+			return t, nil
+		}
+
+		// the types of the columns are only known when reading a table,
+		// not the rows of table functions
+		if sctx.TableInfo == nil {
+			return t, nil
+		}
+
+		// intervals are added to timestamps without being converted
+		// to the type of the column
+		if expr.IsArithmeticOperator(t) &&
+			(leftIsLit && lv.Value.Type() == types.TypeInterval || rightIsLit && rv.Value.Type() == types.TypeInterval) {
+			return t, nil
+		}
+
 		// if one operand is a column and the other is a literal
 		// we can check if the types are compatible
 		lc, leftIsCol := lh.(*expr.Column)
@@ -374,6 +449,240 @@ func precalculateExpr(sctx *StreamContext, e expr.Expr) (expr.Expr, error) {
 	return e, nil
 }
 
+// precalculateLogic returns the result of an operator whose literal operands
+// decide the result on their own, following three-valued logic:
+//
+//	a = NULL --> NULL
+//	a BETWEEN NULL AND 10 --> NULL
+//	a AND false --> false
+//	a OR true --> true
+func precalculateLogic(op expr.Operator) (expr.Expr, bool) {
+	operands := []expr.Expr{op.LeftHand(), op.RightHand()}
+
+	switch t := op.(type) {
+	case *expr.AndOp, *expr.OrOp:
+		_, isAnd := t.(*expr.AndOp)
+		for _, e := range operands {
+			lit, ok := e.(expr.LiteralValue)
+			if !ok || lit.Value.Type() == types.TypeNull {
+				continue
+			}
+			truthy, err := types.IsTruthy(lit.Value)
+			if err != nil {
+				continue
+			}
+			if isAnd && !truthy {
+				return expr.LiteralValue{Value: expr.FalseLiteral}, true
+			}
+			if !isAnd && truthy {
+				return expr.LiteralValue{Value: expr.TrueLiteral}, true
+			}
+		}
+		return nil, false
+	case *expr.BetweenOperator:
+		operands = append(operands, t.X)
+	case *expr.InOperator, *expr.NotInOperator, *expr.IsOperator, *expr.IsNotOperator:
+		return nil, false
+	default:
+		switch op.Token() {
+		case scanner.EQ, scanner.NEQ, scanner.GT, scanner.GTE, scanner.LT, scanner.LTE:
+		default:
+			return nil, false
+		}
+	}
+
+	for _, e := range operands {
+		if lit, ok := e.(expr.LiteralValue); ok && lit.Value.Type() == types.TypeNull {
+			return expr.LiteralValue{Value: expr.NullLiteral}, true
+		}
+	}
+
+	return nil, false
+}
+
+// isCoercedComparison returns whether the operands of op
+// are converted following the coercion mode, see types.Coercion.
+func isCoercedComparison(op expr.Operator) bool {
+	switch op.(type) {
+	case *expr.BetweenOperator, *expr.InOperator, *expr.NotInOperator:
+		return true
+	case *expr.IsOperator, *expr.IsNotOperator:
+		return false
+	}
+
+	switch op.Token() {
+	case scanner.EQ, scanner.NEQ, scanner.GT, scanner.GTE, scanner.LT, scanner.LTE:
+		return true
+	}
+
+	return false
+}
+
+// coerceOperands converts the literals compared with a column or with
+// another literal to the type of the other operand, see coerceLiteral.
+func coerceOperands(sctx *StreamContext, op expr.Operator) error {
+	switch t := op.(type) {
+	case *expr.BetweenOperator:
+		x, a, err := coercePair(sctx, t.X, t.LeftHand())
+		if err != nil {
+			return err
+		}
+		x, b, err := coercePair(sctx, x, t.RightHand())
+		if err != nil {
+			return err
+		}
+		t.X = x
+		t.SetLeftHandExpr(a)
+		t.SetRightHandExpr(b)
+		return nil
+	case *expr.InOperator, *expr.NotInOperator:
+		list, ok := op.RightHand().(expr.LiteralExprList)
+		if !ok {
+			return nil
+		}
+		a := op.LeftHand()
+		for i := range list {
+			var err error
+			a, list[i], err = coercePair(sctx, a, list[i])
+			if err != nil {
+				return err
+			}
+		}
+		op.SetLeftHandExpr(a)
+		return nil
+	}
+
+	a, b, err := coercePair(sctx, op.LeftHand(), op.RightHand())
+	if err != nil {
+		return err
+	}
+	op.SetLeftHandExpr(a)
+	op.SetRightHandExpr(b)
+	return nil
+}
+
+// coercePair converts a literal compared with a column to the type of
+// the column, and a string literal compared with another literal to the type
+// of that literal. The other literals compared with one another are converted
+// when the comparison is evaluated. In strict mode, it also checks
+// that columns compared with one another have comparable types.
+func coercePair(sctx *StreamContext, a, b expr.Expr) (expr.Expr, expr.Expr, error) {
+	av, aIsLit := a.(expr.LiteralValue)
+	bv, bIsLit := b.(expr.LiteralValue)
+	ac, aIsCol := a.(*expr.Column)
+	bc, bIsCol := b.(*expr.Column)
+
+	var err error
+	switch {
+	case aIsCol && bIsCol:
+		at, bt := sctx.columnType(ac), sctx.columnType(bc)
+		if sctx.coercion() == types.CoercionStrict && !at.IsAny() && !bt.IsAny() && !types.AreComparable(at, bt) {
+			return nil, nil, errors.Errorf("cannot compare %s with %s", at, bt)
+		}
+	case aIsCol && bIsLit:
+		b, err = coerceLiteral(sctx, sctx.columnType(ac), bv)
+		b = sctx.padLiteral(ac, b)
+	case aIsLit && bIsCol:
+		a, err = coerceLiteral(sctx, sctx.columnType(bc), av)
+		a = sctx.padLiteral(bc, a)
+	case aIsLit && bIsLit:
+		at, bt := av.Value.Type(), bv.Value.Type()
+		switch {
+		case at == types.TypeText && bt != types.TypeText && bt != types.TypeNull:
+			a, err = castLiteral(sctx, av, bt)
+		case bt == types.TypeText && at != types.TypeText && at != types.TypeNull:
+			b, err = castLiteral(sctx, bv, at)
+		}
+	}
+
+	return a, b, err
+}
+
+// coerceLiteral converts a literal compared with a column of type tp.
+// String literals have no type of their own and are converted to tp in both
+// modes. In strict mode, the other literals must be comparable with tp
+// without conversion, while in lenient mode they are converted to tp.
+func coerceLiteral(sctx *StreamContext, tp types.Type, lit expr.LiteralValue) (expr.Expr, error) {
+	vt := lit.Value.Type()
+	if tp.IsAny() || vt == types.TypeNull || vt == tp {
+		return lit, nil
+	}
+
+	switch {
+	case vt == types.TypeText:
+		return castLiteral(sctx, lit, tp)
+	case sctx.coercion() == types.CoercionStrict && !types.AreComparable(tp, vt):
+		return nil, errors.Errorf("cannot compare %s with %s", tp, vt)
+	case tp.Def().IsIndexComparableWith(vt):
+		return castLiteral(sctx, lit, tp)
+	case tp.Def().IsComparableWith(vt):
+		return lit, nil
+	}
+
+	return castLiteral(sctx, lit, tp)
+}
+
+// castLiteral converts a literal to tp. Texts converted to TIMESTAMPTZ values
+// are parsed in the time zone of the transaction.
+func castLiteral(sctx *StreamContext, lit expr.LiteralValue, tp types.Type) (expr.Expr, error) {
+	var loc *time.Location
+	if sctx.Tx != nil {
+		loc = sctx.Tx.TimeZone
+	}
+
+	v, err := types.CastIn(lit.Value, tp, loc)
+	if err != nil {
+		return nil, errors.Errorf("invalid input syntax for type %s: %s", tp, lit)
+	}
+
+	return expr.LiteralValue{Value: v}, nil
+}
+
+// padLiteral pads a text compared with a CHAR(n) column with spaces,
+// like the texts stored in the column. Texts too long to be stored
+// in the column are left unchanged.
+func (sctx *StreamContext) padLiteral(c *expr.Column, e expr.Expr) expr.Expr {
+	lit, ok := e.(expr.LiteralValue)
+	if !ok || sctx.TableInfo == nil {
+		return e
+	}
+
+	cc := sctx.TableInfo.GetColumnConstraint(c.Name)
+	if cc == nil || !cc.Padded {
+		return e
+	}
+
+	v, err := cc.FitLength(lit.Value)
+	if err != nil {
+		return e
+	}
+
+	return expr.LiteralValue{Value: v}
+}
+
+// columnType returns the type of a column of the table,
+// or TypeAny if it is not known.
+func (sctx *StreamContext) columnType(c *expr.Column) types.Type {
+	if sctx.TableInfo == nil {
+		return types.TypeAny
+	}
+
+	cc := sctx.TableInfo.GetColumnConstraint(c.Name)
+	if cc == nil {
+		return types.TypeAny
+	}
+
+	return cc.Type
+}
+
+func (sctx *StreamContext) coercion() types.Coercion {
+	if sctx.Tx != nil {
+		return sctx.Tx.Coercion
+	}
+
+	return types.CoercionLenient
+}
+
 func CheckExprTypeRule(sctx *StreamContext) error {
 	n := sctx.Stream.Op
 	var err error
@@ -414,7 +723,7 @@ func CheckExprTypeRule(sctx *StreamContext) error {
 
 func checkExprType(sctx *StreamContext, e expr.Expr) (err error) {
 	op, ok := e.(expr.Operator)
-	if !ok {
+	if !ok || sctx.TableInfo == nil {
 		return nil
 	}
 
@@ -535,3 +844,31 @@ func RemoveUnnecessaryTempSortNodesRule(sctx *StreamContext) error {
 
 	return nil
 }
+
+// DeleteRangeRule replaces a table scan followed by a table deletion
+// by a range deletion, which doesn't need to read the rows.
+// It only applies if every scanned row is deleted and if the table has no index,
+// since index entries can only be deleted one by one.
+//
+//	DELETE FROM foo WHERE pk > 10
+//	table.Scan('foo', [{"min": (10), "exclusive": true}]) | table.Delete('foo') | discard()
+//	becomes:
+//	table.DeleteRange('foo', [{"min": (10), "exclusive": true}]) | discard()
+func DeleteRangeRule(sctx *StreamContext) error {
+	scan, ok := sctx.Stream.First().(*table.ScanOperator)
+	if !ok || scan.Reverse {
+		return nil
+	}
+
+	del, ok := scan.GetNext().(*table.DeleteOperator)
+	if !ok || del.Name != scan.TableName {
+		return nil
+	}
+
+	if _, ok := del.GetNext().(*stream.DiscardOperator); !ok || del.GetNext().GetNext() != nil {
+		return nil
+	}
+
+	sctx.Stream = stream.New(table.DeleteRange(scan.TableName, scan.Ranges...)).Pipe(stream.Discard())
+	return nil
+}
@@ -123,6 +123,16 @@ func TestPrecalculateExprRule(t *testing.T) {
 				testutil.DoubleValue(-39),
 			},
 		},
+		{
+			"function with constant arguments: a = lpad('1', 3, '0') -> a = 1",
+			parser.MustParseExpr("a = lpad('1', 3, '0')"),
+			parser.MustParseExpr("a = 1"),
+		},
+		{
+			"volatile function: a = random() -> a = random()",
+			parser.MustParseExpr("a = random()"),
+			parser.MustParseExpr("a = random()"),
+		},
 	}
 
 	for _, test := range tests {
@@ -343,7 +353,7 @@ func TestSelectIndex_Simple(t *testing.T) {
 
 			sctx := planner.NewStreamContext(test.root, tx.Catalog)
 			sctx.Catalog = tx.Catalog
-			st, err := planner.Optimize(test.root, tx.Catalog, nil)
+			st, err := planner.Optimize(test.root, tx, nil)
 			// err := planner.SelectIndex(sctx)
 			require.NoError(t, err)
 			require.Equal(t, test.expected.String(), st.String())
@@ -591,7 +601,7 @@ func TestSelectIndex_Composite(t *testing.T) {
 
 			sctx := planner.NewStreamContext(test.root, tx.Catalog)
 			sctx.Catalog = tx.Catalog
-			st, err := planner.Optimize(test.root, tx.Catalog, []environment.Param{
+			st, err := planner.Optimize(test.root, tx, []environment.Param{
 				{Value: 1},
 				{Value: 2},
 			})
@@ -620,7 +630,7 @@ func TestOptimize(t *testing.T) {
 					stream.New(table.Scan("foo")).Pipe(rows.Filter(parser.MustParseExpr("c = 1 + 2"))),
 					stream.New(table.Scan("bar")).Pipe(rows.Filter(parser.MustParseExpr("d = 1 + $2"))),
 				)),
-				tx.Catalog, []environment.Param{
+				tx, []environment.Param{
 					{Name: "1", Value: 2},
 					{Name: "2", Value: 3},
 				})
@@ -655,7 +665,7 @@ func TestOptimize(t *testing.T) {
 					stream.New(table.Scan("foo")).Pipe(rows.Filter(parser.MustParseExpr("12"))),
 					stream.New(table.Scan("bar")).Pipe(rows.Filter(parser.MustParseExpr("13"))),
 				)),
-				tx.Catalog, nil)
+				tx, nil)
 
 			want := stream.New(stream.Union(
 				stream.New(stream.Concat(
@@ -690,7 +700,7 @@ func TestOptimize(t *testing.T) {
 					Pipe(rows.Filter(parser.MustParseExpr("a = 1"))).
 					Pipe(rows.Filter(parser.MustParseExpr("d = 2"))),
 			)),
-			tx.Catalog, nil)
+			tx, nil)
 
 		want := stream.New(stream.Concat(
 			stream.New(index.Scan("idx_foo_a_d", stream.Range{Min: testutil.ExprList(t, `(1, 2)`), Exact: true})),
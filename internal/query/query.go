@@ -6,6 +6,7 @@ import (
 	"github.com/chaisql/chai/internal/database"
 	"github.com/chaisql/chai/internal/environment"
 	"github.com/chaisql/chai/internal/query/statement"
+	"github.com/cockroachdb/errors"
 )
 
 // A Query can execute statements against the database. It can read or write data
@@ -15,6 +16,8 @@ type Query struct {
 	Statements []statement.Statement
 	tx         *database.Transaction
 	autoCommit bool
+	// true if Prepare prepared all the statements
+	prepared bool
 }
 
 // New creates a new query with the given statements.
@@ -41,6 +44,19 @@ func (q *Query) Prepare(context *Context) error {
 
 	ctx := context.Ctx
 
+	// reject the whole query before running any of its statements
+	if context.DB != nil && context.DB.ReadOnly() {
+		for _, stmt := range q.Statements {
+			if writes(stmt) {
+				return errors.WithStack(database.ErrReadOnly)
+			}
+		}
+	}
+
+	if tx := context.GetTx(); tx != nil && !tx.Writable && !q.IsReadOnly() {
+		return errors.WithStack(database.ErrReadOnlyTx)
+	}
+
 	for i, stmt := range q.Statements {
 		if ctx != nil {
 			select {
@@ -64,6 +80,7 @@ func (q *Query) Prepare(context *Context) error {
 		}
 
 		sctx := &statement.Context{
+			Ctx:  ctx,
 			DB:   context.DB,
 			Conn: context.Conn,
 			Tx:   tx,
@@ -87,9 +104,27 @@ func (q *Query) Prepare(context *Context) error {
 		q.Statements[i] = stmt
 	}
 
+	q.prepared = true
+
 	return nil
 }
 
+// IsReadOnly reports whether none of the statements modifies the database.
+func (q Query) IsReadOnly() bool {
+	for _, stmt := range q.Statements {
+		if writes(stmt) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// IsPrepared reports whether all the statements were prepared by Prepare.
+func (q Query) IsPrepared() bool {
+	return q.prepared
+}
+
 // Run executes all the statements in their own transaction and returns the last result.
 func (q Query) Run(context *Context) (*statement.Result, error) {
 	var res statement.Result
@@ -125,6 +160,12 @@ func (q Query) Run(context *Context) (*statement.Result, error) {
 			continue
 		}
 
+		// statements prepared outside of the transaction
+		// are only checked when they are run
+		if q.tx != nil && !q.tx.Writable && writes(stmt) {
+			return nil, errors.WithStack(database.ErrReadOnlyTx)
+		}
+
 		if q.tx == nil {
 			q.tx, err = context.Conn.BeginTx(&database.TxOptions{
 				ReadOnly: stmt.IsReadOnly(),
@@ -135,6 +176,7 @@ func (q Query) Run(context *Context) (*statement.Result, error) {
 		}
 
 		res, err = stmt.Run(&statement.Context{
+			Ctx:    ctx,
 			DB:     context.DB,
 			Conn:   context.Conn,
 			Tx:     q.tx,
@@ -189,6 +231,17 @@ func (q Query) Run(context *Context) (*statement.Result, error) {
 	return &res, nil
 }
 
+// writes returns whether the statement modifies the database
+// or requires a write transaction.
+func writes(stmt statement.Statement) bool {
+	switch stmt.(type) {
+	case CommitStmt, RollbackStmt:
+		return false
+	}
+
+	return !stmt.IsReadOnly()
+}
+
 type queryAlterer interface {
 	alterQuery(conn *database.Connection, q *Query) error
 }
@@ -0,0 +1,146 @@
+package query
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/query/statement"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+var _ queryAlterer = SetTimeZoneStmt{}
+
+// SetTimeZoneStmt is a statement that sets the time zone of the connection,
+// used to parse and display TIMESTAMPTZ values.
+type SetTimeZoneStmt struct {
+	// Name of a time zone of the IANA database, such as "Europe/Paris",
+	// "Local" for the time zone of the system, or a UTC offset, such as "+02:00".
+	// If empty, the default time zone of the database is used.
+	TimeZone string
+}
+
+func (stmt SetTimeZoneStmt) Bind(ctx *statement.Context) error {
+	return nil
+}
+
+// Prepare implements the Preparer interface.
+func (stmt SetTimeZoneStmt) Prepare(*statement.Context) (statement.Statement, error) {
+	return stmt, nil
+}
+
+func (stmt SetTimeZoneStmt) alterQuery(conn *database.Connection, q *Query) error {
+	if stmt.TimeZone == "" {
+		conn.SetTimeZone(nil)
+		return nil
+	}
+
+	loc, err := LoadTimeZone(stmt.TimeZone)
+	if err != nil {
+		return err
+	}
+
+	conn.SetTimeZone(loc)
+	return nil
+}
+
+func (stmt SetTimeZoneStmt) IsReadOnly() bool {
+	return true
+}
+
+func (stmt SetTimeZoneStmt) Run(ctx *statement.Context) (statement.Result, error) {
+	return statement.Result{}, errors.New("cannot set the time zone outside of a connection")
+}
+
+var _ queryAlterer = SetCoercionStmt{}
+
+// SetCoercionStmt is a statement that sets the coercion mode of the connection,
+// which controls how values of different types are compared.
+type SetCoercionStmt struct {
+	Coercion types.Coercion
+	// If set, the default coercion mode of the database is used.
+	Default bool
+}
+
+func (stmt SetCoercionStmt) Bind(ctx *statement.Context) error {
+	return nil
+}
+
+// Prepare implements the Preparer interface.
+func (stmt SetCoercionStmt) Prepare(*statement.Context) (statement.Statement, error) {
+	return stmt, nil
+}
+
+func (stmt SetCoercionStmt) alterQuery(conn *database.Connection, q *Query) error {
+	if stmt.Default {
+		conn.ResetCoercion()
+		return nil
+	}
+
+	conn.SetCoercion(stmt.Coercion)
+	return nil
+}
+
+func (stmt SetCoercionStmt) IsReadOnly() bool {
+	return true
+}
+
+func (stmt SetCoercionStmt) Run(ctx *statement.Context) (statement.Result, error) {
+	return statement.Result{}, errors.New("cannot set the coercion mode outside of a connection")
+}
+
+// LoadTimeZone returns the location with the given name, which is either
+// a time zone of the IANA database, "Local", "UTC", or a UTC offset
+// formatted as "+HH", "+HH:MM" or "+HHMM".
+func LoadTimeZone(name string) (*time.Location, error) {
+	if name == "" {
+		return nil, errors.New("time zone required")
+	}
+
+	if name[0] == '+' || name[0] == '-' {
+		offset, ok := parseUTCOffset(name[1:])
+		if !ok {
+			return nil, errors.Errorf("invalid time zone %q", name)
+		}
+		if name[0] == '-' {
+			offset = -offset
+		}
+
+		return time.FixedZone(name, offset), nil
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, errors.Errorf("unknown time zone %q", name)
+	}
+
+	return loc, nil
+}
+
+// parseUTCOffset parses an offset formatted as "HH", "HH:MM" or "HHMM"
+// and returns it in seconds.
+func parseUTCOffset(s string) (int, bool) {
+	hh, mm := s, "0"
+	switch {
+	case strings.Contains(s, ":"):
+		hh, mm, _ = strings.Cut(s, ":")
+	case len(s) == 4:
+		hh, mm = s[:2], s[2:]
+	}
+	if len(hh) == 0 || len(hh) > 2 || len(mm) > 2 {
+		return 0, false
+	}
+
+	h, err := strconv.Atoi(hh)
+	if err != nil || h < 0 || h > 15 {
+		return 0, false
+	}
+	m, err := strconv.Atoi(mm)
+	if err != nil || m < 0 || m > 59 {
+		return 0, false
+	}
+
+	return h*3600 + m*60, true
+}
@@ -73,6 +73,7 @@ func (stmt *AlterTableAddColumnStmt) Run(ctx *Context) (Result, error) {
 	// and assign the table to the table.Scan operator
 	// so that it can decode the records properly
 	scan := table.Scan(stmt.TableName)
+	scan.Unrestricted = true
 	scan.Table, err = ctx.Tx.Catalog.GetTable(ctx.Tx, stmt.TableName)
 	if err != nil {
 		return Result{}, errors.Wrap(err, "failed to get table")
@@ -0,0 +1,61 @@
+package statement
+
+import (
+	"context"
+	"os"
+
+	"github.com/cockroachdb/errors"
+)
+
+var _ Statement = (*BackupStmt)(nil)
+
+// BackupStmt is a DSL that allows creating a BACKUP TO statement.
+type BackupStmt struct {
+	// Path of the file the backup is written to.
+	// It must not exist.
+	Path string
+}
+
+// IsReadOnly always returns true. It implements the Statement interface.
+func (stmt *BackupStmt) IsReadOnly() bool {
+	return true
+}
+
+func (stmt *BackupStmt) Bind(ctx *Context) error {
+	return nil
+}
+
+// Run writes a backup of the database, as seen by the transaction, to the file.
+// It implements the Statement interface.
+func (stmt *BackupStmt) Run(ctx *Context) (Result, error) {
+	var res Result
+
+	if stmt.Path == "" {
+		return res, errors.New("missing backup path")
+	}
+
+	// the backup stops when the query is canceled
+	c := ctx.Ctx
+	if c == nil {
+		c = context.Background()
+	}
+
+	f, err := os.OpenFile(stmt.Path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return res, err
+	}
+
+	err = ctx.Tx.Backup(c, f)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		_ = os.Remove(stmt.Path)
+		return res, err
+	}
+
+	return res, nil
+}
@@ -111,7 +111,9 @@ func (stmt *CreateIndexStmt) Run(ctx *Context) (Result, error) {
 		return res, err
 	}
 
-	s := stream.New(table.Scan(stmt.Info.Owner.TableName)).
+	scan := table.Scan(stmt.Info.Owner.TableName)
+	scan.Unrestricted = true
+	s := stream.New(scan).
 		Pipe(index.Insert(stmt.Info.IndexName)).
 		Pipe(stream.Discard())
 
@@ -1,10 +1,15 @@
 package statement
 
 import (
+	"strings"
+
+	"github.com/chaisql/chai/internal/environment"
 	"github.com/chaisql/chai/internal/expr"
 	"github.com/chaisql/chai/internal/planner"
 	"github.com/chaisql/chai/internal/stream"
+	"github.com/chaisql/chai/internal/stream/index"
 	"github.com/chaisql/chai/internal/stream/rows"
+	"github.com/chaisql/chai/internal/stream/table"
 	"github.com/chaisql/chai/internal/types"
 	"github.com/cockroachdb/errors"
 )
@@ -31,25 +36,14 @@ func (stmt *ExplainStmt) Bind(ctx *Context) error {
 // displaying all the operations.
 // Explain currently only works on SELECT, UPDATE, INSERT and DELETE statements.
 func (stmt *ExplainStmt) Run(ctx *Context) (Result, error) {
-	st, err := stmt.Statement.Prepare(ctx)
-	if err != nil {
-		return Result{}, err
-	}
-
-	s, ok := st.(*PreparedStreamStmt)
-	if !ok {
-		return Result{}, errors.New("EXPLAIN only works on INSERT, SELECT, UPDATE AND DELETE statements")
-	}
-
-	// Optimize the stream.
-	s.Stream, err = planner.Optimize(s.Stream, ctx.Tx.Catalog, ctx.Params)
+	st, err := stmt.optimize(ctx)
 	if err != nil {
 		return Result{}, err
 	}
 
 	var plan string
-	if s.Stream != nil {
-		plan = s.Stream.String()
+	if st != nil {
+		plan = st.String()
 	} else {
 		plan = "<no exec>"
 	}
@@ -67,6 +61,188 @@ func (stmt *ExplainStmt) Run(ctx *Context) (Result, error) {
 	return newStatement.Run(ctx)
 }
 
+// optimize prepares the inner statement and returns its optimized stream.
+func (stmt *ExplainStmt) optimize(ctx *Context) (*stream.Stream, error) {
+	st, err := stmt.Statement.Prepare(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s, ok := st.(*PreparedStreamStmt)
+	if !ok {
+		return nil, errors.New("EXPLAIN only works on INSERT, SELECT, UPDATE AND DELETE statements")
+	}
+
+	return planner.Optimize(s.Stream, ctx.Tx, ctx.Params)
+}
+
+// PlanNode describes an operator of the execution plan of a statement.
+type PlanNode struct {
+	// Name of the operator, for example "table.Scan" or "rows.Filter".
+	Operator string
+	// Description of the operator and its arguments, as displayed by EXPLAIN.
+	Description string
+	// Table read or modified by the operator, if any.
+	Table string
+	// Index scanned by the operator, if any.
+	Index string
+	// Upper bound of the number of rows returned by the operator,
+	// or -1 if it cannot be known without running the statement.
+	EstimatedRows int64
+	// Operators whose rows are consumed by this operator.
+	Children []*PlanNode
+}
+
+// Plan returns the execution plan of the inner statement, as a tree whose root
+// is the last operator of the plan. It returns nil if the statement does nothing.
+func (stmt *ExplainStmt) Plan(ctx *Context) (*PlanNode, error) {
+	st, err := stmt.optimize(ctx)
+	if err != nil || st == nil || st.Op == nil {
+		return nil, err
+	}
+
+	var env environment.Environment
+	env.DB = ctx.DB
+	env.Tx = ctx.Tx
+	env.SetParams(ctx.Params)
+
+	return planNode(&env, st.Op), nil
+}
+
+func planNode(env *environment.Environment, op stream.Operator) *PlanNode {
+	desc := op.String()
+	n := PlanNode{
+		Operator:      desc,
+		Description:   desc,
+		EstimatedRows: -1,
+	}
+	if i := strings.IndexByte(desc, '('); i >= 0 {
+		n.Operator = desc[:i]
+	}
+
+	if prev := op.GetPrev(); prev != nil {
+		n.Children = append(n.Children, planNode(env, prev))
+	}
+
+	var streams []*stream.Stream
+	switch t := op.(type) {
+	case *stream.ConcatOperator:
+		streams = t.Streams
+	case *stream.UnionOperator:
+		streams = t.Streams
+	case *stream.OnConflictOperator:
+		if t.OnConflict != nil {
+			streams = []*stream.Stream{t.OnConflict}
+		}
+	}
+	for _, s := range streams {
+		if s != nil && s.Op != nil {
+			n.Children = append(n.Children, planNode(env, s.Op))
+		}
+	}
+
+	// rows of the first child, if known
+	in := int64(-1)
+	if len(n.Children) > 0 {
+		in = n.Children[0].EstimatedRows
+	}
+
+	switch t := op.(type) {
+	case *table.ScanOperator:
+		n.Table = t.TableName
+		if info, err := env.Tx.Catalog.GetTableInfo(t.TableName); err == nil && info.PrimaryKey != nil {
+			n.EstimatedRows = exactRanges(t.Ranges, len(info.PrimaryKey.Columns))
+		}
+	case *index.ScanOperator:
+		n.Index = t.IndexName
+		if info, err := env.Tx.Catalog.GetIndexInfo(t.IndexName); err == nil {
+			n.Table = info.Owner.TableName
+			if info.Unique {
+				n.EstimatedRows = exactRanges(t.Ranges, len(info.Columns))
+			}
+		}
+	case *table.DeleteRangeOperator:
+		n.Table = t.TableName
+		n.EstimatedRows = 0
+	case *table.InsertOperator:
+		n.Table = t.Name
+		n.EstimatedRows = in
+	case *table.ReplaceOperator:
+		n.Table = t.Name
+		n.EstimatedRows = in
+	case *table.DeleteOperator:
+		n.Table = t.Name
+		n.EstimatedRows = in
+	case *stream.DiscardOperator:
+		n.EstimatedRows = 0
+	case *rows.EmitOperator:
+		n.EstimatedRows = int64(len(t.Rows))
+	case *rows.TakeOperator:
+		if limit, ok := evalCount(env, t.E); ok {
+			n.EstimatedRows = limit
+			if in >= 0 {
+				n.EstimatedRows = min(in, limit)
+			}
+		}
+	case *rows.SkipOperator:
+		if offset, ok := evalCount(env, t.E); ok && in >= 0 {
+			n.EstimatedRows = max(in-offset, 0)
+		}
+	case *rows.GroupAggregateOperator:
+		n.EstimatedRows = in
+		if t.E == nil {
+			n.EstimatedRows = 1
+		}
+	case *rows.TableFunctionOperator, *rows.UnnestOperator:
+	case *stream.ConcatOperator, *stream.UnionOperator:
+		var total int64
+		for _, c := range n.Children {
+			if c.EstimatedRows < 0 {
+				total = -1
+				break
+			}
+			total += c.EstimatedRows
+		}
+		n.EstimatedRows = total
+	default:
+		// the other operators return at most the rows they receive
+		n.EstimatedRows = in
+	}
+
+	return &n
+}
+
+// exactRanges returns the number of ranges if each of them
+// selects a single key of the given number of columns, or -1.
+func exactRanges(ranges stream.Ranges, columns int) int64 {
+	if len(ranges) == 0 {
+		return -1
+	}
+
+	for _, r := range ranges {
+		if !r.Exact || len(r.Min) != columns {
+			return -1
+		}
+	}
+
+	return int64(len(ranges))
+}
+
+// evalCount evaluates the argument of LIMIT or OFFSET.
+func evalCount(env *environment.Environment, e expr.Expr) (int64, bool) {
+	v, err := e.Eval(env)
+	if err != nil || !v.Type().IsInteger() {
+		return 0, false
+	}
+
+	v, err = v.CastAs(types.TypeBigint)
+	if err != nil {
+		return 0, false
+	}
+
+	return max(types.AsInt64(v), 0), true
+}
+
 // IsReadOnly indicates that this statement doesn't write anything into
 // the database.
 func (s *ExplainStmt) IsReadOnly() bool {
@@ -183,8 +183,9 @@ func (stmt *InsertStmt) Prepare(c *Context) (Statement, error) {
 	}
 
 	st := StreamStmt{
-		Stream:   s,
-		ReadOnly: false,
+		Stream:    s,
+		ReadOnly:  false,
+		CountRows: true,
 	}
 
 	return st.Prepare(c)
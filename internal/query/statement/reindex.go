@@ -6,6 +6,7 @@ import (
 	"github.com/chaisql/chai/internal/stream"
 	"github.com/chaisql/chai/internal/stream/index"
 	"github.com/chaisql/chai/internal/stream/table"
+	"github.com/cockroachdb/errors"
 )
 
 var _ Statement = (*ReIndexStmt)(nil)
@@ -36,6 +37,10 @@ func (stmt *ReIndexStmt) Bind(ctx *Context) error {
 func (stmt *ReIndexStmt) Prepare(ctx *Context) (Statement, error) {
 	var indexNames []string
 
+	if ctx.Tx.Catalog.IsVirtualTable(stmt.TableOrIndexName) {
+		return nil, errors.Errorf("cannot reindex virtual table %s", stmt.TableOrIndexName)
+	}
+
 	if stmt.TableOrIndexName == "" {
 		indexNames = ctx.Tx.Catalog.Cache.ListObjects(database.RelationIndexType)
 	} else if _, err := ctx.Tx.Catalog.GetTable(ctx.Tx, stmt.TableOrIndexName); err == nil {
@@ -58,12 +63,18 @@ func (stmt *ReIndexStmt) Prepare(ctx *Context) (Statement, error) {
 			return nil, err
 		}
 
+		if ctx.Tx.Catalog.IsVirtualTable(info.Owner.TableName) {
+			return nil, errors.Errorf("cannot reindex index %s of virtual table %s", info.IndexName, info.Owner.TableName)
+		}
+
 		err = idx.Truncate()
 		if err != nil {
 			return nil, err
 		}
 
-		s := stream.New(table.Scan(info.Owner.TableName)).Pipe(index.Insert(info.IndexName))
+		scan := table.Scan(info.Owner.TableName)
+		scan.Unrestricted = true
+		s := stream.New(scan).Pipe(index.Insert(info.IndexName))
 		streams = append(streams, s)
 	}
 
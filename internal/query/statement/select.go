@@ -2,8 +2,10 @@ package statement
 
 import (
 	"fmt"
+	"slices"
 
 	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/expr/functions"
 	"github.com/chaisql/chai/internal/sql/scanner"
 	"github.com/chaisql/chai/internal/stream"
 	"github.com/chaisql/chai/internal/stream/rows"
@@ -14,7 +16,14 @@ import (
 var _ Statement = (*SelectStmt)(nil)
 
 type SelectCoreStmt struct {
-	TableName       string
+	TableName string
+	// array exploded into rows by FROM unnest(expr), instead of a table,
+	// and the name of the column of its elements.
+	UnnestExpr   expr.Expr
+	UnnestColumn string
+	// table function, such as json_each(), returning the rows of the FROM clause
+	// instead of a table.
+	TableFunction   expr.TableFunction
 	Distinct        bool
 	WhereExpr       expr.Expr
 	GroupByExpr     expr.Expr
@@ -22,18 +31,30 @@ type SelectCoreStmt struct {
 }
 
 func (stmt *SelectCoreStmt) Bind(ctx *Context) error {
-	err := BindExpr(ctx, stmt.TableName, stmt.WhereExpr)
+	// the array of FROM unnest(expr) can't refer to any column
+	err := BindExpr(ctx, "", stmt.UnnestExpr)
 	if err != nil {
 		return err
 	}
 
-	err = BindExpr(ctx, stmt.TableName, stmt.GroupByExpr)
+	// and neither can the arguments of a table function
+	err = BindExpr(ctx, "", stmt.TableFunction)
+	if err != nil {
+		return err
+	}
+
+	err = stmt.bindExpr(ctx, stmt.WhereExpr)
+	if err != nil {
+		return err
+	}
+
+	err = stmt.bindExpr(ctx, stmt.GroupByExpr)
 	if err != nil {
 		return err
 	}
 
 	for i := range stmt.ProjectionExprs {
-		err = BindExpr(ctx, stmt.TableName, stmt.ProjectionExprs[i])
+		err = stmt.bindExpr(ctx, stmt.ProjectionExprs[i])
 		if err != nil {
 			return err
 		}
@@ -42,6 +63,37 @@ func (stmt *SelectCoreStmt) Bind(ctx *Context) error {
 	return nil
 }
 
+// bindExpr binds the columns of e to the table of the statement,
+// or checks that they refer to the columns of FROM unnest(expr)
+// or of the table function.
+func (stmt *SelectCoreStmt) bindExpr(ctx *Context, e expr.Expr) (err error) {
+	var columns []string
+	switch {
+	case stmt.UnnestExpr != nil:
+		columns = []string{stmt.UnnestColumn}
+	case stmt.TableFunction != nil:
+		columns = stmt.TableFunction.Columns()
+	default:
+		return BindExpr(ctx, stmt.TableName, e)
+	}
+
+	expr.Walk(e, func(e expr.Expr) bool {
+		if c, ok := e.(*expr.Column); ok && c != nil && !slices.Contains(columns, c.Name) {
+			err = errors.Newf("column %s does not exist", c)
+			return false
+		}
+
+		return true
+	})
+
+	return err
+}
+
+// hasFrom returns true if the statement has a FROM clause.
+func (stmt *SelectCoreStmt) hasFrom() bool {
+	return stmt.TableName != "" || stmt.UnnestExpr != nil || stmt.TableFunction != nil
+}
+
 func (stmt *SelectCoreStmt) Prepare(ctx *Context) (*StreamStmt, error) {
 	isReadOnly := true
 
@@ -56,6 +108,14 @@ func (stmt *SelectCoreStmt) Prepare(ctx *Context) (*StreamStmt, error) {
 		s = s.Pipe(table.Scan(stmt.TableName))
 	}
 
+	if stmt.UnnestExpr != nil {
+		s = s.Pipe(rows.Unnest(stmt.UnnestExpr, stmt.UnnestColumn))
+	}
+
+	if stmt.TableFunction != nil {
+		s = s.Pipe(rows.TableFunction(stmt.TableFunction))
+	}
+
 	if stmt.WhereExpr != nil {
 		s = s.Pipe(rows.Filter(stmt.WhereExpr))
 	}
@@ -103,7 +163,7 @@ func (stmt *SelectCoreStmt) Prepare(ctx *Context) (*StreamStmt, error) {
 		// add Aggregation node
 		s = s.Pipe(rows.TempTreeSort(stmt.GroupByExpr))
 		s = s.Pipe(rows.GroupAggregate(stmt.GroupByExpr, aggregators...))
-	} else if stmt.TableName != "" {
+	} else if stmt.hasFrom() {
 		// if there is no GROUP BY clause, check if there are any aggregation function
 		// and if so add an aggregation node
 		var aggregators []expr.AggregatorBuilder
@@ -127,7 +187,7 @@ func (stmt *SelectCoreStmt) Prepare(ctx *Context) (*StreamStmt, error) {
 	}
 
 	// If there is no FROM clause ensure there is no wildcard or path
-	if stmt.TableName == "" {
+	if !stmt.hasFrom() {
 		var err error
 
 		for _, e := range stmt.ProjectionExprs {
@@ -145,16 +205,47 @@ func (stmt *SelectCoreStmt) Prepare(ctx *Context) (*StreamStmt, error) {
 			}
 		}
 	}
+	// unnest() in the projection repeats each row for every element of its array
+	var unnest *functions.Unnest
+	for _, e := range stmt.ProjectionExprs {
+		var err error
+		expr.Walk(e, func(e expr.Expr) bool {
+			u, ok := e.(*functions.Unnest)
+			if !ok {
+				return true
+			}
+			if unnest != nil && !unnest.IsEqual(u) {
+				err = errors.New("only one unnest() is allowed in the projection")
+				return false
+			}
+			unnest = u
+			return true
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if unnest != nil {
+		s = s.Pipe(rows.Unnest(unnest.Expr, unnest.String()))
+	}
+
 	s = s.Pipe(rows.Project(stmt.ProjectionExprs...))
 
 	// SELECT is read-only most of the time, unless it's using some expressions
 	// that require write access and that are allowed to be run, such as NEXT VALUE FOR
+	// or nextval()
 	for _, e := range stmt.ProjectionExprs {
 		expr.Walk(e, func(e expr.Expr) bool {
-			switch e.(type) {
+			switch t := e.(type) {
 			case expr.NextValueFor:
 				isReadOnly = false
 				return false
+			case *functions.ScalarFunction:
+				if !t.IsReadOnly() {
+					isReadOnly = false
+					return false
+				}
+				return true
 			default:
 				return true
 			}
@@ -202,17 +293,17 @@ func (stmt *SelectStmt) Bind(ctx *Context) error {
 		}
 	}
 
-	err := BindExpr(ctx, stmt.CompoundSelect[0].TableName, stmt.OrderBy)
+	err := stmt.CompoundSelect[0].bindExpr(ctx, stmt.OrderBy)
 	if err != nil {
 		return err
 	}
 
-	err = BindExpr(ctx, stmt.CompoundSelect[0].TableName, stmt.OffsetExpr)
+	err = stmt.CompoundSelect[0].bindExpr(ctx, stmt.OffsetExpr)
 	if err != nil {
 		return err
 	}
 
-	err = BindExpr(ctx, stmt.CompoundSelect[0].TableName, stmt.LimitExpr)
+	err = stmt.CompoundSelect[0].bindExpr(ctx, stmt.LimitExpr)
 	if err != nil {
 		return err
 	}
@@ -1,6 +1,8 @@
 package statement
 
 import (
+	"context"
+
 	"github.com/chaisql/chai/internal/database"
 	"github.com/chaisql/chai/internal/environment"
 	"github.com/chaisql/chai/internal/expr"
@@ -33,6 +35,9 @@ func (stmt *basePreparedStatement) Run(ctx *Context) (Result, error) {
 }
 
 type Context struct {
+	// context of the query, which stops the iteration
+	// of its streams when it is canceled.
+	Ctx    context.Context
 	DB     *database.Database
 	Conn   *database.Connection
 	Tx     *database.Transaction
@@ -1,10 +1,15 @@
 package statement
 
 import (
+	"time"
+
 	"github.com/chaisql/chai/internal/database"
 	"github.com/chaisql/chai/internal/environment"
 	"github.com/chaisql/chai/internal/planner"
+	"github.com/chaisql/chai/internal/row"
 	"github.com/chaisql/chai/internal/stream"
+	"github.com/chaisql/chai/internal/stream/table"
+	"github.com/chaisql/chai/internal/types"
 	"github.com/cockroachdb/errors"
 )
 
@@ -14,20 +19,25 @@ var _ Statement = (*PreparedStreamStmt)(nil)
 type StreamStmt struct {
 	Stream   *stream.Stream
 	ReadOnly bool
+	// CountRows is true if the rows reaching the end of the stream
+	// are the rows inserted, updated or deleted by the statement.
+	CountRows bool
 }
 
 // Prepare implements the Preparer interface.
 func (s *StreamStmt) Prepare(ctx *Context) (Statement, error) {
 	return &PreparedStreamStmt{
-		Stream:   s.Stream,
-		ReadOnly: s.ReadOnly,
+		Stream:    s.Stream,
+		ReadOnly:  s.ReadOnly,
+		CountRows: s.CountRows,
 	}, nil
 }
 
 // PreparedStreamStmt is a PreparedStreamStmt using a Stream.
 type PreparedStreamStmt struct {
-	Stream   *stream.Stream
-	ReadOnly bool
+	Stream    *stream.Stream
+	ReadOnly  bool
+	CountRows bool
 }
 
 func (s *PreparedStreamStmt) Bind(ctx *Context) error {
@@ -37,15 +47,16 @@ func (s *PreparedStreamStmt) Bind(ctx *Context) error {
 // Run returns a result containing the stream. The stream will be executed by calling the Iterate method of
 // the result.
 func (s *PreparedStreamStmt) Run(ctx *Context) (Result, error) {
-	st, err := planner.Optimize(s.Stream.Clone(), ctx.Tx.Catalog, ctx.Params)
+	st, err := planner.Optimize(s.Stream.Clone(), ctx.Tx, ctx.Params)
 	if err != nil {
 		return Result{}, err
 	}
 
 	return Result{
 		Iterator: &StreamStmtIterator{
-			Stream:  st,
-			Context: ctx,
+			Stream:    st,
+			Context:   ctx,
+			CountRows: s.CountRows,
 		},
 	}, nil
 }
@@ -61,17 +72,53 @@ func (s *PreparedStreamStmt) String() string {
 
 // StreamStmtIterator iterates over a stream.
 type StreamStmtIterator struct {
-	Stream  *stream.Stream
-	Context *Context
+	Stream    *stream.Stream
+	Context   *Context
+	CountRows bool
+
+	rowsAffected int64
+}
+
+// RowsAffected returns the number of rows inserted, updated or deleted
+// by the last iteration of the stream, or -1 if it is unknown, which is
+// the case of deletions of whole ranges of rows.
+// It returns 0 for the statements that don't modify rows.
+func (s *StreamStmtIterator) RowsAffected() int64 {
+	return s.rowsAffected
+}
+
+// ReturnsRows reports whether the stream outputs rows. The streams
+// of the statements which only modify the database end with a
+// DiscardOperator and return no rows.
+func (s *StreamStmtIterator) ReturnsRows() bool {
+	if s.Stream.Op == nil {
+		return false
+	}
+
+	_, ok := s.Stream.Op.(*stream.DiscardOperator)
+	return !ok
 }
 
 func (s *StreamStmtIterator) Iterate(fn func(r database.Row) error) error {
 	var env environment.Environment
 	env.DB = s.Context.DB
 	env.Tx = s.Context.Tx
+	env.Ctx = s.Context.Ctx
 	env.SetParams(s.Context.Params)
 
-	err := s.Stream.Iterate(&env, func(env *environment.Environment) error {
+	s.rowsAffected = 0
+	st := s.Stream
+	if d, ok := st.Op.(*stream.DiscardOperator); ok && s.CountRows {
+		if _, ok := d.Prev.(*table.DeleteRangeOperator); ok {
+			s.rowsAffected = -1
+		} else {
+			// count the rows instead of discarding them
+			st = stream.New(d.Prev)
+			fn = func(database.Row) error { return nil }
+		}
+	}
+
+	err := st.Iterate(&env, func(env *environment.Environment) error {
 		// if there is no row in this specific environment,
 		// the last operator is not outputting anything
 		// worth returning to the user.
@@ -79,10 +126,46 @@ func (s *StreamStmtIterator) Iterate(fn func(r database.Row) error) error {
 			return nil
 		}
 
-		return fn(env.Row.(database.Row))
+		if s.CountRows && s.rowsAffected >= 0 {
+			s.rowsAffected++
+		}
+
+		r := env.Row.(database.Row)
+		if loc := env.GetTimeZone(); loc != nil && loc != time.UTC {
+			r = &timeZoneRow{Row: r, loc: loc}
+		}
+
+		return fn(r)
 	})
 	if errors.Is(err, stream.ErrStreamClosed) {
 		err = nil
 	}
 	return err
 }
+
+// timeZoneRow displays the TIMESTAMPTZ values of a row
+// in the time zone of the transaction.
+type timeZoneRow struct {
+	database.Row
+
+	loc *time.Location
+}
+
+func (r *timeZoneRow) Iterate(fn func(column string, value types.Value) error) error {
+	return r.Row.Iterate(func(column string, value types.Value) error {
+		return fn(column, types.InTimeZone(value, r.loc))
+	})
+}
+
+func (r *timeZoneRow) Get(name string) (types.Value, error) {
+	v, err := r.Row.Get(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return types.InTimeZone(v, r.loc), nil
+}
+
+func (r *timeZoneRow) MarshalJSON() ([]byte, error) {
+	return row.MarshalJSON(r)
+}
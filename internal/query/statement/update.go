@@ -126,8 +126,9 @@ func (stmt *UpdateStmt) Prepare(c *Context) (Statement, error) {
 	s = s.Pipe(stream.Discard())
 
 	st := StreamStmt{
-		Stream:   s,
-		ReadOnly: false,
+		Stream:    s,
+		ReadOnly:  false,
+		CountRows: true,
 	}
 
 	return st.Prepare(c)
@@ -0,0 +1,49 @@
+package statement
+
+import (
+	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/stream"
+	"github.com/chaisql/chai/internal/stream/rows"
+	"github.com/chaisql/chai/internal/types"
+)
+
+var _ Statement = (*VacuumStmt)(nil)
+
+// VacuumStmt is a DSL that allows creating a VACUUM statement.
+type VacuumStmt struct{}
+
+// IsReadOnly always returns false. It implements the Statement interface.
+func (stmt *VacuumStmt) IsReadOnly() bool {
+	return false
+}
+
+func (stmt *VacuumStmt) Bind(ctx *Context) error {
+	return nil
+}
+
+// Run removes the data of the dead namespaces and returns a row containing
+// the number of namespaces cleaned up and the number of bytes reclaimed.
+// It implements the Statement interface.
+func (stmt *VacuumStmt) Run(ctx *Context) (Result, error) {
+	report, err := ctx.Tx.Vacuum()
+	if err != nil {
+		return Result{}, err
+	}
+
+	s := PreparedStreamStmt{
+		Stream: &stream.Stream{
+			Op: rows.Project(
+				&expr.NamedExpr{
+					ExprName: "namespaces",
+					Expr:     expr.LiteralValue{Value: types.NewBigintValue(int64(report.Namespaces))},
+				},
+				&expr.NamedExpr{
+					ExprName: "reclaimed_bytes",
+					Expr:     expr.LiteralValue{Value: types.NewBigintValue(int64(report.ReclaimedBytes))},
+				},
+			),
+		},
+		ReadOnly: false,
+	}
+	return s.Run(ctx)
+}
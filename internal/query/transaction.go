@@ -12,7 +12,8 @@ var _ queryAlterer = CommitStmt{}
 
 // BeginStmt is a statement that creates a new transaction.
 type BeginStmt struct {
-	Writable bool
+	Writable     bool
+	Serializable bool
 }
 
 func (stmt BeginStmt) Bind(ctx *statement.Context) error {
@@ -31,7 +32,8 @@ func (stmt BeginStmt) alterQuery(conn *database.Connection, q *Query) error {
 
 	var err error
 	q.tx, err = conn.BeginTx(&database.TxOptions{
-		ReadOnly: !stmt.Writable,
+		ReadOnly:     !stmt.Writable,
+		Serializable: stmt.Serializable,
 	})
 	q.autoCommit = false
 	return err
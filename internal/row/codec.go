@@ -0,0 +1,68 @@
+package row
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+
+	"github.com/cockroachdb/errors"
+)
+
+// Codec converts the values of a Go type to and from the values of the database.
+type Codec struct {
+	// Encode converts a value of the type to a value supported by NewValue.
+	Encode func(x any) (any, error)
+	// Decode converts a value read from the database, passed as one of the
+	// types of scannerValue, to a value of the type. It is not called for NULL values.
+	Decode func(src any) (any, error)
+}
+
+var (
+	// codecs maps the registered types to their *Codec.
+	codecs sync.Map
+	// hasCodecs avoids looking up the map until a codec is registered.
+	hasCodecs atomic.Bool
+)
+
+// RegisterCodec registers the codec of the type t, replacing the previous one.
+// Codecs take precedence over the standard interfaces and the built-in conversions.
+func RegisterCodec(t reflect.Type, c Codec) {
+	if t == nil || c.Encode == nil || c.Decode == nil {
+		panic("row: RegisterCodec requires a type, an encoder and a decoder")
+	}
+
+	codecs.Store(t, &c)
+	hasCodecs.Store(true)
+}
+
+// HasCodec returns whether a codec was registered for the type t.
+func HasCodec(t reflect.Type) bool {
+	return codecFor(t) != nil
+}
+
+func codecFor(t reflect.Type) *Codec {
+	if t == nil || !hasCodecs.Load() {
+		return nil
+	}
+
+	c, ok := codecs.Load(t)
+	if !ok {
+		return nil
+	}
+
+	return c.(*Codec)
+}
+
+// encodeWithCodec converts x using the codec c.
+func encodeWithCodec(c *Codec, x any) (any, error) {
+	v, err := c.Encode(x)
+	if err != nil {
+		return nil, err
+	}
+
+	if reflect.TypeOf(v) == reflect.TypeOf(x) {
+		return nil, errors.Errorf("the codec of %T returned a value of the same type", x)
+	}
+
+	return v, nil
+}
@@ -0,0 +1,201 @@
+package row
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+var rowScannerType = reflect.TypeOf((*RowScanner)(nil)).Elem()
+
+// decoders caches the structDecoder of each struct type.
+var decoders sync.Map
+
+// structDecoder scans rows into the structs of a given type.
+// The fields of the struct are resolved once, by decoderFor,
+// and reused for every row.
+type structDecoder struct {
+	fields []decoderField
+	// columns mapped to a field
+	columns map[string]struct{}
+	// true if an embedded struct implements RowScanner
+	scansRows bool
+}
+
+type decoderField struct {
+	// index of the field, through the embedded structs
+	index  []int
+	column string
+	name   string
+	// embedded struct implementing RowScanner
+	rowScanner bool
+}
+
+// decoderFor returns the decoder of the struct type t.
+func decoderFor(t reflect.Type) *structDecoder {
+	if d, ok := decoders.Load(t); ok {
+		return d.(*structDecoder)
+	}
+
+	d := structDecoder{
+		columns: make(map[string]struct{}),
+	}
+	d.addFields(t, nil)
+
+	actual, _ := decoders.LoadOrStore(t, &d)
+	return actual.(*structDecoder)
+}
+
+// addFields adds the fields of t. The fields of embedded structs, or pointers to structs,
+// are added as if they were fields of t, unless the embedded field has a tag.
+func (d *structDecoder) addFields(t reflect.Type, index []int) {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		idx := append(index[:len(index):len(index)], i)
+
+		gtag, hasTag := sf.Tag.Lookup("chai")
+		if gtag == "-" {
+			continue
+		}
+
+		if sf.Anonymous && !hasTag {
+			et := sf.Type
+			if et.Kind() == reflect.Ptr {
+				et = et.Elem()
+			}
+
+			if et.Kind() == reflect.Struct && et != timeType {
+				if reflect.PointerTo(et).Implements(rowScannerType) {
+					d.fields = append(d.fields, decoderField{index: idx, name: sf.Name, rowScanner: true})
+					d.scansRows = true
+				} else {
+					d.addFields(et, idx)
+				}
+				continue
+			}
+		}
+
+		if !sf.IsExported() {
+			continue
+		}
+
+		column := gtag
+		if !hasTag {
+			column = strings.ToLower(sf.Name)
+		}
+
+		d.fields = append(d.fields, decoderField{index: idx, column: column, name: sf.Name})
+		d.columns[column] = struct{}{}
+	}
+}
+
+// decode scans r into the struct sref.
+// Columns missing from the row are scanned as NULL.
+func (d *structDecoder) decode(r Row, sref reflect.Value) error {
+	for _, f := range d.fields {
+		fv, ok := fieldByIndex(sref, f.index)
+		if !ok {
+			continue
+		}
+
+		if f.rowScanner {
+			if fv.Kind() != reflect.Ptr {
+				fv = fv.Addr()
+			} else if fv.IsNil() {
+				if !fv.CanSet() {
+					continue
+				}
+				fv.Set(reflect.New(fv.Type().Elem()))
+			}
+
+			err := fv.Interface().(RowScanner).ScanRow(r)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		v, err := r.Get(f.column)
+		if errors.Is(err, types.ErrColumnNotFound) {
+			v = types.NewNullValue()
+		} else if err != nil {
+			return err
+		}
+
+		if err := scanValue(v, fv); err != nil {
+			return errors.Wrapf(err, "cannot scan column %q into field %s", f.column, f.name)
+		}
+	}
+
+	return nil
+}
+
+// fieldByIndex returns the field of v at the given index, allocating the nil pointers
+// to embedded structs on the way. It returns false if one of them cannot be allocated,
+// because its type is unexported.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return reflect.Value{}, false
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+
+		v = v.Field(x)
+	}
+
+	return v, true
+}
+
+var errStopScan = errors.New("stop scan")
+
+// ScanFunc returns a function scanning rows into pointers of type t,
+// for scanning many rows into values of the same type.
+// Pointers to structs are scanned like with StructScan, with the fields of the
+// struct resolved once. Pointers to other types, including time.Time and the types
+// implementing sql.Scanner or encoding.TextUnmarshaler, receive the first column of the row.
+func ScanFunc(t reflect.Type) (func(r Row, dest any) error, error) {
+	if t.Kind() != reflect.Ptr {
+		return nil, errors.New("target must be pointer to a valid Go type")
+	}
+
+	if t.Implements(rowScannerType) {
+		return func(r Row, dest any) error {
+			return dest.(RowScanner).ScanRow(r)
+		}, nil
+	}
+
+	et := t.Elem()
+	if et.Kind() == reflect.Struct && et != timeType && !t.Implements(scannerType) && !t.Implements(textUnmarshalerType) && !HasCodec(et) {
+		d := decoderFor(et)
+		return func(r Row, dest any) error {
+			return d.decode(r, reflect.ValueOf(dest).Elem())
+		}, nil
+	}
+
+	return func(r Row, dest any) error {
+		ref := reflect.ValueOf(dest)
+		err := r.Iterate(func(column string, v types.Value) error {
+			err := scanValue(v, ref)
+			if err != nil {
+				return err
+			}
+			return errStopScan
+		})
+		if errors.Is(err, errStopScan) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		return errors.New("cannot scan a row without columns")
+	}, nil
+}
@@ -94,6 +94,9 @@ func marshalText(dst *bytes.Buffer, v types.Value, prefix, indent string, depth
 	case types.TypeInteger, types.TypeBigint:
 		dst.WriteString(strconv.FormatInt(types.AsInt64(v), 10))
 		return nil
+	case types.TypeUnsignedBigint:
+		dst.WriteString(strconv.FormatUint(types.AsUint64(v), 10))
+		return nil
 	case types.TypeDouble:
 		f := types.AsFloat64(v)
 		abs := math.Abs(f)
@@ -113,12 +116,24 @@ func marshalText(dst *bytes.Buffer, v types.Value, prefix, indent string, depth
 		}
 		dst.WriteString(strconv.FormatFloat(types.AsFloat64(v), fmt, prec, 64))
 		return nil
-	case types.TypeTimestamp:
+	case types.TypeReal:
+		dst.WriteString(v.String())
+		return nil
+	case types.TypeTimestamp, types.TypeTimestampTZ:
 		dst.WriteString(strconv.Quote(types.AsTime(v).Format(time.RFC3339Nano)))
 		return nil
-	case types.TypeText:
+	case types.TypeText, types.TypeCitext:
 		dst.WriteString(strconv.Quote(types.AsString(v)))
 		return nil
+	case types.TypeUUID:
+		dst.WriteString(strconv.Quote(types.FormatUUID(v.V().([16]byte))))
+		return nil
+	case types.TypeJSON:
+		dst.WriteString(strconv.Quote(types.FormatJSON(types.AsByteSlice(v))))
+		return nil
+	case types.TypeObject, types.TypeArray, types.TypeInet, types.TypeCidr, types.TypePoint, types.TypeInterval:
+		dst.WriteString(v.String())
+		return nil
 	case types.TypeBlob:
 		src := types.AsByteSlice(v)
 		dst.WriteString("\"\\x")
@@ -0,0 +1,113 @@
+package row
+
+import (
+	"reflect"
+
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// NewFromObject returns a row whose columns are the fields of an object.
+func NewFromObject(o types.ObjectValue) Row {
+	return objectRow(o)
+}
+
+type objectRow types.ObjectValue
+
+var _ Row = objectRow(nil)
+
+func (o objectRow) Iterate(fn func(column string, value types.Value) error) error {
+	return types.ObjectValue(o).Iterate(fn)
+}
+
+func (o objectRow) Get(column string) (types.Value, error) {
+	v, ok := types.ObjectValue(o).Get(column)
+	if !ok {
+		return nil, errors.Wrapf(types.ErrColumnNotFound, "%s not found", column)
+	}
+
+	return v, nil
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (o objectRow) MarshalJSON() ([]byte, error) {
+	return types.ObjectValue(o).MarshalJSON()
+}
+
+// NewObjectValue returns an object made of the columns of a row.
+func NewObjectValue(r Row) (types.ObjectValue, error) {
+	var fields []types.ObjectField
+	err := r.Iterate(func(column string, v types.Value) error {
+		fields = append(fields, types.ObjectField{Name: column, Value: v})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return types.MakeObject(fields...)
+}
+
+// newArrayValue returns an array made of the elements of a Go slice or array.
+func newArrayValue(ref reflect.Value) (types.ArrayValue, error) {
+	values := make([]types.Value, ref.Len())
+	for i := range values {
+		v, err := NewValue(ref.Index(i).Interface())
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+
+	return types.MakeArray(values...)
+}
+
+// scanObject scans an object into a map, a struct or an empty interface,
+// which receives a map[string]any.
+func scanObject(o types.ObjectValue, ref reflect.Value) error {
+	switch ref.Kind() {
+	case reflect.Map:
+		return mapScan(objectRow(o), ref)
+	case reflect.Struct:
+		return structScan(objectRow(o), ref.Addr())
+	case reflect.Interface:
+		m := make(map[string]any)
+		err := mapScan(objectRow(o), reflect.ValueOf(m))
+		if err != nil {
+			return err
+		}
+		ref.Set(reflect.ValueOf(m))
+		return nil
+	}
+
+	return NewErrUnsupportedType(ref.Interface(), "Invalid type")
+}
+
+// scanArray scans an array into a slice, a Go array or an empty interface,
+// which receives a []any.
+func scanArray(a types.ArrayValue, ref reflect.Value) error {
+	switch ref.Kind() {
+	case reflect.Slice:
+		ref.Set(reflect.MakeSlice(ref.Type(), a.Len(), a.Len()))
+	case reflect.Array:
+		if ref.Len() < a.Len() {
+			return errors.Errorf("cannot scan an array of %d elements into a Go array of %d elements", a.Len(), ref.Len())
+		}
+	case reflect.Interface:
+		s := reflect.ValueOf(make([]any, a.Len()))
+		err := a.Iterate(func(i int, v types.Value) error {
+			return scanValue(v, s.Index(i))
+		})
+		if err != nil {
+			return err
+		}
+		ref.Set(s)
+		return nil
+	default:
+		return NewErrUnsupportedType(ref.Interface(), "Invalid type")
+	}
+
+	return a.Iterate(func(i int, v types.Value) error {
+		return scanValue(v, ref.Index(i))
+	})
+}
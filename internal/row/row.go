@@ -1,8 +1,11 @@
 package row
 
 import (
-	"fmt"
+	"database/sql/driver"
+	"encoding"
+	"encoding/json"
 	"math"
+	"net/netip"
 	"reflect"
 	"sort"
 	"strings"
@@ -199,24 +202,83 @@ func newFromStruct(ref reflect.Value) (Row, error) {
 
 // NewValue creates a value whose type is infered from x.
 func NewValue(x any) (types.Value, error) {
+	// Types with a registered codec are converted by their codec
+	if c := codecFor(reflect.TypeOf(x)); c != nil {
+		v, err := encodeWithCodec(c, x)
+		if err != nil {
+			return nil, err
+		}
+		return NewValue(v)
+	}
+
 	// Attempt exact matches first:
 	switch v := x.(type) {
 	case time.Duration:
 		return types.NewBigintValue(v.Nanoseconds()), nil
 	case time.Time:
 		return types.NewTimestampValue(v), nil
+	case json.RawMessage:
+		doc, err := types.ParseJSON(v)
+		if err != nil {
+			return nil, err
+		}
+		return types.NewJSONValue(doc), nil
+	case netip.Prefix:
+		if !v.IsValid() {
+			return types.NewNullValue(), nil
+		}
+		return types.NewInetValue(v), nil
+	case netip.Addr:
+		if !v.IsValid() {
+			return types.NewNullValue(), nil
+		}
+		return types.NewInetValue(netip.PrefixFrom(v, v.BitLen())), nil
 	case nil:
 		return types.NewNullValue(), nil
 	}
 
-	// Compare by kind to detect type definitions over built-in types.
 	v := reflect.ValueOf(x)
-	switch v.Kind() {
-	case reflect.Ptr:
+	if v.Kind() == reflect.Ptr {
 		if v.IsNil() {
 			return types.NewNullValue(), nil
 		}
-		return NewValue(reflect.Indirect(v).Interface())
+		// pointers are converted like the values they point to, unless only
+		// the pointer implements one of the interfaces below
+		if !isConvertible(v.Type()) || isConvertible(v.Type().Elem()) || HasCodec(v.Type().Elem()) {
+			return NewValue(v.Elem().Interface())
+		}
+	}
+
+	// Custom types are converted using the standard interfaces, like in database/sql,
+	// except arrays of 16 bytes which are converted to UUIDs below.
+	if !isUUIDArray(v.Type()) {
+		switch t := x.(type) {
+		case driver.Valuer:
+			dv, err := t.Value()
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := dv.(driver.Valuer); ok {
+				return nil, errors.Errorf("the Value method of %T returned a driver.Valuer", x)
+			}
+			return NewValue(dv)
+		case encoding.TextMarshaler:
+			text, err := t.MarshalText()
+			if err != nil {
+				return nil, err
+			}
+			return types.NewTextValue(string(text)), nil
+		case encoding.BinaryMarshaler:
+			data, err := t.MarshalBinary()
+			if err != nil {
+				return nil, err
+			}
+			return types.NewBlobValue(data), nil
+		}
+	}
+
+	// Compare by kind to detect type definitions over built-in types.
+	switch v.Kind() {
 	case reflect.Bool:
 		return types.NewBooleanValue(v.Bool()), nil
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
@@ -224,10 +286,12 @@ func NewValue(x any) (types.Value, error) {
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
 		x := v.Uint()
 		if x > math.MaxInt64 {
-			return nil, fmt.Errorf("cannot convert unsigned integer struct column to int64: %d out of range", x)
+			return types.NewUnsignedBigintValue(x), nil
 		}
 		return types.NewBigintValue(int64(x)), nil
-	case reflect.Float32, reflect.Float64:
+	case reflect.Float32:
+		return types.NewRealValue(float32(v.Float())), nil
+	case reflect.Float64:
 		return types.NewDoubleValue(v.Float()), nil
 	case reflect.String:
 		return types.NewTextValue(v.String()), nil
@@ -235,7 +299,31 @@ func NewValue(x any) (types.Value, error) {
 		if reflect.TypeOf(v.Interface()).Elem().Kind() == reflect.Uint8 {
 			return types.NewBlobValue(v.Bytes()), nil
 		}
-		return nil, errors.Errorf("unsupported slice type: %T", x)
+		if v.IsNil() {
+			return types.NewNullValue(), nil
+		}
+		return newArrayValue(v)
+	case reflect.Array:
+		if isUUIDArray(v.Type()) {
+			var u [16]byte
+			reflect.Copy(reflect.ValueOf(u[:]), v)
+			return types.NewUUIDValue(u), nil
+		}
+		return newArrayValue(v)
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil, errors.Errorf("unsupported map type: %T, keys must be strings", x)
+		}
+		if v.IsNil() {
+			return types.NewNullValue(), nil
+		}
+		return NewObjectValue(reflectMapObject(v))
+	case reflect.Struct:
+		r, err := newFromStruct(v)
+		if err != nil {
+			return nil, err
+		}
+		return NewObjectValue(r)
 	case reflect.Interface:
 		if v.IsNil() {
 			return types.NewNullValue(), nil
@@ -246,6 +334,24 @@ func NewValue(x any) (types.Value, error) {
 	return nil, NewErrUnsupportedType(x, "")
 }
 
+var (
+	valuerType          = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	binaryMarshalerType = reflect.TypeOf((*encoding.BinaryMarshaler)(nil)).Elem()
+)
+
+// isConvertible returns true if t implements one of the interfaces used by NewValue
+// to convert custom types.
+func isConvertible(t reflect.Type) bool {
+	return t.Implements(valuerType) || t.Implements(textMarshalerType) || t.Implements(binaryMarshalerType)
+}
+
+// isUUIDArray returns true for arrays of 16 bytes,
+// such as the UUID types of most Go packages.
+func isUUIDArray(t reflect.Type) bool {
+	return t.Kind() == reflect.Array && t.Elem().Kind() == reflect.Uint8 && t.Len() == 16
+}
+
 // NewFromCSV takes a list of headers and columns and returns an row.
 // Each header will be assigned as the key and each corresponding column as a text value.
 // The length of headers and columns must be the same.
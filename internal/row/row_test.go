@@ -1,6 +1,8 @@
 package row_test
 
 import (
+	"database/sql/driver"
+	"fmt"
 	"testing"
 	"time"
 
@@ -56,6 +58,13 @@ func TestNewValue(t *testing.T) {
 		{"myInt16", myInt16(500), int64(500)},
 		{"myInt64", myInt64(10), int64(10)},
 		{"myFloat64", myFloat64(10.1), float64(10.1)},
+		{"valuer", money{cents: 150}, int64(150)},
+		{"nil valuer", (*money)(nil), nil},
+		{"text marshaler", userID{n: 1}, "user-1"},
+		{"pointer text marshaler", &ptrID{n: 2}, "ptr-2"},
+		{"binary marshaler", blobID{1, 2, 3, 4}, []byte{1, 2, 3, 4}},
+		{"time pointer", &now, now.UTC()},
+		{"uuid valuer", uuidValuer{1}, [16]byte{1}},
 	}
 
 	for _, test := range tests {
@@ -67,6 +76,44 @@ func TestNewValue(t *testing.T) {
 	}
 }
 
+type money struct {
+	cents int64
+}
+
+func (m money) Value() (driver.Value, error) {
+	return m.cents, nil
+}
+
+type userID struct {
+	n int
+}
+
+func (u userID) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("user-%d", u.n)), nil
+}
+
+type ptrID struct {
+	n int
+}
+
+func (p *ptrID) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("ptr-%d", p.n)), nil
+}
+
+type blobID [4]byte
+
+func (b blobID) MarshalBinary() ([]byte, error) {
+	return b[:], nil
+}
+
+// uuidValuer is converted to a UUID, like the UUID types of most Go packages,
+// even though it implements driver.Valuer.
+type uuidValuer [16]byte
+
+func (u uuidValuer) Value() (driver.Value, error) {
+	return "not a uuid", nil
+}
+
 func TestNewFromMap(t *testing.T) {
 	m := map[string]interface{}{
 		"name":     "foo",
@@ -2,7 +2,13 @@ package row
 
 import (
 	"bytes"
+	"database/sql"
+	"encoding"
+	"encoding/json"
 	"fmt"
+	"io"
+	"math"
+	"net/netip"
 	"reflect"
 	"strings"
 	"time"
@@ -62,8 +68,23 @@ func Scan(r Row, targets ...any) error {
 // The decoding of each struct field can be customized by the format string stored
 // under the "chai" key stored in the struct field's tag.
 // The content of the format string is used instead of the struct field name and passed
-// to the Get method.
+// to the Get method. Fields tagged with "-" and unexported fields are skipped.
+//
+// The fields of embedded structs, or pointers to structs, are scanned as if they were
+// fields of the outer struct, unless the embedded field has a tag.
+// NULL values set pointer fields to nil and the other fields to their zero value.
+// Fields implementing sql.Scanner or encoding.TextUnmarshaler are scanned using these interfaces.
 func StructScan(r Row, t any) error {
+	return structScanInto(r, t, false)
+}
+
+// StructScanStrict is like StructScan, but returns an error
+// if a column of the row doesn't match any field of the struct.
+func StructScanStrict(r Row, t any) error {
+	return structScanInto(r, t, true)
+}
+
+func structScanInto(r Row, t any, strict bool) error {
 	if cb, ok := t.(*ColumnBuffer); ok {
 		return cb.Copy(r)
 	}
@@ -82,50 +103,31 @@ func StructScan(r Row, t any) error {
 		ref.Set(reflect.New(ref.Type().Elem()))
 	}
 
-	return structScan(r, ref)
-}
+	err := structScan(r, ref)
+	if err != nil || !strict {
+		return err
+	}
 
-func structScan(r Row, ref reflect.Value) error {
-	if ref.Type().Implements(reflect.TypeOf((*RowScanner)(nil)).Elem()) {
-		return ref.Interface().(RowScanner).ScanRow(r)
+	d := decoderFor(ref.Elem().Type())
+	if d.scansRows || ref.Type().Implements(rowScannerType) {
+		return nil
 	}
 
-	sref := reflect.Indirect(ref)
-	stp := sref.Type()
-	l := sref.NumField()
-	for i := 0; i < l; i++ {
-		f := sref.Field(i)
-		sf := stp.Field(i)
-		if sf.Anonymous {
-			err := structScan(r, f)
-			if err != nil {
-				return err
-			}
-			continue
-		}
-		var name string
-		if gtag, ok := sf.Tag.Lookup("chai"); ok {
-			if gtag == "-" {
-				continue
-			}
-
-			name = gtag
-		} else {
-			name = strings.ToLower(sf.Name)
-		}
-		v, err := r.Get(name)
-		if errors.Is(err, types.ErrColumnNotFound) {
-			v = types.NewNullValue()
-		} else if err != nil {
-			return err
+	return r.Iterate(func(c string, _ types.Value) error {
+		if _, ok := d.columns[c]; !ok {
+			return errors.Errorf("column %q has no matching field in %s", c, ref.Elem().Type())
 		}
+		return nil
+	})
+}
 
-		if err := scanValue(v, f); err != nil {
-			return err
-		}
+// structScan scans the row into the struct pointed by ref.
+func structScan(r Row, ref reflect.Value) error {
+	if ref.Type().Implements(rowScannerType) {
+		return ref.Interface().(RowScanner).ScanRow(r)
 	}
 
-	return nil
+	return decoderFor(ref.Type().Elem()).decode(r, ref.Elem())
 }
 
 // MapScan decodes the row into a map.
@@ -152,6 +154,9 @@ func mapScan(r Row, ref reflect.Value) error {
 	}
 
 	if ref.IsNil() {
+		if !ref.CanSet() {
+			return errors.New("cannot scan into a nil map")
+		}
 		ref.Set(reflect.MakeMap(ref.Type()))
 	}
 
@@ -168,6 +173,163 @@ func mapScan(r Row, ref reflect.Value) error {
 	})
 }
 
+// SliceScan returns the values of the row, in the order of its columns.
+// Each value is converted to the Go type used when scanning into an empty interface,
+// and NULL values are returned as nil.
+func SliceScan(r Row) ([]any, error) {
+	var values []any
+
+	err := r.Iterate(func(c string, v types.Value) error {
+		var x any
+		err := scanValue(v, reflect.ValueOf(&x))
+		if err != nil {
+			return err
+		}
+
+		values = append(values, x)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+var (
+	readerType            = reflect.TypeOf((*io.Reader)(nil)).Elem()
+	scannerType           = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+	textUnmarshalerType   = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	binaryUnmarshalerType = reflect.TypeOf((*encoding.BinaryUnmarshaler)(nil)).Elem()
+	timeType              = reflect.TypeOf(time.Time{})
+	prefixType            = reflect.TypeOf(netip.Prefix{})
+	addrType              = reflect.TypeOf(netip.Addr{})
+)
+
+// scannerValue converts v to one of the types passed to sql.Scanner implementations:
+// nil, bool, int64, float64, time.Time, string or []byte.
+// Unsigned bigints larger than math.MaxInt64 are passed as uint64.
+func scannerValue(v types.Value) (any, error) {
+	switch v.Type() {
+	case types.TypeNull:
+		return nil, nil
+	case types.TypeBoolean:
+		return types.AsBool(v), nil
+	case types.TypeInteger, types.TypeBigint:
+		return types.AsInt64(v), nil
+	case types.TypeUnsignedBigint:
+		x := types.AsUint64(v)
+		if x > math.MaxInt64 {
+			return x, nil
+		}
+		return int64(x), nil
+	case types.TypeDouble, types.TypeReal:
+		v, err := v.CastAs(types.TypeDouble)
+		if err != nil {
+			return nil, err
+		}
+		return types.AsFloat64(v), nil
+	case types.TypeTimestamp, types.TypeTimestampTZ:
+		return types.AsTime(v), nil
+	case types.TypeBlob:
+		var b []byte
+		err := scanValue(v, reflect.ValueOf(&b))
+		return b, err
+	}
+
+	var s string
+	err := scanValue(v, reflect.ValueOf(&s))
+	return s, err
+}
+
+// scanWithCodec scans v into ref using the codec of its type.
+func scanWithCodec(c *Codec, v types.Value, ref reflect.Value) error {
+	src, err := scannerValue(v)
+	if err != nil {
+		return err
+	}
+
+	x, err := c.Decode(src)
+	if err != nil {
+		return err
+	}
+
+	xv := reflect.ValueOf(x)
+	if !xv.IsValid() || xv.Type() != ref.Type() {
+		return errors.Errorf("the codec of %s returned a value of type %T", ref.Type(), x)
+	}
+
+	ref.Set(xv)
+	return nil
+}
+
+// scanWithInterface scans v using the sql.Scanner implementation of ref, if any.
+// Otherwise, types implementing encoding.BinaryUnmarshaler are scanned from blobs
+// and types implementing encoding.TextUnmarshaler from the text representation of v,
+// except the ones supported by scanValue, such as time.Time.
+// NULL values are only passed to sql.Scanner implementations.
+func scanWithInterface(v types.Value, ref reflect.Value) (bool, error) {
+	if !ref.CanAddr() {
+		return false, nil
+	}
+
+	ptr := ref.Addr()
+	switch {
+	case ptr.Type().Implements(scannerType):
+		src, err := scannerValue(v)
+		if err != nil {
+			return true, err
+		}
+
+		return true, ptr.Interface().(sql.Scanner).Scan(src)
+	case v.Type() == types.TypeNull:
+		return false, nil
+	}
+
+	switch ref.Type() {
+	case timeType, prefixType, addrType:
+		return false, nil
+	}
+
+	switch {
+	case v.Type() == types.TypeBlob && ptr.Type().Implements(binaryUnmarshalerType):
+		var b []byte
+		err := scanValue(v, reflect.ValueOf(&b))
+		if err != nil {
+			return true, err
+		}
+
+		return true, ptr.Interface().(encoding.BinaryUnmarshaler).UnmarshalBinary(bytes.Clone(b))
+	case ptr.Type().Implements(textUnmarshalerType):
+		v, err := v.CastAs(types.TypeText)
+		if err != nil {
+			return true, err
+		}
+
+		return true, ptr.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(types.AsString(v)))
+	}
+
+	return false, nil
+}
+
+// openBlob returns a reader over the content of a blob.
+// Blobs stored outside of their row are streamed instead of being loaded in memory.
+func openBlob(v types.Value) (io.Reader, error) {
+	if bs, ok := v.(types.BlobStream); ok {
+		return bs.Open()
+	}
+
+	v, err := v.CastAs(types.TypeBlob)
+	if err != nil {
+		return nil, err
+	}
+
+	// copy the byte slice to avoid
+	// keeping a reference to the underlying buffer
+	// which could be reused
+	return bytes.NewReader(bytes.Clone(types.AsByteSlice(v))), nil
+}
+
 // ScanValue scans v into t.
 func ScanValue(v types.Value, t any) error {
 	return scanValue(v, reflect.ValueOf(t))
@@ -180,6 +342,13 @@ func scanValue(v types.Value, ref reflect.Value) error {
 
 	if v.Type() == types.TypeNull {
 		if ref.Type().Kind() != reflect.Ptr {
+			if ok, err := scanWithInterface(v, ref); ok {
+				return err
+			}
+			// struct fields are set to their zero value
+			if ref.CanSet() {
+				ref.Set(reflect.Zero(ref.Type()))
+			}
 			return nil
 		}
 
@@ -219,6 +388,42 @@ func scanValue(v types.Value, ref reflect.Value) error {
 		return nil
 	}
 
+	if c := codecFor(ref.Type()); c != nil {
+		return scanWithCodec(c, v, ref)
+	}
+
+	if ok, err := scanWithInterface(v, ref); ok {
+		return err
+	}
+
+	// objects and arrays can be scanned into Go maps, structs, slices and arrays
+	switch x := v.(type) {
+	case types.ObjectValue:
+		switch ref.Kind() {
+		case reflect.Map:
+			return scanObject(x, ref)
+		case reflect.Interface:
+			if ref.Type() != readerType && ref.IsNil() {
+				return scanObject(x, ref)
+			}
+		case reflect.Struct:
+			if ref.Type() != reflect.TypeOf(time.Time{}) {
+				return scanObject(x, ref)
+			}
+		}
+	case types.ArrayValue:
+		switch ref.Kind() {
+		case reflect.Slice, reflect.Array:
+			if ref.Type().Elem().Kind() != reflect.Uint8 {
+				return scanArray(x, ref)
+			}
+		case reflect.Interface:
+			if ref.Type() != readerType && ref.IsNil() {
+				return scanArray(x, ref)
+			}
+		}
+	}
+
 	switch ref.Kind() {
 	case reflect.String:
 		v, err := v.CastAs(types.TypeText)
@@ -235,6 +440,14 @@ func scanValue(v types.Value, ref reflect.Value) error {
 		ref.SetBool(types.AsBool(v))
 		return nil
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if v.Type() == types.TypeUnsignedBigint {
+			x := types.AsUint64(v)
+			if ref.OverflowUint(x) {
+				return fmt.Errorf("cannot convert value %d into Go value of type %s", x, ref.Type().Name())
+			}
+			ref.SetUint(x)
+			return nil
+		}
 		v, err := v.CastAs(types.TypeBigint)
 		if err != nil {
 			return err
@@ -260,11 +473,19 @@ func scanValue(v types.Value, ref reflect.Value) error {
 		ref.SetFloat(types.AsFloat64(v))
 		return nil
 	case reflect.Interface:
+		if ref.Type() == readerType {
+			r, err := openBlob(v)
+			if err != nil {
+				return err
+			}
+			ref.Set(reflect.ValueOf(r))
+			return nil
+		}
 		if !ref.IsNil() {
 			return scanValue(v, ref.Elem())
 		}
 		switch v.Type() {
-		case types.TypeText:
+		case types.TypeText, types.TypeCitext:
 			// copy the string to avoid
 			// keeping a reference to the underlying buffer
 			// which could be reused
@@ -278,29 +499,55 @@ func scanValue(v types.Value, ref reflect.Value) error {
 			b := bytes.Clone(types.AsByteSlice(v))
 			ref.Set(reflect.ValueOf(b))
 			return nil
+		case types.TypeJSON:
+			doc := json.RawMessage(types.FormatJSON(types.AsByteSlice(v)))
+			ref.Set(reflect.ValueOf(doc))
+			return nil
+		case types.TypePoint:
+			ref.Set(reflect.ValueOf(types.FormatPoint(v.V().(types.Point))))
+			return nil
+		case types.TypeInterval:
+			ref.Set(reflect.ValueOf(types.FormatInterval(v.V().(types.Interval))))
+			return nil
 		}
 
 		ref.Set(reflect.ValueOf(v.V()))
 		return nil
 	case reflect.Slice:
 		if ref.Type().Elem().Kind() == reflect.Uint8 {
-			if v.Type() != types.TypeText && v.Type() != types.TypeBlob {
-				return fmt.Errorf("cannot scan value of type %s to byte slice", v.Type())
-			}
-			if v.Type() == types.TypeText {
+			switch v.Type() {
+			case types.TypeText, types.TypeCitext:
 				ref.SetBytes([]byte(types.AsString(v)))
-			} else {
+			case types.TypeBlob:
 				ref.SetBytes(types.AsByteSlice(v))
+			case types.TypeUUID:
+				u := v.V().([16]byte)
+				ref.SetBytes(u[:])
+			case types.TypeJSON:
+				ref.SetBytes([]byte(types.FormatJSON(types.AsByteSlice(v))))
+			case types.TypeObject, types.TypeArray:
+				data, err := v.MarshalJSON()
+				if err != nil {
+					return err
+				}
+				ref.SetBytes(data)
+			default:
+				return fmt.Errorf("cannot scan value of type %s to byte slice", v.Type())
 			}
 			return nil
 		}
 		return NewErrUnsupportedType(ref.Interface(), "Invalid type")
 	case reflect.Array:
 		if ref.Type().Elem().Kind() == reflect.Uint8 {
-			if v.Type() != types.TypeText && v.Type() != types.TypeBlob {
+			switch v.Type() {
+			case types.TypeText, types.TypeCitext, types.TypeBlob:
+				reflect.Copy(ref, reflect.ValueOf(v.V()))
+			case types.TypeUUID:
+				u := v.V().([16]byte)
+				reflect.Copy(ref, reflect.ValueOf(u[:]))
+			default:
 				return fmt.Errorf("cannot scan value of type %s to byte slice", v.Type())
 			}
-			reflect.Copy(ref, reflect.ValueOf(v.V()))
 			return nil
 		}
 		return NewErrUnsupportedType(ref.Interface(), "Invalid type")
@@ -318,10 +565,22 @@ func scanValue(v types.Value, ref reflect.Value) error {
 
 			ref.Set(reflect.ValueOf(parsed))
 			return nil
-		case types.TypeTimestamp:
+		case types.TypeTimestamp, types.TypeTimestampTZ:
 			ref.Set(reflect.ValueOf(types.AsTime(v)))
 			return nil
 		}
+	case "netip.Prefix", "netip.Addr":
+		v, err := v.CastAs(types.TypeInet)
+		if err != nil {
+			return err
+		}
+		p := v.V().(netip.Prefix)
+		if ref.Type() == reflect.TypeOf(p) {
+			ref.Set(reflect.ValueOf(p))
+		} else {
+			ref.Set(reflect.ValueOf(p.Addr()))
+		}
+		return nil
 	}
 
 	return NewErrUnsupportedType(ref.Interface(), "Invalid type")
@@ -1,6 +1,11 @@
 package row_test
 
 import (
+	"database/sql"
+	"net"
+	"net/netip"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
@@ -71,6 +76,25 @@ func TestScan(t *testing.T) {
 		require.Len(t, m, 13)
 	})
 
+	t.Run("Nil map", func(t *testing.T) {
+		var m map[string]any
+		err := row.MapScan(r, m)
+		require.EqualError(t, err, "cannot scan into a nil map")
+	})
+
+	t.Run("Slice", func(t *testing.T) {
+		values, err := row.SliceScan(r)
+		require.NoError(t, err)
+		require.Len(t, values, 13)
+		require.Equal(t, []byte("foo"), values[0])
+		require.Equal(t, "bar", values[1])
+		require.Equal(t, true, values[2])
+		require.Equal(t, int32(10), values[3])
+		require.Equal(t, 10.5, values[8])
+		require.Nil(t, values[9])
+		require.Equal(t, now.UTC(), values[12])
+	})
+
 	t.Run("pointers", func(t *testing.T) {
 		type bar struct {
 			A *int
@@ -114,6 +138,208 @@ func TestScan(t *testing.T) {
 		require.Error(t, err)
 	})
 
+	t.Run("Objects and arrays", func(t *testing.T) {
+		type address struct {
+			City string
+		}
+		type person struct {
+			Name    string
+			Address address
+			Tags    []string
+		}
+
+		obj, err := row.NewValue(map[string]any{
+			"name":    "foo",
+			"address": address{City: "Lyon"},
+			"tags":    []string{"a", "b"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, types.TypeObject, obj.Type())
+
+		d := row.NewColumnBuffer().Add("a", obj)
+
+		var p person
+		err = row.ScanColumn(d, "a", &p)
+		require.NoError(t, err)
+		require.Equal(t, person{Name: "foo", Address: address{City: "Lyon"}, Tags: []string{"a", "b"}}, p)
+
+		var m map[string]any
+		err = row.ScanColumn(d, "a", &m)
+		require.NoError(t, err)
+		require.Equal(t, map[string]any{
+			"name":    "foo",
+			"address": map[string]any{"city": "Lyon"},
+			"tags":    []any{"a", "b"},
+		}, m)
+
+		var s string
+		err = row.ScanColumn(d, "a", &s)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"name": "foo", "address": {"city": "Lyon"}, "tags": ["a", "b"]}`, s)
+	})
+
+	t.Run("Network addresses", func(t *testing.T) {
+		v, err := row.NewValue(netip.MustParseAddr("10.0.0.1"))
+		require.NoError(t, err)
+		require.Equal(t, types.TypeInet, v.Type())
+
+		d := row.NewColumnBuffer().
+			Add("a", v).
+			Add("b", types.NewTextValue("10.1.2.3/16"))
+
+		var p netip.Prefix
+		err = row.ScanColumn(d, "a", &p)
+		require.NoError(t, err)
+		require.Equal(t, netip.MustParsePrefix("10.0.0.1/32"), p)
+
+		var addr netip.Addr
+		err = row.ScanColumn(d, "b", &addr)
+		require.NoError(t, err)
+		require.Equal(t, netip.MustParseAddr("10.1.2.3"), addr)
+
+		var s string
+		err = row.ScanColumn(d, "a", &s)
+		require.NoError(t, err)
+		require.Equal(t, "10.0.0.1", s)
+	})
+
+	t.Run("Embedded structs", func(t *testing.T) {
+		type Base struct {
+			ID int
+		}
+		type Meta struct {
+			Created time.Time
+		}
+		type item struct {
+			Base
+			*Meta
+			Owner  Base   `chai:"owner"`
+			Name   string `chai:"title"`
+			Skip   string `chai:"-"`
+			hidden string
+		}
+
+		owner, err := row.NewValue(map[string]any{"id": 2})
+		require.NoError(t, err)
+
+		d := row.NewColumnBuffer().
+			Add("id", types.NewIntegerValue(1)).
+			Add("created", types.NewTimestampValue(now)).
+			Add("owner", owner).
+			Add("title", types.NewTextValue("foo")).
+			Add("skip", types.NewTextValue("bar")).
+			Add("hidden", types.NewTextValue("baz"))
+
+		var it item
+		err = row.StructScan(d, &it)
+		require.NoError(t, err)
+		require.Equal(t, 1, it.ID)
+		require.NotNil(t, it.Meta)
+		require.True(t, now.Equal(it.Created))
+		require.Equal(t, Base{ID: 2}, it.Owner)
+		require.Equal(t, "foo", it.Name)
+		require.Empty(t, it.Skip)
+		require.Empty(t, it.hidden)
+	})
+
+	t.Run("NULL values", func(t *testing.T) {
+		type bar struct {
+			A int
+			B time.Time
+			C []byte
+			D sql.NullString
+			E *int
+		}
+
+		e := 1
+		b := bar{A: 1, B: now, C: []byte("foo"), D: sql.NullString{String: "foo", Valid: true}, E: &e}
+
+		d := row.NewColumnBuffer().
+			Add("a", types.NewNullValue()).
+			Add("b", types.NewNullValue()).
+			Add("c", types.NewNullValue()).
+			Add("d", types.NewNullValue()).
+			Add("e", types.NewNullValue())
+		err := row.StructScan(d, &b)
+		require.NoError(t, err)
+		require.Equal(t, bar{}, b)
+	})
+
+	t.Run("Custom types", func(t *testing.T) {
+		type bar struct {
+			A sql.NullInt64
+			B sql.NullTime
+			C upperText
+			D []byte
+			E net.IP
+		}
+
+		d := row.NewColumnBuffer().
+			Add("a", types.NewBigintValue(10)).
+			Add("b", types.NewTimestampValue(now)).
+			Add("c", types.NewTextValue("foo")).
+			Add("d", types.NewBlobValue([]byte("bar"))).
+			Add("e", types.NewTextValue("10.0.0.1"))
+
+		var b bar
+		err := row.StructScan(d, &b)
+		require.NoError(t, err)
+		require.Equal(t, sql.NullInt64{Int64: 10, Valid: true}, b.A)
+		require.True(t, b.B.Valid)
+		require.True(t, now.Equal(b.B.Time))
+		require.Equal(t, upperText("FOO"), b.C)
+		require.Equal(t, []byte("bar"), b.D)
+		require.Equal(t, net.ParseIP("10.0.0.1"), b.E)
+	})
+
+	t.Run("Binary unmarshaler", func(t *testing.T) {
+		var b struct {
+			A reversed
+			B reversed
+		}
+
+		d := row.NewColumnBuffer().
+			Add("a", types.NewBlobValue([]byte("abc"))).
+			Add("b", types.NewTextValue("abc"))
+
+		err := row.StructScan(d, &b)
+		require.NoError(t, err)
+		require.Equal(t, reversed("cba"), b.A)
+		// text values are passed to UnmarshalText
+		require.Equal(t, reversed("abc"), b.B)
+	})
+
+	t.Run("Strict", func(t *testing.T) {
+		type Base struct {
+			A int
+		}
+		type bar struct {
+			Base
+			B string `chai:"c"`
+		}
+
+		d := row.NewColumnBuffer().
+			Add("a", types.NewIntegerValue(10)).
+			Add("c", types.NewTextValue("foo"))
+
+		var b bar
+		err := row.StructScanStrict(d, &b)
+		require.NoError(t, err)
+		require.Equal(t, bar{Base: Base{A: 10}, B: "foo"}, b)
+
+		d.Add("d", types.NewIntegerValue(1))
+		err = row.StructScanStrict(d, &b)
+		require.EqualError(t, err, `column "d" has no matching field in row_test.bar`)
+
+		// missing columns are allowed
+		var s struct {
+			A int
+			E int
+		}
+		err = row.StructScanStrict(row.NewColumnBuffer().Add("a", types.NewIntegerValue(1)), &s)
+		require.NoError(t, err)
+	})
+
 	t.Run("Pointer not to struct", func(t *testing.T) {
 		var b int
 		d := row.NewColumnBuffer().Add("a", types.NewIntegerValue(10))
@@ -121,3 +347,25 @@ func TestScan(t *testing.T) {
 		require.Error(t, err)
 	})
 }
+
+// upperText is scanned using the encoding.TextUnmarshaler interface.
+type upperText string
+
+func (u *upperText) UnmarshalText(text []byte) error {
+	*u = upperText(strings.ToUpper(string(text)))
+	return nil
+}
+
+// reversed is scanned using the encoding.BinaryUnmarshaler interface from blobs.
+type reversed string
+
+func (r *reversed) UnmarshalBinary(data []byte) error {
+	slices.Reverse(data)
+	*r = reversed(data)
+	return nil
+}
+
+func (r *reversed) UnmarshalText(text []byte) error {
+	*r = reversed(text)
+	return nil
+}
@@ -0,0 +1,24 @@
+package parser
+
+import (
+	"github.com/chaisql/chai/internal/query/statement"
+	"github.com/chaisql/chai/internal/sql/scanner"
+)
+
+// parseBackupStatement parses a backup statement.
+func (p *Parser) parseBackupStatement() (statement.Statement, error) {
+	var stmt statement.BackupStmt
+
+	// Parse "BACKUP TO".
+	if err := p.ParseTokens(scanner.BACKUP, scanner.TO); err != nil {
+		return nil, err
+	}
+
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	if tok != scanner.STRING {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"string"}, pos)
+	}
+	stmt.Path = lit
+
+	return &stmt, nil
+}
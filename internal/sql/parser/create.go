@@ -6,9 +6,11 @@ import (
 
 	"github.com/chaisql/chai/internal/database"
 	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/expr/functions"
 	"github.com/chaisql/chai/internal/query/statement"
 	"github.com/chaisql/chai/internal/sql/scanner"
 	"github.com/chaisql/chai/internal/tree"
+	"github.com/chaisql/chai/internal/types"
 )
 
 // parseCreateStatement parses a create string and returns a Statement AST row.
@@ -61,9 +63,55 @@ func (p *Parser) parseCreateTableStatement() (*statement.CreateTableStmt, error)
 		return nil, err
 	}
 
+	// parse optional WITH (options)
+	ok, err := p.parseOptional(scanner.WITH)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		err = p.parseTableOptions(&stmt)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return &stmt, err
 }
 
+// parseTableOptions parses the options of a CREATE TABLE statement:
+// (location = 'name').
+func (p *Parser) parseTableOptions(stmt *statement.CreateTableStmt) error {
+	if err := p.ParseTokens(scanner.LPAREN); err != nil {
+		return err
+	}
+
+	var location bool
+	for {
+		tok, pos, lit := p.ScanIgnoreWhitespace()
+		if !isWord(tok, lit, "LOCATION") || location {
+			return newParseError(scanner.Tokstr(tok, lit), []string{"LOCATION"}, pos)
+		}
+		location = true
+
+		if err := p.ParseTokens(scanner.EQ); err != nil {
+			return err
+		}
+
+		tok, pos, lit = p.ScanIgnoreWhitespace()
+		if tok != scanner.STRING || lit == "" {
+			return newParseError(scanner.Tokstr(tok, lit), []string{"location name"}, pos)
+		}
+		stmt.Info.Location = lit
+
+		if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.COMMA {
+			p.Unscan()
+			break
+		}
+	}
+
+	return p.ParseTokens(scanner.RPAREN)
+}
+
 func (p *Parser) parseConstraints(stmt *statement.CreateTableStmt) error {
 	// Parse ( token.
 	tok, pos, lit := p.ScanIgnoreWhitespace()
@@ -136,6 +184,29 @@ func (p *Parser) parseConstraints(stmt *statement.CreateTableStmt) error {
 	return nil
 }
 
+// parseColumnType parses the type of a column. The length of
+// VARCHAR(n), CHAR(n) and CHARACTER(n) columns is enforced on write.
+func (p *Parser) parseColumnType(cc *database.ColumnConstraint) error {
+	tok, _, _ := p.ScanIgnoreWhitespace()
+	switch tok {
+	case scanner.TYPEVARCHAR, scanner.TYPECHAR, scanner.TYPECHARACTER:
+		n, err := p.parseCharacterLength(tok)
+		if err != nil {
+			return err
+		}
+
+		cc.Type = types.TypeText
+		cc.Length = n
+		cc.Padded = tok != scanner.TYPEVARCHAR
+		return nil
+	}
+
+	p.Unscan()
+	var err error
+	cc.Type, err = p.parseType()
+	return err
+}
+
 func (p *Parser) parseColumnDefinition() (*database.ColumnConstraint, []*database.TableConstraint, error) {
 	var err error
 
@@ -146,7 +217,7 @@ func (p *Parser) parseColumnDefinition() (*database.ColumnConstraint, []*databas
 		return nil, nil, err
 	}
 
-	cc.Type, err = p.parseType()
+	err = p.parseColumnType(&cc)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -179,7 +250,7 @@ LOOP:
 					return nil, nil, err
 				}
 				if ok {
-					tc.SortOrder = tree.SortOrder(0).SetDesc(0)
+					tc.SortOrder = tree.SortOrder(nil).SetDesc(0)
 				}
 			}
 
@@ -228,16 +299,27 @@ LOOP:
 				scanner.INTEGER,
 				scanner.NUMBER,
 				scanner.STRING,
+				scanner.HEXSTRING,
 				scanner.TRUE,
 				scanner.FALSE,
 				scanner.NULL,
 				scanner.LPAREN,   // only opening parenthesis are necessary
 				scanner.LBRACKET, // only opening brackets are necessary
 				scanner.NEXT,
+				scanner.IDENT, // only function calls are allowed, such as gen_uuid_v4()
 			)
 			if err != nil {
 				return nil, nil, err
 			}
+			if e == nil {
+				tok, pos, lit := p.ScanIgnoreWhitespace()
+				return nil, nil, newParseError(scanner.Tokstr(tok, lit), []string{"default value"}, pos)
+			}
+
+			err = checkDefaultValue(e)
+			if err != nil {
+				return nil, nil, err
+			}
 
 			cc.DefaultValue = expr.Constraint(e)
 
@@ -262,12 +344,38 @@ LOOP:
 				Check:   expr.Constraint(e),
 				Columns: cols,
 			})
+		case scanner.IDENT:
+			var opt *bool
+			switch {
+			case isWord(tok, lit, "DICTIONARY"):
+				opt = &cc.Dictionary
+			case isWord(tok, lit, "FINITE"):
+				opt = &cc.Finite
+			default:
+				p.Unscan()
+				break LOOP
+			}
+
+			// if the option is already set we return an error
+			if *opt {
+				return nil, nil, newParseError(scanner.Tokstr(tok, lit), []string{"CONSTRAINT", ")"}, pos)
+			}
+
+			*opt = true
 		default:
 			p.Unscan()
 			break LOOP
 		}
 	}
 
+	if cc.Dictionary && cc.Type != types.TypeText {
+		return nil, nil, &ParseError{Message: "DICTIONARY can only be used with TEXT columns"}
+	}
+
+	if cc.Finite && cc.Type != types.TypeDouble && cc.Type != types.TypeReal {
+		return nil, nil, &ParseError{Message: "FINITE can only be used with DOUBLE and REAL columns"}
+	}
+
 	return &cc, tcs, nil
 }
 
@@ -376,21 +484,98 @@ func (p *Parser) parseCreateIndexStatement(unique bool) (*statement.CreateIndexS
 		return nil, err
 	}
 
-	columns, order, err := p.parseColumnList()
-	if err != nil {
+	if err := p.ParseTokens(scanner.LPAREN); err != nil {
 		return nil, err
 	}
-	if len(columns) == 0 {
-		tok, pos, lit := p.ScanIgnoreWhitespace()
-		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"("}, pos)
+
+	var exprs []database.TableExpression
+	var hasExprs bool
+	for i := 0; ; i++ {
+		column, e, err := p.parseIndexedColumn()
+		if err != nil {
+			return nil, err
+		}
+
+		stmt.Info.Columns = append(stmt.Info.Columns, column)
+		exprs = append(exprs, e)
+		hasExprs = hasExprs || e != nil
+
+		stmt.Info.KeySortOrder, err = p.parseSortOrder(stmt.Info.KeySortOrder, i)
+		if err != nil {
+			return nil, err
+		}
+
+		if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.COMMA {
+			p.Unscan()
+			break
+		}
 	}
 
-	stmt.Info.Columns = columns
-	stmt.Info.KeySortOrder = order
+	if err := p.ParseTokens(scanner.RPAREN); err != nil {
+		return nil, err
+	}
+
+	if hasExprs {
+		stmt.Info.Exprs = exprs
+	}
 
 	return &stmt, nil
 }
 
+// parseIndexedColumn parses a column of an index, or an expression extracting
+// a path of a JSON column: JSON_EXTRACT(column, 'path'), or a hash of a column,
+// such as md5(column).
+// Expressions are returned with their SQL representation, used as the column name.
+func (p *Parser) parseIndexedColumn() (string, database.TableExpression, error) {
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	p.Unscan()
+
+	e, err := p.ParseExpr()
+	if err != nil {
+		return "", nil, err
+	}
+
+	switch e := e.(type) {
+	case *expr.Column:
+		return e.Name, nil, nil
+	case *functions.JSONExtract:
+		_, isCol := e.Expr.(*expr.Column)
+		l, isLit := e.Path.(expr.LiteralValue)
+		if isCol && isLit && l.Value.Type() == types.TypeText {
+			return e.String(), expr.Constraint(e), nil
+		}
+	case *functions.ScalarFunction:
+		if _, ok := functions.HashType(e); ok {
+			if _, isCol := e.Params()[0].(*expr.Column); isCol {
+				return e.String(), expr.Constraint(e), nil
+			}
+		}
+	}
+
+	return "", nil, newParseError(scanner.Tokstr(tok, lit), []string{"column", "JSON_EXTRACT(column, path)", "hash(column)"}, pos)
+}
+
+// checkDefaultValue ensures the default value of a column doesn't refer to other columns,
+// and only calls scalar functions, such as gen_uuid_v7().
+func checkDefaultValue(e expr.Expr) (err error) {
+	expr.Walk(e, func(e expr.Expr) bool {
+		switch t := e.(type) {
+		case *expr.Column:
+			err = &ParseError{Message: fmt.Sprintf("default value cannot refer to column %q", t.Name)}
+		case *functions.ScalarFunction:
+			return true
+		case expr.Function:
+			err = &ParseError{Message: fmt.Sprintf("function %s is not allowed in a default value", t)}
+		default:
+			return true
+		}
+
+		return false
+	})
+
+	return err
+}
+
 // This function assumes the CREATE SEQUENCE tokens have already been consumed.
 func (p *Parser) parseCreateSequenceStatement() (*statement.CreateSequenceStmt, error) {
 	var stmt statement.CreateSequenceStmt
@@ -5,12 +5,19 @@ import (
 	"testing"
 
 	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/expr/functions"
 	"github.com/chaisql/chai/internal/query/statement"
 	"github.com/chaisql/chai/internal/sql/parser"
+	"github.com/chaisql/chai/internal/testutil"
+	"github.com/chaisql/chai/internal/tree"
 	"github.com/stretchr/testify/require"
 )
 
 func TestParserCreateIndex(t *testing.T) {
+	jsonExtract, err := functions.NewJSONExtract(&expr.Column{Name: "foo"}, testutil.TextValue("$.a"))
+	require.NoError(t, err)
+
 	tests := []struct {
 		name     string
 		s        string
@@ -45,6 +52,39 @@ func TestParserCreateIndex(t *testing.T) {
 			},
 			false},
 		{"No fields", "CREATE INDEX idx ON test", nil, true},
+		{"NULLs order", "CREATE INDEX idx ON test (foo NULLS LAST, bar DESC NULLS FIRST, baz ASC NULLS FIRST)",
+			&statement.CreateIndexStmt{
+				Info: database.IndexInfo{
+					IndexName:    "idx",
+					Owner:        database.Owner{TableName: "test"},
+					Columns:      []string{"foo", "bar", "baz"},
+					KeySortOrder: tree.SortOrder(nil).SetNullsLast(0).SetDesc(1).SetNullsFirst(1),
+				},
+			},
+			false},
+		{"Invalid NULLs order", "CREATE INDEX idx ON test (foo NULLS)", nil, true},
+		{"JSON path", "CREATE INDEX idx ON test (JSON_EXTRACT(foo, '$.a') DESC, bar)",
+			&statement.CreateIndexStmt{
+				Info: database.IndexInfo{
+					IndexName: "idx",
+					Owner:     database.Owner{TableName: "test"},
+					Columns: []string{
+						`JSON_EXTRACT(foo, "$.a")`,
+						"bar",
+					},
+					Exprs: []database.TableExpression{
+						expr.Constraint(jsonExtract),
+						nil,
+					},
+					KeySortOrder: tree.SortOrder(nil).SetDesc(0),
+				},
+			},
+			false},
+		{"Expression", "CREATE INDEX idx ON test (foo + 1)", nil, true},
+		{"JSON path of an expression", "CREATE INDEX idx ON test (JSON_EXTRACT(LOWER(foo), '$.a'))", nil, true},
+		{"JSON path parameter", "CREATE INDEX idx ON test (JSON_EXTRACT(foo, ?))", nil, true},
+		{"Hash of an expression", "CREATE INDEX idx ON test (md5(LOWER(foo)))", nil, true},
+		{"Not a hash", "CREATE INDEX idx ON test (lpad(foo, 3))", nil, true},
 	}
 
 	for _, test := range tests {
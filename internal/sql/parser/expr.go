@@ -163,6 +163,14 @@ func (p *Parser) parseOperator(minPrecedence int, allowed ...scanner.Token) (fun
 		return expr.Like, op, nil
 	case scanner.CONCAT:
 		return expr.Concat, op, nil
+	case scanner.CONTAINEDBY:
+		return expr.ContainedBy, op, nil
+	case scanner.CONTAINEDBYEQ:
+		return expr.ContainedByOrEq, op, nil
+	case scanner.CONTAINS:
+		return expr.Contains, op, nil
+	case scanner.CONTAINSEQ:
+		return expr.ContainsOrEq, op, nil
 	case scanner.BETWEEN:
 		a, err := p.parseExprWithMinPrecedence(op.Precedence())
 		if err != nil {
@@ -193,7 +201,11 @@ func (p *Parser) parseUnaryExpr(allowed ...scanner.Token) (expr.Expr, error) {
 	switch tok {
 	case scanner.CAST:
 		p.Unscan()
-		return p.parseCastExpression()
+		e, err := p.parseCastExpression()
+		if err != nil {
+			return nil, err
+		}
+		return p.parsePath(e)
 	case scanner.IDENT:
 		tok1, _, _ := p.ScanIgnoreWhitespace()
 		// if the next token is a left parenthesis, this is a function
@@ -203,16 +215,43 @@ func (p *Parser) parseUnaryExpr(allowed ...scanner.Token) (expr.Expr, error) {
 				p.Unscan()
 			}
 			p.Unscan()
-			return p.parseFunction()
+			f, err := p.parseFunction()
+			if err != nil {
+				return nil, err
+			}
+			return p.parsePath(f)
 		}
 		p.Unscan()
+
+		// CURRENT_DATE is a function called without parentheses
+		if isWord(tok, lit, "CURRENT_DATE") {
+			return &functions.CurrentDate{}, nil
+		}
+
 		if tk, _, _ := p.s.Curr(); tk == scanner.WS {
 			p.Unscan()
 		}
 
 		p.Unscan()
 
-		return p.parseColumn()
+		col, err := p.parseColumn()
+		if err != nil {
+			return nil, err
+		}
+		return p.parsePath(col)
+	case scanner.LBRACKET:
+		p.Unscan()
+		o, err := p.parseObjectLiteral()
+		if err != nil {
+			return nil, err
+		}
+		return p.parsePath(o)
+	case scanner.LSBRACKET:
+		exprList, err := p.parseExprListUntil(scanner.RSBRACKET)
+		if err != nil {
+			return nil, err
+		}
+		return p.parsePath(expr.ArrayLiteral(exprList))
 	case scanner.NAMEDPARAM:
 		if len(lit) == 1 {
 			return nil, errors.WithStack(&ParseError{Message: "missing param name"})
@@ -228,19 +267,18 @@ func (p *Parser) parseUnaryExpr(allowed ...scanner.Token) (expr.Expr, error) {
 		}
 		p.orderedParams++
 		return expr.PositionalParam(p.orderedParams), nil
+	case scanner.TYPEINTERVAL:
+		return p.parseIntervalLiteral()
+	case scanner.TYPETIMESTAMP, scanner.TYPETIMESTAMPTZ:
+		p.Unscan()
+		return p.parseTimestampLiteral()
 	case scanner.STRING:
 		if strings.HasPrefix(lit, `\x`) {
-			blob, err := hex.DecodeString(lit[2:])
-			if err != nil {
-				if bt, ok := err.(hex.InvalidByteError); ok {
-					return nil, fmt.Errorf("invalid hexadecimal digit: %c", bt)
-				}
-
-				return nil, err
-			}
-			return expr.LiteralValue{Value: types.NewBlobValue(blob)}, nil
+			return parseHexBlob(lit[2:])
 		}
 		return expr.LiteralValue{Value: types.NewTextValue(lit)}, nil
+	case scanner.HEXSTRING:
+		return parseHexBlob(lit)
 	case scanner.NUMBER:
 		v, err := strconv.ParseFloat(lit, 64)
 		if err != nil {
@@ -260,7 +298,11 @@ func (p *Parser) parseUnaryExpr(allowed ...scanner.Token) (expr.Expr, error) {
 	case scanner.INTEGER:
 		v, err := strconv.ParseInt(lit, 10, 64)
 		if err != nil {
-			// The literal may be too large to fit into an int64, parse as Float64
+			// The literal may be too large to fit into an int64,
+			// parse as an unsigned integer or as Float64
+			if v, err := strconv.ParseUint(lit, 10, 64); err == nil {
+				return expr.LiteralValue{Value: types.NewUnsignedBigintValue(v)}, nil
+			}
 			if v, err := strconv.ParseFloat(lit, 64); err == nil {
 				return expr.LiteralValue{Value: types.NewDoubleValue(v)}, nil
 			}
@@ -285,7 +327,7 @@ func (p *Parser) parseUnaryExpr(allowed ...scanner.Token) (expr.Expr, error) {
 		tok, pos, lit := p.ScanIgnoreWhitespace()
 		switch tok {
 		case scanner.RPAREN:
-			return expr.Parentheses{E: e}, nil
+			return p.parsePath(expr.Parentheses{E: e})
 		case scanner.COMMA:
 			exprList, err := p.parseExprListUntil(scanner.RPAREN)
 			if err != nil {
@@ -320,6 +362,20 @@ func (p *Parser) parseUnaryExpr(allowed ...scanner.Token) (expr.Expr, error) {
 	}
 }
 
+// parseHexBlob parses the hexadecimal digits of a blob literal.
+func parseHexBlob(lit string) (expr.Expr, error) {
+	blob, err := hex.DecodeString(lit)
+	if err != nil {
+		if bt, ok := err.(hex.InvalidByteError); ok {
+			return nil, fmt.Errorf("invalid hexadecimal digit: %c", bt)
+		}
+
+		return nil, err
+	}
+
+	return expr.LiteralValue{Value: types.NewBlobValue(blob)}, nil
+}
+
 // parseInteger parses an integer.
 func (p *Parser) parseInteger() (int64, error) {
 	tok, pos, lit := p.ScanIgnoreWhitespace()
@@ -378,6 +434,64 @@ func (p *Parser) parseIdentList() ([]string, error) {
 	}
 }
 
+// parseIntervalLiteral parses the text of an interval following INTERVAL,
+// such as INTERVAL '1 day'.
+func (p *Parser) parseIntervalLiteral() (expr.Expr, error) {
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	if tok != scanner.STRING {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"string"}, pos)
+	}
+
+	iv, err := types.ParseInterval(lit)
+	if err != nil {
+		return nil, errors.WithStack(&ParseError{Message: err.Error(), Pos: pos})
+	}
+
+	return expr.LiteralValue{Value: types.NewIntervalValue(iv)}, nil
+}
+
+// parseTimestampLiteral parses a timestamp written as a type followed by a text,
+// such as TIMESTAMP '2024-01-02 03:04:05'. The text is converted when the
+// expression is evaluated, in the time zone of the transaction for TIMESTAMPTZ.
+func (p *Parser) parseTimestampLiteral() (expr.Expr, error) {
+	tp, err := p.parseType()
+	if err != nil {
+		return nil, err
+	}
+
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	if tok != scanner.STRING {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"string"}, pos)
+	}
+
+	return &expr.Cast{Expr: expr.LiteralValue{Value: types.NewTextValue(lit)}, CastAs: tp}, nil
+}
+
+// parseTimestampType parses the optional time zone clause following TIMESTAMP:
+// "WITH TIME ZONE" or "WITHOUT TIME ZONE".
+func (p *Parser) parseTimestampType() (types.Type, error) {
+	tp := types.TypeTimestamp
+
+	tok, _, lit := p.ScanIgnoreWhitespace()
+	switch {
+	case tok == scanner.WITH:
+		tp = types.TypeTimestampTZ
+	case isWord(tok, lit, "WITHOUT"):
+	default:
+		p.Unscan()
+		return tp, nil
+	}
+
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); !isWord(tok, lit, "TIME") {
+		return 0, newParseError(scanner.Tokstr(tok, lit), []string{"TIME"}, pos)
+	}
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); !isWord(tok, lit, "ZONE") {
+		return 0, newParseError(scanner.Tokstr(tok, lit), []string{"ZONE"}, pos)
+	}
+
+	return tp, nil
+}
+
 func (p *Parser) parseType() (types.Type, error) {
 	tok, pos, lit := p.ScanIgnoreWhitespace()
 	switch tok {
@@ -386,7 +500,7 @@ func (p *Parser) parseType() (types.Type, error) {
 	case scanner.TYPEBOOL, scanner.TYPEBOOLEAN:
 		return types.TypeBoolean, nil
 	case scanner.TYPEREAL:
-		return types.TypeDouble, nil
+		return types.TypeReal, nil
 	case scanner.TYPEDOUBLE:
 		tok, _, _ := p.ScanIgnoreWhitespace()
 		if tok == scanner.PRECISION {
@@ -398,29 +512,80 @@ func (p *Parser) parseType() (types.Type, error) {
 		scanner.TYPEMEDIUMINT, scanner.TYPESMALLINT:
 		return types.TypeInteger, nil
 	case scanner.TYPEINT8, scanner.TYPEBIGINT:
+		tok, _, _ := p.ScanIgnoreWhitespace()
+		if tok == scanner.UNSIGNED {
+			return types.TypeUnsignedBigint, nil
+		}
+		p.Unscan()
 		return types.TypeBigint, nil
+	case scanner.TYPEUINT64:
+		return types.TypeUnsignedBigint, nil
 	case scanner.TYPETEXT:
 		return types.TypeText, nil
+	case scanner.TYPECITEXT:
+		return types.TypeCitext, nil
 	case scanner.TYPETIMESTAMP:
-		return types.TypeTimestamp, nil
-	case scanner.TYPEVARCHAR, scanner.TYPECHARACTER:
-		if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.LPAREN {
-			return 0, newParseError(scanner.Tokstr(tok, lit), []string{"("}, pos)
+		return p.parseTimestampType()
+	case scanner.TYPETIMESTAMPTZ:
+		return types.TypeTimestampTZ, nil
+	case scanner.TYPEUUID:
+		return types.TypeUUID, nil
+	case scanner.TYPEJSON, scanner.TYPEJSONB:
+		return types.TypeJSON, nil
+	case scanner.TYPEOBJECT, scanner.TYPEDOCUMENT:
+		return types.TypeObject, nil
+	case scanner.TYPEARRAY:
+		return types.TypeArray, nil
+	case scanner.TYPEINET:
+		return types.TypeInet, nil
+	case scanner.TYPECIDR:
+		return types.TypeCidr, nil
+	case scanner.TYPEPOINT:
+		return types.TypePoint, nil
+	case scanner.TYPEINTERVAL:
+		return types.TypeInterval, nil
+	case scanner.TYPEVARCHAR, scanner.TYPECHAR, scanner.TYPECHARACTER:
+		// the length is only enforced for columns, see parseColumnType.
+		if _, err := p.parseCharacterLength(tok); err != nil {
+			return 0, err
 		}
 
-		// The value between parentheses is not used.
-		if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.INTEGER {
-			return 0, newParseError(scanner.Tokstr(tok, lit), []string{"integer"}, pos)
-		}
+		return types.TypeText, nil
+	}
+
+	return 0, newParseError(scanner.Tokstr(tok, lit), []string{"type"}, pos)
+}
 
-		if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.RPAREN {
-			return 0, newParseError(scanner.Tokstr(tok, lit), []string{")"}, pos)
+// parseCharacterLength parses the optional length between parentheses
+// following VARCHAR, CHAR or CHARACTER. Without length, the texts of VARCHAR
+// are not limited and the ones of CHAR and CHARACTER have a single character.
+func (p *Parser) parseCharacterLength(tok scanner.Token) (int, error) {
+	ok, err := p.parseOptional(scanner.LPAREN)
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		if tok == scanner.TYPEVARCHAR {
+			return 0, nil
 		}
+		return 1, nil
+	}
 
-		return types.TypeText, nil
+	_, pos, _ := p.ScanIgnoreWhitespace()
+	p.Unscan()
+	n, err := p.parseInteger()
+	if err != nil {
+		return 0, err
+	}
+	if n < 1 || n > math.MaxInt32 {
+		return 0, errors.WithStack(&ParseError{Message: "length must be at least 1 and at most 2147483647", Pos: pos})
 	}
 
-	return 0, newParseError(scanner.Tokstr(tok, lit), []string{"type"}, pos)
+	if err := p.ParseTokens(scanner.RPAREN); err != nil {
+		return 0, err
+	}
+
+	return int(n), nil
 }
 
 // parsePath parses a path to a specific value.
@@ -434,6 +599,87 @@ func (p *Parser) parseColumn() (*expr.Column, error) {
 	return &expr.Column{Name: col}, nil
 }
 
+// parsePath parses the optional path following an expression,
+// made of fields (.name) and array indexes ([0]).
+func (p *Parser) parsePath(e expr.Expr) (expr.Expr, error) {
+	var path types.JSONPath
+
+	for {
+		tok, pos, _ := p.Scan()
+		if tok == scanner.DOT {
+			field, err := p.parseIdent()
+			if err != nil {
+				return nil, err
+			}
+			path = append(path, types.JSONPathFragment{Field: field})
+			continue
+		}
+		if tok != scanner.LSBRACKET {
+			p.Unscan()
+			break
+		}
+
+		idx, err := p.parseInteger()
+		if err != nil {
+			return nil, err
+		}
+		if idx < 0 || idx > math.MaxInt32 {
+			return nil, errors.WithStack(&ParseError{Message: "invalid array index", Pos: pos})
+		}
+		if err := p.ParseTokens(scanner.RSBRACKET); err != nil {
+			return nil, err
+		}
+		path = append(path, types.JSONPathFragment{Index: int(idx), IsIndex: true})
+	}
+
+	if len(path) == 0 {
+		return e, nil
+	}
+
+	return &expr.Path{Expr: e, Fragments: path}, nil
+}
+
+// parseObjectLiteral parses an object literal, made of
+// a comma-separated list of fields between curly brackets.
+// Each field is a name, either an identifier or a string,
+// followed by a colon and an expression.
+func (p *Parser) parseObjectLiteral() (expr.Expr, error) {
+	if err := p.ParseTokens(scanner.LBRACKET); err != nil {
+		return nil, err
+	}
+
+	var o expr.ObjectLiteral
+	for {
+		tok, pos, lit := p.ScanIgnoreWhitespace()
+		if tok == scanner.RBRACKET && len(o.Fields) == 0 {
+			return &o, nil
+		}
+		if tok != scanner.IDENT && tok != scanner.STRING {
+			return nil, newParseError(scanner.Tokstr(tok, lit), []string{"identifier", "string"}, pos)
+		}
+
+		if err := p.ParseTokens(scanner.COLON); err != nil {
+			return nil, err
+		}
+
+		e, err := p.ParseExpr()
+		if err != nil {
+			return nil, err
+		}
+		o.Fields = append(o.Fields, expr.ObjectLiteralField{Name: lit, Expr: e})
+
+		tok, pos, lit = p.ScanIgnoreWhitespace()
+		switch tok {
+		case scanner.COMMA:
+			continue
+		case scanner.RBRACKET:
+			return &o, nil
+		}
+
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{",", "}"}, pos)
+	}
+}
+
 func (p *Parser) parseExprListUntil(rightToken scanner.Token) (expr.LiteralExprList, error) {
 	var exprList expr.LiteralExprList
 	var expr expr.Expr
@@ -486,6 +732,19 @@ func (p *Parser) parseFunction() (expr.Expr, error) {
 		return nil, err
 	}
 
+	// EXTRACT(field FROM timestamp) is the same as EXTRACT(field, timestamp)
+	if strings.EqualFold(funcName, "extract") {
+		e, ok, err := p.parseExtractFrom()
+		if ok || err != nil {
+			return e, err
+		}
+	}
+
+	// POSITION(substring IN text) is the same as POSITION(substring, text)
+	if strings.EqualFold(funcName, "position") {
+		return p.parsePositionIn()
+	}
+
 	// Check if the function is called without arguments.
 	if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.RPAREN {
 		def, err := functions.GetFunc(funcName)
@@ -513,6 +772,12 @@ func (p *Parser) parseFunction() (expr.Expr, error) {
 		}
 	}
 
+	// Parse optional ORDER BY clause, which sorts the aggregated values.
+	orderBy, desc, err := p.parseCallOrderBy()
+	if err != nil {
+		return nil, err
+	}
+
 	// Parse required ) token.
 	if err := p.ParseTokens(scanner.RPAREN); err != nil {
 		return nil, err
@@ -522,7 +787,108 @@ func (p *Parser) parseFunction() (expr.Expr, error) {
 	if err != nil {
 		return nil, err
 	}
-	return def.Function(exprs...)
+	fn, err := def.Function(exprs...)
+	if err != nil || orderBy == nil {
+		return fn, err
+	}
+
+	switch f := fn.(type) {
+	case *functions.StringAgg:
+		f.OrderBy, f.Desc = orderBy, desc
+	case *functions.ArrayAgg:
+		f.OrderBy, f.Desc = orderBy, desc
+	case *functions.FirstValue:
+		f.OrderBy, f.Desc = orderBy, desc
+	default:
+		return nil, errors.Errorf("%s() doesn't accept ORDER BY", funcName)
+	}
+	return fn, nil
+}
+
+// parseCallOrderBy parses the optional ORDER BY clause of
+// an aggregate function call, before the right parenthesis.
+func (p *Parser) parseCallOrderBy() (expr.Expr, bool, error) {
+	ok, err := p.parseOptional(scanner.ORDER, scanner.BY)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+
+	e, err := p.ParseExpr()
+	if err != nil {
+		return nil, false, err
+	}
+
+	tok, _, _ := p.ScanIgnoreWhitespace()
+	if tok != scanner.ASC && tok != scanner.DESC {
+		p.Unscan()
+	}
+
+	return e, tok == scanner.DESC, nil
+}
+
+// parseExtractFrom parses the end of an EXTRACT(field FROM timestamp) call,
+// after the left parenthesis. It returns false if the call doesn't use FROM.
+func (p *Parser) parseExtractFrom() (expr.Expr, bool, error) {
+	tok, _, lit := p.ScanIgnoreWhitespace()
+	if tok != scanner.IDENT {
+		p.Unscan()
+		return nil, false, nil
+	}
+	if tok1, _, _ := p.ScanIgnoreWhitespace(); tok1 != scanner.FROM {
+		p.Unscan()
+		if tk, _, _ := p.s.Curr(); tk == scanner.WS {
+			p.Unscan()
+		}
+		p.Unscan()
+		return nil, false, nil
+	}
+
+	e, err := p.ParseExpr()
+	if err != nil {
+		return nil, true, err
+	}
+	if err := p.ParseTokens(scanner.RPAREN); err != nil {
+		return nil, true, err
+	}
+
+	def, err := functions.GetFunc("extract")
+	if err != nil {
+		return nil, true, err
+	}
+	f, err := def.Function(expr.LiteralValue{Value: types.NewTextValue(lit)}, e)
+	return f, true, err
+}
+
+// parsePositionIn parses the end of a POSITION(substring IN text) call,
+// after the left parenthesis. The substring can't contain operators whose
+// precedence is lower than the one of IN, unless they are in parentheses.
+func (p *Parser) parsePositionIn() (expr.Expr, error) {
+	sub, err := p.parseExprWithMinPrecedence(scanner.IN.Precedence() + 1)
+	if err != nil {
+		return nil, err
+	}
+
+	var e expr.Expr
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	switch tok {
+	case scanner.IN, scanner.COMMA:
+		e, err = p.ParseExpr()
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"IN", ","}, pos)
+	}
+
+	if err := p.ParseTokens(scanner.RPAREN); err != nil {
+		return nil, err
+	}
+
+	def, err := functions.GetFunc("position")
+	if err != nil {
+		return nil, err
+	}
+	return def.Function(sub, e)
 }
 
 // parseCastExpression parses a string of the form CAST(expr AS type).
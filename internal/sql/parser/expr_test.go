@@ -38,6 +38,9 @@ func TestParserExpr(t *testing.T) {
 		// blobs
 		{"blob as hex string", `'\xff'`, testutil.BlobValue([]byte{255}), false},
 		{"invalid blob hex string", `'\xzz'`, nil, true},
+		{"blob literal", `x'DEADBEEF'`, testutil.BlobValue([]byte{0xde, 0xad, 0xbe, 0xef}), false},
+		{"empty blob literal", `X''`, testutil.BlobValue([]byte{}), false},
+		{"invalid blob literal", `x'DEADBEE'`, nil, true},
 
 		// parentheses
 		{"parentheses: empty", "()", nil, true},
@@ -61,6 +64,27 @@ func TestParserExpr(t *testing.T) {
 				),
 			}, false},
 
+		// objects and arrays
+		{"object", `{a: 1, "b c": [1, x]}`,
+			&expr.ObjectLiteral{Fields: []expr.ObjectLiteralField{
+				{Name: "a", Expr: testutil.IntegerValue(1)},
+				{Name: "b c", Expr: expr.ArrayLiteral{testutil.IntegerValue(1), &expr.Column{Name: "x"}}},
+			}}, false},
+		{"empty object", `{}`, &expr.ObjectLiteral{}, false},
+		{"object: missing colon", `{a 1}`, nil, true},
+		{"object: trailing comma", `{a: 1,}`, nil, true},
+		{"empty array", `[]`, expr.ArrayLiteral(nil), false},
+		{"path", "a.b[1].`c d`", &expr.Path{
+			Expr: &expr.Column{Name: "a"},
+			Fragments: types.JSONPath{
+				{Field: "b"},
+				{Index: 1, IsIndex: true},
+				{Field: "c d"},
+			},
+		}, false},
+		{"path: negative index", "a[-1]", nil, true},
+		{"path: missing field", "a.", nil, true},
+
 		// operators
 		{"=", "age = 10", expr.Eq(&expr.Column{Name: "age"}, testutil.IntegerValue(10)), false},
 		{"!=", "age != 10", expr.Neq(&expr.Column{Name: "age"}, testutil.IntegerValue(10)), false},
@@ -76,6 +100,10 @@ func TestParserExpr(t *testing.T) {
 		{"%", "age % 10", expr.Mod(&expr.Column{Name: "age"}, testutil.IntegerValue(10)), false},
 		{"&", "age & 10", expr.BitwiseAnd(&expr.Column{Name: "age"}, testutil.IntegerValue(10)), false},
 		{"||", "name || 'foo'", expr.Concat(&expr.Column{Name: "name"}, testutil.TextValue("foo")), false},
+		{"<<", "ip << '10.0.0.0/8'", expr.ContainedBy(&expr.Column{Name: "ip"}, testutil.TextValue("10.0.0.0/8")), false},
+		{"<<=", "ip <<= '10.0.0.0/8'", expr.ContainedByOrEq(&expr.Column{Name: "ip"}, testutil.TextValue("10.0.0.0/8")), false},
+		{">>", "'10.0.0.0/8' >> ip", expr.Contains(testutil.TextValue("10.0.0.0/8"), &expr.Column{Name: "ip"}), false},
+		{">>=", "'10.0.0.0/8' >>= ip", expr.ContainsOrEq(testutil.TextValue("10.0.0.0/8"), &expr.Column{Name: "ip"}), false},
 		{"IN", "age IN ages", expr.In(&expr.Column{Name: "age"}, &expr.Column{Name: "ages"}), false},
 		{"NOT IN", "age NOT IN ages", expr.NotIn(&expr.Column{Name: "age"}, &expr.Column{Name: "ages"}), false},
 		{"IS", "age IS NULL", expr.Is(&expr.Column{Name: "age"}, testutil.NullValue()), false},
@@ -124,6 +152,20 @@ func TestParserExpr(t *testing.T) {
 		{"count(*) function", "count(*)", functions.NewCount(expr.Wildcard{}), false},
 		{"count (*) function with spaces", "count      (*)", functions.NewCount(expr.Wildcard{}), false},
 		{"packaged function", "floor(1.2)", testutil.FunctionExpr(t, "floor", testutil.DoubleValue(1.2)), false},
+		{"CURRENT_DATE", "CURRENT_DATE", &functions.CurrentDate{}, false},
+		{"EXTRACT FROM", "EXTRACT(year FROM a)", testutil.FunctionExpr(t, "extract", testutil.TextValue("year"), &expr.Column{Name: "a"}), false},
+		{"EXTRACT", "extract('year', a)", testutil.FunctionExpr(t, "extract", testutil.TextValue("year"), &expr.Column{Name: "a"}), false},
+		{"EXTRACT FROM without source", "EXTRACT(year FROM)", nil, true},
+		{"POSITION IN", "POSITION('b' IN a)", testutil.FunctionExpr(t, "position", testutil.TextValue("b"), &expr.Column{Name: "a"}), false},
+		{"POSITION IN with concatenation", "POSITION('b' || c IN a)", testutil.FunctionExpr(t, "position", expr.Concat(testutil.TextValue("b"), &expr.Column{Name: "c"}), &expr.Column{Name: "a"}), false},
+		{"POSITION", "position('b', a)", testutil.FunctionExpr(t, "position", testutil.TextValue("b"), &expr.Column{Name: "a"}), false},
+		{"POSITION without IN", "POSITION('b' a)", nil, true},
+		{"STRING_AGG", "string_agg(a, ',')", &functions.StringAgg{Expr: &expr.Column{Name: "a"}, Delimiter: testutil.TextValue(",")}, false},
+		{"STRING_AGG ORDER BY", "STRING_AGG(a, ',' ORDER BY b DESC)", &functions.StringAgg{Expr: &expr.Column{Name: "a"}, Delimiter: testutil.TextValue(","), OrderBy: &expr.Column{Name: "b"}, Desc: true}, false},
+		{"GROUP_CONCAT ORDER BY", "group_concat(a ORDER BY b)", &functions.StringAgg{Expr: &expr.Column{Name: "a"}, OrderBy: &expr.Column{Name: "b"}, GroupConcat: true}, false},
+		{"ARRAY_AGG ORDER BY", "array_agg(a ORDER BY b ASC)", &functions.ArrayAgg{Expr: &expr.Column{Name: "a"}, OrderBy: &expr.Column{Name: "b"}}, false},
+		{"LAST_VALUE ORDER BY", "last_value(a ORDER BY b)", &functions.FirstValue{Expr: &expr.Column{Name: "a"}, OrderBy: &expr.Column{Name: "b"}, Last: true}, false},
+		{"ORDER BY in a function that doesn't accept it", "max(a ORDER BY b)", nil, true},
 	}
 
 	for _, test := range tests {
@@ -105,6 +105,8 @@ func (p *Parser) ParseStatement() (statement.Statement, error) {
 	switch tok {
 	case scanner.ALTER:
 		return p.parseAlterStatement()
+	case scanner.BACKUP:
+		return p.parseBackupStatement()
 	case scanner.BEGIN:
 		return p.parseBeginStatement()
 	case scanner.COMMIT:
@@ -127,10 +129,14 @@ func (p *Parser) ParseStatement() (statement.Statement, error) {
 		return p.parseReIndexStatement()
 	case scanner.ROLLBACK:
 		return p.parseRollbackStatement()
+	case scanner.SET:
+		return p.parseSetStatement()
+	case scanner.VACUUM:
+		return p.parseVacuumStatement()
 	}
 
 	return nil, newParseError(scanner.Tokstr(tok, lit), []string{
-		"ALTER", "BEGIN", "COMMIT", "SELECT", "DELETE", "UPDATE", "INSERT", "CREATE", "DROP", "EXPLAIN", "REINDEX", "ROLLBACK",
+		"ALTER", "BACKUP", "BEGIN", "COMMIT", "SELECT", "DELETE", "UPDATE", "INSERT", "CREATE", "DROP", "EXPLAIN", "REINDEX", "ROLLBACK", "SET", "VACUUM",
 	}, pos)
 }
 
@@ -164,7 +170,7 @@ func (p *Parser) parseCondition() (expr.Expr, error) {
 func (p *Parser) parseColumnList() ([]string, tree.SortOrder, error) {
 	// Parse ( token.
 	if ok, err := p.parseOptional(scanner.LPAREN); !ok || err != nil {
-		return nil, 0, err
+		return nil, nil, err
 	}
 
 	var columns []string
@@ -174,24 +180,14 @@ func (p *Parser) parseColumnList() ([]string, tree.SortOrder, error) {
 
 	// Parse first (required) column.
 	if col, err = p.parseIdent(); err != nil {
-		return nil, 0, err
+		return nil, nil, err
 	}
 
 	columns = append(columns, col)
 
-	// Parse optional ASC/DESC token.
-	ok, err := p.parseOptional(scanner.DESC)
+	order, err = p.parseSortOrder(order, 0)
 	if err != nil {
-		return nil, 0, err
-	}
-	if ok {
-		order = order.SetDesc(0)
-	} else {
-		// ignore ASC if set
-		_, err := p.parseOptional(scanner.ASC)
-		if err != nil {
-			return nil, 0, err
-		}
+		return nil, nil, err
 	}
 
 	// Parse remaining (optional) columns.
@@ -204,37 +200,60 @@ func (p *Parser) parseColumnList() ([]string, tree.SortOrder, error) {
 
 		c, err := p.parseIdent()
 		if err != nil {
-			return nil, 0, err
+			return nil, nil, err
 		}
 
 		columns = append(columns, c)
 
 		i++
 
-		// Parse optional ASC/DESC token.
-		ok, err := p.parseOptional(scanner.DESC)
+		order, err = p.parseSortOrder(order, i)
 		if err != nil {
-			return nil, 0, err
-		}
-		if ok {
-			order = order.SetDesc(i)
-		} else {
-			// ignore ASC if set
-			_, err := p.parseOptional(scanner.ASC)
-			if err != nil {
-				return nil, 0, err
-			}
+			return nil, nil, err
 		}
 	}
 
 	// Parse required ) token.
 	if err := p.ParseTokens(scanner.RPAREN); err != nil {
-		return nil, 0, err
+		return nil, nil, err
 	}
 
 	return columns, order, nil
 }
 
+// parseSortOrder parses the optional ASC/DESC and NULLS FIRST/LAST clauses
+// following the i-th column of a key, and sets them in the given order.
+func (p *Parser) parseSortOrder(order tree.SortOrder, i int) (tree.SortOrder, error) {
+	// Parse optional ASC/DESC token.
+	desc, err := p.parseOptional(scanner.DESC)
+	if err != nil {
+		return nil, err
+	}
+	if desc {
+		order = order.SetDesc(i)
+	} else if _, err := p.parseOptional(scanner.ASC); err != nil {
+		// ignore ASC if set
+		return nil, err
+	}
+
+	// Parse optional NULLS FIRST/LAST.
+	// NULLS, FIRST and LAST are not reserved and can still be used as identifiers.
+	if tok, _, lit := p.ScanIgnoreWhitespace(); !isWord(tok, lit, "NULLS") {
+		p.Unscan()
+		return order, nil
+	}
+
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	switch {
+	case isWord(tok, lit, "FIRST"):
+		return order.SetNullsFirst(i), nil
+	case isWord(tok, lit, "LAST"):
+		return order.SetNullsLast(i), nil
+	}
+
+	return nil, newParseError(scanner.Tokstr(tok, lit), []string{"FIRST", "LAST"}, pos)
+}
+
 // Scan returns the next token from the underlying scanner.
 func (p *Parser) Scan() (tok scanner.Token, pos scanner.Pos, lit string) { return p.s.Scan() }
 
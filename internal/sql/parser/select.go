@@ -1,7 +1,10 @@
 package parser
 
 import (
+	"strings"
+
 	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/expr/functions"
 	"github.com/chaisql/chai/internal/query/statement"
 	"github.com/chaisql/chai/internal/sql/scanner"
 	"github.com/cockroachdb/errors"
@@ -97,6 +100,26 @@ func (p *Parser) parseSelectCore() (*statement.SelectCoreStmt, error) {
 		return nil, err
 	}
 
+	// Parse "FROM unnest(expr) [AS name]".
+	if strings.EqualFold(stmt.TableName, "unnest") {
+		stmt.UnnestExpr, stmt.UnnestColumn, err = p.parseFromUnnest()
+		if err != nil {
+			return nil, err
+		}
+		if stmt.UnnestExpr != nil {
+			stmt.TableName = ""
+		}
+	} else if stmt.TableName != "" {
+		// Parse "FROM function(args)", such as json_each(expr).
+		stmt.TableFunction, err = p.parseFromTableFunction(stmt.TableName)
+		if err != nil {
+			return nil, err
+		}
+		if stmt.TableFunction != nil {
+			stmt.TableName = ""
+		}
+	}
+
 	// Parse condition: "WHERE expr".
 	stmt.WhereExpr, err = p.parseCondition()
 	if err != nil {
@@ -184,6 +207,70 @@ func (p *Parser) parseFrom() (string, error) {
 	return ident, nil
 }
 
+// parseFromUnnest parses the end of the unnest(expr) table function,
+// after its name, and the optional name of its column, which defaults to unnest.
+// It returns a nil expression if unnest is not followed by a left parenthesis,
+// in which case it is the name of a table.
+func (p *Parser) parseFromUnnest() (expr.Expr, string, error) {
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.LPAREN {
+		p.Unscan()
+		return nil, "", nil
+	}
+
+	e, err := p.ParseExpr()
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := p.ParseTokens(scanner.RPAREN); err != nil {
+		return nil, "", err
+	}
+
+	column := "unnest"
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.AS {
+		column, err = p.parseIdent()
+		if err != nil {
+			return nil, "", err
+		}
+	} else {
+		p.Unscan()
+	}
+
+	return e, column, nil
+}
+
+// parseFromTableFunction parses the arguments of a table function, after its name.
+// It returns nil if the name is not followed by a left parenthesis,
+// in which case it is the name of a table.
+func (p *Parser) parseFromTableFunction(name string) (expr.TableFunction, error) {
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.LPAREN {
+		p.Unscan()
+		return nil, nil
+	}
+
+	args, err := p.parseExprListUntil(scanner.RPAREN)
+	if err != nil {
+		return nil, err
+	}
+
+	def, err := functions.GetFunc(name)
+	if err != nil {
+		return nil, err
+	}
+
+	fn, err := def.Function(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	tf, ok := fn.(expr.TableFunction)
+	if !ok {
+		return nil, errors.Errorf("%s() cannot be used in the FROM clause", name)
+	}
+
+	return tf, nil
+}
+
 func (p *Parser) parseGroupBy() (expr.Expr, error) {
 	ok, err := p.parseOptional(scanner.GROUP, scanner.BY)
 	if err != nil || !ok {
@@ -94,6 +94,27 @@ func TestParserSelect(t *testing.T) {
 			stream.New(table.Scan("test")).Pipe(rows.Project(parseNamedExpr(t, "a"), parseNamedExpr(t, "b"), expr.Wildcard{})),
 			true, false,
 		},
+		{"WithUnnest", "SELECT a, unnest(b) FROM test",
+			stream.New(table.Scan("test")).
+				Pipe(rows.Unnest(parseExpr("b"), "UNNEST(b)")).
+				Pipe(rows.Project(parseNamedExpr(t, "a"), parseNamedExpr(t, "UNNEST(b)"))),
+			true, false,
+		},
+		{"FromUnnest", "SELECT x FROM unnest([1, 2]) AS x",
+			stream.New(rows.Unnest(parseExpr("[1, 2]", ""), "x")).
+				Pipe(rows.Project(&expr.NamedExpr{ExprName: "x", Expr: &expr.Column{Name: "x"}})),
+			true, false,
+		},
+		{"FromUnnest without alias", "SELECT * FROM unnest([1, 2])",
+			stream.New(rows.Unnest(parseExpr("[1, 2]", ""), "unnest")).
+				Pipe(rows.Project(expr.Wildcard{})),
+			true, false,
+		},
+		{"FromJSONEach", "SELECT * FROM json_each('[1, 2]', '$')",
+			stream.New(rows.TableFunction(&functions.JSONEach{Expr: parseExpr("'[1, 2]'"), Path: parseExpr("'$'")})).
+				Pipe(rows.Project(expr.Wildcard{})),
+			true, false,
+		},
 		{"WithExpr", "SELECT a    > 1 FROM test",
 			stream.New(table.Scan("test")).Pipe(rows.Project(parseNamedExpr(t, "a > 1"))),
 			true, false,
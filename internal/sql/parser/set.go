@@ -0,0 +1,67 @@
+package parser
+
+import (
+	"github.com/chaisql/chai/internal/query"
+	"github.com/chaisql/chai/internal/query/statement"
+	"github.com/chaisql/chai/internal/sql/scanner"
+	"github.com/chaisql/chai/internal/types"
+)
+
+// parseSetStatement parses a SET statement.
+//
+//	SET TIME ZONE {'name' | LOCAL | DEFAULT}
+//	SET COERCION {STRICT | LENIENT | DEFAULT}
+func (p *Parser) parseSetStatement() (statement.Statement, error) {
+	// Parse "SET".
+	if err := p.ParseTokens(scanner.SET); err != nil {
+		return nil, err
+	}
+
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	switch {
+	case isWord(tok, lit, "TIME"):
+		return p.parseSetTimeZone()
+	case isWord(tok, lit, "COERCION"):
+		return p.parseSetCoercion()
+	}
+
+	return nil, newParseError(scanner.Tokstr(tok, lit), []string{"TIME", "COERCION"}, pos)
+}
+
+// parseSetTimeZone parses the end of a SET TIME ZONE statement.
+func (p *Parser) parseSetTimeZone() (statement.Statement, error) {
+	// Parse "ZONE".
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); !isWord(tok, lit, "ZONE") {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"ZONE"}, pos)
+	}
+
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	switch {
+	case tok == scanner.STRING:
+		if _, err := query.LoadTimeZone(lit); err != nil {
+			return nil, err
+		}
+		return query.SetTimeZoneStmt{TimeZone: lit}, nil
+	case isWord(tok, lit, "LOCAL"):
+		return query.SetTimeZoneStmt{TimeZone: "Local"}, nil
+	case tok == scanner.DEFAULT:
+		return query.SetTimeZoneStmt{}, nil
+	}
+
+	return nil, newParseError(scanner.Tokstr(tok, lit), []string{"string", "LOCAL", "DEFAULT"}, pos)
+}
+
+// parseSetCoercion parses the end of a SET COERCION statement.
+func (p *Parser) parseSetCoercion() (statement.Statement, error) {
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	switch {
+	case isWord(tok, lit, "STRICT"):
+		return query.SetCoercionStmt{Coercion: types.CoercionStrict}, nil
+	case isWord(tok, lit, "LENIENT"):
+		return query.SetCoercionStmt{Coercion: types.CoercionLenient}, nil
+	case tok == scanner.DEFAULT:
+		return query.SetCoercionStmt{Default: true}, nil
+	}
+
+	return nil, newParseError(scanner.Tokstr(tok, lit), []string{"STRICT", "LENIENT", "DEFAULT"}, pos)
+}
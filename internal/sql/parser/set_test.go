@@ -0,0 +1,47 @@
+package parser_test
+
+import (
+	"testing"
+
+	"github.com/chaisql/chai/internal/query"
+	"github.com/chaisql/chai/internal/query/statement"
+	"github.com/chaisql/chai/internal/sql/parser"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParserSet(t *testing.T) {
+	tests := []struct {
+		s        string
+		expected statement.Statement
+		errored  bool
+	}{
+		{"SET TIME ZONE 'Europe/Paris'", query.SetTimeZoneStmt{TimeZone: "Europe/Paris"}, false},
+		{"SET TIME ZONE '+02:00'", query.SetTimeZoneStmt{TimeZone: "+02:00"}, false},
+		{"set time zone 'UTC'", query.SetTimeZoneStmt{TimeZone: "UTC"}, false},
+		{"SET TIME ZONE LOCAL", query.SetTimeZoneStmt{TimeZone: "Local"}, false},
+		{"SET TIME ZONE DEFAULT", query.SetTimeZoneStmt{}, false},
+		{"SET TIME ZONE 'Nowhere/Nowhere'", nil, true},
+		{"SET TIME ZONE '+25:00'", nil, true},
+		{"SET TIME ZONE", nil, true},
+		{"SET TIMEZONE 'UTC'", nil, true},
+		{"SET COERCION STRICT", query.SetCoercionStmt{Coercion: types.CoercionStrict}, false},
+		{"set coercion lenient", query.SetCoercionStmt{Coercion: types.CoercionLenient}, false},
+		{"SET COERCION DEFAULT", query.SetCoercionStmt{Default: true}, false},
+		{"SET COERCION 'strict'", nil, true},
+		{"SET COERCION", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.s, func(t *testing.T) {
+			q, err := parser.ParseQuery(test.s)
+			if test.errored {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, q.Statements, 1)
+			require.EqualValues(t, test.expected, q.Statements[0])
+		})
+	}
+}
@@ -1,12 +1,18 @@
 package parser
 
 import (
+	"strings"
+
 	"github.com/chaisql/chai/internal/query"
 	"github.com/chaisql/chai/internal/query/statement"
 	"github.com/chaisql/chai/internal/sql/scanner"
 )
 
 // parseBeginStatement parses a BEGIN statement.
+//
+//	BEGIN [TRANSACTION] [ISOLATION LEVEL {SERIALIZABLE | SNAPSHOT}] [READ {ONLY | WRITE}]
+//
+// The isolation level and the access mode can be specified in any order.
 func (p *Parser) parseBeginStatement() (statement.Statement, error) {
 	// Parse "BEGIN".
 	if err := p.ParseTokens(scanner.BEGIN); err != nil {
@@ -16,25 +22,53 @@ func (p *Parser) parseBeginStatement() (statement.Statement, error) {
 	// parse optional TRANSACTION token
 	_, _ = p.parseOptional(scanner.TRANSACTION)
 
-	// parse optional READ token
-	if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.READ {
-		p.Unscan()
-		return query.BeginStmt{Writable: true}, nil
-	}
+	stmt := query.BeginStmt{Writable: true}
+	var accessMode, isolation bool
+	for {
+		tok, pos, lit := p.ScanIgnoreWhitespace()
+		switch {
+		case tok == scanner.READ && !accessMode:
+			accessMode = true
 
-	// parse ONLY token
-	if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.ONLY {
-		return query.BeginStmt{Writable: false}, nil
-	}
+			// parse ONLY or WRITE token
+			tok, pos, lit := p.ScanIgnoreWhitespace()
+			switch tok {
+			case scanner.ONLY:
+				stmt.Writable = false
+			case scanner.WRITE:
+				stmt.Writable = true
+			default:
+				return query.BeginStmt{}, newParseError(scanner.Tokstr(tok, lit), []string{"ONLY", "WRITE"}, pos)
+			}
+		case isWord(tok, lit, "ISOLATION") && !isolation:
+			isolation = true
 
-	p.Unscan()
+			if tok, pos, lit := p.ScanIgnoreWhitespace(); !isWord(tok, lit, "LEVEL") {
+				return query.BeginStmt{}, newParseError(scanner.Tokstr(tok, lit), []string{"LEVEL"}, pos)
+			}
 
-	// parse WRITE token
-	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.WRITE {
-		return query.BeginStmt{}, newParseError(scanner.Tokstr(tok, lit), []string{"ONLY", "WRITE"}, pos)
+			tok, pos, lit := p.ScanIgnoreWhitespace()
+			switch {
+			case isWord(tok, lit, "SERIALIZABLE"):
+				stmt.Serializable = true
+			case isWord(tok, lit, "SNAPSHOT"):
+				stmt.Serializable = false
+			default:
+				return query.BeginStmt{}, newParseError(scanner.Tokstr(tok, lit), []string{"SERIALIZABLE", "SNAPSHOT"}, pos)
+			}
+		case tok == scanner.EOF || tok == scanner.SEMICOLON:
+			p.Unscan()
+			return stmt, nil
+		default:
+			return query.BeginStmt{}, newParseError(scanner.Tokstr(tok, lit), []string{"ISOLATION", "READ"}, pos)
+		}
 	}
+}
 
-	return query.BeginStmt{Writable: true}, nil
+// isWord returns whether the token is the given non-reserved word,
+// which can still be used as an identifier elsewhere.
+func isWord(tok scanner.Token, lit string, word string) bool {
+	return tok == scanner.IDENT && strings.EqualFold(lit, word)
 }
 
 // parseRollbackStatement parses a ROLLBACK statement.
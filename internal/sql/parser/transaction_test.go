@@ -21,6 +21,12 @@ func TestParserTransactions(t *testing.T) {
 		{"BEGIN READ WRITE", query.BeginStmt{Writable: true}, false},
 		{"BEGIN READ", query.BeginStmt{}, true},
 		{"BEGIN WRITE", query.BeginStmt{}, true},
+		{"BEGIN ISOLATION LEVEL SERIALIZABLE", query.BeginStmt{Writable: true, Serializable: true}, false},
+		{"BEGIN TRANSACTION isolation level serializable READ WRITE", query.BeginStmt{Writable: true, Serializable: true}, false},
+		{"BEGIN READ ONLY ISOLATION LEVEL SNAPSHOT", query.BeginStmt{Writable: false}, false},
+		{"BEGIN ISOLATION LEVEL READ", query.BeginStmt{}, true},
+		{"BEGIN ISOLATION SERIALIZABLE", query.BeginStmt{}, true},
+		{"BEGIN READ ONLY READ WRITE", query.BeginStmt{}, true},
 		{"ROLLBACK", query.RollbackStmt{}, false},
 		{"ROLLBACK TRANSACTION", query.RollbackStmt{}, false},
 		{"COMMIT", query.CommitStmt{}, false},
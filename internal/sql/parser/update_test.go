@@ -81,7 +81,7 @@ func TestParserUpdate(t *testing.T) {
 			require.NoError(t, err)
 
 			require.Len(t, q.Statements, 1)
-			require.EqualValues(t, &statement.PreparedStreamStmt{Stream: test.expected}, q.Statements[0].(*statement.PreparedStreamStmt))
+			require.EqualValues(t, &statement.PreparedStreamStmt{Stream: test.expected, CountRows: true}, q.Statements[0].(*statement.PreparedStreamStmt))
 		})
 	}
 }
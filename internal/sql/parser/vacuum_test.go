@@ -0,0 +1,19 @@
+package parser_test
+
+import (
+	"testing"
+
+	"github.com/chaisql/chai/internal/query/statement"
+	"github.com/chaisql/chai/internal/sql/parser"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParserVacuum(t *testing.T) {
+	q, err := parser.ParseQuery("VACUUM")
+	require.NoError(t, err)
+	require.Len(t, q.Statements, 1)
+	require.EqualValues(t, &statement.VacuumStmt{}, q.Statements[0])
+
+	_, err = parser.ParseQuery("VACUUM test")
+	require.Error(t, err)
+}
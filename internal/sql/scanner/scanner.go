@@ -46,6 +46,17 @@ func (s *scanner) Scan() (tok Token, pos Pos, lit string) {
 	if isWhitespace(ch0) {
 		return s.scanWhitespace()
 	} else if isLetter(ch0) || ch0 == '_' {
+		// x'DEADBEEF' is a blob literal
+		if ch0 == 'x' || ch0 == 'X' {
+			if ch1, _ := s.r.read(); ch1 == '\'' {
+				tok, _, lit := s.scanString()
+				if tok != STRING {
+					return tok, pos, lit
+				}
+				return HEXSTRING, pos, lit
+			}
+			s.r.unread()
+		}
 		s.r.unread()
 		return s.scanIdent(true)
 	} else if isDigit(ch0) {
@@ -113,7 +124,7 @@ func (s *scanner) Scan() (tok Token, pos Pos, lit string) {
 		}
 		s.r.unread()
 		return BITWISEOR, pos, ""
-	case '^':
+	case '^', '#':
 		return BITWISEXOR, pos, ""
 	case '=':
 		ch1, _ := s.r.read()
@@ -135,6 +146,12 @@ func (s *scanner) Scan() (tok Token, pos Pos, lit string) {
 	case '>':
 		if ch1, _ := s.r.read(); ch1 == '=' {
 			return GTE, pos, ""
+		} else if ch1 == '>' {
+			if ch2, _ := s.r.read(); ch2 == '=' {
+				return CONTAINSEQ, pos, ""
+			}
+			s.r.unread()
+			return CONTAINS, pos, ""
 		}
 		s.r.unread()
 		return GT, pos, ""
@@ -143,6 +160,12 @@ func (s *scanner) Scan() (tok Token, pos Pos, lit string) {
 			return LTE, pos, ""
 		} else if ch1 == '>' {
 			return NEQ, pos, ""
+		} else if ch1 == '<' {
+			if ch2, _ := s.r.read(); ch2 == '=' {
+				return CONTAINEDBYEQ, pos, ""
+			}
+			s.r.unread()
+			return CONTAINEDBY, pos, ""
 		}
 		s.r.unread()
 		return LT, pos, ""
@@ -16,7 +16,7 @@ func TestScanner_Scan(t *testing.T) {
 	}{
 		// Special tokens (EOF, ILLEGAL, WS)
 		{s: ``, tok: EOF},
-		{s: `#`, tok: ILLEGAL, lit: `#`},
+		{s: `@`, tok: ILLEGAL, lit: `@`},
 		{s: ` `, tok: WS, lit: " "},
 		{s: "\t", tok: WS, lit: "\t"},
 		{s: "\n", tok: WS, lit: "\n"},
@@ -47,12 +47,20 @@ func TestScanner_Scan(t *testing.T) {
 		{s: `! `, tok: ILLEGAL, lit: "!"},
 		{s: `<`, tok: LT},
 		{s: `<=`, tok: LTE},
+		{s: `<<`, tok: CONTAINEDBY},
+		{s: `<<=`, tok: CONTAINEDBYEQ},
+		{s: `>>`, tok: CONTAINS},
+		{s: `>>=`, tok: CONTAINSEQ},
 		{s: `>`, tok: GT},
 		{s: `>=`, tok: GTE},
 		{s: `IN`, tok: IN},
 		{s: `IS`, tok: IS},
 		{s: `LIKE`, tok: LIKE},
 		{s: `||`, tok: CONCAT},
+		{s: `&`, tok: BITWISEAND},
+		{s: `|`, tok: BITWISEOR},
+		{s: `^`, tok: BITWISEXOR},
+		{s: `#`, tok: BITWISEXOR},
 
 		// Misc tokens
 		{s: `(`, tok: LPAREN},
@@ -107,6 +115,13 @@ func TestScanner_Scan(t *testing.T) {
 		{s: "\"test\nfoo", tok: BADSTRING, lit: `test`},
 		{s: `"test\g"`, tok: BADESCAPE, lit: `\g`, pos: Pos{Line: 0, Char: 6}},
 
+		// Blobs
+		{s: `x'DEADBEEF'`, tok: HEXSTRING, lit: `DEADBEEF`},
+		{s: `X''`, tok: HEXSTRING, lit: ``},
+		{s: `x'AB`, tok: BADSTRING, lit: `AB`},
+		{s: `xy`, tok: IDENT, lit: `xy`},
+		{s: `x`, tok: IDENT, lit: `x`},
+
 		// Numbers
 		{s: `100`, tok: INTEGER, lit: `100`},
 		{s: `100.23`, tok: NUMBER, lit: `100.23`},
@@ -124,6 +139,7 @@ func TestScanner_Scan(t *testing.T) {
 		{s: `ASC`, tok: ASC},
 		{s: `ALL`, tok: ALL},
 		{s: `BY`, tok: BY},
+		{s: `BACKUP`, tok: BACKUP},
 		{s: `BEGIN`, tok: BEGIN},
 		{s: `BETWEEN`, tok: BETWEEN},
 		{s: `CACHE`, tok: CACHE},
@@ -163,6 +179,7 @@ func TestScanner_Scan(t *testing.T) {
 		{s: `PRIMARY`, tok: PRIMARY},
 		{s: `READ`, tok: READ},
 		{s: `REINDEX`, tok: REINDEX},
+		{s: `VACUUM`, tok: VACUUM},
 		{s: `RENAME`, tok: RENAME},
 		{s: `REPLACE`, tok: REPLACE},
 		{s: `RETURNING`, tok: RETURNING},
@@ -191,6 +208,22 @@ func TestScanner_Scan(t *testing.T) {
 		{s: "INTEGER", tok: TYPEINTEGER},
 		{s: "TEXT", tok: TYPETEXT},
 		{s: "TIMESTAMP", tok: TYPETIMESTAMP},
+		{s: "TIMESTAMPTZ", tok: TYPETIMESTAMPTZ},
+		{s: "UUID", tok: TYPEUUID},
+		{s: "INET", tok: TYPEINET},
+		{s: "CIDR", tok: TYPECIDR},
+		{s: "CITEXT", tok: TYPECITEXT},
+		{s: "CHAR", tok: TYPECHAR},
+		{s: "VARCHAR", tok: TYPEVARCHAR},
+		{s: "JSON", tok: TYPEJSON},
+		{s: "JSONB", tok: TYPEJSONB},
+		{s: "OBJECT", tok: TYPEOBJECT},
+		{s: "POINT", tok: TYPEPOINT},
+		{s: "INTERVAL", tok: TYPEINTERVAL},
+		{s: "DOCUMENT", tok: TYPEDOCUMENT},
+		{s: "ARRAY", tok: TYPEARRAY},
+		{s: "UINT64", tok: TYPEUINT64},
+		{s: "UNSIGNED", tok: UNSIGNED},
 	}
 
 	for i, tt := range tests {
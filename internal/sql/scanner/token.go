@@ -24,6 +24,7 @@ const (
 	STRING          // "abc"
 	BADSTRING       // "abc
 	BADESCAPE       // \q
+	HEXSTRING       // x'DEADBEEF'
 	TRUE            // true
 	FALSE           // false
 	NULL            // NULL
@@ -40,7 +41,7 @@ const (
 	MOD        // %
 	BITWISEAND // &
 	BITWISEOR  // |
-	BITWISEXOR // ^
+	BITWISEXOR // ^ or #
 
 	AND // AND
 	OR  // OR
@@ -61,6 +62,11 @@ const (
 	NLIKE    // NOT LIKE
 	CONCAT   // ||
 	BETWEEN  // BETWEEN
+
+	CONTAINEDBY   // <<
+	CONTAINEDBYEQ // <<=
+	CONTAINS      // >>
+	CONTAINSEQ    // >>=
 	operatorEnd
 
 	LPAREN      // (
@@ -82,6 +88,7 @@ const (
 	ALTER
 	AS
 	ASC
+	BACKUP
 	BEGIN
 	BY
 	CACHE
@@ -139,7 +146,9 @@ const (
 	TRANSACTION
 	UNION
 	UNIQUE
+	UNSIGNED
 	UPDATE
+	VACUUM
 	VALUE
 	VALUES
 	WITH
@@ -147,23 +156,37 @@ const (
 	WRITE
 
 	// Types
+	TYPEARRAY
 	TYPEBIGINT
 	TYPEBLOB
 	TYPEBOOL
 	TYPEBOOLEAN
 	TYPEBYTES
+	TYPECHAR
 	TYPECHARACTER
+	TYPECIDR
+	TYPECITEXT
+	TYPEDOCUMENT
 	TYPEDOUBLE
+	TYPEINET
 	TYPEINT
 	TYPEINT2
 	TYPEINT8
 	TYPEINTEGER
+	TYPEINTERVAL
+	TYPEJSON
+	TYPEJSONB
 	TYPEMEDIUMINT
+	TYPEOBJECT
+	TYPEPOINT
 	TYPEREAL
 	TYPESMALLINT
 	TYPETEXT
 	TYPETIMESTAMP
+	TYPETIMESTAMPTZ
 	TYPETINYINT
+	TYPEUINT64
+	TYPEUUID
 	TYPEVARCHAR
 
 	keywordEnd
@@ -180,6 +203,7 @@ var tokens = [...]string{
 	STRING:          "STRING",
 	BADSTRING:       "BADSTRING",
 	BADESCAPE:       "BADESCAPE",
+	HEXSTRING:       "HEXSTRING",
 	TRUE:            "TRUE",
 	FALSE:           "FALSE",
 	REGEX:           "REGEX",
@@ -210,6 +234,11 @@ var tokens = [...]string{
 	IS:       "IS",
 	LIKE:     "LIKE",
 
+	CONTAINEDBY:   "<<",
+	CONTAINEDBYEQ: "<<=",
+	CONTAINS:      ">>",
+	CONTAINSEQ:    ">>=",
+
 	LPAREN:      "(",
 	RPAREN:      ")",
 	LBRACKET:    "{",
@@ -227,6 +256,7 @@ var tokens = [...]string{
 	ALTER:       "ALTER",
 	AS:          "AS",
 	ASC:         "ASC",
+	BACKUP:      "BACKUP",
 	BEGIN:       "BEGIN",
 	BY:          "BY",
 	CACHE:       "CACHE",
@@ -284,31 +314,47 @@ var tokens = [...]string{
 	TRANSACTION: "TRANSACTION",
 	UNION:       "UNION",
 	UNIQUE:      "UNIQUE",
+	UNSIGNED:    "UNSIGNED",
 	UPDATE:      "UPDATE",
+	VACUUM:      "VACUUM",
 	VALUE:       "VALUE",
 	VALUES:      "VALUES",
 	WITH:        "WITH",
 	WHERE:       "WHERE",
 	WRITE:       "WRITE",
 
-	TYPEBIGINT:    "BIGINT",
-	TYPEBLOB:      "BLOB",
-	TYPEBOOL:      "BOOL",
-	TYPEBOOLEAN:   "BOOLEAN",
-	TYPEBYTES:     "BYTES",
-	TYPECHARACTER: "CHARACTER",
-	TYPEDOUBLE:    "DOUBLE",
-	TYPEINT:       "INT",
-	TYPEINT2:      "INT2",
-	TYPEINT8:      "INT8",
-	TYPEINTEGER:   "INTEGER",
-	TYPEMEDIUMINT: "MEDIUMINT",
-	TYPEREAL:      "REAL",
-	TYPESMALLINT:  "SMALLINT",
-	TYPETEXT:      "TEXT",
-	TYPETIMESTAMP: "TIMESTAMP",
-	TYPETINYINT:   "TINYINT",
-	TYPEVARCHAR:   "VARCHAR",
+	TYPEARRAY:       "ARRAY",
+	TYPEBIGINT:      "BIGINT",
+	TYPEBLOB:        "BLOB",
+	TYPEBOOL:        "BOOL",
+	TYPEBOOLEAN:     "BOOLEAN",
+	TYPEBYTES:       "BYTES",
+	TYPECHAR:        "CHAR",
+	TYPECHARACTER:   "CHARACTER",
+	TYPECIDR:        "CIDR",
+	TYPECITEXT:      "CITEXT",
+	TYPEDOCUMENT:    "DOCUMENT",
+	TYPEDOUBLE:      "DOUBLE",
+	TYPEINET:        "INET",
+	TYPEINT:         "INT",
+	TYPEINT2:        "INT2",
+	TYPEINT8:        "INT8",
+	TYPEINTEGER:     "INTEGER",
+	TYPEINTERVAL:    "INTERVAL",
+	TYPEJSON:        "JSON",
+	TYPEJSONB:       "JSONB",
+	TYPEMEDIUMINT:   "MEDIUMINT",
+	TYPEOBJECT:      "OBJECT",
+	TYPEPOINT:       "POINT",
+	TYPEREAL:        "REAL",
+	TYPESMALLINT:    "SMALLINT",
+	TYPETEXT:        "TEXT",
+	TYPETIMESTAMP:   "TIMESTAMP",
+	TYPETIMESTAMPTZ: "TIMESTAMPTZ",
+	TYPETINYINT:     "TINYINT",
+	TYPEUINT64:      "UINT64",
+	TYPEUUID:        "UUID",
+	TYPEVARCHAR:     "VARCHAR",
 }
 
 var keywords map[string]Token
@@ -332,7 +378,7 @@ func (tok Token) Precedence() int {
 		return 3
 	case EQ, NEQ, IS, ISN, IN, NIN, LIKE, NLIKE, EQREGEX, NEQREGEX, BETWEEN:
 		return 4
-	case LT, LTE, GT, GTE:
+	case LT, LTE, GT, GTE, CONTAINEDBY, CONTAINEDBYEQ, CONTAINS, CONTAINSEQ:
 		return 5
 	case BITWISEOR, BITWISEXOR, BITWISEAND:
 		return 6
@@ -64,6 +64,11 @@ func (it *ScanOperator) Iterate(in *environment.Environment, fn func(out *enviro
 		return err
 	}
 
+	pred, err := table.RowPredicate(in.GetContext())
+	if err != nil {
+		return err
+	}
+
 	var newEnv environment.Environment
 	newEnv.SetOuter(in)
 
@@ -73,7 +78,16 @@ func (it *ScanOperator) Iterate(in *environment.Environment, fn func(out *enviro
 
 	if len(it.Ranges) == 0 {
 		return index.IterateOnRange(nil, it.Reverse, func(key *tree.Key) error {
+			if err := in.Err(); err != nil {
+				return err
+			}
 			ptr.ResetWith(table, key)
+			if pred != nil {
+				ok, err := pred(&ptr)
+				if err != nil || !ok {
+					return err
+				}
+			}
 
 			return fn(&newEnv)
 		})
@@ -91,7 +105,16 @@ func (it *ScanOperator) Iterate(in *environment.Environment, fn func(out *enviro
 		}
 
 		err = index.IterateOnRange(r, it.Reverse, func(key *tree.Key) error {
+			if err := in.Err(); err != nil {
+				return err
+			}
 			ptr.ResetWith(table, key)
+			if pred != nil {
+				ok, err := pred(&ptr)
+				if err != nil || !ok {
+					return err
+				}
+			}
 
 			return fn(&newEnv)
 		})
@@ -53,22 +53,19 @@ func (op *ValidateOperator) Iterate(in *environment.Environment, fn func(out *en
 			return errors.New("missing row")
 		}
 
-		vs := make([]types.Value, 0, len(info.Columns))
+		vs, err := info.Values(tx, r)
+		if err != nil {
+			return err
+		}
 
 		// if the indexes values contain NULL somewhere,
 		// we don't check for unicity.
 		// cf: https://sqlite.org/lang_createindex.html#unique_indexes
 		var hasNull bool
-		for _, column := range info.Columns {
-			v, err := r.Get(column)
-			if err != nil {
-				hasNull = true
-				v = types.NewNullValue()
-			} else if v.Type() == types.TypeNull {
+		for _, v := range vs {
+			if v.Type() == types.TypeNull {
 				hasNull = true
 			}
-
-			vs = append(vs, v)
 		}
 
 		if !hasNull {
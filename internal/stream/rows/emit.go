@@ -46,9 +46,21 @@ func (it *EmitOperator) Columns(env *environment.Environment) ([]string, error)
 }
 
 func (op *EmitOperator) Clone() stream.Operator {
+	// the expressions are replaced by the planner when
+	// it precalculates them and must not be shared
+	rows := make([]expr.Row, len(op.Rows))
+	for i, r := range op.Rows {
+		exprs := make([]expr.Expr, len(r.Exprs))
+		for j, e := range r.Exprs {
+			exprs[j] = expr.Clone(e)
+		}
+		rows[i] = expr.Row{Columns: r.Columns, Exprs: exprs}
+	}
+
 	return &EmitOperator{
 		BaseOperator: op.BaseOperator.Clone(),
-		Rows:         op.Rows,
+		Rows:         rows,
+		columns:      op.columns,
 	}
 }
 
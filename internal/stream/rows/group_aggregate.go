@@ -2,6 +2,7 @@ package rows
 
 import (
 	"fmt"
+	"io"
 	"strings"
 
 	"github.com/chaisql/chai/internal/database"
@@ -37,16 +38,26 @@ func (op *GroupAggregateOperator) Clone() stream.Operator {
 	}
 }
 
-func (op *GroupAggregateOperator) Iterate(in *environment.Environment, f func(out *environment.Environment) error) error {
+func (op *GroupAggregateOperator) Iterate(in *environment.Environment, f func(out *environment.Environment) error) (err error) {
 	var lastGroup types.Value
 	var ga *groupAggregator
 
+	// release the resources held by the aggregators of the last group
+	defer func() {
+		if ga != nil {
+			e := ga.Close()
+			if err == nil {
+				err = e
+			}
+		}
+	}()
+
 	var groupExpr string
 	if op.E != nil {
 		groupExpr = op.E.String()
 	}
 
-	err := op.Prev.Iterate(in, func(out *environment.Environment) error {
+	err = op.Prev.Iterate(in, func(out *environment.Environment) error {
 		if op.E == nil {
 			if ga == nil {
 				ga = newGroupAggregator(nil, groupExpr, op.Builders)
@@ -91,6 +102,10 @@ func (op *GroupAggregateOperator) Iterate(in *environment.Environment, f func(ou
 		if err != nil {
 			return err
 		}
+		err = ga.Close()
+		if err != nil {
+			return err
+		}
 
 		lastGroup = group
 
@@ -182,6 +197,19 @@ func (g *groupAggregator) Aggregate(env *environment.Environment) error {
 	return nil
 }
 
+// Close releases the resources held by the aggregators that implement io.Closer,
+// such as the transient trees of the exact percentiles.
+func (g *groupAggregator) Close() error {
+	var err error
+	for _, agg := range g.aggregators {
+		if c, ok := agg.(io.Closer); ok {
+			err = errors.CombineErrors(err, c.Close())
+		}
+	}
+
+	return err
+}
+
 func (g *groupAggregator) Flush(env *environment.Environment) (*environment.Environment, error) {
 	cb := row.NewColumnBuffer()
 
@@ -0,0 +1,62 @@
+package rows
+
+import (
+	"fmt"
+
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/environment"
+	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/row"
+	"github.com/chaisql/chai/internal/stream"
+	"github.com/chaisql/chai/internal/types"
+)
+
+// A TableFunctionOperator outputs the rows returned by a table function.
+type TableFunctionOperator struct {
+	stream.BaseOperator
+	Func expr.TableFunction
+}
+
+// TableFunction evaluates the table function fn and outputs its rows.
+// It is the source of the stream.
+func TableFunction(fn expr.TableFunction) *TableFunctionOperator {
+	return &TableFunctionOperator{Func: fn}
+}
+
+func (op *TableFunctionOperator) Clone() stream.Operator {
+	return &TableFunctionOperator{
+		BaseOperator: op.BaseOperator.Clone(),
+		Func:         expr.Clone(op.Func).(expr.TableFunction),
+	}
+}
+
+func (op *TableFunctionOperator) Columns(env *environment.Environment) ([]string, error) {
+	return op.Func.Columns(), nil
+}
+
+// Iterate implements the Operator interface.
+func (op *TableFunctionOperator) Iterate(in *environment.Environment, f func(out *environment.Environment) error) error {
+	var newEnv environment.Environment
+	newEnv.SetOuter(in)
+
+	columns := op.Func.Columns()
+	cb := row.NewColumnBuffer()
+	var br database.BasicRow
+
+	return op.Func.Iterate(in, func(values []types.Value) error {
+		if err := in.Err(); err != nil {
+			return err
+		}
+		cb.Reset()
+		for i, v := range values {
+			cb.Add(columns[i], v)
+		}
+		br.ResetWith("", nil, cb)
+		newEnv.SetRow(&br)
+		return f(&newEnv)
+	})
+}
+
+func (op *TableFunctionOperator) String() string {
+	return fmt.Sprintf("rows.TableFunction(%s)", op.Func)
+}
@@ -43,8 +43,7 @@ func (op *TempTreeSortOperator) Iterate(in *environment.Environment, fn func(out
 	db := in.GetDB()
 
 	catalog := in.GetTx().Catalog
-	tns := catalog.GetFreeTransientNamespace()
-	tr, cleanup, err := tree.NewTransient(db.Engine.NewTransientSession(), tns, 0)
+	tr, cleanup, err := catalog.NewTransientTree(db.Engine.NewTransientSession(), nil)
 	if err != nil {
 		return err
 	}
@@ -154,6 +153,11 @@ func encodeTempRow(buf []byte, r row.Row) ([]byte, error) {
 	err := r.Iterate(func(column string, v types.Value) error {
 		values = append(values, types.NewTextValue(column))
 		values = append(values, types.NewIntegerValue(int32(v.Type())))
+		// CITEXT values are folded by the key encoding,
+		// store them as TEXT to keep their case
+		if v.Type() == types.TypeCitext {
+			v = types.NewTextValue(types.AsString(v))
+		}
 		values = append(values, v)
 		return nil
 	})
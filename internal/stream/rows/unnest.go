@@ -0,0 +1,136 @@
+package rows
+
+import (
+	"fmt"
+
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/environment"
+	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/row"
+	"github.com/chaisql/chai/internal/stream"
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// An UnnestOperator outputs one row per element of an array.
+type UnnestOperator struct {
+	stream.BaseOperator
+	Expr   expr.Expr
+	Column string
+}
+
+// Unnest evaluates the array expression e and outputs one row per element,
+// in a column with the given name. If the stream has a previous operator,
+// e is evaluated for each of its rows, which is repeated for every element
+// of its array; the column is then only visible to the expressions that read it,
+// such as functions.Unnest. Otherwise the operator is the source of the stream
+// and outputs rows made of that column.
+// NULL and empty arrays don't output any row.
+func Unnest(e expr.Expr, column string) *UnnestOperator {
+	return &UnnestOperator{Expr: e, Column: column}
+}
+
+func (op *UnnestOperator) Clone() stream.Operator {
+	return &UnnestOperator{
+		BaseOperator: op.BaseOperator.Clone(),
+		Expr:         expr.Clone(op.Expr),
+		Column:       op.Column,
+	}
+}
+
+func (op *UnnestOperator) Columns(env *environment.Environment) ([]string, error) {
+	if op.Prev == nil {
+		return []string{op.Column}, nil
+	}
+
+	return op.Prev.Columns(env)
+}
+
+// Iterate implements the Operator interface.
+func (op *UnnestOperator) Iterate(in *environment.Environment, f func(out *environment.Environment) error) error {
+	var newEnv environment.Environment
+
+	if op.Prev == nil {
+		cb := row.NewColumnBuffer()
+		var br database.BasicRow
+
+		newEnv.SetOuter(in)
+		return op.iterateArray(in, func(v types.Value) error {
+			cb.Reset()
+			cb.Add(op.Column, v)
+			br.ResetWith("", nil, cb)
+			newEnv.SetRow(&br)
+			return f(&newEnv)
+		})
+	}
+
+	var ur unnestRow
+	ur.column = op.Column
+	return op.Prev.Iterate(in, func(out *environment.Environment) error {
+		r, ok := out.GetRow()
+		if !ok {
+			return errors.New("missing row")
+		}
+		ur.Row = r
+		if dr, ok := out.GetDatabaseRow(); ok {
+			ur.tableName, ur.key = dr.TableName(), dr.Key()
+		}
+
+		newEnv.SetOuter(out)
+		return op.iterateArray(out, func(v types.Value) error {
+			ur.value = v
+			newEnv.SetRow(&ur)
+			return f(&newEnv)
+		})
+	})
+}
+
+func (op *UnnestOperator) iterateArray(env *environment.Environment, fn func(v types.Value) error) error {
+	v, err := op.Expr.Eval(env)
+	if err != nil {
+		return err
+	}
+
+	switch v.Type() {
+	case types.TypeNull:
+		return nil
+	case types.TypeArray:
+	default:
+		return errors.Errorf("cannot unnest a value of type %s", v.Type())
+	}
+
+	return types.AsArray(v).Iterate(func(_ int, v types.Value) error {
+		return fn(v)
+	})
+}
+
+func (op *UnnestOperator) String() string {
+	return fmt.Sprintf("rows.Unnest(%s, %q)", op.Expr, op.Column)
+}
+
+// unnestRow is a row with an element of an array stored in a hidden column,
+// which Get returns but Iterate skips.
+type unnestRow struct {
+	row.Row
+	tableName string
+	key       *tree.Key
+	column    string
+	value     types.Value
+}
+
+func (r *unnestRow) Get(name string) (types.Value, error) {
+	if name == r.column {
+		return r.value, nil
+	}
+
+	return r.Row.Get(name)
+}
+
+func (r *unnestRow) TableName() string {
+	return r.tableName
+}
+
+func (r *unnestRow) Key() *tree.Key {
+	return r.key
+}
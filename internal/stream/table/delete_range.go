@@ -0,0 +1,101 @@
+package table
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/environment"
+	"github.com/chaisql/chai/internal/stream"
+	"github.com/chaisql/chai/internal/tree"
+)
+
+// A DeleteRangeOperator deletes ranges of rows from a table
+// without reading them.
+type DeleteRangeOperator struct {
+	stream.BaseOperator
+	TableName string
+	Ranges    stream.Ranges
+}
+
+// DeleteRange deletes the rows of the table whose primary key matches the given ranges.
+// If no ranges are provided, it deletes all the rows.
+// It must only be used on tables without indexes, and doesn't produce any row.
+func DeleteRange(tableName string, ranges ...stream.Range) *DeleteRangeOperator {
+	return &DeleteRangeOperator{TableName: tableName, Ranges: ranges}
+}
+
+func (op *DeleteRangeOperator) Clone() stream.Operator {
+	return &DeleteRangeOperator{
+		BaseOperator: op.BaseOperator.Clone(),
+		TableName:    op.TableName,
+		Ranges:       op.Ranges.Clone(),
+	}
+}
+
+// Iterate implements the Operator interface.
+func (op *DeleteRangeOperator) Iterate(in *environment.Environment, _ func(out *environment.Environment) error) error {
+	table, err := in.GetTx().Catalog.GetTable(in.GetTx(), op.TableName)
+	if err != nil {
+		return err
+	}
+
+	// rows restricted by a row policy must be read
+	// to only delete the ones satisfying it.
+	pred, err := table.RowPredicate(in.GetContext())
+	if err != nil {
+		return err
+	}
+
+	deleteRange := table.DeleteRange
+	if pred != nil {
+		deleteRange = func(rng *database.Range) error {
+			return table.IterateOnRange(rng, false, func(key *tree.Key, r database.Row) error {
+				ok, err := pred(r)
+				if err != nil || !ok {
+					return err
+				}
+
+				return table.Delete(key)
+			})
+		}
+	}
+
+	if op.Ranges == nil {
+		return deleteRange(nil)
+	}
+
+	ranges, err := op.Ranges.Eval(in)
+	if err != nil {
+		return err
+	}
+
+	for _, rng := range ranges {
+		err = deleteRange(rng)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (op *DeleteRangeOperator) String() string {
+	var s strings.Builder
+
+	s.WriteString("table.DeleteRange(")
+	s.WriteString(strconv.Quote(op.TableName))
+	if len(op.Ranges) > 0 {
+		s.WriteString(", [")
+		for i, r := range op.Ranges {
+			s.WriteString(r.String())
+			if i+1 < len(op.Ranges) {
+				s.WriteString(", ")
+			}
+		}
+		s.WriteString("]")
+	}
+	s.WriteString(")")
+
+	return s.String()
+}
@@ -30,6 +30,7 @@ func (op *ReplaceOperator) Clone() stream.Operator {
 // Iterate implements the Operator interface.
 func (op *ReplaceOperator) Iterate(in *environment.Environment, f func(out *environment.Environment) error) error {
 	var table *database.Table
+	var pred database.RowPredicate
 
 	it := func(out *environment.Environment) error {
 		r, ok := out.GetDatabaseRow()
@@ -43,6 +44,26 @@ func (op *ReplaceOperator) Iterate(in *environment.Environment, f func(out *envi
 			if err != nil {
 				return err
 			}
+
+			pred, err = table.RowPredicate(in.GetContext())
+			if err != nil {
+				return err
+			}
+		}
+
+		// rows hidden by the row policy of the table cannot be replaced
+		if pred != nil {
+			old, err := table.GetRow(r.Key())
+			if err != nil {
+				return err
+			}
+			ok, err := pred(old)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return errors.Errorf("cannot replace row %s: hidden by the row policy of table %s", r.Key(), op.Name)
+			}
 		}
 
 		_, err := table.Replace(r.Key(), r)
@@ -1,6 +1,7 @@
 package table
 
 import (
+	"context"
 	"strconv"
 	"strings"
 
@@ -20,6 +21,9 @@ type ScanOperator struct {
 	// If set, the operator will scan this table.
 	// It not set, it will get the scan from the catalog.
 	Table *database.Table
+	// If true, the row policy of the table is ignored and every row is scanned,
+	// for example to build an index.
+	Unrestricted bool
 }
 
 // Scan creates an iterator that iterates over each object of the given table that match the given ranges.
@@ -40,6 +44,7 @@ func (op *ScanOperator) Clone() stream.Operator {
 		Ranges:       op.Ranges.Clone(),
 		Reverse:      op.Reverse,
 		Table:        op.Table,
+		Unrestricted: op.Unrestricted,
 	}
 }
 
@@ -69,8 +74,30 @@ func (it *ScanOperator) Iterate(in *environment.Environment, fn func(out *enviro
 		}
 	}
 
+	ctx := in.GetContext()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var pred database.RowPredicate
+	if !it.Unrestricted {
+		pred, err = table.RowPredicate(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
 	for _, rng := range ranges {
-		err = table.IterateOnRange(rng, it.Reverse, func(key *tree.Key, r database.Row) error {
+		err = table.IterateOnRangeContext(ctx, rng, it.Reverse, func(key *tree.Key, r database.Row) error {
+			if err := in.Err(); err != nil {
+				return err
+			}
+			if pred != nil {
+				ok, err := pred(r)
+				if err != nil || !ok {
+					return err
+				}
+			}
 			newEnv.SetRow(r)
 
 			return fn(&newEnv)
@@ -62,7 +62,7 @@ func (op *ValidateOperator) Iterate(in *environment.Environment, fn func(out *en
 		}
 
 		// use the encoded row as the new row
-		eo.ResetWith(&info.ColumnConstraints, buf)
+		eo.ResetWith(tx, info, buf)
 
 		if dRow, ok := row.(database.Row); ok {
 			br.ResetWith(op.tableName, dRow.Key(), &eo)
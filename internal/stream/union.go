@@ -72,8 +72,7 @@ func (it *UnionOperator) Iterate(in *environment.Environment, fn func(out *envir
 			if temp == nil {
 				// create a temporary tree
 				db := in.GetDB()
-				tns := in.GetTx().Catalog.GetFreeTransientNamespace()
-				temp, cleanup, err = tree.NewTransient(db.Engine.NewTransientSession(), tns, 0)
+				temp, cleanup, err = in.GetTx().Catalog.NewTransientTree(db.Engine.NewTransientSession(), nil)
 				if err != nil {
 					return err
 				}
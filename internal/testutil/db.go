@@ -42,7 +42,7 @@ func NewTestTree(t testing.TB, namespace tree.Namespace) *tree.Tree {
 		session.Close()
 	})
 
-	return tree.New(session, namespace, 0)
+	return tree.New(session, namespace, nil)
 }
 
 func NewTestDB(t testing.TB) *database.Database {
@@ -38,6 +38,16 @@ func (k *Key) Encode(ns Namespace, order SortOrder) ([]byte, error) {
 	}
 
 	for i, v := range k.values {
+		// NULLs sort before any other value, unless the order
+		// places them at the opposite of their default position
+		if v.Type() == types.TypeNull && !order.HasDefaultNulls(i) {
+			buf = encoding.EncodeNullLast(buf)
+			if order.IsDesc(i) {
+				buf, _ = encoding.Desc(buf, 1)
+			}
+			continue
+		}
+
 		// extract the sort order
 		buf, err = types.EncodeValueAsKey(buf, v, order.IsDesc(i))
 		if err != nil {
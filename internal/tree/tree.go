@@ -1,48 +1,108 @@
 package tree
 
 import (
-	"fmt"
+	"bytes"
+	"math/big"
 
 	"github.com/chaisql/chai/internal/encoding"
 	"github.com/chaisql/chai/internal/engine"
-	"github.com/cockroachdb/errors"
+	"github.com/chaisql/chai/internal/types"
 )
 
 type Namespace uint64
 
-// SortOrder is a 64-bit unsigned integer that represents
-// the sort order (ASC or DESC) of each value in a key.
+// Bounds returns the smallest key (inclusive) and the largest key (exclusive)
+// of the namespace, as encoded by the engine.
+func (n Namespace) Bounds() (start, end []byte) {
+	return encoding.EncodeInt(nil, int64(n)), encoding.EncodeInt(nil, int64(n)+1)
+}
+
+// SortOrder is a bitset that represents the sort order (ASC or DESC)
+// and the position of NULLs (NULLS FIRST or NULLS LAST)
+// of each value in a key.
 // By default, all values are sorted in ascending order.
-// Each bit represents the sort order of the corresponding value
-// in the key.
+// Each value uses two bits of the bitset: the first one is set if the value
+// is sorted in descending order, the second one is set if NULLs are not
+// at their default position, which is first in ascending order
+// and last in descending order. The bitset grows as needed, there is no limit
+// to the number of values.
+// The zero value sorts all values in ascending order, NULLs first.
 // SortOrder is used in a tree to encode keys.
-// It can only support up to 64 values.
-type SortOrder uint64
+// SortOrder values are never modified: the Set methods return a copy.
+type SortOrder []uint64
 
 func (o SortOrder) IsDesc(i int) bool {
-	if i > 63 {
-		panic(fmt.Sprintf("cannot get sort order of value %d, only 64 values are supported", i))
-	}
-
-	mask := uint64(1) << (63 - i)
-	return uint64(o)&mask>>(63-i) != 0
+	return o.isSet(2 * i)
 }
 
 func (o SortOrder) SetDesc(i int) SortOrder {
-	if i > 63 {
-		panic(fmt.Sprintf("cannot set sort order of value %d, only 64 values are supported", i))
+	return o.set(2*i, true)
+}
+
+func (o SortOrder) SetAsc(i int) SortOrder {
+	return o.set(2*i, false)
+}
+
+// IsNullsFirst returns whether NULLs are sorted before the other values.
+func (o SortOrder) IsNullsFirst(i int) bool {
+	return o.IsDesc(i) == o.isSet(2*i+1)
+}
+
+// HasDefaultNulls returns whether NULLs are at their default position:
+// first in ascending order and last in descending order.
+func (o SortOrder) HasDefaultNulls(i int) bool {
+	return !o.isSet(2*i + 1)
+}
+
+// SetNullsFirst sorts NULLs before the other values.
+// The position of NULLs depends on the order of the value,
+// it must be set after calling SetDesc or SetAsc.
+func (o SortOrder) SetNullsFirst(i int) SortOrder {
+	return o.set(2*i+1, o.IsDesc(i))
+}
+
+// SetNullsLast sorts NULLs after the other values.
+// The position of NULLs depends on the order of the value,
+// it must be set after calling SetDesc or SetAsc.
+func (o SortOrder) SetNullsLast(i int) SortOrder {
+	return o.set(2*i+1, !o.IsDesc(i))
+}
+
+func (o SortOrder) isSet(bit int) bool {
+	w := bit / 64
+	if w >= len(o) {
+		return false
 	}
 
-	mask := uint64(1) << (63 - i)
-	return SortOrder(uint64(o) | mask)
+	return o[w]&sortOrderMask(bit) != 0
 }
 
-func (o SortOrder) SetAsc(i int) SortOrder {
-	if i > 63 {
-		panic(fmt.Sprintf("cannot set sort order of value %d, only 64 values are supported", i))
+func (o SortOrder) set(bit int, on bool) SortOrder {
+	if o.isSet(bit) == on {
+		return o
+	}
+
+	so := make(SortOrder, max(len(o), bit/64+1))
+	copy(so, o)
+	if on {
+		so[bit/64] |= sortOrderMask(bit)
+		return so
+	}
+
+	so[bit/64] &^= sortOrderMask(bit)
+
+	// unset bits at the end are not stored
+	for len(so) > 0 && so[len(so)-1] == 0 {
+		so = so[:len(so)-1]
+	}
+	if len(so) == 0 {
+		return nil
 	}
-	mask := uint64(1) << (63 - i)
-	return SortOrder(uint64(o) &^ mask)
+	return so
+}
+
+func sortOrderMask(bit int) uint64 {
+	return uint64(1) << (63 - bit%64)
 }
 
 // A Tree is an abstraction over a k-v store that allows
@@ -68,24 +128,6 @@ func New(session engine.Session, ns Namespace, order SortOrder) *Tree {
 	}
 }
 
-func NewTransient(session engine.Session, ns Namespace, order SortOrder) (*Tree, func() error, error) {
-	t := Tree{
-		Namespace: ns,
-		Session:   session,
-		Order:     order,
-	}
-
-	// ensure the namespace is not in use
-	err := t.IterateOnRange(nil, false, func(k *Key, b []byte) error {
-		return errors.Errorf("namespace %d is already in use", ns)
-	})
-	if err != nil {
-		return nil, nil, err
-	}
-
-	return &t, t.Truncate, nil
-}
-
 var defaultValue = []byte{0}
 
 // Insert adds a key-obj combination to the tree.
@@ -117,6 +159,34 @@ func (t *Tree) Put(key *Key, value []byte) error {
 	return t.Session.Put(k, value)
 }
 
+// deltaMinSize is the size in bytes of the values above which
+// Update may store a delta instead of the whole new value.
+const deltaMinSize = 128
+
+// Update replaces the value old of an existing key by value.
+// If the session supports it and the values are large and mostly
+// identical, only the delta between them is written, which reduces
+// the write amplification of updates, see engine.MergeSession.
+func (t *Tree) Update(key *Key, old, value []byte) error {
+	ms, ok := t.Session.(engine.MergeSession)
+	if !ok || len(old) < deltaMinSize || len(value) == 0 {
+		return t.Put(key, value)
+	}
+
+	// the delta must be much smaller than the value to be worth it
+	d := engine.Diff(old, value)
+	if len(d.Inserted)*4 > len(value) {
+		return t.Put(key, value)
+	}
+
+	k, err := key.Encode(t.Namespace, t.Order)
+	if err != nil {
+		return err
+	}
+
+	return ms.Merge(k, d)
+}
+
 // Get a key from the tree. If the key doesn't exist,
 // it returns engine.ErrKeyNotFound.
 func (t *Tree) Get(key *Key) ([]byte, error) {
@@ -160,31 +230,33 @@ func (t *Tree) Delete(key *Key) error {
 
 // Truncate the tree.
 func (t *Tree) Truncate() error {
-	return t.Session.DeleteRange(encoding.EncodeInt(nil, int64(t.Namespace)), encoding.EncodeInt(nil, int64(t.Namespace)+1))
+	return t.Session.DeleteRange(t.Namespace.Bounds())
 }
 
-// IterateOnRange iterates on all keys that are in the given range.
-func (t *Tree) IterateOnRange(rng *Range, reverse bool, fn func(*Key, []byte) error) error {
-	var start, end []byte
-	var err error
-
+// DeleteRange deletes all keys that are in the given range.
+// Unlike deleting the keys one by one, it relies on the range deletion
+// of the engine.
+// If the range is nil, it deletes the whole tree.
+func (t *Tree) DeleteRange(rng *Range) error {
 	if rng == nil {
-		rng = &Range{}
+		return t.Truncate()
 	}
 
-	var min, max *Key
-	desc := t.isDescRange(rng)
-	if !desc {
-		min, max = rng.Min, rng.Max
-	} else {
-		min, max = rng.Max, rng.Min
+	start, end, err := t.buildBoundaries(rng)
+	if err != nil {
+		return err
 	}
 
-	if !rng.Exclusive {
-		start, end, err = t.buildInclusiveBoundaries(min, max, desc)
-	} else {
-		start, end, err = t.buildExclusiveBoundaries(min, max, desc)
+	return t.Session.DeleteRange(start, end)
+}
+
+// IterateOnRange iterates on all keys that are in the given range.
+func (t *Tree) IterateOnRange(rng *Range, reverse bool, fn func(*Key, []byte) error) error {
+	if rng == nil {
+		rng = &Range{}
 	}
+
+	start, end, err := t.buildBoundaries(rng)
 	if err != nil {
 		return err
 	}
@@ -233,6 +305,258 @@ func (t *Tree) IterateOnRange(rng *Range, reverse bool, fn func(*Key, []byte) er
 	return it.Error()
 }
 
+// ReadForConstraint informs the session that the range is read to check a constraint,
+// if it detects conflicts between concurrent transactions. See engine.ConstraintReader.
+func (t *Tree) ReadForConstraint(rng *Range) error {
+	cr, ok := t.Session.(engine.ConstraintReader)
+	if !ok {
+		return nil
+	}
+
+	start, end, err := t.buildBoundaries(rng)
+	if err != nil {
+		return err
+	}
+
+	cr.ReadForConstraint(start, end)
+	return nil
+}
+
+// Sample returns up to n keys of the tree, in order, evenly spread across
+// the key space between its first and last keys. Instead of reading
+// every key, the iterator skips to the key closest to the middle sample
+// position, then to the middle of each half, and so on.
+// The keys are approximately uniformly distributed if the keys of the tree
+// are, which makes them suitable to estimate the distribution of the keys
+// or to split the tree in ranges of similar sizes.
+func (t *Tree) Sample(n int) ([]*Key, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+
+	first, err := t.buildFirstKey()
+	if err != nil {
+		return nil, err
+	}
+	it, err := t.Session.Iterator(&engine.IterOptions{
+		LowerBound: first,
+		UpperBound: t.buildLastKey(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer it.Close()
+
+	if !it.Last() {
+		return nil, it.Error()
+	}
+	hi := bytes.Clone(it.Key())
+	it.First()
+	lo := bytes.Clone(it.Key())
+
+	s := sampler{tree: t, it: it}
+	s.keys = append(s.keys, NewEncodedKey(lo))
+	if n > 1 && !bytes.Equal(lo, hi) {
+		err = s.between(lo, hi, n-2, new(int))
+		if err != nil {
+			return nil, err
+		}
+		s.keys = append(s.keys, NewEncodedKey(hi))
+	}
+
+	return s.keys, it.Error()
+}
+
+// sampler samples the keys of an iterator by bisecting the key space.
+type sampler struct {
+	tree *Tree
+	it   engine.Iterator
+	keys []*Key
+}
+
+// between samples up to n keys strictly between lo and hi
+// and adds the number of sampled keys to count.
+func (s *sampler) between(lo, hi []byte, n int, count *int) error {
+	if n <= 0 {
+		return nil
+	}
+
+	// the samples are located at 1/(n+1), 2/(n+1), ... n/(n+1)
+	// of the key space, the middle one is searched first
+	left := (n - 1) / 2
+	mid, err := s.tree.interpolateKey(lo, hi, left+1, n+1)
+	if err != nil {
+		return err
+	}
+	if mid == nil {
+		// the keys can't be bisected, read them
+		for ok := s.it.SeekGE(lo); ok && n > 0; ok = s.it.Next() {
+			k := s.it.Key()
+			if encoding.Compare(k, hi) >= 0 {
+				break
+			}
+			if bytes.Equal(k, lo) {
+				continue
+			}
+			s.keys = append(s.keys, NewEncodedKey(bytes.Clone(k)))
+			*count++
+			n--
+		}
+		return nil
+	}
+
+	// find the key closest to the middle, after or before it
+	if !s.it.SeekGE(mid) || encoding.Compare(s.it.Key(), hi) >= 0 {
+		if !s.it.Prev() {
+			return nil
+		}
+	}
+	k := s.it.Key()
+	if encoding.Compare(k, lo) <= 0 {
+		// no key between lo and hi
+		return nil
+	}
+	m := bytes.Clone(k)
+
+	// the samples not found on the left side are taken on the right side
+	var found int
+	err = s.between(lo, m, left, &found)
+	if err != nil {
+		return err
+	}
+	s.keys = append(s.keys, NewEncodedKey(m))
+	*count += found + 1
+
+	return s.between(m, hi, n-1-found, count)
+}
+
+// interpolateKey returns a key located between lo and hi, at num/den
+// of the distance between them, or nil if there is no such key.
+func (t *Tree) interpolateKey(lo, hi []byte, num, den int) ([]byte, error) {
+	a, err := NewEncodedKey(lo).Decode()
+	if err != nil {
+		return nil, err
+	}
+	b, err := NewEncodedKey(hi).Decode()
+	if err != nil {
+		return nil, err
+	}
+
+	// interpolate the first value that differs
+	i := 0
+	for i < len(a) && i < len(b) {
+		ea, err := types.EncodeValueAsKey(nil, a[i], false)
+		if err != nil {
+			return nil, err
+		}
+		eb, err := types.EncodeValueAsKey(nil, b[i], false)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(ea, eb) {
+			break
+		}
+		i++
+	}
+	if i == len(a) || i == len(b) {
+		return nil, nil
+	}
+
+	v := interpolateValue(a[i], b[i], num, den)
+	if v == nil {
+		return nil, nil
+	}
+
+	values := append(a[:i:i], v)
+	k, err := NewKey(values...).Encode(t.Namespace, t.Order)
+	if err != nil {
+		return nil, err
+	}
+	if encoding.Compare(lo, k) >= 0 || encoding.Compare(k, hi) >= 0 {
+		return nil, nil
+	}
+
+	return k, nil
+}
+
+// interpolateValue returns the value located at num/den of the distance
+// between a and b, or nil if the values can't be interpolated.
+func interpolateValue(a, b types.Value, num, den int) types.Value {
+	switch {
+	case a.Type().IsInteger() && b.Type().IsInteger():
+		x := interpolate(integerPosition(a), integerPosition(b), num, den)
+		if !x.IsInt64() {
+			return types.NewUnsignedBigintValue(x.Uint64())
+		}
+		return types.NewBigintValue(x.Int64())
+	case a.Type() == types.TypeDouble && b.Type() == types.TypeDouble:
+		x, y := types.AsFloat64(a), types.AsFloat64(b)
+		return types.NewDoubleValue(x + (y/float64(den)-x/float64(den))*float64(num))
+	case a.Type() == types.TypeText && b.Type() == types.TypeText:
+		return types.NewTextValue(string(interpolateBytes([]byte(types.AsString(a)), []byte(types.AsString(b)), num, den)))
+	case a.Type() == types.TypeBlob && b.Type() == types.TypeBlob:
+		return types.NewBlobValue(interpolateBytes(types.AsByteSlice(a), types.AsByteSlice(b), num, den))
+	}
+
+	return nil
+}
+
+func integerPosition(v types.Value) *big.Int {
+	if v.Type() == types.TypeUnsignedBigint {
+		return new(big.Int).SetUint64(types.AsUint64(v))
+	}
+
+	return big.NewInt(types.AsInt64(v))
+}
+
+// interpolateBytes interpolates two byte strings,
+// on a few bytes after their common prefix.
+func interpolateBytes(a, b []byte, num, den int) []byte {
+	p := 0
+	for p < len(a) && p < len(b) && a[p] == b[p] {
+		p++
+	}
+
+	size := min(max(len(a), len(b))-p, 16)
+	x := interpolate(bytesPosition(a[p:], size), bytesPosition(b[p:], size), num, den)
+
+	return append(bytes.Clone(a[:p]), x.FillBytes(make([]byte, size))...)
+}
+
+// interpolate returns x + (y - x) * num / den.
+func interpolate(x, y *big.Int, num, den int) *big.Int {
+	d := new(big.Int).Sub(y, x)
+	d.Mul(d, big.NewInt(int64(num)))
+	d.Quo(d, big.NewInt(int64(den)))
+	return d.Add(d, x)
+}
+
+// bytesPosition returns the first size bytes of b as a big endian integer.
+// Missing bytes are considered to be zero.
+func bytesPosition(b []byte, size int) *big.Int {
+	buf := make([]byte, size)
+	copy(buf, b)
+	return new(big.Int).SetBytes(buf)
+}
+
+// buildBoundaries returns the lower and upper bounds
+// of the encoded keys that are in the given range.
+func (t *Tree) buildBoundaries(rng *Range) (start []byte, end []byte, err error) {
+	var min, max *Key
+	desc := t.isDescRange(rng)
+	if !desc {
+		min, max = rng.Min, rng.Max
+	} else {
+		min, max = rng.Max, rng.Min
+	}
+
+	if !rng.Exclusive {
+		return t.buildInclusiveBoundaries(min, max, desc)
+	}
+
+	return t.buildExclusiveBoundaries(min, max, desc)
+}
+
 func (t *Tree) isDescRange(rng *Range) bool {
 	if rng.Min != nil {
 		return t.Order.IsDesc(len(rng.Min.values) - 1)
@@ -294,11 +618,7 @@ func (t *Tree) buildMinKeyForType(max *Key, desc bool) ([]byte, error) {
 
 	if len(max.values) == 1 {
 		buf := encoding.EncodeInt(nil, int64(t.Namespace))
-		if desc {
-			return append(buf, max.values[0].Type().MinEnctypeDesc()), nil
-		}
-
-		return append(buf, max.values[0].Type().MinEnctype()), nil
+		return append(buf, t.minEnctype(max.values[0], 0, desc)), nil
 	}
 
 	buf, err := NewKey(max.values[:len(max.values)-1]...).Encode(t.Namespace, t.Order)
@@ -306,11 +626,7 @@ func (t *Tree) buildMinKeyForType(max *Key, desc bool) ([]byte, error) {
 		return nil, err
 	}
 	i := len(max.values) - 1
-	if desc {
-		return append(buf, max.values[i].Type().MinEnctypeDesc()), nil
-	}
-
-	return append(buf, max.values[i].Type().MinEnctype()), nil
+	return append(buf, t.minEnctype(max.values[i], i, desc)), nil
 }
 
 func (t *Tree) buildMaxKeyForType(min *Key, desc bool) ([]byte, error) {
@@ -320,10 +636,7 @@ func (t *Tree) buildMaxKeyForType(min *Key, desc bool) ([]byte, error) {
 
 	if len(min.values) == 1 {
 		buf := encoding.EncodeInt(nil, int64(t.Namespace))
-		if desc {
-			return append(buf, min.values[0].Type().MaxEnctypeDesc()), nil
-		}
-		return append(buf, min.values[0].Type().MaxEnctype()), nil
+		return append(buf, t.maxEnctype(min.values[0], 0, desc)), nil
 	}
 
 	buf, err := NewKey(min.values[:len(min.values)-1]...).Encode(t.Namespace, t.Order)
@@ -331,11 +644,34 @@ func (t *Tree) buildMaxKeyForType(min *Key, desc bool) ([]byte, error) {
 		return nil, err
 	}
 	i := len(min.values) - 1
+	return append(buf, t.maxEnctype(min.values[i], i, desc)), nil
+}
+
+// minEnctype returns the smallest encoded type of the i-th value of a key.
+func (t *Tree) minEnctype(v types.Value, i int, desc bool) byte {
+	if v.Type() == types.TypeNull && !t.Order.HasDefaultNulls(i) {
+		if desc {
+			return encoding.DESC_NullLastValue
+		}
+		return encoding.NullLastValue
+	}
+
 	if desc {
-		return append(buf, min.values[i].Type().MaxEnctypeDesc()), nil
+		return v.Type().MinEnctypeDesc()
+	}
+	return v.Type().MinEnctype()
+}
+
+// maxEnctype returns the encoded type following the largest encoded type of the i-th value of a key.
+func (t *Tree) maxEnctype(v types.Value, i int, desc bool) byte {
+	if v.Type() == types.TypeNull && !t.Order.HasDefaultNulls(i) {
+		return t.minEnctype(v, i, desc) + 1
 	}
 
-	return append(buf, min.values[i].Type().MaxEnctype()), nil
+	if desc {
+		return v.Type().MaxEnctypeDesc()
+	}
+	return v.Type().MaxEnctype()
 }
 
 func (t *Tree) buildLastKey() []byte {
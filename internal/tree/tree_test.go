@@ -113,6 +113,64 @@ func TestTreeTruncate(t *testing.T) {
 	})
 }
 
+func TestTreeDeleteRange(t *testing.T) {
+	tests := []struct {
+		name      string
+		min, max  *tree.Key
+		exclusive bool
+		order     tree.SortOrder
+		want      []int32
+	}{
+		{"nil", nil, nil, false, nil, nil},
+		{">= 3", tree.NewKey(types.NewIntegerValue(3)), nil, false, nil, []int32{0, 1, 2}},
+		{"> 3", tree.NewKey(types.NewIntegerValue(3)), nil, true, nil, []int32{0, 1, 2, 3}},
+		{"<= 2", nil, tree.NewKey(types.NewIntegerValue(2)), false, nil, []int32{3, 4}},
+		{"1 < x < 4", tree.NewKey(types.NewIntegerValue(1)), tree.NewKey(types.NewIntegerValue(4)), true, nil, []int32{0, 1, 4}},
+		{"= 2", tree.NewKey(types.NewIntegerValue(2)), tree.NewKey(types.NewIntegerValue(2)), false, nil, []int32{0, 1, 3, 4}},
+		{">= 3 desc", tree.NewKey(types.NewIntegerValue(3)), nil, false, tree.SortOrder(nil).SetDesc(0), []int32{2, 1, 0}},
+		{"< 2 desc", nil, tree.NewKey(types.NewIntegerValue(2)), true, tree.SortOrder(nil).SetDesc(0), []int32{4, 3, 2}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tr := testutil.NewTestTree(t, 10)
+			tr.Order = test.order
+
+			for i := int32(0); i < 5; i++ {
+				err := tr.Put(tree.NewKey(types.NewIntegerValue(i)), []byte{1})
+				require.NoError(t, err)
+			}
+
+			// keys of other namespaces must not be deleted
+			other := tree.New(tr.Session, 11, nil)
+			err := other.Put(tree.NewKey(types.NewIntegerValue(0)), []byte{1})
+			require.NoError(t, err)
+
+			var rng *tree.Range
+			if test.min != nil || test.max != nil {
+				rng = &tree.Range{Min: test.min, Max: test.max, Exclusive: test.exclusive}
+			}
+
+			err = tr.DeleteRange(rng)
+			require.NoError(t, err)
+
+			var got []int32
+			err = tr.IterateOnRange(nil, false, func(k *tree.Key, _ []byte) error {
+				vs, err := k.Decode()
+				require.NoError(t, err)
+				got = append(got, types.AsInt32(vs[0]))
+				return nil
+			})
+			require.NoError(t, err)
+			require.Equal(t, test.want, got)
+
+			ok, err := other.Exists(tree.NewKey(types.NewIntegerValue(0)))
+			require.NoError(t, err)
+			require.True(t, ok)
+		})
+	}
+}
+
 func TestTreeIterateOnRange(t *testing.T) {
 	var keys []*tree.Key
 
@@ -181,116 +239,116 @@ func TestTreeIterateOnRange(t *testing.T) {
 			order     tree.SortOrder
 		}{
 			// all
-			{"all", nil, nil, false, 0, 1400, 0},
+			{"all", nil, nil, false, 0, 1400, nil},
 
 			// arity: 1
-			{"= 3", tree.NewKey(types.NewIntegerValue(3)), tree.NewKey(types.NewIntegerValue(3)), false, 400, 500, 0},
-			{">= 3", tree.NewKey(types.NewIntegerValue(3)), nil, false, 400, 1100, 0},
-			{"> 3", tree.NewKey(types.NewIntegerValue(3)), nil, true, 500, 1100, 0},
-			{"<= 3", nil, tree.NewKey(types.NewIntegerValue(3)), false, 100, 500, 0},
-			{"< 3", nil, tree.NewKey(types.NewIntegerValue(3)), true, 100, 400, 0},
-			{">= 3 AND <= 7", tree.NewKey(types.NewIntegerValue(3)), tree.NewKey(types.NewIntegerValue(7)), false, 400, 900, 0},
-			{"> 3 AND < 7", tree.NewKey(types.NewIntegerValue(3)), tree.NewKey(types.NewIntegerValue(7)), true, 500, 800, 0},
+			{"= 3", tree.NewKey(types.NewIntegerValue(3)), tree.NewKey(types.NewIntegerValue(3)), false, 400, 500, nil},
+			{">= 3", tree.NewKey(types.NewIntegerValue(3)), nil, false, 400, 1100, nil},
+			{"> 3", tree.NewKey(types.NewIntegerValue(3)), nil, true, 500, 1100, nil},
+			{"<= 3", nil, tree.NewKey(types.NewIntegerValue(3)), false, 100, 500, nil},
+			{"< 3", nil, tree.NewKey(types.NewIntegerValue(3)), true, 100, 400, nil},
+			{">= 3 AND <= 7", tree.NewKey(types.NewIntegerValue(3)), tree.NewKey(types.NewIntegerValue(7)), false, 400, 900, nil},
+			{"> 3 AND < 7", tree.NewKey(types.NewIntegerValue(3)), tree.NewKey(types.NewIntegerValue(7)), true, 500, 800, nil},
 
 			// arity 1, order desc
-			{"= 3 desc", tree.NewKey(types.NewIntegerValue(3)), tree.NewKey(types.NewIntegerValue(3)), false, 900, 1000, tree.SortOrder(0).SetDesc(0)},
-			{">= 3 desc", tree.NewKey(types.NewIntegerValue(3)), nil, false, 300, 1000, tree.SortOrder(0).SetDesc(0)},
-			{"> 3 desc", tree.NewKey(types.NewIntegerValue(3)), nil, true, 300, 900, tree.SortOrder(0).SetDesc(0)},
-			{"<= 3 desc", nil, tree.NewKey(types.NewIntegerValue(3)), false, 900, 1300, tree.SortOrder(0).SetDesc(0)},
-			{"= 12 desc", tree.NewKey(types.NewIntegerValue(12)), tree.NewKey(types.NewIntegerValue(12)), false, 0, 0, tree.SortOrder(0).SetDesc(0)},
+			{"= 3 desc", tree.NewKey(types.NewIntegerValue(3)), tree.NewKey(types.NewIntegerValue(3)), false, 900, 1000, tree.SortOrder(nil).SetDesc(0)},
+			{">= 3 desc", tree.NewKey(types.NewIntegerValue(3)), nil, false, 300, 1000, tree.SortOrder(nil).SetDesc(0)},
+			{"> 3 desc", tree.NewKey(types.NewIntegerValue(3)), nil, true, 300, 900, tree.SortOrder(nil).SetDesc(0)},
+			{"<= 3 desc", nil, tree.NewKey(types.NewIntegerValue(3)), false, 900, 1300, tree.SortOrder(nil).SetDesc(0)},
+			{"= 12 desc", tree.NewKey(types.NewIntegerValue(12)), tree.NewKey(types.NewIntegerValue(12)), false, 0, 0, tree.SortOrder(nil).SetDesc(0)},
 
 			// arity 2
-			{"= 3 AND = foo1", tree.NewKey(types.NewIntegerValue(3), types.NewTextValue("foo1")), tree.NewKey(types.NewIntegerValue(3), types.NewTextValue("foo1")), false, 410, 420, 0},
-			{"= 3 AND >= foo1", tree.NewKey(types.NewIntegerValue(3), types.NewTextValue("foo1")), nil, false, 410, 500, 0},
-			{"= 3 AND > foo1", tree.NewKey(types.NewIntegerValue(3), types.NewTextValue("foo1")), nil, true, 420, 500, 0},
-			{"= 3 AND <= foo1", nil, tree.NewKey(types.NewIntegerValue(3), types.NewTextValue("foo1")), false, 400, 420, 0},
-			{"= 3 AND < foo1", nil, tree.NewKey(types.NewIntegerValue(3), types.NewTextValue("foo1")), true, 400, 410, 0},
-			{"= 3 AND >= foo1 AND <= foo3", tree.NewKey(types.NewIntegerValue(3), types.NewTextValue("foo1")), tree.NewKey(types.NewIntegerValue(3), types.NewTextValue("foo3")), false, 410, 440, 0},
+			{"= 3 AND = foo1", tree.NewKey(types.NewIntegerValue(3), types.NewTextValue("foo1")), tree.NewKey(types.NewIntegerValue(3), types.NewTextValue("foo1")), false, 410, 420, nil},
+			{"= 3 AND >= foo1", tree.NewKey(types.NewIntegerValue(3), types.NewTextValue("foo1")), nil, false, 410, 500, nil},
+			{"= 3 AND > foo1", tree.NewKey(types.NewIntegerValue(3), types.NewTextValue("foo1")), nil, true, 420, 500, nil},
+			{"= 3 AND <= foo1", nil, tree.NewKey(types.NewIntegerValue(3), types.NewTextValue("foo1")), false, 400, 420, nil},
+			{"= 3 AND < foo1", nil, tree.NewKey(types.NewIntegerValue(3), types.NewTextValue("foo1")), true, 400, 410, nil},
+			{"= 3 AND >= foo1 AND <= foo3", tree.NewKey(types.NewIntegerValue(3), types.NewTextValue("foo1")), tree.NewKey(types.NewIntegerValue(3), types.NewTextValue("foo3")), false, 410, 440, nil},
 
 			// arity 2 desc
-			{"= 3 AND = foo1 desc", tree.NewKey(types.NewIntegerValue(3), types.NewTextValue("foo1")), tree.NewKey(types.NewIntegerValue(3), types.NewTextValue("foo1")), false, 980, 990, tree.SortOrder(0).SetDesc(0).SetDesc(1)},
-			{"= 3 AND >= foo1 desc", tree.NewKey(types.NewIntegerValue(3), types.NewTextValue("foo1")), nil, false, 900, 990, tree.SortOrder(0).SetDesc(0).SetDesc(1)},
-			{"= 3 AND > foo1 desc", tree.NewKey(types.NewIntegerValue(3), types.NewTextValue("foo1")), nil, true, 900, 980, tree.SortOrder(0).SetDesc(0).SetDesc(1)},
-			{"= 3 AND <= foo1 desc", nil, tree.NewKey(types.NewIntegerValue(3), types.NewTextValue("foo1")), false, 980, 1000, tree.SortOrder(0).SetDesc(0).SetDesc(1)},
-			{"= 3 AND < foo1 desc", nil, tree.NewKey(types.NewIntegerValue(3), types.NewTextValue("foo1")), true, 990, 1000, tree.SortOrder(0).SetDesc(0).SetDesc(1)},
-			{"= 3 AND >= foo1 AND <= foo3 desc", tree.NewKey(types.NewIntegerValue(3), types.NewTextValue("foo1")), tree.NewKey(types.NewIntegerValue(3), types.NewTextValue("foo3")), false, 960, 990, tree.SortOrder(0).SetDesc(0).SetDesc(1)},
-			{"= 3 AND > foo1 AND < foo3 desc", tree.NewKey(types.NewIntegerValue(3), types.NewTextValue("foo1")), tree.NewKey(types.NewIntegerValue(3), types.NewTextValue("foo3")), true, 970, 980, tree.SortOrder(0).SetDesc(0).SetDesc(1)},
+			{"= 3 AND = foo1 desc", tree.NewKey(types.NewIntegerValue(3), types.NewTextValue("foo1")), tree.NewKey(types.NewIntegerValue(3), types.NewTextValue("foo1")), false, 980, 990, tree.SortOrder(nil).SetDesc(0).SetDesc(1)},
+			{"= 3 AND >= foo1 desc", tree.NewKey(types.NewIntegerValue(3), types.NewTextValue("foo1")), nil, false, 900, 990, tree.SortOrder(nil).SetDesc(0).SetDesc(1)},
+			{"= 3 AND > foo1 desc", tree.NewKey(types.NewIntegerValue(3), types.NewTextValue("foo1")), nil, true, 900, 980, tree.SortOrder(nil).SetDesc(0).SetDesc(1)},
+			{"= 3 AND <= foo1 desc", nil, tree.NewKey(types.NewIntegerValue(3), types.NewTextValue("foo1")), false, 980, 1000, tree.SortOrder(nil).SetDesc(0).SetDesc(1)},
+			{"= 3 AND < foo1 desc", nil, tree.NewKey(types.NewIntegerValue(3), types.NewTextValue("foo1")), true, 990, 1000, tree.SortOrder(nil).SetDesc(0).SetDesc(1)},
+			{"= 3 AND >= foo1 AND <= foo3 desc", tree.NewKey(types.NewIntegerValue(3), types.NewTextValue("foo1")), tree.NewKey(types.NewIntegerValue(3), types.NewTextValue("foo3")), false, 960, 990, tree.SortOrder(nil).SetDesc(0).SetDesc(1)},
+			{"= 3 AND > foo1 AND < foo3 desc", tree.NewKey(types.NewIntegerValue(3), types.NewTextValue("foo1")), tree.NewKey(types.NewIntegerValue(3), types.NewTextValue("foo3")), true, 970, 980, tree.SortOrder(nil).SetDesc(0).SetDesc(1)},
 
 			// arity 3
-			{"= 3 AND = foo1 AND = 5.0", tree.NewKey(types.NewIntegerValue(3), types.NewTextValue("foo1"), types.NewDoubleValue(5)), tree.NewKey(types.NewIntegerValue(3), types.NewTextValue("foo1"), types.NewDoubleValue(5)), false, 415, 416, 0},
-			{"= 3 AND = foo1 AND >= 5.0", tree.NewKey(types.NewIntegerValue(3), types.NewTextValue("foo1"), types.NewDoubleValue(5)), nil, false, 415, 420, 0},
-			{"= 3 AND = foo1 AND > 5.0", tree.NewKey(types.NewIntegerValue(3), types.NewTextValue("foo1"), types.NewDoubleValue(5)), nil, true, 416, 420, 0},
-			{"= 3 AND = foo1 AND <= 5.0", nil, tree.NewKey(types.NewIntegerValue(3), types.NewTextValue("foo1"), types.NewDoubleValue(5)), false, 410, 416, 0},
-			{"= 3 AND = foo1 AND < 5.0", nil, tree.NewKey(types.NewIntegerValue(3), types.NewTextValue("foo1"), types.NewDoubleValue(5)), true, 410, 415, 0},
+			{"= 3 AND = foo1 AND = 5.0", tree.NewKey(types.NewIntegerValue(3), types.NewTextValue("foo1"), types.NewDoubleValue(5)), tree.NewKey(types.NewIntegerValue(3), types.NewTextValue("foo1"), types.NewDoubleValue(5)), false, 415, 416, nil},
+			{"= 3 AND = foo1 AND >= 5.0", tree.NewKey(types.NewIntegerValue(3), types.NewTextValue("foo1"), types.NewDoubleValue(5)), nil, false, 415, 420, nil},
+			{"= 3 AND = foo1 AND > 5.0", tree.NewKey(types.NewIntegerValue(3), types.NewTextValue("foo1"), types.NewDoubleValue(5)), nil, true, 416, 420, nil},
+			{"= 3 AND = foo1 AND <= 5.0", nil, tree.NewKey(types.NewIntegerValue(3), types.NewTextValue("foo1"), types.NewDoubleValue(5)), false, 410, 416, nil},
+			{"= 3 AND = foo1 AND < 5.0", nil, tree.NewKey(types.NewIntegerValue(3), types.NewTextValue("foo1"), types.NewDoubleValue(5)), true, 410, 415, nil},
 
 			// arity 3 desc
-			{"= 3 AND = foo1 AND = 5.0", tree.NewKey(types.NewIntegerValue(3), types.NewTextValue("foo1"), types.NewDoubleValue(5)), tree.NewKey(types.NewIntegerValue(3), types.NewTextValue("foo1"), types.NewDoubleValue(5)), false, 984, 985, tree.SortOrder(0).SetDesc(0).SetDesc(1).SetDesc(2)},
-			{"= 3 AND = foo1 AND >= 5.0", tree.NewKey(types.NewIntegerValue(3), types.NewTextValue("foo1"), types.NewDoubleValue(5)), nil, false, 980, 985, tree.SortOrder(0).SetDesc(0).SetDesc(1).SetDesc(2)},
-			{"= 3 AND = foo1 AND > 5.0", tree.NewKey(types.NewIntegerValue(3), types.NewTextValue("foo1"), types.NewDoubleValue(5)), nil, true, 980, 984, tree.SortOrder(0).SetDesc(0).SetDesc(1).SetDesc(2)},
-			{"= 3 AND = foo1 AND <= 5.0", nil, tree.NewKey(types.NewIntegerValue(3), types.NewTextValue("foo1"), types.NewDoubleValue(5)), false, 984, 990, tree.SortOrder(0).SetDesc(0).SetDesc(1).SetDesc(2)},
-			{"= 3 AND = foo1 AND < 5.0", nil, tree.NewKey(types.NewIntegerValue(3), types.NewTextValue("foo1"), types.NewDoubleValue(5)), true, 985, 990, tree.SortOrder(0).SetDesc(0).SetDesc(1).SetDesc(2)},
+			{"= 3 AND = foo1 AND = 5.0", tree.NewKey(types.NewIntegerValue(3), types.NewTextValue("foo1"), types.NewDoubleValue(5)), tree.NewKey(types.NewIntegerValue(3), types.NewTextValue("foo1"), types.NewDoubleValue(5)), false, 984, 985, tree.SortOrder(nil).SetDesc(0).SetDesc(1).SetDesc(2)},
+			{"= 3 AND = foo1 AND >= 5.0", tree.NewKey(types.NewIntegerValue(3), types.NewTextValue("foo1"), types.NewDoubleValue(5)), nil, false, 980, 985, tree.SortOrder(nil).SetDesc(0).SetDesc(1).SetDesc(2)},
+			{"= 3 AND = foo1 AND > 5.0", tree.NewKey(types.NewIntegerValue(3), types.NewTextValue("foo1"), types.NewDoubleValue(5)), nil, true, 980, 984, tree.SortOrder(nil).SetDesc(0).SetDesc(1).SetDesc(2)},
+			{"= 3 AND = foo1 AND <= 5.0", nil, tree.NewKey(types.NewIntegerValue(3), types.NewTextValue("foo1"), types.NewDoubleValue(5)), false, 984, 990, tree.SortOrder(nil).SetDesc(0).SetDesc(1).SetDesc(2)},
+			{"= 3 AND = foo1 AND < 5.0", nil, tree.NewKey(types.NewIntegerValue(3), types.NewTextValue("foo1"), types.NewDoubleValue(5)), true, 985, 990, tree.SortOrder(nil).SetDesc(0).SetDesc(1).SetDesc(2)},
 
 			// other types
 
 			// bool
-			{"= false", tree.NewKey(types.NewBooleanValue(false)), tree.NewKey(types.NewBooleanValue(false)), false, 0, 50, 0},
-			{"= true", tree.NewKey(types.NewBooleanValue(true)), tree.NewKey(types.NewBooleanValue(true)), false, 50, 100, 0},
-			{">= false", tree.NewKey(types.NewBooleanValue(false)), nil, false, 0, 100, 0},
-			{"> false", tree.NewKey(types.NewBooleanValue(false)), nil, true, 50, 100, 0},
-			{"<= false", nil, tree.NewKey(types.NewBooleanValue(false)), false, 0, 50, 0},
-			{"< false", nil, tree.NewKey(types.NewBooleanValue(false)), true, 0, 0, 0},
-			{"< true", nil, tree.NewKey(types.NewBooleanValue(true)), true, 0, 50, 0},
+			{"= false", tree.NewKey(types.NewBooleanValue(false)), tree.NewKey(types.NewBooleanValue(false)), false, 0, 50, nil},
+			{"= true", tree.NewKey(types.NewBooleanValue(true)), tree.NewKey(types.NewBooleanValue(true)), false, 50, 100, nil},
+			{">= false", tree.NewKey(types.NewBooleanValue(false)), nil, false, 0, 100, nil},
+			{"> false", tree.NewKey(types.NewBooleanValue(false)), nil, true, 50, 100, nil},
+			{"<= false", nil, tree.NewKey(types.NewBooleanValue(false)), false, 0, 50, nil},
+			{"< false", nil, tree.NewKey(types.NewBooleanValue(false)), true, 0, 0, nil},
+			{"< true", nil, tree.NewKey(types.NewBooleanValue(true)), true, 0, 50, nil},
 
 			// bool desc
-			{"= false desc", tree.NewKey(types.NewBooleanValue(false)), tree.NewKey(types.NewBooleanValue(false)), false, 1350, 1400, tree.SortOrder(0).SetDesc(0)},
-			{"= true desc", tree.NewKey(types.NewBooleanValue(true)), tree.NewKey(types.NewBooleanValue(true)), false, 1300, 1350, tree.SortOrder(0).SetDesc(0)},
-			{">= false desc", tree.NewKey(types.NewBooleanValue(false)), nil, false, 1300, 1400, tree.SortOrder(0).SetDesc(0)},
-			{"> false desc", tree.NewKey(types.NewBooleanValue(false)), nil, true, 1300, 1350, tree.SortOrder(0).SetDesc(0)},
-			{"<= false desc", nil, tree.NewKey(types.NewBooleanValue(false)), false, 1350, 1400, tree.SortOrder(0).SetDesc(0)},
-			{"< false desc", nil, tree.NewKey(types.NewBooleanValue(false)), true, 0, 0, tree.SortOrder(0).SetDesc(0)},
-			{"< true desc", nil, tree.NewKey(types.NewBooleanValue(true)), true, 1350, 1400, tree.SortOrder(0).SetDesc(0)},
+			{"= false desc", tree.NewKey(types.NewBooleanValue(false)), tree.NewKey(types.NewBooleanValue(false)), false, 1350, 1400, tree.SortOrder(nil).SetDesc(0)},
+			{"= true desc", tree.NewKey(types.NewBooleanValue(true)), tree.NewKey(types.NewBooleanValue(true)), false, 1300, 1350, tree.SortOrder(nil).SetDesc(0)},
+			{">= false desc", tree.NewKey(types.NewBooleanValue(false)), nil, false, 1300, 1400, tree.SortOrder(nil).SetDesc(0)},
+			{"> false desc", tree.NewKey(types.NewBooleanValue(false)), nil, true, 1300, 1350, tree.SortOrder(nil).SetDesc(0)},
+			{"<= false desc", nil, tree.NewKey(types.NewBooleanValue(false)), false, 1350, 1400, tree.SortOrder(nil).SetDesc(0)},
+			{"< false desc", nil, tree.NewKey(types.NewBooleanValue(false)), true, 0, 0, tree.SortOrder(nil).SetDesc(0)},
+			{"< true desc", nil, tree.NewKey(types.NewBooleanValue(true)), true, 1350, 1400, tree.SortOrder(nil).SetDesc(0)},
 
 			// double
-			{"= 3.0", tree.NewKey(types.NewDoubleValue(3)), tree.NewKey(types.NewDoubleValue(3)), false, 1130, 1140, 0},
-			{">= 3.0", tree.NewKey(types.NewDoubleValue(3)), nil, false, 1130, 1200, 0},
-			{"> 3.0", tree.NewKey(types.NewDoubleValue(3)), nil, true, 1140, 1200, 0},
-			{"<= 3.0", nil, tree.NewKey(types.NewDoubleValue(3)), false, 1100, 1140, 0},
-			{"< 3.0", nil, tree.NewKey(types.NewDoubleValue(3)), true, 1100, 1130, 0},
+			{"= 3.0", tree.NewKey(types.NewDoubleValue(3)), tree.NewKey(types.NewDoubleValue(3)), false, 1130, 1140, nil},
+			{">= 3.0", tree.NewKey(types.NewDoubleValue(3)), nil, false, 1130, 1200, nil},
+			{"> 3.0", tree.NewKey(types.NewDoubleValue(3)), nil, true, 1140, 1200, nil},
+			{"<= 3.0", nil, tree.NewKey(types.NewDoubleValue(3)), false, 1100, 1140, nil},
+			{"< 3.0", nil, tree.NewKey(types.NewDoubleValue(3)), true, 1100, 1130, nil},
 
 			// double desc
-			{"= 3.0 desc", tree.NewKey(types.NewDoubleValue(3)), tree.NewKey(types.NewDoubleValue(3)), false, 260, 270, tree.SortOrder(0).SetDesc(0)},
-			{">= 3.0 desc", tree.NewKey(types.NewDoubleValue(3)), nil, false, 200, 270, tree.SortOrder(0).SetDesc(0)},
-			{"> 3.0 desc", tree.NewKey(types.NewDoubleValue(3)), nil, true, 200, 260, tree.SortOrder(0).SetDesc(0)},
-			{"<= 3.0 desc", nil, tree.NewKey(types.NewDoubleValue(3)), false, 260, 300, tree.SortOrder(0).SetDesc(0)},
-			{"< 3.0 desc", nil, tree.NewKey(types.NewDoubleValue(3)), true, 270, 300, tree.SortOrder(0).SetDesc(0)},
+			{"= 3.0 desc", tree.NewKey(types.NewDoubleValue(3)), tree.NewKey(types.NewDoubleValue(3)), false, 260, 270, tree.SortOrder(nil).SetDesc(0)},
+			{">= 3.0 desc", tree.NewKey(types.NewDoubleValue(3)), nil, false, 200, 270, tree.SortOrder(nil).SetDesc(0)},
+			{"> 3.0 desc", tree.NewKey(types.NewDoubleValue(3)), nil, true, 200, 260, tree.SortOrder(nil).SetDesc(0)},
+			{"<= 3.0 desc", nil, tree.NewKey(types.NewDoubleValue(3)), false, 260, 300, tree.SortOrder(nil).SetDesc(0)},
+			{"< 3.0 desc", nil, tree.NewKey(types.NewDoubleValue(3)), true, 270, 300, tree.SortOrder(nil).SetDesc(0)},
 
 			// text
-			{"= bar3", tree.NewKey(types.NewTextValue("bar3")), tree.NewKey(types.NewTextValue("bar3")), false, 1230, 1240, 0},
-			{">= bar3", tree.NewKey(types.NewTextValue("bar3")), nil, false, 1230, 1300, 0},
-			{"> bar3", tree.NewKey(types.NewTextValue("bar3")), nil, true, 1240, 1300, 0},
-			{"<= bar3", nil, tree.NewKey(types.NewTextValue("bar3")), false, 1200, 1240, 0},
-			{"< bar3", nil, tree.NewKey(types.NewTextValue("bar3")), true, 1200, 1230, 0},
+			{"= bar3", tree.NewKey(types.NewTextValue("bar3")), tree.NewKey(types.NewTextValue("bar3")), false, 1230, 1240, nil},
+			{">= bar3", tree.NewKey(types.NewTextValue("bar3")), nil, false, 1230, 1300, nil},
+			{"> bar3", tree.NewKey(types.NewTextValue("bar3")), nil, true, 1240, 1300, nil},
+			{"<= bar3", nil, tree.NewKey(types.NewTextValue("bar3")), false, 1200, 1240, nil},
+			{"< bar3", nil, tree.NewKey(types.NewTextValue("bar3")), true, 1200, 1230, nil},
 
 			// text desc
-			{"= bar3 desc", tree.NewKey(types.NewTextValue("bar3")), tree.NewKey(types.NewTextValue("bar3")), false, 160, 170, tree.SortOrder(0).SetDesc(0)},
-			{">= bar3 desc", tree.NewKey(types.NewTextValue("bar3")), nil, false, 100, 170, tree.SortOrder(0).SetDesc(0)},
-			{"> bar3 desc", tree.NewKey(types.NewTextValue("bar3")), nil, true, 100, 160, tree.SortOrder(0).SetDesc(0)},
-			{"<= bar3 desc", nil, tree.NewKey(types.NewTextValue("bar3")), false, 160, 200, tree.SortOrder(0).SetDesc(0)},
-			{"< bar3 desc", nil, tree.NewKey(types.NewTextValue("bar3")), true, 170, 200, tree.SortOrder(0).SetDesc(0)},
+			{"= bar3 desc", tree.NewKey(types.NewTextValue("bar3")), tree.NewKey(types.NewTextValue("bar3")), false, 160, 170, tree.SortOrder(nil).SetDesc(0)},
+			{">= bar3 desc", tree.NewKey(types.NewTextValue("bar3")), nil, false, 100, 170, tree.SortOrder(nil).SetDesc(0)},
+			{"> bar3 desc", tree.NewKey(types.NewTextValue("bar3")), nil, true, 100, 160, tree.SortOrder(nil).SetDesc(0)},
+			{"<= bar3 desc", nil, tree.NewKey(types.NewTextValue("bar3")), false, 160, 200, tree.SortOrder(nil).SetDesc(0)},
+			{"< bar3 desc", nil, tree.NewKey(types.NewTextValue("bar3")), true, 170, 200, tree.SortOrder(nil).SetDesc(0)},
 
 			// blob
-			{"= bar3", tree.NewKey(types.NewBlobValue([]byte("bar3"))), tree.NewKey(types.NewBlobValue([]byte("bar3"))), false, 1330, 1340, 0},
-			{">= bar3", tree.NewKey(types.NewBlobValue([]byte("bar3"))), nil, false, 1330, 1400, 0},
-			{"> bar3", tree.NewKey(types.NewBlobValue([]byte("bar3"))), nil, true, 1340, 1400, 0},
-			{"<= bar3", nil, tree.NewKey(types.NewBlobValue([]byte("bar3"))), false, 1300, 1340, 0},
-			{"< bar3", nil, tree.NewKey(types.NewBlobValue([]byte("bar3"))), true, 1300, 1330, 0},
+			{"= bar3", tree.NewKey(types.NewBlobValue([]byte("bar3"))), tree.NewKey(types.NewBlobValue([]byte("bar3"))), false, 1330, 1340, nil},
+			{">= bar3", tree.NewKey(types.NewBlobValue([]byte("bar3"))), nil, false, 1330, 1400, nil},
+			{"> bar3", tree.NewKey(types.NewBlobValue([]byte("bar3"))), nil, true, 1340, 1400, nil},
+			{"<= bar3", nil, tree.NewKey(types.NewBlobValue([]byte("bar3"))), false, 1300, 1340, nil},
+			{"< bar3", nil, tree.NewKey(types.NewBlobValue([]byte("bar3"))), true, 1300, 1330, nil},
 
 			// blob desc
-			{"= bar3 desc", tree.NewKey(types.NewBlobValue([]byte("bar3"))), tree.NewKey(types.NewBlobValue([]byte("bar3"))), false, 60, 70, tree.SortOrder(0).SetDesc(0)},
-			{">= bar3 desc", tree.NewKey(types.NewBlobValue([]byte("bar3"))), nil, false, 0, 70, tree.SortOrder(0).SetDesc(0)},
-			{"> bar3 desc", tree.NewKey(types.NewBlobValue([]byte("bar3"))), nil, true, 0, 60, tree.SortOrder(0).SetDesc(0)},
-			{"<= bar3 desc", nil, tree.NewKey(types.NewBlobValue([]byte("bar3"))), false, 60, 100, tree.SortOrder(0).SetDesc(0)},
-			{"< bar3 desc", nil, tree.NewKey(types.NewBlobValue([]byte("bar3"))), true, 70, 100, tree.SortOrder(0).SetDesc(0)},
+			{"= bar3 desc", tree.NewKey(types.NewBlobValue([]byte("bar3"))), tree.NewKey(types.NewBlobValue([]byte("bar3"))), false, 60, 70, tree.SortOrder(nil).SetDesc(0)},
+			{">= bar3 desc", tree.NewKey(types.NewBlobValue([]byte("bar3"))), nil, false, 0, 70, tree.SortOrder(nil).SetDesc(0)},
+			{"> bar3 desc", tree.NewKey(types.NewBlobValue([]byte("bar3"))), nil, true, 0, 60, tree.SortOrder(nil).SetDesc(0)},
+			{"<= bar3 desc", nil, tree.NewKey(types.NewBlobValue([]byte("bar3"))), false, 60, 100, tree.SortOrder(nil).SetDesc(0)},
+			{"< bar3 desc", nil, tree.NewKey(types.NewBlobValue([]byte("bar3"))), true, 70, 100, tree.SortOrder(nil).SetDesc(0)},
 		}
 
 		for _, test := range tests {
@@ -344,3 +402,180 @@ func TestTreeIterateOnRange(t *testing.T) {
 		}
 	}
 }
+
+func TestTreeSample(t *testing.T) {
+	t.Run("empty", func(t *testing.T) {
+		tr := testutil.NewTestTree(t, 10)
+
+		keys, err := tr.Sample(10)
+		require.NoError(t, err)
+		require.Empty(t, keys)
+	})
+
+	tr := testutil.NewTestTree(t, 10)
+	for i := 0; i < 10_000; i++ {
+		err := tr.Put(tree.NewKey(types.NewBigintValue(int64(i)*1_000_000)), []byte{1})
+		require.NoError(t, err)
+	}
+	// keys of other trees are ignored
+	other := tree.New(tr.Session, 11, nil)
+	require.NoError(t, other.Put(tree.NewKey(types.NewBigintValue(0)), []byte{1}))
+
+	t.Run("uniform", func(t *testing.T) {
+		keys, err := tr.Sample(10)
+		require.NoError(t, err)
+		require.Len(t, keys, 10)
+
+		for i, k := range keys {
+			vs, err := k.Decode()
+			require.NoError(t, err)
+			// the sampled keys are spread across the tree
+			require.InDelta(t, int64(i)*9_999_000_000/9, types.AsInt64(vs[0]), 50_000_000)
+		}
+	})
+
+	t.Run("composite text keys", func(t *testing.T) {
+		tr := testutil.NewTestTree(t, 10)
+		for i := 0; i < 1000; i++ {
+			err := tr.Put(tree.NewKey(types.NewBooleanValue(i%2 == 0), types.NewTextValue(fmt.Sprintf("key-%04d", i))), []byte{1})
+			require.NoError(t, err)
+		}
+
+		keys, err := tr.Sample(20)
+		require.NoError(t, err)
+		require.Len(t, keys, 20)
+		for i := 1; i < len(keys); i++ {
+			require.Equal(t, 1, encoding.Compare(keys[i].Encoded, keys[i-1].Encoded))
+		}
+	})
+
+	t.Run("more samples than keys", func(t *testing.T) {
+		tr := testutil.NewTestTree(t, 10)
+		for i := 0; i < 3; i++ {
+			err := tr.Put(tree.NewKey(types.NewBigintValue(int64(i))), []byte{1})
+			require.NoError(t, err)
+		}
+
+		keys, err := tr.Sample(10)
+		require.NoError(t, err)
+		require.LessOrEqual(t, len(keys), 3)
+		vs, err := keys[0].Decode()
+		require.NoError(t, err)
+		require.Equal(t, int64(0), types.AsInt64(vs[0]))
+		for i := 1; i < len(keys); i++ {
+			require.Equal(t, 1, encoding.Compare(keys[i].Encoded, keys[i-1].Encoded))
+		}
+	})
+}
+
+func TestSortOrder(t *testing.T) {
+	var o tree.SortOrder
+	require.False(t, o.IsDesc(0))
+	require.False(t, o.IsDesc(1000))
+
+	o1 := o.SetDesc(1).SetDesc(100)
+	require.True(t, o1.IsDesc(1))
+	require.True(t, o1.IsDesc(100))
+	require.False(t, o1.IsDesc(0))
+	require.False(t, o1.IsDesc(64))
+	require.False(t, o1.IsDesc(200))
+
+	// sort orders are never modified
+	o2 := o1.SetAsc(100)
+	require.True(t, o1.IsDesc(100))
+	require.False(t, o2.IsDesc(100))
+	require.True(t, o2.IsDesc(1))
+	require.Equal(t, o1.SetAsc(100), o2)
+	require.Nil(t, o2.SetAsc(1))
+
+	// NULLs are first in ascending order and last in descending order
+	require.True(t, o.IsNullsFirst(0))
+	require.True(t, o.HasDefaultNulls(0))
+	require.False(t, o1.IsNullsFirst(1))
+	require.True(t, o1.HasDefaultNulls(1))
+
+	o3 := o1.SetNullsLast(0).SetNullsFirst(1)
+	require.False(t, o3.IsNullsFirst(0))
+	require.False(t, o3.HasDefaultNulls(0))
+	require.True(t, o3.IsNullsFirst(1))
+	require.False(t, o3.HasDefaultNulls(1))
+	require.False(t, o3.IsDesc(0))
+	require.True(t, o3.IsDesc(1))
+	require.True(t, o1.HasDefaultNulls(0))
+	require.Equal(t, o1, o3.SetNullsFirst(0).SetNullsLast(1))
+
+	t.Run("nulls", func(t *testing.T) {
+		tests := []struct {
+			name  string
+			order tree.SortOrder
+			want  []string
+		}{
+			{"asc", nil, []string{"NULL", "1", "2"}},
+			{"asc nulls last", tree.SortOrder(nil).SetNullsLast(0), []string{"1", "2", "NULL"}},
+			{"desc", tree.SortOrder(nil).SetDesc(0), []string{"2", "1", "NULL"}},
+			{"desc nulls first", tree.SortOrder(nil).SetDesc(0).SetNullsFirst(0), []string{"NULL", "2", "1"}},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				tr := testutil.NewTestTree(t, 10)
+				tr.Order = test.order
+
+				for _, v := range []types.Value{types.NewIntegerValue(2), types.NewNullValue(), types.NewIntegerValue(1)} {
+					err := tr.Put(tree.NewKey(v, types.NewIntegerValue(0)), []byte{1})
+					require.NoError(t, err)
+				}
+
+				var got []string
+				err := tr.IterateOnRange(nil, false, func(k *tree.Key, _ []byte) error {
+					vs, err := k.Decode()
+					require.NoError(t, err)
+					got = append(got, vs[0].String())
+					return nil
+				})
+				require.NoError(t, err)
+				require.Equal(t, test.want, got)
+
+				// ranges on integers skip NULLs
+				got = got[:0]
+				rng := tree.Range{Min: tree.NewKey(types.NewIntegerValue(1))}
+				err = tr.IterateOnRange(&rng, false, func(k *tree.Key, _ []byte) error {
+					vs, err := k.Decode()
+					require.NoError(t, err)
+					got = append(got, vs[0].String())
+					return nil
+				})
+				require.NoError(t, err)
+				require.ElementsMatch(t, []string{"1", "2"}, got)
+			})
+		}
+	})
+
+	t.Run("wide keys", func(t *testing.T) {
+		// the last of 70 values is sorted in descending order
+		order := tree.SortOrder(nil).SetDesc(69)
+		tr := testutil.NewTestTree(t, 10)
+		tr.Order = order
+
+		values := make([]types.Value, 70)
+		for i := range values {
+			values[i] = types.NewIntegerValue(0)
+		}
+		for i := 0; i < 5; i++ {
+			values[69] = types.NewIntegerValue(int32(i))
+			err := tr.Put(tree.NewKey(values...), []byte{1})
+			require.NoError(t, err)
+		}
+
+		var got []int32
+		err := tr.IterateOnRange(nil, false, func(k *tree.Key, _ []byte) error {
+			vs, err := k.Decode()
+			require.NoError(t, err)
+			require.Len(t, vs, 70)
+			got = append(got, types.AsInt32(vs[69]))
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, []int32{4, 3, 2, 1, 0}, got)
+	})
+}
@@ -0,0 +1,203 @@
+package types
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/cockroachdb/errors"
+)
+
+var _ TypeDefinition = ArrayTypeDef{}
+
+type ArrayTypeDef struct{}
+
+func (ArrayTypeDef) New(v any) Value {
+	return NewArrayValue(v.([]byte))
+}
+
+func (ArrayTypeDef) Type() Type {
+	return TypeArray
+}
+
+func (ArrayTypeDef) Decode(src []byte) (Value, int) {
+	n := encoding.Skip(src)
+	return NewArrayValue(src[:n]), n
+}
+
+func (ArrayTypeDef) IsComparableWith(other Type) bool {
+	return other == TypeArray
+}
+
+func (ArrayTypeDef) IsIndexComparableWith(other Type) bool {
+	return other == TypeArray
+}
+
+var _ Value = NewArrayValue(nil)
+
+// ArrayValue is an array stored in a binary form, which can be
+// traversed without being decoded. Its elements keep their SQL type.
+// See MakeArray.
+type ArrayValue []byte
+
+// NewArrayValue returns a SQL ARRAY value from its binary form.
+func NewArrayValue(enc []byte) ArrayValue {
+	return ArrayValue(enc)
+}
+
+// MakeArray returns an array made of the given values.
+// See MakeObject for the types of the values.
+func MakeArray(values ...Value) (ArrayValue, error) {
+	var err error
+
+	dst := encoding.EncodeArrayHeader(nil, len(values))
+	for i, v := range values {
+		dst, err = encodeNestedValue(dst, v)
+		if err != nil {
+			return nil, errors.Wrapf(err, "element %d", i)
+		}
+	}
+
+	return NewArrayValue(dst), nil
+}
+
+func (v ArrayValue) V() any {
+	return []byte(v)
+}
+
+func (v ArrayValue) Type() Type {
+	return TypeArray
+}
+
+func (v ArrayValue) TypeDef() TypeDefinition {
+	return ArrayTypeDef{}
+}
+
+func (v ArrayValue) IsZero() (bool, error) {
+	return v == nil, nil
+}
+
+// Len returns the number of elements of the array.
+func (v ArrayValue) Len() int {
+	l, _ := encoding.DecodeHeader(v)
+	return l
+}
+
+// Get returns the element at the given index,
+// and false if the index is out of range.
+func (v ArrayValue) Get(idx int) (Value, bool) {
+	if idx < 0 {
+		return nil, false
+	}
+
+	enc, ok := extractJSONElement(v, idx)
+	if !ok {
+		return nil, false
+	}
+
+	return decodeNestedValue(enc), true
+}
+
+// Iterate calls fn for each element of the array, in order.
+func (v ArrayValue) Iterate(fn func(i int, value Value) error) error {
+	l, n := encoding.DecodeHeader(v)
+	for i := 0; i < l; i++ {
+		enc := v[n:]
+		n += encoding.Skip(enc)
+		err := fn(i, decodeNestedValue(enc))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// String returns the array using the syntax of array literals.
+func (v ArrayValue) String() string {
+	var sb strings.Builder
+
+	sb.WriteByte('[')
+	_ = v.Iterate(func(i int, value Value) error {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(value.String())
+		return nil
+	})
+	sb.WriteByte(']')
+
+	return sb.String()
+}
+
+func (v ArrayValue) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+func (v ArrayValue) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+
+	buf.WriteByte('[')
+	err := v.Iterate(func(i int, value Value) error {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		data, err := value.MarshalJSON()
+		if err != nil {
+			return err
+		}
+		buf.Write(data)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	buf.WriteByte(']')
+
+	return buf.Bytes(), nil
+}
+
+func (v ArrayValue) Encode(dst []byte) ([]byte, error) {
+	return append(dst, v...), nil
+}
+
+func (v ArrayValue) EncodeAsKey(dst []byte) ([]byte, error) {
+	return v.Encode(dst)
+}
+
+func (v ArrayValue) CastAs(target Type) (Value, error) {
+	switch target {
+	case TypeArray:
+		return v, nil
+	case TypeText, TypeJSON:
+		return castNestedAs(v, target)
+	}
+
+	return nil, errors.Errorf("cannot cast %s as %s", v.Type(), target)
+}
+
+func (v ArrayValue) EQ(other Value) (bool, error) {
+	return compareNested(v, other) == 0, nil
+}
+
+func (v ArrayValue) GT(other Value) (bool, error) {
+	return compareNested(v, other) > 0, nil
+}
+
+func (v ArrayValue) GTE(other Value) (bool, error) {
+	return compareNested(v, other) >= 0, nil
+}
+
+func (v ArrayValue) LT(other Value) (bool, error) {
+	c := compareNested(v, other)
+	return c < 0 && c != incomparable, nil
+}
+
+func (v ArrayValue) LTE(other Value) (bool, error) {
+	c := compareNested(v, other)
+	return c <= 0 && c != incomparable, nil
+}
+
+func (v ArrayValue) Between(a, b Value) (bool, error) {
+	return betweenNested(v, a, b)
+}
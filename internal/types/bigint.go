@@ -2,6 +2,7 @@ package types
 
 import (
 	"math"
+	"math/big"
 	"strconv"
 
 	"github.com/chaisql/chai/internal/encoding"
@@ -26,7 +27,7 @@ func (BigintTypeDef) Decode(src []byte) (Value, int) {
 }
 
 func (BigintTypeDef) IsComparableWith(other Type) bool {
-	return other == TypeBigint || other == TypeInteger || other == TypeDouble
+	return other == TypeBigint || other == TypeInteger || other == TypeUnsignedBigint || other == TypeDouble || other == TypeReal
 }
 
 func (BigintTypeDef) IsIndexComparableWith(other Type) bool {
@@ -89,8 +90,15 @@ func (v BigintValue) CastAs(target Type) (Value, error) {
 			return nil, errors.Errorf("integer out of range")
 		}
 		return NewIntegerValue(int32(v)), nil
+	case TypeUnsignedBigint:
+		if v < 0 {
+			return nil, errors.New("bigint unsigned out of range")
+		}
+		return NewUnsignedBigintValue(uint64(v)), nil
 	case TypeDouble:
 		return NewDoubleValue(float64(v)), nil
+	case TypeReal:
+		return NewRealValue(float32(v)), nil
 	case TypeText:
 		return NewTextValue(v.String()), nil
 	}
@@ -103,8 +111,10 @@ func (v BigintValue) EQ(other Value) (bool, error) {
 	switch t {
 	case TypeBigint, TypeInteger:
 		return int64(v) == AsInt64(other), nil
-	case TypeDouble:
+	case TypeDouble, TypeReal:
 		return float64(int64(v)) == AsFloat64(other), nil
+	case TypeUnsignedBigint:
+		return other.EQ(v)
 	default:
 		return false, nil
 	}
@@ -115,8 +125,10 @@ func (v BigintValue) GT(other Value) (bool, error) {
 	switch t {
 	case TypeBigint, TypeInteger:
 		return int64(v) > AsInt64(other), nil
-	case TypeDouble:
+	case TypeDouble, TypeReal:
 		return float64(int64(v)) > AsFloat64(other), nil
+	case TypeUnsignedBigint:
+		return other.LT(v)
 	default:
 		return false, nil
 	}
@@ -127,8 +139,10 @@ func (v BigintValue) GTE(other Value) (bool, error) {
 	switch t {
 	case TypeBigint, TypeInteger:
 		return int64(v) >= AsInt64(other), nil
-	case TypeDouble:
+	case TypeDouble, TypeReal:
 		return float64(int64(v)) >= AsFloat64(other), nil
+	case TypeUnsignedBigint:
+		return other.LTE(v)
 	default:
 		return false, nil
 	}
@@ -139,8 +153,10 @@ func (v BigintValue) LT(other Value) (bool, error) {
 	switch t {
 	case TypeBigint, TypeInteger:
 		return int64(v) < AsInt64(other), nil
-	case TypeDouble:
+	case TypeDouble, TypeReal:
 		return float64(int64(v)) <= AsFloat64(other), nil
+	case TypeUnsignedBigint:
+		return other.GT(v)
 	default:
 		return false, nil
 	}
@@ -151,8 +167,10 @@ func (v BigintValue) LTE(other Value) (bool, error) {
 	switch t {
 	case TypeBigint, TypeInteger:
 		return int64(v) <= AsInt64(other), nil
-	case TypeDouble:
+	case TypeDouble, TypeReal:
 		return float64(int64(v)) <= AsFloat64(other), nil
+	case TypeUnsignedBigint:
+		return other.GTE(v)
 	default:
 		return false, nil
 	}
@@ -181,7 +199,9 @@ func (v BigintValue) Add(other Numeric) (Value, error) {
 		}
 		xr := xa + xb
 		return NewBigintValue(xr), nil
-	case TypeDouble:
+	case TypeUnsignedBigint:
+		return unsignedArithmetic(v, other, (*big.Int).Add)
+	case TypeDouble, TypeReal:
 		return NewDoubleValue(float64(int64(v)) + AsFloat64(other)), nil
 	}
 
@@ -198,7 +218,9 @@ func (v BigintValue) Sub(other Numeric) (Value, error) {
 		}
 		xr := xa - xb
 		return NewBigintValue(xr), nil
-	case TypeDouble:
+	case TypeUnsignedBigint:
+		return unsignedArithmetic(v, other, (*big.Int).Sub)
+	case TypeDouble, TypeReal:
 		return NewDoubleValue(float64(int64(v)) - AsFloat64(other)), nil
 	}
 
@@ -218,7 +240,9 @@ func (v BigintValue) Mul(other Numeric) (Value, error) {
 		}
 		xr := xa * xb
 		return NewBigintValue(xr), nil
-	case TypeDouble:
+	case TypeUnsignedBigint:
+		return unsignedArithmetic(v, other, (*big.Int).Mul)
+	case TypeDouble, TypeReal:
 		return NewDoubleValue(float64(int64(v)) * AsFloat64(other)), nil
 	}
 
@@ -235,7 +259,13 @@ func (v BigintValue) Div(other Numeric) (Value, error) {
 		}
 
 		return NewBigintValue(xa / xb), nil
-	case TypeDouble:
+	case TypeUnsignedBigint:
+		if AsUint64(other) == 0 {
+			return NewNullValue(), nil
+		}
+
+		return unsignedArithmetic(v, other, (*big.Int).Quo)
+	case TypeDouble, TypeReal:
 		xa := float64(AsInt64(v))
 		xb := AsFloat64(other)
 		if xb == 0 {
@@ -258,7 +288,13 @@ func (v BigintValue) Mod(other Numeric) (Value, error) {
 		}
 
 		return NewBigintValue(xa % xb), nil
-	case TypeDouble:
+	case TypeUnsignedBigint:
+		if AsUint64(other) == 0 {
+			return NewNullValue(), nil
+		}
+
+		return unsignedArithmetic(v, other, (*big.Int).Rem)
+	case TypeDouble, TypeReal:
 		xa := float64(AsInt64(v))
 		xb := AsFloat64(other)
 		mod := math.Mod(xa, xb)
@@ -276,7 +312,9 @@ func (v BigintValue) BitwiseAnd(other Numeric) (Value, error) {
 	switch other.Type() {
 	case TypeBigint, TypeInteger:
 		return NewBigintValue(int64(v) & AsInt64(other)), nil
-	case TypeDouble:
+	case TypeUnsignedBigint:
+		return NewUnsignedBigintValue(uint64(v) & AsUint64(other)), nil
+	case TypeDouble, TypeReal:
 		xa := int64(v)
 		xb := int64(AsFloat64(other))
 		return NewBigintValue(xa & xb), nil
@@ -289,7 +327,9 @@ func (v BigintValue) BitwiseOr(other Numeric) (Value, error) {
 	switch other.Type() {
 	case TypeBigint, TypeInteger:
 		return NewBigintValue(int64(v) | AsInt64(other)), nil
-	case TypeDouble:
+	case TypeUnsignedBigint:
+		return NewUnsignedBigintValue(uint64(v) | AsUint64(other)), nil
+	case TypeDouble, TypeReal:
 		xa := int64(v)
 		xb := int64(AsFloat64(other))
 		return NewBigintValue(xa | xb), nil
@@ -302,7 +342,9 @@ func (v BigintValue) BitwiseXor(other Numeric) (Value, error) {
 	switch other.Type() {
 	case TypeBigint, TypeInteger:
 		return NewBigintValue(int64(v) ^ AsInt64(other)), nil
-	case TypeDouble:
+	case TypeUnsignedBigint:
+		return NewUnsignedBigintValue(uint64(v) ^ AsUint64(other)), nil
+	case TypeDouble, TypeReal:
 		xa := int64(v)
 		xb := int64(AsFloat64(other))
 		return NewBigintValue(xa ^ xb), nil
@@ -310,3 +352,21 @@ func (v BigintValue) BitwiseXor(other Numeric) (Value, error) {
 
 	return NewNullValue(), nil
 }
+
+func (v BigintValue) ShiftLeft(other Numeric) (Value, error) {
+	n, err := shiftCount(other)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewBigintValue(int64(v) << n), nil
+}
+
+func (v BigintValue) ShiftRight(other Numeric) (Value, error) {
+	n, err := shiftCount(other)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewBigintValue(int64(v) >> n), nil
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/base64"
 	"encoding/hex"
+	"io"
 
 	"github.com/chaisql/chai/internal/encoding"
 	"github.com/cockroachdb/errors"
@@ -36,6 +37,18 @@ func (BlobTypeDef) IsIndexComparableWith(other Type) bool {
 
 var _ Value = NewBlobValue(nil)
 
+// A BlobStream is a BLOB value whose content is stored outside of its row.
+// The content is only loaded in memory when the value is used,
+// Open can be used instead to read it one chunk at a time.
+type BlobStream interface {
+	Value
+
+	// Open returns a reader streaming the content of the blob.
+	Open() (io.Reader, error)
+	// Load reads the whole content of the blob.
+	Load() (BlobValue, error)
+}
+
 type BlobValue []byte
 
 // NewBlobValue returns a SQL BLOB value.
@@ -94,6 +107,11 @@ func (v BlobValue) CastAs(target Type) (Value, error) {
 		return v, nil
 	case TypeText:
 		return NewTextValue(base64.StdEncoding.EncodeToString([]byte(v))), nil
+	case TypeUUID:
+		if len(v) != 16 {
+			return nil, errors.Errorf("cannot cast blob of %d bytes as uuid", len(v))
+		}
+		return NewUUIDValue([16]byte(v)), nil
 	}
 
 	return nil, errors.Errorf("cannot cast %s as %s", v.Type(), target)
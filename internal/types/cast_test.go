@@ -68,6 +68,44 @@ func TestCastAs(t *testing.T) {
 		})
 	})
 
+	t.Run("bigint unsigned", func(t *testing.T) {
+		check(t, types.TypeUnsignedBigint, []test{
+			{boolV, nil, true},
+			{integerV, types.NewUnsignedBigintValue(10), false},
+			{types.NewIntegerValue(-1), nil, true},
+			{types.NewBigintValue(math.MaxInt64), types.NewUnsignedBigintValue(math.MaxInt64), false},
+			{doubleV, types.NewUnsignedBigintValue(10), false},
+			{types.NewDoubleValue(-1), nil, true},
+			{types.NewDoubleValue(math.MaxUint64), nil, true},
+			{textV, nil, true},
+			{types.NewTextValue("18446744073709551615"), types.NewUnsignedBigintValue(math.MaxUint64), false},
+			{types.NewTextValue("10.5"), types.NewUnsignedBigintValue(10), false},
+			{types.NewTextValue("-1"), nil, true},
+			{blobV, nil, true},
+		})
+
+		// unsigned integers don't always fit in signed integers
+		_, err := types.NewUnsignedBigintValue(math.MaxInt64 + 1).CastAs(types.TypeBigint)
+		require.Error(t, err)
+	})
+
+	t.Run("real", func(t *testing.T) {
+		check(t, types.TypeReal, []test{
+			{boolV, nil, true},
+			{integerV, types.NewRealValue(10), false},
+			{doubleV, types.NewRealValue(10.5), false},
+			{types.NewDoubleValue(math.MaxFloat64), nil, true},
+			{types.NewTextValue("10.5"), types.NewRealValue(10.5), false},
+			{textV, nil, true},
+			{blobV, nil, true},
+		})
+
+		// single precision is kept when casting back
+		v, err := types.NewRealValue(0.1).CastAs(types.TypeText)
+		require.NoError(t, err)
+		require.Equal(t, types.NewTextValue("0.1"), v)
+	})
+
 	t.Run("double", func(t *testing.T) {
 		check(t, types.TypeDouble, []test{
 			{boolV, nil, true},
@@ -86,6 +124,17 @@ func TestCastAs(t *testing.T) {
 			{integerV, nil, true},
 			{doubleV, nil, true},
 			{types.NewTextValue(now.Format(time.RFC3339Nano)), tsV, false},
+			{types.NewTimestampTZValue(now), tsV, false},
+			{blobV, nil, true},
+		})
+	})
+
+	t.Run("timestamptz", func(t *testing.T) {
+		check(t, types.TypeTimestampTZ, []test{
+			{boolV, nil, true},
+			{integerV, nil, true},
+			{tsV, types.NewTimestampTZValue(now.UTC()), false},
+			{types.NewTextValue(now.Format(time.RFC3339Nano)), types.NewTimestampTZValue(now.UTC()), false},
 			{blobV, nil, true},
 		})
 	})
@@ -100,6 +149,20 @@ func TestCastAs(t *testing.T) {
 		})
 	})
 
+	t.Run("uuid", func(t *testing.T) {
+		uuidV := types.NewUUIDValue([16]byte{0xf8, 0x1d, 0x4f, 0xae, 0x7d, 0xec, 0x11, 0xd0, 0xa7, 0x65, 0x00, 0xa0, 0xc9, 0x1e, 0x6b, 0xf6})
+
+		check(t, types.TypeUUID, []test{
+			{boolV, nil, true},
+			{integerV, nil, true},
+			{types.NewTextValue("f81d4fae-7dec-11d0-a765-00a0c91e6bf6"), uuidV, false},
+			{textV, nil, true},
+			{types.NewBlobValue(uuidV[:]), uuidV, false},
+			{blobV, nil, true},
+			{uuidV, uuidV, false},
+		})
+	})
+
 	t.Run("blob", func(t *testing.T) {
 		check(t, types.TypeBlob, []test{
 			{boolV, nil, true},
@@ -111,3 +174,31 @@ func TestCastAs(t *testing.T) {
 		})
 	})
 }
+
+func TestCastIn(t *testing.T) {
+	loc := time.FixedZone("", 2*3600)
+	ts := time.Date(2024, 1, 15, 8, 0, 0, 0, time.UTC)
+
+	// texts without time zone are parsed in loc
+	v, err := types.CastIn(types.NewTextValue("2024-01-15 10:00:00"), types.TypeTimestampTZ, loc)
+	require.NoError(t, err)
+	require.True(t, types.AsTime(v).Equal(ts))
+
+	v, err = types.CastIn(types.NewTextValue("2024-01-15T10:00:00Z"), types.TypeTimestampTZ, loc)
+	require.NoError(t, err)
+	require.True(t, types.AsTime(v).Equal(ts.Add(2*time.Hour)))
+
+	// and formatted in loc
+	v, err = types.CastIn(types.NewTimestampTZValue(ts), types.TypeText, loc)
+	require.NoError(t, err)
+	require.Equal(t, `"2024-01-15T10:00:00+02:00"`, types.AsString(v))
+
+	// other conversions are not affected
+	v, err = types.CastIn(types.NewTextValue("2024-01-15 10:00:00"), types.TypeTimestamp, loc)
+	require.NoError(t, err)
+	require.Equal(t, types.NewTimestampValue(ts.Add(2*time.Hour)), v)
+
+	v, err = types.CastIn(types.NewTextValue("2024-01-15 10:00:00"), types.TypeTimestampTZ, nil)
+	require.NoError(t, err)
+	require.True(t, types.AsTime(v).Equal(ts.Add(2*time.Hour)))
+}
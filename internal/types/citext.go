@@ -0,0 +1,155 @@
+package types
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/chaisql/chai/internal/encoding"
+)
+
+var _ TypeDefinition = CitextTypeDef{}
+
+type CitextTypeDef struct{}
+
+func (CitextTypeDef) New(v any) Value {
+	return NewCitextValue(v.(string))
+}
+
+func (CitextTypeDef) Type() Type {
+	return TypeCitext
+}
+
+func (CitextTypeDef) Decode(src []byte) (Value, int) {
+	x, n := encoding.DecodeText(src)
+	return NewCitextValue(x), n
+}
+
+func (CitextTypeDef) IsComparableWith(other Type) bool {
+	return other == TypeNull || other.IsText()
+}
+
+func (CitextTypeDef) IsIndexComparableWith(other Type) bool {
+	return other.IsText()
+}
+
+var _ Value = NewCitextValue("")
+
+// CitextValue is a text compared without regard to case.
+// Its original case is preserved, but its key encoding uses
+// its case-folded form, which makes indexes and unique constraints
+// case-insensitive.
+type CitextValue string
+
+// NewCitextValue returns a SQL CITEXT value.
+func NewCitextValue(x string) CitextValue {
+	return CitextValue(x)
+}
+
+func (v CitextValue) V() any {
+	return string(v)
+}
+
+func (v CitextValue) Type() Type {
+	return TypeCitext
+}
+
+func (v CitextValue) TypeDef() TypeDefinition {
+	return CitextTypeDef{}
+}
+
+func (v CitextValue) IsZero() (bool, error) {
+	return v == "", nil
+}
+
+func (v CitextValue) String() string {
+	return strconv.Quote(string(v))
+}
+
+func (v CitextValue) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+func (v CitextValue) MarshalJSON() ([]byte, error) {
+	return v.MarshalText()
+}
+
+func (v CitextValue) Encode(dst []byte) ([]byte, error) {
+	return encoding.EncodeText(dst, string(v)), nil
+}
+
+// EncodeAsKey encodes the case-folded form of the text,
+// so that texts that only differ by their case have the same key.
+func (v CitextValue) EncodeAsKey(dst []byte) ([]byte, error) {
+	return encoding.EncodeText(dst, FoldCase(string(v))), nil
+}
+
+func (v CitextValue) CastAs(target Type) (Value, error) {
+	switch target {
+	case TypeCitext:
+		return v, nil
+	case TypeText:
+		return NewTextValue(string(v)), nil
+	}
+
+	// the other conversions are those of texts
+	return NewTextValue(string(v)).CastAs(target)
+}
+
+// compare compares the case-folded forms of v and other,
+// and returns false if other is not a text.
+func (v CitextValue) compare(other Value) (int, bool) {
+	if !other.Type().IsText() {
+		return 0, false
+	}
+
+	return strings.Compare(FoldCase(string(v)), FoldCase(AsString(other))), true
+}
+
+func (v CitextValue) EQ(other Value) (bool, error) {
+	c, ok := v.compare(other)
+	return ok && c == 0, nil
+}
+
+func (v CitextValue) GT(other Value) (bool, error) {
+	c, ok := v.compare(other)
+	return ok && c > 0, nil
+}
+
+func (v CitextValue) GTE(other Value) (bool, error) {
+	c, ok := v.compare(other)
+	return ok && c >= 0, nil
+}
+
+func (v CitextValue) LT(other Value) (bool, error) {
+	c, ok := v.compare(other)
+	return ok && c < 0, nil
+}
+
+func (v CitextValue) LTE(other Value) (bool, error) {
+	c, ok := v.compare(other)
+	return ok && c <= 0, nil
+}
+
+func (v CitextValue) Between(a, b Value) (bool, error) {
+	if !a.Type().IsText() || !b.Type().IsText() {
+		return false, nil
+	}
+
+	ok, err := v.GTE(a)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	return v.LTE(b)
+}
+
+// FoldCase returns the case-folded form of s, used to compare
+// CITEXT values: texts that only differ by their case, such as "Foo"
+// and "FOO", have the same folded form. Letters are folded one by one,
+// using their simple case mappings, which means that "ß" and "SS" differ.
+func FoldCase(s string) string {
+	return strings.Map(func(r rune) rune {
+		return unicode.ToLower(unicode.ToUpper(r))
+	}, s)
+}
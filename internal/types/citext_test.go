@@ -0,0 +1,54 @@
+package types_test
+
+import (
+	"testing"
+
+	"github.com/chaisql/chai/internal/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCitextValue(t *testing.T) {
+	a := types.NewCitextValue("Alice@Example.com")
+	b := types.NewCitextValue("alice@example.COM")
+
+	ok, err := a.EQ(b)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	ok, err = a.EQ(types.NewTextValue("ALICE@EXAMPLE.COM"))
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	ok, err = types.NewCitextValue("b").GT(types.NewCitextValue("A"))
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// the key encoding is case-insensitive
+	ka, err := a.EncodeAsKey(nil)
+	require.NoError(t, err)
+	kb, err := b.EncodeAsKey(nil)
+	require.NoError(t, err)
+	require.Equal(t, ka, kb)
+
+	// the row encoding keeps the case
+	enc, err := a.Encode(nil)
+	require.NoError(t, err)
+	v, _ := types.TypeCitext.Def().Decode(enc)
+	require.Equal(t, "Alice@Example.com", types.AsString(v))
+	require.Equal(t, types.TypeCitext, v.Type())
+}
+
+func TestFoldCase(t *testing.T) {
+	tests := []struct {
+		a, b string
+	}{
+		{"Hello", "hELLO"},
+		{"ÉCOLE", "école"},
+		{"Σίσυφος", "ΣΊΣΥΦΟΣ"},
+		{"K", "\u212a"}, // Kelvin sign
+	}
+
+	for _, test := range tests {
+		require.Equal(t, types.FoldCase(test.a), types.FoldCase(test.b))
+	}
+}
@@ -0,0 +1,101 @@
+package types
+
+import (
+	"time"
+
+	"github.com/cockroachdb/errors"
+)
+
+// Coercion controls the conversions applied to compare values of different types.
+//
+// In both modes, values of the same type are compared directly, as well as
+// numbers, texts, timestamps and network addresses among themselves.
+// String literals have no type of their own: a string literal compared with
+// a column or a literal of another type is converted to that type when the
+// query is planned, and the query fails if it is not a valid value of that type.
+type Coercion uint8
+
+const (
+	// CoercionLenient converts the other values compared with one another.
+	// A literal compared with a column is converted to the type of the column
+	// when the query is planned, and a text compared with a boolean, a number,
+	// a timestamp, a UUID, a network address or a point is converted to the type
+	// of the other value when the query runs. Values that can't be converted,
+	// and values of types that can't be compared, are neither equal nor ordered.
+	CoercionLenient Coercion = iota
+	// CoercionStrict rejects the comparisons of values of different types:
+	// when the query is planned if the types are known, such as for
+	// columns and literals, and otherwise when it runs.
+	CoercionStrict
+)
+
+func (c Coercion) String() string {
+	if c == CoercionStrict {
+		return "strict"
+	}
+
+	return "lenient"
+}
+
+// AreComparable returns whether values of types a and b are compared
+// without being converted: they are of the same type, numbers, texts,
+// timestamps or network addresses, or one of them is NULL.
+func AreComparable(a, b Type) bool {
+	switch {
+	case a == b, a == TypeNull, b == TypeNull:
+		return true
+	case a.IsNumber() && b.IsNumber():
+		return true
+	case a.IsText() && b.IsText():
+		return true
+	case a.IsTimestamp() && b.IsTimestamp():
+		return true
+	case isNetwork(a) && isNetwork(b):
+		return true
+	}
+
+	return false
+}
+
+func isNetwork(t Type) bool {
+	return t == TypeInet || t == TypeCidr
+}
+
+// isCoercibleFromText returns whether a text compared with
+// a value of type t is converted to t in lenient mode.
+func isCoercibleFromText(t Type) bool {
+	switch t {
+	case TypeBoolean, TypeTimestamp, TypeTimestampTZ, TypeUUID, TypeInet, TypeCidr, TypePoint, TypeInterval:
+		return true
+	}
+
+	return t.IsNumber()
+}
+
+// Coerce converts the values a and b, compared with one another, following
+// the rules of the coercion mode. Texts converted to TIMESTAMPTZ values are
+// parsed in loc. It returns false if the values can't be compared, or an error
+// in strict mode.
+func (c Coercion) Coerce(a, b Value, loc *time.Location) (Value, Value, bool, error) {
+	at, bt := a.Type(), b.Type()
+	if AreComparable(at, bt) {
+		return a, b, true, nil
+	}
+
+	if c == CoercionStrict {
+		return nil, nil, false, errors.Errorf("cannot compare %s with %s", at, bt)
+	}
+
+	switch {
+	case at.IsText() && isCoercibleFromText(bt):
+		if x, err := CastIn(a, bt, loc); err == nil {
+			return x, b, true, nil
+		}
+	case bt.IsText() && isCoercibleFromText(at):
+		if x, err := CastIn(b, at, loc); err == nil {
+			return a, x, true, nil
+		}
+	}
+
+	return a, b, false, nil
+}
@@ -0,0 +1,40 @@
+package types_test
+
+import (
+	"testing"
+
+	"github.com/chaisql/chai/internal/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCoerce(t *testing.T) {
+	tests := []struct {
+		a, b types.Value
+		ok   bool
+		want types.Type
+	}{
+		{types.NewIntegerValue(1), types.NewDoubleValue(1), true, types.TypeInteger},
+		{types.NewTextValue("a"), types.NewCitextValue("A"), true, types.TypeText},
+		{types.NewTextValue("10"), types.NewIntegerValue(1), true, types.TypeInteger},
+		{types.NewTextValue("true"), types.NewBooleanValue(true), true, types.TypeBoolean},
+		{types.NewTextValue("foo"), types.NewIntegerValue(1), false, types.TypeText},
+		{types.NewBooleanValue(true), types.NewIntegerValue(1), false, types.TypeBoolean},
+		{types.NewTextValue("a"), types.NewBlobValue([]byte("a")), false, types.TypeText},
+	}
+
+	for _, test := range tests {
+		a, b, ok, err := types.CoercionLenient.Coerce(test.a, test.b, nil)
+		require.NoError(t, err)
+		require.Equal(t, test.ok, ok, "%s and %s", test.a, test.b)
+		require.Equal(t, test.want, a.Type())
+		require.Equal(t, test.b, b)
+
+		_, _, ok, err = types.CoercionStrict.Coerce(test.a, test.b, nil)
+		if types.AreComparable(test.a.Type(), test.b.Type()) {
+			require.NoError(t, err)
+			require.True(t, ok)
+		} else {
+			require.EqualError(t, err, "cannot compare "+test.a.Type().String()+" with "+test.b.Type().String())
+		}
+	}
+}
@@ -3,6 +3,7 @@ package types_test
 import (
 	"encoding/json"
 	"fmt"
+	"math"
 	"testing"
 	"time"
 
@@ -235,6 +236,10 @@ func TestCompareValues(t *testing.T) {
 		return types.NewTimestampValue(tm)
 	}
 
+	ubigint := func(x uint64) types.Value {
+		return types.NewUnsignedBigintValue(x)
+	}
+
 	tests := []struct {
 		op   string
 		a, b types.Value
@@ -249,6 +254,33 @@ func TestCompareValues(t *testing.T) {
 		{"=", text("2021-01-01T12:05:59.123456+02:00"), ts(carbon.Parse("2021-01-01 10:05:59.123456", "UTC").ToStdTime()), true},
 		{"=", text("2021-01-01T12:05:59.123456+02:00"), ts(carbon.Parse("2021-01-01T12:05:59.123456+02:00", "UTC").ToStdTime()), true},
 		{"=", text("2021-01-01 10:05:59.123456"), ts(carbon.Parse("2021-01-01T12:05:59.123456+02:00", "UTC").ToStdTime()), true},
+
+		// unsigned with signed integers
+		{"=", ubigint(10), types.NewIntegerValue(10), true},
+		{"=", types.NewBigintValue(-1), ubigint(math.MaxUint64), false},
+		{">", ubigint(math.MaxUint64), types.NewBigintValue(math.MaxInt64), true},
+		{">", ubigint(0), types.NewBigintValue(-1), true},
+		{"<", types.NewIntegerValue(-1), ubigint(0), true},
+		{"<=", types.NewBigintValue(math.MaxInt64), ubigint(math.MaxInt64 + 1), true},
+		{">=", types.NewDoubleValue(1.5), ubigint(1), true},
+
+		// real with doubles, in single precision
+		{"=", types.NewRealValue(0.1), types.NewDoubleValue(0.1), true},
+		{"=", types.NewDoubleValue(0.1), types.NewRealValue(0.1), true},
+		{"<", types.NewRealValue(0.1), types.NewDoubleValue(0.2), true},
+		{">", types.NewDoubleValue(0.2), types.NewRealValue(0.1), true},
+
+		// real with integers
+		{"=", types.NewRealValue(2), types.NewIntegerValue(2), true},
+		{"<", types.NewIntegerValue(2), types.NewRealValue(2.5), true},
+		{">", types.NewRealValue(2.5), types.NewBigintValue(2), true},
+
+		// NaN is equal to itself and lower than the other numbers, as in the keys
+		{"=", types.NewDoubleValue(math.NaN()), types.NewDoubleValue(math.NaN()), true},
+		{"<", types.NewDoubleValue(math.NaN()), types.NewDoubleValue(math.Inf(-1)), true},
+		{"<", types.NewDoubleValue(math.NaN()), types.NewIntegerValue(-1), true},
+		{">", types.NewDoubleValue(math.Inf(1)), types.NewDoubleValue(math.MaxFloat64), true},
+		{"=", types.NewDoubleValue(math.Copysign(0, -1)), types.NewDoubleValue(0), true},
 	}
 
 	for _, test := range tests {
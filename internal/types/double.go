@@ -1,6 +1,7 @@
 package types
 
 import (
+	"cmp"
 	"math"
 	"strconv"
 
@@ -26,7 +27,7 @@ func (DoubleTypeDef) Decode(src []byte) (Value, int) {
 }
 
 func (DoubleTypeDef) IsComparableWith(other Type) bool {
-	return other == TypeDouble || other == TypeInteger || other == TypeBigint
+	return other == TypeDouble || other == TypeReal || other == TypeInteger || other == TypeBigint || other == TypeUnsignedBigint
 }
 
 func (DoubleTypeDef) IsIndexComparableWith(other Type) bool {
@@ -122,6 +123,18 @@ func (v DoubleValue) CastAs(target Type) (Value, error) {
 			return nil, errors.New("integer out of range")
 		}
 		return NewBigintValue(int64(v)), nil
+	case TypeReal:
+		f := float64(v)
+		if !math.IsInf(f, 0) && math.Abs(f) > math.MaxFloat32 {
+			return nil, errors.New("real out of range")
+		}
+		return NewRealValue(float32(v)), nil
+	case TypeUnsignedBigint:
+		f := float64(v)
+		if f < 0 || f >= math.MaxUint64 {
+			return nil, errors.New("bigint unsigned out of range")
+		}
+		return NewUnsignedBigintValue(uint64(v)), nil
 	case TypeText:
 		enc, err := v.MarshalJSON()
 		if err != nil {
@@ -133,64 +146,46 @@ func (v DoubleValue) CastAs(target Type) (Value, error) {
 	return nil, errors.Errorf("cannot cast %s as %s", v.Type(), target)
 }
 
-func (v DoubleValue) EQ(other Value) (bool, error) {
-	t := other.Type()
-	switch t {
+// compare returns -1, 0 or 1 depending on whether v is lower than,
+// equal to or greater than other. It returns false if other is not a number.
+// As with the other numbers, NaN is equal to itself and lower than
+// all the other numbers, which is the order of the keys, see encoding.EncodeFloat64.
+func (v DoubleValue) compare(other Value) (int, bool) {
+	switch other.Type() {
 	case TypeDouble:
-		return float64(v) == AsFloat64(other), nil
-	case TypeInteger, TypeBigint:
-		return float64(v) == float64(AsInt64(other)), nil
-	default:
-		return false, nil
+		return cmp.Compare(float64(v), AsFloat64(other)), true
+	case TypeReal:
+		return cmp.Compare(float32(v), AsFloat32(other)), true
+	case TypeInteger, TypeBigint, TypeUnsignedBigint:
+		return cmp.Compare(float64(v), numericAsFloat64(other)), true
 	}
+
+	return 0, false
+}
+
+func (v DoubleValue) EQ(other Value) (bool, error) {
+	c, ok := v.compare(other)
+	return ok && c == 0, nil
 }
 
 func (v DoubleValue) GT(other Value) (bool, error) {
-	t := other.Type()
-	switch t {
-	case TypeDouble:
-		return float64(v) > AsFloat64(other), nil
-	case TypeInteger, TypeBigint:
-		return float64(v) > float64(AsInt64(other)), nil
-	default:
-		return false, nil
-	}
+	c, ok := v.compare(other)
+	return ok && c > 0, nil
 }
 
 func (v DoubleValue) GTE(other Value) (bool, error) {
-	t := other.Type()
-	switch t {
-	case TypeDouble:
-		return float64(v) >= AsFloat64(other), nil
-	case TypeInteger, TypeBigint:
-		return float64(v) >= float64(AsInt64(other)), nil
-	default:
-		return false, nil
-	}
+	c, ok := v.compare(other)
+	return ok && c >= 0, nil
 }
 
 func (v DoubleValue) LT(other Value) (bool, error) {
-	t := other.Type()
-	switch t {
-	case TypeDouble:
-		return float64(v) < AsFloat64(other), nil
-	case TypeInteger, TypeBigint:
-		return float64(v) < float64(AsInt64(other)), nil
-	default:
-		return false, nil
-	}
+	c, ok := v.compare(other)
+	return ok && c < 0, nil
 }
 
 func (v DoubleValue) LTE(other Value) (bool, error) {
-	t := other.Type()
-	switch t {
-	case TypeDouble:
-		return float64(v) <= AsFloat64(other), nil
-	case TypeInteger, TypeBigint:
-		return float64(v) <= float64(AsInt64(other)), nil
-	default:
-		return false, nil
-	}
+	c, ok := v.compare(other)
+	return ok && c <= 0, nil
 }
 
 func (v DoubleValue) Between(a, b Value) (bool, error) {
@@ -210,8 +205,10 @@ func (v DoubleValue) Add(other Numeric) (Value, error) {
 	switch other.Type() {
 	case TypeInteger, TypeBigint:
 		return NewDoubleValue(float64(v) + float64(AsInt64(other))), nil
-	case TypeDouble:
+	case TypeDouble, TypeReal:
 		return NewDoubleValue(float64(v) + AsFloat64(other)), nil
+	case TypeUnsignedBigint:
+		return NewDoubleValue(float64(v) + float64(AsUint64(other))), nil
 	}
 
 	return NewNullValue(), nil
@@ -221,8 +218,10 @@ func (v DoubleValue) Sub(other Numeric) (Value, error) {
 	switch other.Type() {
 	case TypeInteger, TypeBigint:
 		return NewDoubleValue(float64(v) - float64(AsInt64(other))), nil
-	case TypeDouble:
+	case TypeDouble, TypeReal:
 		return NewDoubleValue(float64(v) - AsFloat64(other)), nil
+	case TypeUnsignedBigint:
+		return NewDoubleValue(float64(v) - float64(AsUint64(other))), nil
 	}
 
 	return NewNullValue(), nil
@@ -232,8 +231,10 @@ func (v DoubleValue) Mul(other Numeric) (Value, error) {
 	switch other.Type() {
 	case TypeInteger, TypeBigint:
 		return NewDoubleValue(float64(v) * float64(AsInt64(other))), nil
-	case TypeDouble:
+	case TypeDouble, TypeReal:
 		return NewDoubleValue(float64(v) * AsFloat64(other)), nil
+	case TypeUnsignedBigint:
+		return NewDoubleValue(float64(v) * float64(AsUint64(other))), nil
 	}
 
 	return NewNullValue(), nil
@@ -248,12 +249,19 @@ func (v DoubleValue) Div(other Numeric) (Value, error) {
 		}
 
 		return NewDoubleValue(float64(v) / xb), nil
-	case TypeDouble:
+	case TypeDouble, TypeReal:
 		xb := AsFloat64(other)
 		if xb == 0 {
 			return NewNullValue(), nil
 		}
 
+		return NewDoubleValue(float64(v) / xb), nil
+	case TypeUnsignedBigint:
+		xb := float64(AsUint64(other))
+		if xb == 0 {
+			return NewNullValue(), nil
+		}
+
 		return NewDoubleValue(float64(v) / xb), nil
 	}
 
@@ -270,13 +278,20 @@ func (v DoubleValue) Mod(other Numeric) (Value, error) {
 		}
 
 		return NewDoubleValue(xr), nil
-	case TypeDouble:
+	case TypeDouble, TypeReal:
 		xb := AsFloat64(other)
 		xr := math.Mod(float64(v), xb)
 		if math.IsNaN(xr) {
 			return NewNullValue(), nil
 		}
 
+		return NewDoubleValue(xr), nil
+	case TypeUnsignedBigint:
+		xr := math.Mod(float64(v), float64(AsUint64(other)))
+		if math.IsNaN(xr) {
+			return NewNullValue(), nil
+		}
+
 		return NewDoubleValue(xr), nil
 	}
 
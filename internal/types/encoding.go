@@ -1,24 +1,36 @@
 package types
 
 import (
+	"math"
+
 	"github.com/chaisql/chai/internal/encoding"
 )
 
 var encodedTypeToTypeDefs = map[byte]TypeDefinition{
-	encoding.NullValue:    NullTypeDef{},
-	encoding.FalseValue:   BooleanTypeDef{},
-	encoding.TrueValue:    BooleanTypeDef{},
-	encoding.Int8Value:    IntegerTypeDef{},
-	encoding.Int16Value:   IntegerTypeDef{},
-	encoding.Int32Value:   IntegerTypeDef{},
-	encoding.Int64Value:   BigintTypeDef{},
-	encoding.Uint8Value:   IntegerTypeDef{},
-	encoding.Uint16Value:  IntegerTypeDef{},
-	encoding.Uint32Value:  IntegerTypeDef{},
-	encoding.Uint64Value:  BigintTypeDef{},
-	encoding.Float64Value: DoubleTypeDef{},
-	encoding.TextValue:    TextTypeDef{},
-	encoding.BlobValue:    BlobTypeDef{},
+	encoding.NullValue:     NullTypeDef{},
+	encoding.NullLastValue: NullTypeDef{},
+	encoding.FalseValue:    BooleanTypeDef{},
+	encoding.TrueValue:     BooleanTypeDef{},
+	encoding.Int8Value:     IntegerTypeDef{},
+	encoding.Int16Value:    IntegerTypeDef{},
+	encoding.Int32Value:    IntegerTypeDef{},
+	encoding.Int64Value:    BigintTypeDef{},
+	encoding.Uint8Value:    IntegerTypeDef{},
+	encoding.Uint16Value:   IntegerTypeDef{},
+	encoding.Uint32Value:   IntegerTypeDef{},
+	encoding.Uint64Value:   BigintTypeDef{},
+	encoding.Float64Value:  DoubleTypeDef{},
+	encoding.Float32Value:  RealTypeDef{},
+	encoding.TextValue:     TextTypeDef{},
+	encoding.BlobValue:     BlobTypeDef{},
+	encoding.UUIDValue:     UUIDTypeDef{},
+	encoding.InetValue:     InetTypeDef{},
+	encoding.CidrValue:     CidrTypeDef{},
+	encoding.PointValue:    PointTypeDef{},
+	encoding.IntervalValue: IntervalTypeDef{},
+	encoding.JSONValue:     JSONTypeDef{},
+	encoding.ArrayValue:    ArrayTypeDef{},
+	encoding.ObjectValue:   ObjectTypeDef{},
 }
 
 func DecodeValue(b []byte) (v Value, n int) {
@@ -32,6 +44,24 @@ func DecodeValue(b []byte) (v Value, n int) {
 		return IntegerTypeDef{}.Decode(b)
 	}
 
+	// unsigned 32-bit integers may not fit in an INTEGER
+	if t == encoding.Uint32Value {
+		x, n := encoding.DecodeInt(b)
+		if x > math.MaxInt32 {
+			return NewBigintValue(x), n
+		}
+		return NewIntegerValue(int32(x)), n
+	}
+
+	// unsigned 64-bit integers may not fit in a BIGINT
+	if t == encoding.Uint64Value {
+		x, n := encoding.DecodeUint(b)
+		if x > math.MaxInt64 {
+			return NewUnsignedBigintValue(x), n
+		}
+		return NewBigintValue(int64(x)), n
+	}
+
 	return encodedTypeToTypeDefs[t].Decode(b)
 }
 
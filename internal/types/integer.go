@@ -2,6 +2,7 @@ package types
 
 import (
 	"math"
+	"math/big"
 	"strconv"
 
 	"github.com/chaisql/chai/internal/encoding"
@@ -30,7 +31,7 @@ func (IntegerTypeDef) Decode(src []byte) (Value, int) {
 }
 
 func (IntegerTypeDef) IsComparableWith(other Type) bool {
-	return other == TypeInteger || other == TypeBigint || other == TypeDouble
+	return other == TypeInteger || other == TypeBigint || other == TypeUnsignedBigint || other == TypeDouble || other == TypeReal
 }
 
 func (IntegerTypeDef) IsIndexComparableWith(other Type) bool {
@@ -92,8 +93,15 @@ func (v IntegerValue) CastAs(target Type) (Value, error) {
 		return NewBooleanValue(int32(v) != 0), nil
 	case TypeBigint:
 		return NewBigintValue(int64(v)), nil
+	case TypeUnsignedBigint:
+		if v < 0 {
+			return nil, errors.New("bigint unsigned out of range")
+		}
+		return NewUnsignedBigintValue(uint64(v)), nil
 	case TypeDouble:
 		return NewDoubleValue(float64(v)), nil
+	case TypeReal:
+		return NewRealValue(float32(v)), nil
 	case TypeText:
 		return NewTextValue(v.String()), nil
 	}
@@ -108,8 +116,10 @@ func (v IntegerValue) EQ(other Value) (bool, error) {
 		return int32(v) == AsInt32(other), nil
 	case TypeBigint:
 		return int64(v) == AsInt64(other), nil
-	case TypeDouble:
+	case TypeDouble, TypeReal:
 		return float64(int32(v)) == AsFloat64(other), nil
+	case TypeUnsignedBigint:
+		return other.EQ(v)
 	default:
 		return false, nil
 	}
@@ -122,8 +132,10 @@ func (v IntegerValue) GT(other Value) (bool, error) {
 		return int32(v) > AsInt32(other), nil
 	case TypeBigint:
 		return int64(v) > AsInt64(other), nil
-	case TypeDouble:
+	case TypeDouble, TypeReal:
 		return float64(int32(v)) > AsFloat64(other), nil
+	case TypeUnsignedBigint:
+		return other.LT(v)
 	default:
 		return false, nil
 	}
@@ -136,8 +148,10 @@ func (v IntegerValue) GTE(other Value) (bool, error) {
 		return int32(v) >= AsInt32(other), nil
 	case TypeBigint:
 		return int64(v) >= AsInt64(other), nil
-	case TypeDouble:
+	case TypeDouble, TypeReal:
 		return float64(int32(v)) >= AsFloat64(other), nil
+	case TypeUnsignedBigint:
+		return other.LTE(v)
 	default:
 		return false, nil
 	}
@@ -150,8 +164,10 @@ func (v IntegerValue) LT(other Value) (bool, error) {
 		return int32(v) < AsInt32(other), nil
 	case TypeBigint:
 		return int64(v) < AsInt64(other), nil
-	case TypeDouble:
+	case TypeDouble, TypeReal:
 		return float64(int32(v)) <= AsFloat64(other), nil
+	case TypeUnsignedBigint:
+		return other.GT(v)
 	default:
 		return false, nil
 	}
@@ -164,8 +180,10 @@ func (v IntegerValue) LTE(other Value) (bool, error) {
 		return int32(v) <= AsInt32(other), nil
 	case TypeBigint:
 		return int64(v) <= AsInt64(other), nil
-	case TypeDouble:
+	case TypeDouble, TypeReal:
 		return float64(int32(v)) <= AsFloat64(other), nil
+	case TypeUnsignedBigint:
+		return other.GTE(v)
 	default:
 		return false, nil
 	}
@@ -204,7 +222,9 @@ func (v IntegerValue) Add(other Numeric) (Value, error) {
 
 		xr := xa + xb
 		return NewBigintValue(xr), nil
-	case TypeDouble:
+	case TypeUnsignedBigint:
+		return unsignedArithmetic(v, other, (*big.Int).Add)
+	case TypeDouble, TypeReal:
 		return NewDoubleValue(float64(int32(v)) + AsFloat64(other)), nil
 	}
 
@@ -230,7 +250,9 @@ func (v IntegerValue) Sub(other Numeric) (Value, error) {
 		}
 		xr := xa - xb
 		return NewBigintValue(xr), nil
-	case TypeDouble:
+	case TypeUnsignedBigint:
+		return unsignedArithmetic(v, other, (*big.Int).Sub)
+	case TypeDouble, TypeReal:
 		return NewDoubleValue(float64(int32(v)) - AsFloat64(other)), nil
 	}
 
@@ -257,7 +279,9 @@ func (v IntegerValue) Mul(other Numeric) (Value, error) {
 
 		xr := xa * xb
 		return NewBigintValue(xr), nil
-	case TypeDouble:
+	case TypeUnsignedBigint:
+		return unsignedArithmetic(v, other, (*big.Int).Mul)
+	case TypeDouble, TypeReal:
 		return NewDoubleValue(float64(int32(v)) * AsFloat64(other)), nil
 	}
 
@@ -282,7 +306,13 @@ func (v IntegerValue) Div(other Numeric) (Value, error) {
 		}
 
 		return NewBigintValue(xa / xb), nil
-	case TypeDouble:
+	case TypeUnsignedBigint:
+		if AsUint64(other) == 0 {
+			return nil, errors.New("division by zero")
+		}
+
+		return unsignedArithmetic(v, other, (*big.Int).Quo)
+	case TypeDouble, TypeReal:
 		xa := float64(AsInt64(v))
 		xb := AsFloat64(other)
 		if xb == 0 {
@@ -313,7 +343,13 @@ func (v IntegerValue) Mod(other Numeric) (Value, error) {
 		}
 
 		return NewBigintValue(xa % xb), nil
-	case TypeDouble:
+	case TypeUnsignedBigint:
+		if AsUint64(other) == 0 {
+			return NewNullValue(), nil
+		}
+
+		return unsignedArithmetic(v, other, (*big.Int).Rem)
+	case TypeDouble, TypeReal:
 		xa := float64(AsInt64(v))
 		xb := AsFloat64(other)
 		mod := math.Mod(xa, xb)
@@ -333,7 +369,9 @@ func (v IntegerValue) BitwiseAnd(other Numeric) (Value, error) {
 		return NewIntegerValue(int32(v) & AsInt32(other)), nil
 	case TypeBigint:
 		return NewBigintValue(int64(v) & AsInt64(other)), nil
-	case TypeDouble:
+	case TypeUnsignedBigint:
+		return NewUnsignedBigintValue(uint64(v) & AsUint64(other)), nil
+	case TypeDouble, TypeReal:
 		xa := int32(v)
 		xb := int32(AsFloat64(other))
 		return NewIntegerValue(xa & xb), nil
@@ -348,7 +386,9 @@ func (v IntegerValue) BitwiseOr(other Numeric) (Value, error) {
 		return NewIntegerValue(int32(v) | AsInt32(other)), nil
 	case TypeBigint:
 		return NewBigintValue(int64(v) | AsInt64(other)), nil
-	case TypeDouble:
+	case TypeUnsignedBigint:
+		return NewUnsignedBigintValue(uint64(v) | AsUint64(other)), nil
+	case TypeDouble, TypeReal:
 		xa := int32(v)
 		xb := int32(AsFloat64(other))
 		return NewIntegerValue(xa | xb), nil
@@ -363,7 +403,9 @@ func (v IntegerValue) BitwiseXor(other Numeric) (Value, error) {
 		return NewIntegerValue(int32(v) ^ AsInt32(other)), nil
 	case TypeBigint:
 		return NewBigintValue(int64(v) ^ AsInt64(other)), nil
-	case TypeDouble:
+	case TypeUnsignedBigint:
+		return NewUnsignedBigintValue(uint64(v) ^ AsUint64(other)), nil
+	case TypeDouble, TypeReal:
 		xa := int32(v)
 		xb := int32(AsFloat64(other))
 		return NewIntegerValue(xa ^ xb), nil
@@ -371,3 +413,21 @@ func (v IntegerValue) BitwiseXor(other Numeric) (Value, error) {
 
 	return NewNullValue(), nil
 }
+
+func (v IntegerValue) ShiftLeft(other Numeric) (Value, error) {
+	n, err := shiftCount(other)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewIntegerValue(int32(v) << n), nil
+}
+
+func (v IntegerValue) ShiftRight(other Numeric) (Value, error) {
+	n, err := shiftCount(other)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewIntegerValue(int32(v) >> n), nil
+}
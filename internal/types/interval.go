@@ -0,0 +1,365 @@
+package types
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/cockroachdb/errors"
+)
+
+var _ TypeDefinition = IntervalTypeDef{}
+
+type IntervalTypeDef struct{}
+
+func (IntervalTypeDef) New(v any) Value {
+	return NewIntervalValue(v.(Interval))
+}
+
+func (IntervalTypeDef) Type() Type {
+	return TypeInterval
+}
+
+func (IntervalTypeDef) Decode(src []byte) (Value, int) {
+	months, days, micros, n := encoding.DecodeInterval(src)
+	return NewIntervalValue(Interval{Months: months, Days: days, Micros: micros}), n
+}
+
+func (IntervalTypeDef) IsComparableWith(other Type) bool {
+	return other == TypeInterval || other == TypeText
+}
+
+func (IntervalTypeDef) IsIndexComparableWith(other Type) bool {
+	return other == TypeInterval
+}
+
+// Interval is a duration made of months, days and microseconds,
+// which are added separately to the fields of timestamps, like the
+// intervals of PostgreSQL: adding one month to January 31 returns
+// the last day of February, and adding one day keeps the time of the day
+// when the clocks change.
+type Interval struct {
+	Months int32
+	Days   int32
+	Micros int64
+}
+
+// IntervalBetween returns the interval from b to a, in days and microseconds.
+func IntervalBetween(a, b time.Time) Interval {
+	// timestamps are within ±292,000 years of each other
+	d := a.UnixMicro() - b.UnixMicro()
+
+	return Interval{Days: int32(d / encoding.MicrosPerDay), Micros: d % encoding.MicrosPerDay}
+}
+
+// AddTo returns t plus n times the interval. Adding months keeps the day
+// of the month, or uses the last day of the month if it is shorter.
+func (iv Interval) AddTo(t time.Time, n int) time.Time {
+	if iv.Months != 0 {
+		y, m, d := t.Date()
+		months := int(m) - 1 + n*int(iv.Months)
+		y += FloorDiv(months, 12)
+		m = time.Month(months-12*FloorDiv(months, 12)) + 1
+		d = min(d, DaysIn(y, m))
+		t = time.Date(y, m, d, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	}
+
+	return t.AddDate(0, 0, n*int(iv.Days)).Add(time.Duration(n) * time.Duration(iv.Micros) * time.Microsecond)
+}
+
+// FloorDiv returns a divided by b, rounded towards negative infinity,
+// as required by calendar arithmetic on negative years and months.
+func FloorDiv(a, b int) int {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
+
+// DaysIn returns the number of days of the month of the given year.
+func DaysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// Add returns the sum of two intervals, field by field.
+func (iv Interval) Add(other Interval) (Interval, error) {
+	months := int64(iv.Months) + int64(other.Months)
+	days := int64(iv.Days) + int64(other.Days)
+	micros := iv.Micros + other.Micros
+	if months != int64(int32(months)) || days != int64(int32(days)) ||
+		(iv.Micros > 0 && other.Micros > 0 && micros < 0) || (iv.Micros < 0 && other.Micros < 0 && micros >= 0) {
+		return Interval{}, errors.New("interval out of range")
+	}
+
+	return Interval{Months: int32(months), Days: int32(days), Micros: micros}, nil
+}
+
+// Neg returns the opposite of the interval.
+func (iv Interval) Neg() (Interval, error) {
+	if iv.Months == math.MinInt32 || iv.Days == math.MinInt32 || iv.Micros == math.MinInt64 {
+		return Interval{}, errors.New("interval out of range")
+	}
+
+	return Interval{Months: -iv.Months, Days: -iv.Days, Micros: -iv.Micros}, nil
+}
+
+var _ Value = NewIntervalValue(Interval{})
+
+// IntervalValue is an interval. Intervals are compared by their length,
+// counting 30 days per month and 24 hours per day, then by their months
+// and their days, in the order of their encoding.
+type IntervalValue Interval
+
+// NewIntervalValue returns a SQL INTERVAL value.
+func NewIntervalValue(iv Interval) IntervalValue {
+	return IntervalValue(iv)
+}
+
+func (v IntervalValue) V() any {
+	return Interval(v)
+}
+
+func (v IntervalValue) Type() Type {
+	return TypeInterval
+}
+
+func (v IntervalValue) TypeDef() TypeDefinition {
+	return IntervalTypeDef{}
+}
+
+func (v IntervalValue) IsZero() (bool, error) {
+	return v == IntervalValue{}, nil
+}
+
+func (v IntervalValue) String() string {
+	return strconv.Quote(FormatInterval(Interval(v)))
+}
+
+func (v IntervalValue) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+func (v IntervalValue) MarshalJSON() ([]byte, error) {
+	return v.MarshalText()
+}
+
+func (v IntervalValue) Encode(dst []byte) ([]byte, error) {
+	return encoding.EncodeInterval(dst, v.Months, v.Days, v.Micros), nil
+}
+
+func (v IntervalValue) EncodeAsKey(dst []byte) ([]byte, error) {
+	return v.Encode(dst)
+}
+
+func (v IntervalValue) CastAs(target Type) (Value, error) {
+	switch target {
+	case TypeInterval:
+		return v, nil
+	case TypeText:
+		return NewTextValue(FormatInterval(Interval(v))), nil
+	}
+
+	return nil, errors.Errorf("cannot cast %s as %s", v.Type(), target)
+}
+
+func (v IntervalValue) EQ(other Value) (bool, error) {
+	c, ok, err := compareIntervalWith(Interval(v), other)
+	return ok && c == 0, err
+}
+
+func (v IntervalValue) GT(other Value) (bool, error) {
+	c, ok, err := compareIntervalWith(Interval(v), other)
+	return ok && c > 0, err
+}
+
+func (v IntervalValue) GTE(other Value) (bool, error) {
+	c, ok, err := compareIntervalWith(Interval(v), other)
+	return ok && c >= 0, err
+}
+
+func (v IntervalValue) LT(other Value) (bool, error) {
+	c, ok, err := compareIntervalWith(Interval(v), other)
+	return ok && c < 0, err
+}
+
+func (v IntervalValue) LTE(other Value) (bool, error) {
+	c, ok, err := compareIntervalWith(Interval(v), other)
+	return ok && c <= 0, err
+}
+
+func (v IntervalValue) Between(a, b Value) (bool, error) {
+	var def IntervalTypeDef
+	if !def.IsComparableWith(a.Type()) || !def.IsComparableWith(b.Type()) {
+		return false, nil
+	}
+
+	ok, err := v.GTE(a)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	return v.LTE(b)
+}
+
+// ParseInterval parses an interval in the format of PostgreSQL, such as
+// '1 day', '2 hours 30 minutes' or '1 year 2 mons 3 days 04:05:06',
+// which is the format returned by FormatInterval.
+func ParseInterval(s string) (Interval, error) {
+	var months, days, micros float64
+
+	fields := strings.Fields(strings.ToLower(s))
+	if len(fields) == 0 {
+		return Interval{}, errors.Errorf("invalid interval %q", s)
+	}
+
+	for i := 0; i < len(fields); i++ {
+		if strings.Contains(fields[i], ":") {
+			us, err := parseIntervalTime(fields[i])
+			if err != nil {
+				return Interval{}, errors.Errorf("invalid interval %q", s)
+			}
+			micros += us
+			continue
+		}
+
+		n, err := strconv.ParseFloat(fields[i], 64)
+		if err != nil || i+1 == len(fields) {
+			return Interval{}, errors.Errorf("invalid interval %q", s)
+		}
+		i++
+
+		unit := fields[i]
+		if unit != "ms" && unit != "us" {
+			unit = strings.TrimSuffix(unit, "s")
+		}
+		switch unit {
+		case "microsecond", "us":
+			micros += n
+		case "millisecond", "ms":
+			micros += n * 1e3
+		case "second", "sec":
+			micros += n * 1e6
+		case "minute", "min":
+			micros += n * 60e6
+		case "hour":
+			micros += n * 3600e6
+		case "day", "week", "month", "mon", "year":
+			if n != math.Trunc(n) {
+				return Interval{}, errors.Errorf("invalid interval %q: %s must be a whole number", s, fields[i])
+			}
+			switch unit {
+			case "day":
+				days += n
+			case "week":
+				days += 7 * n
+			case "month", "mon":
+				months += n
+			case "year":
+				months += 12 * n
+			}
+		default:
+			return Interval{}, errors.Errorf("invalid interval %q: unknown unit %q", s, fields[i])
+		}
+	}
+
+	micros = math.Round(micros)
+	if math.Abs(months) > math.MaxInt32 || math.Abs(days) > math.MaxInt32 || math.Abs(micros) >= math.MaxInt64 {
+		return Interval{}, errors.Errorf("interval out of range: %q", s)
+	}
+
+	return Interval{Months: int32(months), Days: int32(days), Micros: int64(micros)}, nil
+}
+
+// parseIntervalTime parses the time of an interval, [-]hh:mm[:ss[.frac]],
+// and returns its number of microseconds.
+func parseIntervalTime(s string) (float64, error) {
+	neg := strings.HasPrefix(s, "-")
+	parts := strings.Split(strings.TrimPrefix(s, "-"), ":")
+	if len(parts) > 3 {
+		return 0, errors.New("invalid time")
+	}
+
+	var us float64
+	for i, unit := range []float64{3600e6, 60e6, 1e6}[:len(parts)] {
+		n, err := strconv.ParseFloat(parts[i], 64)
+		if err != nil || n < 0 || i < 2 && n != math.Trunc(n) {
+			return 0, errors.New("invalid time")
+		}
+		us += n * unit
+	}
+
+	if neg {
+		us = -us
+	}
+	return us, nil
+}
+
+// FormatInterval returns the text representation of an interval,
+// in the format of PostgreSQL, such as "1 year 2 mons 3 days 04:05:06".
+func FormatInterval(iv Interval) string {
+	var parts []string
+	for _, p := range []struct {
+		n    int32
+		unit string
+	}{{iv.Months / 12, "year"}, {iv.Months % 12, "mon"}, {iv.Days, "day"}} {
+		if p.n == 0 {
+			continue
+		}
+		unit := p.unit
+		if p.n != 1 {
+			unit += "s"
+		}
+		parts = append(parts, fmt.Sprintf("%d %s", p.n, unit))
+	}
+
+	if iv.Micros != 0 || len(parts) == 0 {
+		us := uint64(iv.Micros)
+		sign := ""
+		if iv.Micros < 0 {
+			us = -us
+			sign = "-"
+		}
+
+		s := fmt.Sprintf("%s%02d:%02d:%02d", sign, us/3600e6, us/60e6%60, us/1e6%60)
+		if us%1e6 != 0 {
+			s += strings.TrimRight(fmt.Sprintf(".%06d", us%1e6), "0")
+		}
+		parts = append(parts, s)
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// AsInterval returns the interval of an INTERVAL value, or of a text parsed
+// as an interval. It returns false for the other types.
+func AsInterval(v Value) (Interval, bool, error) {
+	switch v.Type() {
+	case TypeInterval:
+		return v.V().(Interval), true, nil
+	case TypeText:
+		iv, err := ParseInterval(AsString(v))
+		if err != nil {
+			return Interval{}, false, err
+		}
+		return iv, true, nil
+	}
+
+	return Interval{}, false, nil
+}
+
+// compareIntervalWith compares intervals in the order of their encoding.
+// It returns false if other is not an interval.
+func compareIntervalWith(iv Interval, other Value) (int, bool, error) {
+	x, ok, err := AsInterval(other)
+	if !ok || err != nil {
+		return 0, false, err
+	}
+
+	a := encoding.EncodeInterval(nil, iv.Months, iv.Days, iv.Micros)
+	b := encoding.EncodeInterval(nil, x.Months, x.Days, x.Micros)
+	return encoding.Compare(a, b), true, nil
+}
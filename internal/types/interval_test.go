@@ -0,0 +1,122 @@
+package types_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/chaisql/chai/internal/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseInterval(t *testing.T) {
+	tests := []struct {
+		s     string
+		want  types.Interval
+		fails bool
+	}{
+		{"1 day", types.Interval{Days: 1}, false},
+		{"2 hours 30 minutes", types.Interval{Micros: 9000e6}, false},
+		{"1 year 2 mons 3 days 04:05:06.5", types.Interval{Months: 14, Days: 3, Micros: 14706500000}, false},
+		{"1 week -1 day", types.Interval{Days: 6}, false},
+		{"-00:00:01", types.Interval{Micros: -1e6}, false},
+		{"1.5 seconds", types.Interval{Micros: 1500000}, false},
+		{"1.5 days", types.Interval{}, true},
+		{"1 fortnight", types.Interval{}, true},
+		{"day", types.Interval{}, true},
+		{"", types.Interval{}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.s, func(t *testing.T) {
+			iv, err := types.ParseInterval(test.s)
+			if test.fails {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.want, iv)
+		})
+	}
+}
+
+func TestFormatInterval(t *testing.T) {
+	tests := []struct {
+		iv   types.Interval
+		want string
+	}{
+		{types.Interval{}, "00:00:00"},
+		{types.Interval{Days: 1}, "1 day"},
+		{types.Interval{Months: 14, Days: 3, Micros: 14706500000}, "1 year 2 mons 3 days 04:05:06.5"},
+		{types.Interval{Months: -1, Days: -2, Micros: -3600e6}, "-1 mons -2 days -01:00:00"},
+		{types.Interval{Micros: 100 * 3600e6}, "100:00:00"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.want, func(t *testing.T) {
+			require.Equal(t, test.want, types.FormatInterval(test.iv))
+
+			// the text is parsed as the same interval
+			iv, err := types.ParseInterval(test.want)
+			require.NoError(t, err)
+			require.Equal(t, test.iv, iv)
+		})
+	}
+}
+
+func TestIntervalAddTo(t *testing.T) {
+	jan31 := time.Date(2024, 1, 31, 10, 0, 0, 0, time.UTC)
+
+	// the last day of the month is used if the month is shorter
+	require.Equal(t, time.Date(2024, 2, 29, 10, 0, 0, 0, time.UTC), types.Interval{Months: 1}.AddTo(jan31, 1))
+	require.Equal(t, time.Date(2023, 12, 31, 10, 0, 0, 0, time.UTC), types.Interval{Months: 1}.AddTo(jan31, -1))
+	require.Equal(t, time.Date(2024, 2, 1, 12, 0, 0, 0, time.UTC), types.Interval{Days: 1, Micros: 2 * 3600e6}.AddTo(jan31, 1))
+
+	// days keep the time of the day when the clocks change
+	paris, err := time.LoadLocation("Europe/Paris")
+	require.NoError(t, err)
+	before := time.Date(2024, 3, 30, 12, 0, 0, 0, paris)
+	require.Equal(t, time.Date(2024, 3, 31, 12, 0, 0, 0, paris), types.Interval{Days: 1}.AddTo(before, 1))
+	require.Equal(t, time.Date(2024, 3, 31, 13, 0, 0, 0, paris), types.Interval{Micros: 24 * 3600e6}.AddTo(before, 1))
+}
+
+func TestFloorDiv(t *testing.T) {
+	require.Equal(t, 2, types.FloorDiv(7, 3))
+	require.Equal(t, -3, types.FloorDiv(-7, 3))
+	require.Equal(t, -3, types.FloorDiv(7, -3))
+	require.Equal(t, 2, types.FloorDiv(-7, -3))
+	require.Equal(t, -2, types.FloorDiv(-6, 3))
+
+	require.Equal(t, 29, types.DaysIn(2024, time.February))
+	require.Equal(t, 28, types.DaysIn(2023, time.February))
+	require.Equal(t, 31, types.DaysIn(2023, time.December))
+}
+
+func TestIntervalBetween(t *testing.T) {
+	a := time.Date(2024, 3, 2, 1, 0, 0, 0, time.UTC)
+	b := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	require.Equal(t, types.Interval{Days: 61, Micros: 3600e6}, types.IntervalBetween(a, b))
+	require.Equal(t, types.Interval{Days: -61, Micros: -3600e6}, types.IntervalBetween(b, a))
+}
+
+func TestIntervalCompare(t *testing.T) {
+	day := types.NewIntervalValue(types.Interval{Days: 1})
+	hours := types.NewIntervalValue(types.Interval{Micros: 23 * 3600e6})
+
+	ok, err := day.GT(hours)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	ok, err = day.EQ(types.NewTextValue("24 hours"))
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	ok, err = day.EQ(types.NewTextValue("1 day"))
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// a month counts as 30 days
+	ok, err = types.NewIntervalValue(types.Interval{Months: 1}).LT(types.NewIntervalValue(types.Interval{Days: 31}))
+	require.NoError(t, err)
+	require.True(t, ok)
+}
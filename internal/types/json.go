@@ -0,0 +1,697 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/cockroachdb/errors"
+)
+
+var _ TypeDefinition = JSONTypeDef{}
+
+type JSONTypeDef struct{}
+
+func (JSONTypeDef) New(v any) Value {
+	return NewJSONValue(v.([]byte))
+}
+
+func (JSONTypeDef) Type() Type {
+	return TypeJSON
+}
+
+func (JSONTypeDef) Decode(src []byte) (Value, int) {
+	x, n := encoding.DecodeJSON(src)
+	return NewJSONValue(x), n
+}
+
+func (JSONTypeDef) IsComparableWith(other Type) bool {
+	return other == TypeJSON
+}
+
+func (JSONTypeDef) IsIndexComparableWith(other Type) bool {
+	return other == TypeJSON
+}
+
+var _ Value = NewJSONValue(nil)
+
+// JSONValue is a JSON document stored in a binary form, which can be
+// traversed without being parsed. See ParseJSON.
+type JSONValue []byte
+
+// NewJSONValue returns a SQL JSONB value from a document
+// returned by ParseJSON.
+func NewJSONValue(doc []byte) JSONValue {
+	return JSONValue(doc)
+}
+
+func (v JSONValue) V() any {
+	return []byte(v)
+}
+
+func (v JSONValue) Type() Type {
+	return TypeJSON
+}
+
+func (v JSONValue) TypeDef() TypeDefinition {
+	return JSONTypeDef{}
+}
+
+func (v JSONValue) IsZero() (bool, error) {
+	return v == nil, nil
+}
+
+func (v JSONValue) String() string {
+	return strconv.Quote(FormatJSON(v))
+}
+
+func (v JSONValue) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// MarshalJSON returns the document itself.
+func (v JSONValue) MarshalJSON() ([]byte, error) {
+	dst, _ := appendJSON(nil, v)
+	return dst, nil
+}
+
+func (v JSONValue) Encode(dst []byte) ([]byte, error) {
+	return encoding.EncodeJSON(dst, v), nil
+}
+
+func (v JSONValue) EncodeAsKey(dst []byte) ([]byte, error) {
+	return v.Encode(dst)
+}
+
+func (v JSONValue) CastAs(target Type) (Value, error) {
+	switch target {
+	case TypeJSON:
+		return v, nil
+	case TypeText:
+		return NewTextValue(FormatJSON(v)), nil
+	case TypeObject, TypeArray:
+		x, err := jsonAsNested(v, target)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot cast %s as %s", v, target)
+		}
+		return x, nil
+	}
+
+	return nil, errors.Errorf("cannot cast %s as %s", v.Type(), target)
+}
+
+// JSON documents are compared by their binary form, which is canonical:
+// equal documents have the same binary form.
+func (v JSONValue) compare(other Value) (int, bool) {
+	if other.Type() != TypeJSON {
+		return 0, false
+	}
+
+	return bytes.Compare(v, AsByteSlice(other)), true
+}
+
+func (v JSONValue) EQ(other Value) (bool, error) {
+	c, ok := v.compare(other)
+	return ok && c == 0, nil
+}
+
+func (v JSONValue) GT(other Value) (bool, error) {
+	c, ok := v.compare(other)
+	return ok && c > 0, nil
+}
+
+func (v JSONValue) GTE(other Value) (bool, error) {
+	c, ok := v.compare(other)
+	return ok && c >= 0, nil
+}
+
+func (v JSONValue) LT(other Value) (bool, error) {
+	c, ok := v.compare(other)
+	return ok && c < 0, nil
+}
+
+func (v JSONValue) LTE(other Value) (bool, error) {
+	c, ok := v.compare(other)
+	return ok && c <= 0, nil
+}
+
+func (v JSONValue) Between(a, b Value) (bool, error) {
+	if a.Type() != TypeJSON || b.Type() != TypeJSON {
+		return false, nil
+	}
+
+	ok, err := v.GTE(a)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	return v.LTE(b)
+}
+
+// ParseJSON parses a JSON document and returns its binary form.
+// Each JSON value is encoded like the SQL value of the same type:
+// null, booleans, numbers as doubles and strings as texts.
+// Arrays and objects are encoded with the number of their elements followed by
+// the elements. The fields of objects are sorted by name, and if a name
+// appears more than once, the last value is kept.
+func ParseJSON(data []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var x any
+	err := dec.Decode(&x)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid json")
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("invalid json: unexpected data after the document")
+	}
+
+	return encodeJSON(nil, x)
+}
+
+func encodeJSON(dst []byte, x any) ([]byte, error) {
+	var err error
+
+	switch x := x.(type) {
+	case nil:
+		return encoding.EncodeNull(dst), nil
+	case bool:
+		return encoding.EncodeBoolean(dst, x), nil
+	case json.Number:
+		f, err := x.Float64()
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid json number %s", x)
+		}
+		return encoding.EncodeFloat64(dst, f), nil
+	case string:
+		return encoding.EncodeText(dst, x), nil
+	case []any:
+		dst = encoding.EncodeArrayHeader(dst, len(x))
+		for _, e := range x {
+			dst, err = encodeJSON(dst, e)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return dst, nil
+	case map[string]any:
+		keys := make([]string, 0, len(x))
+		for k := range x {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+
+		dst = encoding.EncodeObjectHeader(dst, len(keys))
+		for _, k := range keys {
+			dst = encoding.EncodeText(dst, k)
+			dst, err = encodeJSON(dst, x[k])
+			if err != nil {
+				return nil, err
+			}
+		}
+		return dst, nil
+	}
+
+	return nil, errors.Errorf("unsupported json value %T", x)
+}
+
+// FormatJSON returns the text representation of a document returned by ParseJSON.
+func FormatJSON(doc []byte) string {
+	dst, _ := appendJSON(nil, doc)
+	return string(dst)
+}
+
+// appendJSON appends the text representation of the first value of doc
+// and returns the number of bytes read.
+func appendJSON(dst []byte, doc []byte) ([]byte, int) {
+	switch doc[0] {
+	case encoding.NullValue:
+		return append(dst, "null"...), 1
+	case encoding.TrueValue:
+		return append(dst, "true"...), 1
+	case encoding.FalseValue:
+		return append(dst, "false"...), 1
+	case encoding.TextValue:
+		s, n := encoding.DecodeText(doc)
+		return appendJSONString(dst, s), n
+	case encoding.ArrayValue:
+		l, n := encoding.DecodeHeader(doc)
+		dst = append(dst, '[')
+		for i := 0; i < l; i++ {
+			if i > 0 {
+				dst = append(dst, ", "...)
+			}
+			var m int
+			dst, m = appendJSON(dst, doc[n:])
+			n += m
+		}
+		return append(dst, ']'), n
+	case encoding.ObjectValue:
+		l, n := encoding.DecodeHeader(doc)
+		dst = append(dst, '{')
+		for i := 0; i < l; i++ {
+			if i > 0 {
+				dst = append(dst, ", "...)
+			}
+			k, m := encoding.DecodeText(doc[n:])
+			n += m
+			dst = appendJSONString(dst, k)
+			dst = append(dst, ": "...)
+			dst, m = appendJSON(dst, doc[n:])
+			n += m
+		}
+		return append(dst, '}'), n
+	}
+
+	f, n := encoding.DecodeFloat(doc)
+	return appendJSONNumber(dst, f), n
+}
+
+// appendJSONNumber formats numbers the same way as JavaScript.
+func appendJSONNumber(dst []byte, f float64) []byte {
+	abs := math.Abs(f)
+	fmt := byte('f')
+	if abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		fmt = 'e'
+	}
+
+	dst = strconv.AppendFloat(dst, f, fmt, -1, 64)
+	if fmt == 'e' {
+		// clean up e-09 to e-9
+		n := len(dst)
+		if n >= 4 && dst[n-4] == 'e' && dst[n-3] == '-' && dst[n-2] == '0' {
+			dst[n-2] = dst[n-1]
+			dst = dst[:n-1]
+		}
+	}
+
+	return dst
+}
+
+const hexDigits = "0123456789abcdef"
+
+func appendJSONString(dst []byte, s string) []byte {
+	dst = append(dst, '"')
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c >= utf8.RuneSelf {
+			r, size := utf8.DecodeRuneInString(s[i:])
+			dst = utf8.AppendRune(dst, r)
+			i += size
+			continue
+		}
+
+		switch {
+		case c == '"' || c == '\\':
+			dst = append(dst, '\\', c)
+		case c == '\n':
+			dst = append(dst, '\\', 'n')
+		case c == '\r':
+			dst = append(dst, '\\', 'r')
+		case c == '\t':
+			dst = append(dst, '\\', 't')
+		case c < 0x20:
+			dst = append(dst, '\\', 'u', '0', '0', hexDigits[c>>4], hexDigits[c&0xf])
+		default:
+			dst = append(dst, c)
+		}
+		i++
+	}
+
+	return append(dst, '"')
+}
+
+// JSONPath is a path to a value of a JSON document, such as $.a.b[0].
+type JSONPath []JSONPathFragment
+
+// JSONPathFragment is either the name of a field of an object,
+// or the index of an element of an array.
+type JSONPathFragment struct {
+	Field string
+	Index int
+	// if true, the fragment is an array index
+	IsIndex bool
+}
+
+// ParseJSONPath parses a path starting with $, the root of the document,
+// followed by fields (.name or ."quoted name") and array indexes ([0]).
+func ParseJSONPath(s string) (JSONPath, error) {
+	if len(s) == 0 || s[0] != '$' {
+		return nil, errors.Errorf("invalid json path %q: must start with $", s)
+	}
+
+	var path JSONPath
+	for i := 1; i < len(s); {
+		switch s[i] {
+		case '.':
+			i++
+			if i < len(s) && s[i] == '"' {
+				end := i + 1
+				for end < len(s) && s[end] != '"' {
+					end++
+				}
+				if end == len(s) {
+					return nil, errors.Errorf("invalid json path %q: unterminated field name", s)
+				}
+				path = append(path, JSONPathFragment{Field: s[i+1 : end]})
+				i = end + 1
+				continue
+			}
+
+			end := i
+			for end < len(s) && s[end] != '.' && s[end] != '[' {
+				end++
+			}
+			if end == i {
+				return nil, errors.Errorf("invalid json path %q: missing field name", s)
+			}
+			path = append(path, JSONPathFragment{Field: s[i:end]})
+			i = end
+		case '[':
+			end := i + 1
+			for end < len(s) && s[end] != ']' {
+				end++
+			}
+			if end == len(s) {
+				return nil, errors.Errorf("invalid json path %q: missing ]", s)
+			}
+			idx, err := strconv.Atoi(s[i+1 : end])
+			if err != nil || idx < 0 {
+				return nil, errors.Errorf("invalid json path %q: invalid array index %q", s, s[i+1:end])
+			}
+			path = append(path, JSONPathFragment{Index: idx, IsIndex: true})
+			i = end + 1
+		default:
+			return nil, errors.Errorf("invalid json path %q: unexpected character %q", s, s[i])
+		}
+	}
+
+	return path, nil
+}
+
+// Extract returns the binary form of the value selected by the path,
+// and false if it doesn't exist. The document is traversed without being
+// decoded: the values before the selected one are skipped.
+// The returned slice points to doc.
+func (p JSONPath) Extract(doc []byte) ([]byte, bool) {
+	for _, f := range p {
+		var ok bool
+		if f.IsIndex {
+			doc, ok = extractJSONElement(doc, f.Index)
+		} else {
+			doc, ok = extractJSONField(doc, f.Field)
+		}
+		if !ok {
+			return nil, false
+		}
+	}
+
+	return doc[:encoding.Skip(doc)], true
+}
+
+func extractJSONElement(doc []byte, idx int) ([]byte, bool) {
+	if doc[0] != encoding.ArrayValue {
+		return nil, false
+	}
+
+	l, n := encoding.DecodeHeader(doc)
+	if idx >= l {
+		return nil, false
+	}
+
+	for i := 0; i < idx; i++ {
+		n += encoding.Skip(doc[n:])
+	}
+
+	return doc[n:], true
+}
+
+func extractJSONField(doc []byte, field string) ([]byte, bool) {
+	if doc[0] != encoding.ObjectValue {
+		return nil, false
+	}
+
+	l, n := encoding.DecodeHeader(doc)
+	for i := 0; i < l; i++ {
+		k, m := encoding.DecodeText(doc[n:])
+		n += m
+
+		switch {
+		case k == field:
+			return doc[n:], true
+		case k > field:
+			// fields are sorted
+			return nil, false
+		}
+
+		n += encoding.Skip(doc[n:])
+	}
+
+	return nil, false
+}
+
+// JSONToValue converts a value of a JSON document to its SQL equivalent:
+// null to NULL, booleans to BOOLEAN, numbers to DOUBLE, strings to TEXT.
+// Arrays and objects are returned as JSONB.
+func JSONToValue(doc []byte) Value {
+	switch doc[0] {
+	case encoding.NullValue:
+		return NewNullValue()
+	case encoding.TrueValue, encoding.FalseValue:
+		return NewBooleanValue(encoding.DecodeBoolean(doc))
+	case encoding.TextValue:
+		s, _ := encoding.DecodeText(doc)
+		return NewTextValue(s)
+	case encoding.ArrayValue, encoding.ObjectValue:
+		return NewJSONValue(bytes.Clone(doc[:encoding.Skip(doc)]))
+	}
+
+	f, _ := encoding.DecodeFloat(doc)
+	return NewDoubleValue(f)
+}
+
+// JSONDocument returns the binary form of a JSONB value, or of a text parsed
+// as a JSON document. It returns false for the other types.
+func JSONDocument(v Value) ([]byte, bool, error) {
+	switch v.Type() {
+	case TypeJSON:
+		return AsByteSlice(v), true, nil
+	case TypeText:
+		doc, err := ParseJSON([]byte(AsString(v)))
+		if err != nil {
+			return nil, false, err
+		}
+		return doc, true, nil
+	}
+
+	return nil, false, nil
+}
+
+// Set returns a copy of doc where the value selected by the path is replaced by value,
+// given in binary form. If the last fragment of the path is a missing field of an object,
+// or the index following the last element of an array, the value is added.
+// Otherwise, if the path doesn't exist, doc is returned unchanged.
+func (p JSONPath) Set(doc, value []byte) []byte {
+	doc, _ = modifyJSON(doc, p, value)
+	return doc
+}
+
+// Remove returns a copy of doc without the value selected by the path,
+// or doc unchanged if the path doesn't exist. Removing the root of the document
+// returns nil.
+func (p JSONPath) Remove(doc []byte) []byte {
+	doc, _ = modifyJSON(doc, p, nil)
+	return doc
+}
+
+// modifyJSON replaces the value selected by the path with value, or removes it
+// if value is nil, and returns the new binary form of the first value of doc.
+// It returns false if the path doesn't exist.
+func modifyJSON(doc []byte, path JSONPath, value []byte) ([]byte, bool) {
+	total := encoding.Skip(doc)
+	if len(path) == 0 {
+		return value, true
+	}
+
+	f, last := path[0], len(path) == 1
+
+	// start and end of the selected value, and of the bytes
+	// before which a new value is added
+	var start, end, insert int
+	var found bool
+	l, n := encoding.DecodeHeader(doc)
+	switch {
+	case f.IsIndex && doc[0] == encoding.ArrayValue:
+		start = n
+		for i := 0; i < l && i < f.Index; i++ {
+			start += encoding.Skip(doc[start:])
+		}
+		found = f.Index < l
+		if found {
+			end = start + encoding.Skip(doc[start:])
+		} else if f.Index == l {
+			insert = total
+		} else {
+			insert = -1
+		}
+	case !f.IsIndex && doc[0] == encoding.ObjectValue:
+		insert = total
+		pos := n
+		for i := 0; i < l; i++ {
+			k, m := encoding.DecodeText(doc[pos:])
+			if k == f.Field {
+				found = true
+				// for removal, the name of the field is removed as well
+				start, end = pos+m, pos+m+encoding.Skip(doc[pos+m:])
+				if last && value == nil {
+					start = pos
+				}
+				break
+			}
+			if k > f.Field {
+				// fields are sorted
+				insert = pos
+				break
+			}
+			pos += m + encoding.Skip(doc[pos+m:])
+		}
+	default:
+		return doc[:total], false
+	}
+
+	if !found && (!last || value == nil || insert < 0) {
+		return doc[:total], false
+	}
+
+	var child []byte
+	switch {
+	case found && !last:
+		var ok bool
+		child, ok = modifyJSON(doc[start:end], path[1:], value)
+		if !ok {
+			return doc[:total], false
+		}
+	case found && value == nil:
+		l--
+	case found:
+		child = value
+	default:
+		// a new element or field is added
+		l++
+		start, end = insert, insert
+		if !f.IsIndex {
+			child = encoding.EncodeText(child, f.Field)
+		}
+		child = append(child, value...)
+	}
+
+	var dst []byte
+	if doc[0] == encoding.ArrayValue {
+		dst = encoding.EncodeArrayHeader(dst, l)
+	} else {
+		dst = encoding.EncodeObjectHeader(dst, l)
+	}
+	dst = append(dst, doc[n:start]...)
+	dst = append(dst, child...)
+	return append(dst, doc[end:total]...), true
+}
+
+// JSONArrayLength returns the number of elements of an array,
+// and false if the document is not an array.
+func JSONArrayLength(doc []byte) (int, bool) {
+	if doc[0] != encoding.ArrayValue {
+		return 0, false
+	}
+
+	l, _ := encoding.DecodeHeader(doc)
+	return l, true
+}
+
+// IterateJSON calls fn for every element of an array, with its index as a BIGINT,
+// or for every field of an object, with its name as a TEXT.
+// Other values are passed to fn as is, with a NULL key.
+func IterateJSON(doc []byte, fn func(key Value, value []byte) error) error {
+	switch doc[0] {
+	case encoding.ArrayValue:
+		l, n := encoding.DecodeHeader(doc)
+		for i := 0; i < l; i++ {
+			m := encoding.Skip(doc[n:])
+			if err := fn(NewBigintValue(int64(i)), doc[n:n+m]); err != nil {
+				return err
+			}
+			n += m
+		}
+	case encoding.ObjectValue:
+		l, n := encoding.DecodeHeader(doc)
+		for i := 0; i < l; i++ {
+			k, m := encoding.DecodeText(doc[n:])
+			n += m
+			m = encoding.Skip(doc[n:])
+			if err := fn(NewTextValue(k), doc[n:n+m]); err != nil {
+				return err
+			}
+			n += m
+		}
+	default:
+		return fn(NewNullValue(), doc[:encoding.Skip(doc)])
+	}
+
+	return nil
+}
+
+// JSONTypeOf returns the JSON type of a value:
+// null, boolean, number, string, array or object.
+func JSONTypeOf(doc []byte) string {
+	switch doc[0] {
+	case encoding.NullValue:
+		return "null"
+	case encoding.TrueValue, encoding.FalseValue:
+		return "boolean"
+	case encoding.TextValue:
+		return "string"
+	case encoding.ArrayValue:
+		return "array"
+	case encoding.ObjectValue:
+		return "object"
+	}
+
+	return "number"
+}
+
+// ValueToJSON converts a value to the binary form of its JSON equivalent:
+// NULL to null, booleans to booleans, numbers to numbers, JSONB values as is,
+// and the other values to their JSON representation, which is a string for
+// texts, timestamps and the other scalar types.
+func ValueToJSON(v Value) ([]byte, error) {
+	switch v.Type() {
+	case TypeNull:
+		return encoding.EncodeNull(nil), nil
+	case TypeBoolean:
+		return encoding.EncodeBoolean(nil, AsBool(v)), nil
+	case TypeJSON:
+		return AsByteSlice(v), nil
+	case TypeText:
+		return encoding.EncodeText(nil, AsString(v)), nil
+	}
+
+	if v.Type().IsNumber() {
+		d, err := v.CastAs(TypeDouble)
+		if err != nil {
+			return nil, err
+		}
+		return encoding.EncodeFloat64(nil, AsFloat64(d)), nil
+	}
+
+	data, err := v.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseJSON(data)
+}
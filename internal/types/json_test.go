@@ -0,0 +1,149 @@
+package types_test
+
+import (
+	"testing"
+
+	"github.com/chaisql/chai/internal/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseJSON(t *testing.T) {
+	tests := []struct {
+		s     string
+		want  string
+		fails bool
+	}{
+		{`null`, `null`, false},
+		{`true`, `true`, false},
+		{` 10 `, `10`, false},
+		{`-1.5e-7`, `-1.5e-7`, false},
+		{`"a\"\né"`, `"a\"\né"`, false},
+		{`[1, [], {}]`, `[1, [], {}]`, false},
+		// fields are sorted, the last value is kept
+		{`{"b": 1, "a": {"d": 2, "c": 3}, "b": 2}`, `{"a": {"c": 3, "d": 2}, "b": 2}`, false},
+		{`{"a": 1`, ``, true},
+		{`{"a": 1} 1`, ``, true},
+		{`'a'`, ``, true},
+		{``, ``, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.s, func(t *testing.T) {
+			doc, err := types.ParseJSON([]byte(test.s))
+			if test.fails {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.want, types.FormatJSON(doc))
+
+			// the binary form is canonical
+			again, err := types.ParseJSON([]byte(test.want))
+			require.NoError(t, err)
+			require.Equal(t, doc, again)
+		})
+	}
+}
+
+func TestJSONPath(t *testing.T) {
+	doc, err := types.ParseJSON([]byte(`{"a": {"b": [10, {"c": "x"}], "d.e": true}, "f": null}`))
+	require.NoError(t, err)
+
+	tests := []struct {
+		path  string
+		want  types.Value
+		fails bool
+	}{
+		{`$.a.b[0]`, types.NewDoubleValue(10), false},
+		{`$.a.b[1].c`, types.NewTextValue("x"), false},
+		{`$.a."d.e"`, types.NewBooleanValue(true), false},
+		{`$.f`, types.NewNullValue(), false},
+		{`$.a.b[2]`, nil, false},
+		{`$.a.c`, nil, false},
+		{`$.a[0]`, nil, false},
+		{`$.a.b.c`, nil, false},
+		{`a`, nil, true},
+		{`$.`, nil, true},
+		{`$[a]`, nil, true},
+		{`$[-1]`, nil, true},
+		{`$."a`, nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			p, err := types.ParseJSONPath(test.path)
+			if test.fails {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			x, ok := p.Extract(doc)
+			if test.want == nil {
+				require.False(t, ok)
+				return
+			}
+			require.True(t, ok)
+			require.Equal(t, test.want, types.JSONToValue(x))
+		})
+	}
+
+	// objects and arrays are returned as documents
+	p, err := types.ParseJSONPath("$.a.b")
+	require.NoError(t, err)
+	x, ok := p.Extract(doc)
+	require.True(t, ok)
+	v := types.JSONToValue(x)
+	require.Equal(t, types.TypeJSON, v.Type())
+	require.Equal(t, `[10, {"c": "x"}]`, types.FormatJSON(types.AsByteSlice(v)))
+}
+
+func TestJSONPathSetRemove(t *testing.T) {
+	doc, err := types.ParseJSON([]byte(`{"a": {"b": [10, {"c": "x"}]}, "f": null}`))
+	require.NoError(t, err)
+
+	value, err := types.ParseJSON([]byte(`[true]`))
+	require.NoError(t, err)
+
+	tests := []struct {
+		path         string
+		set, removed string
+	}{
+		{`$`, `[true]`, ``},
+		{`$.f`, `{"a": {"b": [10, {"c": "x"}]}, "f": [true]}`, `{"a": {"b": [10, {"c": "x"}]}}`},
+		{`$.a.b[0]`, `{"a": {"b": [[true], {"c": "x"}]}, "f": null}`, `{"a": {"b": [{"c": "x"}]}, "f": null}`},
+		{`$.a.b[1].c`, `{"a": {"b": [10, {"c": [true]}]}, "f": null}`, `{"a": {"b": [10, {}]}, "f": null}`},
+		// missing fields are added, in order
+		{`$.0`, `{"0": [true], "a": {"b": [10, {"c": "x"}]}, "f": null}`, `{"a": {"b": [10, {"c": "x"}]}, "f": null}`},
+		{`$.c`, `{"a": {"b": [10, {"c": "x"}]}, "c": [true], "f": null}`, `{"a": {"b": [10, {"c": "x"}]}, "f": null}`},
+		{`$.z`, `{"a": {"b": [10, {"c": "x"}]}, "f": null, "z": [true]}`, `{"a": {"b": [10, {"c": "x"}]}, "f": null}`},
+		// so is the element following the last one of an array
+		{`$.a.b[2]`, `{"a": {"b": [10, {"c": "x"}, [true]]}, "f": null}`, `{"a": {"b": [10, {"c": "x"}]}, "f": null}`},
+		// other missing paths are ignored
+		{`$.a.b[3]`, `{"a": {"b": [10, {"c": "x"}]}, "f": null}`, `{"a": {"b": [10, {"c": "x"}]}, "f": null}`},
+		{`$.a.c.d`, `{"a": {"b": [10, {"c": "x"}]}, "f": null}`, `{"a": {"b": [10, {"c": "x"}]}, "f": null}`},
+		{`$.a.b.c`, `{"a": {"b": [10, {"c": "x"}]}, "f": null}`, `{"a": {"b": [10, {"c": "x"}]}, "f": null}`},
+		{`$[0]`, `{"a": {"b": [10, {"c": "x"}]}, "f": null}`, `{"a": {"b": [10, {"c": "x"}]}, "f": null}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			p, err := types.ParseJSONPath(test.path)
+			require.NoError(t, err)
+
+			set := p.Set(doc, value)
+			require.Equal(t, test.set, types.FormatJSON(set))
+			// the binary form is canonical
+			want, err := types.ParseJSON([]byte(test.set))
+			require.NoError(t, err)
+			require.Equal(t, want, set)
+
+			removed := p.Remove(doc)
+			if test.removed == "" {
+				require.Nil(t, removed)
+				return
+			}
+			require.Equal(t, test.removed, types.FormatJSON(removed))
+		})
+	}
+}
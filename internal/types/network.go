@@ -0,0 +1,385 @@
+package types
+
+import (
+	"net/netip"
+	"strconv"
+	"strings"
+
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/cockroachdb/errors"
+)
+
+var _ TypeDefinition = InetTypeDef{}
+
+type InetTypeDef struct{}
+
+func (InetTypeDef) New(v any) Value {
+	return NewInetValue(v.(netip.Prefix))
+}
+
+func (InetTypeDef) Type() Type {
+	return TypeInet
+}
+
+func (InetTypeDef) Decode(src []byte) (Value, int) {
+	p, _, n := encoding.DecodeNetwork(src)
+	return NewInetValue(p), n
+}
+
+func (InetTypeDef) IsComparableWith(other Type) bool {
+	return other == TypeInet || other == TypeCidr || other == TypeText
+}
+
+func (InetTypeDef) IsIndexComparableWith(other Type) bool {
+	return other == TypeInet
+}
+
+var _ Value = NewInetValue(netip.Prefix{})
+
+// InetValue is an IPv4 or IPv6 host address, with an optional
+// network prefix. Unlike CIDR values, the bits of the address
+// to the right of the prefix are kept.
+type InetValue netip.Prefix
+
+// NewInetValue returns a SQL INET value.
+func NewInetValue(p netip.Prefix) InetValue {
+	return InetValue(p)
+}
+
+func (v InetValue) V() any {
+	return netip.Prefix(v)
+}
+
+func (v InetValue) Type() Type {
+	return TypeInet
+}
+
+func (v InetValue) TypeDef() TypeDefinition {
+	return InetTypeDef{}
+}
+
+func (v InetValue) IsZero() (bool, error) {
+	return v == InetValue{}, nil
+}
+
+func (v InetValue) String() string {
+	return strconv.Quote(FormatInet(netip.Prefix(v)))
+}
+
+func (v InetValue) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+func (v InetValue) MarshalJSON() ([]byte, error) {
+	return v.MarshalText()
+}
+
+func (v InetValue) Encode(dst []byte) ([]byte, error) {
+	return encoding.EncodeNetwork(dst, encoding.InetValue, netip.Prefix(v)), nil
+}
+
+func (v InetValue) EncodeAsKey(dst []byte) ([]byte, error) {
+	return v.Encode(dst)
+}
+
+func (v InetValue) CastAs(target Type) (Value, error) {
+	switch target {
+	case TypeInet:
+		return v, nil
+	case TypeCidr:
+		return NewCidrValue(netip.Prefix(v).Masked()), nil
+	case TypeText:
+		return NewTextValue(FormatInet(netip.Prefix(v))), nil
+	}
+
+	return nil, errors.Errorf("cannot cast %s as %s", v.Type(), target)
+}
+
+func (v InetValue) EQ(other Value) (bool, error) {
+	c, ok, err := compareNetworkWith(netip.Prefix(v), other)
+	return ok && c == 0, err
+}
+
+func (v InetValue) GT(other Value) (bool, error) {
+	c, ok, err := compareNetworkWith(netip.Prefix(v), other)
+	return ok && c > 0, err
+}
+
+func (v InetValue) GTE(other Value) (bool, error) {
+	c, ok, err := compareNetworkWith(netip.Prefix(v), other)
+	return ok && c >= 0, err
+}
+
+func (v InetValue) LT(other Value) (bool, error) {
+	c, ok, err := compareNetworkWith(netip.Prefix(v), other)
+	return ok && c < 0, err
+}
+
+func (v InetValue) LTE(other Value) (bool, error) {
+	c, ok, err := compareNetworkWith(netip.Prefix(v), other)
+	return ok && c <= 0, err
+}
+
+func (v InetValue) Between(a, b Value) (bool, error) {
+	return betweenNetworks(v, a, b)
+}
+
+var _ TypeDefinition = CidrTypeDef{}
+
+type CidrTypeDef struct{}
+
+func (CidrTypeDef) New(v any) Value {
+	return NewCidrValue(v.(netip.Prefix))
+}
+
+func (CidrTypeDef) Type() Type {
+	return TypeCidr
+}
+
+func (CidrTypeDef) Decode(src []byte) (Value, int) {
+	p, _, n := encoding.DecodeNetwork(src)
+	return NewCidrValue(p), n
+}
+
+func (CidrTypeDef) IsComparableWith(other Type) bool {
+	return other == TypeInet || other == TypeCidr || other == TypeText
+}
+
+func (CidrTypeDef) IsIndexComparableWith(other Type) bool {
+	// CIDR values are converted to INET values without loss
+	return other == TypeCidr || other == TypeInet
+}
+
+var _ Value = NewCidrValue(netip.Prefix{})
+
+// CidrValue is an IPv4 or IPv6 network. The bits of its address
+// to the right of its prefix are always zero.
+type CidrValue netip.Prefix
+
+// NewCidrValue returns a SQL CIDR value.
+func NewCidrValue(p netip.Prefix) CidrValue {
+	return CidrValue(p)
+}
+
+func (v CidrValue) V() any {
+	return netip.Prefix(v)
+}
+
+func (v CidrValue) Type() Type {
+	return TypeCidr
+}
+
+func (v CidrValue) TypeDef() TypeDefinition {
+	return CidrTypeDef{}
+}
+
+func (v CidrValue) IsZero() (bool, error) {
+	return v == CidrValue{}, nil
+}
+
+func (v CidrValue) String() string {
+	return strconv.Quote(netip.Prefix(v).String())
+}
+
+func (v CidrValue) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+func (v CidrValue) MarshalJSON() ([]byte, error) {
+	return v.MarshalText()
+}
+
+func (v CidrValue) Encode(dst []byte) ([]byte, error) {
+	return encoding.EncodeNetwork(dst, encoding.CidrValue, netip.Prefix(v)), nil
+}
+
+func (v CidrValue) EncodeAsKey(dst []byte) ([]byte, error) {
+	return v.Encode(dst)
+}
+
+func (v CidrValue) CastAs(target Type) (Value, error) {
+	switch target {
+	case TypeCidr:
+		return v, nil
+	case TypeInet:
+		return NewInetValue(netip.Prefix(v)), nil
+	case TypeText:
+		return NewTextValue(netip.Prefix(v).String()), nil
+	}
+
+	return nil, errors.Errorf("cannot cast %s as %s", v.Type(), target)
+}
+
+func (v CidrValue) EQ(other Value) (bool, error) {
+	c, ok, err := compareNetworkWith(netip.Prefix(v), other)
+	return ok && c == 0, err
+}
+
+func (v CidrValue) GT(other Value) (bool, error) {
+	c, ok, err := compareNetworkWith(netip.Prefix(v), other)
+	return ok && c > 0, err
+}
+
+func (v CidrValue) GTE(other Value) (bool, error) {
+	c, ok, err := compareNetworkWith(netip.Prefix(v), other)
+	return ok && c >= 0, err
+}
+
+func (v CidrValue) LT(other Value) (bool, error) {
+	c, ok, err := compareNetworkWith(netip.Prefix(v), other)
+	return ok && c < 0, err
+}
+
+func (v CidrValue) LTE(other Value) (bool, error) {
+	c, ok, err := compareNetworkWith(netip.Prefix(v), other)
+	return ok && c <= 0, err
+}
+
+func (v CidrValue) Between(a, b Value) (bool, error) {
+	return betweenNetworks(v, a, b)
+}
+
+// ParseInet parses an IPv4 or IPv6 address, optionally followed by
+// the length of its network prefix, such as "192.168.1.5/24".
+// Addresses without a prefix are single hosts, as in "192.168.1.5/32".
+func ParseInet(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		p, err := netip.ParsePrefix(s)
+		if err != nil {
+			return netip.Prefix{}, errors.New("invalid inet")
+		}
+		return p, nil
+	}
+
+	addr, err := netip.ParseAddr(s)
+	if err != nil || addr.Zone() != "" {
+		return netip.Prefix{}, errors.New("invalid inet")
+	}
+
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// ParseCidr parses an IPv4 or IPv6 network, such as "192.168.1.0/24".
+// The bits of the address to the right of the prefix must be zero.
+func ParseCidr(s string) (netip.Prefix, error) {
+	p, err := ParseInet(s)
+	if err != nil {
+		return netip.Prefix{}, errors.New("invalid cidr")
+	}
+
+	if p.Masked() != p {
+		return netip.Prefix{}, errors.New("invalid cidr: the address has bits set to the right of the prefix")
+	}
+
+	return p, nil
+}
+
+// FormatInet returns the text representation of an INET value,
+// without prefix if the value is a single host.
+func FormatInet(p netip.Prefix) string {
+	if p.IsSingleIP() {
+		return p.Addr().String()
+	}
+
+	return p.String()
+}
+
+// asNetwork returns the network address of an INET or a CIDR value,
+// or of a text parsed as an INET value. It returns false for the other types.
+func asNetwork(v Value) (netip.Prefix, bool, error) {
+	switch v.Type() {
+	case TypeInet, TypeCidr:
+		return v.V().(netip.Prefix), true, nil
+	case TypeText:
+		p, err := ParseInet(AsString(v))
+		if err != nil {
+			return netip.Prefix{}, false, err
+		}
+		return p, true, nil
+	}
+
+	return netip.Prefix{}, false, nil
+}
+
+// compareNetworkWith compares network addresses in the order of their encoding:
+// IPv4 addresses first, then by address and by length of prefix.
+// It returns false if other is not a network address.
+func compareNetworkWith(p netip.Prefix, other Value) (int, bool, error) {
+	x, ok, err := asNetwork(other)
+	if !ok || err != nil {
+		return 0, false, err
+	}
+
+	if c := p.Addr().Compare(x.Addr()); c != 0 {
+		return c, true, nil
+	}
+
+	return p.Bits() - x.Bits(), true, nil
+}
+
+func betweenNetworks(v, a, b Value) (bool, error) {
+	var def InetTypeDef
+	if !def.IsComparableWith(a.Type()) || !def.IsComparableWith(b.Type()) {
+		return false, nil
+	}
+
+	ok, err := v.GTE(a)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	return v.LTE(b)
+}
+
+// NetworkContains returns whether the network a contains the address
+// or the network b: b must belong to the same family, be at least as specific
+// as a, and its address must be part of the network of a.
+// If strict is true, b must be strictly more specific than a.
+// It returns false if a or b are not network addresses.
+func NetworkContains(a, b Value, strict bool) (bool, error) {
+	x, ok, err := asNetwork(a)
+	if !ok || err != nil {
+		return false, err
+	}
+	y, ok, err := asNetwork(b)
+	if !ok || err != nil {
+		return false, err
+	}
+
+	if x.Addr().BitLen() != y.Addr().BitLen() {
+		return false, nil
+	}
+	if y.Bits() < x.Bits() || (strict && y.Bits() == x.Bits()) {
+		return false, nil
+	}
+
+	return x.Masked().Contains(y.Addr()), nil
+}
+
+// NetworkBounds returns the smallest and the largest values of the given type,
+// INET or CIDR, that can be contained in the network v.
+// All the values contained in v are sorted between these bounds.
+func NetworkBounds(v Value, tp Type) (Value, Value, error) {
+	p, ok, err := asNetwork(v)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !ok {
+		return nil, nil, errors.Errorf("cannot use %s as a network", v.Type())
+	}
+
+	first := p.Masked().Addr()
+	last := first.AsSlice()
+	for i := p.Bits(); i < len(last)*8; i++ {
+		last[i/8] |= 0x80 >> (i % 8)
+	}
+	lastAddr, _ := netip.AddrFromSlice(last)
+
+	lo := netip.PrefixFrom(first, 0)
+	hi := netip.PrefixFrom(lastAddr, lastAddr.BitLen())
+	if tp == TypeCidr {
+		return NewCidrValue(lo), NewCidrValue(hi), nil
+	}
+
+	return NewInetValue(lo), NewInetValue(hi), nil
+}
@@ -0,0 +1,66 @@
+package types_test
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/chaisql/chai/internal/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseInet(t *testing.T) {
+	tests := []struct {
+		s     string
+		want  string
+		fails bool
+	}{
+		{"192.168.1.5", "192.168.1.5/32", false},
+		{"192.168.1.5/24", "192.168.1.5/24", false},
+		{"::1", "::1/128", false},
+		{"2001:db8::/32", "2001:db8::/32", false},
+		{"192.168.1.256", "", true},
+		{"192.168.1.5/33", "", true},
+		{"fe80::1%eth0", "", true},
+		{"", "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.s, func(t *testing.T) {
+			p, err := types.ParseInet(test.s)
+			if test.fails {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, netip.MustParsePrefix(test.want), p)
+		})
+	}
+
+	_, err := types.ParseCidr("192.168.1.5/24")
+	require.Error(t, err)
+	p, err := types.ParseCidr("192.168.1.0/24")
+	require.NoError(t, err)
+	require.Equal(t, "192.168.1.0/24", p.String())
+}
+
+func TestNetworkBounds(t *testing.T) {
+	tests := []struct {
+		network     string
+		first, last string
+	}{
+		{"10.0.0.0/8", "10.0.0.0/0", "10.255.255.255/32"},
+		{"10.1.2.3/16", "10.1.0.0/0", "10.1.255.255/32"},
+		{"10.1.2.3", "10.1.2.3/0", "10.1.2.3/32"},
+		{"0.0.0.0/0", "0.0.0.0/0", "255.255.255.255/32"},
+		{"2001:db8::/33", "2001:db8::/0", "2001:db8:7fff:ffff:ffff:ffff:ffff:ffff/128"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.network, func(t *testing.T) {
+			first, last, err := types.NetworkBounds(types.NewTextValue(test.network), types.TypeCidr)
+			require.NoError(t, err)
+			require.Equal(t, netip.MustParsePrefix(test.first), first.V())
+			require.Equal(t, netip.MustParsePrefix(test.last), last.V())
+		})
+	}
+}
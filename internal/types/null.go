@@ -18,7 +18,9 @@ func (NullTypeDef) Type() Type {
 }
 
 func (NullTypeDef) Decode(src []byte) (Value, int) {
-	if src[0] != encoding.NullValue && src[0] != encoding.DESC_NullValue {
+	switch src[0] {
+	case encoding.NullValue, encoding.DESC_NullValue, encoding.NullLastValue, encoding.DESC_NullLastValue:
+	default:
 		panic(errors.New("invalid encoded null value"))
 	}
 
@@ -1,5 +1,7 @@
 package types
 
+import "github.com/cockroachdb/errors"
+
 type Numeric interface {
 	Value
 
@@ -35,6 +37,32 @@ type Integral interface {
 	// Only numeric values and booleans can be calculated together.
 	// If both v and u are integers, the result will be an integer.
 	BitwiseXor(other Numeric) (Value, error)
+	// ShiftLeft calculates v << u and returns the result, which has the type of v.
+	// Bits shifted beyond the size of v are discarded.
+	ShiftLeft(other Numeric) (Value, error)
+	// ShiftRight calculates v >> u and returns the result, which has the type of v.
+	// Signed values are shifted arithmetically, keeping their sign.
+	ShiftRight(other Numeric) (Value, error)
+}
+
+// shiftCount returns the number of bits of a shift, which must be an integer
+// and must not be negative. Counts larger than 64 are returned as 64.
+func shiftCount(other Numeric) (uint, error) {
+	var n int64
+	switch other.Type() {
+	case TypeInteger, TypeBigint:
+		n = AsInt64(other)
+	case TypeUnsignedBigint:
+		n = int64(min(AsUint64(other), 64))
+	default:
+		return 0, errors.Errorf("cannot shift by a value of type %s", other.Type())
+	}
+
+	if n < 0 {
+		return 0, errors.New("negative shift count")
+	}
+
+	return uint(min(n, 64)), nil
 }
 
 func isMulOverflow[T int32 | int64](left, right, min, max T) bool {
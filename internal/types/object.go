@@ -0,0 +1,376 @@
+package types
+
+import (
+	"bytes"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/cockroachdb/errors"
+)
+
+var _ TypeDefinition = ObjectTypeDef{}
+
+type ObjectTypeDef struct{}
+
+func (ObjectTypeDef) New(v any) Value {
+	return NewObjectValue(v.([]byte))
+}
+
+func (ObjectTypeDef) Type() Type {
+	return TypeObject
+}
+
+func (ObjectTypeDef) Decode(src []byte) (Value, int) {
+	n := encoding.Skip(src)
+	return NewObjectValue(src[:n]), n
+}
+
+func (ObjectTypeDef) IsComparableWith(other Type) bool {
+	return other == TypeObject
+}
+
+func (ObjectTypeDef) IsIndexComparableWith(other Type) bool {
+	return other == TypeObject
+}
+
+var _ Value = NewObjectValue(nil)
+
+// ObjectValue is an object stored in a binary form, which can be
+// traversed without being decoded. Unlike JSONB documents, the values
+// of its fields keep their SQL type. See MakeObject.
+type ObjectValue []byte
+
+// NewObjectValue returns a SQL OBJECT value from its binary form.
+func NewObjectValue(enc []byte) ObjectValue {
+	return ObjectValue(enc)
+}
+
+// ObjectField is a field of an object.
+type ObjectField struct {
+	Name  string
+	Value Value
+}
+
+// MakeObject returns an object made of the given fields.
+// The fields are sorted by name, and if a name appears more than once,
+// the last value is kept. See encodeNestedValue for the types of the values.
+func MakeObject(fields ...ObjectField) (ObjectValue, error) {
+	fields = slices.Clone(fields)
+	slices.SortStableFunc(fields, func(a, b ObjectField) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	// keep the last value of each name
+	unique := fields[:0]
+	for i, f := range fields {
+		if i+1 < len(fields) && fields[i+1].Name == f.Name {
+			continue
+		}
+		unique = append(unique, f)
+	}
+
+	var err error
+	dst := encoding.EncodeObjectHeader(nil, len(unique))
+	for _, f := range unique {
+		dst = encoding.EncodeText(dst, f.Name)
+		dst, err = encodeNestedValue(dst, f.Value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "field %q", f.Name)
+		}
+	}
+
+	return NewObjectValue(dst), nil
+}
+
+func (v ObjectValue) V() any {
+	return []byte(v)
+}
+
+func (v ObjectValue) Type() Type {
+	return TypeObject
+}
+
+func (v ObjectValue) TypeDef() TypeDefinition {
+	return ObjectTypeDef{}
+}
+
+func (v ObjectValue) IsZero() (bool, error) {
+	return v == nil, nil
+}
+
+// Get returns the value of a field of the object,
+// and false if the field doesn't exist.
+func (v ObjectValue) Get(field string) (Value, bool) {
+	enc, ok := extractJSONField(v, field)
+	if !ok {
+		return nil, false
+	}
+
+	return decodeNestedValue(enc), true
+}
+
+// Iterate calls fn for each field of the object, in order.
+func (v ObjectValue) Iterate(fn func(field string, value Value) error) error {
+	l, n := encoding.DecodeHeader(v)
+	for i := 0; i < l; i++ {
+		k, m := encoding.DecodeText(v[n:])
+		n += m
+
+		enc := v[n:]
+		n += encoding.Skip(enc)
+		err := fn(k, decodeNestedValue(enc))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// String returns the object using the syntax of object literals.
+func (v ObjectValue) String() string {
+	var sb strings.Builder
+
+	sb.WriteByte('{')
+	_ = v.Iterate(func(field string, value Value) error {
+		if sb.Len() > 1 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(strconv.Quote(field))
+		sb.WriteString(": ")
+		sb.WriteString(value.String())
+		return nil
+	})
+	sb.WriteByte('}')
+
+	return sb.String()
+}
+
+func (v ObjectValue) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+func (v ObjectValue) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+
+	buf.WriteByte('{')
+	err := v.Iterate(func(field string, value Value) error {
+		if buf.Len() > 1 {
+			buf.WriteString(", ")
+		}
+		buf.WriteString(strconv.Quote(field))
+		buf.WriteString(": ")
+		data, err := value.MarshalJSON()
+		if err != nil {
+			return err
+		}
+		buf.Write(data)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+func (v ObjectValue) Encode(dst []byte) ([]byte, error) {
+	return append(dst, v...), nil
+}
+
+func (v ObjectValue) EncodeAsKey(dst []byte) ([]byte, error) {
+	return v.Encode(dst)
+}
+
+func (v ObjectValue) CastAs(target Type) (Value, error) {
+	switch target {
+	case TypeObject:
+		return v, nil
+	case TypeText, TypeJSON:
+		return castNestedAs(v, target)
+	}
+
+	return nil, errors.Errorf("cannot cast %s as %s", v.Type(), target)
+}
+
+func (v ObjectValue) EQ(other Value) (bool, error) {
+	return compareNested(v, other) == 0, nil
+}
+
+func (v ObjectValue) GT(other Value) (bool, error) {
+	return compareNested(v, other) > 0, nil
+}
+
+func (v ObjectValue) GTE(other Value) (bool, error) {
+	return compareNested(v, other) >= 0, nil
+}
+
+func (v ObjectValue) LT(other Value) (bool, error) {
+	c := compareNested(v, other)
+	return c < 0 && c != incomparable, nil
+}
+
+func (v ObjectValue) LTE(other Value) (bool, error) {
+	c := compareNested(v, other)
+	return c <= 0 && c != incomparable, nil
+}
+
+func (v ObjectValue) Between(a, b Value) (bool, error) {
+	return betweenNested(v, a, b)
+}
+
+// incomparable is returned by compareNested when the values
+// are not of the same type.
+const incomparable = -2
+
+// compareNested compares objects and arrays using the order of their
+// binary form, which compares the fields and the elements one by one.
+// It returns incomparable if the values are not of the same type.
+func compareNested(v, other Value) int {
+	if other.Type() != v.Type() {
+		return incomparable
+	}
+
+	c := encoding.Compare(AsByteSlice(v), AsByteSlice(other))
+	switch {
+	case c < 0:
+		return -1
+	case c > 0:
+		return 1
+	}
+	return 0
+}
+
+func betweenNested(v, a, b Value) (bool, error) {
+	if a.Type() != v.Type() || b.Type() != v.Type() {
+		return false, nil
+	}
+
+	return compareNested(v, a) >= 0 && compareNested(v, b) <= 0, nil
+}
+
+// castNestedAs converts objects and arrays to their JSON representation.
+func castNestedAs(v Value, target Type) (Value, error) {
+	data, err := v.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	if target == TypeText {
+		return NewTextValue(string(data)), nil
+	}
+
+	doc, err := ParseJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	return NewJSONValue(doc), nil
+}
+
+// encodeNestedValue appends the binary form of a value of an object or an array.
+// NULL, booleans, integers, doubles, texts, blobs, objects and arrays keep
+// their type. The other types are converted: REAL to DOUBLE, timestamps,
+// UUIDs, network addresses, CITEXT, points and intervals to TEXT, and JSONB
+// documents to objects, arrays or scalars.
+func encodeNestedValue(dst []byte, v Value) ([]byte, error) {
+	var err error
+
+	switch v.Type() {
+	case TypeObject, TypeArray:
+		return append(dst, AsByteSlice(v)...), nil
+	case TypeJSON:
+		dst, _ = encodeJSONAsNested(dst, AsByteSlice(v))
+		return dst, nil
+	case TypeReal:
+		v, err = v.CastAs(TypeDouble)
+	case TypeTimestamp, TypeTimestampTZ, TypeUUID, TypeInet, TypeCidr, TypeCitext, TypePoint, TypeInterval:
+		v, err = v.CastAs(TypeText)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// the key encoding keeps doubles as doubles, even when they are round
+	return v.EncodeAsKey(dst)
+}
+
+// encodeJSONAsNested appends the first value of a document returned by
+// ParseJSON, converted to the binary form of objects and arrays, and returns
+// the number of bytes read. Round numbers are converted to integers,
+// the other numbers to doubles.
+func encodeJSONAsNested(dst []byte, doc []byte) ([]byte, int) {
+	switch doc[0] {
+	case encoding.ArrayValue:
+		l, n := encoding.DecodeHeader(doc)
+		dst = encoding.EncodeArrayHeader(dst, l)
+		for i := 0; i < l; i++ {
+			var m int
+			dst, m = encodeJSONAsNested(dst, doc[n:])
+			n += m
+		}
+		return dst, n
+	case encoding.ObjectValue:
+		l, n := encoding.DecodeHeader(doc)
+		dst = encoding.EncodeObjectHeader(dst, l)
+		for i := 0; i < l; i++ {
+			m := encoding.Skip(doc[n:])
+			dst = append(dst, doc[n:n+m]...)
+			n += m
+			dst, m = encodeJSONAsNested(dst, doc[n:])
+			n += m
+		}
+		return dst, n
+	case encoding.Float64Value:
+		f, n := encoding.DecodeFloat(doc)
+		// integers larger than 2^53 may not have been represented exactly
+		if f == float64(int64(f)) && f >= -(1<<53) && f <= 1<<53 {
+			return encoding.EncodeInt(dst, int64(f)), n
+		}
+		return encoding.EncodeFloat64(dst, f), n
+	}
+
+	n := encoding.Skip(doc)
+	return append(dst, doc[:n]...), n
+}
+
+// decodeNestedValue decodes the first value of an object or an array.
+// Objects and arrays are copied.
+func decodeNestedValue(enc []byte) Value {
+	switch enc[0] {
+	case encoding.ObjectValue:
+		return NewObjectValue(bytes.Clone(enc[:encoding.Skip(enc)]))
+	case encoding.ArrayValue:
+		return NewArrayValue(bytes.Clone(enc[:encoding.Skip(enc)]))
+	}
+
+	v, _ := DecodeValue(enc)
+	return v
+}
+
+// parseNested parses a JSON text and converts it to an object or an array.
+func parseNested(s string, target Type) (Value, error) {
+	doc, err := ParseJSON([]byte(s))
+	if err != nil {
+		return nil, err
+	}
+
+	return jsonAsNested(doc, target)
+}
+
+// jsonAsNested converts a document returned by ParseJSON
+// to an object or an array.
+func jsonAsNested(doc []byte, target Type) (Value, error) {
+	switch {
+	case target == TypeObject && doc[0] == encoding.ObjectValue:
+		enc, _ := encodeJSONAsNested(nil, doc)
+		return NewObjectValue(enc), nil
+	case target == TypeArray && doc[0] == encoding.ArrayValue:
+		enc, _ := encodeJSONAsNested(nil, doc)
+		return NewArrayValue(enc), nil
+	}
+
+	return nil, errors.Errorf("%s is not an %s", FormatJSON(doc), target)
+}
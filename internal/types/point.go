@@ -0,0 +1,252 @@
+package types
+
+import (
+	"bytes"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/cockroachdb/errors"
+)
+
+var _ TypeDefinition = PointTypeDef{}
+
+type PointTypeDef struct{}
+
+func (PointTypeDef) New(v any) Value {
+	return NewPointValue(v.(Point))
+}
+
+func (PointTypeDef) Type() Type {
+	return TypePoint
+}
+
+func (PointTypeDef) Decode(src []byte) (Value, int) {
+	lat, lon, n := encoding.DecodePoint(src)
+	return NewPointValue(Point{Lat: lat, Lon: lon}), n
+}
+
+func (PointTypeDef) IsComparableWith(other Type) bool {
+	return other == TypePoint || other == TypeText
+}
+
+func (PointTypeDef) IsIndexComparableWith(other Type) bool {
+	return other == TypePoint
+}
+
+// Point is a location on Earth, in degrees.
+type Point struct {
+	Lat float64
+	Lon float64
+}
+
+// NewPoint returns a point, or an error if the latitude is not
+// within [-90, 90] or the longitude is not within [-180, 180].
+func NewPoint(lat, lon float64) (Point, error) {
+	if !(lat >= -90 && lat <= 90) {
+		return Point{}, errors.New("latitude must be within [-90, 90]")
+	}
+	if !(lon >= -180 && lon <= 180) {
+		return Point{}, errors.New("longitude must be within [-180, 180]")
+	}
+
+	return Point{Lat: lat, Lon: lon}, nil
+}
+
+// earthRadius is the mean radius of the Earth, in meters.
+const earthRadius = 6_371_008.8
+
+// Distance returns the great-circle distance between two points, in meters.
+func (p Point) Distance(other Point) float64 {
+	lat1 := p.Lat * math.Pi / 180
+	lat2 := other.Lat * math.Pi / 180
+	dlat := lat2 - lat1
+	dlon := (other.Lon - p.Lon) * math.Pi / 180
+
+	h := math.Sin(dlat/2)*math.Sin(dlat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dlon/2)*math.Sin(dlon/2)
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// InBox returns whether the point is within the bounding box whose
+// south-west and north-east corners are sw and ne, edges included.
+// If the longitude of sw is greater than the one of ne, the box
+// crosses the antimeridian.
+func (p Point) InBox(sw, ne Point) bool {
+	if p.Lat < sw.Lat || p.Lat > ne.Lat {
+		return false
+	}
+
+	if sw.Lon <= ne.Lon {
+		return p.Lon >= sw.Lon && p.Lon <= ne.Lon
+	}
+
+	return p.Lon >= sw.Lon || p.Lon <= ne.Lon
+}
+
+var _ Value = NewPointValue(Point{})
+
+// PointValue is a geographic point. Its key encoding follows
+// a Z-order curve, see encoding.EncodePoint.
+type PointValue Point
+
+// NewPointValue returns a SQL POINT value.
+func NewPointValue(p Point) PointValue {
+	return PointValue(p)
+}
+
+func (v PointValue) V() any {
+	return Point(v)
+}
+
+func (v PointValue) Type() Type {
+	return TypePoint
+}
+
+func (v PointValue) TypeDef() TypeDefinition {
+	return PointTypeDef{}
+}
+
+func (v PointValue) IsZero() (bool, error) {
+	return v == PointValue{}, nil
+}
+
+func (v PointValue) String() string {
+	return strconv.Quote(FormatPoint(Point(v)))
+}
+
+func (v PointValue) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+func (v PointValue) MarshalJSON() ([]byte, error) {
+	return v.MarshalText()
+}
+
+func (v PointValue) Encode(dst []byte) ([]byte, error) {
+	return encoding.EncodePoint(dst, v.Lat, v.Lon), nil
+}
+
+func (v PointValue) EncodeAsKey(dst []byte) ([]byte, error) {
+	return v.Encode(dst)
+}
+
+func (v PointValue) CastAs(target Type) (Value, error) {
+	switch target {
+	case TypePoint:
+		return v, nil
+	case TypeText:
+		return NewTextValue(FormatPoint(Point(v))), nil
+	}
+
+	return nil, errors.Errorf("cannot cast %s as %s", v.Type(), target)
+}
+
+func (v PointValue) EQ(other Value) (bool, error) {
+	c, ok, err := comparePointWith(Point(v), other)
+	return ok && c == 0, err
+}
+
+func (v PointValue) GT(other Value) (bool, error) {
+	c, ok, err := comparePointWith(Point(v), other)
+	return ok && c > 0, err
+}
+
+func (v PointValue) GTE(other Value) (bool, error) {
+	c, ok, err := comparePointWith(Point(v), other)
+	return ok && c >= 0, err
+}
+
+func (v PointValue) LT(other Value) (bool, error) {
+	c, ok, err := comparePointWith(Point(v), other)
+	return ok && c < 0, err
+}
+
+func (v PointValue) LTE(other Value) (bool, error) {
+	c, ok, err := comparePointWith(Point(v), other)
+	return ok && c <= 0, err
+}
+
+func (v PointValue) Between(a, b Value) (bool, error) {
+	var def PointTypeDef
+	if !def.IsComparableWith(a.Type()) || !def.IsComparableWith(b.Type()) {
+		return false, nil
+	}
+
+	ok, err := v.GTE(a)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	return v.LTE(b)
+}
+
+// ParsePoint parses a point written as its latitude and longitude,
+// separated by a comma and optionally surrounded by parentheses,
+// such as "(48.8566, 2.3522)".
+func ParsePoint(s string) (Point, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") {
+		s = s[1 : len(s)-1]
+	}
+
+	slat, slon, ok := strings.Cut(s, ",")
+	if !ok {
+		return Point{}, errors.New("invalid point")
+	}
+
+	lat, err := strconv.ParseFloat(strings.TrimSpace(slat), 64)
+	if err != nil {
+		return Point{}, errors.New("invalid point")
+	}
+	lon, err := strconv.ParseFloat(strings.TrimSpace(slon), 64)
+	if err != nil {
+		return Point{}, errors.New("invalid point")
+	}
+
+	return NewPoint(lat, lon)
+}
+
+// FormatPoint returns the text representation of a point,
+// such as "(48.8566, 2.3522)".
+func FormatPoint(p Point) string {
+	var sb strings.Builder
+
+	sb.WriteByte('(')
+	sb.WriteString(strconv.FormatFloat(p.Lat, 'f', -1, 64))
+	sb.WriteString(", ")
+	sb.WriteString(strconv.FormatFloat(p.Lon, 'f', -1, 64))
+	sb.WriteByte(')')
+
+	return sb.String()
+}
+
+// AsPoint returns the point of a POINT value, or of a text parsed
+// as a point. It returns false for the other types.
+func AsPoint(v Value) (Point, bool, error) {
+	switch v.Type() {
+	case TypePoint:
+		return v.V().(Point), true, nil
+	case TypeText:
+		p, err := ParsePoint(AsString(v))
+		if err != nil {
+			return Point{}, false, err
+		}
+		return p, true, nil
+	}
+
+	return Point{}, false, nil
+}
+
+// comparePointWith compares points in the order of their encoding.
+// It returns false if other is not a point.
+func comparePointWith(p Point, other Value) (int, bool, error) {
+	x, ok, err := AsPoint(other)
+	if !ok || err != nil {
+		return 0, false, err
+	}
+
+	a := encoding.EncodePoint(nil, p.Lat, p.Lon)
+	b := encoding.EncodePoint(nil, x.Lat, x.Lon)
+	return bytes.Compare(a, b), true, nil
+}
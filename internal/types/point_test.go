@@ -0,0 +1,58 @@
+package types_test
+
+import (
+	"testing"
+
+	"github.com/chaisql/chai/internal/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePoint(t *testing.T) {
+	tests := []struct {
+		s     string
+		want  types.Point
+		fails bool
+	}{
+		{"(48.8566, 2.3522)", types.Point{Lat: 48.8566, Lon: 2.3522}, false},
+		{"-33.8688,151.2093", types.Point{Lat: -33.8688, Lon: 151.2093}, false},
+		{" ( 0 , 0 ) ", types.Point{}, false},
+		{"(90, -180)", types.Point{Lat: 90, Lon: -180}, false},
+		{"(90.1, 0)", types.Point{}, true},
+		{"(0, 180.1)", types.Point{}, true},
+		{"(NaN, 0)", types.Point{}, true},
+		{"(1 2)", types.Point{}, true},
+		{"", types.Point{}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.s, func(t *testing.T) {
+			p, err := types.ParsePoint(test.s)
+			if test.fails {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.want, p)
+		})
+	}
+}
+
+func TestPointDistance(t *testing.T) {
+	paris := types.Point{Lat: 48.8566, Lon: 2.3522}
+	london := types.Point{Lat: 51.5074, Lon: -0.1278}
+
+	require.Zero(t, paris.Distance(paris))
+	require.InDelta(t, 343_556, paris.Distance(london), 1)
+	require.Equal(t, paris.Distance(london), london.Distance(paris))
+}
+
+func TestPointInBox(t *testing.T) {
+	sw := types.Point{Lat: -10, Lon: 170}
+	ne := types.Point{Lat: 10, Lon: -170}
+
+	require.True(t, types.Point{Lat: 0, Lon: 175}.InBox(sw, ne))
+	require.True(t, types.Point{Lat: 0, Lon: -175}.InBox(sw, ne))
+	require.False(t, types.Point{Lat: 0, Lon: 0}.InBox(sw, ne))
+	require.False(t, types.Point{Lat: 11, Lon: 175}.InBox(sw, ne))
+	require.True(t, types.Point{Lat: 10, Lon: 170}.InBox(sw, ne))
+}
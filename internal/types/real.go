@@ -0,0 +1,260 @@
+package types
+
+import (
+	"cmp"
+	"math"
+	"strconv"
+
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/cockroachdb/errors"
+)
+
+var _ TypeDefinition = RealTypeDef{}
+
+type RealTypeDef struct{}
+
+func (RealTypeDef) New(v any) Value {
+	return NewRealValue(v.(float32))
+}
+
+func (RealTypeDef) Type() Type {
+	return TypeReal
+}
+
+func (RealTypeDef) Decode(src []byte) (Value, int) {
+	return NewRealValue(encoding.DecodeFloat32(src[1:])), 5
+}
+
+func (RealTypeDef) IsComparableWith(other Type) bool {
+	return other == TypeReal || other.IsNumber()
+}
+
+func (RealTypeDef) IsIndexComparableWith(other Type) bool {
+	return other == TypeReal || other == TypeDouble
+}
+
+var _ Numeric = NewRealValue(0)
+
+// RealValue is a single precision floating point number.
+// Arithmetic between two REAL values returns a REAL, any other
+// number is promoted to DOUBLE along with the REAL value.
+// When compared with a DOUBLE, the DOUBLE is rounded to single precision,
+// so that REAL columns can be compared with double literals such as 0.1.
+type RealValue float32
+
+// NewRealValue returns a SQL REAL value.
+func NewRealValue(x float32) RealValue {
+	return RealValue(x)
+}
+
+func (v RealValue) V() any {
+	return float32(v)
+}
+
+func (v RealValue) Type() Type {
+	return TypeReal
+}
+
+func (v RealValue) TypeDef() TypeDefinition {
+	return RealTypeDef{}
+}
+
+func (v RealValue) IsZero() (bool, error) {
+	return v == 0, nil
+}
+
+func (v RealValue) String() string {
+	f := float32(v)
+	prec := -1
+	// if the number is round, add .0
+	if float32(int64(f)) == f {
+		prec = 1
+	}
+	return strconv.FormatFloat(float64(f), realFormat(f), prec, 32)
+}
+
+func (v RealValue) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+func (v RealValue) MarshalJSON() ([]byte, error) {
+	f := float32(v)
+	return strconv.AppendFloat(nil, float64(f), realFormat(f), -1, 32), nil
+}
+
+// realFormat returns the format used by strconv to write f.
+func realFormat(f float32) byte {
+	abs := math.Abs(float64(f))
+	if abs != 0 && (abs < 1e-6 || abs >= 1e15) {
+		return 'e'
+	}
+
+	return 'f'
+}
+
+func (v RealValue) Encode(dst []byte) ([]byte, error) {
+	return encoding.EncodeFloat32(dst, float32(v)), nil
+}
+
+func (v RealValue) EncodeAsKey(dst []byte) ([]byte, error) {
+	return v.Encode(dst)
+}
+
+func (v RealValue) CastAs(target Type) (Value, error) {
+	switch target {
+	case TypeReal:
+		return v, nil
+	case TypeDouble:
+		return NewDoubleValue(float64(v)), nil
+	case TypeInteger, TypeBigint, TypeUnsignedBigint:
+		return NewDoubleValue(float64(v)).CastAs(target)
+	case TypeText:
+		enc, err := v.MarshalJSON()
+		if err != nil {
+			return nil, err
+		}
+		return NewTextValue(string(enc)), nil
+	}
+
+	return nil, errors.Errorf("cannot cast %s as %s", v.Type(), target)
+}
+
+// compare returns -1, 0 or 1 depending on whether v is lower than,
+// equal to or greater than other. It returns false if other is not a number.
+func (v RealValue) compare(other Value) (int, bool) {
+	switch other.Type() {
+	case TypeReal:
+		return cmp.Compare(float32(v), AsFloat32(other)), true
+	case TypeDouble:
+		return cmp.Compare(float32(v), float32(AsFloat64(other))), true
+	case TypeInteger, TypeBigint, TypeUnsignedBigint:
+		return cmp.Compare(float64(v), numericAsFloat64(other)), true
+	}
+
+	return 0, false
+}
+
+func (v RealValue) EQ(other Value) (bool, error) {
+	c, ok := v.compare(other)
+	return ok && c == 0, nil
+}
+
+func (v RealValue) GT(other Value) (bool, error) {
+	c, ok := v.compare(other)
+	return ok && c > 0, nil
+}
+
+func (v RealValue) GTE(other Value) (bool, error) {
+	c, ok := v.compare(other)
+	return ok && c >= 0, nil
+}
+
+func (v RealValue) LT(other Value) (bool, error) {
+	c, ok := v.compare(other)
+	return ok && c < 0, nil
+}
+
+func (v RealValue) LTE(other Value) (bool, error) {
+	c, ok := v.compare(other)
+	return ok && c <= 0, nil
+}
+
+func (v RealValue) Between(a, b Value) (bool, error) {
+	if !a.Type().IsNumber() || !b.Type().IsNumber() {
+		return false, nil
+	}
+
+	ok, err := a.LTE(v)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	return b.GTE(v)
+}
+
+func (v RealValue) Add(other Numeric) (Value, error) {
+	switch other.Type() {
+	case TypeReal:
+		return NewRealValue(float32(v) + AsFloat32(other)), nil
+	case TypeDouble, TypeInteger, TypeBigint, TypeUnsignedBigint:
+		return NewDoubleValue(float64(v) + numericAsFloat64(other)), nil
+	}
+
+	return NewNullValue(), nil
+}
+
+func (v RealValue) Sub(other Numeric) (Value, error) {
+	switch other.Type() {
+	case TypeReal:
+		return NewRealValue(float32(v) - AsFloat32(other)), nil
+	case TypeDouble, TypeInteger, TypeBigint, TypeUnsignedBigint:
+		return NewDoubleValue(float64(v) - numericAsFloat64(other)), nil
+	}
+
+	return NewNullValue(), nil
+}
+
+func (v RealValue) Mul(other Numeric) (Value, error) {
+	switch other.Type() {
+	case TypeReal:
+		return NewRealValue(float32(v) * AsFloat32(other)), nil
+	case TypeDouble, TypeInteger, TypeBigint, TypeUnsignedBigint:
+		return NewDoubleValue(float64(v) * numericAsFloat64(other)), nil
+	}
+
+	return NewNullValue(), nil
+}
+
+func (v RealValue) Div(other Numeric) (Value, error) {
+	switch other.Type() {
+	case TypeReal:
+		xb := AsFloat32(other)
+		if xb == 0 {
+			return NewNullValue(), nil
+		}
+
+		return NewRealValue(float32(v) / xb), nil
+	case TypeDouble, TypeInteger, TypeBigint, TypeUnsignedBigint:
+		xb := numericAsFloat64(other)
+		if xb == 0 {
+			return NewNullValue(), nil
+		}
+
+		return NewDoubleValue(float64(v) / xb), nil
+	}
+
+	return NewNullValue(), nil
+}
+
+func (v RealValue) Mod(other Numeric) (Value, error) {
+	switch other.Type() {
+	case TypeReal:
+		xr := math.Mod(float64(v), float64(AsFloat32(other)))
+		if math.IsNaN(xr) {
+			return NewNullValue(), nil
+		}
+
+		return NewRealValue(float32(xr)), nil
+	case TypeDouble, TypeInteger, TypeBigint, TypeUnsignedBigint:
+		xr := math.Mod(float64(v), numericAsFloat64(other))
+		if math.IsNaN(xr) {
+			return NewNullValue(), nil
+		}
+
+		return NewDoubleValue(xr), nil
+	}
+
+	return NewNullValue(), nil
+}
+
+// numericAsFloat64 returns the value of any number as a float64.
+func numericAsFloat64(v Value) float64 {
+	switch v.Type() {
+	case TypeInteger, TypeBigint:
+		return float64(AsInt64(v))
+	case TypeUnsignedBigint:
+		return float64(AsUint64(v))
+	}
+
+	return AsFloat64(v)
+}
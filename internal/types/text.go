@@ -28,11 +28,13 @@ func (TextTypeDef) Decode(src []byte) (Value, int) {
 }
 
 func (TextTypeDef) IsComparableWith(other Type) bool {
-	return other == TypeNull || other == TypeText || other == TypeBoolean || other == TypeInteger || other == TypeBigint || other == TypeDouble || other == TypeTimestamp || other == TypeBlob
+	return other == TypeNull || other == TypeText || other == TypeBoolean || other == TypeInteger || other == TypeBigint || other == TypeUnsignedBigint || other == TypeDouble || other == TypeReal || other == TypeTimestamp || other == TypeTimestampTZ || other == TypeBlob || other == TypeUUID || other == TypeInet || other == TypeCidr || other == TypeCitext || other == TypePoint || other == TypeInterval
 }
 
 func (t TextTypeDef) IsIndexComparableWith(other Type) bool {
-	return t.IsComparableWith(other)
+	// texts compared to TIMESTAMPTZ values depend on the time zone
+	// of the transaction, and can't be converted in advance.
+	return other != TypeTimestampTZ && t.IsComparableWith(other)
 }
 
 var _ Value = NewTextValue("")
@@ -84,6 +86,8 @@ func (v TextValue) CastAs(target Type) (Value, error) {
 	switch target {
 	case TypeText:
 		return v, nil
+	case TypeCitext:
+		return NewCitextValue(string(v)), nil
 	case TypeBoolean:
 		b, err := strconv.ParseBool(string(v))
 		if err != nil {
@@ -112,18 +116,83 @@ func (v TextValue) CastAs(target Type) (Value, error) {
 			i = int64(f)
 		}
 		return NewBigintValue(i), nil
+	case TypeUnsignedBigint:
+		i, err := strconv.ParseUint(string(v), 10, 64)
+		if err != nil {
+			intErr := err
+			f, err := strconv.ParseFloat(string(v), 64)
+			if err != nil {
+				return nil, fmt.Errorf(`cannot cast %q as bigint unsigned: %w`, v.V(), intErr)
+			}
+			return NewDoubleValue(f).CastAs(TypeUnsignedBigint)
+		}
+		return NewUnsignedBigintValue(i), nil
 	case TypeDouble:
 		f, err := strconv.ParseFloat(string(v), 64)
 		if err != nil {
 			return nil, fmt.Errorf(`cannot cast %q as double: %w`, v.V(), err)
 		}
 		return NewDoubleValue(f), nil
+	case TypeReal:
+		f, err := strconv.ParseFloat(string(v), 32)
+		if err != nil {
+			return nil, fmt.Errorf(`cannot cast %q as real: %w`, v.V(), err)
+		}
+		return NewRealValue(float32(f)), nil
 	case TypeTimestamp:
 		t, err := ParseTimestamp(string(v))
 		if err != nil {
 			return nil, fmt.Errorf(`cannot cast %q as timestamp: %w`, v.V(), err)
 		}
 		return NewTimestampValue(t), nil
+	case TypeTimestampTZ:
+		t, err := ParseTimestamp(string(v))
+		if err != nil {
+			return nil, fmt.Errorf(`cannot cast %q as timestamptz: %w`, v.V(), err)
+		}
+		return NewTimestampTZValue(t), nil
+	case TypeUUID:
+		x, err := ParseUUID(string(v))
+		if err != nil {
+			return nil, fmt.Errorf(`cannot cast %q as uuid: %w`, v.V(), err)
+		}
+		return NewUUIDValue(x), nil
+	case TypeInet:
+		p, err := ParseInet(string(v))
+		if err != nil {
+			return nil, fmt.Errorf(`cannot cast %q as inet: %w`, v.V(), err)
+		}
+		return NewInetValue(p), nil
+	case TypeCidr:
+		p, err := ParseCidr(string(v))
+		if err != nil {
+			return nil, fmt.Errorf(`cannot cast %q as cidr: %w`, v.V(), err)
+		}
+		return NewCidrValue(p), nil
+	case TypePoint:
+		p, err := ParsePoint(string(v))
+		if err != nil {
+			return nil, fmt.Errorf(`cannot cast %q as point: %w`, v.V(), err)
+		}
+		return NewPointValue(p), nil
+	case TypeInterval:
+		iv, err := ParseInterval(string(v))
+		if err != nil {
+			return nil, fmt.Errorf(`cannot cast %q as interval: %w`, v.V(), err)
+		}
+		return NewIntervalValue(iv), nil
+	case TypeJSON:
+		doc, err := ParseJSON([]byte(v))
+		if err != nil {
+			return nil, fmt.Errorf(`cannot cast %q as jsonb: %w`, v.V(), err)
+		}
+		return NewJSONValue(doc), nil
+	case TypeObject, TypeArray:
+		x, err := parseNested(string(v), target)
+		if err != nil {
+			return nil, fmt.Errorf(`cannot cast %q as %s: %w`, v.V(), target, err)
+		}
+		return x, nil
 	case TypeBlob:
 		s := string(v)
 		b, err := base64.StdEncoding.DecodeString(s)
@@ -140,9 +209,11 @@ func (v TextValue) CastAs(target Type) (Value, error) {
 func (v TextValue) EQ(other Value) (bool, error) {
 	t := other.Type()
 	switch t {
+	case TypeUUID, TypeInet, TypeCidr, TypeCitext, TypePoint, TypeInterval:
+		return other.EQ(v)
 	case TypeText:
 		return strings.Compare(string(v), AsString(other)) == 0, nil
-	case TypeTimestamp:
+	case TypeTimestamp, TypeTimestampTZ:
 		ts, err := ParseTimestamp(AsString(v))
 		if err != nil {
 			return false, err
@@ -156,9 +227,11 @@ func (v TextValue) EQ(other Value) (bool, error) {
 func (v TextValue) GT(other Value) (bool, error) {
 	t := other.Type()
 	switch t {
+	case TypeUUID, TypeInet, TypeCidr, TypeCitext, TypePoint, TypeInterval:
+		return other.LT(v)
 	case TypeText:
 		return strings.Compare(string(v), AsString(other)) > 0, nil
-	case TypeTimestamp:
+	case TypeTimestamp, TypeTimestampTZ:
 		ts, err := ParseTimestamp(AsString(v))
 		if err != nil {
 			return false, err
@@ -172,9 +245,11 @@ func (v TextValue) GT(other Value) (bool, error) {
 func (v TextValue) GTE(other Value) (bool, error) {
 	t := other.Type()
 	switch t {
+	case TypeUUID, TypeInet, TypeCidr, TypeCitext, TypePoint, TypeInterval:
+		return other.LTE(v)
 	case TypeText:
 		return strings.Compare(string(v), AsString(other)) >= 0, nil
-	case TypeTimestamp:
+	case TypeTimestamp, TypeTimestampTZ:
 		t1, err := ParseTimestamp(AsString(v))
 		if err != nil {
 			return false, err
@@ -189,9 +264,11 @@ func (v TextValue) GTE(other Value) (bool, error) {
 func (v TextValue) LT(other Value) (bool, error) {
 	t := other.Type()
 	switch t {
+	case TypeUUID, TypeInet, TypeCidr, TypeCitext, TypePoint, TypeInterval:
+		return other.GT(v)
 	case TypeText:
 		return strings.Compare(string(v), AsString(other)) < 0, nil
-	case TypeTimestamp:
+	case TypeTimestamp, TypeTimestampTZ:
 		ts, err := ParseTimestamp(AsString(v))
 		if err != nil {
 			return false, err
@@ -205,9 +282,11 @@ func (v TextValue) LT(other Value) (bool, error) {
 func (v TextValue) LTE(other Value) (bool, error) {
 	t := other.Type()
 	switch t {
+	case TypeUUID, TypeInet, TypeCidr, TypeCitext, TypePoint, TypeInterval:
+		return other.GTE(v)
 	case TypeText:
 		return strings.Compare(string(v), AsString(other)) <= 0, nil
-	case TypeTimestamp:
+	case TypeTimestamp, TypeTimestampTZ:
 		t1, err := ParseTimestamp(AsString(v))
 		if err != nil {
 			return false, err
@@ -220,7 +299,7 @@ func (v TextValue) LTE(other Value) (bool, error) {
 }
 
 func (v TextValue) Between(a, b Value) (bool, error) {
-	if a.Type() != TypeText || b.Type() != TypeText {
+	if !a.Type().IsText() || !b.Type().IsText() {
 		return false, nil
 	}
 
@@ -28,11 +28,11 @@ func (t TimestampTypeDef) Decode(src []byte) (Value, int) {
 }
 
 func (TimestampTypeDef) IsComparableWith(other Type) bool {
-	return other == TypeTimestamp || other == TypeText
+	return other.IsTimestampCompatible()
 }
 
 func (TimestampTypeDef) IsIndexComparableWith(other Type) bool {
-	return other == TypeTimestamp
+	return other.IsTimestamp()
 }
 
 var _ Value = NewTimestampValue(time.Time{})
@@ -90,6 +90,8 @@ func (v TimestampValue) CastAs(target Type) (Value, error) {
 	switch target {
 	case TypeTimestamp:
 		return v, nil
+	case TypeTimestampTZ:
+		return NewTimestampTZValue(time.Time(v)), nil
 	case TypeText:
 		return NewTextValue(v.String()), nil
 	}
@@ -100,7 +102,7 @@ func (v TimestampValue) CastAs(target Type) (Value, error) {
 func (v TimestampValue) EQ(other Value) (bool, error) {
 	t := other.Type()
 	switch t {
-	case TypeTimestamp:
+	case TypeTimestamp, TypeTimestampTZ:
 		return time.Time(v).Equal(AsTime(other)), nil
 	case TypeText:
 		ts, err := ParseTimestamp(AsString(other))
@@ -116,7 +118,7 @@ func (v TimestampValue) EQ(other Value) (bool, error) {
 func (v TimestampValue) GT(other Value) (bool, error) {
 	t := other.Type()
 	switch t {
-	case TypeTimestamp:
+	case TypeTimestamp, TypeTimestampTZ:
 		return time.Time(v).After(AsTime(other)), nil
 	case TypeText:
 		ts, err := ParseTimestamp(AsString(other))
@@ -132,7 +134,7 @@ func (v TimestampValue) GT(other Value) (bool, error) {
 func (v TimestampValue) GTE(other Value) (bool, error) {
 	t := other.Type()
 	switch t {
-	case TypeTimestamp:
+	case TypeTimestamp, TypeTimestampTZ:
 		ta := time.Time(v)
 		tb := AsTime(other)
 		return ta.After(tb) || ta.Equal(tb), nil
@@ -152,7 +154,7 @@ func (v TimestampValue) GTE(other Value) (bool, error) {
 func (v TimestampValue) LT(other Value) (bool, error) {
 	t := other.Type()
 	switch t {
-	case TypeTimestamp:
+	case TypeTimestamp, TypeTimestampTZ:
 		return time.Time(v).Before(AsTime(other)), nil
 	case TypeText:
 		ts, err := ParseTimestamp(AsString(other))
@@ -168,7 +170,7 @@ func (v TimestampValue) LT(other Value) (bool, error) {
 func (v TimestampValue) LTE(other Value) (bool, error) {
 	t := other.Type()
 	switch t {
-	case TypeTimestamp:
+	case TypeTimestamp, TypeTimestampTZ:
 		ta := time.Time(v)
 		tb := AsTime(other)
 		return ta.Before(tb) || ta.Equal(tb), nil
@@ -199,16 +201,32 @@ func (v TimestampValue) Between(a, b Value) (bool, error) {
 }
 
 func ParseTimestamp(s string) (time.Time, error) {
-	c := carbon.Parse(s, "UTC")
+	return ParseTimestampIn(s, time.UTC)
+}
+
+// ParseTimestampIn parses s as a timestamp. If s doesn't specify
+// a time zone, it is interpreted in the given location.
+func ParseTimestampIn(s string, loc *time.Location) (time.Time, error) {
+	c := carbon.NewCarbon().SetLocation(loc).Parse(s)
 	if c.Error != nil {
 		return time.Time{}, errors.New("invalid timestamp")
 	}
 
 	ts := c.ToStdTime()
-	m := ts.UnixMicro()
-	if m > maxTime || m < minTime {
-		return time.Time{}, errors.New("timestamp out of range")
+	err := ValidateTimestamp(ts)
+	if err != nil {
+		return time.Time{}, err
 	}
 
 	return ts, nil
 }
+
+// ValidateTimestamp returns an error if t is out of the range of the timestamps.
+func ValidateTimestamp(t time.Time) error {
+	// the number of microseconds of t may overflow
+	if t.After(time.UnixMicro(maxTime)) || t.Before(time.UnixMicro(minTime)) {
+		return errors.New("timestamp out of range")
+	}
+
+	return nil
+}
@@ -0,0 +1,168 @@
+package types
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/cockroachdb/errors"
+)
+
+var _ TypeDefinition = TimestampTZTypeDef{}
+
+type TimestampTZTypeDef struct{}
+
+func (TimestampTZTypeDef) New(v any) Value {
+	return NewTimestampTZValue(v.(time.Time))
+}
+
+func (TimestampTZTypeDef) Type() Type {
+	return TypeTimestampTZ
+}
+
+func (t TimestampTZTypeDef) Decode(src []byte) (Value, int) {
+	ts, n := encoding.DecodeTimestamp(src)
+	return NewTimestampTZValue(ts.UTC()), n
+}
+
+func (TimestampTZTypeDef) IsComparableWith(other Type) bool {
+	return other.IsTimestampCompatible()
+}
+
+func (TimestampTZTypeDef) IsIndexComparableWith(other Type) bool {
+	return other.IsTimestamp()
+}
+
+var _ Value = NewTimestampTZValue(time.Time{})
+
+// TimestampTZValue is an instant in time, along with the location
+// used to display it. Only the instant is encoded: two values with the same
+// instant are equal, whatever their location.
+type TimestampTZValue time.Time
+
+// NewTimestampTZValue returns a SQL TIMESTAMP WITH TIME ZONE value.
+// The location of x is only used to display the value.
+func NewTimestampTZValue(x time.Time) TimestampTZValue {
+	return TimestampTZValue(x)
+}
+
+func (v TimestampTZValue) V() any {
+	return time.Time(v)
+}
+
+func (v TimestampTZValue) Type() Type {
+	return TypeTimestampTZ
+}
+
+func (v TimestampTZValue) TypeDef() TypeDefinition {
+	return TimestampTZTypeDef{}
+}
+
+func (v TimestampTZValue) IsZero() (bool, error) {
+	return time.Time(v).IsZero(), nil
+}
+
+func (v TimestampTZValue) String() string {
+	return strconv.Quote(time.Time(v).Format(time.RFC3339Nano))
+}
+
+func (v TimestampTZValue) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+func (v TimestampTZValue) MarshalJSON() ([]byte, error) {
+	return v.MarshalText()
+}
+
+func (v TimestampTZValue) Encode(dst []byte) ([]byte, error) {
+	return encoding.EncodeTimestamp(dst, time.Time(v)), nil
+}
+
+func (v TimestampTZValue) EncodeAsKey(dst []byte) ([]byte, error) {
+	return v.Encode(dst)
+}
+
+func (v TimestampTZValue) CastAs(target Type) (Value, error) {
+	switch target {
+	case TypeTimestampTZ:
+		return v, nil
+	case TypeTimestamp:
+		return NewTimestampValue(time.Time(v)), nil
+	case TypeText:
+		return NewTextValue(v.String()), nil
+	}
+
+	return nil, errors.Errorf("cannot cast %s as %s", v.Type(), target)
+}
+
+// utc returns the instant of v as a TIMESTAMP,
+// which compares the same way.
+func (v TimestampTZValue) utc() TimestampValue {
+	return NewTimestampValue(time.Time(v))
+}
+
+func (v TimestampTZValue) EQ(other Value) (bool, error) {
+	return v.utc().EQ(other)
+}
+
+func (v TimestampTZValue) GT(other Value) (bool, error) {
+	return v.utc().GT(other)
+}
+
+func (v TimestampTZValue) GTE(other Value) (bool, error) {
+	return v.utc().GTE(other)
+}
+
+func (v TimestampTZValue) LT(other Value) (bool, error) {
+	return v.utc().LT(other)
+}
+
+func (v TimestampTZValue) LTE(other Value) (bool, error) {
+	return v.utc().LTE(other)
+}
+
+func (v TimestampTZValue) Between(a, b Value) (bool, error) {
+	return v.utc().Between(a, b)
+}
+
+// CastIn converts v to the target type like CastAs, using loc as the
+// time zone of the conversions between TEXT and TIMESTAMPTZ:
+// texts without time zone are parsed in loc, and TIMESTAMPTZ values are formatted in loc.
+// A nil loc is UTC. Values are converted to CITEXT through their TEXT representation.
+func CastIn(v Value, target Type, loc *time.Location) (Value, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	switch {
+	case v.Type() == TypeText && target == TypeTimestampTZ:
+		t, err := ParseTimestampIn(AsString(v), loc)
+		if err != nil {
+			return nil, errors.Errorf(`cannot cast %q as timestamptz: %v`, v.V(), err)
+		}
+		return NewTimestampTZValue(t.In(loc)), nil
+	case v.Type() == TypeTimestampTZ && target != TypeTimestampTZ:
+		return NewTimestampTZValue(AsTime(v).In(loc)).CastAs(target)
+	case target == TypeCitext && !v.Type().IsText():
+		x, err := CastIn(v, TypeText, loc)
+		if err != nil {
+			return nil, err
+		}
+		return x.CastAs(TypeCitext)
+	}
+
+	return v.CastAs(target)
+}
+
+// InTimeZone returns v converted to loc if it is a TIMESTAMPTZ,
+// and v otherwise. A nil loc is UTC.
+func InTimeZone(v Value, loc *time.Location) Value {
+	if v.Type() != TypeTimestampTZ {
+		return v
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	return NewTimestampTZValue(AsTime(v).In(loc))
+}
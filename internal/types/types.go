@@ -28,6 +28,18 @@ const (
 	TypeTimestamp
 	TypeText
 	TypeBlob
+	TypeTimestampTZ
+	TypeUUID
+	TypeJSON
+	TypeUnsignedBigint
+	TypeReal
+	TypeObject
+	TypeArray
+	TypeInet
+	TypeCidr
+	TypeCitext
+	TypePoint
+	TypeInterval
 )
 
 func (t Type) Def() TypeDefinition {
@@ -40,14 +52,38 @@ func (t Type) Def() TypeDefinition {
 		return IntegerTypeDef{}
 	case TypeBigint:
 		return BigintTypeDef{}
+	case TypeUnsignedBigint:
+		return UnsignedBigintTypeDef{}
 	case TypeDouble:
 		return DoubleTypeDef{}
+	case TypeReal:
+		return RealTypeDef{}
 	case TypeTimestamp:
 		return TimestampTypeDef{}
 	case TypeText:
 		return TextTypeDef{}
 	case TypeBlob:
 		return BlobTypeDef{}
+	case TypeTimestampTZ:
+		return TimestampTZTypeDef{}
+	case TypeUUID:
+		return UUIDTypeDef{}
+	case TypeJSON:
+		return JSONTypeDef{}
+	case TypeObject:
+		return ObjectTypeDef{}
+	case TypeArray:
+		return ArrayTypeDef{}
+	case TypeInet:
+		return InetTypeDef{}
+	case TypeCidr:
+		return CidrTypeDef{}
+	case TypeCitext:
+		return CitextTypeDef{}
+	case TypePoint:
+		return PointTypeDef{}
+	case TypeInterval:
+		return IntervalTypeDef{}
 	}
 
 	return nil
@@ -63,14 +99,38 @@ func (t Type) String() string {
 		return "integer"
 	case TypeBigint:
 		return "bigint"
+	case TypeUnsignedBigint:
+		return "bigint unsigned"
 	case TypeDouble:
 		return "double"
+	case TypeReal:
+		return "real"
 	case TypeTimestamp:
 		return "timestamp"
+	case TypeTimestampTZ:
+		return "timestamptz"
+	case TypeUUID:
+		return "uuid"
+	case TypeJSON:
+		return "jsonb"
+	case TypeObject:
+		return "object"
+	case TypeArray:
+		return "array"
+	case TypeInet:
+		return "inet"
+	case TypeCidr:
+		return "cidr"
+	case TypePoint:
+		return "point"
+	case TypeInterval:
+		return "interval"
 	case TypeBlob:
 		return "blob"
 	case TypeText:
 		return "text"
+	case TypeCitext:
+		return "citext"
 	}
 
 	panic(fmt.Sprintf("unsupported type %#v", t))
@@ -86,14 +146,34 @@ func (t Type) MinEnctype() byte {
 		return encoding.Int32Value
 	case TypeBigint:
 		return encoding.Int64Value
+	case TypeUnsignedBigint:
+		return encoding.IntSmallValue
 	case TypeDouble:
 		return encoding.Float64Value
-	case TypeTimestamp:
+	case TypeReal:
+		return encoding.Float32Value
+	case TypeTimestamp, TypeTimestampTZ:
 		return encoding.Int64Value
-	case TypeText:
+	case TypeText, TypeCitext:
 		return encoding.TextValue
 	case TypeBlob:
 		return encoding.BlobValue
+	case TypeUUID:
+		return encoding.UUIDValue
+	case TypeJSON:
+		return encoding.JSONValue
+	case TypeObject:
+		return encoding.ObjectValue
+	case TypeArray:
+		return encoding.ArrayValue
+	case TypeInet:
+		return encoding.InetValue
+	case TypeCidr:
+		return encoding.CidrValue
+	case TypePoint:
+		return encoding.PointValue
+	case TypeInterval:
+		return encoding.IntervalValue
 	default:
 		panic(fmt.Sprintf("unsupported type %v", t))
 	}
@@ -107,16 +187,34 @@ func (t Type) MinEnctypeDesc() byte {
 		return encoding.DESC_TrueValue
 	case TypeInteger:
 		return encoding.DESC_Uint32Value
-	case TypeBigint:
+	case TypeBigint, TypeUnsignedBigint:
 		return encoding.DESC_Uint64Value
 	case TypeDouble:
 		return encoding.DESC_Float64Value
-	case TypeTimestamp:
+	case TypeReal:
+		return encoding.DESC_Float32Value
+	case TypeTimestamp, TypeTimestampTZ:
 		return encoding.DESC_Uint64Value
-	case TypeText:
+	case TypeText, TypeCitext:
 		return encoding.DESC_TextValue
 	case TypeBlob:
 		return encoding.DESC_BlobValue
+	case TypeUUID:
+		return encoding.DESC_UUIDValue
+	case TypeJSON:
+		return encoding.DESC_JSONValue
+	case TypeObject:
+		return encoding.DESC_ObjectValue
+	case TypeArray:
+		return encoding.DESC_ArrayValue
+	case TypeInet:
+		return encoding.DESC_InetValue
+	case TypeCidr:
+		return encoding.DESC_CidrValue
+	case TypePoint:
+		return encoding.DESC_PointValue
+	case TypeInterval:
+		return encoding.DESC_IntervalValue
 	default:
 		panic(fmt.Sprintf("unsupported type %v", t))
 	}
@@ -130,16 +228,34 @@ func (t Type) MaxEnctype() byte {
 		return encoding.TrueValue + 1
 	case TypeInteger:
 		return encoding.Uint32Value + 1
-	case TypeBigint:
+	case TypeBigint, TypeUnsignedBigint:
 		return encoding.Uint64Value + 1
 	case TypeDouble:
 		return encoding.Float64Value + 1
-	case TypeTimestamp:
+	case TypeReal:
+		return encoding.Float32Value + 1
+	case TypeTimestamp, TypeTimestampTZ:
 		return encoding.Uint64Value + 1
-	case TypeText:
+	case TypeText, TypeCitext:
 		return encoding.TextValue + 1
 	case TypeBlob:
 		return encoding.BlobValue + 1
+	case TypeUUID:
+		return encoding.UUIDValue + 1
+	case TypeJSON:
+		return encoding.JSONValue + 1
+	case TypeObject:
+		return encoding.ObjectValue + 1
+	case TypeArray:
+		return encoding.ArrayValue + 1
+	case TypeInet:
+		return encoding.InetValue + 1
+	case TypeCidr:
+		return encoding.CidrValue + 1
+	case TypePoint:
+		return encoding.PointValue + 1
+	case TypeInterval:
+		return encoding.IntervalValue + 1
 	default:
 		panic(fmt.Sprintf("unsupported type %v", t))
 	}
@@ -153,14 +269,34 @@ func (t Type) MaxEnctypeDesc() byte {
 		return encoding.DESC_FalseValue + 1
 	case TypeInteger:
 		return encoding.DESC_Int64Value + 1
+	case TypeUnsignedBigint:
+		return encoding.DESC_IntSmallValue + 1
 	case TypeDouble:
 		return encoding.DESC_Float64Value + 1
-	case TypeTimestamp:
+	case TypeReal:
+		return encoding.DESC_Float32Value + 1
+	case TypeTimestamp, TypeTimestampTZ:
 		return encoding.DESC_Int64Value + 1
-	case TypeText:
+	case TypeText, TypeCitext:
 		return encoding.DESC_TextValue + 1
 	case TypeBlob:
 		return encoding.DESC_BlobValue + 1
+	case TypeUUID:
+		return encoding.DESC_UUIDValue + 1
+	case TypeJSON:
+		return encoding.DESC_JSONValue + 1
+	case TypeObject:
+		return encoding.DESC_ObjectValue + 1
+	case TypeArray:
+		return encoding.DESC_ArrayValue + 1
+	case TypeInet:
+		return encoding.DESC_InetValue + 1
+	case TypeCidr:
+		return encoding.DESC_CidrValue + 1
+	case TypePoint:
+		return encoding.DESC_PointValue + 1
+	case TypeInterval:
+		return encoding.DESC_IntervalValue + 1
 	default:
 		panic(fmt.Sprintf("unsupported type %v", t))
 	}
@@ -168,16 +304,26 @@ func (t Type) MaxEnctypeDesc() byte {
 
 // IsNumber returns true if t is either an integer or a float.
 func (t Type) IsNumber() bool {
-	return t == TypeInteger || t == TypeBigint || t == TypeDouble
+	return t == TypeInteger || t == TypeBigint || t == TypeUnsignedBigint || t == TypeDouble || t == TypeReal
 }
 
 func (t Type) IsInteger() bool {
-	return t == TypeInteger || t == TypeBigint
+	return t == TypeInteger || t == TypeBigint || t == TypeUnsignedBigint
 }
 
-// IsTimestampCompatible returns true if t is either a timestamp, an integer, or a text.
+// IsText returns true if t is a text, case-sensitive or not.
+func (t Type) IsText() bool {
+	return t == TypeText || t == TypeCitext
+}
+
+// IsTimestampCompatible returns true if t is either a timestamp, with or without time zone, or a text.
 func (t Type) IsTimestampCompatible() bool {
-	return t == TypeTimestamp || t == TypeText
+	return t == TypeTimestamp || t == TypeTimestampTZ || t == TypeText
+}
+
+// IsTimestamp returns true if t is a timestamp, with or without time zone.
+func (t Type) IsTimestamp() bool {
+	return t == TypeTimestamp || t == TypeTimestampTZ
 }
 
 func (t Type) IsComparableWith(other Type) bool {
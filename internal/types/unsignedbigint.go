@@ -0,0 +1,320 @@
+package types
+
+import (
+	"cmp"
+	"math"
+	"math/big"
+	"strconv"
+
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/cockroachdb/errors"
+)
+
+var _ TypeDefinition = UnsignedBigintTypeDef{}
+
+type UnsignedBigintTypeDef struct{}
+
+func (UnsignedBigintTypeDef) New(v any) Value {
+	return NewUnsignedBigintValue(v.(uint64))
+}
+
+func (UnsignedBigintTypeDef) Type() Type {
+	return TypeUnsignedBigint
+}
+
+func (UnsignedBigintTypeDef) Decode(src []byte) (Value, int) {
+	x, n := encoding.DecodeUint(src)
+	return NewUnsignedBigintValue(x), n
+}
+
+func (UnsignedBigintTypeDef) IsComparableWith(other Type) bool {
+	return other == TypeUnsignedBigint || other == TypeBigint || other == TypeInteger || other == TypeDouble || other == TypeReal
+}
+
+func (UnsignedBigintTypeDef) IsIndexComparableWith(other Type) bool {
+	return other == TypeUnsignedBigint || other == TypeBigint || other == TypeInteger
+}
+
+var _ Numeric = NewUnsignedBigintValue(0)
+var _ Integral = NewUnsignedBigintValue(0)
+var _ Value = NewUnsignedBigintValue(0)
+
+// UnsignedBigintValue is an unsigned 64-bit integer.
+// It is encoded like signed integers, which sorts both
+// kinds of integers by their numeric value.
+type UnsignedBigintValue uint64
+
+// NewUnsignedBigintValue returns a SQL BIGINT UNSIGNED value.
+func NewUnsignedBigintValue(x uint64) UnsignedBigintValue {
+	return UnsignedBigintValue(x)
+}
+
+func (v UnsignedBigintValue) V() any {
+	return uint64(v)
+}
+
+func (v UnsignedBigintValue) Type() Type {
+	return TypeUnsignedBigint
+}
+
+func (v UnsignedBigintValue) TypeDef() TypeDefinition {
+	return UnsignedBigintTypeDef{}
+}
+
+func (v UnsignedBigintValue) IsZero() (bool, error) {
+	return v == 0, nil
+}
+
+func (v UnsignedBigintValue) String() string {
+	return strconv.FormatUint(uint64(v), 10)
+}
+
+func (v UnsignedBigintValue) MarshalText() ([]byte, error) {
+	return []byte(strconv.FormatUint(uint64(v), 10)), nil
+}
+
+func (v UnsignedBigintValue) MarshalJSON() ([]byte, error) {
+	return v.MarshalText()
+}
+
+func (v UnsignedBigintValue) Encode(dst []byte) ([]byte, error) {
+	return encoding.EncodeUint(dst, uint64(v)), nil
+}
+
+func (v UnsignedBigintValue) EncodeAsKey(dst []byte) ([]byte, error) {
+	return v.Encode(dst)
+}
+
+func (v UnsignedBigintValue) CastAs(target Type) (Value, error) {
+	switch target {
+	case TypeUnsignedBigint:
+		return v, nil
+	case TypeInteger:
+		if uint64(v) > math.MaxInt32 {
+			return nil, errors.Errorf("integer out of range")
+		}
+		return NewIntegerValue(int32(v)), nil
+	case TypeBigint:
+		if uint64(v) > math.MaxInt64 {
+			return nil, errors.Errorf("bigint out of range")
+		}
+		return NewBigintValue(int64(v)), nil
+	case TypeDouble:
+		return NewDoubleValue(float64(v)), nil
+	case TypeReal:
+		return NewRealValue(float32(v)), nil
+	case TypeText:
+		return NewTextValue(v.String()), nil
+	}
+
+	return nil, errors.Errorf("cannot cast %s as %s", v.Type(), target)
+}
+
+// compare returns -1, 0 or 1 depending on whether v is lower than,
+// equal to or greater than other. It returns false if other is not a number.
+func (v UnsignedBigintValue) compare(other Value) (int, bool) {
+	switch other.Type() {
+	case TypeUnsignedBigint:
+		return cmp.Compare(uint64(v), AsUint64(other)), true
+	case TypeBigint, TypeInteger:
+		x := AsInt64(other)
+		if x < 0 {
+			return 1, true
+		}
+		return cmp.Compare(uint64(v), uint64(x)), true
+	case TypeDouble, TypeReal:
+		return cmp.Compare(float64(v), AsFloat64(other)), true
+	}
+
+	return 0, false
+}
+
+func (v UnsignedBigintValue) EQ(other Value) (bool, error) {
+	c, ok := v.compare(other)
+	return ok && c == 0, nil
+}
+
+func (v UnsignedBigintValue) GT(other Value) (bool, error) {
+	c, ok := v.compare(other)
+	return ok && c > 0, nil
+}
+
+func (v UnsignedBigintValue) GTE(other Value) (bool, error) {
+	c, ok := v.compare(other)
+	return ok && c >= 0, nil
+}
+
+func (v UnsignedBigintValue) LT(other Value) (bool, error) {
+	c, ok := v.compare(other)
+	return ok && c < 0, nil
+}
+
+func (v UnsignedBigintValue) LTE(other Value) (bool, error) {
+	c, ok := v.compare(other)
+	return ok && c <= 0, nil
+}
+
+func (v UnsignedBigintValue) Between(a, b Value) (bool, error) {
+	if !a.Type().IsNumber() || !b.Type().IsNumber() {
+		return false, nil
+	}
+
+	ok, err := a.LTE(v)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	return b.GTE(v)
+}
+
+func (v UnsignedBigintValue) Add(other Numeric) (Value, error) {
+	switch other.Type() {
+	case TypeUnsignedBigint, TypeBigint, TypeInteger:
+		return unsignedArithmetic(v, other, (*big.Int).Add)
+	case TypeDouble, TypeReal:
+		return NewDoubleValue(float64(v) + AsFloat64(other)), nil
+	}
+
+	return NewNullValue(), nil
+}
+
+func (v UnsignedBigintValue) Sub(other Numeric) (Value, error) {
+	switch other.Type() {
+	case TypeUnsignedBigint, TypeBigint, TypeInteger:
+		return unsignedArithmetic(v, other, (*big.Int).Sub)
+	case TypeDouble, TypeReal:
+		return NewDoubleValue(float64(v) - AsFloat64(other)), nil
+	}
+
+	return NewNullValue(), nil
+}
+
+func (v UnsignedBigintValue) Mul(other Numeric) (Value, error) {
+	switch other.Type() {
+	case TypeUnsignedBigint, TypeBigint, TypeInteger:
+		return unsignedArithmetic(v, other, (*big.Int).Mul)
+	case TypeDouble, TypeReal:
+		return NewDoubleValue(float64(v) * AsFloat64(other)), nil
+	}
+
+	return NewNullValue(), nil
+}
+
+func (v UnsignedBigintValue) Div(other Numeric) (Value, error) {
+	switch other.Type() {
+	case TypeUnsignedBigint, TypeBigint, TypeInteger:
+		if bigIntOf(other).Sign() == 0 {
+			return NewNullValue(), nil
+		}
+
+		return unsignedArithmetic(v, other, (*big.Int).Quo)
+	case TypeDouble, TypeReal:
+		xb := AsFloat64(other)
+		if xb == 0 {
+			return NewNullValue(), nil
+		}
+
+		return NewDoubleValue(float64(v) / xb), nil
+	}
+
+	return NewNullValue(), nil
+}
+
+func (v UnsignedBigintValue) Mod(other Numeric) (Value, error) {
+	switch other.Type() {
+	case TypeUnsignedBigint, TypeBigint, TypeInteger:
+		if bigIntOf(other).Sign() == 0 {
+			return NewNullValue(), nil
+		}
+
+		return unsignedArithmetic(v, other, (*big.Int).Rem)
+	case TypeDouble, TypeReal:
+		mod := math.Mod(float64(v), AsFloat64(other))
+		if math.IsNaN(mod) {
+			return NewNullValue(), nil
+		}
+
+		return NewDoubleValue(mod), nil
+	}
+
+	return NewNullValue(), nil
+}
+
+func (v UnsignedBigintValue) BitwiseAnd(other Numeric) (Value, error) {
+	switch other.Type() {
+	case TypeUnsignedBigint, TypeBigint, TypeInteger:
+		return NewUnsignedBigintValue(uint64(v) & asUint64Bits(other)), nil
+	case TypeDouble, TypeReal:
+		return NewUnsignedBigintValue(uint64(v) & uint64(int64(AsFloat64(other)))), nil
+	}
+
+	return NewNullValue(), nil
+}
+
+func (v UnsignedBigintValue) BitwiseOr(other Numeric) (Value, error) {
+	switch other.Type() {
+	case TypeUnsignedBigint, TypeBigint, TypeInteger:
+		return NewUnsignedBigintValue(uint64(v) | asUint64Bits(other)), nil
+	case TypeDouble, TypeReal:
+		return NewUnsignedBigintValue(uint64(v) | uint64(int64(AsFloat64(other)))), nil
+	}
+
+	return NewNullValue(), nil
+}
+
+func (v UnsignedBigintValue) BitwiseXor(other Numeric) (Value, error) {
+	switch other.Type() {
+	case TypeUnsignedBigint, TypeBigint, TypeInteger:
+		return NewUnsignedBigintValue(uint64(v) ^ asUint64Bits(other)), nil
+	case TypeDouble, TypeReal:
+		return NewUnsignedBigintValue(uint64(v) ^ uint64(int64(AsFloat64(other)))), nil
+	}
+
+	return NewNullValue(), nil
+}
+
+func (v UnsignedBigintValue) ShiftLeft(other Numeric) (Value, error) {
+	n, err := shiftCount(other)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewUnsignedBigintValue(uint64(v) << n), nil
+}
+
+func (v UnsignedBigintValue) ShiftRight(other Numeric) (Value, error) {
+	n, err := shiftCount(other)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewUnsignedBigintValue(uint64(v) >> n), nil
+}
+
+// unsignedArithmetic applies op to two integers, at least one of them being unsigned.
+// The result is unsigned, it is an error if it doesn't fit in 64 bits.
+func unsignedArithmetic(a, b Value, op func(z, x, y *big.Int) *big.Int) (Value, error) {
+	z := op(new(big.Int), bigIntOf(a), bigIntOf(b))
+	if !z.IsUint64() {
+		return nil, errors.New("bigint unsigned out of range")
+	}
+
+	return NewUnsignedBigintValue(z.Uint64()), nil
+}
+
+func bigIntOf(v Value) *big.Int {
+	if v.Type() == TypeUnsignedBigint {
+		return new(big.Int).SetUint64(AsUint64(v))
+	}
+
+	return big.NewInt(AsInt64(v))
+}
+
+// asUint64Bits returns the two's complement representation of an integer.
+func asUint64Bits(v Value) uint64 {
+	if v.Type() == TypeUnsignedBigint {
+		return AsUint64(v)
+	}
+
+	return uint64(AsInt64(v))
+}
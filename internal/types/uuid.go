@@ -0,0 +1,254 @@
+package types
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/cockroachdb/errors"
+)
+
+var _ TypeDefinition = UUIDTypeDef{}
+
+type UUIDTypeDef struct{}
+
+func (UUIDTypeDef) New(v any) Value {
+	return NewUUIDValue(v.([16]byte))
+}
+
+func (UUIDTypeDef) Type() Type {
+	return TypeUUID
+}
+
+func (UUIDTypeDef) Decode(src []byte) (Value, int) {
+	x, n := encoding.DecodeUUID(src)
+	return NewUUIDValue(x), n
+}
+
+func (UUIDTypeDef) IsComparableWith(other Type) bool {
+	return other == TypeUUID || other == TypeText
+}
+
+func (UUIDTypeDef) IsIndexComparableWith(other Type) bool {
+	return other == TypeUUID
+}
+
+var _ Value = NewUUIDValue([16]byte{})
+
+type UUIDValue [16]byte
+
+// NewUUIDValue returns a SQL UUID value.
+func NewUUIDValue(x [16]byte) UUIDValue {
+	return UUIDValue(x)
+}
+
+func (v UUIDValue) V() any {
+	return [16]byte(v)
+}
+
+func (v UUIDValue) Type() Type {
+	return TypeUUID
+}
+
+func (v UUIDValue) TypeDef() TypeDefinition {
+	return UUIDTypeDef{}
+}
+
+func (v UUIDValue) IsZero() (bool, error) {
+	return v == UUIDValue{}, nil
+}
+
+func (v UUIDValue) String() string {
+	return strconv.Quote(FormatUUID(v))
+}
+
+func (v UUIDValue) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+func (v UUIDValue) MarshalJSON() ([]byte, error) {
+	return v.MarshalText()
+}
+
+func (v UUIDValue) Encode(dst []byte) ([]byte, error) {
+	return encoding.EncodeUUID(dst, v), nil
+}
+
+func (v UUIDValue) EncodeAsKey(dst []byte) ([]byte, error) {
+	return v.Encode(dst)
+}
+
+func (v UUIDValue) CastAs(target Type) (Value, error) {
+	switch target {
+	case TypeUUID:
+		return v, nil
+	case TypeText:
+		return NewTextValue(FormatUUID(v)), nil
+	case TypeBlob:
+		return NewBlobValue(bytes.Clone(v[:])), nil
+	}
+
+	return nil, errors.Errorf("cannot cast %s as %s", v.Type(), target)
+}
+
+// compare returns the result of the comparison of v with other,
+// and false if they can't be compared.
+func (v UUIDValue) compare(other Value) (int, bool, error) {
+	var x [16]byte
+	switch other.Type() {
+	case TypeUUID:
+		x = other.V().([16]byte)
+	case TypeText:
+		var err error
+		x, err = ParseUUID(AsString(other))
+		if err != nil {
+			return 0, false, err
+		}
+	default:
+		return 0, false, nil
+	}
+
+	return bytes.Compare(v[:], x[:]), true, nil
+}
+
+func (v UUIDValue) EQ(other Value) (bool, error) {
+	c, ok, err := v.compare(other)
+	return ok && c == 0, err
+}
+
+func (v UUIDValue) GT(other Value) (bool, error) {
+	c, ok, err := v.compare(other)
+	return ok && c > 0, err
+}
+
+func (v UUIDValue) GTE(other Value) (bool, error) {
+	c, ok, err := v.compare(other)
+	return ok && c >= 0, err
+}
+
+func (v UUIDValue) LT(other Value) (bool, error) {
+	c, ok, err := v.compare(other)
+	return ok && c < 0, err
+}
+
+func (v UUIDValue) LTE(other Value) (bool, error) {
+	c, ok, err := v.compare(other)
+	return ok && c <= 0, err
+}
+
+func (v UUIDValue) Between(a, b Value) (bool, error) {
+	var def UUIDTypeDef
+	if !def.IsComparableWith(a.Type()) || !def.IsComparableWith(b.Type()) {
+		return false, nil
+	}
+
+	ok, err := v.GTE(a)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	return v.LTE(b)
+}
+
+// ParseUUID parses a UUID written in its canonical form,
+// such as "f81d4fae-7dec-11d0-a765-00a0c91e6bf6", with or
+// without hyphens and braces, in any case.
+func ParseUUID(s string) ([16]byte, error) {
+	var x [16]byte
+
+	if len(s) == 38 && s[0] == '{' && s[37] == '}' {
+		s = s[1:37]
+	}
+
+	switch len(s) {
+	case 36:
+		if s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+			return x, errors.New("invalid uuid")
+		}
+		s = s[:8] + s[9:13] + s[14:18] + s[19:23] + s[24:]
+	case 32:
+	default:
+		return x, errors.New("invalid uuid")
+	}
+
+	_, err := hex.Decode(x[:], []byte(s))
+	if err != nil {
+		return x, errors.New("invalid uuid")
+	}
+
+	return x, nil
+}
+
+// FormatUUID returns the canonical form of a UUID.
+func FormatUUID(x [16]byte) string {
+	var buf [36]byte
+	hex.Encode(buf[0:8], x[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], x[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], x[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], x[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], x[10:])
+	return string(buf[:])
+}
+
+// NewUUIDv4 returns a random UUID, as defined by RFC 9562.
+func NewUUIDv4() ([16]byte, error) {
+	var x [16]byte
+	_, err := rand.Read(x[:])
+	if err != nil {
+		return x, err
+	}
+
+	x[6] = x[6]&0x0f | 0x40 // version 4
+	x[8] = x[8]&0x3f | 0x80 // variant 10
+	return x, nil
+}
+
+// last timestamp used by NewUUIDv7, in units of 1/4096 millisecond.
+var uuidv7 struct {
+	sync.Mutex
+	last int64
+}
+
+// NewUUIDv7 returns a UUID starting with the given time, as defined by RFC 9562:
+// UUIDs generated later sort after the previous ones.
+// The 48 first bits store the number of milliseconds since the Unix epoch,
+// and the following 12 bits the fraction of the millisecond. UUIDs generated
+// by the process are strictly increasing, even within the same fraction of millisecond
+// or if the clock goes backwards. The remaining 62 bits are random.
+func NewUUIDv7(t time.Time) ([16]byte, error) {
+	var x [16]byte
+	_, err := rand.Read(x[8:])
+	if err != nil {
+		return x, err
+	}
+
+	ns := t.UnixNano()
+	ts := (ns/int64(time.Millisecond))<<12 | (ns%int64(time.Millisecond))*4096/int64(time.Millisecond)
+
+	uuidv7.Lock()
+	if ts <= uuidv7.last {
+		ts = uuidv7.last + 1
+	}
+	uuidv7.last = ts
+	uuidv7.Unlock()
+
+	ms, frac := ts>>12, ts&0xfff
+	x[0] = byte(ms >> 40)
+	x[1] = byte(ms >> 32)
+	x[2] = byte(ms >> 24)
+	x[3] = byte(ms >> 16)
+	x[4] = byte(ms >> 8)
+	x[5] = byte(ms)
+	x[6] = 0x70 | byte(frac>>8) // version 7
+	x[7] = byte(frac)
+	x[8] = x[8]&0x3f | 0x80 // variant 10
+	return x, nil
+}
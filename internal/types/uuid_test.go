@@ -0,0 +1,71 @@
+package types_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/chaisql/chai/internal/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseUUID(t *testing.T) {
+	want := [16]byte{0xf8, 0x1d, 0x4f, 0xae, 0x7d, 0xec, 0x11, 0xd0, 0xa7, 0x65, 0x00, 0xa0, 0xc9, 0x1e, 0x6b, 0xf6}
+
+	tests := []struct {
+		s     string
+		fails bool
+	}{
+		{"f81d4fae-7dec-11d0-a765-00a0c91e6bf6", false},
+		{"F81D4FAE-7DEC-11D0-A765-00A0C91E6BF6", false},
+		{"{f81d4fae-7dec-11d0-a765-00a0c91e6bf6}", false},
+		{"f81d4fae7dec11d0a76500a0c91e6bf6", false},
+		{"f81d4fae-7dec-11d0-a765-00a0c91e6bf", true},
+		{"f81d4fae_7dec_11d0_a765_00a0c91e6bf6", true},
+		{"g81d4fae-7dec-11d0-a765-00a0c91e6bf6", true},
+		{"", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.s, func(t *testing.T) {
+			x, err := types.ParseUUID(test.s)
+			if test.fails {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, want, x)
+			require.Equal(t, "f81d4fae-7dec-11d0-a765-00a0c91e6bf6", types.FormatUUID(x))
+		})
+	}
+}
+
+func TestNewUUID(t *testing.T) {
+	x, err := types.NewUUIDv4()
+	require.NoError(t, err)
+	require.Equal(t, byte(0x40), x[6]&0xf0)
+	require.Equal(t, byte(0x80), x[8]&0xc0)
+
+	// version 7 UUIDs sort by time, even when generated within the same millisecond
+	now := time.Now()
+	var prev []byte
+	for i := 0; i < 1000; i++ {
+		x, err := types.NewUUIDv7(now)
+		require.NoError(t, err)
+		require.Equal(t, byte(0x70), x[6]&0xf0)
+		require.Equal(t, byte(0x80), x[8]&0xc0)
+
+		k, err := types.NewUUIDValue(x).EncodeAsKey(nil)
+		require.NoError(t, err)
+		require.Len(t, k, 17)
+		require.Positive(t, bytes.Compare(k, prev))
+		prev = k
+	}
+
+	// the first 48 bits store the time in milliseconds
+	later := now.Add(time.Hour)
+	x, err = types.NewUUIDv7(later)
+	require.NoError(t, err)
+	ms := int64(x[0])<<40 | int64(x[1])<<32 | int64(x[2])<<24 | int64(x[3])<<16 | int64(x[4])<<8 | int64(x[5])
+	require.Equal(t, later.UnixMilli(), ms)
+}
@@ -55,22 +55,44 @@ func AsInt64(v Value) int64 {
 	return v.V().(int64)
 }
 
+func AsUint64(v Value) uint64 {
+	uv, ok := v.(UnsignedBigintValue)
+	if ok {
+		return uint64(uv)
+	}
+
+	return v.V().(uint64)
+}
+
 func AsFloat64(v Value) float64 {
-	dv, ok := v.(DoubleValue)
+	switch fv := v.(type) {
+	case DoubleValue:
+		return float64(fv)
+	case RealValue:
+		return float64(fv)
+	}
+
+	return v.V().(float64)
+}
+
+func AsFloat32(v Value) float32 {
+	rv, ok := v.(RealValue)
 	if !ok {
-		return v.V().(float64)
+		return v.V().(float32)
 	}
 
-	return float64(dv)
+	return float32(rv)
 }
 
 func AsTime(v Value) time.Time {
-	tv, ok := v.(TimestampValue)
-	if !ok {
-		return v.V().(time.Time)
+	switch tv := v.(type) {
+	case TimestampValue:
+		return time.Time(tv)
+	case TimestampTZValue:
+		return time.Time(tv)
 	}
 
-	return time.Time(tv)
+	return v.V().(time.Time)
 }
 
 func AsString(v Value) string {
@@ -91,6 +113,15 @@ func AsByteSlice(v Value) []byte {
 	return bv
 }
 
+func AsArray(v Value) ArrayValue {
+	av, ok := v.(ArrayValue)
+	if !ok {
+		return NewArrayValue(v.V().([]byte))
+	}
+
+	return av
+}
+
 func IsNull(v Value) bool {
 	return v == nil || v.Type() == TypeNull
 }
@@ -110,6 +141,8 @@ func IsTruthy(v Value) (bool, error) {
 //
 //	int32
 //	int64
+//	uint64
+//	float32
 //	float64
 //	bool
 //	[]byte
@@ -126,6 +159,10 @@ func (v *ValueScanner) Scan(src any) error {
 		v.V = NewIntegerValue(t)
 	case int64:
 		v.V = NewBigintValue(t)
+	case uint64:
+		v.V = NewUnsignedBigintValue(t)
+	case float32:
+		v.V = NewRealValue(t)
 	case float64:
 		v.V = NewDoubleValue(t)
 	case bool:
@@ -0,0 +1,207 @@
+package chai
+
+import (
+	"bufio"
+	"expvar"
+	"io"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/chaisql/chai/internal/database"
+)
+
+// latencyBuckets are the upper bounds of the buckets of the query latency histogram.
+var latencyBuckets = [...]time.Duration{
+	100 * time.Microsecond,
+	250 * time.Microsecond,
+	500 * time.Microsecond,
+	time.Millisecond,
+	2500 * time.Microsecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// queryMetrics counts the queries of a database and their duration.
+type queryMetrics struct {
+	queries atomic.Int64
+	errors  atomic.Int64
+	// sum of the durations, in nanoseconds
+	sum atomic.Int64
+	// number of queries per bucket, the last one
+	// counting the queries slower than every bound
+	buckets [len(latencyBuckets) + 1]atomic.Int64
+}
+
+func (m *queryMetrics) observe(d time.Duration, err error) {
+	m.queries.Add(1)
+	if err != nil {
+		m.errors.Add(1)
+	}
+	m.sum.Add(int64(d))
+
+	i := 0
+	for i < len(latencyBuckets) && d > latencyBuckets[i] {
+		i++
+	}
+	m.buckets[i].Add(1)
+}
+
+// TransactionStats counts the transactions of a database since it was opened.
+type TransactionStats = database.TransactionStats
+
+// Metrics describes the activity of a database since it was opened.
+// It is returned by DB.Metrics and can be exported to Prometheus with
+// WritePrometheus or DB.MetricsHandler, or to expvar with DB.PublishExpvar.
+type Metrics struct {
+	// Number of queries run, and number of those that failed.
+	Queries     int64
+	QueryErrors int64
+	// Duration of the queries, until their result was closed.
+	QueryLatency LatencyHistogram
+
+	// Number of queries found, or not, in the cache of the prepared queries.
+	// See Options.StatementCacheSize.
+	StatementCacheHits   int64
+	StatementCacheMisses int64
+
+	Transactions TransactionStats
+
+	// Counters of the storage engine, nil for in-memory databases. See DB.Stats.
+	Storage *Stats
+}
+
+// StatementCacheHitRate returns the proportion of queries found in the cache
+// of the prepared queries, between 0 and 1. It returns 0 if no query was looked up.
+func (m *Metrics) StatementCacheHitRate() float64 {
+	total := m.StatementCacheHits + m.StatementCacheMisses
+	if total == 0 {
+		return 0
+	}
+
+	return float64(m.StatementCacheHits) / float64(total)
+}
+
+// LatencyHistogram counts durations in buckets.
+type LatencyHistogram struct {
+	// Upper bounds of the buckets, in increasing order.
+	Bounds []time.Duration
+	// Counts[i] is the number of durations lower than or equal to Bounds[i].
+	Counts []int64
+	// Number of durations and their sum.
+	Count int64
+	Sum   time.Duration
+}
+
+// Metrics returns the metrics of the database.
+func (db *DB) Metrics() *Metrics {
+	var m Metrics
+
+	m.QueryLatency.Bounds = latencyBuckets[:]
+	m.QueryLatency.Counts = make([]int64, len(latencyBuckets))
+	if qm := db.metrics; qm != nil {
+		m.Queries = qm.queries.Load()
+		m.QueryErrors = qm.errors.Load()
+		m.QueryLatency.Sum = time.Duration(qm.sum.Load())
+
+		var n int64
+		for i := range qm.buckets {
+			n += qm.buckets[i].Load()
+			if i < len(latencyBuckets) {
+				m.QueryLatency.Counts[i] = n
+			}
+		}
+		m.QueryLatency.Count = n
+	}
+
+	if db.stmtCache != nil {
+		m.StatementCacheHits, m.StatementCacheMisses = db.stmtCache.stats()
+	}
+
+	m.Transactions = db.DB.TransactionStats()
+
+	// in-memory databases don't report the counters of their engine
+	if s, err := db.DB.EngineStats(); err == nil {
+		m.Storage = s
+	}
+
+	return &m
+}
+
+// WritePrometheus writes the metrics in the text format of Prometheus.
+// The names of the metrics start with "chai_".
+func (m *Metrics) WritePrometheus(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	metric := func(name, typ, help string, v float64) {
+		bw.WriteString("# HELP " + name + " " + help + "\n")
+		bw.WriteString("# TYPE " + name + " " + typ + "\n")
+		bw.WriteString(name + " " + formatFloat(v) + "\n")
+	}
+
+	metric("chai_queries_total", "counter", "Number of queries run.", float64(m.Queries))
+	metric("chai_query_errors_total", "counter", "Number of queries that failed.", float64(m.QueryErrors))
+
+	const latency = "chai_query_duration_seconds"
+	bw.WriteString("# HELP " + latency + " Duration of the queries.\n")
+	bw.WriteString("# TYPE " + latency + " histogram\n")
+	for i, b := range m.QueryLatency.Bounds {
+		bw.WriteString(latency + `_bucket{le="` + formatFloat(b.Seconds()) + `"} ` + strconv.FormatInt(m.QueryLatency.Counts[i], 10) + "\n")
+	}
+	bw.WriteString(latency + `_bucket{le="+Inf"} ` + strconv.FormatInt(m.QueryLatency.Count, 10) + "\n")
+	bw.WriteString(latency + "_sum " + formatFloat(m.QueryLatency.Sum.Seconds()) + "\n")
+	bw.WriteString(latency + "_count " + strconv.FormatInt(m.QueryLatency.Count, 10) + "\n")
+
+	metric("chai_statement_cache_hits_total", "counter", "Number of queries found in the statement cache.", float64(m.StatementCacheHits))
+	metric("chai_statement_cache_misses_total", "counter", "Number of queries not found in the statement cache.", float64(m.StatementCacheMisses))
+
+	metric("chai_transactions_active", "gauge", "Number of transactions neither committed nor rolled back.", float64(m.Transactions.Active))
+	metric("chai_transactions_committed_total", "counter", "Number of transactions committed.", float64(m.Transactions.Committed))
+	metric("chai_transactions_rolled_back_total", "counter", "Number of transactions rolled back.", float64(m.Transactions.RolledBack))
+
+	if s := m.Storage; s != nil {
+		metric("chai_block_cache_size_bytes", "gauge", "Number of bytes used by the block cache.", float64(s.BlockCacheSize))
+		metric("chai_block_cache_hits_total", "counter", "Number of blocks found in the block cache.", float64(s.BlockCacheHits))
+		metric("chai_block_cache_misses_total", "counter", "Number of blocks not found in the block cache.", float64(s.BlockCacheMisses))
+		metric("chai_compaction_debt_bytes", "gauge", "Number of bytes to compact for the storage to reach a stable shape.", float64(s.CompactionDebt))
+		metric("chai_memtable_size_bytes", "gauge", "Number of bytes of the memtables.", float64(s.MemTableSize))
+		metric("chai_wal_size_bytes", "gauge", "Number of bytes used on disk by the write-ahead log.", float64(s.WALSize))
+		metric("chai_disk_usage_bytes", "gauge", "Number of bytes used on disk.", float64(s.DiskUsage))
+		metric("chai_read_amplification", "gauge", "Number of sstables to read, in the worst case, to find a key.", float64(s.ReadAmplification))
+	}
+
+	return bw.Flush()
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// MetricsHandler returns an HTTP handler serving the metrics of the database
+// in the text format of Prometheus, to be scraped by a Prometheus server.
+func (db *DB) MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_ = db.Metrics().WritePrometheus(w)
+	})
+}
+
+// PublishExpvar publishes the metrics of the database with expvar
+// under the given name. They are then served as JSON, with the other
+// variables, by the /debug/vars handler of the expvar package.
+// Like expvar.Publish, it panics if the name is already used.
+func (db *DB) PublishExpvar(name string) {
+	expvar.Publish(name, expvar.Func(func() any {
+		return db.Metrics()
+	}))
+}
@@ -0,0 +1,426 @@
+// Package migrate applies versioned migrations to a database.
+//
+// Each migration is identified by a version and applied within a transaction,
+// which also records its version in a table of the database, named
+// schema_migrations by default. Migrations are applied in increasing order
+// of version and reverted in decreasing order.
+//
+//	m, err := migrate.New(db, []migrate.Migration{
+//		{
+//			Version: 1,
+//			Name:    "create users",
+//			Up:      migrate.SQL(`CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT)`),
+//			Down:    migrate.SQL(`DROP TABLE users`),
+//		},
+//	}, nil)
+//	if err != nil {
+//		return err
+//	}
+//
+//	_, err = m.Up(ctx)
+package migrate
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/chaisql/chai"
+	"github.com/chaisql/chai/internal/stringutil"
+	"github.com/cockroachdb/errors"
+)
+
+// DefaultTable is the name of the table recording the applied migrations.
+const DefaultTable = "schema_migrations"
+
+// Migration is a change of the database, identified by its version.
+type Migration struct {
+	// Version of the migration, which must be positive and unique.
+	Version int64
+	// Optional name of the migration, recorded with its version.
+	Name string
+	// Up applies the migration. It is required.
+	Up func(ctx context.Context, tx *chai.Tx) error
+	// Down reverts the migration. If nil, the migration cannot be reverted.
+	Down func(ctx context.Context, tx *chai.Tx) error
+}
+
+// SQL returns a function running the given statements,
+// to be used as the Up or Down function of a migration.
+func SQL(q string) func(ctx context.Context, tx *chai.Tx) error {
+	return func(ctx context.Context, tx *chai.Tx) error {
+		return tx.ExecContext(ctx, q)
+	}
+}
+
+// Options configure a Migrator.
+type Options struct {
+	// Table recording the applied migrations. Defaults to DefaultTable.
+	Table string
+	// DryRun applies, or reverts, the migrations within a single transaction
+	// which is rolled back at the end, leaving the database untouched.
+	// It reports the migrations that would be applied and the errors they would return.
+	DryRun bool
+}
+
+// AppliedMigration describes a migration recorded as applied.
+type AppliedMigration struct {
+	Version   int64
+	Name      string
+	AppliedAt time.Time
+}
+
+// Migrator applies and reverts a list of migrations.
+type Migrator struct {
+	db         *chai.DB
+	migrations []Migration
+	table      string
+	dryRun     bool
+}
+
+// New returns a Migrator of the given migrations, which don't need to be sorted.
+// A nil opts uses the default options.
+func New(db *chai.DB, migrations []Migration, opts *Options) (*Migrator, error) {
+	if opts == nil {
+		opts = new(Options)
+	}
+
+	m := Migrator{
+		db:         db,
+		migrations: slices.Clone(migrations),
+		table:      opts.Table,
+		dryRun:     opts.DryRun,
+	}
+	if m.table == "" {
+		m.table = DefaultTable
+	}
+
+	slices.SortFunc(m.migrations, func(a, b Migration) int {
+		return compareVersions(a.Version, b.Version)
+	})
+	for i, mig := range m.migrations {
+		if mig.Version <= 0 {
+			return nil, errors.Errorf("invalid version %d of migration %q: versions must be positive", mig.Version, mig.Name)
+		}
+		if mig.Up == nil {
+			return nil, errors.Errorf("migration %d has no Up function", mig.Version)
+		}
+		if i > 0 && m.migrations[i-1].Version == mig.Version {
+			return nil, errors.Errorf("duplicate migration version %d", mig.Version)
+		}
+	}
+
+	return &m, nil
+}
+
+func compareVersions(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// Up applies the migrations which were not applied yet, in order,
+// and returns them. See UpTo.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	return m.UpTo(ctx, -1)
+}
+
+// UpTo applies the migrations which were not applied yet, up to the given
+// version included, or all of them if the version is negative.
+// Each migration is applied in its own transaction: if one of them fails,
+// the ones applied before it are kept, and are returned with the error.
+func (m *Migrator) UpTo(ctx context.Context, version int64) ([]Migration, error) {
+	return m.run(ctx, false, func(applied map[int64]AppliedMigration) ([]Migration, error) {
+		var plan []Migration
+		for _, mig := range m.migrations {
+			if version >= 0 && mig.Version > version {
+				break
+			}
+			if _, ok := applied[mig.Version]; !ok {
+				plan = append(plan, mig)
+			}
+		}
+
+		return plan, nil
+	})
+}
+
+// Down reverts the last applied migration and returns it,
+// or returns nil if no migration is applied.
+func (m *Migrator) Down(ctx context.Context) (*Migration, error) {
+	var last *Migration
+	reverted, err := m.run(ctx, true, func(applied map[int64]AppliedMigration) ([]Migration, error) {
+		var latest int64
+		for v := range applied {
+			latest = max(latest, v)
+		}
+		if latest == 0 {
+			return nil, nil
+		}
+
+		mig, err := m.revertible(latest)
+		if err != nil {
+			return nil, err
+		}
+		return []Migration{mig}, nil
+	})
+	if len(reverted) > 0 {
+		last = &reverted[0]
+	}
+
+	return last, err
+}
+
+// DownTo reverts the applied migrations whose version is greater than the given version,
+// from the most recent to the oldest, and returns them. Like with UpTo, each migration
+// is reverted in its own transaction. A version of zero reverts every migration.
+func (m *Migrator) DownTo(ctx context.Context, version int64) ([]Migration, error) {
+	return m.run(ctx, true, func(applied map[int64]AppliedMigration) ([]Migration, error) {
+		versions := make([]int64, 0, len(applied))
+		for v := range applied {
+			if v > version {
+				versions = append(versions, v)
+			}
+		}
+		slices.Sort(versions)
+		slices.Reverse(versions)
+
+		plan := make([]Migration, 0, len(versions))
+		for _, v := range versions {
+			mig, err := m.revertible(v)
+			if err != nil {
+				return nil, err
+			}
+			plan = append(plan, mig)
+		}
+
+		return plan, nil
+	})
+}
+
+// revertible returns the migration of the given version,
+// or an error if it is unknown or has no Down function.
+func (m *Migrator) revertible(version int64) (Migration, error) {
+	i, ok := slices.BinarySearchFunc(m.migrations, version, func(mig Migration, v int64) int {
+		return compareVersions(mig.Version, v)
+	})
+	if !ok {
+		return Migration{}, errors.Errorf("cannot revert unknown migration %d", version)
+	}
+	if m.migrations[i].Down == nil {
+		return Migration{}, errors.Errorf("migration %d cannot be reverted: it has no Down function", version)
+	}
+
+	return m.migrations[i], nil
+}
+
+// Applied returns the migrations recorded as applied, ordered by version.
+// It returns no migrations if the table of the migrations doesn't exist.
+func (m *Migrator) Applied(ctx context.Context) ([]AppliedMigration, error) {
+	conn, err := m.db.WithContext(ctx).Connect()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var list []AppliedMigration
+	err = conn.View(func(tx *chai.Tx) error {
+		applied, err := m.applied(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		for _, a := range applied {
+			list = append(list, a)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(list, func(a, b AppliedMigration) int {
+		return compareVersions(a.Version, b.Version)
+	})
+	return list, nil
+}
+
+// Version returns the version of the last applied migration, or zero if none was applied.
+func (m *Migrator) Version(ctx context.Context) (int64, error) {
+	applied, err := m.Applied(ctx)
+	if err != nil || len(applied) == 0 {
+		return 0, err
+	}
+
+	return applied[len(applied)-1].Version, nil
+}
+
+// Pending returns the migrations which were not applied yet, in order.
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	applied, err := m.Applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make(map[int64]bool, len(applied))
+	for _, a := range applied {
+		versions[a.Version] = true
+	}
+
+	var pending []Migration
+	for _, mig := range m.migrations {
+		if !versions[mig.Version] {
+			pending = append(pending, mig)
+		}
+	}
+
+	return pending, nil
+}
+
+// run applies, or reverts, the migrations returned by plan, from the applied migrations.
+// It returns the migrations that were applied or reverted.
+func (m *Migrator) run(ctx context.Context, down bool, plan func(map[int64]AppliedMigration) ([]Migration, error)) ([]Migration, error) {
+	conn, err := m.db.WithContext(ctx).Connect()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if m.dryRun {
+		tx, err := conn.Begin(true)
+		if err != nil {
+			return nil, err
+		}
+		defer tx.Rollback()
+
+		err = m.createTable(ctx, tx)
+		if err != nil {
+			return nil, err
+		}
+
+		applied, err := m.applied(ctx, tx)
+		if err != nil {
+			return nil, err
+		}
+
+		migrations, err := plan(applied)
+		if err != nil {
+			return nil, err
+		}
+
+		for i, mig := range migrations {
+			err = m.step(ctx, tx, mig, down)
+			if err != nil {
+				return migrations[:i], err
+			}
+		}
+
+		return migrations, nil
+	}
+
+	err = conn.Update(func(tx *chai.Tx) error {
+		return m.createTable(ctx, tx)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var migrations []Migration
+	err = conn.View(func(tx *chai.Tx) error {
+		applied, err := m.applied(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		migrations, err = plan(applied)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i, mig := range migrations {
+		if err := ctx.Err(); err != nil {
+			return migrations[:i], err
+		}
+
+		err = conn.Update(func(tx *chai.Tx) error {
+			return m.step(ctx, tx, mig, down)
+		})
+		if err != nil {
+			return migrations[:i], err
+		}
+	}
+
+	return migrations, nil
+}
+
+// step applies, or reverts, a migration and records it.
+// Migrations applied concurrently by another process fail to be recorded
+// because of the primary key of the table.
+func (m *Migrator) step(ctx context.Context, tx *chai.Tx, mig Migration, down bool) error {
+	table := stringutil.NormalizeIdentifier(m.table, '`')
+
+	if down {
+		err := mig.Down(ctx, tx)
+		if err != nil {
+			return errors.Wrapf(err, "failed to revert migration %d", mig.Version)
+		}
+
+		return tx.ExecContext(ctx, `DELETE FROM `+table+` WHERE version = ?`, mig.Version)
+	}
+
+	err := mig.Up(ctx, tx)
+	if err != nil {
+		return errors.Wrapf(err, "failed to apply migration %d", mig.Version)
+	}
+
+	err = tx.ExecContext(ctx, `INSERT INTO `+table+` (version, name, applied_at) VALUES (?, ?, ?)`,
+		mig.Version, mig.Name, time.Now().UTC())
+	if chai.IsAlreadyExistsError(err) {
+		return errors.Errorf("migration %d was already applied", mig.Version)
+	}
+	return err
+}
+
+func (m *Migrator) createTable(ctx context.Context, tx *chai.Tx) error {
+	return tx.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS `+stringutil.NormalizeIdentifier(m.table, '`')+` (
+		version BIGINT PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at TIMESTAMP NOT NULL
+	)`)
+}
+
+// applied returns the migrations recorded in the table, which may not exist yet.
+func (m *Migrator) applied(ctx context.Context, tx *chai.Tx) (map[int64]AppliedMigration, error) {
+	applied := make(map[int64]AppliedMigration)
+
+	res, err := tx.QueryContext(ctx, `SELECT version, name, applied_at FROM `+stringutil.NormalizeIdentifier(m.table, '`'))
+	if chai.IsNotFoundError(err) {
+		return applied, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer res.Close()
+
+	err = res.Iterate(func(r *chai.Row) error {
+		var a AppliedMigration
+		err := r.Scan(&a.Version, &a.Name, &a.AppliedAt)
+		if err != nil {
+			return err
+		}
+
+		applied[a.Version] = a
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return applied, nil
+}
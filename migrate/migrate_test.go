@@ -0,0 +1,183 @@
+package migrate_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/chaisql/chai"
+	"github.com/chaisql/chai/migrate"
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/require"
+)
+
+var migrations = []migrate.Migration{
+	{
+		Version: 2,
+		Name:    "add users index",
+		Up:      migrate.SQL(`CREATE INDEX users_name ON users (name)`),
+		Down:    migrate.SQL(`DROP INDEX users_name`),
+	},
+	{
+		Version: 1,
+		Name:    "create users",
+		Up:      migrate.SQL(`CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT)`),
+		Down:    migrate.SQL(`DROP TABLE users`),
+	},
+	{
+		Version: 3,
+		Name:    "add admin",
+		Up: func(ctx context.Context, tx *chai.Tx) error {
+			return tx.ExecContext(ctx, `INSERT INTO users (id, name) VALUES (?, ?)`, 1, "admin")
+		},
+	},
+}
+
+func versions(ms []migrate.Migration) []int64 {
+	var vs []int64
+	for _, m := range ms {
+		vs = append(vs, m.Version)
+	}
+	return vs
+}
+
+func tableExists(t *testing.T, db *chai.DB, name string) bool {
+	t.Helper()
+
+	r, err := db.QueryRow(`SELECT COUNT(*) FROM __chai_catalog WHERE name = ?`, name)
+	require.NoError(t, err)
+	var n int
+	require.NoError(t, r.Scan(&n))
+	return n > 0
+}
+
+func TestMigrator(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	m, err := migrate.New(db, migrations, nil)
+	require.NoError(t, err)
+
+	v, err := m.Version(ctx)
+	require.NoError(t, err)
+	require.Zero(t, v)
+
+	applied, err := m.UpTo(ctx, 2)
+	require.NoError(t, err)
+	require.Equal(t, []int64{1, 2}, versions(applied))
+	require.True(t, tableExists(t, db, "users_name"))
+
+	pending, err := m.Pending(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []int64{3}, versions(pending))
+
+	applied, err = m.Up(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []int64{3}, versions(applied))
+
+	list, err := m.Applied(ctx)
+	require.NoError(t, err)
+	require.Len(t, list, 3)
+	require.Equal(t, "create users", list[0].Name)
+	require.False(t, list[0].AppliedAt.IsZero())
+
+	// nothing left to apply
+	applied, err = m.Up(ctx)
+	require.NoError(t, err)
+	require.Empty(t, applied)
+
+	// the last migration cannot be reverted
+	_, err = m.Down(ctx)
+	require.Error(t, err)
+
+	db2, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db2.Close()
+
+	m2, err := migrate.New(db2, migrations[:2], nil)
+	require.NoError(t, err)
+	_, err = m2.Up(ctx)
+	require.NoError(t, err)
+
+	last, err := m2.Down(ctx)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, last.Version)
+	require.False(t, tableExists(t, db2, "users_name"))
+
+	reverted, err := m2.DownTo(ctx, 0)
+	require.NoError(t, err)
+	require.Equal(t, []int64{1}, versions(reverted))
+	require.False(t, tableExists(t, db2, "users"))
+
+	last, err = m2.Down(ctx)
+	require.NoError(t, err)
+	require.Nil(t, last)
+}
+
+func TestMigratorFailure(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	m, err := migrate.New(db, []migrate.Migration{
+		migrations[1],
+		{
+			Version: 2,
+			Up: func(ctx context.Context, tx *chai.Tx) error {
+				err := tx.Exec(`CREATE TABLE other (id INTEGER PRIMARY KEY)`)
+				if err != nil {
+					return err
+				}
+				return errors.New("boom")
+			},
+		},
+	}, &migrate.Options{Table: "versions"})
+	require.NoError(t, err)
+
+	applied, err := m.Up(ctx)
+	require.ErrorContains(t, err, "boom")
+	require.Equal(t, []int64{1}, versions(applied))
+	require.True(t, tableExists(t, db, "versions"))
+	require.False(t, tableExists(t, db, "other"))
+
+	v, err := m.Version(ctx)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, v)
+}
+
+func TestMigratorDryRun(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	m, err := migrate.New(db, migrations, &migrate.Options{DryRun: true})
+	require.NoError(t, err)
+
+	applied, err := m.Up(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []int64{1, 2, 3}, versions(applied))
+
+	require.False(t, tableExists(t, db, "users"))
+	require.False(t, tableExists(t, db, migrate.DefaultTable))
+}
+
+func TestNew(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = migrate.New(db, []migrate.Migration{migrations[0], migrations[0]}, nil)
+	require.Error(t, err)
+
+	_, err = migrate.New(db, []migrate.Migration{{Version: 0, Up: migrations[0].Up}}, nil)
+	require.Error(t, err)
+
+	_, err = migrate.New(db, []migrate.Migration{{Version: 1}}, nil)
+	require.Error(t, err)
+}
@@ -0,0 +1,76 @@
+package chai
+
+import (
+	"context"
+
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/environment"
+	"github.com/chaisql/chai/internal/query/statement"
+	"github.com/chaisql/chai/internal/row"
+	"github.com/chaisql/chai/internal/sql/parser"
+	"github.com/chaisql/chai/internal/types"
+)
+
+// A RowPolicy returns the condition that the rows of a table must satisfy
+// to be visible to a query, as an SQL expression such as "tenant_id = ?",
+// along with the arguments of its parameters.
+// It is called with the context of the query every time the table is scanned,
+// which allows restricting the rows based on values stored in the context,
+// such as the tenant of a request. An empty condition makes every row visible.
+type RowPolicy func(ctx context.Context) (cond string, args []any, err error)
+
+// SetRowPolicy restricts the rows of the given table that queries can read, update or delete
+// to the ones satisfying the condition returned by the policy, as if it was added to the
+// WHERE clause of every statement reading the table, including the ones already prepared.
+// It replaces the previous policy of the table, and a nil policy removes it.
+// The table doesn't need to exist.
+// Inserted rows are not checked: use OnWrite to validate them. However,
+// INSERT ... ON CONFLICT DO REPLACE fails if the conflicting row is hidden by the policy.
+//
+//	db.SetRowPolicy("orders", func(ctx context.Context) (string, []any, error) {
+//		tenant, ok := ctx.Value(tenantKey{}).(string)
+//		if !ok {
+//			return "", nil, errors.New("no tenant")
+//		}
+//		return "tenant_id = ?", []any{tenant}, nil
+//	})
+func (db *DB) SetRowPolicy(table string, policy RowPolicy) {
+	if policy == nil {
+		db.DB.SetRowPolicy(table, nil)
+		return
+	}
+
+	db.DB.SetRowPolicy(table, func(ctx context.Context, tx *database.Transaction) (database.RowPredicate, error) {
+		cond, args, err := policy(ctx)
+		if err != nil || cond == "" {
+			return nil, err
+		}
+
+		e, err := parser.ParseExpr(cond)
+		if err != nil {
+			return nil, err
+		}
+
+		err = statement.BindExpr(&statement.Context{Ctx: ctx, DB: db.DB, Tx: tx}, table, e)
+		if err != nil {
+			return nil, err
+		}
+
+		env := environment.Environment{
+			Params: argsToParams(args),
+			DB:     db.DB,
+			Tx:     tx,
+			Ctx:    ctx,
+		}
+
+		return func(r row.Row) (bool, error) {
+			env.SetRow(r)
+			v, err := e.Eval(&env)
+			if err != nil {
+				return false, err
+			}
+
+			return types.IsTruthy(v)
+		}, nil
+	})
+}
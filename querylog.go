@@ -0,0 +1,120 @@
+package chai
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/chaisql/chai/internal/query/statement"
+)
+
+// QueryLogger observes the queries run by a database. See DB.SetQueryLogger.
+type QueryLogger struct {
+	// OnQuery is called once for every query, after its result is closed
+	// or after it failed, with the context of the query.
+	// It is called by the goroutine running the query and must not block.
+	OnQuery func(ctx context.Context, e QueryEvent)
+
+	// SlowQueryThreshold, if positive, restricts the calls to OnQuery
+	// to the queries that took at least this duration.
+	SlowQueryThreshold time.Duration
+
+	// RedactArgs removes the arguments of the queries from the events,
+	// for example if they may contain personal data or secrets.
+	RedactArgs bool
+}
+
+// QueryEvent describes a query that was run.
+type QueryEvent struct {
+	// SQL is the text of the query.
+	SQL string
+	// Args are the arguments bound to the parameters of the query.
+	// They are nil if QueryLogger.RedactArgs is set.
+	Args []any
+	// Duration of the query, from the moment it was run until its result
+	// was closed. It includes the time spent by the caller reading the rows.
+	Duration time.Duration
+	// RowsAffected is the number of rows inserted, updated or deleted by
+	// the last statement of the query, or -1 if it is unknown, which is the
+	// case of deletions of whole ranges of rows.
+	RowsAffected int64
+	// Err is the error returned by the query, if any.
+	Err error
+}
+
+// SetQueryLogger sets the logger of the queries run by the database
+// and its connections, replacing the previous one. A nil logger
+// stops logging. It is safe to call while queries are running.
+func (db *DB) SetQueryLogger(l *QueryLogger) {
+	// databases which were not opened by this package have no logger
+	if db.queryLogger == nil {
+		db.queryLogger = new(atomic.Pointer[QueryLogger])
+	}
+
+	db.queryLogger.Store(l)
+}
+
+// queryLog records a query until it is over.
+type queryLog struct {
+	logger  *QueryLogger
+	metrics *queryMetrics
+	ctx     context.Context
+	sql     string
+	args    []any
+	start   time.Time
+}
+
+// startQueryLog returns a queryLog for the query, or nil
+// if the queries of the database are neither logged nor measured.
+func startQueryLog(db *DB, ctx context.Context, q string, args []any) *queryLog {
+	var l *QueryLogger
+	if db.queryLogger != nil {
+		l = db.queryLogger.Load()
+		if l != nil && l.OnQuery == nil {
+			l = nil
+		}
+	}
+	if l == nil && db.metrics == nil {
+		return nil
+	}
+
+	if l != nil && l.RedactArgs {
+		args = nil
+	}
+
+	return &queryLog{
+		logger:  l,
+		metrics: db.metrics,
+		ctx:     ctx,
+		sql:     q,
+		args:    args,
+		start:   time.Now(),
+	}
+}
+
+// done records the outcome of the query and calls the logger.
+func (l *queryLog) done(res *statement.Result, err error) {
+	d := time.Since(l.start)
+	if l.metrics != nil {
+		l.metrics.observe(d, err)
+	}
+
+	if l.logger == nil || d < l.logger.SlowQueryThreshold {
+		return
+	}
+
+	var n int64
+	if res != nil {
+		if it, ok := res.Iterator.(*statement.StreamStmtIterator); ok {
+			n = it.RowsAffected()
+		}
+	}
+
+	l.logger.OnQuery(l.ctx, QueryEvent{
+		SQL:          l.sql,
+		Args:         l.args,
+		Duration:     d,
+		RowsAffected: n,
+		Err:          err,
+	})
+}
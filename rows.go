@@ -0,0 +1,195 @@
+package chai
+
+import (
+	"context"
+	"sync"
+
+	"github.com/chaisql/chai/internal/stream"
+	"github.com/cockroachdb/errors"
+)
+
+// Query runs the query and returns an iterator over its rows.
+// See QueryContext.
+func (db *DB) Query(q string, args ...any) (*Rows, error) {
+	return db.QueryContext(db.ctx, q, args...)
+}
+
+// QueryContext runs the query with the given context and returns an iterator over its rows,
+// which are read one at a time, as Next is called, without loading the whole result in memory.
+// The rows must always be closed after usage, to release the connection used by the query.
+// See Statement.QueryContext for how the context is used.
+func (db *DB) QueryContext(ctx context.Context, q string, args ...any) (*Rows, error) {
+	conn, release, err := db.acquireConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := conn.QueryContext(ctx, q, args...)
+	if err != nil {
+		release()
+		return nil, err
+	}
+
+	cols, err := res.Columns()
+	if err != nil {
+		_ = res.Close()
+		release()
+		return nil, err
+	}
+
+	rs := Rows{
+		res:     res,
+		release: release,
+		columns: cols,
+		c:       make(chan rowsMsg),
+		stop:    make(chan struct{}),
+	}
+	rs.wg.Add(1)
+	go rs.iterate()
+
+	return &rs, nil
+}
+
+// Rows is an iterator over the rows of a query, returned by DB.Query.
+// Its usage is similar to the one of sql.Rows:
+//
+//	rows, err := db.Query("SELECT id, name FROM users")
+//	if err != nil {
+//		return err
+//	}
+//	defer rows.Close()
+//
+//	for rows.Next() {
+//		var id int
+//		var name string
+//		if err := rows.Scan(&id, &name); err != nil {
+//			return err
+//		}
+//	}
+//	return rows.Err()
+//
+// Rows is not safe for concurrent use.
+type Rows struct {
+	res     *Result
+	release func()
+	columns []string
+
+	// the rows are read by a goroutine, which sends them
+	// one at a time on c when Next asks for them on c.
+	c    chan rowsMsg
+	stop chan struct{}
+	wg   sync.WaitGroup
+
+	row    *Row
+	err    error
+	done   bool
+	closed bool
+}
+
+type rowsMsg struct {
+	row *Row
+	err error
+}
+
+func (rs *Rows) iterate() {
+	defer rs.wg.Done()
+	defer close(rs.c)
+
+	select {
+	case <-rs.stop:
+		return
+	case <-rs.c:
+	}
+
+	err := rs.res.Iterate(func(r *Row) error {
+		select {
+		case <-rs.stop:
+			return stream.ErrStreamClosed
+		case rs.c <- rowsMsg{row: r}:
+		}
+
+		select {
+		case <-rs.stop:
+			return stream.ErrStreamClosed
+		case <-rs.c:
+			return nil
+		}
+	})
+	if err == nil {
+		return
+	}
+
+	select {
+	case <-rs.stop:
+	case rs.c <- rowsMsg{err: err}:
+	}
+}
+
+// Next prepares the next row to be read with Scan or Row.
+// It returns false when there are no more rows or if an error occurred,
+// which is then returned by Err. The rows are closed automatically
+// once Next returns false.
+func (rs *Rows) Next() bool {
+	rs.row = nil
+	if rs.done || rs.closed {
+		return false
+	}
+
+	rs.c <- rowsMsg{}
+	m, ok := <-rs.c
+	if ok && m.err == nil {
+		rs.row = m.row
+		return true
+	}
+
+	rs.done = true
+	rs.err = m.err
+	if err := rs.Close(); rs.err == nil {
+		rs.err = err
+	}
+
+	return false
+}
+
+// Scan copies the columns of the current row into the values pointed at by dest,
+// like Row.Scan.
+func (rs *Rows) Scan(dest ...any) error {
+	if rs.row == nil {
+		return errors.New("Scan called without calling Next")
+	}
+
+	return rs.row.Scan(dest...)
+}
+
+// Row returns the current row, or nil if Next wasn't called or returned false.
+// It is only valid until the next call to Next or Close: use Row.Clone to retain it.
+func (rs *Rows) Row() *Row {
+	return rs.row
+}
+
+// Columns returns the names of the columns of the rows.
+func (rs *Rows) Columns() []string {
+	return rs.columns
+}
+
+// Err returns the error, if any, that stopped the iteration.
+func (rs *Rows) Err() error {
+	return rs.err
+}
+
+// Close stops the iteration and releases the connection used by the query.
+// It is safe to call Close more than once.
+func (rs *Rows) Close() error {
+	if rs.closed {
+		return nil
+	}
+	rs.closed = true
+	rs.row = nil
+
+	close(rs.stop)
+	rs.wg.Wait()
+
+	err := rs.res.Close()
+	rs.release()
+	return err
+}
@@ -128,4 +128,24 @@ ALTER TABLE test ADD COLUMN;
 
 -- test: bad syntax: missing column keyword
 ALTER TABLE test ADD a int;
--- error:
\ No newline at end of file
+-- error:
+
+-- test: dictionary
+INSERT INTO test VALUES (1), (2);
+ALTER TABLE test ADD COLUMN b TEXT DICTIONARY DEFAULT 'new';
+INSERT INTO test VALUES (3, 'old');
+SELECT * FROM test;
+/* result:
+{
+  "a": 1,
+  "b": "new"
+}
+{
+  "a": 2,
+  "b": "new"
+}
+{
+  "a": 3,
+  "b": "old"
+}
+*/
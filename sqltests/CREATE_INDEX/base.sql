@@ -77,3 +77,48 @@ SELECT name, owner_table_name AS table_name, sql FROM __chai_catalog WHERE type
 -- test: generated name with IF NOT EXISTS
 CREATE INDEX IF NOT EXISTS ON test(a);
 -- error:
+
+-- test: JSON path
+CREATE TABLE docs (d JSONB);
+CREATE INDEX ON docs (JSON_EXTRACT(d, '$.a.b') DESC);
+SELECT name, owner_table_name AS table_name, sql FROM __chai_catalog WHERE type = "index";
+/* result:
+{
+  "name": "docs_expr_idx",
+  "table_name": "docs",
+  "sql": "CREATE INDEX docs_expr_idx ON docs (JSON_EXTRACT(d, \"$.a.b\") DESC)"
+}
+*/
+
+-- test: expression
+CREATE INDEX ON test (a + 1);
+-- error:
+
+-- test: NULLs order
+CREATE INDEX ON test (a NULLS LAST);
+CREATE INDEX ON test (a DESC NULLS FIRST);
+CREATE INDEX ON test (a ASC NULLS FIRST);
+CREATE INDEX ON test (a DESC NULLS LAST);
+SELECT name, sql FROM __chai_catalog WHERE type = "index" ORDER BY name;
+/* result:
+{
+  "name": "test_a_idx",
+  "sql": "CREATE INDEX test_a_idx ON test (a NULLS LAST)"
+}
+{
+  "name": "test_a_idx1",
+  "sql": "CREATE INDEX test_a_idx1 ON test (a DESC NULLS FIRST)"
+}
+{
+  "name": "test_a_idx2",
+  "sql": "CREATE INDEX test_a_idx2 ON test (a)"
+}
+{
+  "name": "test_a_idx3",
+  "sql": "CREATE INDEX test_a_idx3 ON test (a DESC)"
+}
+*/
+
+-- test: invalid NULLs order
+CREATE INDEX ON test (a NULLS);
+-- error:
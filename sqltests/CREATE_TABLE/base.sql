@@ -34,3 +34,15 @@ SELECT name, sql FROM __chai_catalog WHERE type = "table" AND name = "test";
   "sql": "CREATE TABLE test (a INTEGER)"
 }
 */
+
+-- test: location not supported in memory
+CREATE TABLE test(a int) WITH (location = 'cold');
+-- error:
+
+-- test: unknown option
+CREATE TABLE test(a int) WITH (foo = 'bar');
+-- error:
+
+-- test: duplicate location
+CREATE TABLE test(a int) WITH (location = 'cold', location = 'hot');
+-- error:
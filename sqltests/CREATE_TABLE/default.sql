@@ -48,3 +48,31 @@ CREATE TABLE test(a BLOB DEFAULT 1 AND 1);
 CREATE TABLE test(a BLOB DEFAULT b);
 -- error:
 
+
+-- test: blob literal
+CREATE TABLE test(a INT, b BLOB DEFAULT x'CAFE');
+INSERT INTO test (a) VALUES (1);
+SELECT encode(b, 'hex') AS b FROM test;
+/* result:
+{
+  "b": "cafe"
+}
+*/
+
+-- test: function call
+CREATE TABLE test(id UUID PRIMARY KEY DEFAULT gen_uuid_v7(), a INT);
+SELECT name, sql FROM __chai_catalog WHERE type = "table" AND name = "test";
+/* result:
+{
+  "name": "test",
+  "sql": "CREATE TABLE test (id UUID NOT NULL DEFAULT gen_uuid_v7(), a INTEGER, CONSTRAINT test_pk PRIMARY KEY (id))"
+}
+*/
+
+-- test: function call with a column
+CREATE TABLE test(a TEXT, b TEXT DEFAULT md5(a));
+-- error:
+
+-- test: function call of an incompatible type
+CREATE TABLE test(a INT DEFAULT gen_uuid_v4());
+-- error:
@@ -0,0 +1,60 @@
+-- test: basic
+CREATE TABLE test(a INT, b TEXT DICTIONARY NOT NULL);
+SELECT name, sql FROM __chai_catalog WHERE type = "table" AND name = "test";
+/* result:
+{
+  "name": "test",
+  "sql": "CREATE TABLE test (a INTEGER, b TEXT DICTIONARY NOT NULL)"
+}
+*/
+
+-- test: dictionary twice
+CREATE TABLE test(a TEXT DICTIONARY DICTIONARY);
+-- error:
+
+-- test: not a text
+CREATE TABLE test(a INT DICTIONARY);
+-- error:
+
+-- test: values
+CREATE TABLE test(a INT PRIMARY KEY, b TEXT DICTIONARY DEFAULT 'none');
+INSERT INTO test (a, b) VALUES (1, 'open'), (2, 'closed'), (3, 'open'), (4, NULL);
+INSERT INTO test (a) VALUES (5);
+UPDATE test SET b = 'closed!' WHERE a = 2;
+SELECT a, b FROM test ORDER BY a;
+/* result:
+{
+  "a": 1,
+  "b": "open"
+}
+{
+  "a": 2,
+  "b": "closed!"
+}
+{
+  "a": 3,
+  "b": "open"
+}
+{
+  "a": 4,
+  "b": null
+}
+{
+  "a": 5,
+  "b": "none"
+}
+*/
+
+-- test: index
+CREATE TABLE test(a INT PRIMARY KEY, b TEXT DICTIONARY);
+CREATE INDEX test_b ON test(b);
+INSERT INTO test (a, b) VALUES (1, 'open'), (2, 'closed'), (3, 'open');
+SELECT a FROM test WHERE b = 'open' ORDER BY a;
+/* result:
+{
+  "a": 1
+}
+{
+  "a": 3
+}
+*/
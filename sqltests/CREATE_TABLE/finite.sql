@@ -0,0 +1,53 @@
+-- test: basic
+CREATE TABLE test(a INT, b DOUBLE FINITE NOT NULL, c REAL FINITE);
+SELECT name, sql FROM __chai_catalog WHERE type = "table" AND name = "test";
+/* result:
+{
+  "name": "test",
+  "sql": "CREATE TABLE test (a INTEGER, b DOUBLE FINITE NOT NULL, c REAL FINITE)"
+}
+*/
+
+-- test: finite twice
+CREATE TABLE test(a DOUBLE FINITE FINITE);
+-- error:
+
+-- test: not a double
+CREATE TABLE test(a INT FINITE);
+-- error:
+
+-- test: NaN
+CREATE TABLE test(a DOUBLE FINITE);
+INSERT INTO test (a) VALUES (CAST('NaN' AS DOUBLE));
+-- error: FINITE constraint error: [a]
+
+-- test: infinity
+CREATE TABLE test(a DOUBLE FINITE);
+INSERT INTO test (a) VALUES (CAST('-Inf' AS DOUBLE));
+-- error: FINITE constraint error: [a]
+
+-- test: real
+CREATE TABLE test(a REAL FINITE);
+INSERT INTO test (a) VALUES (CAST('Infinity' AS DOUBLE));
+-- error: FINITE constraint error: [a]
+
+-- test: update
+CREATE TABLE test(a DOUBLE FINITE);
+INSERT INTO test (a) VALUES (1.5);
+UPDATE test SET a = CAST('NaN' AS DOUBLE);
+-- error: FINITE constraint error: [a]
+
+-- test: finite values
+CREATE TABLE test(a DOUBLE FINITE, b DOUBLE);
+INSERT INTO test (a, b) VALUES (1.5, CAST('NaN' AS DOUBLE)), (NULL, NULL);
+SELECT a, CAST(b AS TEXT) AS b FROM test ORDER BY a;
+/* result:
+{
+  "a": null,
+  "b": null
+}
+{
+  "a": 1.5,
+  "b": "NaN"
+}
+*/
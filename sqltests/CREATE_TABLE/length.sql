@@ -0,0 +1,124 @@
+-- test: VARCHAR(n)
+CREATE TABLE test(a INT PRIMARY KEY, b VARCHAR(5));
+INSERT INTO test (a, b) VALUES (1, 'abc'), (2, 'abcde'), (3, 'héllo'), (4, NULL);
+SELECT a, b FROM test ORDER BY a;
+/* result:
+{
+  "a": 1,
+  "b": "abc"
+}
+{
+  "a": 2,
+  "b": "abcde"
+}
+{
+  "a": 3,
+  "b": "héllo"
+}
+{
+  "a": 4,
+  "b": null
+}
+*/
+
+-- test: VARCHAR(n) too long
+CREATE TABLE test(a VARCHAR(5));
+INSERT INTO test (a) VALUES ('abcdef');
+-- error: value too long for type VARCHAR(5)
+
+-- test: VARCHAR(n) trailing spaces are removed
+CREATE TABLE test(a VARCHAR(5));
+INSERT INTO test (a) VALUES ('ab   '), ('abc    ');
+SELECT a FROM test;
+/* result:
+{
+  "a": "ab   "
+}
+{
+  "a": "abc  "
+}
+*/
+
+-- test: VARCHAR(n) non-text values
+CREATE TABLE test(a VARCHAR(3));
+INSERT INTO test (a) VALUES (123);
+INSERT INTO test (a) VALUES (1234);
+-- error: value too long for type VARCHAR(3)
+
+-- test: CHAR(n) padding
+CREATE TABLE test(a INT PRIMARY KEY, b CHAR(5));
+INSERT INTO test (a, b) VALUES (1, 'ab'), (2, 'abcde'), (3, '');
+SELECT a, b, LEN(b) AS l FROM test ORDER BY a;
+/* result:
+{
+  "a": 1,
+  "b": "ab   ",
+  "l": 5
+}
+{
+  "a": 2,
+  "b": "abcde",
+  "l": 5
+}
+{
+  "a": 3,
+  "b": "     ",
+  "l": 5
+}
+*/
+
+-- test: CHAR(n) too long
+CREATE TABLE test(a CHAR(2));
+INSERT INTO test (a) VALUES ('abc');
+-- error: value too long for type CHAR(2)
+
+-- test: CHAR(n) comparisons
+CREATE TABLE test(a INT PRIMARY KEY, b CHAR(5));
+INSERT INTO test (a, b) VALUES (1, 'ab'), (2, 'abc');
+SELECT a FROM test WHERE b = 'ab';
+/* result:
+{
+  "a": 1
+}
+*/
+
+-- test: CHAR(n) comparisons with an index
+CREATE TABLE test(a INT PRIMARY KEY, b CHAR(5));
+CREATE INDEX test_b ON test(b);
+INSERT INTO test (a, b) VALUES (1, 'ab'), (2, 'abc');
+SELECT a FROM test WHERE b IN ('abc', 'abcdefgh');
+/* result:
+{
+  "a": 2
+}
+*/
+
+-- test: UPDATE
+CREATE TABLE test(a INT PRIMARY KEY, b VARCHAR(3));
+INSERT INTO test (a, b) VALUES (1, 'ab');
+UPDATE test SET b = 'abcd';
+-- error: value too long for type VARCHAR(3)
+
+-- test: DEFAULT
+CREATE TABLE test(a INT PRIMARY KEY, b CHAR(3) DEFAULT 'x');
+INSERT INTO test (a) VALUES (1);
+SELECT b FROM test;
+/* result:
+{
+  "b": "x  "
+}
+*/
+
+-- test: ALTER TABLE ADD COLUMN
+CREATE TABLE test(a INT PRIMARY KEY);
+ALTER TABLE test ADD COLUMN b VARCHAR(2);
+INSERT INTO test (a, b) VALUES (1, 'abc');
+-- error: value too long for type VARCHAR(2)
+
+-- test: CAST ignores the length
+SELECT CAST('abcdef' AS VARCHAR(2)) AS a;
+/* result:
+{
+  "a": "abcdef"
+}
+*/
@@ -122,22 +122,176 @@ SELECT name, sql FROM __chai_catalog WHERE type = "table" AND name = "test";
 }
 */
 
--- test: TEXT ALIAS: VARCHAR(n)
+-- test: VARCHAR(n)
 CREATE TABLE test (a VARCHAR(255));
 SELECT name, sql FROM __chai_catalog WHERE type = "table" AND name = "test";
 /* result:
+{
+  "name": "test",
+  "sql": "CREATE TABLE test (a VARCHAR(255))"
+}
+*/
+
+-- test: TEXT ALIAS: VARCHAR
+CREATE TABLE test (a VARCHAR);
+SELECT name, sql FROM __chai_catalog WHERE type = "table" AND name = "test";
+/* result:
 {
   "name": "test",
   "sql": "CREATE TABLE test (a TEXT)"
 }
 */
 
--- test: TEXT ALIAS: character(n)
+-- test: CHAR(n)
+CREATE TABLE test (a CHAR(10));
+SELECT name, sql FROM __chai_catalog WHERE type = "table" AND name = "test";
+/* result:
+{
+  "name": "test",
+  "sql": "CREATE TABLE test (a CHAR(10))"
+}
+*/
+
+-- test: CHAR(n) ALIAS: character(n)
 CREATE TABLE test (a character(255));
 SELECT name, sql FROM __chai_catalog WHERE type = "table" AND name = "test";
 /* result:
 {
   "name": "test",
-  "sql": "CREATE TABLE test (a TEXT)"
+  "sql": "CREATE TABLE test (a CHAR(255))"
+}
+*/
+
+-- test: CHAR(n) ALIAS: CHAR
+CREATE TABLE test (a CHAR);
+SELECT name, sql FROM __chai_catalog WHERE type = "table" AND name = "test";
+/* result:
+{
+  "name": "test",
+  "sql": "CREATE TABLE test (a CHAR(1))"
+}
+*/
+
+-- test: VARCHAR(0)
+CREATE TABLE test (a VARCHAR(0));
+-- error:
+
+-- test: TIMESTAMPTZ
+CREATE TABLE test (a TIMESTAMPTZ);
+SELECT name, sql FROM __chai_catalog WHERE type = "table" AND name = "test";
+/* result:
+{
+  "name": "test",
+  "sql": "CREATE TABLE test (a TIMESTAMPTZ)"
+}
+*/
+
+-- test: TIMESTAMPTZ ALIAS: TIMESTAMP WITH TIME ZONE
+CREATE TABLE test (a TIMESTAMP WITH TIME ZONE);
+SELECT name, sql FROM __chai_catalog WHERE type = "table" AND name = "test";
+/* result:
+{
+  "name": "test",
+  "sql": "CREATE TABLE test (a TIMESTAMPTZ)"
+}
+*/
+
+-- test: TIMESTAMP ALIAS: TIMESTAMP WITHOUT TIME ZONE
+CREATE TABLE test (a timestamp without time zone);
+SELECT name, sql FROM __chai_catalog WHERE type = "table" AND name = "test";
+/* result:
+{
+  "name": "test",
+  "sql": "CREATE TABLE test (a TIMESTAMP)"
+}
+*/
+
+-- test: UUID
+CREATE TABLE test (a UUID);
+SELECT name, sql FROM __chai_catalog WHERE type = "table" AND name = "test";
+/* result:
+{
+  "name": "test",
+  "sql": "CREATE TABLE test (a UUID)"
+}
+*/
+
+-- test: JSONB
+CREATE TABLE test (a JSONB, b JSON);
+SELECT name, sql FROM __chai_catalog WHERE type = "table" AND name = "test";
+/* result:
+{
+  "name": "test",
+  "sql": "CREATE TABLE test (a JSONB, b JSONB)"
+}
+*/
+
+-- test: OBJECT
+CREATE TABLE test (a OBJECT, b DOCUMENT, c ARRAY);
+SELECT name, sql FROM __chai_catalog WHERE type = "table" AND name = "test";
+/* result:
+{
+  "name": "test",
+  "sql": "CREATE TABLE test (a OBJECT, b OBJECT, c ARRAY)"
+}
+*/
+
+-- test: CITEXT
+CREATE TABLE test (a CITEXT);
+SELECT name, sql FROM __chai_catalog WHERE type = "table" AND name = "test";
+/* result:
+{
+  "name": "test",
+  "sql": "CREATE TABLE test (a CITEXT)"
+}
+*/
+
+-- test: INET
+CREATE TABLE test (a INET, b CIDR);
+SELECT name, sql FROM __chai_catalog WHERE type = "table" AND name = "test";
+/* result:
+{
+  "name": "test",
+  "sql": "CREATE TABLE test (a INET, b CIDR)"
+}
+*/
+
+-- test: POINT
+CREATE TABLE test (a POINT);
+SELECT name, sql FROM __chai_catalog WHERE type = "table" AND name = "test";
+/* result:
+{
+  "name": "test",
+  "sql": "CREATE TABLE test (a POINT)"
+}
+*/
+
+-- test: INTERVAL
+CREATE TABLE test (a INTERVAL);
+SELECT name, sql FROM __chai_catalog WHERE type = "table" AND name = "test";
+/* result:
+{
+  "name": "test",
+  "sql": "CREATE TABLE test (a INTERVAL)"
+}
+*/
+
+-- test: BIGINT UNSIGNED
+CREATE TABLE test (a BIGINT UNSIGNED, b UINT64, c INT8 UNSIGNED);
+SELECT name, sql FROM __chai_catalog WHERE type = "table" AND name = "test";
+/* result:
+{
+  "name": "test",
+  "sql": "CREATE TABLE test (a BIGINT UNSIGNED, b BIGINT UNSIGNED, c BIGINT UNSIGNED)"
+}
+*/
+
+-- test: REAL
+CREATE TABLE test (a REAL);
+SELECT name, sql FROM __chai_catalog WHERE type = "table" AND name = "test";
+/* result:
+{
+  "name": "test",
+  "sql": "CREATE TABLE test (a REAL)"
 }
 */
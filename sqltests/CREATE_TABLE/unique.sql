@@ -97,6 +97,25 @@ WHERE
 }
 */
 
+-- test: table constraint: multiple columns with NULLs order
+CREATE TABLE test(a INT, b INT, c INT, UNIQUE(a DESC NULLS FIRST, b NULLS LAST, c ASC NULLS FIRST));
+SELECT name, sql 
+FROM __chai_catalog 
+WHERE 
+    (type = "table" AND name = "test") 
+  OR
+    (type = "index" AND name = "test_a_b_c_idx");
+/* result:
+{
+  "name": "test",
+  "sql": "CREATE TABLE test (a INTEGER, b INTEGER, c INTEGER, CONSTRAINT test_a_b_c_unique UNIQUE (a DESC NULLS FIRST, b NULLS LAST, c))"
+}
+{
+  "name": "test_a_b_c_idx",
+  "sql": "CREATE UNIQUE INDEX test_a_b_c_idx ON test (a DESC NULLS FIRST, b NULLS LAST, c)"
+}
+*/
+
 -- test: table constraint: undeclared column
 CREATE TABLE test(a INT, UNIQUE(b));
 -- error:
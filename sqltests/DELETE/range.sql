@@ -0,0 +1,28 @@
+-- setup:
+CREATE TABLE test(a int PRIMARY KEY, b int);
+INSERT INTO test (a, b) VALUES (1, 1), (2, 2), (3, 3), (4, 4), (5, 5);
+
+-- test: range on primary key
+DELETE FROM test WHERE a > 1 AND a <= 3;
+SELECT a FROM test;
+/* result:
+{"a": 1}
+{"a": 4}
+{"a": 5}
+*/
+
+-- test: IN
+DELETE FROM test WHERE a IN (1, 5);
+SELECT a FROM test;
+/* result:
+{"a": 2}
+{"a": 3}
+{"a": 4}
+*/
+
+-- test: no condition
+DELETE FROM test;
+SELECT COUNT(*) FROM test;
+/* result:
+{"COUNT(*)": 0}
+*/
@@ -0,0 +1,66 @@
+-- setup:
+CREATE TABLE src(a INT);
+INSERT INTO src (a) VALUES (1), (2), (3);
+
+-- test: default value evaluated per row
+CREATE TABLE test(id UUID PRIMARY KEY DEFAULT gen_uuid_v7(), a INT);
+INSERT INTO test (a) VALUES (1), (2);
+INSERT INTO test (a) SELECT a FROM src;
+SELECT COUNT(*) AS n, MIN(a) AS min, MAX(a) AS max FROM test;
+/* result:
+{
+  "n": 5,
+  "min": 1,
+  "max": 3
+}
+*/
+
+-- test: default values are increasing
+CREATE TABLE test(id UUID DEFAULT gen_uuid_v7(), a INT PRIMARY KEY);
+INSERT INTO test (a) SELECT a FROM src;
+SELECT a FROM test ORDER BY id DESC;
+/* result:
+{
+  "a": 3
+}
+{
+  "a": 2
+}
+{
+  "a": 1
+}
+*/
+
+-- test: volatile function in INSERT ... SELECT
+CREATE TABLE test(id UUID PRIMARY KEY, a INT);
+INSERT INTO test (id, a) SELECT gen_uuid_v4(), a FROM src;
+SELECT COUNT(*) AS n FROM test;
+/* result:
+{
+  "n": 3
+}
+*/
+
+-- test: volatile function in a filter
+SELECT COUNT(*) AS n FROM src WHERE gen_uuid_v4() != gen_uuid_v4();
+/* result:
+{
+  "n": 3
+}
+*/
+
+-- test: volatile function in a filter is not precalculated
+EXPLAIN SELECT a FROM src WHERE gen_uuid_v4() != gen_uuid_v4();
+/* result:
+{
+  "plan": 'table.Scan("src") | rows.Filter(gen_uuid_v4() != gen_uuid_v4()) | rows.Project(a)'
+}
+*/
+
+-- test: immutable function in a filter is precalculated
+EXPLAIN SELECT a FROM src WHERE md5('a') = md5('a');
+/* result:
+{
+  "plan": 'table.Scan("src") | rows.Project(a)'
+}
+*/
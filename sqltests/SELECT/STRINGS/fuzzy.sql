@@ -0,0 +1,43 @@
+-- setup:
+CREATE TABLE customers(
+    id INT PRIMARY KEY,
+    name TEXT
+);
+
+INSERT INTO customers (id, name) VALUES
+    (1, 'Jon Smith'),
+    (2, 'John Smyth'),
+    (3, 'Jane Doe'),
+    (4, NULL);
+
+-- test: fuzzy lookup by edit distance
+SELECT id FROM customers WHERE levenshtein(lower(name), 'john smith') <= 2;
+/* result:
+{
+    "id": 1
+}
+{
+    "id": 2
+}
+*/
+
+-- test: fuzzy lookup by trigrams
+SELECT id FROM customers WHERE similarity(name, 'jon smith') > 0.5;
+/* result:
+{
+    "id": 1
+}
+*/
+
+-- test: phonetic lookup
+SELECT id, soundex(split_part(name, ' ', 2)) AS code FROM customers WHERE difference(split_part(name, ' ', 2), 'Smith') = 4;
+/* result:
+{
+    "id": 1,
+    "code": "S530"
+}
+{
+    "id": 2,
+    "code": "S530"
+}
+*/
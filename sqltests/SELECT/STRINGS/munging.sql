@@ -0,0 +1,72 @@
+-- setup:
+CREATE TABLE test(
+    id INT PRIMARY KEY,
+    line TEXT
+);
+
+INSERT INTO test (id, line) VALUES
+    (1, 'smith,JOHN,42 main st'),
+    (2, 'doe,jane,7  elm st'),
+    (3, NULL);
+
+-- test: split and reformat
+SELECT
+    id,
+    initcap(split_part(line, ',', 2)) AS first,
+    upper(split_part(line, ',', 1)) AS last,
+    regexp_replace(split_part(line, ',', -1), ' +', ' ', 'g') AS street
+FROM test ORDER BY id;
+/* result:
+{
+    "id": 1,
+    "first": "John",
+    "last": "SMITH",
+    "street": "42 main st"
+}
+{
+    "id": 2,
+    "first": "Jane",
+    "last": "DOE",
+    "street": "7 elm st"
+}
+{
+    "id": 3,
+    "first": NULL,
+    "last": NULL,
+    "street": NULL
+}
+*/
+
+-- test: padding and positions
+SELECT lpad(CAST(id AS TEXT), 3, '0') AS code, POSITION(',' IN line) AS comma, reverse(split_part(line, ',', 1)) AS r
+FROM test WHERE line IS NOT NULL ORDER BY id;
+/* result:
+{
+    "code": "001",
+    "comma": 6,
+    "r": "htims"
+}
+{
+    "code": "002",
+    "comma": 4,
+    "r": "eod"
+}
+*/
+
+-- test: filter with a regular expression
+SELECT id, regexp_match(line, '(\\d+) +(\\w+)') AS m FROM test WHERE regexp_match(line, '^doe') IS NOT NULL;
+/* result:
+{
+    "id": 2,
+    "m": '["7", "elm"]'
+}
+*/
+
+-- test: translate and repeat
+SELECT translate(split_part(line, ',', 1), 'mt', 'MT') AS s, repeat('!', id) AS r FROM test WHERE id = 2;
+/* result:
+{
+    "s": "doe",
+    "r": "!!"
+}
+*/
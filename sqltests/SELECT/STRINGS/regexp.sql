@@ -0,0 +1,81 @@
+-- setup:
+CREATE TABLE logs(
+    id INT PRIMARY KEY,
+    line TEXT
+);
+
+INSERT INTO logs (id, line) VALUES
+    (1, '2024-01-02 ERROR [db] connection lost'),
+    (2, '2024-01-02 INFO [http] GET /index'),
+    (3, 'garbage');
+
+-- test: capture groups
+SELECT id, regexp_match(line, '^([0-9-]+) ([A-Z]+) .([a-z]+).') AS m FROM logs;
+/* result:
+{
+    "id": 1,
+    "m": '["2024-01-02", "ERROR", "db"]'
+}
+{
+    "id": 2,
+    "m": '["2024-01-02", "INFO", "http"]'
+}
+{
+    "id": 3,
+    "m": null
+}
+*/
+
+-- test: single group
+SELECT id, regexp_match(line, '[[]([a-z]+)')[0] AS component FROM logs WHERE id < 3;
+/* result:
+{
+    "id": 1,
+    "component": "db"
+}
+{
+    "id": 2,
+    "component": "http"
+}
+*/
+
+-- test: regexp_split_to_table
+SELECT * FROM regexp_split_to_table('a, b,c', ', *');
+/* result:
+{
+    "regexp_split_to_table": "a"
+}
+{
+    "regexp_split_to_table": "b"
+}
+{
+    "regexp_split_to_table": "c"
+}
+*/
+
+-- test: regexp_split_to_table with a filter
+SELECT regexp_split_to_table AS word FROM regexp_split_to_table('The quick brown fox', ' ', 'i') WHERE len(regexp_split_to_table) > 3;
+/* result:
+{
+    "word": "quick"
+}
+{
+    "word": "brown"
+}
+*/
+
+-- test: regexp_split_to_table NULL
+SELECT count(*) FROM regexp_split_to_table(NULL, ',');
+/* result:
+{
+    "COUNT(*)": 0
+}
+*/
+
+-- test: regexp_split_to_table in the projection
+SELECT regexp_split_to_table('a,b', ',');
+-- error:
+
+-- test: regexp_split_to_table with too few arguments
+SELECT * FROM regexp_split_to_table('a,b');
+-- error:
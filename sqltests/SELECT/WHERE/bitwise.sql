@@ -0,0 +1,62 @@
+-- setup:
+CREATE TABLE users (
+    id INT PRIMARY KEY,
+    flags INT NOT NULL
+);
+
+INSERT INTO users (id, flags) VALUES
+    (1, 1),
+    (2, 3),
+    (3, 6),
+    (4, 0);
+
+-- test: flag set
+SELECT id FROM users WHERE flags & 2 = 2;
+/* result:
+{
+    id: 2
+}
+{
+    id: 3
+}
+*/
+
+-- test: flag shifted
+SELECT id FROM users WHERE flags & (1 << 2) != 0;
+/* result:
+{
+    id: 3
+}
+*/
+
+-- test: get_bit and bit_count
+SELECT id, get_bit(flags, 1) AS admin, bit_count(flags) AS n FROM users WHERE id < 3;
+/* result:
+{
+    id: 1,
+    admin: 0,
+    n: 1
+}
+{
+    id: 2,
+    admin: 1,
+    n: 2
+}
+*/
+
+-- test: set and clear flags
+UPDATE users SET flags = flags | 8 WHERE id = 4;
+UPDATE users SET flags = flags # 1 WHERE id = 2;
+SELECT id, flags, flags >> 1 AS shifted FROM users WHERE id IN (2, 4);
+/* result:
+{
+    id: 2,
+    flags: 2,
+    shifted: 1
+}
+{
+    id: 4,
+    flags: 8,
+    shifted: 4
+}
+*/
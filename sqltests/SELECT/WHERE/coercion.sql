@@ -0,0 +1,112 @@
+-- This file tests the comparisons of values of different types
+-- in lenient mode, the default coercion mode.
+
+-- setup:
+CREATE TABLE test(
+    id int primary key,
+    a int,
+    b boolean,
+    c text,
+    d timestamp
+);
+
+INSERT INTO test VALUES
+    (1, 1, true, '1', '2023-01-01'),
+    (2, 2, false, 'foo', '2024-01-01');
+
+-- suite: no index
+
+-- suite: index on a
+CREATE INDEX ON test(a);
+
+-- suite: index on c
+CREATE INDEX ON test(c);
+
+-- test: string literals take the type of the column
+SELECT id FROM test WHERE a = '2';
+/* result:
+{
+    id: 2
+}
+*/
+
+-- test: string literals take the type of the column, range
+SELECT id FROM test WHERE a > '1';
+/* result:
+{
+    id: 2
+}
+*/
+
+-- test: string literals take the type of the column, timestamp
+SELECT id FROM test WHERE d < '2023-06-01';
+/* result:
+{
+    id: 1
+}
+*/
+
+-- test: invalid string literal
+SELECT id FROM test WHERE a = 'foo';
+-- error: invalid input syntax for type integer: "foo"
+
+-- test: literals take the type of the column
+SELECT id FROM test WHERE b = 1;
+/* result:
+{
+    id: 1
+}
+*/
+
+-- test: literals take the type of the text column
+SELECT id FROM test WHERE c = 1;
+/* result:
+{
+    id: 1
+}
+*/
+
+-- test: IN
+SELECT id FROM test WHERE a IN ('2', 3);
+/* result:
+{
+    id: 2
+}
+*/
+
+-- test: BETWEEN
+SELECT id FROM test WHERE a BETWEEN '0' AND '1';
+/* result:
+{
+    id: 1
+}
+*/
+
+-- test: texts are converted to the type of the other value
+SELECT id FROM test WHERE a = c;
+/* result:
+{
+    id: 1
+}
+*/
+
+-- test: texts that can't be converted are not equal
+SELECT id FROM test WHERE a != c;
+/* result:
+{
+    id: 2
+}
+*/
+
+-- test: texts compared with expressions
+SELECT id FROM test WHERE a + 1 = '3';
+/* result:
+{
+    id: 2
+}
+*/
+
+-- test: types that can't be compared are not equal
+SELECT id FROM test WHERE a = b;
+/* result:
+*/
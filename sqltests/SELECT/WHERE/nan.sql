@@ -0,0 +1,106 @@
+-- This file tests the order of NaN and infinities, which is the same
+-- with and without index: NaN is equal to itself and lower than -Inf.
+
+-- setup:
+CREATE TABLE test(id int primary key, a double);
+
+INSERT INTO test VALUES
+    (1, CAST('NaN' AS DOUBLE)),
+    (2, CAST('+Inf' AS DOUBLE)),
+    (3, CAST('-Inf' AS DOUBLE)),
+    (4, 0.5),
+    (5, -0.5),
+    (6, CAST('NaN' AS DOUBLE));
+
+-- suite: no index
+
+-- suite: index
+CREATE INDEX on test(a);
+
+-- suite: unique index
+CREATE UNIQUE INDEX on test(id, a);
+
+-- test: order
+SELECT id, CAST(a AS TEXT) AS t FROM test ORDER BY a;
+/* result:
+{
+  "id": 1,
+  "t": "NaN"
+}
+{
+  "id": 6,
+  "t": "NaN"
+}
+{
+  "id": 3,
+  "t": "-Inf"
+}
+{
+  "id": 5,
+  "t": "-0.5"
+}
+{
+  "id": 4,
+  "t": "0.5"
+}
+{
+  "id": 2,
+  "t": "+Inf"
+}
+*/
+
+-- test: NaN is equal to itself
+SELECT id FROM test WHERE a = CAST('NaN' AS DOUBLE) ORDER BY id;
+/* result:
+{
+  "id": 1
+}
+{
+  "id": 6
+}
+*/
+
+-- test: range
+SELECT id FROM test WHERE a < 0 ORDER BY id;
+/* result:
+{
+  "id": 1
+}
+{
+  "id": 3
+}
+{
+  "id": 5
+}
+{
+  "id": 6
+}
+*/
+
+-- test: infinity
+SELECT id FROM test WHERE a > 1 ORDER BY id;
+/* result:
+{
+  "id": 2
+}
+*/
+
+-- test: group by
+SELECT COUNT(*) AS n FROM test GROUP BY a;
+/* result:
+{
+  "n": 2
+}
+{
+  "n": 1
+}
+{
+  "n": 1
+}
+{
+  "n": 1
+}
+{
+  "n": 1
+}
+*/
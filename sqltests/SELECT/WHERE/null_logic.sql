@@ -0,0 +1,102 @@
+-- This file tests that NULL follows three-valued logic in filters:
+-- rows are only kept when the condition is true, and conditions
+-- involving NULL are NULL unless the other operands decide the result.
+
+-- setup:
+CREATE TABLE test(
+    id int primary key,
+    a int,
+    b boolean
+);
+
+INSERT INTO test VALUES
+    (1, 1, true),
+    (2, NULL, NULL),
+    (3, 3, false);
+
+-- suite: no index
+
+-- suite: index on a
+CREATE INDEX ON test(a);
+
+-- suite: index on b
+CREATE INDEX ON test(b);
+
+-- test: NOT with NULL
+SELECT id FROM test WHERE NOT (a = 1);
+/* result:
+{
+    id: 3
+}
+*/
+
+-- test: NOT on a boolean column
+SELECT id FROM test WHERE NOT b;
+/* result:
+{
+    id: 3
+}
+*/
+
+-- test: AND with NULL
+SELECT id FROM test WHERE NOT (a > 0 AND b);
+/* result:
+{
+    id: 3
+}
+*/
+
+-- test: OR with NULL
+SELECT id FROM test WHERE NOT (a = 3 OR b);
+/* result:
+*/
+
+-- test: IN with NULL
+SELECT id FROM test WHERE a IN (1, NULL);
+/* result:
+{
+    id: 1
+}
+*/
+
+-- test: NOT IN with NULL
+SELECT id FROM test WHERE a NOT IN (1, NULL);
+/* result:
+*/
+
+-- test: comparison with NULL
+SELECT id FROM test WHERE a != NULL;
+/* result:
+*/
+
+-- test: IS NULL
+SELECT id FROM test WHERE b IS NULL OR b;
+/* result:
+{
+    id: 1
+}
+{
+    id: 2
+}
+*/
+
+-- test: projection
+SELECT b AND NULL AS x, b OR NULL AS y, NOT b AS z FROM test WHERE id = 3;
+/* result:
+{
+    x: false,
+    y: null,
+    z: true
+}
+*/
+
+-- test: check constraints pass when the condition is NULL
+CREATE TABLE checked(a int, b int, CHECK (a > 0 AND b > 0));
+INSERT INTO checked VALUES (1, NULL);
+SELECT * FROM checked;
+/* result:
+{
+    a: 1,
+    b: null
+}
+*/
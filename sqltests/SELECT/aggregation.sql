@@ -18,3 +18,192 @@ SELECT a % 2 FROM test GROUP BY a % 2
 {"a % 2": 0}
 {"a % 2": 1}
 */
+
+-- test: BOOL_AND, BOOL_OR and EVERY
+SELECT bool_and(a > 0), bool_and(a > 1), bool_or(a > 4), bool_or(a > 5), every(a < 6) FROM test
+/* result:
+{"BOOL_AND(a > 0)": true, "BOOL_AND(a > 1)": false, "BOOL_OR(a > 4)": true, "BOOL_OR(a > 5)": false, "EVERY(a < 6)": true}
+*/
+
+-- test: BOOL_AND and BOOL_OR ignore NULL
+SELECT bool_and(a > 2 OR NULL), bool_or(a > 5 OR NULL) FROM test
+/* result:
+{"BOOL_AND(a > 2 OR NULL)": true, "BOOL_OR(a > 5 OR NULL)": null}
+*/
+
+-- test: BOOL_AND with GROUP BY
+SELECT a % 2, bool_and(a < 5) FROM test GROUP BY a % 2
+/* result:
+{"a % 2": 0, "BOOL_AND(a < 5)": true}
+{"a % 2": 1, "BOOL_AND(a < 5)": false}
+*/
+
+-- test: BOOL_AND without rows
+SELECT bool_and(a > 0), bool_or(a > 0) FROM test WHERE a > 10
+/* result:
+{"BOOL_AND(a > 0)": null, "BOOL_OR(a > 0)": null}
+*/
+
+-- test: VAR_POP, VAR_SAMP, STDDEV_POP and STDDEV_SAMP
+SELECT var_pop(a), var_samp(a), stddev_pop(a), stddev_samp(a) FROM test
+/* result:
+{"VAR_POP(a)": 2.0, "VAR_SAMP(a)": 2.5, "STDDEV_POP(a)": 1.4142135623730951, "STDDEV_SAMP(a)": 1.5811388300841898}
+*/
+
+-- test: VAR_POP and VAR_SAMP ignore NULL
+INSERT INTO test (a) VALUES (NULL), (NULL);
+SELECT var_pop(a), var_samp(a), covar_pop(a, 1) FROM test
+/* result:
+{"VAR_POP(a)": 2.0, "VAR_SAMP(a)": 2.5, "COVAR_POP(a, 1)": 0.0}
+*/
+
+-- test: COVAR_POP, COVAR_SAMP and CORR
+SELECT covar_pop(a * 2, a), covar_samp(a * 2, a), corr(a * 2, a), corr(a * -1, a) FROM test
+/* result:
+{"COVAR_POP(a * 2, a)": 4.0, "COVAR_SAMP(a * 2, a)": 5.0, "CORR(a * 2, a)": 1.0, "CORR(a * -1, a)": -1.0}
+*/
+
+-- test: CORR of a constant
+SELECT corr(1, a) FROM test
+/* result:
+{"CORR(1, a)": null}
+*/
+
+-- test: STDDEV_POP with GROUP BY
+SELECT a % 2, stddev_pop(a), covar_pop(a, a) FROM test GROUP BY a % 2
+/* result:
+{"a % 2": 0, "STDDEV_POP(a)": 1.0, "COVAR_POP(a, a)": 1.0}
+{"a % 2": 1, "STDDEV_POP(a)": 1.632993161855452, "COVAR_POP(a, a)": 2.6666666666666665}
+*/
+
+-- test: statistical aggregates without rows
+SELECT var_pop(a), stddev_samp(a), covar_pop(a, a), corr(a, a) FROM test WHERE a > 10
+/* result:
+{"VAR_POP(a)": null, "STDDEV_SAMP(a)": null, "COVAR_POP(a, a)": null, "CORR(a, a)": null}
+*/
+
+-- test: MEDIAN, PERCENTILE_CONT and APPROX_PERCENTILE
+SELECT median(a), percentile_cont(a, 0), percentile_cont(a, 0.9), approx_percentile(a, 0.5), approx_percentile(a, 1) FROM test
+/* result:
+{"MEDIAN(a)": 3.0, "PERCENTILE_CONT(a, 0)": 1.0, "PERCENTILE_CONT(a, 0.9)": 4.6, "APPROX_PERCENTILE(a, 0.5)": 3.0, "APPROX_PERCENTILE(a, 1)": 5.0}
+*/
+
+-- test: MEDIAN with GROUP BY
+INSERT INTO test (a) VALUES (NULL);
+SELECT a % 2, median(a), percentile_cont(a, 0.25) FROM test GROUP BY a % 2
+/* result:
+{"a % 2": null, "MEDIAN(a)": null, "PERCENTILE_CONT(a, 0.25)": null}
+{"a % 2": 0, "MEDIAN(a)": 3.0, "PERCENTILE_CONT(a, 0.25)": 2.5}
+{"a % 2": 1, "MEDIAN(a)": 3.0, "PERCENTILE_CONT(a, 0.25)": 2.0}
+*/
+
+-- test: percentiles without rows
+SELECT median(a), approx_percentile(a, 0.5) FROM test WHERE a > 10
+/* result:
+{"MEDIAN(a)": null, "APPROX_PERCENTILE(a, 0.5)": null}
+*/
+
+-- test: PERCENTILE_CONT with an invalid percentile
+SELECT percentile_cont(a, 2) FROM test
+-- error: PERCENTILE_CONT(): percentile 2 is not between 0 and 1
+
+-- test: APPROX_COUNT_DISTINCT
+INSERT INTO test (a) VALUES (1), (2), (NULL);
+SELECT approx_count_distinct(a), approx_count_distinct(a % 2), approx_count_distinct(a > 10) FROM test
+/* result:
+{"APPROX_COUNT_DISTINCT(a)": 5, "APPROX_COUNT_DISTINCT(a % 2)": 2, "APPROX_COUNT_DISTINCT(a > 10)": 1}
+*/
+
+-- test: APPROX_COUNT_DISTINCT with GROUP BY
+SELECT a % 2, approx_count_distinct(a) FROM test GROUP BY a % 2
+/* result:
+{"a % 2": 0, "APPROX_COUNT_DISTINCT(a)": 2}
+{"a % 2": 1, "APPROX_COUNT_DISTINCT(a)": 3}
+*/
+
+-- test: APPROX_COUNT_DISTINCT without rows
+SELECT approx_count_distinct(a) FROM test WHERE a > 10
+/* result:
+{"APPROX_COUNT_DISTINCT(a)": 0}
+*/
+
+-- test: APPROX_COUNT_DISTINCT on many values
+SELECT approx_count_distinct(generate_series % 5000) > 4900 AND approx_count_distinct(generate_series % 5000) < 5100 AS ok FROM generate_series(1, 20000)
+/* result:
+{"ok": true}
+*/
+
+-- test: HISTOGRAM
+INSERT INTO test (a) VALUES (NULL), (-3), (12);
+SELECT histogram(a, 0, 6, 3) AS h FROM test
+/* result:
+{"h": '[1, 1, 2, 2, 1]'}
+*/
+
+-- test: HISTOGRAM with GROUP BY
+SELECT a % 2, histogram(a, 0, 6, 3) AS h FROM test GROUP BY a % 2
+/* result:
+{"a % 2": 0, "h": '[0, 0, 1, 1, 0]'}
+{"a % 2": 1, "h": '[0, 1, 1, 1, 0]'}
+*/
+
+-- test: HISTOGRAM without rows
+SELECT histogram(a, 0, 6, 3) FROM test WHERE a > 100
+/* result:
+{"HISTOGRAM(a, 0, 6, 3)": null}
+*/
+
+-- test: HISTOGRAM with an invalid number of buckets
+SELECT histogram(a, 0, 6, 0) FROM test
+-- error: HISTOGRAM(value, low, high, count): count must be greater than zero
+
+-- test: COUNT_IF and SUM_IF
+INSERT INTO test (a) VALUES (NULL);
+SELECT count_if(a > 2), sum_if(a % 2 = 1, a), sum_if(a > 2, a * 0.5), count(*) FROM test
+/* result:
+{"COUNT_IF(a > 2)": 3, "SUM_IF(a % 2 = 1, a)": 9, "SUM_IF(a > 2, a * 0.5)": 6.0, "COUNT(*)": 6}
+*/
+
+-- test: COUNT_IF and SUM_IF with GROUP BY
+SELECT a % 2, count_if(a >= 2), sum_if(a >= 2, a) FROM test GROUP BY a % 2
+/* result:
+{"a % 2": 0, "COUNT_IF(a >= 2)": 2, "SUM_IF(a >= 2, a)": 6}
+{"a % 2": 1, "COUNT_IF(a >= 2)": 2, "SUM_IF(a >= 2, a)": 8}
+*/
+
+-- test: COUNT_IF and SUM_IF without matching rows
+SELECT count_if(a > 10), sum_if(a > 10, a) FROM test
+/* result:
+{"COUNT_IF(a > 10)": 0, "SUM_IF(a > 10, a)": null}
+*/
+
+-- test: COUNT_IF in an expression
+SELECT count_if(a > 1) * 100 / count(*) AS pct FROM test
+/* result:
+{"pct": 80}
+*/
+
+-- test: STRING_AGG
+SELECT string_agg(a, ', ') FROM test
+/* result:
+{"STRING_AGG(a, \", \")": "1, 2, 3, 4, 5"}
+*/
+
+-- test: STRING_AGG ORDER BY
+INSERT INTO test (a) VALUES (NULL);
+SELECT a % 2, string_agg(a, '-' ORDER BY a DESC), group_concat(a) FROM test GROUP BY a % 2
+/* result:
+{"a % 2": null, "STRING_AGG(a, \"-\" ORDER BY a DESC)": null, "GROUP_CONCAT(a)": null}
+{"a % 2": 0, "STRING_AGG(a, \"-\" ORDER BY a DESC)": "4-2", "GROUP_CONCAT(a)": "2,4"}
+{"a % 2": 1, "STRING_AGG(a, \"-\" ORDER BY a DESC)": "5-3-1", "GROUP_CONCAT(a)": "1,3,5"}
+*/
+
+-- test: STRING_AGG without rows
+SELECT string_agg(a, ',') FROM test WHERE a > 10
+/* result:
+{"STRING_AGG(a, \",\")": null}
+*/
+
+-- test: ORDER BY in a function that doesn't accept it
+SELECT max(a ORDER BY a) FROM test
+-- error: max() doesn't accept ORDER BY
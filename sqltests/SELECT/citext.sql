@@ -0,0 +1,80 @@
+-- setup:
+CREATE TABLE test(email CITEXT PRIMARY KEY, name CITEXT, age INT);
+INSERT INTO test (email, name, age) VALUES
+    ('Alice@Example.com', 'Alice', 30),
+    ('bob@example.com', 'BOB', 25),
+    ('carol@EXAMPLE.com', 'carol', 35);
+
+-- suite: no index
+
+-- suite: with index
+CREATE INDEX ON test(name);
+
+-- test: pk lookup
+SELECT email, age FROM test WHERE email = 'alice@example.com';
+/* result:
+{
+    email: "Alice@Example.com",
+    age: 30
+}
+*/
+
+-- test: index lookup
+SELECT email FROM test WHERE name = 'bob';
+/* result:
+{
+    email: "bob@example.com"
+}
+*/
+
+-- test: range
+SELECT name FROM test WHERE name >= 'B' ORDER BY name;
+/* result:
+{
+    name: "BOB"
+}
+{
+    name: "carol"
+}
+*/
+
+-- test: order by
+SELECT name FROM test ORDER BY name DESC;
+/* result:
+{
+    name: "carol"
+}
+{
+    name: "BOB"
+}
+{
+    name: "Alice"
+}
+*/
+
+-- test: like
+SELECT email FROM test WHERE name LIKE 'c%';
+/* result:
+{
+    email: "carol@EXAMPLE.com"
+}
+*/
+
+-- test: duplicate primary key
+INSERT INTO test (email, name) VALUES ('ALICE@example.COM', 'Alice2');
+-- error:
+
+-- test: unique constraint
+CREATE TABLE users(id INT PRIMARY KEY, username CITEXT UNIQUE);
+INSERT INTO users (id, username) VALUES (1, 'JohnDoe');
+INSERT INTO users (id, username) VALUES (2, 'johndoe');
+-- error:
+
+-- test: update keeps the case
+UPDATE test SET name = 'ALICE' WHERE email = 'ALICE@EXAMPLE.COM';
+SELECT name FROM test WHERE name = 'alice';
+/* result:
+{
+    name: "ALICE"
+}
+*/
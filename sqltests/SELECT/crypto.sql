@@ -0,0 +1,34 @@
+-- setup:
+CREATE TABLE users(
+    id INT PRIMARY KEY,
+    ssn BLOB
+);
+
+INSERT INTO users (id, ssn) VALUES
+    (1, encrypt('123-45-6789', '0123456789abcdef0123456789abcdef')),
+    (2, encrypt('987-65-4321', '0123456789abcdef0123456789abcdef'));
+
+-- test: decrypt column
+SELECT id, convert_from(decrypt(ssn, '0123456789abcdef0123456789abcdef'), 'UTF8') AS ssn FROM users;
+/* result:
+{
+    "id": 1,
+    "ssn": "123-45-6789"
+}
+{
+    "id": 2,
+    "ssn": "987-65-4321"
+}
+*/
+
+-- test: wrong key
+SELECT decrypt(ssn, 'fedcba9876543210fedcba9876543210') FROM users;
+-- error:
+
+-- test: hmac
+SELECT encode(hmac('The quick brown fox jumps over the lazy dog', 'key', 'sha1'), 'hex') AS mac;
+/* result:
+{
+    "mac": "de7c9b85b8b78aa6bc8a7a36f70a90701c9db4d9"
+}
+*/
@@ -0,0 +1,175 @@
+-- setup:
+CREATE TABLE sales (
+    day TIMESTAMP PRIMARY KEY,
+    amount INT
+);
+
+INSERT INTO sales (day, amount) VALUES
+    ('2024-01-01', 10),
+    ('2024-01-03', 20);
+
+-- test: integers
+SELECT * FROM generate_series(1, 3);
+/* result:
+{
+    generate_series: 1
+}
+{
+    generate_series: 2
+}
+{
+    generate_series: 3
+}
+*/
+
+-- test: integers with a step
+SELECT generate_series AS n FROM generate_series(10, 1, -4);
+/* result:
+{
+    n: 10
+}
+{
+    n: 6
+}
+{
+    n: 2
+}
+*/
+
+-- test: empty series
+SELECT count(*) FROM generate_series(5, 1);
+/* result:
+{
+    "COUNT(*)": 0
+}
+*/
+
+-- test: aggregation and filter
+SELECT sum(generate_series) AS s FROM generate_series(1, 100) WHERE generate_series % 2 = 0;
+/* result:
+{
+    s: 2550
+}
+*/
+
+-- test: doubles
+SELECT * FROM generate_series(0, 1, 0.5);
+/* result:
+{
+    generate_series: 0.0
+}
+{
+    generate_series: 0.5
+}
+{
+    generate_series: 1.0
+}
+*/
+
+-- test: timestamps
+SELECT * FROM generate_series(CAST('2024-01-01' AS TIMESTAMP), CAST('2024-01-03' AS TIMESTAMP), '1 day');
+/* result:
+{
+    generate_series: "2024-01-01T00:00:00Z"
+}
+{
+    generate_series: "2024-01-02T00:00:00Z"
+}
+{
+    generate_series: "2024-01-03T00:00:00Z"
+}
+*/
+
+-- test: timestamps with a text start
+SELECT * FROM generate_series('2024-01-01 10:00', '2024-01-01 11:00', '30 minutes');
+/* result:
+{
+    generate_series: "2024-01-01T10:00:00Z"
+}
+{
+    generate_series: "2024-01-01T10:30:00Z"
+}
+{
+    generate_series: "2024-01-01T11:00:00Z"
+}
+*/
+
+-- test: months keep the last day
+SELECT * FROM generate_series('2024-01-31', '2024-04-30', '1 month');
+/* result:
+{
+    generate_series: "2024-01-31T00:00:00Z"
+}
+{
+    generate_series: "2024-02-29T00:00:00Z"
+}
+{
+    generate_series: "2024-03-31T00:00:00Z"
+}
+{
+    generate_series: "2024-04-30T00:00:00Z"
+}
+*/
+
+-- test: backwards with a composite interval
+SELECT * FROM generate_series('2024-01-02', '2024-01-01', '-1 day 12:00:00');
+/* result:
+{
+    generate_series: "2024-01-02T00:00:00Z"
+}
+{
+    generate_series: "2024-01-01T12:00:00Z"
+}
+{
+    generate_series: "2024-01-01T00:00:00Z"
+}
+*/
+
+-- test: interval step
+SELECT * FROM generate_series(TIMESTAMP '2024-01-01', TIMESTAMP '2024-01-01 01:00:00', INTERVAL '20 minutes');
+/* result:
+{
+    generate_series: "2024-01-01T00:00:00Z"
+}
+{
+    generate_series: "2024-01-01T00:20:00Z"
+}
+{
+    generate_series: "2024-01-01T00:40:00Z"
+}
+{
+    generate_series: "2024-01-01T01:00:00Z"
+}
+*/
+
+-- test: NULL
+SELECT count(*) FROM generate_series(1, NULL);
+/* result:
+{
+    "COUNT(*)": 0
+}
+*/
+
+-- test: zero step
+SELECT * FROM generate_series(1, 3, 0);
+-- error:
+
+-- test: missing interval
+SELECT * FROM generate_series('2024-01-01', '2024-01-03');
+-- error:
+
+-- test: invalid interval
+SELECT * FROM generate_series('2024-01-01', '2024-01-03', '1 fortnight');
+-- error:
+
+-- test: too few arguments
+SELECT * FROM generate_series(1);
+-- error:
+
+-- test: non interval step
+SELECT * FROM generate_series('2024-01-01', '2024-01-03', 1);
+-- error:
+
+-- test: in the projection
+SELECT generate_series(1, 3);
+-- error:
@@ -0,0 +1,113 @@
+-- setup:
+CREATE TABLE tasks (
+    id INT PRIMARY KEY,
+    started_at TIMESTAMP NOT NULL,
+    ended_at TIMESTAMP,
+    timeout INTERVAL
+);
+INSERT INTO tasks (id, started_at, ended_at, timeout) VALUES
+    (1, '2024-01-01 10:00:00', '2024-01-01 10:30:00', '1 hour'),
+    (2, '2024-01-01 11:00:00', '2024-01-01 13:00:00', INTERVAL '90 minutes'),
+    (3, '2024-01-31 09:00:00', '2024-02-02 09:00:00', '1 day'),
+    (4, '2024-02-01 08:00:00', NULL, NULL);
+
+-- suite: no index
+
+-- suite: with index
+CREATE INDEX ON tasks(timeout);
+
+-- test: duration
+SELECT id, ended_at - started_at AS duration FROM tasks ORDER BY id;
+/* result:
+{
+    id: 1,
+    duration: "00:30:00"
+}
+{
+    id: 2,
+    duration: "02:00:00"
+}
+{
+    id: 3,
+    duration: "2 days"
+}
+{
+    id: 4,
+    duration: NULL
+}
+*/
+
+-- test: deadline
+SELECT id, started_at + timeout AS deadline FROM tasks ORDER BY id;
+/* result:
+{
+    id: 1,
+    deadline: "2024-01-01T11:00:00Z"
+}
+{
+    id: 2,
+    deadline: "2024-01-01T12:30:00Z"
+}
+{
+    id: 3,
+    deadline: "2024-02-01T09:00:00Z"
+}
+{
+    id: 4,
+    deadline: NULL
+}
+*/
+
+-- test: timed out
+SELECT id FROM tasks WHERE ended_at - started_at > timeout ORDER BY id;
+/* result:
+{
+    id: 2
+}
+{
+    id: 3
+}
+*/
+
+-- test: filter on an interval column
+SELECT id FROM tasks WHERE timeout >= INTERVAL '90 minutes' ORDER BY id;
+/* result:
+{
+    id: 2
+}
+{
+    id: 3
+}
+*/
+
+-- test: order by interval
+SELECT id, timeout FROM tasks WHERE timeout IS NOT NULL ORDER BY timeout DESC;
+/* result:
+{
+    id: 3,
+    timeout: "1 day"
+}
+{
+    id: 2,
+    timeout: "01:30:00"
+}
+{
+    id: 1,
+    timeout: "01:00:00"
+}
+*/
+
+-- test: filter with timestamp arithmetic
+SELECT id FROM tasks WHERE started_at >= TIMESTAMP '2024-02-02' - INTERVAL '2 days' ORDER BY id;
+/* result:
+{
+    id: 3
+}
+{
+    id: 4
+}
+*/
+
+-- test: invalid interval
+INSERT INTO tasks (id, started_at, timeout) VALUES (5, '2024-01-01', 'soon');
+-- error:
@@ -0,0 +1,126 @@
+-- setup:
+CREATE TABLE test (
+    id INT PRIMARY KEY,
+    d JSONB
+);
+
+INSERT INTO test (id, d) VALUES
+    (1, '{"name": "a", "tags": ["x", "y"]}'),
+    (2, '{"name": "b", "tags": []}');
+
+-- test: json_each on an object
+SELECT * FROM json_each('{"b": [1, 2], "a": 1.5, "c": null}');
+/* result:
+{
+    "key": "a",
+    "value": 1.5,
+    "type": "number"
+}
+{
+    "key": "b",
+    "value": '[1, 2]',
+    "type": "array"
+}
+{
+    "key": "c",
+    "value": null,
+    "type": "null"
+}
+*/
+
+-- test: json_each on an array
+SELECT `key`, `value` FROM json_each('["x", true]');
+/* result:
+{
+    "key": 0,
+    "value": "x"
+}
+{
+    "key": 1,
+    "value": true
+}
+*/
+
+-- test: json_each with a path
+SELECT `value` FROM json_each('{"a": {"b": [1, 2]}}', '$.a.b') WHERE `value` > 1;
+/* result:
+{
+    "value": 2.0
+}
+*/
+
+-- test: json_each with a missing path
+SELECT count(*) FROM json_each('{"a": 1}', '$.b');
+/* result:
+{
+    "COUNT(*)": 0
+}
+*/
+
+-- test: json_each on a scalar
+SELECT * FROM json_each('"x"');
+/* result:
+{
+    "key": null,
+    "value": "x",
+    "type": "string"
+}
+*/
+
+-- test: json_each with a filter
+SELECT type FROM json_each('[1, "a", {}]') WHERE `key` = 2;
+/* result:
+{
+    "type": "object"
+}
+*/
+
+-- test: json_each with an unknown column
+SELECT name FROM json_each('[1]');
+-- error:
+
+-- test: json_each in the projection
+SELECT json_each('[1]');
+-- error:
+
+-- test: json_each with too many arguments
+SELECT * FROM json_each('[1]', '$', 1);
+-- error:
+
+-- test: not a table function
+SELECT * FROM lower('a');
+-- error:
+
+-- test: json_array_length
+SELECT id, json_array_length(d, '$.tags') AS n FROM test;
+/* result:
+{
+    id: 1,
+    n: 2
+}
+{
+    id: 2,
+    n: 0
+}
+*/
+
+-- test: UPDATE with json_set
+UPDATE test SET d = json_set(d, '$.tags[0]', 'z') WHERE id = 2;
+SELECT CAST(d AS TEXT) AS d FROM test WHERE id = 2;
+/* result:
+{
+    d: '{"name": "b", "tags": ["z"]}'
+}
+*/
+
+-- test: UPDATE with json_remove
+UPDATE test SET d = json_remove(d, '$.tags');
+SELECT CAST(d AS TEXT) AS d FROM test;
+/* result:
+{
+    d: '{"name": "a"}'
+}
+{
+    d: '{"name": "b"}'
+}
+*/
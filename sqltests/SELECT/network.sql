@@ -0,0 +1,152 @@
+-- setup:
+CREATE TABLE test(id INT PRIMARY KEY, a INET, b CIDR);
+INSERT INTO test (id, a, b) VALUES
+    (1, '10.0.0.1', '10.0.0.0/8'),
+    (2, '10.1.2.3/16', '10.1.0.0/16'),
+    (3, '10.255.255.255', '10.255.255.255/32'),
+    (4, '11.0.0.1', '11.0.0.0/8'),
+    (5, '9.255.255.255', '0.0.0.0/0'),
+    (6, '10.0.0.0/4', '10.0.0.0/31'),
+    (7, '2001:db8::1', '2001:db8::/32'),
+    (8, '::ffff:10.0.0.1', '::/0');
+
+-- suite: no index
+
+-- suite: with index
+CREATE INDEX ON test(a);
+CREATE INDEX ON test(b);
+
+-- test: contained by
+SELECT id, a FROM test WHERE a << '10.0.0.0/8' ORDER BY id;
+/* result:
+{
+    id: 1,
+    a: "10.0.0.1"
+}
+{
+    id: 2,
+    a: "10.1.2.3/16"
+}
+{
+    id: 3,
+    a: "10.255.255.255"
+}
+*/
+
+-- test: contained by or equal
+SELECT id FROM test WHERE b <<= '10.0.0.0/8' ORDER BY id;
+/* result:
+{
+    id: 1
+}
+{
+    id: 2
+}
+{
+    id: 3
+}
+{
+    id: 6
+}
+*/
+
+-- test: contains
+SELECT id FROM test WHERE '10.0.0.0/8' >> b ORDER BY id;
+/* result:
+{
+    id: 2
+}
+{
+    id: 3
+}
+{
+    id: 6
+}
+*/
+
+-- test: contains or equal
+SELECT id FROM test WHERE '10.1.0.0/16' >>= b ORDER BY id;
+/* result:
+{
+    id: 2
+}
+*/
+
+-- test: networks containing an address
+SELECT id FROM test WHERE b >>= '10.1.2.3' ORDER BY id;
+/* result:
+{
+    id: 1
+}
+{
+    id: 2
+}
+{
+    id: 5
+}
+*/
+
+-- test: ipv6
+SELECT id FROM test WHERE a << '2001:db8::/32';
+/* result:
+{
+    id: 7
+}
+*/
+
+-- test: other family
+SELECT id FROM test WHERE a <<= '11.0.0.0/8' ORDER BY id;
+/* result:
+{
+    id: 4
+}
+*/
+
+-- test: order by
+SELECT id, b FROM test ORDER BY b;
+/* result:
+{
+    id: 5,
+    b: "0.0.0.0/0"
+}
+{
+    id: 1,
+    b: "10.0.0.0/8"
+}
+{
+    id: 6,
+    b: "10.0.0.0/31"
+}
+{
+    id: 2,
+    b: "10.1.0.0/16"
+}
+{
+    id: 3,
+    b: "10.255.255.255/32"
+}
+{
+    id: 4,
+    b: "11.0.0.0/8"
+}
+{
+    id: 8,
+    b: "::/0"
+}
+{
+    id: 7,
+    b: "2001:db8::/32"
+}
+*/
+
+-- test: equality
+SELECT id FROM test WHERE a = '10.1.2.3/16';
+/* result:
+{
+    id: 2
+}
+*/
+
+-- test: invalid cidr
+INSERT INTO test (id, b) VALUES (9, '10.0.0.1/8');
+-- error: cannot cast "10.0.0.1/8" as cidr: invalid cidr: the address has bits set to the right of the prefix
@@ -0,0 +1,77 @@
+-- setup:
+CREATE TABLE test (
+    id INT PRIMARY KEY,
+    doc OBJECT,
+    tags ARRAY
+);
+
+INSERT INTO test (id, doc, tags) VALUES
+    (1, {name: 'a', age: 10, address: {city: 'Lyon'}}, ['x', 'y']),
+    (2, '{"name": "b", "age": 20.5, "address": {"city": "Paris"}}', '["y"]'),
+    (3, {name: 'c'}, []);
+
+-- test: select
+SELECT doc, tags FROM test WHERE id = 1;
+/* result:
+{
+    doc: '{"address": {"city": "Lyon"}, "age": 10, "name": "a"}',
+    tags: '["x", "y"]'
+}
+*/
+
+-- test: paths
+SELECT id, doc.name, doc.address.city, tags[0] FROM test ORDER BY id;
+/* result:
+{
+    id: 1,
+    "doc.name": "a",
+    "doc.address.city": "Lyon",
+    "tags[0]": "x"
+}
+{
+    id: 2,
+    "doc.name": "b",
+    "doc.address.city": "Paris",
+    "tags[0]": "y"
+}
+{
+    id: 3,
+    "doc.name": "c",
+    "doc.address.city": null,
+    "tags[0]": null
+}
+*/
+
+-- test: where
+SELECT id FROM test WHERE doc.age > 15;
+/* result:
+{
+    id: 2
+}
+*/
+
+-- test: update
+UPDATE test SET doc = {name: doc.name, age: 11} WHERE id = 1;
+SELECT doc FROM test WHERE id = 1;
+/* result:
+{
+    doc: '{"age": 11, "name": "a"}'
+}
+*/
+
+-- test: object equality
+SELECT id FROM test WHERE doc = {name: 'c'};
+/* result:
+{
+    id: 3
+}
+*/
+
+-- test: not an object
+INSERT INTO test (id, doc) VALUES (4, '[1]');
+-- error:
+
+-- test: unique index
+CREATE UNIQUE INDEX ON test (doc);
+INSERT INTO test (id, doc) VALUES (4, {name: 'c'});
+-- error:
@@ -0,0 +1,101 @@
+-- setup:
+CREATE TABLE test(a INT PRIMARY KEY, b REAL);
+INSERT INTO test (a, b) VALUES
+    (1, 0.1),
+    (2, -2.5),
+    (3, 3),
+    (4, 1e30),
+    (5, -1e-10);
+
+-- suite: no index
+
+-- suite: with index
+CREATE INDEX ON test(b);
+
+-- suite: with desc index
+CREATE INDEX ON test(b DESC);
+
+-- test: asc
+SELECT a, b FROM test ORDER BY b;
+/* result:
+{
+    a: 2,
+    b: -2.5
+}
+{
+    a: 5,
+    b: -1e-10
+}
+{
+    a: 1,
+    b: 0.1
+}
+{
+    a: 3,
+    b: 3.0
+}
+{
+    a: 4,
+    b: 1e+30
+}
+*/
+
+-- test: desc
+SELECT a FROM test ORDER BY b DESC;
+/* result:
+{
+    a: 4
+}
+{
+    a: 3
+}
+{
+    a: 1
+}
+{
+    a: 5
+}
+{
+    a: 2
+}
+*/
+
+-- test: double literal
+SELECT a FROM test WHERE b = 0.1;
+/* result:
+{
+    a: 1
+}
+*/
+
+-- test: range
+SELECT a FROM test WHERE b > 0.1 AND b <= 3.0 ORDER BY b;
+/* result:
+{
+    a: 3
+}
+*/
+
+-- test: typeof
+SELECT typeof(b) AS t, typeof(b + b) AS t2, typeof(b + 1.0) AS t3 FROM test WHERE a = 1;
+/* result:
+{
+    t: "real",
+    t2: "real",
+    t3: "double"
+}
+*/
+
+-- test: aggregation
+SELECT SUM(b) AS s, AVG(b) AS avg, MIN(b) AS m FROM test WHERE a <= 3;
+/* result:
+{
+    s: 0.6000000014901161,
+    avg: 0.20000000049670538,
+    m: -2.5
+}
+*/
+
+-- test: out of range
+INSERT INTO test (a, b) VALUES (6, 1e300);
+-- error:
@@ -0,0 +1,72 @@
+-- setup:
+CREATE TABLE test(a timestamptz PRIMARY KEY, b timestamptz);
+INSERT INTO test (a, b) VALUES
+    ("2023-01-01T10:00:00+02:00", "2023-01-01T10:00:00+02:00"),
+    ("2023-01-01T09:00:00Z", "2023-01-01T09:00:00Z"),
+    ("2023-01-01T08:30:00Z", "2023-01-01T08:30:00Z"),
+    ("2023-01-01T02:00:00-05:00", "2023-01-01T02:00:00-05:00");
+
+-- suite: no index
+
+-- suite: with index
+CREATE INDEX ON test(b);
+
+-- test: asc
+SELECT a FROM test ORDER BY b;
+/* result:
+{
+    a: "2023-01-01T07:00:00Z",
+}
+{
+    a: "2023-01-01T08:00:00Z"
+}
+{
+    a: "2023-01-01T08:30:00Z"
+}
+{
+    a: "2023-01-01T09:00:00Z"
+}
+*/
+
+-- test: desc
+SELECT b FROM test ORDER BY b DESC;
+/* result:
+{
+    b: "2023-01-01T09:00:00Z",
+}
+{
+    b: "2023-01-01T08:30:00Z"
+}
+{
+    b: "2023-01-01T08:00:00Z"
+}
+{
+    b: "2023-01-01T07:00:00Z"
+}
+*/
+
+-- test: pk range
+SELECT a FROM test WHERE a > "2023-01-01T09:00:00+01:00" ORDER BY a;
+/* result:
+{
+    a: "2023-01-01T08:30:00Z",
+}
+{
+    a: "2023-01-01T09:00:00Z"
+}
+*/
+
+-- test: index range
+SELECT b FROM test WHERE b >= "2023-01-01T08:30:00Z" AND b < "2023-01-01 10:00:00" ORDER BY b;
+/* result:
+{
+    b: "2023-01-01T08:30:00Z",
+}
+{
+    b: "2023-01-01T09:00:00Z"
+}
+*/
+
+-- test: duplicate instant
+INSERT INTO test (a) VALUES ("2023-01-01T11:00:00+03:00");
+-- error:
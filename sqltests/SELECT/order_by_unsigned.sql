@@ -0,0 +1,124 @@
+-- setup:
+CREATE TABLE test(a BIGINT UNSIGNED PRIMARY KEY, b BIGINT UNSIGNED);
+INSERT INTO test (a, b) VALUES
+    (18446744073709551615, 18446744073709551615),
+    (9223372036854775808, 9223372036854775808),
+    (9223372036854775807, 9223372036854775807),
+    (10, 10),
+    (0, 0);
+
+-- suite: no index
+
+-- suite: with index
+CREATE INDEX ON test(b);
+
+-- suite: with desc index
+CREATE INDEX ON test(b DESC);
+
+-- test: asc
+SELECT a FROM test ORDER BY b;
+/* result:
+{
+    a: 0
+}
+{
+    a: 10
+}
+{
+    a: 9223372036854775807
+}
+{
+    a: 9223372036854775808
+}
+{
+    a: 18446744073709551615
+}
+*/
+
+-- test: desc
+SELECT b FROM test ORDER BY b DESC;
+/* result:
+{
+    b: 18446744073709551615
+}
+{
+    b: 9223372036854775808
+}
+{
+    b: 9223372036854775807
+}
+{
+    b: 10
+}
+{
+    b: 0
+}
+*/
+
+-- test: pk range
+SELECT a FROM test WHERE a > 9223372036854775807 ORDER BY a;
+/* result:
+{
+    a: 9223372036854775808
+}
+{
+    a: 18446744073709551615
+}
+*/
+
+-- test: index range
+SELECT a FROM test WHERE b >= 10 AND b < 18446744073709551615 ORDER BY b;
+/* result:
+{
+    a: 10
+}
+{
+    a: 9223372036854775807
+}
+{
+    a: 9223372036854775808
+}
+*/
+
+-- test: index lookup
+SELECT a FROM test WHERE b = 18446744073709551615;
+/* result:
+{
+    a: 18446744073709551615
+}
+*/
+
+-- test: typeof
+SELECT typeof(a) AS t FROM test WHERE a = 10;
+/* result:
+{
+    t: "bigint unsigned"
+}
+*/
+
+-- test: aggregation
+SELECT MIN(b), MAX(b), SUM(b) FROM test WHERE b <= 10;
+/* result:
+{
+    "MIN(b)": 0,
+    "MAX(b)": 10,
+    "SUM(b)": 10
+}
+*/
+
+-- test: max
+SELECT MAX(b), AVG(b) > 0 AS avg FROM test;
+/* result:
+{
+    "MAX(b)": 18446744073709551615,
+    "avg": true
+}
+*/
+
+-- test: negative
+INSERT INTO test (a) VALUES (-1);
+-- error:
+
+-- test: duplicate
+INSERT INTO test (a) VALUES (18446744073709551615);
+-- error:
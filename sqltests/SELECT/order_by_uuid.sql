@@ -0,0 +1,82 @@
+-- setup:
+CREATE TABLE test(a uuid PRIMARY KEY, b uuid);
+INSERT INTO test (a, b) VALUES
+    ("0190b6a4-0000-7000-8000-000000000002", "0190b6a4-0000-7000-8000-000000000002"),
+    ("0190B6A4-0000-7000-8000-000000000003", "0190B6A4-0000-7000-8000-000000000003"),
+    ("0190b6a400007000800000000000000a", "0190b6a400007000800000000000000a"),
+    ("{0190b6a3-ffff-7fff-bfff-ffffffffffff}", "{0190b6a3-ffff-7fff-bfff-ffffffffffff}");
+
+-- suite: no index
+
+-- suite: with index
+CREATE INDEX ON test(b);
+
+-- test: asc
+SELECT a FROM test ORDER BY b;
+/* result:
+{
+    a: "0190b6a3-ffff-7fff-bfff-ffffffffffff",
+}
+{
+    a: "0190b6a4-0000-7000-8000-000000000002"
+}
+{
+    a: "0190b6a4-0000-7000-8000-000000000003"
+}
+{
+    a: "0190b6a4-0000-7000-8000-00000000000a"
+}
+*/
+
+-- test: desc
+SELECT b FROM test ORDER BY b DESC;
+/* result:
+{
+    b: "0190b6a4-0000-7000-8000-00000000000a",
+}
+{
+    b: "0190b6a4-0000-7000-8000-000000000003"
+}
+{
+    b: "0190b6a4-0000-7000-8000-000000000002"
+}
+{
+    b: "0190b6a3-ffff-7fff-bfff-ffffffffffff"
+}
+*/
+
+-- test: pk range
+SELECT a FROM test WHERE a > "0190b6a4-0000-7000-8000-000000000002" ORDER BY a;
+/* result:
+{
+    a: "0190b6a4-0000-7000-8000-000000000003",
+}
+{
+    a: "0190b6a4-0000-7000-8000-00000000000a"
+}
+*/
+
+-- test: index lookup
+SELECT a FROM test WHERE b = "0190B6A4-0000-7000-8000-000000000003";
+/* result:
+{
+    a: "0190b6a4-0000-7000-8000-000000000003"
+}
+*/
+
+-- test: duplicate
+INSERT INTO test (a) VALUES ("0190b6a4000070008000000000000002");
+-- error:
+
+-- test: invalid
+INSERT INTO test (a) VALUES ("0190b6a4");
+-- error:
+
+-- test: generated
+INSERT INTO test (a) VALUES (gen_uuid_v7()), (gen_uuid_v4());
+SELECT COUNT(*) FROM test;
+/* result:
+{
+    "COUNT(*)": 6
+}
+*/
@@ -0,0 +1,131 @@
+-- setup:
+CREATE TABLE test(id INT PRIMARY KEY, name TEXT, p POINT);
+INSERT INTO test (id, name, p) VALUES
+    (1, 'Paris', '(48.8566, 2.3522)'),
+    (2, 'Versailles', '(48.8049, 2.1204)'),
+    (3, 'London', '(51.5074, -0.1278)'),
+    (4, 'Lyon', '(45.764, 4.8357)'),
+    (5, 'Orleans', '(47.9029, 1.9093)'),
+    (6, 'Sydney', '(-33.8688, 151.2093)'),
+    (7, 'Null Island', '(0, 0)'),
+    (8, 'Nowhere', NULL);
+
+-- suite: no index
+
+-- suite: with index
+CREATE INDEX ON test(p);
+
+-- test: within box
+SELECT id, name, p FROM test WHERE within_box(p, make_point(48, 1.5), make_point(49.5, 3)) ORDER BY id;
+/* result:
+{
+    id: 1,
+    name: "Paris",
+    p: "(48.8566, 2.3522)"
+}
+{
+    id: 2,
+    name: "Versailles",
+    p: "(48.8049, 2.1204)"
+}
+*/
+
+-- test: within box with text corners
+SELECT id FROM test WHERE within_box(p, '(45, -1)', '(52, 5)') ORDER BY id;
+/* result:
+{
+    id: 1
+}
+{
+    id: 2
+}
+{
+    id: 3
+}
+{
+    id: 4
+}
+{
+    id: 5
+}
+*/
+
+-- test: within box around the origin
+SELECT id FROM test WHERE within_box(p, make_point(-1, -1), make_point(1, 1));
+/* result:
+{
+    id: 7
+}
+*/
+
+-- test: within box crossing the antimeridian
+SELECT id FROM test WHERE within_box(p, make_point(-40, 150), make_point(-30, -170));
+/* result:
+{
+    id: 6
+}
+*/
+
+-- test: empty box
+SELECT id FROM test WHERE within_box(p, make_point(49, 1.5), make_point(48, 3));
+/* result:
+*/
+
+-- test: within box with another filter
+SELECT id FROM test WHERE within_box(p, make_point(45, -1), make_point(52, 5)) AND id > 3 ORDER BY id;
+/* result:
+{
+    id: 4
+}
+{
+    id: 5
+}
+*/
+
+-- test: equality
+SELECT id FROM test WHERE p = '(51.5074, -0.1278)';
+/* result:
+{
+    id: 3
+}
+*/
+
+-- test: distance
+SELECT name, CAST (distance(p, make_point(48.8566, 2.3522)) / 1000 AS INTEGER) AS km FROM test WHERE distance(p, make_point(48.8566, 2.3522)) < 300000 ORDER BY id;
+/* result:
+{
+    name: "Paris",
+    km: 0
+}
+{
+    name: "Versailles",
+    km: 17
+}
+{
+    name: "Orleans",
+    km: 110
+}
+*/
+
+-- test: latitude and longitude
+SELECT latitude(p) AS lat, longitude(p) AS lon FROM test WHERE id = 6;
+/* result:
+{
+    lat: -33.8688,
+    lon: 151.2093
+}
+*/
+
+-- test: order by
+SELECT id FROM test WHERE id < 4 ORDER BY p;
+/* result:
+{
+    id: 3
+}
+{
+    id: 2
+}
+{
+    id: 1
+}
+*/
@@ -0,0 +1,61 @@
+-- setup:
+CREATE TABLE test(a INT);
+INSERT INTO test (a) VALUES (1), (2), (3), (4), (5), (6), (7), (8), (9), (10);
+
+-- test: random_between bounds
+SELECT COUNT(*) AS n FROM test WHERE random_between(a, a + 2) BETWEEN a AND a + 2;
+/* result:
+{
+  "n": 10
+}
+*/
+
+-- test: random_between with equal bounds
+SELECT random_between(3, 3) AS r;
+/* result:
+{
+  "r": 3
+}
+*/
+
+-- test: random_between with NULL
+SELECT random_between(NULL, 3) AS r;
+/* result:
+{
+  "r": NULL
+}
+*/
+
+-- test: random_between with invalid bounds
+SELECT random_between(3, 2);
+-- error:
+
+-- test: setseed makes random reproducible
+SELECT setseed(42) AS s, random_between(1, 1000000) AS r;
+/* result:
+{
+  "s": NULL,
+  "r": 278676
+}
+*/
+
+-- test: setseed again
+SELECT setseed(42) AS s, random_between(1, 1000000) AS r;
+/* result:
+{
+  "s": NULL,
+  "r": 278676
+}
+*/
+
+-- test: setseed with a double
+SELECT setseed(0.5) AS s;
+/* result:
+{
+  "s": NULL
+}
+*/
+
+-- test: setseed out of range
+SELECT setseed(1.5);
+-- error:
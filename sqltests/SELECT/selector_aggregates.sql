@@ -0,0 +1,82 @@
+-- setup:
+CREATE TABLE readings (
+    id INT PRIMARY KEY,
+    device TEXT,
+    ts TIMESTAMP,
+    reading DOUBLE
+);
+
+INSERT INTO readings (id, device, ts, reading) VALUES
+    (1, 'a', '2024-01-01 10:00:00', 1.5),
+    (2, 'b', '2024-01-01 10:00:00', 20.0),
+    (3, 'a', '2024-01-01 12:00:00', 3.5),
+    (4, 'b', '2024-01-01 09:00:00', 10.0),
+    (5, 'a', '2024-01-01 11:00:00', NULL),
+    (6, 'b', NULL, 30.0);
+
+-- test: latest reading per device
+SELECT device, arg_max(reading, ts), arg_min(reading, ts) FROM readings GROUP BY device;
+/* result:
+{
+    device: "a",
+    "ARG_MAX(reading, ts)": 3.5,
+    "ARG_MIN(reading, ts)": 1.5
+}
+{
+    device: "b",
+    "ARG_MAX(reading, ts)": 20.0,
+    "ARG_MIN(reading, ts)": 10.0
+}
+*/
+
+-- test: ARG_MIN and ARG_MAX keep the first row among equal values
+SELECT arg_min(id, ts), arg_max(id, device) FROM readings;
+/* result:
+{
+    "ARG_MIN(id, ts)": 4,
+    "ARG_MAX(id, device)": 2
+}
+*/
+
+-- test: ARG_MAX of NULL
+SELECT arg_max(reading, ts) FROM readings WHERE id = 5;
+/* result:
+{
+    "ARG_MAX(reading, ts)": null
+}
+*/
+
+-- test: FIRST_VALUE and LAST_VALUE
+SELECT first_value(reading), last_value(reading) FROM readings;
+/* result:
+{
+    "FIRST_VALUE(reading)": 1.5,
+    "LAST_VALUE(reading)": 30.0
+}
+*/
+
+-- test: FIRST_VALUE and LAST_VALUE ORDER BY
+SELECT device, first_value(id ORDER BY ts), last_value(reading ORDER BY ts), first_value(id ORDER BY ts DESC) FROM readings GROUP BY device;
+/* result:
+{
+    device: "a",
+    "FIRST_VALUE(id ORDER BY ts)": 1,
+    "LAST_VALUE(reading ORDER BY ts)": 3.5,
+    "FIRST_VALUE(id ORDER BY ts DESC)": 3
+}
+{
+    device: "b",
+    "FIRST_VALUE(id ORDER BY ts)": 6,
+    "LAST_VALUE(reading ORDER BY ts)": 20.0,
+    "FIRST_VALUE(id ORDER BY ts DESC)": 2
+}
+*/
+
+-- test: selectors without rows
+SELECT first_value(id), arg_min(id, ts) FROM readings WHERE id > 10;
+/* result:
+{
+    "FIRST_VALUE(id)": null,
+    "ARG_MIN(id, ts)": null
+}
+*/
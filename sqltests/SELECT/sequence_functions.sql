@@ -0,0 +1,143 @@
+-- setup:
+CREATE SEQUENCE seq;
+CREATE SEQUENCE seq_by_10 INCREMENT BY 10 MAXVALUE 100;
+CREATE TABLE test (a INT);
+INSERT INTO test (a) VALUES (1), (2), (3);
+
+-- test: nextval
+SELECT nextval('seq') AS a, nextval('seq') AS b;
+/* result:
+{
+  a: 1,
+  b: 2
+}
+*/
+
+-- test: nextval and NEXT VALUE FOR
+SELECT nextval('seq') AS a, NEXT VALUE FOR seq AS b, nextval('seq_by_10') AS c;
+/* result:
+{
+  a: 1,
+  b: 2,
+  c: 1
+}
+*/
+
+-- test: currval and lastval
+SELECT nextval('seq'), nextval('seq_by_10'), nextval('seq');
+SELECT currval('seq') AS a, currval('seq_by_10') AS b, lastval() AS c;
+/* result:
+{
+  a: 2,
+  b: 1,
+  c: 2
+}
+*/
+
+-- test: currval before nextval
+SELECT currval('seq');
+-- error:
+
+-- test: lastval before nextval
+SELECT lastval();
+-- error:
+
+-- test: unknown sequence
+SELECT nextval('unknown');
+-- error:
+
+-- test: NULL
+SELECT nextval(NULL) AS a, currval(NULL) AS b, setval('seq', NULL) AS c;
+/* result:
+{
+  a: NULL,
+  b: NULL,
+  c: NULL
+}
+*/
+
+-- test: setval
+SELECT setval('seq', 10) AS a, currval('seq') AS b, nextval('seq') AS c;
+/* result:
+{
+  a: 10,
+  b: 10,
+  c: 11
+}
+*/
+
+-- test: setval not called
+SELECT setval('seq_by_10', 50, false) AS a, nextval('seq_by_10') AS b, nextval('seq_by_10') AS c;
+/* result:
+{
+  a: 50,
+  b: 50,
+  c: 60
+}
+*/
+
+-- test: setval out of bounds
+SELECT setval('seq_by_10', 101);
+-- error:
+
+-- test: setval with a non integer value
+SELECT setval('seq', 'a');
+-- error:
+
+-- test: rows
+SELECT a, nextval('seq_by_10') AS n FROM test;
+/* result:
+{
+  a: 1,
+  n: 1
+}
+{
+  a: 2,
+  n: 11
+}
+{
+  a: 3,
+  n: 21
+}
+*/
+
+-- test: maximum value
+SELECT setval('seq_by_10', 91);
+SELECT nextval('seq_by_10');
+-- error:
+
+-- test: DEFAULT
+CREATE TABLE test2 (id BIGINT PRIMARY KEY DEFAULT nextval('seq'), a TEXT);
+INSERT INTO test2 (a) VALUES ('a'), ('b');
+INSERT INTO test2 (a) VALUES ('c');
+SELECT id, a FROM test2;
+/* result:
+{
+  id: 1,
+  a: "a"
+}
+{
+  id: 2,
+  a: "b"
+}
+{
+  id: 3,
+  a: "c"
+}
+*/
+
+-- test: in INSERT and UPDATE
+CREATE TABLE test2 (a BIGINT, b BIGINT);
+INSERT INTO test2 (a) VALUES (nextval('seq')), (nextval('seq'));
+UPDATE test2 SET b = nextval('seq_by_10');
+SELECT a, b FROM test2;
+/* result:
+{
+  a: 1,
+  b: 1
+}
+{
+  a: 2,
+  b: 11
+}
+*/
@@ -0,0 +1,165 @@
+-- setup:
+CREATE TABLE test (
+    id INT PRIMARY KEY,
+    tags ARRAY
+);
+
+INSERT INTO test (id, tags) VALUES
+    (1, ['x', 'y']),
+    (2, ['y']),
+    (3, []),
+    (4, NULL);
+
+-- test: ARRAY_AGG
+SELECT array_agg(id) FROM test;
+/* result:
+{
+    "ARRAY_AGG(id)": '[1, 2, 3, 4]'
+}
+*/
+
+-- test: ARRAY_AGG ORDER BY
+SELECT array_agg(tags ORDER BY id DESC) FROM test WHERE id < 4;
+/* result:
+{
+    "ARRAY_AGG(tags ORDER BY id DESC)": '[[], ["y"], ["x", "y"]]'
+}
+*/
+
+-- test: ARRAY_AGG keeps NULL
+SELECT array_agg(tags[0]) FROM test;
+/* result:
+{
+    "ARRAY_AGG(tags[0])": '["x", "y", null, null]'
+}
+*/
+
+-- test: ARRAY_AGG with GROUP BY
+SELECT id % 2, array_agg(id) FROM test GROUP BY id % 2;
+/* result:
+{
+    "id % 2": 0,
+    "ARRAY_AGG(id)": '[2, 4]'
+}
+{
+    "id % 2": 1,
+    "ARRAY_AGG(id)": '[1, 3]'
+}
+*/
+
+-- test: ARRAY_AGG without rows
+SELECT array_agg(id) FROM test WHERE id > 10;
+/* result:
+{
+    "ARRAY_AGG(id)": null
+}
+*/
+
+-- test: UNNEST in the projection
+SELECT id, unnest(tags) FROM test;
+/* result:
+{
+    id: 1,
+    "UNNEST(tags)": "x"
+}
+{
+    id: 1,
+    "UNNEST(tags)": "y"
+}
+{
+    id: 2,
+    "UNNEST(tags)": "y"
+}
+*/
+
+-- test: UNNEST in an expression
+SELECT unnest(tags) = 'y' AS isy FROM test WHERE id = 1;
+/* result:
+{
+    isy: false
+}
+{
+    isy: true
+}
+*/
+
+-- test: UNNEST with wildcard
+SELECT *, unnest(tags) AS tag FROM test WHERE id = 2;
+/* result:
+{
+    id: 2,
+    tags: '["y"]',
+    tag: "y"
+}
+*/
+
+-- test: UNNEST of an aggregate
+SELECT unnest(array_agg(id)) FROM test WHERE id > 2;
+/* result:
+{
+    "UNNEST(ARRAY_AGG(id))": 3
+}
+{
+    "UNNEST(ARRAY_AGG(id))": 4
+}
+*/
+
+-- test: UNNEST without FROM
+SELECT unnest([1, 2]) AS a;
+/* result:
+{
+    a: 1
+}
+{
+    a: 2
+}
+*/
+
+-- test: FROM UNNEST
+SELECT * FROM unnest([3, 1, 2]);
+/* result:
+{
+    unnest: 3
+}
+{
+    unnest: 1
+}
+{
+    unnest: 2
+}
+*/
+
+-- test: FROM UNNEST AS
+SELECT x + 1 FROM unnest([3, 1, 2]) AS x WHERE x > 1 ORDER BY x;
+/* result:
+{
+    "x + 1": 3
+}
+{
+    "x + 1": 4
+}
+*/
+
+-- test: FROM UNNEST with aggregate
+SELECT sum(x) FROM unnest([3, 1, 2]) AS x;
+/* result:
+{
+    "SUM(x)": 6
+}
+*/
+
+-- test: FROM UNNEST with unknown column
+SELECT y FROM unnest([3, 1, 2]) AS x;
+-- error: column y does not exist
+
+-- test: FROM UNNEST of a value that is not an array
+SELECT * FROM unnest(1);
+-- error: cannot unnest a value of type integer
+
+-- test: UNNEST in WHERE
+SELECT id FROM test WHERE unnest(tags) = 'x';
+-- error: UNNEST() is only allowed in the projection and in the FROM clause
+
+-- test: several UNNEST
+SELECT unnest(tags), unnest([1]) FROM test;
+-- error: only one unnest() is allowed in the projection
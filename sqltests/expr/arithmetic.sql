@@ -47,6 +47,39 @@ NULL
 > 1 ^ NULL
 NULL
 
+> 5 # 3
+6
+
+> 1 << 4
+16
+
+> 256 >> 4
+16
+
+> -16 >> 2
+-4
+
+> 2147483647 << 1
+-2
+
+> 1 << 64
+0
+
+> 9223372036854775807 << 1
+-2
+
+> 18446744073709551615 >> 60
+15
+
+> 1 << NULL
+NULL
+
+> 1 + 1 << 2
+8
+
+> 6 & 3 << 1
+4
+
 -- test: divide by zero
 ! 1 / 0
 'division by zero'
@@ -76,6 +109,12 @@ NULL
 ! 1 ^ a
 'no table specified'
 
+-- test: shifts
+! 1 << -1
+'negative shift count'
+
+! 1 << 'a'
+
 -- test: division
 > 1 / 2
 0
@@ -99,3 +138,47 @@ NULL
 ! 1000000000 * 1000000000
 
 ! 1000000000000000000 * 1000000000000000000 * 1000000000000000000
+
+-- test: arithmetic with reals
+> CAST (1.5 AS REAL) + CAST (2 AS REAL)
+3.5
+
+> typeof(CAST (1.5 AS REAL) * CAST (2 AS REAL))
+'real'
+
+> typeof(CAST (1.5 AS REAL) + 1.5)
+'double'
+
+> typeof(CAST (1.5 AS REAL) + 1)
+'double'
+
+> typeof(1 - CAST (1.5 AS REAL))
+'double'
+
+> CAST (1 AS REAL) / CAST (0 AS REAL)
+NULL
+
+-- test: arithmetic with unsigned integers
+> 9223372036854775808 + 1
+9223372036854775809
+
+> -1 + 9223372036854775808
+9223372036854775807
+
+> 18446744073709551615 / 5
+3689348814741910323
+
+> 18446744073709551615 % 10
+5
+
+> 18446744073709551615 + 0.5
+1.8446744073709552e+19
+
+> 18446744073709551615 & 255
+255
+
+! 18446744073709551615 + 1
+'bigint unsigned out of range'
+
+! 1 - 9223372036854775808
+'bigint unsigned out of range'
@@ -14,6 +14,50 @@ true
 ! CAST (1 AS BLOB)
 'cannot cast integer as blob'
 
+-- test: source(BIGINT UNSIGNED)
+> CAST (18446744073709551615 AS BIGINT UNSIGNED)
+18446744073709551615
+
+> CAST (1 AS UINT64)
+1
+
+> typeof(CAST (1 AS BIGINT UNSIGNED))
+'bigint unsigned'
+
+> CAST (CAST (1 AS BIGINT UNSIGNED) AS BIGINT)
+1
+
+! CAST (18446744073709551615 AS BIGINT)
+'bigint out of range'
+
+! CAST (-1 AS BIGINT UNSIGNED)
+'bigint unsigned out of range'
+
+> CAST ('18446744073709551615' AS BIGINT UNSIGNED)
+18446744073709551615
+
+-- test: source(REAL)
+> CAST (1.1 AS REAL)
+1.1
+
+> typeof(CAST (1.1 AS REAL))
+'real'
+
+> CAST (CAST (1.5 AS REAL) AS DOUBLE)
+1.5
+
+> CAST (CAST (1.5 AS REAL) AS INTEGER)
+1
+
+> CAST (CAST (0.1 AS REAL) AS TEXT)
+'0.1'
+
+> CAST ('2.5' AS REAL)
+2.5
+
+! CAST (1e300 AS REAL)
+'real out of range'
+
 -- test: source(DOUBLE)
 > CAST (1.1 AS DOUBLE)
 1.1
@@ -0,0 +1,80 @@
+-- test: cast
+> CAST ('Foo' AS CITEXT)
+'Foo'
+
+> typeof(CAST ('Foo' AS CITEXT))
+'citext'
+
+> typeof(CAST (CAST ('Foo' AS CITEXT) AS TEXT))
+'text'
+
+> CAST (10 AS CITEXT)
+'10'
+
+> CAST (CAST ('10' AS CITEXT) AS INTEGER)
+10
+
+! CAST (CAST ('foo' AS CITEXT) AS INTEGER)
+'cannot cast "foo" as integer: strconv.ParseInt: parsing "foo": invalid syntax'
+
+-- test: comparison
+> CAST ('Foo' AS CITEXT) = 'fOO'
+true
+
+> 'fOO' = CAST ('Foo' AS CITEXT)
+true
+
+> CAST ('Foo' AS CITEXT) = CAST ('FOO' AS CITEXT)
+true
+
+> CAST ('Foo' AS CITEXT) != 'foo'
+false
+
+> CAST ('Foo' AS CITEXT) = 'bar'
+false
+
+> CAST ('a' AS CITEXT) < 'B'
+true
+
+> 'B' > CAST ('a' AS CITEXT)
+true
+
+> CAST ('ÉTÉ' AS CITEXT) = 'été'
+true
+
+> CAST ('b' AS CITEXT) BETWEEN 'A' AND 'C'
+true
+
+> CAST ('1' AS CITEXT) = 1
+true
+
+> CAST ('a' AS CITEXT) = 1
+false
+
+-- test: functions and operators
+> CAST ('Foo' AS CITEXT) LIKE 'f%'
+true
+
+> 'Foo' LIKE CAST ('F_O' AS CITEXT)
+true
+
+> CAST ('Foo' AS CITEXT) NOT LIKE 'F%'
+false
+
+> CAST ('Foo' AS CITEXT) || 'Bar'
+'FooBar'
+
+> typeof(CAST ('Foo' AS CITEXT) || 'Bar')
+'text'
+
+> LOWER(CAST ('Foo' AS CITEXT))
+'foo'
+
+> UPPER(CAST ('Foo' AS CITEXT))
+'FOO'
+
+> LEN(CAST ('Foo' AS CITEXT))
+3
+
+> TRIM(CAST (' Foo ' AS CITEXT))
+'Foo'
@@ -0,0 +1,99 @@
+-- test: literal
+> INTERVAL '1 day'
+'1 day'
+
+> typeof(INTERVAL '1 day')
+'interval'
+
+> INTERVAL '1 year 14 mons 36 hours'
+'2 years 2 mons 36:00:00'
+
+> INTERVAL '-1 week 00:00:01.5'
+'-7 days 00:00:01.5'
+
+! INTERVAL '1 fortnight'
+'unknown unit'
+
+-- test: cast
+> CAST ('2 hours 30 minutes' AS INTERVAL)
+'02:30:00'
+
+> CAST (INTERVAL '1 mon' AS TEXT)
+'1 mon'
+
+> CAST ({a: INTERVAL '1 day'} AS TEXT)
+'{"a": "1 day"}'
+
+! CAST ('soon' AS INTERVAL)
+'cannot cast "soon" as interval'
+
+! CAST (INTERVAL '1 day' AS INTEGER)
+'cannot cast interval as integer'
+
+-- test: timestamp plus interval
+> TIMESTAMP '2024-01-31 10:00:00' + INTERVAL '1 mon'
+'2024-02-29T10:00:00Z'
+
+> INTERVAL '1 day 02:00' + TIMESTAMP '2024-01-31 10:00:00'
+'2024-02-01T12:00:00Z'
+
+> typeof(TIMESTAMP '2024-01-31' + INTERVAL '1 day')
+'timestamp'
+
+> TIMESTAMP '2024-03-31' - INTERVAL '1 mon 1 day'
+'2024-02-28T00:00:00Z'
+
+> TIMESTAMPTZ '2024-01-31T10:00:00+02:00' + INTERVAL '1 hour'
+'2024-01-31T09:00:00Z'
+
+> TIMESTAMP '2024-01-31' + NULL
+NULL
+
+> TIMESTAMP '2024-01-31' + 1
+NULL
+
+! TIMESTAMP '9999-12-31' + INTERVAL '1000000 years'
+'timestamp out of range'
+
+-- test: timestamp minus timestamp
+> TIMESTAMP '2024-03-02 01:00:00' - TIMESTAMP '2024-01-01'
+'61 days 01:00:00'
+
+> TIMESTAMP '2024-01-01' - TIMESTAMP '2024-01-01 00:00:01'
+'-00:00:01'
+
+> typeof(TIMESTAMP '2024-01-02' - TIMESTAMP '2024-01-01')
+'interval'
+
+-- test: interval plus interval
+> INTERVAL '1 day' + INTERVAL '1 mon 2 hours'
+'1 mon 1 day 02:00:00'
+
+> INTERVAL '1 day' - INTERVAL '1 hour'
+'1 day -01:00:00'
+
+-- test: comparisons
+> INTERVAL '1 day' > INTERVAL '23 hours'
+true
+
+> INTERVAL '1 mon' < INTERVAL '31 days'
+true
+
+> INTERVAL '36 hours' = '36:00:00'
+true
+
+> TIMESTAMP '2024-03-02' - TIMESTAMP '2024-01-01' > INTERVAL '60 days'
+true
+
+-- test: dates and timestamps
+> make_date(2024, 1, 2) = TIMESTAMP '2024-01-02'
+true
+
+> make_date(2024, 1, 2) < TIMESTAMP '2024-01-02 00:00:01'
+true
+
+> TIMESTAMP '2024-01-02 12:00:00' > '2024-01-02'
+true
+
+> TIMESTAMPTZ '2024-01-02T00:00:00+01:00' < make_date(2024, 1, 2)
+true
@@ -24,6 +24,16 @@
 > typeof(-100000000000)
 'bigint'
 
+-- test: literals/unsigned bigints
+> 18446744073709551615
+18446744073709551615
+
+> typeof(18446744073709551615)
+'bigint unsigned'
+
+> typeof(18446744073709551616)
+'double'
+
 -- test: literals/doubles
 
 > 1.0
@@ -95,3 +105,20 @@ false
 
 ! '\xhello'
 'invalid hexadecimal digit: h'
+
+> x'DEADBEEF'
+'\xDEADBEEF'
+
+> typeof(X'deadbeef')
+'blob'
+
+> x'DEADBEEF' = '\xDEADBEEF'
+true
+
+> x''
+'\x'
+
+! x'hello'
+'invalid hexadecimal digit: h'
+
+! x'ABC'
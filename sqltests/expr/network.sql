@@ -0,0 +1,119 @@
+-- test: cast
+> CAST ('192.168.1.5' AS INET)
+'192.168.1.5'
+
+> typeof(CAST ('192.168.1.5' AS INET))
+'inet'
+
+> CAST ('192.168.1.5/24' AS INET)
+'192.168.1.5/24'
+
+> CAST ('192.168.1.5/32' AS INET)
+'192.168.1.5'
+
+> CAST ('2001:DB8::1' AS INET)
+'2001:db8::1'
+
+> CAST ('192.168.1.0/24' AS CIDR)
+'192.168.1.0/24'
+
+> typeof(CAST ('192.168.1.0/24' AS CIDR))
+'cidr'
+
+> CAST ('192.168.1.5' AS CIDR)
+'192.168.1.5/32'
+
+> CAST (CAST ('192.168.1.5/24' AS INET) AS CIDR)
+'192.168.1.0/24'
+
+> CAST (CAST ('192.168.1.0/24' AS CIDR) AS TEXT)
+'192.168.1.0/24'
+
+> CAST ({a: CAST ('10.0.0.1' AS INET)} AS TEXT)
+'{"a": "10.0.0.1"}'
+
+! CAST ('192.168.1.256' AS INET)
+'cannot cast "192.168.1.256" as inet'
+
+! CAST ('fe80::1%eth0' AS INET)
+'cannot cast "fe80::1%eth0" as inet'
+
+! CAST ('192.168.1.5/24' AS CIDR)
+'bits set to the right of the prefix'
+
+! CAST (CAST ('10.0.0.1' AS INET) AS INTEGER)
+'cannot cast inet as integer'
+
+-- test: comparison
+> CAST ('10.0.0.1' AS INET) = '10.0.0.1'
+true
+
+> CAST ('10.0.0.1' AS INET) = '10.0.0.1/32'
+true
+
+> CAST ('10.0.0.1' AS INET) = '10.0.0.1/8'
+false
+
+> CAST ('10.0.0.0/8' AS INET) = CAST ('10.0.0.0/8' AS CIDR)
+true
+
+> CAST ('10.0.0.2' AS INET) > '10.0.0.1'
+true
+
+> CAST ('10.0.0.1/16' AS INET) > '10.0.0.1/8'
+true
+
+> CAST ('::1' AS INET) > '255.255.255.255'
+true
+
+> CAST ('10.0.0.1' AS INET) = 1
+false
+
+> CAST ('10.0.0.1' AS INET) = 'foo'
+false
+
+-- test: containment
+> CAST ('10.1.2.3' AS INET) << CAST ('10.0.0.0/8' AS CIDR)
+true
+
+> CAST ('10.1.2.3' AS INET) << '10.0.0.0/8'
+true
+
+> '10.1.2.3' << '10.0.0.0/8'
+true
+
+> CAST ('11.1.2.3' AS INET) << '10.0.0.0/8'
+false
+
+> CAST ('10.0.0.0/8' AS CIDR) << '10.0.0.0/8'
+false
+
+> CAST ('10.0.0.0/8' AS CIDR) <<= '10.0.0.0/8'
+true
+
+> CAST ('10.0.0.0/4' AS INET) <<= '10.0.0.0/8'
+false
+
+> CAST ('10.1.2.3/16' AS INET) <<= '10.1.2.3/16'
+true
+
+> CAST ('10.0.0.0/8' AS CIDR) >> '10.1.2.3'
+true
+
+> CAST ('10.0.0.0/8' AS CIDR) >> '10.0.0.0/8'
+false
+
+> CAST ('10.0.0.0/8' AS CIDR) >>= '10.0.0.0/8'
+true
+
+> CAST ('2001:db8::/32' AS CIDR) >> '2001:db8:1::1'
+true
+
+> CAST ('::/0' AS CIDR) >> '10.0.0.1'
+false
+
+> CAST ('10.0.0.1' AS INET) << NULL
+NULL
+
+! CAST ('10.0.0.1' AS INET) << 'foo'
+'invalid inet'
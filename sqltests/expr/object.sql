@@ -0,0 +1,115 @@
+-- test: literals/objects
+> {a: 1, "b c": 'foo'}
+{"a": 1, "b c": "foo"}
+
+> typeof({a: 1})
+'object'
+
+> {}
+{}
+
+> {b: 1, a: 2}
+{a: 2, b: 1}
+
+> {a: 1, a: 2}
+{a: 2}
+
+> {a: {b: [1, 2.5, 'c', true, null]}}
+{a: {b: [1, 2.5, "c", true, null]}}
+
+> {a: 1 + 1, b: 'a' || 'b'}
+{a: 2, b: "ab"}
+
+! {a 1}
+'found 1, expected :'
+
+-- test: literals/arrays
+> [1, 'a', [2, 3]]
+[1, "a", [2, 3]]
+
+> typeof([1])
+'array'
+
+> []
+[]
+
+-- test: paths
+> {a: 1}.a
+1
+
+> {a: {b: [1, {c: 'foo'}]}}.a.b[1].c
+'foo'
+
+> typeof({a: {b: 10}}.a.b)
+'integer'
+
+> typeof({a: {b: 10.0}}.a.b)
+'double'
+
+> typeof({a: {b: 'foo'}}.a)
+'object'
+
+> {a: 1}.b
+NULL
+
+> {a: 1}.a.b
+NULL
+
+> {a: [1]}.a[1]
+NULL
+
+> [1, 2][1]
+2
+
+> (CAST ('{"a": {"b": 1}}' AS JSONB)).a.b
+1.0
+
+-- test: cast
+> CAST ({a: 1, b: [true, 'foo']} AS TEXT)
+'{"a": 1, "b": [true, "foo"]}'
+
+> CAST ([1, 2] AS TEXT)
+'[1, 2]'
+
+> CAST ('{"a": 1, "b": [1.5, "foo"]}' AS OBJECT)
+{a: 1, b: [1.5, "foo"]}
+
+> typeof(CAST ('{"a": 1}' AS OBJECT).a)
+'integer'
+
+> CAST ('{"a": 1}' AS DOCUMENT)
+{a: 1}
+
+> CAST ('[1, {"a": null}]' AS ARRAY)
+[1, {a: null}]
+
+> CAST (CAST ('{"a": [1, 2]}' AS JSONB) AS OBJECT)
+{a: [1, 2]}
+
+> CAST ({a: [1, 2]} AS JSONB)
+CAST ('{"a": [1, 2]}' AS JSONB)
+
+! CAST ('[1]' AS OBJECT)
+'[1] is not an object'
+
+! CAST ({a: 1} AS INTEGER)
+'cannot cast object as integer'
+
+-- test: comparison
+> {a: 1, b: 2} = {b: 2, a: 1}
+true
+
+> {a: 1} = {a: 2}
+false
+
+> {a: 1} < {a: 2}
+true
+
+> [1, 2] = [1, 2]
+true
+
+> [1, 2] = [2, 1]
+false
+
+> {a: 1} = [1]
+false
@@ -0,0 +1,110 @@
+-- test: cast
+> CAST ('(48.8566, 2.3522)' AS POINT)
+'(48.8566, 2.3522)'
+
+> typeof(CAST ('(48.8566, 2.3522)' AS POINT))
+'point'
+
+> CAST ('-33.8688,151.2093' AS POINT)
+'(-33.8688, 151.2093)'
+
+> CAST (CAST ('(48.8566, 2.3522)' AS POINT) AS TEXT)
+'(48.8566, 2.3522)'
+
+> CAST ({a: CAST ('(1, 2)' AS POINT)} AS TEXT)
+'{"a": "(1, 2)"}'
+
+! CAST ('(91, 0)' AS POINT)
+'cannot cast "(91, 0)" as point: latitude must be within [-90, 90]'
+
+! CAST ('(0, 181)' AS POINT)
+'cannot cast "(0, 181)" as point: longitude must be within [-180, 180]'
+
+! CAST ('48.8566' AS POINT)
+'cannot cast "48.8566" as point: invalid point'
+
+! CAST (CAST ('(1, 2)' AS POINT) AS INTEGER)
+'cannot cast point as integer'
+
+-- test: make_point
+> make_point(48.8566, 2.3522)
+'(48.8566, 2.3522)'
+
+> make_point(1, -2)
+'(1, -2)'
+
+> make_point(NULL, 2)
+NULL
+
+! make_point('a', 2)
+'make_point(lat, lon) expects lat and lon to be numbers'
+
+! make_point(100, 2)
+'make_point(lat, lon): latitude must be within [-90, 90]'
+
+-- test: latitude and longitude
+> latitude(make_point(48.8566, 2.3522))
+48.8566
+
+> longitude(make_point(48.8566, 2.3522))
+2.3522
+
+> latitude('(1, 2)')
+1.0
+
+> longitude(NULL)
+NULL
+
+! latitude(1)
+'latitude(arg1) expects a point, got integer'
+
+-- test: distance
+> distance(make_point(48.8566, 2.3522), make_point(48.8566, 2.3522))
+0.0
+
+> CAST (distance(make_point(48.8566, 2.3522), make_point(51.5074, -0.1278)) AS INTEGER)
+343556
+
+> CAST (distance(make_point(0, 0), make_point(0, 180)) AS INTEGER)
+20015114
+
+> distance(make_point(1, 2), NULL)
+NULL
+
+-- test: within_box
+> within_box(make_point(48.8566, 2.3522), make_point(48, 2), make_point(49, 3))
+true
+
+> within_box(make_point(48.8566, 3.5), make_point(48, 2), make_point(49, 3))
+false
+
+> within_box(make_point(48, 2), make_point(48, 2), make_point(49, 3))
+true
+
+> within_box('(0, 179.5)', '(-1, 179)', '(1, -179)')
+true
+
+> within_box('(0, -179.5)', '(-1, 179)', '(1, -179)')
+true
+
+> within_box('(0, 0)', '(-1, 179)', '(1, -179)')
+false
+
+> within_box(NULL, make_point(48, 2), make_point(49, 3))
+NULL
+
+! within_box(1, make_point(48, 2), make_point(49, 3))
+'within_box(p, sw, ne) expects p, sw and ne to be points'
+
+-- test: comparison
+> make_point(1, 2) = '(1, 2)'
+true
+
+> make_point(1, 2) = make_point(1, 2.5)
+false
+
+> make_point(0, -0.0) = make_point(0, 0)
+true
+
+> make_point(1, 2) < make_point(3, 4)
+true
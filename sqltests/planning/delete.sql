@@ -0,0 +1,35 @@
+-- setup:
+CREATE TABLE test(a int PRIMARY KEY, b int);
+CREATE TABLE test_idx(a int PRIMARY KEY, b int UNIQUE);
+
+-- test: range on primary key
+EXPLAIN DELETE FROM test WHERE a > 10;
+/* result:
+{
+    "plan": 'table.DeleteRange("test", [{"min": (10), "exclusive": true}]) | discard()'
+}
+*/
+
+-- test: no condition
+EXPLAIN DELETE FROM test;
+/* result:
+{
+    "plan": 'table.DeleteRange("test") | discard()'
+}
+*/
+
+-- test: remaining filter
+EXPLAIN DELETE FROM test WHERE a > 10 AND b = 5;
+/* result:
+{
+    "plan": 'table.Scan("test", [{"min": (10), "exclusive": true}]) | rows.Filter(b = 5) | table.Delete(\'test\') | discard()'
+}
+*/
+
+-- test: with index
+EXPLAIN DELETE FROM test_idx WHERE a > 10;
+/* result:
+{
+    "plan": 'table.Scan("test_idx", [{"min": (10), "exclusive": true}]) | index.Delete("test_idx_b_idx") | table.Delete(\'test_idx\') | discard()'
+}
+*/
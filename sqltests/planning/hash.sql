@@ -0,0 +1,56 @@
+-- setup:
+CREATE TABLE test(id int PRIMARY KEY, email TEXT);
+
+CREATE INDEX test_email_md5 ON test(md5(email));
+CREATE INDEX test_email_xxhash ON test(xxhash(email));
+
+INSERT INTO
+    test (id, email)
+VALUES
+    (1, 'a@example.com'),
+    (2, 'b@example.com'),
+    (3, NULL);
+
+-- test: md5
+EXPLAIN SELECT id FROM test WHERE md5(email) = 'd3d7ebb9768eb6f1d6cee6d0cefd341b';
+/* result:
+{
+    "plan": 'index.Scan("test_email_md5", [{"min": ("d3d7ebb9768eb6f1d6cee6d0cefd341b"), "exact": true}]) | rows.Project(id)'
+}
+*/
+
+-- test: md5 result
+SELECT id FROM test WHERE md5(email) = 'd3d7ebb9768eb6f1d6cee6d0cefd341b';
+/* result:
+{
+    "id": 2
+}
+*/
+
+-- test: xxhash
+EXPLAIN SELECT id FROM test WHERE xxhash(email) = 1;
+/* result:
+{
+    "plan": 'index.Scan("test_email_xxhash", [{"min": (1), "exact": true}]) | rows.Project(id)'
+}
+*/
+
+-- test: xxhash result
+SELECT id FROM test WHERE xxhash(email) = xxhash('a@example.com');
+/* result:
+{
+    "id": 1
+}
+*/
+
+-- test: catalog
+SELECT sql FROM __chai_catalog WHERE name = "test_email_md5";
+/* result:
+{
+    "sql": "CREATE INDEX test_email_md5 ON test (md5(email))"
+}
+*/
+
+-- test: hash of a constant
+CREATE INDEX ON test(md5('a'));
+-- error:
@@ -0,0 +1,91 @@
+-- setup:
+CREATE TABLE test(id int PRIMARY KEY, d JSONB);
+
+CREATE INDEX test_age ON test(JSON_EXTRACT(d, '$.age'));
+
+INSERT INTO
+    test (id, d)
+VALUES
+    (1, '{"name": "a", "age": 10}'),
+    (2, '{"name": "b", "age": 20.5}'),
+    (3, '{"name": "c", "age": "30"}'),
+    (4, '{"name": "d"}'),
+    (5, '{"name": "e", "age": 40}');
+
+-- test: =
+EXPLAIN SELECT id FROM test WHERE JSON_EXTRACT(d, '$.age') = 10;
+/* result:
+{
+    "plan": 'index.Scan("test_age", [{"min": (10.0), "exact": true}]) | rows.Project(id)'
+}
+*/
+
+-- test: = result
+SELECT id FROM test WHERE JSON_EXTRACT(d, '$.age') = 10;
+/* result:
+{
+    "id": 1
+}
+*/
+
+-- test: >
+EXPLAIN SELECT id FROM test WHERE JSON_EXTRACT(d, '$.age') > 15;
+/* result:
+{
+    "plan": 'index.Scan("test_age", [{"min": (15.0), "exclusive": true}]) | rows.Project(id)'
+}
+*/
+
+-- test: > result
+SELECT id FROM test WHERE JSON_EXTRACT(d, '$.age') > 15;
+/* result:
+{
+    "id": 2
+}
+{
+    "id": 5
+}
+*/
+
+-- test: text
+SELECT id FROM test WHERE JSON_EXTRACT(d, '$.age') = '30';
+/* result:
+{
+    "id": 3
+}
+*/
+
+-- test: IN
+SELECT id FROM test WHERE JSON_EXTRACT(d, '$.age') IN (10, 40);
+/* result:
+{
+    "id": 1
+}
+{
+    "id": 5
+}
+*/
+
+-- test: BETWEEN
+SELECT id FROM test WHERE JSON_EXTRACT(d, '$.age') BETWEEN 10 AND 30;
+/* result:
+{
+    "id": 1
+}
+{
+    "id": 2
+}
+*/
+
+-- test: missing path
+SELECT id FROM test WHERE JSON_EXTRACT(d, '$.age') IS NULL;
+/* result:
+{
+    "id": 4
+}
+*/
+
+-- test: unique
+CREATE UNIQUE INDEX test_name ON test(JSON_EXTRACT(d, '$.name'));
+INSERT INTO test (id, d) VALUES (6, '{"name": "a"}');
+-- error:
@@ -0,0 +1,49 @@
+-- test: contained by with index
+CREATE TABLE test(a INET, b INT);
+CREATE INDEX ON test(a);
+EXPLAIN SELECT * FROM test WHERE a <<= '10.0.0.0/8';
+/* result:
+{
+    "plan": 'index.Scan("test_a_idx", [{"min": ("10.0.0.0/0"), "max": ("10.255.255.255")}]) | rows.Filter(a <<= "10.0.0.0/8")'
+}
+*/
+
+-- test: contains with index
+CREATE TABLE test(a CIDR, b INT);
+CREATE INDEX ON test(a);
+EXPLAIN SELECT * FROM test WHERE '2001:db8::/32' >> a AND b > 1;
+/* result:
+{
+    "plan": 'index.Scan("test_a_idx", [{"min": ("2001:db8::/0"), "max": ("2001:db8:ffff:ffff:ffff:ffff:ffff:ffff/128")}]) | rows.Filter("2001:db8::/32" >> a) | rows.Filter(b > 1)'
+}
+*/
+
+-- test: composite index
+CREATE TABLE test(a INT, b INET);
+CREATE INDEX ON test(a, b);
+EXPLAIN SELECT * FROM test WHERE a = 1 AND b << '192.168.0.0/16';
+/* result:
+{
+    "plan": 'index.Scan("test_a_b_idx", [{"min": (1, "192.168.0.0/0"), "max": (1, "192.168.255.255")}]) | rows.Filter(b << "192.168.0.0/16")'
+}
+*/
+
+-- test: not a network
+CREATE TABLE test(a TEXT, b INT);
+CREATE INDEX ON test(a);
+EXPLAIN SELECT * FROM test WHERE a <<= '10.0.0.0/8';
+/* result:
+{
+    "plan": 'table.Scan("test") | rows.Filter(a <<= "10.0.0.0/8")'
+}
+*/
+
+-- test: column on both sides
+CREATE TABLE test(a INET, b CIDR);
+CREATE INDEX ON test(a);
+EXPLAIN SELECT * FROM test WHERE a <<= b;
+/* result:
+{
+    "plan": 'table.Scan("test") | rows.Filter(a <<= b)'
+}
+*/
@@ -0,0 +1,112 @@
+-- setup:
+CREATE TABLE test(a int, b int, c int);
+
+CREATE INDEX test_a ON test(a NULLS LAST);
+
+CREATE INDEX test_b ON test(b DESC NULLS FIRST);
+
+CREATE INDEX test_c ON test(c DESC NULLS LAST);
+
+INSERT INTO
+    test (a, b, c)
+VALUES
+    (1, 1, 1),
+    (NULL, NULL, NULL),
+    (3, 3, 3),
+    (2, 2, 2);
+
+-- test: NULLS LAST index, ASC
+EXPLAIN SELECT * FROM test ORDER BY a;
+/* result:
+{
+    "plan": 'table.Scan("test") | rows.TempTreeSort(a)'
+}
+*/
+
+-- test: NULLS LAST index, DESC
+EXPLAIN SELECT * FROM test ORDER BY a DESC;
+/* result:
+{
+    "plan": 'table.Scan("test") | rows.TempTreeSortReverse(a)'
+}
+*/
+
+-- test: NULLS FIRST descending index
+EXPLAIN SELECT * FROM test ORDER BY b DESC;
+/* result:
+{
+    "plan": 'table.Scan("test") | rows.TempTreeSortReverse(b)'
+}
+*/
+
+-- test: NULLS LAST descending index
+EXPLAIN SELECT * FROM test ORDER BY c DESC;
+/* result:
+{
+    "plan": 'index.Scan("test_c")'
+}
+*/
+
+-- test: NULLS LAST index with filter
+EXPLAIN SELECT a FROM test WHERE a > 1;
+/* result:
+{
+    "plan": 'index.Scan("test_a", [{"min": (1), "exclusive": true}]) | rows.Project(a)'
+}
+*/
+
+-- test: results with filter
+SELECT a FROM test WHERE a > 1;
+/* result:
+{
+    "a": 2
+}
+{
+    "a": 3
+}
+*/
+
+-- test: results with filter, descending index
+SELECT b FROM test WHERE b < 3;
+/* result:
+{
+    "b": 2
+}
+{
+    "b": 1
+}
+*/
+
+-- test: NULLs are sorted at their default position
+SELECT a FROM test ORDER BY a;
+/* result:
+{
+    "a": null
+}
+{
+    "a": 1
+}
+{
+    "a": 2
+}
+{
+    "a": 3
+}
+*/
+
+-- test: NULLs are sorted at their default position, DESC
+SELECT b FROM test ORDER BY b DESC;
+/* result:
+{
+    "b": 3
+}
+{
+    "b": 2
+}
+{
+    "b": 1
+}
+{
+    "b": null
+}
+*/
@@ -0,0 +1,49 @@
+-- test: within box with index
+CREATE TABLE test(a POINT, b INT);
+CREATE INDEX ON test(a);
+EXPLAIN SELECT * FROM test WHERE within_box(a, make_point(48, 2), make_point(49, 3));
+/* result:
+{
+    "plan": 'index.Scan("test_a_idx", [{"min": ("(48, 2)"), "max": ("(49, 3)")}]) | rows.Filter(within_box(a, make_point(48, 2), make_point(49, 3)))'
+}
+*/
+
+-- test: text corners
+CREATE TABLE test(a POINT, b INT);
+CREATE INDEX ON test(a);
+EXPLAIN SELECT * FROM test WHERE within_box(a, '(48, 2)', '(49, 3)') AND b > 1;
+/* result:
+{
+    "plan": 'index.Scan("test_a_idx", [{"min": ("(48, 2)"), "max": ("(49, 3)")}]) | rows.Filter(within_box(a, "(48, 2)", "(49, 3)")) | rows.Filter(b > 1)'
+}
+*/
+
+-- test: composite index
+CREATE TABLE test(a INT, b POINT);
+CREATE INDEX ON test(a, b);
+EXPLAIN SELECT * FROM test WHERE a = 1 AND within_box(b, make_point(-10, -10), make_point(10, 10));
+/* result:
+{
+    "plan": 'index.Scan("test_a_b_idx", [{"min": (1, "(-10, -10)"), "max": (1, "(10, 10)")}]) | rows.Filter(within_box(b, make_point(-10, -10), make_point(10, 10)))'
+}
+*/
+
+-- test: box crossing the antimeridian
+CREATE TABLE test(a POINT, b INT);
+CREATE INDEX ON test(a);
+EXPLAIN SELECT * FROM test WHERE within_box(a, make_point(-40, 150), make_point(-30, -170));
+/* result:
+{
+    "plan": 'table.Scan("test") | rows.Filter(within_box(a, make_point(-40, 150), make_point(-30, -170)))'
+}
+*/
+
+-- test: corners not constant
+CREATE TABLE test(a POINT, b POINT);
+CREATE INDEX ON test(a);
+EXPLAIN SELECT * FROM test WHERE within_box(a, b, make_point(10, 10));
+/* result:
+{
+    "plan": 'table.Scan("test") | rows.Filter(within_box(a, b, make_point(10, 10)))'
+}
+*/
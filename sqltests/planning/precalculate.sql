@@ -24,3 +24,43 @@ EXPLAIN SELECT * FROM test WHERE 4 * 3 BETWEEN 3 + 6 AND 5 * 10;
     plan: "table.Scan(\"test\")"
 }
 */
+
+-- test: precalculate comparisons with NULL
+EXPLAIN SELECT * FROM test WHERE a = NULL;
+/* result:
+{
+    plan: ""
+}
+*/
+
+-- test: precalculate BETWEEN with NULL
+EXPLAIN SELECT * FROM test WHERE a BETWEEN 1 AND NULL;
+/* result:
+{
+    plan: ""
+}
+*/
+
+-- test: precalculate AND with false
+EXPLAIN SELECT * FROM test WHERE a > 1 OR a < 0 AND false;
+/* result:
+{
+    plan: "table.Scan(\"test\") | rows.Filter(a > 1 OR false)"
+}
+*/
+
+-- test: precalculate OR with true
+EXPLAIN SELECT * FROM test WHERE a > 1 OR true;
+/* result:
+{
+    plan: "table.Scan(\"test\")"
+}
+*/
+
+-- test: AND with NULL is not precalculated
+EXPLAIN SELECT * FROM test WHERE a > 1 AND (a < 3 OR NULL);
+/* result:
+{
+    plan: "table.Scan(\"test\") | rows.Filter(a > 1) | rows.Filter((a < 3 OR NULL))"
+}
+*/
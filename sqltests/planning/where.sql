@@ -84,4 +84,19 @@ EXPLAIN SELECT * FROM test WHERE a IN (1, b + 3);
  {
     "plan": 'table.Scan("test") | rows.Filter(a IN (1, b + 3))'
  }
-*/
\ No newline at end of file
+*/
+-- test: IN with NULL
+EXPLAIN SELECT * FROM test WHERE a IN (1, NULL, 3);
+/* result:
+ {
+    "plan": 'index.Scan("test_a", [{"min": (1), "exact": true}, {"min": (3), "exact": true}])'
+ }
+*/
+
+-- test: IN with only NULL
+EXPLAIN SELECT * FROM test WHERE a IN (NULL);
+/* result:
+ {
+    "plan": 'table.Scan("test") | rows.Filter(a IN (NULL))'
+ }
+*/
@@ -300,24 +300,33 @@ func RequireRowsEqf(t *testing.T, raw string, rows *sql.Rows, msg string, args .
 
 	var got []row.Row
 
-	cols, err := rows.Columns()
-	require.NoError(t, err, errMsg...)
+	// the rows of the last result set are compared
+	for {
+		got = got[:0]
 
-	for rows.Next() {
-		vals := make([]any, len(cols))
-		for i := range vals {
-			vals[i] = new(types.ValueScanner)
-		}
-		err := rows.Scan(vals...)
+		cols, err := rows.Columns()
 		require.NoError(t, err, errMsg...)
 
-		var cb row.ColumnBuffer
+		for rows.Next() {
+			vals := make([]any, len(cols))
+			for i := range vals {
+				vals[i] = new(types.ValueScanner)
+			}
+			err := rows.Scan(vals...)
+			require.NoError(t, err, errMsg...)
+
+			var cb row.ColumnBuffer
 
-		for i := range vals {
-			cb.Add(cols[i], vals[i].(*types.ValueScanner).V)
+			for i := range vals {
+				cb.Add(cols[i], vals[i].(*types.ValueScanner).V)
+			}
+
+			got = append(got, &cb)
 		}
 
-		got = append(got, &cb)
+		if !rows.NextResultSet() {
+			break
+		}
 	}
 
 	if err := rows.Err(); err != nil {
@@ -0,0 +1,132 @@
+package chai
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/query"
+	"github.com/chaisql/chai/internal/types"
+)
+
+// defaultStatementCacheSize is the default value of Options.StatementCacheSize.
+const defaultStatementCacheSize = 1000
+
+// statementCache keeps the most recently prepared queries of a database,
+// to avoid parsing and preparing the same queries again and again.
+// Prepared queries depend on the schema, so the cache only holds the queries
+// prepared with the current catalog of the database, and is cleared
+// every time a change of the schema is committed.
+type statementCache struct {
+	mu      sync.Mutex
+	size    int
+	catalog *database.Catalog
+	entries map[statementCacheKey]*list.Element
+	// least recently used entries at the back
+	lru list.List
+	// number of lookups that found, or not, a query, reported by DB.Metrics.
+	hits, misses int64
+}
+
+// queries are prepared with the time zone and the coercion mode of the connection.
+type statementCacheKey struct {
+	q        string
+	timeZone *time.Location
+	coercion types.Coercion
+}
+
+type statementCacheEntry struct {
+	key statementCacheKey
+	pq  query.Query
+}
+
+// newStatementCache returns a cache of the given size, or nil if size is negative.
+func newStatementCache(size int) *statementCache {
+	if size < 0 {
+		return nil
+	}
+	if size == 0 {
+		size = defaultStatementCacheSize
+	}
+
+	return &statementCache{
+		size:    size,
+		entries: make(map[statementCacheKey]*list.Element),
+	}
+}
+
+// get returns the query prepared for the key with the given catalog.
+func (c *statementCache) get(key statementCacheKey, catalog *database.Catalog) (query.Query, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.catalog != catalog {
+		c.reset(catalog)
+		c.misses++
+		return query.Query{}, false
+	}
+
+	e, ok := c.entries[key]
+	if !ok {
+		c.misses++
+		return query.Query{}, false
+	}
+
+	c.hits++
+	c.lru.MoveToFront(e)
+	return e.Value.(*statementCacheEntry).pq, true
+}
+
+// put adds a query prepared with the given catalog.
+// It is ignored if the schema has changed since.
+func (c *statementCache) put(key statementCacheKey, pq query.Query, catalog *database.Catalog) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.catalog != catalog {
+		return
+	}
+
+	if e, ok := c.entries[key]; ok {
+		e.Value.(*statementCacheEntry).pq = pq
+		c.lru.MoveToFront(e)
+		return
+	}
+
+	c.entries[key] = c.lru.PushFront(&statementCacheEntry{key: key, pq: pq})
+
+	if c.lru.Len() > c.size {
+		e := c.lru.Back()
+		c.lru.Remove(e)
+		delete(c.entries, e.Value.(*statementCacheEntry).key)
+	}
+}
+
+// reset removes all the entries and sets the catalog of the next ones.
+func (c *statementCache) reset(catalog *database.Catalog) {
+	c.catalog = catalog
+	clear(c.entries)
+	c.lru.Init()
+}
+
+// clear removes all the entries, for changes of the schema which don't
+// replace the catalog, like the registration of virtual tables.
+func (c *statementCache) clear() {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.reset(nil)
+}
+
+// stats returns the number of hits and misses of the cache.
+func (c *statementCache) stats() (hits, misses int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.hits, c.misses
+}
@@ -0,0 +1,63 @@
+package chai
+
+import (
+	"reflect"
+
+	"github.com/chaisql/chai/internal/row"
+)
+
+// QueryAs runs the query and returns its rows as values of type T.
+// If T is a struct, each row is scanned like with Row.StructScan, and the fields
+// of T are resolved once for all the rows. Otherwise, for example if T is an integer,
+// a string or a time.Time, each value receives the first column of the row.
+func QueryAs[T any](db *DB, q string, args ...any) ([]T, error) {
+	scan, err := row.ScanFunc(reflect.TypeFor[*T]())
+	if err != nil {
+		return nil, err
+	}
+
+	var values []T
+	err = db.withConn(db.ctx, func(c *Connection) error {
+		res, err := c.QueryContext(db.ctx, q, args...)
+		if err != nil {
+			return err
+		}
+		defer res.Close()
+
+		return res.Iterate(func(r *Row) error {
+			var v T
+			err := scan(r.Row, &v)
+			if err != nil {
+				return err
+			}
+
+			values = append(values, v)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+// QueryOneAs runs the query and returns its first row as a value of type T,
+// like QueryAs. If the query returns no rows, it returns an error
+// for which IsNotFoundError returns true.
+func QueryOneAs[T any](db *DB, q string, args ...any) (T, error) {
+	var v T
+
+	scan, err := row.ScanFunc(reflect.TypeFor[*T]())
+	if err != nil {
+		return v, err
+	}
+
+	r, err := db.QueryRowContext(db.ctx, q, args...)
+	if err != nil {
+		return v, err
+	}
+
+	err = scan(r.Row, &v)
+	return v, err
+}
@@ -0,0 +1,101 @@
+package chai
+
+import (
+	"context"
+
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/query/statement"
+	"github.com/chaisql/chai/internal/row"
+	"github.com/chaisql/chai/internal/sql/parser"
+	"github.com/cockroachdb/errors"
+)
+
+// VirtualTable is a read-only table whose rows are generated by Go code
+// every time the table is scanned, for example to expose in-process data to SQL.
+// It can be read like any table, and its rows copied to other tables
+// with INSERT ... SELECT. See DB.RegisterVirtualTable.
+type VirtualTable struct {
+	// Schema of the table, as a CREATE TABLE statement, for example
+	// "CREATE TABLE config (name TEXT PRIMARY KEY, val TEXT)".
+	// Each row is converted to the types of the columns and validated
+	// against the constraints of the schema.
+	Schema string
+
+	// Rows calls fn for every row of the table, with the values of the columns
+	// in the order of the schema. The values are converted like the arguments of queries.
+	// The filter describes the rows requested by the query: rows not matching it
+	// can be skipped, but don't need to be, since they are filtered anyway.
+	// The context is the one of the query.
+	Rows func(ctx context.Context, f *VirtualFilter, fn func(values ...any) error) error
+}
+
+// VirtualFilter describes the rows of a virtual table requested by a query.
+type VirtualFilter struct {
+	// Key holds the values of the first columns of the primary key, in order,
+	// that the requested rows have, if the query selects rows by primary key,
+	// for example with "WHERE name = 'a'". It is empty otherwise.
+	// The values are those of the query, converted to the types of the columns.
+	Key []any
+}
+
+// RegisterVirtualTable registers a virtual table, whose name is the one of its schema.
+// The name must not be used by another table. Virtual tables are not stored in the
+// database: they must be registered every time the database is opened.
+func (db *DB) RegisterVirtualTable(t VirtualTable) error {
+	if t.Rows == nil {
+		return errors.New("virtual tables require a Rows function")
+	}
+
+	q, err := parser.ParseQuery(t.Schema)
+	if err != nil {
+		return err
+	}
+	if len(q.Statements) != 1 {
+		return errors.New("the schema of a virtual table must be a single CREATE TABLE statement")
+	}
+	stmt, ok := q.Statements[0].(*statement.CreateTableStmt)
+	if !ok {
+		return errors.New("the schema of a virtual table must be a CREATE TABLE statement")
+	}
+
+	info := stmt.Info
+	columns := info.ColumnConstraints.Ordered
+
+	defer db.stmtCache.clear()
+
+	return db.DB.RegisterVirtualTable(&info, func(ctx context.Context, tx *database.Transaction, rng *database.Range, fn func(r row.Row) error) error {
+		var f VirtualFilter
+		if rng != nil && rng.Exact {
+			f.Key = make([]any, len(rng.Min))
+			for i, v := range rng.Min {
+				if err := row.ScanValue(v, &f.Key[i]); err != nil {
+					return err
+				}
+			}
+		}
+
+		return t.Rows(ctx, &f, func(values ...any) error {
+			if len(values) != len(columns) {
+				return errors.Errorf("virtual table %s has %d columns, got %d values", info.TableName, len(columns), len(values))
+			}
+
+			cb := row.NewColumnBuffer()
+			for i, x := range values {
+				v, err := row.NewValue(x)
+				if err != nil {
+					return err
+				}
+				cb.Add(columns[i].Column, v)
+			}
+
+			return fn(cb)
+		})
+	})
+}
+
+// UnregisterVirtualTable removes a virtual table registered with RegisterVirtualTable.
+func (db *DB) UnregisterVirtualTable(name string) error {
+	defer db.stmtCache.clear()
+
+	return db.DB.UnregisterVirtualTable(name)
+}